@@ -1,76 +1,63 @@
+// Package client defines the grad API gractl commands are written against. The commands use
+// a *gradclient.Client talking to a server, or with --mock-data a Fixture serving runners and
+// canned command output from a JSON file, for demos, docs and golden tests.
 package client
 
 import (
-	"fmt"
-	"os"
+	"context"
 	"time"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
 	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
 )
 
-// Client wraps the gRPC client connection
-type Client struct {
-	conn           *grpc.ClientConn
-	runnerService  gradv1.RunnerServiceClient
-	executeService gradv1.ExecuteServiceClient
+// RunnerClient manages runners through the RunnerService
+type RunnerClient interface {
+	CreateRunner(ctx context.Context, opts ...gradclient.CreateOption) (*gradv1.Runner, error)
+	CreateRunnerWithWarnings(ctx context.Context, opts ...gradclient.CreateOption) (*gradv1.Runner, []string, error)
+	GetRunner(ctx context.Context, runnerID string) (*gradv1.Runner, error)
+	ListRunners(ctx context.Context, req *gradv1.ListRunnersRequest) ([]*gradv1.Runner, int32, error)
+	ListAllRunners(ctx context.Context, req *gradv1.ListRunnersRequest) ([]*gradv1.Runner, error)
+	GetRunnerStats(ctx context.Context, status gradv1.RunnerStatus) (*gradv1.GetRunnerStatsResponse, error)
+	GetRunnerMetrics(ctx context.Context, runnerID string) (*gradv1.RunnerUsage, error)
+	ListRunnerMetrics(ctx context.Context) ([]*gradv1.RunnerUsage, error)
+	GetRunnerDiagnostics(ctx context.Context, runnerID string, tailLines int32) (*gradv1.GetRunnerDiagnosticsResponse, error)
+	AddSSHKey(ctx context.Context, runnerID, publicKey string) (*gradv1.SSHKey, bool, error)
+	ListSSHKeys(ctx context.Context, runnerID string) ([]*gradv1.SSHKey, error)
+	RemoveSSHKey(ctx context.Context, runnerID, fingerprint string) (bool, error)
+	SnapshotWorkspace(ctx context.Context, req *gradv1.SnapshotWorkspaceRequest, progress func(*gradv1.SnapshotWorkspaceResponse)) (*gradv1.SnapshotWorkspaceResponse, error)
+	UpdateRunnerDescription(ctx context.Context, runnerID, description string) (*gradv1.Runner, error)
+	ReserveRunner(ctx context.Context, runnerID string, until time.Time) (*gradv1.Runner, error)
+	SetRunnerProtected(ctx context.Context, runnerID string, protected bool) (*gradv1.Runner, error)
+	CloneRunner(ctx context.Context, sourceRunnerID, name string) (*gradv1.Runner, error)
+	DeleteRunner(ctx context.Context, runnerID string, opts ...gradclient.DeleteOption) (*gradv1.DeleteRunnerResponse, error)
+	DeleteRunnerGroup(ctx context.Context, group string) (*gradv1.DeleteRunnerGroupResponse, error)
 }
 
-// Config holds client configuration
-type Config struct {
-	ServerAddress string
-	Timeout       time.Duration
+// ExecuteClient runs commands in runners, through the RunnerService (Exec) or the
+// ExecuteService (Execute)
+type ExecuteClient interface {
+	Exec(ctx context.Context, req *gradv1.ExecuteCommandRequest, handle gradclient.StreamHandler) (*gradv1.ExecuteCommandStreamResponse, error)
+	Execute(ctx context.Context, req *gradv1.ExecuteCommandRequest, handle gradclient.StreamHandler) (*gradv1.ExecuteCommandStreamResponse, error)
 }
 
-// DefaultConfig returns default client configuration
-func DefaultConfig() *Config {
-	serverAddr := os.Getenv("GRAD_SERVER")
-	if serverAddr == "" {
-		serverAddr = "localhost:9090"
-	}
-
-	return &Config{
-		ServerAddress: serverAddr,
-		Timeout:       30 * time.Second,
-	}
+// AdminClient reads and changes server-wide settings
+type AdminClient interface {
+	SetRunnerImage(ctx context.Context, adminToken string, req *gradv1.SetRunnerImageRequest) (*gradv1.SetRunnerImageResponse, error)
+	GetServerInfo(ctx context.Context) (*gradv1.GetServerInfoResponse, error)
+	TailServerLogs(ctx context.Context, adminToken string, req *gradv1.TailServerLogsRequest, handle func(*gradv1.ServerLogRecord) error) error
+	ReloadConfig(ctx context.Context, adminToken string) (*gradv1.ReloadConfigResponse, error)
 }
 
-// NewClient creates a new gRPC client
-func NewClient(cfg *Config) (*Client, error) {
-	if cfg == nil {
-		cfg = DefaultConfig()
-	}
-
-	conn, err := grpc.NewClient(cfg.ServerAddress,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create connection to server %s: %w", cfg.ServerAddress, err)
-	}
-
-	return &Client{
-		conn:           conn,
-		runnerService:  gradv1.NewRunnerServiceClient(conn),
-		executeService: gradv1.NewExecuteServiceClient(conn),
-	}, nil
+// Client is everything gractl commands need from grad
+type Client interface {
+	RunnerClient
+	ExecuteClient
+	AdminClient
+	Close() error
 }
 
-// Close closes the client connection
-func (c *Client) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
-	}
-	return nil
-}
-
-// RunnerService returns the runner service client
-func (c *Client) RunnerService() gradv1.RunnerServiceClient {
-	return c.runnerService
-}
-
-// ExecuteService returns the execute service client
-func (c *Client) ExecuteService() gradv1.ExecuteServiceClient {
-	return c.executeService
-}
\ No newline at end of file
+var (
+	_ Client = (*gradclient.Client)(nil)
+	_ Client = (*Fixture)(nil)
+)
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+// Fixture is a Client serving the runners and canned command output of a JSON file instead of
+// a server. It is a *gradclient.Client backed by a gradtest.Fake loaded from the file, so mock
+// data behaves like the fake tests run against. Commands that change runners change the fake,
+// so they are visible for the rest of the process but the file is never written.
+//
+// The file holds runners, usage and server info in the protobuf JSON gractl prints with -o json,
+// so the output of `gractl runners list -o json` is a fixture too:
+//
+//	{
+//	  "runners": [{"id": "runner-1", "name": "analytics", "status": "RUNNER_STATUS_RUNNING"}],
+//	  "labels": {"runner-1": {"team": "data"}},
+//	  "usage": [{"runner_id": "runner-1", "cpu_millicores": "250", "memory_bytes": "536870912"}],
+//	  "exec": [{"command": "echo hello", "stdout": "hello\n"}],
+//	  "server_info": {"build_info": {"version": "v1.2.0"}},
+//	  "server_logs": [{"level": "WARN", "message": "Runner image is not pinned"}]
+//	}
+type Fixture struct {
+	*gradclient.Client
+	fake *gradtest.Fake
+}
+
+// CannedExec is the output of a command run in a fixture's runners
+type CannedExec struct {
+	// RunnerID limits the output to one runner; empty matches every runner
+	RunnerID string `json:"runner_id,omitempty"`
+	// Command is matched exactly against the command line, an argv with each word quoted
+	// for the shell
+	Command  string `json:"command"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode int32  `json:"exit_code,omitempty"`
+}
+
+// fixtureFile is the JSON layout of a fixture. Unknown top-level fields, such as the total of
+// a runner list, are ignored.
+type fixtureFile struct {
+	Runners    []json.RawMessage            `json:"runners"`
+	Labels     map[string]map[string]string `json:"labels"`
+	Usage      []json.RawMessage            `json:"usage"`
+	Exec       []CannedExec                 `json:"exec"`
+	ServerInfo json.RawMessage              `json:"server_info"`
+	ServerLogs []json.RawMessage            `json:"server_logs"`
+}
+
+// LoadFixture reads a fixture from a JSON file
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock data: %w", err)
+	}
+	fixture, err := ParseFixture(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mock data in %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// ParseFixture parses the JSON of a fixture
+func ParseFixture(data []byte) (*Fixture, error) {
+	var file fixtureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	fake := gradtest.NewFake()
+	seen := make(map[string]bool)
+	for i, raw := range file.Runners {
+		runner := &gradv1.Runner{}
+		if err := protojson.Unmarshal(raw, runner); err != nil {
+			return nil, fmt.Errorf("runners[%d]: %w", i, err)
+		}
+		if runner.Id == "" {
+			return nil, fmt.Errorf("runners[%d]: id is required", i)
+		}
+		if seen[runner.Id] {
+			return nil, fmt.Errorf("runners[%d]: duplicate id %s", i, runner.Id)
+		}
+		seen[runner.Id] = true
+		fake.AddRunners(runner)
+	}
+	for runnerID, labels := range file.Labels {
+		fake.SetLabels(runnerID, labels)
+	}
+	var usages []*gradv1.RunnerUsage
+	for i, raw := range file.Usage {
+		usage := &gradv1.RunnerUsage{}
+		if err := protojson.Unmarshal(raw, usage); err != nil {
+			return nil, fmt.Errorf("usage[%d]: %w", i, err)
+		}
+		usages = append(usages, usage)
+	}
+	fake.SetUsage(usages...)
+	for _, exec := range file.Exec {
+		fake.SetRunnerOutput(exec.RunnerID, exec.Command, gradtest.Output{Stdout: exec.Stdout, Stderr: exec.Stderr, ExitCode: exec.ExitCode})
+	}
+	if len(file.ServerInfo) > 0 {
+		info := &gradv1.GetServerInfoResponse{}
+		if err := protojson.Unmarshal(file.ServerInfo, info); err != nil {
+			return nil, fmt.Errorf("server_info: %w", err)
+		}
+		fake.SetServerInfo(info)
+	}
+	var records []*gradv1.ServerLogRecord
+	for i, raw := range file.ServerLogs {
+		record := &gradv1.ServerLogRecord{}
+		if err := protojson.Unmarshal(raw, record); err != nil {
+			return nil, fmt.Errorf("server_logs[%d]: %w", i, err)
+		}
+		records = append(records, record)
+	}
+	fake.SetServerLogs(records...)
+	return &Fixture{Client: gradclient.NewFromServices(fake, fake, fake), fake: fake}, nil
+}
+
+// TailServerLogs passes the fixture's log records of at least req.MinLevel to handle. A
+// fixture logs nothing new, so the stream ends after them even with req.Follow. The admin
+// token isn't checked.
+func (f *Fixture) TailServerLogs(ctx context.Context, adminToken string, req *gradv1.TailServerLogsRequest, handle func(*gradv1.ServerLogRecord) error) error {
+	req = proto.Clone(req).(*gradv1.TailServerLogsRequest)
+	req.Follow = false
+	return f.Client.TailServerLogs(ctx, adminToken, req, handle)
+}
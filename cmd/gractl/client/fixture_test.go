@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+const testFixture = `{
+  "runners": [
+    {"id": "runner-1", "name": "analytics", "status": "RUNNER_STATUS_RUNNING", "group": "nightly", "description": "Nightly import"},
+    {"id": "runner-2", "name": "build", "status": "RUNNER_STATUS_RUNNING", "image_digest": "sha256:3f1c0a9e"},
+    {"id": "runner-7", "status": "RUNNER_STATUS_STOPPED", "group": "nightly"}
+  ],
+  "labels": {"runner-2": {"team": "ml"}},
+  "usage": [
+    {"runner_id": "runner-1", "cpu_millicores": "100"},
+    {"runner_id": "runner-2", "cpu_millicores": "900"}
+  ],
+  "exec": [
+    {"command": "hostname", "stdout": "any\n"},
+    {"runner_id": "runner-2", "command": "hostname", "stdout": "build\n", "stderr": "slow\n", "exit_code": 2}
+  ],
+  "total": 3
+}`
+
+// testNow is the clock of fixtures created by newTestFixture
+var testNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func newTestFixture(t *testing.T) *Fixture {
+	t.Helper()
+	f, err := ParseFixture([]byte(testFixture))
+	if err != nil {
+		t.Fatalf("ParseFixture failed: %v", err)
+	}
+	f.fake.SetClock(func() time.Time { return testNow })
+	return f
+}
+
+// runnerIDs returns the IDs of runners (pure function)
+func runnerIDs(runners []*gradv1.Runner) string {
+	var ids []string
+	for _, runner := range runners {
+		ids = append(ids, runner.Id)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestParseFixtureErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		expectMessage string
+	}{
+		{"Not JSON", `runners: []`, "invalid character"},
+		{"Missing ID", `{"runners": [{"name": "build"}]}`, "runners[0]: id is required"},
+		{"Duplicate ID", `{"runners": [{"id": "runner-1"}, {"id": "runner-1"}]}`, "runners[1]: duplicate id runner-1"},
+		{"Unknown runner field", `{"runners": [{"id": "runner-1", "colour": "red"}]}`, "runners[0]"},
+		{"Bad usage", `{"usage": [{"cpu_millicores": "lots"}]}`, "usage[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFixture([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.expectMessage) {
+				t.Errorf("Expected an error containing %q, got %v", tt.expectMessage, err)
+			}
+		})
+	}
+}
+
+func TestFixtureListRunners(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		req      *gradv1.ListRunnersRequest
+		expected string
+		total    int32
+	}{
+		{"All", &gradv1.ListRunnersRequest{}, "runner-1,runner-2,runner-7", 3},
+		{"Status", &gradv1.ListRunnersRequest{Status: gradv1.RunnerStatus_RUNNER_STATUS_RUNNING}, "runner-1,runner-2", 2},
+		{"Group", &gradv1.ListRunnersRequest{Group: "nightly"}, "runner-1,runner-7", 2},
+		{"Filter matches description", &gradv1.ListRunnersRequest{Filter: "IMPORT"}, "runner-1", 1},
+		{"Image digest prefix", &gradv1.ListRunnersRequest{ImageDigest: "sha256:3f1c"}, "runner-2", 1},
+		{"Labels", &gradv1.ListRunnersRequest{Labels: map[string]string{"team": "ml"}}, "runner-2", 1},
+		{"Page", &gradv1.ListRunnersRequest{Limit: 1, Offset: 1}, "runner-2", 3},
+		{"Past the end", &gradv1.ListRunnersRequest{Offset: 5}, "", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runners, total, err := f.ListRunners(ctx, tt.req)
+			if err != nil {
+				t.Fatalf("ListRunners failed: %v", err)
+			}
+			if got := runnerIDs(runners); got != tt.expected || total != tt.total {
+				t.Errorf("Expected %q of %d, got %q of %d", tt.expected, tt.total, got, total)
+			}
+		})
+	}
+}
+
+func TestFixtureChangesRunners(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+
+	created, err := f.CreateRunner(ctx, gradclient.WithName("scratch"), gradclient.WithGroup("ci"))
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if created.Id != "runner-8" || created.Status != gradv1.RunnerStatus_RUNNER_STATUS_RUNNING || created.CreatedAt != testNow.Unix() {
+		t.Errorf("Expected running runner-8 created now, got %v", created)
+	}
+
+	// Changing a returned runner doesn't change the fixture
+	created.Name = "changed"
+	if got, _ := f.GetRunner(ctx, "runner-8"); got.Name != "scratch" {
+		t.Errorf("Expected the fixture's copy to stay scratch, got %q", got.Name)
+	}
+
+	if _, err := f.UpdateRunnerDescription(ctx, "runner-8", "Scratch space"); err != nil {
+		t.Fatalf("UpdateRunnerDescription failed: %v", err)
+	}
+	until := testNow.Add(2 * time.Hour)
+	if updated, err := f.ReserveRunner(ctx, "runner-8", until); err != nil || updated.ReservedUntil != until.Unix() || updated.Description != "Scratch space" {
+		t.Errorf("Expected the description and reservation to be kept, got %v, %v", updated, err)
+	}
+
+	clone, err := f.CloneRunner(ctx, "runner-1", "analytics-2")
+	if err != nil || clone.Id != "runner-9" || clone.Group != "nightly" {
+		t.Errorf("Expected runner-9 in group nightly, got %v, %v", clone, err)
+	}
+
+	group, err := f.DeleteRunnerGroup(ctx, "nightly")
+	if err != nil || strings.Join(group.DeletedRunnerIds, ",") != "runner-1,runner-7,runner-9" {
+		t.Errorf("Expected the nightly runners to be deleted, got %v, %v", group, err)
+	}
+	resp, err := f.DeleteRunner(ctx, "runner-8", gradclient.WithWait(time.Minute))
+	if err != nil || !resp.Completed {
+		t.Errorf("Expected a completed deletion, got %v, %v", resp, err)
+	}
+	if _, err := f.DeleteRunner(ctx, "runner-8"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound deleting twice, got %v", err)
+	}
+
+	runners, _ := f.ListAllRunners(ctx, &gradv1.ListRunnersRequest{})
+	if got := runnerIDs(runners); got != "runner-2" {
+		t.Errorf("Expected only runner-2 to be left, got %q", got)
+	}
+}
+
+func TestFixtureExec(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		runnerID     string
+		command      string
+		expectOutput string
+		expectCode   int32
+		expectError  codes.Code
+	}{
+		{"Any runner", "runner-1", "hostname", "stdout:any\n", 0, codes.OK},
+		{"Runner specific output wins", "runner-2", "hostname", "stdout:build\nstderr:slow\n", 2, codes.OK},
+		{"No canned output", "runner-1", "uptime", "stderr:no canned output for \"uptime\"\n", gradtest.MissingCommandExitCode, codes.OK},
+		{"Stopped runner", "runner-7", "hostname", "", 0, codes.FailedPrecondition},
+		{"Missing runner", "runner-3", "hostname", "", 0, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output strings.Builder
+			exit, err := f.Exec(ctx, &gradv1.ExecuteCommandRequest{RunnerId: tt.runnerID, Command: tt.command}, func(resp *gradv1.ExecuteCommandStreamResponse) error {
+				switch resp.Type {
+				case gradv1.StreamType_STREAM_TYPE_STDOUT:
+					output.WriteString("stdout:" + string(resp.Data))
+				case gradv1.StreamType_STREAM_TYPE_STDERR:
+					output.WriteString("stderr:" + string(resp.Data))
+				}
+				return nil
+			})
+			if status.Code(err) != tt.expectError {
+				t.Fatalf("Expected %s, got %v", tt.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+			if output.String() != tt.expectOutput || exit.ExitCode != tt.expectCode {
+				t.Errorf("Expected %q with exit code %d, got %q with %d", tt.expectOutput, tt.expectCode, output.String(), exit.ExitCode)
+			}
+		})
+	}
+}
+
+func TestFixtureSSHKeys(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+	publicKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKSCwjmdMiYdEr3GXYYmw4qGDMpdnmcniJzE2vip01OM test@ed25519"
+
+	key, added, err := f.AddSSHKey(ctx, "runner-1", publicKey)
+	if err != nil || !added || key.Comment != "test@ed25519" {
+		t.Fatalf("Expected the key to be added, got %v, %v, %v", key, added, err)
+	}
+	if _, added, _ := f.AddSSHKey(ctx, "runner-1", publicKey); added {
+		t.Error("Expected adding the key again to do nothing")
+	}
+	if _, _, err := f.AddSSHKey(ctx, "runner-1", "not a key"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a bad key, got %v", err)
+	}
+	if keys, _ := f.ListSSHKeys(ctx, "runner-1"); len(keys) != 1 || keys[0].Fingerprint != key.Fingerprint {
+		t.Errorf("Expected the added key to be listed, got %v", keys)
+	}
+	if removed, _ := f.RemoveSSHKey(ctx, "runner-1", key.Fingerprint); !removed {
+		t.Error("Expected the key to be removed")
+	}
+	if keys, _ := f.ListSSHKeys(ctx, "runner-1"); len(keys) != 0 {
+		t.Errorf("Expected no keys after removing it, got %v", keys)
+	}
+}
+
+func TestFixtureStatsAndMetrics(t *testing.T) {
+	f := newTestFixture(t)
+	ctx := context.Background()
+
+	stats, err := f.GetRunnerStats(ctx, gradv1.RunnerStatus_RUNNER_STATUS_UNSPECIFIED)
+	if err != nil || stats.Total != 3 || stats.ByStatus["running"] != 2 || stats.ByStatus["stopped"] != 1 {
+		t.Errorf("Expected counts by status name, got %v, %v", stats, err)
+	}
+
+	usages, err := f.ListRunnerMetrics(ctx)
+	if err != nil || len(usages) != 2 || usages[0].RunnerId != "runner-2" {
+		t.Errorf("Expected runner-2 first as the busiest, got %v, %v", usages, err)
+	}
+
+	empty, _ := ParseFixture([]byte(`{"runners": [{"id": "runner-1"}]}`))
+	if _, err := empty.GetRunnerMetrics(ctx, "runner-1"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented without usage, got %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// AdminCmd represents the admin command
+var AdminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Change grad settings at runtime",
+	Long: `Change grad settings without restarting it. Admin commands authenticate with the
+token in the ` + gradclient.AdminTokenEnv + ` environment variable, which must match the server's.`,
+}
+
+// setImageCmd represents the admin set-image command
+var setImageCmd = &cobra.Command{
+	Use:   "set-image [IMAGE]",
+	Short: "Change the image of new runners",
+	Long: `Change the runner image, and with --s3fs-image the s3fs sidecar image, of runners
+created from now on. Existing runners keep their image. --revert restores the images
+in use before the last change.
+
+Examples:
+  gractl admin set-image ghcr.io/strrl/grad-runner:v1.18.0
+  gractl admin set-image --s3fs-image ghcr.io/strrl/grad-runner-s3fs:v1.18.0
+  gractl admin set-image --revert`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		revert, _ := cmd.Flags().GetBool("revert")
+		s3fsImage, _ := cmd.Flags().GetString("s3fs-image")
+		switch {
+		case revert && (len(args) > 0 || s3fsImage != ""):
+			return fmt.Errorf("--revert can't be combined with an image")
+		case !revert && len(args) == 0 && s3fsImage == "":
+			return fmt.Errorf("pass an image, --s3fs-image or --revert")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		token := os.Getenv(gradclient.AdminTokenEnv)
+		if token == "" {
+			fmt.Fprintf(os.Stderr, "Set %s to the server's admin token\n", gradclient.AdminTokenEnv)
+			os.Exit(1)
+		}
+
+		grpcClient, err := newGRPCClient(cmd, globalConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
+			os.Exit(ExitCodeTempFail)
+		}
+		defer grpcClient.Close()
+
+		req := &gradv1.SetRunnerImageRequest{}
+		req.Revert, _ = cmd.Flags().GetBool("revert")
+		req.S3FsImage, _ = cmd.Flags().GetString("s3fs-image")
+		if len(args) > 0 {
+			req.Image = args[0]
+		}
+
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		resp, err := grpcClient.SetRunnerImage(ctx, token, req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to set runner image: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Print(formatImageChange(resp))
+	},
+}
+
+// formatImageChange lists the images of new runners and the ones they replaced (pure function)
+func formatImageChange(resp *gradv1.SetRunnerImageResponse) string {
+	var b strings.Builder
+	line := func(label, current, previous string) {
+		if current == previous {
+			fmt.Fprintf(&b, "%-14s%s (unchanged)\n", label, current)
+			return
+		}
+		fmt.Fprintf(&b, "%-14s%s (was %s)\n", label, current, previous)
+	}
+	line("Runner image:", resp.Image, resp.PreviousImage)
+	line("S3FS image:", resp.S3FsImage, resp.PreviousS3FsImage)
+	return b.String()
+}
+
+// reloadConfigCmd represents the admin reload command
+var reloadConfigCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reload grad's configuration",
+	Long: `Have grad reload its configuration file and environment as on SIGHUP, and list the
+settings that changed. Idle timeouts, allowlists, rate limits and env and command limits
+take effect right away; the others, such as the namespace, need grad to restart. An
+invalid configuration is reported and changes nothing.
+
+Examples:
+  gractl admin reload
+  gractl admin reload -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		token := os.Getenv(gradclient.AdminTokenEnv)
+		if token == "" {
+			fmt.Fprintf(os.Stderr, "Set %s to the server's admin token\n", gradclient.AdminTokenEnv)
+			os.Exit(1)
+		}
+
+		grpcClient, err := newGRPCClient(cmd, globalConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
+			os.Exit(ExitCodeTempFail)
+		}
+		defer grpcClient.Close()
+
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		resp, err := grpcClient.ReloadConfig(ctx, token)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to reload configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputFormat == OutputFormatJSON {
+			data, err := protoJSONOptions.Marshal(resp)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to print reload: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s\n", data)
+			return
+		}
+		fmt.Print(formatConfigReload(resp))
+	},
+}
+
+// formatConfigReload lists the settings a reload applied and those needing a restart (pure function)
+func formatConfigReload(resp *gradv1.ReloadConfigResponse) string {
+	if len(resp.AppliedKeys) == 0 && len(resp.IgnoredKeys) == 0 {
+		return "Configuration reloaded, no settings changed\n"
+	}
+	var b strings.Builder
+	if len(resp.AppliedKeys) > 0 {
+		fmt.Fprintf(&b, "Applied:         %s\n", strings.Join(resp.AppliedKeys, ", "))
+	}
+	if len(resp.IgnoredKeys) > 0 {
+		fmt.Fprintf(&b, "Needs a restart: %s\n", strings.Join(resp.IgnoredKeys, ", "))
+	}
+	return b.String()
+}
+
+// adminLogsCmd represents the admin logs command
+var adminLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show grad's own recent logs",
+	Long: `Show the most recent records grad logged, kept in its memory, with secrets already
+redacted. --follow keeps streaming new records until Ctrl+C. With -o json every record is
+printed as the JSON line grad logged.
+
+Examples:
+  gractl admin logs
+  gractl admin logs --follow --level warn
+  gractl admin logs -o json | jq 'select(.runner_id == "runner-1")'`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		token := os.Getenv(gradclient.AdminTokenEnv)
+		if token == "" {
+			fmt.Fprintf(os.Stderr, "Set %s to the server's admin token\n", gradclient.AdminTokenEnv)
+			os.Exit(1)
+		}
+
+		req := &gradv1.TailServerLogsRequest{}
+		req.Follow, _ = cmd.Flags().GetBool("follow")
+		req.MinLevel, _ = cmd.Flags().GetString("level")
+		if err := validateLogLevel(req.MinLevel); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --level: %v\n", err)
+			os.Exit(1)
+		}
+
+		grpcClient, err := newGRPCClient(cmd, globalConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
+			os.Exit(ExitCodeTempFail)
+		}
+		defer grpcClient.Close()
+
+		// Without --follow the stream ends by itself, so it is bounded like a unary call;
+		// following only stops on Ctrl+C, which ends it quietly
+		ctx := commandContext(cmd)
+		if !req.Follow {
+			var cancel context.CancelFunc
+			ctx, cancel = rpcContext(ctx)
+			defer cancel()
+		}
+		err = grpcClient.TailServerLogs(ctx, token, req, func(record *gradv1.ServerLogRecord) error {
+			return writeServerLogRecord(os.Stdout, record, outputFormat == OutputFormatJSON)
+		})
+		if err != nil && !errors.Is(interruptible(ctx, err), errInterrupted) {
+			fmt.Fprintf(os.Stderr, "Failed to tail server logs: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// logLevels are the values of admin logs --level
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// validateLogLevel checks a --level value, "" meaning every level (pure function)
+func validateLogLevel(level string) error {
+	if level != "" && !slices.Contains(logLevels, strings.ToLower(level)) {
+		return fmt.Errorf("unknown log level %q (must be one of %s)", level, strings.Join(logLevels, ", "))
+	}
+	return nil
+}
+
+// writeServerLogRecord writes a log record of grad: as the JSON line grad logged, or as its
+// time, level and message followed by its other attributes as key=value
+func writeServerLogRecord(w io.Writer, record *gradv1.ServerLogRecord, asJSON bool) error {
+	if asJSON {
+		_, err := fmt.Fprintln(w, record.Json)
+		return err
+	}
+	_, err := fmt.Fprintln(w, formatServerLogRecord(record))
+	return err
+}
+
+// formatServerLogRecord renders a log record on one line, in UTC, keeping its attributes in
+// the order they were logged. Nested attributes are shown as JSON. (pure function)
+func formatServerLogRecord(record *gradv1.ServerLogRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s %s",
+		time.UnixMilli(record.TimeUnixMs).UTC().Format("2006-01-02T15:04:05.000Z"),
+		record.Level,
+		record.Message)
+
+	dec := json.NewDecoder(strings.NewReader(record.Json))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return b.String()
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			break
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			break
+		}
+		key, _ := token.(string)
+		if key == "time" || key == "level" || key == "msg" {
+			continue
+		}
+		fmt.Fprintf(&b, " %s=%s", key, formatLogValue(value))
+	}
+	return b.String()
+}
+
+// formatLogValue renders an attribute value: strings bare unless they need quoting, anything
+// else as compact JSON (pure function)
+func formatLogValue(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, value); err != nil {
+		return string(value)
+	}
+	return compact.String()
+}
+
+func init() {
+	setImageCmd.Flags().String("s3fs-image", "", "New s3fs sidecar image (optional)")
+	setImageCmd.Flags().Bool("revert", false, "Restore the images in use before the last change")
+
+	// -f is --filter elsewhere, so following has no shorthand
+	adminLogsCmd.Flags().Bool("follow", false, "Keep streaming new records until Ctrl+C")
+	adminLogsCmd.Flags().String("level", "", "Lowest level to show: "+strings.Join(logLevels, ", ")+" (default all)")
+
+	AdminCmd.AddCommand(setImageCmd)
+	AdminCmd.AddCommand(adminLogsCmd)
+	AdminCmd.AddCommand(reloadConfigCmd)
+}
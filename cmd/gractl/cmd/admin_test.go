@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"testing"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+func TestFormatImageChange(t *testing.T) {
+	resp := &gradv1.SetRunnerImageResponse{
+		Image:             "ghcr.io/strrl/grad-runner:v2",
+		S3FsImage:         "ghcr.io/strrl/grad-runner-s3fs:latest",
+		PreviousImage:     "ghcr.io/strrl/grad-runner:v1",
+		PreviousS3FsImage: "ghcr.io/strrl/grad-runner-s3fs:latest",
+	}
+
+	expected := "Runner image: ghcr.io/strrl/grad-runner:v2 (was ghcr.io/strrl/grad-runner:v1)\n" +
+		"S3FS image:   ghcr.io/strrl/grad-runner-s3fs:latest (unchanged)\n"
+	if got := formatImageChange(resp); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestFormatServerLogRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   *gradv1.ServerLogRecord
+		expected string
+	}{
+		{
+			"Attributes in logged order",
+			&gradv1.ServerLogRecord{
+				TimeUnixMs: 1700000000123,
+				Level:      "WARN",
+				Message:    "Runner image is not pinned",
+				Json:       `{"time":"2023-11-14T22:13:20.123Z","level":"WARN","msg":"Runner image is not pinned","warning":"uses :latest","runner_id":"runner-1","count":2,"token":"[REDACTED]"}`,
+			},
+			`2023-11-14T22:13:20.123Z WARN  Runner image is not pinned warning="uses :latest" runner_id=runner-1 count=2 token=[REDACTED]`,
+		},
+		{
+			"Nested attributes",
+			&gradv1.ServerLogRecord{
+				TimeUnixMs: 1700000000000,
+				Level:      "INFO",
+				Message:    "Starting grad service",
+				Json:       `{"time":"2023-11-14T22:13:20Z","level":"INFO","msg":"Starting grad service","rate_limits":{"MutatingRate": 5},"empty":""}`,
+			},
+			`2023-11-14T22:13:20.000Z INFO  Starting grad service rate_limits={"MutatingRate":5} empty=""`,
+		},
+		{
+			"Not JSON",
+			&gradv1.ServerLogRecord{TimeUnixMs: 1700000000000, Level: "ERROR", Message: "boom", Json: "boom"},
+			"2023-11-14T22:13:20.000Z ERROR boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatServerLogRecord(tt.record); got != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateLogLevel(t *testing.T) {
+	for _, level := range []string{"", "debug", "info", "WARN", "error"} {
+		if err := validateLogLevel(level); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", level, err)
+		}
+	}
+	if err := validateLogLevel("loud"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+func TestFormatConfigReload(t *testing.T) {
+	tests := []struct {
+		resp     *gradv1.ReloadConfigResponse
+		expected string
+	}{
+		{&gradv1.ReloadConfigResponse{}, "Configuration reloaded, no settings changed\n"},
+		{
+			&gradv1.ReloadConfigResponse{AppliedKeys: []string{"CLEANUP_INTERVAL", "RUNNER_IDLE_TIMEOUT"}, IgnoredKeys: []string{"KUBERNETES_NAMESPACE"}},
+			"Applied:         CLEANUP_INTERVAL, RUNNER_IDLE_TIMEOUT\nNeeds a restart: KUBERNETES_NAMESPACE\n",
+		},
+		{&gradv1.ReloadConfigResponse{IgnoredKeys: []string{"SSH_PORT"}}, "Needs a restart: SSH_PORT\n"},
+	}
+	for _, tt := range tests {
+		if got := formatConfigReload(tt.resp); got != tt.expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, got)
+		}
+	}
+}
+
+func TestAdminReloadCommand(t *testing.T) {
+	t.Setenv("GRAD_ADMIN_TOKEN", "secret")
+	stdout, _ := runGractl(t, "admin", "reload")
+	if stdout != "Configuration reloaded, no settings changed\n" {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+}
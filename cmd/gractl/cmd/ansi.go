@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// maxStrippedLineBytes bounds how much of a line without a newline the filter holds back.
+// Longer lines are written out early, after which a carriage return can't erase them.
+const maxStrippedLineBytes = 64 * 1024
+
+// commandStdout and commandStderr receive the output of remote commands. They are the
+// process's own streams unless setupCommandOutput wraps them with an ansiStripper.
+var (
+	commandStdout io.Writer = os.Stdout
+	commandStderr io.Writer = os.Stderr
+)
+
+// ansiState tracks where the filter is within an escape sequence
+type ansiState int
+
+const (
+	ansiText ansiState = iota
+	// ansiEscape follows ESC
+	ansiEscape
+	// ansiEscapeIntermediate is within ESC followed by intermediate bytes, e.g. ESC ( B
+	ansiEscapeIntermediate
+	// ansiCSI is within ESC [ ... up to its final byte, e.g. colors and cursor movement
+	ansiCSI
+	// ansiString is within OSC, DCS, SOS, PM or APC, terminated by BEL or ESC \
+	ansiString
+	// ansiStringEscape follows ESC within a string
+	ansiStringEscape
+)
+
+// ansiStripper is a streaming filter that removes ANSI escape sequences and collapses lines
+// redrawn with carriage returns, such as progress bars, to their final state. Output is
+// written a line at a time; Flush writes whatever is left of an unterminated last line.
+type ansiStripper struct {
+	out   io.Writer
+	state ansiState
+	// line is the current line, not written yet because a carriage return may still erase it
+	line []byte
+	// carriageReturn is set after \r: the next text starts the line over, a \n ends it
+	carriageReturn bool
+}
+
+// newANSIStripper returns a filter writing the cleaned-up stream to out
+func newANSIStripper(out io.Writer) *ansiStripper {
+	return &ansiStripper{out: out}
+}
+
+// Write filters p; sequences and lines may be split across calls
+func (s *ansiStripper) Write(p []byte) (int, error) {
+	var ready []byte
+	for _, b := range p {
+		switch s.state {
+		case ansiText:
+			ready = s.text(b, ready)
+		case ansiEscape:
+			switch {
+			case b == '[':
+				s.state = ansiCSI
+			case b == ']' || b == 'P' || b == 'X' || b == '^' || b == '_':
+				s.state = ansiString
+			case b >= 0x20 && b <= 0x2f:
+				s.state = ansiEscapeIntermediate
+			default:
+				s.state = ansiText
+			}
+		case ansiEscapeIntermediate:
+			if b < 0x20 || b > 0x2f {
+				s.state = ansiText
+			}
+		case ansiCSI:
+			if b >= 0x40 && b <= 0x7e {
+				s.state = ansiText
+			}
+		case ansiString:
+			if b == 0x07 {
+				s.state = ansiText
+			} else if b == 0x1b {
+				s.state = ansiStringEscape
+			}
+		case ansiStringEscape:
+			if b == '\\' {
+				s.state = ansiText
+			} else {
+				s.state = ansiString
+			}
+		}
+	}
+
+	if len(ready) > 0 {
+		if _, err := s.out.Write(ready); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// text handles a byte outside escape sequences, appending completed output to ready
+func (s *ansiStripper) text(b byte, ready []byte) []byte {
+	switch b {
+	case 0x1b:
+		s.state = ansiEscape
+	case '\r':
+		s.carriageReturn = true
+	case '\n':
+		ready = append(append(ready, s.line...), '\n')
+		s.line = s.line[:0]
+		s.carriageReturn = false
+	default:
+		if s.carriageReturn {
+			s.line = s.line[:0]
+			s.carriageReturn = false
+		}
+		s.line = append(s.line, b)
+		if len(s.line) >= maxStrippedLineBytes {
+			ready = append(ready, s.line...)
+			s.line = s.line[:0]
+		}
+	}
+	return ready
+}
+
+// Flush writes the unterminated last line, if any
+func (s *ansiStripper) Flush() error {
+	if len(s.line) == 0 {
+		return nil
+	}
+	_, err := s.out.Write(s.line)
+	s.line = s.line[:0]
+	return err
+}
+
+// addStripANSIFlag adds --strip-ansi to a command that streams remote output
+func addStripANSIFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("strip-ansi", false, "Remove ANSI escape codes and keep only the final state of lines redrawn with \\r (default true when the output is not a terminal)")
+}
+
+// stripANSIEnabled decides whether to filter a stream: an explicit --strip-ansi wins,
+// otherwise only output that isn't going to a terminal is filtered (pure function)
+func stripANSIEnabled(flagSet, flagValue, isTerminal bool) bool {
+	if flagSet {
+		return flagValue
+	}
+	return !isTerminal
+}
+
+// setupCommandOutput applies --strip-ansi to commandStdout and commandStderr and returns a
+// function that flushes the filters; call it before exiting. Unfiltered streams are left
+// untouched, so their output stays byte-exact.
+func setupCommandOutput(cmd *cobra.Command) func() {
+	flagSet := cmd.Flags().Changed("strip-ansi")
+	flagValue, _ := cmd.Flags().GetBool("strip-ansi")
+
+	var strippers []*ansiStripper
+	wrap := func(f *os.File) io.Writer {
+		if !stripANSIEnabled(flagSet, flagValue, term.IsTerminal(int(f.Fd()))) {
+			return f
+		}
+		stripper := newANSIStripper(f)
+		strippers = append(strippers, stripper)
+		return stripper
+	}
+	commandStdout = wrap(os.Stdout)
+	commandStderr = wrap(os.Stderr)
+
+	return func() {
+		for _, stripper := range strippers {
+			stripper.Flush()
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// ansiFixtures are byte streams as tools write them to a terminal, with the text they
+// should leave behind once stripped
+var ansiFixtures = []struct {
+	name   string
+	input  string
+	expect string
+}{
+	{
+		name:   "Plain text",
+		input:  "hello\nworld\n",
+		expect: "hello\nworld\n",
+	},
+	{
+		name:   "Colors",
+		input:  "\x1b[1;32mPASS\x1b[0m ok\n\x1b[38;5;196mFAIL\x1b[m\n",
+		expect: "PASS ok\nFAIL\n",
+	},
+	{
+		name: "tqdm progress bar",
+		input: "  0%|          | 0/3 [00:00<?, ?it/s]" +
+			"\r 33%|███▎      | 1/3 [00:01<00:02,  1.00s/it]" +
+			"\r100%|██████████| 3/3 [00:03<00:00,  1.00s/it]\n" +
+			"done\n",
+		expect: "100%|██████████| 3/3 [00:03<00:00,  1.00s/it]\ndone\n",
+	},
+	{
+		name: "pip download with cursor control",
+		input: "Collecting numpy\n" +
+			"\x1b[?25l   \x1b[90m━━━━━━━━━━\x1b[0m \x1b[32m0.0/18.2 MB\x1b[0m" +
+			"\r\x1b[2K   \x1b[38;2;249;38;114m━━━━━\x1b[0m \x1b[32m9.1/18.2 MB\x1b[0m" +
+			"\r\x1b[2K   \x1b[38;2;114;156;31m━━━━━━━━━━\x1b[0m \x1b[32m18.2/18.2 MB\x1b[0m\n" +
+			"\x1b[?25hSuccessfully installed numpy\n",
+		expect: "Collecting numpy\n   ━━━━━━━━━━ 18.2/18.2 MB\nSuccessfully installed numpy\n",
+	},
+	{
+		name:   "Windows line endings",
+		input:  "one\r\ntwo\r\n",
+		expect: "one\ntwo\n",
+	},
+	{
+		name:   "Window title and hyperlink",
+		input:  "\x1b]0;build\x07\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\\n",
+		expect: "link\n",
+	},
+	{
+		name:   "Charset selection",
+		input:  "\x1b(Bbox\x1b(0\n",
+		expect: "box\n",
+	},
+	{
+		name:   "Unterminated last line",
+		input:  "step 1/2\rstep 2/2",
+		expect: "step 2/2",
+	},
+	{
+		name:   "Trailing carriage return",
+		input:  "waiting...\r",
+		expect: "waiting...",
+	},
+}
+
+func stripANSI(t *testing.T, chunks ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	stripper := newANSIStripper(&out)
+	for _, chunk := range chunks {
+		if n, err := stripper.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) returned %d, %v", chunk, n, err)
+		}
+	}
+	if err := stripper.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	return out.String()
+}
+
+func TestANSIStripper(t *testing.T) {
+	for _, tt := range ansiFixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripANSI(t, tt.input); got != tt.expect {
+				t.Errorf("Expected %q, got %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestANSIStripperSplitWrites(t *testing.T) {
+	// Stream chunks can end anywhere, including inside an escape sequence or a UTF-8 rune
+	for _, tt := range ansiFixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i <= len(tt.input); i++ {
+				if got := stripANSI(t, tt.input[:i], tt.input[i:]); got != tt.expect {
+					t.Fatalf("Split at %d: expected %q, got %q", i, tt.expect, got)
+				}
+			}
+
+			var bytewise []string
+			for i := 0; i < len(tt.input); i++ {
+				bytewise = append(bytewise, tt.input[i:i+1])
+			}
+			if got := stripANSI(t, bytewise...); got != tt.expect {
+				t.Errorf("Byte by byte: expected %q, got %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestANSIStripperWritesCompleteLines(t *testing.T) {
+	var out bytes.Buffer
+	stripper := newANSIStripper(&out)
+
+	stripper.Write([]byte("first\nsecond"))
+	if out.String() != "first\n" {
+		t.Errorf("Expected only the finished line to be written, got %q", out.String())
+	}
+
+	// A line without a newline is not held back indefinitely
+	long := strings.Repeat("x", maxStrippedLineBytes)
+	out.Reset()
+	stripper.Write([]byte(long))
+	if out.Len() != maxStrippedLineBytes {
+		t.Errorf("Expected a %d byte line to be written, got %d bytes", maxStrippedLineBytes, out.Len())
+	}
+}
+
+func TestStripANSIEnabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		flagSet    bool
+		flagValue  bool
+		isTerminal bool
+		expect     bool
+	}{
+		{"Terminal", false, false, true, false},
+		{"Pipe", false, false, false, true},
+		{"Forced on a terminal", true, true, true, true},
+		{"Disabled for a pipe", true, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripANSIEnabled(tt.flagSet, tt.flagValue, tt.isTerminal); got != tt.expect {
+				t.Errorf("Expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestCommandOutputPassthrough(t *testing.T) {
+	// With --strip-ansi=false command output goes straight to the process's streams, byte for byte
+	defer func() {
+		execCmd.Flags().Set("strip-ansi", "false")
+		execCmd.Flags().Lookup("strip-ansi").Changed = false
+		commandStdout, commandStderr = os.Stdout, os.Stderr
+	}()
+	if err := execCmd.Flags().Set("strip-ansi", "false"); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	setupCommandOutput(execCmd)
+
+	if commandStdout != io.Writer(os.Stdout) || commandStderr != io.Writer(os.Stderr) {
+		t.Errorf("Expected unfiltered output streams, got %T and %T", commandStdout, commandStderr)
+	}
+}
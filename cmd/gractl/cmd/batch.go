@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/strrl/gra/cmd/gractl/client"
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// stdinRunnerIDs is the RUNNER_ID argument reading the runner IDs from stdin
+const stdinRunnerIDs = "-"
+
+// readRunnerIDs reads newline-separated runner IDs, skipping blank lines and IDs already read
+func readRunnerIDs(r io.Reader) ([]string, error) {
+	var runnerIDs []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		runnerID := strings.TrimSpace(scanner.Text())
+		if runnerID == "" || seen[runnerID] {
+			continue
+		}
+		seen[runnerID] = true
+		runnerIDs = append(runnerIDs, runnerID)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read runner IDs from stdin: %w", err)
+	}
+	return runnerIDs, nil
+}
+
+// validateRunnerIDArgs rejects - mixed with runner IDs, since it's unclear which order they'd go in
+func validateRunnerIDArgs(args []string) error {
+	for _, arg := range args {
+		if arg == stdinRunnerIDs && len(args) > 1 {
+			return fmt.Errorf("cannot mix - with runner IDs: pass either - or the IDs")
+		}
+	}
+	return nil
+}
+
+// resolveRunnerIDs returns the runner IDs of args, reading them from stdin for -
+func resolveRunnerIDs(args []string, stdin io.Reader) ([]string, error) {
+	if err := validateRunnerIDArgs(args); err != nil {
+		return nil, err
+	}
+	if len(args) == 1 && args[0] == stdinRunnerIDs {
+		runnerIDs, err := readRunnerIDs(stdin)
+		if err != nil {
+			return nil, err
+		}
+		if len(runnerIDs) == 0 {
+			return nil, fmt.Errorf("no runner IDs on stdin")
+		}
+		return runnerIDs, nil
+	}
+	return args, nil
+}
+
+// isBatch reports whether args ask for the per-runner output of several runners: more than
+// one ID or -, even if stdin only holds one, so scripts get the same shape either way
+func isBatch(args []string) bool {
+	return len(args) > 1 || (len(args) == 1 && args[0] == stdinRunnerIDs)
+}
+
+// forEachRunner calls fn for every runner with at most parallel calls at a time and returns
+// once all of them did; fn gets the index of the runner to store its result at
+func forEachRunner(runnerIDs []string, parallel int, fn func(i int, runnerID string)) {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, runnerID := range runnerIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			fn(i, runnerID)
+		}()
+	}
+	wg.Wait()
+}
+
+// getRunnerResult is the outcome of getting one of several runners
+type getRunnerResult struct {
+	RunnerID string
+	Runner   *gradv1.Runner
+	Err      error
+}
+
+// getRunners gets every runner, results in the order of runnerIDs, each within the request timeout
+func getRunners(ctx context.Context, c client.RunnerClient, runnerIDs []string, parallel int) []getRunnerResult {
+	results := make([]getRunnerResult, len(runnerIDs))
+	forEachRunner(runnerIDs, parallel, func(i int, runnerID string) {
+		ctx, cancel := rpcContext(ctx)
+		defer cancel()
+		runner, err := c.GetRunner(ctx, runnerID)
+		results[i] = getRunnerResult{RunnerID: runnerID, Runner: runner, Err: err}
+	})
+	return results
+}
+
+// deleteRunnerResult is the outcome of deleting one of several runners
+type deleteRunnerResult struct {
+	RunnerID string
+	Response *gradv1.DeleteRunnerResponse
+	Err      error
+}
+
+// exitCode returns the exit code deleting just this runner would have: 1 if it failed or left
+// objects behind, ExitCodeTempFail if they are still terminating after --wait
+func (r deleteRunnerResult) exitCode() int {
+	switch {
+	case r.Err != nil || len(r.Response.FailedResources) > 0:
+		return 1
+	case len(r.Response.RemainingResources) > 0:
+		return ExitCodeTempFail
+	default:
+		return 0
+	}
+}
+
+// deleteRunners deletes every runner, results in the order of runnerIDs. timeout bounds each
+// deletion, 0 for no deadline.
+func deleteRunners(ctx context.Context, c client.RunnerClient, runnerIDs []string, parallel int, timeout time.Duration, opts ...gradclient.DeleteOption) []deleteRunnerResult {
+	results := make([]deleteRunnerResult, len(runnerIDs))
+	forEachRunner(runnerIDs, parallel, func(i int, runnerID string) {
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		resp, err := c.DeleteRunner(ctx, runnerID, opts...)
+		results[i] = deleteRunnerResult{RunnerID: runnerID, Response: resp, Err: err}
+	})
+	return results
+}
+
+// deleteTimeout returns how long deleting a runner may take: the request timeout, plus with
+// --wait the wait's own timeout, or no deadline if the wait has none and the server bounds it
+// (pure function)
+func deleteTimeout(wait bool, waitTimeout, requestTimeout time.Duration) time.Duration {
+	switch {
+	case !wait:
+		return requestTimeout
+	case waitTimeout <= 0:
+		return 0
+	default:
+		return waitTimeout + requestTimeout
+	}
+}
+
+// batchExitCode aggregates the exit codes of the runners: 0 if all succeeded,
+// ExitCodeTempFail if retrying may fix every failure and 1 otherwise (pure function)
+func batchExitCode(codes []int) int {
+	exitCode := 0
+	for _, code := range codes {
+		switch code {
+		case 0:
+		case ExitCodeTempFail:
+			exitCode = ExitCodeTempFail
+		default:
+			return 1
+		}
+	}
+	return exitCode
+}
+
+// batchEntry is the JSON of one runner in the output of several runners. Runner or Response
+// holds the protobuf JSON of the result, Error why there is none.
+type batchEntry struct {
+	RunnerID string          `json:"runner_id"`
+	Runner   json.RawMessage `json:"runner,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// writeBatchJSON writes entries as an indented JSON array
+func writeBatchJSON(out io.Writer, entries []batchEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", data)
+	return err
+}
+
+// writeGetRunnersJSON writes one entry per runner, with the runner or why it couldn't be got
+func writeGetRunnersJSON(out io.Writer, results []getRunnerResult) error {
+	entries := make([]batchEntry, len(results))
+	for i, result := range results {
+		entries[i] = batchEntry{RunnerID: result.RunnerID}
+		if result.Err != nil {
+			entries[i].Error = describeError(result.Err)
+			continue
+		}
+		data, err := protoJSONOptions.Marshal(result.Runner)
+		if err != nil {
+			return err
+		}
+		entries[i].Runner = data
+	}
+	return writeBatchJSON(out, entries)
+}
+
+// writeDeleteRunnersJSON writes one entry per runner, with the deletion's response or why it failed
+func writeDeleteRunnersJSON(out io.Writer, results []deleteRunnerResult) error {
+	entries := make([]batchEntry, len(results))
+	for i, result := range results {
+		entries[i] = batchEntry{RunnerID: result.RunnerID}
+		if result.Err != nil {
+			entries[i].Error = describeError(result.Err)
+			continue
+		}
+		data, err := protoJSONOptions.Marshal(result.Response)
+		if err != nil {
+			return err
+		}
+		entries[i].Response = data
+	}
+	return writeBatchJSON(out, entries)
+}
+
+// writeGetRunnersTable prints the runners found as a table, and to errOut the ones that weren't
+func writeGetRunnersTable(out, errOut io.Writer, results []getRunnerResult, wide bool) error {
+	var runners []*gradv1.Runner
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(errOut, "Failed to get runner %s: %s\n", result.RunnerID, describeError(result.Err))
+			continue
+		}
+		runners = append(runners, result.Runner)
+	}
+	if len(runners) == 0 {
+		return nil
+	}
+	return writeRunnerTable(out, runners, wide)
+}
+
+// writeDeleteRunnersResults prints one line per runner deleted, failed or still terminating,
+// then a summary
+func writeDeleteRunnersResults(out io.Writer, results []deleteRunnerResult) error {
+	deleted := 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			fmt.Fprintf(out, "  failed   %s: %s\n", result.RunnerID, describeError(result.Err))
+		case len(result.Response.FailedResources) > 0:
+			fmt.Fprintf(out, "  failed   %s: %s\n", result.RunnerID, strings.Join(result.Response.FailedResources, "; "))
+		case len(result.Response.RemainingResources) > 0:
+			fmt.Fprintf(out, "  pending  %s: %s\n", result.RunnerID, strings.Join(result.Response.RemainingResources, ", "))
+		default:
+			fmt.Fprintf(out, "  deleted  %s\n", result.RunnerID)
+			deleted++
+		}
+	}
+	_, err := fmt.Fprintf(out, "Deleted %d out of %d runners\n", deleted, len(results))
+	return err
+}
+
+// addParallelFlag adds --parallel to a command acting on several runners at once
+func addParallelFlag(cmd *cobra.Command) {
+	cmd.Flags().IntP("parallel", "p", 5, "Number of runners to act on at a time when given several IDs")
+}
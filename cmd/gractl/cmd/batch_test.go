@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/strrl/gra/cmd/gractl/client"
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+func TestReadRunnerIDs(t *testing.T) {
+	runnerIDs, err := readRunnerIDs(strings.NewReader("runner-1\n\n  runner-2 \r\nrunner-1\nrunner-3"))
+	if err != nil {
+		t.Fatalf("readRunnerIDs failed: %v", err)
+	}
+	expected := []string{"runner-1", "runner-2", "runner-3"}
+	if !reflect.DeepEqual(runnerIDs, expected) {
+		t.Errorf("Expected %v, got %v", expected, runnerIDs)
+	}
+}
+
+func TestResolveRunnerIDs(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		stdin         string
+		expected      []string
+		expectMessage string
+	}{
+		{"Single ID", []string{"runner-1"}, "", []string{"runner-1"}, ""},
+		{"Several IDs", []string{"runner-1", "runner-2"}, "runner-3\n", []string{"runner-1", "runner-2"}, ""},
+		{"Stdin", []string{"-"}, "runner-1\nrunner-2\n", []string{"runner-1", "runner-2"}, ""},
+		{"Empty stdin", []string{"-"}, "\n\n", nil, "no runner IDs"},
+		{"Mixed", []string{"runner-1", "-"}, "runner-2\n", nil, "cannot mix"},
+		{"Stdin twice", []string{"-", "-"}, "runner-2\n", nil, "cannot mix"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveRunnerIDs(tt.args, strings.NewReader(tt.stdin))
+			if tt.expectMessage != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectMessage) {
+					t.Errorf("Expected an error mentioning %q, got %v", tt.expectMessage, err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v (%v)", tt.expected, got, err)
+			}
+		})
+	}
+}
+
+func TestForEachRunnerConcurrency(t *testing.T) {
+	runnerIDs := []string{"runner-a", "runner-b", "runner-c", "runner-d", "runner-e"}
+
+	var running, maxRunning, calls atomic.Int32
+	forEachRunner(runnerIDs, 2, func(i int, runnerID string) {
+		calls.Add(1)
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	if got := calls.Load(); got != int32(len(runnerIDs)) {
+		t.Errorf("Expected %d calls, got %d", len(runnerIDs), got)
+	}
+	if got := maxRunning.Load(); got != 2 {
+		t.Errorf("Expected 2 calls at a time, got %d", got)
+	}
+}
+
+// newBatchFixture returns a fixture with runner-1 and runner-2
+func newBatchFixture(t *testing.T) *client.Fixture {
+	t.Helper()
+	fixture, err := client.ParseFixture([]byte(`{"runners": [
+		{"id": "runner-1", "name": "analytics", "status": "RUNNER_STATUS_RUNNING"},
+		{"id": "runner-2", "status": "RUNNER_STATUS_CREATING"}
+	]}`))
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	return fixture
+}
+
+func TestGetRunnersPartialFailure(t *testing.T) {
+	results := getRunners(context.Background(), newBatchFixture(t), []string{"runner-2", "runner-9", "runner-1"}, 2)
+
+	var stdout, stderr bytes.Buffer
+	if err := writeGetRunnersTable(&stdout, &stderr, results, false); err != nil {
+		t.Fatalf("Failed to write runners: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "runner-2") || !strings.HasPrefix(lines[2], "runner-1") {
+		t.Errorf("Expected runner-2 and runner-1 in the order given, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Failed to get runner runner-9: ") || !strings.Contains(stderr.String(), "runner not found") {
+		t.Errorf("Expected runner-9 to be reported missing, got %q", stderr.String())
+	}
+
+	var out bytes.Buffer
+	if err := writeGetRunnersJSON(&out, results); err != nil {
+		t.Fatalf("Failed to write runners: %v", err)
+	}
+	var entries []struct {
+		RunnerID string `json:"runner_id"`
+		Runner   *struct {
+			ID string `json:"id"`
+		} `json:"runner"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("Expected a JSON array, got %v:\n%s", err, out.String())
+	}
+	if len(entries) != 3 || entries[0].Runner == nil || entries[0].Runner.ID != "runner-2" {
+		t.Fatalf("Expected runner-2 first, got %s", out.String())
+	}
+	if entries[1].RunnerID != "runner-9" || entries[1].Runner != nil || !strings.Contains(entries[1].Error, "runner not found") {
+		t.Errorf("Expected an error entry for runner-9, got %+v", entries[1])
+	}
+}
+
+func TestDeleteRunnersPartialFailure(t *testing.T) {
+	fixture := newBatchFixture(t)
+	results := deleteRunners(context.Background(), fixture, []string{"runner-1", "runner-9", "runner-2"}, 5, defaultRequestTimeout)
+
+	var out bytes.Buffer
+	if err := writeDeleteRunnersResults(&out, results); err != nil {
+		t.Fatalf("Failed to write results: %v", err)
+	}
+	expected := "  deleted  runner-1\n" +
+		"  failed   runner-9: rpc error: code = NotFound desc = runner not found\n" +
+		"  deleted  runner-2\n" +
+		"Deleted 2 out of 3 runners\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	runners, err := fixture.ListAllRunners(context.Background(), &gradv1.ListRunnersRequest{})
+	if err != nil || len(runners) != 0 {
+		t.Errorf("Expected both runners to be deleted, got %v (%v)", runners, err)
+	}
+
+	out.Reset()
+	if err := writeDeleteRunnersJSON(&out, results); err != nil {
+		t.Fatalf("Failed to write results: %v", err)
+	}
+	var entries []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("Expected a JSON array, got %v:\n%s", err, out.String())
+	}
+	if len(entries) != 3 || entries[0]["response"] == nil || entries[1]["error"] == nil || entries[1]["response"] != nil {
+		t.Errorf("Expected a response for runner-1 and an error for runner-9, got %s", out.String())
+	}
+}
+
+func TestDeleteRunnerResultExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   deleteRunnerResult
+		expected int
+	}{
+		{"Deleted", deleteRunnerResult{Response: &gradv1.DeleteRunnerResponse{}}, 0},
+		{"Not found", deleteRunnerResult{Err: errors.New("runner not found")}, 1},
+		{"Objects left behind", deleteRunnerResult{Response: &gradv1.DeleteRunnerResponse{FailedResources: []string{"service"}}}, 1},
+		{"Still terminating", deleteRunnerResult{Response: &gradv1.DeleteRunnerResponse{RemainingResources: []string{"pod"}}}, ExitCodeTempFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.exitCode(); got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBatchExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		codes    []int
+		expected int
+	}{
+		{"All succeeded", []int{0, 0}, 0},
+		{"One failed", []int{0, 1, 0}, 1},
+		{"Still terminating", []int{0, ExitCodeTempFail}, ExitCodeTempFail},
+		{"Failure outranks terminating", []int{ExitCodeTempFail, 1}, 1},
+		{"Other codes fail", []int{ExitCodeUnavailable}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchExitCode(tt.codes); got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
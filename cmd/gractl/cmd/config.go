@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/strrl/gra/cmd/gractl/config"
+)
+
+// settableKeys are the keys gractl config set accepts, each with a function checking a value
+// and returning it as TOML
+var settableKeys = map[string]func(value string) (string, error){
+	"output.format": func(value string) (string, error) {
+		if _, err := parseOutputFormat(value); err != nil {
+			return "", err
+		}
+		return strconv.Quote(value), nil
+	},
+	"output.color": func(value string) (string, error) {
+		if _, err := parseColorMode(value); err != nil {
+			return "", err
+		}
+		return strconv.Quote(value), nil
+	},
+	"output.wide_columns": func(value string) (string, error) {
+		wide, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid boolean: %s (supported: true, false)", value)
+		}
+		return strconv.FormatBool(wide), nil
+	},
+}
+
+// settableKeyNames returns the keys gractl config set accepts, sorted (pure function)
+func settableKeyNames() []string {
+	names := make([]string, 0, len(settableKeys))
+	for name := range settableKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tomlSetting checks value for key and returns it as TOML (pure function)
+func tomlSetting(key, value string) (string, error) {
+	encode, ok := settableKeys[key]
+	if !ok {
+		return "", fmt.Errorf("unknown key: %s (supported: %s)", key, strings.Join(settableKeyNames(), ", "))
+	}
+	return encode(value)
+}
+
+// configFilePath returns the config file gractl config set writes: the one in use, else
+// .gractl.toml in the current directory
+func configFilePath(cfg *config.Config) string {
+	if cfg != nil && cfg.File != "" {
+		return cfg.File
+	}
+	return config.GetConfigPath()
+}
+
+// ConfigCmd represents the config command
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage gractl preferences",
+	Long:  `Manage the preferences stored in .gractl.toml.`,
+	// An invalid preference must not keep config set from fixing it, so only load the config
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		loadGlobalConfig()
+	},
+}
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Persist a preference in .gractl.toml",
+	Long: `Persist a preference in the .gractl.toml in use, or in the current directory if there is
+none. The rest of the file, comments included, is kept. Flags still take precedence.
+
+Keys:
+  output.format        table, wide or json: the default of --output
+  output.color         auto, always or never; auto colors terminals unless NO_COLOR is set
+  output.wide_columns  true to add the extra columns of -o wide to tables
+
+Examples:
+  gractl config set output.format json`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, value := args[0], args[1]
+		setting, err := tomlSetting(key, value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid setting: %v\n", err)
+			os.Exit(ExitCodeUsage)
+		}
+
+		path := configFilePath(globalConfig)
+		if err := config.SetValue(path, key, setting); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to set %s: %v\n", key, err)
+			os.Exit(1)
+		}
+		logger.Infof("Set %s to %s in %s", key, setting, path)
+	},
+}
+
+func init() {
+	ConfigCmd.AddCommand(configSetCmd)
+}
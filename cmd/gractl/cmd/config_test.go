@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTOMLSetting(t *testing.T) {
+	tests := []struct {
+		key      string
+		value    string
+		expected string
+	}{
+		{"output.format", "json", `"json"`},
+		{"output.color", "never", `"never"`},
+		{"output.wide_columns", "1", "true"},
+	}
+	for _, tt := range tests {
+		if got, err := tomlSetting(tt.key, tt.value); err != nil || got != tt.expected {
+			t.Errorf("Expected %s for %s=%s, got %q (%v)", tt.expected, tt.key, tt.value, got, err)
+		}
+	}
+
+	for _, invalid := range [][2]string{{"output.format", "yaml"}, {"output.color", "rainbow"}, {"output.wide_columns", "maybe"}} {
+		if _, err := tomlSetting(invalid[0], invalid[1]); err == nil {
+			t.Errorf("Expected %s=%s to be rejected", invalid[0], invalid[1])
+		}
+	}
+	if _, err := tomlSetting("server.token", "secret"); err == nil || !strings.Contains(err.Error(), "output.format") {
+		t.Errorf("Expected an unknown key to list the supported ones, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/strrl/gra/cmd/gractl/client"
+	"github.com/strrl/gra/cmd/gractl/config"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// MockDataEnv names a fixture file to serve commands from instead of a server, like --mock-data
+const MockDataEnv = "GRACTL_MOCK_DATA"
+
+// mockDataFlag is the hidden global --mock-data flag
+var mockDataFlag string
+
+// mockDataPath returns the fixture from --mock-data or else MockDataEnv, "" for none (pure function)
+func mockDataPath(flagValue string, getenv func(string) string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return getenv(MockDataEnv)
+}
+
+// resolveServerAddress picks the server address from the --server flag or the config file
+// and reports where it came from
+func resolveServerAddress(flagValue string, flagChanged bool, cfg *config.Config) (address, source string) {
+	if flagChanged {
+		return flagValue, "--server flag"
+	}
+	if cfg != nil && cfg.Server.Address != "" {
+		return cfg.Server.Address, cfg.Server.AddressSource
+	}
+	return flagValue, "default"
+}
+
+// newGRPCClient connects to the server configured for cmd, failing if it is unreachable. With
+// mock data it loads that instead and never contacts a server.
+func newGRPCClient(cmd *cobra.Command, cfg *config.Config) (client.Client, error) {
+	if path := mockDataPath(mockDataFlag, os.Getenv); path != "" {
+		logger.Debugf("Serving commands from mock data in %s", path)
+		fixture, err := client.LoadFixture(path)
+		if err != nil {
+			return nil, err
+		}
+		return fixture, nil
+	}
+
+	flagValue, _ := cmd.Flags().GetString("server")
+	address, source := resolveServerAddress(flagValue, cmd.Flags().Changed("server"), cfg)
+
+	clientCfg := &gradclient.Config{
+		ServerAddress: address,
+		AddressSource: source,
+		DialTimeout:   gradclient.DefaultDialTimeout,
+	}
+	if cfg != nil && cfg.Server.DialTimeout > 0 {
+		clientCfg.DialTimeout = cfg.Server.DialTimeout
+	}
+	logger.Debugf("Connecting to %s (from %s)", address, source)
+
+	c, err := gradclient.NewClient(clientCfg)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// s3Workspace returns the default S3 workspace and credentials from the config file, if any
+func s3Workspace(cfg *config.Config) gradclient.S3Workspace {
+	if cfg == nil {
+		return gradclient.S3Workspace{}
+	}
+	return gradclient.S3Workspace{
+		Bucket:   cfg.S3.Bucket,
+		Endpoint: cfg.S3.Endpoint,
+		Prefix:   cfg.S3.Prefix,
+		Region:   cfg.S3.Region,
+		ReadOnly: cfg.S3.ReadOnly,
+
+		AccessKeyID:     cfg.S3.AccessKeyID,
+		SecretAccessKey: cfg.S3.SecretAccessKey,
+		SessionToken:    cfg.S3.SessionToken,
+	}
+}
+
+// addWorkspaceFlags adds the flags setting the S3 workspace of the runners cmd creates
+func addWorkspaceFlags(cmd *cobra.Command) {
+	cmd.Flags().String("s3-bucket", "", "S3 bucket name for workspace")
+	cmd.Flags().String("s3-endpoint", "", "S3 endpoint URL (optional, defaults to AWS S3)")
+	cmd.Flags().String("s3-prefix", "", "S3 path prefix within the bucket (optional)")
+	cmd.Flags().String("s3-region", "", "AWS region (optional, defaults to us-east-1)")
+	cmd.Flags().Bool("read-only", false, "Mount S3 bucket as read-only")
+}
+
+// workspaceFromFlags returns the S3 workspace from the config file with the flags of
+// addWorkspaceFlags overriding it setting by setting, so --s3-bucket can be mixed with the
+// endpoint and credentials from the config
+func workspaceFromFlags(cmd *cobra.Command, cfg *config.Config) gradclient.S3Workspace {
+	workspace := s3Workspace(cfg)
+	if bucket, _ := cmd.Flags().GetString("s3-bucket"); bucket != "" {
+		workspace.Bucket = bucket
+	}
+	if endpoint, _ := cmd.Flags().GetString("s3-endpoint"); endpoint != "" {
+		workspace.Endpoint = endpoint
+	}
+	if prefix, _ := cmd.Flags().GetString("s3-prefix"); prefix != "" {
+		workspace.Prefix = prefix
+	}
+	if region, _ := cmd.Flags().GetString("s3-region"); region != "" {
+		workspace.Region = region
+	}
+	if cmd.Flags().Changed("read-only") {
+		workspace.ReadOnly, _ = cmd.Flags().GetBool("read-only")
+	}
+	return workspace
+}
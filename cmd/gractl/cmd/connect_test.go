@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/strrl/gra/cmd/gractl/config"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+func TestResolveServerAddress(t *testing.T) {
+	fileConfig := &config.Config{
+		Server: config.ServerConfig{
+			Address:       "grad.internal:9090",
+			AddressSource: "config file /home/user/.gractl.toml",
+		},
+	}
+
+	tests := []struct {
+		name           string
+		flagValue      string
+		flagChanged    bool
+		cfg            *config.Config
+		expectedAddr   string
+		expectedSource string
+	}{
+		{"Flag wins over config", "other:9090", true, fileConfig, "other:9090", "--server flag"},
+		{"Config used when flag unset", "localhost:9090", false, fileConfig, "grad.internal:9090", "config file /home/user/.gractl.toml"},
+		{"Default without config", "localhost:9090", false, nil, "localhost:9090", "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, source := resolveServerAddress(tt.flagValue, tt.flagChanged, tt.cfg)
+			if addr != tt.expectedAddr || source != tt.expectedSource {
+				t.Errorf("Expected (%s, %s), got (%s, %s)", tt.expectedAddr, tt.expectedSource, addr, source)
+			}
+		})
+	}
+}
+
+func TestMockDataPath(t *testing.T) {
+	getenv := func(value string) func(string) string {
+		return func(key string) string {
+			if key != MockDataEnv {
+				t.Errorf("Expected %s to be looked up, got %s", MockDataEnv, key)
+			}
+			return value
+		}
+	}
+
+	if got := mockDataPath("", getenv("")); got != "" {
+		t.Errorf("Expected no mock data, got %q", got)
+	}
+	if got := mockDataPath("", getenv("env.json")); got != "env.json" {
+		t.Errorf("Expected the environment's mock data, got %q", got)
+	}
+	if got := mockDataPath("flag.json", getenv("env.json")); got != "flag.json" {
+		t.Errorf("Expected --mock-data to win over the environment, got %q", got)
+	}
+}
+
+func TestWorkspaceFromFlags(t *testing.T) {
+	cfg := &config.Config{S3: config.S3Config{
+		Bucket:          "data",
+		Endpoint:        "http://minio:9000",
+		Prefix:          "team",
+		ReadOnly:        true,
+		AccessKeyID:     "AKIA",
+		SecretAccessKey: "s3cr3t",
+	}}
+
+	tests := []struct {
+		name     string
+		args     []string
+		cfg      *config.Config
+		expected gradclient.S3Workspace
+	}{
+		{"Config without flags", nil, cfg, s3Workspace(cfg)},
+		{
+			"Flags override the config setting by setting",
+			[]string{"--s3-bucket", "other", "--s3-region", "eu-west-1", "--read-only=false"},
+			cfg,
+			gradclient.S3Workspace{Bucket: "other", Endpoint: "http://minio:9000", Prefix: "team", Region: "eu-west-1", AccessKeyID: "AKIA", SecretAccessKey: "s3cr3t"},
+		},
+		{
+			"Flags without config",
+			[]string{"--s3-bucket", "other", "--s3-endpoint", "http://s3:9000", "--s3-prefix", "runs", "--read-only"},
+			nil,
+			gradclient.S3Workspace{Bucket: "other", Endpoint: "http://s3:9000", Prefix: "runs", ReadOnly: true},
+		},
+	}
+	// Both commands creating runners take the flags the same way
+	for _, cmd := range []*cobra.Command{createCmd, ExecuteCmd} {
+		for _, tt := range tests {
+			t.Run(cmd.Name()+"/"+tt.name, func(t *testing.T) {
+				resetFlags(cmd)
+				if err := cmd.ParseFlags(tt.args); err != nil {
+					t.Fatalf("Failed to parse flags: %v", err)
+				}
+				if got := workspaceFromFlags(cmd, tt.cfg); got != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, got)
+				}
+			})
+		}
+		resetFlags(cmd)
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/strrl/gra/cmd/gractl/config"
+)
+
+// defaultRequestTimeout bounds each unary RPC unless --request-timeout or server.request_timeout
+// says otherwise
+const defaultRequestTimeout = 30 * time.Second
+
+var (
+	// requestTimeoutFlag is the global --request-timeout flag
+	requestTimeoutFlag time.Duration
+	// requestTimeout bounds each unary RPC, resolved by initGlobals
+	requestTimeout = defaultRequestTimeout
+)
+
+// errInterrupted reports that a call was cancelled by SIGINT or SIGTERM
+var errInterrupted = errors.New("interrupted")
+
+// NewCommandContext returns the context of one gractl invocation, to run the root command
+// with. The first SIGINT or SIGTERM cancels it; a second one kills gractl as usual, in case
+// whatever the first one cancelled doesn't return.
+func NewCommandContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// commandContext returns the context of cmd's invocation, or context.Background() for a
+// command run without Execute, as in tests. Streams use it as it is: they have no deadline
+// but stop on Ctrl+C.
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// rpcContext returns ctx bounded by the request timeout, for one unary RPC
+func rpcContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, requestTimeout)
+}
+
+// withTimeout returns ctx bounded by timeout, or only cancellable if timeout is 0
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// interruptible marks err, from a call made with ctx, as errInterrupted once ctx is cancelled,
+// since gRPC reports that as a bare Canceled status (pure function)
+func interruptible(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return fmt.Errorf("%w: %w", errInterrupted, err)
+	}
+	return err
+}
+
+// resolveRequestTimeout picks the request timeout from the --request-timeout flag or the
+// config file, the default if neither sets one
+func resolveRequestTimeout(flagValue time.Duration, flagChanged bool, cfg *config.Config) (time.Duration, error) {
+	if flagChanged {
+		if flagValue <= 0 {
+			return 0, fmt.Errorf("invalid --request-timeout: %s (must be positive)", flagValue)
+		}
+		return flagValue, nil
+	}
+	if cfg == nil || cfg.Server.RequestTimeout == 0 {
+		return defaultRequestTimeout, nil
+	}
+	if cfg.Server.RequestTimeout < 0 {
+		return 0, fmt.Errorf("invalid server.request_timeout: %s (must be positive)", cfg.Server.RequestTimeout)
+	}
+	return cfg.Server.RequestTimeout, nil
+}
+
+// sleepContext returns a sleep for runWithRetry that ends early once ctx is done
+func sleepContext(ctx context.Context) func(time.Duration) {
+	return func(d time.Duration) {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/strrl/gra/cmd/gractl/config"
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+// promptly is how soon a hanging call must return once its context ends
+const promptly = 5 * time.Second
+
+func TestResolveRequestTimeout(t *testing.T) {
+	fileConfig := &config.Config{Server: config.ServerConfig{RequestTimeout: 2 * time.Minute}}
+
+	tests := []struct {
+		name        string
+		flagValue   time.Duration
+		flagChanged bool
+		cfg         *config.Config
+		expected    time.Duration
+		expectErr   bool
+	}{
+		{"Flag wins over config", 10 * time.Second, true, fileConfig, 10 * time.Second, false},
+		{"Config used when flag unset", defaultRequestTimeout, false, fileConfig, 2 * time.Minute, false},
+		{"Default without config", defaultRequestTimeout, false, nil, defaultRequestTimeout, false},
+		{"Default for unset config", defaultRequestTimeout, false, &config.Config{}, defaultRequestTimeout, false},
+		{"Zero flag", 0, true, fileConfig, 0, true},
+		{"Negative config", defaultRequestTimeout, false, &config.Config{Server: config.ServerConfig{RequestTimeout: -time.Second}}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveRequestTimeout(tt.flagValue, tt.flagChanged, tt.cfg)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %t, got %v", tt.expectErr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// hangingClient returns a client whose calls of method hang until their context ends
+func hangingClient(method string) *gradclient.Client {
+	fake := gradtest.NewFake()
+	fake.AddRunners(gradtest.NewRunner("runner-1"))
+	fake.SetLatency(method, time.Hour)
+	return gradclient.NewFromServices(fake, fake, fake)
+}
+
+func TestRequestTimeoutAbortsHangingCall(t *testing.T) {
+	defer func(previous time.Duration) { requestTimeout = previous }(requestTimeout)
+	requestTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	results := getRunners(context.Background(), hangingClient(gradv1.RunnerService_GetRunner_FullMethodName), []string{"runner-1"}, 1)
+	if elapsed := time.Since(start); elapsed > promptly {
+		t.Errorf("Expected the request timeout to abort the call, took %s", elapsed)
+	}
+	if status.Code(results[0].Err) != codes.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded, got %v", results[0].Err)
+	}
+}
+
+func TestCancellingAbortsHangingCall(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	results := getRunners(ctx, hangingClient(gradv1.RunnerService_GetRunner_FullMethodName), []string{"runner-1"}, 1)
+	if elapsed := time.Since(start); elapsed > promptly {
+		t.Errorf("Expected cancelling to abort the call, took %s", elapsed)
+	}
+	if status.Code(results[0].Err) != codes.Canceled {
+		t.Errorf("Expected Canceled, got %v", results[0].Err)
+	}
+}
+
+func TestCancellingInterruptsStream(t *testing.T) {
+	// Streams have no deadline, however short the request timeout
+	defer func(previous time.Duration) { requestTimeout = previous }(requestTimeout)
+	requestTimeout = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	c := hangingClient(gradv1.RunnerService_ExecuteCommandStream_FullMethodName)
+	exec := streamExec(c, &gradv1.ExecuteCommandRequest{Command: "sleep 3600"})
+	start := time.Now()
+	_, err := exec(ctx, "runner-1", io.Discard, io.Discard)
+	if elapsed := time.Since(start); elapsed > promptly {
+		t.Errorf("Expected cancelling to abort the stream, took %s", elapsed)
+	}
+	if !errors.Is(err, errInterrupted) {
+		t.Errorf("Expected the stream to be interrupted, got %v", err)
+	}
+	if retry := (retryPolicy{Retries: 3, OnStreamError: true}); retry.shouldRetry(nil, err) {
+		t.Error("Expected an interrupted command not to be retried")
+	}
+}
+
+func TestInterruptible(t *testing.T) {
+	streamErr := status.Error(codes.Canceled, "context canceled")
+	if err := interruptible(context.Background(), streamErr); errors.Is(err, errInterrupted) {
+		t.Errorf("Expected an error on a live context as is, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := interruptible(ctx, nil); err != nil {
+		t.Errorf("Expected no error to stay nil, got %v", err)
+	}
+	if err := interruptible(ctx, streamErr); !errors.Is(err, errInterrupted) || status.Code(err) != codes.Canceled {
+		t.Errorf("Expected an interrupted Canceled error, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if err := interruptible(ctx, streamErr); errors.Is(err, errInterrupted) {
+		t.Errorf("Expected a deadline not to count as an interruption, got %v", err)
+	}
+}
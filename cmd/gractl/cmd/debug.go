@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// redactedValue replaces env values in support bundles
+const redactedValue = "<redacted>"
+
+// bundleClient is the part of the grad client a support bundle is collected from
+type bundleClient interface {
+	GetRunner(ctx context.Context, runnerID string) (*gradv1.Runner, error)
+	GetRunnerDiagnostics(ctx context.Context, runnerID string, tailLines int32) (*gradv1.GetRunnerDiagnosticsResponse, error)
+	GetServerInfo(ctx context.Context) (*gradv1.GetServerInfoResponse, error)
+}
+
+// bundleManifest describes a support bundle; it is written last, as manifest.json
+type bundleManifest struct {
+	RunnerID    string       `json:"runner_id"`
+	Server      string       `json:"server"`
+	CollectedAt time.Time    `json:"collected_at"`
+	Redacted    string       `json:"redacted"`
+	Files       []bundleFile `json:"files"`
+	// Errors lists what couldn't be collected, by part
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// bundleFile is an entry of a support bundle's manifest
+type bundleFile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// bundleWriter adds files to a gzipped tarball under a common directory
+type bundleWriter struct {
+	tw       *tar.Writer
+	dir      string
+	modTime  time.Time
+	manifest *bundleManifest
+}
+
+// add writes a file to the bundle and lists it in the manifest
+func (b *bundleWriter) add(name, description string, data []byte) error {
+	if err := b.tw.WriteHeader(&tar.Header{
+		Name:     path.Join(b.dir, name),
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  b.modTime,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		return err
+	}
+	if b.manifest != nil {
+		b.manifest.Files = append(b.manifest.Files, bundleFile{Name: name, Description: description})
+	}
+	return nil
+}
+
+// addJSON writes v as indented JSON
+func (b *bundleWriter) addJSON(name, description string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.add(name, description, append(data, '\n'))
+}
+
+// addProto writes m in gractl's JSON format
+func (b *bundleWriter) addProto(name, description string, m proto.Message) error {
+	data, err := marshalProtoJSON(m)
+	if err != nil {
+		return err
+	}
+	return b.add(name, description, data)
+}
+
+// writeSupportBundle collects what's known about a runner from client and writes it to w as
+// a gzipped tarball with a manifest. Parts that can't be collected are listed in the
+// manifest's errors; it only fails if nothing about the runner could be collected.
+func writeSupportBundle(ctx context.Context, w io.Writer, client bundleClient, manifest *bundleManifest, tailLines int32) error {
+	manifest.Redacted = "env values of the runner and its pod's containers"
+	manifest.Errors = map[string]string{}
+
+	// Each request gets the whole request timeout
+	rpcCtx, cancel := rpcContext(ctx)
+	runner, runnerErr := client.GetRunner(rpcCtx, manifest.RunnerID)
+	cancel()
+	rpcCtx, cancel = rpcContext(ctx)
+	diagnostics, diagnosticsErr := client.GetRunnerDiagnostics(rpcCtx, manifest.RunnerID, tailLines)
+	cancel()
+	if runnerErr != nil && diagnosticsErr != nil {
+		return runnerErr
+	}
+	rpcCtx, cancel = rpcContext(ctx)
+	serverInfo, serverInfoErr := client.GetServerInfo(rpcCtx)
+	cancel()
+
+	gz := gzip.NewWriter(w)
+	b := &bundleWriter{tw: tar.NewWriter(gz), dir: "gractl-debug-" + manifest.RunnerID, modTime: manifest.CollectedAt, manifest: manifest}
+
+	if runnerErr != nil {
+		manifest.Errors["runner"] = runnerErr.Error()
+	} else if err := b.addProto("runner.json", "The runner as returned by GetRunner", redactRunner(runner)); err != nil {
+		return err
+	}
+
+	if serverInfoErr != nil {
+		manifest.Errors["server_info"] = serverInfoErr.Error()
+	} else if err := b.addProto("server-info.json", "Server build, limits and Kubernetes version", serverInfo); err != nil {
+		return err
+	}
+
+	if diagnosticsErr != nil {
+		manifest.Errors["diagnostics"] = diagnosticsErr.Error()
+	} else if err := addDiagnostics(b, diagnostics); err != nil {
+		return err
+	}
+
+	if len(manifest.Errors) == 0 {
+		manifest.Errors = nil
+	}
+	b.manifest = nil
+	if err := b.addJSON("manifest.json", "", manifest); err != nil {
+		return err
+	}
+
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// addDiagnostics writes the runner's pod, events, executions and container logs
+func addDiagnostics(b *bundleWriter, diagnostics *gradv1.GetRunnerDiagnosticsResponse) error {
+	pod, err := redactPodJSON([]byte(diagnostics.PodJson))
+	if err != nil {
+		b.manifest.Errors["pod"] = err.Error()
+	} else if err := b.add("pod.json", "The runner's pod as Kubernetes has it", pod); err != nil {
+		return err
+	}
+
+	if err := b.addJSON("events.json", "Events about the runner's pod, oldest first", protoList(diagnostics.Events)); err != nil {
+		return err
+	}
+	if err := b.addJSON("executions.json", "Commands run since the server started, oldest first", protoList(diagnostics.Executions)); err != nil {
+		return err
+	}
+
+	for _, log := range diagnostics.Logs {
+		name := path.Join("logs", log.Container+".log")
+		if log.Error != "" {
+			b.manifest.Errors[name] = log.Error
+			continue
+		}
+		if err := b.add(name, fmt.Sprintf("Tail of the %s container's log", log.Container), []byte(log.Log)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protoList renders messages as a JSON array in gractl's JSON format (pure function)
+func protoList[M proto.Message](messages []M) []json.RawMessage {
+	list := make([]json.RawMessage, 0, len(messages))
+	for _, m := range messages {
+		data, err := protoJSONOptions.Marshal(m)
+		if err != nil {
+			continue
+		}
+		list = append(list, data)
+	}
+	return list
+}
+
+// redactRunner returns a copy of runner without its env values (pure function)
+func redactRunner(runner *gradv1.Runner) *gradv1.Runner {
+	runner = proto.Clone(runner).(*gradv1.Runner)
+	for key := range runner.Env {
+		runner.Env[key] = redactedValue
+	}
+	return runner
+}
+
+// redactPodJSON replaces the env values of every container of a pod, leaving references to
+// secrets and config maps as they are (pure function)
+func redactPodJSON(podJSON []byte) ([]byte, error) {
+	var pod map[string]any
+	if err := json.Unmarshal(podJSON, &pod); err != nil {
+		return nil, fmt.Errorf("invalid pod JSON: %w", err)
+	}
+
+	spec, _ := pod["spec"].(map[string]any)
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _ := spec[field].([]any)
+		for _, c := range containers {
+			container, _ := c.(map[string]any)
+			env, _ := container["env"].([]any)
+			for _, e := range env {
+				if envVar, ok := e.(map[string]any); ok {
+					if _, ok := envVar["value"]; ok {
+						envVar["value"] = redactedValue
+					}
+				}
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(pod, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// debugCmd represents the debug command
+var debugCmd = &cobra.Command{
+	Use:   "debug RUNNER_ID",
+	Short: "Collect a support bundle for debugging a runner",
+	Long: `Collect everything needed to debug a runner into a gzipped tarball: the runner,
+its pod, the pod's events, the tail of each container's log, recent executions and the
+server's info, with a manifest.json describing the files.
+
+Env values are redacted, but commands and logs are included as they are; check the bundle
+before sharing it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runnerID := resolveRunnerArg(cmd, grpcClient, args[0])
+		tailLines, _ := cmd.Flags().GetInt32("tail")
+		bundlePath, _ := cmd.Flags().GetString("bundle")
+		if bundlePath == "" {
+			bundlePath = fmt.Sprintf("gractl-debug-%s.tar.gz", runnerID)
+		}
+
+		flagValue, _ := cmd.Flags().GetString("server")
+		server, _ := resolveServerAddress(flagValue, cmd.Flags().Changed("server"), globalConfig)
+		manifest := &bundleManifest{
+			RunnerID:    runnerID,
+			Server:      server,
+			CollectedAt: time.Now().UTC().Truncate(time.Second),
+		}
+
+		file, err := os.Create(bundlePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create bundle: %v\n", err)
+			os.Exit(1)
+		}
+		if err := writeSupportBundle(commandContext(cmd), file, grpcClient, manifest, tailLines); err != nil {
+			file.Close()
+			os.Remove(bundlePath)
+			if status.Code(err) == codes.NotFound {
+				fmt.Fprintf(os.Stderr, "Runner %s not found\n", runnerID)
+			} else {
+				fmt.Fprintf(os.Stderr, "Failed to collect support bundle: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write bundle: %v\n", err)
+			os.Exit(1)
+		}
+
+		for part, partErr := range manifest.Errors {
+			logger.Warnf("Could not collect %s: %s", part, partErr)
+		}
+		fmt.Printf("Wrote support bundle to %s\n", bundlePath)
+	},
+}
+
+func init() {
+	debugCmd.Flags().String("bundle", "", "File to write the bundle to (defaults to gractl-debug-RUNNER_ID.tar.gz)")
+	debugCmd.Flags().Int32("tail", 200, "Number of log lines to collect per container (capped by the server)")
+
+	RunnersCmd.AddCommand(debugCmd)
+}
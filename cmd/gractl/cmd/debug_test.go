@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+// readBundle returns the files of a gzipped tarball by name
+func readBundle(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected a gzipped bundle, got %v", err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		if err != nil {
+			t.Fatalf("Failed to read bundle: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", header.Name, err)
+		}
+		files[header.Name] = string(content)
+	}
+}
+
+func TestWriteSupportBundle(t *testing.T) {
+	fake := gradtest.NewFake()
+	fake.AddRunners(gradtest.NewRunner("runner-1",
+		gradtest.WithStatus(gradv1.RunnerStatus_RUNNER_STATUS_ERROR),
+		gradtest.WithEnv(map[string]string{"API_TOKEN": "s3cr3t"}),
+	))
+	fake.SetDiagnostics("runner-1", &gradv1.GetRunnerDiagnosticsResponse{
+		PodJson: `{"kind":"Pod","spec":{"containers":[{"name":"runner","env":[{"name":"API_TOKEN","value":"s3cr3t"},{"name":"DB_PASSWORD","valueFrom":{"secretKeyRef":{"name":"db","key":"password"}}}]}]}}`,
+		Events:  []*gradv1.RunnerEvent{{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container"}},
+		Logs: []*gradv1.ContainerLog{
+			{Container: "runner", Log: "line 1\nline 2\n"},
+			{Container: "s3fs", Error: "container s3fs is waiting to start"},
+		},
+		Executions: []*gradv1.ExecutionRecord{{Command: "make test", ExitCode: 2}},
+	})
+	fake.FailWith(gradv1.RunnerService_GetServerInfo_FullMethodName, status.Error(codes.Unavailable, "connection reset"))
+	client := gradclient.NewFromServices(fake, fake, fake)
+	manifest := &bundleManifest{RunnerID: "runner-1", Server: "localhost:9090", CollectedAt: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}
+
+	var buf bytes.Buffer
+	if err := writeSupportBundle(context.Background(), &buf, client, manifest, 50); err != nil {
+		t.Fatalf("writeSupportBundle failed: %v", err)
+	}
+	requests := fake.Requests(gradv1.RunnerService_GetRunnerDiagnostics_FullMethodName)
+	if len(requests) != 1 || requests[0].(*gradv1.GetRunnerDiagnosticsRequest).TailLines != 50 {
+		t.Errorf("Expected 50 log lines to be asked for, got %v", requests)
+	}
+
+	files := readBundle(t, buf.Bytes())
+	const dir = "gractl-debug-runner-1/"
+	for _, name := range []string{"manifest.json", "runner.json", "pod.json", "events.json", "executions.json", "logs/runner.log"} {
+		if _, ok := files[dir+name]; !ok {
+			t.Errorf("Expected %s in the bundle, got %d files", name, len(files))
+		}
+	}
+	for name, content := range files {
+		if strings.Contains(content, "s3cr3t") {
+			t.Errorf("Expected env values to be redacted from %s", name)
+		}
+	}
+
+	if !strings.Contains(files[dir+"pod.json"], `"secretKeyRef"`) {
+		t.Errorf("Expected secret references to be kept, got %s", files[dir+"pod.json"])
+	}
+	if files[dir+"logs/runner.log"] != "line 1\nline 2\n" {
+		t.Errorf("Expected the runner log as is, got %q", files[dir+"logs/runner.log"])
+	}
+	if !strings.Contains(files[dir+"events.json"], `"reason": "BackOff"`) {
+		t.Errorf("Expected the BackOff event, got %s", files[dir+"events.json"])
+	}
+	if !strings.Contains(files[dir+"runner.json"], `"status": "RUNNER_STATUS_ERROR"`) {
+		t.Errorf("Expected the runner in gractl's JSON format, got %s", files[dir+"runner.json"])
+	}
+
+	var got bundleManifest
+	if err := json.Unmarshal([]byte(files[dir+"manifest.json"]), &got); err != nil {
+		t.Fatalf("Expected a JSON manifest, got %v", err)
+	}
+	if got.RunnerID != "runner-1" || got.Server != "localhost:9090" || !got.CollectedAt.Equal(manifest.CollectedAt) {
+		t.Errorf("Expected the runner, server and collection time in the manifest, got %+v", got)
+	}
+	if len(got.Files) != len(files)-1 {
+		t.Errorf("Expected every other file to be listed, got %v", got.Files)
+	}
+	// What couldn't be collected is listed instead of failing the bundle
+	if len(got.Errors) != 2 || got.Errors["server_info"] == "" || got.Errors["logs/s3fs.log"] == "" {
+		t.Errorf("Expected the server info and s3fs log errors, got %v", got.Errors)
+	}
+}
+
+func TestWriteSupportBundleMissingRunner(t *testing.T) {
+	fake := gradtest.NewFake()
+	var buf bytes.Buffer
+	err := writeSupportBundle(context.Background(), &buf, gradclient.NewFromServices(fake, fake, fake), &bundleManifest{RunnerID: "missing"}, 0)
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing to be written, got %d bytes", buf.Len())
+	}
+}
+
+func TestRedactPodJSON(t *testing.T) {
+	if _, err := redactPodJSON([]byte("not json")); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+
+	redacted, err := redactPodJSON([]byte(`{"spec":{"initContainers":[{"env":[{"name":"AWS_SECRET_ACCESS_KEY","value":"abc"}]}]}}`))
+	if err != nil {
+		t.Fatalf("redactPodJSON failed: %v", err)
+	}
+	if strings.Contains(string(redacted), "abc") || !strings.Contains(string(redacted), "AWS_SECRET_ACCESS_KEY") {
+		t.Errorf("Expected the value to be redacted and the name kept, got %s", redacted)
+	}
+}
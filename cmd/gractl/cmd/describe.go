@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// describeCommandWidth is how much of a command the executions of a description show
+const describeCommandWidth = 60
+
+// describeClient is the part of the grad client a runner description is put together from
+type describeClient interface {
+	GetRunner(ctx context.Context, runnerID string) (*gradv1.Runner, error)
+	GetRunnerDiagnostics(ctx context.Context, runnerID string, tailLines int32) (*gradv1.GetRunnerDiagnosticsResponse, error)
+}
+
+// runnerDescription is everything describe shows about a runner
+type runnerDescription struct {
+	Runner *gradv1.Runner
+	// Diagnosed is whether the events and executions could be got
+	Diagnosed bool
+	// Events are about the runner's pod, oldest first
+	Events []*gradv1.RunnerEvent
+	// Executions are the latest commands run on the runner, oldest first
+	Executions []*gradv1.ExecutionRecord
+	// Mounts are this machine's live workspace mounts of the runner
+	Mounts []mountRecord
+	// Notes say which sections were left out and why, e.g. because the server is too old to
+	// support them
+	Notes []string
+}
+
+// describeRunner gets the runner and its recent events and executions from client, keeping the
+// last executions of them. Only failing to get the runner is an error; sections the server
+// doesn't support, or couldn't provide, are left out with a note.
+func describeRunner(ctx context.Context, client describeClient, runnerID string, executions int) (*runnerDescription, error) {
+	rpcCtx, cancel := rpcContext(ctx)
+	runner, err := client.GetRunner(rpcCtx, runnerID)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	desc := &runnerDescription{Runner: runner}
+
+	// Only events and executions are used, so a single log line per container is enough
+	rpcCtx, cancel = rpcContext(ctx)
+	diagnostics, err := client.GetRunnerDiagnostics(rpcCtx, runnerID, 1)
+	cancel()
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		desc.Notes = append(desc.Notes, "Events and executions are left out, the server doesn't support runner diagnostics")
+	case err != nil:
+		desc.Notes = append(desc.Notes, fmt.Sprintf("Events and executions are left out, failed to get them: %v", err))
+	default:
+		desc.Diagnosed = true
+		desc.Events = diagnostics.Events
+		desc.Executions = lastExecutions(diagnostics.Executions, executions)
+	}
+	return desc, nil
+}
+
+// lastExecutions returns the last n of executions (pure function)
+func lastExecutions(executions []*gradv1.ExecutionRecord, n int) []*gradv1.ExecutionRecord {
+	if n < 0 || len(executions) <= n {
+		return executions
+	}
+	return executions[len(executions)-n:]
+}
+
+// runnerMounts returns the mounts of runnerID (pure function)
+func runnerMounts(mounts []mountRecord, runnerID string) []mountRecord {
+	var matched []mountRecord
+	for _, mount := range mounts {
+		if mount.RunnerID == runnerID {
+			matched = append(matched, mount)
+		}
+	}
+	return matched
+}
+
+// writeRunnerDescription writes a description like kubectl describe does: the runner's
+// details followed by a section each for its conditions, mounts, events and executions
+func writeRunnerDescription(out io.Writer, desc *runnerDescription) error {
+	if err := writeRunnerDetails(out, desc.Runner); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\nConditions:\n")
+	if err := writeConditionTable(out, desc.Runner.Conditions); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\nWorkspace Mounts:\n")
+	if len(desc.Mounts) == 0 {
+		fmt.Fprintf(out, "  <none>\n")
+	}
+	for _, mount := range desc.Mounts {
+		port := "pending"
+		if mount.LocalPort != 0 {
+			port = fmt.Sprintf("port %d", mount.LocalPort)
+		}
+		fmt.Fprintf(out, "  %s (%s, pid %d, since %s)\n", mount.Mountpoint, port, mount.PID, formatTimestamp(mount.StartedAt.Unix()))
+	}
+
+	if desc.Diagnosed {
+		fmt.Fprintf(out, "\nEvents:\n")
+		if err := writeEventTable(out, desc.Events); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\nRecent Executions:\n")
+		if err := writeExecutionTable(out, desc.Executions); err != nil {
+			return err
+		}
+	}
+
+	if len(desc.Notes) > 0 {
+		fmt.Fprintf(out, "\nNotes:\n")
+		for _, note := range desc.Notes {
+			fmt.Fprintf(out, "  %s\n", note)
+		}
+	}
+	return nil
+}
+
+// writeConditionTable writes a runner's conditions as an indented table, in the order the
+// runner goes through them
+func writeConditionTable(out io.Writer, conditions []*gradv1.RunnerCondition) error {
+	if len(conditions) == 0 {
+		fmt.Fprintf(out, "  <none>\n")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  TYPE\tSTATUS\tLAST TRANSITION\tREASON\tMESSAGE")
+	for _, condition := range conditions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", condition.Type, formatConditionStatus(condition.Status),
+			formatTimestamp(condition.LastTransitionTime), condition.Reason, condition.Message)
+	}
+	return w.Flush()
+}
+
+// formatConditionStatus renders a condition status like Kubernetes does, e.g. "True" (pure function)
+func formatConditionStatus(status gradv1.ConditionStatus) string {
+	switch status {
+	case gradv1.ConditionStatus_CONDITION_STATUS_TRUE:
+		return "True"
+	case gradv1.ConditionStatus_CONDITION_STATUS_FALSE:
+		return "False"
+	default:
+		return "Unknown"
+	}
+}
+
+// writeEventTable writes events as an indented table, oldest first
+func writeEventTable(out io.Writer, events []*gradv1.RunnerEvent) error {
+	if len(events) == 0 {
+		fmt.Fprintf(out, "  <none>\n")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  TYPE\tREASON\tLAST SEEN\tCOUNT\tMESSAGE")
+	for _, event := range events {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%d\t%s\n", event.Type, event.Reason, formatTimestamp(event.LastTimestamp), event.Count, event.Message)
+	}
+	return w.Flush()
+}
+
+// writeExecutionTable writes executions as an indented table, oldest first
+func writeExecutionTable(out io.Writer, executions []*gradv1.ExecutionRecord) error {
+	if len(executions) == 0 {
+		fmt.Fprintf(out, "  <none>\n")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  STARTED\tDURATION\tEXIT\tCOMMAND")
+	for _, execution := range executions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", formatTimestamp(execution.StartedAt), formatExecutionDuration(execution),
+			formatExecutionExit(execution), formatExecutionCommand(execution))
+	}
+	return w.Flush()
+}
+
+// formatExecutionDuration is how long an execution ran, "-" if that isn't known (pure function)
+func formatExecutionDuration(execution *gradv1.ExecutionRecord) string {
+	if execution.StartedAt == 0 || execution.FinishedAt < execution.StartedAt {
+		return "-"
+	}
+	return (time.Duration(execution.FinishedAt-execution.StartedAt) * time.Second).String()
+}
+
+// formatExecutionExit is an execution's exit code, or "error" if it couldn't run or finish (pure function)
+func formatExecutionExit(execution *gradv1.ExecutionRecord) string {
+	if execution.Error != "" {
+		return "error"
+	}
+	return fmt.Sprint(execution.ExitCode)
+}
+
+// formatExecutionCommand fits an execution's command on one row, marking the bootstrap command
+// and adding why the command failed, if it did (pure function)
+func formatExecutionCommand(execution *gradv1.ExecutionRecord) string {
+	command := formatDescription(execution.Command, describeCommandWidth)
+	if execution.Bootstrap {
+		command = "(bootstrap) " + command
+	}
+	if execution.Error != "" {
+		command += ": " + execution.Error
+	}
+	return command
+}
+
+// runnerDescriptionJSON is the JSON layout of a description, nesting the runner and its events
+// and executions in gractl's JSON format
+type runnerDescriptionJSON struct {
+	Runner     json.RawMessage   `json:"runner"`
+	Events     []json.RawMessage `json:"events"`
+	Executions []json.RawMessage `json:"executions"`
+	Mounts     []mountRecord     `json:"mounts"`
+	Notes      []string          `json:"notes"`
+}
+
+// writeRunnerDescriptionJSON writes a description as indented JSON. Lists are always present,
+// empty if there is nothing in them or they were left out.
+func writeRunnerDescriptionJSON(out io.Writer, desc *runnerDescription) error {
+	runner, err := protoJSONOptions.Marshal(desc.Runner)
+	if err != nil {
+		return err
+	}
+	v := runnerDescriptionJSON{
+		Runner:     runner,
+		Events:     protoList(desc.Events),
+		Executions: protoList(desc.Executions),
+		Mounts:     append([]mountRecord{}, desc.Mounts...),
+		Notes:      append([]string{}, desc.Notes...),
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", data)
+	return err
+}
+
+// describeCmd represents the runners describe command
+var describeCmd = &cobra.Command{
+	Use:   "describe RUNNER_ID",
+	Short: "Show a runner with its activity, workspace mounts, events and recent executions",
+	Long: `Show everything about a runner in one place, like kubectl describe: its status, activity
+(SSH sessions, reservation and resource usage), the provisioning steps it went through, this machine's workspace mounts of it, the
+events of its pod and the commands run on it most recently.
+
+Sections an older grad server doesn't support are left out with a note. With -o json the
+runner and its events, executions and mounts are nested in a single object.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runnerID := resolveRunnerArg(cmd, grpcClient, args[0])
+		executions, _ := cmd.Flags().GetInt("executions")
+
+		desc, err := describeRunner(commandContext(cmd), grpcClient, runnerID, executions)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				fmt.Fprintf(os.Stderr, "Runner %s not found\n", runnerID)
+			} else {
+				fmt.Fprintf(os.Stderr, "Failed to describe runner: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		state, err := defaultMountState()
+		if err == nil {
+			var mounts []mountRecord
+			if mounts, err = state.Live(); err == nil {
+				desc.Mounts = runnerMounts(mounts, runnerID)
+			}
+		}
+		if err != nil {
+			desc.Notes = append(desc.Notes, fmt.Sprintf("Workspace mounts are left out, failed to read them: %v", err))
+		}
+
+		if outputFormat == OutputFormatJSON {
+			err = writeRunnerDescriptionJSON(os.Stdout, desc)
+		} else {
+			err = writeRunnerDescription(os.Stdout, desc)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print runner: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	describeCmd.Flags().Int("executions", 5, "Number of recent executions to show (-1 for all the server keeps)")
+
+	RunnersCmd.AddCommand(describeCmd)
+}
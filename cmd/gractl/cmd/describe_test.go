@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+// useUTC makes timestamps render in UTC for the rest of the test
+func useUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+}
+
+// describeFake returns a fake server with testRunner, reserved and busy, and some of its events
+// and executions
+func describeFake() *gradtest.Fake {
+	runner := testRunner()
+	runner.ReservedUntil = 1700014400
+	runner.Usage = &gradv1.RunnerUsage{CpuMillicores: 250, MemoryBytes: 512 << 20}
+	fake := gradtest.NewFake()
+	fake.AddRunners(runner)
+	fake.SetDiagnostics("runner-1", &gradv1.GetRunnerDiagnosticsResponse{
+		Events: []*gradv1.RunnerEvent{
+			{Type: "Normal", Reason: "Pulled", Message: "Container image already present on machine", Count: 1, LastTimestamp: 1700000030},
+			{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container", Count: 3, LastTimestamp: 1700000200},
+		},
+		Executions: []*gradv1.ExecutionRecord{
+			{Command: "pip install -r requirements.txt", Bootstrap: true, StartedAt: 1700000040, FinishedAt: 1700000052},
+			{Command: "python import.py --date 2023-11-14", ExitCode: 1, StartedAt: 1700000400, FinishedAt: 1700000461},
+			{Command: "sleep 600", Error: "command timed out after 30s", StartedAt: 1700000500, FinishedAt: 1700000530},
+		},
+	})
+	return fake
+}
+
+// testMounts returns a live mount of runner-1 and one of another runner
+func testMounts() []mountRecord {
+	return []mountRecord{
+		{RunnerID: "runner-1", Mountpoint: "/home/me/gra-workspaces/runner-1", LocalPort: 40022, PID: 4242, StartedAt: time.Unix(1700000600, 0)},
+		{RunnerID: "runner-2", Mountpoint: "/home/me/gra-workspaces/runner-2", PID: 4343, StartedAt: time.Unix(1700000700, 0)},
+	}
+}
+
+func TestDescribeRunnerGolden(t *testing.T) {
+	useUTC(t)
+	fake := describeFake()
+	client := gradclient.NewFromServices(fake, fake, fake)
+
+	desc, err := describeRunner(context.Background(), client, "runner-1", 3)
+	if err != nil {
+		t.Fatalf("describeRunner failed: %v", err)
+	}
+	desc.Mounts = runnerMounts(testMounts(), "runner-1")
+
+	var text, jsonOut bytes.Buffer
+	if err := writeRunnerDescription(&text, desc); err != nil {
+		t.Fatalf("writeRunnerDescription failed: %v", err)
+	}
+	assertGolden(t, "describe", text.Bytes())
+	if err := writeRunnerDescriptionJSON(&jsonOut, desc); err != nil {
+		t.Fatalf("writeRunnerDescriptionJSON failed: %v", err)
+	}
+	assertGolden(t, "describe_json", jsonOut.Bytes())
+}
+
+func TestDescribeRunnerUnimplemented(t *testing.T) {
+	useUTC(t)
+	fake := describeFake()
+	fake.FailWith(gradv1.RunnerService_GetRunnerDiagnostics_FullMethodName, status.Error(codes.Unimplemented, "unknown method GetRunnerDiagnostics"))
+	client := gradclient.NewFromServices(fake, fake, fake)
+
+	desc, err := describeRunner(context.Background(), client, "runner-1", 5)
+	if err != nil {
+		t.Fatalf("Expected an older server to still be described, got %v", err)
+	}
+
+	var text, jsonOut bytes.Buffer
+	if err := writeRunnerDescription(&text, desc); err != nil {
+		t.Fatalf("writeRunnerDescription failed: %v", err)
+	}
+	assertGolden(t, "describe_unimplemented", text.Bytes())
+	if err := writeRunnerDescriptionJSON(&jsonOut, desc); err != nil {
+		t.Fatalf("writeRunnerDescriptionJSON failed: %v", err)
+	}
+	assertGolden(t, "describe_unimplemented_json", jsonOut.Bytes())
+}
+
+func TestDescribeRunnerNotFound(t *testing.T) {
+	fake := describeFake()
+	client := gradclient.NewFromServices(fake, fake, fake)
+
+	if _, err := describeRunner(context.Background(), client, "runner-9", 5); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown runner, got %v", err)
+	}
+}
+
+func TestLastExecutions(t *testing.T) {
+	executions := []*gradv1.ExecutionRecord{{Command: "a"}, {Command: "b"}, {Command: "c"}}
+	tests := []struct {
+		n        int
+		expected string
+	}{
+		{2, "bc"},
+		{5, "abc"},
+		{0, ""},
+		{-1, "abc"},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, execution := range lastExecutions(executions, tt.n) {
+			got += execution.Command
+		}
+		if got != tt.expected {
+			t.Errorf("lastExecutions(%d): expected %q, got %q", tt.n, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/strrl/gra/cmd/gractl/client"
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// execAllResult is the outcome of the command on one runner
+type execAllResult struct {
+	RunnerID string
+	// Exit is the EXIT message, nil if the command didn't run to completion
+	Exit *gradv1.ExecuteCommandStreamResponse
+	// Err is set if the command couldn't be run or its stream broke off
+	Err error
+}
+
+// failed reports whether the runner counts against exec-all's exit code
+func (r execAllResult) failed() bool {
+	return r.Err != nil || r.Exit == nil || r.Exit.ExitCode != 0
+}
+
+// execFunc runs the command on one runner, writing its output to stdout and stderr, and
+// returns the EXIT message
+type execFunc func(ctx context.Context, runnerID string, stdout, stderr io.Writer) (*gradv1.ExecuteCommandStreamResponse, error)
+
+// execOutputs returns where the output of a runner goes; done is called once its command finished
+type execOutputs func(runnerID string) (stdout, stderr io.Writer, done func() error, err error)
+
+// fanOutExec runs exec on every runner with at most parallel commands at a time. Results
+// are in the order of runnerIDs, whatever order the commands finish in.
+func fanOutExec(ctx context.Context, runnerIDs []string, parallel int, exec execFunc, outputs execOutputs) []execAllResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]execAllResult, len(runnerIDs))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, runnerID := range runnerIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			results[i] = execAllResult{RunnerID: runnerID}
+			stdout, stderr, done, err := outputs(runnerID)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].Exit, results[i].Err = exec(ctx, runnerID, stdout, stderr)
+			if err := done(); err != nil && results[i].Err == nil {
+				results[i].Err = err
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// prefixWriter writes each line to out behind a prefix. A line is held back until its
+// newline arrives, so lines of runners sharing out don't get mixed up; mu is shared by them.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	line   []byte
+}
+
+// Write writes the complete lines in p; lines may be split across calls
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.line = append(w.line, p...)
+	end := bytes.LastIndexByte(w.line, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+
+	var buf strings.Builder
+	for _, line := range strings.SplitAfter(string(w.line[:end+1]), "\n") {
+		if line != "" {
+			buf.WriteString(w.prefix)
+			buf.WriteString(line)
+		}
+	}
+	w.line = w.line[end+1:]
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := io.WriteString(w.out, buf.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes what's left of an unterminated last line, ending it with a newline
+func (w *prefixWriter) Flush() error {
+	if len(w.line) == 0 {
+		return nil
+	}
+	line := w.prefix + string(w.line) + "\n"
+	w.line = nil
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := io.WriteString(w.out, line)
+	return err
+}
+
+// prefixedOutputs writes the output of each runner to stdout and stderr, every line
+// prefixed with the runner ID padded to the longest of runnerIDs
+func prefixedOutputs(stdout, stderr io.Writer, runnerIDs []string) execOutputs {
+	width := 0
+	for _, runnerID := range runnerIDs {
+		width = max(width, len(runnerID))
+	}
+
+	var mu sync.Mutex
+	return func(runnerID string) (io.Writer, io.Writer, func() error, error) {
+		prefix := fmt.Sprintf("%-*s | ", width, runnerID)
+		out := &prefixWriter{mu: &mu, out: stdout, prefix: prefix}
+		errOut := &prefixWriter{mu: &mu, out: stderr, prefix: prefix}
+		done := func() error {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			return errOut.Flush()
+		}
+		return out, errOut, done, nil
+	}
+}
+
+// fileOutputs writes the output of each runner, stdout and stderr alike, to dir/RUNNER_ID.log
+func fileOutputs(dir string) execOutputs {
+	return func(runnerID string) (io.Writer, io.Writer, func() error, error) {
+		f, err := os.Create(filepath.Join(dir, runnerID+".log"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		// The file is shared by both streams, whose messages arrive one at a time
+		return f, f, f.Close, nil
+	}
+}
+
+// writeExecAllSummary prints the exit code of every runner and how many failed
+func writeExecAllSummary(out io.Writer, results []execAllResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "RUNNER\tEXIT\tNOTE")
+	failed := 0
+	for _, result := range results {
+		if result.failed() {
+			failed++
+		}
+
+		exitCode, note := "-", ""
+		switch {
+		case errors.Is(result.Err, errInterrupted):
+			note = "cancelled before the command exited"
+		case result.Err != nil:
+			note = result.Err.Error()
+		case result.Exit == nil:
+			note = "stream ended without an exit status"
+		default:
+			exitCode = fmt.Sprint(result.Exit.ExitCode)
+			note = exitFailureMessage(result.Exit)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.RunnerID, exitCode, note)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(out, "%d runners: %d succeeded, %d failed\n", len(results), len(results)-failed, failed)
+	return err
+}
+
+// streamExec returns an execFunc running req's command through c.Exec; cancelling ctx fails
+// it with errInterrupted
+func streamExec(c client.ExecuteClient, req *gradv1.ExecuteCommandRequest) execFunc {
+	return func(ctx context.Context, runnerID string, stdout, stderr io.Writer) (*gradv1.ExecuteCommandStreamResponse, error) {
+		exit, err := c.Exec(ctx, &gradv1.ExecuteCommandRequest{
+			RunnerId:   runnerID,
+			Command:    req.Command,
+			Argv:       req.Argv,
+			Shell:      req.Shell,
+			Timeout:    req.Timeout,
+			WorkingDir: req.WorkingDir,
+		}, gradclient.WriteOutput(stdout, stderr))
+		return exit, interruptible(ctx, err)
+	}
+}
+
+// execAllCmd runs a command on every matching runner
+var execAllCmd = &cobra.Command{
+	Use:   "exec-all [flags] -- COMMAND [args...]",
+	Short: "Execute a command in every matching runner",
+	Long: `Execute a command in every runner matching --status, --label and --group, a few at a
+time. Each output line is prefixed with the runner ID, or with --output-dir each runner's
+output goes to DIR/RUNNER_ID.log. A summary of exit codes is printed to stderr at the end;
+gractl exits with 1 if the command failed on any runner. As with exec, the words after --
+reach the program as given.
+
+  gractl runners exec-all -- nvidia-smi
+  gractl runners exec-all --label team=ml --parallel 10 -- rm -rf /tmp/cache
+  gractl runners exec-all --group ci-1234 --output-dir ./logs -- cat /var/log/setup.log`,
+	Args: commandArgs(1, 0),
+	Run: func(cmd *cobra.Command, args []string) {
+		statusStr, _ := cmd.Flags().GetString("status")
+		labels, _ := cmd.Flags().GetStringToString("label")
+		group, _ := cmd.Flags().GetString("group")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		outputDir, _ := cmd.Flags().GetString("output-dir")
+		shell, _ := cmd.Flags().GetString("shell")
+		timeout, _ := cmd.Flags().GetInt32("timeout")
+		workdir, _ := cmd.Flags().GetString("workdir")
+
+		status, err := ParseRunnerStatus(statusStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid status: %v\n", err)
+			os.Exit(1)
+		}
+		if parallel < 1 {
+			fmt.Fprintf(os.Stderr, "--parallel must be at least 1\n")
+			os.Exit(1)
+		}
+
+		// Ctrl+C cancels the commands still running and those not started yet
+		ctx := commandContext(cmd)
+		listCtx, cancel := rpcContext(ctx)
+		defer cancel()
+		runners, err := grpcClient.ListAllRunners(listCtx, &gradv1.ListRunnersRequest{
+			Status: status,
+			Group:  group,
+			Labels: labels,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list runners: %v\n", err)
+			os.Exit(1)
+		}
+		if len(runners) == 0 {
+			fmt.Fprintln(os.Stderr, "No matching runners")
+			return
+		}
+
+		runnerIDs := make([]string, len(runners))
+		for i, runner := range runners {
+			runnerIDs[i] = runner.Id
+		}
+
+		outputs := prefixedOutputs(os.Stdout, os.Stderr, runnerIDs)
+		if outputDir != "" {
+			if err := os.MkdirAll(outputDir, 0o755); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create output directory: %v\n", err)
+				os.Exit(1)
+			}
+			outputs = fileOutputs(outputDir)
+		}
+
+		command, argv := commandFromArgs(args, cmd.ArgsLenAtDash() >= 0)
+		req := &gradv1.ExecuteCommandRequest{
+			Command:    command,
+			Argv:       argv,
+			Shell:      shell,
+			Timeout:    timeout,
+			WorkingDir: workdir,
+		}
+		logger.Debugf("Executing %q on %d runners, %d at a time", commandLine(req), len(runnerIDs), parallel)
+		results := fanOutExec(ctx, runnerIDs, parallel, streamExec(grpcClient, req), outputs)
+
+		writeExecAllSummary(os.Stderr, results)
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "Cancelled")
+			os.Exit(ExitCodeInterrupted)
+		}
+		for _, result := range results {
+			if result.failed() {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	execAllCmd.Flags().StringP("status", "s", "running", "Only run in runners with this status (empty for any)")
+	execAllCmd.Flags().StringToString("label", nil, "Only run in runners with this label, e.g. team=ml (repeatable)")
+	execAllCmd.Flags().StringP("group", "g", "", "Only run in runners of this group")
+	execAllCmd.Flags().IntP("parallel", "p", 5, "Number of runners to run the command in at a time")
+	execAllCmd.Flags().String("output-dir", "", "Write each runner's output to DIR/RUNNER_ID.log instead of prefixing it with the runner ID")
+	execAllCmd.Flags().StringP("shell", "S", "", "Shell to use for command execution (defaults to each runner's default shell)")
+	execAllCmd.Flags().Int32P("timeout", "t", 30, "Command execution timeout in seconds")
+	execAllCmd.Flags().StringP("workdir", "W", "", "Working directory for command execution (defaults to each runner's default workdir)")
+
+	RunnersCmd.AddCommand(execAllCmd)
+}
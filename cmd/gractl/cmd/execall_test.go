@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+func TestFanOutExecOrderAndConcurrency(t *testing.T) {
+	runnerIDs := []string{"runner-a", "runner-b", "runner-c", "runner-d", "runner-e", "runner-f"}
+
+	var running, maxRunning atomic.Int32
+	exec := func(ctx context.Context, runnerID string, stdout, stderr io.Writer) (*gradv1.ExecuteCommandStreamResponse, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+
+		// Earlier runners take longer, so they finish last
+		index := int(runnerID[len(runnerID)-1] - 'a')
+		time.Sleep(time.Duration(len(runnerIDs)-index) * 5 * time.Millisecond)
+
+		if runnerID == "runner-c" {
+			return nil, errors.New("runner not running")
+		}
+		return &gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_EXIT, ExitCode: int32(index)}, nil
+	}
+
+	results := fanOutExec(context.Background(), runnerIDs, 2, exec, prefixedOutputs(io.Discard, io.Discard, runnerIDs))
+
+	var gotIDs []string
+	for _, result := range results {
+		gotIDs = append(gotIDs, result.RunnerID)
+	}
+	if !reflect.DeepEqual(gotIDs, runnerIDs) {
+		t.Errorf("Expected results in runner order %v, got %v", runnerIDs, gotIDs)
+	}
+	if got := maxRunning.Load(); got != 2 {
+		t.Errorf("Expected 2 commands at a time, got %d", got)
+	}
+
+	if results[0].failed() {
+		t.Errorf("Expected runner-a to succeed, got %+v", results[0])
+	}
+	if results[1].Exit.GetExitCode() != 1 || !results[1].failed() {
+		t.Errorf("Expected runner-b to fail with exit code 1, got %+v", results[1])
+	}
+	if results[2].Err == nil || !results[2].failed() {
+		t.Errorf("Expected runner-c to fail with an error, got %+v", results[2])
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	runnerIDs := []string{"runner-a", "r-b"}
+	outputs := prefixedOutputs(&out, &out, runnerIDs)
+	aOut, _, aDone, _ := outputs("runner-a")
+	bOut, bErr, bDone, _ := outputs("r-b")
+
+	// Lines split across writes come out whole, even with another runner writing in between
+	io.WriteString(aOut, "GPU 0: A1")
+	io.WriteString(bOut, "ready\n")
+	io.WriteString(aOut, "00\nGPU 1: A100\nMem")
+	io.WriteString(bErr, "warning: low disk\n")
+	aDone()
+	bDone()
+
+	expected := "r-b      | ready\n" +
+		"runner-a | GPU 0: A100\n" +
+		"runner-a | GPU 1: A100\n" +
+		"r-b      | warning: low disk\n" +
+		"runner-a | Mem\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestPrefixWriterConcurrent(t *testing.T) {
+	var out bytes.Buffer
+	runnerIDs := []string{"runner-a", "runner-b"}
+	outputs := prefixedOutputs(&out, &out, runnerIDs)
+
+	var wg sync.WaitGroup
+	for _, runnerID := range runnerIDs {
+		stdout, _, done, _ := outputs(runnerID)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				fmt.Fprintf(stdout, "line %d of ", i)
+				fmt.Fprintf(stdout, "%s\n", runnerID)
+			}
+			done()
+		}()
+	}
+	wg.Wait()
+
+	lines := bytes.Split(bytes.TrimSuffix(out.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 200 {
+		t.Fatalf("Expected 200 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var prefix, suffix string
+		var i int
+		if _, err := fmt.Sscanf(string(line), "%s | line %d of %s", &prefix, &i, &suffix); err != nil || prefix != suffix {
+			t.Errorf("Expected a whole line of one runner, got %q", line)
+		}
+	}
+}
+
+func TestFileOutputs(t *testing.T) {
+	dir := t.TempDir()
+	exec := func(ctx context.Context, runnerID string, stdout, stderr io.Writer) (*gradv1.ExecuteCommandStreamResponse, error) {
+		fmt.Fprintf(stdout, "hello from %s\n", runnerID)
+		fmt.Fprintln(stderr, "done")
+		return &gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_EXIT}, nil
+	}
+
+	results := fanOutExec(context.Background(), []string{"runner-a", "runner-b"}, 5, exec, fileOutputs(dir))
+	for _, result := range results {
+		if result.failed() {
+			t.Errorf("Expected %s to succeed, got %+v", result.RunnerID, result)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, result.RunnerID+".log"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if expected := "hello from " + result.RunnerID + "\ndone\n"; string(data) != expected {
+			t.Errorf("Expected %q in %s.log, got %q", expected, result.RunnerID, data)
+		}
+	}
+}
+
+func TestWriteExecAllSummary(t *testing.T) {
+	results := []execAllResult{
+		{RunnerID: "runner-a", Exit: &gradv1.ExecuteCommandStreamResponse{}},
+		{RunnerID: "runner-b", Exit: &gradv1.ExecuteCommandStreamResponse{ExitCode: 137, FailureReason: gradv1.ExitReason_EXIT_REASON_SIGNALED, Signal: "SIGKILL", LikelyOom: true}},
+		{RunnerID: "runner-c", Err: errors.New("rpc error: code = FailedPrecondition desc = runner is not running")},
+		{RunnerID: "runner-d"},
+	}
+
+	var out bytes.Buffer
+	if err := writeExecAllSummary(&out, results); err != nil {
+		t.Fatalf("writeExecAllSummary failed: %v", err)
+	}
+
+	expected := "RUNNER     EXIT   NOTE\n" +
+		"runner-a   0      \n" +
+		"runner-b   137    command terminated by SIGKILL (likely OOM)\n" +
+		"runner-c   -      rpc error: code = FailedPrecondition desc = runner is not running\n" +
+		"runner-d   -      stream ended without an exit status\n" +
+		"4 runners: 1 succeeded, 3 failed\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
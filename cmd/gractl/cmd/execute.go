@@ -1,16 +1,14 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"io"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/strrl/gra/cmd/gractl/client"
-	"github.com/strrl/gra/cmd/gractl/config"
 	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/shellquote"
 )
 
 // ExecuteCmd represents the top-level execute command
@@ -20,134 +18,209 @@ var ExecuteCmd = &cobra.Command{
 	Long: `Execute a command with automatic runner provisioning. 
 If no runners are available, a new runner will be created automatically.
 
+Use --runner to run in a specific runner instead, --ephemeral to run in a fresh
+runner that is deleted when the command finishes, or --keep-runner to run in a
+fresh runner that is left running. With --arch only runners of that CPU architecture
+are used or created.
+
 Use -- to separate gractl flags from the command to execute:
   gractl execute -- python script.py --verbose
   gractl execute --timeout 60 -- ls -la /workspace
-  gractl execute --shell sh -- curl -s https://api.example.com`,
-	Args: cobra.MinimumNArgs(1),
+  gractl execute --shell sh -- curl -s https://api.example.com
+  gractl execute --ephemeral -- make test
+
+The words after -- are passed to the program exactly as given: quotes, $VARIABLES, wildcards
+and ; reach it unchanged. To use shell syntax, pass the command line as one word, or without
+--. With --shell none the program is run directly, without any shell:
+  gractl execute -- python -c "print('a b')"
+  gractl execute -- 'ls /workspace/*.csv | wc -l'
+  gractl execute --shell none -- env
+
+A runner created for the command mounts the S3 workspace from the config file, with
+--s3-bucket, --s3-endpoint, --s3-prefix, --s3-region and --read-only overriding it as
+for runners create:
+  gractl execute --ephemeral --s3-bucket other-data -- ls /workspace/dataset
+
+Use --retry to run a flaky command again while it exits non-zero, optionally only
+for the exit codes given with --retry-on:
+  gractl execute --retry 3 --retry-on 128,255 -- git fetch origin
+
+If the output stream breaks while the command runs, gractl reattaches to the command
+unless --no-resume is set, and fails with exit code 75 if it can't.
+
+Use --tee to also save the output to a file while it streams, with the exit code and
+timing in FILE.meta.json, as for runners exec:
+  gractl execute --tee logs/test.log -- make test`,
+	Args: commandArgs(1, 0),
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load configuration from file and environment
-		globalConfig, err := config.LoadConfig()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
-			os.Exit(1)
-		}
-		
 		// Get flags
-		serverAddress, _ := cmd.Flags().GetString("server")
 		shell, _ := cmd.Flags().GetString("shell")
 		timeout, _ := cmd.Flags().GetInt32("timeout")
 		workdir, _ := cmd.Flags().GetString("workdir")
-		
-		// Use server address from config if not provided via flag
-		if serverAddress == "localhost:9090" && globalConfig.Server.Address != "" {
-			serverAddress = globalConfig.Server.Address
+		runnerID, _ := cmd.Flags().GetString("runner")
+		ephemeral, _ := cmd.Flags().GetBool("ephemeral")
+		keepRunner, _ := cmd.Flags().GetBool("keep-runner")
+		arch, _ := cmd.Flags().GetString("arch")
+		noResume, _ := cmd.Flags().GetBool("no-resume")
+		noCorrelationEnv, _ := cmd.Flags().GetBool("no-correlation-env")
+		retry, err := retryPolicyFromFlags(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// Every attempt would leave another runner behind
+		if keepRunner && retry.Retries > 0 {
+			fmt.Fprintf(os.Stderr, "Error: --retry can't be used with --keep-runner\n")
+			os.Exit(1)
 		}
 		
 		// Handle double dash separation for command arguments
-		var command string
+		words := args
 		dashIndex := cmd.ArgsLenAtDash()
 		if dashIndex >= 0 {
 			// Double dash found, use everything after the dash as the command
-			commandArgs := args[dashIndex:]
-			if len(commandArgs) == 0 {
+			words = args[dashIndex:]
+			if len(words) == 0 {
 				fmt.Fprintf(os.Stderr, "Error: No command specified after --\n")
 				os.Exit(1)
 			}
-			command = strings.Join(commandArgs, " ")
-		} else {
-			// No double dash, treat all args as the command (backward compatibility)
-			command = strings.Join(args, " ")
 		}
+		command, argv := commandFromArgs(words, dashIndex >= 0)
 
 		// Initialize client
-		cfg := &client.Config{
-			ServerAddress: serverAddress,
-		}
-		
-		grpcClient, err := client.NewClient(cfg)
+		grpcClient, err := newGRPCClient(cmd, globalConfig)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
-			os.Exit(1)
+			os.Exit(ExitCodeTempFail)
 		}
 		defer grpcClient.Close()
-
-		// Prepare environment variables map with AWS credentials from config
-		envMap := make(map[string]string)
-		if globalConfig.S3.AccessKeyID != "" {
-			envMap["AWS_ACCESS_KEY_ID"] = globalConfig.S3.AccessKeyID
-		}
-		if globalConfig.S3.SecretAccessKey != "" {
-			envMap["AWS_SECRET_ACCESS_KEY"] = globalConfig.S3.SecretAccessKey
-		}
-		if globalConfig.S3.SessionToken != "" {
-			envMap["AWS_SESSION_TOKEN"] = globalConfig.S3.SessionToken
+		if runnerID != "" {
+			runnerID = resolveRunnerArg(cmd, grpcClient, runnerID)
 		}
 
-		// Automatically inject SSH public key if available
-		if sshPublicKey, err := client.GetUserSSHPublicKey(); err == nil && sshPublicKey != "" {
-			envMap["PUBLIC_KEY"] = sshPublicKey
+		// Pass the workspace credentials and, for a runner created for the command, the user's
+		// SSH public key
+		var sshPublicKey string
+		if runnerID == "" {
+			sshPublicKey = sshPublicKeyForRunner(cmd, globalConfig)
 		}
+		workspace := workspaceFromFlags(cmd, globalConfig)
 
 		// Create request
 		req := &gradv1.ExecuteCommandRequest{
+			RunnerId:   runnerID,
 			Command:    command,
+			Argv:       argv,
 			Shell:      shell,
 			Timeout:    timeout,
 			WorkingDir: workdir,
-			Env:        envMap,
+			Env:        gradclient.CredentialEnv(workspace, sshPublicKey),
+			Workspace:  workspace.Proto(),
+			Ephemeral:  ephemeral,
+			KeepRunner: keepRunner,
+			Resumable:  !noResume,
+			Arch:       arch,
+
+			DisableCorrelationEnv: noCorrelationEnv,
 		}
-		
-		// Add workspace configuration if S3 bucket is specified in config
-		if globalConfig.S3.Bucket != "" {
-			req.Workspace = &gradv1.WorkspaceConfig{
-				Bucket:   globalConfig.S3.Bucket,
-				Endpoint: globalConfig.S3.Endpoint,
-				Prefix:   globalConfig.S3.Prefix,
-				Region:   globalConfig.S3.Region,
-				ReadOnly: globalConfig.S3.ReadOnly,
-			}
+		if err := outputLimitFromFlags(cmd, req); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
 
-		// Execute command with streaming
-		stream, err := grpcClient.ExecuteService().ExecuteCommand(context.Background(), req)
+		logger.Debugf("Executing %q with shell %q (timeout %ds)", commandLine(req), shell, timeout)
+
+		tee, err := teeFromFlags(cmd, commandLine(req))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to start command execution: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		var exitCode int32 = 0
-		for {
-			resp, err := stream.Recv()
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				fmt.Fprintf(os.Stderr, "Stream error: %v\n", err)
-				os.Exit(1)
-			}
-
-			switch resp.Type {
-			case gradv1.StreamType_STREAM_TYPE_STDOUT:
-				os.Stdout.Write(resp.Data)
-			case gradv1.StreamType_STREAM_TYPE_STDERR:
-				os.Stderr.Write(resp.Data)
-			case gradv1.StreamType_STREAM_TYPE_EXIT:
-				exitCode = resp.ExitCode
-			}
+		// Execute command with streaming, retrying as the --retry flags allow. With --ephemeral
+		// every attempt runs in a fresh runner. Ctrl+C cancels the stream, and with it the command.
+		ctx := commandContext(cmd)
+		warnDisabledWorkspace(ctx, grpcClient, workspace)
+		flushOutput := setupCommandOutput(cmd)
+		exit, err := runWithRetry(retry, os.Stderr, sleepContext(ctx), func() (*gradv1.ExecuteCommandStreamResponse, error) {
+			defer flushOutput()
+			exit, err := grpcClient.Execute(ctx, req, announceStart(tee.handler(gradclient.WriteOutput(commandStdout, commandStderr))))
+			return exit, interruptible(ctx, err)
+		})
+		if err := tee.finish(exit, err); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save the --tee output: %v\n", err)
 		}
 
-		// Exit with the same code as the command
-		if exitCode != 0 {
-			os.Exit(int(exitCode))
-		}
+		// Exit with the same code as the command, unless --no-exit-code-propagation is set
+		exitWithCommandStatus(cmd, exit, err)
 	},
 }
 
+// commandFromArgs returns the command line or argv to run for the words given on the command
+// line. Words after -- are an argv, so each reaches the program as it is, except a single word,
+// which stays a command line for the shell as before. Other words are joined with spaces, so
+// the shell splits and expands them (pure function)
+func commandFromArgs(words []string, afterDash bool) (string, []string) {
+	if afterDash && len(words) > 1 {
+		return "", words
+	}
+	return strings.Join(words, " "), nil
+}
+
+// commandLine returns the command line of req, quoting its argv if it has one (pure function)
+func commandLine(req *gradv1.ExecuteCommandRequest) string {
+	if len(req.Argv) > 0 {
+		return shellquote.Join(req.Argv)
+	}
+	return req.Command
+}
+
+// largeCommandHint tells what to do with a command too large to send
+const largeCommandHint = "put large scripts in the workspace, e.g. with gractl workspace sync, and run them from there"
+
+// commandArgs returns an Args validator requiring at least n words that rejects a command, made
+// of the words from index from on or after --, larger than grad accepts. cobra validates the
+// words before gractl connects, so no round trip is spent on it.
+func commandArgs(n, from int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MinimumNArgs(n)(cmd, args); err != nil {
+			return err
+		}
+		words := args[from:]
+		dashIndex := cmd.ArgsLenAtDash()
+		if dashIndex >= from {
+			words = args[dashIndex:]
+		}
+		return checkCommandSize(commandFromArgs(words, dashIndex >= 0))
+	}
+}
+
+// checkCommandSize rejects a command larger than grad accepts, saying what to do instead
+// (pure function)
+func checkCommandSize(command string, argv []string) error {
+	if err := gradclient.CheckCommandSize(command, argv); err != nil {
+		return fmt.Errorf("%w; %s", err, largeCommandHint)
+	}
+	return nil
+}
+
 func init() {
 	// Command flags
-	ExecuteCmd.Flags().StringP("server", "", "localhost:9090", "gRPC server address")
-	ExecuteCmd.Flags().StringP("shell", "s", "bash", "Shell to use for command execution")
+	ExecuteCmd.Flags().StringP("shell", "S", "", "Shell to use for command execution (defaults to the runner's default shell)")
 	ExecuteCmd.Flags().Int32P("timeout", "t", 30, "Command execution timeout in seconds")
-	ExecuteCmd.Flags().StringP("workdir", "w", "", "Working directory for command execution")
+	ExecuteCmd.Flags().StringP("workdir", "W", "", "Working directory for command execution")
+	ExecuteCmd.Flags().String("runner", "", "Run in this runner, by ID or as name/NAME, failing if it doesn't exist or isn't running")
+	ExecuteCmd.Flags().Bool("ephemeral", false, "Run in a new runner and delete it when the command finishes")
+	ExecuteCmd.Flags().String("ssh-public-key", "", "Public key file to allow SSH logins to a new runner with (defaults to ~/.ssh/id_*.pub, then the ssh-agent's first key)")
+	ExecuteCmd.Flags().Bool("keep-runner", false, "Run in a new runner and leave it running afterwards")
+	ExecuteCmd.MarkFlagsMutuallyExclusive("runner", "ephemeral", "keep-runner")
+	ExecuteCmd.Flags().String("arch", "", "CPU architecture of the runner to use or create, e.g. arm64 (must be supported by the server)")
+	ExecuteCmd.MarkFlagsMutuallyExclusive("runner", "arch")
+	addWorkspaceFlags(ExecuteCmd)
+	addStripANSIFlag(ExecuteCmd)
+	addExitCodeFlag(ExecuteCmd)
+	addRetryFlags(ExecuteCmd)
+	addOutputLimitFlags(ExecuteCmd)
+	addResumeFlag(ExecuteCmd)
+	addCorrelationEnvFlag(ExecuteCmd)
+	addTeeFlags(ExecuteCmd)
 }
\ No newline at end of file
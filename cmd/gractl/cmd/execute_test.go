@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+func TestCommandFromArgs(t *testing.T) {
+	tests := []struct {
+		name            string
+		words           []string
+		afterDash       bool
+		expectedCommand string
+		expectedArgv    []string
+	}{
+		{"Words without dash are joined", []string{"ls", "-la", "/workspace"}, false, "ls -la /workspace", nil},
+		{"Shell syntax without dash is kept", []string{"ls *.go | wc -l"}, false, "ls *.go | wc -l", nil},
+		{"Words after dash are an argv", []string{"ls", "-la", "/workspace"}, true, "", []string{"ls", "-la", "/workspace"}},
+		{"Quotes after dash are kept", []string{"python", "-c", "print('a b')"}, true, "", []string{"python", "-c", "print('a b')"}},
+		{"Single word after dash is a command line", []string{"ls *.go | wc -l"}, true, "ls *.go | wc -l", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, argv := commandFromArgs(tt.words, tt.afterDash)
+			if command != tt.expectedCommand || !slices.Equal(argv, tt.expectedArgv) {
+				t.Errorf("Expected %q %q, got %q %q", tt.expectedCommand, tt.expectedArgv, command, argv)
+			}
+		})
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	req := &gradv1.ExecuteCommandRequest{Argv: []string{"echo", "$HOME", "a;b"}}
+	if got := commandLine(req); got != "echo '$HOME' 'a;b'" {
+		t.Errorf("Expected the argv quoted, got %q", got)
+	}
+	req = &gradv1.ExecuteCommandRequest{Command: "echo $HOME"}
+	if got := commandLine(req); got != "echo $HOME" {
+		t.Errorf("Expected the command line as it is, got %q", got)
+	}
+}
+
+func TestExecArgvSurvivesRoundTrip(t *testing.T) {
+	// The mock data has output only for the quoted command lines, so a word the shell would
+	// split, expand or end the command at fails the test
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"Quotes", []string{"python", "-c", "print('a b')"}, "a b\n"},
+		{"Expansion, wildcards and semicolons", []string{"echo", "$HOME", "*.go", "a;", "rm", "-rf", "x", "`id`"}, "$HOME *.go a; rm -rf x `id`\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, prefix := range [][]string{{"runners", "exec", "runner-1"}, {"execute", "--runner", "runner-1"}} {
+				args := append(append(slices.Clone(prefix), "--"), tt.args...)
+				stdout, stderr := runGractl(t, args...)
+				if stdout != tt.expected {
+					t.Errorf("Expected gractl %s to print %q, got %q\n%s", prefix[0], tt.expected, stdout, stderr)
+				}
+			}
+		})
+	}
+}
+
+func TestLargeCommandsRejectedBeforeConnecting(t *testing.T) {
+	large := strings.Repeat("x", gradclient.MaxCommandBytes)
+	script := filepath.Join(t.TempDir(), "bootstrap.sh")
+	if err := os.WriteFile(script, []byte(large+"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"runners exec", []string{"runners", "exec", "runner-1", "echo " + large}, "command is 131077 bytes"},
+		{"runners exec argv", []string{"runners", "exec", "runner-1", "--", "echo", large}, "command is 131077 bytes"},
+		{"execute", []string{"execute", "--", "echo", large}, "command is 131077 bytes"},
+		{"exec-all", []string{"runners", "exec-all", "--", "echo " + large}, "command is 131077 bytes"},
+		{"create --bootstrap", []string{"runners", "create", "--bootstrap", "echo " + large}, "bootstrap command is 131077 bytes"},
+		{"create --bootstrap-file", []string{"runners", "create", "--bootstrap-file", script}, "is 131073 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := testRoot()
+			resetFlags(root)
+			root.SetArgs(append([]string{"--mock-data", filepath.Join("testdata", "mock_data.json")}, tt.args...))
+
+			var err error
+			captureOutput(t, func() { err = root.Execute() })
+			if err == nil || !strings.Contains(err.Error(), tt.expected) || !strings.Contains(err.Error(), largeCommandHint) {
+				t.Errorf("Expected %q with a hint, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// Exit codes for failures to run a command at all, following sysexits.h. A command that
+// did run exits gractl with its own exit code.
+const (
+	// ExitCodeUsage means the server rejected the request as invalid
+	ExitCodeUsage = 64
+	// ExitCodeUnavailable means the runner doesn't exist or isn't running
+	ExitCodeUnavailable = 69
+	// ExitCodeInternal means the server failed to run the command
+	ExitCodeInternal = 70
+	// ExitCodeTempFail means the server couldn't be reached or is rate limiting; retrying may help
+	ExitCodeTempFail = 75
+	// ExitCodeInterrupted means gractl was interrupted by Ctrl+C or SIGTERM, 128+SIGINT like a shell
+	ExitCodeInterrupted = 130
+)
+
+// infraExitCode maps an error from the exec RPC to gractl's exit code. A stream that broke
+// and couldn't be reattached to is a temporary failure whatever stopped the reattaching.
+func infraExitCode(err error) int {
+	var resumeErr *gradclient.ResumeError
+	if errors.As(err, &resumeErr) {
+		return ExitCodeTempFail
+	}
+
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return ExitCodeUsage
+	case codes.NotFound, codes.FailedPrecondition:
+		return ExitCodeUnavailable
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.ResourceExhausted:
+		return ExitCodeTempFail
+	default:
+		return ExitCodeInternal
+	}
+}
+
+// describeError renders an RPC error for the terminal. A rejected request's field violations
+// are listed one per line, so all of them can be fixed before retrying.
+func describeError(err error) string {
+	var lines []string
+	for _, detail := range status.Convert(err).Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, violation := range badRequest.FieldViolations {
+			lines = append(lines, fmt.Sprintf("  - %s: %s", violation.Field, violation.Description))
+		}
+	}
+	if len(lines) == 0 {
+		return err.Error()
+	}
+	return "invalid request:\n" + strings.Join(lines, "\n")
+}
+
+// exitFailureMessage explains an EXIT message for a command that didn't exit on its own, or returns ""
+func exitFailureMessage(resp *gradv1.ExecuteCommandStreamResponse) string {
+	switch resp.FailureReason {
+	case gradv1.ExitReason_EXIT_REASON_SIGNALED:
+		if resp.LikelyOom {
+			return fmt.Sprintf("command terminated by %s (likely OOM)", resp.Signal)
+		}
+		return fmt.Sprintf("command terminated by %s", resp.Signal)
+	case gradv1.ExitReason_EXIT_REASON_TIMEOUT:
+		return "command timed out"
+	case gradv1.ExitReason_EXIT_REASON_CANCELLED:
+		return "command cancelled"
+	case gradv1.ExitReason_EXIT_REASON_OUTPUT_LIMIT:
+		return "command terminated for exceeding the output limit"
+	default:
+		return ""
+	}
+}
+
+// truncationWarning explains an EXIT message of a command whose output was cut short, or returns ""
+func truncationWarning(resp *gradv1.ExecuteCommandStreamResponse) string {
+	if !resp.OutputTruncated {
+		return ""
+	}
+	return fmt.Sprintf("WARNING: output truncated at %d bytes; the command wrote %d bytes of stdout and %d bytes of stderr",
+		resp.MaxOutputBytes, resp.StdoutBytes, resp.StderrBytes)
+}
+
+// announceStart wraps handle to log the runner a command runs on once its STARTED message
+// arrives, hidden by --quiet, and its exec ID, shell and working directory with --verbose
+func announceStart(handle gradclient.StreamHandler) gradclient.StreamHandler {
+	return func(resp *gradv1.ExecuteCommandStreamResponse) error {
+		if resp.Type == gradv1.StreamType_STREAM_TYPE_STARTED && resp.RunnerId != "" {
+			logger.Infof("running on %s", resp.RunnerId)
+			logger.Debugf("Execution %s runs with shell %q in %q", resp.ExecId, resp.Shell, resp.WorkingDir)
+			for _, name := range slices.Sorted(maps.Keys(resp.CorrelationEnv)) {
+				logger.Debugf("Command environment has %s=%s", name, resp.CorrelationEnv[name])
+			}
+		}
+		return handle(resp)
+	}
+}
+
+// addOutputLimitFlags adds --max-output-bytes and --truncate-policy to a command that runs a remote command
+func addOutputLimitFlags(cmd *cobra.Command) {
+	cmd.Flags().Int64("max-output-bytes", 0, "Most bytes of stdout and stderr to receive, 0 for the server's limit (which can only be lowered)")
+	cmd.Flags().String("truncate-policy", "kill", "What happens once the output passes --max-output-bytes: kill the command, or discard the rest of its output")
+}
+
+// outputLimitFromFlags reads the flags added by addOutputLimitFlags into req
+func outputLimitFromFlags(cmd *cobra.Command, req *gradv1.ExecuteCommandRequest) error {
+	maxBytes, _ := cmd.Flags().GetInt64("max-output-bytes")
+	policy, _ := cmd.Flags().GetString("truncate-policy")
+	if maxBytes < 0 {
+		return fmt.Errorf("--max-output-bytes must not be negative, got %d", maxBytes)
+	}
+	switch policy {
+	case "kill":
+		req.TruncatePolicy = gradv1.TruncatePolicy_TRUNCATE_POLICY_KILL
+	case "discard":
+		req.TruncatePolicy = gradv1.TruncatePolicy_TRUNCATE_POLICY_DISCARD
+	default:
+		return fmt.Errorf("--truncate-policy must be kill or discard, got %q", policy)
+	}
+	req.MaxOutputBytes = maxBytes
+	return nil
+}
+
+// addExitCodeFlag adds --no-exit-code-propagation to a command that runs a remote command
+func addExitCodeFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-exit-code-propagation", false, "Exit 0 once the command has run and report its exit code in a final \"grad-exit-code: N\" line on stderr, or an exit object with -o json")
+}
+
+// exitStatusLine is the final line reporting the command's exit code with --no-exit-code-propagation
+type exitStatusLine struct {
+	Type     string `json:"type"`
+	ExitCode int32  `json:"exit_code"`
+}
+
+// finishCommand reports how a streamed command ended and returns gractl's exit code: the
+// command's own, or with propagate unset 0 after writing the exit code to stdout in JSON mode
+// and to stderr otherwise. A failed stream or one without an exit status returns an
+// infrastructure exit code either way, even if the command had reported 0, and one cancelled
+// by Ctrl+C ExitCodeInterrupted.
+func finishCommand(stdout, stderr io.Writer, format OutputFormat, exit *gradv1.ExecuteCommandStreamResponse, streamErr error, propagate bool) int {
+	if errors.Is(streamErr, errInterrupted) {
+		fmt.Fprintf(stderr, "Cancelled: the command was stopped before it exited, so its output is incomplete (exit code %d)\n", ExitCodeInterrupted)
+		return ExitCodeInterrupted
+	}
+	if streamErr != nil {
+		fmt.Fprintf(stderr, "Command execution failed: %s\n", describeError(streamErr))
+		return infraExitCode(streamErr)
+	}
+	if exit == nil {
+		fmt.Fprintln(stderr, "Stream ended without an exit status")
+		return ExitCodeInternal
+	}
+	if warning := truncationWarning(exit); warning != "" {
+		fmt.Fprintln(stderr, warning)
+	}
+	if message := exitFailureMessage(exit); message != "" {
+		fmt.Fprintln(stderr, message)
+	}
+	if propagate {
+		return int(exit.ExitCode)
+	}
+
+	if format == OutputFormatJSON {
+		line, err := json.Marshal(exitStatusLine{Type: "exit", ExitCode: exit.ExitCode})
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to print exit status: %v\n", err)
+			return ExitCodeInternal
+		}
+		if _, err := fmt.Fprintf(stdout, "%s\n", line); err != nil {
+			fmt.Fprintf(stderr, "Failed to print exit status: %v\n", err)
+			return ExitCodeInternal
+		}
+		return 0
+	}
+	fmt.Fprintf(stderr, "grad-exit-code: %d\n", exit.ExitCode)
+	return 0
+}
+
+// exitWithCommandStatus exits as finishCommand decides, honoring cmd's --no-exit-code-propagation
+func exitWithCommandStatus(cmd *cobra.Command, exit *gradv1.ExecuteCommandStreamResponse, streamErr error) {
+	noPropagation, _ := cmd.Flags().GetBool("no-exit-code-propagation")
+	if code := finishCommand(os.Stdout, os.Stderr, outputFormat, exit, streamErr, !noPropagation); code != 0 {
+		os.Exit(code)
+	}
+}
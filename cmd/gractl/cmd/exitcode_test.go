@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+func TestInfraExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"Invalid request", status.Error(codes.InvalidArgument, "invalid request"), ExitCodeUsage},
+		{"Runner not found", status.Error(codes.NotFound, "runner not found"), ExitCodeUnavailable},
+		{"Runner not running", status.Error(codes.FailedPrecondition, "runner is not running"), ExitCodeUnavailable},
+		{"Server unreachable", status.Error(codes.Unavailable, "connection refused"), ExitCodeTempFail},
+		{"Rate limited", status.Error(codes.ResourceExhausted, "rate limit exceeded"), ExitCodeTempFail},
+		{"Exec failure", status.Error(codes.Internal, "command execution failed"), ExitCodeInternal},
+		{"Non-gRPC error", errors.New("boom"), ExitCodeInternal},
+		{"Reattaching failed", &gradclient.ResumeError{ExecID: "exec-1", StreamErr: status.Error(codes.Unavailable, "connection reset"), Err: status.Error(codes.NotFound, "execution exec-1 not found")}, ExitCodeTempFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := infraExitCode(tt.err); got != tt.expected {
+				t.Errorf("Expected exit code %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExitFailureMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		exit     *gradv1.ExecuteCommandStreamResponse
+		expected string
+	}{
+		{"Normal exit", &gradv1.ExecuteCommandStreamResponse{ExitCode: 1, FailureReason: gradv1.ExitReason_EXIT_REASON_EXITED}, ""},
+		{"Old server", &gradv1.ExecuteCommandStreamResponse{ExitCode: 1}, ""},
+		{"Signal", &gradv1.ExecuteCommandStreamResponse{ExitCode: 137, FailureReason: gradv1.ExitReason_EXIT_REASON_SIGNALED, Signal: "SIGKILL"}, "command terminated by SIGKILL"},
+		{"OOM", &gradv1.ExecuteCommandStreamResponse{ExitCode: 137, FailureReason: gradv1.ExitReason_EXIT_REASON_SIGNALED, Signal: "SIGKILL", LikelyOom: true}, "command terminated by SIGKILL (likely OOM)"},
+		{"Timeout", &gradv1.ExecuteCommandStreamResponse{ExitCode: 124, FailureReason: gradv1.ExitReason_EXIT_REASON_TIMEOUT}, "command timed out"},
+		{"Cancelled", &gradv1.ExecuteCommandStreamResponse{ExitCode: 130, FailureReason: gradv1.ExitReason_EXIT_REASON_CANCELLED}, "command cancelled"},
+		{"Output limit", &gradv1.ExecuteCommandStreamResponse{ExitCode: 141, FailureReason: gradv1.ExitReason_EXIT_REASON_OUTPUT_LIMIT}, "command terminated for exceeding the output limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitFailureMessage(tt.exit); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDescribeError(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "invalid request: name: too long; timeout: must be non-negative").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "name", Description: "too long"},
+			{Field: "timeout", Description: "must be non-negative"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add details: %v", err)
+	}
+
+	expected := "invalid request:\n  - name: too long\n  - timeout: must be non-negative"
+	if got := describeError(st.Err()); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got := describeError(fmt.Errorf("failed to execute command: %w", st.Err())); got != expected {
+		t.Errorf("Expected the violations of a wrapped error, got %q", got)
+	}
+
+	plain := status.Error(codes.NotFound, "runner not found")
+	if got := describeError(plain); got != plain.Error() {
+		t.Errorf("Expected errors without violations as is, got %q", got)
+	}
+}
+
+func TestFinishCommand(t *testing.T) {
+	exited := func(code int32) *gradv1.ExecuteCommandStreamResponse {
+		return &gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_EXIT, ExitCode: code, FailureReason: gradv1.ExitReason_EXIT_REASON_EXITED}
+	}
+	timedOut := &gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_EXIT, ExitCode: 124, FailureReason: gradv1.ExitReason_EXIT_REASON_TIMEOUT}
+	streamErr := status.Error(codes.Unavailable, "connection reset")
+	truncated := &gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_EXIT, ExitCode: 141, FailureReason: gradv1.ExitReason_EXIT_REASON_OUTPUT_LIMIT,
+		OutputTruncated: true, MaxOutputBytes: 1024, StdoutBytes: 4096, StderrBytes: 12}
+	truncationWarning := "WARNING: output truncated at 1024 bytes; the command wrote 4096 bytes of stdout and 12 bytes of stderr\n"
+
+	tests := []struct {
+		name           string
+		format         OutputFormat
+		exit           *gradv1.ExecuteCommandStreamResponse
+		streamErr      error
+		propagate      bool
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{name: "Success", format: OutputFormatTable, exit: exited(0), propagate: true},
+		{name: "Propagates failure", format: OutputFormatTable, exit: exited(3), propagate: true, expectedCode: 3},
+		{name: "Explains timeout", format: OutputFormatTable, exit: timedOut, propagate: true, expectedCode: 124, expectedStderr: "command timed out\n"},
+		{name: "Truncated", format: OutputFormatTable, exit: truncated, propagate: true, expectedCode: 141, expectedStderr: truncationWarning + "command terminated for exceeding the output limit\n"},
+		{name: "Truncated in JSON mode", format: OutputFormatJSON, exit: truncated, expectedStdout: `{"type":"exit","exit_code":141}` + "\n", expectedStderr: truncationWarning + "command terminated for exceeding the output limit\n"},
+		{name: "Status line", format: OutputFormatTable, exit: exited(3), expectedStderr: "grad-exit-code: 3\n"},
+		{name: "Status line after timeout", format: OutputFormatTable, exit: timedOut, expectedStderr: "command timed out\ngrad-exit-code: 124\n"},
+		{name: "Status line in wide mode", format: OutputFormatWide, exit: exited(0), expectedStderr: "grad-exit-code: 0\n"},
+		{name: "Exit object", format: OutputFormatJSON, exit: exited(3), expectedStdout: `{"type":"exit","exit_code":3}` + "\n"},
+		{name: "Stream error", format: OutputFormatTable, streamErr: streamErr, propagate: true, expectedCode: ExitCodeTempFail, expectedStderr: "Command execution failed: rpc error: code = Unavailable desc = connection reset\n"},
+		{name: "Stream error after exit 0", format: OutputFormatTable, exit: exited(0), streamErr: streamErr, propagate: true, expectedCode: ExitCodeTempFail, expectedStderr: "Command execution failed: rpc error: code = Unavailable desc = connection reset\n"},
+		{name: "Stream error without propagation", format: OutputFormatTable, exit: exited(0), streamErr: streamErr, expectedCode: ExitCodeTempFail, expectedStderr: "Command execution failed: rpc error: code = Unavailable desc = connection reset\n"},
+		{name: "Stream error without propagation in JSON mode", format: OutputFormatJSON, streamErr: streamErr, expectedCode: ExitCodeTempFail, expectedStderr: "Command execution failed: rpc error: code = Unavailable desc = connection reset\n"},
+		{name: "Interrupted", format: OutputFormatJSON, streamErr: fmt.Errorf("%w: %w", errInterrupted, status.Error(codes.Canceled, "context canceled")), expectedCode: ExitCodeInterrupted, expectedStderr: "Cancelled: the command was stopped before it exited, so its output is incomplete (exit code 130)\n"},
+		{name: "No exit status", format: OutputFormatTable, expectedCode: ExitCodeInternal, expectedStderr: "Stream ended without an exit status\n"},
+		{name: "No exit status without propagation", format: OutputFormatJSON, expectedCode: ExitCodeInternal, expectedStderr: "Stream ended without an exit status\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := finishCommand(&stdout, &stderr, tt.format, tt.exit, tt.streamErr, tt.propagate)
+			if code != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d", tt.expectedCode, code)
+			}
+			if stdout.String() != tt.expectedStdout {
+				t.Errorf("Expected stdout %q, got %q", tt.expectedStdout, stdout.String())
+			}
+			if stderr.String() != tt.expectedStderr {
+				t.Errorf("Expected stderr %q, got %q", tt.expectedStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestOutputLimitFromFlags(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		expectMax    int64
+		expectPolicy gradv1.TruncatePolicy
+		expectErr    bool
+	}{
+		{"Defaults", nil, 0, gradv1.TruncatePolicy_TRUNCATE_POLICY_KILL, false},
+		{"Discard", []string{"--max-output-bytes", "1048576", "--truncate-policy", "discard"}, 1048576, gradv1.TruncatePolicy_TRUNCATE_POLICY_DISCARD, false},
+		{"Negative limit", []string{"--max-output-bytes", "-1"}, 0, 0, true},
+		{"Unknown policy", []string{"--truncate-policy", "drop"}, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addOutputLimitFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("Failed to parse flags: %v", err)
+			}
+
+			req := &gradv1.ExecuteCommandRequest{}
+			err := outputLimitFromFlags(cmd, req)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %t, got %v", tt.expectErr, err)
+			}
+			if !tt.expectErr && (req.MaxOutputBytes != tt.expectMax || req.TruncatePolicy != tt.expectPolicy) {
+				t.Errorf("Expected %d (%s), got %d (%s)", tt.expectMax, tt.expectPolicy, req.MaxOutputBytes, req.TruncatePolicy)
+			}
+		})
+	}
+}
+
+func TestAnnounceStart(t *testing.T) {
+	tests := []struct {
+		name           string
+		verbosity      Verbosity
+		expectedStderr string
+	}{
+		{"Default", VerbosityNormal, "running on runner-42\n"},
+		{"Quiet", VerbosityQuiet, ""},
+		{"Verbose", VerbosityVerbose, "running on runner-42\ndebug: Execution exec-1 runs with shell \"bash\" in \"/workspace\"\n"},
+	}
+
+	origLogger := logger
+	defer func() { logger = origLogger }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr, stdout bytes.Buffer
+			logger = newLogger(&stderr, tt.verbosity, false)
+
+			handle := announceStart(gradclient.WriteOutput(&stdout, &stdout))
+			for _, resp := range []*gradv1.ExecuteCommandStreamResponse{
+				{Type: gradv1.StreamType_STREAM_TYPE_STARTED, ExecId: "exec-1", RunnerId: "runner-42", Shell: "bash", WorkingDir: "/workspace"},
+				{Type: gradv1.StreamType_STREAM_TYPE_STDOUT, Data: []byte("hello\n")},
+			} {
+				if err := handle(resp); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+			}
+
+			if stderr.String() != tt.expectedStderr {
+				t.Errorf("Expected stderr %q, got %q", tt.expectedStderr, stderr.String())
+			}
+			if stdout.String() != "hello\n" {
+				t.Errorf("Expected the output passed on, got %q", stdout.String())
+			}
+		})
+	}
+}
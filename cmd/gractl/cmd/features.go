@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// featureS3Workspace is how the server names S3 workspaces in its disabled features
+const featureS3Workspace = "s3-workspace"
+
+// serverInfoClient is the part of the grad client that reports the server's disabled features
+type serverInfoClient interface {
+	GetServerInfo(ctx context.Context) (*gradv1.GetServerInfoResponse, error)
+}
+
+// warnDisabledWorkspace warns before a request with an S3 workspace is sent to a server that
+// has them disabled, as air-gapped servers do, since it will refuse the request. The workspace
+// may come from the config file rather than a flag, which the warning points out. Failing to
+// look up the server info only skips the warning: the server still refuses the request.
+func warnDisabledWorkspace(ctx context.Context, c serverInfoClient, workspace gradclient.S3Workspace) {
+	if workspace.Bucket == "" {
+		return
+	}
+	rpcCtx, cancel := rpcContext(ctx)
+	defer cancel()
+	info, err := c.GetServerInfo(rpcCtx)
+	if err != nil {
+		logger.Debugf("Failed to get server info: %v", err)
+		return
+	}
+	if slices.Contains(info.DisabledFeatures, featureS3Workspace) {
+		logger.Warnf("The server has S3 workspaces disabled, so it will refuse the workspace of bucket %s; remove --s3-bucket or the s3 section of the config file", workspace.Bucket)
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+func TestWarnDisabledWorkspace(t *testing.T) {
+	origLogger := logger
+	defer func() { logger = origLogger }()
+
+	fake := gradtest.NewFake()
+	c := gradclient.NewFromServices(fake, fake, fake)
+	datasets := gradclient.S3Workspace{Bucket: "datasets"}
+
+	tests := []struct {
+		name      string
+		disabled  []string
+		workspace gradclient.S3Workspace
+		warned    bool
+	}{
+		{"Disabled", []string{"s3-workspace", "tracing"}, datasets, true},
+		{"OtherFeaturesDisabled", []string{"tracing"}, datasets, false},
+		{"NoWorkspace", []string{"s3-workspace"}, gradclient.S3Workspace{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.SetServerInfo(&gradv1.GetServerInfoResponse{DisabledFeatures: tt.disabled})
+			var stderr bytes.Buffer
+			logger = newLogger(&stderr, VerbosityNormal, false)
+
+			warnDisabledWorkspace(context.Background(), c, tt.workspace)
+			warned := strings.Contains(stderr.String(), "S3 workspaces disabled") && strings.Contains(stderr.String(), "datasets")
+			if warned != tt.warned {
+				t.Errorf("Expected warned %v, got %q", tt.warned, stderr.String())
+			}
+		})
+	}
+}
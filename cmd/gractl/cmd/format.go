@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/strrl/gra/cmd/gractl/config"
 	gradv1 "github.com/strrl/gra/gen/grad/v1"
 )
 
@@ -16,16 +23,84 @@ type OutputFormat string
 
 const (
 	OutputFormatTable OutputFormat = "table"
-	OutputFormatJSON  OutputFormat = "json"
+	// OutputFormatWide is the table format with extra columns, e.g. runner descriptions
+	OutputFormatWide OutputFormat = "wide"
+	OutputFormatJSON OutputFormat = "json"
 )
 
+// descriptionColumnWidth is how many characters of a description the wide table shows
+const descriptionColumnWidth = 40
+
+// errorSuffixWidth is how many characters of an error message the table adds to the status
+const errorSuffixWidth = 40
+
+// staleImageMarker follows the table status of a runner with a stale image
+const staleImageMarker = "*"
+
 var outputFormat OutputFormat = OutputFormatTable
 
+// legacyJSON selects the pre-protojson JSON output (Go field names, numeric enums).
+// Deprecated: kept for one release to give scripts time to migrate.
+var legacyJSON bool
+
+// protoJSONOptions defines the JSON schema of gractl output: proto field names,
+// enums as strings and zero values always present
+var protoJSONOptions = protojson.MarshalOptions{
+	UseProtoNames:   true,
+	UseEnumNumbers:  false,
+	EmitUnpopulated: true,
+}
+
+// parseOutputFormat parses the --output flag (pure function)
+func parseOutputFormat(format string) (OutputFormat, error) {
+	switch OutputFormat(format) {
+	case OutputFormatTable, OutputFormatWide, OutputFormatJSON:
+		return OutputFormat(format), nil
+	default:
+		return "", fmt.Errorf("invalid output format: %s (supported: table, wide, json)", format)
+	}
+}
+
+// resolveOutputFormat picks the output format: the --output flag if it was passed, else
+// output.format from the config, with output.wide_columns turning a table into a wide one.
+// An invalid configured format says where it was set (pure function).
+func resolveOutputFormat(flagValue string, flagChanged bool, output config.OutputConfig) (OutputFormat, error) {
+	if flagChanged {
+		return parseOutputFormat(flagValue)
+	}
+	if output.Format == "" {
+		output.Format = string(OutputFormatTable)
+	}
+	format, err := parseOutputFormat(output.Format)
+	if err != nil {
+		return "", fmt.Errorf("%w in output.format from %s; fix it or pass --output", err, output.FormatSource)
+	}
+	if format == OutputFormatTable && output.WideColumns {
+		return OutputFormatWide, nil
+	}
+	return format, nil
+}
+
+// resolveColorMode parses output.color from the config, saying where an invalid one was set (pure function)
+func resolveColorMode(output config.OutputConfig) (ColorMode, error) {
+	if output.Color == "" {
+		return ColorAuto, nil
+	}
+	mode, err := parseColorMode(output.Color)
+	if err != nil {
+		return "", fmt.Errorf("%w in output.color from %s", err, output.ColorSource)
+	}
+	return mode, nil
+}
+
 // PrintRunnerList prints a list of runners in the specified format
-func PrintRunnerList(runners []*gradv1.Runner) error {
+func PrintRunnerList(runners []*gradv1.Runner, total int32) error {
 	switch outputFormat {
 	case OutputFormatJSON:
-		return printJSON(runners)
+		if legacyJSON {
+			return printJSON(runners)
+		}
+		return printProtoJSON(&gradv1.ListRunnersResponse{Runners: runners, Total: total})
 	default:
 		return printRunnerTable(runners)
 	}
@@ -35,28 +110,283 @@ func PrintRunnerList(runners []*gradv1.Runner) error {
 func PrintRunner(runner *gradv1.Runner) error {
 	switch outputFormat {
 	case OutputFormatJSON:
-		return printJSON(runner)
+		if legacyJSON {
+			return printJSON(runner)
+		}
+		return printProtoJSON(runner)
+	default:
+		return writeRunnerDetails(os.Stdout, runner)
+	}
+}
+
+// PrintCreatedRunners prints newly created runners: with --quiet only their IDs, one per line,
+// otherwise like get or list do, followed in the table formats by hints on what to do next.
+// The hints go to stderr, so stdout only carries the runners.
+func PrintCreatedRunners(runners []*gradv1.Runner) error {
+	if quietFlag {
+		return writeRunnerIDs(os.Stdout, runners)
+	}
+
+	var err error
+	if len(runners) == 1 {
+		err = PrintRunner(runners[0])
+	} else {
+		err = PrintRunnerList(runners, int32(len(runners)))
+	}
+	if err != nil || outputFormat == OutputFormatJSON || !logger.Enabled(VerbosityNormal) {
+		return err
+	}
+	return writeNextSteps(logger.Writer(), runners)
+}
+
+// writeRunnerIDs prints the ID of every runner on a line of its own
+func writeRunnerIDs(out io.Writer, runners []*gradv1.Runner) error {
+	for _, runner := range runners {
+		if _, err := fmt.Fprintln(out, runner.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextSteps returns commands to try on newly created runners, each with what it does
+// (pure function)
+func nextSteps(runners []*gradv1.Runner) [][2]string {
+	ids := make([]string, len(runners))
+	running := true
+	for i, runner := range runners {
+		ids[i] = runner.Id
+		running = running && runner.Status == gradv1.RunnerStatus_RUNNER_STATUS_RUNNING
+	}
+	idList := strings.Join(ids, " ")
+
+	var steps [][2]string
+	if !running && len(runners) == 1 {
+		steps = append(steps, [2]string{"gractl runners wait " + idList + " --for=running", "wait until it is ready"})
+	} else if !running {
+		steps = append(steps, [2]string{"gractl runners wait " + idList + " --for=running --all", "wait until they are ready"})
+	}
+
+	if len(runners) > 1 {
+		if group := runners[0].Group; group != "" {
+			return append(steps,
+				[2]string{"gractl runners exec-all --group " + group + " -- uname -a", "run a command in each of them"},
+				[2]string{"gractl runners delete --group " + group, "delete them when done"})
+		}
+		return append(steps,
+			[2]string{"gractl runners exec " + ids[0] + " -- uname -a", "run a command in one of them"},
+			[2]string{"gractl runners delete " + idList, "delete them when done"})
+	}
+
+	runner := runners[0]
+	steps = append(steps,
+		[2]string{"gractl runners exec " + runner.Id + " -- uname -a", "run a command"},
+		[2]string{"gractl workspace sync " + runner.Id, "mount its workspace under ./runners/" + runner.Id})
+	if runner.Ssh != nil && runner.Ssh.Host != "" {
+		steps = append(steps, [2]string{fmt.Sprintf("ssh -p %d %s@%s", runner.Ssh.Port, runner.Ssh.Username, runner.Ssh.Host), "log in from inside the cluster"})
+	}
+	return append(steps,
+		[2]string{"gractl runners ssh-keys add " + runner.Id + " KEY.pub", "let another SSH key log in"},
+		[2]string{"gractl runners delete " + runner.Id, "delete it when done"})
+}
+
+// writeNextSteps prints the commands of nextSteps as a "Next steps" block
+func writeNextSteps(out io.Writer, runners []*gradv1.Runner) error {
+	if len(runners) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nNext steps:")
+	for _, step := range nextSteps(runners) {
+		fmt.Fprintf(w, "  %s\t# %s\n", step[0], step[1])
+	}
+	return w.Flush()
+}
+
+// PrintRunnerStats prints runner counts in the specified format
+func PrintRunnerStats(stats *gradv1.GetRunnerStatsResponse) error {
+	switch outputFormat {
+	case OutputFormatJSON:
+		return printProtoJSON(stats)
+	default:
+		return writeRunnerStats(os.Stdout, stats)
+	}
+}
+
+// PrintRunnerUsage prints live runner usage in the specified format
+func PrintRunnerUsage(usages []*gradv1.RunnerUsage, containers bool) error {
+	switch outputFormat {
+	case OutputFormatJSON:
+		return printProtoJSON(&gradv1.ListRunnerMetricsResponse{Usage: usages})
+	default:
+		return writeRunnerUsageTable(os.Stdout, usages, containers)
+	}
+}
+
+// PrintDeleteRunnerResponse prints the outcome of a runner deletion in the specified format
+func PrintDeleteRunnerResponse(resp *gradv1.DeleteRunnerResponse) error {
+	switch outputFormat {
+	case OutputFormatJSON:
+		return printProtoJSON(resp)
+	default:
+		return writeDeleteRunnerResponse(os.Stdout, resp)
+	}
+}
+
+// PrintDeleteRunnerGroupResponse prints the outcome of a group deletion in the specified format
+func PrintDeleteRunnerGroupResponse(group string, resp *gradv1.DeleteRunnerGroupResponse) error {
+	switch outputFormat {
+	case OutputFormatJSON:
+		return printProtoJSON(resp)
 	default:
-		return printRunnerDetails(runner)
+		return writeDeleteRunnerGroupResponse(os.Stdout, group, resp)
+	}
+}
+
+// PrintSSHKeys prints the keys added to a runner in the specified format
+func PrintSSHKeys(keys []*gradv1.SSHKey) error {
+	switch outputFormat {
+	case OutputFormatJSON:
+		return printProtoJSON(&gradv1.ListSSHKeysResponse{Keys: keys})
+	default:
+		return writeSSHKeyTable(os.Stdout, keys)
+	}
+}
+
+// writeDeleteRunnerGroupResponse prints one line per runner deleted or left behind, then a summary
+func writeDeleteRunnerGroupResponse(out io.Writer, group string, resp *gradv1.DeleteRunnerGroupResponse) error {
+	total := len(resp.DeletedRunnerIds) + len(resp.Failed)
+	if total == 0 {
+		fmt.Fprintf(out, "No runners found in group %s\n", group)
+		return nil
+	}
+	for _, runnerID := range resp.DeletedRunnerIds {
+		fmt.Fprintf(out, "  deleted  %s\n", runnerID)
+	}
+	for _, failure := range resp.Failed {
+		fmt.Fprintf(out, "  failed   %s: %s\n", failure.RunnerId, failure.Error)
+	}
+	fmt.Fprintf(out, "Deleted %d out of %d runners in group %s\n", len(resp.DeletedRunnerIds), total, group)
+	return nil
+}
+
+// writeDeleteRunnerResponse prints the message followed by one line per object cleaned up, left
+// behind or, after waiting, still terminating
+func writeDeleteRunnerResponse(out io.Writer, resp *gradv1.DeleteRunnerResponse) error {
+	fmt.Fprintln(out, resp.Message)
+	for _, resource := range resp.DeletedResources {
+		fmt.Fprintf(out, "  deleted  %s\n", resource)
+	}
+	for _, failure := range resp.FailedResources {
+		fmt.Fprintf(out, "  failed   %s\n", failure)
+	}
+	for _, resource := range resp.RemainingResources {
+		fmt.Fprintf(out, "  pending  %s\n", resource)
+	}
+	return nil
+}
+
+// writeRunnerUsageTable prints one row per runner in the order given, like kubectl top;
+// containers adds an indented row per container below each runner
+func writeRunnerUsageTable(out io.Writer, usages []*gradv1.RunnerUsage, containers bool) error {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "RUNNER\tCPU(cores)\tMEMORY(bytes)\tSTDOUT\tSTDERR")
+	for _, usage := range usages {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", usage.RunnerId, formatUsageCPU(usage.CpuMillicores), formatUsageMemory(usage.MemoryBytes),
+			formatOutputBytes(usage.StdoutBytes), formatOutputBytes(usage.StderrBytes))
+		if containers {
+			for _, container := range usage.Containers {
+				fmt.Fprintf(w, "  %s\t%s\t%s\n", container.Name, formatUsageCPU(container.CpuMillicores), formatUsageMemory(container.MemoryBytes))
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// writeSSHKeyTable prints one row per key, oldest first
+func writeSSHKeyTable(out io.Writer, keys []*gradv1.SSHKey) error {
+	if len(keys) == 0 {
+		fmt.Fprintln(out, "No SSH keys added")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "FINGERPRINT\tTYPE\tCOMMENT")
+	for _, key := range keys {
+		comment := key.Comment
+		if comment == "" {
+			comment = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key.Fingerprint, key.Type, comment)
+	}
+	return w.Flush()
+}
+
+// writeRunnerStats prints the total and one line per grouping, largest groups first
+func writeRunnerStats(out io.Writer, stats *gradv1.GetRunnerStatsResponse) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TOTAL\t%d\n", stats.Total)
+	fmt.Fprintf(w, "STATUS\t%s\n", formatCounts(stats.ByStatus))
+	fmt.Fprintf(w, "PRESET\t%s\n", formatCounts(stats.ByPreset))
+	fmt.Fprintf(w, "OWNER\t%s\n", formatCounts(stats.ByOwner))
+	return w.Flush()
+}
+
+// formatCounts renders counts as "key=n" pairs sorted by count, then key (pure function)
+func formatCounts(counts map[string]int32) string {
+	if len(counts) == 0 {
+		return "-"
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
 	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		label := key
+		if label == "" {
+			label = "<none>"
+		}
+		pairs[i] = fmt.Sprintf("%s=%d", label, counts[key])
+	}
+	return strings.Join(pairs, " ")
 }
 
-// PrintStreamData prints streaming command output
-func PrintStreamData(streamType gradv1.StreamType, data []byte) error {
+// PrintStreamResponse prints a streaming command response. In JSON mode every
+// response, including the exit message, is printed as one JSON object per line.
+func PrintStreamResponse(resp *gradv1.ExecuteCommandStreamResponse) error {
 	switch outputFormat {
 	case OutputFormatJSON:
-		streamData := map[string]interface{}{
-			"type": streamType.String(),
-			"data": string(data),
+		if legacyJSON {
+			if resp.Type == gradv1.StreamType_STREAM_TYPE_EXIT || resp.Type == gradv1.StreamType_STREAM_TYPE_STARTED {
+				return nil
+			}
+			streamData := map[string]interface{}{
+				"type": resp.Type.String(),
+				"data": string(resp.Data),
+			}
+			return printJSON(streamData)
+		}
+		data, err := marshalProtoJSONLine(resp)
+		if err != nil {
+			return err
 		}
-		return printJSON(streamData)
+		_, err = os.Stdout.Write(data)
+		return err
 	default:
-		switch streamType {
+		switch resp.Type {
 		case gradv1.StreamType_STREAM_TYPE_STDOUT:
-			_, err := os.Stdout.Write(data)
+			_, err := commandStdout.Write(resp.Data)
 			return err
 		case gradv1.StreamType_STREAM_TYPE_STDERR:
-			_, err := os.Stderr.Write(data)
+			_, err := commandStderr.Write(resp.Data)
 			return err
 		}
 		return nil
@@ -80,58 +410,213 @@ func printJSON(v interface{}) error {
 	return encoder.Encode(v)
 }
 
+func printProtoJSON(m proto.Message) error {
+	data, err := marshalProtoJSON(m)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// marshalProtoJSON renders a message as indented JSON. protojson deliberately
+// randomizes its whitespace, so the compact output is re-indented to keep it stable.
+func marshalProtoJSON(m proto.Message) ([]byte, error) {
+	compact, err := protoJSONOptions.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, compact, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// marshalProtoJSONLine renders a message as a single line of compact JSON
+func marshalProtoJSONLine(m proto.Message) ([]byte, error) {
+	data, err := protoJSONOptions.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
 func printRunnerTable(runners []*gradv1.Runner) error {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "ID\tNAME\tSTATUS\tCPU\tMEMORY\tAGE")
+	if err := writeRunnerTable(os.Stdout, runners, outputFormat == OutputFormatWide); err != nil {
+		return err
+	}
+	for _, runner := range runners {
+		if runner.StaleImage {
+			fmt.Fprintf(os.Stderr, "%s runs a different image than new runners get; recreate the runner to update it\n", staleImageMarker)
+			break
+		}
+	}
+	return nil
+}
 
+// writeRunnerTable prints one row per runner; wide adds ARCH and DESCRIPTION columns
+func writeRunnerTable(out io.Writer, runners []*gradv1.Runner, wide bool) error {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	if wide {
+		fmt.Fprintln(w, "ID\tNAME\tGROUP\tSTATUS\tCPU\tMEMORY\tAGE\tRESERVED\tARCH\tDESCRIPTION")
+	} else {
+		fmt.Fprintln(w, "ID\tNAME\tGROUP\tSTATUS\tCPU\tMEMORY\tAGE\tRESERVED")
+	}
+
+	now := time.Now()
 	for _, runner := range runners {
 		age := formatAge(runner.CreatedAt)
 		cpu := formatCPU(runner.Resources)
 		memory := formatMemory(runner.Resources)
-		status := formatStatus(runner.Status)
+		status := formatTableStatus(runner)
+		group := runner.Group
+		if group == "" {
+			group = "-"
+		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
 			runner.Id,
 			runner.Name,
+			group,
 			status,
 			cpu,
 			memory,
 			age,
+			formatReserved(runner.ReservedUntil, now),
 		)
+		if wide {
+			fmt.Fprintf(w, "\t%s\t%s", formatArch(runner.Arch), formatDescription(runner.Description, descriptionColumnWidth))
+		}
+		fmt.Fprintln(w)
 	}
 
 	return w.Flush()
 }
 
-func printRunnerDetails(runner *gradv1.Runner) error {
-	fmt.Printf("ID:         %s\n", runner.Id)
-	fmt.Printf("Name:       %s\n", runner.Name)
-	fmt.Printf("Status:     %s\n", formatStatus(runner.Status))
-	fmt.Printf("Created:    %s\n", formatTimestamp(runner.CreatedAt))
-	fmt.Printf("Updated:    %s\n", formatTimestamp(runner.UpdatedAt))
+// writeRunnerDetails writes a runner as aligned fields followed by indented sections
+func writeRunnerDetails(out io.Writer, runner *gradv1.Runner) error {
+	fmt.Fprintf(out, "ID:         %s\n", runner.Id)
+	fmt.Fprintf(out, "Name:       %s\n", runner.Name)
+	if runner.Group != "" {
+		fmt.Fprintf(out, "Group:      %s\n", runner.Group)
+	}
+	fmt.Fprintf(out, "Status:     %s\n", formatStatus(runner.Status))
+	if runner.ErrorReason != gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_UNSPECIFIED {
+		fmt.Fprintf(out, "Error:      %s\n", formatRunnerError(runner))
+	}
+	if runner.StatusDetail != "" {
+		fmt.Fprintf(out, "Detail:     %s\n", runner.StatusDetail)
+	}
+	fmt.Fprintf(out, "Created:    %s\n", formatTimestamp(runner.CreatedAt))
+	fmt.Fprintf(out, "Updated:    %s\n", formatTimestamp(runner.UpdatedAt))
+	if runner.ProvisioningDurationMs > 0 {
+		fmt.Fprintf(out, "Ready in:   %s\n", time.Duration(runner.ProvisioningDurationMs)*time.Millisecond)
+	}
+	if runner.ReservedUntil != 0 {
+		fmt.Fprintf(out, "Reserved:   until %s\n", formatTimestamp(runner.ReservedUntil))
+	}
+	if runner.Protected {
+		fmt.Fprintf(out, "Protected:  yes, deleting it needs --override-protection\n")
+	}
+	if runner.Spot {
+		fmt.Fprintf(out, "Spot:       yes, its node may be reclaimed\n")
+	}
+	if runner.AutoRestart {
+		fmt.Fprintf(out, "Restarts:   %d (auto-restart)\n", runner.RestartCount)
+	} else if runner.RestartCount > 0 {
+		fmt.Fprintf(out, "Restarts:   %d\n", runner.RestartCount)
+	}
 	
 	if runner.IpAddress != "" {
-		fmt.Printf("IP Address: %s\n", runner.IpAddress)
+		fmt.Fprintf(out, "IP Address: %s\n", runner.IpAddress)
+	}
+	if runner.NetworkPolicy != "" {
+		fmt.Fprintf(out, "Net Policy: %s\n", runner.NetworkPolicy)
+	} else {
+		fmt.Fprintf(out, "Net Policy: none\n")
+	}
+	if runner.PriorityClassName != "" {
+		fmt.Fprintf(out, "Priority:   %s\n", runner.PriorityClassName)
+	}
+	if runner.Arch != "" {
+		fmt.Fprintf(out, "Arch:       %s\n", runner.Arch)
+	}
+	if mode := formatWorkspaceMode(runner.WorkspaceMode); mode != "" {
+		fmt.Fprintf(out, "Workspace:  %s\n", mode)
+	}
+	if runner.Image != "" && runner.StaleImage {
+		fmt.Fprintf(out, "Image:      %s (stale, new runners get a different image)\n", runner.Image)
+	} else if runner.Image != "" {
+		fmt.Fprintf(out, "Image:      %s\n", runner.Image)
+	}
+	if runner.ImageDigest != "" {
+		fmt.Fprintf(out, "Digest:     %s\n", runner.ImageDigest)
+	}
+	if runner.DefaultShell != "" {
+		fmt.Fprintf(out, "Shell:      %s\n", runner.DefaultShell)
+	}
+	if runner.DefaultWorkdir != "" {
+		fmt.Fprintf(out, "Workdir:    %s\n", runner.DefaultWorkdir)
 	}
 
 	if runner.Resources != nil {
-		fmt.Printf("\nResources:\n")
-		fmt.Printf("  CPU:      %s\n", formatCPU(runner.Resources))
-		fmt.Printf("  Memory:   %s\n", formatMemory(runner.Resources))
-		fmt.Printf("  Storage:  %dGB\n", runner.Resources.StorageGb)
+		fmt.Fprintf(out, "\nResources:\n")
+		fmt.Fprintf(out, "  CPU:      %s\n", formatCPU(runner.Resources))
+		fmt.Fprintf(out, "  Memory:   %s\n", formatMemory(runner.Resources))
+		fmt.Fprintf(out, "  Storage:  %dGB\n", runner.Resources.StorageGb)
+	}
+
+	if runner.Usage != nil {
+		fmt.Fprintf(out, "\nUsage:\n")
+		fmt.Fprintf(out, "  CPU:      %s\n", formatUsageCPU(runner.Usage.CpuMillicores))
+		fmt.Fprintf(out, "  Memory:   %s\n", formatUsageMemory(runner.Usage.MemoryBytes))
+	}
+
+	if runner.Description != "" {
+		fmt.Fprintf(out, "\nDescription:\n")
+		for _, line := range strings.Split(runner.Description, "\n") {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+
+	if len(runner.HostAliases) > 0 {
+		fmt.Fprintf(out, "\nHost Aliases:\n")
+		for _, alias := range runner.HostAliases {
+			fmt.Fprintf(out, "  %s  %s\n", alias.Ip, strings.Join(alias.Hostnames, " "))
+		}
+	}
+
+	if runner.BootstrapCommand != "" {
+		fmt.Fprintf(out, "\nBootstrap Command:\n")
+		fmt.Fprintf(out, "  %s\n", runner.BootstrapCommand)
 	}
 
 	if runner.Ssh != nil && runner.Ssh.Host != "" {
-		fmt.Printf("\nSSH Access:\n")
-		fmt.Printf("  Host:     %s\n", runner.Ssh.Host)
-		fmt.Printf("  Port:     %d\n", runner.Ssh.Port)
-		fmt.Printf("  Username: %s\n", runner.Ssh.Username)
+		fmt.Fprintf(out, "\nSSH Access:\n")
+		fmt.Fprintf(out, "  Host:     %s\n", runner.Ssh.Host)
+		fmt.Fprintf(out, "  Port:     %d\n", runner.Ssh.Port)
+		fmt.Fprintf(out, "  Username: %s\n", runner.Ssh.Username)
+		fmt.Fprintf(out, "  Sessions: %s\n", formatSSHSessions(runner))
 	}
 
 	if len(runner.Env) > 0 {
-		fmt.Printf("\nEnvironment Variables:\n")
+		fmt.Fprintf(out, "\nEnvironment Variables:\n")
+		keys := make([]string, 0, len(runner.Env))
 		for k := range runner.Env {
-			fmt.Printf("  %s\n", k)
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(out, "  %s\n", k)
 		}
 	}
 
@@ -150,11 +635,82 @@ func formatStatus(status gradv1.RunnerStatus) string {
 		return "Stopped"
 	case gradv1.RunnerStatus_RUNNER_STATUS_ERROR:
 		return "Error"
+	case gradv1.RunnerStatus_RUNNER_STATUS_BOOTSTRAPPING:
+		return "Bootstrapping"
+	case gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED:
+		return "DeleteFailed"
+	case gradv1.RunnerStatus_RUNNER_STATUS_RESTARTING:
+		return "Restarting"
 	default:
 		return "Unknown"
 	}
 }
 
+// formatErrorReason names an error reason the way the server's metrics label it (pure function)
+func formatErrorReason(reason gradv1.RunnerErrorReason) string {
+	return strings.ToLower(strings.TrimPrefix(reason.String(), "RUNNER_ERROR_REASON_"))
+}
+
+// formatRunnerError describes why a runner is in error, e.g. "image_pull: container runner: ErrImagePull" (pure function)
+func formatRunnerError(runner *gradv1.Runner) string {
+	reason := formatErrorReason(runner.ErrorReason)
+	if runner.ErrorMessage == "" {
+		return reason
+	}
+	return reason + ": " + runner.ErrorMessage
+}
+
+// formatTableStatus is the status column of the runner table, with a runner's error
+// shortened after it (pure function)
+func formatTableStatus(runner *gradv1.Runner) string {
+	status := formatStatus(runner.Status)
+	if runner.StaleImage {
+		status += staleImageMarker
+	}
+	if runner.Protected {
+		status += protectedMarker
+	}
+	if runner.ErrorReason == gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_UNSPECIFIED {
+		return status
+	}
+	return status + " (" + formatDescription(formatRunnerError(runner), errorSuffixWidth) + ")"
+}
+
+// formatArch shows the architecture a runner is pinned to, or "any" if it isn't (pure function)
+func formatArch(arch string) string {
+	if arch == "" {
+		return "any"
+	}
+	return arch
+}
+
+// formatWorkspaceMode describes how a runner's S3 workspace is mounted, "" without one (pure function)
+func formatWorkspaceMode(mode gradv1.WorkspaceMode) string {
+	switch mode {
+	case gradv1.WorkspaceMode_WORKSPACE_MODE_READ_WRITE:
+		return "read-write"
+	case gradv1.WorkspaceMode_WORKSPACE_MODE_READ_ONLY:
+		return "read-only"
+	case gradv1.WorkspaceMode_WORKSPACE_MODE_READ_ONLY_UNENFORCED:
+		return "read-only, not enforced (writes outside the S3 mount are lost; recreate the runner to enforce it)"
+	default:
+		return ""
+	}
+}
+
+// formatDescription fits a description on one table row of at most width characters (pure function)
+func formatDescription(description string, width int) string {
+	if description == "" {
+		return "-"
+	}
+	line := strings.Join(strings.Fields(description), " ")
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	return string(runes[:width-3]) + "..."
+}
+
 func formatCPU(resources *gradv1.ResourceRequirements) string {
 	if resources == nil {
 		return "N/A"
@@ -174,6 +730,30 @@ func formatMemory(resources *gradv1.ResourceRequirements) string {
 	return fmt.Sprintf("%dM", resources.MemoryMb)
 }
 
+// formatUsageCPU renders CPU usage in millicores the way kubectl top does, e.g. "250m" (pure function)
+func formatUsageCPU(millicores int64) string {
+	return fmt.Sprintf("%dm", millicores)
+}
+
+// formatUsageMemory renders memory usage in mebibytes the way kubectl top does, e.g. "128Mi" (pure function)
+func formatUsageMemory(bytes int64) string {
+	return fmt.Sprintf("%dMi", bytes/(1<<20))
+}
+
+// formatOutputBytes renders an amount of command output in the largest binary unit it fills (pure function)
+func formatOutputBytes(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1fGi", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%dMi", bytes/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%dKi", bytes/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", bytes)
+	}
+}
+
 func formatAge(createdAt int64) string {
 	if createdAt == 0 {
 		return "N/A"
@@ -192,6 +772,14 @@ func formatAge(createdAt int64) string {
 	return fmt.Sprintf("%ds", int(duration.Seconds()))
 }
 
+// formatSSHSessions returns the open SSH sessions of a runner and when the latest login was
+func formatSSHSessions(runner *gradv1.Runner) string {
+	if runner.LastSshLogin == 0 {
+		return fmt.Sprintf("%d open, no logins seen", runner.SshSessions)
+	}
+	return fmt.Sprintf("%d open, last login %s", runner.SshSessions, formatTimestamp(runner.LastSshLogin))
+}
+
 func formatTimestamp(timestamp int64) string {
 	if timestamp == 0 {
 		return "N/A"
@@ -212,6 +800,12 @@ func ParseRunnerStatus(status string) (gradv1.RunnerStatus, error) {
 		return gradv1.RunnerStatus_RUNNER_STATUS_STOPPED, nil
 	case "error":
 		return gradv1.RunnerStatus_RUNNER_STATUS_ERROR, nil
+	case "bootstrapping":
+		return gradv1.RunnerStatus_RUNNER_STATUS_BOOTSTRAPPING, nil
+	case "delete_failed":
+		return gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED, nil
+	case "restarting":
+		return gradv1.RunnerStatus_RUNNER_STATUS_RESTARTING, nil
 	case "":
 		return gradv1.RunnerStatus_RUNNER_STATUS_UNSPECIFIED, nil
 	default:
@@ -0,0 +1,535 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/strrl/gra/cmd/gractl/config"
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// testRunner returns a runner with every field populated
+func testRunner() *gradv1.Runner {
+	return &gradv1.Runner{
+		Id:     "runner-1",
+		Name:   "analytics",
+		Status: gradv1.RunnerStatus_RUNNER_STATUS_RUNNING,
+		Resources: &gradv1.ResourceRequirements{
+			CpuMillicores: 2000,
+			MemoryMb:      2048,
+			StorageGb:     40,
+		},
+		CreatedAt: 1700000000,
+		UpdatedAt: 1700000060,
+		Ssh: &gradv1.SSHDetails{
+			Host:     "10.0.0.12",
+			Port:     22,
+			Username: "runner",
+		},
+		IpAddress:        "10.0.0.12",
+		Env:              map[string]string{"B_VAR": "2", "A_VAR": "1"},
+		BootstrapCommand: "pip install -r requirements.txt",
+
+		ProvisioningDurationMs: 42500,
+		NetworkPolicy:          "grad-runner-runner-1",
+		DefaultShell:           "zsh",
+		DefaultWorkdir:         "/workspace",
+		Description:            "Nightly analytics import\nOwned by the data team",
+		Group:                  "nightly",
+		HostAliases:            []*gradv1.HostAlias{{Ip: "10.0.0.5", Hostnames: []string{"legacy.corp.example", "legacy"}}},
+		Image:                  "ghcr.io/strrl/grad-runner:v1.2.0",
+		ImageDigest:            "sha256:3f1c0a9e8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d",
+		SshSessions:            2,
+		LastSshLogin:           1700000300,
+		Conditions: []*gradv1.RunnerCondition{
+			{Type: "PodCreated", Status: gradv1.ConditionStatus_CONDITION_STATUS_TRUE, LastTransitionTime: 1700000000},
+			{Type: "PodScheduled", Status: gradv1.ConditionStatus_CONDITION_STATUS_TRUE, LastTransitionTime: 1700000001},
+			{Type: "ImagePulled", Status: gradv1.ConditionStatus_CONDITION_STATUS_TRUE, LastTransitionTime: 1700000030},
+			{Type: "ContainersReady", Status: gradv1.ConditionStatus_CONDITION_STATUS_TRUE, LastTransitionTime: 1700000031},
+			{Type: "BootstrapComplete", Status: gradv1.ConditionStatus_CONDITION_STATUS_TRUE, LastTransitionTime: 1700000052, Reason: "Succeeded"},
+		},
+	}
+}
+
+// assertGolden compares output with testdata/<name>.golden, rewriting it with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("JSON output for %s changed; if intended, run go test -update\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+// testRoot is the root command of runGractl. Cobra copies the root's flags into a subcommand
+// the first time it runs, so every run must share one root.
+var testRoot = sync.OnceValue(func() *cobra.Command {
+	root := &cobra.Command{Use: "gractl"}
+	RegisterGlobalFlags(root)
+	root.AddCommand(RunnersCmd, ExecuteCmd, AdminCmd)
+	return root
+})
+
+// resetFlags returns the flags of cmd and its subcommands that an earlier run set to their
+// defaults, since cobra keeps them between runs
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// runGractl runs gractl with args against the runners and command output of
+// testdata/mock_data.json, ignoring the user's .gractl.toml, and returns what it printed.
+// Commands exiting with a non-zero code end the test binary, so only run ones that succeed.
+func runGractl(t *testing.T, args ...string) (string, string) {
+	t.Helper()
+
+	mockData, err := filepath.Abs(filepath.Join("testdata", "mock_data.json"))
+	if err != nil {
+		t.Fatalf("Failed to find mock data: %v", err)
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NO_COLOR", "1")
+
+	root := testRoot()
+	resetFlags(root)
+	root.SetArgs(append([]string{"--mock-data", mockData}, args...))
+
+	stdout, stderr := captureOutput(t, func() { err = root.Execute() })
+	if err != nil {
+		t.Fatalf("gractl %s failed: %v\n%s", strings.Join(args, " "), err, stderr)
+	}
+	return stdout, stderr
+}
+
+func TestRunnerJSONGolden(t *testing.T) {
+	// runner-1 of the mock data has every field populated
+	stdout, _ := runGractl(t, "runners", "get", "runner-1", "-o", "json")
+	assertGolden(t, "runner", []byte(stdout))
+}
+
+func TestRunnerListJSONGolden(t *testing.T) {
+	// runner-2 of the mock data is mostly empty, which checks that zero values are still emitted
+	stdout, _ := runGractl(t, "runners", "list", "-o", "json")
+	assertGolden(t, "runner_list", []byte(stdout))
+}
+
+func TestExecJSONGolden(t *testing.T) {
+	stdout, _ := runGractl(t, "runners", "exec", "runner-1", "-o", "json", "--no-exit-code-propagation", "--", "python", "train.py")
+	assertGolden(t, "exec", []byte(stdout))
+}
+
+func TestStreamJSONGolden(t *testing.T) {
+	responses := []*gradv1.ExecuteCommandStreamResponse{
+		{Type: gradv1.StreamType_STREAM_TYPE_STDOUT, Data: []byte("hello\n")},
+		{Type: gradv1.StreamType_STREAM_TYPE_STDERR, Data: []byte("warning\n")},
+		{Type: gradv1.StreamType_STREAM_TYPE_EXIT, ExitCode: 3, FailureReason: gradv1.ExitReason_EXIT_REASON_EXITED},
+	}
+
+	var got []byte
+	for _, resp := range responses {
+		data, err := marshalProtoJSONLine(resp)
+		if err != nil {
+			t.Fatalf("Failed to marshal stream response: %v", err)
+		}
+		got = append(got, data...)
+	}
+	assertGolden(t, "stream", got)
+}
+
+func TestWriteRunnerStats(t *testing.T) {
+	stats := &gradv1.GetRunnerStatsResponse{
+		Total:    5,
+		ByStatus: map[string]int32{"running": 3, "error": 1, "creating": 1},
+		ByPreset: map[string]int32{"small": 5},
+		ByOwner:  map[string]int32{"alice": 2, "": 2, "bob": 1},
+	}
+
+	var out bytes.Buffer
+	if err := writeRunnerStats(&out, stats); err != nil {
+		t.Fatalf("Failed to write stats: %v", err)
+	}
+
+	expected := "TOTAL   5\n" +
+		"STATUS  running=3 creating=1 error=1\n" +
+		"PRESET  small=5\n" +
+		"OWNER   <none>=2 alice=2 bob=1\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := writeRunnerStats(&out, &gradv1.GetRunnerStatsResponse{}); err != nil {
+		t.Fatalf("Failed to write stats: %v", err)
+	}
+	if !strings.Contains(out.String(), "STATUS  -\n") {
+		t.Errorf("Expected empty groups to print '-', got:\n%s", out.String())
+	}
+}
+
+func TestWriteDeleteRunnerResponse(t *testing.T) {
+	resp := &gradv1.DeleteRunnerResponse{
+		Message:          "runner runner-1 deletion incomplete: 1 object(s) could not be deleted, delete it again to retry",
+		DeletedResources: []string{"secret/credentials"},
+		FailedResources:  []string{"persistentvolumeclaim/workspace: forbidden"},
+	}
+
+	var out bytes.Buffer
+	if err := writeDeleteRunnerResponse(&out, resp); err != nil {
+		t.Fatalf("Failed to write response: %v", err)
+	}
+
+	expected := resp.Message + "\n" +
+		"  deleted  secret/credentials\n" +
+		"  failed   persistentvolumeclaim/workspace: forbidden\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	// Objects still terminating after --wait
+	resp = &gradv1.DeleteRunnerResponse{
+		Message:            "runner runner-1 deletion still in progress: 1 object(s) still terminating",
+		DeletedResources:   []string{"pod/grad-runner-1"},
+		RemainingResources: []string{"pod/grad-runner-1"},
+	}
+	out.Reset()
+	if err := writeDeleteRunnerResponse(&out, resp); err != nil {
+		t.Fatalf("Failed to write response: %v", err)
+	}
+
+	expected = resp.Message + "\n" +
+		"  deleted  pod/grad-runner-1\n" +
+		"  pending  pod/grad-runner-1\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestWriteRunnerUsageTable(t *testing.T) {
+	usages := []*gradv1.RunnerUsage{
+		{RunnerId: "runner-2", CpuMillicores: 1503, MemoryBytes: 576 << 20, StdoutBytes: 3 << 30, StderrBytes: 1500, Containers: []*gradv1.ContainerUsage{
+			{Name: "runner", CpuMillicores: 1500, MemoryBytes: 512 << 20},
+			{Name: "s3fs", CpuMillicores: 3, MemoryBytes: 64 << 20},
+		}},
+		{RunnerId: "runner-1", CpuMillicores: 0, MemoryBytes: 300 << 10},
+	}
+
+	var out bytes.Buffer
+	if err := writeRunnerUsageTable(&out, usages, false); err != nil {
+		t.Fatalf("Failed to write table: %v", err)
+	}
+	expected := "RUNNER     CPU(cores)   MEMORY(bytes)   STDOUT   STDERR\n" +
+		"runner-2   1503m        576Mi           3.0Gi    1Ki\n" +
+		"runner-1   0m           0Mi             0B       0B\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := writeRunnerUsageTable(&out, usages[:1], true); err != nil {
+		t.Fatalf("Failed to write table: %v", err)
+	}
+	expected = "RUNNER     CPU(cores)   MEMORY(bytes)   STDOUT   STDERR\n" +
+		"runner-2   1503m        576Mi           3.0Gi    1Ki\n" +
+		"  runner   1500m        512Mi\n" +
+		"  s3fs     3m           64Mi\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestWriteSSHKeyTable(t *testing.T) {
+	keys := []*gradv1.SSHKey{
+		{Fingerprint: "SHA256:xGNji13BKIUvz1NnNE7EssSe/UDcUNG0rDnTGZa4jxE", Type: "ssh-ed25519", Comment: "alice@laptop"},
+		{Fingerprint: "SHA256:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU", Type: "ssh-rsa"},
+	}
+
+	var out bytes.Buffer
+	if err := writeSSHKeyTable(&out, keys); err != nil {
+		t.Fatalf("Failed to write table: %v", err)
+	}
+	expected := "FINGERPRINT                                          TYPE          COMMENT\n" +
+		"SHA256:xGNji13BKIUvz1NnNE7EssSe/UDcUNG0rDnTGZa4jxE   ssh-ed25519   alice@laptop\n" +
+		"SHA256:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU   ssh-rsa       -\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := writeSSHKeyTable(&out, nil); err != nil {
+		t.Fatalf("Failed to write table: %v", err)
+	}
+	if out.String() != "No SSH keys added\n" {
+		t.Errorf("Expected a message without keys, got %q", out.String())
+	}
+}
+
+func TestWriteDeleteRunnerGroupResponse(t *testing.T) {
+	resp := &gradv1.DeleteRunnerGroupResponse{
+		DeletedRunnerIds: []string{"runner-1", "runner-3"},
+		Failed:           []*gradv1.RunnerDeleteFailure{{RunnerId: "runner-2", Error: "cleanup incomplete"}},
+	}
+
+	var out bytes.Buffer
+	if err := writeDeleteRunnerGroupResponse(&out, "ci-1234", resp); err != nil {
+		t.Fatalf("Failed to write response: %v", err)
+	}
+
+	expected := "  deleted  runner-1\n" +
+		"  deleted  runner-3\n" +
+		"  failed   runner-2: cleanup incomplete\n" +
+		"Deleted 2 out of 3 runners in group ci-1234\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	writeDeleteRunnerGroupResponse(&out, "ci-1234", &gradv1.DeleteRunnerGroupResponse{})
+	if out.String() != "No runners found in group ci-1234\n" {
+		t.Errorf("Expected a message for an empty group, got %q", out.String())
+	}
+}
+
+func TestWriteRunnerTableWide(t *testing.T) {
+	runners := []*gradv1.Runner{testRunner(), {Id: "runner-2", Status: gradv1.RunnerStatus_RUNNER_STATUS_CREATING}}
+	runners[0].Arch = "arm64"
+
+	var out bytes.Buffer
+	if err := writeRunnerTable(&out, runners, false); err != nil {
+		t.Fatalf("Failed to write table: %v", err)
+	}
+	if strings.Contains(out.String(), "DESCRIPTION") || strings.Contains(out.String(), "ARCH") {
+		t.Errorf("Expected no ARCH or DESCRIPTION column without wide, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := writeRunnerTable(&out, runners, true); err != nil {
+		t.Fatalf("Failed to write table: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[0]); !slices.Equal(fields[len(fields)-2:], []string{"ARCH", "DESCRIPTION"}) {
+		t.Errorf("Expected ARCH and DESCRIPTION columns, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], " arm64 ") || !strings.Contains(lines[2], " any ") {
+		t.Errorf("Expected arm64 and any as the architectures, got:\n%s", out.String())
+	}
+	if !strings.HasSuffix(lines[1], "Nightly analytics import Owned by the...") {
+		t.Errorf("Expected a truncated one-line description, got %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], " -") {
+		t.Errorf("Expected '-' for a runner without description, got %q", lines[2])
+	}
+}
+
+func TestWriteRunnerTableGroup(t *testing.T) {
+	runners := []*gradv1.Runner{testRunner(), {Id: "runner-2", Status: gradv1.RunnerStatus_RUNNER_STATUS_CREATING}}
+
+	var out bytes.Buffer
+	if err := writeRunnerTable(&out, runners, false); err != nil {
+		t.Fatalf("Failed to write table: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[0]); len(fields) < 3 || fields[2] != "GROUP" {
+		t.Errorf("Expected GROUP as the third column, got %q", lines[0])
+	}
+	if fields := strings.Fields(lines[1]); len(fields) < 3 || fields[2] != "nightly" {
+		t.Errorf("Expected group nightly, got %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); len(fields) < 2 || fields[1] != "-" {
+		t.Errorf("Expected '-' for a runner without group, got %q", lines[2])
+	}
+}
+
+func TestFormatTableStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		runner   *gradv1.Runner
+		expected string
+	}{
+		{"No error", &gradv1.Runner{Status: gradv1.RunnerStatus_RUNNER_STATUS_RUNNING}, "Running"},
+		{"Stale image", &gradv1.Runner{Status: gradv1.RunnerStatus_RUNNER_STATUS_RUNNING, StaleImage: true}, "Running*"},
+		{
+			"Reason only",
+			&gradv1.Runner{Status: gradv1.RunnerStatus_RUNNER_STATUS_ERROR, ErrorReason: gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_UNKNOWN},
+			"Error (unknown)",
+		},
+		{
+			"Long message",
+			&gradv1.Runner{
+				Status:       gradv1.RunnerStatus_RUNNER_STATUS_ERROR,
+				ErrorReason:  gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_IMAGE_PULL,
+				ErrorMessage: "container runner: ImagePullBackOff: Back-off pulling image",
+			},
+			"Error (image_pull: container runner: ImagePu...)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTableStatus(tt.runner); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatDescription(t *testing.T) {
+	tests := []struct {
+		description string
+		expected    string
+	}{
+		{"", "-"},
+		{"short", "short"},
+		{"two\nlines", "two lines"},
+		{"exactly ten", "exactly..."},
+		{"ünïcödé ñame", "ünïcödé..."},
+	}
+
+	for _, tt := range tests {
+		if got := formatDescription(tt.description, 10); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestFormatSSHSessions(t *testing.T) {
+	lastLogin := time.Date(2026, 10, 17, 11, 40, 0, 0, time.UTC)
+	tests := []struct {
+		runner   *gradv1.Runner
+		expected string
+	}{
+		{&gradv1.Runner{}, "0 open, no logins seen"},
+		{&gradv1.Runner{SshSessions: 2, LastSshLogin: lastLogin.Unix()}, "2 open, last login " + lastLogin.Local().Format(time.RFC3339)},
+		{&gradv1.Runner{LastSshLogin: lastLogin.Unix()}, "0 open, last login " + lastLogin.Local().Format(time.RFC3339)},
+	}
+
+	for _, tt := range tests {
+		if got := formatSSHSessions(tt.runner); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, format := range []string{"table", "wide", "json"} {
+		if got, err := parseOutputFormat(format); err != nil || string(got) != format {
+			t.Errorf("Expected %s, got %q (%v)", format, got, err)
+		}
+	}
+	if _, err := parseOutputFormat("yaml"); err == nil {
+		t.Error("Expected an error for yaml")
+	}
+}
+
+func TestResolveOutputFormat(t *testing.T) {
+	configured := func(format string, wide bool) config.OutputConfig {
+		return config.OutputConfig{Format: format, WideColumns: wide, FormatSource: "config file /home/alice/.gractl.toml"}
+	}
+
+	tests := []struct {
+		name        string
+		flagValue   string
+		flagChanged bool
+		output      config.OutputConfig
+		expected    OutputFormat
+	}{
+		{"Default", "table", false, config.OutputConfig{}, OutputFormatTable},
+		{"Configured", "table", false, configured("json", false), OutputFormatJSON},
+		{"Flag over config", "table", true, configured("json", false), OutputFormatTable},
+		{"Flag over an invalid config", "json", true, configured("yaml", false), OutputFormatJSON},
+		{"Wide columns", "table", false, configured("table", true), OutputFormatWide},
+		{"Wide columns leave JSON alone", "table", false, configured("json", true), OutputFormatJSON},
+		{"Flag over wide columns", "table", true, configured("table", true), OutputFormatTable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveOutputFormat(tt.flagValue, tt.flagChanged, tt.output)
+			if err != nil || got != tt.expected {
+				t.Errorf("Expected %s, got %q (%v)", tt.expected, got, err)
+			}
+		})
+	}
+}
+
+func TestResolveOutputFormatInvalidConfig(t *testing.T) {
+	_, err := resolveOutputFormat("table", false, config.OutputConfig{Format: "yaml", FormatSource: "config file /home/alice/.gractl.toml"})
+	if err == nil {
+		t.Fatal("Expected an invalid configured format to fail")
+	}
+	for _, want := range []string{"yaml", "table, wide, json", "output.format", "/home/alice/.gractl.toml", "--output"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to mention %q, got %q", want, err)
+		}
+	}
+
+	if _, err := resolveOutputFormat("yaml", true, config.OutputConfig{}); err == nil {
+		t.Error("Expected an invalid --output to fail")
+	}
+}
+
+func TestResolveColorMode(t *testing.T) {
+	if mode, err := resolveColorMode(config.OutputConfig{}); err != nil || mode != ColorAuto {
+		t.Errorf("Expected auto by default, got %q (%v)", mode, err)
+	}
+	if mode, err := resolveColorMode(config.OutputConfig{Color: "never"}); err != nil || mode != ColorNever {
+		t.Errorf("Expected never, got %q (%v)", mode, err)
+	}
+	_, err := resolveColorMode(config.OutputConfig{Color: "rainbow", ColorSource: "GRACTL_OUTPUT_COLOR environment variable"})
+	if err == nil || !strings.Contains(err.Error(), "GRACTL_OUTPUT_COLOR") {
+		t.Errorf("Expected the error to say where the color was set, got %v", err)
+	}
+}
+
+func TestRunnerDetailsWorkspaceMode(t *testing.T) {
+	// runner-1 of the mock data has a read-only workspace, runner-2 none
+	stdout, _ := runGractl(t, "runners", "get", "runner-1")
+	if !strings.Contains(stdout, "\nWorkspace:  read-only\n") {
+		t.Errorf("Expected the workspace mode, got:\n%s", stdout)
+	}
+	stdout, _ = runGractl(t, "runners", "get", "runner-2")
+	if strings.Contains(stdout, "Workspace:") {
+		t.Errorf("Expected no workspace mode without a workspace, got:\n%s", stdout)
+	}
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureOutput runs fn with os.Stdout and os.Stderr redirected and returns what was written to each
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+
+	origStdout, origStderr := os.Stdout, os.Stderr
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	os.Stdout, os.Stderr = stdoutW, stderrW
+	defer func() { os.Stdout, os.Stderr = origStdout, origStderr }()
+
+	var stdout, stderr bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); io.Copy(&stdout, stdoutR) }()
+	go func() { defer wg.Done(); io.Copy(&stderr, stderrR) }()
+
+	fn()
+
+	stdoutW.Close()
+	stderrW.Close()
+	wg.Wait()
+	return stdout.String(), stderr.String()
+}
+
+func TestVerbosityStreams(t *testing.T) {
+	tests := []struct {
+		name           string
+		quiet          bool
+		verbose        bool
+		expectProgress bool
+		expectDebug    bool
+	}{
+		{name: "Default", expectProgress: true},
+		{name: "Quiet", quiet: true},
+		{name: "Verbose", verbose: true, expectProgress: true, expectDebug: true},
+	}
+
+	origLogger := logger
+	defer func() {
+		logger = origLogger
+		quietFlag, verboseFlag = false, false
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv("NO_COLOR", "1")
+			quietFlag, verboseFlag = tt.quiet, tt.verbose
+
+			stdout, stderr := captureOutput(t, func() {
+				configureLogger()
+				workspaceInitCmd.Run(workspaceInitCmd, nil)
+			})
+
+			if stdout != "" {
+				t.Errorf("Expected nothing on stdout, got %q", stdout)
+			}
+			if got := strings.Contains(stderr, "Workspace initialized successfully!"); got != tt.expectProgress {
+				t.Errorf("Expected progress on stderr=%v, got %q", tt.expectProgress, stderr)
+			}
+			if got := strings.Contains(stderr, "debug: Created CLAUDE.md"); got != tt.expectDebug {
+				t.Errorf("Expected debug on stderr=%v, got %q", tt.expectDebug, stderr)
+			}
+			if _, err := os.Stat("CLAUDE.md"); err != nil {
+				t.Errorf("Expected CLAUDE.md to be created: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoggerWarningsAlwaysShown(t *testing.T) {
+	var out bytes.Buffer
+	l := newLogger(&out, VerbosityQuiet, false)
+
+	l.Debugf("debug %d", 1)
+	l.Infof("info %d", 2)
+	l.Warnf("warn %d", 3)
+
+	if out.String() != "warning: warn 3\n" {
+		t.Errorf("Expected only the warning, got %q", out.String())
+	}
+}
+
+func TestLoggerColor(t *testing.T) {
+	var out bytes.Buffer
+	newLogger(&out, VerbosityVerbose, true).Debugf("details")
+	if out.String() != ansiDim+"debug: details"+ansiReset+"\n" {
+		t.Errorf("Expected dimmed debug line, got %q", out.String())
+	}
+
+	out.Reset()
+	newLogger(&out, VerbosityVerbose, true).Infof("progress")
+	if out.String() != "progress\n" {
+		t.Errorf("Expected plain progress line, got %q", out.String())
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       ColorMode
+		noColor    string
+		isTerminal bool
+		expected   bool
+	}{
+		{"Terminal", ColorAuto, "", true, true},
+		{"Terminal with NO_COLOR", ColorAuto, "1", true, false},
+		{"Pipe", ColorAuto, "", false, false},
+		{"Always to a pipe", ColorAlways, "", false, true},
+		{"Always with NO_COLOR", ColorAlways, "1", true, true},
+		{"Never", ColorNever, "", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := useColor(tt.mode, tt.noColor, tt.isTerminal); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
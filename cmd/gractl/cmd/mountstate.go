@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// mountRecord is a workspace mount held by a workspace sync process
+type mountRecord struct {
+	RunnerID   string `json:"runner_id"`
+	Mountpoint string `json:"mountpoint"`
+	// LocalPort, PortForwardPID and SSHFSPID are 0 while the mount is being set up
+	LocalPort      int       `json:"local_port,omitempty"`
+	PID            int       `json:"pid"`
+	PortForwardPID int       `json:"port_forward_pid,omitempty"`
+	SSHFSPID       int       `json:"sshfs_pid,omitempty"`
+	StartedAt      time.Time `json:"started_at"`
+}
+
+// mountStateFile is the JSON layout of the mount state file
+type mountStateFile struct {
+	Mounts []mountRecord `json:"mounts"`
+}
+
+// mountState is the file recording the workspace mounts of every sync process of the user,
+// so one invocation can see what another has mounted. Every change holds an exclusive lock
+// on a file next to it.
+type mountState struct {
+	path string
+}
+
+// defaultMountState returns the user's mount state, in ~/.cache/gractl/mounts.json on Linux
+func defaultMountState() (*mountState, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the mount state file: %w", err)
+	}
+	return &mountState{path: filepath.Join(cacheDir, "gractl", "mounts.json")}, nil
+}
+
+// Update calls fn with the recorded mounts, without stale ones, and records the mounts fn
+// leaves, holding the lock throughout
+func (s *mountState) Update(fn func(mounts []mountRecord) ([]mountRecord, error)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	mounts, err := s.read()
+	if err != nil {
+		return err
+	}
+	mounts, err = fn(pruneStaleMounts(mounts, processAlive))
+	if err != nil {
+		return err
+	}
+	return s.write(mounts)
+}
+
+// Live returns the recorded mounts that are still live, pruning stale ones from the file
+func (s *mountState) Live() ([]mountRecord, error) {
+	var live []mountRecord
+	err := s.Update(func(mounts []mountRecord) ([]mountRecord, error) {
+		live = mounts
+		return mounts, nil
+	})
+	return live, err
+}
+
+// Claim records pending mounts of runnerIDs at their workspace directories for the process
+// pid. It fails if another live process has one of them mounted, unless force is set.
+func (s *mountState) Claim(runnerIDs []string, pid int, force bool, now time.Time) error {
+	return s.Update(func(mounts []mountRecord) ([]mountRecord, error) {
+		if conflicts := mountConflicts(mounts, runnerIDs, pid); len(conflicts) > 0 && !force {
+			return nil, &alreadyMountedError{conflicts: conflicts}
+		}
+		for _, runnerID := range runnerIDs {
+			mountpoint, err := filepath.Abs(runnerWorkspaceDir(runnerID))
+			if err != nil {
+				return nil, err
+			}
+			mounts = append(mounts, mountRecord{RunnerID: runnerID, Mountpoint: mountpoint, PID: pid, StartedAt: now})
+		}
+		return mounts, nil
+	})
+}
+
+// Record replaces the record of mount.PID for mount.RunnerID, once the mount is set up
+func (s *mountState) Record(mount mountRecord) error {
+	return s.Update(func(mounts []mountRecord) ([]mountRecord, error) {
+		for i := range mounts {
+			if mounts[i].RunnerID == mount.RunnerID && mounts[i].PID == mount.PID {
+				mount.StartedAt = mounts[i].StartedAt
+				mounts[i] = mount
+				return mounts, nil
+			}
+		}
+		return append(mounts, mount), nil
+	})
+}
+
+// Release removes the records of the process pid for runnerIDs, or all of them if none are given
+func (s *mountState) Release(pid int, runnerIDs ...string) error {
+	return s.Update(func(mounts []mountRecord) ([]mountRecord, error) {
+		return slices.DeleteFunc(mounts, func(mount mountRecord) bool {
+			return mount.PID == pid && (len(runnerIDs) == 0 || slices.Contains(runnerIDs, mount.RunnerID))
+		}), nil
+	})
+}
+
+// alreadyMountedError reports the mounts of other processes a sync would duplicate
+type alreadyMountedError struct {
+	conflicts []mountRecord
+}
+
+func (e *alreadyMountedError) Error() string {
+	var b strings.Builder
+	for _, mount := range e.conflicts {
+		fmt.Fprintf(&b, "%s is already mounted at %s by gractl (pid %d)\n", mount.RunnerID, mount.Mountpoint, mount.PID)
+	}
+	b.WriteString("stop that sync first, or pass --force to mount it again")
+	return b.String()
+}
+
+// lock takes the exclusive lock of the state file, creating its directory if needed, and
+// returns how to release it
+func (s *mountState) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mount state directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mount state lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock mount state: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// read returns the recorded mounts, none if the file doesn't exist yet
+func (s *mountState) read() ([]mountRecord, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mount state: %w", err)
+	}
+	var file mountStateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid mount state in %s: %w", s.path, err)
+	}
+	return file.Mounts, nil
+}
+
+// write replaces the recorded mounts, through a temporary file so readers never see half of it
+func (s *mountState) write(mounts []mountRecord) error {
+	data, err := json.MarshalIndent(mountStateFile{Mounts: mounts}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write mount state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write mount state: %w", err)
+	}
+	return nil
+}
+
+// pruneStaleMounts drops the mounts whose sync process or sshfs has exited, as when a sync
+// was killed before it could clean up (pure function)
+func pruneStaleMounts(mounts []mountRecord, alive func(pid int) bool) []mountRecord {
+	return slices.DeleteFunc(slices.Clone(mounts), func(mount mountRecord) bool {
+		return !alive(mount.PID) || (mount.SSHFSPID != 0 && !alive(mount.SSHFSPID))
+	})
+}
+
+// mountConflicts returns the mounts of other processes for any of runnerIDs (pure function)
+func mountConflicts(mounts []mountRecord, runnerIDs []string, pid int) []mountRecord {
+	var conflicts []mountRecord
+	for _, mount := range mounts {
+		if mount.PID != pid && slices.Contains(runnerIDs, mount.RunnerID) {
+			conflicts = append(conflicts, mount)
+		}
+	}
+	return conflicts
+}
+
+// writeMountTable prints one row per mount, in the order they were started
+func writeMountTable(out io.Writer, mounts []mountRecord, now time.Time) error {
+	if len(mounts) == 0 {
+		fmt.Fprintln(out, "No workspaces mounted")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "RUNNER\tMOUNTPOINT\tPORT\tPID\tAGE")
+	for _, mount := range mounts {
+		port := "-"
+		if mount.LocalPort != 0 {
+			port = fmt.Sprint(mount.LocalPort)
+		}
+		age := now.Sub(mount.StartedAt).Truncate(time.Second)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", mount.RunnerID, mount.Mountpoint, port, mount.PID, age)
+	}
+	return w.Flush()
+}
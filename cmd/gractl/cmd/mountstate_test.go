@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testMountState returns a mount state in a temporary directory
+func testMountState(t *testing.T) *mountState {
+	return &mountState{path: filepath.Join(t.TempDir(), "gractl", "mounts.json")}
+}
+
+// exitedPID returns the PID of a process that has exited
+func exitedPID(t *testing.T) int {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to run a short-lived process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestMountStateConcurrentUpdates(t *testing.T) {
+	state := testMountState(t)
+	pid := os.Getpid()
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := state.Update(func(mounts []mountRecord) ([]mountRecord, error) {
+				return append(mounts, mountRecord{RunnerID: fmt.Sprintf("runner-%d", i), PID: pid}), nil
+			})
+			if err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mounts, err := state.Live()
+	if err != nil {
+		t.Fatalf("Live failed: %v", err)
+	}
+	if len(mounts) != writers {
+		t.Errorf("Expected every concurrent update to be kept, got %d of %d", len(mounts), writers)
+	}
+}
+
+func TestMountStateLockExcludesUpdates(t *testing.T) {
+	state := testMountState(t)
+	unlock, err := state.lock()
+	if err != nil {
+		t.Fatalf("lock failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- state.Update(func(mounts []mountRecord) ([]mountRecord, error) {
+			return append(mounts, mountRecord{RunnerID: "runner-1", PID: os.Getpid()}), nil
+		})
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("Expected the update to wait for the lock, it returned %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Update failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the update to go ahead once the lock is released")
+	}
+}
+
+func TestPruneStaleMounts(t *testing.T) {
+	alive := func(pid int) bool { return pid < 100 }
+	mounts := []mountRecord{
+		{RunnerID: "runner-1", PID: 10, SSHFSPID: 11},
+		{RunnerID: "runner-2", PID: 200, SSHFSPID: 11},
+		{RunnerID: "runner-3", PID: 10, SSHFSPID: 300},
+		{RunnerID: "runner-4", PID: 10},
+	}
+
+	var got []string
+	for _, mount := range pruneStaleMounts(mounts, alive) {
+		got = append(got, mount.RunnerID)
+	}
+	if strings.Join(got, " ") != "runner-1 runner-4" {
+		t.Errorf("Expected the mounts of live syncs with live sshfs or none yet, got %q", got)
+	}
+	if len(mounts) != 4 {
+		t.Error("Expected the mounts passed in to be left alone")
+	}
+}
+
+func TestMountStatePrunesExitedSyncs(t *testing.T) {
+	state := testMountState(t)
+	dead := exitedPID(t)
+	err := state.Update(func([]mountRecord) ([]mountRecord, error) {
+		return []mountRecord{{RunnerID: "runner-1", PID: os.Getpid()}, {RunnerID: "runner-2", PID: dead}}, nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	mounts, err := state.Live()
+	if err != nil {
+		t.Fatalf("Live failed: %v", err)
+	}
+	if len(mounts) != 1 || mounts[0].RunnerID != "runner-1" {
+		t.Errorf("Expected only the mount of the live process, got %+v", mounts)
+	}
+	if kept, _ := state.read(); len(kept) != 1 {
+		t.Errorf("Expected the stale mount to be pruned from the file, got %+v", kept)
+	}
+}
+
+func TestMountStateClaim(t *testing.T) {
+	state := testMountState(t)
+	other := os.Getppid()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	if err := state.Claim([]string{"runner-1"}, other, false, now); err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+
+	pid := os.Getpid()
+	err := state.Claim([]string{"runner-1", "runner-2"}, pid, false, now)
+	var conflict *alreadyMountedError
+	if !errors.As(err, &conflict) || len(conflict.conflicts) != 1 || conflict.conflicts[0].PID != other {
+		t.Fatalf("Expected runner-1 to be refused as mounted by %d, got %v", other, err)
+	}
+	if mounts, _ := state.Live(); len(mounts) != 1 {
+		t.Errorf("Expected a refused claim to record nothing, got %+v", mounts)
+	}
+
+	if err := state.Claim([]string{"runner-1", "runner-2"}, pid, true, now); err != nil {
+		t.Fatalf("Expected --force to mount runner-1 again, got %v", err)
+	}
+	if err := state.Record(mountRecord{RunnerID: "runner-2", PID: pid, LocalPort: 22001}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	mounts, _ := state.Live()
+	if len(mounts) != 3 || mounts[2].LocalPort != 22001 || !mounts[2].StartedAt.Equal(now) {
+		t.Errorf("Expected runner-2 to be recorded with its port and start time, got %+v", mounts)
+	}
+
+	if err := state.Release(pid, "runner-2"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if mounts, _ := state.Live(); len(mounts) != 2 {
+		t.Errorf("Expected runner-2 to be released, got %+v", mounts)
+	}
+	if err := state.Release(pid); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if mounts, _ := state.Live(); len(mounts) != 1 || mounts[0].PID != other {
+		t.Errorf("Expected only the other process's mount to be left, got %+v", mounts)
+	}
+}
+
+func TestWriteMountTable(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	mounts := []mountRecord{
+		{RunnerID: "runner-1", Mountpoint: "/home/alice/proj/runners/runner-1/workspace", LocalPort: 22001, PID: 4242, StartedAt: now.Add(-90 * time.Second)},
+		{RunnerID: "runner-2", Mountpoint: "/home/alice/proj/runners/runner-2/workspace", PID: 4242, StartedAt: now},
+	}
+
+	var got strings.Builder
+	if err := writeMountTable(&got, mounts, now); err != nil {
+		t.Fatalf("writeMountTable failed: %v", err)
+	}
+	expected := "RUNNER     MOUNTPOINT                                    PORT    PID    AGE\n" +
+		"runner-1   /home/alice/proj/runners/runner-1/workspace   22001   4242   1m30s\n" +
+		"runner-2   /home/alice/proj/runners/runner-2/workspace   -       4242   0s\n"
+	if got.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got.String())
+	}
+}
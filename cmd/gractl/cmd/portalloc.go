@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// portAllocator hands out distinct local ports for runner port-forwards. Each port is
+// reserved by holding a listener on it until the port-forward is about to bind it.
+type portAllocator struct {
+	mu sync.Mutex
+
+	// minPort and maxPort bound the probed range; both zero means kernel-assigned ports
+	minPort int
+	maxPort int
+	next    int
+
+	ports     map[string]int
+	used      map[int]bool
+	listeners map[string]net.Listener
+}
+
+// newPortAllocator creates an allocator for a "start-end" port range, or for
+// kernel-assigned ports if portRange is empty
+func newPortAllocator(portRange string) (*portAllocator, error) {
+	a := &portAllocator{
+		ports:     make(map[string]int),
+		used:      make(map[int]bool),
+		listeners: make(map[string]net.Listener),
+	}
+	if portRange == "" {
+		return a, nil
+	}
+
+	start, end, ok := strings.Cut(portRange, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid port range %q: expected start-end", portRange)
+	}
+	minPort, err := strconv.Atoi(strings.TrimSpace(start))
+	if err != nil {
+		return nil, fmt.Errorf("invalid port range %q: %w", portRange, err)
+	}
+	maxPort, err := strconv.Atoi(strings.TrimSpace(end))
+	if err != nil {
+		return nil, fmt.Errorf("invalid port range %q: %w", portRange, err)
+	}
+	if minPort < 1 || maxPort > 65535 || minPort > maxPort {
+		return nil, fmt.Errorf("invalid port range %q", portRange)
+	}
+
+	a.minPort, a.maxPort, a.next = minPort, maxPort, minPort
+	return a, nil
+}
+
+// Allocate reserves a local port for a runner. Allocating twice for the same runner returns the same port.
+func (a *portAllocator) Allocate(runnerID string) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if port, ok := a.ports[runnerID]; ok {
+		return port, nil
+	}
+
+	listener, err := a.reserve()
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate local port for %s: %w", runnerID, err)
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	a.ports[runnerID] = port
+	a.used[port] = true
+	a.listeners[runnerID] = listener
+	return port, nil
+}
+
+// reserve binds the next free port; callers must hold a.mu
+func (a *portAllocator) reserve() (net.Listener, error) {
+	if a.minPort == 0 {
+		// The kernel won't hand out a port that is still bound, but a released one may come back
+		for attempt := 0; attempt < 10; attempt++ {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				return nil, err
+			}
+			if !a.used[listener.Addr().(*net.TCPAddr).Port] {
+				return listener, nil
+			}
+			listener.Close()
+		}
+		return nil, fmt.Errorf("no unused port assigned after 10 attempts")
+	}
+
+	size := a.maxPort - a.minPort + 1
+	for i := 0; i < size; i++ {
+		port := a.next
+		a.next++
+		if a.next > a.maxPort {
+			a.next = a.minPort
+		}
+		if a.used[port] {
+			continue
+		}
+		listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+		if err != nil {
+			// Taken by another process
+			continue
+		}
+		return listener, nil
+	}
+	return nil, fmt.Errorf("no free port in range %d-%d", a.minPort, a.maxPort)
+}
+
+// Handoff closes the reservation so the port-forward can bind the port. The port stays
+// assigned to the runner and won't be handed out again.
+func (a *portAllocator) Handoff(runnerID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if listener, ok := a.listeners[runnerID]; ok {
+		listener.Close()
+		delete(a.listeners, runnerID)
+	}
+}
+
+// Release frees a runner's port
+func (a *portAllocator) Release(runnerID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if listener, ok := a.listeners[runnerID]; ok {
+		listener.Close()
+		delete(a.listeners, runnerID)
+	}
+	if port, ok := a.ports[runnerID]; ok {
+		delete(a.used, port)
+		delete(a.ports, runnerID)
+	}
+}
+
+// WriteTable writes the runner to port mapping, sorted by runner ID
+func (a *portAllocator) WriteTable(out io.Writer) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	runnerIDs := make([]string, 0, len(a.ports))
+	for runnerID := range a.ports {
+		runnerIDs = append(runnerIDs, runnerID)
+	}
+	sort.Strings(runnerIDs)
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "RUNNER\tLOCAL PORT\tPOD")
+	for _, runnerID := range runnerIDs {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", runnerID, a.ports[runnerID], runnerPodName(runnerID))
+	}
+	w.Flush()
+}
+
+// runnerPodName matches the pod name used by grad: grad-runner-{runnerID}
+func runnerPodName(runnerID string) string {
+	return fmt.Sprintf("grad-runner-%s", runnerID)
+}
+
+// hostnameProbe returns the hostname of the SSH server behind a local port
+type hostnameProbe func(localPort int) (string, error)
+
+// sshHostnameProbe runs `hostname` over SSH through the port-forward
+func sshHostnameProbe(localPort int) (string, error) {
+	cmd := exec.Command("ssh",
+		"-p", strconv.Itoa(localPort),
+		"-o", "UserKnownHostsFile=/dev/null",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "PasswordAuthentication=no",
+		"-o", "IdentitiesOnly=yes",
+		"-o", "ConnectTimeout=10",
+		"-o", "LogLevel=ERROR",
+		"root@localhost", "hostname",
+	)
+	logger.Debugf("Executing: %s", strings.Join(cmd.Args, " "))
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("ssh hostname probe failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// verifyRunnerHost checks that a local port reaches the runner's pod, whose hostname is its pod name
+func verifyRunnerHost(runnerID string, localPort int, probe hostnameProbe) error {
+	hostname, err := probe(localPort)
+	if err != nil {
+		return err
+	}
+
+	expected := runnerPodName(runnerID)
+	if hostname != expected {
+		return fmt.Errorf("localhost:%d reached host %q, expected %q", localPort, hostname, expected)
+	}
+	return nil
+}
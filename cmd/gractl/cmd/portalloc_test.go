@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPortAllocatorConcurrent(t *testing.T) {
+	tests := []struct {
+		name      string
+		portRange string
+	}{
+		{"OS-assigned ports", ""},
+		{"Port range", "30000-30999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ports, err := newPortAllocator(tt.portRange)
+			if err != nil {
+				t.Fatalf("newPortAllocator failed: %v", err)
+			}
+
+			const runners = 20
+			results := make([]int, runners)
+			errs := make([]error, runners)
+			var wg sync.WaitGroup
+			for i := 0; i < runners; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					results[i], errs[i] = ports.Allocate(fmt.Sprintf("runner-%d", i))
+				}(i)
+			}
+			wg.Wait()
+
+			seen := make(map[int]string)
+			for i, port := range results {
+				if errs[i] != nil {
+					t.Fatalf("Allocate failed: %v", errs[i])
+				}
+				runnerID := fmt.Sprintf("runner-%d", i)
+				if other, ok := seen[port]; ok {
+					t.Errorf("Expected unique ports, got %d for both %s and %s", port, other, runnerID)
+				}
+				seen[port] = runnerID
+
+				// Allocating again returns the same port
+				if again, _ := ports.Allocate(runnerID); again != port {
+					t.Errorf("Expected %s to keep port %d, got %d", runnerID, port, again)
+				}
+			}
+
+			for i := 0; i < runners; i++ {
+				ports.Release(fmt.Sprintf("runner-%d", i))
+			}
+		})
+	}
+}
+
+func TestPortAllocatorReservesUntilHandoff(t *testing.T) {
+	ports, err := newPortAllocator("")
+	if err != nil {
+		t.Fatalf("newPortAllocator failed: %v", err)
+	}
+
+	port, err := ports.Allocate("runner-1")
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	if listener, err := net.Listen("tcp", addr); err == nil {
+		listener.Close()
+		t.Fatal("Expected the port to be reserved before handoff")
+	}
+
+	ports.Handoff("runner-1")
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("Expected the port to be free after handoff: %v", err)
+	}
+	listener.Close()
+}
+
+func TestPortAllocatorRangeExhausted(t *testing.T) {
+	// Find a free port to use as a one-port range
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	ports, err := newPortAllocator(fmt.Sprintf("%d-%d", port, port))
+	if err != nil {
+		t.Fatalf("newPortAllocator failed: %v", err)
+	}
+	if got, err := ports.Allocate("runner-1"); err != nil || got != port {
+		t.Fatalf("Expected port %d, got %d (%v)", port, got, err)
+	}
+
+	// Still assigned after handoff
+	ports.Handoff("runner-1")
+	if _, err := ports.Allocate("runner-2"); err == nil {
+		t.Error("Expected error when the range is exhausted")
+	}
+
+	ports.Release("runner-1")
+	if got, err := ports.Allocate("runner-2"); err != nil || got != port {
+		t.Errorf("Expected released port %d to be reused, got %d (%v)", port, got, err)
+	}
+	ports.Release("runner-2")
+}
+
+func TestNewPortAllocatorInvalidRange(t *testing.T) {
+	for _, portRange := range []string{"22000", "a-b", "23000-22000", "0-100", "60000-70000"} {
+		if _, err := newPortAllocator(portRange); err == nil {
+			t.Errorf("Expected error for port range %q", portRange)
+		}
+	}
+}
+
+func TestPortAllocatorWriteTable(t *testing.T) {
+	ports, err := newPortAllocator("")
+	if err != nil {
+		t.Fatalf("newPortAllocator failed: %v", err)
+	}
+	defer ports.Release("runner-a")
+	defer ports.Release("runner-b")
+
+	portB, _ := ports.Allocate("runner-b")
+	portA, _ := ports.Allocate("runner-a")
+
+	var out bytes.Buffer
+	ports.WriteTable(&out)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %q", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "runner-a") || !strings.Contains(lines[1], strconv.Itoa(portA)) || !strings.Contains(lines[1], "grad-runner-runner-a") {
+		t.Errorf("Expected runner-a row first, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "runner-b") || !strings.Contains(lines[2], strconv.Itoa(portB)) {
+		t.Errorf("Expected runner-b row second, got %q", lines[2])
+	}
+}
+
+func TestVerifyRunnerHost(t *testing.T) {
+	tests := []struct {
+		name        string
+		hostname    string
+		probeErr    error
+		expectError bool
+	}{
+		{"Matching pod", "grad-runner-runner-1", nil, false},
+		{"Other runner's pod", "grad-runner-runner-2", nil, true},
+		{"Probe failure", "", fmt.Errorf("connection refused"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := func(localPort int) (string, error) {
+				if localPort != 22001 {
+					t.Errorf("Expected probe on port 22001, got %d", localPort)
+				}
+				return tt.hostname, tt.probeErr
+			}
+
+			err := verifyRunnerHost("runner-1", 22001, probe)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error=%v, got %v", tt.expectError, err)
+			}
+			if tt.name == "Other runner's pod" && !strings.Contains(err.Error(), "grad-runner-runner-2") {
+				t.Errorf("Expected error to name the host reached, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// protectedMarker follows the table status of a runner protected from deletion
+const protectedMarker = " [locked]"
+
+// setRunnerProtected protects the runner of a protect or unprotect command, or removes its
+// protection, and prints it
+func setRunnerProtected(cmd *cobra.Command, ref string, protected bool) {
+	runnerID := resolveRunnerArg(cmd, grpcClient, ref)
+	ctx, cancel := rpcContext(commandContext(cmd))
+	defer cancel()
+	runner, err := grpcClient.SetRunnerProtected(ctx, runnerID, protected)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to update runner: %s\n", describeError(err))
+		os.Exit(1)
+	}
+	if protected {
+		logger.Infof("Runner %s is protected from deletion", runnerID)
+	} else {
+		logger.Infof("Runner %s is no longer protected from deletion", runnerID)
+	}
+
+	if err := PrintRunner(runner); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print runner: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// protectCmd represents the runners protect command
+var protectCmd = &cobra.Command{
+	Use:   "protect RUNNER_ID",
+	Short: "Protect a runner from deletion",
+	Long: `Protect a runner from deletion. Deleting a protected runner fails unless
+runners delete is given --override-protection, delete --all skips it, and the server's idle
+cleanup never deletes it. Protected runners are marked [locked] in runners list.
+
+Runners can also be protected when created, with runners create --protected.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setRunnerProtected(cmd, args[0], true)
+	},
+}
+
+// unprotectCmd represents the runners unprotect command
+var unprotectCmd = &cobra.Command{
+	Use:   "unprotect RUNNER_ID",
+	Short: "Remove a runner's protection from deletion",
+	Long: `Remove the protection runners protect gives a runner, so it can be deleted again and
+is subject to the server's idle cleanup.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setRunnerProtected(cmd, args[0], false)
+	},
+}
+
+func init() {
+	RunnersCmd.AddCommand(protectCmd)
+	RunnersCmd.AddCommand(unprotectCmd)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProtectCommands(t *testing.T) {
+	// runner-1 of the mock data is protected, runner-2 isn't
+	stdout, _ := runGractl(t, "runners", "list")
+	if !strings.Contains(stdout, "Running"+protectedMarker) || strings.Contains(stdout, "Creating"+protectedMarker) {
+		t.Errorf("Expected only runner-1 to be marked protected, got:\n%s", stdout)
+	}
+	stdout, _ = runGractl(t, "runners", "get", "runner-1")
+	if !strings.Contains(stdout, "\nProtected:  yes") {
+		t.Errorf("Expected runner-1 to show its protection, got:\n%s", stdout)
+	}
+
+	stdout, _ = runGractl(t, "runners", "unprotect", "runner-1", "-o", "json")
+	if !strings.Contains(stdout, `"protected": false`) {
+		t.Errorf("Expected runner-1 to be unprotected, got:\n%s", stdout)
+	}
+	stdout, _ = runGractl(t, "runners", "protect", "name/analytics", "-o", "json")
+	if !strings.Contains(stdout, `"protected": true`) {
+		t.Errorf("Expected runner-1 to be protected, got:\n%s", stdout)
+	}
+}
+
+func TestDeleteAllSkipsProtected(t *testing.T) {
+	stdout, _ := runGractl(t, "runners", "delete", "--all")
+	expected := "Skipped protected runner: runner-1\n" +
+		"Deleted runner: runner-2\n" +
+		"Successfully deleted 1 out of 2 runners, skipped 1 protected\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/strrl/gra/cmd/gractl/client"
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// parsePruneStatuses parses the --status values of runners prune, dropping duplicates
+func parsePruneStatuses(values []string) ([]gradv1.RunnerStatus, error) {
+	var statuses []gradv1.RunnerStatus
+	for _, value := range values {
+		status, err := ParseRunnerStatus(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		if status == gradv1.RunnerStatus_RUNNER_STATUS_UNSPECIFIED {
+			return nil, fmt.Errorf("status must not be empty")
+		}
+		if !slices.Contains(statuses, status) {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("at least one status is required")
+	}
+	return statuses, nil
+}
+
+// selectPruneRunners lists the runners with one of statuses created before olderThan ago,
+// oldest first. Protected runners are left out and counted, as are runners of unknown age.
+func selectPruneRunners(ctx context.Context, c client.RunnerClient, statuses []gradv1.RunnerStatus, olderThan time.Duration, now time.Time) ([]*gradv1.Runner, int, error) {
+	cutoff := now.Add(-olderThan).Unix()
+	var selected []*gradv1.Runner
+	protected := 0
+	for _, status := range statuses {
+		runners, err := c.ListAllRunners(ctx, &gradv1.ListRunnersRequest{Status: status})
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, runner := range runners {
+			// A server that ignores the status filter lists every runner
+			if runner.Status != status || runner.CreatedAt == 0 || runner.CreatedAt > cutoff {
+				continue
+			}
+			if runner.Protected {
+				protected++
+				continue
+			}
+			selected = append(selected, runner)
+		}
+	}
+	slices.SortFunc(selected, func(a, b *gradv1.Runner) int {
+		return cmp.Or(cmp.Compare(a.CreatedAt, b.CreatedAt), strings.Compare(a.Id, b.Id))
+	})
+	return selected, protected, nil
+}
+
+// pruneReason says what a pruned runner was left in: its error or status detail (pure function)
+func pruneReason(runner *gradv1.Runner) string {
+	switch {
+	case runner.ErrorReason != gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_UNSPECIFIED:
+		return formatDescription(formatRunnerError(runner), errorSuffixWidth)
+	case runner.StatusDetail != "":
+		return formatDescription(runner.StatusDetail, errorSuffixWidth)
+	default:
+		return "-"
+	}
+}
+
+// writePruneTable prints the runners of runners prune with the outcome of deleting each, then
+// a summary. results holds the deletions in the order of runners, nil for a dry run.
+func writePruneTable(out io.Writer, runners []*gradv1.Runner, results []deleteRunnerResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "RUNNER ID\tNAME\tSTATUS\tAGE\tREASON\tRESULT")
+	deleted := 0
+	for i, runner := range runners {
+		result := "would delete"
+		if results != nil {
+			switch r := results[i]; {
+			case r.Err != nil:
+				result = "failed: " + describeError(r.Err)
+			case len(r.Response.FailedResources) > 0:
+				result = "failed: " + strings.Join(r.Response.FailedResources, "; ")
+			default:
+				result = "deleted"
+				deleted++
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", runner.Id, runner.Name, formatStatus(runner.Status), formatAge(runner.CreatedAt), pruneReason(runner), result)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	var err error
+	if results == nil {
+		_, err = fmt.Fprintf(out, "Would delete %d runners\n", len(runners))
+	} else {
+		_, err = fmt.Fprintf(out, "Deleted %d out of %d runners\n", deleted, len(runners))
+	}
+	return err
+}
+
+// confirmPrune asks on out whether to delete count runners and reports whether the answer
+// read from in was yes
+func confirmPrune(in io.Reader, out io.Writer, count int) bool {
+	fmt.Fprintf(out, "Delete %d runners? [y/N]: ", count)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// pruneOptions are the flags of runners prune
+type pruneOptions struct {
+	Statuses  []gradv1.RunnerStatus
+	OlderThan time.Duration
+	DryRun    bool
+	// Yes deletes the runners without asking
+	Yes      bool
+	Parallel int
+}
+
+// prune deletes the runners selected by opts a few at a time and prints them to out with the
+// outcome, returning the exit code. Unless opts.Yes it lists them and asks on errOut first,
+// reading the answer from in; a dry run only lists them.
+func prune(ctx context.Context, c client.RunnerClient, opts pruneOptions, in io.Reader, out, errOut io.Writer) int {
+	listCtx, cancel := rpcContext(ctx)
+	defer cancel()
+	runners, protected, err := selectPruneRunners(listCtx, c, opts.Statuses, opts.OlderThan, time.Now())
+	if err != nil {
+		fmt.Fprintf(errOut, "Failed to list runners: %s\n", describeError(err))
+		return 1
+	}
+	if protected > 0 {
+		logger.Infof("Skipping %d protected runners", protected)
+	}
+	if len(runners) == 0 {
+		fmt.Fprintln(out, "No runners to prune")
+		return 0
+	}
+
+	if opts.DryRun || !opts.Yes {
+		if err := writePruneTable(out, runners, nil); err != nil {
+			fmt.Fprintf(errOut, "Failed to print runners: %v\n", err)
+			return 1
+		}
+		if opts.DryRun {
+			return 0
+		}
+		if !confirmPrune(in, errOut, len(runners)) {
+			fmt.Fprintln(errOut, "Nothing deleted")
+			return 0
+		}
+	}
+
+	runnerIDs := make([]string, len(runners))
+	for i, runner := range runners {
+		runnerIDs[i] = runner.Id
+	}
+	results := deleteRunners(ctx, c, runnerIDs, opts.Parallel, requestTimeout)
+	if err := writePruneTable(out, runners, results); err != nil {
+		fmt.Fprintf(errOut, "Failed to print runners: %v\n", err)
+		return 1
+	}
+
+	codes := make([]int, len(results))
+	for i, result := range results {
+		codes[i] = result.exitCode()
+	}
+	return batchExitCode(codes)
+}
+
+// pruneCmd deletes old stopped and errored runners
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old stopped and errored runners",
+	Long: `Delete every runner with one of the --status values that was created more than
+--older-than ago, a few at a time, and print a table of the runners with their age, what
+they were left in and whether they were deleted.
+
+gractl lists the runners and asks before deleting them unless --yes is given. --dry-run only
+lists them, without deleting anything. Protected runners are never pruned.
+
+gractl exits with 1 if any deletion failed, and with 0 if there was nothing to prune.
+
+  gractl runners prune --dry-run
+  gractl runners prune --older-than 2h --status error --yes`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+		statusValues, _ := cmd.Flags().GetStringSlice("status")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+
+		statuses, err := parsePruneStatuses(statusValues)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid status: %v\n", err)
+			os.Exit(1)
+		}
+		if olderThan < 0 {
+			fmt.Fprintf(os.Stderr, "--older-than must not be negative, got %s\n", olderThan)
+			os.Exit(1)
+		}
+		if parallel < 1 {
+			fmt.Fprintf(os.Stderr, "--parallel must be at least 1\n")
+			os.Exit(1)
+		}
+
+		code := prune(commandContext(cmd), grpcClient, pruneOptions{
+			Statuses:  statuses,
+			OlderThan: olderThan,
+			DryRun:    dryRun,
+			Yes:       yes,
+			Parallel:  parallel,
+		}, cmd.InOrStdin(), os.Stdout, os.Stderr)
+		if code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	pruneCmd.Flags().Duration("older-than", 24*time.Hour, "Only delete runners created longer ago than this, e.g. 2h")
+	pruneCmd.Flags().StringSliceP("status", "s", []string{"stopped", "error"}, "Only delete runners with one of these statuses (comma-separated or repeatable)")
+	pruneCmd.Flags().Bool("dry-run", false, "Only list the runners that would be deleted")
+	pruneCmd.Flags().BoolP("yes", "y", false, "Delete the runners without asking")
+	pruneCmd.Flags().IntP("parallel", "p", 5, "Number of runners to delete at a time")
+
+	RunnersCmd.AddCommand(pruneCmd)
+}
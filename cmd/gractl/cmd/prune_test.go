@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/strrl/gra/cmd/gractl/client"
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// newPruneFixture returns a fixture with runners of every kind prune has to tell apart:
+// runner-1 to runner-3 qualify for the default --status and --older-than, the others don't
+func newPruneFixture(t *testing.T) *client.Fixture {
+	t.Helper()
+	ago := func(d time.Duration) int64 { return time.Now().Add(-d).Unix() }
+	fixture, err := client.ParseFixture(fmt.Appendf(nil, `{"runners": [
+		{"id": "runner-1", "name": "old", "status": "RUNNER_STATUS_STOPPED", "created_at": %d},
+		{"id": "runner-2", "status": "RUNNER_STATUS_ERROR", "created_at": %d, "error_reason": "RUNNER_ERROR_REASON_IMAGE_PULL", "error_message": "ErrImagePull"},
+		{"id": "runner-3", "status": "RUNNER_STATUS_ERROR", "created_at": %d, "status_detail": "pod failed"},
+		{"id": "runner-4", "status": "RUNNER_STATUS_RUNNING", "created_at": %d},
+		{"id": "runner-5", "status": "RUNNER_STATUS_STOPPED", "created_at": %d},
+		{"id": "runner-6", "status": "RUNNER_STATUS_STOPPED", "created_at": %d, "protected": true}
+	]}`, ago(72*time.Hour), ago(49*time.Hour), ago(30*time.Hour), ago(96*time.Hour), ago(time.Hour), ago(96*time.Hour)))
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	return fixture
+}
+
+// deleteRecordingClient records the runners deleted through it and fails to delete those in fail
+type deleteRecordingClient struct {
+	*client.Fixture
+	fail map[string]bool
+
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (c *deleteRecordingClient) DeleteRunner(ctx context.Context, runnerID string, opts ...gradclient.DeleteOption) (*gradv1.DeleteRunnerResponse, error) {
+	c.mu.Lock()
+	c.deleted = append(c.deleted, runnerID)
+	c.mu.Unlock()
+	if c.fail[runnerID] {
+		return nil, status.Error(codes.Internal, "failed to delete pod")
+	}
+	return c.Fixture.DeleteRunner(ctx, runnerID, opts...)
+}
+
+// defaultPruneOptions are the options of runners prune without flags
+func defaultPruneOptions() pruneOptions {
+	return pruneOptions{
+		Statuses:  []gradv1.RunnerStatus{gradv1.RunnerStatus_RUNNER_STATUS_STOPPED, gradv1.RunnerStatus_RUNNER_STATUS_ERROR},
+		OlderThan: 24 * time.Hour,
+		Parallel:  2,
+	}
+}
+
+func TestPruneDryRun(t *testing.T) {
+	c := &deleteRecordingClient{Fixture: newPruneFixture(t)}
+	opts := defaultPruneOptions()
+	opts.DryRun = true
+
+	var out, errOut bytes.Buffer
+	if code := prune(context.Background(), c, opts, strings.NewReader("y\n"), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	if len(c.deleted) != 0 {
+		t.Errorf("Expected a dry run not to delete anything, deleted %v", c.deleted)
+	}
+
+	// Oldest first, each with its age and what it was left in
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 || lines[4] != "Would delete 3 runners" {
+		t.Fatalf("Expected 3 runners and a summary, got:\n%s", out.String())
+	}
+	for i, expected := range []string{
+		"runner-1 old Stopped 3d - would delete",
+		"runner-2 Error 2d image_pull: ErrImagePull would delete",
+		"runner-3 Error 1d pod failed would delete",
+	} {
+		if got := strings.Join(strings.Fields(lines[i+1]), " "); got != expected {
+			t.Errorf("Expected row %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestPruneConfirmation(t *testing.T) {
+	c := &deleteRecordingClient{Fixture: newPruneFixture(t)}
+
+	// Anything but yes, including no answer at all, deletes nothing
+	var out, errOut bytes.Buffer
+	if code := prune(context.Background(), c, defaultPruneOptions(), strings.NewReader(""), &out, &errOut); code != 0 || len(c.deleted) != 0 {
+		t.Fatalf("Expected nothing deleted without an answer, got exit code %d and %v", code, c.deleted)
+	}
+	if !strings.Contains(errOut.String(), "Delete 3 runners? [y/N]: Nothing deleted") {
+		t.Errorf("Expected to be asked, got %q", errOut.String())
+	}
+
+	out.Reset()
+	if code := prune(context.Background(), c, defaultPruneOptions(), strings.NewReader("yes\n"), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if !strings.HasSuffix(out.String(), "Deleted 3 out of 3 runners\n") {
+		t.Errorf("Expected the runners to be deleted, got:\n%s", out.String())
+	}
+}
+
+func TestPrunePartialFailure(t *testing.T) {
+	c := &deleteRecordingClient{Fixture: newPruneFixture(t), fail: map[string]bool{"runner-2": true}}
+	opts := defaultPruneOptions()
+	opts.Yes = true
+
+	var out, errOut bytes.Buffer
+	if code := prune(context.Background(), c, opts, strings.NewReader(""), &out, &errOut); code != 1 {
+		t.Errorf("Expected exit code 1 for the failed deletion, got %d", code)
+	}
+	if strings.Contains(errOut.String(), "[y/N]") {
+		t.Errorf("Expected --yes not to ask, got %q", errOut.String())
+	}
+	if len(c.deleted) != 3 {
+		t.Errorf("Expected runner-1 to runner-3 to be deleted, got %v", c.deleted)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "failed: rpc error: code = Internal desc = failed to delete pod") || !strings.HasSuffix(output, "Deleted 2 out of 3 runners\n") {
+		t.Errorf("Expected runner-2 to fail, got:\n%s", output)
+	}
+	runners, _ := c.ListAllRunners(context.Background(), &gradv1.ListRunnersRequest{})
+	var left []string
+	for _, runner := range runners {
+		left = append(left, runner.Id)
+	}
+	if strings.Join(left, ",") != "runner-2,runner-4,runner-5,runner-6" {
+		t.Errorf("Expected runner-2 and the runners not pruned to be left, got %v", left)
+	}
+}
+
+func TestParsePruneStatuses(t *testing.T) {
+	statuses, err := parsePruneStatuses([]string{"error", " stopped", "Error"})
+	if err != nil || len(statuses) != 2 || statuses[0] != gradv1.RunnerStatus_RUNNER_STATUS_ERROR || statuses[1] != gradv1.RunnerStatus_RUNNER_STATUS_STOPPED {
+		t.Errorf("Expected error and stopped, got %v (%v)", statuses, err)
+	}
+	for _, values := range [][]string{nil, {""}, {"gone"}} {
+		if _, err := parsePruneStatuses(values); err == nil {
+			t.Errorf("Expected %q to be rejected", values)
+		}
+	}
+}
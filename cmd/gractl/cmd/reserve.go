@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// reservationLayouts are the --until formats besides a bare HH:MM, which means today
+var reservationLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// parseReservationEnd turns --until or --for into the end of a reservation, the zero time if
+// neither is set. --until takes HH:MM for today or a date and time, in now's time zone unless
+// it says otherwise. (pure function)
+func parseReservationEnd(until string, forDuration time.Duration, now time.Time) (time.Time, error) {
+	if until != "" && forDuration != 0 {
+		return time.Time{}, fmt.Errorf("--until and --for can't be used together")
+	}
+	if forDuration < 0 {
+		return time.Time{}, fmt.Errorf("--for must be positive, got %s", forDuration)
+	}
+	if forDuration > 0 {
+		return now.Add(forDuration), nil
+	}
+	if until == "" {
+		return time.Time{}, nil
+	}
+
+	if clock, err := time.ParseInLocation("15:04", until, now.Location()); err == nil {
+		end := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !end.After(now) {
+			return time.Time{}, fmt.Errorf("%s has passed today; pass a date and time, e.g. %s", until, now.AddDate(0, 0, 1).Format("2006-01-02T")+until)
+		}
+		return end, nil
+	}
+	for _, layout := range reservationLayouts {
+		if end, err := time.ParseInLocation(layout, until, now.Location()); err == nil {
+			return end, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected HH:MM or YYYY-MM-DDTHH:MM)", until)
+}
+
+// extendReservation returns the end of a reservation that ends at reservedUntil, a Unix
+// timestamp that is 0 for none, after adding by to it, counting from now if it has already
+// ended (pure function)
+func extendReservation(reservedUntil int64, by time.Duration, now time.Time) time.Time {
+	start := now
+	if end := time.Unix(reservedUntil, 0); reservedUntil != 0 && end.After(now) {
+		start = end
+	}
+	return start.Add(by)
+}
+
+// formatReserved shows how long a runner stays reserved, "-" if it isn't (pure function)
+func formatReserved(reservedUntil int64, now time.Time) string {
+	left := time.Unix(reservedUntil, 0).Sub(now)
+	switch {
+	case reservedUntil == 0 || left <= 0:
+		return "-"
+	case left >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(left.Hours()/24))
+	case left >= time.Hour:
+		return fmt.Sprintf("%dh%dm", int(left.Hours()), int(left.Minutes())%60)
+	case left >= time.Minute:
+		return fmt.Sprintf("%dm", int(left.Minutes()))
+	default:
+		return "<1m"
+	}
+}
+
+// addReservationFlags adds --until and --for to a command that reserves runners
+func addReservationFlags(cmd *cobra.Command, forUsage string) {
+	cmd.Flags().String("until", "", "Keep the runner from idle cleanup until this time, e.g. 18:00 or 2026-10-17T09:00")
+	cmd.Flags().Duration("for", 0, forUsage)
+	cmd.MarkFlagsMutuallyExclusive("until", "for")
+}
+
+// extendCmd represents the runners extend command
+var extendCmd = &cobra.Command{
+	Use:   "extend RUNNER_ID --for DURATION | --until TIME",
+	Short: "Extend a runner's reservation",
+	Long: `Keep a runner from idle cleanup for longer. --for adds to the current reservation, or to
+now if the runner isn't reserved; --until sets when the reservation ends. The server caps
+reservations at its RUNNER_MAX_RESERVATION from now. Once a reservation ends, the runner is
+deleted after the usual idle timeout without commands.
+
+Examples:
+  gractl runners extend runner-1 --for 2h
+  gractl runners extend runner-1 --until 18:00`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runnerID := resolveRunnerArg(cmd, grpcClient, args[0])
+		until, _ := cmd.Flags().GetString("until")
+		forDuration, _ := cmd.Flags().GetDuration("for")
+		if until == "" && forDuration == 0 {
+			fmt.Fprintf(os.Stderr, "Nothing to extend: pass --for or --until\n")
+			os.Exit(ExitCodeUsage)
+		}
+
+		now := time.Now()
+		end, err := parseReservationEnd(until, 0, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid reservation: %v\n", err)
+			os.Exit(ExitCodeUsage)
+		}
+		ctx := commandContext(cmd)
+		if forDuration != 0 {
+			if forDuration < 0 {
+				fmt.Fprintf(os.Stderr, "Invalid reservation: --for must be positive, got %s\n", forDuration)
+				os.Exit(ExitCodeUsage)
+			}
+			getCtx, cancel := rpcContext(ctx)
+			defer cancel()
+			runner, err := grpcClient.GetRunner(getCtx, runnerID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get runner: %s\n", describeError(err))
+				os.Exit(1)
+			}
+			end = extendReservation(runner.ReservedUntil, forDuration, now)
+		}
+
+		reserveCtx, cancel := rpcContext(ctx)
+		defer cancel()
+		runner, err := grpcClient.ReserveRunner(reserveCtx, runnerID, end)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to extend reservation: %s\n", describeError(err))
+			os.Exit(1)
+		}
+		logger.Infof("Runner %s is reserved until %s", runnerID, end.Format(time.RFC3339))
+
+		if err := PrintRunner(runner); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print runner: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	addReservationFlags(extendCmd, "How much longer to keep the runner, e.g. 2h")
+	RunnersCmd.AddCommand(extendCmd)
+}
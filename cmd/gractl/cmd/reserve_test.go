@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReservationEnd(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	now := time.Date(2026, 10, 16, 12, 30, 0, 0, zone)
+
+	tests := []struct {
+		name          string
+		until         string
+		forDuration   time.Duration
+		expected      time.Time
+		expectMessage string
+	}{
+		{"Neither", "", 0, time.Time{}, ""},
+		{"For", "", 4 * time.Hour, now.Add(4 * time.Hour), ""},
+		{"Until later today", "18:00", 0, time.Date(2026, 10, 16, 18, 0, 0, 0, zone), ""},
+		{"Until a date", "2026-10-17T09:00", 0, time.Date(2026, 10, 17, 9, 0, 0, 0, zone), ""},
+		{"Until RFC 3339", "2026-10-17T09:00:00Z", 0, time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC), ""},
+		{"Until earlier today", "09:00", 0, time.Time{}, "09:00 has passed today; pass a date and time, e.g. 2026-10-17T09:00"},
+		{"Until garbage", "tonight", 0, time.Time{}, `invalid time "tonight"`},
+		{"Negative for", "", -time.Hour, time.Time{}, "--for must be positive"},
+		{"Both", "18:00", time.Hour, time.Time{}, "can't be used together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReservationEnd(tt.until, tt.forDuration, now)
+			if tt.expectMessage != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectMessage) {
+					t.Errorf("Expected an error containing %q, got %v", tt.expectMessage, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExtendReservation(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		reservedUntil int64
+		expected      time.Time
+	}{
+		{"Not reserved", 0, now.Add(2 * time.Hour)},
+		{"Reserved", now.Add(time.Hour).Unix(), now.Add(3 * time.Hour)},
+		{"Reservation ended", now.Add(-time.Hour).Unix(), now.Add(2 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extendReservation(tt.reservedUntil, 2*time.Hour, now); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatReserved(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		left     time.Duration
+		expected string
+	}{
+		{"Days", 50 * time.Hour, "2d"},
+		{"Hours", 3*time.Hour + 20*time.Minute, "3h20m"},
+		{"Minutes", 45 * time.Minute, "45m"},
+		{"Seconds", 30 * time.Second, "<1m"},
+		{"Ended", -time.Minute, "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatReserved(now.Add(tt.left).Unix(), now); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+	if got := formatReserved(0, now); got != "-" {
+		t.Errorf("Expected - without a reservation, got %q", got)
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/strrl/gra/cmd/gractl/client"
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// runnerNamePrefix marks a RUNNER_ID argument naming the runner instead, e.g. name/train-1
+const runnerNamePrefix = "name/"
+
+// Exit codes of looking up a runner by name, with runners id-of or a name/NAME argument
+const (
+	// ExitCodeNameNotFound means no runner has the name
+	ExitCodeNameNotFound = 3
+	// ExitCodeNameAmbiguous means several runners have the name, so it doesn't say which one is meant
+	ExitCodeNameAmbiguous = 4
+)
+
+// maxNameCandidates is how many of the runners sharing a name an ambiguity error lists
+const maxNameCandidates = 10
+
+// runnerNameError is a name that no runner has, or that the runners in Candidates share
+type runnerNameError struct {
+	Name       string
+	Candidates []*gradv1.Runner
+	// Total counts the runners with the name, which may be more than the candidates listed
+	Total int32
+}
+
+func (e *runnerNameError) Error() string {
+	if e.Total == 0 {
+		return fmt.Sprintf("no runner is named %q", e.Name)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d runners are named %q, pass the ID of one of them instead:", e.Total, e.Name)
+	for _, runner := range e.Candidates {
+		fmt.Fprintf(&b, "\n  %s  %s  %s", runner.Id, formatStatus(runner.Status), formatAge(runner.CreatedAt))
+	}
+	if more := int(e.Total) - len(e.Candidates); more > 0 {
+		fmt.Fprintf(&b, "\n  and %d more", more)
+	}
+	return b.String()
+}
+
+// exitCode returns ExitCodeNameNotFound or ExitCodeNameAmbiguous
+func (e *runnerNameError) exitCode() int {
+	if e.Total == 0 {
+		return ExitCodeNameNotFound
+	}
+	return ExitCodeNameAmbiguous
+}
+
+// runnerIDByName returns the ID of the only runner named name, asking grad for the runners
+// with that name in a single ListRunners call. Names aren't unique, so an error lists the
+// runners sharing one.
+func runnerIDByName(ctx context.Context, c client.RunnerClient, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("runner name must not be empty")
+	}
+	runners, total, err := c.ListRunners(ctx, &gradv1.ListRunnersRequest{Name: name, Limit: maxNameCandidates})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up runner %q: %s", name, describeError(err))
+	}
+	// A grad predating the name filter ignores it and lists every runner
+	for _, runner := range runners {
+		if runner.Name != name {
+			return "", fmt.Errorf("grad can't look up runners by name, upgrade it or pass the runner ID")
+		}
+	}
+	if total != 1 {
+		return "", &runnerNameError{Name: name, Candidates: runners, Total: total}
+	}
+	return runners[0].Id, nil
+}
+
+// resolveRunnerRef returns the ID of the runner a RUNNER_ID argument refers to: the argument
+// itself, or for name/NAME the ID of the only runner named NAME
+func resolveRunnerRef(ctx context.Context, c client.RunnerClient, ref string) (string, error) {
+	name, ok := strings.CutPrefix(ref, runnerNamePrefix)
+	if !ok {
+		return ref, nil
+	}
+	return runnerIDByName(ctx, c, name)
+}
+
+// resolveRunnerRefs resolves each RUNNER_ID argument as resolveRunnerRef does, in order
+func resolveRunnerRefs(ctx context.Context, c client.RunnerClient, refs []string) ([]string, error) {
+	runnerIDs := make([]string, len(refs))
+	for i, ref := range refs {
+		runnerID, err := resolveRunnerRef(ctx, c, ref)
+		if err != nil {
+			return nil, err
+		}
+		runnerIDs[i] = runnerID
+	}
+	return runnerIDs, nil
+}
+
+// runnerRefExitCode returns gractl's exit code for a RUNNER_ID argument that couldn't be resolved
+func runnerRefExitCode(err error) int {
+	var nameErr *runnerNameError
+	if errors.As(err, &nameErr) {
+		return nameErr.exitCode()
+	}
+	return 1
+}
+
+// resolveRunnerArgs resolves the RUNNER_ID arguments of cmd with c within the request
+// timeout, exiting gractl if one of them can't be
+func resolveRunnerArgs(cmd *cobra.Command, c client.RunnerClient, refs ...string) []string {
+	ctx, cancel := rpcContext(commandContext(cmd))
+	defer cancel()
+	runnerIDs, err := resolveRunnerRefs(ctx, c, refs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(runnerRefExitCode(err))
+	}
+	return runnerIDs
+}
+
+// resolveRunnerArg resolves one RUNNER_ID argument of cmd, see resolveRunnerArgs
+func resolveRunnerArg(cmd *cobra.Command, c client.RunnerClient, ref string) string {
+	return resolveRunnerArgs(cmd, c, ref)[0]
+}
+
+// idOfCmd represents the runners id-of command
+var idOfCmd = &cobra.Command{
+	Use:   "id-of NAME",
+	Short: "Print the ID of the runner with a name",
+	Long: `Print the ID of the runner named NAME, for scripts and tools that take runner IDs.
+
+Names aren't unique: if several runners share NAME, their IDs are listed on stderr and gractl
+exits with 4. It exits with 3 if no runner has the name.
+
+Every command taking a RUNNER_ID also takes name/NAME to address a runner by name the same way:
+  gractl runners exec name/train-1 -- nvidia-smi
+  ID=$(gractl runners id-of train-1) || exit`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		runnerID, err := runnerIDByName(ctx, grpcClient, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(runnerRefExitCode(err))
+		}
+		fmt.Println(runnerID)
+	},
+}
+
+func init() {
+	RunnersCmd.AddCommand(idOfCmd)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/strrl/gra/cmd/gractl/client"
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// newNamedFixture returns a fixture with runner-1 named analytics and runner-2 and runner-3
+// both named train
+func newNamedFixture(t *testing.T) *client.Fixture {
+	t.Helper()
+	fixture, err := client.ParseFixture([]byte(`{"runners": [
+		{"id": "runner-1", "name": "analytics", "status": "RUNNER_STATUS_RUNNING"},
+		{"id": "runner-2", "name": "train", "status": "RUNNER_STATUS_RUNNING"},
+		{"id": "runner-3", "name": "train", "status": "RUNNER_STATUS_STOPPED"}
+	]}`))
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	return fixture
+}
+
+func TestRunnerIDByName(t *testing.T) {
+	fixture := newNamedFixture(t)
+
+	t.Run("Unique", func(t *testing.T) {
+		runnerID, err := runnerIDByName(context.Background(), fixture, "analytics")
+		if err != nil || runnerID != "runner-1" {
+			t.Errorf("Expected runner-1, got %q (%v)", runnerID, err)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := runnerIDByName(context.Background(), fixture, "analytic")
+		var nameErr *runnerNameError
+		if !errors.As(err, &nameErr) || runnerRefExitCode(err) != ExitCodeNameNotFound {
+			t.Fatalf("Expected a not found error, got %v", err)
+		}
+		if err.Error() != `no runner is named "analytic"` {
+			t.Errorf("Unexpected message: %v", err)
+		}
+	})
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		_, err := runnerIDByName(context.Background(), fixture, "train")
+		if runnerRefExitCode(err) != ExitCodeNameAmbiguous {
+			t.Fatalf("Expected an ambiguity error, got %v", err)
+		}
+		message := err.Error()
+		if !strings.HasPrefix(message, `2 runners are named "train"`) || !strings.Contains(message, "\n  runner-2  Running") || !strings.Contains(message, "\n  runner-3  Stopped") {
+			t.Errorf("Expected both candidates listed, got:\n%s", message)
+		}
+	})
+}
+
+func TestResolveRunnerRefs(t *testing.T) {
+	fixture := newNamedFixture(t)
+
+	runnerIDs, err := resolveRunnerRefs(context.Background(), fixture, []string{"runner-3", "name/analytics", "analytics"})
+	expected := []string{"runner-3", "runner-1", "analytics"}
+	if err != nil || !reflect.DeepEqual(runnerIDs, expected) {
+		t.Errorf("Expected %v, got %v (%v)", expected, runnerIDs, err)
+	}
+
+	if _, err := resolveRunnerRefs(context.Background(), fixture, []string{"runner-1", "name/train"}); runnerRefExitCode(err) != ExitCodeNameAmbiguous {
+		t.Errorf("Expected an ambiguity error, got %v", err)
+	}
+	if _, err := resolveRunnerRefs(context.Background(), fixture, []string{"name/"}); err == nil || runnerRefExitCode(err) != 1 {
+		t.Errorf("Expected an empty name to be rejected, got %v", err)
+	}
+}
+
+// nameBlindClient lists runners like a grad without the name filter, ignoring it
+type nameBlindClient struct {
+	*client.Fixture
+}
+
+func (c nameBlindClient) ListRunners(ctx context.Context, req *gradv1.ListRunnersRequest) ([]*gradv1.Runner, int32, error) {
+	req.Name = ""
+	return c.Fixture.ListRunners(ctx, req)
+}
+
+func TestRunnerIDByNameOldServer(t *testing.T) {
+	_, err := runnerIDByName(context.Background(), nameBlindClient{newNamedFixture(t)}, "train")
+	if err == nil || !strings.Contains(err.Error(), "can't look up runners by name") {
+		t.Errorf("Expected the lookup to fail rather than pick a runner, got %v", err)
+	}
+}
+
+func TestIDOfCommand(t *testing.T) {
+	stdout, _ := runGractl(t, "runners", "id-of", "analytics")
+	if stdout != "runner-1\n" {
+		t.Errorf("Expected runner-1, got %q", stdout)
+	}
+
+	// A name/NAME argument gets the same runner as its ID
+	stdout, _ = runGractl(t, "runners", "get", "name/analytics", "-o", "json")
+	assertGolden(t, "runner", []byte(stdout))
+}
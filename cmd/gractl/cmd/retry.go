@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// retryPolicy decides whether and when exec and execute run a failed command again
+type retryPolicy struct {
+	// Retries is how many times the command is run again after its first attempt
+	Retries int
+	// Delay is the wait before the first retry
+	Delay time.Duration
+	// Backoff multiplies the wait after every retry; 1 keeps it constant
+	Backoff float64
+	// ExitCodes limits retries to these exit codes; when empty any non-zero exit code is retried
+	ExitCodes []int
+	// OnStreamError also retries when the command couldn't be run or its output stream broke
+	OnStreamError bool
+}
+
+// addRetryFlags adds --retry and the flags tuning it to a command that runs a remote command
+func addRetryFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("retry", 0, "Run the command up to N more times while it exits non-zero")
+	cmd.Flags().Duration("retry-delay", time.Second, "Wait before the first retry")
+	cmd.Flags().Float64("retry-backoff", 2, "Multiply the wait by this after every retry (1 keeps it constant)")
+	cmd.Flags().IntSlice("retry-on", nil, "Only retry these exit codes, e.g. 1,255 (defaults to any non-zero exit code)")
+	cmd.Flags().Bool("retry-on-stream-error", false, "Also retry when the command couldn't be run or its output stream broke")
+}
+
+// addResumeFlag adds --no-resume to a command that runs a remote command
+func addResumeFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-resume", false, "Fail as soon as the output stream breaks instead of reattaching to the command, e.g. while grad restarts")
+}
+
+// addCorrelationEnvFlag adds --no-correlation-env to a command that runs a remote command
+func addCorrelationEnvFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-correlation-env", false, "Don't set GRAD_RUNNER_ID, GRAD_EXEC_ID and TRACEPARENT in the command's environment")
+}
+
+// retryPolicyFromFlags reads the retry flags added by addRetryFlags
+func retryPolicyFromFlags(cmd *cobra.Command) (retryPolicy, error) {
+	flags := cmd.Flags()
+	retries, _ := flags.GetInt("retry")
+	delay, _ := flags.GetDuration("retry-delay")
+	backoff, _ := flags.GetFloat64("retry-backoff")
+	exitCodes, _ := flags.GetIntSlice("retry-on")
+	onStreamError, _ := flags.GetBool("retry-on-stream-error")
+	policy := retryPolicy{
+		Retries:       retries,
+		Delay:         delay,
+		Backoff:       backoff,
+		ExitCodes:     exitCodes,
+		OnStreamError: onStreamError,
+	}
+
+	if retries == 0 {
+		for _, name := range []string{"retry-delay", "retry-backoff", "retry-on", "retry-on-stream-error"} {
+			if flags.Changed(name) {
+				return retryPolicy{}, fmt.Errorf("--%s needs --retry", name)
+			}
+		}
+	}
+	return policy, policy.validate()
+}
+
+// validate checks the policy's values (pure function)
+func (p retryPolicy) validate() error {
+	if p.Retries < 0 {
+		return errors.New("--retry must not be negative")
+	}
+	if p.Delay < 0 {
+		return errors.New("--retry-delay must not be negative")
+	}
+	if p.Backoff < 1 {
+		return errors.New("--retry-backoff must be at least 1")
+	}
+	for _, code := range p.ExitCodes {
+		if code < 1 || code > 255 {
+			return fmt.Errorf("--retry-on exit code %d is outside 1-255", code)
+		}
+	}
+	return nil
+}
+
+// shouldRetry decides whether an attempt that ended with exit and streamErr is retried. A
+// request the server rejected fails the same way every time, so it is never retried, and
+// neither is one cancelled by Ctrl+C. (pure function)
+func (p retryPolicy) shouldRetry(exit *gradv1.ExecuteCommandStreamResponse, streamErr error) bool {
+	if errors.Is(streamErr, errInterrupted) {
+		return false
+	}
+	if streamErr != nil || exit == nil {
+		return p.OnStreamError && (streamErr == nil || infraExitCode(streamErr) != ExitCodeUsage)
+	}
+	if exit.ExitCode == 0 {
+		return false
+	}
+	return len(p.ExitCodes) == 0 || slices.Contains(p.ExitCodes, int(exit.ExitCode))
+}
+
+// delay returns the wait before the retry-th retry, counting from 1 (pure function)
+func (p retryPolicy) delay(retry int) time.Duration {
+	return time.Duration(float64(p.Delay) * math.Pow(p.Backoff, float64(retry-1)))
+}
+
+// describeAttempt explains how an attempt that is about to be retried ended (pure function)
+func describeAttempt(exit *gradv1.ExecuteCommandStreamResponse, streamErr error) string {
+	switch {
+	case streamErr != nil:
+		return "failed: " + describeError(streamErr)
+	case exit == nil:
+		return "ended without an exit status"
+	}
+	if message := exitFailureMessage(exit); message != "" {
+		return fmt.Sprintf("exited with code %d (%s)", exit.ExitCode, message)
+	}
+	return fmt.Sprintf("exited with code %d", exit.ExitCode)
+}
+
+// runWithRetry runs attempt until it succeeds, ends in a way p doesn't retry or retries run
+// out, and returns how the last attempt ended. Before every retry it writes a banner about the
+// previous attempt to banner and waits with sleep.
+func runWithRetry(p retryPolicy, banner io.Writer, sleep func(time.Duration), attempt func() (*gradv1.ExecuteCommandStreamResponse, error)) (*gradv1.ExecuteCommandStreamResponse, error) {
+	exit, err := attempt()
+	for retry := 1; retry <= p.Retries && p.shouldRetry(exit, err); retry++ {
+		delay := p.delay(retry)
+		fmt.Fprintf(banner, "--- attempt %d/%d %s, retrying in %s ---\n", retry, p.Retries+1, describeAttempt(exit, err), delay)
+		sleep(delay)
+		exit, err = attempt()
+	}
+	return exit, err
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+// attempt scripts an attempt of a command printing output and exiting with exitCode
+func attempt(output string, exitCode int32) gradtest.ExecScript {
+	return gradtest.ExecScript{Frames: gradtest.Output{Stdout: output, ExitCode: exitCode}.Frames()}
+}
+
+// newFlakyClient returns a client whose command streams in runner-1 go through attempts in turn
+func newFlakyClient(attempts ...gradtest.ExecScript) (*gradclient.Client, *gradtest.Fake) {
+	fake := gradtest.NewFake()
+	fake.AddRunners(gradtest.NewRunner("runner-1"))
+	fake.QueueExec(attempts...)
+	return gradclient.NewFromServices(fake, fake, fake), fake
+}
+
+// attempts counts the command streams fake was asked for
+func attempts(fake *gradtest.Fake) int {
+	return len(fake.Requests(gradv1.RunnerService_ExecuteCommandStream_FullMethodName))
+}
+
+// flakyRun is the outcome of runFlaky: the last attempt's result, the output of every
+// attempt, the banners and the waits between attempts
+type flakyRun struct {
+	exit   *gradv1.ExecuteCommandStreamResponse
+	err    error
+	output string
+	banner string
+	waits  []time.Duration
+}
+
+// runFlaky runs a command in c's runner-1 with policy, reusing c for every attempt
+func runFlaky(c *gradclient.Client, policy retryPolicy) flakyRun {
+	var output, banner bytes.Buffer
+	var run flakyRun
+	sleep := func(d time.Duration) { run.waits = append(run.waits, d) }
+	run.exit, run.err = runWithRetry(policy, &banner, sleep, func() (*gradv1.ExecuteCommandStreamResponse, error) {
+		return c.Exec(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "make test"}, gradclient.WriteOutput(&output, &output))
+	})
+	run.output, run.banner = output.String(), banner.String()
+	return run
+}
+
+func TestRunWithRetryFailsThenSucceeds(t *testing.T) {
+	c, fake := newFlakyClient(
+		attempt("connection reset\n", 1),
+		attempt("connection reset\n", 1),
+		attempt("done\n", 0),
+	)
+	run := runFlaky(c, retryPolicy{Retries: 5, Delay: time.Second, Backoff: 2})
+
+	if run.err != nil || run.exit.ExitCode != 0 {
+		t.Fatalf("Expected the last attempt to exit 0, got %v, %v", run.exit, run.err)
+	}
+	if attempts(fake) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts(fake))
+	}
+	if run.output != "connection reset\nconnection reset\ndone\n" {
+		t.Errorf("Expected the output of every attempt, got %q", run.output)
+	}
+	expectedBanner := "--- attempt 1/6 exited with code 1, retrying in 1s ---\n" +
+		"--- attempt 2/6 exited with code 1, retrying in 2s ---\n"
+	if run.banner != expectedBanner {
+		t.Errorf("Expected banners\n%s\ngot\n%s", expectedBanner, run.banner)
+	}
+	if len(run.waits) != 2 || run.waits[0] != time.Second || run.waits[1] != 2*time.Second {
+		t.Errorf("Expected waits of 1s and 2s, got %v", run.waits)
+	}
+}
+
+func TestRunWithRetryPropagatesLastExitCode(t *testing.T) {
+	c, fake := newFlakyClient(attempt("", 255), attempt("", 255), attempt("", 3))
+	run := runFlaky(c, retryPolicy{Retries: 2, Backoff: 1})
+
+	if run.err != nil || run.exit.ExitCode != 3 {
+		t.Fatalf("Expected the last attempt's exit code 3, got %v, %v", run.exit, run.err)
+	}
+	if attempts(fake) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts(fake))
+	}
+}
+
+func TestRunWithRetryOnlyListedExitCodes(t *testing.T) {
+	c, fake := newFlakyClient(attempt("", 255), attempt("", 2), attempt("", 0))
+	run := runFlaky(c, retryPolicy{Retries: 5, Backoff: 1, ExitCodes: []int{1, 255}})
+
+	if run.exit.ExitCode != 2 || attempts(fake) != 2 {
+		t.Errorf("Expected to stop at the unlisted exit code 2 after 2 attempts, got %d after %d", run.exit.ExitCode, attempts(fake))
+	}
+}
+
+func TestRunWithRetryStreamErrors(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	c, fake := newFlakyClient(gradtest.ExecScript{Err: unavailable}, attempt("", 0))
+	if run := runFlaky(c, retryPolicy{Retries: 3, Backoff: 1}); run.err != unavailable || attempts(fake) != 1 {
+		t.Errorf("Expected a stream error not to be retried by default, got %v after %d attempts", run.err, attempts(fake))
+	}
+
+	c, fake = newFlakyClient(gradtest.ExecScript{Err: unavailable}, attempt("", 0))
+	run := runFlaky(c, retryPolicy{Retries: 3, Backoff: 1, OnStreamError: true})
+	if run.err != nil || run.exit.ExitCode != 0 || attempts(fake) != 2 {
+		t.Errorf("Expected --retry-on-stream-error to retry, got %v, %v after %d attempts", run.exit, run.err, attempts(fake))
+	}
+	if !strings.Contains(run.banner, "attempt 1/4 failed: ") {
+		t.Errorf("Expected the banner to report the stream error, got %q", run.banner)
+	}
+
+	rejected := status.Error(codes.InvalidArgument, "invalid shell")
+	c, fake = newFlakyClient(gradtest.ExecScript{Err: rejected}, attempt("", 0))
+	if run := runFlaky(c, retryPolicy{Retries: 3, Backoff: 1, OnStreamError: true}); run.err != rejected || attempts(fake) != 1 {
+		t.Errorf("Expected a rejected request not to be retried, got %v after %d attempts", run.err, attempts(fake))
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  retryPolicy
+		wantErr string
+	}{
+		{"default", retryPolicy{Backoff: 2, Delay: time.Second}, ""},
+		{"negative retries", retryPolicy{Retries: -1, Backoff: 1}, "--retry must not be negative"},
+		{"negative delay", retryPolicy{Retries: 1, Delay: -time.Second, Backoff: 1}, "--retry-delay must not be negative"},
+		{"shrinking backoff", retryPolicy{Retries: 1, Backoff: 0.5}, "--retry-backoff must be at least 1"},
+		{"exit code 0", retryPolicy{Retries: 1, Backoff: 1, ExitCodes: []int{0}}, "--retry-on exit code 0 is outside 1-255"},
+		{"exit code 256", retryPolicy{Retries: 1, Backoff: 1, ExitCodes: []int{1, 256}}, "--retry-on exit code 256 is outside 1-255"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Expected %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,23 +1,25 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	gradv1 "github.com/strrl/gra/gen/grad/v1"
 	"github.com/strrl/gra/cmd/gractl/client"
 	"github.com/strrl/gra/cmd/gractl/config"
+	"github.com/strrl/gra/pkg/gradclient"
 )
 
 var (
 	serverAddress string
 	outputFormatStr string
-	grpcClient    *client.Client
+	grpcClient    client.Client
 	globalConfig  *config.Config
 )
 
@@ -27,39 +29,15 @@ var RunnersCmd = &cobra.Command{
 	Short: "Manage runners",
 	Long:  `Manage runner instances including creating, listing, and executing commands.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Load configuration from file and environment
-		var err error
-		globalConfig, err = config.LoadConfig()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
-			os.Exit(1)
-		}
-
-		// Use server address from config if not provided via flag
-		if serverAddress == "localhost:9090" && globalConfig.Server.Address != "" {
-			serverAddress = globalConfig.Server.Address
-		}
-
-		// Set output format
-		switch outputFormatStr {
-		case "json":
-			outputFormat = OutputFormatJSON
-		case "table":
-			outputFormat = OutputFormatTable
-		default:
-			fmt.Fprintf(os.Stderr, "Invalid output format: %s (supported: table, json)\n", outputFormatStr)
-			os.Exit(1)
-		}
+		// Cobra only runs the closest PersistentPreRun, so do what the root command's would
+		initGlobals()
 
-		// Initialize client for all subcommands
-		cfg := &client.Config{
-			ServerAddress: serverAddress,
-		}
-		
-		grpcClient, err = client.NewClient(cfg)
+		// Initialize client for all subcommands, failing early if the server is unreachable
+		var err error
+		grpcClient, err = newGRPCClient(cmd, globalConfig)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
-			os.Exit(1)
+			os.Exit(ExitCodeTempFail)
 		}
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
@@ -70,152 +48,498 @@ var RunnersCmd = &cobra.Command{
 	},
 }
 
+// checkBootstrapSize rejects a --bootstrap command or --bootstrap-file larger than grad
+// accepts, before gractl connects
+func checkBootstrapSize(cmd *cobra.Command) error {
+	bootstrap, _ := cmd.Flags().GetString("bootstrap")
+	if err := gradclient.CheckCommandSize(bootstrap, nil); err != nil {
+		return fmt.Errorf("bootstrap %w; %s", err, largeCommandHint)
+	}
+	bootstrapFile, _ := cmd.Flags().GetString("bootstrap-file")
+	if bootstrapFile == "" {
+		return nil
+	}
+	// A file that can't be read is reported when it is read
+	if info, err := os.Stat(bootstrapFile); err == nil && info.Size() > gradclient.MaxCommandBytes {
+		return fmt.Errorf("bootstrap file %s is %d bytes, more than the %d grad accepts; %s", bootstrapFile, info.Size(), gradclient.MaxCommandBytes, largeCommandHint)
+	}
+	return nil
+}
+
 // createCmd represents the create command
 var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a new runner",
-	Long:  `Create a new runner instance with optional name and environment variables.`,
+	Long: `Create a new runner instance with optional name and environment variables.
+
+Use --count to create several runners with the same configuration, typically
+together with --group so they can be listed and deleted as one.
+
+The table output is followed by suggested next steps on stderr. With --quiet only the
+runner IDs are printed, one per line, and -o json prints just the runner (or list):
+
+  RUNNER=$(gractl runners create -q --name build)`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		return checkBootstrapSize(cmd)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		name, _ := cmd.Flags().GetString("name")
+		group, _ := cmd.Flags().GetString("group")
+		count, _ := cmd.Flags().GetInt("count")
+		hostAliasFlags, _ := cmd.Flags().GetStringArray("host-alias")
 		envVars, _ := cmd.Flags().GetStringSlice("env")
-		
-		// S3 workspace configuration flags
-		s3Bucket, _ := cmd.Flags().GetString("s3-bucket")
-		s3Endpoint, _ := cmd.Flags().GetString("s3-endpoint")
-		s3Prefix, _ := cmd.Flags().GetString("s3-prefix")
-		s3Region, _ := cmd.Flags().GetString("s3-region")
-		readOnly, _ := cmd.Flags().GetBool("read-only")
-
-		// Use config values as defaults if flags are not provided
-		if s3Bucket == "" && globalConfig.S3.Bucket != "" {
-			s3Bucket = globalConfig.S3.Bucket
-		}
-		// Always use config values for other S3 settings if not explicitly provided via flags
-		// This allows mixing --s3-bucket flag with config file settings
-		if s3Endpoint == "" && globalConfig.S3.Endpoint != "" {
-			s3Endpoint = globalConfig.S3.Endpoint
-		}
-		if s3Prefix == "" && globalConfig.S3.Prefix != "" {
-			s3Prefix = globalConfig.S3.Prefix
-		}
-		if s3Region == "" && globalConfig.S3.Region != "" {
-			s3Region = globalConfig.S3.Region
-		}
-		if !cmd.Flags().Changed("read-only") && globalConfig.S3.ReadOnly {
-			readOnly = globalConfig.S3.ReadOnly
-		}
-
-		// Parse environment variables
-		envMap := make(map[string]string)
-		for _, env := range envVars {
-			parts := strings.SplitN(env, "=", 2)
-			if len(parts) == 2 {
-				envMap[parts[0]] = parts[1]
-			}
+		bootstrap, _ := cmd.Flags().GetString("bootstrap")
+		bootstrapFile, _ := cmd.Flags().GetString("bootstrap-file")
+		disableProxy, _ := cmd.Flags().GetBool("disable-proxy")
+		serviceAccount, _ := cmd.Flags().GetString("service-account")
+		disableSAToken, _ := cmd.Flags().GetBool("disable-sa-token")
+		priorityClass, _ := cmd.Flags().GetString("priority-class")
+		arch, _ := cmd.Flags().GetString("arch")
+		skipValidation, _ := cmd.Flags().GetBool("skip-validation")
+		defaultShell, _ := cmd.Flags().GetString("default-shell")
+		defaultWorkdir, _ := cmd.Flags().GetString("default-workdir")
+		description, _ := cmd.Flags().GetString("description")
+		autoRestart, _ := cmd.Flags().GetBool("auto-restart")
+		until, _ := cmd.Flags().GetString("until")
+		reserveFor, _ := cmd.Flags().GetDuration("for")
+
+		if count < 1 {
+			fmt.Fprintf(os.Stderr, "Invalid count: %d (must be at least 1)\n", count)
+			os.Exit(1)
 		}
 
-		// Always auto-inject AWS credentials from config if available (regardless of bucket source)
-		// This allows using --s3-bucket flag while still getting credentials from config
-		if globalConfig.S3.AccessKeyID != "" {
-			envMap["AWS_ACCESS_KEY_ID"] = globalConfig.S3.AccessKeyID
-		}
-		if globalConfig.S3.SecretAccessKey != "" {
-			envMap["AWS_SECRET_ACCESS_KEY"] = globalConfig.S3.SecretAccessKey
-		}
-		if globalConfig.S3.SessionToken != "" {
-			envMap["AWS_SESSION_TOKEN"] = globalConfig.S3.SessionToken
+		hostAliases, err := parseHostAliasFlags(hostAliasFlags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --host-alias: %v\n", err)
+			os.Exit(1)
 		}
 
-		// Automatically inject SSH public key if available
-		if sshPublicKey, err := client.GetUserSSHPublicKey(); err == nil && sshPublicKey != "" {
-			envMap["PUBLIC_KEY"] = sshPublicKey
+		reservedUntil, err := parseReservationEnd(until, reserveFor, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid reservation: %v\n", err)
+			os.Exit(1)
 		}
 
-		req := &gradv1.CreateRunnerRequest{
-			Name: name,
-			Env:  envMap,
+		// Bootstrap command can come from the flag or a script file, but not both
+		if bootstrap != "" && bootstrapFile != "" {
+			fmt.Fprintf(os.Stderr, "Cannot use --bootstrap and --bootstrap-file together\n")
+			os.Exit(1)
+		}
+		if bootstrapFile != "" {
+			content, err := os.ReadFile(bootstrapFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to read bootstrap file: %v\n", err)
+				os.Exit(1)
+			}
+			bootstrap = string(content)
 		}
 		
-		// Add workspace configuration if S3 bucket is specified (either via flag or config)
-		if s3Bucket != "" {
-			req.Workspace = &gradv1.WorkspaceConfig{
-				Bucket:    s3Bucket,
-				Endpoint:  s3Endpoint,
-				Prefix:    s3Prefix,
-				Region:    s3Region,
-				ReadOnly:  readOnly,
+		// S3 workspace from the config file and flags
+		workspace := workspaceFromFlags(cmd, globalConfig)
+		s3fsCPU, _ := cmd.Flags().GetString("s3fs-cpu")
+		s3fsMemory, _ := cmd.Flags().GetString("s3fs-memory")
+
+		// The sidecar only exists for runners with an S3 workspace
+		if workspace.Bucket == "" && (s3fsCPU != "" || s3fsMemory != "") {
+			fmt.Fprintf(os.Stderr, "--s3fs-cpu and --s3fs-memory require an S3 bucket (--s3-bucket or config)\n")
+			os.Exit(1)
+		}
+
+		opts := []gradclient.CreateOption{
+			gradclient.WithEnv(parseEnvFlags(envVars)),
+			// Credentials from the config are passed even if the bucket came from a flag
+			gradclient.WithWorkspace(workspace),
+			gradclient.WithBootstrapCommand(bootstrap),
+			gradclient.WithServiceAccount(serviceAccount),
+			gradclient.WithPriorityClass(priorityClass),
+			gradclient.WithArch(arch),
+			gradclient.WithDefaultShell(defaultShell),
+			gradclient.WithDefaultWorkdir(defaultWorkdir),
+			gradclient.WithDescription(description),
+			gradclient.WithGroup(group),
+			gradclient.WithHostAliases(hostAliases...),
+			gradclient.WithReservation(reservedUntil),
+		}
+		if protected, _ := cmd.Flags().GetBool("protected"); protected {
+			opts = append(opts, gradclient.WithProtection())
+		}
+		if spot, _ := cmd.Flags().GetBool("spot"); spot {
+			opts = append(opts, gradclient.WithSpot())
+		}
+		if workspace.Bucket != "" {
+			opts = append(opts, gradclient.WithSidecarResources(s3fsCPU, s3fsMemory))
+			logger.Debugf("Using S3 workspace bucket %s (endpoint %q, prefix %q)", workspace.Bucket, workspace.Endpoint, workspace.Prefix)
+		}
+		if disableProxy {
+			opts = append(opts, gradclient.WithoutProxy())
+		}
+		if disableSAToken {
+			opts = append(opts, gradclient.WithoutServiceAccountToken())
+		}
+		if skipValidation {
+			opts = append(opts, gradclient.WithoutValidation())
+		}
+		// Without the flag the server default applies; --auto-restart=false opts out of it
+		if cmd.Flags().Changed("auto-restart") {
+			opts = append(opts, gradclient.WithAutoRestart(autoRestart))
+		}
+
+		// Inject the user's SSH public key for SSH and workspace sync
+		opts = append(opts, gradclient.WithSSHPublicKey(sshPublicKeyForRunner(cmd, globalConfig)))
+
+		ctx := commandContext(cmd)
+		warnDisabledWorkspace(ctx, grpcClient, workspace)
+		if count == 1 {
+			createCtx, cancel := rpcContext(ctx)
+			defer cancel()
+			runner, warnings, err := grpcClient.CreateRunnerWithWarnings(createCtx, append(opts, gradclient.WithName(name))...)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create runner: %s\n", describeError(err))
+				os.Exit(1)
+			}
+			warnCreated(runner, warnings)
+
+			if err := PrintCreatedRunners([]*gradv1.Runner{runner}); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to print runner: %v\n", err)
+				os.Exit(1)
 			}
+			return
 		}
 
-		resp, err := grpcClient.RunnerService().CreateRunner(context.Background(), req)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to create runner: %v\n", err)
-			os.Exit(1)
+		// Runners of a batch get numbered names; the ones created before a failure are kept
+		var created []*gradv1.Runner
+		var createErr error
+		for i := 1; i <= count; i++ {
+			runnerName := name
+			if name != "" {
+				runnerName = fmt.Sprintf("%s-%d", name, i)
+			}
+			createCtx, cancel := rpcContext(ctx)
+			runner, warnings, err := grpcClient.CreateRunnerWithWarnings(createCtx, append(opts, gradclient.WithName(runnerName))...)
+			cancel()
+			if err != nil {
+				createErr = fmt.Errorf("%d of %d: %w", i, count, err)
+				break
+			}
+			warnCreated(runner, warnings)
+			created = append(created, runner)
 		}
 
-		if err := PrintRunner(resp.Runner); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to print runner: %v\n", err)
+		if len(created) > 0 {
+			if err := PrintCreatedRunners(created); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to print runners: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if createErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create runner %v\n", createErr)
 			os.Exit(1)
 		}
 	},
 }
 
+// warnCreated shows the warnings the server returned about a runner it created
+func warnCreated(runner *gradv1.Runner, warnings []string) {
+	for _, warning := range warnings {
+		logger.Warnf("Runner %s: %s", runner.Id, warning)
+	}
+}
+
+// parseEnvFlags parses --env values of the form KEY=VALUE, skipping ones without "=" (pure function)
+func parseEnvFlags(values []string) map[string]string {
+	env := make(map[string]string)
+	for _, value := range values {
+		if key, val, ok := strings.Cut(value, "="); ok {
+			env[key] = val
+		}
+	}
+	return env
+}
+
+// parseHostAliasFlags parses --host-alias values of the form IP=HOSTNAME[,HOSTNAME...]. The
+// server validates the IP and hostnames. (pure function)
+func parseHostAliasFlags(values []string) ([]*gradv1.HostAlias, error) {
+	var aliases []*gradv1.HostAlias
+	for _, value := range values {
+		ip, hostnames, ok := strings.Cut(value, "=")
+		if !ok || ip == "" || hostnames == "" {
+			return nil, fmt.Errorf("%q is not IP=HOSTNAME[,HOSTNAME...]", value)
+		}
+		alias := &gradv1.HostAlias{Ip: ip}
+		for _, hostname := range strings.Split(hostnames, ",") {
+			if hostname = strings.TrimSpace(hostname); hostname != "" {
+				alias.Hostnames = append(alias.Hostnames, hostname)
+			}
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List runners",
-	Long:  `List all runners with optional filtering by status.`,
+	Long: `List all runners with optional filtering by status.
+
+--sort-by sorts the runners before they are paged with --limit and --offset. Servers that
+can't sort return their usual order, and only the returned page is sorted.
+
+A * after the status marks a runner running a different image than new runners get, such
+as after the server was upgraded; recreate it to update it.`,
 	Aliases: []string{"ls"},
 	Run: func(cmd *cobra.Command, args []string) {
 		statusStr, _ := cmd.Flags().GetString("status")
 		limit, _ := cmd.Flags().GetInt32("limit")
 		offset, _ := cmd.Flags().GetInt32("offset")
+		watch, _ := cmd.Flags().GetBool("watch")
+		interval, _ := cmd.Flags().GetInt("interval")
+		filter, _ := cmd.Flags().GetString("filter")
+		group, _ := cmd.Flags().GetString("group")
+		imageDigest, _ := cmd.Flags().GetString("image-digest")
+		labels, _ := cmd.Flags().GetStringToString("label")
+		sortBy, _ := cmd.Flags().GetString("sort-by")
+		reverse, _ := cmd.Flags().GetBool("reverse")
+		staleOnly, _ := cmd.Flags().GetBool("stale-only")
+		arch, _ := cmd.Flags().GetString("arch")
 
 		status, err := ParseRunnerStatus(statusStr)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Invalid status: %v\n", err)
 			os.Exit(1)
 		}
+		if err := validateSortKey(sortBy); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --sort-by: %v\n", err)
+			os.Exit(1)
+		}
+		if reverse && sortBy == "" {
+			fmt.Fprintln(os.Stderr, "--reverse requires --sort-by")
+			os.Exit(1)
+		}
 
 		req := &gradv1.ListRunnersRequest{
-			Status: status,
-			Limit:  limit,
-			Offset: offset,
+			Status:      status,
+			Limit:       limit,
+			Offset:      offset,
+			Filter:      filter,
+			Group:       group,
+			ImageDigest: imageDigest,
+			Labels:      labels,
+			OrderBy:     sortBy,
+			Reverse:     reverse,
+			StaleOnly:   staleOnly,
+			Arch:        arch,
+		}
+
+		if watch {
+			if interval <= 0 {
+				fmt.Fprintf(os.Stderr, "Invalid interval: %d (must be positive)\n", interval)
+				os.Exit(1)
+			}
+
+			// Ctrl+C stops watching and exits cleanly
+			if err := watchRunners(commandContext(cmd), req, time.Duration(interval)*time.Second); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to watch runners: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		}
 
-		resp, err := grpcClient.RunnerService().ListRunners(context.Background(), req)
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		runners, total, err := grpcClient.ListRunners(ctx, req)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to list runners: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Failed to list runners: %s\n", describeError(err))
 			os.Exit(1)
 		}
+		runners = keepStaleRunners(runners, req.StaleOnly)
+		sortRunners(runners, req.OrderBy, req.Reverse)
 
-		if err := PrintRunnerList(resp.Runners); err != nil {
+		if err := PrintRunnerList(runners, total); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to print runners: %v\n", err)
 			os.Exit(1)
 		}
 	},
 }
 
+// keepStaleRunners returns the runners with a stale image if staleOnly is set, or else all of
+// them. Servers that don't know stale_only list every runner, none of them stale. (pure function)
+func keepStaleRunners(runners []*gradv1.Runner, staleOnly bool) []*gradv1.Runner {
+	if !staleOnly {
+		return runners
+	}
+	stale := []*gradv1.Runner{}
+	for _, runner := range runners {
+		if runner.StaleImage {
+			stale = append(stale, runner)
+		}
+	}
+	return stale
+}
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show runner counts",
+	Long:  `Show how many runners there are, grouped by status, preset and owner, without listing them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		statusStr, _ := cmd.Flags().GetString("status")
+
+		status, err := ParseRunnerStatus(statusStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid status: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		resp, err := grpcClient.GetRunnerStats(ctx, status)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get runner stats: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := PrintRunnerStats(resp); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print runner stats: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// topCmd represents the top command
+var topCmd = &cobra.Command{
+	Use:   "top [RUNNER_ID]",
+	Short: "Show live CPU and memory usage of runners",
+	Long: `Show the CPU and memory runners use right now, highest CPU first, like kubectl top.
+Usage comes from the cluster's metrics-server and lags by up to a minute. STDOUT and STDERR
+are the command output each runner has streamed since grad started.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		containers, _ := cmd.Flags().GetBool("containers")
+
+		var usages []*gradv1.RunnerUsage
+		if len(args) == 1 {
+			ctx, cancel := rpcContext(commandContext(cmd))
+			defer cancel()
+			usage, err := grpcClient.GetRunnerMetrics(ctx, resolveRunnerArg(cmd, grpcClient, args[0]))
+			if err != nil {
+				exitRunnerMetricsError(err)
+			}
+			usages = []*gradv1.RunnerUsage{usage}
+		} else {
+			var err error
+			ctx, cancel := rpcContext(commandContext(cmd))
+			defer cancel()
+			usages, err = grpcClient.ListRunnerMetrics(ctx)
+			if err != nil {
+				exitRunnerMetricsError(err)
+			}
+		}
+
+		if err := PrintRunnerUsage(usages, containers); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print runner usage: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// exitRunnerMetricsError reports a failure to get runner usage and exits; without
+// metrics-server the server's hint on installing it is shown as is
+func exitRunnerMetricsError(err error) {
+	if status.Code(err) == codes.Unimplemented {
+		fmt.Fprintf(os.Stderr, "Runner usage is not available: %s\n", status.Convert(err).Message())
+		os.Exit(ExitCodeUnavailable)
+	}
+	fmt.Fprintf(os.Stderr, "Failed to get runner usage: %v\n", err)
+	os.Exit(1)
+}
+
 // getCmd represents the get command
 var getCmd = &cobra.Command{
-	Use:   "get RUNNER_ID",
+	Use:   "get RUNNER_ID... | -",
 	Short: "Get runner details",
-	Long:  `Get detailed information about a specific runner.`,
+	Long: `Get detailed information about a specific runner.
+
+Given several IDs, or - to read newline-separated IDs from stdin, the runners are fetched a
+few at a time and listed as a table, or with -o json as an array with an entry per ID holding
+the runner or the error. gractl exits with 1 if any runner couldn't be got.
+
+  gractl runners list -o json | jq -r '.runners[].id' | gractl runners get - -o json`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return validateRunnerIDArgs(args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if isBatch(args) {
+			runGetRunners(cmd, args)
+			return
+		}
+
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		runner, err := grpcClient.GetRunner(ctx, resolveRunnerArg(cmd, grpcClient, args[0]))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get runner: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := PrintRunner(runner); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print runner: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update RUNNER_ID",
+	Short: "Update a runner",
+	Long:  `Change the editable fields of a runner. Only the flags given are changed.`,
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		runnerID := args[0]
+		if !cmd.Flags().Changed("description") {
+			fmt.Fprintf(os.Stderr, "Nothing to update: pass --description\n")
+			os.Exit(1)
+		}
+		description, _ := cmd.Flags().GetString("description")
 
-		req := &gradv1.GetRunnerRequest{
-			RunnerId: runnerID,
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		runner, err := grpcClient.UpdateRunnerDescription(ctx, resolveRunnerArg(cmd, grpcClient, args[0]), description)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to update runner: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := PrintRunner(runner); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print runner: %v\n", err)
+			os.Exit(1)
 		}
+	},
+}
 
-		resp, err := grpcClient.RunnerService().GetRunner(context.Background(), req)
+// cloneCmd represents the clone command
+var cloneCmd = &cobra.Command{
+	Use:   "clone SOURCE_RUNNER_ID",
+	Short: "Create a runner just like an existing one",
+	Long: `Create a new runner with the same env, S3 workspace, bootstrap command, labels and
+defaults as an existing runner. Files changed inside the source runner are not copied;
+the bootstrap command runs again in the new one.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		runner, err := grpcClient.CloneRunner(ctx, resolveRunnerArg(cmd, grpcClient, args[0]), name)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to get runner: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Failed to clone runner: %v\n", err)
 			os.Exit(1)
 		}
 
-		if err := PrintRunner(resp.Runner); err != nil {
+		if err := PrintRunner(runner); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to print runner: %v\n", err)
 			os.Exit(1)
 		}
@@ -224,22 +548,86 @@ var getCmd = &cobra.Command{
 
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
-	Use:   "delete [RUNNER_ID]",
-	Short: "Delete a runner or all runners",
-	Long:  `Delete a runner instance by ID, or delete all runners with --all flag.`,
+	Use:   "delete [RUNNER_ID... | -]",
+	Short: "Delete runners, a group of runners or all runners",
+	Long:  `Delete runner instances by ID, every runner of a group with --group, or all runners with --all flag.
+
+Given several IDs, or - to read newline-separated IDs from stdin, the runners are deleted a
+few at a time with a line per runner, or with -o json an array with an entry per ID holding
+the response or the error. gractl exits with 1 if any deletion failed.
+
+Deletion returns once the runner's objects are deleted, while its pod may still be terminating.
+Use --wait to return only once everything is gone, e.g. before creating a runner with the same
+name; gractl exits with 75 if something is still terminating when the wait times out.
+
+Use --snapshot-first with --snapshot-to to archive a runner's workspace to S3, as runners
+snapshot does, before deleting it; the runner is kept if the snapshot fails.
+
+Runners protected with runners protect fail to delete unless --override-protection is given;
+--all skips them and --group reports them as failed.`,
 	Aliases: []string{"rm"},
 	Args: func(cmd *cobra.Command, args []string) error {
 		all, _ := cmd.Flags().GetBool("all")
-		if all && len(args) > 0 {
-			return fmt.Errorf("cannot specify runner ID when using --all flag")
+		group, _ := cmd.Flags().GetString("group")
+		if all && group != "" {
+			return fmt.Errorf("cannot use --all and --group together")
 		}
-		if !all && len(args) != 1 {
-			return fmt.Errorf("requires exactly one RUNNER_ID when not using --all flag")
+		if (all || group != "") && len(args) > 0 {
+			return fmt.Errorf("cannot specify runner ID when using --all or --group flag")
 		}
-		return nil
+		if wait, _ := cmd.Flags().GetBool("wait"); wait && group != "" {
+			return fmt.Errorf("cannot use --wait with --group")
+		}
+		if override, _ := cmd.Flags().GetBool("override-protection"); override && (all || group != "") {
+			return fmt.Errorf("--override-protection only deletes runners given by ID")
+		}
+		if !all && group == "" && len(args) == 0 {
+			return fmt.Errorf("requires a RUNNER_ID or - when not using --all or --group flag")
+		}
+		if snapshotFirst, _ := cmd.Flags().GetBool("snapshot-first"); snapshotFirst {
+			if all || group != "" || isBatch(args) {
+				return fmt.Errorf("--snapshot-first deletes a single runner")
+			}
+			if to, _ := cmd.Flags().GetString("snapshot-to"); to == "" {
+				return fmt.Errorf("--snapshot-first requires --snapshot-to")
+			}
+		}
+		return validateRunnerIDArgs(args)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		all, _ := cmd.Flags().GetBool("all")
+		group, _ := cmd.Flags().GetString("group")
+
+		wait, _ := cmd.Flags().GetBool("wait")
+		waitTimeout, _ := cmd.Flags().GetDuration("wait-timeout")
+		var opts []gradclient.DeleteOption
+		if wait {
+			opts = append(opts, gradclient.WithWait(waitTimeout))
+		}
+		if override, _ := cmd.Flags().GetBool("override-protection"); override {
+			opts = append(opts, gradclient.WithOverrideProtection())
+		}
+		ctx := commandContext(cmd)
+		timeout := deleteTimeout(wait, waitTimeout, requestTimeout)
+
+		if group != "" {
+			ctx, cancel := rpcContext(commandContext(cmd))
+			defer cancel()
+			resp, err := grpcClient.DeleteRunnerGroup(ctx, group)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to delete group: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := PrintDeleteRunnerGroupResponse(group, resp); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to print response: %v\n", err)
+				os.Exit(1)
+			}
+			if len(resp.Failed) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
 		
 		if all {
 			// Delete all runners
@@ -250,147 +638,328 @@ var deleteCmd = &cobra.Command{
 				Offset: 0,
 			}
 
-			listResp, err := grpcClient.RunnerService().ListRunners(context.Background(), listReq)
+			listCtx, cancel := rpcContext(ctx)
+			defer cancel()
+			runners, _, err := grpcClient.ListRunners(listCtx, listReq)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to list runners: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Failed to list runners: %s\n", describeError(err))
 				os.Exit(1)
 			}
 
-			if len(listResp.Runners) == 0 {
+			if len(runners) == 0 {
 				fmt.Printf("No runners found to delete\n")
 				return
 			}
 
 			// Delete each runner
-			successCount := 0
-			for _, runner := range listResp.Runners {
-				deleteReq := &gradv1.DeleteRunnerRequest{
-					RunnerId: runner.Id,
+			successCount, skippedCount := 0, 0
+			for _, runner := range runners {
+				if runner.Protected {
+					fmt.Printf("Skipped protected runner: %s\n", runner.Id)
+					skippedCount++
+					continue
 				}
-
-				_, err := grpcClient.RunnerService().DeleteRunner(context.Background(), deleteReq)
+				deleteCtx, cancel := withTimeout(ctx, timeout)
+				resp, err := grpcClient.DeleteRunner(deleteCtx, runner.Id, opts...)
+				cancel()
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Failed to delete runner %s: %v\n", runner.Id, err)
+				} else if len(resp.FailedResources) > 0 {
+					fmt.Fprintf(os.Stderr, "Failed to delete runner %s: %s\n", runner.Id, strings.Join(resp.FailedResources, "; "))
+				} else if len(resp.RemainingResources) > 0 {
+					fmt.Fprintf(os.Stderr, "Runner %s still terminating: %s\n", runner.Id, strings.Join(resp.RemainingResources, ", "))
 				} else {
 					fmt.Printf("Deleted runner: %s\n", runner.Id)
 					successCount++
 				}
 			}
 
-			fmt.Printf("Successfully deleted %d out of %d runners\n", successCount, len(listResp.Runners))
+			fmt.Printf("Successfully deleted %d out of %d runners", successCount, len(runners))
+			if skippedCount > 0 {
+				fmt.Printf(", skipped %d protected", skippedCount)
+			}
+			fmt.Println()
+		} else if isBatch(args) {
+			runDeleteRunners(cmd, args, timeout, opts)
 		} else {
 			// Delete single runner
-			runnerID := args[0]
-
-			req := &gradv1.DeleteRunnerRequest{
-				RunnerId: runnerID,
+			runnerID := resolveRunnerArg(cmd, grpcClient, args[0])
+			if snapshotFirst, _ := cmd.Flags().GetBool("snapshot-first"); snapshotFirst {
+				req, err := snapshotRequest(runnerID, getSnapshotFlags(cmd, "snapshot-"), os.Getenv)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+					os.Exit(1)
+				}
+				resp, err := snapshotWorkspace(ctx, grpcClient, req, logger.Infof)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to snapshot runner, not deleting it: %s\n", snapshotErrorMessage(err))
+					os.Exit(1)
+				}
+				logger.Infof("Uploaded workspace of runner %s to %s (%s)", runnerID, snapshotURL(resp), formatOutputBytes(resp.SizeBytes))
 			}
 
-			resp, err := grpcClient.RunnerService().DeleteRunner(context.Background(), req)
+			deleteCtx, cancel := withTimeout(ctx, timeout)
+			defer cancel()
+			resp, err := grpcClient.DeleteRunner(deleteCtx, runnerID, opts...)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to delete runner: %v\n", err)
 				os.Exit(1)
 			}
 
-			if err := PrintMessage(resp.Message); err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to print message: %v\n", err)
+			if err := PrintDeleteRunnerResponse(resp); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to print response: %v\n", err)
 				os.Exit(1)
 			}
+			if len(resp.FailedResources) > 0 {
+				os.Exit(1)
+			}
+			if len(resp.RemainingResources) > 0 {
+				os.Exit(ExitCodeTempFail)
+			}
 		}
 	},
 }
 
+// runGetRunners gets several runners, printing each or why it couldn't be got
+func runGetRunners(cmd *cobra.Command, args []string) {
+	runnerIDs, err := resolveRunnerIDs(args, cmd.InOrStdin())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read runner IDs: %v\n", err)
+		os.Exit(1)
+	}
+	runnerIDs = resolveRunnerArgs(cmd, grpcClient, runnerIDs...)
+	parallel, _ := cmd.Flags().GetInt("parallel")
+
+	results := getRunners(commandContext(cmd), grpcClient, runnerIDs, parallel)
+	if outputFormat == OutputFormatJSON {
+		err = writeGetRunnersJSON(os.Stdout, results)
+	} else {
+		err = writeGetRunnersTable(os.Stdout, os.Stderr, results, outputFormat == OutputFormatWide)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print runners: %v\n", err)
+		os.Exit(1)
+	}
+
+	codes := make([]int, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			codes[i] = 1
+		}
+	}
+	if code := batchExitCode(codes); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// runDeleteRunners deletes several runners, printing the outcome for each
+func runDeleteRunners(cmd *cobra.Command, args []string, timeout time.Duration, opts []gradclient.DeleteOption) {
+	runnerIDs, err := resolveRunnerIDs(args, cmd.InOrStdin())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read runner IDs: %v\n", err)
+		os.Exit(1)
+	}
+	runnerIDs = resolveRunnerArgs(cmd, grpcClient, runnerIDs...)
+	parallel, _ := cmd.Flags().GetInt("parallel")
+
+	results := deleteRunners(commandContext(cmd), grpcClient, runnerIDs, parallel, timeout, opts...)
+	if outputFormat == OutputFormatJSON {
+		err = writeDeleteRunnersJSON(os.Stdout, results)
+	} else {
+		err = writeDeleteRunnersResults(os.Stdout, results)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print response: %v\n", err)
+		os.Exit(1)
+	}
+
+	codes := make([]int, len(results))
+	for i, result := range results {
+		codes[i] = result.exitCode()
+	}
+	if code := batchExitCode(codes); code != 0 {
+		os.Exit(code)
+	}
+}
+
 // execCmd represents the exec command
 var execCmd = &cobra.Command{
 	Use:   "exec RUNNER_ID COMMAND [args...]",
 	Short: "Execute a command in a runner",
-	Long:  `Execute a command in a specific runner instance with streaming output.`,
-	Args:  cobra.MinimumNArgs(2),
+	Long: `Execute a command in a specific runner instance with streaming output.
+
+Words after -- are passed to the program exactly as given, without the shell splitting or
+expanding them; without --, or as a single word, the command line goes through the shell:
+  gractl runners exec runner-1 -- python -c "print('a b')"
+  gractl runners exec runner-1 'ls /workspace/*.csv | wc -l'
+
+Use --retry to run the command again while it exits non-zero, waiting --retry-delay
+before the first retry and multiplying the wait by --retry-backoff after each one.
+The exit code is the last attempt's.
+
+If the output stream breaks while the command runs, e.g. during a grad restart, gractl
+reattaches to the command and carries on from the last output received. If it can't,
+it fails with exit code 75 and a warning that the output is incomplete. Use --no-resume
+to fail as soon as the stream breaks.
+
+Use --tee to also save the output to a file while it streams, with the exit code and timing
+in FILE.meta.json; the file keeps what arrived even if gractl is interrupted:
+  gractl runners exec runner-1 --tee logs/train.log -- python train.py
+  gractl runners exec runner-1 --tee out.log --tee-stderr err.log --append -- make`,
+	Args: commandArgs(2, 1),
 	Run: func(cmd *cobra.Command, args []string) {
-		runnerID := args[0]
-		command := strings.Join(args[1:], " ")
+		runnerID := resolveRunnerArg(cmd, grpcClient, args[0])
+		command, argv := commandFromArgs(args[1:], cmd.ArgsLenAtDash() >= 0)
 
 		shell, _ := cmd.Flags().GetString("shell")
 		timeout, _ := cmd.Flags().GetInt32("timeout")
 		workdir, _ := cmd.Flags().GetString("workdir")
+		noResume, _ := cmd.Flags().GetBool("no-resume")
+		noCorrelationEnv, _ := cmd.Flags().GetBool("no-correlation-env")
+		retry, err := retryPolicyFromFlags(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 
 		req := &gradv1.ExecuteCommandRequest{
 			RunnerId:   runnerID,
 			Command:    command,
+			Argv:       argv,
 			Shell:      shell,
 			Timeout:    timeout,
 			WorkingDir: workdir,
+			Resumable:  !noResume,
+
+			DisableCorrelationEnv: noCorrelationEnv,
+		}
+		if err := outputLimitFromFlags(cmd, req); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
+		logger.Debugf("Executing %q on runner %s with shell %q (timeout %ds)", commandLine(req), runnerID, shell, timeout)
 
-		// Use streaming execution (only option available)
-		stream, err := grpcClient.RunnerService().ExecuteCommandStream(context.Background(), req)
+		tee, err := teeFromFlags(cmd, commandLine(req))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to start command execution: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		var exitCode int32 = 0
-		for {
-			resp, err := stream.Recv()
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				fmt.Fprintf(os.Stderr, "Stream error: %v\n", err)
-				os.Exit(1)
-			}
-
-			switch resp.Type {
-			case gradv1.StreamType_STREAM_TYPE_STDOUT, gradv1.StreamType_STREAM_TYPE_STDERR:
-				if err := PrintStreamData(resp.Type, resp.Data); err != nil {
+		// Ctrl+C cancels the stream, and with it the command
+		ctx := commandContext(cmd)
+		flushOutput := setupCommandOutput(cmd)
+		exit, err := runWithRetry(retry, os.Stderr, sleepContext(ctx), func() (*gradv1.ExecuteCommandStreamResponse, error) {
+			defer flushOutput()
+			exit, err := grpcClient.Exec(ctx, req, announceStart(tee.handler(func(resp *gradv1.ExecuteCommandStreamResponse) error {
+				if err := PrintStreamResponse(resp); err != nil {
 					fmt.Fprintf(os.Stderr, "Failed to print stream data: %v\n", err)
-					os.Exit(1)
+					os.Exit(ExitCodeInternal)
 				}
-			case gradv1.StreamType_STREAM_TYPE_EXIT:
-				exitCode = resp.ExitCode
-			}
+				return nil
+			})))
+			return exit, interruptible(ctx, err)
+		})
+		if err := tee.finish(exit, err); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save the --tee output: %v\n", err)
 		}
 
-		// Exit with the same code as the command
-		if exitCode != 0 {
-			os.Exit(int(exitCode))
-		}
+		// Exit with the same code as the command, unless --no-exit-code-propagation is set
+		exitWithCommandStatus(cmd, exit, err)
 	},
 }
 
 func init() {
 	// Global flags
-	RunnersCmd.PersistentFlags().StringVar(&serverAddress, "server", "localhost:9090", "gRPC server address")
-	RunnersCmd.PersistentFlags().StringVarP(&outputFormatStr, "output", "o", "table", "Output format (table, json)")
+	RunnersCmd.PersistentFlags().BoolVar(&legacyJSON, "legacy-json", false, "Use the old JSON output format (deprecated, will be removed in the next release)")
 
 	// Create command flags
 	createCmd.Flags().StringP("name", "n", "", "Runner name (optional)")
 	createCmd.Flags().StringSliceP("env", "e", []string{}, "Environment variables (KEY=VALUE)")
+	createCmd.Flags().String("bootstrap", "", "Command to run once after the runner becomes ready (e.g. \"pip install -r requirements.txt\")")
+	createCmd.Flags().String("bootstrap-file", "", "Path to a script to run once after the runner becomes ready")
+	createCmd.Flags().Bool("disable-proxy", false, "Don't inject the server-configured HTTP proxy into the runner")
+	createCmd.Flags().String("service-account", "", "ServiceAccount to run the runner as, for runners that need Kubernetes API access (must be allowed by the server)")
+	createCmd.Flags().Bool("disable-sa-token", false, "Don't mount a Kubernetes API token into the runner")
+	createCmd.Flags().String("priority-class", "", "Kubernetes PriorityClass for the runner's pod (must be allowed by the server; defaults to the server's)")
+	createCmd.Flags().Bool("skip-validation", false, "Don't have the server check that the --priority-class exists")
+	createCmd.Flags().String("arch", "", "CPU architecture to run the runner on, e.g. arm64 for native arm64 builds (must be supported by the server; defaults to any node)")
+	createCmd.Flags().String("default-shell", "", "Shell for commands that don't pass --shell, e.g. zsh (defaults to the server's, usually bash)")
+	createCmd.Flags().String("default-workdir", "", "Absolute working directory for commands that don't pass --workdir, e.g. /workspace")
+	createCmd.Flags().StringP("description", "d", "", "Note on what the runner is for, up to 1024 bytes")
+	createCmd.Flags().StringP("group", "g", "", "Group to put the runner in, e.g. ci-1234, for listing and deleting runners together")
+	createCmd.Flags().StringArray("host-alias", nil, "Extra /etc/hosts entry as IP=HOSTNAME[,HOSTNAME...], e.g. 10.0.0.5=legacy.corp.example,legacy (repeatable)")
+	createCmd.Flags().Bool("protected", false, "Protect the runner from deletion without --override-protection and from idle cleanup")
+	createCmd.Flags().Bool("auto-restart", false, "Recreate the runner if its pod fails, e.g. when its node goes away (defaults to the server's setting, on with --spot)")
+	createCmd.Flags().Bool("spot", false, "Run the runner on cheaper spot/preemptible nodes, which may be reclaimed (must be configured on the server)")
+	createCmd.Flags().String("ssh-public-key", "", "Public key file to allow SSH logins with (defaults to ~/.ssh/id_*.pub, then the ssh-agent's first key)")
+	createCmd.Flags().Int("count", 1, "Number of runners to create; with --name they are named NAME-1 to NAME-COUNT")
+	addReservationFlags(createCmd, "Keep the runner from idle cleanup for this long, e.g. 4h (at most the server's maximum)")
 	
 	// S3 workspace configuration flags
-	createCmd.Flags().String("s3-bucket", "", "S3 bucket name for workspace")
-	createCmd.Flags().String("s3-endpoint", "", "S3 endpoint URL (optional, defaults to AWS S3)")
-	createCmd.Flags().String("s3-prefix", "", "S3 path prefix within the bucket (optional)")
-	createCmd.Flags().String("s3-region", "", "AWS region (optional, defaults to us-east-1)")
-	createCmd.Flags().Bool("read-only", false, "Mount S3 bucket as read-only")
+	addWorkspaceFlags(createCmd)
+	createCmd.Flags().String("s3fs-cpu", "", "CPU for the s3fs sidecar, e.g. 200m (optional, bounded by the server max)")
+	createCmd.Flags().String("s3fs-memory", "", "Memory for the s3fs sidecar, e.g. 512Mi, for datasets with many files (optional, bounded by the server max)")
 
 	// List command flags
-	listCmd.Flags().StringP("status", "s", "", "Filter by status (creating, running, stopping, stopped, error)")
+	listCmd.Flags().StringP("status", "s", "", "Filter by status (creating, bootstrapping, running, restarting, stopping, stopped, error, delete_failed)")
 	listCmd.Flags().Int32P("limit", "l", 0, "Limit number of results")
 	listCmd.Flags().Int32("offset", 0, "Offset for pagination")
+	listCmd.Flags().BoolP("watch", "w", false, "Watch runners, refreshing the list until interrupted")
+	listCmd.Flags().Int("interval", 2, "Refresh interval in seconds for --watch")
+	listCmd.Flags().StringP("filter", "f", "", "Only list runners whose ID, name or description contains this text (case-insensitive)")
+	listCmd.Flags().StringP("group", "g", "", "Only list runners of this group")
+	listCmd.Flags().String("image-digest", "", "Only list runners whose image digest starts with this, e.g. sha256:3f1c")
+	listCmd.Flags().StringToString("label", nil, "Only list runners with this label, e.g. team=ml (repeatable)")
+	listCmd.Flags().String("sort-by", "", "Sort by id, name, age (newest first), status, cpu or memory (largest first)")
+	listCmd.Flags().Bool("reverse", false, "Reverse the --sort-by order")
+	listCmd.Flags().Bool("stale-only", false, "Only list runners running a different image than new runners get (marked * in the table)")
+	listCmd.Flags().String("arch", "", "Only list runners pinned to this CPU architecture, e.g. arm64")
+
+	// Get command flags
+	addParallelFlag(getCmd)
+
+	// Update command flags
+	updateCmd.Flags().StringP("description", "d", "", "New description, up to 1024 bytes (empty to clear)")
+
+	// Clone command flags
+	cloneCmd.Flags().StringP("name", "n", "", "Name of the new runner (optional)")
+
+	// Stats command flags
+	statsCmd.Flags().StringP("status", "s", "", "Only count runners with this status (creating, bootstrapping, running, restarting, stopping, stopped, error, delete_failed)")
+
+	// Top command flags
+	topCmd.Flags().Bool("containers", false, "Also show the usage of each container")
 
 	// Delete command flags
 	deleteCmd.Flags().Bool("all", false, "Delete all runners")
+	deleteCmd.Flags().StringP("group", "g", "", "Delete every runner of this group")
+	deleteCmd.Flags().Bool("wait", false, "Wait until the runner's pod and other objects are gone")
+	deleteCmd.Flags().Duration("wait-timeout", 0, "How long --wait may take (0 waits as long as the server allows)")
+	deleteCmd.Flags().Bool("override-protection", false, "Delete the runners even if they are protected")
+	deleteCmd.Flags().Bool("snapshot-first", false, "Archive the runner's workspace to S3 before deleting it, keeping the runner if that fails")
+	addSnapshotFlags(deleteCmd, "snapshot-")
+	addParallelFlag(deleteCmd)
 
 	// Exec command flags
-	execCmd.Flags().StringP("shell", "s", "bash", "Shell to use for command execution")
+	execCmd.Flags().StringP("shell", "S", "", "Shell to use for command execution (defaults to the runner's default shell)")
 	execCmd.Flags().Int32P("timeout", "t", 30, "Command execution timeout in seconds")
-	execCmd.Flags().StringP("workdir", "w", "", "Working directory for command execution")
+	execCmd.Flags().StringP("workdir", "W", "", "Working directory for command execution (defaults to the runner's default workdir)")
+	addStripANSIFlag(execCmd)
+	addExitCodeFlag(execCmd)
+	addRetryFlags(execCmd)
+	addOutputLimitFlags(execCmd)
+	addResumeFlag(execCmd)
+	addCorrelationEnvFlag(execCmd)
+	addTeeFlags(execCmd)
 
 	// Add subcommands
 	RunnersCmd.AddCommand(createCmd)
 	RunnersCmd.AddCommand(listCmd)
+	RunnersCmd.AddCommand(statsCmd)
+	RunnersCmd.AddCommand(topCmd)
 	RunnersCmd.AddCommand(getCmd)
+	RunnersCmd.AddCommand(updateCmd)
+	RunnersCmd.AddCommand(cloneCmd)
 	RunnersCmd.AddCommand(deleteCmd)
 	RunnersCmd.AddCommand(execCmd)
 }
\ No newline at end of file
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+func TestParseHostAliasFlags(t *testing.T) {
+	aliases, err := parseHostAliasFlags([]string{"10.0.0.5=legacy.corp.example,legacy", "fd00::6=db.corp.example"})
+	if err != nil {
+		t.Fatalf("parseHostAliasFlags failed: %v", err)
+	}
+	expected := []*gradv1.HostAlias{
+		{Ip: "10.0.0.5", Hostnames: []string{"legacy.corp.example", "legacy"}},
+		{Ip: "fd00::6", Hostnames: []string{"db.corp.example"}},
+	}
+	if len(aliases) != len(expected) {
+		t.Fatalf("Expected %d aliases, got %v", len(expected), aliases)
+	}
+	for i := range expected {
+		if !proto.Equal(aliases[i], expected[i]) {
+			t.Errorf("Expected %v, got %v", expected[i], aliases[i])
+		}
+	}
+
+	for _, value := range []string{"10.0.0.5", "=legacy", "10.0.0.5="} {
+		if _, err := parseHostAliasFlags([]string{value}); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestKeepStaleRunners(t *testing.T) {
+	runners := []*gradv1.Runner{{Id: "runner-1", StaleImage: true}, {Id: "runner-2"}}
+
+	if got := keepStaleRunners(runners, false); len(got) != 2 {
+		t.Errorf("Expected every runner without --stale-only, got %d", len(got))
+	}
+	if got := keepStaleRunners(runners, true); len(got) != 1 || got[0].Id != "runner-1" {
+		t.Errorf("Expected only runner-1, got %v", got)
+	}
+	// A server that doesn't know stale_only lists runners it doesn't mark
+	if got := keepStaleRunners([]*gradv1.Runner{{Id: "runner-2"}}, true); got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty list, got %v", got)
+	}
+}
+
+// Times of a runner the mock data just created, which change every run, in tables and JSON
+var (
+	creationTimes     = regexp.MustCompile(`\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(Z|[+-]\d\d:\d\d)`)
+	creationTimesJSON = regexp.MustCompile(`("(created|updated)_at": )"\d+"`)
+)
+
+func TestCreateGolden(t *testing.T) {
+	key, err := filepath.Abs(filepath.Join("testdata", "id_ed25519.pub"))
+	if err != nil {
+		t.Fatalf("Failed to find test key: %v", err)
+	}
+	create := []string{"runners", "create", "--name", "build", "--ssh-public-key", key}
+
+	tests := []struct {
+		name   string
+		golden string
+		flags  []string
+	}{
+		{"Table with next steps on stderr", "create", nil},
+		{"Quiet prints only the ID", "create_quiet", []string{"-q"}},
+		{"JSON prints only the runner", "create_json", []string{"-o", "json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := runGractl(t, append(create, tt.flags...)...)
+			got := "--- stdout\n" + stdout + "--- stderr\n" + stderr
+			got = creationTimes.ReplaceAllString(got, "<now>")
+			got = creationTimesJSON.ReplaceAllString(got, `$1"<now>"`)
+			assertGolden(t, tt.golden, []byte(got))
+		})
+	}
+}
+
+func TestCreateSpot(t *testing.T) {
+	key, err := filepath.Abs(filepath.Join("testdata", "id_ed25519.pub"))
+	if err != nil {
+		t.Fatalf("Failed to find test key: %v", err)
+	}
+	stdout, _ := runGractl(t, "runners", "create", "--spot", "-o", "json", "--ssh-public-key", key)
+	if !strings.Contains(stdout, `"spot": true`) {
+		t.Errorf("Expected a spot runner, got:\n%s", stdout)
+	}
+}
+
+func TestNextStepsForGroup(t *testing.T) {
+	runners := []*gradv1.Runner{
+		{Id: "runner-4", Group: "ci-1234", Status: gradv1.RunnerStatus_RUNNER_STATUS_CREATING},
+		{Id: "runner-5", Group: "ci-1234", Status: gradv1.RunnerStatus_RUNNER_STATUS_RUNNING},
+	}
+	expected := []string{
+		"gractl runners wait runner-4 runner-5 --for=running --all",
+		"gractl runners exec-all --group ci-1234 -- uname -a",
+		"gractl runners delete --group ci-1234",
+	}
+
+	steps := nextSteps(runners)
+	if len(steps) != len(expected) {
+		t.Fatalf("Expected %d steps, got %v", len(expected), steps)
+	}
+	for i, step := range steps {
+		if step[0] != expected[i] {
+			t.Errorf("Expected step %d to be %q, got %q", i, expected[i], step[0])
+		}
+	}
+}
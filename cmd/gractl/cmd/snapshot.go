@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// missingCredentialsMessage starts the error grad returns when neither the request nor the
+// runner has S3 credentials
+const missingCredentialsMessage = "no S3 credentials"
+
+// snapshotClient is the part of the grad client snapshots are taken with
+type snapshotClient interface {
+	SnapshotWorkspace(ctx context.Context, req *gradv1.SnapshotWorkspaceRequest, progress func(*gradv1.SnapshotWorkspaceResponse)) (*gradv1.SnapshotWorkspaceResponse, error)
+}
+
+// snapshotFlags are the flags describing where snapshots go, shared by runners snapshot and
+// runners delete --snapshot-first
+type snapshotFlags struct {
+	to               string
+	endpoint         string
+	region           string
+	localCredentials bool
+}
+
+// addSnapshotFlags adds the flags saying where snapshots go to cmd, their names starting with
+// prefix
+func addSnapshotFlags(cmd *cobra.Command, prefix string) {
+	cmd.Flags().String(prefix+"to", "", "S3 URL to upload the archive to, e.g. s3://bucket/path/ (a URL ending in / gets RUNNER_ID-TIMESTAMP.tar.gz appended)")
+	cmd.Flags().String(prefix+"endpoint", "", "S3 endpoint to upload to (defaults to the runner's workspace endpoint)")
+	cmd.Flags().String(prefix+"region", "", "S3 region to upload to (defaults to the runner's workspace region)")
+	cmd.Flags().Bool("local-credentials", false, "Upload with the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN of this shell instead of the runner's credentials")
+}
+
+// getSnapshotFlags reads the flags added by addSnapshotFlags
+func getSnapshotFlags(cmd *cobra.Command, prefix string) snapshotFlags {
+	var flags snapshotFlags
+	flags.to, _ = cmd.Flags().GetString(prefix + "to")
+	flags.endpoint, _ = cmd.Flags().GetString(prefix + "endpoint")
+	flags.region, _ = cmd.Flags().GetString(prefix + "region")
+	flags.localCredentials, _ = cmd.Flags().GetBool("local-credentials")
+	return flags
+}
+
+// snapshotRequest builds the request to snapshot runnerID as flags say, taking credentials
+// from getenv with --local-credentials (pure function)
+func snapshotRequest(runnerID string, flags snapshotFlags, getenv func(string) string) (*gradv1.SnapshotWorkspaceRequest, error) {
+	req := &gradv1.SnapshotWorkspaceRequest{
+		RunnerId:    runnerID,
+		Destination: flags.to,
+		Endpoint:    flags.endpoint,
+		Region:      flags.region,
+	}
+	if flags.localCredentials {
+		creds := &gradv1.S3Credentials{
+			AccessKeyId:     getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyId == "" || creds.SecretAccessKey == "" {
+			return nil, fmt.Errorf("--local-credentials needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY to be set")
+		}
+		req.Credentials = creds
+	}
+	return req, nil
+}
+
+// snapshotWorkspace takes a snapshot with client, reporting each change of phase and the
+// progress within it with logf
+func snapshotWorkspace(ctx context.Context, client snapshotClient, req *gradv1.SnapshotWorkspaceRequest, logf func(format string, args ...any)) (*gradv1.SnapshotWorkspaceResponse, error) {
+	return client.SnapshotWorkspace(ctx, req, func(resp *gradv1.SnapshotWorkspaceResponse) {
+		logf("%s", formatSnapshotProgress(resp))
+	})
+}
+
+// formatSnapshotProgress renders a progress report, e.g. "Uploading: 12Mi of 40Mi (30%)"
+// (pure function)
+func formatSnapshotProgress(resp *gradv1.SnapshotWorkspaceResponse) string {
+	phase := "Snapshotting"
+	switch resp.Phase {
+	case gradv1.SnapshotPhase_SNAPSHOT_PHASE_ARCHIVING:
+		phase = "Archiving"
+	case gradv1.SnapshotPhase_SNAPSHOT_PHASE_UPLOADING:
+		phase = "Uploading"
+	}
+	if resp.BytesTotal <= 0 {
+		return fmt.Sprintf("%s: %s", phase, formatOutputBytes(resp.BytesDone))
+	}
+	return fmt.Sprintf("%s: %s of %s (%d%%)", phase, formatOutputBytes(resp.BytesDone), formatOutputBytes(resp.BytesTotal), resp.BytesDone*100/resp.BytesTotal)
+}
+
+// snapshotURL is the S3 URL of an uploaded archive (pure function)
+func snapshotURL(resp *gradv1.SnapshotWorkspaceResponse) string {
+	return fmt.Sprintf("s3://%s/%s", resp.Bucket, resp.Key)
+}
+
+// snapshotErrorMessage explains why a snapshot failed, telling missing credentials and a
+// runner without room to stage the archive apart from other failures (pure function)
+func snapshotErrorMessage(err error) string {
+	st := status.Convert(err)
+	switch {
+	case st.Code() == codes.FailedPrecondition && strings.HasPrefix(st.Message(), missingCredentialsMessage):
+		return fmt.Sprintf("%s; give the runner AWS credentials or pass --local-credentials", st.Message())
+	case st.Code() == codes.ResourceExhausted:
+		return fmt.Sprintf("the runner has no room to stage the archive: %s", st.Message())
+	default:
+		return describeError(err)
+	}
+}
+
+// snapshotCmd represents the runners snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot RUNNER_ID --to s3://BUCKET/PATH",
+	Short: "Archive a runner's workspace to S3",
+	Long: `Archive the workspace of a running runner as a .tar.gz and upload it to S3, e.g. before
+deleting the runner. The S3 dataset mounted in the workspace is left out.
+
+The runner uploads the archive itself, with its own AWS credentials and its workspace's S3
+endpoint and region unless --local-credentials, --endpoint or --region say otherwise.
+Progress goes to stderr; the URL and size of the archive are printed once it is uploaded, or
+with -o json the final progress report.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runnerID := resolveRunnerArg(cmd, grpcClient, args[0])
+		flags := getSnapshotFlags(cmd, "")
+		if flags.to == "" {
+			fmt.Fprintf(os.Stderr, "Pass the S3 URL to upload to with --to\n")
+			os.Exit(1)
+		}
+		req, err := snapshotRequest(runnerID, flags, os.Getenv)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+
+		// Archiving a large workspace takes far longer than an RPC, so only Ctrl-C stops it
+		resp, err := snapshotWorkspace(commandContext(cmd), grpcClient, req, logger.Infof)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				fmt.Fprintf(os.Stderr, "Runner %s not found\n", runnerID)
+			} else {
+				fmt.Fprintf(os.Stderr, "Failed to snapshot runner: %s\n", snapshotErrorMessage(err))
+			}
+			os.Exit(1)
+		}
+
+		if outputFormat == OutputFormatJSON {
+			data, err := protoJSONOptions.Marshal(resp)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to print snapshot: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s\n", data)
+			return
+		}
+		fmt.Printf("Uploaded workspace of runner %s to %s (%s)\n", runnerID, snapshotURL(resp), formatOutputBytes(resp.SizeBytes))
+	},
+}
+
+func init() {
+	addSnapshotFlags(snapshotCmd, "")
+
+	RunnersCmd.AddCommand(snapshotCmd)
+}
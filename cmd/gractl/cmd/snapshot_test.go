@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+func TestSnapshotRequest(t *testing.T) {
+	env := map[string]string{"AWS_ACCESS_KEY_ID": "AKIA", "AWS_SECRET_ACCESS_KEY": "s3cr3t"}
+	getenv := func(key string) string { return env[key] }
+	flags := snapshotFlags{to: "s3://archive/", region: "eu-west-1"}
+
+	req, err := snapshotRequest("runner-1", flags, getenv)
+	if err != nil || req.Credentials != nil || req.Destination != "s3://archive/" || req.Region != "eu-west-1" {
+		t.Errorf("Expected the runner's own credentials by default, got %v, %v", req, err)
+	}
+
+	flags.localCredentials = true
+	req, err = snapshotRequest("runner-1", flags, getenv)
+	if err != nil || req.Credentials.GetAccessKeyId() != "AKIA" || req.Credentials.GetSecretAccessKey() != "s3cr3t" {
+		t.Errorf("Expected the shell's credentials, got %v, %v", req, err)
+	}
+
+	delete(env, "AWS_SECRET_ACCESS_KEY")
+	if _, err := snapshotRequest("runner-1", flags, getenv); err == nil {
+		t.Error("Expected an error without a secret key")
+	}
+}
+
+func TestFormatSnapshotProgress(t *testing.T) {
+	tests := []struct {
+		resp     *gradv1.SnapshotWorkspaceResponse
+		expected string
+	}{
+		{&gradv1.SnapshotWorkspaceResponse{Phase: gradv1.SnapshotPhase_SNAPSHOT_PHASE_ARCHIVING, BytesDone: 12 << 20, BytesTotal: 40 << 20}, "Archiving: 12Mi of 40Mi (30%)"},
+		{&gradv1.SnapshotWorkspaceResponse{Phase: gradv1.SnapshotPhase_SNAPSHOT_PHASE_UPLOADING, BytesDone: 2 << 30, BytesTotal: 2 << 30}, "Uploading: 2.0Gi of 2.0Gi (100%)"},
+		{&gradv1.SnapshotWorkspaceResponse{Phase: gradv1.SnapshotPhase_SNAPSHOT_PHASE_ARCHIVING, BytesDone: 512}, "Archiving: 512B"},
+	}
+	for _, tt := range tests {
+		if got := formatSnapshotProgress(tt.resp); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestSnapshotErrorMessage(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{status.Error(codes.FailedPrecondition, "no S3 credentials: no AWS credentials found"), "no S3 credentials: no AWS credentials found; give the runner AWS credentials or pass --local-credentials"},
+		{status.Error(codes.ResourceExhausted, "insufficient disk space: needs 12Gi in /tmp, 3Gi free"), "the runner has no room to stage the archive: insufficient disk space: needs 12Gi in /tmp, 3Gi free"},
+		{status.Error(codes.FailedPrecondition, "runner is not running"), "rpc error: code = FailedPrecondition desc = runner is not running"},
+	}
+	for _, tt := range tests {
+		if got := snapshotErrorMessage(tt.err); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestSnapshotWorkspace(t *testing.T) {
+	fake := gradtest.NewFake()
+	fake.AddRunners(gradtest.NewRunner("runner-1"))
+	client := gradclient.NewFromServices(fake, fake, fake)
+
+	var logged []string
+	logf := func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+	resp, err := snapshotWorkspace(context.Background(), client, &gradv1.SnapshotWorkspaceRequest{RunnerId: "runner-1", Destination: "s3://archive/run.tar.gz"}, logf)
+	if err != nil {
+		t.Fatalf("snapshotWorkspace failed: %v", err)
+	}
+	if snapshotURL(resp) != "s3://archive/run.tar.gz" {
+		t.Errorf("Expected the archive at the destination, got %s", snapshotURL(resp))
+	}
+	if len(logged) != 2 || !strings.HasPrefix(logged[0], "Archiving: ") || !strings.HasPrefix(logged[1], "Uploading: ") {
+		t.Errorf("Expected archiving and uploading to be logged, got %q", logged)
+	}
+}
+
+func TestSnapshotCommand(t *testing.T) {
+	stdout, _ := runGractl(t, "runners", "snapshot", "runner-1", "--to", "s3://archive/run.tar.gz")
+	if stdout != "Uploaded workspace of runner runner-1 to s3://archive/run.tar.gz (1Mi)\n" {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+}
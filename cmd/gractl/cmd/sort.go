@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// runnerSortKeys are the values of list --sort-by, the order_by keys grad understands
+var runnerSortKeys = []string{"id", "name", "age", "status", "cpu", "memory"}
+
+// validateSortKey checks a --sort-by value, "" meaning unsorted (pure function)
+func validateSortKey(key string) error {
+	if key != "" && !slices.Contains(runnerSortKeys, key) {
+		return fmt.Errorf("unknown sort key %q (must be one of %s)", key, strings.Join(runnerSortKeys, ", "))
+	}
+	return nil
+}
+
+// sortRunners orders a page of runners by key the way grad orders them for order_by, so a
+// page from a server that ignores order_by is still sorted: id and name alphabetically,
+// status in API order, age newest first, cpu and memory largest request first, then by ID.
+// Runners without resources count as requesting none. reverse flips the order; an empty key
+// leaves the runners as they are. (pure function)
+func sortRunners(runners []*gradv1.Runner, key string, reverse bool) {
+	var compare func(a, b *gradv1.Runner) int
+	switch key {
+	case "id":
+		compare = func(a, b *gradv1.Runner) int { return 0 }
+	case "name":
+		compare = func(a, b *gradv1.Runner) int { return cmp.Compare(a.Name, b.Name) }
+	case "age":
+		compare = func(a, b *gradv1.Runner) int { return cmp.Compare(b.CreatedAt, a.CreatedAt) }
+	case "status":
+		compare = func(a, b *gradv1.Runner) int { return cmp.Compare(a.Status, b.Status) }
+	case "cpu":
+		compare = func(a, b *gradv1.Runner) int {
+			return cmp.Compare(b.Resources.GetCpuMillicores(), a.Resources.GetCpuMillicores())
+		}
+	case "memory":
+		compare = func(a, b *gradv1.Runner) int {
+			return cmp.Compare(b.Resources.GetMemoryMb(), a.Resources.GetMemoryMb())
+		}
+	default:
+		return
+	}
+
+	slices.SortStableFunc(runners, func(a, b *gradv1.Runner) int {
+		if reverse {
+			a, b = b, a
+		}
+		return cmp.Or(compare(a, b), cmp.Compare(a.Id, b.Id))
+	})
+}
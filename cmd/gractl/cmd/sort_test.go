@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// sortFixture returns runners differing in every sort key, with ties and missing resources
+func sortFixture() []*gradv1.Runner {
+	resources := func(cpu, memory int32) *gradv1.ResourceRequirements {
+		return &gradv1.ResourceRequirements{CpuMillicores: cpu, MemoryMb: memory}
+	}
+	return []*gradv1.Runner{
+		{Id: "runner-3", Name: "web", Status: gradv1.RunnerStatus_RUNNER_STATUS_RUNNING, CreatedAt: 1700000300, Resources: resources(2000, 2048)},
+		{Id: "runner-10", Name: "api", Status: gradv1.RunnerStatus_RUNNER_STATUS_ERROR, CreatedAt: 1700000500, Resources: resources(8000, 1024)},
+		{Id: "runner-1", Name: "web", Status: gradv1.RunnerStatus_RUNNER_STATUS_CREATING, CreatedAt: 1700000100},
+		{Id: "runner-7", Name: "", Status: gradv1.RunnerStatus_RUNNER_STATUS_RUNNING, CreatedAt: 1700000700, Resources: resources(2000, 8192)},
+		{Id: "runner-5", Name: "batch", Status: gradv1.RunnerStatus_RUNNER_STATUS_STOPPED, CreatedAt: 1700000500, Resources: &gradv1.ResourceRequirements{}},
+	}
+}
+
+func TestSortRunnersGolden(t *testing.T) {
+	var got strings.Builder
+	for _, key := range runnerSortKeys {
+		for _, reverse := range []bool{false, true} {
+			runners := sortFixture()
+			sortRunners(runners, key, reverse)
+
+			ids := make([]string, len(runners))
+			for i, runner := range runners {
+				ids[i] = runner.Id
+			}
+			fmt.Fprintf(&got, "--sort-by %s --reverse=%t: %s\n", key, reverse, strings.Join(ids, " "))
+		}
+	}
+	assertGolden(t, "runner_sort", []byte(got.String()))
+}
+
+func TestValidateSortKey(t *testing.T) {
+	for _, key := range append([]string{""}, runnerSortKeys...) {
+		if err := validateSortKey(key); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", key, err)
+		}
+	}
+	if err := validateSortKey("size"); err == nil {
+		t.Error("Expected an unknown key to be rejected")
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/strrl/gra/cmd/gractl/config"
+	"github.com/strrl/gra/pkg/sshkey"
+)
+
+// readPublicKeyFile reads an SSH public key from path, or finds the user's key if path is
+// empty, and checks it is one before it is sent to the server
+func readPublicKeyFile(path string) (string, error) {
+	key, _, err := sshkey.DefaultFinder().Find(path)
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		return "", fmt.Errorf("no SSH public key found in ~/.ssh or ssh-agent; pass a key file")
+	}
+	return key.String(), nil
+}
+
+// sshPublicKeyForRunner returns the key to inject into a new runner: the --ssh-public-key
+// file, else the ssh.public_key config file, else the user's key from ~/.ssh or ssh-agent.
+// A key file that was asked for but can't be read is fatal; finding no key only warns.
+func sshPublicKeyForRunner(cmd *cobra.Command, cfg *config.Config) string {
+	path, _ := cmd.Flags().GetString("ssh-public-key")
+	if path == "" && cfg != nil {
+		path = cfg.SSH.PublicKey
+	}
+
+	key, source, err := sshkey.DefaultFinder().Find(path)
+	if err != nil && path != "" {
+		fmt.Fprintf(os.Stderr, "Failed to read SSH public key: %v\n", err)
+		os.Exit(1)
+	}
+	if key == nil {
+		logger.Warnf("%s", noSSHKeyWarning(err))
+		return ""
+	}
+
+	logger.Debugf("Injecting SSH public key %s from %s", key.Fingerprint(), source)
+	return key.String()
+}
+
+// noSSHKeyWarning explains that a runner gets no SSH key and what that breaks (pure function)
+func noSSHKeyWarning(agentErr error) string {
+	warning := "No SSH public key found in ~/.ssh or ssh-agent"
+	if agentErr != nil {
+		warning += fmt.Sprintf(" (%v)", agentErr)
+	}
+	return warning + "; the runner won't accept SSH logins and workspace sync won't work. " +
+		"Pass --ssh-public-key or set ssh.public_key in .gractl.toml"
+}
+
+// sshKeysCmd represents the ssh-keys command
+var sshKeysCmd = &cobra.Command{
+	Use:   "ssh-keys",
+	Short: "Manage the SSH keys allowed to log in to a runner",
+	Long: `Add, list and remove the SSH public keys allowed to log in to a running runner, e.g.
+to give a teammate access without recreating it. The key a runner was created with is not
+listed and can't be removed.`,
+}
+
+// sshKeysAddCmd represents the ssh-keys add command
+var sshKeysAddCmd = &cobra.Command{
+	Use:   "add RUNNER_ID [PUBLIC_KEY_FILE]",
+	Short: "Allow a public key to log in to a runner",
+	Long: `Allow a public key to log in to a running runner. Without a file, your own key is
+added from ~/.ssh (id_ed25519.pub, id_rsa.pub, ...) or else from ssh-agent. Adding a key the
+runner already has changes nothing.
+
+Examples:
+  gractl runners ssh-keys add runner-1 ~/keys/alice.pub`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runnerID := resolveRunnerArg(cmd, grpcClient, args[0])
+		var path string
+		if len(args) > 1 {
+			path = args[1]
+		}
+		publicKey, err := readPublicKeyFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read SSH key: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		key, added, err := grpcClient.AddSSHKey(ctx, runnerID, publicKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to add SSH key: %s\n", describeError(err))
+			os.Exit(1)
+		}
+
+		message := fmt.Sprintf("Added SSH key %s to runner %s", key.Fingerprint, runnerID)
+		if !added {
+			message = fmt.Sprintf("Runner %s already has SSH key %s", runnerID, key.Fingerprint)
+		}
+		if err := PrintMessage(message); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print message: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// sshKeysListCmd represents the ssh-keys list command
+var sshKeysListCmd = &cobra.Command{
+	Use:   "list RUNNER_ID",
+	Short: "List the SSH keys added to a runner",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runnerID := resolveRunnerArg(cmd, grpcClient, args[0])
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		keys, err := grpcClient.ListSSHKeys(ctx, runnerID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list SSH keys: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := PrintSSHKeys(keys); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print SSH keys: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// sshKeysRemoveCmd represents the ssh-keys remove command
+var sshKeysRemoveCmd = &cobra.Command{
+	Use:   "remove RUNNER_ID FINGERPRINT",
+	Short: "Remove an SSH key from a runner",
+	Long: `Remove an SSH key added with "ssh-keys add" by its fingerprint, as shown by
+"ssh-keys list". Sessions already logged in with the key are not ended.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runnerID, fingerprint := resolveRunnerArg(cmd, grpcClient, args[0]), args[1]
+		ctx, cancel := rpcContext(commandContext(cmd))
+		defer cancel()
+		removed, err := grpcClient.RemoveSSHKey(ctx, runnerID, fingerprint)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to remove SSH key: %s\n", describeError(err))
+			os.Exit(1)
+		}
+
+		message := fmt.Sprintf("Removed SSH key %s from runner %s", fingerprint, runnerID)
+		if !removed {
+			message = fmt.Sprintf("Runner %s has no SSH key %s", runnerID, fingerprint)
+		}
+		if err := PrintMessage(message); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print message: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	sshKeysCmd.AddCommand(sshKeysAddCmd)
+	sshKeysCmd.AddCommand(sshKeysListCmd)
+	sshKeysCmd.AddCommand(sshKeysRemoveCmd)
+
+	RunnersCmd.AddCommand(sshKeysCmd)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// teeMetaSuffix is appended to the --tee path for the file recording how the command ended
+const teeMetaSuffix = ".meta.json"
+
+// teeMeta is the content of the .meta.json file written next to the --tee output
+type teeMeta struct {
+	Command  string `json:"command"`
+	RunnerID string `json:"runner_id,omitempty"`
+	ExecID   string `json:"exec_id,omitempty"`
+	// ExitCode is the command's own, null if the stream ended without one
+	ExitCode        *int32    `json:"exit_code"`
+	Interrupted     bool      `json:"interrupted"`
+	Error           string    `json:"error,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+}
+
+// commandTee copies the output of a remote command to local files as it is received, while
+// it is printed as usual. Writes aren't buffered, so whatever arrived before an interrupt is
+// in the files.
+type commandTee struct {
+	// stdout and stderr are the files the streams go to; both are the same file unless
+	// --tee-stderr splits them, and either is nil if not teed
+	stdout   *os.File
+	stderr   *os.File
+	metaPath string
+	meta     teeMeta
+}
+
+// addTeeFlags adds --tee, --tee-stderr and --append to a command that streams remote output
+func addTeeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("tee", "", "Also write the command's output to this file as it is received, stderr too unless --tee-stderr is set, and its exit code and timing to FILE.meta.json")
+	cmd.Flags().String("tee-stderr", "", "Write the command's stderr to this file instead of the --tee file")
+	cmd.Flags().Bool("append", false, "Append to the --tee and --tee-stderr files instead of overwriting them")
+}
+
+// teeFromFlags opens the files of the flags added by addTeeFlags for command, or returns nil
+// if neither --tee nor --tee-stderr is set
+func teeFromFlags(cmd *cobra.Command, command string) (*commandTee, error) {
+	stdoutPath, _ := cmd.Flags().GetString("tee")
+	stderrPath, _ := cmd.Flags().GetString("tee-stderr")
+	appendMode, _ := cmd.Flags().GetBool("append")
+	if stdoutPath == "" && stderrPath == "" {
+		if appendMode {
+			return nil, errors.New("--append needs --tee or --tee-stderr")
+		}
+		return nil, nil
+	}
+	return openTee(stdoutPath, stderrPath, appendMode, command)
+}
+
+// openTee creates the files output is teed to, and their parent directories. Without
+// stderrPath, or with one naming the same file, stderr goes to stdoutPath too, interleaved as
+// received; without stdoutPath stdout isn't teed.
+func openTee(stdoutPath, stderrPath string, appendMode bool, command string) (*commandTee, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMode {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	open := func(path string) (*os.File, error) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create the directory of %s: %w", path, err)
+		}
+		return os.OpenFile(path, flags, 0o644)
+	}
+
+	t := &commandTee{
+		metaPath: cmp.Or(stdoutPath, stderrPath) + teeMetaSuffix,
+		meta:     teeMeta{Command: command, StartedAt: time.Now()},
+	}
+	var err error
+	if stdoutPath != "" {
+		if t.stdout, err = open(stdoutPath); err != nil {
+			return nil, err
+		}
+		t.stderr = t.stdout
+	}
+	if stderrPath != "" && !sameFile(t.stdout, stderrPath) {
+		if t.stderr, err = open(stderrPath); err != nil {
+			t.closeFiles()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// sameFile reports whether path names the open file, which may be nil, such as through a
+// different spelling of its path or a link to it
+func sameFile(file *os.File, path string) bool {
+	if file == nil {
+		return false
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	pathInfo, err := os.Stat(path)
+	return err == nil && os.SameFile(fileInfo, pathInfo)
+}
+
+// handler wraps handle to write the output in each message to the tee files first. A nil tee
+// returns handle as it is.
+func (t *commandTee) handler(handle gradclient.StreamHandler) gradclient.StreamHandler {
+	if t == nil {
+		return handle
+	}
+	return func(resp *gradv1.ExecuteCommandStreamResponse) error {
+		var file *os.File
+		switch resp.Type {
+		case gradv1.StreamType_STREAM_TYPE_STARTED:
+			t.meta.RunnerID = cmp.Or(resp.RunnerId, t.meta.RunnerID)
+			t.meta.ExecID = cmp.Or(resp.ExecId, t.meta.ExecID)
+		case gradv1.StreamType_STREAM_TYPE_STDOUT:
+			file = t.stdout
+		case gradv1.StreamType_STREAM_TYPE_STDERR:
+			file = t.stderr
+		}
+		if file != nil {
+			if _, err := file.Write(resp.Data); err != nil {
+				return fmt.Errorf("failed to write --tee output: %w", err)
+			}
+		}
+		return handle(resp)
+	}
+}
+
+// finish records how the command ended, with exit and streamErr as runWithRetry returned
+// them, in the .meta.json file and closes the tee files. Call it before exiting, also when
+// the command was interrupted. Finishing a nil tee does nothing.
+func (t *commandTee) finish(exit *gradv1.ExecuteCommandStreamResponse, streamErr error) error {
+	if t == nil {
+		return nil
+	}
+	t.meta.FinishedAt = time.Now()
+	t.meta.DurationSeconds = t.meta.FinishedAt.Sub(t.meta.StartedAt).Seconds()
+	if exit != nil {
+		t.meta.ExitCode = &exit.ExitCode
+	}
+	t.meta.Interrupted = errors.Is(streamErr, errInterrupted)
+	if streamErr != nil {
+		t.meta.Error = streamErr.Error()
+	}
+
+	err := t.closeFiles()
+	data, marshalErr := json.MarshalIndent(t.meta, "", "  ")
+	if marshalErr != nil {
+		return errors.Join(err, marshalErr)
+	}
+	return errors.Join(err, os.WriteFile(t.metaPath, append(data, '\n'), 0o644))
+}
+
+// closeFiles syncs and closes the tee files
+func (t *commandTee) closeFiles() error {
+	files := []*os.File{t.stdout}
+	if t.stderr != t.stdout {
+		files = append(files, t.stderr)
+	}
+
+	var errs []error
+	for _, file := range files {
+		if file != nil {
+			errs = append(errs, file.Sync(), file.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+// teeClient returns a client of a fake with a running runner-1 where make builds with a
+// warning and exits with 2
+func teeClient() (*gradclient.Client, *gradtest.Fake) {
+	fake := gradtest.NewFake()
+	fake.AddRunners(gradtest.NewRunner("runner-1"))
+	fake.SetOutput("make", gradtest.Output{Stdout: "built\n", Stderr: "warning: unused variable\n", ExitCode: 2})
+	return gradclient.NewFromServices(fake, fake, fake), fake
+}
+
+// runTeed runs make on runner-1 through tee, printing nothing, and finishes the tee
+func runTeed(t *testing.T, c *gradclient.Client, tee *commandTee) {
+	t.Helper()
+	exit, err := c.Exec(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "make"}, tee.handler(gradclient.WriteOutput(io.Discard, io.Discard)))
+	if err := tee.finish(exit, err); err != nil {
+		t.Fatalf("Failed to finish the tee: %v", err)
+	}
+}
+
+// readFile returns the content of path
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+// readTeeMeta decodes the .meta.json file of the tee file path
+func readTeeMeta(t *testing.T, path string) teeMeta {
+	t.Helper()
+	var meta teeMeta
+	if err := json.Unmarshal([]byte(readFile(t, path+teeMetaSuffix)), &meta); err != nil {
+		t.Fatalf("Failed to decode the meta file: %v", err)
+	}
+	return meta
+}
+
+func TestTeeWritesOutputAndMeta(t *testing.T) {
+	c, _ := teeClient()
+	path := filepath.Join(t.TempDir(), "logs", "build", "make.log")
+
+	tee, err := openTee(path, "", false, "make")
+	if err != nil {
+		t.Fatalf("openTee failed: %v", err)
+	}
+	runTeed(t, c, tee)
+
+	if got := readFile(t, path); got != "built\nwarning: unused variable\n" {
+		t.Errorf("Expected stdout and stderr in the file, got %q", got)
+	}
+	meta := readTeeMeta(t, path)
+	if meta.ExitCode == nil || *meta.ExitCode != 2 || meta.Interrupted || meta.Error != "" {
+		t.Errorf("Expected the command's exit code 2, got %+v", meta)
+	}
+	if meta.Command != "make" || meta.RunnerID != "runner-1" {
+		t.Errorf("Expected make on runner-1, got %q on %q", meta.Command, meta.RunnerID)
+	}
+	if meta.FinishedAt.Before(meta.StartedAt) || meta.DurationSeconds < 0 {
+		t.Errorf("Expected the command to finish after it started, got %+v", meta)
+	}
+}
+
+func TestTeeSplitsStderrAndAppends(t *testing.T) {
+	c, _ := teeClient()
+	dir := t.TempDir()
+	stdoutPath, stderrPath := filepath.Join(dir, "out.log"), filepath.Join(dir, "err.log")
+
+	for range 2 {
+		tee, err := openTee(stdoutPath, stderrPath, true, "make")
+		if err != nil {
+			t.Fatalf("openTee failed: %v", err)
+		}
+		runTeed(t, c, tee)
+	}
+	if got := readFile(t, stdoutPath); got != "built\nbuilt\n" {
+		t.Errorf("Expected stdout of both runs, got %q", got)
+	}
+	if got := readFile(t, stderrPath); got != "warning: unused variable\nwarning: unused variable\n" {
+		t.Errorf("Expected stderr of both runs, got %q", got)
+	}
+
+	// Without --append the file starts over
+	tee, err := openTee(stdoutPath, stderrPath, false, "make")
+	if err != nil {
+		t.Fatalf("openTee failed: %v", err)
+	}
+	runTeed(t, c, tee)
+	if got := readFile(t, stdoutPath); got != "built\n" {
+		t.Errorf("Expected only the last run's stdout, got %q", got)
+	}
+}
+
+func TestTeeStderrToTheSameFile(t *testing.T) {
+	c, _ := teeClient()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "make.log")
+	link := filepath.Join(dir, "latest.log")
+	if err := os.Symlink(path, link); err != nil {
+		t.Fatalf("Failed to link the tee file: %v", err)
+	}
+	t.Chdir(dir)
+
+	// The same file under another name is opened once, so the streams interleave instead of
+	// overwriting each other
+	for _, stderrPath := range []string{path, "./make.log", link} {
+		tee, err := openTee(path, stderrPath, false, "make")
+		if err != nil {
+			t.Fatalf("openTee failed: %v", err)
+		}
+		if tee.stderr != tee.stdout {
+			t.Errorf("Expected %s to reuse the stdout file", stderrPath)
+		}
+		runTeed(t, c, tee)
+		if got := readFile(t, path); got != "built\nwarning: unused variable\n" {
+			t.Errorf("Expected stdout and stderr in the file for %s, got %q", stderrPath, got)
+		}
+	}
+}
+
+func TestTeeKeepsOutputWhenInterrupted(t *testing.T) {
+	c, fake := teeClient()
+	fake.QueueExec(gradtest.ExecScript{
+		Frames: []*gradv1.ExecuteCommandStreamResponse{gradtest.StdoutFrame("epoch 1\n")},
+		Err:    status.Error(codes.Canceled, "context canceled"),
+	})
+	path := filepath.Join(t.TempDir(), "train.log")
+	tee, err := openTee(path, "", false, "python train.py")
+	if err != nil {
+		t.Fatalf("openTee failed: %v", err)
+	}
+
+	// Ctrl+C arrives once the first output has been printed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exit, err := c.Exec(ctx, &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "python train.py"}, tee.handler(func(resp *gradv1.ExecuteCommandStreamResponse) error {
+		if resp.Type == gradv1.StreamType_STREAM_TYPE_STDOUT {
+			cancel()
+		}
+		return nil
+	}))
+	err = interruptible(ctx, err)
+	if !errors.Is(err, errInterrupted) {
+		t.Fatalf("Expected the stream to be interrupted, got %v", err)
+	}
+	if err := tee.finish(exit, err); err != nil {
+		t.Fatalf("Failed to finish the tee: %v", err)
+	}
+
+	if got := readFile(t, path); got != "epoch 1\n" {
+		t.Errorf("Expected the output received before the interrupt, got %q", got)
+	}
+	if meta := readTeeMeta(t, path); !meta.Interrupted || meta.ExitCode != nil || meta.Error == "" {
+		t.Errorf("Expected an interrupted command without exit code, got %+v", meta)
+	}
+}
+
+func TestTeeFromFlags(t *testing.T) {
+	cmd := &cobra.Command{}
+	addTeeFlags(cmd)
+	if tee, err := teeFromFlags(cmd, "make"); tee != nil || err != nil {
+		t.Errorf("Expected no tee without flags, got %v and %v", tee, err)
+	}
+
+	cmd.Flags().Set("append", "true")
+	if _, err := teeFromFlags(cmd, "make"); err == nil {
+		t.Error("Expected --append without --tee to be rejected")
+	}
+
+	path := filepath.Join(t.TempDir(), "err.log")
+	cmd.Flags().Set("tee-stderr", path)
+	tee, err := teeFromFlags(cmd, "make")
+	if err != nil {
+		t.Fatalf("teeFromFlags failed: %v", err)
+	}
+	c, _ := teeClient()
+	runTeed(t, c, tee)
+	if got := readFile(t, path); got != "warning: unused variable\n" {
+		t.Errorf("Expected only stderr, got %q", got)
+	}
+	if meta := readTeeMeta(t, path); meta.ExitCode == nil {
+		t.Errorf("Expected the meta file next to the --tee-stderr file, got %+v", meta)
+	}
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// Exit codes of gractl runners wait besides 0 for a met condition. Failures to reach the
+// server exit with the usual infrastructure codes.
+const (
+	// exitCodeWaitTimeout means the timeout passed before the condition was met
+	exitCodeWaitTimeout = 1
+	// exitCodeWaitUnreachable means the runners got into a state the condition can't follow from
+	exitCodeWaitUnreachable = 2
+)
+
+// waitCondition is a state gractl runners wait can wait for
+type waitCondition string
+
+const (
+	waitForRunning waitCondition = "running"
+	waitForStopped waitCondition = "stopped"
+	waitForDeleted waitCondition = "deleted"
+)
+
+// waitState is how a runner stands with respect to a condition
+type waitState int
+
+const (
+	// waitPending means the runner may still meet the condition
+	waitPending waitState = iota
+	// waitMet means the runner meets the condition
+	waitMet
+	// waitUnreachable means the runner won't meet the condition without intervention
+	waitUnreachable
+)
+
+// waitClient is the part of the grad client gractl runners wait polls
+type waitClient interface {
+	GetRunner(ctx context.Context, runnerID string) (*gradv1.Runner, error)
+}
+
+// parseWaitCondition parses the --for flag (pure function)
+func parseWaitCondition(value string) (waitCondition, error) {
+	switch condition := waitCondition(value); condition {
+	case waitForRunning, waitForStopped, waitForDeleted:
+		return condition, nil
+	default:
+		return "", fmt.Errorf("invalid condition %q (must be running, stopped or deleted)", value)
+	}
+}
+
+// check returns how runner stands with respect to the condition; a nil runner has been
+// deleted (pure function)
+func (c waitCondition) check(runner *gradv1.Runner) waitState {
+	if runner == nil {
+		if c == waitForRunning {
+			return waitUnreachable
+		}
+		// A runner that is gone is stopped too
+		return waitMet
+	}
+
+	switch c {
+	case waitForRunning:
+		switch runner.Status {
+		case gradv1.RunnerStatus_RUNNER_STATUS_RUNNING:
+			return waitMet
+		case gradv1.RunnerStatus_RUNNER_STATUS_ERROR,
+			gradv1.RunnerStatus_RUNNER_STATUS_STOPPING,
+			gradv1.RunnerStatus_RUNNER_STATUS_STOPPED,
+			gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED:
+			return waitUnreachable
+		}
+	case waitForStopped:
+		switch runner.Status {
+		case gradv1.RunnerStatus_RUNNER_STATUS_STOPPED:
+			return waitMet
+		case gradv1.RunnerStatus_RUNNER_STATUS_ERROR,
+			gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED:
+			return waitUnreachable
+		}
+	case waitForDeleted:
+		// Deleting a runner again is the only way out of delete_failed
+		if runner.Status == gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED {
+			return waitUnreachable
+		}
+	}
+	// Creating, bootstrapping, restarting and stopping runners are on their way somewhere
+	return waitPending
+}
+
+// runnerStateName names a runner's state in wait progress lines; a nil runner has been
+// deleted (pure function)
+func runnerStateName(runner *gradv1.Runner) string {
+	if runner == nil {
+		return "Deleted"
+	}
+	return formatStatus(runner.Status)
+}
+
+// describeUnreachable explains why a runner won't meet condition (pure function)
+func describeUnreachable(runnerID string, runner *gradv1.Runner, condition waitCondition) string {
+	state := strings.ToLower(runnerStateName(runner))
+	if runner != nil && runner.StatusDetail != "" {
+		state += ": " + runner.StatusDetail
+	}
+	return fmt.Sprintf("runner %s is %s and won't become %s", runnerID, state, condition)
+}
+
+// waitForRunners polls runnerIDs every interval until all of them, or with all unset any of
+// them, meet condition, reporting state changes to progress. It returns gractl's exit code,
+// with an error explaining any code but 0. ctx's deadline is the wait's timeout; cancelling
+// ctx interrupts the wait with ExitCodeInterrupted.
+func waitForRunners(ctx context.Context, client waitClient, runnerIDs []string, condition waitCondition, all bool, interval time.Duration, progress func(format string, args ...interface{})) (int, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastState := make(map[string]string, len(runnerIDs))
+	pending := runnerIDs
+	// stopped ends the wait once ctx is done: its deadline times the wait out, Ctrl+C interrupts it
+	stopped := func() (int, error) {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return ExitCodeInterrupted, errInterrupted
+		}
+		return exitCodeWaitTimeout, fmt.Errorf("timed out waiting for %s to become %s", strings.Join(pending, ", "), condition)
+	}
+	for {
+		var stillPending, unreachable []string
+		met := 0
+		for _, runnerID := range runnerIDs {
+			getCtx, cancel := rpcContext(ctx)
+			runner, err := client.GetRunner(getCtx, runnerID)
+			cancel()
+			if err != nil && status.Code(err) != codes.NotFound {
+				if ctx.Err() != nil {
+					return stopped()
+				}
+				return infraExitCode(err), fmt.Errorf("failed to get runner %s: %w", runnerID, err)
+			}
+			if err != nil {
+				runner = nil
+			}
+
+			state := runnerStateName(runner)
+			if previous, ok := lastState[runnerID]; !ok {
+				progress("%s: %s", runnerID, state)
+			} else if previous != state {
+				progress("%s: %s -> %s", runnerID, previous, state)
+			}
+			lastState[runnerID] = state
+
+			switch condition.check(runner) {
+			case waitMet:
+				met++
+			case waitUnreachable:
+				unreachable = append(unreachable, describeUnreachable(runnerID, runner, condition))
+			default:
+				stillPending = append(stillPending, runnerID)
+			}
+		}
+		pending = stillPending
+
+		switch {
+		case all && len(unreachable) > 0, !all && len(unreachable) == len(runnerIDs):
+			return exitCodeWaitUnreachable, fmt.Errorf("%s", strings.Join(unreachable, "; "))
+		case all && met == len(runnerIDs), !all && met > 0:
+			return 0, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return stopped()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitCmd represents the runners wait command
+var waitCmd = &cobra.Command{
+	Use:   "wait RUNNER_ID... --for=running|stopped|deleted",
+	Short: "Wait until runners are running, stopped or deleted",
+	Long: `Wait until a runner is running, stopped or deleted, e.g. in a CI script. Each change of
+state is printed to stderr unless --quiet is set. With several runners, gractl returns once
+any of them meets the condition, or with --all once all of them do.
+
+A deleted runner counts as stopped. Exits with 0 once the condition is met, 1 if --timeout
+passes first and 2 if a runner gets into a state it won't get out of on its own, e.g. error
+while waiting for running or delete_failed while waiting for deleted.
+
+Examples:
+  gractl runners wait runner-1 --for=running --timeout 5m
+  gractl runners wait job-1 job-2 job-3 --for=deleted --all`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		forValue, _ := cmd.Flags().GetString("for")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		all, _ := cmd.Flags().GetBool("all")
+
+		condition, err := parseWaitCondition(forValue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --for: %v\n", err)
+			os.Exit(ExitCodeUsage)
+		}
+
+		ctx := commandContext(cmd)
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		runnerIDs := resolveRunnerArgs(cmd, grpcClient, args...)
+		code, err := waitForRunners(ctx, grpcClient, runnerIDs, condition, all, gradclient.DefaultPollInterval, logger.Infof)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to wait for runners: %v\n", err)
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	waitCmd.Flags().String("for", "", "State to wait for: running, stopped or deleted")
+	waitCmd.Flags().Duration("timeout", 5*time.Minute, "How long to wait, e.g. 90s or 10m (0 waits forever)")
+	waitCmd.Flags().Bool("all", false, "With several runners, wait until all of them meet the condition rather than any")
+	_ = waitCmd.MarkFlagRequired("for")
+
+	RunnersCmd.AddCommand(waitCmd)
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+// newWaitClient returns a client whose runners go through their scripts, one status per
+// GetRunner, all with the status detail ImagePullBackOff
+func newWaitClient(scripts map[string][]gradv1.RunnerStatus) (*gradclient.Client, *gradtest.Fake) {
+	fake := gradtest.NewFake()
+	for runnerID, script := range scripts {
+		fake.AddRunners(gradtest.NewRunner(runnerID, gradtest.WithStatusDetail("ImagePullBackOff")))
+		fake.ScriptStatus(runnerID, script...)
+	}
+	return gradclient.NewFromServices(fake, fake, fake), fake
+}
+
+func TestWaitConditionCheck(t *testing.T) {
+	tests := []struct {
+		condition waitCondition
+		status    gradv1.RunnerStatus
+		expected  waitState
+	}{
+		{waitForRunning, gradv1.RunnerStatus_RUNNER_STATUS_CREATING, waitPending},
+		{waitForRunning, gradv1.RunnerStatus_RUNNER_STATUS_BOOTSTRAPPING, waitPending},
+		{waitForRunning, gradv1.RunnerStatus_RUNNER_STATUS_RESTARTING, waitPending},
+		{waitForRunning, gradv1.RunnerStatus_RUNNER_STATUS_RUNNING, waitMet},
+		{waitForRunning, gradv1.RunnerStatus_RUNNER_STATUS_ERROR, waitUnreachable},
+		{waitForRunning, gradv1.RunnerStatus_RUNNER_STATUS_STOPPING, waitUnreachable},
+		{waitForRunning, gradtest.StatusDeleted, waitUnreachable},
+		{waitForStopped, gradv1.RunnerStatus_RUNNER_STATUS_RUNNING, waitPending},
+		{waitForStopped, gradv1.RunnerStatus_RUNNER_STATUS_STOPPING, waitPending},
+		{waitForStopped, gradv1.RunnerStatus_RUNNER_STATUS_STOPPED, waitMet},
+		{waitForStopped, gradtest.StatusDeleted, waitMet},
+		{waitForStopped, gradv1.RunnerStatus_RUNNER_STATUS_ERROR, waitUnreachable},
+		{waitForDeleted, gradv1.RunnerStatus_RUNNER_STATUS_STOPPING, waitPending},
+		{waitForDeleted, gradv1.RunnerStatus_RUNNER_STATUS_ERROR, waitPending},
+		{waitForDeleted, gradtest.StatusDeleted, waitMet},
+		{waitForDeleted, gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED, waitUnreachable},
+	}
+
+	for _, tt := range tests {
+		var runner *gradv1.Runner
+		if tt.status != gradtest.StatusDeleted {
+			runner = &gradv1.Runner{Status: tt.status}
+		}
+		if got := tt.condition.check(runner); got != tt.expected {
+			t.Errorf("Expected %s for %s to be %d, got %d", tt.condition, runnerStateName(runner), tt.expected, got)
+		}
+	}
+}
+
+func TestParseWaitCondition(t *testing.T) {
+	if condition, err := parseWaitCondition("deleted"); err != nil || condition != waitForDeleted {
+		t.Errorf("Expected deleted, got %q, %v", condition, err)
+	}
+	if _, err := parseWaitCondition("ready"); err == nil {
+		t.Error("Expected an unknown condition to be rejected")
+	}
+}
+
+func TestWaitForRunners(t *testing.T) {
+	creating := gradv1.RunnerStatus_RUNNER_STATUS_CREATING
+	bootstrapping := gradv1.RunnerStatus_RUNNER_STATUS_BOOTSTRAPPING
+	running := gradv1.RunnerStatus_RUNNER_STATUS_RUNNING
+	stopping := gradv1.RunnerStatus_RUNNER_STATUS_STOPPING
+	errored := gradv1.RunnerStatus_RUNNER_STATUS_ERROR
+	deleteFailed := gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED
+	deleted := gradtest.StatusDeleted
+
+	tests := []struct {
+		name          string
+		scripts       map[string][]gradv1.RunnerStatus
+		condition     waitCondition
+		all           bool
+		expectedCode  int
+		expectedError string
+		expectedLines []string
+	}{
+		{
+			name:          "Becomes running",
+			scripts:       map[string][]gradv1.RunnerStatus{"runner-1": {creating, creating, bootstrapping, running}},
+			condition:     waitForRunning,
+			expectedLines: []string{"runner-1: Creating", "runner-1: Creating -> Bootstrapping", "runner-1: Bootstrapping -> Running"},
+		},
+		{
+			name:          "Errors while waiting for running",
+			scripts:       map[string][]gradv1.RunnerStatus{"runner-1": {creating, errored}},
+			condition:     waitForRunning,
+			expectedCode:  exitCodeWaitUnreachable,
+			expectedError: "runner runner-1 is error: ImagePullBackOff and won't become running",
+			expectedLines: []string{"runner-1: Creating", "runner-1: Creating -> Error"},
+		},
+		{
+			name:          "Deleted",
+			scripts:       map[string][]gradv1.RunnerStatus{"runner-1": {running, stopping, deleted}},
+			condition:     waitForDeleted,
+			expectedLines: []string{"runner-1: Running", "runner-1: Running -> Stopping", "runner-1: Stopping -> Deleted"},
+		},
+		{
+			name:          "Deletion fails",
+			scripts:       map[string][]gradv1.RunnerStatus{"runner-1": {stopping, deleteFailed}},
+			condition:     waitForDeleted,
+			expectedCode:  exitCodeWaitUnreachable,
+			expectedError: "won't become deleted",
+		},
+		{
+			name:          "Never found while waiting for running",
+			scripts:       map[string][]gradv1.RunnerStatus{"runner-1": {deleted}},
+			condition:     waitForRunning,
+			expectedCode:  exitCodeWaitUnreachable,
+			expectedError: "runner runner-1 is deleted and won't become running",
+		},
+		{
+			name:          "Times out",
+			scripts:       map[string][]gradv1.RunnerStatus{"runner-1": {creating}},
+			condition:     waitForRunning,
+			expectedCode:  exitCodeWaitTimeout,
+			expectedError: "timed out waiting for runner-1 to become running",
+			expectedLines: []string{"runner-1: Creating"},
+		},
+		{
+			name: "Any runner",
+			scripts: map[string][]gradv1.RunnerStatus{
+				"runner-1": {creating},
+				"runner-2": {creating, running},
+			},
+			condition: waitForRunning,
+		},
+		{
+			name: "Any runner while another errors",
+			scripts: map[string][]gradv1.RunnerStatus{
+				"runner-1": {errored},
+				"runner-2": {creating, creating, running},
+			},
+			condition: waitForRunning,
+		},
+		{
+			name: "All runners",
+			scripts: map[string][]gradv1.RunnerStatus{
+				"runner-1": {creating, running},
+				"runner-2": {creating, creating, creating, running},
+			},
+			condition: waitForRunning,
+			all:       true,
+		},
+		{
+			name: "All runners times out on the slowest",
+			scripts: map[string][]gradv1.RunnerStatus{
+				"runner-1": {creating, running},
+				"runner-2": {creating},
+			},
+			condition:     waitForRunning,
+			all:           true,
+			expectedCode:  exitCodeWaitTimeout,
+			expectedError: "timed out waiting for runner-2 to become running",
+		},
+		{
+			name: "All runners when one errors",
+			scripts: map[string][]gradv1.RunnerStatus{
+				"runner-1": {creating, errored},
+				"runner-2": {creating, running},
+			},
+			condition:     waitForRunning,
+			all:           true,
+			expectedCode:  exitCodeWaitUnreachable,
+			expectedError: "runner runner-1 is error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			var runnerIDs []string
+			for _, runnerID := range []string{"runner-1", "runner-2"} {
+				if _, ok := tt.scripts[runnerID]; ok {
+					runnerIDs = append(runnerIDs, runnerID)
+				}
+			}
+			client, _ := newWaitClient(tt.scripts)
+			var lines []string
+			progress := func(format string, args ...interface{}) {
+				lines = append(lines, fmt.Sprintf(format, args...))
+			}
+
+			code, err := waitForRunners(ctx, client, runnerIDs, tt.condition, tt.all, time.Millisecond, progress)
+			if code != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d (%v)", tt.expectedCode, code, err)
+			}
+			if tt.expectedError == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.expectedError != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedError)) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+			if tt.expectedLines != nil && strings.Join(lines, "\n") != strings.Join(tt.expectedLines, "\n") {
+				t.Errorf("Expected progress %q, got %q", tt.expectedLines, lines)
+			}
+		})
+	}
+}
+
+func TestWaitForRunnersServerError(t *testing.T) {
+	client, fake := newWaitClient(map[string][]gradv1.RunnerStatus{"runner-1": {gradv1.RunnerStatus_RUNNER_STATUS_CREATING}})
+	fake.FailWith(gradv1.RunnerService_GetRunner_FullMethodName, status.Error(codes.Unavailable, "connection refused"))
+	code, err := waitForRunners(context.Background(), client, []string{"runner-1"}, waitForRunning, false, time.Millisecond, func(string, ...interface{}) {})
+	if code != ExitCodeTempFail || err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Expected the server error with exit code %d, got %d, %v", ExitCodeTempFail, code, err)
+	}
+}
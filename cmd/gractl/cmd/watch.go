@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+const (
+	ansiClearScreen = "\033[H\033[2J"
+	ansiHighlight   = "\033[1;33m"
+	ansiReset       = "\033[0m"
+)
+
+// changedRunners returns the IDs of runners that are new or whose status differs from the previous snapshot
+func changedRunners(prev, curr []*gradv1.Runner) map[string]bool {
+	prevStatus := make(map[string]gradv1.RunnerStatus, len(prev))
+	for _, runner := range prev {
+		prevStatus[runner.Id] = runner.Status
+	}
+
+	changed := make(map[string]bool)
+	for _, runner := range curr {
+		if status, ok := prevStatus[runner.Id]; !ok || status != runner.Status {
+			changed[runner.Id] = true
+		}
+	}
+	return changed
+}
+
+// removedRunners returns the IDs of runners present in the previous snapshot but not the current one
+func removedRunners(prev, curr []*gradv1.Runner) []string {
+	current := make(map[string]bool, len(curr))
+	for _, runner := range curr {
+		current[runner.Id] = true
+	}
+
+	var removed []string
+	for _, runner := range prev {
+		if !current[runner.Id] {
+			removed = append(removed, runner.Id)
+		}
+	}
+	return removed
+}
+
+// highlightRunnerTable renders the runner table, marking rows of changed runners.
+// With color the row is highlighted, otherwise it is prefixed with "*".
+func highlightRunnerTable(runners []*gradv1.Runner, changed map[string]bool, color bool) (string, error) {
+	var buf bytes.Buffer
+	if err := writeRunnerTable(&buf, runners, outputFormat == OutputFormatWide); err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	var out strings.Builder
+	for i, line := range lines {
+		// The first line is the header, the rest follow the order of runners
+		isChanged := i > 0 && changed[runners[i-1].Id]
+		switch {
+		case color && isChanged:
+			out.WriteString(ansiHighlight + line + ansiReset)
+		case color:
+			out.WriteString(line)
+		case isChanged:
+			out.WriteString("* " + line)
+		default:
+			out.WriteString("  " + line)
+		}
+		out.WriteString("\n")
+	}
+	return out.String(), nil
+}
+
+// watchRunners polls the runner list every interval and redraws it until ctx is cancelled.
+// On a terminal the screen is redrawn in place; otherwise a snapshot is appended whenever the list changes.
+func watchRunners(ctx context.Context, req *gradv1.ListRunnersRequest, interval time.Duration) error {
+	color := colorEnabled(os.Stdout)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev []*gradv1.Runner
+	first := true
+	for {
+		listCtx, cancel := rpcContext(ctx)
+		runners, total, err := grpcClient.ListRunners(listCtx, req)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Failed to list runners: %v\n", err)
+		} else {
+			runners = keepStaleRunners(runners, req.StaleOnly)
+			sortRunners(runners, req.OrderBy, req.Reverse)
+			resp := &gradv1.ListRunnersResponse{Runners: runners, Total: total}
+			changed := map[string]bool{}
+			if !first {
+				changed = changedRunners(prev, resp.Runners)
+			}
+			removed := removedRunners(prev, resp.Runners)
+
+			if err := renderWatchSnapshot(os.Stdout, resp, changed, removed, interval, color, first); err != nil {
+				return err
+			}
+
+			prev = resp.Runners
+			first = false
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderWatchSnapshot prints one refresh of the watched runner list
+func renderWatchSnapshot(w io.Writer, resp *gradv1.ListRunnersResponse, changed map[string]bool, removed []string, interval time.Duration, color, first bool) error {
+	if outputFormat == OutputFormatJSON {
+		data, err := marshalProtoJSONLine(resp)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	// In append-only mode, only print when something changed
+	if !color && !first && len(changed) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	table, err := highlightRunnerTable(resp.Runners, changed, color)
+	if err != nil {
+		return err
+	}
+
+	if color {
+		fmt.Fprint(w, ansiClearScreen)
+		fmt.Fprintf(w, "Every %s: gractl runners list    %s\n\n", interval, time.Now().Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(w, "--- %s ---\n", time.Now().Format(time.RFC3339))
+	}
+	fmt.Fprint(w, table)
+	if len(removed) > 0 {
+		fmt.Fprintf(w, "\nRemoved: %s\n", strings.Join(removed, ", "))
+	}
+	return nil
+}
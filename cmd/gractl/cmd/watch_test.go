@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+func snapshotRunner(id string, status gradv1.RunnerStatus) *gradv1.Runner {
+	return &gradv1.Runner{Id: id, Name: id, Status: status}
+}
+
+func TestChangedRunnersAcrossSnapshots(t *testing.T) {
+	snapshots := [][]*gradv1.Runner{
+		{
+			snapshotRunner("runner-1", gradv1.RunnerStatus_RUNNER_STATUS_CREATING),
+		},
+		{
+			snapshotRunner("runner-1", gradv1.RunnerStatus_RUNNER_STATUS_CREATING),
+			snapshotRunner("runner-2", gradv1.RunnerStatus_RUNNER_STATUS_CREATING),
+		},
+		{
+			snapshotRunner("runner-1", gradv1.RunnerStatus_RUNNER_STATUS_RUNNING),
+			snapshotRunner("runner-2", gradv1.RunnerStatus_RUNNER_STATUS_CREATING),
+		},
+		{
+			snapshotRunner("runner-2", gradv1.RunnerStatus_RUNNER_STATUS_CREATING),
+		},
+	}
+
+	expectedChanged := []map[string]bool{
+		{"runner-2": true},
+		{"runner-1": true},
+		{},
+	}
+	expectedRemoved := [][]string{nil, nil, {"runner-1"}}
+
+	for i := 1; i < len(snapshots); i++ {
+		changed := changedRunners(snapshots[i-1], snapshots[i])
+		if !reflect.DeepEqual(changed, expectedChanged[i-1]) {
+			t.Errorf("Snapshot %d: expected changed %v, got %v", i, expectedChanged[i-1], changed)
+		}
+
+		removed := removedRunners(snapshots[i-1], snapshots[i])
+		if !reflect.DeepEqual(removed, expectedRemoved[i-1]) {
+			t.Errorf("Snapshot %d: expected removed %v, got %v", i, expectedRemoved[i-1], removed)
+		}
+	}
+}
+
+func TestHighlightRunnerTable(t *testing.T) {
+	runners := []*gradv1.Runner{
+		snapshotRunner("runner-1", gradv1.RunnerStatus_RUNNER_STATUS_RUNNING),
+		snapshotRunner("runner-2", gradv1.RunnerStatus_RUNNER_STATUS_CREATING),
+	}
+	changed := map[string]bool{"runner-2": true}
+
+	t.Run("Color", func(t *testing.T) {
+		table, err := highlightRunnerTable(runners, changed, true)
+		if err != nil {
+			t.Fatalf("Failed to render table: %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(table, "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected header and 2 rows, got %d lines", len(lines))
+		}
+		if strings.Contains(lines[1], ansiHighlight) {
+			t.Errorf("Expected unchanged row not to be highlighted: %q", lines[1])
+		}
+		if !strings.HasPrefix(lines[2], ansiHighlight) || !strings.Contains(lines[2], "runner-2") {
+			t.Errorf("Expected changed row to be highlighted: %q", lines[2])
+		}
+	})
+
+	t.Run("Plain", func(t *testing.T) {
+		table, err := highlightRunnerTable(runners, changed, false)
+		if err != nil {
+			t.Fatalf("Failed to render table: %v", err)
+		}
+
+		if strings.Contains(table, "\033[") {
+			t.Error("Expected no escape codes without color")
+		}
+
+		lines := strings.Split(strings.TrimSuffix(table, "\n"), "\n")
+		if !strings.HasPrefix(lines[0], "  ID") {
+			t.Errorf("Expected header to be indented, got %q", lines[0])
+		}
+		if !strings.HasPrefix(lines[1], "  runner-1") {
+			t.Errorf("Expected unchanged row to be unmarked, got %q", lines[1])
+		}
+		if !strings.HasPrefix(lines[2], "* runner-2") {
+			t.Errorf("Expected changed row to be marked, got %q", lines[2])
+		}
+	})
+}
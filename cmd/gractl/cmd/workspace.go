@@ -2,15 +2,15 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"os/exec"
-	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -52,9 +52,13 @@ Requirements:
 - The runner(s) must have been created with SSH public key support
 - The runner(s) must be in 'running' status
 
+A runner another sync has mounted is refused unless --force is given; see
+gractl workspace sync status for what is mounted.
+
 Examples:
   gractl workspace sync runner-1    # Sync specific runner
   gractl workspace sync             # Sync all running runners
+  gractl workspace sync status      # List the mounts of running syncs
 
 The mounted workspace(s) will be available at:
   ./runners/runner-1/workspace/
@@ -65,18 +69,16 @@ Press Ctrl+C to unmount and clean up.`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		// Initialize gRPC client
-		serverAddress, _ := cmd.Flags().GetString("server")
-		cfg := &client.Config{
-			ServerAddress: serverAddress,
-		}
-		
-		grpcClient, err := client.NewClient(cfg)
+		grpcClient, err := newGRPCClient(cmd, globalConfig)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
 			os.Exit(1)
 		}
 		defer grpcClient.Close()
 
+		// Ctrl+C stops the sync, unmounting and cleaning up
+		ctx := commandContext(cmd)
+
 		// Check dependencies first
 		if err := checkWorkspaceDependencies(); err != nil {
 			fmt.Fprintf(os.Stderr, "Dependency check failed: %v\n", err)
@@ -87,10 +89,10 @@ Press Ctrl+C to unmount and clean up.`,
 		var runnersToSync []string
 		if len(args) == 1 {
 			// Single runner specified
-			runnersToSync = []string{args[0]}
+			runnersToSync = []string{resolveRunnerArg(cmd, grpcClient, args[0])}
 		} else {
 			// Get all running runners
-			runningRunners, err := getWorkspaceRunningRunners(grpcClient)
+			runningRunners, err := getWorkspaceRunningRunners(ctx, grpcClient)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to get running runners: %v\n", err)
 				os.Exit(1)
@@ -99,15 +101,15 @@ Press Ctrl+C to unmount and clean up.`,
 		}
 
 		if len(runnersToSync) == 0 {
-			fmt.Println("No running runners found to sync.")
+			logger.Infof("No running runners found to sync.")
 			os.Exit(0)
 		}
 
-		fmt.Printf("Syncing %d runner(s): %s\n", len(runnersToSync), strings.Join(runnersToSync, ", "))
+		logger.Infof("Syncing %d runner(s): %s", len(runnersToSync), strings.Join(runnersToSync, ", "))
 
 		// Verify all runners exist and are running
 		for _, runnerID := range runnersToSync {
-			runner, err := getWorkspaceRunnerStatus(grpcClient, runnerID)
+			runner, err := getWorkspaceRunnerStatus(ctx, grpcClient, runnerID)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to get runner status for %s: %v\n", runnerID, err)
 				os.Exit(1)
@@ -120,6 +122,32 @@ Press Ctrl+C to unmount and clean up.`,
 			}
 		}
 
+		// Record the mounts for workspace sync status, refusing to mount a runner twice
+		force, _ := cmd.Flags().GetBool("force")
+		pid := os.Getpid()
+		state, err := defaultMountState()
+		if err == nil {
+			err = state.Claim(runnersToSync, pid, force, time.Now())
+			var conflict *alreadyMountedError
+			if errors.As(err, &conflict) {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err != nil {
+			logger.Warnf("Not tracking mounts for workspace sync status: %v", err)
+			state = nil
+		}
+		release := func(runnerIDs ...string) {
+			if state == nil {
+				return
+			}
+			if err := state.Release(pid, runnerIDs...); err != nil {
+				logger.Warnf("Failed to update mount state: %v", err)
+			}
+		}
+		defer release()
+
 		// Setup workspace syncs for all runners
 		type runnerSync struct {
 			runnerID       string
@@ -132,25 +160,47 @@ Press Ctrl+C to unmount and clean up.`,
 		var activeSyncs []runnerSync
 		var syncMutex sync.Mutex
 
+		portRange, _ := cmd.Flags().GetString("port-range")
+		ports, err := newPortAllocator(portRange)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+
+		// Reserve every port up front so concurrent port-forwards can't collide
+		for _, runnerID := range runnersToSync {
+			if _, err := ports.Allocate(runnerID); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		}
+		if logger.Enabled(VerbosityNormal) {
+			ports.WriteTable(logger.Writer())
+		}
+
 		// Start workspace sync for each runner
 		for _, runnerID := range runnersToSync {
 			// Create local workspace directory
-			workspaceDir := client.GetRunnerWorkspaceDir(runnerID)
-			if err := client.CreateLocalDirectory(workspaceDir); err != nil {
+			workspaceDir := runnerWorkspaceDir(runnerID)
+			if err := createLocalDirectory(workspaceDir); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to create local workspace directory for %s: %v\n", runnerID, err)
+				ports.Release(runnerID)
+				release(runnerID)
 				continue
 			}
 
-			fmt.Printf("Created local workspace directory: %s\n", workspaceDir)
+			logger.Debugf("Created local workspace directory: %s", workspaceDir)
 
 			// Start kubectl port-forward
-			localPort, portForwardCmd, err := startWorkspacePortForward(runnerID)
+			localPort, portForwardCmd, err := startWorkspacePortForward(runnerID, ports)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to start port forwarding for %s: %v\n", runnerID, err)
+				ports.Release(runnerID)
+				release(runnerID)
 				continue
 			}
 
-			fmt.Printf("Port forwarding started: localhost:%d -> %s:22\n", localPort, runnerID)
+			logger.Infof("Port forwarding started: localhost:%d -> %s:22", localPort, runnerID)
 
 			// Wait a moment for port forwarding to establish
 			time.Sleep(2 * time.Second)
@@ -162,10 +212,42 @@ Press Ctrl+C to unmount and clean up.`,
 				if portForwardCmd != nil && portForwardCmd.Process != nil {
 					portForwardCmd.Process.Kill()
 				}
+				ports.Release(runnerID)
+				release(runnerID)
+				continue
+			}
+
+			// Make sure the port reaches this runner and not some other forward
+			if err := verifyRunnerHost(runnerID, localPort, sshHostnameProbe); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to verify workspace mount for %s: %v\n", runnerID, err)
+				unmountWorkspaceDir(workspaceDir)
+				if sshfsCmd.Process != nil {
+					sshfsCmd.Process.Kill()
+				}
+				if portForwardCmd.Process != nil {
+					portForwardCmd.Process.Kill()
+				}
+				ports.Release(runnerID)
+				release(runnerID)
 				continue
 			}
 
-			fmt.Printf("Workspace mounted: %s:/workspace -> %s\n", runnerID, workspaceDir)
+			logger.Infof("Workspace mounted: %s:/workspace -> %s", runnerID, workspaceDir)
+
+			if state != nil {
+				mountpoint, _ := filepath.Abs(workspaceDir)
+				err := state.Record(mountRecord{
+					RunnerID:       runnerID,
+					Mountpoint:     mountpoint,
+					LocalPort:      localPort,
+					PID:            pid,
+					PortForwardPID: portForwardCmd.Process.Pid,
+					SSHFSPID:       sshfsCmd.Process.Pid,
+				})
+				if err != nil {
+					logger.Warnf("Failed to update mount state: %v", err)
+				}
+			}
 
 			// Add to active syncs
 			syncMutex.Lock()
@@ -180,20 +262,21 @@ Press Ctrl+C to unmount and clean up.`,
 		}
 
 		if len(activeSyncs) == 0 {
-			fmt.Println("No workspace syncs were successfully established.")
+			fmt.Fprintln(os.Stderr, "No workspace syncs were successfully established.")
+			release()
 			os.Exit(1)
 		}
 
-		fmt.Printf("\nSuccessfully synced %d workspace(s). Press Ctrl+C to unmount and exit...\n", len(activeSyncs))
+		logger.Infof("Successfully synced %d workspace(s). Press Ctrl+C to unmount and exit...", len(activeSyncs))
 
 		// Setup cleanup function
 		cleanupAll := func() {
-			fmt.Println("\nCleaning up all workspace syncs...")
+			logger.Infof("Cleaning up all workspace syncs...")
 			syncMutex.Lock()
 			defer syncMutex.Unlock()
 			
 			for _, sync := range activeSyncs {
-				fmt.Printf("Cleaning up %s...\n", sync.runnerID)
+				logger.Debugf("Cleaning up %s...", sync.runnerID)
 				
 				// Unmount workspace
 				unmountWorkspaceDir(sync.workspaceDir)
@@ -212,9 +295,40 @@ Press Ctrl+C to unmount and clean up.`,
 		defer cleanupAll()
 
 		// Wait for interrupt signal
-		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-		<-sigChan
+		<-ctx.Done()
+	},
+}
+
+// workspaceSyncStatusCmd represents the workspace sync status command
+var workspaceSyncStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List the workspaces mounted by running syncs",
+	Long: `List the workspaces mounted by every running workspace sync of the current user,
+from any directory. Syncs record their mounts in a state file under the user cache
+directory (~/.cache/gractl/mounts.json on Linux); entries of syncs that exited without
+cleaning up are pruned.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		state, err := defaultMountState()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		mounts, err := state.Live()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read mount state: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputFormat == OutputFormatJSON {
+			err = printJSON(mountStateFile{Mounts: append([]mountRecord{}, mounts...)})
+		} else {
+			err = writeMountTable(os.Stdout, mounts, time.Now())
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print mounts: %v\n", err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -242,11 +356,11 @@ This will create all template files in the current working directory.`,
 			fmt.Fprintf(os.Stderr, "Failed to initialize workspace: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println("Workspace initialized successfully!")
-		fmt.Println("\nNext steps:")
-		fmt.Println("1. Review CLAUDE.md for setup instructions")
-		fmt.Println("2. Create .gractl.toml configuration file")
-		fmt.Println("3. Start using /gra-dataset-introduction and /gra-query commands")
+		logger.Infof("Workspace initialized successfully!")
+		logger.Infof("\nNext steps:")
+		logger.Infof("1. Review CLAUDE.md for setup instructions")
+		logger.Infof("2. Create .gractl.toml configuration file")
+		logger.Infof("3. Start using /gra-dataset-introduction and /gra-query commands")
 	},
 }
 
@@ -271,7 +385,7 @@ func initWorkspace() error {
 		
 		// Check if file already exists
 		if _, err := os.Stat(path); err == nil {
-			fmt.Printf("File %s already exists, skipping...\n", path)
+			logger.Infof("File %s already exists, skipping...", path)
 			return nil
 		}
 		
@@ -286,18 +400,18 @@ func initWorkspace() error {
 			return fmt.Errorf("failed to create file %s: %w", path, err)
 		}
 		
-		fmt.Printf("Created %s\n", path)
+		logger.Debugf("Created %s", path)
 		return nil
 	})
 }
 
 // checkWorkspaceDependencies verifies that required external commands are available
 func checkWorkspaceDependencies() error {
-	if err := client.CheckCommandAvailable("kubectl"); err != nil {
+	if err := checkCommandAvailable("kubectl"); err != nil {
 		return fmt.Errorf("kubectl not found: %w", err)
 	}
 
-	if err := client.CheckCommandAvailable("sshfs"); err != nil {
+	if err := checkCommandAvailable("sshfs"); err != nil {
 		return fmt.Errorf("sshfs not found: %w. Please install sshfs", err)
 	}
 
@@ -305,19 +419,21 @@ func checkWorkspaceDependencies() error {
 }
 
 // getWorkspaceRunningRunners retrieves all runners with RUNNING status
-func getWorkspaceRunningRunners(grpcClient *client.Client) ([]string, error) {
+func getWorkspaceRunningRunners(ctx context.Context, grpcClient client.RunnerClient) ([]string, error) {
 	req := &gradv1.ListRunnersRequest{
 		Status: gradv1.RunnerStatus_RUNNER_STATUS_RUNNING,
 		Limit:  100, // reasonable limit for workspace sync
 	}
 
-	resp, err := grpcClient.RunnerService().ListRunners(context.Background(), req)
+	ctx, cancel := rpcContext(ctx)
+	defer cancel()
+	runners, _, err := grpcClient.ListRunners(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var runnerIDs []string
-	for _, runner := range resp.Runners {
+	for _, runner := range runners {
 		runnerIDs = append(runnerIDs, runner.Id)
 	}
 
@@ -325,34 +441,28 @@ func getWorkspaceRunningRunners(grpcClient *client.Client) ([]string, error) {
 }
 
 // getWorkspaceRunnerStatus retrieves the current status of a runner
-func getWorkspaceRunnerStatus(grpcClient *client.Client, runnerID string) (*gradv1.Runner, error) {
-	req := &gradv1.GetRunnerRequest{
-		RunnerId: runnerID,
-	}
+func getWorkspaceRunnerStatus(ctx context.Context, grpcClient client.RunnerClient, runnerID string) (*gradv1.Runner, error) {
+	ctx, cancel := rpcContext(ctx)
+	defer cancel()
+	return grpcClient.GetRunner(ctx, runnerID)
+}
 
-	resp, err := grpcClient.RunnerService().GetRunner(context.Background(), req)
+// startWorkspacePortForward starts kubectl port-forward on the runner's allocated port and returns the port and process
+func startWorkspacePortForward(runnerID string, ports *portAllocator) (int, *exec.Cmd, error) {
+	localPort, err := ports.Allocate(runnerID)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
 
-	return resp.Runner, nil
-}
-
-// startWorkspacePortForward starts kubectl port-forward and returns the local port and process
-func startWorkspacePortForward(runnerID string) (int, *exec.Cmd, error) {
-	// Use a high port number to avoid conflicts
-	localPort := 2222 + (int(time.Now().Unix()) % 1000)
-
-	// Pod name format matches what's used in kubernetes.go: grad-runner-{runnerID}
-	podName := fmt.Sprintf("grad-runner-%s", runnerID)
+	podName := runnerPodName(runnerID)
 	portMapping := fmt.Sprintf("%d:22", localPort)
 
 	cmd := exec.Command("kubectl", "port-forward", "pod/"+podName, portMapping)
 	
-	// Debug: Print the kubectl command for debugging
-	fmt.Printf("DEBUG: Executing kubectl command: %s\n", strings.Join(cmd.Args, " "))
-	
-	// Start the process
+	logger.Debugf("Executing: %s", strings.Join(cmd.Args, " "))
+
+	// Start the process, releasing the reservation right before kubectl binds the port
+	ports.Handoff(runnerID)
 	if err := cmd.Start(); err != nil {
 		return 0, nil, fmt.Errorf("failed to start kubectl port-forward: %w", err)
 	}
@@ -376,9 +486,7 @@ func startWorkspaceSSHFSMount(localPort int, mountPoint string) (*exec.Cmd, erro
 		"-o", "IdentitiesOnly=yes",           // only use specified identity
 	)
 
-	// Debug: Print the full sshfs command for debugging
-	fmt.Printf("DEBUG: Executing sshfs command: %s %s\n", cmd.Path, strings.Join(cmd.Args[1:], " "))
-	fmt.Printf("DEBUG: Full command: %s\n", strings.Join(cmd.Args, " "))
+	logger.Debugf("Executing: %s", strings.Join(cmd.Args, " "))
 
 	// Run sshfs in the background
 	if err := cmd.Start(); err != nil {
@@ -399,7 +507,7 @@ func startWorkspaceSSHFSMount(localPort int, mountPoint string) (*exec.Cmd, erro
 
 // unmountWorkspaceDir safely unmounts the sshfs filesystem
 func unmountWorkspaceDir(mountPoint string) {
-	fmt.Printf("Unmounting workspace: %s\n", mountPoint)
+	logger.Debugf("Unmounting workspace: %s", mountPoint)
 
 	// Use fusermount to unmount (standard way to unmount FUSE filesystems)
 	cmd := exec.Command("fusermount", "-u", mountPoint)
@@ -407,16 +515,51 @@ func unmountWorkspaceDir(mountPoint string) {
 		// If fusermount fails, try umount as fallback
 		cmd = exec.Command("umount", mountPoint)
 		if err := cmd.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to unmount %s: %v\n", mountPoint, err)
+			logger.Warnf("Failed to unmount %s: %v", mountPoint, err)
 		}
 	}
 }
 
 func init() {
 	// Add global flags to the workspace sync command
-	workspaceSyncCmd.Flags().String("server", "localhost:9090", "gRPC server address")
+	workspaceSyncCmd.Flags().String("port-range", "", "Local port range for port forwarding, e.g. 22000-22999 (default: ports assigned by the OS)")
+	workspaceSyncCmd.Flags().Bool("force", false, "Mount runners even if another sync has them mounted")
+	workspaceSyncCmd.AddCommand(workspaceSyncStatusCmd)
 
 	// Add subcommands to workspace command
 	WorkspaceCmd.AddCommand(workspaceSyncCmd)
 	WorkspaceCmd.AddCommand(workspaceInitCmd)
+}
+
+// createLocalDirectory creates a directory if it doesn't exist
+func createLocalDirectory(path string) error {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", path, err)
+	}
+	return nil
+}
+
+// runnerWorkspaceDir returns the local workspace directory path for a runner
+func runnerWorkspaceDir(runnerID string) string {
+	return filepath.Join("runners", runnerID, "workspace")
+}
+
+// checkCommandAvailable checks if a command is available in PATH
+func checkCommandAvailable(command string) error {
+	_, err := os.Stat("/usr/bin/" + command)
+	if err == nil {
+		return nil
+	}
+	
+	_, err = os.Stat("/usr/local/bin/" + command)
+	if err == nil {
+		return nil
+	}
+
+	// Check if command exists in PATH
+	if _, err := exec.LookPath(command); err != nil {
+		return fmt.Errorf("command '%s' not found in PATH", command)
+	}
+	
+	return nil
 }
\ No newline at end of file
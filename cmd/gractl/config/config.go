@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -15,6 +17,19 @@ type Config struct {
 	
 	// Server configuration
 	Server ServerConfig `mapstructure:"server"`
+
+	// SSH configuration for new runners
+	SSH SSHConfig `mapstructure:"ssh"`
+
+	// Output preferences, overridden by the global flags
+	Output OutputConfig `mapstructure:"output"`
+
+	// EnvFile is a file of KEY=VALUE lines, relative to the config file, loaded into the
+	// environment before ${NAME} references in the config file are expanded
+	EnvFile string `mapstructure:"env_file"`
+
+	// File is the config file that was read, empty if there is none
+	File string `mapstructure:"-"`
 }
 
 // S3Config holds S3 workspace configuration
@@ -29,9 +44,36 @@ type S3Config struct {
 	ReadOnly        bool   `mapstructure:"read_only"`
 }
 
+// SSHConfig holds the SSH key injected into new runners
+type SSHConfig struct {
+	// PublicKey is the public key file to inject, e.g. ~/.ssh/work.pub; empty looks in ~/.ssh
+	// and the ssh-agent
+	PublicKey string `mapstructure:"public_key"`
+}
+
+// OutputConfig holds the defaults of how gractl prints results
+type OutputConfig struct {
+	// Format is the default of --output: table, wide or json
+	Format string `mapstructure:"format"`
+	// Color is auto, always or never; auto colors terminals unless NO_COLOR is set
+	Color string `mapstructure:"color"`
+	// WideColumns adds the wide format's extra columns to tables unless --output says otherwise
+	WideColumns bool `mapstructure:"wide_columns"`
+
+	// FormatSource and ColorSource describe where Format and Color came from, for errors
+	FormatSource string `mapstructure:"-"`
+	ColorSource  string `mapstructure:"-"`
+}
+
 // ServerConfig holds server connection configuration
 type ServerConfig struct {
-	Address string `mapstructure:"address"`
+	Address     string        `mapstructure:"address"`
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+	// RequestTimeout bounds each RPC that returns a single response; streams have no deadline
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+
+	// AddressSource describes where Address came from, for diagnostics
+	AddressSource string `mapstructure:"-"`
 }
 
 // LoadConfig loads configuration from .gractl.toml file and environment variables
@@ -56,7 +98,11 @@ func LoadConfig() (*Config, error) {
 
 	// Set environment variable prefix
 	v.SetEnvPrefix("GRACTL")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
+	if err := bindEnv(v); err != nil {
+		return nil, err
+	}
 
 	// Set default values
 	setDefaults(v)
@@ -69,23 +115,65 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	// Variables of the env file may override settings or be used by ${NAME} in the config file
+	if envFile := v.GetString("env_file"); envFile != "" {
+		envFile, err := expandEnv(envFile, os.LookupEnv)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env_file: %w", err)
+		}
+		if err := loadEnvFile(envFile, v.ConfigFileUsed()); err != nil {
+			return nil, err
+		}
+	}
+	if err := expandConfigValues(v); err != nil {
+		return nil, err
+	}
+
 	// Unmarshal into config struct
 	var config Config
 	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	config.File = v.ConfigFileUsed()
+	config.Server.AddressSource = valueSource(v, "server.address")
+	config.Output.FormatSource = valueSource(v, "output.format")
+	config.Output.ColorSource = valueSource(v, "output.color")
+
 	return &config, nil
 }
 
+// valueSource describes where viper took the value of key from. Environment variables take
+// precedence over the config file in viper.
+func valueSource(v *viper.Viper, key string) string {
+	env := envName(key)
+	if _, ok := os.LookupEnv(env); ok {
+		return env + " environment variable"
+	}
+	if v.InConfig(key) {
+		return fmt.Sprintf("config file %s", v.ConfigFileUsed())
+	}
+	return "default"
+}
+
 // setDefaults sets default configuration values
 func setDefaults(v *viper.Viper) {
 	// Server defaults
 	v.SetDefault("server.address", "localhost:9090")
+	v.SetDefault("server.dial_timeout", "5s")
+	v.SetDefault("server.request_timeout", "30s")
 	
 	// S3 defaults
 	v.SetDefault("s3.region", "us-east-1")
 	v.SetDefault("s3.read_only", false)
+
+	// SSH defaults, so GRACTL_SSH_PUBLIC_KEY is picked up
+	v.SetDefault("ssh.public_key", "")
+
+	// Output defaults; the --output flag takes precedence
+	v.SetDefault("output.format", "table")
+	v.SetDefault("output.color", "auto")
+	v.SetDefault("output.wide_columns", false)
 }
 
 // getHomeDir returns the user's home directory
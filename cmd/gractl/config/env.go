@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// envPrefix starts the environment variable overriding each config key
+const envPrefix = "GRACTL_"
+
+// envName returns the environment variable overriding key, e.g. GRACTL_S3_SECRET_ACCESS_KEY
+// for s3.secret_access_key (pure function)
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// configKeys returns the viper key of every setting of t, a struct type with mapstructure
+// tags, e.g. s3.access_key_id (pure function)
+func configKeys(t reflect.Type) []string {
+	var keys []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type.PkgPath() == t.PkgPath() {
+			for _, key := range configKeys(field.Type) {
+				keys = append(keys, name+"."+key)
+			}
+			continue
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
+
+// bindEnv binds every config key to its environment variable. AutomaticEnv alone only
+// applies to keys viper already knows about, so a nested key missing from the config file
+// and the defaults would ignore its variable.
+func bindEnv(v *viper.Viper) error {
+	for _, key := range configKeys(reflect.TypeOf(Config{})) {
+		if err := v.BindEnv(key, envName(key)); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", envName(key), err)
+		}
+	}
+	return nil
+}
+
+// parseEnvFile parses KEY=VALUE lines. Blank lines, # comments and an export prefix are
+// ignored, and values may be quoted with ' or ". (pure function)
+func parseEnvFile(content string) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !validEnvName(name) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE, got %q", lineNumber, line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[name] = value
+	}
+	return env, scanner.Err()
+}
+
+// validEnvName reports whether name is a shell variable name (pure function)
+func validEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// loadEnvFile sets the variables of the env file at path, relative to the directory of the
+// config file, that aren't already set, so the real environment wins over the file
+func loadEnvFile(path, configFile string) error {
+	if !filepath.IsAbs(path) && configFile != "" {
+		path = filepath.Join(filepath.Dir(configFile), path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read env_file: %w", err)
+	}
+	env, err := parseEnvFile(string(content))
+	if err != nil {
+		return fmt.Errorf("invalid env_file %s: %w", path, err)
+	}
+	for name, value := range env {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to set %s from env_file: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// expandEnv replaces ${NAME} in value with the variable NAME, and ${NAME:-default} with
+// default when NAME is unset or empty. Other uses of $ are kept as they are. It fails naming
+// the variables that are unset and have no default. (pure function)
+func expandEnv(value string, lookup func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+	var missing []string
+	rest := value
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", value)
+		}
+		b.WriteString(rest[:start])
+		name, fallback, hasFallback := strings.Cut(rest[start+2:start+end], ":-")
+		if !validEnvName(name) {
+			return "", fmt.Errorf("invalid variable name %q", name)
+		}
+		switch variable, ok := lookup(name); {
+		case ok && (variable != "" || !hasFallback):
+			b.WriteString(variable)
+		case hasFallback:
+			b.WriteString(fallback)
+		default:
+			missing = append(missing, name)
+		}
+		rest = rest[start+end+1:]
+	}
+	switch len(missing) {
+	case 0:
+		return b.String(), nil
+	case 1:
+		return "", fmt.Errorf("environment variable %s is not set", missing[0])
+	default:
+		return "", fmt.Errorf("environment variables %s are not set", strings.Join(missing, ", "))
+	}
+}
+
+// expandConfigValues expands ${NAME} in the config file's string values, except those an
+// environment variable overrides
+func expandConfigValues(v *viper.Viper) error {
+	var problems []string
+	for _, key := range configKeys(reflect.TypeOf(Config{})) {
+		if !v.InConfig(key) {
+			continue
+		}
+		if _, ok := os.LookupEnv(envName(key)); ok {
+			continue
+		}
+		value, ok := v.Get(key).(string)
+		if !ok || !strings.Contains(value, "${") {
+			continue
+		}
+		expanded, err := expandEnv(value, os.LookupEnv)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		v.Set(key, expanded)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config file %s: %s", v.ConfigFileUsed(), strings.Join(problems, "; "))
+	}
+	return nil
+}
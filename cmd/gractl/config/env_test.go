@@ -0,0 +1,185 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"SECRET": "s3cr3t", "EMPTY": "", "BUCKET": "data"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		name          string
+		value         string
+		expected      string
+		expectMessage string
+	}{
+		{"No references", "plain", "plain", ""},
+		{"Whole value", "${SECRET}", "s3cr3t", ""},
+		{"Inside text", "s3://${BUCKET}/prefix", "s3://data/prefix", ""},
+		{"Bare dollar kept", "pa$$word $SECRET", "pa$$word $SECRET", ""},
+		{"Set but empty", "${EMPTY}", "", ""},
+		{"Default when unset", "${REGION:-us-east-1}", "us-east-1", ""},
+		{"Default when empty", "${EMPTY:-fallback}", "fallback", ""},
+		{"Default unused", "${BUCKET:-other}", "data", ""},
+		{"Unset", "${TOKEN}/${OTHER}", "", "variables TOKEN, OTHER are not set"},
+		{"Unterminated", "${SECRET", "", "unterminated"},
+		{"Invalid name", "${1ST}", "", "invalid variable name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEnv(tt.value, lookup)
+			if tt.expectMessage != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectMessage) {
+					t.Errorf("Expected an error mentioning %q, got %v", tt.expectMessage, err)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("Expected %q, got %q (%v)", tt.expected, got, err)
+			}
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	env, err := parseEnvFile("# credentials\n\nexport AWS_KEY=AKIA\nSECRET = \"with spaces\"\nTOKEN='a=b'\n")
+	if err != nil {
+		t.Fatalf("parseEnvFile failed: %v", err)
+	}
+	expected := map[string]string{"AWS_KEY": "AKIA", "SECRET": "with spaces", "TOKEN": "a=b"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected %v, got %v", expected, env)
+	}
+
+	if _, err := parseEnvFile("OK=1\nnot a variable\n"); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error naming line 2, got %v", err)
+	}
+}
+
+func TestConfigKeys(t *testing.T) {
+	keys := configKeys(reflect.TypeOf(Config{}))
+	for _, key := range []string{"s3.secret_access_key", "server.dial_timeout", "output.wide_columns", "env_file"} {
+		if !strings.Contains(strings.Join(keys, " "), key) {
+			t.Errorf("Expected %s among %v", key, keys)
+		}
+	}
+	for _, key := range keys {
+		if strings.HasSuffix(key, "source") || key == "file" {
+			t.Errorf("Expected fields without a mapstructure key to be skipped, got %s", key)
+		}
+	}
+}
+
+// writeConfig writes a .gractl.toml into a new working directory, with HOME pointing
+// elsewhere so the user's own config isn't read, and returns the directory
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".gractl.toml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Chdir(dir)
+	t.Setenv("HOME", t.TempDir())
+	return dir
+}
+
+// unsetEnv unsets name for the test, restoring it afterwards
+func unsetEnv(t *testing.T, name string) {
+	t.Helper()
+	t.Setenv(name, "")
+	os.Unsetenv(name)
+}
+
+func TestLoadConfigExpandsEnv(t *testing.T) {
+	writeConfig(t, "[s3]\nbucket = \"data\"\naccess_key_id = \"${GRACTL_TEST_KEY_ID}\"\nsecret_access_key = \"${GRACTL_TEST_SECRET}\"\nregion = \"${GRACTL_TEST_REGION:-eu-west-1}\"\n")
+	t.Setenv("GRACTL_TEST_KEY_ID", "AKIA")
+	t.Setenv("GRACTL_TEST_SECRET", "from-env")
+	unsetEnv(t, "GRACTL_TEST_REGION")
+	unsetEnv(t, "GRACTL_S3_SECRET_ACCESS_KEY")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.S3.AccessKeyID != "AKIA" || cfg.S3.SecretAccessKey != "from-env" || cfg.S3.Region != "eu-west-1" {
+		t.Errorf("Expected expanded S3 settings, got %+v", cfg.S3)
+	}
+
+	// The GRACTL_ variable of a key wins over the config file, without being expanded
+	t.Setenv("GRACTL_S3_SECRET_ACCESS_KEY", "override-${NOT_EXPANDED}")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.S3.SecretAccessKey != "override-${NOT_EXPANDED}" {
+		t.Errorf("Expected GRACTL_S3_SECRET_ACCESS_KEY to win, got %q", cfg.S3.SecretAccessKey)
+	}
+}
+
+func TestLoadConfigMissingEnv(t *testing.T) {
+	writeConfig(t, "[s3]\nsecret_access_key = \"${GRACTL_TEST_MISSING}\"\n")
+	unsetEnv(t, "GRACTL_TEST_MISSING")
+	unsetEnv(t, "GRACTL_S3_SECRET_ACCESS_KEY")
+
+	_, err := LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), "s3.secret_access_key") || !strings.Contains(err.Error(), "GRACTL_TEST_MISSING is not set") {
+		t.Errorf("Expected an error naming the key and the variable, got %v", err)
+	}
+}
+
+func TestLoadConfigNestedEnvOverride(t *testing.T) {
+	// No [s3] table, so only the explicit binding makes viper see the variable
+	writeConfig(t, "[server]\naddress = \"grad:9090\"\n")
+	t.Setenv("GRACTL_S3_SECRET_ACCESS_KEY", "from-env")
+	t.Setenv("GRACTL_S3_SESSION_TOKEN", "token")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.S3.SecretAccessKey != "from-env" || cfg.S3.SessionToken != "token" {
+		t.Errorf("Expected nested keys from the environment, got %+v", cfg.S3)
+	}
+}
+
+func TestLoadConfigEnvFile(t *testing.T) {
+	dir := writeConfig(t, "env_file = \"secrets/.env\"\n\n[s3]\naccess_key_id = \"${GRACTL_TEST_KEY_ID}\"\nsecret_access_key = \"${GRACTL_TEST_SECRET}\"\n")
+	if err := os.Mkdir(filepath.Join(dir, "secrets"), 0o700); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	envFile := "GRACTL_TEST_KEY_ID=from-file\nGRACTL_TEST_SECRET=from-file\nGRACTL_OUTPUT_FORMAT=json\n"
+	if err := os.WriteFile(filepath.Join(dir, "secrets", ".env"), []byte(envFile), 0o600); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	unsetEnv(t, "GRACTL_TEST_KEY_ID")
+	unsetEnv(t, "GRACTL_OUTPUT_FORMAT")
+	unsetEnv(t, "GRACTL_S3_ACCESS_KEY_ID")
+	unsetEnv(t, "GRACTL_S3_SECRET_ACCESS_KEY")
+	// The real environment wins over the env file
+	t.Setenv("GRACTL_TEST_SECRET", "from-env")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.S3.AccessKeyID != "from-file" || cfg.S3.SecretAccessKey != "from-env" {
+		t.Errorf("Expected from-file and from-env, got %q and %q", cfg.S3.AccessKeyID, cfg.S3.SecretAccessKey)
+	}
+	if cfg.Output.Format != "json" {
+		t.Errorf("Expected GRACTL_OUTPUT_FORMAT from the env file, got %q", cfg.Output.Format)
+	}
+
+	writeConfig(t, "env_file = \"missing.env\"\n")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "env_file") {
+		t.Errorf("Expected a missing env file to be an error, got %v", err)
+	}
+}
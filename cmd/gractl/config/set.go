@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// SetValue sets key, e.g. output.format, to value in the config file at path, creating the
+// file if there is none. value is written as is, so strings need their TOML quotes. Every
+// other line, comments included, is kept.
+func SetValue(path, key, value string) error {
+	section, name, ok := strings.Cut(key, ".")
+	if !ok || section == "" || name == "" || strings.Contains(name, ".") {
+		return fmt.Errorf("invalid key %q (expected section.name)", key)
+	}
+
+	mode := fs.FileMode(0o600)
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read config file: %w", err)
+	default:
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+	}
+
+	updated := setTOMLValue(string(content), section, name, value)
+
+	// Don't leave a file behind that gractl can't read
+	v := viper.New()
+	v.SetConfigType("toml")
+	if err := v.ReadConfig(strings.NewReader(updated)); err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, []byte(updated), mode); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// setTOMLValue returns content with name in the [section] table set to value, replacing the
+// line that sets it or adding one at the end of the table, which is added if missing (pure function)
+func setTOMLValue(content, section, name, value string) string {
+	setting := name + " = " + value
+	lines := strings.Split(content, "\n")
+
+	current := ""
+	insertAt := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if header, ok := tableHeader(trimmed); ok {
+			current = header
+			if current == section {
+				insertAt = i + 1
+			}
+			continue
+		}
+		if current != section || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if key, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(key) == name {
+			lines[i] = setting
+			return strings.Join(lines, "\n")
+		}
+		insertAt = i + 1
+	}
+
+	if insertAt >= 0 {
+		lines = append(lines[:insertAt], append([]string{setting}, lines[insertAt:]...)...)
+		return strings.Join(lines, "\n")
+	}
+
+	var b strings.Builder
+	b.WriteString(content)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+	if strings.TrimSpace(content) != "" {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "[%s]\n%s\n", section, setting)
+	return b.String()
+}
+
+// tableHeader returns the name of the TOML table a trimmed line starts, e.g. "output" for
+// "[output]"; arrays of tables don't count (pure function)
+func tableHeader(trimmed string) (string, bool) {
+	if !strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "[[") {
+		return "", false
+	}
+	end := strings.Index(trimmed, "]")
+	if end < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(trimmed[1:end]), true
+}
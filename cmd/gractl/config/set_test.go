@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetTOMLValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Empty file",
+			content:  "",
+			expected: "[output]\nformat = \"json\"\n",
+		},
+		{
+			name:     "Missing table",
+			content:  "[server]\naddress = \"grad:9090\"",
+			expected: "[server]\naddress = \"grad:9090\"\n\n[output]\nformat = \"json\"\n",
+		},
+		{
+			name:     "Replaces the line and keeps other comments",
+			content:  "# gractl\n[output]\nformat = \"table\" # was wide\ncolor = \"auto\"\n",
+			expected: "# gractl\n[output]\nformat = \"json\"\ncolor = \"auto\"\n",
+		},
+		{
+			name:     "Adds to the end of the table",
+			content:  "[output]\ncolor = \"auto\"\n\n# S3 workspace\n[s3]\nformat = \"csv\"\n",
+			expected: "[output]\ncolor = \"auto\"\nformat = \"json\"\n\n# S3 workspace\n[s3]\nformat = \"csv\"\n",
+		},
+		{
+			name:     "Ignores commented out settings",
+			content:  "[output] # preferences\n# format = \"wide\"\n",
+			expected: "[output] # preferences\nformat = \"json\"\n# format = \"wide\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := setTOMLValue(tt.content, "output", "format", `"json"`); got != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gractl.toml")
+	if err := os.WriteFile(path, []byte("[server]\naddress = \"grad:9090\"\n"), 0o640); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := SetValue(path, "output.format", `"json"`); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	if err := SetValue(path, "output.wide_columns", "true"); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	expected := "[server]\naddress = \"grad:9090\"\n\n[output]\nformat = \"json\"\nwide_columns = true\n"
+	if string(content) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, content)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+		t.Errorf("Expected the file mode to be kept, got %v", info.Mode().Perm())
+	}
+
+	if err := SetValue(path, "output.format", "json"); err == nil {
+		t.Error("Expected a value that isn't TOML to be rejected")
+	}
+	if err := SetValue(path, "format", `"json"`); err == nil {
+		t.Error("Expected a key without a table to be rejected")
+	}
+	if after, _ := os.ReadFile(path); string(after) != expected {
+		t.Errorf("Expected a failed update to leave the file alone, got:\n%s", after)
+	}
+}
+
+func TestLoadConfigOutput(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", dir)
+	if err := os.WriteFile(filepath.Join(dir, ".gractl.toml"), []byte("[output]\nformat = \"json\"\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Setenv("GRACTL_OUTPUT_COLOR", "never")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Output.Format != "json" || cfg.Output.FormatSource != "config file "+filepath.Join(dir, ".gractl.toml") {
+		t.Errorf("Expected json from the config file, got %q from %s", cfg.Output.Format, cfg.Output.FormatSource)
+	}
+	if cfg.Output.Color != "never" || cfg.Output.ColorSource != "GRACTL_OUTPUT_COLOR environment variable" {
+		t.Errorf("Expected never from the environment, got %q from %s", cfg.Output.Color, cfg.Output.ColorSource)
+	}
+	if cfg.Output.WideColumns {
+		t.Error("Expected wide columns to be off by default")
+	}
+}
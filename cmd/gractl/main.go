@@ -18,14 +18,22 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	cmd.RegisterGlobalFlags(rootCmd)
+
 	// Register subcommands
 	rootCmd.AddCommand(cmd.RunnersCmd)
 	rootCmd.AddCommand(cmd.ExecuteCmd)
 	rootCmd.AddCommand(cmd.WorkspaceCmd)
+	rootCmd.AddCommand(cmd.AdminCmd)
+	rootCmd.AddCommand(cmd.ConfigCmd)
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl+C cancels the requests and streams of the command
+	ctx, stop := cmd.NewCommandContext()
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
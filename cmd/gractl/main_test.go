@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// walkCommands calls fn for cmd and every command below it
+func walkCommands(cmd *cobra.Command, fn func(*cobra.Command)) {
+	fn(cmd)
+	for _, child := range cmd.Commands() {
+		walkCommands(child, fn)
+	}
+}
+
+func TestFlagShorthandsAreConsistent(t *testing.T) {
+	// A shorthand means the same flag in every command, so muscle memory carries over
+	owners := map[string]string{}
+	walkCommands(rootCmd, func(cmd *cobra.Command) {
+		cmd.Flags().AddFlagSet(cmd.InheritedFlags())
+		cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			if flag.Shorthand == "" {
+				return
+			}
+			if owner, ok := owners[flag.Shorthand]; ok && owner != flag.Name {
+				t.Errorf("Expected -%s to mean --%s, but %s uses it for --%s", flag.Shorthand, owner, cmd.CommandPath(), flag.Name)
+				return
+			}
+			owners[flag.Shorthand] = flag.Name
+		})
+	})
+}
+
+func TestGlobalFlagsAreInherited(t *testing.T) {
+	walkCommands(rootCmd, func(cmd *cobra.Command) {
+		for _, name := range []string{"server", "output", "quiet", "verbose"} {
+			flag := cmd.Flags().Lookup(name)
+			if flag == nil {
+				flag = cmd.InheritedFlags().Lookup(name)
+			}
+			if flag == nil {
+				t.Errorf("Expected %s to accept --%s", cmd.CommandPath(), name)
+				continue
+			}
+			if flag != rootCmd.PersistentFlags().Lookup(name) {
+				t.Errorf("Expected %s to inherit --%s from gractl instead of defining its own", cmd.CommandPath(), name)
+			}
+		}
+	})
+}
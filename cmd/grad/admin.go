@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	grpcserver "github.com/strrl/gra/internal/grad/grpc"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// rateLimitsBody is the JSON form of the rate limits used by the admin API. Fields left
+// out of a PUT keep their current value.
+type rateLimitsBody struct {
+	MutatingRPS   *float64 `json:"mutating_rps,omitempty"`
+	MutatingBurst *int     `json:"mutating_burst,omitempty"`
+	ReadRPS       *float64 `json:"read_rps,omitempty"`
+	ReadBurst     *int     `json:"read_burst,omitempty"`
+
+	IdentityMutatingRPS   *float64 `json:"identity_mutating_rps,omitempty"`
+	IdentityMutatingBurst *int     `json:"identity_mutating_burst,omitempty"`
+	IdentityReadRPS       *float64 `json:"identity_read_rps,omitempty"`
+	IdentityReadBurst     *int     `json:"identity_read_burst,omitempty"`
+}
+
+// newRateLimitsBody converts limits to their JSON form
+func newRateLimitsBody(limits service.RateLimits) rateLimitsBody {
+	return rateLimitsBody{
+		MutatingRPS:   &limits.MutatingRate,
+		MutatingBurst: &limits.MutatingBurst,
+		ReadRPS:       &limits.ReadRate,
+		ReadBurst:     &limits.ReadBurst,
+
+		IdentityMutatingRPS:   &limits.IdentityMutatingRate,
+		IdentityMutatingBurst: &limits.IdentityMutatingBurst,
+		IdentityReadRPS:       &limits.IdentityReadRate,
+		IdentityReadBurst:     &limits.IdentityReadBurst,
+	}
+}
+
+// applyTo returns limits with the fields set in b replaced
+func (b rateLimitsBody) applyTo(limits service.RateLimits) service.RateLimits {
+	if b.MutatingRPS != nil {
+		limits.MutatingRate = *b.MutatingRPS
+	}
+	if b.MutatingBurst != nil {
+		limits.MutatingBurst = *b.MutatingBurst
+	}
+	if b.ReadRPS != nil {
+		limits.ReadRate = *b.ReadRPS
+	}
+	if b.ReadBurst != nil {
+		limits.ReadBurst = *b.ReadBurst
+	}
+	if b.IdentityMutatingRPS != nil {
+		limits.IdentityMutatingRate = *b.IdentityMutatingRPS
+	}
+	if b.IdentityMutatingBurst != nil {
+		limits.IdentityMutatingBurst = *b.IdentityMutatingBurst
+	}
+	if b.IdentityReadRPS != nil {
+		limits.IdentityReadRate = *b.IdentityReadRPS
+	}
+	if b.IdentityReadBurst != nil {
+		limits.IdentityReadBurst = *b.IdentityReadBurst
+	}
+	return limits
+}
+
+// runnerImageBody is the JSON form of a runner image change used by the admin API. Fields
+// left out of a PUT keep their current image; revert restores the images before the last change.
+type runnerImageBody struct {
+	Image     string `json:"image,omitempty"`
+	S3FSImage string `json:"s3fs_image,omitempty"`
+	Revert    bool   `json:"revert,omitempty"`
+}
+
+// runnerImagesBody reports the images of new runners and, after a change, the ones they replaced
+type runnerImagesBody struct {
+	Image             string `json:"image"`
+	S3FSImage         string `json:"s3fs_image"`
+	PreviousImage     string `json:"previous_image,omitempty"`
+	PreviousS3FSImage string `json:"previous_s3fs_image,omitempty"`
+}
+
+// requireAdminToken rejects requests without "Authorization: Bearer <token>". Like the gRPC
+// AdminServer, an empty token disables the admin API, rejecting every request.
+func requireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin API is disabled on this server, set GRAD_ADMIN_TOKEN"})
+			return
+		}
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid admin token"})
+		}
+	}
+}
+
+// registerAdminRoutes adds the admin API, which changes server settings without a restart.
+// Every admin request must carry adminToken; without one, all are rejected.
+func registerAdminRoutes(r gin.IRouter, rateLimiter *grpcserver.RateLimiter, kubernetes *service.KubernetesConfig, adminToken string) {
+	admin := r.Group("/admin", requireAdminToken(adminToken))
+
+	admin.GET("/rate-limits", func(c *gin.Context) {
+		c.JSON(http.StatusOK, newRateLimitsBody(rateLimiter.Limits()))
+	})
+
+	admin.PUT("/rate-limits", func(c *gin.Context) {
+		var body rateLimitsBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limits := body.applyTo(rateLimiter.Limits())
+		if err := rateLimiter.SetLimits(limits); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Info("Updated rate limits", "rate_limits", limits)
+		c.JSON(http.StatusOK, newRateLimitsBody(limits))
+	})
+	admin.GET("/runner-image", func(c *gin.Context) {
+		images := kubernetes.Images()
+		c.JSON(http.StatusOK, runnerImagesBody{Image: images.Runner, S3FSImage: images.S3FS})
+	})
+
+	admin.PUT("/runner-image", func(c *gin.Context) {
+		var body runnerImageBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if body.Revert && (body.Image != "" || body.S3FSImage != "") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "revert can't be combined with image or s3fs_image"})
+			return
+		}
+
+		var previous service.RunnerImages
+		var err error
+		if body.Revert {
+			previous, err = kubernetes.RevertImages()
+		} else {
+			previous, err = kubernetes.SetImages(service.RunnerImages{Runner: body.Image, S3FS: body.S3FSImage})
+		}
+		switch {
+		case errors.Is(err, service.ErrFailedPrecondition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		images := kubernetes.Images()
+		c.JSON(http.StatusOK, runnerImagesBody{
+			Image:             images.Runner,
+			S3FSImage:         images.S3FS,
+			PreviousImage:     previous.Runner,
+			PreviousS3FSImage: previous.S3FS,
+		})
+	})
+}
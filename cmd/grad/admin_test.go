@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	grpcserver "github.com/strrl/gra/internal/grad/grpc"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+func TestAdminRateLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, err := grpcserver.NewRateLimiter(*service.DefaultRateLimits(), nil)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	r := gin.New()
+	registerAdminRoutes(r, limiter, service.DefaultKubernetesConfig(), "secret")
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/rate-limits", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var got map[string]float64
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if got["mutating_rps"] != service.DefaultRateLimits().MutatingRate {
+		t.Errorf("Expected mutating_rps %v, got %v", service.DefaultRateLimits().MutatingRate, got["mutating_rps"])
+	}
+
+	// A partial update keeps the other limits
+	rec = do(http.MethodPut, `{"mutating_rps": 1, "mutating_burst": 2}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	limits := limiter.Limits()
+	if limits.MutatingRate != 1 || limits.MutatingBurst != 2 {
+		t.Errorf("Expected mutating limit 1/s burst 2, got %v/s burst %d", limits.MutatingRate, limits.MutatingBurst)
+	}
+	if limits.ReadRate != service.DefaultRateLimits().ReadRate {
+		t.Errorf("Expected read rate to be unchanged, got %v", limits.ReadRate)
+	}
+
+	// Invalid limits are rejected and leave the current ones in place
+	rec = do(http.MethodPut, `{"read_rps": -1}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative rate, got %d", rec.Code)
+	}
+	if limiter.Limits().ReadRate != service.DefaultRateLimits().ReadRate {
+		t.Errorf("Expected read rate to be unchanged after a rejected update, got %v", limiter.Limits().ReadRate)
+	}
+
+	rec = do(http.MethodPut, `not json`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", rec.Code)
+	}
+
+	// Lifting the limits needs the admin token
+	for _, header := range []string{"", "Bearer wrong"} {
+		req := httptest.NewRequest(http.MethodPut, "/admin/rate-limits", strings.NewReader(`{"mutating_rps": 1000}`))
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 with Authorization %q, got %d", header, rec.Code)
+		}
+	}
+	if limiter.Limits().MutatingRate != 1 {
+		t.Errorf("Expected unauthenticated updates to keep mutating rate 1, got %v", limiter.Limits().MutatingRate)
+	}
+}
+
+func TestAdminRunnerImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, err := grpcserver.NewRateLimiter(*service.DefaultRateLimits(), nil)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	kubernetes := service.DefaultKubernetesConfig()
+	r := gin.New()
+	registerAdminRoutes(r, limiter, kubernetes, "secret")
+
+	do := func(method, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/runner-image", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := do(http.MethodPut, token, `{"image": "ghcr.io/strrl/grad-runner:v2"}`); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 with token %q, got %d", token, rec.Code)
+		}
+	}
+	if rec := do(http.MethodGet, "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 reading the images without a token, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodPut, "secret", `{"image": "ghcr.io/strrl/grad-runner:v2"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var got runnerImagesBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	expected := runnerImagesBody{
+		Image:             "ghcr.io/strrl/grad-runner:v2",
+		S3FSImage:         service.DefaultS3FSImage,
+		PreviousImage:     service.DefaultRunnerImage,
+		PreviousS3FSImage: service.DefaultS3FSImage,
+	}
+	if got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if kubernetes.Images().Runner != expected.Image {
+		t.Errorf("Expected new runners to use %s, got %s", expected.Image, kubernetes.Images().Runner)
+	}
+
+	// Invalid changes are rejected and leave the images in place
+	for _, body := range []string{`{"image": "Not/Valid"}`, `{}`, `{"image": "ghcr.io/strrl/grad-runner:v3", "revert": true}`, `not json`} {
+		if rec := do(http.MethodPut, "secret", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+	if kubernetes.Images().Runner != expected.Image {
+		t.Errorf("Expected rejected changes to keep %s, got %s", expected.Image, kubernetes.Images().Runner)
+	}
+
+	rec = do(http.MethodPut, "secret", `{"revert": true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if kubernetes.Images().Runner != service.DefaultRunnerImage {
+		t.Errorf("Expected the revert to restore %s, got %s", service.DefaultRunnerImage, kubernetes.Images().Runner)
+	}
+}
+
+func TestAdminAPIDisabledWithoutToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, err := grpcserver.NewRateLimiter(*service.DefaultRateLimits(), nil)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	kubernetes := service.DefaultKubernetesConfig()
+	r := gin.New()
+	registerAdminRoutes(r, limiter, kubernetes, "")
+
+	for _, path := range []string{"/admin/runner-image", "/admin/rate-limits"} {
+		for _, method := range []string{http.MethodGet, http.MethodPut} {
+			req := httptest.NewRequest(method, path, strings.NewReader(`{"image": "evil.example.com/runner:latest", "mutating_rps": 1000}`))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("Expected 403 for %s %s without an admin token configured, got %d", method, path, rec.Code)
+			}
+		}
+	}
+	if kubernetes.Images().Runner != service.DefaultRunnerImage {
+		t.Errorf("Expected the runner image to stay %s, got %s", service.DefaultRunnerImage, kubernetes.Images().Runner)
+	}
+	if limiter.Limits() != *service.DefaultRateLimits() {
+		t.Errorf("Expected the default rate limits to stay, got %+v", limiter.Limits())
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// checkTimeout bounds the Kubernetes API calls made by grad check
+const checkTimeout = 30 * time.Second
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the configuration and Kubernetes access, then exit",
+	Long: `Run the checks grad needs to pass before serving traffic, without starting the servers:
+the configuration is valid, the Kubernetes API is reachable, grad has the RBAC permissions
+it needs on pods in the runner namespace, and the configured images are valid references.
+Prints PASS or FAIL per check and exits non-zero if any check fails.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(cmd.Context(), checkTimeout)
+		defer cancel()
+
+		config, configErr := loadConfig(cmd.Flags())
+		if !runChecks(ctx, cmd.OutOrStdout(), config, configErr, service.NewKubernetesClient) {
+			return fmt.Errorf("preflight checks failed")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
+
+// runChecks prints a PASS/FAIL line per check to out and reports whether every check passed.
+// Later checks are skipped when the config or the Kubernetes client can't be loaded.
+func runChecks(ctx context.Context, out io.Writer, config *service.Config, configErr error,
+	newClient func(*service.KubernetesConfig) (*service.KubernetesClient, error)) bool {
+	report := func(check service.PreflightCheck) bool {
+		if check.Err != nil {
+			fmt.Fprintf(out, "FAIL  %s: %v\n", check.Name, check.Err)
+			return false
+		}
+		if check.Warning != "" {
+			fmt.Fprintf(out, "WARN  %s: %s\n", check.Name, check.Warning)
+			return true
+		}
+		fmt.Fprintf(out, "PASS  %s\n", check.Name)
+		return true
+	}
+
+	if !report(service.PreflightCheck{Name: "configuration", Err: configErr}) {
+		return false
+	}
+
+	k8sClient, err := newClient(config.Kubernetes)
+	if !report(service.PreflightCheck{Name: "kubernetes client", Err: err}) {
+		return false
+	}
+
+	passed := true
+	for _, check := range k8sClient.Preflight(ctx) {
+		passed = report(check) && passed
+	}
+	return passed
+}
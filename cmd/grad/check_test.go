@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+func TestRunChecksStopsOnConfigError(t *testing.T) {
+	var out bytes.Buffer
+	newClient := func(*service.KubernetesConfig) (*service.KubernetesClient, error) {
+		t.Fatal("Expected no Kubernetes client to be created for an invalid config")
+		return nil, nil
+	}
+
+	_, configErr := service.LoadConfigFrom(func(key string) string {
+		if key == "RUNNER_IDLE_TIMEOUT" {
+			return "forever"
+		}
+		return ""
+	})
+	if runChecks(context.Background(), &out, nil, configErr, newClient) {
+		t.Error("Expected checks to fail")
+	}
+	if !strings.HasPrefix(out.String(), "FAIL  configuration: ") || !strings.Contains(out.String(), "RUNNER_IDLE_TIMEOUT") {
+		t.Errorf("Expected a configuration failure naming the bad value, got:\n%s", out.String())
+	}
+}
+
+func TestRunChecksReportsClientError(t *testing.T) {
+	config, err := service.LoadConfigFrom(func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	newClient := func(*service.KubernetesConfig) (*service.KubernetesClient, error) {
+		return nil, errors.New("no kubeconfig")
+	}
+
+	var out bytes.Buffer
+	if runChecks(context.Background(), &out, config, nil, newClient) {
+		t.Error("Expected checks to fail")
+	}
+	expected := "PASS  configuration\nFAIL  kubernetes client: no kubeconfig\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
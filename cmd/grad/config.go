@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// configFileEnv names the configuration file when --config-file isn't set
+const configFileEnv = "GRAD_CONFIG_FILE"
+
+// flagEnvKeys maps each configuration flag to the environment variable it overrides
+var flagEnvKeys = map[string]string{}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect grad configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration and print the effective values",
+	Long: `Load the configuration from flags, the config file and environment variables the
+same way the server does, without starting it. Every invalid value is reported at once.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		return printConfig(cmd.OutOrStdout(), config)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+	registerConfigFlags(rootCmd.PersistentFlags())
+}
+
+// registerConfigFlags adds the flags that override configuration environment variables
+func registerConfigFlags(flags *pflag.FlagSet) {
+	flags.String("config-file", "", fmt.Sprintf("File of KEY=VALUE lines setting configuration environment variables, read again on SIGHUP (env %s)", configFileEnv))
+
+	rateLimits := service.DefaultRateLimits()
+	configFlag(flags, "rate-limit-mutating", "RATE_LIMIT_MUTATING_RPS", fmt.Sprint(rateLimits.MutatingRate), "Requests per second allowed for RPCs that create or delete runners, 0 to disable")
+	configFlag(flags, "rate-limit-mutating-burst", "RATE_LIMIT_MUTATING_BURST", fmt.Sprint(rateLimits.MutatingBurst), "Burst size for mutating RPCs")
+	configFlag(flags, "rate-limit-read", "RATE_LIMIT_READ_RPS", fmt.Sprint(rateLimits.ReadRate), "Requests per second allowed for read RPCs, 0 to disable")
+	configFlag(flags, "rate-limit-read-burst", "RATE_LIMIT_READ_BURST", fmt.Sprint(rateLimits.ReadBurst), "Burst size for read RPCs")
+	configFlag(flags, "rate-limit-identity-mutating", "RATE_LIMIT_IDENTITY_MUTATING_RPS", fmt.Sprint(rateLimits.IdentityMutatingRate), "Mutating requests per second allowed per authenticated client, 0 to disable")
+	configFlag(flags, "rate-limit-identity-mutating-burst", "RATE_LIMIT_IDENTITY_MUTATING_BURST", fmt.Sprint(rateLimits.IdentityMutatingBurst), "Per-client burst size for mutating RPCs")
+	configFlag(flags, "rate-limit-identity-read", "RATE_LIMIT_IDENTITY_READ_RPS", fmt.Sprint(rateLimits.IdentityReadRate), "Read requests per second allowed per authenticated client, 0 to disable")
+	configFlag(flags, "rate-limit-identity-read-burst", "RATE_LIMIT_IDENTITY_READ_BURST", fmt.Sprint(rateLimits.IdentityReadBurst), "Per-client burst size for read RPCs")
+
+	streamLimits := service.DefaultStreamLimits()
+	configFlag(flags, "grpc-max-concurrent-streams", "GRPC_MAX_CONCURRENT_STREAMS", fmt.Sprint(streamLimits.MaxConcurrentStreams), "Maximum concurrent gRPC streams per client connection")
+	configFlag(flags, "grpc-max-exec-streams", "GRPC_MAX_EXEC_STREAMS", fmt.Sprint(streamLimits.MaxExecStreams), "Maximum command output streams open at once across all clients, 0 for no limit")
+	configFlag(flags, "grpc-max-exec-output-bytes", "GRPC_MAX_EXEC_OUTPUT_BYTES", fmt.Sprint(streamLimits.MaxExecOutputBytes), "Maximum bytes of output sent for a single command, 0 for no limit")
+	configFlag(flags, "grpc-exec-resume-grace", "GRPC_EXEC_RESUME_GRACE", streamLimits.ExecResumeGrace.String(), "How long a resumable command keeps running after its stream breaks, waiting for the client to reattach")
+
+	// Durations take Go duration strings, e.g. 90s or 1h30m
+	for _, setting := range service.DefaultDurations().Settings() {
+		name := strings.ToLower(strings.ReplaceAll(setting.Key, "_", "-"))
+		usage := fmt.Sprintf("%s, between %s and %s", setting.Description, setting.Min, setting.Max)
+		configFlag(flags, name, setting.Key, setting.Target.String(), usage)
+	}
+	configFlag(flags, "runner-group-idle-timeouts", "RUNNER_GROUP_IDLE_TIMEOUTS", "", "Idle timeouts for runner groups matching a pattern, e.g. ci-*=15m,nightly=2h")
+	configFlag(flags, "disable-features", "GRAD_DISABLE_FEATURES", "", "Features to turn off on air-gapped clusters: s3-workspace, webhooks, tracing, comma-separated")
+}
+
+// configFlag adds a string flag overriding the environment variable key. The value is
+// parsed and validated with the rest of the configuration, so errors are reported together.
+func configFlag(flags *pflag.FlagSet, name, key, defaultValue, usage string) {
+	flags.String(name, defaultValue, fmt.Sprintf("%s (env %s)", usage, key))
+	flagEnvKeys[name] = key
+}
+
+// configLookup returns a lookup that prefers flags set on the command line over the environment
+func configLookup(flags *pflag.FlagSet, getenv func(string) string) func(string) string {
+	overrides := make(map[string]string)
+	for name, key := range flagEnvKeys {
+		if flag := flags.Lookup(name); flag != nil && flag.Changed {
+			overrides[key] = flag.Value.String()
+		}
+	}
+	return func(key string) string {
+		if value, ok := overrides[key]; ok {
+			return value
+		}
+		return getenv(key)
+	}
+}
+
+// configSource returns the function giving the lookup grad's configuration is loaded from:
+// flags set on the command line win over the config file, which wins over getenv. The file is
+// read again on every call, so reloads see its changes.
+func configSource(flags *pflag.FlagSet, getenv func(string) string) func() (func(string) string, error) {
+	return func() (func(string) string, error) {
+		path, _ := flags.GetString("config-file")
+		if path == "" {
+			path = getenv(configFileEnv)
+		}
+		var fileValues map[string]string
+		if path != "" {
+			values, err := service.ReadConfigFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read config file: %w", err)
+			}
+			fileValues = values
+		}
+		return configLookup(flags, func(key string) string {
+			if value, ok := fileValues[key]; ok {
+				return value
+			}
+			return getenv(key)
+		}), nil
+	}
+}
+
+// loadConfig loads the grad configuration from flags, the config file and the environment
+func loadConfig(flags *pflag.FlagSet) (*service.Config, error) {
+	lookup, err := configSource(flags, os.Getenv)()
+	if err != nil {
+		return nil, err
+	}
+	return service.LoadConfigFrom(lookup)
+}
+
+// printConfig writes the effective configuration, keyed by environment variable
+func printConfig(out io.Writer, config *service.Config) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	k8s := config.Kubernetes
+	fmt.Fprintf(w, "KUBERNETES_NAMESPACE\t%s\n", k8s.Namespace)
+	fmt.Fprintf(w, "RUNNER_IMAGE\t%s\n", k8s.RunnerImage)
+	fmt.Fprintf(w, "S3FS_IMAGE\t%s\n", k8s.S3FSImage)
+	fmt.Fprintf(w, "SSH_PORT\t%d\n", k8s.SSHPort)
+	fmt.Fprintf(w, "RUNNER_DEFAULT_CPU\t%s\n", k8s.DefaultCPU)
+	fmt.Fprintf(w, "RUNNER_DEFAULT_MEMORY\t%s\n", k8s.DefaultMemory)
+	fmt.Fprintf(w, "RUNNER_DEFAULT_STORAGE\t%s\n", k8s.DefaultStorage)
+	fmt.Fprintf(w, "RUNNER_DEFAULT_SHELL\t%s\n", k8s.DefaultShell)
+	fmt.Fprintf(w, "RUNNER_DEFAULT_WORKDIR\t%s\n", k8s.DefaultWorkdir)
+	fmt.Fprintf(w, "S3FS_CPU_REQUEST\t%s\n", k8s.Sidecar.CPURequest)
+	fmt.Fprintf(w, "S3FS_MEMORY_REQUEST\t%s\n", k8s.Sidecar.MemoryRequest)
+	fmt.Fprintf(w, "S3FS_CPU_LIMIT\t%s\n", k8s.Sidecar.CPULimit)
+	fmt.Fprintf(w, "S3FS_MEMORY_LIMIT\t%s\n", k8s.Sidecar.MemoryLimit)
+	fmt.Fprintf(w, "RUNNER_AUTO_RESTART\t%t\n", k8s.Restart.Default)
+	fmt.Fprintf(w, "RUNNER_MAX_RESTARTS\t%d\n", k8s.Restart.MaxRestarts)
+	fmt.Fprintf(w, "RUNNER_RESTART_BACKOFF\t%s\n", k8s.Restart.Backoff)
+	fmt.Fprintf(w, "GRAD_DISABLE_FEATURES\t%s\n", k8s.DisabledFeatures)
+
+	fmt.Fprintf(w, "ENV_MAX_VALUE_BYTES\t%d\n", config.EnvLimits.MaxValueBytes)
+	fmt.Fprintf(w, "ENV_MAX_TOTAL_BYTES\t%d\n", config.EnvLimits.MaxTotalBytes)
+	fmt.Fprintf(w, "ENV_MAX_ENTRIES\t%d\n", config.EnvLimits.MaxEntries)
+	fmt.Fprintf(w, "EXEC_MAX_COMMAND_BYTES\t%d\n", config.CommandLimits.MaxBytes)
+	fmt.Fprintf(w, "EXEC_MAX_ARGS\t%d\n", config.CommandLimits.MaxArgs)
+
+	limits := config.RateLimits
+	fmt.Fprintf(w, "RATE_LIMIT_MUTATING_RPS\t%v\n", limits.MutatingRate)
+	fmt.Fprintf(w, "RATE_LIMIT_MUTATING_BURST\t%d\n", limits.MutatingBurst)
+	fmt.Fprintf(w, "RATE_LIMIT_READ_RPS\t%v\n", limits.ReadRate)
+	fmt.Fprintf(w, "RATE_LIMIT_READ_BURST\t%d\n", limits.ReadBurst)
+	fmt.Fprintf(w, "RATE_LIMIT_IDENTITY_MUTATING_RPS\t%v\n", limits.IdentityMutatingRate)
+	fmt.Fprintf(w, "RATE_LIMIT_IDENTITY_MUTATING_BURST\t%d\n", limits.IdentityMutatingBurst)
+	fmt.Fprintf(w, "RATE_LIMIT_IDENTITY_READ_RPS\t%v\n", limits.IdentityReadRate)
+	fmt.Fprintf(w, "RATE_LIMIT_IDENTITY_READ_BURST\t%d\n", limits.IdentityReadBurst)
+
+	fmt.Fprintf(w, "GRPC_MAX_CONCURRENT_STREAMS\t%d\n", config.StreamLimits.MaxConcurrentStreams)
+	fmt.Fprintf(w, "GRPC_MAX_EXEC_STREAMS\t%d\n", config.StreamLimits.MaxExecStreams)
+	fmt.Fprintf(w, "GRPC_MAX_EXEC_OUTPUT_BYTES\t%d\n", config.StreamLimits.MaxExecOutputBytes)
+	fmt.Fprintf(w, "GRPC_EXEC_RESUME_GRACE\t%s\n", config.StreamLimits.ExecResumeGrace)
+
+	for _, setting := range config.Durations.Settings() {
+		fmt.Fprintf(w, "%s\t%s\n", setting.Key, *setting.Target)
+	}
+	fmt.Fprintf(w, "RUNNER_GROUP_IDLE_TIMEOUTS\t%s\n", config.GroupIdleTimeouts)
+
+	// Never print the token itself
+	adminToken := "not set"
+	if config.AdminToken != "" {
+		adminToken = "set"
+	}
+	fmt.Fprintf(w, "GRAD_ADMIN_TOKEN\t%s\n", adminToken)
+
+	return w.Flush()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// newConfigFlags returns a flag set with the configuration flags parsed from args
+func newConfigFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := pflag.NewFlagSet("grad", pflag.ContinueOnError)
+	registerConfigFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	return flags
+}
+
+func TestConfigLookupPrefersFlags(t *testing.T) {
+	flags := newConfigFlags(t, "--runner-idle-timeout=1h", "--rate-limit-read=20")
+	env := map[string]string{
+		"RUNNER_IDLE_TIMEOUT": "10m",
+		"CLEANUP_INTERVAL":    "30s",
+	}
+
+	config, err := service.LoadConfigFrom(configLookup(flags, func(key string) string { return env[key] }))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if config.Durations.IdleTimeout != time.Hour {
+		t.Errorf("Expected the flag to override the environment, got %s", config.Durations.IdleTimeout)
+	}
+	if config.Durations.CleanupInterval != 30*time.Second {
+		t.Errorf("Expected the environment to apply when the flag is unset, got %s", config.Durations.CleanupInterval)
+	}
+	if config.RateLimits.ReadRate != 20 {
+		t.Errorf("Expected read rate 20, got %v", config.RateLimits.ReadRate)
+	}
+}
+
+func TestConfigSourceReadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grad.env")
+	if err := os.WriteFile(path, []byte("# Overrides\nRUNNER_IDLE_TIMEOUT=20m\nCLEANUP_INTERVAL=45s\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	env := map[string]string{
+		configFileEnv:         path,
+		"RUNNER_IDLE_TIMEOUT": "10m",
+		"CLEANUP_INTERVAL":    "30s",
+		"RATE_LIMIT_READ_RPS": "20",
+	}
+	load := configSource(newConfigFlags(t, "--cleanup-interval=2m"), func(key string) string { return env[key] })
+
+	lookup, err := load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for key, expected := range map[string]string{"CLEANUP_INTERVAL": "2m", "RUNNER_IDLE_TIMEOUT": "20m", "RATE_LIMIT_READ_RPS": "20"} {
+		if value := lookup(key); value != expected {
+			t.Errorf("%s: expected %q (flags, then the file, then the environment), got %q", key, expected, value)
+		}
+	}
+
+	// Every load reads the file again
+	if err := os.WriteFile(path, []byte("RUNNER_IDLE_TIMEOUT=1h\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if lookup, err := load(); err != nil || lookup("RUNNER_IDLE_TIMEOUT") != "1h" {
+		t.Errorf("Expected the changed file to be read, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("RUNNER_IDLE_TIMEOUT\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := load(); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected the malformed line to be reported, got %v", err)
+	}
+}
+
+func TestConfigLookupAggregatesFlagProblems(t *testing.T) {
+	flags := newConfigFlags(t, "--shutdown-grace=1h", "--runner-monitor-interval=soon")
+
+	_, err := service.LoadConfigFrom(configLookup(flags, func(string) string { return "" }))
+	var configErr *service.ConfigError
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 2 {
+		t.Fatalf("Expected a ConfigError with 2 problems, got %v", err)
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	config, err := service.LoadConfigFrom(configLookup(newConfigFlags(t, "--cleanup-interval=90s", "--disable-features=tracing,s3-workspace"), func(string) string { return "" }))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := printConfig(&out, config); err != nil {
+		t.Fatalf("Failed to print config: %v", err)
+	}
+	values := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			values[fields[0]] = fields[1]
+		}
+	}
+	if values["CLEANUP_INTERVAL"] != "1m30s" {
+		t.Errorf("Expected CLEANUP_INTERVAL 1m30s, got:\n%s", out.String())
+	}
+	if values["RATE_LIMIT_MUTATING_RPS"] != "5" {
+		t.Errorf("Expected RATE_LIMIT_MUTATING_RPS 5, got:\n%s", out.String())
+	}
+	if values["GRAD_DISABLE_FEATURES"] != "s3-workspace,tracing" {
+		t.Errorf("Expected GRAD_DISABLE_FEATURES s3-workspace,tracing, got:\n%s", out.String())
+	}
+}
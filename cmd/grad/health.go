@@ -0,0 +1,34 @@
+package main
+
+import (
+	"time"
+
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// healthBody is the JSON body of /health. Fleet tooling reads the build details from it;
+// status is kept for clients that only look at that.
+type healthBody struct {
+	Status            string `json:"status"`
+	Version           string `json:"version"`
+	Commit            string `json:"commit"`
+	BuildDate         string `json:"build_date"`
+	GoVersion         string `json:"go_version"`
+	Uptime            string `json:"uptime"`
+	UptimeSeconds     int64  `json:"uptime_seconds"`
+	KubernetesVersion string `json:"kubernetes_version"`
+}
+
+// newHealthBody reports status as healthy (pure function)
+func newHealthBody(status service.ServerStatus) healthBody {
+	return healthBody{
+		Status:            "ok",
+		Version:           status.Version,
+		Commit:            status.Commit,
+		BuildDate:         status.BuildDate,
+		GoVersion:         status.GoVersion,
+		Uptime:            status.Uptime.Round(time.Second).String(),
+		UptimeSeconds:     int64(status.Uptime.Seconds()),
+		KubernetesVersion: status.KubernetesVersion,
+	}
+}
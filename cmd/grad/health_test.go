@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// getHealth requests /health and decodes its body
+func getHealth(t *testing.T, handler http.Handler) map[string]any {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	return body
+}
+
+func TestHealthReportsBuildInfo(t *testing.T) {
+	fetches := 0
+	fetch := func(ctx context.Context) (string, error) {
+		fetches++
+		return "v1.30.2", nil
+	}
+	build := service.BuildInfo{Version: "v1.2.0", Commit: "3f1c0a9e", BuildDate: "2026-10-01T12:00:00Z", GoVersion: "go1.24.5"}
+	r := newTestRouterWithInfo(t, false, false, service.NewServerInfo(build, fetch, time.Hour))
+
+	if fetches != 0 {
+		t.Fatalf("Expected the Kubernetes version to be looked up lazily, got %d lookups", fetches)
+	}
+
+	body := getHealth(t, r)
+	var keys []string
+	for key := range body {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	expectedKeys := []string{"build_date", "commit", "go_version", "kubernetes_version", "status", "uptime", "uptime_seconds", "version"}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Expected keys %v, got %v", expectedKeys, keys)
+	}
+
+	expected := map[string]any{
+		"status":             "ok",
+		"version":            "v1.2.0",
+		"commit":             "3f1c0a9e",
+		"build_date":         "2026-10-01T12:00:00Z",
+		"go_version":         "go1.24.5",
+		"kubernetes_version": "v1.30.2",
+	}
+	for key, value := range expected {
+		if body[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, body[key])
+		}
+	}
+	if _, ok := body["uptime_seconds"].(float64); !ok {
+		t.Errorf("Expected a numeric uptime_seconds, got %v", body["uptime_seconds"])
+	}
+
+	// Health checks within the TTL reuse the version
+	getHealth(t, r)
+	getHealth(t, r)
+	if fetches != 1 {
+		t.Errorf("Expected 1 Kubernetes version lookup, got %d", fetches)
+	}
+}
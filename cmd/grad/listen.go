@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// unixSocketScheme is the address prefix selecting a Unix domain socket, as understood by gRPC clients
+const unixSocketScheme = "unix://"
+
+// parseSocketMode parses an octal file mode such as "0660"
+func parseSocketMode(mode string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid socket mode %q: must be octal like 0660", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// listenGRPC listens on a TCP address (host:port) or a Unix socket (unix:///path/to/grad.sock).
+// For Unix sockets a stale socket file is removed first and the socket gets socketMode permissions.
+func listenGRPC(addr string, socketMode os.FileMode) (net.Listener, error) {
+	if !strings.HasPrefix(addr, unixSocketScheme) {
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, unixSocketScheme)
+	if path == "" {
+		return nil, fmt.Errorf("invalid unix socket address %q: missing path", addr)
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, socketMode); err != nil {
+		lis.Close()
+		return nil, fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+
+	return lis, nil
+}
+
+// removeStaleSocket deletes a socket file left behind by a previous run; other files are left alone
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("refusing to remove %s: not a socket", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	grpcserver "github.com/strrl/gra/internal/grad/grpc"
+	"github.com/strrl/gra/internal/grad/service"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// memoryRunnerService is an in-memory RunnerService for exercising the transport
+type memoryRunnerService struct {
+	mu      sync.Mutex
+	runners []*service.Runner
+}
+
+func (m *memoryRunnerService) Start(ctx context.Context) {}
+
+func (m *memoryRunnerService) Stop() {}
+
+func (m *memoryRunnerService) CreateRunner(ctx context.Context, req *service.CreateRunnerRequest) (*service.Runner, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	runner := &service.Runner{
+		ID:     fmt.Sprintf("runner-%d", len(m.runners)+1),
+		Name:   req.Name,
+		Status: service.RunnerStatusCreating,
+	}
+	m.runners = append(m.runners, runner)
+	return runner, nil
+}
+
+func (m *memoryRunnerService) DeleteRunner(ctx context.Context, runnerID string, overrideProtection bool) (*service.RunnerCleanup, error) {
+	return nil, service.ErrRunnerNotFound
+}
+
+func (m *memoryRunnerService) WaitForRunnerDeleted(ctx context.Context, runnerID string, timeout time.Duration) ([]service.RunnerResource, error) {
+	return nil, nil
+}
+
+func (m *memoryRunnerService) GetRunnerUsage(ctx context.Context, runnerID string) (*service.RunnerUsage, error) {
+	return nil, service.ErrMetricsUnavailable
+}
+
+func (m *memoryRunnerService) ListRunnerUsage(ctx context.Context) ([]*service.RunnerUsage, error) {
+	return nil, service.ErrMetricsUnavailable
+}
+
+func (m *memoryRunnerService) UpdateRunner(ctx context.Context, runnerID string, req *service.UpdateRunnerRequest) (*service.Runner, error) {
+	return nil, service.ErrRunnerNotFound
+}
+
+func (m *memoryRunnerService) CloneRunner(ctx context.Context, sourceRunnerID, name string) (*service.Runner, error) {
+	return nil, service.ErrRunnerNotFound
+}
+
+func (m *memoryRunnerService) DeleteRunnerGroup(ctx context.Context, group string) (*service.RunnerGroupDeletion, error) {
+	return &service.RunnerGroupDeletion{}, nil
+}
+
+func (m *memoryRunnerService) ListRunners(ctx context.Context, opts *service.ListOptions) ([]*service.Runner, int32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.runners, int32(len(m.runners)), nil
+}
+
+func (m *memoryRunnerService) GetRunner(ctx context.Context, runnerID string) (*service.Runner, error) {
+	return nil, service.ErrRunnerNotFound
+}
+
+func (m *memoryRunnerService) GetRunnerStats(ctx context.Context, opts *service.ListOptions) (*service.RunnerStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &service.RunnerStats{Total: int32(len(m.runners))}, nil
+}
+
+func (m *memoryRunnerService) GetRunnerDiagnostics(ctx context.Context, runnerID string, tailLines int64) (*service.RunnerDiagnostics, error) {
+	return nil, service.ErrRunnerNotFound
+}
+
+func (m *memoryRunnerService) AddSSHKey(ctx context.Context, runnerID, publicKey string) (*service.SSHKey, bool, error) {
+	return nil, false, service.ErrRunnerNotFound
+}
+
+func (m *memoryRunnerService) ListSSHKeys(ctx context.Context, runnerID string) ([]service.SSHKey, error) {
+	return nil, service.ErrRunnerNotFound
+}
+
+func (m *memoryRunnerService) RemoveSSHKey(ctx context.Context, runnerID, fingerprint string) (bool, error) {
+	return false, service.ErrRunnerNotFound
+}
+
+func (m *memoryRunnerService) SnapshotWorkspace(ctx context.Context, req *service.SnapshotRequest, progress func(service.SnapshotProgress)) (*service.SnapshotProgress, error) {
+	return nil, service.ErrRunnerNotFound
+}
+
+func (m *memoryRunnerService) ExecuteCommandStream(ctx context.Context, req *service.ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+	close(stdoutCh)
+	close(stderrCh)
+	return service.ExitStatus{Reason: service.ExitReasonExited}, nil
+}
+
+// tempSocketPath returns a short socket path; Unix socket paths are limited to ~100 bytes
+func tempSocketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "grad")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "grad.sock")
+}
+
+func TestUnixSocketRoundTrip(t *testing.T) {
+	path := tempSocketPath(t)
+
+	lis, err := listenGRPC("unix://"+path, 0600)
+	if err != nil {
+		t.Fatalf("listenGRPC failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected socket file to exist: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected socket mode 0600, got %o", info.Mode().Perm())
+	}
+
+	srv := grpc.NewServer()
+	gradv1.RegisterRunnerServiceServer(srv, grpcserver.NewServer(&memoryRunnerService{}, nil, nil, nil, nil, nil, nil))
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	c, err := gradclient.NewClient(&gradclient.Config{
+		ServerAddress: "unix://" + path,
+		DialTimeout:   2 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect over unix socket: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	created, err := c.RunnerService().CreateRunner(ctx, &gradv1.CreateRunnerRequest{Name: "socket-runner"})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if created.Runner.Name != "socket-runner" {
+		t.Errorf("Expected runner name 'socket-runner', got '%s'", created.Runner.Name)
+	}
+
+	list, err := c.RunnerService().ListRunners(ctx, &gradv1.ListRunnersRequest{})
+	if err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+	if list.Total != 1 || len(list.Runners) != 1 || list.Runners[0].Id != created.Runner.Id {
+		t.Errorf("Expected to list the created runner, got %v", list.Runners)
+	}
+}
+
+func TestListenGRPCRemovesStaleSocket(t *testing.T) {
+	path := tempSocketPath(t)
+
+	// Leave a socket file behind, as after a crash
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	lis, err := listenGRPC("unix://"+path, 0660)
+	if err != nil {
+		t.Fatalf("Expected stale socket to be replaced, got: %v", err)
+	}
+	lis.Close()
+}
+
+func TestListenGRPCRefusesRegularFile(t *testing.T) {
+	path := tempSocketPath(t)
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if _, err := listenGRPC("unix://"+path, 0660); err == nil {
+		t.Fatal("Expected listenGRPC to refuse to replace a regular file")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected regular file to be left alone: %v", err)
+	}
+}
+
+func TestParseSocketMode(t *testing.T) {
+	mode, err := parseSocketMode("0660")
+	if err != nil || mode != 0660 {
+		t.Errorf("Expected 0660, got %o (err=%v)", mode, err)
+	}
+
+	if _, err := parseSocketMode("rw-rw----"); err == nil {
+		t.Error("Expected non-octal mode to be rejected")
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
-	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -22,141 +21,213 @@ import (
 
 	gradv1 "github.com/strrl/gra/gen/grad/v1"
 	grpcserver "github.com/strrl/gra/internal/grad/grpc"
+	"github.com/strrl/gra/internal/grad/logs"
+	"github.com/strrl/gra/internal/grad/metrics"
 	"github.com/strrl/gra/internal/grad/service"
 )
 
 var (
-	httpPort string
-	grpcPort string
-
-	// Prometheus metrics
-	httpRequestsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total number of HTTP requests",
-		},
-		[]string{"method", "endpoint", "status"},
-	)
-
-	httpRequestDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name: "http_request_duration_seconds",
-			Help: "Duration of HTTP requests in seconds",
-		},
-		[]string{"method", "endpoint"},
-	)
-
-	grpcRequestsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "grpc_requests_total",
-			Help: "Total number of gRPC requests",
-		},
-		[]string{"method", "status"},
-	)
-
-	grpcRequestDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name: "grpc_request_duration_seconds",
-			Help: "Duration of gRPC requests in seconds",
-		},
-		[]string{"method"},
-	)
+	httpPort       string
+	grpcPort       string
+	grpcAddr       string
+	grpcSocketMode string
+	enableAdminAPI bool
+	enableAPIDocs  bool
+	skipImageCheck bool
 )
 
-func init() {
-	prometheus.MustRegister(httpRequestsTotal)
-	prometheus.MustRegister(httpRequestDuration)
-	prometheus.MustRegister(grpcRequestsTotal)
-	prometheus.MustRegister(grpcRequestDuration)
-}
-
 var rootCmd = &cobra.Command{
 	Use:   "grad",
 	Short: "Grad - HTTP and gRPC service for managing runners",
 	Long:  `Grad is a dual HTTP/gRPC service that manages runner lifecycle in Kubernetes.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		runServers()
+		runServers(cmd)
 	},
 }
 
 func init() {
 	rootCmd.Flags().StringVar(&httpPort, "http-port", "8080", "HTTP server port")
 	rootCmd.Flags().StringVar(&grpcPort, "grpc-port", "9090", "gRPC server port")
+	rootCmd.Flags().StringVar(&grpcAddr, "grpc-addr", "", "gRPC listen address, host:port or unix:///path/to/grad.sock (overrides --grpc-port)")
+	rootCmd.Flags().StringVar(&grpcSocketMode, "grpc-socket-mode", "0660", "Permissions for the gRPC Unix socket")
+	rootCmd.Flags().BoolVar(&enableAdminAPI, "enable-admin-api", false, "Serve the admin API under /admin on the HTTP port (requests must carry GRAD_ADMIN_TOKEN; all are rejected when it is unset)")
+	rootCmd.Flags().BoolVar(&skipImageCheck, "skip-image-check", false, "Don't resolve the runner and s3fs images in their registries at startup and on image changes, e.g. on air-gapped clusters")
+	rootCmd.Flags().BoolVar(&enableAPIDocs, "enable-api-docs", false, "Serve Swagger UI for /openapi.json at /docs on the HTTP port (loads Swagger UI from unpkg.com)")
 }
 
-func runServers() {
+func runServers(cmd *cobra.Command) {
 	var wg sync.WaitGroup
 	wg.Add(3) // HTTP server, gRPC server, and cleanup service
 
-	// Initialize structured logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-	slog.SetDefault(logger)
+	// Initialize structured logger, redacting secrets and keeping the most recent records
+	// for the admin API to tail
+	logBuffer := logs.NewBuffer(logs.DefaultBufferSize)
+	slog.SetDefault(slog.New(logs.NewHandler(os.Stdout, slog.LevelInfo, logBuffer)))
+
+	// Load configuration; flags override the config file, which overrides environment variables
+	reloader, config, err := service.NewConfigReloader(configSource(cmd.Flags(), os.Getenv))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m, err := metrics.New(prometheus.DefaultRegisterer)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rateLimiter, err := grpcserver.NewRateLimiter(*config.RateLimits, m)
+	if err != nil {
+		log.Fatalf("Invalid rate limits: %v", err)
+	}
 
-	// Load configuration
-	config := service.LoadConfig()
+	build := service.ReadBuildInfo()
 
 	// Log current runner image configuration
 	slog.Info("Starting grad service",
+		"version", build.Version,
+		"commit", build.Commit,
 		"runner_image", config.Kubernetes.RunnerImage,
 		"http_port", httpPort,
 		"grpc_port", grpcPort,
+		"grpc_addr", grpcAddr,
+		"rate_limits", *config.RateLimits,
+		"stream_limits", *config.StreamLimits,
+		"durations", *config.Durations,
 	)
+	logDisabledFeatures(config.Kubernetes.DisabledFeatures)
+
+	if warning := service.MutableImageTagWarning(config.Kubernetes.RunnerImage); warning != "" {
+		slog.Warn("Runner image is not pinned", "warning", warning)
+	}
 
 	// Initialize Kubernetes client
 	k8sClient, err := service.NewKubernetesClient(config.Kubernetes)
 	if err != nil {
 		log.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
+	k8sClient.SetAPITimeout(config.Durations.APITimeout)
+
+	// Create the runner namespace up front when grad is allowed to manage it
+	if err := k8sClient.EnsureNamespace(context.Background(), config.Kubernetes.Namespace); err != nil {
+		log.Fatalf("Failed to ensure runner namespace: %v", err)
+	}
+
+	if err := k8sClient.EnsureServiceAccount(context.Background()); err != nil {
+		log.Fatalf("Failed to ensure runner service account: %v", err)
+	}
+
+	// Validate the network policy config and apply the shared policy if used
+	if err := k8sClient.SetupNetworkPolicy(context.Background()); err != nil {
+		log.Fatalf("Failed to set up runner network policy: %v", err)
+	}
+
+	// Maintain the headless Service behind stable runner DNS names, or remove a stale one
+	if err := k8sClient.SetupRunnerService(context.Background()); err != nil {
+		log.Fatalf("Failed to set up headless runner service: %v", err)
+	}
+
+	// Runner usage needs metrics-server; without it the usage RPCs return Unimplemented
+	k8sClient.DetectMetrics()
+
+	// Events on runner pods explain grad's actions in kubectl describe; they need RBAC to create events
+	k8sClient.EnableEvents(context.Background())
+
+	// Resolve the configured images in their registries, so that an image that can't be pulled
+	// degrades readiness right away instead of failing the first runner
+	var imageCheck *service.ImageCheck
+	if !skipImageCheck {
+		registry, err := service.LoadRegistryClient(config.ImageCheckPullSecretFile)
+		if err != nil {
+			log.Fatalf("Failed to set up the image check: %v", err)
+		}
+		imageCheck = service.NewImageCheck(registry)
+		checkImages := func(images service.RunnerImages) {
+			// Without S3 workspaces the s3fs image is never pulled
+			if config.Kubernetes.DisabledFeatures.Disabled(service.FeatureS3Workspace) {
+				images.S3FS = ""
+			}
+			imageCheck.Start(images)
+		}
+		checkImages(config.Kubernetes.Images())
+		config.Kubernetes.OnImagesChange(checkImages)
+	}
+
+	// Reported by /health and GetServerInfo
+	serverInfo := service.NewServerInfo(build, k8sClient.ServerVersion, service.DefaultKubernetesVersionTTL)
 
 	// Initialize activity tracker for runner cleanup
 	activityTracker := service.NewActivityTracker()
 
 	// Initialize runner service
-	runnerService := service.NewRunnerService(k8sClient, activityTracker)
+	runnerService := service.NewRunnerService(k8sClient, activityTracker, config.Durations, m)
 
 	// Initialize execute service
-	executeService := service.NewExecuteService(runnerService)
+	executeService := service.NewExecuteService(runnerService, config.Durations, config.Kubernetes.DisabledFeatures)
 
 	// Initialize cleanup service for inactive runners
-	cleanupService := service.NewCleanupService(runnerService, activityTracker)
+	cleanupService := service.NewCleanupService(runnerService, activityTracker, k8sClient, config.Durations, config.GroupIdleTimeouts)
 
 	// Create gRPC server with service dependencies
-	grpcSrv := grpcserver.NewServer(runnerService, executeService)
+	grpcSrv := grpcserver.NewServer(runnerService, executeService, config.EnvLimits, config.CommandLimits, config.StreamLimits, serverInfo, m)
+	grpcSrv.SetDisabledFeatures(config.Kubernetes.DisabledFeatures)
+	adminSrv := grpcserver.NewAdminServer(config.Kubernetes, config.AdminToken, logBuffer, reloader)
+
+	// A reload only changes these settings; the others keep the values grad started with
+	reloader.OnReload(func(reloaded *service.Config, reload *service.ConfigReload) {
+		cleanupService.SetIdleTimeouts(reloaded.Durations, reloaded.GroupIdleTimeouts)
+		config.Kubernetes.SetAllowlists(reloaded.Kubernetes.Allowlists())
+		grpcSrv.SetLimits(reloaded.EnvLimits, reloaded.CommandLimits)
+		// Setting rate limits refills every bucket and replaces limits set through the admin
+		// API, so they are only set when they changed
+		if reload.Changed("RATE_LIMIT_") {
+			if err := rateLimiter.SetLimits(*reloaded.RateLimits); err != nil {
+				slog.Error("Failed to apply reloaded rate limits", "error", err)
+			}
+		}
+	})
 
 	// Start HTTP server
 	go func() {
 		defer wg.Done()
-		runHTTPServer()
+		runHTTPServer(k8sClient, imageCheck, serverInfo, rateLimiter, config, m)
 	}()
 
 	// Start gRPC server
 	go func() {
 		defer wg.Done()
-		runGRPCServer(grpcSrv)
+		runGRPCServer(grpcSrv, adminSrv, rateLimiter, config.StreamLimits, m)
 	}()
 
-	// Start cleanup service
-	ctx, cancelCleanup := context.WithCancel(context.Background())
+	// Start runner service background work and cleanup service
+	ctx, cancelServices := context.WithCancel(context.Background())
+	runnerService.Start(ctx)
 	go func() {
 		defer wg.Done()
 		cleanupService.Start(ctx)
 	}()
 
-	// Wait for interrupt signal
+	// Wait for interrupt signal, reloading the configuration on SIGHUP meanwhile
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		slog.Info("Reloading configuration on SIGHUP")
+		// Reload logs what changed, or why the configuration was kept
+		_, _ = reloader.Reload()
+	}
 
 	slog.Info("Shutting down grad services...")
 
-	// Stop cleanup service first
-	cancelCleanup()
+	// Stop cleanup service and runner background work first
+	cancelServices()
 	cleanupService.Stop()
+	runnerService.Stop()
+	k8sClient.StopEvents()
 
 	// Graceful shutdown context
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Durations.ShutdownGrace)
 	defer cancel()
 
 	// Shutdown both servers (we'll add this logic)
@@ -165,8 +236,43 @@ func runServers() {
 	slog.Info("grad services stopped")
 }
 
-func runHTTPServer() {
+// logDisabledFeatures logs the features turned off with --disable-features and what each
+// one stops grad from doing
+func logDisabledFeatures(features service.DisabledFeatures) {
+	if len(features) == 0 {
+		return
+	}
+	slog.Info("Disabled features", "features", features.List())
+	if features.Disabled(service.FeatureS3Workspace) {
+		slog.Info("S3 workspaces disabled: requests with a workspace are refused and runners get no s3fs sidecar")
+	}
+	if features.Disabled(service.FeatureWebhooks) {
+		slog.Info("Webhooks disabled: no webhook notifications are sent")
+	}
+	if features.Disabled(service.FeatureTracing) {
+		slog.Info("Tracing disabled: commands don't get the TRACEPARENT of grad's span")
+	}
+}
+
+func runHTTPServer(k8sClient *service.KubernetesClient, imageCheck *service.ImageCheck, serverInfo *service.ServerInfo, rateLimiter *grpcserver.RateLimiter, config *service.Config, m *metrics.Metrics) {
 	gin.SetMode(gin.ReleaseMode)
+	r := newHTTPRouter(k8sClient, imageCheck, serverInfo, rateLimiter, config, m, promhttp.Handler())
+
+	server := &http.Server{
+		Addr:    ":" + httpPort,
+		Handler: r,
+	}
+
+	slog.Info("HTTP server starting", "port", httpPort)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Error("HTTP server error", "error", err)
+	}
+}
+
+// newHTTPRouter registers the HTTP endpoints, including the optional APIs enabled by flags.
+// Requests are recorded in m, and metricsHandler serves the registry m is registered with.
+// imageCheck is nil if skipped. Routes added here must also be described in buildOpenAPIDocument.
+func newHTTPRouter(k8sClient *service.KubernetesClient, imageCheck *service.ImageCheck, serverInfo *service.ServerInfo, rateLimiter *grpcserver.RateLimiter, config *service.Config, m *metrics.Metrics, metricsHandler http.Handler) *gin.Engine {
 	r := gin.New()
 
 	// Add middleware for logging and recovery
@@ -174,49 +280,80 @@ func runHTTPServer() {
 	r.Use(gin.Recovery())
 
 	// Add Prometheus metrics middleware
-	r.Use(prometheusMiddleware())
+	r.Use(prometheusMiddleware(m))
 
-	// Health check endpoint
+	// Health check endpoint, also reporting the build and Kubernetes version
 	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		c.JSON(http.StatusOK, newHealthBody(serverInfo.Status(c.Request.Context())))
 	})
 
-	// Readiness check endpoint
+	// Readiness check endpoint; grad can't manage runners without the Kubernetes API. With an
+	// image that can't be pulled it still serves everything but new runners, so it is only degraded.
 	r.GET("/ready", func(c *gin.Context) {
+		if err := k8sClient.CheckAPIAccess(c.Request.Context()); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": err.Error()})
+			return
+		}
+		if problem := imageCheck.Problem(); problem != "" {
+			c.JSON(http.StatusOK, gin.H{"status": "degraded", "error": problem})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{"status": "ready"})
 	})
 
 	// Prometheus metrics endpoint
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/metrics", gin.WrapH(metricsHandler))
 
-	server := &http.Server{
-		Addr:    ":" + httpPort,
-		Handler: r,
+	if enableAdminAPI {
+		if config.AdminToken == "" {
+			slog.Warn("The admin API rejects every request without GRAD_ADMIN_TOKEN")
+		}
+		registerAdminRoutes(r, rateLimiter, config.Kubernetes, config.AdminToken)
 	}
 
-	slog.Info("HTTP server starting", "port", httpPort)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		slog.Error("HTTP server error", "error", err)
-	}
+	registerAPIDocsRoutes(r, buildOpenAPIDocument(enableAdminAPI, enableAPIDocs), enableAPIDocs)
+
+	return r
 }
 
-func runGRPCServer(srv *grpcserver.Server) {
-	lis, err := net.Listen("tcp", ":"+grpcPort)
+func runGRPCServer(srv *grpcserver.Server, adminSrv *grpcserver.AdminServer, rateLimiter *grpcserver.RateLimiter, streamLimits *service.StreamLimits, m *metrics.Metrics) {
+	addr := grpcAddr
+	if addr == "" {
+		addr = ":" + grpcPort
+	}
+
+	socketMode, err := parseSocketMode(grpcSocketMode)
 	if err != nil {
-		log.Fatalf("Failed to listen on port %s: %v", grpcPort, err)
+		log.Fatalf("Failed to start gRPC server: %v", err)
+	}
+
+	lis, err := listenGRPC(addr, socketMode)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", addr, err)
+	}
+
+	grpcServer := newGRPCServer(srv, adminSrv, rateLimiter, streamLimits, m)
+	slog.Info("gRPC server starting", "addr", addr)
+	if err := grpcServer.Serve(lis); err != nil {
+		slog.Error("gRPC server error", "error", err)
 	}
+}
 
-	grpcServer := grpc.NewServer()
+// newGRPCServer registers grad's services behind the metrics and rate limit interceptors.
+// Requests rejected by the rate limiter are recorded too, as ResourceExhausted.
+func newGRPCServer(srv *grpcserver.Server, adminSrv *grpcserver.AdminServer, rateLimiter *grpcserver.RateLimiter, streamLimits *service.StreamLimits, m *metrics.Metrics) *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.MaxConcurrentStreams(uint32(streamLimits.MaxConcurrentStreams)),
+		grpc.ChainUnaryInterceptor(grpcserver.MetricsUnaryInterceptor(m), rateLimiter.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(grpcserver.MetricsStreamInterceptor(m), rateLimiter.StreamInterceptor()),
+	)
 	gradv1.RegisterRunnerServiceServer(grpcServer, srv)
 	gradv1.RegisterExecuteServiceServer(grpcServer, srv)
+	gradv1.RegisterAdminServiceServer(grpcServer, adminSrv)
 
 	// Enable reflection for grpcurl and other tools
 	reflection.Register(grpcServer)
-
-	slog.Info("gRPC server starting", "port", grpcPort)
-	if err := grpcServer.Serve(lis); err != nil {
-		slog.Error("gRPC server error", "error", err)
-	}
+	return grpcServer
 }
 
 func shutdownServers(ctx context.Context) {
@@ -226,7 +363,8 @@ func shutdownServers(ctx context.Context) {
 	slog.Info("Server shutdown logic would be implemented here")
 }
 
-func prometheusMiddleware() gin.HandlerFunc {
+// prometheusMiddleware records every HTTP request in m
+func prometheusMiddleware(m *metrics.Metrics) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
@@ -235,8 +373,8 @@ func prometheusMiddleware() gin.HandlerFunc {
 		duration := time.Since(start).Seconds()
 		status := fmt.Sprintf("%d", c.Writer.Status())
 
-		httpRequestsTotal.WithLabelValues(c.Request.Method, c.Request.URL.Path, status).Inc()
-		httpRequestDuration.WithLabelValues(c.Request.Method, c.Request.URL.Path).Observe(duration)
+		m.HTTPRequestsTotal.WithLabelValues(c.Request.Method, c.Request.URL.Path, status).Inc()
+		m.HTTPRequestDuration.WithLabelValues(c.Request.Method, c.Request.URL.Path).Observe(duration)
 	}
 }
 
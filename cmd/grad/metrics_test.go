@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	grpcserver "github.com/strrl/gra/internal/grad/grpc"
+	"github.com/strrl/gra/internal/grad/metrics"
+	"github.com/strrl/gra/internal/grad/service"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// newTestMetrics returns metrics registered with a new registry and a /metrics handler serving it
+func newTestMetrics(t *testing.T) (*metrics.Metrics, http.Handler) {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	m, err := metrics.New(registry)
+	if err != nil {
+		t.Fatalf("Failed to create metrics: %v", err)
+	}
+	return m, promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// testServer is an HTTP router and a gRPC server over a Unix socket sharing one registry
+type testServer struct {
+	router *gin.Engine
+	client *gradclient.Client
+}
+
+// startTestServer starts grad's HTTP router and gRPC server against a registry of their own
+func startTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	m, metricsHandler := newTestMetrics(t)
+	limiter, err := grpcserver.NewRateLimiter(*service.DefaultRateLimits(), m)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	config := &service.Config{Kubernetes: service.DefaultKubernetesConfig()}
+	info := service.NewServerInfo(service.ReadBuildInfo(), nil, time.Minute)
+	router := newHTTPRouter(nil, nil, info, limiter, config, m, metricsHandler)
+
+	path := tempSocketPath(t)
+	lis, err := listenGRPC("unix://"+path, 0600)
+	if err != nil {
+		t.Fatalf("listenGRPC failed: %v", err)
+	}
+	srv := grpcserver.NewServer(&memoryRunnerService{}, nil, nil, nil, nil, nil, m)
+	grpcServer := newGRPCServer(srv, grpcserver.NewAdminServer(config.Kubernetes, "", nil, nil), limiter, service.DefaultStreamLimits(), m)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	client, err := gradclient.NewClient(&gradclient.Config{
+		ServerAddress: "unix://" + path,
+		DialTimeout:   2 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect over unix socket: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return &testServer{router: router, client: client}
+}
+
+// get serves a GET request of path and returns the response body
+func (s *testServer) get(t *testing.T, path string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from %s, got %d: %s", path, rec.Code, rec.Body)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestServersWithSeparateRegistries(t *testing.T) {
+	first := startTestServer(t)
+	second := startTestServer(t)
+
+	first.get(t, "/health")
+	first.get(t, "/health")
+	second.get(t, "/health")
+	if _, err := first.client.RunnerService().ListRunners(context.Background(), &gradv1.ListRunnersRequest{}); err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+
+	listRunners := `grpc_requests_total{method="` + gradv1.RunnerService_ListRunners_FullMethodName + `",status="OK"} 1`
+	firstMetrics := first.get(t, "/metrics")
+	for _, line := range []string{`http_requests_total{endpoint="/health",method="GET",status="200"} 2`, listRunners} {
+		if !strings.Contains(firstMetrics, line) {
+			t.Errorf("Expected the first server to report %s, got:\n%s", line, firstMetrics)
+		}
+	}
+
+	secondMetrics := second.get(t, "/metrics")
+	if line := `http_requests_total{endpoint="/health",method="GET",status="200"} 1`; !strings.Contains(secondMetrics, line) {
+		t.Errorf("Expected the second server to report %s, got:\n%s", line, secondMetrics)
+	}
+	if strings.Contains(secondMetrics, "grpc_requests_total{") {
+		t.Errorf("Expected the second server to report no gRPC requests, got:\n%s", secondMetrics)
+	}
+}
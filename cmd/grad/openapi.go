@@ -0,0 +1,260 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIDocument is the subset of an OpenAPI 3.0 document needed to describe grad's HTTP API
+type openAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       openAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components openAPIComponents                      `json:"components,omitempty"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPIOperation struct {
+	Summary     string                     `json:"summary"`
+	OperationID string                     `json:"operationId"`
+	Tags        []string                   `json:"tags,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas,omitempty"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `json:"$ref,omitempty"`
+	Type       string                    `json:"type,omitempty"`
+	Format     string                    `json:"format,omitempty"`
+	Properties map[string]*openAPISchema `json:"properties,omitempty"`
+}
+
+// jsonContent describes a JSON body with schema
+func jsonContent(schema *openAPISchema) map[string]openAPIMediaType {
+	return map[string]openAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// schemaRef refers to a schema under components
+func schemaRef(name string) *openAPISchema {
+	return &openAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+// objectSchema derives the schema of a JSON-encoded struct from its json tags, so it can't
+// drift from the type the handlers bind (pure function)
+func objectSchema(t reflect.Type) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		switch fieldType.Kind() {
+		case reflect.Float32, reflect.Float64:
+			schema.Properties[name] = &openAPISchema{Type: "number", Format: "double"}
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			schema.Properties[name] = &openAPISchema{Type: "integer"}
+		case reflect.Bool:
+			schema.Properties[name] = &openAPISchema{Type: "boolean"}
+		default:
+			schema.Properties[name] = &openAPISchema{Type: "string"}
+		}
+	}
+	return schema
+}
+
+// buildOpenAPIDocument describes the HTTP routes newHTTPRouter registers with the given
+// optional APIs enabled. gRPC methods aren't part of it; use reflection for those.
+func buildOpenAPIDocument(adminAPI, apiDocs bool) *openAPIDocument {
+	healthSchema := objectSchema(reflect.TypeOf(healthBody{}))
+	statusSchema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{
+		"status": {Type: "string"},
+		"error":  {Type: "string"},
+	}}
+	errorSchema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{
+		"error": {Type: "string"},
+	}}
+
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       "grad HTTP API",
+			Description: "Health, metrics and administration endpoints of grad. Runners are managed through the gRPC API.",
+			Version:     "v1",
+		},
+		Paths: map[string]map[string]openAPIOperation{
+			"/health": {"get": {
+				Summary:     "Liveness check with build info, uptime and the Kubernetes server version",
+				OperationID: "getHealth",
+				Tags:        []string{"health"},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: "grad is running", Content: jsonContent(healthSchema)},
+				},
+			}},
+			"/ready": {"get": {
+				Summary:     "Readiness check, failing while the Kubernetes API is unreachable and degraded while a configured image can't be pulled",
+				OperationID: "getReady",
+				Tags:        []string{"health"},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: "grad can manage runners; status is degraded, with the error, if the runner or s3fs image can't be resolved in its registry", Content: jsonContent(statusSchema)},
+					"503": {Description: "The Kubernetes API is unreachable", Content: jsonContent(statusSchema)},
+				},
+			}},
+			"/metrics": {"get": {
+				Summary:     "Prometheus metrics",
+				OperationID: "getMetrics",
+				Tags:        []string{"metrics"},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: "Metrics in the Prometheus text format", Content: map[string]openAPIMediaType{
+						"text/plain": {Schema: &openAPISchema{Type: "string"}},
+					}},
+				},
+			}},
+			"/openapi.json": {"get": {
+				Summary:     "This document",
+				OperationID: "getOpenAPI",
+				Tags:        []string{"docs"},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: "OpenAPI 3 document", Content: jsonContent(&openAPISchema{Type: "object"})},
+				},
+			}},
+		},
+	}
+
+	if apiDocs {
+		doc.Paths["/docs"] = map[string]openAPIOperation{"get": {
+			Summary:     "Swagger UI for this document",
+			OperationID: "getDocs",
+			Tags:        []string{"docs"},
+			Responses: map[string]openAPIResponse{
+				"200": {Description: "HTML page", Content: map[string]openAPIMediaType{
+					"text/html": {Schema: &openAPISchema{Type: "string"}},
+				}},
+			},
+		}}
+	}
+
+	if adminAPI {
+		doc.Components.Schemas = map[string]*openAPISchema{
+			"RateLimits":   objectSchema(reflect.TypeOf(rateLimitsBody{})),
+			"RunnerImage":  objectSchema(reflect.TypeOf(runnerImageBody{})),
+			"RunnerImages": objectSchema(reflect.TypeOf(runnerImagesBody{})),
+			"Error":        errorSchema,
+		}
+		unauthorized := openAPIResponse{Description: "Missing or invalid admin token", Content: jsonContent(schemaRef("Error"))}
+		disabled := openAPIResponse{Description: "Admin API disabled, GRAD_ADMIN_TOKEN is not set", Content: jsonContent(schemaRef("Error"))}
+		doc.Paths["/admin/rate-limits"] = map[string]openAPIOperation{
+			"get": {
+				Summary:     "Current gRPC rate limits",
+				OperationID: "getRateLimits",
+				Tags:        []string{"admin"},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: "Rate limits in effect", Content: jsonContent(schemaRef("RateLimits"))},
+					"401": unauthorized,
+					"403": disabled,
+				},
+			},
+			"put": {
+				Summary:     "Change gRPC rate limits; fields left out keep their value",
+				OperationID: "putRateLimits",
+				Tags:        []string{"admin"},
+				RequestBody: &openAPIRequestBody{Required: true, Content: jsonContent(schemaRef("RateLimits"))},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: "Rate limits now in effect", Content: jsonContent(schemaRef("RateLimits"))},
+					"400": {Description: "Malformed body or invalid limits", Content: jsonContent(schemaRef("Error"))},
+					"401": unauthorized,
+					"403": disabled,
+				},
+			},
+		}
+		doc.Paths["/admin/runner-image"] = map[string]openAPIOperation{
+			"get": {
+				Summary:     "Images of runners created from now on",
+				OperationID: "getRunnerImage",
+				Tags:        []string{"admin"},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: "Runner and s3fs sidecar images", Content: jsonContent(schemaRef("RunnerImages"))},
+					"401": unauthorized,
+					"403": disabled,
+				},
+			},
+			"put": {
+				Summary:     "Change the images of new runners, or revert the last change; existing runners keep theirs",
+				OperationID: "putRunnerImage",
+				Tags:        []string{"admin"},
+				RequestBody: &openAPIRequestBody{Required: true, Content: jsonContent(schemaRef("RunnerImage"))},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: "Images now in use and the ones they replaced", Content: jsonContent(schemaRef("RunnerImages"))},
+					"400": {Description: "Malformed body or invalid image reference", Content: jsonContent(schemaRef("Error"))},
+					"401": unauthorized,
+					"403": disabled,
+					"409": {Description: "Nothing to revert", Content: jsonContent(schemaRef("Error"))},
+				},
+			},
+		}
+	}
+
+	return doc
+}
+
+// swaggerUIPage loads Swagger UI from unpkg and points it at /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>grad API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// registerAPIDocsRoutes serves doc at /openapi.json and, if apiDocs is set, Swagger UI at /docs
+func registerAPIDocsRoutes(r gin.IRouter, doc *openAPIDocument, apiDocs bool) {
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	})
+
+	if apiDocs {
+		r.GET("/docs", func(c *gin.Context) {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+		})
+	}
+}
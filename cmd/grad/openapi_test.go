@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	grpcserver "github.com/strrl/gra/internal/grad/grpc"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// ginParam matches gin path parameters such as :id
+var ginParam = regexp.MustCompile(`:([^/]+)`)
+
+// newTestRouter builds the HTTP router with the optional APIs set as given
+func newTestRouter(t *testing.T, adminAPI, apiDocs bool) *gin.Engine {
+	return newTestRouterWithInfo(t, adminAPI, apiDocs, service.NewServerInfo(service.ReadBuildInfo(), nil, time.Minute))
+}
+
+// newTestRouterWithInfo builds the HTTP router reporting info from /health
+func newTestRouterWithInfo(t *testing.T, adminAPI, apiDocs bool, info *service.ServerInfo) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	limiter, err := grpcserver.NewRateLimiter(*service.DefaultRateLimits(), nil)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+
+	previousAdminAPI, previousAPIDocs := enableAdminAPI, enableAPIDocs
+	enableAdminAPI, enableAPIDocs = adminAPI, apiDocs
+	t.Cleanup(func() { enableAdminAPI, enableAPIDocs = previousAdminAPI, previousAPIDocs })
+
+	m, metricsHandler := newTestMetrics(t)
+	return newHTTPRouter(nil, nil, info, limiter, &service.Config{Kubernetes: service.DefaultKubernetesConfig()}, m, metricsHandler)
+}
+
+func TestOpenAPIDocumentCoversRoutes(t *testing.T) {
+	for _, adminAPI := range []bool{false, true} {
+		for _, apiDocs := range []bool{false, true} {
+			t.Run(fmt.Sprintf("admin=%v,docs=%v", adminAPI, apiDocs), func(t *testing.T) {
+				r := newTestRouter(t, adminAPI, apiDocs)
+				doc := buildOpenAPIDocument(adminAPI, apiDocs)
+
+				registered := make(map[string]bool)
+				for _, route := range r.Routes() {
+					path := ginParam.ReplaceAllString(route.Path, "{$1}")
+					method := strings.ToLower(route.Method)
+					registered[method+" "+path] = true
+					if _, ok := doc.Paths[path][method]; !ok {
+						t.Errorf("Route %s %s is missing from the OpenAPI document", route.Method, route.Path)
+					}
+				}
+
+				for path, operations := range doc.Paths {
+					for method := range operations {
+						if !registered[method+" "+path] {
+							t.Errorf("OpenAPI document describes %s %s, which isn't registered", strings.ToUpper(method), path)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestOpenAPIEndpoint(t *testing.T) {
+	r := newTestRouter(t, true, false)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var doc struct {
+		OpenAPI    string                     `json:"openapi"`
+		Paths      map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]struct {
+					Type string `json:"type"`
+				} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("Expected OpenAPI 3.0.3, got %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/admin/rate-limits"]; !ok {
+		t.Errorf("Expected the admin API to be described, got paths %v", doc.Paths)
+	}
+	properties := doc.Components.Schemas["RateLimits"].Properties
+	if properties["mutating_rps"].Type != "number" || properties["mutating_burst"].Type != "integer" {
+		t.Errorf("Expected rate limit properties from rateLimitsBody, got %+v", properties)
+	}
+
+	// Swagger UI is only served with --enable-api-docs
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for /docs without --enable-api-docs, got %d", rec.Code)
+	}
+
+	r = newTestRouter(t, false, true)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `url: "/openapi.json"`) {
+		t.Errorf("Expected Swagger UI for /openapi.json, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,8 @@
+#!/bin/sh
+# Runner pods start the entrypoint with `sleep infinity`, which busybox's sleep doesn't
+# understand, so the stub just idles until the pod is deleted
+trap 'exit 0' TERM INT
+while :; do
+    sleep 3600 &
+    wait $!
+done
@@ -28,6 +28,16 @@ if [ -n "$PUBLIC_KEY" ]; then
     chmod 600 /root/.ssh/authorized_keys
 fi
 
+# Trust extra CA certificates mounted by grad (e.g. for a TLS-intercepting proxy)
+if [ -n "$GRAD_EXTRA_CA_DIR" ] && [ -d "$GRAD_EXTRA_CA_DIR" ]; then
+    mkdir -p /usr/local/share/ca-certificates/grad
+    for cert in "$GRAD_EXTRA_CA_DIR"/*; do
+        [ -f "$cert" ] || continue
+        cp "$cert" "/usr/local/share/ca-certificates/grad/$(basename "$cert" .pem).crt"
+    done
+    update-ca-certificates
+fi
+
 # Set proper ownership for workspace
 chown runner:runner /workspace
 
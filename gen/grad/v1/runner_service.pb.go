@@ -21,6 +21,124 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// TruncatePolicy decides what happens to a command whose output passes its limit
+type TruncatePolicy int32
+
+const (
+	// Same as TRUNCATE_POLICY_KILL
+	TruncatePolicy_TRUNCATE_POLICY_UNSPECIFIED TruncatePolicy = 0
+	// Terminate the command; it finishes with EXIT_REASON_OUTPUT_LIMIT
+	TruncatePolicy_TRUNCATE_POLICY_KILL TruncatePolicy = 1
+	// Let the command run to completion, discarding the output beyond the limit
+	TruncatePolicy_TRUNCATE_POLICY_DISCARD TruncatePolicy = 2
+)
+
+// Enum value maps for TruncatePolicy.
+var (
+	TruncatePolicy_name = map[int32]string{
+		0: "TRUNCATE_POLICY_UNSPECIFIED",
+		1: "TRUNCATE_POLICY_KILL",
+		2: "TRUNCATE_POLICY_DISCARD",
+	}
+	TruncatePolicy_value = map[string]int32{
+		"TRUNCATE_POLICY_UNSPECIFIED": 0,
+		"TRUNCATE_POLICY_KILL":        1,
+		"TRUNCATE_POLICY_DISCARD":     2,
+	}
+)
+
+func (x TruncatePolicy) Enum() *TruncatePolicy {
+	p := new(TruncatePolicy)
+	*p = x
+	return p
+}
+
+func (x TruncatePolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TruncatePolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_grad_v1_runner_service_proto_enumTypes[0].Descriptor()
+}
+
+func (TruncatePolicy) Type() protoreflect.EnumType {
+	return &file_grad_v1_runner_service_proto_enumTypes[0]
+}
+
+func (x TruncatePolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TruncatePolicy.Descriptor instead.
+func (TruncatePolicy) EnumDescriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{0}
+}
+
+// ExitReason describes how a command finished
+type ExitReason int32
+
+const (
+	ExitReason_EXIT_REASON_UNSPECIFIED ExitReason = 0
+	// The command exited on its own; exit_code is its exit status
+	ExitReason_EXIT_REASON_EXITED ExitReason = 1
+	// The command was terminated by a signal; exit_code is 128 + the signal number
+	ExitReason_EXIT_REASON_SIGNALED ExitReason = 2
+	// The request timeout elapsed; exit_code is 124
+	ExitReason_EXIT_REASON_TIMEOUT ExitReason = 3
+	// The client cancelled the request; exit_code is 130
+	ExitReason_EXIT_REASON_CANCELLED ExitReason = 4
+	// The command was terminated for passing its output limit under TRUNCATE_POLICY_KILL;
+	// exit_code is 141, as for a command whose reader went away (SIGPIPE)
+	ExitReason_EXIT_REASON_OUTPUT_LIMIT ExitReason = 5
+)
+
+// Enum value maps for ExitReason.
+var (
+	ExitReason_name = map[int32]string{
+		0: "EXIT_REASON_UNSPECIFIED",
+		1: "EXIT_REASON_EXITED",
+		2: "EXIT_REASON_SIGNALED",
+		3: "EXIT_REASON_TIMEOUT",
+		4: "EXIT_REASON_CANCELLED",
+		5: "EXIT_REASON_OUTPUT_LIMIT",
+	}
+	ExitReason_value = map[string]int32{
+		"EXIT_REASON_UNSPECIFIED":  0,
+		"EXIT_REASON_EXITED":       1,
+		"EXIT_REASON_SIGNALED":     2,
+		"EXIT_REASON_TIMEOUT":      3,
+		"EXIT_REASON_CANCELLED":    4,
+		"EXIT_REASON_OUTPUT_LIMIT": 5,
+	}
+)
+
+func (x ExitReason) Enum() *ExitReason {
+	p := new(ExitReason)
+	*p = x
+	return p
+}
+
+func (x ExitReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExitReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_grad_v1_runner_service_proto_enumTypes[1].Descriptor()
+}
+
+func (ExitReason) Type() protoreflect.EnumType {
+	return &file_grad_v1_runner_service_proto_enumTypes[1]
+}
+
+func (x ExitReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ExitReason.Descriptor instead.
+func (ExitReason) EnumDescriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{1}
+}
+
 // StreamType indicates the type of streaming data
 type StreamType int32
 
@@ -29,6 +147,9 @@ const (
 	StreamType_STREAM_TYPE_STDOUT      StreamType = 1
 	StreamType_STREAM_TYPE_STDERR      StreamType = 2
 	StreamType_STREAM_TYPE_EXIT        StreamType = 3
+	// First message of every command, sent once its runner is resolved and before any
+	// output, carrying exec_id, runner_id, shell and working_dir
+	StreamType_STREAM_TYPE_STARTED StreamType = 4
 )
 
 // Enum value maps for StreamType.
@@ -38,12 +159,14 @@ var (
 		1: "STREAM_TYPE_STDOUT",
 		2: "STREAM_TYPE_STDERR",
 		3: "STREAM_TYPE_EXIT",
+		4: "STREAM_TYPE_STARTED",
 	}
 	StreamType_value = map[string]int32{
 		"STREAM_TYPE_UNSPECIFIED": 0,
 		"STREAM_TYPE_STDOUT":      1,
 		"STREAM_TYPE_STDERR":      2,
 		"STREAM_TYPE_EXIT":        3,
+		"STREAM_TYPE_STARTED":     4,
 	}
 )
 
@@ -58,11 +181,11 @@ func (x StreamType) String() string {
 }
 
 func (StreamType) Descriptor() protoreflect.EnumDescriptor {
-	return file_grad_v1_runner_service_proto_enumTypes[0].Descriptor()
+	return file_grad_v1_runner_service_proto_enumTypes[2].Descriptor()
 }
 
 func (StreamType) Type() protoreflect.EnumType {
-	return &file_grad_v1_runner_service_proto_enumTypes[0]
+	return &file_grad_v1_runner_service_proto_enumTypes[2]
 }
 
 func (x StreamType) Number() protoreflect.EnumNumber {
@@ -71,19 +194,132 @@ func (x StreamType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use StreamType.Descriptor instead.
 func (StreamType) EnumDescriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{0}
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{2}
+}
+
+// ConditionStatus says whether a runner condition holds
+type ConditionStatus int32
+
+const (
+	ConditionStatus_CONDITION_STATUS_UNSPECIFIED ConditionStatus = 0
+	ConditionStatus_CONDITION_STATUS_TRUE        ConditionStatus = 1
+	ConditionStatus_CONDITION_STATUS_FALSE       ConditionStatus = 2
+	// grad can't tell yet, e.g. whether the image was pulled before the pod is scheduled
+	ConditionStatus_CONDITION_STATUS_UNKNOWN ConditionStatus = 3
+)
+
+// Enum value maps for ConditionStatus.
+var (
+	ConditionStatus_name = map[int32]string{
+		0: "CONDITION_STATUS_UNSPECIFIED",
+		1: "CONDITION_STATUS_TRUE",
+		2: "CONDITION_STATUS_FALSE",
+		3: "CONDITION_STATUS_UNKNOWN",
+	}
+	ConditionStatus_value = map[string]int32{
+		"CONDITION_STATUS_UNSPECIFIED": 0,
+		"CONDITION_STATUS_TRUE":        1,
+		"CONDITION_STATUS_FALSE":       2,
+		"CONDITION_STATUS_UNKNOWN":     3,
+	}
+)
+
+func (x ConditionStatus) Enum() *ConditionStatus {
+	p := new(ConditionStatus)
+	*p = x
+	return p
+}
+
+func (x ConditionStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConditionStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_grad_v1_runner_service_proto_enumTypes[3].Descriptor()
+}
+
+func (ConditionStatus) Type() protoreflect.EnumType {
+	return &file_grad_v1_runner_service_proto_enumTypes[3]
+}
+
+func (x ConditionStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ConditionStatus.Descriptor instead.
+func (ConditionStatus) EnumDescriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{3}
+}
+
+// SnapshotPhase is the step a workspace snapshot is at
+type SnapshotPhase int32
+
+const (
+	SnapshotPhase_SNAPSHOT_PHASE_UNSPECIFIED SnapshotPhase = 0
+	// The workspace is being archived in the runner
+	SnapshotPhase_SNAPSHOT_PHASE_ARCHIVING SnapshotPhase = 1
+	// The archive is being uploaded
+	SnapshotPhase_SNAPSHOT_PHASE_UPLOADING SnapshotPhase = 2
+	// The archive was uploaded; always the last message
+	SnapshotPhase_SNAPSHOT_PHASE_DONE SnapshotPhase = 3
+)
+
+// Enum value maps for SnapshotPhase.
+var (
+	SnapshotPhase_name = map[int32]string{
+		0: "SNAPSHOT_PHASE_UNSPECIFIED",
+		1: "SNAPSHOT_PHASE_ARCHIVING",
+		2: "SNAPSHOT_PHASE_UPLOADING",
+		3: "SNAPSHOT_PHASE_DONE",
+	}
+	SnapshotPhase_value = map[string]int32{
+		"SNAPSHOT_PHASE_UNSPECIFIED": 0,
+		"SNAPSHOT_PHASE_ARCHIVING":   1,
+		"SNAPSHOT_PHASE_UPLOADING":   2,
+		"SNAPSHOT_PHASE_DONE":        3,
+	}
+)
+
+func (x SnapshotPhase) Enum() *SnapshotPhase {
+	p := new(SnapshotPhase)
+	*p = x
+	return p
+}
+
+func (x SnapshotPhase) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SnapshotPhase) Descriptor() protoreflect.EnumDescriptor {
+	return file_grad_v1_runner_service_proto_enumTypes[4].Descriptor()
+}
+
+func (SnapshotPhase) Type() protoreflect.EnumType {
+	return &file_grad_v1_runner_service_proto_enumTypes[4]
+}
+
+func (x SnapshotPhase) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SnapshotPhase.Descriptor instead.
+func (SnapshotPhase) EnumDescriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{4}
 }
 
 // RunnerStatus represents the status of a runner
 type RunnerStatus int32
 
 const (
-	RunnerStatus_RUNNER_STATUS_UNSPECIFIED RunnerStatus = 0
-	RunnerStatus_RUNNER_STATUS_CREATING    RunnerStatus = 1
-	RunnerStatus_RUNNER_STATUS_RUNNING     RunnerStatus = 2
-	RunnerStatus_RUNNER_STATUS_STOPPING    RunnerStatus = 3
-	RunnerStatus_RUNNER_STATUS_STOPPED     RunnerStatus = 4
-	RunnerStatus_RUNNER_STATUS_ERROR       RunnerStatus = 5
+	RunnerStatus_RUNNER_STATUS_UNSPECIFIED   RunnerStatus = 0
+	RunnerStatus_RUNNER_STATUS_CREATING      RunnerStatus = 1
+	RunnerStatus_RUNNER_STATUS_RUNNING       RunnerStatus = 2
+	RunnerStatus_RUNNER_STATUS_STOPPING      RunnerStatus = 3
+	RunnerStatus_RUNNER_STATUS_STOPPED       RunnerStatus = 4
+	RunnerStatus_RUNNER_STATUS_ERROR         RunnerStatus = 5
+	RunnerStatus_RUNNER_STATUS_BOOTSTRAPPING RunnerStatus = 6
+	RunnerStatus_RUNNER_STATUS_DELETE_FAILED RunnerStatus = 7
+	RunnerStatus_RUNNER_STATUS_RESTARTING    RunnerStatus = 8
 )
 
 // Enum value maps for RunnerStatus.
@@ -95,14 +331,20 @@ var (
 		3: "RUNNER_STATUS_STOPPING",
 		4: "RUNNER_STATUS_STOPPED",
 		5: "RUNNER_STATUS_ERROR",
+		6: "RUNNER_STATUS_BOOTSTRAPPING",
+		7: "RUNNER_STATUS_DELETE_FAILED",
+		8: "RUNNER_STATUS_RESTARTING",
 	}
 	RunnerStatus_value = map[string]int32{
-		"RUNNER_STATUS_UNSPECIFIED": 0,
-		"RUNNER_STATUS_CREATING":    1,
-		"RUNNER_STATUS_RUNNING":     2,
-		"RUNNER_STATUS_STOPPING":    3,
-		"RUNNER_STATUS_STOPPED":     4,
-		"RUNNER_STATUS_ERROR":       5,
+		"RUNNER_STATUS_UNSPECIFIED":   0,
+		"RUNNER_STATUS_CREATING":      1,
+		"RUNNER_STATUS_RUNNING":       2,
+		"RUNNER_STATUS_STOPPING":      3,
+		"RUNNER_STATUS_STOPPED":       4,
+		"RUNNER_STATUS_ERROR":         5,
+		"RUNNER_STATUS_BOOTSTRAPPING": 6,
+		"RUNNER_STATUS_DELETE_FAILED": 7,
+		"RUNNER_STATUS_RESTARTING":    8,
 	}
 )
 
@@ -117,11 +359,11 @@ func (x RunnerStatus) String() string {
 }
 
 func (RunnerStatus) Descriptor() protoreflect.EnumDescriptor {
-	return file_grad_v1_runner_service_proto_enumTypes[1].Descriptor()
+	return file_grad_v1_runner_service_proto_enumTypes[5].Descriptor()
 }
 
 func (RunnerStatus) Type() protoreflect.EnumType {
-	return &file_grad_v1_runner_service_proto_enumTypes[1]
+	return &file_grad_v1_runner_service_proto_enumTypes[5]
 }
 
 func (x RunnerStatus) Number() protoreflect.EnumNumber {
@@ -130,7 +372,136 @@ func (x RunnerStatus) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use RunnerStatus.Descriptor instead.
 func (RunnerStatus) EnumDescriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{1}
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{5}
+}
+
+// RunnerErrorReason classifies why a runner entered the error state
+type RunnerErrorReason int32
+
+const (
+	RunnerErrorReason_RUNNER_ERROR_REASON_UNSPECIFIED RunnerErrorReason = 0
+	// The pod failed, e.g. it was evicted or its node was lost
+	RunnerErrorReason_RUNNER_ERROR_REASON_POD_FAILED RunnerErrorReason = 1
+	// The pod didn't become ready within the provisioning timeout
+	RunnerErrorReason_RUNNER_ERROR_REASON_PROVISION_TIMEOUT RunnerErrorReason = 2
+	// The bootstrap command failed
+	RunnerErrorReason_RUNNER_ERROR_REASON_BOOTSTRAP_FAILED RunnerErrorReason = 3
+	// A container image couldn't be pulled
+	RunnerErrorReason_RUNNER_ERROR_REASON_IMAGE_PULL RunnerErrorReason = 4
+	// A container was killed for exceeding its memory limit
+	RunnerErrorReason_RUNNER_ERROR_REASON_OOM RunnerErrorReason = 5
+	// The pod is in error for a reason grad doesn't recognize
+	RunnerErrorReason_RUNNER_ERROR_REASON_UNKNOWN RunnerErrorReason = 6
+	// The pod's node was shut down, e.g. a spot node that was reclaimed
+	RunnerErrorReason_RUNNER_ERROR_REASON_NODE_SHUTDOWN RunnerErrorReason = 7
+)
+
+// Enum value maps for RunnerErrorReason.
+var (
+	RunnerErrorReason_name = map[int32]string{
+		0: "RUNNER_ERROR_REASON_UNSPECIFIED",
+		1: "RUNNER_ERROR_REASON_POD_FAILED",
+		2: "RUNNER_ERROR_REASON_PROVISION_TIMEOUT",
+		3: "RUNNER_ERROR_REASON_BOOTSTRAP_FAILED",
+		4: "RUNNER_ERROR_REASON_IMAGE_PULL",
+		5: "RUNNER_ERROR_REASON_OOM",
+		6: "RUNNER_ERROR_REASON_UNKNOWN",
+		7: "RUNNER_ERROR_REASON_NODE_SHUTDOWN",
+	}
+	RunnerErrorReason_value = map[string]int32{
+		"RUNNER_ERROR_REASON_UNSPECIFIED":       0,
+		"RUNNER_ERROR_REASON_POD_FAILED":        1,
+		"RUNNER_ERROR_REASON_PROVISION_TIMEOUT": 2,
+		"RUNNER_ERROR_REASON_BOOTSTRAP_FAILED":  3,
+		"RUNNER_ERROR_REASON_IMAGE_PULL":        4,
+		"RUNNER_ERROR_REASON_OOM":               5,
+		"RUNNER_ERROR_REASON_UNKNOWN":           6,
+		"RUNNER_ERROR_REASON_NODE_SHUTDOWN":     7,
+	}
+)
+
+func (x RunnerErrorReason) Enum() *RunnerErrorReason {
+	p := new(RunnerErrorReason)
+	*p = x
+	return p
+}
+
+func (x RunnerErrorReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RunnerErrorReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_grad_v1_runner_service_proto_enumTypes[6].Descriptor()
+}
+
+func (RunnerErrorReason) Type() protoreflect.EnumType {
+	return &file_grad_v1_runner_service_proto_enumTypes[6]
+}
+
+func (x RunnerErrorReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RunnerErrorReason.Descriptor instead.
+func (RunnerErrorReason) EnumDescriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{6}
+}
+
+// WorkspaceMode is how a runner's S3 workspace is mounted into the runner container
+type WorkspaceMode int32
+
+const (
+	WorkspaceMode_WORKSPACE_MODE_UNSPECIFIED WorkspaceMode = 0
+	WorkspaceMode_WORKSPACE_MODE_READ_WRITE  WorkspaceMode = 1
+	// The workspace is mounted read-only, so writes to it fail
+	WorkspaceMode_WORKSPACE_MODE_READ_ONLY WorkspaceMode = 2
+	// s3fs mounts the bucket read-only, but the runner container's mount of it is writable, as
+	// for runners created before grad enforced read-only workspaces. Writes made while s3fs
+	// isn't mounted land in the pod and are lost.
+	WorkspaceMode_WORKSPACE_MODE_READ_ONLY_UNENFORCED WorkspaceMode = 3
+)
+
+// Enum value maps for WorkspaceMode.
+var (
+	WorkspaceMode_name = map[int32]string{
+		0: "WORKSPACE_MODE_UNSPECIFIED",
+		1: "WORKSPACE_MODE_READ_WRITE",
+		2: "WORKSPACE_MODE_READ_ONLY",
+		3: "WORKSPACE_MODE_READ_ONLY_UNENFORCED",
+	}
+	WorkspaceMode_value = map[string]int32{
+		"WORKSPACE_MODE_UNSPECIFIED":          0,
+		"WORKSPACE_MODE_READ_WRITE":           1,
+		"WORKSPACE_MODE_READ_ONLY":            2,
+		"WORKSPACE_MODE_READ_ONLY_UNENFORCED": 3,
+	}
+)
+
+func (x WorkspaceMode) Enum() *WorkspaceMode {
+	p := new(WorkspaceMode)
+	*p = x
+	return p
+}
+
+func (x WorkspaceMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WorkspaceMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_grad_v1_runner_service_proto_enumTypes[7].Descriptor()
+}
+
+func (WorkspaceMode) Type() protoreflect.EnumType {
+	return &file_grad_v1_runner_service_proto_enumTypes[7]
+}
+
+func (x WorkspaceMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WorkspaceMode.Descriptor instead.
+func (WorkspaceMode) EnumDescriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{7}
 }
 
 // CreateRunnerRequest defines the request to create a new runner
@@ -141,7 +512,50 @@ type CreateRunnerRequest struct {
 	// Environment variables to set in the runner
 	Env map[string]string `protobuf:"bytes,2,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// Workspace configuration for S3 mounting
-	Workspace     *WorkspaceConfig `protobuf:"bytes,3,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	Workspace *WorkspaceConfig `protobuf:"bytes,3,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	// Command executed once after the runner becomes ready (optional).
+	// The runner stays in BOOTSTRAPPING until it exits 0.
+	BootstrapCommand string `protobuf:"bytes,4,opt,name=bootstrap_command,json=bootstrapCommand,proto3" json:"bootstrap_command,omitempty"`
+	// Skip the server-configured HTTP proxy for this runner
+	DisableProxy bool `protobuf:"varint,5,opt,name=disable_proxy,json=disableProxy,proto3" json:"disable_proxy,omitempty"`
+	// ServiceAccount to run the runner as (optional, must be allowed by the server).
+	// Its API token is mounted unless disable_service_account_token is set.
+	ServiceAccount string `protobuf:"bytes,6,opt,name=service_account,json=serviceAccount,proto3" json:"service_account,omitempty"`
+	// Don't mount a Kubernetes API token into the runner
+	DisableServiceAccountToken bool `protobuf:"varint,7,opt,name=disable_service_account_token,json=disableServiceAccountToken,proto3" json:"disable_service_account_token,omitempty"`
+	// Shell for commands that don't set one, e.g. "zsh" (optional, defaults to the server config)
+	DefaultShell string `protobuf:"bytes,8,opt,name=default_shell,json=defaultShell,proto3" json:"default_shell,omitempty"`
+	// Absolute working directory for commands that don't set one (optional, defaults to the server config)
+	DefaultWorkdir string `protobuf:"bytes,9,opt,name=default_workdir,json=defaultWorkdir,proto3" json:"default_workdir,omitempty"`
+	// Free-form note on what the runner is for (optional, at most 1024 bytes)
+	Description string `protobuf:"bytes,10,opt,name=description,proto3" json:"description,omitempty"`
+	// Group the runner belongs to, for operating on related runners together (optional).
+	// Must be a valid Kubernetes label value.
+	Group string `protobuf:"bytes,11,opt,name=group,proto3" json:"group,omitempty"`
+	// Extra /etc/hosts entries for the runner, added to the server-configured ones (optional)
+	HostAliases []*HostAlias `protobuf:"bytes,12,rep,name=host_aliases,json=hostAliases,proto3" json:"host_aliases,omitempty"`
+	// Recreate the runner's pod with the same spec if it fails, e.g. when its node goes away
+	// (optional, defaults to the server config). Restarts are bounded by the server.
+	AutoRestart *bool `protobuf:"varint,13,opt,name=auto_restart,json=autoRestart,proto3,oneof" json:"auto_restart,omitempty"`
+	// Unix timestamp until which the runner is kept even when idle (optional, at most the
+	// server's RUNNER_MAX_RESERVATION from now). Idle cleanup counts from it once it has passed.
+	ReservedUntil int64 `protobuf:"varint,14,opt,name=reserved_until,json=reservedUntil,proto3" json:"reserved_until,omitempty"`
+	// PriorityClass for the runner's pod (optional, must be allowed by the server). Defaults to
+	// the server's RUNNER_PRIORITY_CLASS.
+	PriorityClassName string `protobuf:"bytes,15,opt,name=priority_class_name,json=priorityClassName,proto3" json:"priority_class_name,omitempty"`
+	// Don't check that the priority class exists, e.g. when grad may not read PriorityClasses.
+	// Kubernetes still rejects the pod if it doesn't.
+	SkipValidation bool `protobuf:"varint,16,opt,name=skip_validation,json=skipValidation,proto3" json:"skip_validation,omitempty"`
+	// CPU architecture the runner must run on, e.g. "arm64" (optional, must be one the server
+	// supports). Unset runners may land on any node.
+	Arch string `protobuf:"bytes,17,opt,name=arch,proto3" json:"arch,omitempty"`
+	// Protect the runner from deletion: DeleteRunner refuses it unless override_protection is
+	// set, and idle cleanup never deletes it
+	Protected bool `protobuf:"varint,18,opt,name=protected,proto3" json:"protected,omitempty"`
+	// Run the runner on the server's spot/preemptible nodes, with the tolerations and
+	// nodeSelector of its RUNNER_SPOT_* config (FAILED_PRECONDITION without one). Spot runners
+	// default to auto_restart, so one whose node is reclaimed comes back on another.
+	Spot          bool `protobuf:"varint,19,opt,name=spot,proto3" json:"spot,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -197,114 +611,300 @@ func (x *CreateRunnerRequest) GetWorkspace() *WorkspaceConfig {
 	return nil
 }
 
-// WorkspaceConfig defines S3 workspace configuration
-type WorkspaceConfig struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// S3 bucket name
-	Bucket string `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"`
-	// S3 endpoint URL (optional, defaults to AWS S3)
-	Endpoint string `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
-	// S3 path prefix within the bucket (optional)
-	Prefix string `protobuf:"bytes,3,opt,name=prefix,proto3" json:"prefix,omitempty"`
-	// AWS region (optional, defaults to us-east-1)
-	Region string `protobuf:"bytes,4,opt,name=region,proto3" json:"region,omitempty"`
-	// Read-only mount (optional, defaults to false)
-	ReadOnly      bool `protobuf:"varint,5,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *WorkspaceConfig) Reset() {
-	*x = WorkspaceConfig{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[1]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *CreateRunnerRequest) GetBootstrapCommand() string {
+	if x != nil {
+		return x.BootstrapCommand
+	}
+	return ""
 }
 
-func (x *WorkspaceConfig) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *CreateRunnerRequest) GetDisableProxy() bool {
+	if x != nil {
+		return x.DisableProxy
+	}
+	return false
 }
 
-func (*WorkspaceConfig) ProtoMessage() {}
-
-func (x *WorkspaceConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[1]
+func (x *CreateRunnerRequest) GetServiceAccount() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ServiceAccount
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use WorkspaceConfig.ProtoReflect.Descriptor instead.
-func (*WorkspaceConfig) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{1}
+func (x *CreateRunnerRequest) GetDisableServiceAccountToken() bool {
+	if x != nil {
+		return x.DisableServiceAccountToken
+	}
+	return false
 }
 
-func (x *WorkspaceConfig) GetBucket() string {
+func (x *CreateRunnerRequest) GetDefaultShell() string {
 	if x != nil {
-		return x.Bucket
+		return x.DefaultShell
 	}
 	return ""
 }
 
-func (x *WorkspaceConfig) GetEndpoint() string {
+func (x *CreateRunnerRequest) GetDefaultWorkdir() string {
 	if x != nil {
-		return x.Endpoint
+		return x.DefaultWorkdir
 	}
 	return ""
 }
 
-func (x *WorkspaceConfig) GetPrefix() string {
+func (x *CreateRunnerRequest) GetDescription() string {
 	if x != nil {
-		return x.Prefix
+		return x.Description
 	}
 	return ""
 }
 
-func (x *WorkspaceConfig) GetRegion() string {
+func (x *CreateRunnerRequest) GetGroup() string {
 	if x != nil {
-		return x.Region
+		return x.Group
 	}
 	return ""
 }
 
-func (x *WorkspaceConfig) GetReadOnly() bool {
+func (x *CreateRunnerRequest) GetHostAliases() []*HostAlias {
 	if x != nil {
-		return x.ReadOnly
+		return x.HostAliases
 	}
-	return false
+	return nil
 }
 
-// CreateRunnerResponse defines the response after creating a runner
-type CreateRunnerResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// The created runner details
-	Runner        *Runner `protobuf:"bytes,1,opt,name=runner,proto3" json:"runner,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *CreateRunnerRequest) GetAutoRestart() bool {
+	if x != nil && x.AutoRestart != nil {
+		return *x.AutoRestart
+	}
+	return false
 }
 
-func (x *CreateRunnerResponse) Reset() {
-	*x = CreateRunnerResponse{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[2]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *CreateRunnerRequest) GetReservedUntil() int64 {
+	if x != nil {
+		return x.ReservedUntil
+	}
+	return 0
 }
 
-func (x *CreateRunnerResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *CreateRunnerRequest) GetPriorityClassName() string {
+	if x != nil {
+		return x.PriorityClassName
+	}
+	return ""
 }
 
-func (*CreateRunnerResponse) ProtoMessage() {}
+func (x *CreateRunnerRequest) GetSkipValidation() bool {
+	if x != nil {
+		return x.SkipValidation
+	}
+	return false
+}
 
-func (x *CreateRunnerResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[2]
+func (x *CreateRunnerRequest) GetArch() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *CreateRunnerRequest) GetProtected() bool {
+	if x != nil {
+		return x.Protected
+	}
+	return false
+}
+
+func (x *CreateRunnerRequest) GetSpot() bool {
+	if x != nil {
+		return x.Spot
+	}
+	return false
+}
+
+// HostAlias maps an IP address to hostnames in a runner's /etc/hosts
+type HostAlias struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ip            string                 `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	Hostnames     []string               `protobuf:"bytes,2,rep,name=hostnames,proto3" json:"hostnames,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostAlias) Reset() {
+	*x = HostAlias{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostAlias) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostAlias) ProtoMessage() {}
+
+func (x *HostAlias) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostAlias.ProtoReflect.Descriptor instead.
+func (*HostAlias) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HostAlias) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *HostAlias) GetHostnames() []string {
+	if x != nil {
+		return x.Hostnames
+	}
+	return nil
+}
+
+// WorkspaceConfig defines S3 workspace configuration
+type WorkspaceConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// S3 bucket name
+	Bucket string `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	// S3 endpoint URL (optional, defaults to AWS S3)
+	Endpoint string `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// S3 path prefix within the bucket (optional)
+	Prefix string `protobuf:"bytes,3,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// AWS region (optional, defaults to us-east-1)
+	Region string `protobuf:"bytes,4,opt,name=region,proto3" json:"region,omitempty"`
+	// Read-only mount (optional, defaults to false)
+	ReadOnly bool `protobuf:"varint,5,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	// CPU for the s3fs sidecar as a Kubernetes quantity, e.g. "200m" (optional, defaults to the server config)
+	S3FsCpu string `protobuf:"bytes,6,opt,name=s3fs_cpu,json=s3fsCpu,proto3" json:"s3fs_cpu,omitempty"`
+	// Memory for the s3fs sidecar as a Kubernetes quantity, e.g. "512Mi" (optional, defaults to the server config)
+	S3FsMemory    string `protobuf:"bytes,7,opt,name=s3fs_memory,json=s3fsMemory,proto3" json:"s3fs_memory,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkspaceConfig) Reset() {
+	*x = WorkspaceConfig{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkspaceConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceConfig) ProtoMessage() {}
+
+func (x *WorkspaceConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceConfig.ProtoReflect.Descriptor instead.
+func (*WorkspaceConfig) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WorkspaceConfig) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *WorkspaceConfig) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *WorkspaceConfig) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *WorkspaceConfig) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *WorkspaceConfig) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *WorkspaceConfig) GetS3FsCpu() string {
+	if x != nil {
+		return x.S3FsCpu
+	}
+	return ""
+}
+
+func (x *WorkspaceConfig) GetS3FsMemory() string {
+	if x != nil {
+		return x.S3FsMemory
+	}
+	return ""
+}
+
+// CreateRunnerResponse defines the response after creating a runner
+type CreateRunnerResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The created runner details
+	Runner *Runner `protobuf:"bytes,1,opt,name=runner,proto3" json:"runner,omitempty"`
+	// Problems noticed while creating the runner that didn't stop it, such as no node having
+	// room for it yet on a cluster that only warns about capacity
+	Warnings      []string `protobuf:"bytes,2,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRunnerResponse) Reset() {
+	*x = CreateRunnerResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRunnerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRunnerResponse) ProtoMessage() {}
+
+func (x *CreateRunnerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
 		}
@@ -315,7 +915,7 @@ func (x *CreateRunnerResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateRunnerResponse.ProtoReflect.Descriptor instead.
 func (*CreateRunnerResponse) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{2}
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *CreateRunnerResponse) GetRunner() *Runner {
@@ -325,18 +925,35 @@ func (x *CreateRunnerResponse) GetRunner() *Runner {
 	return nil
 }
 
+func (x *CreateRunnerResponse) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
 // DeleteRunnerRequest defines the request to delete a runner
 type DeleteRunnerRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// ID of the runner to delete
-	RunnerId      string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// Don't return until the runner's pod and the objects deleted with it are gone, or
+	// until wait_timeout_seconds pass. Without it the objects may still be terminating,
+	// and a runner created right away with the same name can conflict with them.
+	Wait bool `protobuf:"varint,2,opt,name=wait,proto3" json:"wait,omitempty"`
+	// How long to wait, in seconds. Zero, or more than the server allows, waits for the
+	// server's RUNNER_DELETE_WAIT_TIMEOUT.
+	WaitTimeoutSeconds int32 `protobuf:"varint,3,opt,name=wait_timeout_seconds,json=waitTimeoutSeconds,proto3" json:"wait_timeout_seconds,omitempty"`
+	// Delete the runner even if it is protected. Without it a protected runner fails with
+	// FAILED_PRECONDITION.
+	OverrideProtection bool `protobuf:"varint,4,opt,name=override_protection,json=overrideProtection,proto3" json:"override_protection,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *DeleteRunnerRequest) Reset() {
 	*x = DeleteRunnerRequest{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[3]
+	mi := &file_grad_v1_runner_service_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -348,7 +965,7 @@ func (x *DeleteRunnerRequest) String() string {
 func (*DeleteRunnerRequest) ProtoMessage() {}
 
 func (x *DeleteRunnerRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[3]
+	mi := &file_grad_v1_runner_service_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -361,40 +978,3219 @@ func (x *DeleteRunnerRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteRunnerRequest.ProtoReflect.Descriptor instead.
 func (*DeleteRunnerRequest) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{3}
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteRunnerRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *DeleteRunnerRequest) GetWait() bool {
+	if x != nil {
+		return x.Wait
+	}
+	return false
+}
+
+func (x *DeleteRunnerRequest) GetWaitTimeoutSeconds() int32 {
+	if x != nil {
+		return x.WaitTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *DeleteRunnerRequest) GetOverrideProtection() bool {
+	if x != nil {
+		return x.OverrideProtection
+	}
+	return false
+}
+
+// DeleteRunnerResponse defines the response after deleting a runner
+type DeleteRunnerResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Success message, or a summary of the objects that could not be deleted
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// Objects deleted along with the runner, as kind/name
+	DeletedResources []string `protobuf:"bytes,2,rep,name=deleted_resources,json=deletedResources,proto3" json:"deleted_resources,omitempty"`
+	// Objects that could not be deleted, as "kind/name: error". The runner is left
+	// in RUNNER_STATUS_DELETE_FAILED and deleting it again retries them.
+	FailedResources []string `protobuf:"bytes,3,rep,name=failed_resources,json=failedResources,proto3" json:"failed_resources,omitempty"`
+	// With wait, whether every object was gone before the wait timed out
+	Completed bool `protobuf:"varint,4,opt,name=completed,proto3" json:"completed,omitempty"`
+	// With wait, objects still terminating when the wait timed out, as kind/name
+	RemainingResources []string `protobuf:"bytes,5,rep,name=remaining_resources,json=remainingResources,proto3" json:"remaining_resources,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *DeleteRunnerResponse) Reset() {
+	*x = DeleteRunnerResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRunnerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRunnerResponse) ProtoMessage() {}
+
+func (x *DeleteRunnerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRunnerResponse.ProtoReflect.Descriptor instead.
+func (*DeleteRunnerResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteRunnerResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DeleteRunnerResponse) GetDeletedResources() []string {
+	if x != nil {
+		return x.DeletedResources
+	}
+	return nil
+}
+
+func (x *DeleteRunnerResponse) GetFailedResources() []string {
+	if x != nil {
+		return x.FailedResources
+	}
+	return nil
+}
+
+func (x *DeleteRunnerResponse) GetCompleted() bool {
+	if x != nil {
+		return x.Completed
+	}
+	return false
+}
+
+func (x *DeleteRunnerResponse) GetRemainingResources() []string {
+	if x != nil {
+		return x.RemainingResources
+	}
+	return nil
+}
+
+// DeleteRunnerGroupRequest defines the request to delete the runners of a group
+type DeleteRunnerGroupRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Group whose runners are deleted
+	Group         string `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRunnerGroupRequest) Reset() {
+	*x = DeleteRunnerGroupRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRunnerGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRunnerGroupRequest) ProtoMessage() {}
+
+func (x *DeleteRunnerGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRunnerGroupRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRunnerGroupRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteRunnerGroupRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+// DeleteRunnerGroupResponse defines the response after deleting a group's runners
+type DeleteRunnerGroupResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// IDs of the runners that were deleted
+	DeletedRunnerIds []string `protobuf:"bytes,1,rep,name=deleted_runner_ids,json=deletedRunnerIds,proto3" json:"deleted_runner_ids,omitempty"`
+	// Runners that could not be deleted, or only partially
+	Failed        []*RunnerDeleteFailure `protobuf:"bytes,2,rep,name=failed,proto3" json:"failed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRunnerGroupResponse) Reset() {
+	*x = DeleteRunnerGroupResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRunnerGroupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRunnerGroupResponse) ProtoMessage() {}
+
+func (x *DeleteRunnerGroupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRunnerGroupResponse.ProtoReflect.Descriptor instead.
+func (*DeleteRunnerGroupResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteRunnerGroupResponse) GetDeletedRunnerIds() []string {
+	if x != nil {
+		return x.DeletedRunnerIds
+	}
+	return nil
+}
+
+func (x *DeleteRunnerGroupResponse) GetFailed() []*RunnerDeleteFailure {
+	if x != nil {
+		return x.Failed
+	}
+	return nil
+}
+
+// RunnerDeleteFailure describes a runner that could not be deleted
+type RunnerDeleteFailure struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner
+	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// Why the deletion failed
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunnerDeleteFailure) Reset() {
+	*x = RunnerDeleteFailure{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunnerDeleteFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunnerDeleteFailure) ProtoMessage() {}
+
+func (x *RunnerDeleteFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunnerDeleteFailure.ProtoReflect.Descriptor instead.
+func (*RunnerDeleteFailure) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RunnerDeleteFailure) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *RunnerDeleteFailure) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// ListRunnersRequest defines the request to list runners
+type ListRunnersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional filter by status
+	Status RunnerStatus `protobuf:"varint,1,opt,name=status,proto3,enum=grad.v1.RunnerStatus" json:"status,omitempty"`
+	// Pagination limit
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Pagination offset
+	Offset int32 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Only list runners whose ID, name or description contains this text, ignoring case
+	Filter string `protobuf:"bytes,4,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Only list runners in this group
+	Group string `protobuf:"bytes,5,opt,name=group,proto3" json:"group,omitempty"`
+	// Only list runners whose image digest starts with this, e.g. "sha256:3f1c"
+	ImageDigest string `protobuf:"bytes,6,opt,name=image_digest,json=imageDigest,proto3" json:"image_digest,omitempty"`
+	// Only list runners whose pod has all of these labels, e.g. team=ml
+	Labels map[string]string `protobuf:"bytes,7,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Sort runners before paging: "id" and "name" alphabetically, "status" in the order of the
+	// RunnerStatus values, "age" newest first, "cpu" and "memory" by requested resources,
+	// largest first. Ties are broken by ID. Empty keeps the server's order.
+	OrderBy string `protobuf:"bytes,8,opt,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	// Reverse the order_by order
+	Reverse bool `protobuf:"varint,9,opt,name=reverse,proto3" json:"reverse,omitempty"`
+	// Only list runners with a stale_image
+	StaleOnly bool `protobuf:"varint,10,opt,name=stale_only,json=staleOnly,proto3" json:"stale_only,omitempty"`
+	// Only list runners pinned to this CPU architecture
+	Arch string `protobuf:"bytes,11,opt,name=arch,proto3" json:"arch,omitempty"`
+	// Only list runners with exactly this name. Names aren't unique, so several may match.
+	Name          string `protobuf:"bytes,12,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRunnersRequest) Reset() {
+	*x = ListRunnersRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRunnersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRunnersRequest) ProtoMessage() {}
+
+func (x *ListRunnersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRunnersRequest.ProtoReflect.Descriptor instead.
+func (*ListRunnersRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListRunnersRequest) GetStatus() RunnerStatus {
+	if x != nil {
+		return x.Status
+	}
+	return RunnerStatus_RUNNER_STATUS_UNSPECIFIED
+}
+
+func (x *ListRunnersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRunnersRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListRunnersRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *ListRunnersRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *ListRunnersRequest) GetImageDigest() string {
+	if x != nil {
+		return x.ImageDigest
+	}
+	return ""
+}
+
+func (x *ListRunnersRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *ListRunnersRequest) GetOrderBy() string {
+	if x != nil {
+		return x.OrderBy
+	}
+	return ""
+}
+
+func (x *ListRunnersRequest) GetReverse() bool {
+	if x != nil {
+		return x.Reverse
+	}
+	return false
+}
+
+func (x *ListRunnersRequest) GetStaleOnly() bool {
+	if x != nil {
+		return x.StaleOnly
+	}
+	return false
+}
+
+func (x *ListRunnersRequest) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *ListRunnersRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// ListRunnersResponse defines the response containing runner list
+type ListRunnersResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// List of runners
+	Runners []*Runner `protobuf:"bytes,1,rep,name=runners,proto3" json:"runners,omitempty"`
+	// Total count of runners (for pagination)
+	Total         int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRunnersResponse) Reset() {
+	*x = ListRunnersResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRunnersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRunnersResponse) ProtoMessage() {}
+
+func (x *ListRunnersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRunnersResponse.ProtoReflect.Descriptor instead.
+func (*ListRunnersResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListRunnersResponse) GetRunners() []*Runner {
+	if x != nil {
+		return x.Runners
+	}
+	return nil
+}
+
+func (x *ListRunnersResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+// UpdateRunnerRequest defines the fields to change on a runner. Unset fields are left as they are.
+type UpdateRunnerRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner to update
+	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// New description, at most 1024 bytes; an empty string clears it
+	Description *string `protobuf:"bytes,2,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	// New end of the runner's reservation as a Unix timestamp, at most the server's
+	// RUNNER_MAX_RESERVATION from now; 0 clears it
+	ReservedUntil *int64 `protobuf:"varint,3,opt,name=reserved_until,json=reservedUntil,proto3,oneof" json:"reserved_until,omitempty"`
+	// Protect the runner from deletion, or remove its protection
+	Protected     *bool `protobuf:"varint,4,opt,name=protected,proto3,oneof" json:"protected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRunnerRequest) Reset() {
+	*x = UpdateRunnerRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRunnerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRunnerRequest) ProtoMessage() {}
+
+func (x *UpdateRunnerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRunnerRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRunnerRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateRunnerRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *UpdateRunnerRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *UpdateRunnerRequest) GetReservedUntil() int64 {
+	if x != nil && x.ReservedUntil != nil {
+		return *x.ReservedUntil
+	}
+	return 0
+}
+
+func (x *UpdateRunnerRequest) GetProtected() bool {
+	if x != nil && x.Protected != nil {
+		return *x.Protected
+	}
+	return false
+}
+
+// UpdateRunnerResponse defines the response after updating a runner
+type UpdateRunnerResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The updated runner
+	Runner        *Runner `protobuf:"bytes,1,opt,name=runner,proto3" json:"runner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRunnerResponse) Reset() {
+	*x = UpdateRunnerResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRunnerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRunnerResponse) ProtoMessage() {}
+
+func (x *UpdateRunnerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRunnerResponse.ProtoReflect.Descriptor instead.
+func (*UpdateRunnerResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UpdateRunnerResponse) GetRunner() *Runner {
+	if x != nil {
+		return x.Runner
+	}
+	return nil
+}
+
+// CloneRunnerRequest defines the request to clone a runner
+type CloneRunnerRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner whose configuration is copied; it must not be in error or being deleted
+	SourceRunnerId string `protobuf:"bytes,1,opt,name=source_runner_id,json=sourceRunnerId,proto3" json:"source_runner_id,omitempty"`
+	// Name of the new runner (optional, will be auto-generated if not provided)
+	Name          string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloneRunnerRequest) Reset() {
+	*x = CloneRunnerRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloneRunnerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloneRunnerRequest) ProtoMessage() {}
+
+func (x *CloneRunnerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloneRunnerRequest.ProtoReflect.Descriptor instead.
+func (*CloneRunnerRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CloneRunnerRequest) GetSourceRunnerId() string {
+	if x != nil {
+		return x.SourceRunnerId
+	}
+	return ""
+}
+
+func (x *CloneRunnerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// CloneRunnerResponse defines the response for cloning a runner
+type CloneRunnerResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The created runner details
+	Runner        *Runner `protobuf:"bytes,1,opt,name=runner,proto3" json:"runner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloneRunnerResponse) Reset() {
+	*x = CloneRunnerResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloneRunnerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloneRunnerResponse) ProtoMessage() {}
+
+func (x *CloneRunnerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloneRunnerResponse.ProtoReflect.Descriptor instead.
+func (*CloneRunnerResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CloneRunnerResponse) GetRunner() *Runner {
+	if x != nil {
+		return x.Runner
+	}
+	return nil
+}
+
+// GetServerInfoRequest defines the request for server information
+type GetServerInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServerInfoRequest) Reset() {
+	*x = GetServerInfoRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServerInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServerInfoRequest) ProtoMessage() {}
+
+func (x *GetServerInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServerInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetServerInfoRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{15}
+}
+
+// GetServerInfoResponse describes the server a client is talking to
+type GetServerInfoResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Limits on gRPC streams and how many are in use
+	StreamLimits *StreamLimits `protobuf:"bytes,1,opt,name=stream_limits,json=streamLimits,proto3" json:"stream_limits,omitempty"`
+	// The grad build serving the request
+	BuildInfo *BuildInfo `protobuf:"bytes,2,opt,name=build_info,json=buildInfo,proto3" json:"build_info,omitempty"`
+	// Seconds since grad started
+	UptimeSeconds int64 `protobuf:"varint,3,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	// Git version of the Kubernetes API server grad manages runners on, e.g. "v1.30.2";
+	// empty if it couldn't be looked up
+	KubernetesVersion string `protobuf:"bytes,4,opt,name=kubernetes_version,json=kubernetesVersion,proto3" json:"kubernetes_version,omitempty"`
+	// Limits on the size of commands and bootstrap commands
+	CommandLimits *CommandLimits `protobuf:"bytes,5,opt,name=command_limits,json=commandLimits,proto3" json:"command_limits,omitempty"`
+	// Features turned off on this server with --disable-features, e.g. "s3-workspace" on an
+	// air-gapped cluster; requests using them fail with FAILED_PRECONDITION
+	DisabledFeatures []string `protobuf:"bytes,6,rep,name=disabled_features,json=disabledFeatures,proto3" json:"disabled_features,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetServerInfoResponse) Reset() {
+	*x = GetServerInfoResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServerInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServerInfoResponse) ProtoMessage() {}
+
+func (x *GetServerInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServerInfoResponse.ProtoReflect.Descriptor instead.
+func (*GetServerInfoResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetServerInfoResponse) GetStreamLimits() *StreamLimits {
+	if x != nil {
+		return x.StreamLimits
+	}
+	return nil
+}
+
+func (x *GetServerInfoResponse) GetBuildInfo() *BuildInfo {
+	if x != nil {
+		return x.BuildInfo
+	}
+	return nil
+}
+
+func (x *GetServerInfoResponse) GetUptimeSeconds() int64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
+func (x *GetServerInfoResponse) GetKubernetesVersion() string {
+	if x != nil {
+		return x.KubernetesVersion
+	}
+	return ""
+}
+
+func (x *GetServerInfoResponse) GetCommandLimits() *CommandLimits {
+	if x != nil {
+		return x.CommandLimits
+	}
+	return nil
+}
+
+func (x *GetServerInfoResponse) GetDisabledFeatures() []string {
+	if x != nil {
+		return x.DisabledFeatures
+	}
+	return nil
+}
+
+// BuildInfo describes a grad binary; values that weren't recorded at build time are "unknown"
+type BuildInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Commit        string                 `protobuf:"bytes,2,opt,name=commit,proto3" json:"commit,omitempty"`
+	BuildDate     string                 `protobuf:"bytes,3,opt,name=build_date,json=buildDate,proto3" json:"build_date,omitempty"`
+	GoVersion     string                 `protobuf:"bytes,4,opt,name=go_version,json=goVersion,proto3" json:"go_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildInfo) Reset() {
+	*x = BuildInfo{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildInfo) ProtoMessage() {}
+
+func (x *BuildInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildInfo.ProtoReflect.Descriptor instead.
+func (*BuildInfo) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BuildInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *BuildInfo) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *BuildInfo) GetBuildDate() string {
+	if x != nil {
+		return x.BuildDate
+	}
+	return ""
+}
+
+func (x *BuildInfo) GetGoVersion() string {
+	if x != nil {
+		return x.GoVersion
+	}
+	return ""
+}
+
+// StreamLimits describes the gRPC stream limits of the server
+type StreamLimits struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// HTTP/2 limit on concurrent streams per client connection
+	MaxConcurrentStreams uint32 `protobuf:"varint,1,opt,name=max_concurrent_streams,json=maxConcurrentStreams,proto3" json:"max_concurrent_streams,omitempty"`
+	// Command output streams allowed at once across all clients, 0 if unlimited
+	MaxExecStreams int32 `protobuf:"varint,2,opt,name=max_exec_streams,json=maxExecStreams,proto3" json:"max_exec_streams,omitempty"`
+	// Command output streams open right now
+	ActiveExecStreams int32 `protobuf:"varint,3,opt,name=active_exec_streams,json=activeExecStreams,proto3" json:"active_exec_streams,omitempty"`
+	// Most bytes of output sent per command, 0 if unlimited
+	MaxExecOutputBytes int64 `protobuf:"varint,4,opt,name=max_exec_output_bytes,json=maxExecOutputBytes,proto3" json:"max_exec_output_bytes,omitempty"`
+	// How long a resumable command keeps running after its stream breaks, waiting for the
+	// client to reattach
+	ExecResumeGraceSeconds int32 `protobuf:"varint,5,opt,name=exec_resume_grace_seconds,json=execResumeGraceSeconds,proto3" json:"exec_resume_grace_seconds,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *StreamLimits) Reset() {
+	*x = StreamLimits{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLimits) ProtoMessage() {}
+
+func (x *StreamLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLimits.ProtoReflect.Descriptor instead.
+func (*StreamLimits) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *StreamLimits) GetMaxConcurrentStreams() uint32 {
+	if x != nil {
+		return x.MaxConcurrentStreams
+	}
+	return 0
+}
+
+func (x *StreamLimits) GetMaxExecStreams() int32 {
+	if x != nil {
+		return x.MaxExecStreams
+	}
+	return 0
+}
+
+func (x *StreamLimits) GetActiveExecStreams() int32 {
+	if x != nil {
+		return x.ActiveExecStreams
+	}
+	return 0
+}
+
+func (x *StreamLimits) GetMaxExecOutputBytes() int64 {
+	if x != nil {
+		return x.MaxExecOutputBytes
+	}
+	return 0
+}
+
+func (x *StreamLimits) GetExecResumeGraceSeconds() int32 {
+	if x != nil {
+		return x.ExecResumeGraceSeconds
+	}
+	return 0
+}
+
+// CommandLimits describes how large a command the server accepts. Larger ones are rejected
+// with InvalidArgument before anything runs.
+type CommandLimits struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Longest command line, bootstrap command or argv quoted for the shell, in bytes
+	MaxCommandBytes int32 `protobuf:"varint,1,opt,name=max_command_bytes,json=maxCommandBytes,proto3" json:"max_command_bytes,omitempty"`
+	// Most words of argv
+	MaxArgs       int32 `protobuf:"varint,2,opt,name=max_args,json=maxArgs,proto3" json:"max_args,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandLimits) Reset() {
+	*x = CommandLimits{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandLimits) ProtoMessage() {}
+
+func (x *CommandLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandLimits.ProtoReflect.Descriptor instead.
+func (*CommandLimits) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *CommandLimits) GetMaxCommandBytes() int32 {
+	if x != nil {
+		return x.MaxCommandBytes
+	}
+	return 0
+}
+
+func (x *CommandLimits) GetMaxArgs() int32 {
+	if x != nil {
+		return x.MaxArgs
+	}
+	return 0
+}
+
+// GetRunnerStatsRequest defines the request for runner counts
+type GetRunnerStatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional filter by status, as in ListRunnersRequest
+	Status        RunnerStatus `protobuf:"varint,1,opt,name=status,proto3,enum=grad.v1.RunnerStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRunnerStatsRequest) Reset() {
+	*x = GetRunnerStatsRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRunnerStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunnerStatsRequest) ProtoMessage() {}
+
+func (x *GetRunnerStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunnerStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetRunnerStatsRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetRunnerStatsRequest) GetStatus() RunnerStatus {
+	if x != nil {
+		return x.Status
+	}
+	return RunnerStatus_RUNNER_STATUS_UNSPECIFIED
+}
+
+// GetRunnerStatsResponse defines the response containing runner counts
+type GetRunnerStatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Total count of runners matching the filter
+	Total int32 `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	// Counts keyed by status name (e.g. "running")
+	ByStatus map[string]int32 `protobuf:"bytes,2,rep,name=by_status,json=byStatus,proto3" json:"by_status,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Counts keyed by resource preset (e.g. "small", or "custom" if none matches)
+	ByPreset map[string]int32 `protobuf:"bytes,3,rep,name=by_preset,json=byPreset,proto3" json:"by_preset,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Counts keyed by the grad.io/owner pod label (empty key for runners without one)
+	ByOwner       map[string]int32 `protobuf:"bytes,4,rep,name=by_owner,json=byOwner,proto3" json:"by_owner,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRunnerStatsResponse) Reset() {
+	*x = GetRunnerStatsResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRunnerStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunnerStatsResponse) ProtoMessage() {}
+
+func (x *GetRunnerStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunnerStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetRunnerStatsResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetRunnerStatsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *GetRunnerStatsResponse) GetByStatus() map[string]int32 {
+	if x != nil {
+		return x.ByStatus
+	}
+	return nil
+}
+
+func (x *GetRunnerStatsResponse) GetByPreset() map[string]int32 {
+	if x != nil {
+		return x.ByPreset
+	}
+	return nil
+}
+
+func (x *GetRunnerStatsResponse) GetByOwner() map[string]int32 {
+	if x != nil {
+		return x.ByOwner
+	}
+	return nil
+}
+
+// ExecuteCommandRequest defines the request to execute a command
+type ExecuteCommandRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner to execute code in. Required for RunnerService; for ExecuteService
+	// it runs the command in exactly this runner, which must exist and be running.
+	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// Command to execute, a command line for the shell (required unless argv is set)
+	Command string `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	// Shell to use for the command (e.g., bash, sh, python). "none" runs argv directly,
+	// without a shell.
+	Shell string `protobuf:"bytes,3,opt,name=shell,proto3" json:"shell,omitempty"`
+	// Timeout for execution (in seconds)
+	Timeout int32 `protobuf:"varint,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Working directory for execution
+	WorkingDir string `protobuf:"bytes,5,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`
+	// Workspace configuration for S3 mounting (used when auto-creating runners)
+	Workspace *WorkspaceConfig `protobuf:"bytes,6,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	// Environment variables to set in the runner (used when auto-creating runners)
+	Env map[string]string `protobuf:"bytes,7,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Create a fresh runner for this command and delete it once the command finishes,
+	// whatever its exit code (ExecuteService only, can't be combined with runner_id)
+	Ephemeral bool `protobuf:"varint,8,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
+	// Create a fresh runner for this command and leave it running afterwards
+	// (ExecuteService only, can't be combined with runner_id or ephemeral)
+	KeepRunner bool `protobuf:"varint,9,opt,name=keep_runner,json=keepRunner,proto3" json:"keep_runner,omitempty"`
+	// Most bytes of stdout and stderr together to send for this command, 0 for the
+	// server's limit (StreamLimits.max_exec_output_bytes). It may lower the server's
+	// limit but not raise it.
+	MaxOutputBytes int64 `protobuf:"varint,10,opt,name=max_output_bytes,json=maxOutputBytes,proto3" json:"max_output_bytes,omitempty"`
+	// What happens to the command once its output passes the limit
+	TruncatePolicy TruncatePolicy `protobuf:"varint,11,opt,name=truncate_policy,json=truncatePolicy,proto3,enum=grad.v1.TruncatePolicy" json:"truncate_policy,omitempty"`
+	// Keep the command running for a grace period after its stream breaks, so the client
+	// can reattach with AttachExecution with the exec_id of the STREAM_TYPE_STARTED message.
+	// Every message then has a sequence number. A stream that breaks before STARTED, e.g.
+	// while a runner is created for the command, can't be reattached.
+	Resumable bool `protobuf:"varint,12,opt,name=resumable,proto3" json:"resumable,omitempty"`
+	// Program and arguments to run instead of command, each passed exactly as given. With
+	// shell "none" they are executed directly; otherwise they are quoted into a command line
+	// for the shell, which must then be POSIX-compatible. Can't be combined with command.
+	Argv []string `protobuf:"bytes,13,rep,name=argv,proto3" json:"argv,omitempty"`
+	// CPU architecture of the runner, e.g. "arm64" (ExecuteService only): a running runner is
+	// only reused if pinned to it, and auto-created runners are
+	Arch string `protobuf:"bytes,14,opt,name=arch,proto3" json:"arch,omitempty"`
+	// Don't set the correlation variables of the STREAM_TYPE_STARTED message in the
+	// command's environment
+	DisableCorrelationEnv bool `protobuf:"varint,15,opt,name=disable_correlation_env,json=disableCorrelationEnv,proto3" json:"disable_correlation_env,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *ExecuteCommandRequest) Reset() {
+	*x = ExecuteCommandRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteCommandRequest) ProtoMessage() {}
+
+func (x *ExecuteCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteCommandRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteCommandRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ExecuteCommandRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetShell() string {
+	if x != nil {
+		return x.Shell
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetTimeout() int32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *ExecuteCommandRequest) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetWorkspace() *WorkspaceConfig {
+	if x != nil {
+		return x.Workspace
+	}
+	return nil
+}
+
+func (x *ExecuteCommandRequest) GetEnv() map[string]string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *ExecuteCommandRequest) GetEphemeral() bool {
+	if x != nil {
+		return x.Ephemeral
+	}
+	return false
+}
+
+func (x *ExecuteCommandRequest) GetKeepRunner() bool {
+	if x != nil {
+		return x.KeepRunner
+	}
+	return false
+}
+
+func (x *ExecuteCommandRequest) GetMaxOutputBytes() int64 {
+	if x != nil {
+		return x.MaxOutputBytes
+	}
+	return 0
+}
+
+func (x *ExecuteCommandRequest) GetTruncatePolicy() TruncatePolicy {
+	if x != nil {
+		return x.TruncatePolicy
+	}
+	return TruncatePolicy_TRUNCATE_POLICY_UNSPECIFIED
+}
+
+func (x *ExecuteCommandRequest) GetResumable() bool {
+	if x != nil {
+		return x.Resumable
+	}
+	return false
+}
+
+func (x *ExecuteCommandRequest) GetArgv() []string {
+	if x != nil {
+		return x.Argv
+	}
+	return nil
+}
+
+func (x *ExecuteCommandRequest) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetDisableCorrelationEnv() bool {
+	if x != nil {
+		return x.DisableCorrelationEnv
+	}
+	return false
+}
+
+// AttachExecutionRequest defines the request to reattach to a resumable command
+type AttachExecutionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// exec_id of the STREAM_TYPE_STARTED message of the command
+	ExecId string `protobuf:"bytes,1,opt,name=exec_id,json=execId,proto3" json:"exec_id,omitempty"`
+	// Sequence number of the last message received; only later messages are sent
+	AfterSequence int64 `protobuf:"varint,2,opt,name=after_sequence,json=afterSequence,proto3" json:"after_sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachExecutionRequest) Reset() {
+	*x = AttachExecutionRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachExecutionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachExecutionRequest) ProtoMessage() {}
+
+func (x *AttachExecutionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachExecutionRequest.ProtoReflect.Descriptor instead.
+func (*AttachExecutionRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AttachExecutionRequest) GetExecId() string {
+	if x != nil {
+		return x.ExecId
+	}
+	return ""
+}
+
+func (x *AttachExecutionRequest) GetAfterSequence() int64 {
+	if x != nil {
+		return x.AfterSequence
+	}
+	return 0
+}
+
+// ExecuteCommandStreamResponse defines streaming response for command execution
+type ExecuteCommandStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Type of data being streamed
+	Type StreamType `protobuf:"varint,1,opt,name=type,proto3,enum=grad.v1.StreamType" json:"type,omitempty"`
+	// Data content (stdout/stderr)
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// Exit code (only present in final message when type = EXIT).
+	// The command's own exit code is passed through verbatim. Failures to run the
+	// command at all are returned as gRPC errors and never produce an EXIT message.
+	ExitCode int32 `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	// How the command finished (only present in final message when type = EXIT)
+	FailureReason ExitReason `protobuf:"varint,4,opt,name=failure_reason,json=failureReason,proto3,enum=grad.v1.ExitReason" json:"failure_reason,omitempty"`
+	// Name of the signal that terminated the command, e.g. "SIGKILL"
+	// (only present when failure_reason = EXIT_REASON_SIGNALED)
+	Signal string `protobuf:"bytes,5,opt,name=signal,proto3" json:"signal,omitempty"`
+	// Set when the command was killed by SIGKILL and the runner container was
+	// OOM-killed at the same time, so the memory limit was likely hit
+	LikelyOom bool `protobuf:"varint,6,opt,name=likely_oom,json=likelyOom,proto3" json:"likely_oom,omitempty"`
+	// Set when output beyond max_output_bytes wasn't sent
+	// (only present in final message when type = EXIT)
+	OutputTruncated bool `protobuf:"varint,7,opt,name=output_truncated,json=outputTruncated,proto3" json:"output_truncated,omitempty"`
+	// Bytes the command wrote to stdout and stderr, including any that weren't sent
+	// (only present in final message when type = EXIT)
+	StdoutBytes int64 `protobuf:"varint,8,opt,name=stdout_bytes,json=stdoutBytes,proto3" json:"stdout_bytes,omitempty"`
+	StderrBytes int64 `protobuf:"varint,9,opt,name=stderr_bytes,json=stderrBytes,proto3" json:"stderr_bytes,omitempty"`
+	// Output limit the command ran with, 0 if unlimited
+	// (only present in final message when type = EXIT)
+	MaxOutputBytes int64 `protobuf:"varint,10,opt,name=max_output_bytes,json=maxOutputBytes,proto3" json:"max_output_bytes,omitempty"`
+	// ID of the command, which AttachExecution reattaches to if it was run with resumable set
+	// (only present when type = STARTED)
+	ExecId string `protobuf:"bytes,11,opt,name=exec_id,json=execId,proto3" json:"exec_id,omitempty"`
+	// Position of the message in the command's stream, counting from 1
+	// (only present for commands run with resumable set)
+	Sequence int64 `protobuf:"varint,12,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Runner the command runs in (only present when type = STARTED)
+	RunnerId string `protobuf:"bytes,13,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// Shell the command runs with, "none" for argv run directly, and its working
+	// directory, empty for the container's (only present when type = STARTED)
+	Shell      string `protobuf:"bytes,14,opt,name=shell,proto3" json:"shell,omitempty"`
+	WorkingDir string `protobuf:"bytes,15,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`
+	// Variables set in the command's environment to join its telemetry with grad's:
+	// GRAD_RUNNER_ID, GRAD_EXEC_ID and, when grad traces the request, a W3C TRACEPARENT of
+	// its span. Empty if the request set disable_correlation_env.
+	// (only present when type = STARTED)
+	CorrelationEnv map[string]string `protobuf:"bytes,16,rep,name=correlation_env,json=correlationEnv,proto3" json:"correlation_env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ExecuteCommandStreamResponse) Reset() {
+	*x = ExecuteCommandStreamResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteCommandStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteCommandStreamResponse) ProtoMessage() {}
+
+func (x *ExecuteCommandStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteCommandStreamResponse.ProtoReflect.Descriptor instead.
+func (*ExecuteCommandStreamResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ExecuteCommandStreamResponse) GetType() StreamType {
+	if x != nil {
+		return x.Type
+	}
+	return StreamType_STREAM_TYPE_UNSPECIFIED
+}
+
+func (x *ExecuteCommandStreamResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ExecuteCommandStreamResponse) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *ExecuteCommandStreamResponse) GetFailureReason() ExitReason {
+	if x != nil {
+		return x.FailureReason
+	}
+	return ExitReason_EXIT_REASON_UNSPECIFIED
+}
+
+func (x *ExecuteCommandStreamResponse) GetSignal() string {
+	if x != nil {
+		return x.Signal
+	}
+	return ""
+}
+
+func (x *ExecuteCommandStreamResponse) GetLikelyOom() bool {
+	if x != nil {
+		return x.LikelyOom
+	}
+	return false
+}
+
+func (x *ExecuteCommandStreamResponse) GetOutputTruncated() bool {
+	if x != nil {
+		return x.OutputTruncated
+	}
+	return false
+}
+
+func (x *ExecuteCommandStreamResponse) GetStdoutBytes() int64 {
+	if x != nil {
+		return x.StdoutBytes
+	}
+	return 0
+}
+
+func (x *ExecuteCommandStreamResponse) GetStderrBytes() int64 {
+	if x != nil {
+		return x.StderrBytes
+	}
+	return 0
+}
+
+func (x *ExecuteCommandStreamResponse) GetMaxOutputBytes() int64 {
+	if x != nil {
+		return x.MaxOutputBytes
+	}
+	return 0
+}
+
+func (x *ExecuteCommandStreamResponse) GetExecId() string {
+	if x != nil {
+		return x.ExecId
+	}
+	return ""
+}
+
+func (x *ExecuteCommandStreamResponse) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ExecuteCommandStreamResponse) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *ExecuteCommandStreamResponse) GetShell() string {
+	if x != nil {
+		return x.Shell
+	}
+	return ""
+}
+
+func (x *ExecuteCommandStreamResponse) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
+	}
+	return ""
+}
+
+func (x *ExecuteCommandStreamResponse) GetCorrelationEnv() map[string]string {
+	if x != nil {
+		return x.CorrelationEnv
+	}
+	return nil
+}
+
+// GetRunnerRequest defines the request to get runner details
+type GetRunnerRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner to retrieve
+	RunnerId      string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRunnerRequest) Reset() {
+	*x = GetRunnerRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRunnerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunnerRequest) ProtoMessage() {}
+
+func (x *GetRunnerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunnerRequest.ProtoReflect.Descriptor instead.
+func (*GetRunnerRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetRunnerRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+// GetRunnerResponse defines the response containing runner details
+type GetRunnerResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The runner details
+	Runner        *Runner `protobuf:"bytes,1,opt,name=runner,proto3" json:"runner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRunnerResponse) Reset() {
+	*x = GetRunnerResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRunnerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunnerResponse) ProtoMessage() {}
+
+func (x *GetRunnerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunnerResponse.ProtoReflect.Descriptor instead.
+func (*GetRunnerResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetRunnerResponse) GetRunner() *Runner {
+	if x != nil {
+		return x.Runner
+	}
+	return nil
+}
+
+// Runner represents a runner instance
+type Runner struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique identifier for the runner
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Name of the runner
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Current status of the runner
+	Status RunnerStatus `protobuf:"varint,3,opt,name=status,proto3,enum=grad.v1.RunnerStatus" json:"status,omitempty"`
+	// Resource requirements/allocation
+	Resources *ResourceRequirements `protobuf:"bytes,4,opt,name=resources,proto3" json:"resources,omitempty"`
+	// Creation timestamp
+	CreatedAt int64 `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Last updated timestamp
+	UpdatedAt int64 `protobuf:"varint,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// SSH connection details
+	Ssh *SSHDetails `protobuf:"bytes,7,opt,name=ssh,proto3" json:"ssh,omitempty"`
+	// Runner's IP address
+	IpAddress string `protobuf:"bytes,8,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	// Environment variables
+	Env map[string]string `protobuf:"bytes,9,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Human readable detail about the current status (e.g. bootstrap failure output)
+	StatusDetail string `protobuf:"bytes,10,opt,name=status_detail,json=statusDetail,proto3" json:"status_detail,omitempty"`
+	// Bootstrap command configured for this runner
+	BootstrapCommand string `protobuf:"bytes,11,opt,name=bootstrap_command,json=bootstrapCommand,proto3" json:"bootstrap_command,omitempty"`
+	// Time from creation until the runner first became ready, in milliseconds (0 until then)
+	ProvisioningDurationMs int64 `protobuf:"varint,12,opt,name=provisioning_duration_ms,json=provisioningDurationMs,proto3" json:"provisioning_duration_ms,omitempty"`
+	// Name of the NetworkPolicy restricting the runner's traffic (empty if none)
+	NetworkPolicy string `protobuf:"bytes,13,opt,name=network_policy,json=networkPolicy,proto3" json:"network_policy,omitempty"`
+	// Shell and working directory used for commands that don't set them (empty for the server default)
+	DefaultShell   string `protobuf:"bytes,14,opt,name=default_shell,json=defaultShell,proto3" json:"default_shell,omitempty"`
+	DefaultWorkdir string `protobuf:"bytes,15,opt,name=default_workdir,json=defaultWorkdir,proto3" json:"default_workdir,omitempty"`
+	// Free-form note on what the runner is for
+	Description string `protobuf:"bytes,16,opt,name=description,proto3" json:"description,omitempty"`
+	// Group the runner belongs to, empty if none
+	Group string `protobuf:"bytes,17,opt,name=group,proto3" json:"group,omitempty"`
+	// Entries added to the runner's /etc/hosts, from the server config and the create request
+	HostAliases []*HostAlias `protobuf:"bytes,18,rep,name=host_aliases,json=hostAliases,proto3" json:"host_aliases,omitempty"`
+	// Image reference the runner container was created from
+	Image string `protobuf:"bytes,19,opt,name=image,proto3" json:"image,omitempty"`
+	// Digest the image resolved to on the node, e.g. "sha256:...", empty until the container has started
+	ImageDigest string `protobuf:"bytes,20,opt,name=image_digest,json=imageDigest,proto3" json:"image_digest,omitempty"`
+	// How often the runner's pod was recreated after failing
+	RestartCount int32 `protobuf:"varint,21,opt,name=restart_count,json=restartCount,proto3" json:"restart_count,omitempty"`
+	// Whether the runner's pod is recreated if it fails
+	AutoRestart bool `protobuf:"varint,22,opt,name=auto_restart,json=autoRestart,proto3" json:"auto_restart,omitempty"`
+	// Live resource usage, only set by GetRunner and only if metrics-server reports on the runner
+	Usage *RunnerUsage `protobuf:"bytes,23,opt,name=usage,proto3" json:"usage,omitempty"`
+	// Unix timestamp until which idle cleanup keeps the runner, 0 if it isn't reserved
+	ReservedUntil int64 `protobuf:"varint,24,opt,name=reserved_until,json=reservedUntil,proto3" json:"reserved_until,omitempty"`
+	// Why the runner is in the error state, UNSPECIFIED for runners in any other state
+	ErrorReason RunnerErrorReason `protobuf:"varint,25,opt,name=error_reason,json=errorReason,proto3,enum=grad.v1.RunnerErrorReason" json:"error_reason,omitempty"`
+	// Human readable explanation of the error, e.g. the container's termination reason
+	ErrorMessage string `protobuf:"bytes,26,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	// Whether the runner runs a different image than grad now creates runners with, such as
+	// after an upgrade changed the runner image. A mutable tag like latest counts as the same
+	// image even if it has moved on since.
+	StaleImage bool `protobuf:"varint,27,opt,name=stale_image,json=staleImage,proto3" json:"stale_image,omitempty"`
+	// SSH sessions open in the runner when grad last looked, every SSH_SESSION_CHECK_INTERVAL
+	SshSessions int32 `protobuf:"varint,28,opt,name=ssh_sessions,json=sshSessions,proto3" json:"ssh_sessions,omitempty"`
+	// Unix timestamp of the latest SSH login grad saw, 0 if it saw none
+	LastSshLogin int64 `protobuf:"varint,29,opt,name=last_ssh_login,json=lastSshLogin,proto3" json:"last_ssh_login,omitempty"`
+	// PriorityClass of the runner's pod, empty if it has none
+	PriorityClassName string `protobuf:"bytes,30,opt,name=priority_class_name,json=priorityClassName,proto3" json:"priority_class_name,omitempty"`
+	// CPU architecture the runner is pinned to, empty if it may run on any node
+	Arch string `protobuf:"bytes,31,opt,name=arch,proto3" json:"arch,omitempty"`
+	// Provisioning steps of the runner in order, like the conditions of a pod: PodCreated,
+	// PodScheduled, ImagePulled, ContainersReady, then WorkspaceMounted for runners with an S3
+	// workspace and BootstrapComplete for runners with a bootstrap command
+	Conditions []*RunnerCondition `protobuf:"bytes,32,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	// How the runner's S3 workspace is mounted into its container, UNSPECIFIED without one
+	WorkspaceMode WorkspaceMode `protobuf:"varint,33,opt,name=workspace_mode,json=workspaceMode,proto3,enum=grad.v1.WorkspaceMode" json:"workspace_mode,omitempty"`
+	// Whether the runner is protected from deletion, see CreateRunnerRequest.protected
+	Protected bool `protobuf:"varint,34,opt,name=protected,proto3" json:"protected,omitempty"`
+	// Whether the runner was created to run on spot/preemptible nodes
+	Spot          bool `protobuf:"varint,35,opt,name=spot,proto3" json:"spot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Runner) Reset() {
+	*x = Runner{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Runner) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Runner) ProtoMessage() {}
+
+func (x *Runner) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Runner.ProtoReflect.Descriptor instead.
+func (*Runner) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *Runner) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Runner) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Runner) GetStatus() RunnerStatus {
+	if x != nil {
+		return x.Status
+	}
+	return RunnerStatus_RUNNER_STATUS_UNSPECIFIED
+}
+
+func (x *Runner) GetResources() *ResourceRequirements {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *Runner) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Runner) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *Runner) GetSsh() *SSHDetails {
+	if x != nil {
+		return x.Ssh
+	}
+	return nil
+}
+
+func (x *Runner) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *Runner) GetEnv() map[string]string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *Runner) GetStatusDetail() string {
+	if x != nil {
+		return x.StatusDetail
+	}
+	return ""
+}
+
+func (x *Runner) GetBootstrapCommand() string {
+	if x != nil {
+		return x.BootstrapCommand
+	}
+	return ""
+}
+
+func (x *Runner) GetProvisioningDurationMs() int64 {
+	if x != nil {
+		return x.ProvisioningDurationMs
+	}
+	return 0
+}
+
+func (x *Runner) GetNetworkPolicy() string {
+	if x != nil {
+		return x.NetworkPolicy
+	}
+	return ""
+}
+
+func (x *Runner) GetDefaultShell() string {
+	if x != nil {
+		return x.DefaultShell
+	}
+	return ""
+}
+
+func (x *Runner) GetDefaultWorkdir() string {
+	if x != nil {
+		return x.DefaultWorkdir
+	}
+	return ""
+}
+
+func (x *Runner) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Runner) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Runner) GetHostAliases() []*HostAlias {
+	if x != nil {
+		return x.HostAliases
+	}
+	return nil
+}
+
+func (x *Runner) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *Runner) GetImageDigest() string {
+	if x != nil {
+		return x.ImageDigest
+	}
+	return ""
+}
+
+func (x *Runner) GetRestartCount() int32 {
+	if x != nil {
+		return x.RestartCount
+	}
+	return 0
+}
+
+func (x *Runner) GetAutoRestart() bool {
+	if x != nil {
+		return x.AutoRestart
+	}
+	return false
+}
+
+func (x *Runner) GetUsage() *RunnerUsage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+func (x *Runner) GetReservedUntil() int64 {
+	if x != nil {
+		return x.ReservedUntil
+	}
+	return 0
+}
+
+func (x *Runner) GetErrorReason() RunnerErrorReason {
+	if x != nil {
+		return x.ErrorReason
+	}
+	return RunnerErrorReason_RUNNER_ERROR_REASON_UNSPECIFIED
+}
+
+func (x *Runner) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *Runner) GetStaleImage() bool {
+	if x != nil {
+		return x.StaleImage
+	}
+	return false
+}
+
+func (x *Runner) GetSshSessions() int32 {
+	if x != nil {
+		return x.SshSessions
+	}
+	return 0
+}
+
+func (x *Runner) GetLastSshLogin() int64 {
+	if x != nil {
+		return x.LastSshLogin
+	}
+	return 0
+}
+
+func (x *Runner) GetPriorityClassName() string {
+	if x != nil {
+		return x.PriorityClassName
+	}
+	return ""
+}
+
+func (x *Runner) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *Runner) GetConditions() []*RunnerCondition {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *Runner) GetWorkspaceMode() WorkspaceMode {
+	if x != nil {
+		return x.WorkspaceMode
+	}
+	return WorkspaceMode_WORKSPACE_MODE_UNSPECIFIED
+}
+
+func (x *Runner) GetProtected() bool {
+	if x != nil {
+		return x.Protected
+	}
+	return false
+}
+
+func (x *Runner) GetSpot() bool {
+	if x != nil {
+		return x.Spot
+	}
+	return false
+}
+
+// RunnerCondition is the state of one provisioning step of a runner
+type RunnerCondition struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Step the condition is about, e.g. "PodScheduled"
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Whether the step is done
+	Status ConditionStatus `protobuf:"varint,2,opt,name=status,proto3,enum=grad.v1.ConditionStatus" json:"status,omitempty"`
+	// Unix timestamp of when the status last changed, 0 if not known
+	LastTransitionTime int64 `protobuf:"varint,3,opt,name=last_transition_time,json=lastTransitionTime,proto3" json:"last_transition_time,omitempty"`
+	// Machine readable reason for the status, e.g. "Unschedulable" or "ImagePullBackOff"
+	Reason string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Human readable detail, e.g. why the pod can't be scheduled
+	Message       string `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunnerCondition) Reset() {
+	*x = RunnerCondition{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunnerCondition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunnerCondition) ProtoMessage() {}
+
+func (x *RunnerCondition) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunnerCondition.ProtoReflect.Descriptor instead.
+func (*RunnerCondition) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RunnerCondition) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RunnerCondition) GetStatus() ConditionStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ConditionStatus_CONDITION_STATUS_UNSPECIFIED
+}
+
+func (x *RunnerCondition) GetLastTransitionTime() int64 {
+	if x != nil {
+		return x.LastTransitionTime
+	}
+	return 0
+}
+
+func (x *RunnerCondition) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RunnerCondition) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// RunnerUsage is the resource usage of a runner as last sampled by metrics-server
+type RunnerUsage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner
+	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// When the usage was sampled, as a Unix timestamp
+	Timestamp int64 `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Period the usage was averaged over, in milliseconds
+	WindowMs int64 `protobuf:"varint,3,opt,name=window_ms,json=windowMs,proto3" json:"window_ms,omitempty"`
+	// Usage of all containers together
+	CpuMillicores int64 `protobuf:"varint,4,opt,name=cpu_millicores,json=cpuMillicores,proto3" json:"cpu_millicores,omitempty"`
+	MemoryBytes   int64 `protobuf:"varint,5,opt,name=memory_bytes,json=memoryBytes,proto3" json:"memory_bytes,omitempty"`
+	// Usage of each container, e.g. the runner and its s3fs sidecar
+	Containers []*ContainerUsage `protobuf:"bytes,6,rep,name=containers,proto3" json:"containers,omitempty"`
+	// Bytes of command output the runner streamed since grad started, from commands and the
+	// bootstrap command
+	StdoutBytes   int64 `protobuf:"varint,7,opt,name=stdout_bytes,json=stdoutBytes,proto3" json:"stdout_bytes,omitempty"`
+	StderrBytes   int64 `protobuf:"varint,8,opt,name=stderr_bytes,json=stderrBytes,proto3" json:"stderr_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunnerUsage) Reset() {
+	*x = RunnerUsage{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunnerUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunnerUsage) ProtoMessage() {}
+
+func (x *RunnerUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunnerUsage.ProtoReflect.Descriptor instead.
+func (*RunnerUsage) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RunnerUsage) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *RunnerUsage) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *RunnerUsage) GetWindowMs() int64 {
+	if x != nil {
+		return x.WindowMs
+	}
+	return 0
+}
+
+func (x *RunnerUsage) GetCpuMillicores() int64 {
+	if x != nil {
+		return x.CpuMillicores
+	}
+	return 0
+}
+
+func (x *RunnerUsage) GetMemoryBytes() int64 {
+	if x != nil {
+		return x.MemoryBytes
+	}
+	return 0
+}
+
+func (x *RunnerUsage) GetContainers() []*ContainerUsage {
+	if x != nil {
+		return x.Containers
+	}
+	return nil
+}
+
+func (x *RunnerUsage) GetStdoutBytes() int64 {
+	if x != nil {
+		return x.StdoutBytes
+	}
+	return 0
+}
+
+func (x *RunnerUsage) GetStderrBytes() int64 {
+	if x != nil {
+		return x.StderrBytes
+	}
+	return 0
+}
+
+// ContainerUsage is the resource usage of one container of a runner
+type ContainerUsage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the container
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// CPU in thousandths of a core
+	CpuMillicores int64 `protobuf:"varint,2,opt,name=cpu_millicores,json=cpuMillicores,proto3" json:"cpu_millicores,omitempty"`
+	// Working set memory in bytes
+	MemoryBytes   int64 `protobuf:"varint,3,opt,name=memory_bytes,json=memoryBytes,proto3" json:"memory_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContainerUsage) Reset() {
+	*x = ContainerUsage{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContainerUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContainerUsage) ProtoMessage() {}
+
+func (x *ContainerUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContainerUsage.ProtoReflect.Descriptor instead.
+func (*ContainerUsage) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ContainerUsage) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ContainerUsage) GetCpuMillicores() int64 {
+	if x != nil {
+		return x.CpuMillicores
+	}
+	return 0
+}
+
+func (x *ContainerUsage) GetMemoryBytes() int64 {
+	if x != nil {
+		return x.MemoryBytes
+	}
+	return 0
+}
+
+// GetRunnerMetricsRequest defines the request for a runner's live usage
+type GetRunnerMetricsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner
+	RunnerId      string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRunnerMetricsRequest) Reset() {
+	*x = GetRunnerMetricsRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRunnerMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunnerMetricsRequest) ProtoMessage() {}
+
+func (x *GetRunnerMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunnerMetricsRequest.ProtoReflect.Descriptor instead.
+func (*GetRunnerMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GetRunnerMetricsRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+// GetRunnerMetricsResponse contains a runner's live usage
+type GetRunnerMetricsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Usage         *RunnerUsage           `protobuf:"bytes,1,opt,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRunnerMetricsResponse) Reset() {
+	*x = GetRunnerMetricsResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRunnerMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunnerMetricsResponse) ProtoMessage() {}
+
+func (x *GetRunnerMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunnerMetricsResponse.ProtoReflect.Descriptor instead.
+func (*GetRunnerMetricsResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GetRunnerMetricsResponse) GetUsage() *RunnerUsage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+// ListRunnerMetricsRequest defines the request for the live usage of all runners
+type ListRunnerMetricsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRunnerMetricsRequest) Reset() {
+	*x = ListRunnerMetricsRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRunnerMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRunnerMetricsRequest) ProtoMessage() {}
+
+func (x *ListRunnerMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRunnerMetricsRequest.ProtoReflect.Descriptor instead.
+func (*ListRunnerMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{33}
+}
+
+// ListRunnerMetricsResponse contains the live usage of all runners, highest CPU first
+type ListRunnerMetricsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Usage         []*RunnerUsage         `protobuf:"bytes,1,rep,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRunnerMetricsResponse) Reset() {
+	*x = ListRunnerMetricsResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRunnerMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRunnerMetricsResponse) ProtoMessage() {}
+
+func (x *ListRunnerMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRunnerMetricsResponse.ProtoReflect.Descriptor instead.
+func (*ListRunnerMetricsResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListRunnerMetricsResponse) GetUsage() []*RunnerUsage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+// GetRunnerDiagnosticsRequest defines the request for a runner's diagnostics
+type GetRunnerDiagnosticsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner
+	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// Number of log lines to return per container (optional, defaults to 200, capped by the server)
+	TailLines     int32 `protobuf:"varint,2,opt,name=tail_lines,json=tailLines,proto3" json:"tail_lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRunnerDiagnosticsRequest) Reset() {
+	*x = GetRunnerDiagnosticsRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRunnerDiagnosticsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunnerDiagnosticsRequest) ProtoMessage() {}
+
+func (x *GetRunnerDiagnosticsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunnerDiagnosticsRequest.ProtoReflect.Descriptor instead.
+func (*GetRunnerDiagnosticsRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *GetRunnerDiagnosticsRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *GetRunnerDiagnosticsRequest) GetTailLines() int32 {
+	if x != nil {
+		return x.TailLines
+	}
+	return 0
+}
+
+// GetRunnerDiagnosticsResponse contains a runner's diagnostics. Env values and commands are
+// returned as is; clients sharing them should redact secrets.
+type GetRunnerDiagnosticsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The runner's pod as JSON, without managed fields
+	PodJson string `protobuf:"bytes,1,opt,name=pod_json,json=podJson,proto3" json:"pod_json,omitempty"`
+	// Events about the pod, oldest first
+	Events []*RunnerEvent `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	// Tail of the log of each container
+	Logs []*ContainerLog `protobuf:"bytes,3,rep,name=logs,proto3" json:"logs,omitempty"`
+	// Most recent executions since the server started, oldest first
+	Executions    []*ExecutionRecord `protobuf:"bytes,4,rep,name=executions,proto3" json:"executions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRunnerDiagnosticsResponse) Reset() {
+	*x = GetRunnerDiagnosticsResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRunnerDiagnosticsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunnerDiagnosticsResponse) ProtoMessage() {}
+
+func (x *GetRunnerDiagnosticsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunnerDiagnosticsResponse.ProtoReflect.Descriptor instead.
+func (*GetRunnerDiagnosticsResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *GetRunnerDiagnosticsResponse) GetPodJson() string {
+	if x != nil {
+		return x.PodJson
+	}
+	return ""
+}
+
+func (x *GetRunnerDiagnosticsResponse) GetEvents() []*RunnerEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *GetRunnerDiagnosticsResponse) GetLogs() []*ContainerLog {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+func (x *GetRunnerDiagnosticsResponse) GetExecutions() []*ExecutionRecord {
+	if x != nil {
+		return x.Executions
+	}
+	return nil
+}
+
+// SSHKey is a public key allowed to log in to a runner
+type SSHKey struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// SHA256 fingerprint as shown by ssh-keygen -l, e.g. SHA256:xGNji13B...
+	Fingerprint string `protobuf:"bytes,1,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	// Key type, e.g. ssh-ed25519
+	Type          string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Comment       string `protobuf:"bytes,3,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SSHKey) Reset() {
+	*x = SSHKey{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SSHKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SSHKey) ProtoMessage() {}
+
+func (x *SSHKey) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SSHKey.ProtoReflect.Descriptor instead.
+func (*SSHKey) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *SSHKey) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *SSHKey) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SSHKey) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+// AddSSHKeyRequest defines the request to add a public key to a runner
+type AddSSHKeyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner
+	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// Public key in authorized_keys format, e.g. the content of ~/.ssh/id_ed25519.pub
+	PublicKey     string `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddSSHKeyRequest) Reset() {
+	*x = AddSSHKeyRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddSSHKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddSSHKeyRequest) ProtoMessage() {}
+
+func (x *AddSSHKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddSSHKeyRequest.ProtoReflect.Descriptor instead.
+func (*AddSSHKeyRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *AddSSHKeyRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *AddSSHKeyRequest) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+// AddSSHKeyResponse contains the added key
+type AddSSHKeyResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   *SSHKey                `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// False if the runner already had the key
+	Added         bool `protobuf:"varint,2,opt,name=added,proto3" json:"added,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddSSHKeyResponse) Reset() {
+	*x = AddSSHKeyResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddSSHKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddSSHKeyResponse) ProtoMessage() {}
+
+func (x *AddSSHKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddSSHKeyResponse.ProtoReflect.Descriptor instead.
+func (*AddSSHKeyResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *AddSSHKeyResponse) GetKey() *SSHKey {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *AddSSHKeyResponse) GetAdded() bool {
+	if x != nil {
+		return x.Added
+	}
+	return false
+}
+
+// ListSSHKeysRequest defines the request for the keys of a runner
+type ListSSHKeysRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner
+	RunnerId      string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSSHKeysRequest) Reset() {
+	*x = ListSSHKeysRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSSHKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSSHKeysRequest) ProtoMessage() {}
+
+func (x *ListSSHKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSSHKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListSSHKeysRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ListSSHKeysRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+// ListSSHKeysResponse contains the keys added to a runner, oldest first
+type ListSSHKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*SSHKey              `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSSHKeysResponse) Reset() {
+	*x = ListSSHKeysResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSSHKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSSHKeysResponse) ProtoMessage() {}
+
+func (x *ListSSHKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSSHKeysResponse.ProtoReflect.Descriptor instead.
+func (*ListSSHKeysResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ListSSHKeysResponse) GetKeys() []*SSHKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+// RemoveSSHKeyRequest defines the request to remove a key from a runner
+type RemoveSSHKeyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner
+	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// Fingerprint of the key, as returned by ListSSHKeys
+	Fingerprint   string `protobuf:"bytes,2,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveSSHKeyRequest) Reset() {
+	*x = RemoveSSHKeyRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveSSHKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveSSHKeyRequest) ProtoMessage() {}
+
+func (x *RemoveSSHKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveSSHKeyRequest.ProtoReflect.Descriptor instead.
+func (*RemoveSSHKeyRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *RemoveSSHKeyRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *RemoveSSHKeyRequest) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+// RemoveSSHKeyResponse reports whether the key was removed
+type RemoveSSHKeyResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// False if the runner didn't have the key
+	Removed       bool `protobuf:"varint,1,opt,name=removed,proto3" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveSSHKeyResponse) Reset() {
+	*x = RemoveSSHKeyResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveSSHKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveSSHKeyResponse) ProtoMessage() {}
+
+func (x *RemoveSSHKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveSSHKeyResponse.ProtoReflect.Descriptor instead.
+func (*RemoveSSHKeyResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *RemoveSSHKeyResponse) GetRemoved() bool {
+	if x != nil {
+		return x.Removed
+	}
+	return false
+}
+
+// SnapshotWorkspaceRequest defines the request to archive a runner's workspace to S3
+type SnapshotWorkspaceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the runner
+	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// S3 URL to upload the archive to, e.g. s3://bucket/snapshots/run-42.tar.gz. A URL ending in
+	// / gets RUNNER_ID-YYYYMMDDTHHMMSSZ.tar.gz appended.
+	Destination string `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	// S3 endpoint URL (optional, defaults to the runner's workspace endpoint, then AWS S3)
+	Endpoint string `protobuf:"bytes,3,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// AWS region (optional, defaults to the runner's workspace region)
+	Region string `protobuf:"bytes,4,opt,name=region,proto3" json:"region,omitempty"`
+	// Credentials to upload with (optional, defaults to the AWS credentials the runner has)
+	Credentials   *S3Credentials `protobuf:"bytes,5,opt,name=credentials,proto3" json:"credentials,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotWorkspaceRequest) Reset() {
+	*x = SnapshotWorkspaceRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotWorkspaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotWorkspaceRequest) ProtoMessage() {}
+
+func (x *SnapshotWorkspaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotWorkspaceRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotWorkspaceRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *SnapshotWorkspaceRequest) GetRunnerId() string {
+	if x != nil {
+		return x.RunnerId
+	}
+	return ""
+}
+
+func (x *SnapshotWorkspaceRequest) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *SnapshotWorkspaceRequest) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *SnapshotWorkspaceRequest) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *SnapshotWorkspaceRequest) GetCredentials() *S3Credentials {
+	if x != nil {
+		return x.Credentials
+	}
+	return nil
+}
+
+// S3Credentials are AWS credentials for S3
+type S3Credentials struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	AccessKeyId     string                 `protobuf:"bytes,1,opt,name=access_key_id,json=accessKeyId,proto3" json:"access_key_id,omitempty"`
+	SecretAccessKey string                 `protobuf:"bytes,2,opt,name=secret_access_key,json=secretAccessKey,proto3" json:"secret_access_key,omitempty"`
+	// Only for temporary credentials
+	SessionToken  string `protobuf:"bytes,3,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *S3Credentials) Reset() {
+	*x = S3Credentials{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *S3Credentials) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*S3Credentials) ProtoMessage() {}
+
+func (x *S3Credentials) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use S3Credentials.ProtoReflect.Descriptor instead.
+func (*S3Credentials) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *S3Credentials) GetAccessKeyId() string {
+	if x != nil {
+		return x.AccessKeyId
+	}
+	return ""
+}
+
+func (x *S3Credentials) GetSecretAccessKey() string {
+	if x != nil {
+		return x.SecretAccessKey
+	}
+	return ""
+}
+
+func (x *S3Credentials) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+// SnapshotWorkspaceResponse reports the progress of a workspace snapshot
+type SnapshotWorkspaceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Phase SnapshotPhase          `protobuf:"varint,1,opt,name=phase,proto3,enum=grad.v1.SnapshotPhase" json:"phase,omitempty"`
+	// Bytes archived or uploaded so far, and how many there are in all, 0 until known
+	BytesDone  int64 `protobuf:"varint,2,opt,name=bytes_done,json=bytesDone,proto3" json:"bytes_done,omitempty"`
+	BytesTotal int64 `protobuf:"varint,3,opt,name=bytes_total,json=bytesTotal,proto3" json:"bytes_total,omitempty"`
+	// With SNAPSHOT_PHASE_DONE, where the archive was uploaded and its size
+	Bucket        string `protobuf:"bytes,4,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	Key           string `protobuf:"bytes,5,opt,name=key,proto3" json:"key,omitempty"`
+	SizeBytes     int64  `protobuf:"varint,6,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotWorkspaceResponse) Reset() {
+	*x = SnapshotWorkspaceResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotWorkspaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotWorkspaceResponse) ProtoMessage() {}
+
+func (x *SnapshotWorkspaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotWorkspaceResponse.ProtoReflect.Descriptor instead.
+func (*SnapshotWorkspaceResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *SnapshotWorkspaceResponse) GetPhase() SnapshotPhase {
+	if x != nil {
+		return x.Phase
+	}
+	return SnapshotPhase_SNAPSHOT_PHASE_UNSPECIFIED
+}
+
+func (x *SnapshotWorkspaceResponse) GetBytesDone() int64 {
+	if x != nil {
+		return x.BytesDone
+	}
+	return 0
+}
+
+func (x *SnapshotWorkspaceResponse) GetBytesTotal() int64 {
+	if x != nil {
+		return x.BytesTotal
+	}
+	return 0
+}
+
+func (x *SnapshotWorkspaceResponse) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
 }
 
-func (x *DeleteRunnerRequest) GetRunnerId() string {
+func (x *SnapshotWorkspaceResponse) GetKey() string {
 	if x != nil {
-		return x.RunnerId
+		return x.Key
 	}
 	return ""
 }
 
-// DeleteRunnerResponse defines the response after deleting a runner
-type DeleteRunnerResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Success message
-	Message       string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *SnapshotWorkspaceResponse) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
 }
 
-func (x *DeleteRunnerResponse) Reset() {
-	*x = DeleteRunnerResponse{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[4]
+// RunnerEvent is a Kubernetes event about a runner's pod
+type RunnerEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Normal or Warning
+	Type    string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Reason  string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// Part of the pod the event is about, e.g. spec.containers{runner}
+	FieldPath string `protobuf:"bytes,4,opt,name=field_path,json=fieldPath,proto3" json:"field_path,omitempty"`
+	// How often the event happened, and when first and last, as Unix timestamps
+	Count          int32 `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	FirstTimestamp int64 `protobuf:"varint,6,opt,name=first_timestamp,json=firstTimestamp,proto3" json:"first_timestamp,omitempty"`
+	LastTimestamp  int64 `protobuf:"varint,7,opt,name=last_timestamp,json=lastTimestamp,proto3" json:"last_timestamp,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RunnerEvent) Reset() {
+	*x = RunnerEvent{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteRunnerResponse) String() string {
+func (x *RunnerEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteRunnerResponse) ProtoMessage() {}
+func (*RunnerEvent) ProtoMessage() {}
 
-func (x *DeleteRunnerResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[4]
+func (x *RunnerEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -405,108 +4201,87 @@ func (x *DeleteRunnerResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteRunnerResponse.ProtoReflect.Descriptor instead.
-func (*DeleteRunnerResponse) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use RunnerEvent.ProtoReflect.Descriptor instead.
+func (*RunnerEvent) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{47}
 }
 
-func (x *DeleteRunnerResponse) GetMessage() string {
+func (x *RunnerEvent) GetType() string {
 	if x != nil {
-		return x.Message
+		return x.Type
 	}
 	return ""
 }
 
-// ListRunnersRequest defines the request to list runners
-type ListRunnersRequest struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Optional filter by status
-	Status RunnerStatus `protobuf:"varint,1,opt,name=status,proto3,enum=grad.v1.RunnerStatus" json:"status,omitempty"`
-	// Pagination limit
-	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
-	// Pagination offset
-	Offset        int32 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *ListRunnersRequest) Reset() {
-	*x = ListRunnersRequest{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[5]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *ListRunnersRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *RunnerEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
 }
 
-func (*ListRunnersRequest) ProtoMessage() {}
-
-func (x *ListRunnersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[5]
+func (x *RunnerEvent) GetMessage() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Message
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use ListRunnersRequest.ProtoReflect.Descriptor instead.
-func (*ListRunnersRequest) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{5}
+func (x *RunnerEvent) GetFieldPath() string {
+	if x != nil {
+		return x.FieldPath
+	}
+	return ""
 }
 
-func (x *ListRunnersRequest) GetStatus() RunnerStatus {
+func (x *RunnerEvent) GetCount() int32 {
 	if x != nil {
-		return x.Status
+		return x.Count
 	}
-	return RunnerStatus_RUNNER_STATUS_UNSPECIFIED
+	return 0
 }
 
-func (x *ListRunnersRequest) GetLimit() int32 {
+func (x *RunnerEvent) GetFirstTimestamp() int64 {
 	if x != nil {
-		return x.Limit
+		return x.FirstTimestamp
 	}
 	return 0
 }
 
-func (x *ListRunnersRequest) GetOffset() int32 {
+func (x *RunnerEvent) GetLastTimestamp() int64 {
 	if x != nil {
-		return x.Offset
+		return x.LastTimestamp
 	}
 	return 0
 }
 
-// ListRunnersResponse defines the response containing runner list
-type ListRunnersResponse struct {
+// ContainerLog is the tail of a container's log
+type ContainerLog struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// List of runners
-	Runners []*Runner `protobuf:"bytes,1,rep,name=runners,proto3" json:"runners,omitempty"`
-	// Total count of runners (for pagination)
-	Total         int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	// Name of the container, e.g. runner or s3fs
+	Container string `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
+	Log       string `protobuf:"bytes,2,opt,name=log,proto3" json:"log,omitempty"`
+	// Why the log couldn't be read, e.g. because the container hasn't started
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListRunnersResponse) Reset() {
-	*x = ListRunnersResponse{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[6]
+func (x *ContainerLog) Reset() {
+	*x = ContainerLog{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListRunnersResponse) String() string {
+func (x *ContainerLog) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListRunnersResponse) ProtoMessage() {}
+func (*ContainerLog) ProtoMessage() {}
 
-func (x *ListRunnersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[6]
+func (x *ContainerLog) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -517,61 +4292,65 @@ func (x *ListRunnersResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListRunnersResponse.ProtoReflect.Descriptor instead.
-func (*ListRunnersResponse) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use ContainerLog.ProtoReflect.Descriptor instead.
+func (*ContainerLog) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{48}
 }
 
-func (x *ListRunnersResponse) GetRunners() []*Runner {
+func (x *ContainerLog) GetContainer() string {
 	if x != nil {
-		return x.Runners
+		return x.Container
 	}
-	return nil
+	return ""
 }
 
-func (x *ListRunnersResponse) GetTotal() int32 {
+func (x *ContainerLog) GetLog() string {
 	if x != nil {
-		return x.Total
+		return x.Log
 	}
-	return 0
+	return ""
 }
 
-// ExecuteCommandRequest defines the request to execute a command
-type ExecuteCommandRequest struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// ID of the runner to execute code in
-	RunnerId string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
-	// Command to execute
-	Command string `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
-	// Shell to use for the command (e.g., bash, sh, python)
-	Shell string `protobuf:"bytes,3,opt,name=shell,proto3" json:"shell,omitempty"`
-	// Timeout for execution (in seconds)
-	Timeout int32 `protobuf:"varint,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
-	// Working directory for execution
-	WorkingDir string `protobuf:"bytes,5,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`
-	// Workspace configuration for S3 mounting (used when auto-creating runners)
-	Workspace *WorkspaceConfig `protobuf:"bytes,6,opt,name=workspace,proto3" json:"workspace,omitempty"`
-	// Environment variables to set in the runner (used when auto-creating runners)
-	Env           map[string]string `protobuf:"bytes,7,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+func (x *ContainerLog) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// ExecutionRecord describes a command that ran in a runner
+type ExecutionRecord struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Command  string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	ExitCode int32                  `protobuf:"varint,2,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	// Why the command couldn't run or finish, if it didn't
+	Error string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// Combined output, only kept for the bootstrap command
+	Output string `protobuf:"bytes,4,opt,name=output,proto3" json:"output,omitempty"`
+	// Whether this was the runner's bootstrap command
+	Bootstrap bool `protobuf:"varint,5,opt,name=bootstrap,proto3" json:"bootstrap,omitempty"`
+	// When the command started and finished, as Unix timestamps
+	StartedAt     int64 `protobuf:"varint,6,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt    int64 `protobuf:"varint,7,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ExecuteCommandRequest) Reset() {
-	*x = ExecuteCommandRequest{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[7]
+func (x *ExecutionRecord) Reset() {
+	*x = ExecutionRecord{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ExecuteCommandRequest) String() string {
+func (x *ExecutionRecord) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ExecuteCommandRequest) ProtoMessage() {}
+func (*ExecutionRecord) ProtoMessage() {}
 
-func (x *ExecuteCommandRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[7]
+func (x *ExecutionRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -582,88 +4361,154 @@ func (x *ExecuteCommandRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ExecuteCommandRequest.ProtoReflect.Descriptor instead.
-func (*ExecuteCommandRequest) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use ExecutionRecord.ProtoReflect.Descriptor instead.
+func (*ExecutionRecord) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{49}
 }
 
-func (x *ExecuteCommandRequest) GetRunnerId() string {
+func (x *ExecutionRecord) GetCommand() string {
 	if x != nil {
-		return x.RunnerId
+		return x.Command
 	}
 	return ""
 }
 
-func (x *ExecuteCommandRequest) GetCommand() string {
+func (x *ExecutionRecord) GetExitCode() int32 {
 	if x != nil {
-		return x.Command
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *ExecutionRecord) GetError() string {
+	if x != nil {
+		return x.Error
 	}
 	return ""
 }
 
-func (x *ExecuteCommandRequest) GetShell() string {
+func (x *ExecutionRecord) GetOutput() string {
 	if x != nil {
-		return x.Shell
+		return x.Output
 	}
 	return ""
 }
 
-func (x *ExecuteCommandRequest) GetTimeout() int32 {
+func (x *ExecutionRecord) GetBootstrap() bool {
 	if x != nil {
-		return x.Timeout
+		return x.Bootstrap
+	}
+	return false
+}
+
+func (x *ExecutionRecord) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
 	}
 	return 0
 }
 
-func (x *ExecuteCommandRequest) GetWorkingDir() string {
+func (x *ExecutionRecord) GetFinishedAt() int64 {
 	if x != nil {
-		return x.WorkingDir
+		return x.FinishedAt
 	}
-	return ""
+	return 0
 }
 
-func (x *ExecuteCommandRequest) GetWorkspace() *WorkspaceConfig {
+// ResourceRequirements defines resource allocation for a runner
+type ResourceRequirements struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// CPU allocation (in millicores, e.g., 1000 = 1 CPU)
+	CpuMillicores int32 `protobuf:"varint,1,opt,name=cpu_millicores,json=cpuMillicores,proto3" json:"cpu_millicores,omitempty"`
+	// Memory allocation (in MB)
+	MemoryMb int32 `protobuf:"varint,2,opt,name=memory_mb,json=memoryMb,proto3" json:"memory_mb,omitempty"`
+	// Storage allocation (in GB)
+	StorageGb     int32 `protobuf:"varint,3,opt,name=storage_gb,json=storageGb,proto3" json:"storage_gb,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceRequirements) Reset() {
+	*x = ResourceRequirements{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceRequirements) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceRequirements) ProtoMessage() {}
+
+func (x *ResourceRequirements) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[50]
 	if x != nil {
-		return x.Workspace
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *ExecuteCommandRequest) GetEnv() map[string]string {
+// Deprecated: Use ResourceRequirements.ProtoReflect.Descriptor instead.
+func (*ResourceRequirements) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *ResourceRequirements) GetCpuMillicores() int32 {
 	if x != nil {
-		return x.Env
+		return x.CpuMillicores
 	}
-	return nil
+	return 0
 }
 
-// ExecuteCommandStreamResponse defines streaming response for command execution
-type ExecuteCommandStreamResponse struct {
+func (x *ResourceRequirements) GetMemoryMb() int32 {
+	if x != nil {
+		return x.MemoryMb
+	}
+	return 0
+}
+
+func (x *ResourceRequirements) GetStorageGb() int32 {
+	if x != nil {
+		return x.StorageGb
+	}
+	return 0
+}
+
+// SSHDetails contains SSH connection information
+type SSHDetails struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Type of data being streamed
-	Type StreamType `protobuf:"varint,1,opt,name=type,proto3,enum=grad.v1.StreamType" json:"type,omitempty"`
-	// Data content (stdout/stderr)
-	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
-	// Exit code (only present in final message when type = EXIT)
-	ExitCode      int32 `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	// SSH host
+	Host string `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	// SSH port
+	Port int32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	// SSH username
+	Username string `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	// SSH public key (for connection)
+	PublicKey     string `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ExecuteCommandStreamResponse) Reset() {
-	*x = ExecuteCommandStreamResponse{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[8]
+func (x *SSHDetails) Reset() {
+	*x = SSHDetails{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ExecuteCommandStreamResponse) String() string {
+func (x *SSHDetails) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ExecuteCommandStreamResponse) ProtoMessage() {}
+func (*SSHDetails) ProtoMessage() {}
 
-func (x *ExecuteCommandStreamResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[8]
+func (x *SSHDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -674,56 +4519,67 @@ func (x *ExecuteCommandStreamResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ExecuteCommandStreamResponse.ProtoReflect.Descriptor instead.
-func (*ExecuteCommandStreamResponse) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use SSHDetails.ProtoReflect.Descriptor instead.
+func (*SSHDetails) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{51}
 }
 
-func (x *ExecuteCommandStreamResponse) GetType() StreamType {
+func (x *SSHDetails) GetHost() string {
 	if x != nil {
-		return x.Type
+		return x.Host
 	}
-	return StreamType_STREAM_TYPE_UNSPECIFIED
+	return ""
 }
 
-func (x *ExecuteCommandStreamResponse) GetData() []byte {
+func (x *SSHDetails) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *SSHDetails) GetUsername() string {
 	if x != nil {
-		return x.Data
+		return x.Username
 	}
-	return nil
+	return ""
 }
 
-func (x *ExecuteCommandStreamResponse) GetExitCode() int32 {
+func (x *SSHDetails) GetPublicKey() string {
 	if x != nil {
-		return x.ExitCode
+		return x.PublicKey
 	}
-	return 0
+	return ""
 }
 
-// GetRunnerRequest defines the request to get runner details
-type GetRunnerRequest struct {
+// SetRunnerImageRequest defines the request to change the runner images
+type SetRunnerImageRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// ID of the runner to retrieve
-	RunnerId      string `protobuf:"bytes,1,opt,name=runner_id,json=runnerId,proto3" json:"runner_id,omitempty"`
+	// Runner image reference (optional, keeps the current one if empty)
+	Image string `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
+	// S3FS sidecar image reference (optional, keeps the current one if empty)
+	S3FsImage string `protobuf:"bytes,2,opt,name=s3fs_image,json=s3fsImage,proto3" json:"s3fs_image,omitempty"`
+	// Restore the images in use before the last change instead; image and s3fs_image must be empty
+	Revert        bool `protobuf:"varint,3,opt,name=revert,proto3" json:"revert,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetRunnerRequest) Reset() {
-	*x = GetRunnerRequest{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[9]
+func (x *SetRunnerImageRequest) Reset() {
+	*x = SetRunnerImageRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetRunnerRequest) String() string {
+func (x *SetRunnerImageRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetRunnerRequest) ProtoMessage() {}
+func (*SetRunnerImageRequest) ProtoMessage() {}
 
-func (x *GetRunnerRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[9]
+func (x *SetRunnerImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -734,42 +4590,58 @@ func (x *GetRunnerRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetRunnerRequest.ProtoReflect.Descriptor instead.
-func (*GetRunnerRequest) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use SetRunnerImageRequest.ProtoReflect.Descriptor instead.
+func (*SetRunnerImageRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *GetRunnerRequest) GetRunnerId() string {
+func (x *SetRunnerImageRequest) GetImage() string {
 	if x != nil {
-		return x.RunnerId
+		return x.Image
 	}
 	return ""
 }
 
-// GetRunnerResponse defines the response containing runner details
-type GetRunnerResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// The runner details
-	Runner        *Runner `protobuf:"bytes,1,opt,name=runner,proto3" json:"runner,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *SetRunnerImageRequest) GetS3FsImage() string {
+	if x != nil {
+		return x.S3FsImage
+	}
+	return ""
 }
 
-func (x *GetRunnerResponse) Reset() {
-	*x = GetRunnerResponse{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[10]
+func (x *SetRunnerImageRequest) GetRevert() bool {
+	if x != nil {
+		return x.Revert
+	}
+	return false
+}
+
+// SetRunnerImageResponse reports the images in use before and after the change
+type SetRunnerImageResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Image             string                 `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
+	S3FsImage         string                 `protobuf:"bytes,2,opt,name=s3fs_image,json=s3fsImage,proto3" json:"s3fs_image,omitempty"`
+	PreviousImage     string                 `protobuf:"bytes,3,opt,name=previous_image,json=previousImage,proto3" json:"previous_image,omitempty"`
+	PreviousS3FsImage string                 `protobuf:"bytes,4,opt,name=previous_s3fs_image,json=previousS3fsImage,proto3" json:"previous_s3fs_image,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *SetRunnerImageResponse) Reset() {
+	*x = SetRunnerImageResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetRunnerResponse) String() string {
+func (x *SetRunnerImageResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetRunnerResponse) ProtoMessage() {}
+func (*SetRunnerImageResponse) ProtoMessage() {}
 
-func (x *GetRunnerResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[10]
+func (x *SetRunnerImageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -780,58 +4652,65 @@ func (x *GetRunnerResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetRunnerResponse.ProtoReflect.Descriptor instead.
-func (*GetRunnerResponse) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use SetRunnerImageResponse.ProtoReflect.Descriptor instead.
+func (*SetRunnerImageResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{53}
 }
 
-func (x *GetRunnerResponse) GetRunner() *Runner {
+func (x *SetRunnerImageResponse) GetImage() string {
 	if x != nil {
-		return x.Runner
+		return x.Image
 	}
-	return nil
+	return ""
 }
 
-// Runner represents a runner instance
-type Runner struct {
+func (x *SetRunnerImageResponse) GetS3FsImage() string {
+	if x != nil {
+		return x.S3FsImage
+	}
+	return ""
+}
+
+func (x *SetRunnerImageResponse) GetPreviousImage() string {
+	if x != nil {
+		return x.PreviousImage
+	}
+	return ""
+}
+
+func (x *SetRunnerImageResponse) GetPreviousS3FsImage() string {
+	if x != nil {
+		return x.PreviousS3FsImage
+	}
+	return ""
+}
+
+// TailServerLogsRequest defines the request to tail grad's own logs
+type TailServerLogsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Unique identifier for the runner
-	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	// Name of the runner
-	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	// Current status of the runner
-	Status RunnerStatus `protobuf:"varint,3,opt,name=status,proto3,enum=grad.v1.RunnerStatus" json:"status,omitempty"`
-	// Resource requirements/allocation
-	Resources *ResourceRequirements `protobuf:"bytes,4,opt,name=resources,proto3" json:"resources,omitempty"`
-	// Creation timestamp
-	CreatedAt int64 `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	// Last updated timestamp
-	UpdatedAt int64 `protobuf:"varint,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	// SSH connection details
-	Ssh *SSHDetails `protobuf:"bytes,7,opt,name=ssh,proto3" json:"ssh,omitempty"`
-	// Runner's IP address
-	IpAddress string `protobuf:"bytes,8,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
-	// Environment variables
-	Env           map[string]string `protobuf:"bytes,9,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Keep streaming records as they are logged instead of ending after the recent ones
+	Follow bool `protobuf:"varint,1,opt,name=follow,proto3" json:"follow,omitempty"`
+	// Lowest level to stream: debug, info, warn or error (optional, all records if empty)
+	MinLevel      string `protobuf:"bytes,2,opt,name=min_level,json=minLevel,proto3" json:"min_level,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Runner) Reset() {
-	*x = Runner{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[11]
+func (x *TailServerLogsRequest) Reset() {
+	*x = TailServerLogsRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Runner) String() string {
+func (x *TailServerLogsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Runner) ProtoMessage() {}
+func (*TailServerLogsRequest) ProtoMessage() {}
 
-func (x *Runner) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[11]
+func (x *TailServerLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -842,102 +4721,119 @@ func (x *Runner) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Runner.ProtoReflect.Descriptor instead.
-func (*Runner) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use TailServerLogsRequest.ProtoReflect.Descriptor instead.
+func (*TailServerLogsRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *Runner) GetId() string {
+func (x *TailServerLogsRequest) GetFollow() bool {
 	if x != nil {
-		return x.Id
+		return x.Follow
 	}
-	return ""
+	return false
 }
 
-func (x *Runner) GetName() string {
+func (x *TailServerLogsRequest) GetMinLevel() string {
 	if x != nil {
-		return x.Name
+		return x.MinLevel
 	}
 	return ""
 }
 
-func (x *Runner) GetStatus() RunnerStatus {
-	if x != nil {
-		return x.Status
-	}
-	return RunnerStatus_RUNNER_STATUS_UNSPECIFIED
+// ServerLogRecord is one of grad's log records, with secrets already redacted
+type ServerLogRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// When the record was logged (Unix milliseconds)
+	TimeUnixMs int64 `protobuf:"varint,1,opt,name=time_unix_ms,json=timeUnixMs,proto3" json:"time_unix_ms,omitempty"`
+	// Level, e.g. INFO or WARN
+	Level   string `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
+	Message string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// The record as grad logged it, one JSON object with every attribute
+	Json          string `protobuf:"bytes,4,opt,name=json,proto3" json:"json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Runner) GetResources() *ResourceRequirements {
-	if x != nil {
-		return x.Resources
-	}
-	return nil
+func (x *ServerLogRecord) Reset() {
+	*x = ServerLogRecord{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *Runner) GetCreatedAt() int64 {
+func (x *ServerLogRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerLogRecord) ProtoMessage() {}
+
+func (x *ServerLogRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[55]
 	if x != nil {
-		return x.CreatedAt
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *Runner) GetUpdatedAt() int64 {
+// Deprecated: Use ServerLogRecord.ProtoReflect.Descriptor instead.
+func (*ServerLogRecord) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *ServerLogRecord) GetTimeUnixMs() int64 {
 	if x != nil {
-		return x.UpdatedAt
+		return x.TimeUnixMs
 	}
 	return 0
 }
 
-func (x *Runner) GetSsh() *SSHDetails {
+func (x *ServerLogRecord) GetLevel() string {
 	if x != nil {
-		return x.Ssh
+		return x.Level
 	}
-	return nil
+	return ""
 }
 
-func (x *Runner) GetIpAddress() string {
+func (x *ServerLogRecord) GetMessage() string {
 	if x != nil {
-		return x.IpAddress
+		return x.Message
 	}
 	return ""
 }
 
-func (x *Runner) GetEnv() map[string]string {
+func (x *ServerLogRecord) GetJson() string {
 	if x != nil {
-		return x.Env
+		return x.Json
 	}
-	return nil
+	return ""
 }
 
-// ResourceRequirements defines resource allocation for a runner
-type ResourceRequirements struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// CPU allocation (in millicores, e.g., 1000 = 1 CPU)
-	CpuMillicores int32 `protobuf:"varint,1,opt,name=cpu_millicores,json=cpuMillicores,proto3" json:"cpu_millicores,omitempty"`
-	// Memory allocation (in MB)
-	MemoryMb int32 `protobuf:"varint,2,opt,name=memory_mb,json=memoryMb,proto3" json:"memory_mb,omitempty"`
-	// Storage allocation (in GB)
-	StorageGb     int32 `protobuf:"varint,3,opt,name=storage_gb,json=storageGb,proto3" json:"storage_gb,omitempty"`
+// ReloadConfigRequest defines the request to reload grad's configuration
+type ReloadConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ResourceRequirements) Reset() {
-	*x = ResourceRequirements{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[12]
+func (x *ReloadConfigRequest) Reset() {
+	*x = ReloadConfigRequest{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResourceRequirements) String() string {
+func (x *ReloadConfigRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResourceRequirements) ProtoMessage() {}
+func (*ReloadConfigRequest) ProtoMessage() {}
 
-func (x *ResourceRequirements) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[12]
+func (x *ReloadConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -948,62 +4844,38 @@ func (x *ResourceRequirements) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResourceRequirements.ProtoReflect.Descriptor instead.
-func (*ResourceRequirements) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{12}
-}
-
-func (x *ResourceRequirements) GetCpuMillicores() int32 {
-	if x != nil {
-		return x.CpuMillicores
-	}
-	return 0
-}
-
-func (x *ResourceRequirements) GetMemoryMb() int32 {
-	if x != nil {
-		return x.MemoryMb
-	}
-	return 0
-}
-
-func (x *ResourceRequirements) GetStorageGb() int32 {
-	if x != nil {
-		return x.StorageGb
-	}
-	return 0
+// Deprecated: Use ReloadConfigRequest.ProtoReflect.Descriptor instead.
+func (*ReloadConfigRequest) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{56}
 }
 
-// SSHDetails contains SSH connection information
-type SSHDetails struct {
+// ReloadConfigResponse lists the settings that changed since grad loaded its configuration, by
+// environment variable
+type ReloadConfigResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// SSH host
-	Host string `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
-	// SSH port
-	Port int32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
-	// SSH username
-	Username string `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
-	// SSH public key (for connection)
-	PublicKey     string `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	// Changed settings now in effect
+	AppliedKeys []string `protobuf:"bytes,1,rep,name=applied_keys,json=appliedKeys,proto3" json:"applied_keys,omitempty"`
+	// Changed settings that only take effect when grad restarts
+	IgnoredKeys   []string `protobuf:"bytes,2,rep,name=ignored_keys,json=ignoredKeys,proto3" json:"ignored_keys,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SSHDetails) Reset() {
-	*x = SSHDetails{}
-	mi := &file_grad_v1_runner_service_proto_msgTypes[13]
+func (x *ReloadConfigResponse) Reset() {
+	*x = ReloadConfigResponse{}
+	mi := &file_grad_v1_runner_service_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SSHDetails) String() string {
+func (x *ReloadConfigResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SSHDetails) ProtoMessage() {}
+func (*ReloadConfigResponse) ProtoMessage() {}
 
-func (x *SSHDetails) ProtoReflect() protoreflect.Message {
-	mi := &file_grad_v1_runner_service_proto_msgTypes[13]
+func (x *ReloadConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grad_v1_runner_service_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1014,70 +4886,167 @@ func (x *SSHDetails) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SSHDetails.ProtoReflect.Descriptor instead.
-func (*SSHDetails) Descriptor() ([]byte, []int) {
-	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{13}
-}
-
-func (x *SSHDetails) GetHost() string {
-	if x != nil {
-		return x.Host
-	}
-	return ""
-}
-
-func (x *SSHDetails) GetPort() int32 {
-	if x != nil {
-		return x.Port
-	}
-	return 0
+// Deprecated: Use ReloadConfigResponse.ProtoReflect.Descriptor instead.
+func (*ReloadConfigResponse) Descriptor() ([]byte, []int) {
+	return file_grad_v1_runner_service_proto_rawDescGZIP(), []int{57}
 }
 
-func (x *SSHDetails) GetUsername() string {
+func (x *ReloadConfigResponse) GetAppliedKeys() []string {
 	if x != nil {
-		return x.Username
+		return x.AppliedKeys
 	}
-	return ""
+	return nil
 }
 
-func (x *SSHDetails) GetPublicKey() string {
+func (x *ReloadConfigResponse) GetIgnoredKeys() []string {
 	if x != nil {
-		return x.PublicKey
+		return x.IgnoredKeys
 	}
-	return ""
+	return nil
 }
 
 var File_grad_v1_runner_service_proto protoreflect.FileDescriptor
 
 const file_grad_v1_runner_service_proto_rawDesc = "" +
 	"\n" +
-	"\x1cgrad/v1/runner_service.proto\x12\agrad.v1\"\xd2\x01\n" +
+	"\x1cgrad/v1/runner_service.proto\x12\agrad.v1\"\xcc\x06\n" +
 	"\x13CreateRunnerRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x127\n" +
 	"\x03env\x18\x02 \x03(\v2%.grad.v1.CreateRunnerRequest.EnvEntryR\x03env\x126\n" +
-	"\tworkspace\x18\x03 \x01(\v2\x18.grad.v1.WorkspaceConfigR\tworkspace\x1a6\n" +
+	"\tworkspace\x18\x03 \x01(\v2\x18.grad.v1.WorkspaceConfigR\tworkspace\x12+\n" +
+	"\x11bootstrap_command\x18\x04 \x01(\tR\x10bootstrapCommand\x12#\n" +
+	"\rdisable_proxy\x18\x05 \x01(\bR\fdisableProxy\x12'\n" +
+	"\x0fservice_account\x18\x06 \x01(\tR\x0eserviceAccount\x12A\n" +
+	"\x1ddisable_service_account_token\x18\a \x01(\bR\x1adisableServiceAccountToken\x12#\n" +
+	"\rdefault_shell\x18\b \x01(\tR\fdefaultShell\x12'\n" +
+	"\x0fdefault_workdir\x18\t \x01(\tR\x0edefaultWorkdir\x12 \n" +
+	"\vdescription\x18\n" +
+	" \x01(\tR\vdescription\x12\x14\n" +
+	"\x05group\x18\v \x01(\tR\x05group\x125\n" +
+	"\fhost_aliases\x18\f \x03(\v2\x12.grad.v1.HostAliasR\vhostAliases\x12&\n" +
+	"\fauto_restart\x18\r \x01(\bH\x00R\vautoRestart\x88\x01\x01\x12%\n" +
+	"\x0ereserved_until\x18\x0e \x01(\x03R\rreservedUntil\x12.\n" +
+	"\x13priority_class_name\x18\x0f \x01(\tR\x11priorityClassName\x12'\n" +
+	"\x0fskip_validation\x18\x10 \x01(\bR\x0eskipValidation\x12\x12\n" +
+	"\x04arch\x18\x11 \x01(\tR\x04arch\x12\x1c\n" +
+	"\tprotected\x18\x12 \x01(\bR\tprotected\x12\x12\n" +
+	"\x04spot\x18\x13 \x01(\bR\x04spot\x1a6\n" +
 	"\bEnvEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x92\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\x0f\n" +
+	"\r_auto_restart\"9\n" +
+	"\tHostAlias\x12\x0e\n" +
+	"\x02ip\x18\x01 \x01(\tR\x02ip\x12\x1c\n" +
+	"\thostnames\x18\x02 \x03(\tR\thostnames\"\xce\x01\n" +
 	"\x0fWorkspaceConfig\x12\x16\n" +
 	"\x06bucket\x18\x01 \x01(\tR\x06bucket\x12\x1a\n" +
 	"\bendpoint\x18\x02 \x01(\tR\bendpoint\x12\x16\n" +
 	"\x06prefix\x18\x03 \x01(\tR\x06prefix\x12\x16\n" +
 	"\x06region\x18\x04 \x01(\tR\x06region\x12\x1b\n" +
-	"\tread_only\x18\x05 \x01(\bR\breadOnly\"?\n" +
+	"\tread_only\x18\x05 \x01(\bR\breadOnly\x12\x19\n" +
+	"\bs3fs_cpu\x18\x06 \x01(\tR\as3fsCpu\x12\x1f\n" +
+	"\vs3fs_memory\x18\a \x01(\tR\n" +
+	"s3fsMemory\"[\n" +
 	"\x14CreateRunnerResponse\x12'\n" +
-	"\x06runner\x18\x01 \x01(\v2\x0f.grad.v1.RunnerR\x06runner\"2\n" +
+	"\x06runner\x18\x01 \x01(\v2\x0f.grad.v1.RunnerR\x06runner\x12\x1a\n" +
+	"\bwarnings\x18\x02 \x03(\tR\bwarnings\"\xa9\x01\n" +
 	"\x13DeleteRunnerRequest\x12\x1b\n" +
-	"\trunner_id\x18\x01 \x01(\tR\brunnerId\"0\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\x12\x12\n" +
+	"\x04wait\x18\x02 \x01(\bR\x04wait\x120\n" +
+	"\x14wait_timeout_seconds\x18\x03 \x01(\x05R\x12waitTimeoutSeconds\x12/\n" +
+	"\x13override_protection\x18\x04 \x01(\bR\x12overrideProtection\"\xd7\x01\n" +
 	"\x14DeleteRunnerResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\"q\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12+\n" +
+	"\x11deleted_resources\x18\x02 \x03(\tR\x10deletedResources\x12)\n" +
+	"\x10failed_resources\x18\x03 \x03(\tR\x0ffailedResources\x12\x1c\n" +
+	"\tcompleted\x18\x04 \x01(\bR\tcompleted\x12/\n" +
+	"\x13remaining_resources\x18\x05 \x03(\tR\x12remainingResources\"0\n" +
+	"\x18DeleteRunnerGroupRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\"\x7f\n" +
+	"\x19DeleteRunnerGroupResponse\x12,\n" +
+	"\x12deleted_runner_ids\x18\x01 \x03(\tR\x10deletedRunnerIds\x124\n" +
+	"\x06failed\x18\x02 \x03(\v2\x1c.grad.v1.RunnerDeleteFailureR\x06failed\"H\n" +
+	"\x13RunnerDeleteFailure\x12\x1b\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xba\x03\n" +
 	"\x12ListRunnersRequest\x12-\n" +
 	"\x06status\x18\x01 \x01(\x0e2\x15.grad.v1.RunnerStatusR\x06status\x12\x14\n" +
 	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
-	"\x06offset\x18\x03 \x01(\x05R\x06offset\"V\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06filter\x18\x04 \x01(\tR\x06filter\x12\x14\n" +
+	"\x05group\x18\x05 \x01(\tR\x05group\x12!\n" +
+	"\fimage_digest\x18\x06 \x01(\tR\vimageDigest\x12?\n" +
+	"\x06labels\x18\a \x03(\v2'.grad.v1.ListRunnersRequest.LabelsEntryR\x06labels\x12\x19\n" +
+	"\border_by\x18\b \x01(\tR\aorderBy\x12\x18\n" +
+	"\areverse\x18\t \x01(\bR\areverse\x12\x1d\n" +
+	"\n" +
+	"stale_only\x18\n" +
+	" \x01(\bR\tstaleOnly\x12\x12\n" +
+	"\x04arch\x18\v \x01(\tR\x04arch\x12\x12\n" +
+	"\x04name\x18\f \x01(\tR\x04name\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"V\n" +
 	"\x13ListRunnersResponse\x12)\n" +
 	"\arunners\x18\x01 \x03(\v2\x0f.grad.v1.RunnerR\arunners\x12\x14\n" +
-	"\x05total\x18\x02 \x01(\x05R\x05total\"\xca\x02\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"\xd9\x01\n" +
+	"\x13UpdateRunnerRequest\x12\x1b\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\x12%\n" +
+	"\vdescription\x18\x02 \x01(\tH\x00R\vdescription\x88\x01\x01\x12*\n" +
+	"\x0ereserved_until\x18\x03 \x01(\x03H\x01R\rreservedUntil\x88\x01\x01\x12!\n" +
+	"\tprotected\x18\x04 \x01(\bH\x02R\tprotected\x88\x01\x01B\x0e\n" +
+	"\f_descriptionB\x11\n" +
+	"\x0f_reserved_untilB\f\n" +
+	"\n" +
+	"_protected\"?\n" +
+	"\x14UpdateRunnerResponse\x12'\n" +
+	"\x06runner\x18\x01 \x01(\v2\x0f.grad.v1.RunnerR\x06runner\"R\n" +
+	"\x12CloneRunnerRequest\x12(\n" +
+	"\x10source_runner_id\x18\x01 \x01(\tR\x0esourceRunnerId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\">\n" +
+	"\x13CloneRunnerResponse\x12'\n" +
+	"\x06runner\x18\x01 \x01(\v2\x0f.grad.v1.RunnerR\x06runner\"\x16\n" +
+	"\x14GetServerInfoRequest\"\xc8\x02\n" +
+	"\x15GetServerInfoResponse\x12:\n" +
+	"\rstream_limits\x18\x01 \x01(\v2\x15.grad.v1.StreamLimitsR\fstreamLimits\x121\n" +
+	"\n" +
+	"build_info\x18\x02 \x01(\v2\x12.grad.v1.BuildInfoR\tbuildInfo\x12%\n" +
+	"\x0euptime_seconds\x18\x03 \x01(\x03R\ruptimeSeconds\x12-\n" +
+	"\x12kubernetes_version\x18\x04 \x01(\tR\x11kubernetesVersion\x12=\n" +
+	"\x0ecommand_limits\x18\x05 \x01(\v2\x16.grad.v1.CommandLimitsR\rcommandLimits\x12+\n" +
+	"\x11disabled_features\x18\x06 \x03(\tR\x10disabledFeatures\"{\n" +
+	"\tBuildInfo\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x16\n" +
+	"\x06commit\x18\x02 \x01(\tR\x06commit\x12\x1d\n" +
+	"\n" +
+	"build_date\x18\x03 \x01(\tR\tbuildDate\x12\x1d\n" +
+	"\n" +
+	"go_version\x18\x04 \x01(\tR\tgoVersion\"\x8c\x02\n" +
+	"\fStreamLimits\x124\n" +
+	"\x16max_concurrent_streams\x18\x01 \x01(\rR\x14maxConcurrentStreams\x12(\n" +
+	"\x10max_exec_streams\x18\x02 \x01(\x05R\x0emaxExecStreams\x12.\n" +
+	"\x13active_exec_streams\x18\x03 \x01(\x05R\x11activeExecStreams\x121\n" +
+	"\x15max_exec_output_bytes\x18\x04 \x01(\x03R\x12maxExecOutputBytes\x129\n" +
+	"\x19exec_resume_grace_seconds\x18\x05 \x01(\x05R\x16execResumeGraceSeconds\"V\n" +
+	"\rCommandLimits\x12*\n" +
+	"\x11max_command_bytes\x18\x01 \x01(\x05R\x0fmaxCommandBytes\x12\x19\n" +
+	"\bmax_args\x18\x02 \x01(\x05R\amaxArgs\"F\n" +
+	"\x15GetRunnerStatsRequest\x12-\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x15.grad.v1.RunnerStatusR\x06status\"\xc5\x03\n" +
+	"\x16GetRunnerStatsResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12J\n" +
+	"\tby_status\x18\x02 \x03(\v2-.grad.v1.GetRunnerStatsResponse.ByStatusEntryR\bbyStatus\x12J\n" +
+	"\tby_preset\x18\x03 \x03(\v2-.grad.v1.GetRunnerStatsResponse.ByPresetEntryR\bbyPreset\x12G\n" +
+	"\bby_owner\x18\x04 \x03(\v2,.grad.v1.GetRunnerStatsResponse.ByOwnerEntryR\abyOwner\x1a;\n" +
+	"\rByStatusEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a;\n" +
+	"\rByPresetEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a:\n" +
+	"\fByOwnerEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\xf3\x04\n" +
 	"\x15ExecuteCommandRequest\x12\x1b\n" +
 	"\trunner_id\x18\x01 \x01(\tR\brunnerId\x12\x18\n" +
 	"\acommand\x18\x02 \x01(\tR\acommand\x12\x14\n" +
@@ -1086,18 +5055,50 @@ const file_grad_v1_runner_service_proto_rawDesc = "" +
 	"\vworking_dir\x18\x05 \x01(\tR\n" +
 	"workingDir\x126\n" +
 	"\tworkspace\x18\x06 \x01(\v2\x18.grad.v1.WorkspaceConfigR\tworkspace\x129\n" +
-	"\x03env\x18\a \x03(\v2'.grad.v1.ExecuteCommandRequest.EnvEntryR\x03env\x1a6\n" +
+	"\x03env\x18\a \x03(\v2'.grad.v1.ExecuteCommandRequest.EnvEntryR\x03env\x12\x1c\n" +
+	"\tephemeral\x18\b \x01(\bR\tephemeral\x12\x1f\n" +
+	"\vkeep_runner\x18\t \x01(\bR\n" +
+	"keepRunner\x12(\n" +
+	"\x10max_output_bytes\x18\n" +
+	" \x01(\x03R\x0emaxOutputBytes\x12@\n" +
+	"\x0ftruncate_policy\x18\v \x01(\x0e2\x17.grad.v1.TruncatePolicyR\x0etruncatePolicy\x12\x1c\n" +
+	"\tresumable\x18\f \x01(\bR\tresumable\x12\x12\n" +
+	"\x04argv\x18\r \x03(\tR\x04argv\x12\x12\n" +
+	"\x04arch\x18\x0e \x01(\tR\x04arch\x126\n" +
+	"\x17disable_correlation_env\x18\x0f \x01(\bR\x15disableCorrelationEnv\x1a6\n" +
 	"\bEnvEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"x\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"X\n" +
+	"\x16AttachExecutionRequest\x12\x17\n" +
+	"\aexec_id\x18\x01 \x01(\tR\x06execId\x12%\n" +
+	"\x0eafter_sequence\x18\x02 \x01(\x03R\rafterSequence\"\xb6\x05\n" +
 	"\x1cExecuteCommandStreamResponse\x12'\n" +
 	"\x04type\x18\x01 \x01(\x0e2\x13.grad.v1.StreamTypeR\x04type\x12\x12\n" +
 	"\x04data\x18\x02 \x01(\fR\x04data\x12\x1b\n" +
-	"\texit_code\x18\x03 \x01(\x05R\bexitCode\"/\n" +
+	"\texit_code\x18\x03 \x01(\x05R\bexitCode\x12:\n" +
+	"\x0efailure_reason\x18\x04 \x01(\x0e2\x13.grad.v1.ExitReasonR\rfailureReason\x12\x16\n" +
+	"\x06signal\x18\x05 \x01(\tR\x06signal\x12\x1d\n" +
+	"\n" +
+	"likely_oom\x18\x06 \x01(\bR\tlikelyOom\x12)\n" +
+	"\x10output_truncated\x18\a \x01(\bR\x0foutputTruncated\x12!\n" +
+	"\fstdout_bytes\x18\b \x01(\x03R\vstdoutBytes\x12!\n" +
+	"\fstderr_bytes\x18\t \x01(\x03R\vstderrBytes\x12(\n" +
+	"\x10max_output_bytes\x18\n" +
+	" \x01(\x03R\x0emaxOutputBytes\x12\x17\n" +
+	"\aexec_id\x18\v \x01(\tR\x06execId\x12\x1a\n" +
+	"\bsequence\x18\f \x01(\x03R\bsequence\x12\x1b\n" +
+	"\trunner_id\x18\r \x01(\tR\brunnerId\x12\x14\n" +
+	"\x05shell\x18\x0e \x01(\tR\x05shell\x12\x1f\n" +
+	"\vworking_dir\x18\x0f \x01(\tR\n" +
+	"workingDir\x12b\n" +
+	"\x0fcorrelation_env\x18\x10 \x03(\v29.grad.v1.ExecuteCommandStreamResponse.CorrelationEnvEntryR\x0ecorrelationEnv\x1aA\n" +
+	"\x13CorrelationEnvEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"/\n" +
 	"\x10GetRunnerRequest\x12\x1b\n" +
 	"\trunner_id\x18\x01 \x01(\tR\brunnerId\"<\n" +
 	"\x11GetRunnerResponse\x12'\n" +
-	"\x06runner\x18\x01 \x01(\v2\x0f.grad.v1.RunnerR\x06runner\"\x80\x03\n" +
+	"\x06runner\x18\x01 \x01(\v2\x0f.grad.v1.RunnerR\x06runner\"\x81\v\n" +
 	"\x06Runner\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12-\n" +
@@ -1110,10 +5111,142 @@ const file_grad_v1_runner_service_proto_rawDesc = "" +
 	"\x03ssh\x18\a \x01(\v2\x13.grad.v1.SSHDetailsR\x03ssh\x12\x1d\n" +
 	"\n" +
 	"ip_address\x18\b \x01(\tR\tipAddress\x12*\n" +
-	"\x03env\x18\t \x03(\v2\x18.grad.v1.Runner.EnvEntryR\x03env\x1a6\n" +
+	"\x03env\x18\t \x03(\v2\x18.grad.v1.Runner.EnvEntryR\x03env\x12#\n" +
+	"\rstatus_detail\x18\n" +
+	" \x01(\tR\fstatusDetail\x12+\n" +
+	"\x11bootstrap_command\x18\v \x01(\tR\x10bootstrapCommand\x128\n" +
+	"\x18provisioning_duration_ms\x18\f \x01(\x03R\x16provisioningDurationMs\x12%\n" +
+	"\x0enetwork_policy\x18\r \x01(\tR\rnetworkPolicy\x12#\n" +
+	"\rdefault_shell\x18\x0e \x01(\tR\fdefaultShell\x12'\n" +
+	"\x0fdefault_workdir\x18\x0f \x01(\tR\x0edefaultWorkdir\x12 \n" +
+	"\vdescription\x18\x10 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05group\x18\x11 \x01(\tR\x05group\x125\n" +
+	"\fhost_aliases\x18\x12 \x03(\v2\x12.grad.v1.HostAliasR\vhostAliases\x12\x14\n" +
+	"\x05image\x18\x13 \x01(\tR\x05image\x12!\n" +
+	"\fimage_digest\x18\x14 \x01(\tR\vimageDigest\x12#\n" +
+	"\rrestart_count\x18\x15 \x01(\x05R\frestartCount\x12!\n" +
+	"\fauto_restart\x18\x16 \x01(\bR\vautoRestart\x12*\n" +
+	"\x05usage\x18\x17 \x01(\v2\x14.grad.v1.RunnerUsageR\x05usage\x12%\n" +
+	"\x0ereserved_until\x18\x18 \x01(\x03R\rreservedUntil\x12=\n" +
+	"\ferror_reason\x18\x19 \x01(\x0e2\x1a.grad.v1.RunnerErrorReasonR\verrorReason\x12#\n" +
+	"\rerror_message\x18\x1a \x01(\tR\ferrorMessage\x12\x1f\n" +
+	"\vstale_image\x18\x1b \x01(\bR\n" +
+	"staleImage\x12!\n" +
+	"\fssh_sessions\x18\x1c \x01(\x05R\vsshSessions\x12$\n" +
+	"\x0elast_ssh_login\x18\x1d \x01(\x03R\flastSshLogin\x12.\n" +
+	"\x13priority_class_name\x18\x1e \x01(\tR\x11priorityClassName\x12\x12\n" +
+	"\x04arch\x18\x1f \x01(\tR\x04arch\x128\n" +
+	"\n" +
+	"conditions\x18  \x03(\v2\x18.grad.v1.RunnerConditionR\n" +
+	"conditions\x12=\n" +
+	"\x0eworkspace_mode\x18! \x01(\x0e2\x16.grad.v1.WorkspaceModeR\rworkspaceMode\x12\x1c\n" +
+	"\tprotected\x18\" \x01(\bR\tprotected\x12\x12\n" +
+	"\x04spot\x18# \x01(\bR\x04spot\x1a6\n" +
 	"\bEnvEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"y\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbb\x01\n" +
+	"\x0fRunnerCondition\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x120\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x18.grad.v1.ConditionStatusR\x06status\x120\n" +
+	"\x14last_transition_time\x18\x03 \x01(\x03R\x12lastTransitionTime\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\"\xae\x02\n" +
+	"\vRunnerUsage\x12\x1b\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x1b\n" +
+	"\twindow_ms\x18\x03 \x01(\x03R\bwindowMs\x12%\n" +
+	"\x0ecpu_millicores\x18\x04 \x01(\x03R\rcpuMillicores\x12!\n" +
+	"\fmemory_bytes\x18\x05 \x01(\x03R\vmemoryBytes\x127\n" +
+	"\n" +
+	"containers\x18\x06 \x03(\v2\x17.grad.v1.ContainerUsageR\n" +
+	"containers\x12!\n" +
+	"\fstdout_bytes\x18\a \x01(\x03R\vstdoutBytes\x12!\n" +
+	"\fstderr_bytes\x18\b \x01(\x03R\vstderrBytes\"n\n" +
+	"\x0eContainerUsage\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12%\n" +
+	"\x0ecpu_millicores\x18\x02 \x01(\x03R\rcpuMillicores\x12!\n" +
+	"\fmemory_bytes\x18\x03 \x01(\x03R\vmemoryBytes\"6\n" +
+	"\x17GetRunnerMetricsRequest\x12\x1b\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\"F\n" +
+	"\x18GetRunnerMetricsResponse\x12*\n" +
+	"\x05usage\x18\x01 \x01(\v2\x14.grad.v1.RunnerUsageR\x05usage\"\x1a\n" +
+	"\x18ListRunnerMetricsRequest\"G\n" +
+	"\x19ListRunnerMetricsResponse\x12*\n" +
+	"\x05usage\x18\x01 \x03(\v2\x14.grad.v1.RunnerUsageR\x05usage\"Y\n" +
+	"\x1bGetRunnerDiagnosticsRequest\x12\x1b\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\x12\x1d\n" +
+	"\n" +
+	"tail_lines\x18\x02 \x01(\x05R\ttailLines\"\xcc\x01\n" +
+	"\x1cGetRunnerDiagnosticsResponse\x12\x19\n" +
+	"\bpod_json\x18\x01 \x01(\tR\apodJson\x12,\n" +
+	"\x06events\x18\x02 \x03(\v2\x14.grad.v1.RunnerEventR\x06events\x12)\n" +
+	"\x04logs\x18\x03 \x03(\v2\x15.grad.v1.ContainerLogR\x04logs\x128\n" +
+	"\n" +
+	"executions\x18\x04 \x03(\v2\x18.grad.v1.ExecutionRecordR\n" +
+	"executions\"X\n" +
+	"\x06SSHKey\x12 \n" +
+	"\vfingerprint\x18\x01 \x01(\tR\vfingerprint\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\acomment\x18\x03 \x01(\tR\acomment\"N\n" +
+	"\x10AddSSHKeyRequest\x12\x1b\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tR\tpublicKey\"L\n" +
+	"\x11AddSSHKeyResponse\x12!\n" +
+	"\x03key\x18\x01 \x01(\v2\x0f.grad.v1.SSHKeyR\x03key\x12\x14\n" +
+	"\x05added\x18\x02 \x01(\bR\x05added\"1\n" +
+	"\x12ListSSHKeysRequest\x12\x1b\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\":\n" +
+	"\x13ListSSHKeysResponse\x12#\n" +
+	"\x04keys\x18\x01 \x03(\v2\x0f.grad.v1.SSHKeyR\x04keys\"T\n" +
+	"\x13RemoveSSHKeyRequest\x12\x1b\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\x12 \n" +
+	"\vfingerprint\x18\x02 \x01(\tR\vfingerprint\"0\n" +
+	"\x14RemoveSSHKeyResponse\x12\x18\n" +
+	"\aremoved\x18\x01 \x01(\bR\aremoved\"\xc7\x01\n" +
+	"\x18SnapshotWorkspaceRequest\x12\x1b\n" +
+	"\trunner_id\x18\x01 \x01(\tR\brunnerId\x12 \n" +
+	"\vdestination\x18\x02 \x01(\tR\vdestination\x12\x1a\n" +
+	"\bendpoint\x18\x03 \x01(\tR\bendpoint\x12\x16\n" +
+	"\x06region\x18\x04 \x01(\tR\x06region\x128\n" +
+	"\vcredentials\x18\x05 \x01(\v2\x16.grad.v1.S3CredentialsR\vcredentials\"\x84\x01\n" +
+	"\rS3Credentials\x12\"\n" +
+	"\raccess_key_id\x18\x01 \x01(\tR\vaccessKeyId\x12*\n" +
+	"\x11secret_access_key\x18\x02 \x01(\tR\x0fsecretAccessKey\x12#\n" +
+	"\rsession_token\x18\x03 \x01(\tR\fsessionToken\"\xd2\x01\n" +
+	"\x19SnapshotWorkspaceResponse\x12,\n" +
+	"\x05phase\x18\x01 \x01(\x0e2\x16.grad.v1.SnapshotPhaseR\x05phase\x12\x1d\n" +
+	"\n" +
+	"bytes_done\x18\x02 \x01(\x03R\tbytesDone\x12\x1f\n" +
+	"\vbytes_total\x18\x03 \x01(\x03R\n" +
+	"bytesTotal\x12\x16\n" +
+	"\x06bucket\x18\x04 \x01(\tR\x06bucket\x12\x10\n" +
+	"\x03key\x18\x05 \x01(\tR\x03key\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x06 \x01(\x03R\tsizeBytes\"\xd8\x01\n" +
+	"\vRunnerEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"field_path\x18\x04 \x01(\tR\tfieldPath\x12\x14\n" +
+	"\x05count\x18\x05 \x01(\x05R\x05count\x12'\n" +
+	"\x0ffirst_timestamp\x18\x06 \x01(\x03R\x0efirstTimestamp\x12%\n" +
+	"\x0elast_timestamp\x18\a \x01(\x03R\rlastTimestamp\"T\n" +
+	"\fContainerLog\x12\x1c\n" +
+	"\tcontainer\x18\x01 \x01(\tR\tcontainer\x12\x10\n" +
+	"\x03log\x18\x02 \x01(\tR\x03log\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xd4\x01\n" +
+	"\x0fExecutionRecord\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x1b\n" +
+	"\texit_code\x18\x02 \x01(\x05R\bexitCode\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x16\n" +
+	"\x06output\x18\x04 \x01(\tR\x06output\x12\x1c\n" +
+	"\tbootstrap\x18\x05 \x01(\bR\tbootstrap\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x06 \x01(\x03R\tstartedAt\x12\x1f\n" +
+	"\vfinished_at\x18\a \x01(\x03R\n" +
+	"finishedAt\"y\n" +
 	"\x14ResourceRequirements\x12%\n" +
 	"\x0ecpu_millicores\x18\x01 \x01(\x05R\rcpuMillicores\x12\x1b\n" +
 	"\tmemory_mb\x18\x02 \x01(\x05R\bmemoryMb\x12\x1d\n" +
@@ -1125,28 +5258,109 @@ const file_grad_v1_runner_service_proto_rawDesc = "" +
 	"\x04port\x18\x02 \x01(\x05R\x04port\x12\x1a\n" +
 	"\busername\x18\x03 \x01(\tR\busername\x12\x1d\n" +
 	"\n" +
-	"public_key\x18\x04 \x01(\tR\tpublicKey*o\n" +
+	"public_key\x18\x04 \x01(\tR\tpublicKey\"d\n" +
+	"\x15SetRunnerImageRequest\x12\x14\n" +
+	"\x05image\x18\x01 \x01(\tR\x05image\x12\x1d\n" +
+	"\n" +
+	"s3fs_image\x18\x02 \x01(\tR\ts3fsImage\x12\x16\n" +
+	"\x06revert\x18\x03 \x01(\bR\x06revert\"\xa4\x01\n" +
+	"\x16SetRunnerImageResponse\x12\x14\n" +
+	"\x05image\x18\x01 \x01(\tR\x05image\x12\x1d\n" +
+	"\n" +
+	"s3fs_image\x18\x02 \x01(\tR\ts3fsImage\x12%\n" +
+	"\x0eprevious_image\x18\x03 \x01(\tR\rpreviousImage\x12.\n" +
+	"\x13previous_s3fs_image\x18\x04 \x01(\tR\x11previousS3fsImage\"L\n" +
+	"\x15TailServerLogsRequest\x12\x16\n" +
+	"\x06follow\x18\x01 \x01(\bR\x06follow\x12\x1b\n" +
+	"\tmin_level\x18\x02 \x01(\tR\bminLevel\"w\n" +
+	"\x0fServerLogRecord\x12 \n" +
+	"\ftime_unix_ms\x18\x01 \x01(\x03R\n" +
+	"timeUnixMs\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\tR\x05level\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x12\n" +
+	"\x04json\x18\x04 \x01(\tR\x04json\"\x15\n" +
+	"\x13ReloadConfigRequest\"\\\n" +
+	"\x14ReloadConfigResponse\x12!\n" +
+	"\fapplied_keys\x18\x01 \x03(\tR\vappliedKeys\x12!\n" +
+	"\fignored_keys\x18\x02 \x03(\tR\vignoredKeys*h\n" +
+	"\x0eTruncatePolicy\x12\x1f\n" +
+	"\x1bTRUNCATE_POLICY_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14TRUNCATE_POLICY_KILL\x10\x01\x12\x1b\n" +
+	"\x17TRUNCATE_POLICY_DISCARD\x10\x02*\xad\x01\n" +
+	"\n" +
+	"ExitReason\x12\x1b\n" +
+	"\x17EXIT_REASON_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12EXIT_REASON_EXITED\x10\x01\x12\x18\n" +
+	"\x14EXIT_REASON_SIGNALED\x10\x02\x12\x17\n" +
+	"\x13EXIT_REASON_TIMEOUT\x10\x03\x12\x19\n" +
+	"\x15EXIT_REASON_CANCELLED\x10\x04\x12\x1c\n" +
+	"\x18EXIT_REASON_OUTPUT_LIMIT\x10\x05*\x88\x01\n" +
 	"\n" +
 	"StreamType\x12\x1b\n" +
 	"\x17STREAM_TYPE_UNSPECIFIED\x10\x00\x12\x16\n" +
 	"\x12STREAM_TYPE_STDOUT\x10\x01\x12\x16\n" +
 	"\x12STREAM_TYPE_STDERR\x10\x02\x12\x14\n" +
-	"\x10STREAM_TYPE_EXIT\x10\x03*\xb4\x01\n" +
+	"\x10STREAM_TYPE_EXIT\x10\x03\x12\x17\n" +
+	"\x13STREAM_TYPE_STARTED\x10\x04*\x88\x01\n" +
+	"\x0fConditionStatus\x12 \n" +
+	"\x1cCONDITION_STATUS_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15CONDITION_STATUS_TRUE\x10\x01\x12\x1a\n" +
+	"\x16CONDITION_STATUS_FALSE\x10\x02\x12\x1c\n" +
+	"\x18CONDITION_STATUS_UNKNOWN\x10\x03*\x84\x01\n" +
+	"\rSnapshotPhase\x12\x1e\n" +
+	"\x1aSNAPSHOT_PHASE_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18SNAPSHOT_PHASE_ARCHIVING\x10\x01\x12\x1c\n" +
+	"\x18SNAPSHOT_PHASE_UPLOADING\x10\x02\x12\x17\n" +
+	"\x13SNAPSHOT_PHASE_DONE\x10\x03*\x94\x02\n" +
 	"\fRunnerStatus\x12\x1d\n" +
 	"\x19RUNNER_STATUS_UNSPECIFIED\x10\x00\x12\x1a\n" +
 	"\x16RUNNER_STATUS_CREATING\x10\x01\x12\x19\n" +
 	"\x15RUNNER_STATUS_RUNNING\x10\x02\x12\x1a\n" +
 	"\x16RUNNER_STATUS_STOPPING\x10\x03\x12\x19\n" +
 	"\x15RUNNER_STATUS_STOPPED\x10\x04\x12\x17\n" +
-	"\x13RUNNER_STATUS_ERROR\x10\x052\x98\x03\n" +
+	"\x13RUNNER_STATUS_ERROR\x10\x05\x12\x1f\n" +
+	"\x1bRUNNER_STATUS_BOOTSTRAPPING\x10\x06\x12\x1f\n" +
+	"\x1bRUNNER_STATUS_DELETE_FAILED\x10\a\x12\x1c\n" +
+	"\x18RUNNER_STATUS_RESTARTING\x10\b*\xba\x02\n" +
+	"\x11RunnerErrorReason\x12#\n" +
+	"\x1fRUNNER_ERROR_REASON_UNSPECIFIED\x10\x00\x12\"\n" +
+	"\x1eRUNNER_ERROR_REASON_POD_FAILED\x10\x01\x12)\n" +
+	"%RUNNER_ERROR_REASON_PROVISION_TIMEOUT\x10\x02\x12(\n" +
+	"$RUNNER_ERROR_REASON_BOOTSTRAP_FAILED\x10\x03\x12\"\n" +
+	"\x1eRUNNER_ERROR_REASON_IMAGE_PULL\x10\x04\x12\x1b\n" +
+	"\x17RUNNER_ERROR_REASON_OOM\x10\x05\x12\x1f\n" +
+	"\x1bRUNNER_ERROR_REASON_UNKNOWN\x10\x06\x12%\n" +
+	"!RUNNER_ERROR_REASON_NODE_SHUTDOWN\x10\a*\x95\x01\n" +
+	"\rWorkspaceMode\x12\x1e\n" +
+	"\x1aWORKSPACE_MODE_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19WORKSPACE_MODE_READ_WRITE\x10\x01\x12\x1c\n" +
+	"\x18WORKSPACE_MODE_READ_ONLY\x10\x02\x12'\n" +
+	"#WORKSPACE_MODE_READ_ONLY_UNENFORCED\x10\x032\xde\v\n" +
 	"\rRunnerService\x12K\n" +
 	"\fCreateRunner\x12\x1c.grad.v1.CreateRunnerRequest\x1a\x1d.grad.v1.CreateRunnerResponse\x12K\n" +
 	"\fDeleteRunner\x12\x1c.grad.v1.DeleteRunnerRequest\x1a\x1d.grad.v1.DeleteRunnerResponse\x12H\n" +
 	"\vListRunners\x12\x1b.grad.v1.ListRunnersRequest\x1a\x1c.grad.v1.ListRunnersResponse\x12_\n" +
 	"\x14ExecuteCommandStream\x12\x1e.grad.v1.ExecuteCommandRequest\x1a%.grad.v1.ExecuteCommandStreamResponse0\x01\x12B\n" +
-	"\tGetRunner\x12\x19.grad.v1.GetRunnerRequest\x1a\x1a.grad.v1.GetRunnerResponse2k\n" +
+	"\tGetRunner\x12\x19.grad.v1.GetRunnerRequest\x1a\x1a.grad.v1.GetRunnerResponse\x12Q\n" +
+	"\x0eGetRunnerStats\x12\x1e.grad.v1.GetRunnerStatsRequest\x1a\x1f.grad.v1.GetRunnerStatsResponse\x12K\n" +
+	"\fUpdateRunner\x12\x1c.grad.v1.UpdateRunnerRequest\x1a\x1d.grad.v1.UpdateRunnerResponse\x12H\n" +
+	"\vCloneRunner\x12\x1b.grad.v1.CloneRunnerRequest\x1a\x1c.grad.v1.CloneRunnerResponse\x12N\n" +
+	"\rGetServerInfo\x12\x1d.grad.v1.GetServerInfoRequest\x1a\x1e.grad.v1.GetServerInfoResponse\x12Z\n" +
+	"\x11DeleteRunnerGroup\x12!.grad.v1.DeleteRunnerGroupRequest\x1a\".grad.v1.DeleteRunnerGroupResponse\x12W\n" +
+	"\x10GetRunnerMetrics\x12 .grad.v1.GetRunnerMetricsRequest\x1a!.grad.v1.GetRunnerMetricsResponse\x12Z\n" +
+	"\x11ListRunnerMetrics\x12!.grad.v1.ListRunnerMetricsRequest\x1a\".grad.v1.ListRunnerMetricsResponse\x12c\n" +
+	"\x14GetRunnerDiagnostics\x12$.grad.v1.GetRunnerDiagnosticsRequest\x1a%.grad.v1.GetRunnerDiagnosticsResponse\x12B\n" +
+	"\tAddSSHKey\x12\x19.grad.v1.AddSSHKeyRequest\x1a\x1a.grad.v1.AddSSHKeyResponse\x12H\n" +
+	"\vListSSHKeys\x12\x1b.grad.v1.ListSSHKeysRequest\x1a\x1c.grad.v1.ListSSHKeysResponse\x12K\n" +
+	"\fRemoveSSHKey\x12\x1c.grad.v1.RemoveSSHKeyRequest\x1a\x1d.grad.v1.RemoveSSHKeyResponse\x12[\n" +
+	"\x0fAttachExecution\x12\x1f.grad.v1.AttachExecutionRequest\x1a%.grad.v1.ExecuteCommandStreamResponse0\x01\x12\\\n" +
+	"\x11SnapshotWorkspace\x12!.grad.v1.SnapshotWorkspaceRequest\x1a\".grad.v1.SnapshotWorkspaceResponse0\x012k\n" +
 	"\x0eExecuteService\x12Y\n" +
-	"\x0eExecuteCommand\x12\x1e.grad.v1.ExecuteCommandRequest\x1a%.grad.v1.ExecuteCommandStreamResponse0\x01B\x87\x01\n" +
+	"\x0eExecuteCommand\x12\x1e.grad.v1.ExecuteCommandRequest\x1a%.grad.v1.ExecuteCommandStreamResponse0\x012\xfc\x01\n" +
+	"\fAdminService\x12Q\n" +
+	"\x0eSetRunnerImage\x12\x1e.grad.v1.SetRunnerImageRequest\x1a\x1f.grad.v1.SetRunnerImageResponse\x12L\n" +
+	"\x0eTailServerLogs\x12\x1e.grad.v1.TailServerLogsRequest\x1a\x18.grad.v1.ServerLogRecord0\x01\x12K\n" +
+	"\fReloadConfig\x12\x1c.grad.v1.ReloadConfigRequest\x1a\x1d.grad.v1.ReloadConfigResponseB\x87\x01\n" +
 	"\vcom.grad.v1B\x12RunnerServiceProtoP\x01Z'github.com/strrl/gra/gen/grad/v1;gradv1\xa2\x02\x03GXX\xaa\x02\aGrad.V1\xca\x02\aGrad\\V1\xe2\x02\x13Grad\\V1\\GPBMetadata\xea\x02\bGrad::V1b\x06proto3"
 
 var (
@@ -1161,60 +5375,178 @@ func file_grad_v1_runner_service_proto_rawDescGZIP() []byte {
 	return file_grad_v1_runner_service_proto_rawDescData
 }
 
-var file_grad_v1_runner_service_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_grad_v1_runner_service_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_grad_v1_runner_service_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_grad_v1_runner_service_proto_msgTypes = make([]protoimpl.MessageInfo, 66)
 var file_grad_v1_runner_service_proto_goTypes = []any{
-	(StreamType)(0),                      // 0: grad.v1.StreamType
-	(RunnerStatus)(0),                    // 1: grad.v1.RunnerStatus
-	(*CreateRunnerRequest)(nil),          // 2: grad.v1.CreateRunnerRequest
-	(*WorkspaceConfig)(nil),              // 3: grad.v1.WorkspaceConfig
-	(*CreateRunnerResponse)(nil),         // 4: grad.v1.CreateRunnerResponse
-	(*DeleteRunnerRequest)(nil),          // 5: grad.v1.DeleteRunnerRequest
-	(*DeleteRunnerResponse)(nil),         // 6: grad.v1.DeleteRunnerResponse
-	(*ListRunnersRequest)(nil),           // 7: grad.v1.ListRunnersRequest
-	(*ListRunnersResponse)(nil),          // 8: grad.v1.ListRunnersResponse
-	(*ExecuteCommandRequest)(nil),        // 9: grad.v1.ExecuteCommandRequest
-	(*ExecuteCommandStreamResponse)(nil), // 10: grad.v1.ExecuteCommandStreamResponse
-	(*GetRunnerRequest)(nil),             // 11: grad.v1.GetRunnerRequest
-	(*GetRunnerResponse)(nil),            // 12: grad.v1.GetRunnerResponse
-	(*Runner)(nil),                       // 13: grad.v1.Runner
-	(*ResourceRequirements)(nil),         // 14: grad.v1.ResourceRequirements
-	(*SSHDetails)(nil),                   // 15: grad.v1.SSHDetails
-	nil,                                  // 16: grad.v1.CreateRunnerRequest.EnvEntry
-	nil,                                  // 17: grad.v1.ExecuteCommandRequest.EnvEntry
-	nil,                                  // 18: grad.v1.Runner.EnvEntry
+	(TruncatePolicy)(0),                  // 0: grad.v1.TruncatePolicy
+	(ExitReason)(0),                      // 1: grad.v1.ExitReason
+	(StreamType)(0),                      // 2: grad.v1.StreamType
+	(ConditionStatus)(0),                 // 3: grad.v1.ConditionStatus
+	(SnapshotPhase)(0),                   // 4: grad.v1.SnapshotPhase
+	(RunnerStatus)(0),                    // 5: grad.v1.RunnerStatus
+	(RunnerErrorReason)(0),               // 6: grad.v1.RunnerErrorReason
+	(WorkspaceMode)(0),                   // 7: grad.v1.WorkspaceMode
+	(*CreateRunnerRequest)(nil),          // 8: grad.v1.CreateRunnerRequest
+	(*HostAlias)(nil),                    // 9: grad.v1.HostAlias
+	(*WorkspaceConfig)(nil),              // 10: grad.v1.WorkspaceConfig
+	(*CreateRunnerResponse)(nil),         // 11: grad.v1.CreateRunnerResponse
+	(*DeleteRunnerRequest)(nil),          // 12: grad.v1.DeleteRunnerRequest
+	(*DeleteRunnerResponse)(nil),         // 13: grad.v1.DeleteRunnerResponse
+	(*DeleteRunnerGroupRequest)(nil),     // 14: grad.v1.DeleteRunnerGroupRequest
+	(*DeleteRunnerGroupResponse)(nil),    // 15: grad.v1.DeleteRunnerGroupResponse
+	(*RunnerDeleteFailure)(nil),          // 16: grad.v1.RunnerDeleteFailure
+	(*ListRunnersRequest)(nil),           // 17: grad.v1.ListRunnersRequest
+	(*ListRunnersResponse)(nil),          // 18: grad.v1.ListRunnersResponse
+	(*UpdateRunnerRequest)(nil),          // 19: grad.v1.UpdateRunnerRequest
+	(*UpdateRunnerResponse)(nil),         // 20: grad.v1.UpdateRunnerResponse
+	(*CloneRunnerRequest)(nil),           // 21: grad.v1.CloneRunnerRequest
+	(*CloneRunnerResponse)(nil),          // 22: grad.v1.CloneRunnerResponse
+	(*GetServerInfoRequest)(nil),         // 23: grad.v1.GetServerInfoRequest
+	(*GetServerInfoResponse)(nil),        // 24: grad.v1.GetServerInfoResponse
+	(*BuildInfo)(nil),                    // 25: grad.v1.BuildInfo
+	(*StreamLimits)(nil),                 // 26: grad.v1.StreamLimits
+	(*CommandLimits)(nil),                // 27: grad.v1.CommandLimits
+	(*GetRunnerStatsRequest)(nil),        // 28: grad.v1.GetRunnerStatsRequest
+	(*GetRunnerStatsResponse)(nil),       // 29: grad.v1.GetRunnerStatsResponse
+	(*ExecuteCommandRequest)(nil),        // 30: grad.v1.ExecuteCommandRequest
+	(*AttachExecutionRequest)(nil),       // 31: grad.v1.AttachExecutionRequest
+	(*ExecuteCommandStreamResponse)(nil), // 32: grad.v1.ExecuteCommandStreamResponse
+	(*GetRunnerRequest)(nil),             // 33: grad.v1.GetRunnerRequest
+	(*GetRunnerResponse)(nil),            // 34: grad.v1.GetRunnerResponse
+	(*Runner)(nil),                       // 35: grad.v1.Runner
+	(*RunnerCondition)(nil),              // 36: grad.v1.RunnerCondition
+	(*RunnerUsage)(nil),                  // 37: grad.v1.RunnerUsage
+	(*ContainerUsage)(nil),               // 38: grad.v1.ContainerUsage
+	(*GetRunnerMetricsRequest)(nil),      // 39: grad.v1.GetRunnerMetricsRequest
+	(*GetRunnerMetricsResponse)(nil),     // 40: grad.v1.GetRunnerMetricsResponse
+	(*ListRunnerMetricsRequest)(nil),     // 41: grad.v1.ListRunnerMetricsRequest
+	(*ListRunnerMetricsResponse)(nil),    // 42: grad.v1.ListRunnerMetricsResponse
+	(*GetRunnerDiagnosticsRequest)(nil),  // 43: grad.v1.GetRunnerDiagnosticsRequest
+	(*GetRunnerDiagnosticsResponse)(nil), // 44: grad.v1.GetRunnerDiagnosticsResponse
+	(*SSHKey)(nil),                       // 45: grad.v1.SSHKey
+	(*AddSSHKeyRequest)(nil),             // 46: grad.v1.AddSSHKeyRequest
+	(*AddSSHKeyResponse)(nil),            // 47: grad.v1.AddSSHKeyResponse
+	(*ListSSHKeysRequest)(nil),           // 48: grad.v1.ListSSHKeysRequest
+	(*ListSSHKeysResponse)(nil),          // 49: grad.v1.ListSSHKeysResponse
+	(*RemoveSSHKeyRequest)(nil),          // 50: grad.v1.RemoveSSHKeyRequest
+	(*RemoveSSHKeyResponse)(nil),         // 51: grad.v1.RemoveSSHKeyResponse
+	(*SnapshotWorkspaceRequest)(nil),     // 52: grad.v1.SnapshotWorkspaceRequest
+	(*S3Credentials)(nil),                // 53: grad.v1.S3Credentials
+	(*SnapshotWorkspaceResponse)(nil),    // 54: grad.v1.SnapshotWorkspaceResponse
+	(*RunnerEvent)(nil),                  // 55: grad.v1.RunnerEvent
+	(*ContainerLog)(nil),                 // 56: grad.v1.ContainerLog
+	(*ExecutionRecord)(nil),              // 57: grad.v1.ExecutionRecord
+	(*ResourceRequirements)(nil),         // 58: grad.v1.ResourceRequirements
+	(*SSHDetails)(nil),                   // 59: grad.v1.SSHDetails
+	(*SetRunnerImageRequest)(nil),        // 60: grad.v1.SetRunnerImageRequest
+	(*SetRunnerImageResponse)(nil),       // 61: grad.v1.SetRunnerImageResponse
+	(*TailServerLogsRequest)(nil),        // 62: grad.v1.TailServerLogsRequest
+	(*ServerLogRecord)(nil),              // 63: grad.v1.ServerLogRecord
+	(*ReloadConfigRequest)(nil),          // 64: grad.v1.ReloadConfigRequest
+	(*ReloadConfigResponse)(nil),         // 65: grad.v1.ReloadConfigResponse
+	nil,                                  // 66: grad.v1.CreateRunnerRequest.EnvEntry
+	nil,                                  // 67: grad.v1.ListRunnersRequest.LabelsEntry
+	nil,                                  // 68: grad.v1.GetRunnerStatsResponse.ByStatusEntry
+	nil,                                  // 69: grad.v1.GetRunnerStatsResponse.ByPresetEntry
+	nil,                                  // 70: grad.v1.GetRunnerStatsResponse.ByOwnerEntry
+	nil,                                  // 71: grad.v1.ExecuteCommandRequest.EnvEntry
+	nil,                                  // 72: grad.v1.ExecuteCommandStreamResponse.CorrelationEnvEntry
+	nil,                                  // 73: grad.v1.Runner.EnvEntry
 }
 var file_grad_v1_runner_service_proto_depIdxs = []int32{
-	16, // 0: grad.v1.CreateRunnerRequest.env:type_name -> grad.v1.CreateRunnerRequest.EnvEntry
-	3,  // 1: grad.v1.CreateRunnerRequest.workspace:type_name -> grad.v1.WorkspaceConfig
-	13, // 2: grad.v1.CreateRunnerResponse.runner:type_name -> grad.v1.Runner
-	1,  // 3: grad.v1.ListRunnersRequest.status:type_name -> grad.v1.RunnerStatus
-	13, // 4: grad.v1.ListRunnersResponse.runners:type_name -> grad.v1.Runner
-	3,  // 5: grad.v1.ExecuteCommandRequest.workspace:type_name -> grad.v1.WorkspaceConfig
-	17, // 6: grad.v1.ExecuteCommandRequest.env:type_name -> grad.v1.ExecuteCommandRequest.EnvEntry
-	0,  // 7: grad.v1.ExecuteCommandStreamResponse.type:type_name -> grad.v1.StreamType
-	13, // 8: grad.v1.GetRunnerResponse.runner:type_name -> grad.v1.Runner
-	1,  // 9: grad.v1.Runner.status:type_name -> grad.v1.RunnerStatus
-	14, // 10: grad.v1.Runner.resources:type_name -> grad.v1.ResourceRequirements
-	15, // 11: grad.v1.Runner.ssh:type_name -> grad.v1.SSHDetails
-	18, // 12: grad.v1.Runner.env:type_name -> grad.v1.Runner.EnvEntry
-	2,  // 13: grad.v1.RunnerService.CreateRunner:input_type -> grad.v1.CreateRunnerRequest
-	5,  // 14: grad.v1.RunnerService.DeleteRunner:input_type -> grad.v1.DeleteRunnerRequest
-	7,  // 15: grad.v1.RunnerService.ListRunners:input_type -> grad.v1.ListRunnersRequest
-	9,  // 16: grad.v1.RunnerService.ExecuteCommandStream:input_type -> grad.v1.ExecuteCommandRequest
-	11, // 17: grad.v1.RunnerService.GetRunner:input_type -> grad.v1.GetRunnerRequest
-	9,  // 18: grad.v1.ExecuteService.ExecuteCommand:input_type -> grad.v1.ExecuteCommandRequest
-	4,  // 19: grad.v1.RunnerService.CreateRunner:output_type -> grad.v1.CreateRunnerResponse
-	6,  // 20: grad.v1.RunnerService.DeleteRunner:output_type -> grad.v1.DeleteRunnerResponse
-	8,  // 21: grad.v1.RunnerService.ListRunners:output_type -> grad.v1.ListRunnersResponse
-	10, // 22: grad.v1.RunnerService.ExecuteCommandStream:output_type -> grad.v1.ExecuteCommandStreamResponse
-	12, // 23: grad.v1.RunnerService.GetRunner:output_type -> grad.v1.GetRunnerResponse
-	10, // 24: grad.v1.ExecuteService.ExecuteCommand:output_type -> grad.v1.ExecuteCommandStreamResponse
-	19, // [19:25] is the sub-list for method output_type
-	13, // [13:19] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	66, // 0: grad.v1.CreateRunnerRequest.env:type_name -> grad.v1.CreateRunnerRequest.EnvEntry
+	10, // 1: grad.v1.CreateRunnerRequest.workspace:type_name -> grad.v1.WorkspaceConfig
+	9,  // 2: grad.v1.CreateRunnerRequest.host_aliases:type_name -> grad.v1.HostAlias
+	35, // 3: grad.v1.CreateRunnerResponse.runner:type_name -> grad.v1.Runner
+	16, // 4: grad.v1.DeleteRunnerGroupResponse.failed:type_name -> grad.v1.RunnerDeleteFailure
+	5,  // 5: grad.v1.ListRunnersRequest.status:type_name -> grad.v1.RunnerStatus
+	67, // 6: grad.v1.ListRunnersRequest.labels:type_name -> grad.v1.ListRunnersRequest.LabelsEntry
+	35, // 7: grad.v1.ListRunnersResponse.runners:type_name -> grad.v1.Runner
+	35, // 8: grad.v1.UpdateRunnerResponse.runner:type_name -> grad.v1.Runner
+	35, // 9: grad.v1.CloneRunnerResponse.runner:type_name -> grad.v1.Runner
+	26, // 10: grad.v1.GetServerInfoResponse.stream_limits:type_name -> grad.v1.StreamLimits
+	25, // 11: grad.v1.GetServerInfoResponse.build_info:type_name -> grad.v1.BuildInfo
+	27, // 12: grad.v1.GetServerInfoResponse.command_limits:type_name -> grad.v1.CommandLimits
+	5,  // 13: grad.v1.GetRunnerStatsRequest.status:type_name -> grad.v1.RunnerStatus
+	68, // 14: grad.v1.GetRunnerStatsResponse.by_status:type_name -> grad.v1.GetRunnerStatsResponse.ByStatusEntry
+	69, // 15: grad.v1.GetRunnerStatsResponse.by_preset:type_name -> grad.v1.GetRunnerStatsResponse.ByPresetEntry
+	70, // 16: grad.v1.GetRunnerStatsResponse.by_owner:type_name -> grad.v1.GetRunnerStatsResponse.ByOwnerEntry
+	10, // 17: grad.v1.ExecuteCommandRequest.workspace:type_name -> grad.v1.WorkspaceConfig
+	71, // 18: grad.v1.ExecuteCommandRequest.env:type_name -> grad.v1.ExecuteCommandRequest.EnvEntry
+	0,  // 19: grad.v1.ExecuteCommandRequest.truncate_policy:type_name -> grad.v1.TruncatePolicy
+	2,  // 20: grad.v1.ExecuteCommandStreamResponse.type:type_name -> grad.v1.StreamType
+	1,  // 21: grad.v1.ExecuteCommandStreamResponse.failure_reason:type_name -> grad.v1.ExitReason
+	72, // 22: grad.v1.ExecuteCommandStreamResponse.correlation_env:type_name -> grad.v1.ExecuteCommandStreamResponse.CorrelationEnvEntry
+	35, // 23: grad.v1.GetRunnerResponse.runner:type_name -> grad.v1.Runner
+	5,  // 24: grad.v1.Runner.status:type_name -> grad.v1.RunnerStatus
+	58, // 25: grad.v1.Runner.resources:type_name -> grad.v1.ResourceRequirements
+	59, // 26: grad.v1.Runner.ssh:type_name -> grad.v1.SSHDetails
+	73, // 27: grad.v1.Runner.env:type_name -> grad.v1.Runner.EnvEntry
+	9,  // 28: grad.v1.Runner.host_aliases:type_name -> grad.v1.HostAlias
+	37, // 29: grad.v1.Runner.usage:type_name -> grad.v1.RunnerUsage
+	6,  // 30: grad.v1.Runner.error_reason:type_name -> grad.v1.RunnerErrorReason
+	36, // 31: grad.v1.Runner.conditions:type_name -> grad.v1.RunnerCondition
+	7,  // 32: grad.v1.Runner.workspace_mode:type_name -> grad.v1.WorkspaceMode
+	3,  // 33: grad.v1.RunnerCondition.status:type_name -> grad.v1.ConditionStatus
+	38, // 34: grad.v1.RunnerUsage.containers:type_name -> grad.v1.ContainerUsage
+	37, // 35: grad.v1.GetRunnerMetricsResponse.usage:type_name -> grad.v1.RunnerUsage
+	37, // 36: grad.v1.ListRunnerMetricsResponse.usage:type_name -> grad.v1.RunnerUsage
+	55, // 37: grad.v1.GetRunnerDiagnosticsResponse.events:type_name -> grad.v1.RunnerEvent
+	56, // 38: grad.v1.GetRunnerDiagnosticsResponse.logs:type_name -> grad.v1.ContainerLog
+	57, // 39: grad.v1.GetRunnerDiagnosticsResponse.executions:type_name -> grad.v1.ExecutionRecord
+	45, // 40: grad.v1.AddSSHKeyResponse.key:type_name -> grad.v1.SSHKey
+	45, // 41: grad.v1.ListSSHKeysResponse.keys:type_name -> grad.v1.SSHKey
+	53, // 42: grad.v1.SnapshotWorkspaceRequest.credentials:type_name -> grad.v1.S3Credentials
+	4,  // 43: grad.v1.SnapshotWorkspaceResponse.phase:type_name -> grad.v1.SnapshotPhase
+	8,  // 44: grad.v1.RunnerService.CreateRunner:input_type -> grad.v1.CreateRunnerRequest
+	12, // 45: grad.v1.RunnerService.DeleteRunner:input_type -> grad.v1.DeleteRunnerRequest
+	17, // 46: grad.v1.RunnerService.ListRunners:input_type -> grad.v1.ListRunnersRequest
+	30, // 47: grad.v1.RunnerService.ExecuteCommandStream:input_type -> grad.v1.ExecuteCommandRequest
+	33, // 48: grad.v1.RunnerService.GetRunner:input_type -> grad.v1.GetRunnerRequest
+	28, // 49: grad.v1.RunnerService.GetRunnerStats:input_type -> grad.v1.GetRunnerStatsRequest
+	19, // 50: grad.v1.RunnerService.UpdateRunner:input_type -> grad.v1.UpdateRunnerRequest
+	21, // 51: grad.v1.RunnerService.CloneRunner:input_type -> grad.v1.CloneRunnerRequest
+	23, // 52: grad.v1.RunnerService.GetServerInfo:input_type -> grad.v1.GetServerInfoRequest
+	14, // 53: grad.v1.RunnerService.DeleteRunnerGroup:input_type -> grad.v1.DeleteRunnerGroupRequest
+	39, // 54: grad.v1.RunnerService.GetRunnerMetrics:input_type -> grad.v1.GetRunnerMetricsRequest
+	41, // 55: grad.v1.RunnerService.ListRunnerMetrics:input_type -> grad.v1.ListRunnerMetricsRequest
+	43, // 56: grad.v1.RunnerService.GetRunnerDiagnostics:input_type -> grad.v1.GetRunnerDiagnosticsRequest
+	46, // 57: grad.v1.RunnerService.AddSSHKey:input_type -> grad.v1.AddSSHKeyRequest
+	48, // 58: grad.v1.RunnerService.ListSSHKeys:input_type -> grad.v1.ListSSHKeysRequest
+	50, // 59: grad.v1.RunnerService.RemoveSSHKey:input_type -> grad.v1.RemoveSSHKeyRequest
+	31, // 60: grad.v1.RunnerService.AttachExecution:input_type -> grad.v1.AttachExecutionRequest
+	52, // 61: grad.v1.RunnerService.SnapshotWorkspace:input_type -> grad.v1.SnapshotWorkspaceRequest
+	30, // 62: grad.v1.ExecuteService.ExecuteCommand:input_type -> grad.v1.ExecuteCommandRequest
+	60, // 63: grad.v1.AdminService.SetRunnerImage:input_type -> grad.v1.SetRunnerImageRequest
+	62, // 64: grad.v1.AdminService.TailServerLogs:input_type -> grad.v1.TailServerLogsRequest
+	64, // 65: grad.v1.AdminService.ReloadConfig:input_type -> grad.v1.ReloadConfigRequest
+	11, // 66: grad.v1.RunnerService.CreateRunner:output_type -> grad.v1.CreateRunnerResponse
+	13, // 67: grad.v1.RunnerService.DeleteRunner:output_type -> grad.v1.DeleteRunnerResponse
+	18, // 68: grad.v1.RunnerService.ListRunners:output_type -> grad.v1.ListRunnersResponse
+	32, // 69: grad.v1.RunnerService.ExecuteCommandStream:output_type -> grad.v1.ExecuteCommandStreamResponse
+	34, // 70: grad.v1.RunnerService.GetRunner:output_type -> grad.v1.GetRunnerResponse
+	29, // 71: grad.v1.RunnerService.GetRunnerStats:output_type -> grad.v1.GetRunnerStatsResponse
+	20, // 72: grad.v1.RunnerService.UpdateRunner:output_type -> grad.v1.UpdateRunnerResponse
+	22, // 73: grad.v1.RunnerService.CloneRunner:output_type -> grad.v1.CloneRunnerResponse
+	24, // 74: grad.v1.RunnerService.GetServerInfo:output_type -> grad.v1.GetServerInfoResponse
+	15, // 75: grad.v1.RunnerService.DeleteRunnerGroup:output_type -> grad.v1.DeleteRunnerGroupResponse
+	40, // 76: grad.v1.RunnerService.GetRunnerMetrics:output_type -> grad.v1.GetRunnerMetricsResponse
+	42, // 77: grad.v1.RunnerService.ListRunnerMetrics:output_type -> grad.v1.ListRunnerMetricsResponse
+	44, // 78: grad.v1.RunnerService.GetRunnerDiagnostics:output_type -> grad.v1.GetRunnerDiagnosticsResponse
+	47, // 79: grad.v1.RunnerService.AddSSHKey:output_type -> grad.v1.AddSSHKeyResponse
+	49, // 80: grad.v1.RunnerService.ListSSHKeys:output_type -> grad.v1.ListSSHKeysResponse
+	51, // 81: grad.v1.RunnerService.RemoveSSHKey:output_type -> grad.v1.RemoveSSHKeyResponse
+	32, // 82: grad.v1.RunnerService.AttachExecution:output_type -> grad.v1.ExecuteCommandStreamResponse
+	54, // 83: grad.v1.RunnerService.SnapshotWorkspace:output_type -> grad.v1.SnapshotWorkspaceResponse
+	32, // 84: grad.v1.ExecuteService.ExecuteCommand:output_type -> grad.v1.ExecuteCommandStreamResponse
+	61, // 85: grad.v1.AdminService.SetRunnerImage:output_type -> grad.v1.SetRunnerImageResponse
+	63, // 86: grad.v1.AdminService.TailServerLogs:output_type -> grad.v1.ServerLogRecord
+	65, // 87: grad.v1.AdminService.ReloadConfig:output_type -> grad.v1.ReloadConfigResponse
+	66, // [66:88] is the sub-list for method output_type
+	44, // [44:66] is the sub-list for method input_type
+	44, // [44:44] is the sub-list for extension type_name
+	44, // [44:44] is the sub-list for extension extendee
+	0,  // [0:44] is the sub-list for field type_name
 }
 
 func init() { file_grad_v1_runner_service_proto_init() }
@@ -1222,15 +5554,17 @@ func file_grad_v1_runner_service_proto_init() {
 	if File_grad_v1_runner_service_proto != nil {
 		return
 	}
+	file_grad_v1_runner_service_proto_msgTypes[0].OneofWrappers = []any{}
+	file_grad_v1_runner_service_proto_msgTypes[11].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_grad_v1_runner_service_proto_rawDesc), len(file_grad_v1_runner_service_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   17,
+			NumEnums:      8,
+			NumMessages:   66,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   3,
 		},
 		GoTypes:           file_grad_v1_runner_service_proto_goTypes,
 		DependencyIndexes: file_grad_v1_runner_service_proto_depIdxs,
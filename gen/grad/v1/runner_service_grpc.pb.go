@@ -24,6 +24,19 @@ const (
 	RunnerService_ListRunners_FullMethodName          = "/grad.v1.RunnerService/ListRunners"
 	RunnerService_ExecuteCommandStream_FullMethodName = "/grad.v1.RunnerService/ExecuteCommandStream"
 	RunnerService_GetRunner_FullMethodName            = "/grad.v1.RunnerService/GetRunner"
+	RunnerService_GetRunnerStats_FullMethodName       = "/grad.v1.RunnerService/GetRunnerStats"
+	RunnerService_UpdateRunner_FullMethodName         = "/grad.v1.RunnerService/UpdateRunner"
+	RunnerService_CloneRunner_FullMethodName          = "/grad.v1.RunnerService/CloneRunner"
+	RunnerService_GetServerInfo_FullMethodName        = "/grad.v1.RunnerService/GetServerInfo"
+	RunnerService_DeleteRunnerGroup_FullMethodName    = "/grad.v1.RunnerService/DeleteRunnerGroup"
+	RunnerService_GetRunnerMetrics_FullMethodName     = "/grad.v1.RunnerService/GetRunnerMetrics"
+	RunnerService_ListRunnerMetrics_FullMethodName    = "/grad.v1.RunnerService/ListRunnerMetrics"
+	RunnerService_GetRunnerDiagnostics_FullMethodName = "/grad.v1.RunnerService/GetRunnerDiagnostics"
+	RunnerService_AddSSHKey_FullMethodName            = "/grad.v1.RunnerService/AddSSHKey"
+	RunnerService_ListSSHKeys_FullMethodName          = "/grad.v1.RunnerService/ListSSHKeys"
+	RunnerService_RemoveSSHKey_FullMethodName         = "/grad.v1.RunnerService/RemoveSSHKey"
+	RunnerService_AttachExecution_FullMethodName      = "/grad.v1.RunnerService/AttachExecution"
+	RunnerService_SnapshotWorkspace_FullMethodName    = "/grad.v1.RunnerService/SnapshotWorkspace"
 )
 
 // RunnerServiceClient is the client API for RunnerService service.
@@ -42,6 +55,41 @@ type RunnerServiceClient interface {
 	ExecuteCommandStream(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExecuteCommandStreamResponse], error)
 	// GetRunner returns details about a specific runner
 	GetRunner(ctx context.Context, in *GetRunnerRequest, opts ...grpc.CallOption) (*GetRunnerResponse, error)
+	// GetRunnerStats returns runner counts without the runners themselves
+	GetRunnerStats(ctx context.Context, in *GetRunnerStatsRequest, opts ...grpc.CallOption) (*GetRunnerStatsResponse, error)
+	// UpdateRunner changes the editable fields of a runner
+	UpdateRunner(ctx context.Context, in *UpdateRunnerRequest, opts ...grpc.CallOption) (*UpdateRunnerResponse, error)
+	// CloneRunner creates a new runner with the same configuration as an existing one
+	CloneRunner(ctx context.Context, in *CloneRunnerRequest, opts ...grpc.CallOption) (*CloneRunnerResponse, error)
+	// GetServerInfo returns the server's limits and their current usage
+	GetServerInfo(ctx context.Context, in *GetServerInfoRequest, opts ...grpc.CallOption) (*GetServerInfoResponse, error)
+	// DeleteRunnerGroup deletes every runner in a group concurrently
+	DeleteRunnerGroup(ctx context.Context, in *DeleteRunnerGroupRequest, opts ...grpc.CallOption) (*DeleteRunnerGroupResponse, error)
+	// GetRunnerMetrics returns the live CPU and memory usage of a runner's containers from
+	// metrics-server. Fails with UNIMPLEMENTED if the cluster doesn't serve metrics.k8s.io.
+	GetRunnerMetrics(ctx context.Context, in *GetRunnerMetricsRequest, opts ...grpc.CallOption) (*GetRunnerMetricsResponse, error)
+	// ListRunnerMetrics returns the live usage of every runner metrics-server reports on
+	ListRunnerMetrics(ctx context.Context, in *ListRunnerMetricsRequest, opts ...grpc.CallOption) (*ListRunnerMetricsResponse, error)
+	// GetRunnerDiagnostics returns what's needed to debug a runner: its pod as Kubernetes has
+	// it, the pod's events, the tail of each container's log and recent executions
+	GetRunnerDiagnostics(ctx context.Context, in *GetRunnerDiagnosticsRequest, opts ...grpc.CallOption) (*GetRunnerDiagnosticsResponse, error)
+	// AddSSHKey allows a public key to log in to a running runner; adding a key it already has
+	// is a no-op
+	AddSSHKey(ctx context.Context, in *AddSSHKeyRequest, opts ...grpc.CallOption) (*AddSSHKeyResponse, error)
+	// ListSSHKeys returns the keys added with AddSSHKey, without running anything in the runner
+	ListSSHKeys(ctx context.Context, in *ListSSHKeysRequest, opts ...grpc.CallOption) (*ListSSHKeysResponse, error)
+	// RemoveSSHKey removes a key added with AddSSHKey by its fingerprint
+	RemoveSSHKey(ctx context.Context, in *RemoveSSHKeyRequest, opts ...grpc.CallOption) (*RemoveSSHKeyResponse, error)
+	// AttachExecution streams the output of a command started with resumable set, from the
+	// message after after_sequence. Fails with NOT_FOUND once grad no longer has the
+	// execution, e.g. after a restart, and OUT_OF_RANGE if the output to resume from was
+	// dropped from its buffer.
+	AttachExecution(ctx context.Context, in *AttachExecutionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExecuteCommandStreamResponse], error)
+	// SnapshotWorkspace archives a running runner's /workspace, without the S3 dataset mount,
+	// and uploads it to S3, streaming progress until the upload completes. Fails with
+	// FAILED_PRECONDITION if the runner has no S3 credentials to upload with, and
+	// RESOURCE_EXHAUSTED if it lacks the disk space to stage the archive.
+	SnapshotWorkspace(ctx context.Context, in *SnapshotWorkspaceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SnapshotWorkspaceResponse], error)
 }
 
 type runnerServiceClient struct {
@@ -111,6 +159,154 @@ func (c *runnerServiceClient) GetRunner(ctx context.Context, in *GetRunnerReques
 	return out, nil
 }
 
+func (c *runnerServiceClient) GetRunnerStats(ctx context.Context, in *GetRunnerStatsRequest, opts ...grpc.CallOption) (*GetRunnerStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRunnerStatsResponse)
+	err := c.cc.Invoke(ctx, RunnerService_GetRunnerStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) UpdateRunner(ctx context.Context, in *UpdateRunnerRequest, opts ...grpc.CallOption) (*UpdateRunnerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateRunnerResponse)
+	err := c.cc.Invoke(ctx, RunnerService_UpdateRunner_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) CloneRunner(ctx context.Context, in *CloneRunnerRequest, opts ...grpc.CallOption) (*CloneRunnerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloneRunnerResponse)
+	err := c.cc.Invoke(ctx, RunnerService_CloneRunner_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) GetServerInfo(ctx context.Context, in *GetServerInfoRequest, opts ...grpc.CallOption) (*GetServerInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetServerInfoResponse)
+	err := c.cc.Invoke(ctx, RunnerService_GetServerInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) DeleteRunnerGroup(ctx context.Context, in *DeleteRunnerGroupRequest, opts ...grpc.CallOption) (*DeleteRunnerGroupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteRunnerGroupResponse)
+	err := c.cc.Invoke(ctx, RunnerService_DeleteRunnerGroup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) GetRunnerMetrics(ctx context.Context, in *GetRunnerMetricsRequest, opts ...grpc.CallOption) (*GetRunnerMetricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRunnerMetricsResponse)
+	err := c.cc.Invoke(ctx, RunnerService_GetRunnerMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) ListRunnerMetrics(ctx context.Context, in *ListRunnerMetricsRequest, opts ...grpc.CallOption) (*ListRunnerMetricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRunnerMetricsResponse)
+	err := c.cc.Invoke(ctx, RunnerService_ListRunnerMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) GetRunnerDiagnostics(ctx context.Context, in *GetRunnerDiagnosticsRequest, opts ...grpc.CallOption) (*GetRunnerDiagnosticsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRunnerDiagnosticsResponse)
+	err := c.cc.Invoke(ctx, RunnerService_GetRunnerDiagnostics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) AddSSHKey(ctx context.Context, in *AddSSHKeyRequest, opts ...grpc.CallOption) (*AddSSHKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddSSHKeyResponse)
+	err := c.cc.Invoke(ctx, RunnerService_AddSSHKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) ListSSHKeys(ctx context.Context, in *ListSSHKeysRequest, opts ...grpc.CallOption) (*ListSSHKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSSHKeysResponse)
+	err := c.cc.Invoke(ctx, RunnerService_ListSSHKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) RemoveSSHKey(ctx context.Context, in *RemoveSSHKeyRequest, opts ...grpc.CallOption) (*RemoveSSHKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveSSHKeyResponse)
+	err := c.cc.Invoke(ctx, RunnerService_RemoveSSHKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runnerServiceClient) AttachExecution(ctx context.Context, in *AttachExecutionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExecuteCommandStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RunnerService_ServiceDesc.Streams[1], RunnerService_AttachExecution_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AttachExecutionRequest, ExecuteCommandStreamResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RunnerService_AttachExecutionClient = grpc.ServerStreamingClient[ExecuteCommandStreamResponse]
+
+func (c *runnerServiceClient) SnapshotWorkspace(ctx context.Context, in *SnapshotWorkspaceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SnapshotWorkspaceResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RunnerService_ServiceDesc.Streams[2], RunnerService_SnapshotWorkspace_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SnapshotWorkspaceRequest, SnapshotWorkspaceResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RunnerService_SnapshotWorkspaceClient = grpc.ServerStreamingClient[SnapshotWorkspaceResponse]
+
 // RunnerServiceServer is the server API for RunnerService service.
 // All implementations must embed UnimplementedRunnerServiceServer
 // for forward compatibility.
@@ -127,6 +323,41 @@ type RunnerServiceServer interface {
 	ExecuteCommandStream(*ExecuteCommandRequest, grpc.ServerStreamingServer[ExecuteCommandStreamResponse]) error
 	// GetRunner returns details about a specific runner
 	GetRunner(context.Context, *GetRunnerRequest) (*GetRunnerResponse, error)
+	// GetRunnerStats returns runner counts without the runners themselves
+	GetRunnerStats(context.Context, *GetRunnerStatsRequest) (*GetRunnerStatsResponse, error)
+	// UpdateRunner changes the editable fields of a runner
+	UpdateRunner(context.Context, *UpdateRunnerRequest) (*UpdateRunnerResponse, error)
+	// CloneRunner creates a new runner with the same configuration as an existing one
+	CloneRunner(context.Context, *CloneRunnerRequest) (*CloneRunnerResponse, error)
+	// GetServerInfo returns the server's limits and their current usage
+	GetServerInfo(context.Context, *GetServerInfoRequest) (*GetServerInfoResponse, error)
+	// DeleteRunnerGroup deletes every runner in a group concurrently
+	DeleteRunnerGroup(context.Context, *DeleteRunnerGroupRequest) (*DeleteRunnerGroupResponse, error)
+	// GetRunnerMetrics returns the live CPU and memory usage of a runner's containers from
+	// metrics-server. Fails with UNIMPLEMENTED if the cluster doesn't serve metrics.k8s.io.
+	GetRunnerMetrics(context.Context, *GetRunnerMetricsRequest) (*GetRunnerMetricsResponse, error)
+	// ListRunnerMetrics returns the live usage of every runner metrics-server reports on
+	ListRunnerMetrics(context.Context, *ListRunnerMetricsRequest) (*ListRunnerMetricsResponse, error)
+	// GetRunnerDiagnostics returns what's needed to debug a runner: its pod as Kubernetes has
+	// it, the pod's events, the tail of each container's log and recent executions
+	GetRunnerDiagnostics(context.Context, *GetRunnerDiagnosticsRequest) (*GetRunnerDiagnosticsResponse, error)
+	// AddSSHKey allows a public key to log in to a running runner; adding a key it already has
+	// is a no-op
+	AddSSHKey(context.Context, *AddSSHKeyRequest) (*AddSSHKeyResponse, error)
+	// ListSSHKeys returns the keys added with AddSSHKey, without running anything in the runner
+	ListSSHKeys(context.Context, *ListSSHKeysRequest) (*ListSSHKeysResponse, error)
+	// RemoveSSHKey removes a key added with AddSSHKey by its fingerprint
+	RemoveSSHKey(context.Context, *RemoveSSHKeyRequest) (*RemoveSSHKeyResponse, error)
+	// AttachExecution streams the output of a command started with resumable set, from the
+	// message after after_sequence. Fails with NOT_FOUND once grad no longer has the
+	// execution, e.g. after a restart, and OUT_OF_RANGE if the output to resume from was
+	// dropped from its buffer.
+	AttachExecution(*AttachExecutionRequest, grpc.ServerStreamingServer[ExecuteCommandStreamResponse]) error
+	// SnapshotWorkspace archives a running runner's /workspace, without the S3 dataset mount,
+	// and uploads it to S3, streaming progress until the upload completes. Fails with
+	// FAILED_PRECONDITION if the runner has no S3 credentials to upload with, and
+	// RESOURCE_EXHAUSTED if it lacks the disk space to stage the archive.
+	SnapshotWorkspace(*SnapshotWorkspaceRequest, grpc.ServerStreamingServer[SnapshotWorkspaceResponse]) error
 	mustEmbedUnimplementedRunnerServiceServer()
 }
 
@@ -152,6 +383,45 @@ func (UnimplementedRunnerServiceServer) ExecuteCommandStream(*ExecuteCommandRequ
 func (UnimplementedRunnerServiceServer) GetRunner(context.Context, *GetRunnerRequest) (*GetRunnerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetRunner not implemented")
 }
+func (UnimplementedRunnerServiceServer) GetRunnerStats(context.Context, *GetRunnerStatsRequest) (*GetRunnerStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRunnerStats not implemented")
+}
+func (UnimplementedRunnerServiceServer) UpdateRunner(context.Context, *UpdateRunnerRequest) (*UpdateRunnerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRunner not implemented")
+}
+func (UnimplementedRunnerServiceServer) CloneRunner(context.Context, *CloneRunnerRequest) (*CloneRunnerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloneRunner not implemented")
+}
+func (UnimplementedRunnerServiceServer) GetServerInfo(context.Context, *GetServerInfoRequest) (*GetServerInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServerInfo not implemented")
+}
+func (UnimplementedRunnerServiceServer) DeleteRunnerGroup(context.Context, *DeleteRunnerGroupRequest) (*DeleteRunnerGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRunnerGroup not implemented")
+}
+func (UnimplementedRunnerServiceServer) GetRunnerMetrics(context.Context, *GetRunnerMetricsRequest) (*GetRunnerMetricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRunnerMetrics not implemented")
+}
+func (UnimplementedRunnerServiceServer) ListRunnerMetrics(context.Context, *ListRunnerMetricsRequest) (*ListRunnerMetricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRunnerMetrics not implemented")
+}
+func (UnimplementedRunnerServiceServer) GetRunnerDiagnostics(context.Context, *GetRunnerDiagnosticsRequest) (*GetRunnerDiagnosticsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRunnerDiagnostics not implemented")
+}
+func (UnimplementedRunnerServiceServer) AddSSHKey(context.Context, *AddSSHKeyRequest) (*AddSSHKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddSSHKey not implemented")
+}
+func (UnimplementedRunnerServiceServer) ListSSHKeys(context.Context, *ListSSHKeysRequest) (*ListSSHKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSSHKeys not implemented")
+}
+func (UnimplementedRunnerServiceServer) RemoveSSHKey(context.Context, *RemoveSSHKeyRequest) (*RemoveSSHKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveSSHKey not implemented")
+}
+func (UnimplementedRunnerServiceServer) AttachExecution(*AttachExecutionRequest, grpc.ServerStreamingServer[ExecuteCommandStreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method AttachExecution not implemented")
+}
+func (UnimplementedRunnerServiceServer) SnapshotWorkspace(*SnapshotWorkspaceRequest, grpc.ServerStreamingServer[SnapshotWorkspaceResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method SnapshotWorkspace not implemented")
+}
 func (UnimplementedRunnerServiceServer) mustEmbedUnimplementedRunnerServiceServer() {}
 func (UnimplementedRunnerServiceServer) testEmbeddedByValue()                       {}
 
@@ -256,6 +526,226 @@ func _RunnerService_GetRunner_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RunnerService_GetRunnerStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRunnerStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).GetRunnerStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_GetRunnerStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).GetRunnerStats(ctx, req.(*GetRunnerStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_UpdateRunner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRunnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).UpdateRunner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_UpdateRunner_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).UpdateRunner(ctx, req.(*UpdateRunnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_CloneRunner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloneRunnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).CloneRunner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_CloneRunner_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).CloneRunner(ctx, req.(*CloneRunnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_GetServerInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServerInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).GetServerInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_GetServerInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).GetServerInfo(ctx, req.(*GetServerInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_DeleteRunnerGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRunnerGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).DeleteRunnerGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_DeleteRunnerGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).DeleteRunnerGroup(ctx, req.(*DeleteRunnerGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_GetRunnerMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRunnerMetricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).GetRunnerMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_GetRunnerMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).GetRunnerMetrics(ctx, req.(*GetRunnerMetricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_ListRunnerMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRunnerMetricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).ListRunnerMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_ListRunnerMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).ListRunnerMetrics(ctx, req.(*ListRunnerMetricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_GetRunnerDiagnostics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRunnerDiagnosticsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).GetRunnerDiagnostics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_GetRunnerDiagnostics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).GetRunnerDiagnostics(ctx, req.(*GetRunnerDiagnosticsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_AddSSHKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddSSHKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).AddSSHKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_AddSSHKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).AddSSHKey(ctx, req.(*AddSSHKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_ListSSHKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSSHKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).ListSSHKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_ListSSHKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).ListSSHKeys(ctx, req.(*ListSSHKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_RemoveSSHKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveSSHKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RunnerServiceServer).RemoveSSHKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RunnerService_RemoveSSHKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RunnerServiceServer).RemoveSSHKey(ctx, req.(*RemoveSSHKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RunnerService_AttachExecution_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AttachExecutionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RunnerServiceServer).AttachExecution(m, &grpc.GenericServerStream[AttachExecutionRequest, ExecuteCommandStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RunnerService_AttachExecutionServer = grpc.ServerStreamingServer[ExecuteCommandStreamResponse]
+
+func _RunnerService_SnapshotWorkspace_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SnapshotWorkspaceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RunnerServiceServer).SnapshotWorkspace(m, &grpc.GenericServerStream[SnapshotWorkspaceRequest, SnapshotWorkspaceResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RunnerService_SnapshotWorkspaceServer = grpc.ServerStreamingServer[SnapshotWorkspaceResponse]
+
 // RunnerService_ServiceDesc is the grpc.ServiceDesc for RunnerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -279,6 +769,50 @@ var RunnerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetRunner",
 			Handler:    _RunnerService_GetRunner_Handler,
 		},
+		{
+			MethodName: "GetRunnerStats",
+			Handler:    _RunnerService_GetRunnerStats_Handler,
+		},
+		{
+			MethodName: "UpdateRunner",
+			Handler:    _RunnerService_UpdateRunner_Handler,
+		},
+		{
+			MethodName: "CloneRunner",
+			Handler:    _RunnerService_CloneRunner_Handler,
+		},
+		{
+			MethodName: "GetServerInfo",
+			Handler:    _RunnerService_GetServerInfo_Handler,
+		},
+		{
+			MethodName: "DeleteRunnerGroup",
+			Handler:    _RunnerService_DeleteRunnerGroup_Handler,
+		},
+		{
+			MethodName: "GetRunnerMetrics",
+			Handler:    _RunnerService_GetRunnerMetrics_Handler,
+		},
+		{
+			MethodName: "ListRunnerMetrics",
+			Handler:    _RunnerService_ListRunnerMetrics_Handler,
+		},
+		{
+			MethodName: "GetRunnerDiagnostics",
+			Handler:    _RunnerService_GetRunnerDiagnostics_Handler,
+		},
+		{
+			MethodName: "AddSSHKey",
+			Handler:    _RunnerService_AddSSHKey_Handler,
+		},
+		{
+			MethodName: "ListSSHKeys",
+			Handler:    _RunnerService_ListSSHKeys_Handler,
+		},
+		{
+			MethodName: "RemoveSSHKey",
+			Handler:    _RunnerService_RemoveSSHKey_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -286,6 +820,16 @@ var RunnerService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _RunnerService_ExecuteCommandStream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "AttachExecution",
+			Handler:       _RunnerService_AttachExecution_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SnapshotWorkspace",
+			Handler:       _RunnerService_SnapshotWorkspace_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "grad/v1/runner_service.proto",
 }
@@ -400,3 +944,195 @@ var ExecuteService_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "grad/v1/runner_service.proto",
 }
+
+const (
+	AdminService_SetRunnerImage_FullMethodName = "/grad.v1.AdminService/SetRunnerImage"
+	AdminService_TailServerLogs_FullMethodName = "/grad.v1.AdminService/TailServerLogs"
+	AdminService_ReloadConfig_FullMethodName   = "/grad.v1.AdminService/ReloadConfig"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AdminService changes grad's configuration at runtime. Every RPC needs the admin token.
+type AdminServiceClient interface {
+	// SetRunnerImage changes the images of runners created from now on; existing runners keep theirs
+	SetRunnerImage(ctx context.Context, in *SetRunnerImageRequest, opts ...grpc.CallOption) (*SetRunnerImageResponse, error)
+	// TailServerLogs streams grad's most recent log records, then new ones as they are logged if follow is set
+	TailServerLogs(ctx context.Context, in *TailServerLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ServerLogRecord], error)
+	// ReloadConfig reloads grad's configuration like SIGHUP, applying the settings that can change while it runs
+	ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) SetRunnerImage(ctx context.Context, in *SetRunnerImageRequest, opts ...grpc.CallOption) (*SetRunnerImageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetRunnerImageResponse)
+	err := c.cc.Invoke(ctx, AdminService_SetRunnerImage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) TailServerLogs(ctx context.Context, in *TailServerLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ServerLogRecord], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[0], AdminService_TailServerLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TailServerLogsRequest, ServerLogRecord]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_TailServerLogsClient = grpc.ServerStreamingClient[ServerLogRecord]
+
+func (c *adminServiceClient) ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReloadConfigResponse)
+	err := c.cc.Invoke(ctx, AdminService_ReloadConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// AdminService changes grad's configuration at runtime. Every RPC needs the admin token.
+type AdminServiceServer interface {
+	// SetRunnerImage changes the images of runners created from now on; existing runners keep theirs
+	SetRunnerImage(context.Context, *SetRunnerImageRequest) (*SetRunnerImageResponse, error)
+	// TailServerLogs streams grad's most recent log records, then new ones as they are logged if follow is set
+	TailServerLogs(*TailServerLogsRequest, grpc.ServerStreamingServer[ServerLogRecord]) error
+	// ReloadConfig reloads grad's configuration like SIGHUP, applying the settings that can change while it runs
+	ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) SetRunnerImage(context.Context, *SetRunnerImageRequest) (*SetRunnerImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRunnerImage not implemented")
+}
+func (UnimplementedAdminServiceServer) TailServerLogs(*TailServerLogsRequest, grpc.ServerStreamingServer[ServerLogRecord]) error {
+	return status.Errorf(codes.Unimplemented, "method TailServerLogs not implemented")
+}
+func (UnimplementedAdminServiceServer) ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadConfig not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_SetRunnerImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRunnerImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetRunnerImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetRunnerImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetRunnerImage(ctx, req.(*SetRunnerImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_TailServerLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailServerLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).TailServerLogs(m, &grpc.GenericServerStream[TailServerLogsRequest, ServerLogRecord]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_TailServerLogsServer = grpc.ServerStreamingServer[ServerLogRecord]
+
+func _AdminService_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ReloadConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ReloadConfig(ctx, req.(*ReloadConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "grad.v1.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetRunnerImage",
+			Handler:    _AdminService_SetRunnerImage_Handler,
+		},
+		{
+			MethodName: "ReloadConfig",
+			Handler:    _AdminService_ReloadConfig_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TailServerLogs",
+			Handler:       _AdminService_TailServerLogs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "grad/v1/runner_service.proto",
+}
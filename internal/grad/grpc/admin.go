@@ -0,0 +1,170 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/logs"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// AdminTokenMetadataKey carries the admin token of AdminService calls as "Bearer <token>"
+const AdminTokenMetadataKey = "authorization"
+
+// AdminServer implements the gRPC AdminService. Every call must carry the admin token.
+type AdminServer struct {
+	gradv1.UnimplementedAdminServiceServer
+	kubernetes *service.KubernetesConfig
+	token      string
+	logs       *logs.Buffer
+	reloader   *service.ConfigReloader
+}
+
+// NewAdminServer creates an admin server changing kubernetes, tailing the records kept in
+// logBuffer and reloading the configuration with reloader; logBuffer and reloader may be nil
+// if grad keeps no logs or can't reload. An empty token disables it, rejecting every call.
+func NewAdminServer(kubernetes *service.KubernetesConfig, token string, logBuffer *logs.Buffer, reloader *service.ConfigReloader) *AdminServer {
+	return &AdminServer{kubernetes: kubernetes, token: token, logs: logBuffer, reloader: reloader}
+}
+
+// SetRunnerImage changes the images of runners created from now on, or reverts the last change
+func (s *AdminServer) SetRunnerImage(ctx context.Context, req *gradv1.SetRunnerImageRequest) (*gradv1.SetRunnerImageResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.Revert && (req.Image != "" || req.S3FsImage != "") {
+		return nil, status.Errorf(codes.InvalidArgument, "revert can't be combined with image or s3fs_image")
+	}
+
+	var previous service.RunnerImages
+	var err error
+	if req.Revert {
+		previous, err = s.kubernetes.RevertImages()
+	} else {
+		previous, err = s.kubernetes.SetImages(service.RunnerImages{Runner: req.Image, S3FS: req.S3FsImage})
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidRequest):
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, service.ErrFailedPrecondition):
+		return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+	case err != nil:
+		slog.Error("Failed to set runner images", "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+	}
+
+	current := s.kubernetes.Images()
+	return &gradv1.SetRunnerImageResponse{
+		Image:             current.Runner,
+		S3FsImage:         current.S3FS,
+		PreviousImage:     previous.Runner,
+		PreviousS3FsImage: previous.S3FS,
+	}, nil
+}
+
+// TailServerLogs streams the log records grad keeps of at least req.MinLevel, then with
+// req.Follow new ones until the client goes away
+func (s *AdminServer) TailServerLogs(req *gradv1.TailServerLogsRequest, stream gradv1.AdminService_TailServerLogsServer) error {
+	ctx := stream.Context()
+	if err := s.authorize(ctx); err != nil {
+		return err
+	}
+	minLevel, err := parseLogLevel(req.MinLevel)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if s.logs == nil {
+		return status.Errorf(codes.Unimplemented, "this server keeps no logs to tail")
+	}
+
+	if !req.Follow {
+		for _, record := range s.logs.Tail(minLevel) {
+			if err := stream.Send(logRecordToProto(record)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	recent, records, stop := s.logs.Follow(minLevel)
+	defer stop()
+	for _, record := range recent {
+		if err := stream.Send(logRecordToProto(record)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case record := <-records:
+			if err := stream.Send(logRecordToProto(record)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ReloadConfig reloads the configuration as SIGHUP does, reporting the settings that changed.
+// A configuration that doesn't load or validate changes nothing.
+func (s *AdminServer) ReloadConfig(ctx context.Context, req *gradv1.ReloadConfigRequest) (*gradv1.ReloadConfigResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.reloader == nil {
+		return nil, status.Errorf(codes.Unimplemented, "this server can't reload its configuration")
+	}
+
+	reload, err := s.reloader.Reload()
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "configuration not reloaded: %v", err)
+	}
+	return &gradv1.ReloadConfigResponse{AppliedKeys: reload.Applied, IgnoredKeys: reload.Ignored}, nil
+}
+
+// parseLogLevel parses a min_level, "" meaning every level (pure function)
+func parseLogLevel(level string) (slog.Level, error) {
+	if level == "" {
+		return slog.LevelDebug, nil
+	}
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("invalid min_level %q (must be debug, info, warn or error)", level)
+	}
+	return parsed, nil
+}
+
+// logRecordToProto converts a kept log record (pure function)
+func logRecordToProto(record logs.Record) *gradv1.ServerLogRecord {
+	return &gradv1.ServerLogRecord{
+		TimeUnixMs: record.Time.UnixMilli(),
+		Level:      record.Level.String(),
+		Message:    record.Message,
+		Json:       string(record.JSON),
+	}
+}
+
+// authorize checks the admin token of a call in constant time
+func (s *AdminServer) authorize(ctx context.Context) error {
+	if s.token == "" {
+		return status.Errorf(codes.PermissionDenied, "admin API is disabled on this server")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(AdminTokenMetadataKey) {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Errorf(codes.Unauthenticated, "missing or invalid admin token")
+}
@@ -0,0 +1,247 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/logs"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// adminContext returns an incoming call context carrying authorization, if set
+func adminContext(authorization string) context.Context {
+	if authorization == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(AdminTokenMetadataKey, authorization))
+}
+
+func TestAdminServerAuthorization(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverToken   string
+		authorization string
+		expectCode    codes.Code
+	}{
+		{"Disabled", "", "Bearer secret", codes.PermissionDenied},
+		{"Missing token", "secret", "", codes.Unauthenticated},
+		{"Wrong token", "secret", "Bearer other", codes.Unauthenticated},
+		{"Not a bearer token", "secret", "secret", codes.Unauthenticated},
+		{"Valid token", "secret", "Bearer secret", codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := service.DefaultKubernetesConfig()
+			s := NewAdminServer(config, tt.serverToken, nil, nil)
+
+			_, err := s.SetRunnerImage(adminContext(tt.authorization), &gradv1.SetRunnerImageRequest{Image: "ghcr.io/strrl/grad-runner:v2"})
+			if status.Code(err) != tt.expectCode {
+				t.Fatalf("Expected %s, got %v", tt.expectCode, err)
+			}
+			if tt.expectCode != codes.OK && config.Images().Runner != service.DefaultRunnerImage {
+				t.Errorf("Expected a rejected call to keep the runner image, got %s", config.Images().Runner)
+			}
+		})
+	}
+}
+
+func TestAdminServerSetRunnerImage(t *testing.T) {
+	ctx := adminContext("Bearer secret")
+	s := NewAdminServer(service.DefaultKubernetesConfig(), "secret", nil, nil)
+
+	// Nothing to revert yet
+	_, err := s.SetRunnerImage(ctx, &gradv1.SetRunnerImageRequest{Revert: true})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %v", err)
+	}
+
+	resp, err := s.SetRunnerImage(ctx, &gradv1.SetRunnerImageRequest{Image: "ghcr.io/strrl/grad-runner:v2"})
+	if err != nil {
+		t.Fatalf("SetRunnerImage failed: %v", err)
+	}
+	if resp.Image != "ghcr.io/strrl/grad-runner:v2" || resp.PreviousImage != service.DefaultRunnerImage {
+		t.Errorf("Expected %s replacing %s, got %s replacing %s", "ghcr.io/strrl/grad-runner:v2", service.DefaultRunnerImage, resp.Image, resp.PreviousImage)
+	}
+	if resp.S3FsImage != service.DefaultS3FSImage || resp.PreviousS3FsImage != service.DefaultS3FSImage {
+		t.Errorf("Expected the s3fs image to be unchanged, got %s replacing %s", resp.S3FsImage, resp.PreviousS3FsImage)
+	}
+
+	invalid := []*gradv1.SetRunnerImageRequest{
+		{},
+		{Image: "not a valid image"},
+		{Image: "ghcr.io/strrl/grad-runner:v3", Revert: true},
+	}
+	for _, req := range invalid {
+		if _, err := s.SetRunnerImage(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", req, err)
+		}
+	}
+
+	resp, err = s.SetRunnerImage(ctx, &gradv1.SetRunnerImageRequest{Revert: true})
+	if err != nil {
+		t.Fatalf("SetRunnerImage failed: %v", err)
+	}
+	if resp.Image != service.DefaultRunnerImage || resp.PreviousImage != "ghcr.io/strrl/grad-runner:v2" {
+		t.Errorf("Expected the revert to restore %s, got %s replacing %s", service.DefaultRunnerImage, resp.Image, resp.PreviousImage)
+	}
+}
+
+// logStream is the server end of a TailServerLogs stream, passing the records sent on
+type logStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	records chan *gradv1.ServerLogRecord
+}
+
+func (s *logStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *logStream) Send(record *gradv1.ServerLogRecord) error {
+	s.records <- record
+	return nil
+}
+
+// tailServerLogs runs TailServerLogs on ctx to completion, returning the messages sent
+func tailServerLogs(ctx context.Context, s *AdminServer, req *gradv1.TailServerLogsRequest) ([]string, error) {
+	stream := &logStream{ctx: ctx, records: make(chan *gradv1.ServerLogRecord, 100)}
+	err := s.TailServerLogs(req, stream)
+	close(stream.records)
+	var messages []string
+	for record := range stream.records {
+		messages = append(messages, record.Message)
+	}
+	return messages, err
+}
+
+func TestAdminServerTailServerLogs(t *testing.T) {
+	buffer := logs.NewBuffer(10)
+	logger := slog.New(logs.NewHandler(io.Discard, slog.LevelDebug, buffer))
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn", "token", "hunter2")
+	logger.Error("error")
+
+	s := NewAdminServer(service.DefaultKubernetesConfig(), "secret", buffer, nil)
+	ctx := adminContext("Bearer secret")
+
+	tests := []struct {
+		minLevel   string
+		expected   []string
+		expectCode codes.Code
+	}{
+		{"", []string{"debug", "info", "warn", "error"}, codes.OK},
+		{"warn", []string{"warn", "error"}, codes.OK},
+		{"ERROR", []string{"error"}, codes.OK},
+		{"loud", nil, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.minLevel, func(t *testing.T) {
+			got, err := tailServerLogs(ctx, s, &gradv1.TailServerLogsRequest{MinLevel: tt.minLevel})
+			if status.Code(err) != tt.expectCode {
+				t.Fatalf("Expected %s, got %v", tt.expectCode, err)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := tailServerLogs(context.Background(), s, &gradv1.TailServerLogsRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected a call without the token to be Unauthenticated, got %v", err)
+	}
+	noLogs := NewAdminServer(service.DefaultKubernetesConfig(), "secret", nil, nil)
+	if _, err := tailServerLogs(ctx, noLogs, &gradv1.TailServerLogsRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented without a log buffer, got %v", err)
+	}
+}
+
+func TestAdminServerTailServerLogsFollow(t *testing.T) {
+	buffer := logs.NewBuffer(10)
+	logger := slog.New(logs.NewHandler(io.Discard, slog.LevelInfo, buffer))
+	logger.Warn("before")
+
+	s := NewAdminServer(service.DefaultKubernetesConfig(), "secret", buffer, nil)
+	ctx, cancel := context.WithCancel(adminContext("Bearer secret"))
+	stream := &logStream{ctx: ctx, records: make(chan *gradv1.ServerLogRecord, 100)}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.TailServerLogs(&gradv1.TailServerLogsRequest{Follow: true, MinLevel: "warn"}, stream)
+	}()
+
+	next := func() string {
+		select {
+		case record := <-stream.records:
+			return record.Message
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected a record to be streamed")
+			return ""
+		}
+	}
+	if got := next(); got != "before" {
+		t.Errorf("Expected the recent record first, got %q", got)
+	}
+	logger.Info("skipped")
+	logger.Error("after")
+	if got := next(); got != "after" {
+		t.Errorf("Expected the new error, got %q", got)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("Expected Canceled once the client goes away, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected following to stop once the client goes away")
+	}
+}
+
+func TestAdminServerReloadConfig(t *testing.T) {
+	ctx := adminContext("Bearer secret")
+	env := map[string]string{"RUNNER_IDLE_TIMEOUT": "10m"}
+	load := func() (func(string) string, error) {
+		values := maps.Clone(env)
+		return func(key string) string { return values[key] }, nil
+	}
+	reloader, _, err := service.NewConfigReloader(load)
+	if err != nil {
+		t.Fatalf("NewConfigReloader failed: %v", err)
+	}
+	s := NewAdminServer(service.DefaultKubernetesConfig(), "secret", nil, reloader)
+
+	env["RUNNER_IDLE_TIMEOUT"] = "20m"
+	env["KUBERNETES_NAMESPACE"] = "runners"
+	resp, err := s.ReloadConfig(ctx, &gradv1.ReloadConfigRequest{})
+	if err != nil {
+		t.Fatalf("ReloadConfig failed: %v", err)
+	}
+	if !slices.Equal(resp.AppliedKeys, []string{"RUNNER_IDLE_TIMEOUT"}) || !slices.Equal(resp.IgnoredKeys, []string{"KUBERNETES_NAMESPACE"}) {
+		t.Errorf("Expected the idle timeout applied and the namespace ignored, got %v", resp)
+	}
+
+	env["RUNNER_IDLE_TIMEOUT"] = "soon"
+	if _, err := s.ReloadConfig(ctx, &gradv1.ReloadConfigRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected an invalid configuration to fail the precondition, got %v", err)
+	}
+	if _, err := s.ReloadConfig(context.Background(), &gradv1.ReloadConfigRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected a call without the token to be Unauthenticated, got %v", err)
+	}
+	noReload := NewAdminServer(service.DefaultKubernetesConfig(), "secret", nil, nil)
+	if _, err := noReload.ReloadConfig(ctx, &gradv1.ReloadConfigRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented without a reloader, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// Outcomes used as the outcome label of the exec stream duration histogram. A command that
+// exits non-zero still counts as a success: the stream delivered its exit status.
+const (
+	ExecOutcomeSuccess   = "success"
+	ExecOutcomeError     = "error"
+	ExecOutcomeTimeout   = "timeout"
+	ExecOutcomeCancelled = "cancelled"
+)
+
+// Streams used as the stream label of the exec output byte counter
+const (
+	ExecStreamStdout = "stdout"
+	ExecStreamStderr = "stderr"
+)
+
+// execOutcome classifies how a command output stream ended: result is set once the command
+// finished, err is what the stream returned and ctxErr the error of the stream's context, which
+// explains err when the client went away or its deadline passed (pure function)
+func execOutcome(result *execResult, err, ctxErr error) string {
+	if err != nil && ctxErr != nil {
+		err = ctxErr
+	}
+	if err == nil && result != nil {
+		switch result.status.Reason {
+		case service.ExitReasonTimeout:
+			return ExecOutcomeTimeout
+		case service.ExitReasonCancelled:
+			return ExecOutcomeCancelled
+		}
+		return ExecOutcomeSuccess
+	}
+	switch {
+	case errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled:
+		return ExecOutcomeCancelled
+	case errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded:
+		return ExecOutcomeTimeout
+	}
+	return ExecOutcomeError
+}
+
+// streamLabel returns the stream label of output of streamType (pure function)
+func streamLabel(streamType gradv1.StreamType) string {
+	if streamType == gradv1.StreamType_STREAM_TYPE_STDERR {
+		return ExecStreamStderr
+	}
+	return ExecStreamStdout
+}
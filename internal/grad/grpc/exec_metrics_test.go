@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/metrics"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// scrapeExecMetrics gathers the exec stream metrics from a registry the way /metrics would,
+// keyed by name and label values, e.g. grpc_exec_stream_bytes_total{stdout}. Histograms
+// report their sample count.
+func scrapeExecMetrics(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	samples := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			var labels []string
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetValue())
+			}
+			key := family.GetName() + "{" + strings.Join(labels, ",") + "}"
+			switch {
+			case metric.GetCounter() != nil:
+				samples[key] = metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				samples[key] = metric.GetGauge().GetValue()
+			case metric.GetHistogram() != nil:
+				samples[key] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return samples
+}
+
+func TestExecStreamMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m, err := metrics.New(registry)
+	if err != nil {
+		t.Fatalf("Failed to create metrics: %v", err)
+	}
+
+	s := NewServer(nil, nil, nil, nil, nil, nil, m)
+	discard := func(*gradv1.ExecuteCommandStreamResponse) error { return nil }
+	run := func(ctx context.Context, execute func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error)) {
+		s.streamCommandOutput(ctx, "exec-test", &outputLimit{}, discard, execute)
+	}
+
+	// 1000 bytes of stdout in two chunks, 24 of stderr and an empty chunk that isn't sent
+	run(context.Background(), func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+		stdoutCh <- make([]byte, 600)
+		stdoutCh <- make([]byte, 400)
+		stdoutCh <- nil
+		stderrCh <- make([]byte, 24)
+		close(stdoutCh)
+		close(stderrCh)
+		return service.ExitStatus{Code: 1, Reason: service.ExitReasonExited}, nil
+	})
+	run(context.Background(), func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+		stdoutCh <- make([]byte, 48)
+		close(stdoutCh)
+		close(stderrCh)
+		return service.ExitStatus{Code: service.ExitCodeTimeout, Reason: service.ExitReasonTimeout}, nil
+	})
+	run(context.Background(), func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+		return service.ExitStatus{}, service.ErrRunnerNotRunning
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	run(ctx, func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+		<-ctx.Done()
+		return service.ExitStatus{}, ctx.Err()
+	})
+
+	got := scrapeExecMetrics(t, registry)
+	expected := map[string]float64{
+		"grpc_exec_stream_bytes_total{stdout}":         1048,
+		"grpc_exec_stream_bytes_total{stderr}":         24,
+		"grpc_exec_stream_duration_seconds{success}":   1,
+		"grpc_exec_stream_duration_seconds{timeout}":   1,
+		"grpc_exec_stream_duration_seconds{error}":     1,
+		"grpc_exec_stream_duration_seconds{cancelled}": 1,
+		"grpc_active_exec_streams{}":                   0,
+	}
+	for key, value := range expected {
+		if got[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, got[key])
+		}
+	}
+}
+
+func TestExecOutcome(t *testing.T) {
+	exited := &execResult{status: service.ExitStatus{Code: 2, Reason: service.ExitReasonExited}}
+	tests := []struct {
+		name     string
+		result   *execResult
+		err      error
+		ctxErr   error
+		expected string
+	}{
+		{"Non-zero exit", exited, nil, nil, ExecOutcomeSuccess},
+		{"Command timed out", &execResult{status: service.ExitStatus{Reason: service.ExitReasonTimeout}}, nil, nil, ExecOutcomeTimeout},
+		{"Command cancelled", &execResult{status: service.ExitStatus{Reason: service.ExitReasonCancelled}}, nil, nil, ExecOutcomeCancelled},
+		{"Client went away", nil, context.Canceled, nil, ExecOutcomeCancelled},
+		{"Client deadline", nil, status.Error(codes.DeadlineExceeded, "deadline"), nil, ExecOutcomeTimeout},
+		{"Service error after the client went away", nil, status.Error(codes.Internal, "context canceled"), context.Canceled, ExecOutcomeCancelled},
+		{"Service error", nil, status.Error(codes.NotFound, "runner not found"), nil, ExecOutcomeError},
+		{"Exit not delivered", exited, errors.New("transport is closing"), nil, ExecOutcomeError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := execOutcome(tt.result, tt.err, tt.ctxErr); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// maxBufferedExecBytes caps the output a resumable command keeps for clients reattaching to
+// it. While a client is attached, the command waits for it rather than drop output it hasn't
+// been sent; without one, older output is dropped, and reattaching from before it fails with
+// OUT_OF_RANGE.
+const maxBufferedExecBytes = 4 << 20
+
+// executionRegistry holds resumable commands by exec ID while they run, and for the resume
+// grace period after they finish so a client can still collect the end of their output
+type executionRegistry struct {
+	mu         sync.Mutex
+	executions map[string]*execution
+	grace      time.Duration
+}
+
+// newExecutionRegistry creates a registry keeping commands without a client for grace
+func newExecutionRegistry(grace time.Duration) *executionRegistry {
+	return &executionRegistry{
+		executions: make(map[string]*execution),
+		grace:      grace,
+	}
+}
+
+// start registers an execution with ID id and runs it in the background, passing run a context
+// that outlives ctx and is cancelled once the execution has had no client for the grace period.
+// Every message run sends is numbered and buffered for the execution's clients. The client
+// starting it is attached before it runs and is returned for follow.
+func (r *executionRegistry) start(ctx context.Context, id string, run func(ctx context.Context, send func(*gradv1.ExecuteCommandStreamResponse) error) error) (*execution, *follower) {
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	e := &execution{
+		id:        id,
+		grace:     r.grace,
+		cancel:    cancel,
+		stopped:   runCtx.Done(),
+		changed:   make(chan struct{}),
+		progress:  make(chan struct{}),
+		followers: make(map[*follower]struct{}),
+	}
+	first := e.attach(0)
+	r.mu.Lock()
+	r.executions[e.id] = e
+	r.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		e.finish(run(runCtx, e.append))
+		time.AfterFunc(r.grace, func() { r.remove(e.id) })
+	}()
+	return e, first
+}
+
+// get returns the execution with ID id, or nil if there is none
+func (r *executionRegistry) get(id string) *execution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.executions[id]
+}
+
+// remove forgets the execution with ID id
+func (r *executionRegistry) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.executions, id)
+}
+
+// newExecID returns a random exec ID
+func newExecID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "exec-" + hex.EncodeToString(b)
+}
+
+// execution is a resumable command: the messages of its stream, numbered from 1, and the
+// clients following them
+type execution struct {
+	id     string
+	grace  time.Duration
+	cancel context.CancelFunc
+	// stopped is closed once the command is cancelled
+	stopped <-chan struct{}
+
+	mu sync.Mutex
+	// frames holds the last messages up to maxBufferedExecBytes of output; next is the
+	// sequence number of the last message
+	frames   []*gradv1.ExecuteCommandStreamResponse
+	buffered int
+	next     int64
+	done     bool
+	err      error
+	// changed is closed and replaced whenever a message is added or the execution ends
+	changed chan struct{}
+	// progress is closed and replaced whenever a client is sent a message or detaches
+	progress  chan struct{}
+	followers map[*follower]struct{}
+	// abandon cancels the command once the last client has been gone for the grace period
+	abandon *time.Timer
+}
+
+// follower is a client following an execution
+type follower struct {
+	// sent is the sequence number of the last message the client was sent
+	sent int64
+}
+
+// append numbers a message and buffers it, dropping the oldest messages beyond
+// maxBufferedExecBytes. Before dropping a message an attached client hasn't been sent yet it
+// waits for the client, so a slow client slows the command down as a plain stream would.
+func (e *execution) append(frame *gradv1.ExecuteCommandStreamResponse) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.next++
+	frame.Sequence = e.next
+	e.frames = append(e.frames, frame)
+	e.buffered += len(frame.Data)
+	e.notify()
+	for e.buffered > maxBufferedExecBytes && len(e.frames) > 1 {
+		if e.behind(e.frames[0].Sequence) {
+			progress := e.progress
+			e.mu.Unlock()
+			select {
+			case <-progress:
+				e.mu.Lock()
+				continue
+			case <-e.stopped:
+				e.mu.Lock()
+				return context.Canceled
+			}
+		}
+		e.buffered -= len(e.frames[0].Data)
+		e.frames = e.frames[1:]
+	}
+	return nil
+}
+
+// behind reports whether an attached client hasn't been sent message sequence yet; e.mu must be held
+func (e *execution) behind(sequence int64) bool {
+	for f := range e.followers {
+		if f.sent < sequence {
+			return true
+		}
+	}
+	return false
+}
+
+// advance records that f was sent message sequence, waking an append waiting for it
+func (e *execution) advance(f *follower, sequence int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	f.sent = sequence
+	e.progressed()
+}
+
+// progressed wakes an append waiting for clients; e.mu must be held
+func (e *execution) progressed() {
+	close(e.progress)
+	e.progress = make(chan struct{})
+}
+
+// finish ends the execution with err, nil if its stream ended normally
+func (e *execution) finish(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.done = true
+	e.err = err
+	if e.abandon != nil {
+		e.abandon.Stop()
+		e.abandon = nil
+	}
+	e.notify()
+}
+
+// notify wakes the clients waiting for a change; e.mu must be held
+func (e *execution) notify() {
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+// since returns the buffered messages after sequence number after and, if the execution has
+// ended, how; otherwise changed is closed once there is more
+func (e *execution) since(after int64) (frames []*gradv1.ExecuteCommandStreamResponse, ended bool, err error, changed <-chan struct{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	first := e.next - int64(len(e.frames)) + 1
+	if after+1 < first {
+		return nil, true, status.Errorf(codes.OutOfRange, "output of execution %s after message %d is no longer buffered", e.id, after), nil
+	}
+	if after < e.next {
+		frames = append(frames, e.frames[after+1-first:]...)
+	}
+	return frames, e.done, e.err, e.changed
+}
+
+// follow sends the client f, attached with attach, the messages after the last it was sent
+// as they arrive, and returns how the execution ended or the error of ctx or send. It detaches
+// f when it returns; the command keeps running without its clients for the grace period.
+func (e *execution) follow(ctx context.Context, f *follower, send func(*gradv1.ExecuteCommandStreamResponse) error) error {
+	defer e.detach(f)
+
+	e.mu.Lock()
+	after := f.sent
+	e.mu.Unlock()
+	for {
+		frames, ended, err, changed := e.since(after)
+		for _, frame := range frames {
+			if err := send(frame); err != nil {
+				return err
+			}
+			after = frame.Sequence
+			e.advance(f, after)
+		}
+		if ended {
+			return err
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// attach registers a client that was sent the messages up to sequence number after, keeping
+// the command from being abandoned
+func (e *execution) attach(after int64) *follower {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	f := &follower{sent: after}
+	e.followers[f] = struct{}{}
+	if e.abandon != nil {
+		e.abandon.Stop()
+		e.abandon = nil
+	}
+	return f
+}
+
+// detach unregisters a client, so the command no longer waits for it. Once the last is gone
+// the command is cancelled unless a client attaches within the grace period.
+func (e *execution) detach(f *follower) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.followers, f)
+	e.progressed()
+	if len(e.followers) > 0 || e.done {
+		return
+	}
+	e.abandon = time.AfterFunc(e.grace, func() {
+		slog.Warn("Cancelling resumable command no client reattached to", "exec_id", e.id, "grace", e.grace)
+		e.cancel()
+	})
+}
@@ -0,0 +1,168 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// followed collects the messages an execution sends to a client
+type followed struct {
+	frames []*gradv1.ExecuteCommandStreamResponse
+}
+
+func (f *followed) send(frame *gradv1.ExecuteCommandStreamResponse) error {
+	f.frames = append(f.frames, frame)
+	return nil
+}
+
+func TestExecutionResume(t *testing.T) {
+	registry := newExecutionRegistry(time.Minute)
+	proceed := make(chan struct{})
+	e, client := registry.start(context.Background(), "exec-test", func(ctx context.Context, send func(*gradv1.ExecuteCommandStreamResponse) error) error {
+		send(startedMessage("exec-test", service.ExecStart{RunnerID: "runner-1"}))
+		send(&gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_STDOUT, Data: []byte("one\n")})
+		<-proceed
+		send(&gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_STDOUT, Data: []byte("two\n")})
+		return send(&gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_EXIT})
+	})
+
+	// The first client's stream breaks after the first line
+	first := &followed{}
+	ctx, cancel := context.WithCancel(context.Background())
+	err := e.follow(ctx, client, func(frame *gradv1.ExecuteCommandStreamResponse) error {
+		first.send(frame)
+		if frame.Type == gradv1.StreamType_STREAM_TYPE_STDOUT {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the first client to be cancelled, got %v", err)
+	}
+	if len(first.frames) != 2 || first.frames[0].Type != gradv1.StreamType_STREAM_TYPE_STARTED || first.frames[0].ExecId != "exec-test" {
+		t.Fatalf("Expected STARTED with an exec ID then the first line, got %v", first.frames)
+	}
+
+	close(proceed)
+	attached := registry.get(first.frames[0].ExecId)
+	if attached == nil {
+		t.Fatal("Expected the execution to be registered")
+	}
+	second := &followed{}
+	if err := attached.follow(context.Background(), attached.attach(first.frames[1].Sequence), second.send); err != nil {
+		t.Fatalf("Expected the execution to end normally, got %v", err)
+	}
+	if len(second.frames) != 2 || string(second.frames[0].Data) != "two\n" || second.frames[0].Sequence != 3 || second.frames[1].Type != gradv1.StreamType_STREAM_TYPE_EXIT {
+		t.Errorf("Expected the rest of the output from message 3, got %v", second.frames)
+	}
+
+	// A finished execution can still be read to its end
+	replay := &followed{}
+	if err := attached.follow(context.Background(), attached.attach(0), replay.send); err != nil || len(replay.frames) != 4 {
+		t.Errorf("Expected all 4 messages again, got %v, %v", replay.frames, err)
+	}
+}
+
+func TestExecutionAbandoned(t *testing.T) {
+	registry := newExecutionRegistry(10 * time.Millisecond)
+	cancelled := make(chan struct{})
+	e, client := registry.start(context.Background(), "exec-test", func(ctx context.Context, send func(*gradv1.ExecuteCommandStreamResponse) error) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e.follow(ctx, client, (&followed{}).send)
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the command to be cancelled once nobody reattached within the grace period")
+	}
+}
+
+func TestExecutionDroppedOutput(t *testing.T) {
+	registry := newExecutionRegistry(time.Minute)
+	chunk := []byte(strings.Repeat("x", maxBufferedExecBytes/2))
+	sent := make(chan struct{})
+	e, client := registry.start(context.Background(), "exec-test", func(ctx context.Context, send func(*gradv1.ExecuteCommandStreamResponse) error) error {
+		send(startedMessage("exec-test", service.ExecStart{}))
+		for i := 0; i < 3; i++ {
+			send(&gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_STDOUT, Data: chunk})
+		}
+		close(sent)
+		return nil
+	})
+	// Output is only dropped once no client is attached
+	e.detach(client)
+	<-sent
+
+	if err := e.follow(context.Background(), e.attach(1), (&followed{}).send); status.Code(err) != codes.OutOfRange {
+		t.Errorf("Expected OutOfRange resuming from dropped output, got %v", err)
+	}
+	recent := &followed{}
+	if err := e.follow(context.Background(), e.attach(3), recent.send); err != nil || len(recent.frames) != 1 {
+		t.Errorf("Expected the last chunk, got %d messages, %v", len(recent.frames), err)
+	}
+}
+
+func TestExecutionWaitsForSlowClient(t *testing.T) {
+	registry := newExecutionRegistry(time.Minute)
+	chunk := []byte(strings.Repeat("x", 1<<20))
+	const chunks = 12
+	var produced atomic.Int32
+	e, client := registry.start(context.Background(), "exec-test", func(ctx context.Context, send func(*gradv1.ExecuteCommandStreamResponse) error) error {
+		send(startedMessage("exec-test", service.ExecStart{}))
+		for i := 0; i < chunks; i++ {
+			if err := send(&gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_STDOUT, Data: chunk}); err != nil {
+				return err
+			}
+			produced.Add(1)
+		}
+		return send(&gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_EXIT})
+	})
+
+	// The client stalls on the first chunk, long enough for the command to produce all of its
+	// output if nothing held it back
+	var received int
+	err := e.follow(context.Background(), client, func(frame *gradv1.ExecuteCommandStreamResponse) error {
+		if frame.Type != gradv1.StreamType_STREAM_TYPE_STDOUT {
+			return nil
+		}
+		if received == 0 {
+			time.Sleep(100 * time.Millisecond)
+			if n := produced.Load(); n > maxBufferedExecBytes/int32(len(chunk))+1 {
+				t.Errorf("Expected the command to wait for the client, got %d chunks produced", n)
+			}
+		}
+		received++
+		return nil
+	})
+	if err != nil || received != chunks {
+		t.Errorf("Expected all %d chunks, got %d, %v", chunks, received, err)
+	}
+}
+
+func TestAttachExecutionUnknown(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, nil, nil, nil)
+
+	err := s.AttachExecution(&gradv1.AttachExecutionRequest{ExecId: "exec-gone"}, nil)
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown execution, got %v", err)
+	}
+	if err := s.AttachExecution(&gradv1.AttachExecutionRequest{}, nil); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without exec_id, got %v", err)
+	}
+}
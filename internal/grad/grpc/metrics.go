@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/strrl/gra/internal/grad/metrics"
+)
+
+// MetricsUnaryInterceptor counts unary RPCs and observes their duration in m
+func MetricsUnaryInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		startedAt := time.Now()
+		resp, err := handler(ctx, req)
+		observeRequest(m, info.FullMethod, startedAt, err)
+		return resp, err
+	}
+}
+
+// MetricsStreamInterceptor counts streaming RPCs and observes how long they stay open in m
+func MetricsStreamInterceptor(m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		startedAt := time.Now()
+		err := handler(srv, ss)
+		observeRequest(m, info.FullMethod, startedAt, err)
+		return err
+	}
+}
+
+// observeRequest records an RPC of method that started at startedAt and returned err
+func observeRequest(m *metrics.Metrics, method string, startedAt time.Time, err error) {
+	m.GRPCRequestsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.GRPCRequestDuration.WithLabelValues(method).Observe(time.Since(startedAt).Seconds())
+}
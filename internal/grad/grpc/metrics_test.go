@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/metrics"
+)
+
+func TestMetricsInterceptors(t *testing.T) {
+	m := metrics.NewUnregistered()
+	unary := MetricsUnaryInterceptor(m)
+	info := &grpc.UnaryServerInfo{FullMethod: gradv1.RunnerService_GetRunner_FullMethodName}
+
+	ok := func(ctx context.Context, req any) (any, error) { return "runner", nil }
+	notFound := func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.NotFound, "runner not found")
+	}
+	for _, handler := range []grpc.UnaryHandler{ok, ok, notFound} {
+		unary(context.Background(), nil, info, handler)
+	}
+
+	stream := MetricsStreamInterceptor(m)
+	streamInfo := &grpc.StreamServerInfo{FullMethod: gradv1.RunnerService_ExecuteCommandStream_FullMethodName}
+	stream(nil, nil, streamInfo, func(srv any, ss grpc.ServerStream) error { return nil })
+
+	getRunner, execStream := gradv1.RunnerService_GetRunner_FullMethodName, gradv1.RunnerService_ExecuteCommandStream_FullMethodName
+	counts := []struct {
+		method   string
+		code     codes.Code
+		expected float64
+	}{
+		{getRunner, codes.OK, 2},
+		{getRunner, codes.NotFound, 1},
+		{execStream, codes.OK, 1},
+		{execStream, codes.Internal, 0},
+	}
+	for _, c := range counts {
+		if got := testutil.ToFloat64(m.GRPCRequestsTotal.WithLabelValues(c.method, c.code.String())); got != c.expected {
+			t.Errorf("Expected %v requests of %s with %s, got %v", c.expected, c.method, c.code, got)
+		}
+	}
+	if got := testutil.CollectAndCount(m.GRPCRequestDuration); got != 2 {
+		t.Errorf("Expected a duration series per method, got %d", got)
+	}
+}
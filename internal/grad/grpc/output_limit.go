@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// outputLimit caps the output of one command sent to the client. It counts all the output,
+// including what isn't sent, so the EXIT message can report how much was dropped.
+type outputLimit struct {
+	// maxBytes caps stdout and stderr together, 0 for no cap
+	maxBytes int64
+	policy   service.TruncatePolicy
+
+	stdoutBytes int64
+	stderrBytes int64
+	// truncated is set once output beyond maxBytes has been dropped
+	truncated bool
+}
+
+// newOutputLimit returns the limit for req: the lower of the server's maxBytes and the
+// request's own, where 0 means no limit (pure function)
+func newOutputLimit(req *service.ExecuteCommandRequest, maxBytes int64) *outputLimit {
+	if req.MaxOutputBytes > 0 && (maxBytes <= 0 || req.MaxOutputBytes < maxBytes) {
+		maxBytes = req.MaxOutputBytes
+	}
+	policy := req.TruncatePolicy
+	if policy == "" {
+		policy = service.TruncatePolicyKill
+	}
+	return &outputLimit{maxBytes: maxBytes, policy: policy}
+}
+
+// accept counts a chunk of output of streamType and returns the part of it to send, which is
+// empty once the limit has been reached
+func (l *outputLimit) accept(streamType gradv1.StreamType, data []byte) []byte {
+	before := l.stdoutBytes + l.stderrBytes
+	if streamType == gradv1.StreamType_STREAM_TYPE_STDERR {
+		l.stderrBytes += int64(len(data))
+	} else {
+		l.stdoutBytes += int64(len(data))
+	}
+
+	if l.maxBytes <= 0 {
+		return data
+	}
+	remaining := l.maxBytes - min(before, l.maxBytes)
+	if int64(len(data)) <= remaining {
+		return data
+	}
+	l.truncated = true
+	return data[:remaining]
+}
+
+// exitStatus returns the status to report for a command that finished with status. A
+// command cancelled because it passed the limit under TruncatePolicyKill reports
+// ExitReasonOutputLimit instead.
+func (l *outputLimit) exitStatus(status service.ExitStatus) service.ExitStatus {
+	if l.truncated && l.policy == service.TruncatePolicyKill && status.Reason == service.ExitReasonCancelled {
+		return service.ExitStatus{Code: service.ExitCodeOutputLimit, Reason: service.ExitReasonOutputLimit}
+	}
+	return status
+}
+
+// annotate adds the output counts and limit to an EXIT message
+func (l *outputLimit) annotate(exit *gradv1.ExecuteCommandStreamResponse) {
+	exit.OutputTruncated = l.truncated
+	exit.StdoutBytes = l.stdoutBytes
+	exit.StderrBytes = l.stderrBytes
+	exit.MaxOutputBytes = l.maxBytes
+}
@@ -0,0 +1,133 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/metrics"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+func TestNewOutputLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          *service.ExecuteCommandRequest
+		serverMax    int64
+		expectMax    int64
+		expectPolicy service.TruncatePolicy
+	}{
+		{"Server limit", &service.ExecuteCommandRequest{}, 1000, 1000, service.TruncatePolicyKill},
+		{"Lower request limit", &service.ExecuteCommandRequest{MaxOutputBytes: 10}, 1000, 10, service.TruncatePolicyKill},
+		{"Request can't raise the limit", &service.ExecuteCommandRequest{MaxOutputBytes: 5000}, 1000, 1000, service.TruncatePolicyKill},
+		{"Request limit without a server limit", &service.ExecuteCommandRequest{MaxOutputBytes: 5000}, 0, 5000, service.TruncatePolicyKill},
+		{"Unlimited", &service.ExecuteCommandRequest{}, 0, 0, service.TruncatePolicyKill},
+		{"Discard", &service.ExecuteCommandRequest{TruncatePolicy: service.TruncatePolicyDiscard}, 1000, 1000, service.TruncatePolicyDiscard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := newOutputLimit(tt.req, tt.serverMax)
+			if limit.maxBytes != tt.expectMax || limit.policy != tt.expectPolicy {
+				t.Errorf("Expected limit %d (%s), got %d (%s)", tt.expectMax, tt.expectPolicy, limit.maxBytes, limit.policy)
+			}
+		})
+	}
+}
+
+func TestOutputLimitAccept(t *testing.T) {
+	limit := &outputLimit{maxBytes: 10}
+
+	if got := limit.accept(gradv1.StreamType_STREAM_TYPE_STDOUT, []byte("123456")); string(got) != "123456" {
+		t.Errorf("Expected the whole chunk within the limit, got %q", got)
+	}
+	if got := limit.accept(gradv1.StreamType_STREAM_TYPE_STDERR, []byte("abcdef")); string(got) != "abcd" {
+		t.Errorf("Expected the chunk cut at the limit, got %q", got)
+	}
+	if got := limit.accept(gradv1.StreamType_STREAM_TYPE_STDOUT, []byte("xyz")); len(got) != 0 {
+		t.Errorf("Expected nothing past the limit, got %q", got)
+	}
+	if !limit.truncated || limit.stdoutBytes != 9 || limit.stderrBytes != 6 {
+		t.Errorf("Expected truncation with 9 stdout and 6 stderr bytes counted, got %+v", limit)
+	}
+
+	exact := &outputLimit{maxBytes: 4}
+	exact.accept(gradv1.StreamType_STREAM_TYPE_STDOUT, []byte("1234"))
+	if exact.truncated {
+		t.Error("Expected output of exactly the limit not to be truncated")
+	}
+}
+
+// floodOutput writes 10-byte chunks of stdout, count of them or until ctx is cancelled
+func floodOutput(ctx context.Context, stdoutCh, stderrCh chan<- []byte, count int) (service.ExitStatus, error) {
+	defer close(stdoutCh)
+	defer close(stderrCh)
+	for i := 0; i < count; i++ {
+		select {
+		case <-ctx.Done():
+			return service.ExitStatus{Code: service.ExitCodeCancelled, Reason: service.ExitReasonCancelled}, nil
+		case stdoutCh <- []byte("0123456789"):
+		}
+	}
+	return service.ExitStatus{Code: 0, Reason: service.ExitReasonExited}, nil
+}
+
+func TestStreamCommandOutputOutputLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       service.TruncatePolicy
+		chunks       int
+		expectCode   int32
+		expectReason gradv1.ExitReason
+	}{
+		{"Kill", service.TruncatePolicyKill, 1 << 30, service.ExitCodeOutputLimit, gradv1.ExitReason_EXIT_REASON_OUTPUT_LIMIT},
+		{"Discard", service.TruncatePolicyDiscard, 500, 0, gradv1.ExitReason_EXIT_REASON_EXITED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := metrics.NewUnregistered()
+			s := NewServer(nil, nil, nil, nil, nil, nil, m)
+
+			var sent []*gradv1.ExecuteCommandStreamResponse
+			send := func(resp *gradv1.ExecuteCommandStreamResponse) error {
+				sent = append(sent, resp)
+				return nil
+			}
+			limit := &outputLimit{maxBytes: 25, policy: tt.policy}
+			err := s.streamCommandOutput(context.Background(), "exec-test", limit, send, func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+				return floodOutput(ctx, stdoutCh, stderrCh, tt.chunks)
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var output int
+			for _, resp := range sent[:len(sent)-1] {
+				output += len(resp.Data)
+			}
+			if output != 25 {
+				t.Errorf("Expected 25 bytes of output sent, got %d", output)
+			}
+
+			exit := sent[len(sent)-1]
+			if exit.Type != gradv1.StreamType_STREAM_TYPE_EXIT {
+				t.Fatalf("Expected last message to be EXIT, got %s", exit.Type)
+			}
+			if exit.ExitCode != tt.expectCode || exit.FailureReason != tt.expectReason {
+				t.Errorf("Expected exit (%d, %s), got (%d, %s)", tt.expectCode, tt.expectReason, exit.ExitCode, exit.FailureReason)
+			}
+			if !exit.OutputTruncated || exit.MaxOutputBytes != 25 || exit.StdoutBytes <= 25 || exit.StderrBytes != 0 {
+				t.Errorf("Expected truncation reported with the byte counts, got %+v", exit)
+			}
+			if tt.policy == service.TruncatePolicyDiscard && exit.StdoutBytes != 5000 {
+				t.Errorf("Expected all 5000 bytes counted, got %d", exit.StdoutBytes)
+			}
+			if got := testutil.ToFloat64(m.ExecOutputTruncatedTotal.WithLabelValues(string(tt.policy))); got != 1 {
+				t.Errorf("Expected 1 truncated execution counted, got %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/metrics"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// RPC classes used as the class label of the throttle counter
+const (
+	RPCClassMutating = "mutating"
+	RPCClassRead     = "read"
+)
+
+// Limit scopes used as the scope label of the throttle counter
+const (
+	RateLimitScopeGlobal   = "global"
+	RateLimitScopeIdentity = "identity"
+)
+
+const (
+	// gradServicePrefix selects the RPCs that are rate limited; reflection and health are not
+	gradServicePrefix = "/grad.v1."
+	// maxTrackedIdentities bounds the per-identity limiters kept in memory
+	maxTrackedIdentities = 10000
+)
+
+// mutatingMethods create, update or delete pods. ExecuteService.ExecuteCommand may create a runner.
+var mutatingMethods = map[string]bool{
+	gradv1.RunnerService_CreateRunner_FullMethodName:      true,
+	gradv1.RunnerService_DeleteRunner_FullMethodName:      true,
+	gradv1.RunnerService_UpdateRunner_FullMethodName:      true,
+	gradv1.RunnerService_CloneRunner_FullMethodName:       true,
+	gradv1.RunnerService_DeleteRunnerGroup_FullMethodName: true,
+	gradv1.ExecuteService_ExecuteCommand_FullMethodName:   true,
+}
+
+// RateLimiter applies token-bucket limits to the grad gRPC API. Limits can be changed at
+// runtime with SetLimits, which starts every bucket full again.
+type RateLimiter struct {
+	mu         sync.Mutex
+	limits     service.RateLimits
+	global     map[string]*rate.Limiter
+	identities map[string]*rate.Limiter
+	// identity returns the authenticated client of a request, or "" if there is none
+	identity func(ctx context.Context) string
+	now      func() time.Time
+	metrics  *metrics.Metrics
+}
+
+// NewRateLimiter creates a rate limiter enforcing limits and counting rejections in m; a nil m
+// counts into unregistered metrics
+func NewRateLimiter(limits service.RateLimits, m *metrics.Metrics) (*RateLimiter, error) {
+	if m == nil {
+		m = metrics.NewUnregistered()
+	}
+	l := &RateLimiter{
+		identity: peerIdentity,
+		now:      time.Now,
+		metrics:  m,
+	}
+	if err := l.SetLimits(limits); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Limits returns the limits currently enforced
+func (l *RateLimiter) Limits() service.RateLimits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limits
+}
+
+// SetLimits replaces the enforced limits, rejecting invalid ones
+func (l *RateLimiter) SetLimits(limits service.RateLimits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	l.global = map[string]*rate.Limiter{
+		RPCClassMutating: newBucket(limits.MutatingRate, limits.MutatingBurst),
+		RPCClassRead:     newBucket(limits.ReadRate, limits.ReadBurst),
+	}
+	l.identities = make(map[string]*rate.Limiter)
+	return nil
+}
+
+// UnaryInterceptor rejects unary RPCs over the limit
+func (l *RateLimiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.allow(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor rejects streaming RPCs over the limit
+func (l *RateLimiter) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.allow(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// allow takes a token for method from the global bucket and, if enabled, the caller's bucket.
+// It returns a ResourceExhausted error with a RetryInfo detail when either is empty.
+func (l *RateLimiter) allow(ctx context.Context, method string) error {
+	if !strings.HasPrefix(method, gradServicePrefix) {
+		return nil
+	}
+
+	class := RPCClassRead
+	if mutatingMethods[method] {
+		class = RPCClassMutating
+	}
+
+	l.mu.Lock()
+	buckets := []*rate.Limiter{l.global[class]}
+	scopes := []string{RateLimitScopeGlobal}
+	if identity := l.identity(ctx); identity != "" {
+		buckets = append(buckets, l.identityBucket(class, identity))
+		scopes = append(scopes, RateLimitScopeIdentity)
+	}
+	l.mu.Unlock()
+
+	// Reserve from every bucket first, so a rejection doesn't use up tokens elsewhere
+	now := l.now()
+	reservations := make([]*rate.Reservation, 0, len(buckets))
+	var retryAfter time.Duration
+	var scope string
+	for i, bucket := range buckets {
+		if bucket == nil {
+			continue
+		}
+		reservation := bucket.ReserveN(now, 1)
+		reservations = append(reservations, reservation)
+		if delay := reservation.DelayFrom(now); delay > retryAfter {
+			retryAfter, scope = delay, scopes[i]
+		}
+	}
+	if retryAfter == 0 {
+		return nil
+	}
+	for _, reservation := range reservations {
+		reservation.CancelAt(now)
+	}
+
+	l.metrics.RateLimitedRequestsTotal.WithLabelValues(class, scope).Inc()
+	return rateLimitError(ctx, class, scope, retryAfter)
+}
+
+// identityBucket returns the caller's bucket for class, or nil if identities aren't limited; l.mu must be held
+func (l *RateLimiter) identityBucket(class, identity string) *rate.Limiter {
+	r, burst := l.limits.IdentityReadRate, l.limits.IdentityReadBurst
+	if class == RPCClassMutating {
+		r, burst = l.limits.IdentityMutatingRate, l.limits.IdentityMutatingBurst
+	}
+	if r == 0 {
+		return nil
+	}
+
+	key := class + "/" + identity
+	bucket, ok := l.identities[key]
+	if !ok {
+		// Forgetting everyone is crude but keeps memory bounded; it only lets a burst through
+		if len(l.identities) >= maxTrackedIdentities {
+			l.identities = make(map[string]*rate.Limiter)
+		}
+		bucket = newBucket(r, burst)
+		l.identities[key] = bucket
+	}
+	return bucket
+}
+
+// newBucket returns a token bucket, or nil if r is zero and there is no limit (pure function)
+func newBucket(r float64, burst int) *rate.Limiter {
+	if r == 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(r), burst)
+}
+
+// rateLimitError builds the ResourceExhausted error returned to throttled clients. The delay
+// is sent as a RetryInfo detail and as a retry-after header in whole seconds.
+func rateLimitError(ctx context.Context, class, scope string, retryAfter time.Duration) error {
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", fmt.Sprint(seconds)))
+
+	st := status.Newf(codes.ResourceExhausted, "rate limit exceeded for %s requests (%s), retry after %s",
+		class, scope, retryAfter.Round(time.Millisecond))
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// peerIdentity returns the common name of a verified TLS client certificate, or "" for
+// unauthenticated clients, which are only subject to the global limits
+func peerIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+}
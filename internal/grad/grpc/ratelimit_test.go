@@ -0,0 +1,186 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// stubRunnerServer answers CreateRunner and ListRunners without doing anything
+type stubRunnerServer struct {
+	gradv1.UnimplementedRunnerServiceServer
+}
+
+func (stubRunnerServer) CreateRunner(ctx context.Context, req *gradv1.CreateRunnerRequest) (*gradv1.CreateRunnerResponse, error) {
+	return &gradv1.CreateRunnerResponse{}, nil
+}
+
+func (stubRunnerServer) ListRunners(ctx context.Context, req *gradv1.ListRunnersRequest) (*gradv1.ListRunnersResponse, error) {
+	return &gradv1.ListRunnersResponse{}, nil
+}
+
+// startLimitedServer serves a stub RunnerService behind limiter and returns a client for it
+func startLimitedServer(t *testing.T, limiter *RateLimiter) gradv1.RunnerServiceClient {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(limiter.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(limiter.StreamInterceptor()),
+	)
+	gradv1.RegisterRunnerServiceServer(srv, stubRunnerServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return gradv1.NewRunnerServiceClient(conn)
+}
+
+func TestRateLimiterRejectsOverLimit(t *testing.T) {
+	limiter, err := NewRateLimiter(service.RateLimits{MutatingRate: 0.01, MutatingBurst: 2, ReadRate: 100, ReadBurst: 10}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	client := startLimitedServer(t, limiter)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.CreateRunner(ctx, &gradv1.CreateRunnerRequest{}); err != nil {
+			t.Fatalf("Expected request %d within the burst to succeed, got %v", i+1, err)
+		}
+	}
+
+	var header metadata.MD
+	_, err = client.CreateRunner(ctx, &gradv1.CreateRunnerRequest{}, grpc.Header(&header))
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted, got %v", err)
+	}
+
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	if retryInfo == nil {
+		t.Fatalf("Expected a RetryInfo detail, got %v", st.Details())
+	}
+	if delay := retryInfo.RetryDelay.AsDuration(); delay <= 0 || delay > 100*time.Second {
+		t.Errorf("Expected a retry delay of up to 100s, got %s", delay)
+	}
+	if values := header.Get("retry-after"); len(values) != 1 || values[0] == "0" {
+		t.Errorf("Expected a retry-after header in seconds, got %v", values)
+	}
+
+	if got := testutil.ToFloat64(limiter.metrics.RateLimitedRequestsTotal.WithLabelValues(RPCClassMutating, RateLimitScopeGlobal)); got != 1 {
+		t.Errorf("Expected 1 throttled request to be counted, got %v", got)
+	}
+
+	// Reads have their own bucket
+	if _, err := client.ListRunners(ctx, &gradv1.ListRunnersRequest{}); err != nil {
+		t.Errorf("Expected reads to be unaffected by the mutating limit, got %v", err)
+	}
+}
+
+func TestRateLimiterSetLimits(t *testing.T) {
+	limiter, err := NewRateLimiter(service.RateLimits{MutatingRate: 0.01, MutatingBurst: 1}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	client := startLimitedServer(t, limiter)
+	ctx := context.Background()
+
+	client.CreateRunner(ctx, &gradv1.CreateRunnerRequest{})
+	if _, err := client.CreateRunner(ctx, &gradv1.CreateRunnerRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted, got %v", err)
+	}
+
+	if err := limiter.SetLimits(service.RateLimits{MutatingRate: 1, MutatingBurst: 0}); err == nil {
+		t.Error("Expected a zero burst to be rejected")
+	}
+
+	// A zero rate turns the limit off
+	if err := limiter.SetLimits(service.RateLimits{}); err != nil {
+		t.Fatalf("Failed to set limits: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := client.CreateRunner(ctx, &gradv1.CreateRunnerRequest{}); err != nil {
+			t.Fatalf("Expected unlimited requests to succeed, got %v", err)
+		}
+	}
+}
+
+func TestRateLimiterPerIdentity(t *testing.T) {
+	limiter, err := NewRateLimiter(service.RateLimits{
+		MutatingRate:          100,
+		MutatingBurst:         100,
+		IdentityMutatingRate:  0.01,
+		IdentityMutatingBurst: 1,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	type identityKey struct{}
+	limiter.identity = func(ctx context.Context) string {
+		identity, _ := ctx.Value(identityKey{}).(string)
+		return identity
+	}
+	method := gradv1.RunnerService_CreateRunner_FullMethodName
+
+	alice := context.WithValue(context.Background(), identityKey{}, "alice")
+	bob := context.WithValue(context.Background(), identityKey{}, "bob")
+
+	if err := limiter.allow(alice, method); err != nil {
+		t.Fatalf("Expected alice's first request to succeed, got %v", err)
+	}
+	err = limiter.allow(alice, method)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected alice to be throttled, got %v", err)
+	}
+	if msg := status.Convert(err).Message(); !strings.Contains(msg, RateLimitScopeIdentity) {
+		t.Errorf("Expected error to name the identity scope, got %q", msg)
+	}
+	if err := limiter.allow(bob, method); err != nil {
+		t.Errorf("Expected bob to have a separate bucket, got %v", err)
+	}
+	// Unauthenticated callers only use the global bucket
+	for i := 0; i < 3; i++ {
+		if err := limiter.allow(context.Background(), method); err != nil {
+			t.Errorf("Expected unauthenticated request to succeed, got %v", err)
+		}
+	}
+}
+
+func TestRateLimiterIgnoresOtherServices(t *testing.T) {
+	limiter, err := NewRateLimiter(service.RateLimits{ReadRate: 0.01, ReadBurst: 1}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := limiter.allow(context.Background(), "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"); err != nil {
+			t.Fatalf("Expected reflection requests not to be limited, got %v", err)
+		}
+	}
+}
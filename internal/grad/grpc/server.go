@@ -5,9 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
 	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/metrics"
 	"github.com/strrl/gra/internal/grad/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -18,26 +22,84 @@ type Server struct {
 	gradv1.UnimplementedExecuteServiceServer
 	runnerService  service.RunnerService
 	executeService service.ExecuteService
+	// limitsMu guards envLimits and commandLimits, which SetLimits changes at runtime
+	limitsMu      sync.RWMutex
+	envLimits     *service.EnvLimits
+	commandLimits *service.CommandLimits
+	streams       *StreamLimiter
+	// maxOutputBytes caps the output sent for a command, 0 for no cap
+	maxOutputBytes int64
+	executions     *executionRegistry
+	info           *service.ServerInfo
+	metrics        *metrics.Metrics
+	// disabledFeatures are advertised by GetServerInfo; the services refuse them
+	disabledFeatures service.DisabledFeatures
+	// streamWakeup, if set, is called on every pass of streamCommandOutput's loop, so tests
+	// can tell an idle stream blocks rather than spins
+	streamWakeup func()
 }
 
-// NewServer creates a new gRPC server instance
-func NewServer(runnerService service.RunnerService, executeService service.ExecuteService) *Server {
+// NewServer creates a new gRPC server instance. A nil commandLimits applies the defaults; a nil
+// streamLimits leaves command streams unlimited; a nil info leaves the build details out of
+// GetServerInfo; a nil m records command streams into unregistered metrics.
+func NewServer(runnerService service.RunnerService, executeService service.ExecuteService, envLimits *service.EnvLimits, commandLimits *service.CommandLimits, streamLimits *service.StreamLimits, info *service.ServerInfo, m *metrics.Metrics) *Server {
+	if commandLimits == nil {
+		commandLimits = service.DefaultCommandLimits()
+	}
+	if streamLimits == nil {
+		streamLimits = &service.StreamLimits{}
+	}
+	if m == nil {
+		m = metrics.NewUnregistered()
+	}
 	return &Server{
 		runnerService:  runnerService,
 		executeService: executeService,
+		envLimits:      envLimits,
+		commandLimits:  commandLimits,
+		streams:        NewStreamLimiter(*streamLimits, m.ActiveExecStreams),
+		maxOutputBytes: int64(streamLimits.MaxExecOutputBytes),
+		executions:     newExecutionRegistry(streamLimits.ExecResumeGrace),
+		info:           info,
+		metrics:        m,
 	}
 }
 
-// CreateRunner creates a new runner instance
-func (s *Server) CreateRunner(ctx context.Context, req *gradv1.CreateRunnerRequest) (*gradv1.CreateRunnerResponse, error) {
-	// Validate request
-	if err := s.validateCreateRunnerRequest(req); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+// SetLimits replaces the limits requests are validated against. A nil commandLimits applies
+// the defaults.
+func (s *Server) SetLimits(envLimits *service.EnvLimits, commandLimits *service.CommandLimits) {
+	if commandLimits == nil {
+		commandLimits = service.DefaultCommandLimits()
 	}
+	s.limitsMu.Lock()
+	defer s.limitsMu.Unlock()
+	s.envLimits = envLimits
+	s.commandLimits = commandLimits
+}
+
+// SetDisabledFeatures sets the features GetServerInfo reports as disabled. Call it before
+// serving.
+func (s *Server) SetDisabledFeatures(features service.DisabledFeatures) {
+	s.disabledFeatures = features
+}
+
+// limits returns the limits requests are validated against
+func (s *Server) limits() (*service.EnvLimits, *service.CommandLimits) {
+	s.limitsMu.RLock()
+	defer s.limitsMu.RUnlock()
+	return s.envLimits, s.commandLimits
+}
 
+// CreateRunner creates a new runner instance
+func (s *Server) CreateRunner(ctx context.Context, req *gradv1.CreateRunnerRequest) (*gradv1.CreateRunnerResponse, error) {
 	// Convert proto request to domain request
 	domainReq := service.FromProtoCreateRunnerRequest(req)
 
+	// Validate request
+	if err := s.validateCreateRunnerRequest(domainReq); err != nil {
+		return nil, s.mapServiceError(err)
+	}
+
 	// Call service layer
 	runner, err := s.runnerService.CreateRunner(ctx, domainReq)
 	if err != nil {
@@ -46,7 +108,8 @@ func (s *Server) CreateRunner(ctx context.Context, req *gradv1.CreateRunnerReque
 
 	// Convert domain response to proto response
 	return &gradv1.CreateRunnerResponse{
-		Runner: runner.ToProto(),
+		Runner:   runner.ToProto(),
+		Warnings: runner.Warnings,
 	}, nil
 }
 
@@ -56,27 +119,108 @@ func (s *Server) DeleteRunner(ctx context.Context, req *gradv1.DeleteRunnerReque
 	if req.RunnerId == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "runner_id is required")
 	}
+	if req.WaitTimeoutSeconds < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "wait_timeout_seconds must not be negative")
+	}
 
 	// Call service layer
-	err := s.runnerService.DeleteRunner(ctx, req.RunnerId)
+	cleanup, err := s.runnerService.DeleteRunner(ctx, req.RunnerId, req.OverrideProtection)
+	if err != nil && !errors.Is(err, service.ErrCleanupIncomplete) {
+		return nil, s.mapServiceError(err)
+	}
+
+	resp := deleteRunnerResponse(req.RunnerId, cleanup)
+	// Objects that failed to delete won't go away by waiting, so there is nothing to wait for
+	if !req.Wait || len(cleanup.Failed) > 0 {
+		return resp, nil
+	}
+
+	timeout := time.Duration(req.WaitTimeoutSeconds) * time.Second
+	remaining, err := s.runnerService.WaitForRunnerDeleted(ctx, req.RunnerId, timeout)
 	if err != nil {
 		return nil, s.mapServiceError(err)
 	}
+	waitedDeleteRunnerResponse(req.RunnerId, resp, remaining)
+	return resp, nil
+}
 
-	return &gradv1.DeleteRunnerResponse{
-		Message: fmt.Sprintf("runner %s deletion initiated", req.RunnerId),
-	}, nil
+// waitedDeleteRunnerResponse reports on resp whether the objects of a deleted runner were gone
+// before the wait timed out
+func waitedDeleteRunnerResponse(runnerID string, resp *gradv1.DeleteRunnerResponse, remaining []service.RunnerResource) {
+	resp.Completed = len(remaining) == 0
+	for _, resource := range remaining {
+		resp.RemainingResources = append(resp.RemainingResources, resource.String())
+	}
+	if resp.Completed {
+		resp.Message = fmt.Sprintf("runner %s deleted", runnerID)
+		return
+	}
+	resp.Message = fmt.Sprintf("runner %s deletion still in progress: %d object(s) still terminating", runnerID, len(remaining))
 }
 
-// ListRunners returns all available runners
-func (s *Server) ListRunners(ctx context.Context, req *gradv1.ListRunnersRequest) (*gradv1.ListRunnersResponse, error) {
+// deleteRunnerResponse reports what a runner deletion cleaned up. A partial cleanup is not an
+// RPC error: the response lists the objects left behind and the runner stays delete_failed.
+func deleteRunnerResponse(runnerID string, cleanup *service.RunnerCleanup) *gradv1.DeleteRunnerResponse {
+	resp := &gradv1.DeleteRunnerResponse{
+		Message: fmt.Sprintf("runner %s deletion initiated", runnerID),
+	}
+	for _, resource := range cleanup.Deleted {
+		resp.DeletedResources = append(resp.DeletedResources, resource.String())
+	}
+	for _, failure := range cleanup.Failed {
+		resp.FailedResources = append(resp.FailedResources, fmt.Sprintf("%s: %v", failure.Resource, failure.Err))
+	}
+	if len(cleanup.Failed) > 0 {
+		resp.Message = fmt.Sprintf("runner %s deletion incomplete: %d object(s) could not be deleted, delete it again to retry",
+			runnerID, len(cleanup.Failed))
+	}
+	return resp
+}
+
+// DeleteRunnerGroup removes every runner of a group. Runners that can't be deleted are
+// listed in the response; the RPC only fails if the group can't be listed.
+func (s *Server) DeleteRunnerGroup(ctx context.Context, req *gradv1.DeleteRunnerGroupRequest) (*gradv1.DeleteRunnerGroupResponse, error) {
 	// Validate request
-	if req.Limit < 0 || req.Offset < 0 {
-		return nil, status.Errorf(codes.InvalidArgument, "limit and offset must be non-negative")
+	if req.Group == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group is required")
 	}
 
+	// Call service layer
+	deletion, err := s.runnerService.DeleteRunnerGroup(ctx, req.Group)
+	if err != nil {
+		return nil, s.mapServiceError(err)
+	}
+
+	resp := &gradv1.DeleteRunnerGroupResponse{
+		DeletedRunnerIds: deletion.Deleted,
+	}
+	for _, failure := range deletion.Failed {
+		resp.Failed = append(resp.Failed, &gradv1.RunnerDeleteFailure{
+			RunnerId: failure.RunnerID,
+			Error:    failure.Err.Error(),
+		})
+	}
+	return resp, nil
+}
+
+// ListRunners returns all available runners
+func (s *Server) ListRunners(ctx context.Context, req *gradv1.ListRunnersRequest) (*gradv1.ListRunnersResponse, error) {
 	// Convert proto request to domain options
 	opts := service.FromProtoListOptions(req.Status, req.Limit, req.Offset)
+	opts.Filter = req.Filter
+	opts.Group = req.Group
+	opts.ImageDigest = req.ImageDigest
+	opts.Labels = req.Labels
+	opts.OrderBy = req.OrderBy
+	opts.Reverse = req.Reverse
+	opts.StaleOnly = req.StaleOnly
+	opts.Arch = req.Arch
+	opts.Name = req.Name
+
+	// Validate request
+	if err := service.ListOptionsViolations(opts).Err(); err != nil {
+		return nil, s.mapServiceError(err)
+	}
 
 	// Call service layer
 	runners, total, err := s.runnerService.ListRunners(ctx, opts)
@@ -96,103 +240,106 @@ func (s *Server) ListRunners(ctx context.Context, req *gradv1.ListRunnersRequest
 	}, nil
 }
 
-// ExecuteCommandStream executes a command in a specific runner with streaming output
-func (s *Server) ExecuteCommandStream(req *gradv1.ExecuteCommandRequest, stream gradv1.RunnerService_ExecuteCommandStreamServer) error {
+// UpdateRunner changes the editable fields of a runner
+func (s *Server) UpdateRunner(ctx context.Context, req *gradv1.UpdateRunnerRequest) (*gradv1.UpdateRunnerResponse, error) {
 	// Validate request
-	if err := s.validateExecuteCommandRequest(req); err != nil {
-		return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	if req.RunnerId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "runner_id is required")
 	}
 
-	// Convert proto request to domain request
-	domainReq := service.FromProtoExecuteCommandRequest(req)
+	// Call service layer
+	runner, err := s.runnerService.UpdateRunner(ctx, req.RunnerId, service.FromProtoUpdateRunnerRequest(req))
+	if err != nil {
+		return nil, s.mapServiceError(err)
+	}
 
-	// Create channels for streaming
-	// Note: stdoutCh and stderrCh will be closed by the sender (Kubernetes layer)
-	stdoutCh := make(chan []byte, 100)
-	stderrCh := make(chan []byte, 100)
+	return &gradv1.UpdateRunnerResponse{
+		Runner: runner.ToProto(),
+	}, nil
+}
 
-	// exitCh and errCh are owned by this gRPC layer
-	exitCh := make(chan int32, 1)
-	errCh := make(chan error, 1)
+// CloneRunner creates a new runner with the configuration of an existing one
+func (s *Server) CloneRunner(ctx context.Context, req *gradv1.CloneRunnerRequest) (*gradv1.CloneRunnerResponse, error) {
+	// Validate request
+	if req.SourceRunnerId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "source_runner_id is required")
+	}
 
-	// Start command execution in a goroutine
-	go func() {
-		// Only close channels that this goroutine owns/sends to
-		defer close(exitCh)
-		defer close(errCh)
-
-		exitCode, err := s.runnerService.ExecuteCommandStream(stream.Context(), domainReq, stdoutCh, stderrCh)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		exitCh <- exitCode
-	}()
+	// Call service layer
+	runner, err := s.runnerService.CloneRunner(ctx, req.SourceRunnerId, req.Name)
+	if err != nil {
+		return nil, s.mapServiceError(err)
+	}
 
-	// Stream the output
-	for {
-		select {
-		case data, ok := <-stdoutCh:
-			if !ok {
-				stdoutCh = nil
-				continue
-			}
-			if len(data) > 0 {
-				if err := stream.Send(&gradv1.ExecuteCommandStreamResponse{
-					Type: gradv1.StreamType_STREAM_TYPE_STDOUT,
-					Data: data,
-				}); err != nil {
-					return err
-				}
-			}
+	return &gradv1.CloneRunnerResponse{
+		Runner: runner.ToProto(),
+	}, nil
+}
 
-		case data, ok := <-stderrCh:
-			if !ok {
-				stderrCh = nil
-				continue
-			}
-			if len(data) > 0 {
-				if err := stream.Send(&gradv1.ExecuteCommandStreamResponse{
-					Type: gradv1.StreamType_STREAM_TYPE_STDERR,
-					Data: data,
-				}); err != nil {
-					return err
-				}
-			}
+// GetServerInfo returns the server's limits and their current usage
+func (s *Server) GetServerInfo(ctx context.Context, req *gradv1.GetServerInfoRequest) (*gradv1.GetServerInfoResponse, error) {
+	_, commandLimits := s.limits()
+	resp := &gradv1.GetServerInfoResponse{
+		StreamLimits: s.streams.ToProto(),
+		CommandLimits: &gradv1.CommandLimits{
+			MaxCommandBytes: int32(commandLimits.MaxBytes),
+			MaxArgs:         int32(commandLimits.MaxArgs),
+		},
+		DisabledFeatures: s.disabledFeatures.List(),
+	}
+	if s.info != nil {
+		status := s.info.Status(ctx)
+		resp.BuildInfo = status.BuildInfo.ToProto()
+		resp.UptimeSeconds = int64(status.Uptime.Seconds())
+		resp.KubernetesVersion = status.KubernetesVersion
+	}
+	return resp, nil
+}
 
-		case exitCode := <-exitCh:
-			// Send final exit message
-			return stream.Send(&gradv1.ExecuteCommandStreamResponse{
-				Type:     gradv1.StreamType_STREAM_TYPE_EXIT,
-				ExitCode: exitCode,
-			})
+// GetRunnerStats returns runner counts grouped by status, preset and owner
+func (s *Server) GetRunnerStats(ctx context.Context, req *gradv1.GetRunnerStatsRequest) (*gradv1.GetRunnerStatsResponse, error) {
+	opts := service.FromProtoListOptions(req.Status, 0, 0)
 
-		case err, ok := <-errCh:
-			if !ok {
-				// errCh was closed, no error to handle
-				continue
-			}
-			return s.mapServiceError(err)
+	stats, err := s.runnerService.GetRunnerStats(ctx, opts)
+	if err != nil {
+		return nil, s.mapServiceError(err)
+	}
 
-		case <-stream.Context().Done():
-			return stream.Context().Err()
-		}
+	return stats.ToProto(), nil
+}
 
-		// If both stdout and stderr channels are closed, wait for exit
-		if stdoutCh == nil && stderrCh == nil {
-			select {
-			case exitCode := <-exitCh:
-				return stream.Send(&gradv1.ExecuteCommandStreamResponse{
-					Type:     gradv1.StreamType_STREAM_TYPE_EXIT,
-					ExitCode: exitCode,
-				})
-			case err := <-errCh:
-				return s.mapServiceError(err)
-			case <-stream.Context().Done():
-				return stream.Context().Err()
-			}
-		}
+// ExecuteCommandStream executes a command in a specific runner with streaming output
+func (s *Server) ExecuteCommandStream(req *gradv1.ExecuteCommandRequest, stream gradv1.RunnerService_ExecuteCommandStreamServer) error {
+	// Convert proto request to domain request
+	domainReq := service.FromProtoExecuteCommandRequest(req)
+
+	// Validate request
+	if err := s.validateExecuteCommandRequest(domainReq, true); err != nil {
+		return s.mapServiceError(err)
 	}
+
+	limit := newOutputLimit(domainReq, s.maxOutputBytes)
+	return s.runCommand(stream.Context(), domainReq, req.Resumable, limit, stream.Send, func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+		domainReq.Started = started
+		return s.runnerService.ExecuteCommandStream(ctx, domainReq, stdoutCh, stderrCh)
+	})
+}
+
+// AttachExecution streams the output of a resumable command from after the last message the
+// client received
+func (s *Server) AttachExecution(req *gradv1.AttachExecutionRequest, stream gradv1.RunnerService_AttachExecutionServer) error {
+	if req.ExecId == "" {
+		return status.Errorf(codes.InvalidArgument, "exec_id is required")
+	}
+	if req.AfterSequence < 0 {
+		return status.Errorf(codes.InvalidArgument, "after_sequence must not be negative")
+	}
+
+	execution := s.executions.get(req.ExecId)
+	if execution == nil {
+		return status.Errorf(codes.NotFound, "execution %s not found: it ended, nobody reattached in time, or grad restarted", req.ExecId)
+	}
+	return execution.follow(stream.Context(), execution.attach(req.AfterSequence), stream.Send)
 }
 
 // GetRunner returns details about a specific runner
@@ -213,49 +360,165 @@ func (s *Server) GetRunner(ctx context.Context, req *gradv1.GetRunnerRequest) (*
 	}, nil
 }
 
-// validateCreateRunnerRequest validates the create runner request
-func (s *Server) validateCreateRunnerRequest(req *gradv1.CreateRunnerRequest) error {
-	// Name validation (optional but if provided, must be valid)
-	if req.Name != "" && len(req.Name) > 100 {
-		return errors.New("name must be less than 100 characters")
+// GetRunnerMetrics returns a runner's live CPU and memory usage from metrics-server
+func (s *Server) GetRunnerMetrics(ctx context.Context, req *gradv1.GetRunnerMetricsRequest) (*gradv1.GetRunnerMetricsResponse, error) {
+	if req.RunnerId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "runner_id is required")
 	}
 
-	// Note: Resource requirements are ignored - preset configuration (2c2g40g) is always used
+	usage, err := s.runnerService.GetRunnerUsage(ctx, req.RunnerId)
+	if err != nil {
+		return nil, s.mapServiceError(err)
+	}
 
-	return nil
+	return &gradv1.GetRunnerMetricsResponse{Usage: usage.ToProto()}, nil
 }
 
-// validateExecuteCommandRequest validates the execute command request
-func (s *Server) validateExecuteCommandRequest(req *gradv1.ExecuteCommandRequest) error {
+// ListRunnerMetrics returns the live usage of every runner metrics-server reports on
+func (s *Server) ListRunnerMetrics(ctx context.Context, req *gradv1.ListRunnerMetricsRequest) (*gradv1.ListRunnerMetricsResponse, error) {
+	usages, err := s.runnerService.ListRunnerUsage(ctx)
+	if err != nil {
+		return nil, s.mapServiceError(err)
+	}
+
+	resp := &gradv1.ListRunnerMetricsResponse{}
+	for _, usage := range usages {
+		resp.Usage = append(resp.Usage, usage.ToProto())
+	}
+	return resp, nil
+}
+
+// GetRunnerDiagnostics returns a runner's pod, events, container logs and recent executions
+func (s *Server) GetRunnerDiagnostics(ctx context.Context, req *gradv1.GetRunnerDiagnosticsRequest) (*gradv1.GetRunnerDiagnosticsResponse, error) {
 	if req.RunnerId == "" {
-		return errors.New("runner_id is required")
+		return nil, status.Errorf(codes.InvalidArgument, "runner_id is required")
+	}
+	if req.TailLines < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "tail_lines must not be negative")
 	}
 
-	if req.Command == "" {
-		return errors.New("command is required")
+	diagnostics, err := s.runnerService.GetRunnerDiagnostics(ctx, req.RunnerId, int64(req.TailLines))
+	if err != nil {
+		return nil, s.mapServiceError(err)
 	}
 
-	if req.Timeout < 0 {
-		return errors.New("timeout must be non-negative")
+	return diagnostics.ToProto(), nil
+}
+
+// AddSSHKey allows a public key to log in to a runner
+func (s *Server) AddSSHKey(ctx context.Context, req *gradv1.AddSSHKeyRequest) (*gradv1.AddSSHKeyResponse, error) {
+	var v service.Violations
+	if req.RunnerId == "" {
+		v.Add("runner_id", "is required")
+	}
+	if req.PublicKey == "" {
+		v.Add("public_key", "is required")
+	}
+	if err := v.Err(); err != nil {
+		return nil, s.mapServiceError(err)
 	}
 
-	// Set default timeout if not provided
-	if req.Timeout == 0 {
-		req.Timeout = 30 // 30 seconds default
+	key, added, err := s.runnerService.AddSSHKey(ctx, req.RunnerId, req.PublicKey)
+	if err != nil {
+		return nil, s.mapServiceError(err)
 	}
 
-	return nil
+	return &gradv1.AddSSHKeyResponse{Key: key.ToProto(), Added: added}, nil
+}
+
+// ListSSHKeys returns the keys added to a runner
+func (s *Server) ListSSHKeys(ctx context.Context, req *gradv1.ListSSHKeysRequest) (*gradv1.ListSSHKeysResponse, error) {
+	if req.RunnerId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "runner_id is required")
+	}
+
+	keys, err := s.runnerService.ListSSHKeys(ctx, req.RunnerId)
+	if err != nil {
+		return nil, s.mapServiceError(err)
+	}
+
+	resp := &gradv1.ListSSHKeysResponse{}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, key.ToProto())
+	}
+	return resp, nil
+}
+
+// RemoveSSHKey removes a key from a runner by its fingerprint
+func (s *Server) RemoveSSHKey(ctx context.Context, req *gradv1.RemoveSSHKeyRequest) (*gradv1.RemoveSSHKeyResponse, error) {
+	var v service.Violations
+	if req.RunnerId == "" {
+		v.Add("runner_id", "is required")
+	}
+	if req.Fingerprint == "" {
+		v.Add("fingerprint", "is required")
+	}
+	if err := v.Err(); err != nil {
+		return nil, s.mapServiceError(err)
+	}
+
+	removed, err := s.runnerService.RemoveSSHKey(ctx, req.RunnerId, req.Fingerprint)
+	if err != nil {
+		return nil, s.mapServiceError(err)
+	}
+
+	return &gradv1.RemoveSSHKeyResponse{Removed: removed}, nil
 }
 
-// validateExecuteServiceCommandRequest validates the execute command request for ExecuteService
-// This is similar to validateExecuteCommandRequest but doesn't require runner_id
-func (s *Server) validateExecuteServiceCommandRequest(req *gradv1.ExecuteCommandRequest) error {
-	if req.Command == "" {
-		return errors.New("command is required")
+// SnapshotWorkspace archives a runner's workspace to S3, streaming progress and finally the
+// uploaded archive
+func (s *Server) SnapshotWorkspace(req *gradv1.SnapshotWorkspaceRequest, stream gradv1.RunnerService_SnapshotWorkspaceServer) error {
+	var v service.Violations
+	if req.RunnerId == "" {
+		v.Add("runner_id", "is required")
+	}
+	if req.Destination == "" {
+		v.Add("destination", "is required")
 	}
+	if err := v.Err(); err != nil {
+		return s.mapServiceError(err)
+	}
+
+	// A client that went away cancels the context, which stops the upload
+	var sendErr error
+	result, err := s.runnerService.SnapshotWorkspace(stream.Context(), service.FromProtoSnapshotRequest(req), func(p service.SnapshotProgress) {
+		if sendErr == nil {
+			sendErr = stream.Send(p.ToProto())
+		}
+	})
+	if err != nil {
+		return s.mapServiceError(err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	return stream.Send(result.ToProto())
+}
+
+// validateCreateRunnerRequest checks every field of a create runner request, reporting all
+// problems at once
+func (s *Server) validateCreateRunnerRequest(req *service.CreateRunnerRequest) error {
+	// Sidecar resources are checked against the server maximums by the service layer
+	envLimits, commandLimits := s.limits()
+	v := service.CreateRunnerViolations(req, nil)
+	v.CheckEnv("env", req.Env, envLimits)
+	v.CheckCommand("bootstrap_command", req.BootstrapCommand, commandLimits)
+
+	// Note: Resource requirements are ignored - preset configuration (2c2g40g) is always used
+
+	return v.Err()
+}
 
-	if req.Timeout < 0 {
-		return errors.New("timeout must be non-negative")
+// validateExecuteCommandRequest checks every field of an execute command request, reporting
+// all problems at once. RunnerService and ExecuteService share these rules; only the former
+// requires runner_id.
+func (s *Server) validateExecuteCommandRequest(req *service.ExecuteCommandRequest, requireRunner bool) error {
+	envLimits, commandLimits := s.limits()
+	v := service.ExecuteCommandViolations(req, requireRunner)
+	v.CheckEnv("env", req.Env, envLimits)
+	v.CheckExecCommand(req, commandLimits)
+	if err := v.Err(); err != nil {
+		return err
 	}
 
 	// Set default timeout if not provided
@@ -268,52 +531,119 @@ func (s *Server) validateExecuteServiceCommandRequest(req *gradv1.ExecuteCommand
 
 // ExecuteCommand executes a command with automatic runner provisioning
 func (s *Server) ExecuteCommand(req *gradv1.ExecuteCommandRequest, stream gradv1.ExecuteService_ExecuteCommandServer) error {
+	// Convert proto request to domain request
+	domainReq := service.FromProtoExecuteCommandRequest(req)
+
 	// Validate request (without runner_id requirement)
-	if err := s.validateExecuteServiceCommandRequest(req); err != nil {
-		return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	if err := s.validateExecuteCommandRequest(domainReq, false); err != nil {
+		return s.mapServiceError(err)
 	}
 
-	// Convert proto request to domain request
-	domainReq := service.FromProtoExecuteCommandRequest(req)
+	limit := newOutputLimit(domainReq, s.maxOutputBytes)
+	return s.runCommand(stream.Context(), domainReq, req.Resumable, limit, stream.Send, func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+		domainReq.Started = started
+		return s.executeService.ExecuteCommand(ctx, domainReq, stdoutCh, stderrCh)
+	})
+}
+
+// runCommand streams a command's output to send with streamCommandOutput under a new exec ID,
+// which it sets on req. A resumable command runs as an execution clients can reattach to by
+// that ID, holding its stream slot while it runs, and the stream follows it like
+// AttachExecution does.
+func (s *Server) runCommand(ctx context.Context, req *service.ExecuteCommandRequest, resumable bool, limit *outputLimit, send func(*gradv1.ExecuteCommandStreamResponse) error, execute func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error)) error {
+	execID := newExecID()
+	req.ExecID = execID
+	if !resumable {
+		return s.streamCommandOutput(ctx, execID, limit, send, execute)
+	}
+	execution, client := s.executions.start(ctx, execID, func(ctx context.Context, send func(*gradv1.ExecuteCommandStreamResponse) error) error {
+		return s.streamCommandOutput(ctx, execID, limit, send, execute)
+	})
+	return execution.follow(ctx, client, send)
+}
+
+// execResult is the outcome of a command run by the service layer
+type execResult struct {
+	status service.ExitStatus
+	err    error
+}
+
+// streamCommandOutput runs execute and forwards its output within limit to send, after the
+// STARTED message and followed by the EXIT message. Service errors are returned as gRPC errors
+// and never produce an EXIT message. Under TruncatePolicyKill, the context passed to execute
+// is cancelled once the output passes the limit.
+func (s *Server) streamCommandOutput(ctx context.Context, execID string, limit *outputLimit, send func(*gradv1.ExecuteCommandStreamResponse) error, execute func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error)) (err error) {
+	release, err := s.streams.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// After the result arrives, keep going until the remaining output is sent
+	var result *execResult
+	startedAt := time.Now()
+	defer func() {
+		s.metrics.ExecStreamDuration.WithLabelValues(execOutcome(result, err, ctx.Err())).Observe(time.Since(startedAt).Seconds())
+	}()
 
-	// Create channels for streaming
-	// Note: stdoutCh and stderrCh will be closed by the sender (service layer)
+	// stdoutCh and stderrCh are closed by the sender once the command has run
 	stdoutCh := make(chan []byte, 100)
 	stderrCh := make(chan []byte, 100)
+	resultCh := make(chan execResult, 1)
 
-	// exitCh and errCh are owned by this gRPC layer
-	exitCh := make(chan int32, 1)
-	errCh := make(chan error, 1)
+	execCtx, cancelExec := context.WithCancel(ctx)
+	defer cancelExec()
 
-	// Start command execution in a goroutine
-	go func() {
-		// Only close channels that this goroutine owns/sends to
-		defer close(exitCh)
-		defer close(errCh)
-
-		exitCode, err := s.executeService.ExecuteCommand(stream.Context(), domainReq, stdoutCh, stderrCh)
-		if err != nil {
-			errCh <- err
-			return
+	// The service reports what the command resolved to before running it, and waits until
+	// the STARTED message is sent so none of the output can overtake it. One that doesn't
+	// report it still gets a STARTED message, with just the exec ID, ahead of the output.
+	startedCh := make(chan service.ExecStart)
+	started := func(start service.ExecStart) {
+		select {
+		case startedCh <- start:
+		case <-execCtx.Done():
+		}
+	}
+	sentStarted := false
+	sendStarted := func(start service.ExecStart) error {
+		if sentStarted {
+			return nil
 		}
-		exitCh <- exitCode
+		sentStarted = true
+		return send(startedMessage(execID, start))
+	}
+
+	sendChunk := func(streamType gradv1.StreamType, data []byte) error {
+		if err := sendStarted(service.ExecStart{}); err != nil {
+			return err
+		}
+		return s.sendLimitedOutput(send, limit, streamType, data, cancelExec)
+	}
+
+	go func() {
+		status, err := execute(execCtx, started, stdoutCh, stderrCh)
+		resultCh <- execResult{status: status, err: err}
 	}()
 
-	// Stream the output (same logic as ExecuteCommandStream)
-	for {
+	// A closed output channel is set to nil, which a select never picks, so while the command
+	// is idle the loop blocks on what is still open instead of waking up for nothing
+	for result == nil || stdoutCh != nil || stderrCh != nil {
+		if s.streamWakeup != nil {
+			s.streamWakeup()
+		}
 		select {
+		case start := <-startedCh:
+			if err := sendStarted(start); err != nil {
+				return err
+			}
+
 		case data, ok := <-stdoutCh:
 			if !ok {
 				stdoutCh = nil
 				continue
 			}
-			if len(data) > 0 {
-				if err := stream.Send(&gradv1.ExecuteCommandStreamResponse{
-					Type: gradv1.StreamType_STREAM_TYPE_STDOUT,
-					Data: data,
-				}); err != nil {
-					return err
-				}
+			if err := sendChunk(gradv1.StreamType_STREAM_TYPE_STDOUT, data); err != nil {
+				return err
 			}
 
 		case data, ok := <-stderrCh:
@@ -321,48 +651,94 @@ func (s *Server) ExecuteCommand(req *gradv1.ExecuteCommandRequest, stream gradv1
 				stderrCh = nil
 				continue
 			}
-			if len(data) > 0 {
-				if err := stream.Send(&gradv1.ExecuteCommandStreamResponse{
-					Type: gradv1.StreamType_STREAM_TYPE_STDERR,
-					Data: data,
-				}); err != nil {
-					return err
-				}
+			if err := sendChunk(gradv1.StreamType_STREAM_TYPE_STDERR, data); err != nil {
+				return err
 			}
 
-		case exitCode := <-exitCh:
-			// Send final exit message
-			return stream.Send(&gradv1.ExecuteCommandStreamResponse{
-				Type:     gradv1.StreamType_STREAM_TYPE_EXIT,
-				ExitCode: exitCode,
-			})
-
-		case err, ok := <-errCh:
-			if !ok {
-				// errCh was closed, no error to handle
-				continue
+		case r := <-resultCh:
+			// Failures before the command started may leave the output channels open
+			if r.err != nil {
+				return s.mapServiceError(r.err)
 			}
-			return s.mapServiceError(err)
+			result = &r
 
-		case <-stream.Context().Done():
-			return stream.Context().Err()
+		case <-ctx.Done():
+			return ctx.Err()
 		}
+	}
 
-		// If both stdout and stderr channels are closed, wait for exit
-		if stdoutCh == nil && stderrCh == nil {
-			select {
-			case exitCode := <-exitCh:
-				return stream.Send(&gradv1.ExecuteCommandStreamResponse{
-					Type:     gradv1.StreamType_STREAM_TYPE_EXIT,
-					ExitCode: exitCode,
-				})
-			case err := <-errCh:
-				return s.mapServiceError(err)
-			case <-stream.Context().Done():
-				return stream.Context().Err()
-			}
-		}
+	if err := sendStarted(service.ExecStart{}); err != nil {
+		return err
+	}
+	result.status = limit.exitStatus(result.status)
+	exit := result.status.ToProto()
+	limit.annotate(exit)
+	return send(exit)
+}
+
+// startedMessage returns the STARTED message of command execID, which resolved to start (pure function)
+func startedMessage(execID string, start service.ExecStart) *gradv1.ExecuteCommandStreamResponse {
+	return &gradv1.ExecuteCommandStreamResponse{
+		Type:       gradv1.StreamType_STREAM_TYPE_STARTED,
+		ExecId:     execID,
+		RunnerId:   start.RunnerID,
+		Shell:      start.Shell,
+		WorkingDir: start.WorkingDir,
+
+		CorrelationEnv: start.CorrelationEnv,
+	}
+}
+
+// sendLimitedOutput sends the part of a chunk of command output within limit. The first time
+// the limit is passed it counts the truncation and, under TruncatePolicyKill, calls cancel to
+// terminate the command.
+func (s *Server) sendLimitedOutput(send func(*gradv1.ExecuteCommandStreamResponse) error, limit *outputLimit, streamType gradv1.StreamType, data []byte, cancel context.CancelFunc) error {
+	wasTruncated := limit.truncated
+	if err := s.sendOutput(send, streamType, limit.accept(streamType, data)); err != nil {
+		return err
+	}
+	if !limit.truncated || wasTruncated {
+		return nil
+	}
+
+	slog.Warn("Command output passed the limit", "max_output_bytes", limit.maxBytes, "truncate_policy", limit.policy)
+	s.metrics.ExecOutputTruncatedTotal.WithLabelValues(string(limit.policy)).Inc()
+	if limit.policy == service.TruncatePolicyKill {
+		cancel()
+	}
+	return nil
+}
+
+// sendOutput sends a chunk of command output, skipping empty chunks
+func (s *Server) sendOutput(send func(*gradv1.ExecuteCommandStreamResponse) error, streamType gradv1.StreamType, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := send(&gradv1.ExecuteCommandStreamResponse{
+		Type: streamType,
+		Data: data,
+	}); err != nil {
+		return err
+	}
+	s.metrics.ExecStreamBytesTotal.WithLabelValues(streamLabel(streamType)).Add(float64(len(data)))
+	return nil
+}
+
+// invalidRequestStatus returns an InvalidArgument status with a BadRequest detail listing
+// every field violation, so clients can show them one by one
+func invalidRequestStatus(err *service.ValidationError) error {
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range err.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Description: violation.Description,
+		})
+	}
+	st := status.New(codes.InvalidArgument, err.Error())
+	if detailed, detailErr := st.WithDetails(badRequest); detailErr == nil {
+		return detailed.Err()
 	}
+	return st.Err()
 }
 
 // mapServiceError maps domain errors to gRPC status errors
@@ -370,15 +746,31 @@ func (s *Server) mapServiceError(err error) error {
 	if err == nil {
 		return nil
 	}
+	var validationErr *service.ValidationError
 	switch {
 	case errors.Is(err, service.ErrRunnerNotFound):
 		return status.Errorf(codes.NotFound, "runner not found")
 	case errors.Is(err, service.ErrRunnerNotRunning):
 		return status.Errorf(codes.FailedPrecondition, "runner is not running")
+	case errors.As(err, &validationErr):
+		return invalidRequestStatus(validationErr)
 	case errors.Is(err, service.ErrInvalidRequest):
-		return status.Errorf(codes.InvalidArgument, "invalid request")
+		return status.Errorf(codes.InvalidArgument, "%v", err)
 	case errors.Is(err, service.ErrResourceConflict):
 		return status.Errorf(codes.AlreadyExists, "resource conflict")
+	case errors.Is(err, service.ErrFailedPrecondition):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	case errors.Is(err, service.ErrMissingCredentials):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	case errors.Is(err, service.ErrInsufficientDiskSpace):
+		return status.Errorf(codes.ResourceExhausted, "%v", err)
+	case errors.Is(err, service.ErrMetricsUnavailable):
+		return status.Errorf(codes.Unimplemented, "%v", err)
+	case errors.Is(err, service.ErrKubernetesAPI) && errors.Is(err, context.DeadlineExceeded):
+		slog.Error("Kubernetes API call timed out", "error", err)
+		return status.Errorf(codes.DeadlineExceeded, "kubernetes API timed out: %v", err)
+	case errors.Is(err, service.ErrKubernetesAPI) && errors.Is(err, context.Canceled):
+		return status.Errorf(codes.Canceled, "%v", err)
 	case errors.Is(err, service.ErrKubernetesAPI):
 		slog.Error("Kubernetes API error", "error", err)
 		return status.Errorf(codes.Internal, "kubernetes API error: %v", err)
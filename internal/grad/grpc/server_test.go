@@ -0,0 +1,777 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// collectStream runs streamCommandOutput with execute and returns the messages sent
+func collectStream(t *testing.T, execute func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error)) ([]*gradv1.ExecuteCommandStreamResponse, error) {
+	t.Helper()
+
+	var sent []*gradv1.ExecuteCommandStreamResponse
+	send := func(resp *gradv1.ExecuteCommandStreamResponse) error {
+		sent = append(sent, resp)
+		return nil
+	}
+
+	s := NewServer(nil, nil, nil, nil, nil, nil, nil)
+	err := s.streamCommandOutput(context.Background(), "exec-test", &outputLimit{}, send, execute)
+	return sent, err
+}
+
+func TestStreamCommandOutputExitStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       service.ExitStatus
+		expectCode   int32
+		expectReason gradv1.ExitReason
+		expectSignal string
+	}{
+		{
+			name:         "Exit code passed through",
+			status:       service.ExitStatus{Code: 1, Reason: service.ExitReasonExited},
+			expectCode:   1,
+			expectReason: gradv1.ExitReason_EXIT_REASON_EXITED,
+		},
+		{
+			name:         "Killed by signal",
+			status:       service.ExitStatus{Code: 137, Reason: service.ExitReasonSignaled, Signal: "SIGKILL"},
+			expectCode:   137,
+			expectReason: gradv1.ExitReason_EXIT_REASON_SIGNALED,
+			expectSignal: "SIGKILL",
+		},
+		{
+			name:         "Timed out",
+			status:       service.ExitStatus{Code: service.ExitCodeTimeout, Reason: service.ExitReasonTimeout},
+			expectCode:   124,
+			expectReason: gradv1.ExitReason_EXIT_REASON_TIMEOUT,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent, err := collectStream(t, func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+				started(service.ExecStart{RunnerID: "runner-1", Shell: "bash"})
+				stdoutCh <- []byte("out\n")
+				stderrCh <- []byte("err\n")
+				close(stdoutCh)
+				close(stderrCh)
+				return tt.status, nil
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(sent) != 4 {
+				t.Fatalf("Expected started, stdout, stderr and exit messages, got %d messages", len(sent))
+			}
+			exit := sent[len(sent)-1]
+			if exit.Type != gradv1.StreamType_STREAM_TYPE_EXIT {
+				t.Fatalf("Expected last message to be EXIT, got %s", exit.Type)
+			}
+			if exit.ExitCode != tt.expectCode || exit.FailureReason != tt.expectReason || exit.Signal != tt.expectSignal {
+				t.Errorf("Expected exit (%d, %s, %q), got (%d, %s, %q)", tt.expectCode, tt.expectReason, tt.expectSignal, exit.ExitCode, exit.FailureReason, exit.Signal)
+			}
+		})
+	}
+}
+
+func TestStreamCommandOutputInfrastructureError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		expectCode codes.Code
+	}{
+		{"Runner not found", service.ErrRunnerNotFound, codes.NotFound},
+		{"Runner not running", service.ErrRunnerNotRunning, codes.FailedPrecondition},
+		{"Exec failure", fmt.Errorf("%w: failed to create executor", service.ErrCommandExecution), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Failures before the command runs leave the output channels open
+			sent, err := collectStream(t, func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+				return service.ExitStatus{}, tt.err
+			})
+
+			if status.Code(err) != tt.expectCode {
+				t.Errorf("Expected gRPC code %s, got %v", tt.expectCode, err)
+			}
+			if len(sent) != 0 {
+				t.Errorf("Expected no messages for a command that never started, got %v", sent)
+			}
+		})
+	}
+}
+
+func TestStreamCommandOutputSendsAllOutputBeforeExit(t *testing.T) {
+	sent, err := collectStream(t, func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+		for i := 0; i < 50; i++ {
+			stdoutCh <- []byte(fmt.Sprintf("line %d\n", i))
+		}
+		close(stdoutCh)
+		close(stderrCh)
+		return service.ExitStatus{Reason: service.ExitReasonExited}, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(sent) != 52 {
+		t.Fatalf("Expected a started message, 50 output messages and an exit, got %d messages", len(sent))
+	}
+	if sent[51].Type != gradv1.StreamType_STREAM_TYPE_EXIT {
+		t.Errorf("Expected EXIT last, got %s", sent[51].Type)
+	}
+}
+
+func TestStreamCommandOutputIdleStream(t *testing.T) {
+	var sent []*gradv1.ExecuteCommandStreamResponse
+	send := func(resp *gradv1.ExecuteCommandStreamResponse) error {
+		sent = append(sent, resp)
+		return nil
+	}
+	wakeups := 0
+	s := NewServer(nil, nil, nil, nil, nil, nil, nil)
+	s.streamWakeup = func() { wakeups++ }
+
+	// stdout closes right away while stderr stays open without output, as with a command
+	// that closed its stdout and then waits
+	err := s.streamCommandOutput(context.Background(), "exec-test", &outputLimit{}, send, func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+		started(service.ExecStart{RunnerID: "runner-42"})
+		close(stdoutCh)
+		time.Sleep(100 * time.Millisecond)
+		close(stderrCh)
+		return service.ExitStatus{Reason: service.ExitReasonExited}, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sent) != 2 || sent[1].Type != gradv1.StreamType_STREAM_TYPE_EXIT {
+		t.Fatalf("Expected STARTED and EXIT, got %v", sent)
+	}
+	// One pass to start, then one for each of STARTED, the two closed channels and the result
+	if wakeups > 5 {
+		t.Errorf("Expected the loop to block while the stream is idle, it woke up %d times", wakeups)
+	}
+}
+
+func TestStreamCommandOutputSendsStartedFirst(t *testing.T) {
+	tests := []struct {
+		name          string
+		execute       func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error)
+		expectStarted service.ExecStart
+		expectTypes   []gradv1.StreamType
+	}{
+		{
+			name: "Reported before output",
+			execute: func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+				started(service.ExecStart{RunnerID: "runner-42", Shell: "bash", WorkingDir: "/workspace", CorrelationEnv: map[string]string{"GRAD_RUNNER_ID": "runner-42", "GRAD_EXEC_ID": "exec-test"}})
+				// Output of the two streams may be forwarded in either order, so only one is used
+				stderrCh <- []byte("err\n")
+				close(stdoutCh)
+				close(stderrCh)
+				return service.ExitStatus{Reason: service.ExitReasonExited}, nil
+			},
+			expectStarted: service.ExecStart{RunnerID: "runner-42", Shell: "bash", WorkingDir: "/workspace", CorrelationEnv: map[string]string{"GRAD_RUNNER_ID": "runner-42", "GRAD_EXEC_ID": "exec-test"}},
+			expectTypes:   []gradv1.StreamType{gradv1.StreamType_STREAM_TYPE_STARTED, gradv1.StreamType_STREAM_TYPE_STDERR, gradv1.StreamType_STREAM_TYPE_EXIT},
+		},
+		{
+			name: "Without output",
+			execute: func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+				started(service.ExecStart{RunnerID: "runner-42", Shell: service.ExecShellNone})
+				close(stdoutCh)
+				close(stderrCh)
+				return service.ExitStatus{Reason: service.ExitReasonExited}, nil
+			},
+			expectStarted: service.ExecStart{RunnerID: "runner-42", Shell: service.ExecShellNone},
+			expectTypes:   []gradv1.StreamType{gradv1.StreamType_STREAM_TYPE_STARTED, gradv1.StreamType_STREAM_TYPE_EXIT},
+		},
+		{
+			name: "Not reported",
+			execute: func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+				stdoutCh <- []byte("out\n")
+				close(stdoutCh)
+				close(stderrCh)
+				return service.ExitStatus{Reason: service.ExitReasonExited}, nil
+			},
+			expectTypes: []gradv1.StreamType{gradv1.StreamType_STREAM_TYPE_STARTED, gradv1.StreamType_STREAM_TYPE_STDOUT, gradv1.StreamType_STREAM_TYPE_EXIT},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent, err := collectStream(t, tt.execute)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var types []gradv1.StreamType
+			for _, resp := range sent {
+				types = append(types, resp.Type)
+			}
+			if !slices.Equal(types, tt.expectTypes) {
+				t.Fatalf("Expected messages %v, got %v", tt.expectTypes, types)
+			}
+			started := sent[0]
+			if started.ExecId != "exec-test" {
+				t.Errorf("Expected exec ID 'exec-test', got '%s'", started.ExecId)
+			}
+			got := service.ExecStart{RunnerID: started.RunnerId, Shell: started.Shell, WorkingDir: started.WorkingDir, CorrelationEnv: started.CorrelationEnv}
+			if !reflect.DeepEqual(got, tt.expectStarted) {
+				t.Errorf("Expected started %+v, got %+v", tt.expectStarted, got)
+			}
+		})
+	}
+}
+
+func TestStreamCommandOutputSendsPromptBeforeExit(t *testing.T) {
+	received := make(chan string, 1)
+	send := func(resp *gradv1.ExecuteCommandStreamResponse) error {
+		if resp.Type == gradv1.StreamType_STREAM_TYPE_STDOUT {
+			received <- string(resp.Data)
+		}
+		return nil
+	}
+
+	// The command waits for its prompt to reach the client before it exits
+	s := NewServer(nil, nil, nil, nil, nil, nil, nil)
+	err := s.streamCommandOutput(context.Background(), "exec-test", &outputLimit{}, send, func(ctx context.Context, started func(service.ExecStart), stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+		defer close(stdoutCh)
+		defer close(stderrCh)
+
+		stdoutCh <- []byte("Continue? [y/N]: ")
+		select {
+		case prompt := <-received:
+			if prompt != "Continue? [y/N]: " {
+				t.Errorf("Expected the prompt, got %q", prompt)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Expected the prompt to reach the client while the command runs")
+		}
+		return service.ExitStatus{Reason: service.ExitReasonExited}, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestGetServerInfoBuildInfo(t *testing.T) {
+	build := service.BuildInfo{Version: "v1.2.0", Commit: "3f1c0a9e", BuildDate: "2026-10-01T12:00:00Z", GoVersion: "go1.24.5"}
+	fetch := func(ctx context.Context) (string, error) { return "v1.30.2", nil }
+	s := NewServer(nil, nil, nil, nil, nil, service.NewServerInfo(build, fetch, time.Minute), nil)
+
+	resp, err := s.GetServerInfo(context.Background(), &gradv1.GetServerInfoRequest{})
+	if err != nil {
+		t.Fatalf("GetServerInfo failed: %v", err)
+	}
+	if b := resp.BuildInfo; b.GetVersion() != "v1.2.0" || b.GetCommit() != "3f1c0a9e" || b.GetBuildDate() != "2026-10-01T12:00:00Z" || b.GetGoVersion() != "go1.24.5" {
+		t.Errorf("Expected the build info, got %+v", b)
+	}
+	if resp.KubernetesVersion != "v1.30.2" {
+		t.Errorf("Expected Kubernetes v1.30.2, got %q", resp.KubernetesVersion)
+	}
+
+	// Without server info only the stream limits are reported
+	resp, err = NewServer(nil, nil, nil, nil, nil, nil, nil).GetServerInfo(context.Background(), &gradv1.GetServerInfoRequest{})
+	if err != nil || resp.BuildInfo != nil || resp.StreamLimits == nil {
+		t.Errorf("Expected only stream limits, got %+v (%v)", resp, err)
+	}
+}
+
+func TestGetServerInfoDisabledFeatures(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, nil, nil, nil)
+	resp, err := s.GetServerInfo(context.Background(), &gradv1.GetServerInfoRequest{})
+	if err != nil || resp.DisabledFeatures != nil {
+		t.Errorf("Expected no disabled features, got %v (%v)", resp.GetDisabledFeatures(), err)
+	}
+
+	s.SetDisabledFeatures(service.DisabledFeatures{service.FeatureTracing: true, service.FeatureS3Workspace: true})
+	resp, err = s.GetServerInfo(context.Background(), &gradv1.GetServerInfoRequest{})
+	if err != nil {
+		t.Fatalf("GetServerInfo failed: %v", err)
+	}
+	if expected := []string{"s3-workspace", "tracing"}; !slices.Equal(resp.DisabledFeatures, expected) {
+		t.Errorf("Expected %v disabled, got %v", expected, resp.DisabledFeatures)
+	}
+}
+
+// deletingRunnerService deletes runners with cleanup and reports remaining as still terminating
+type deletingRunnerService struct {
+	service.RunnerService
+	cleanup   *service.RunnerCleanup
+	remaining []service.RunnerResource
+	waited    []time.Duration
+}
+
+func (d *deletingRunnerService) DeleteRunner(ctx context.Context, runnerID string, overrideProtection bool) (*service.RunnerCleanup, error) {
+	if len(d.cleanup.Failed) > 0 {
+		return d.cleanup, service.ErrCleanupIncomplete
+	}
+	return d.cleanup, nil
+}
+
+func (d *deletingRunnerService) WaitForRunnerDeleted(ctx context.Context, runnerID string, timeout time.Duration) ([]service.RunnerResource, error) {
+	d.waited = append(d.waited, timeout)
+	return d.remaining, nil
+}
+
+func TestDeleteRunnerWait(t *testing.T) {
+	pod := service.RunnerResource{Kind: "Pod", Name: "grad-runner-1"}
+	claim := service.RunnerResource{Kind: "PersistentVolumeClaim", Name: "workspace"}
+
+	tests := []struct {
+		name            string
+		req             *gradv1.DeleteRunnerRequest
+		cleanup         *service.RunnerCleanup
+		remaining       []service.RunnerResource
+		expectWaited    []time.Duration
+		expectCompleted bool
+		expectRemaining []string
+		expectMessage   string
+	}{
+		{
+			name:          "Without wait",
+			req:           &gradv1.DeleteRunnerRequest{RunnerId: "runner-1"},
+			cleanup:       &service.RunnerCleanup{Deleted: []service.RunnerResource{pod}},
+			remaining:     []service.RunnerResource{pod},
+			expectMessage: "runner runner-1 deletion initiated",
+		},
+		{
+			name:            "Gone",
+			req:             &gradv1.DeleteRunnerRequest{RunnerId: "runner-1", Wait: true, WaitTimeoutSeconds: 30},
+			cleanup:         &service.RunnerCleanup{Deleted: []service.RunnerResource{pod, claim}},
+			expectWaited:    []time.Duration{30 * time.Second},
+			expectCompleted: true,
+			expectMessage:   "runner runner-1 deleted",
+		},
+		{
+			name:            "Still terminating",
+			req:             &gradv1.DeleteRunnerRequest{RunnerId: "runner-1", Wait: true},
+			cleanup:         &service.RunnerCleanup{Deleted: []service.RunnerResource{pod, claim}},
+			remaining:       []service.RunnerResource{claim},
+			expectWaited:    []time.Duration{0},
+			expectRemaining: []string{"persistentvolumeclaim/workspace"},
+			expectMessage:   "runner runner-1 deletion still in progress: 1 object(s) still terminating",
+		},
+		{
+			name: "Cleanup failed",
+			req:  &gradv1.DeleteRunnerRequest{RunnerId: "runner-1", Wait: true},
+			cleanup: &service.RunnerCleanup{Failed: []service.RunnerResourceFailure{
+				{Resource: claim, Err: fmt.Errorf("admission webhook denied the request")},
+			}},
+			expectMessage: "runner runner-1 deletion incomplete: 1 object(s) could not be deleted, delete it again to retry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runners := &deletingRunnerService{cleanup: tt.cleanup, remaining: tt.remaining}
+			s := NewServer(runners, nil, nil, nil, nil, nil, nil)
+
+			resp, err := s.DeleteRunner(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("DeleteRunner failed: %v", err)
+			}
+
+			if fmt.Sprint(runners.waited) != fmt.Sprint(tt.expectWaited) {
+				t.Errorf("Expected waits %v, got %v", tt.expectWaited, runners.waited)
+			}
+			if resp.Completed != tt.expectCompleted {
+				t.Errorf("Expected completed %t, got %t", tt.expectCompleted, resp.Completed)
+			}
+			if fmt.Sprint(resp.RemainingResources) != fmt.Sprint(tt.expectRemaining) {
+				t.Errorf("Expected remaining %v, got %v", tt.expectRemaining, resp.RemainingResources)
+			}
+			if resp.Message != tt.expectMessage {
+				t.Errorf("Expected message %q, got %q", tt.expectMessage, resp.Message)
+			}
+		})
+	}
+
+	s := NewServer(&deletingRunnerService{}, nil, nil, nil, nil, nil, nil)
+	_, err := s.DeleteRunner(context.Background(), &gradv1.DeleteRunnerRequest{RunnerId: "runner-1", Wait: true, WaitTimeoutSeconds: -1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a negative timeout, got %v", err)
+	}
+}
+
+// usageRunnerService reports usage or fails with err
+type usageRunnerService struct {
+	service.RunnerService
+	err error
+}
+
+func (u *usageRunnerService) GetRunnerUsage(ctx context.Context, runnerID string) (*service.RunnerUsage, error) {
+	if u.err != nil {
+		return nil, u.err
+	}
+	return &service.RunnerUsage{RunnerID: runnerID, Containers: []service.ContainerUsage{{Name: "runner", CPUMillicores: 250, MemoryBytes: 1 << 30}}}, nil
+}
+
+func (u *usageRunnerService) ListRunnerUsage(ctx context.Context) ([]*service.RunnerUsage, error) {
+	if u.err != nil {
+		return nil, u.err
+	}
+	return []*service.RunnerUsage{{RunnerID: "runner-2"}, {RunnerID: "runner-1"}}, nil
+}
+
+func TestGetRunnerMetrics(t *testing.T) {
+	s := NewServer(&usageRunnerService{}, nil, nil, nil, nil, nil, nil)
+
+	resp, err := s.GetRunnerMetrics(context.Background(), &gradv1.GetRunnerMetricsRequest{RunnerId: "runner-1"})
+	if err != nil {
+		t.Fatalf("GetRunnerMetrics failed: %v", err)
+	}
+	if resp.Usage.RunnerId != "runner-1" || resp.Usage.CpuMillicores != 250 || resp.Usage.MemoryBytes != 1<<30 {
+		t.Errorf("Expected runner-1 at 250m and 1Gi, got %+v", resp.Usage)
+	}
+
+	list, err := s.ListRunnerMetrics(context.Background(), &gradv1.ListRunnerMetricsRequest{})
+	if err != nil {
+		t.Fatalf("ListRunnerMetrics failed: %v", err)
+	}
+	if len(list.Usage) != 2 || list.Usage[0].RunnerId != "runner-2" {
+		t.Errorf("Expected the service's order to be kept, got %v", list.Usage)
+	}
+
+	if _, err := s.GetRunnerMetrics(context.Background(), &gradv1.GetRunnerMetricsRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a runner ID, got %v", err)
+	}
+
+	// Without metrics-server the hint reaches the client
+	s = NewServer(&usageRunnerService{err: fmt.Errorf("%w: install metrics-server", service.ErrMetricsUnavailable)}, nil, nil, nil, nil, nil, nil)
+	_, err = s.ListRunnerMetrics(context.Background(), &gradv1.ListRunnerMetricsRequest{})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented without metrics-server, got %v", err)
+	}
+	if !strings.Contains(status.Convert(err).Message(), "install metrics-server") {
+		t.Errorf("Expected the hint in the message, got %q", status.Convert(err).Message())
+	}
+}
+
+// diagnosticsRunnerService records the tail lines asked for
+type diagnosticsRunnerService struct {
+	service.RunnerService
+	tailLines int64
+}
+
+func (d *diagnosticsRunnerService) GetRunnerDiagnostics(ctx context.Context, runnerID string, tailLines int64) (*service.RunnerDiagnostics, error) {
+	if runnerID != "runner-1" {
+		return nil, service.ErrRunnerNotFound
+	}
+	d.tailLines = tailLines
+	return &service.RunnerDiagnostics{PodJSON: []byte(`{"kind":"Pod"}`), Logs: []service.ContainerLog{{Container: "runner", Log: "ready\n"}}}, nil
+}
+
+func TestGetRunnerDiagnostics(t *testing.T) {
+	svc := &diagnosticsRunnerService{}
+	s := NewServer(svc, nil, nil, nil, nil, nil, nil)
+
+	resp, err := s.GetRunnerDiagnostics(context.Background(), &gradv1.GetRunnerDiagnosticsRequest{RunnerId: "runner-1", TailLines: 50})
+	if err != nil {
+		t.Fatalf("GetRunnerDiagnostics failed: %v", err)
+	}
+	if svc.tailLines != 50 {
+		t.Errorf("Expected 50 tail lines to be passed on, got %d", svc.tailLines)
+	}
+	if resp.PodJson != `{"kind":"Pod"}` || len(resp.Logs) != 1 || resp.Logs[0].Log != "ready\n" {
+		t.Errorf("Expected the pod and runner log, got %v", resp)
+	}
+
+	tests := []struct {
+		req      *gradv1.GetRunnerDiagnosticsRequest
+		expected codes.Code
+	}{
+		{&gradv1.GetRunnerDiagnosticsRequest{}, codes.InvalidArgument},
+		{&gradv1.GetRunnerDiagnosticsRequest{RunnerId: "runner-1", TailLines: -1}, codes.InvalidArgument},
+		{&gradv1.GetRunnerDiagnosticsRequest{RunnerId: "missing"}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		if _, err := s.GetRunnerDiagnostics(context.Background(), tt.req); status.Code(err) != tt.expected {
+			t.Errorf("Expected %s for %v, got %v", tt.expected, tt.req, err)
+		}
+	}
+}
+
+// snapshotRunnerService reports two steps of progress for snapshots, or fails them with err
+type snapshotRunnerService struct {
+	service.RunnerService
+	err error
+	req *service.SnapshotRequest
+}
+
+func (s *snapshotRunnerService) SnapshotWorkspace(ctx context.Context, req *service.SnapshotRequest, progress func(service.SnapshotProgress)) (*service.SnapshotProgress, error) {
+	s.req = req
+	progress(service.SnapshotProgress{Phase: service.SnapshotPhaseArchiving, BytesDone: 4096, BytesTotal: 4096})
+	if s.err != nil {
+		return nil, s.err
+	}
+	progress(service.SnapshotProgress{Phase: service.SnapshotPhaseUploading, BytesDone: 1024, BytesTotal: 2048})
+	return &service.SnapshotProgress{Phase: service.SnapshotPhaseDone, Bucket: "archive", Key: "run.tar.gz", SizeBytes: 2048}, nil
+}
+
+// recvSnapshot reads a snapshot stream to its end, returning what it got
+func recvSnapshot(stream gradv1.RunnerService_SnapshotWorkspaceClient) ([]*gradv1.SnapshotWorkspaceResponse, error) {
+	var responses []*gradv1.SnapshotWorkspaceResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return responses, nil
+		}
+		if err != nil {
+			return responses, err
+		}
+		responses = append(responses, resp)
+	}
+}
+
+func TestSnapshotWorkspace(t *testing.T) {
+	svc := &snapshotRunnerService{}
+	client := startStreamServer(t, svc, &service.StreamLimits{MaxConcurrentStreams: 10})
+
+	stream, err := client.SnapshotWorkspace(context.Background(), &gradv1.SnapshotWorkspaceRequest{
+		RunnerId:    "runner-1",
+		Destination: "s3://archive/run.tar.gz",
+		Credentials: &gradv1.S3Credentials{AccessKeyId: "AKIA", SecretAccessKey: "s3cr3t"},
+	})
+	if err != nil {
+		t.Fatalf("SnapshotWorkspace failed: %v", err)
+	}
+	responses, err := recvSnapshot(stream)
+	if err != nil {
+		t.Fatalf("Expected the snapshot to succeed, got %v", err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("Expected two progress reports and the result, got %v", responses)
+	}
+	if responses[0].Phase != gradv1.SnapshotPhase_SNAPSHOT_PHASE_ARCHIVING || responses[1].Phase != gradv1.SnapshotPhase_SNAPSHOT_PHASE_UPLOADING || responses[1].BytesDone != 1024 {
+		t.Errorf("Expected archiving then uploading progress, got %v", responses[:2])
+	}
+	if done := responses[2]; done.Phase != gradv1.SnapshotPhase_SNAPSHOT_PHASE_DONE || done.Bucket != "archive" || done.Key != "run.tar.gz" || done.SizeBytes != 2048 {
+		t.Errorf("Expected the uploaded archive last, got %v", done)
+	}
+	if creds := svc.req.Credentials; creds == nil || creds.AccessKeyID != "AKIA" || creds.SecretAccessKey != "s3cr3t" {
+		t.Errorf("Expected the credentials to be passed on, got %+v", svc.req.Credentials)
+	}
+
+	tests := []struct {
+		name     string
+		req      *gradv1.SnapshotWorkspaceRequest
+		err      error
+		expected codes.Code
+	}{
+		{"No destination", &gradv1.SnapshotWorkspaceRequest{RunnerId: "runner-1"}, nil, codes.InvalidArgument},
+		{"Missing credentials", &gradv1.SnapshotWorkspaceRequest{RunnerId: "runner-1", Destination: "s3://archive/"}, fmt.Errorf("%w: no AWS credentials found", service.ErrMissingCredentials), codes.FailedPrecondition},
+		{"Insufficient disk space", &gradv1.SnapshotWorkspaceRequest{RunnerId: "runner-1", Destination: "s3://archive/"}, fmt.Errorf("%w: needs 12Gi", service.ErrInsufficientDiskSpace), codes.ResourceExhausted},
+		{"Runner not running", &gradv1.SnapshotWorkspaceRequest{RunnerId: "runner-1", Destination: "s3://archive/"}, service.ErrRunnerNotRunning, codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc.err = tt.err
+			stream, err := client.SnapshotWorkspace(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("SnapshotWorkspace failed: %v", err)
+			}
+			if _, err := recvSnapshot(stream); status.Code(err) != tt.expected {
+				t.Errorf("Expected %s, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestMapServiceErrorKubernetesContext(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		err      error
+		expected codes.Code
+	}{
+		{fmt.Errorf("%w: %w", service.ErrKubernetesAPI, context.DeadlineExceeded), codes.DeadlineExceeded},
+		{fmt.Errorf("%w: %w", service.ErrKubernetesAPI, context.Canceled), codes.Canceled},
+		{fmt.Errorf("%w: forbidden", service.ErrKubernetesAPI), codes.Internal},
+	}
+	for _, tt := range tests {
+		if code := status.Code(s.mapServiceError(tt.err)); code != tt.expected {
+			t.Errorf("Expected %s for %v, got %s", tt.expected, tt.err, code)
+		}
+	}
+}
+
+// fieldViolations returns the BadRequest field violations of err by field
+func fieldViolations(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+	violations := map[string]string{}
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				violations[violation.Field] = violation.Description
+			}
+		}
+	}
+	return violations
+}
+
+func TestValidationReportsEveryViolation(t *testing.T) {
+	s := NewServer(nil, nil, &service.EnvLimits{MaxValueBytes: 4, MaxTotalBytes: 64, MaxEntries: 4}, nil, nil, nil, nil)
+
+	_, err := s.CreateRunner(context.Background(), &gradv1.CreateRunnerRequest{
+		Name:           strings.Repeat("x", 101),
+		DefaultWorkdir: "relative/dir",
+		Env:            map[string]string{"RUNNER_ID": "runner-1"},
+	})
+	violations := fieldViolations(t, err)
+	for _, field := range []string{"name", "default_workdir", "env"} {
+		if violations[field] == "" {
+			t.Errorf("Expected a violation of %s, got %v", field, violations)
+		}
+	}
+
+	// ExecuteService shares the exec rules, except runner_id
+	for name, execute := range map[string]func(*gradv1.ExecuteCommandRequest) error{
+		"RunnerService":  func(req *gradv1.ExecuteCommandRequest) error { return s.ExecuteCommandStream(req, nil) },
+		"ExecuteService": func(req *gradv1.ExecuteCommandRequest) error { return s.ExecuteCommand(req, nil) },
+	} {
+		violations := fieldViolations(t, execute(&gradv1.ExecuteCommandRequest{
+			RunnerId: "runner-1",
+			Timeout:  -1,
+			Shell:    "bash -l",
+			Env:      map[string]string{"BIG": "12345"},
+
+			MaxOutputBytes: -1,
+			TruncatePolicy: gradv1.TruncatePolicy(42),
+		}))
+		for _, field := range []string{"command", "timeout", "shell", "max_output_bytes", "truncate_policy", "env"} {
+			if violations[field] == "" {
+				t.Errorf("%s: Expected a violation of %s, got %v", name, field, violations)
+			}
+		}
+	}
+
+	violations = fieldViolations(t, s.ExecuteCommandStream(&gradv1.ExecuteCommandRequest{Command: "ls"}, nil))
+	if len(violations) != 1 || violations["runner_id"] == "" {
+		t.Errorf("Expected only runner_id to be required, got %v", violations)
+	}
+
+	_, err = s.ListRunners(context.Background(), &gradv1.ListRunnersRequest{Limit: -1, Offset: -1, Group: "not a group"})
+	violations = fieldViolations(t, err)
+	if len(violations) != 3 {
+		t.Errorf("Expected limit, offset and group violations, got %v", violations)
+	}
+}
+
+func TestCommandLimits(t *testing.T) {
+	s := NewServer(nil, nil, nil, &service.CommandLimits{MaxBytes: 16, MaxArgs: 3}, nil, nil, nil)
+	long := strings.Repeat("x", 17)
+
+	// Every entry point taking a command rejects one over the limit, saying how large it was
+	_, err := s.CreateRunner(context.Background(), &gradv1.CreateRunnerRequest{BootstrapCommand: long})
+	if violation := fieldViolations(t, err)["bootstrap_command"]; !strings.Contains(violation, "at most 16 bytes, got 17") {
+		t.Errorf("Expected the bootstrap command to be too long, got %q", violation)
+	}
+	for name, execute := range map[string]func(*gradv1.ExecuteCommandRequest) error{
+		"RunnerService":  func(req *gradv1.ExecuteCommandRequest) error { return s.ExecuteCommandStream(req, nil) },
+		"ExecuteService": func(req *gradv1.ExecuteCommandRequest) error { return s.ExecuteCommand(req, nil) },
+	} {
+		violations := fieldViolations(t, execute(&gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: long}))
+		if !strings.Contains(violations["command"], "got 17") {
+			t.Errorf("%s: Expected the command to be too long, got %v", name, violations)
+		}
+
+		// The quoted command line counts, not just the words
+		violations = fieldViolations(t, execute(&gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Argv: []string{"ls", "a", "b", "c"}}))
+		if !strings.Contains(violations["argv"], "at most 3 words, got 4") {
+			t.Errorf("%s: Expected too many words, got %v", name, violations)
+		}
+		violations = fieldViolations(t, execute(&gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Argv: []string{"echo", "it's a q"}}))
+		if !strings.Contains(violations["argv"], "at most 16 bytes, got 18") {
+			t.Errorf("%s: Expected the quoted command line to be too long, got %v", name, violations)
+		}
+	}
+
+	info, err := s.GetServerInfo(context.Background(), &gradv1.GetServerInfoRequest{})
+	if err != nil {
+		t.Fatalf("GetServerInfo failed: %v", err)
+	}
+	if limits := info.CommandLimits; limits.MaxCommandBytes != 16 || limits.MaxArgs != 3 {
+		t.Errorf("Expected the limits to be exposed, got %v", limits)
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	s := NewServer(nil, nil, &service.EnvLimits{MaxValueBytes: 4, MaxTotalBytes: 64, MaxEntries: 8}, &service.CommandLimits{MaxBytes: 16, MaxArgs: 3}, nil, nil, nil)
+	req := &service.CreateRunnerRequest{BootstrapCommand: strings.Repeat("x", 17), Env: map[string]string{"A": "12345"}}
+
+	// Requests validated while the limits change see either the old or the new ones
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					_ = s.validateCreateRunnerRequest(req)
+				}
+			}
+		}()
+	}
+	for i := range 100 {
+		s.SetLimits(&service.EnvLimits{MaxValueBytes: 4 + i, MaxTotalBytes: 64, MaxEntries: 8}, &service.CommandLimits{MaxBytes: 16 + i, MaxArgs: 3})
+	}
+	close(done)
+	wg.Wait()
+
+	if err := s.validateCreateRunnerRequest(req); err != nil {
+		t.Errorf("Expected the raised limits to allow the request, got %v", err)
+	}
+	info, err := s.GetServerInfo(context.Background(), &gradv1.GetServerInfoRequest{})
+	if err != nil || info.CommandLimits.MaxCommandBytes != 115 {
+		t.Errorf("Expected the new command limits to be exposed, got %v, %v", info.GetCommandLimits(), err)
+	}
+
+	// Without command limits the defaults apply
+	s.SetLimits(nil, nil)
+	if info, _ := s.GetServerInfo(context.Background(), &gradv1.GetServerInfoRequest{}); info.CommandLimits.MaxCommandBytes != int32(service.DefaultCommandLimits().MaxBytes) {
+		t.Errorf("Expected the default command limits, got %v", info.CommandLimits)
+	}
+}
+
+func TestSSHKeyRequestsRequireFields(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, nil, nil, nil)
+
+	_, err := s.AddSSHKey(context.Background(), &gradv1.AddSSHKeyRequest{})
+	if violations := fieldViolations(t, err); violations["runner_id"] == "" || violations["public_key"] == "" {
+		t.Errorf("Expected runner_id and public_key to be required, got %v", violations)
+	}
+	_, err = s.RemoveSSHKey(context.Background(), &gradv1.RemoveSSHKeyRequest{RunnerId: "runner-1"})
+	if violations := fieldViolations(t, err); len(violations) != 1 || violations["fingerprint"] == "" {
+		t.Errorf("Expected fingerprint to be required, got %v", violations)
+	}
+	if _, err := s.ListSSHKeys(context.Background(), &gradv1.ListSSHKeysRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a runner ID, got %v", err)
+	}
+}
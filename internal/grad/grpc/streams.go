@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// streamWarnPercent is how full a stream limit gets before grad logs a warning
+const streamWarnPercent = 80
+
+// StreamLimiter counts open command output streams, in total and per client connection.
+// The total is capped by MaxExecStreams; per connection, HTTP/2 enforces
+// MaxConcurrentStreams and the limiter only logs when a connection gets close to it.
+type StreamLimiter struct {
+	mu          sync.Mutex
+	limits      service.StreamLimits
+	active      int
+	connections map[string]int
+	// activeGauge reports active as grpc_active_exec_streams
+	activeGauge prometheus.Gauge
+}
+
+// NewStreamLimiter creates a stream limiter enforcing limits and reporting the open streams to activeGauge
+func NewStreamLimiter(limits service.StreamLimits, activeGauge prometheus.Gauge) *StreamLimiter {
+	return &StreamLimiter{
+		limits:      limits,
+		connections: make(map[string]int),
+		activeGauge: activeGauge,
+	}
+}
+
+// Acquire registers a stream for the connection of ctx. Beyond MaxExecStreams it returns a
+// ResourceExhausted error; otherwise release must be called once the stream ends.
+func (l *StreamLimiter) Acquire(ctx context.Context) (release func(), err error) {
+	connection := peerAddress(ctx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limits.MaxExecStreams > 0 && l.active >= l.limits.MaxExecStreams {
+		slog.Warn("Rejected exec stream over the limit",
+			"peer", connection, "active_exec_streams", l.active, "max_exec_streams", l.limits.MaxExecStreams)
+		return nil, status.Errorf(codes.ResourceExhausted, "too many command streams open (limit %d), try again later", l.limits.MaxExecStreams)
+	}
+
+	l.active++
+	l.connections[connection]++
+	l.activeGauge.Inc()
+
+	if l.active == warnThreshold(l.limits.MaxExecStreams) {
+		slog.Warn("Exec streams approaching the limit",
+			"active_exec_streams", l.active, "max_exec_streams", l.limits.MaxExecStreams)
+	}
+	if count := l.connections[connection]; count == warnThreshold(l.limits.MaxConcurrentStreams) {
+		slog.Warn("Connection approaching the concurrent stream limit",
+			"peer", connection, "exec_streams", count, "max_concurrent_streams", l.limits.MaxConcurrentStreams)
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { l.release(connection) }) }, nil
+}
+
+// release unregisters a stream of connection
+func (l *StreamLimiter) release(connection string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	l.activeGauge.Dec()
+	if l.connections[connection]--; l.connections[connection] <= 0 {
+		delete(l.connections, connection)
+	}
+}
+
+// Active returns the number of open streams
+func (l *StreamLimiter) Active() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active
+}
+
+// ToProto reports the limits and current usage
+func (l *StreamLimiter) ToProto() *gradv1.StreamLimits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return &gradv1.StreamLimits{
+		MaxConcurrentStreams:   uint32(l.limits.MaxConcurrentStreams),
+		MaxExecStreams:         int32(l.limits.MaxExecStreams),
+		ActiveExecStreams:      int32(l.active),
+		MaxExecOutputBytes:     int64(l.limits.MaxExecOutputBytes),
+		ExecResumeGraceSeconds: int32(l.limits.ExecResumeGrace / time.Second),
+	}
+}
+
+// warnThreshold returns the count at which limit is considered nearly reached, 0 for no limit (pure function)
+func warnThreshold(limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	return max((limit*streamWarnPercent+99)/100, 1)
+}
+
+// peerAddress returns the client address of a request, identifying its connection
+func peerAddress(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return "unknown"
+}
@@ -0,0 +1,140 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/internal/grad/service"
+)
+
+// blockingRunnerService runs commands that print one line and then wait for finish to close
+type blockingRunnerService struct {
+	service.RunnerService
+	finish chan struct{}
+}
+
+func (b *blockingRunnerService) ExecuteCommandStream(ctx context.Context, req *service.ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+	defer close(stdoutCh)
+	defer close(stderrCh)
+
+	req.Started(service.ExecStart{RunnerID: req.RunnerID, Shell: "bash"})
+	stdoutCh <- []byte("started\n")
+	select {
+	case <-b.finish:
+		return service.ExitStatus{Reason: service.ExitReasonExited}, nil
+	case <-ctx.Done():
+		return service.ExitStatus{}, ctx.Err()
+	}
+}
+
+// startStreamServer serves a Server limited to streamLimits and returns a client for it
+func startStreamServer(t *testing.T, runners service.RunnerService, streamLimits *service.StreamLimits) gradv1.RunnerServiceClient {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := grpc.NewServer(grpc.MaxConcurrentStreams(uint32(streamLimits.MaxConcurrentStreams)))
+	gradv1.RegisterRunnerServiceServer(srv, NewServer(runners, nil, nil, nil, streamLimits, nil, nil))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return gradv1.NewRunnerServiceClient(conn)
+}
+
+// openExecStream starts a command and waits for its STARTED message and first output
+func openExecStream(t *testing.T, client gradv1.RunnerServiceClient) (gradv1.RunnerService_ExecuteCommandStreamClient, error) {
+	t.Helper()
+
+	stream, err := client.ExecuteCommandStream(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "sleep 60"})
+	if err != nil {
+		return nil, err
+	}
+	for range 2 {
+		if _, err := stream.Recv(); err != nil {
+			return nil, err
+		}
+	}
+	return stream, nil
+}
+
+func TestExecStreamLimit(t *testing.T) {
+	runners := &blockingRunnerService{finish: make(chan struct{})}
+	client := startStreamServer(t, runners, &service.StreamLimits{MaxConcurrentStreams: 10, MaxExecStreams: 2})
+
+	var open []gradv1.RunnerService_ExecuteCommandStreamClient
+	for i := 0; i < 2; i++ {
+		stream, err := openExecStream(t, client)
+		if err != nil {
+			t.Fatalf("Expected stream %d within the limit to open, got %v", i+1, err)
+		}
+		open = append(open, stream)
+	}
+
+	if _, err := openExecStream(t, client); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted beyond the limit, got %v", err)
+	}
+
+	info, err := client.GetServerInfo(context.Background(), &gradv1.GetServerInfoRequest{})
+	if err != nil {
+		t.Fatalf("GetServerInfo failed: %v", err)
+	}
+	if limits := info.StreamLimits; limits.MaxExecStreams != 2 || limits.ActiveExecStreams != 2 || limits.MaxConcurrentStreams != 10 {
+		t.Errorf("Expected 2 of 2 exec streams and 10 concurrent streams, got %+v", limits)
+	}
+
+	// The rejection doesn't disturb the streams already open
+	close(runners.finish)
+	for i, stream := range open {
+		resp, err := stream.Recv()
+		if err != nil || resp.Type != gradv1.StreamType_STREAM_TYPE_EXIT {
+			t.Fatalf("Expected stream %d to finish with EXIT, got %v, %v", i+1, resp, err)
+		}
+		if _, err := stream.Recv(); err != io.EOF {
+			t.Errorf("Expected stream %d to end, got %v", i+1, err)
+		}
+	}
+
+	// Finished streams free their slots
+	stream, err := openExecStream(t, client)
+	if err != nil {
+		t.Fatalf("Expected a stream to open after others finished, got %v", err)
+	}
+	if resp, err := stream.Recv(); err != nil || resp.Type != gradv1.StreamType_STREAM_TYPE_EXIT {
+		t.Errorf("Expected EXIT, got %v, %v", resp, err)
+	}
+}
+
+func TestWarnThreshold(t *testing.T) {
+	tests := []struct {
+		limit  int
+		expect int
+	}{
+		{0, 0},
+		{1, 1},
+		{2, 2},
+		{10, 8},
+		{100, 80},
+		{256, 205},
+	}
+
+	for _, tt := range tests {
+		if got := warnThreshold(tt.limit); got != tt.expect {
+			t.Errorf("Expected threshold %d for limit %d, got %d", tt.expect, tt.limit, got)
+		}
+	}
+}
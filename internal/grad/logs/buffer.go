@@ -0,0 +1,110 @@
+package logs
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultBufferSize is how many records grad keeps for the admin API to tail
+const DefaultBufferSize = 1000
+
+// followerBacklog is how many records a follower may fall behind before it misses records
+const followerBacklog = 256
+
+// Record is a log record kept by a Buffer
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// JSON is the record as logged, without the trailing newline
+	JSON []byte
+}
+
+// Buffer keeps the most recent log records, evicting the oldest once it is full, and passes
+// new records on to followers. It is safe for concurrent use.
+type Buffer struct {
+	mu        sync.Mutex
+	records   []Record
+	start     int
+	count     int
+	followers map[*follower]struct{}
+}
+
+// follower receives the records of at least its level as they are added
+type follower struct {
+	minLevel slog.Level
+	records  chan Record
+}
+
+// NewBuffer returns a buffer keeping the last size records
+func NewBuffer(size int) *Buffer {
+	if size < 1 {
+		size = 1
+	}
+	return &Buffer{
+		records:   make([]Record, size),
+		followers: make(map[*follower]struct{}),
+	}
+}
+
+// Add keeps record, evicting the oldest one if the buffer is full, and passes it to the
+// followers of its level. A follower that has fallen followerBacklog records behind misses it
+// rather than holding up logging.
+func (b *Buffer) Add(record Record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.count < len(b.records) {
+		b.records[(b.start+b.count)%len(b.records)] = record
+		b.count++
+	} else {
+		b.records[b.start] = record
+		b.start = (b.start + 1) % len(b.records)
+	}
+
+	for f := range b.followers {
+		if record.Level < f.minLevel {
+			continue
+		}
+		select {
+		case f.records <- record:
+		default:
+		}
+	}
+}
+
+// Tail returns the kept records of at least minLevel, oldest first
+func (b *Buffer) Tail(minLevel slog.Level) []Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tail(minLevel)
+}
+
+// Follow returns the kept records of at least minLevel like Tail, and a channel receiving
+// those added afterwards, without a gap between them. stop must be called once done.
+func (b *Buffer) Follow(minLevel slog.Level) (recent []Record, records <-chan Record, stop func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f := &follower{minLevel: minLevel, records: make(chan Record, followerBacklog)}
+	b.followers[f] = struct{}{}
+	stop = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.followers, f)
+	}
+	return b.tail(minLevel), f.records, stop
+}
+
+// tail returns the kept records of at least minLevel; b.mu must be held
+func (b *Buffer) tail(minLevel slog.Level) []Record {
+	var records []Record
+	for i := 0; i < b.count; i++ {
+		record := b.records[(b.start+i)%len(b.records)]
+		if record.Level >= minLevel {
+			records = append(records, record)
+		}
+	}
+	return records
+}
@@ -0,0 +1,165 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// messages returns the messages of records, in order
+func messages(records []Record) string {
+	var got []string
+	for _, record := range records {
+		got = append(got, record.Message)
+	}
+	return strings.Join(got, " ")
+}
+
+func TestBufferEvictsOldest(t *testing.T) {
+	b := NewBuffer(3)
+	if got := b.Tail(slog.LevelDebug); len(got) != 0 {
+		t.Errorf("Expected an empty buffer, got %q", messages(got))
+	}
+
+	for _, message := range []string{"a", "b", "c", "d", "e"} {
+		b.Add(Record{Level: slog.LevelInfo, Message: message})
+	}
+	if got := messages(b.Tail(slog.LevelDebug)); got != "c d e" {
+		t.Errorf("Expected the last 3 records, got %q", got)
+	}
+
+	b.Add(Record{Level: slog.LevelInfo, Message: "f"})
+	if got := messages(b.Tail(slog.LevelDebug)); got != "d e f" {
+		t.Errorf("Expected the oldest record to be evicted, got %q", got)
+	}
+}
+
+func TestBufferLevelFilter(t *testing.T) {
+	b := NewBuffer(10)
+	b.Add(Record{Level: slog.LevelDebug, Message: "debug"})
+	b.Add(Record{Level: slog.LevelInfo, Message: "info"})
+	b.Add(Record{Level: slog.LevelWarn, Message: "warn"})
+	b.Add(Record{Level: slog.LevelError, Message: "error"})
+
+	tests := []struct {
+		minLevel slog.Level
+		expected string
+	}{
+		{slog.LevelDebug, "debug info warn error"},
+		{slog.LevelInfo, "info warn error"},
+		{slog.LevelWarn, "warn error"},
+		{slog.LevelError, "error"},
+		{slog.LevelError + 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.minLevel.String(), func(t *testing.T) {
+			if got := messages(b.Tail(tt.minLevel)); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBufferFollow(t *testing.T) {
+	b := NewBuffer(10)
+	b.Add(Record{Level: slog.LevelInfo, Message: "before"})
+	b.Add(Record{Level: slog.LevelWarn, Message: "before-warn"})
+
+	recent, records, stop := b.Follow(slog.LevelWarn)
+	if got := messages(recent); got != "before-warn" {
+		t.Errorf("Expected the recent warnings, got %q", got)
+	}
+
+	b.Add(Record{Level: slog.LevelInfo, Message: "after"})
+	b.Add(Record{Level: slog.LevelError, Message: "after-error"})
+	select {
+	case record := <-records:
+		if record.Message != "after-error" {
+			t.Errorf("Expected the new error, got %q", record.Message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the new error to be followed")
+	}
+
+	stop()
+	b.Add(Record{Level: slog.LevelError, Message: "stopped"})
+	select {
+	case record := <-records:
+		t.Errorf("Expected no records after stop, got %q", record.Message)
+	default:
+	}
+}
+
+func TestBufferSlowFollowerDoesNotBlock(t *testing.T) {
+	b := NewBuffer(1)
+	_, _, stop := b.Follow(slog.LevelDebug)
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < followerBacklog*2; i++ {
+			b.Add(Record{Level: slog.LevelInfo, Message: "record"})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a follower not reading to leave logging unblocked")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var out bytes.Buffer
+	b := NewBuffer(10)
+	logger := slog.New(NewHandler(&out, slog.LevelInfo, b)).With("component", "test")
+
+	logger.Debug("hidden")
+	logger.Info("Admin call", "admin_token", "hunter2", "runner_id", "runner-1")
+	logger.WithGroup("s3").Warn("Mounting bucket", "secret_access_key", "abc", "bucket", "data")
+
+	records := b.Tail(slog.LevelDebug)
+	if got := messages(records); got != "Admin call Mounting bucket" {
+		t.Fatalf("Expected the records of at least info, got %q", got)
+	}
+	if records[1].Level != slog.LevelWarn {
+		t.Errorf("Expected WARN, got %s", records[1].Level)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines on the output, got %q", out.String())
+	}
+	for i, record := range records {
+		if string(record.JSON) != lines[i] {
+			t.Errorf("Expected the kept record to match the output\n  kept:   %s\n  output: %s", record.JSON, lines[i])
+		}
+		if strings.Contains(string(record.JSON), "hunter2") || strings.Contains(string(record.JSON), "abc") {
+			t.Errorf("Expected secrets to be redacted, got %s", record.JSON)
+		}
+	}
+
+	var first map[string]any
+	if err := json.Unmarshal(records[0].JSON, &first); err != nil {
+		t.Fatalf("Expected a JSON record, got %v", err)
+	}
+	if first["admin_token"] != Redacted || first["runner_id"] != "runner-1" || first["component"] != "test" {
+		t.Errorf("Expected the token redacted and the other attributes kept, got %v", first)
+	}
+}
+
+func TestIsSecretKey(t *testing.T) {
+	for _, key := range []string{"token", "admin_token", "AWS_SECRET_ACCESS_KEY", "password", "Authorization", "ssh_private_key", "credentials"} {
+		if !IsSecretKey(key) {
+			t.Errorf("Expected %q to be a secret", key)
+		}
+	}
+	for _, key := range []string{"runner_id", "public_key", "error", "image", "bucket"} {
+		if IsSecretKey(key) {
+			t.Errorf("Expected %q not to be a secret", key)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// Handler is a slog handler writing records as JSON lines to an output, like
+// slog.JSONHandler, and keeping each in a Buffer. Secret attributes are redacted before
+// either sees them.
+type Handler struct {
+	json   slog.Handler
+	state  *handlerState
+	buffer *Buffer
+}
+
+// handlerState is shared by a handler and those derived from it with WithAttrs or WithGroup
+type handlerState struct {
+	mu   sync.Mutex
+	out  io.Writer
+	line bytes.Buffer
+}
+
+var _ slog.Handler = (*Handler)(nil)
+
+// NewHandler returns a handler logging records of at least level to out and buffer
+func NewHandler(out io.Writer, level slog.Leveler, buffer *Buffer) *Handler {
+	state := &handlerState{out: out}
+	json := slog.NewJSONHandler(&state.line, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: Redact,
+	})
+	return &Handler{json: json, state: state, buffer: buffer}
+}
+
+// Enabled reports whether records of level are logged
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.json.Enabled(ctx, level)
+}
+
+// Handle formats r as JSON, writes it to the output and keeps it in the buffer
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+
+	h.state.line.Reset()
+	if err := h.json.Handle(ctx, r); err != nil {
+		return err
+	}
+	line := h.state.line.Bytes()
+	h.buffer.Add(Record{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		JSON:    bytes.Clone(bytes.TrimSuffix(line, []byte("\n"))),
+	})
+	_, err := h.state.out.Write(line)
+	return err
+}
+
+// WithAttrs returns a handler adding attrs to every record
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{json: h.json.WithAttrs(attrs), state: h.state, buffer: h.buffer}
+}
+
+// WithGroup returns a handler nesting the attributes of every record in group
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{json: h.json.WithGroup(name), state: h.state, buffer: h.buffer}
+}
@@ -0,0 +1,45 @@
+// Package logs sets up grad's logging: JSON records on stdout with secrets redacted, the most
+// recent of which are kept in memory for the admin API to tail.
+package logs
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Redacted replaces the value of an attribute that looks like a secret
+const Redacted = "[REDACTED]"
+
+// secretKeyParts are the parts of attribute keys whose values are secrets, matched
+// case-insensitively anywhere in the key
+var secretKeyParts = []string{
+	"token",
+	"secret",
+	"password",
+	"passwd",
+	"credential",
+	"authorization",
+	"access_key",
+	"private_key",
+	"api_key",
+}
+
+// IsSecretKey reports whether an attribute named key holds a secret (pure function)
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact is a slog ReplaceAttr function replacing the values of secret attributes, in any
+// group, with Redacted (pure function)
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindGroup && IsSecretKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
@@ -0,0 +1,187 @@
+// Package metrics defines grad's Prometheus metrics. They are created and registered
+// together by New and passed to the components recording them, so several servers, such as
+// in tests, can run in one process against separate registries.
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the collectors of grad's HTTP server, gRPC server and runner service
+type Metrics struct {
+	// HTTPRequestsTotal counts HTTP requests by method, endpoint and status
+	HTTPRequestsTotal *prometheus.CounterVec
+	// HTTPRequestDuration observes HTTP request latency by method and endpoint
+	HTTPRequestDuration *prometheus.HistogramVec
+	// GRPCRequestsTotal counts gRPC calls by method and status code
+	GRPCRequestsTotal *prometheus.CounterVec
+	// GRPCRequestDuration observes gRPC call latency by method; for streams, until the stream ends
+	GRPCRequestDuration *prometheus.HistogramVec
+
+	// RateLimitedRequestsTotal counts gRPC requests rejected by the rate limiter
+	RateLimitedRequestsTotal *prometheus.CounterVec
+	// ActiveExecStreams is the number of command output streams currently open
+	ActiveExecStreams prometheus.Gauge
+	// ExecStreamDuration observes how long command output streams stay open, from both
+	// RunnerService.ExecuteCommandStream and ExecuteService.ExecuteCommand
+	ExecStreamDuration *prometheus.HistogramVec
+	// ExecStreamBytesTotal counts the command output sent to clients
+	ExecStreamBytesTotal *prometheus.CounterVec
+	// ExecOutputTruncatedTotal counts commands whose output passed its limit, by truncate policy
+	ExecOutputTruncatedTotal *prometheus.CounterVec
+
+	// RunnerProvisioningDuration observes the time from CreateRunner until the runner pod is first seen ready
+	RunnerProvisioningDuration *prometheus.HistogramVec
+	// RunnerProvisioningFailuresTotal counts runners that never became ready
+	RunnerProvisioningFailuresTotal *prometheus.CounterVec
+	// RunnerRestartsTotal counts attempts to recreate the pods of failed auto-restart runners
+	RunnerRestartsTotal *prometheus.CounterVec
+	// RunnerErrorsTotal counts runners grad saw enter the error state, by error reason
+	RunnerErrorsTotal *prometheus.CounterVec
+	// StaleImageRunners is the number of runners running another image than new runners get,
+	// as of the last check
+	StaleImageRunners prometheus.Gauge
+}
+
+// New creates grad's metrics and registers them with reg. It fails if any of them is already
+// registered there, leaving reg as it was.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := NewUnregistered()
+	collectors := m.collectors()
+	for i, collector := range collectors {
+		if err := reg.Register(collector); err != nil {
+			for _, registered := range collectors[:i] {
+				reg.Unregister(registered)
+			}
+			return nil, fmt.Errorf("failed to register metrics: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// NewUnregistered creates grad's metrics without registering them, for components whose
+// metrics aren't exposed, such as those constructed in tests
+func NewUnregistered() *Metrics {
+	return &Metrics{
+		HTTPRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "Total number of HTTP requests",
+			},
+			[]string{"method", "endpoint", "status"},
+		),
+		HTTPRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "http_request_duration_seconds",
+				Help: "Duration of HTTP requests in seconds",
+			},
+			[]string{"method", "endpoint"},
+		),
+		GRPCRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_requests_total",
+				Help: "Total number of gRPC requests",
+			},
+			[]string{"method", "status"},
+		),
+		GRPCRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "grpc_request_duration_seconds",
+				Help: "Duration of gRPC requests in seconds",
+			},
+			[]string{"method"},
+		),
+		RateLimitedRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_rate_limited_requests_total",
+				Help: "Total number of gRPC requests rejected by the rate limiter",
+			},
+			[]string{"class", "scope"},
+		),
+		ActiveExecStreams: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "grpc_active_exec_streams",
+				Help: "Number of command output streams currently open",
+			},
+		),
+		ExecStreamDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "grpc_exec_stream_duration_seconds",
+				Help:    "Duration of command output streams by outcome",
+				Buckets: []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900, 3600},
+			},
+			[]string{"outcome"},
+		),
+		ExecStreamBytesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_exec_stream_bytes_total",
+				Help: "Total bytes of command output sent to clients by stream",
+			},
+			[]string{"stream"},
+		),
+		ExecOutputTruncatedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_exec_output_truncated_total",
+				Help: "Total number of commands whose output passed the output limit, by truncate policy",
+			},
+			[]string{"policy"},
+		),
+		RunnerProvisioningDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "runner_provisioning_duration_seconds",
+				Help:    "Time from runner creation until the runner pod is ready, in seconds",
+				Buckets: []float64{5, 10, 20, 30, 45, 60, 90, 120, 180, 300},
+			},
+			[]string{"preset", "image"},
+		),
+		RunnerProvisioningFailuresTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "runner_provisioning_failures_total",
+				Help: "Total number of runners that failed to become ready",
+			},
+			[]string{"reason"},
+		),
+		RunnerRestartsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "runner_restarts_total",
+				Help: "Total number of attempts to recreate failed runner pods, by result",
+			},
+			[]string{"result"},
+		),
+		RunnerErrorsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "runner_errors_total",
+				Help: "Total number of runners that entered the error state, by reason",
+			},
+			[]string{"reason"},
+		),
+		StaleImageRunners: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "runners_stale_image",
+				Help: "Number of runners running a different image than new runners get",
+			},
+		),
+	}
+}
+
+// collectors returns every collector of m, in the order they are registered
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.HTTPRequestsTotal,
+		m.HTTPRequestDuration,
+		m.GRPCRequestsTotal,
+		m.GRPCRequestDuration,
+		m.RateLimitedRequestsTotal,
+		m.ActiveExecStreams,
+		m.ExecStreamDuration,
+		m.ExecStreamBytesTotal,
+		m.ExecOutputTruncatedTotal,
+		m.RunnerProvisioningDuration,
+		m.RunnerProvisioningFailuresTotal,
+		m.RunnerRestartsTotal,
+		m.RunnerErrorsTotal,
+		m.StaleImageRunners,
+	}
+}
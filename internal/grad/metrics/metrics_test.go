@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewSeparateRegistries(t *testing.T) {
+	first, err := New(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	second, err := New(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("Expected a second registry to take the same metrics, got %v", err)
+	}
+
+	first.RunnerRestartsTotal.WithLabelValues("restarted").Inc()
+	if got := testutil.ToFloat64(second.RunnerRestartsTotal.WithLabelValues("restarted")); got != 0 {
+		t.Errorf("Expected the metrics of separate registries to be independent, got %v", got)
+	}
+}
+
+func TestNewAlreadyRegistered(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if _, err := New(registry); err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	_, err := New(registry)
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if !errors.As(err, &alreadyRegistered) {
+		t.Fatalf("Expected an AlreadyRegisteredError, got %v", err)
+	}
+}
+
+func TestNewConflictLeavesRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	// Registered under a name New uses further down its list
+	registry.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "runner_restarts_total", Help: "Conflicting"}))
+
+	if _, err := New(registry); err == nil {
+		t.Fatal("Expected a conflicting metric to fail")
+	}
+	if count, err := testutil.GatherAndCount(registry); err != nil || count != 1 {
+		t.Errorf("Expected only the conflicting metric to remain, got %d (%v)", count, err)
+	}
+}
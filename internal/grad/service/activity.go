@@ -10,15 +10,66 @@ import (
 type ActivityTracker struct {
 	mu             sync.RWMutex
 	lastActiveTimes map[string]time.Time
+	outputBytes     map[string]OutputBytes
+	execsInFlight   map[string]int
+}
+
+// OutputBytes counts the command output a runner sent, since grad started
+type OutputBytes struct {
+	Stdout int64
+	Stderr int64
 }
 
 // NewActivityTracker creates a new activity tracker
 func NewActivityTracker() *ActivityTracker {
 	return &ActivityTracker{
 		lastActiveTimes: make(map[string]time.Time),
+		outputBytes:     make(map[string]OutputBytes),
+		execsInFlight:   make(map[string]int),
+	}
+}
+
+// BeginExec counts a command running in a runner until the returned func is called
+func (at *ActivityTracker) BeginExec(runnerID string) (end func()) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	at.execsInFlight[runnerID]++
+	return func() {
+		at.mu.Lock()
+		defer at.mu.Unlock()
+		if at.execsInFlight[runnerID]--; at.execsInFlight[runnerID] <= 0 {
+			delete(at.execsInFlight, runnerID)
+		}
 	}
 }
 
+// ExecInFlight reports whether a command counted with BeginExec is running in a runner
+func (at *ActivityTracker) ExecInFlight(runnerID string) bool {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+	return at.execsInFlight[runnerID] > 0
+}
+
+// AddOutputBytes counts n bytes of command output of a runner on stream, stdout or stderr
+func (at *ActivityTracker) AddOutputBytes(runnerID, stream string, n int) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	counts := at.outputBytes[runnerID]
+	if stream == "stderr" {
+		counts.Stderr += int64(n)
+	} else {
+		counts.Stdout += int64(n)
+	}
+	at.outputBytes[runnerID] = counts
+}
+
+// GetOutputBytes returns the command output counted for a runner
+func (at *ActivityTracker) GetOutputBytes(runnerID string) OutputBytes {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+	return at.outputBytes[runnerID]
+}
+
 // UpdateLastActiveTime records the last active time for a runner
 func (at *ActivityTracker) UpdateLastActiveTime(runnerID string) {
 	at.mu.Lock()
@@ -31,6 +82,18 @@ func (at *ActivityTracker) UpdateLastActiveTime(runnerID string) {
 		"total_tracked", len(at.lastActiveTimes))
 }
 
+// RestoreLastActiveTime starts tracking a runner from lastActive unless it is already tracked,
+// reporting whether it was
+func (at *ActivityTracker) RestoreLastActiveTime(runnerID string, lastActive time.Time) bool {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	if _, tracked := at.lastActiveTimes[runnerID]; tracked {
+		return false
+	}
+	at.lastActiveTimes[runnerID] = lastActive
+	return true
+}
+
 // GetLastActiveTime retrieves the last active time for a runner
 // Returns zero time if runner has no recorded activity
 func (at *ActivityTracker) GetLastActiveTime(runnerID string) time.Time {
@@ -76,6 +139,7 @@ func (at *ActivityTracker) RemoveRunner(runnerID string) {
 	
 	_, existed := at.lastActiveTimes[runnerID]
 	delete(at.lastActiveTimes, runnerID)
+	delete(at.outputBytes, runnerID)
 	
 	if existed {
 		slog.Info("Removed runner from activity tracking", 
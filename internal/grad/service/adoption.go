@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// lastActiveAnnotationInterval is how often a runner in use has its last activity written to
+// its pod; a restarted grad may think it idle for up to this long
+const lastActiveAnnotationInterval = time.Minute
+
+// recordActivity marks runnerID as active now, persisting it at most once per
+// lastActiveAnnotationInterval
+func (s *runnerService) recordActivity(ctx context.Context, runnerID string) {
+	previous := s.activityTracker.GetLastActiveTime(runnerID)
+	s.activityTracker.UpdateLastActiveTime(runnerID)
+
+	lastActive := s.activityTracker.GetLastActiveTime(runnerID)
+	if lastActive.Sub(previous) < lastActiveAnnotationInterval {
+		return
+	}
+	if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, map[string]string{
+		RunnerLastActiveAnnotation: lastActive.UTC().Format(time.RFC3339),
+	}); err != nil {
+		slog.Warn("Failed to record runner activity", "runner_id", runnerID, "error", err)
+	}
+}
+
+// adoptRunner starts tracking the activity of a running runner grad has no record of, which
+// happens after a restart since the tracker lives in memory. Its last activity comes from the
+// last-active annotation, or its creation time if no command ever ran. Runners this grad
+// created are only tracked once they run a command, so they are left alone.
+func (s *runnerService) adoptRunner(pod *corev1.Pod, runner *Runner) {
+	if runner.Status != RunnerStatusRunning || pod.DeletionTimestamp != nil {
+		return
+	}
+
+	lastActive, err := time.Parse(time.RFC3339, pod.Annotations[RunnerLastActiveAnnotation])
+	if err != nil {
+		lastActive = time.Unix(runner.CreatedAt, 0)
+		if !lastActive.Before(s.startedAt) {
+			return
+		}
+	}
+
+	if s.activityTracker.RestoreLastActiveTime(runner.ID, lastActive) {
+		slog.Info("Adopted runner into activity tracking", "runner_id", runner.ID, "last_active", lastActive)
+	}
+}
+
+// adoptRunners adopts every runner at startup, so idle ones left by a previous grad are
+// cleaned up even if nobody lists them
+func (s *runnerService) adoptRunners(ctx context.Context) {
+	if _, _, err := s.ListRunners(ctx, &ListOptions{}); err != nil {
+		slog.Warn("Failed to adopt existing runners", "error", err)
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// addRunningRunner adds the ready pod of a runner created at createdAt, as a previous grad left it
+func addRunningRunner(t *testing.T, svc *runnerService, clientset *fake.Clientset, runnerID string, createdAt time.Time, annotations map[string]string) {
+	t.Helper()
+
+	meta := metav1.ObjectMeta{
+		Name:      kubernetesClient(svc).getPodName(runnerID),
+		Namespace: svc.k8sClient.Config().Namespace,
+		Labels:    runnerPodLabels(runnerID),
+	}
+	meta.Annotations = map[string]string{
+		RunnerIDAnnotation:      runnerID,
+		RunnerCreatedAnnotation: createdAt.UTC().Format(time.RFC3339),
+	}
+	for key, value := range annotations {
+		meta.Annotations[key] = value
+	}
+	if _, err := clientset.CoreV1().Pods(meta.Namespace).Create(context.Background(), &corev1.Pod{ObjectMeta: meta}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	setPodStatus(t, svc, clientset, runnerID, corev1.PodRunning, true)
+}
+
+func TestRestoreLastActiveTime(t *testing.T) {
+	tracker := NewActivityTracker()
+	tracker.UpdateLastActiveTime("runner-1")
+	tracked := tracker.GetLastActiveTime("runner-1")
+
+	if tracker.RestoreLastActiveTime("runner-1", tracked.Add(-time.Hour)) {
+		t.Error("Expected a tracked runner not to be restored")
+	}
+	if got := tracker.GetLastActiveTime("runner-1"); !got.Equal(tracked) {
+		t.Errorf("Expected %s to be kept, got %s", tracked, got)
+	}
+
+	lastActive := time.Now().Add(-time.Hour)
+	if !tracker.RestoreLastActiveTime("runner-2", lastActive) {
+		t.Error("Expected an untracked runner to be restored")
+	}
+	if got := tracker.GetLastActiveTime("runner-2"); !got.Equal(lastActive) {
+		t.Errorf("Expected %s, got %s", lastActive, got)
+	}
+}
+
+func TestRunnersAreAdoptedAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+
+	lastActive := svc.startedAt.Add(-10 * time.Minute).Truncate(time.Second)
+	createdAt := svc.startedAt.Add(-time.Hour).Truncate(time.Second)
+	addRunningRunner(t, svc, clientset, "used", createdAt, map[string]string{
+		RunnerLastActiveAnnotation: lastActive.UTC().Format(time.RFC3339),
+	})
+	addRunningRunner(t, svc, clientset, "unused", createdAt, nil)
+	// Created by this grad, which would have tracked it had a command run
+	addRunningRunner(t, svc, clientset, "new", svc.startedAt.Add(time.Minute), nil)
+
+	if _, err := svc.GetRunner(ctx, "used"); err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if got := svc.activityTracker.GetLastActiveTime("used"); !got.Equal(lastActive) {
+		t.Errorf("Expected used to be last active at %s, got %s", lastActive, got)
+	}
+
+	if _, _, err := svc.ListRunners(ctx, &ListOptions{}); err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+	if got := svc.activityTracker.GetLastActiveTime("unused"); !got.Equal(createdAt) {
+		t.Errorf("Expected unused to be last active when created at %s, got %s", createdAt, got)
+	}
+	if got := svc.activityTracker.GetLastActiveTime("new"); !got.IsZero() {
+		t.Errorf("Expected new not to be tracked, got %s", got)
+	}
+
+	// Adopted runners are found idle without waiting for another command
+	inactive := svc.activityTracker.GetInactiveRunners(30 * time.Minute)
+	if len(inactive) != 1 || inactive[0] != "unused" {
+		t.Errorf("Expected only unused to be idle for 30 minutes, got %v", inactive)
+	}
+}
+
+func TestStartAdoptsRunners(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	svc, clientset := newTestRunnerService()
+	createdAt := svc.startedAt.Add(-time.Hour).Truncate(time.Second)
+	addRunningRunner(t, svc, clientset, "runner-1", createdAt, nil)
+
+	svc.Start(context.Background())
+	svc.Stop()
+
+	if got := svc.activityTracker.GetLastActiveTime("runner-1"); !got.Equal(createdAt) {
+		t.Errorf("Expected runner-1 to be adopted at startup, got %s", got)
+	}
+}
+
+func TestRecordActivityPersistsLastActiveTime(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	addRunningRunner(t, svc, clientset, "runner-1", svc.startedAt.Add(-time.Hour), nil)
+
+	before := len(clientset.Actions())
+	patches := func() int {
+		count := 0
+		for _, action := range clientset.Actions()[before:] {
+			if action.Matches("patch", "pods") {
+				count++
+			}
+		}
+		return count
+	}
+	lastActiveAnnotation := func() string {
+		pod, err := svc.k8sClient.GetRunnerPod(ctx, "runner-1")
+		if err != nil {
+			t.Fatalf("GetRunnerPod failed: %v", err)
+		}
+		return pod.Annotations[RunnerLastActiveAnnotation]
+	}
+
+	svc.recordActivity(ctx, "runner-1")
+	first := lastActiveAnnotation()
+	if first != svc.activityTracker.GetLastActiveTime("runner-1").UTC().Format(time.RFC3339) {
+		t.Errorf("Expected the first command to be recorded, got %q", first)
+	}
+
+	// Commands in quick succession don't write the pod every time
+	svc.recordActivity(ctx, "runner-1")
+	if got := patches(); got != 1 {
+		t.Errorf("Expected 1 pod patch, got %d", got)
+	}
+
+	// A minute later the annotation is refreshed
+	svc.activityTracker.RemoveRunner("runner-1")
+	svc.activityTracker.RestoreLastActiveTime("runner-1", time.Now().Add(-lastActiveAnnotationInterval))
+	svc.recordActivity(ctx, "runner-1")
+	if got := patches(); got != 2 {
+		t.Errorf("Expected 2 pod patches, got %d", got)
+	}
+	if got := lastActiveAnnotation(); got == "" || got < first {
+		t.Errorf("Expected the annotation to be refreshed, got %q after %q", got, first)
+	}
+}
+
+func TestRecordActivityWithoutPod(t *testing.T) {
+	svc, _ := newTestRunnerService()
+
+	// The annotation patch fails on the missing pod, which doesn't stop the activity from being
+	// tracked
+	svc.recordActivity(context.Background(), "missing")
+	if svc.activityTracker.GetLastActiveTime("missing").IsZero() {
+		t.Error("Expected the activity to be tracked")
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import "slices"
+
+// RunnerAllowlists are what requests may pick for their runners beyond the server's defaults
+type RunnerAllowlists struct {
+	ServiceAccounts []string
+	PriorityClasses []string
+}
+
+// Allowlists returns the ServiceAccounts and PriorityClasses requests may pick
+func (c *KubernetesConfig) Allowlists() RunnerAllowlists {
+	c.allowlistsMu.RLock()
+	defer c.allowlistsMu.RUnlock()
+	allowlists := RunnerAllowlists{PriorityClasses: c.AllowedPriorityClasses}
+	if c.ServiceAccount != nil {
+		allowlists.ServiceAccounts = c.ServiceAccount.Allowed
+	}
+	return allowlists
+}
+
+// SetAllowlists replaces the ServiceAccounts and PriorityClasses requests may pick. Runners
+// that already exist keep theirs.
+func (c *KubernetesConfig) SetAllowlists(allowlists RunnerAllowlists) {
+	c.allowlistsMu.Lock()
+	defer c.allowlistsMu.Unlock()
+	if c.ServiceAccount == nil {
+		c.ServiceAccount = &ServiceAccountConfig{}
+	}
+	c.ServiceAccount.Allowed = slices.Clone(allowlists.ServiceAccounts)
+	c.AllowedPriorityClasses = slices.Clone(allowlists.PriorityClasses)
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/time/rate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// annotationWriteInterval is how far apart NewKubernetesClient spaces annotation writes to one
+// pod; writes arriving in between are merged into the next one
+const annotationWriteInterval = 100 * time.Millisecond
+
+// maxAnnotationBytes caps the value of annotations without a limit of their own. With the
+// limits below it keeps a pod's annotations well under the 256 KiB Kubernetes allows in total.
+const maxAnnotationBytes = 4 << 10
+
+// annotationLimit caps the value of one annotation. Free text over it is truncated with a
+// marker saying so; other values are rejected, as a cut-off value couldn't be parsed.
+type annotationLimit struct {
+	maxBytes int
+	truncate bool
+}
+
+// annotationLimits are the annotations whose values may exceed maxAnnotationBytes or be truncated
+var annotationLimits = map[string]annotationLimit{
+	RunnerStatusDetailAnnotation: {maxBytes: 16 << 10, truncate: true},
+	RunnerErrorMessageAnnotation: {maxBytes: maxAnnotationBytes, truncate: true},
+	RunnerSSHKeysAnnotation:      {maxBytes: 64 << 10},
+}
+
+// limitAnnotations returns annotations with their values fitted to their limits, or an error
+// wrapping ErrFailedPrecondition if one can't be truncated (pure function)
+func limitAnnotations(annotations map[string]string) (map[string]string, error) {
+	limited := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		limit, ok := annotationLimits[key]
+		if !ok {
+			limit = annotationLimit{maxBytes: maxAnnotationBytes}
+		}
+		if len(value) > limit.maxBytes {
+			if !limit.truncate {
+				return nil, fmt.Errorf("%w: annotation %s is %d bytes, over its limit of %d", ErrFailedPrecondition, key, len(value), limit.maxBytes)
+			}
+			value = truncateAnnotation(value, limit.maxBytes)
+		}
+		limited[key] = value
+	}
+	return limited, nil
+}
+
+// truncateAnnotation cuts value down to maxBytes, keeping its start and ending it with a
+// marker counting the bytes dropped. It doesn't split UTF-8 sequences. (pure function)
+func truncateAnnotation(value string, maxBytes int) string {
+	// The marker can only get shorter once the dropped byte count is known
+	cut := maxBytes - len(truncationMarker(len(value)))
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut] + truncationMarker(len(value)-cut)
+}
+
+// truncationMarker ends a value dropped bytes were cut from (pure function)
+func truncationMarker(dropped int) string {
+	return fmt.Sprintf("\n[truncated %d bytes]", dropped)
+}
+
+// annotationWriter writes annotations to pods with merge patches, which only touch the keys
+// written, so writers of different keys can't undo each other's changes the way updating the
+// whole pod could. Writes to a pod are rate limited to one per interval, and the writes that
+// queue up meanwhile go out together in a single patch. Its zero value writes without a limit.
+type annotationWriter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	pods map[string]*podAnnotations
+}
+
+// podAnnotations are the annotation writes to one pod
+type podAnnotations struct {
+	// lock is held, by putting a value in it, while writing to the pod
+	lock    chan struct{}
+	limiter *rate.Limiter
+	// pending collects the writes waiting for the next patch
+	pending *annotationBatch
+}
+
+// annotationBatch is the annotations of writes merged into one patch, later writes winning
+type annotationBatch struct {
+	annotations map[string]string
+	// done is closed once the patch has been sent, err being how it went
+	done chan struct{}
+	err  error
+}
+
+// write sets annotations on podName, calling patch with them merged with the other writes
+// queued for the pod. If ctx ends first, the annotations may still be written with the next
+// write to the pod.
+func (w *annotationWriter) write(ctx context.Context, podName string, annotations map[string]string, patch func(ctx context.Context, annotations map[string]string) error) error {
+	annotations, err := limitAnnotations(annotations)
+	if err != nil {
+		return err
+	}
+	pod, batch := w.queue(podName, annotations)
+
+	select {
+	case pod.lock <- struct{}{}:
+	case <-batch.done:
+		return batch.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-pod.lock }()
+
+	select {
+	case <-batch.done:
+		// An earlier writer took the batch along with its own
+		return batch.err
+	default:
+	}
+	if err := pod.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	pod.pending = nil
+	w.mu.Unlock()
+
+	batch.err = patch(ctx, batch.annotations)
+	close(batch.done)
+	return batch.err
+}
+
+// queue adds annotations to the pending batch of podName and returns the pod and the batch
+func (w *annotationWriter) queue(podName string, annotations map[string]string) (*podAnnotations, *annotationBatch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.pods == nil {
+		w.pods = make(map[string]*podAnnotations)
+	}
+	pod, ok := w.pods[podName]
+	if !ok {
+		limit := rate.Inf
+		if w.interval > 0 {
+			limit = rate.Every(w.interval)
+		}
+		pod = &podAnnotations{
+			lock:    make(chan struct{}, 1),
+			limiter: rate.NewLimiter(limit, 1),
+		}
+		w.pods[podName] = pod
+	}
+	if pod.pending == nil {
+		pod.pending = &annotationBatch{
+			annotations: make(map[string]string),
+			done:        make(chan struct{}),
+		}
+	}
+	for key, value := range annotations {
+		pod.pending.annotations[key] = value
+	}
+	return pod, pod.pending
+}
+
+// forget drops the state kept for podName, once it is being deleted
+func (w *annotationWriter) forget(podName string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.pods, podName)
+}
+
+// patchPodAnnotations sets annotations on a pod with a merge patch, leaving its other
+// annotations alone
+func (k *KubernetesClient) patchPodAnnotations(ctx context.Context, podName string, annotations map[string]string) error {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = k.clientset.CoreV1().Pods(k.config.Namespace).Patch(ctx, podName, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// updateFinalizers replaces the finalizers of a pod with what change makes of them. The patch
+// carries the resourceVersion change was given, so the API server rejects it if the pod
+// changed in between, and it is retried on the pod as it now is. change returning false
+// leaves the pod alone.
+func (k *KubernetesClient) updateFinalizers(ctx context.Context, podName string, change func(finalizers []string) ([]string, bool)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ctx, cancel := k.apiContext(ctx)
+		defer cancel()
+
+		pods := k.clientset.CoreV1().Pods(k.config.Namespace)
+		pod, err := pods.Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		finalizers, changed := change(pod.Finalizers)
+		if !changed {
+			return nil
+		}
+
+		patch, err := json.Marshal(map[string]any{
+			"metadata": map[string]any{
+				"resourceVersion": pod.ResourceVersion,
+				"finalizers":      finalizers,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = pods.Patch(ctx, podName, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
+
+// withFinalizer adds RunnerFinalizer to finalizers, reporting false if they have it already (pure function)
+func withFinalizer(finalizers []string) ([]string, bool) {
+	for _, finalizer := range finalizers {
+		if finalizer == RunnerFinalizer {
+			return finalizers, false
+		}
+	}
+	return append(append([]string{}, finalizers...), RunnerFinalizer), true
+}
+
+// withoutFinalizer removes RunnerFinalizer from finalizers, reporting false if they don't have it (pure function)
+func withoutFinalizer(finalizers []string) ([]string, bool) {
+	remaining := make([]string, 0, len(finalizers))
+	for _, finalizer := range finalizers {
+		if finalizer != RunnerFinalizer {
+			remaining = append(remaining, finalizer)
+		}
+	}
+	return remaining, len(remaining) != len(finalizers)
+}
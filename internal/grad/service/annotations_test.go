@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// podPatches counts the patches sent to pods
+func podPatches(clientset *fake.Clientset) int {
+	count := 0
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "patch" && action.GetResource().Resource == "pods" {
+			count++
+		}
+	}
+	return count
+}
+
+func TestUpdateRunnerAnnotationsConcurrent(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "grad-runner-runner-1",
+			Namespace:   "default",
+			Annotations: map[string]string{RunnerIDAnnotation: "runner-1"},
+			Finalizers:  []string{RunnerFinalizer},
+		},
+	}
+	clientset := fake.NewSimpleClientset(pod)
+	k8sClient := &KubernetesClient{
+		clientset:   clientset,
+		config:      DefaultKubernetesConfig(),
+		annotations: annotationWriter{interval: 50 * time.Millisecond},
+	}
+
+	// Writers of different keys, and a finalizer change racing them, must not undo each other
+	const writers = 20
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, writers+1)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- k8sClient.UpdateRunnerAnnotations(ctx, "runner-1", map[string]string{
+				fmt.Sprintf("%stest-%d", RunnerAnnotationPrefix, i): "set",
+			})
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs <- k8sClient.RemoveRunnerFinalizer(ctx, pod.Name)
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	updated, err := k8sClient.GetRunnerPod(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	for i := 0; i < writers; i++ {
+		if key := fmt.Sprintf("%stest-%d", RunnerAnnotationPrefix, i); updated.Annotations[key] != "set" {
+			t.Errorf("Expected %s to be written", key)
+		}
+	}
+	if updated.Annotations[RunnerIDAnnotation] != "runner-1" {
+		t.Error("Expected existing annotations to be preserved")
+	}
+	if len(updated.Finalizers) != 0 {
+		t.Errorf("Expected the finalizer to be removed, got %v", updated.Finalizers)
+	}
+	// One patch for the finalizer, and the rate limit batches the annotation writes
+	if got := podPatches(clientset); got >= writers {
+		t.Errorf("Expected the writes to be batched into fewer than %d patches, got %d", writers, got)
+	}
+}
+
+func TestUpdateRunnerAnnotationsRateLimited(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "grad-runner-runner-1", Namespace: "default"}}
+	k8sClient := &KubernetesClient{
+		clientset:   fake.NewSimpleClientset(pod),
+		config:      DefaultKubernetesConfig(),
+		annotations: annotationWriter{interval: time.Minute},
+	}
+
+	if err := k8sClient.UpdateRunnerAnnotations(context.Background(), "runner-1", map[string]string{RunnerLastActiveAnnotation: "now"}); err != nil {
+		t.Fatalf("UpdateRunnerAnnotations failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := k8sClient.UpdateRunnerAnnotations(ctx, "runner-1", map[string]string{RunnerLastActiveAnnotation: "later"}); err == nil {
+		t.Error("Expected a second write within the interval to wait past the deadline")
+	}
+}
+
+func TestRunnerFinalizerConflict(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "grad-runner-runner-1",
+			Namespace:       "default",
+			ResourceVersion: "1",
+			Finalizers:      []string{"example.com/other"},
+		},
+	}
+	clientset := fake.NewSimpleClientset(pod)
+	k8sClient := &KubernetesClient{clientset: clientset, config: DefaultKubernetesConfig()}
+
+	// Another writer changes the pod between each of the first two reads and patches
+	conflicts := 2
+	var bodies []string
+	clientset.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		bodies = append(bodies, string(action.(k8stesting.PatchAction).GetPatch()))
+		if conflicts > 0 {
+			conflicts--
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, pod.Name, errors.New("the object has been modified"))
+		}
+		return false, nil, nil
+	})
+
+	ctx := context.Background()
+	if err := k8sClient.AddRunnerFinalizer(ctx, pod.Name); err != nil {
+		t.Fatalf("AddRunnerFinalizer failed: %v", err)
+	}
+	if len(bodies) != 3 || !strings.Contains(bodies[0], `"resourceVersion":"1"`) {
+		t.Errorf("Expected 3 patches guarded by the resourceVersion, got %v", bodies)
+	}
+	updated, _ := clientset.CoreV1().Pods("default").Get(ctx, pod.Name, metav1.GetOptions{})
+	if len(updated.Finalizers) != 2 || updated.Finalizers[1] != RunnerFinalizer {
+		t.Errorf("Expected the finalizer to be added after the other, got %v", updated.Finalizers)
+	}
+
+	// Adding it again doesn't patch
+	if err := k8sClient.AddRunnerFinalizer(ctx, pod.Name); err != nil || len(bodies) != 3 {
+		t.Errorf("Expected no patch for a finalizer already there, got %d patches, %v", len(bodies), err)
+	}
+
+	if err := k8sClient.RemoveRunnerFinalizer(ctx, pod.Name); err != nil {
+		t.Fatalf("RemoveRunnerFinalizer failed: %v", err)
+	}
+	updated, _ = clientset.CoreV1().Pods("default").Get(ctx, pod.Name, metav1.GetOptions{})
+	if len(updated.Finalizers) != 1 || updated.Finalizers[0] != "example.com/other" {
+		t.Errorf("Expected only the other finalizer to be left, got %v", updated.Finalizers)
+	}
+}
+
+func TestLimitAnnotations(t *testing.T) {
+	detail := "bootstrap command failed with exit code 1\n" + strings.Repeat("é", 10<<10)
+	limited, err := limitAnnotations(map[string]string{
+		RunnerStatusDetailAnnotation:    detail,
+		RunnerBootstrapStatusAnnotation: BootstrapStatusFailed,
+	})
+	if err != nil {
+		t.Fatalf("limitAnnotations failed: %v", err)
+	}
+	truncated := limited[RunnerStatusDetailAnnotation]
+	if len(truncated) > 16<<10 || !utf8.ValidString(truncated) {
+		t.Errorf("Expected at most 16 KiB of valid UTF-8, got %d bytes", len(truncated))
+	}
+	if !strings.HasPrefix(truncated, "bootstrap command failed") || !strings.Contains(truncated, fmt.Sprintf("[truncated %d bytes]", len(detail)-strings.Index(truncated, "\n[truncated"))) {
+		t.Errorf("Expected the start kept and the dropped bytes counted, got ...%q", truncated[len(truncated)-40:])
+	}
+	if limited[RunnerBootstrapStatusAnnotation] != BootstrapStatusFailed {
+		t.Error("Expected values within their limit to be kept")
+	}
+
+	for _, key := range []string{RunnerSSHKeysAnnotation, RunnerDescriptionAnnotation} {
+		_, err := limitAnnotations(map[string]string{key: strings.Repeat("x", 64<<10+1)})
+		if !errors.Is(err, ErrFailedPrecondition) {
+			t.Errorf("Expected %s over its limit to be rejected, got %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultSupportedArchs are the CPU architectures runners may be pinned to unless
+// RUNNER_SUPPORTED_ARCHS says otherwise; the runner image is built for both
+var DefaultSupportedArchs = []string{"amd64", "arm64"}
+
+// validateArch checks that a requested architecture is one of supported; empty means the
+// runner may run on any node (pure function)
+func validateArch(arch string, supported []string) error {
+	if arch == "" || slices.Contains(supported, arch) {
+		return nil
+	}
+	return fmt.Errorf("unsupported architecture %q, must be one of %s", arch, strings.Join(supported, ", "))
+}
+
+// archNodeSelector returns the nodeSelector pinning a runner to arch, nil for any node
+// (pure function)
+func archNodeSelector(arch string) map[string]string {
+	if arch == "" {
+		return nil
+	}
+	return map[string]string{corev1.LabelArchStable: arch}
+}
+
+// podArch returns the architecture a runner pod is pinned to, empty if none (pure function)
+func podArch(pod *corev1.Pod) string {
+	return pod.Spec.NodeSelector[corev1.LabelArchStable]
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestValidateArch(t *testing.T) {
+	supported := []string{"amd64", "arm64"}
+	tests := []struct {
+		arch      string
+		expectErr bool
+	}{
+		{"", false},
+		{"amd64", false},
+		{"arm64", false},
+		{"s390x", true},
+		{"ARM64", true},
+		{"aarch64", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arch, func(t *testing.T) {
+			err := validateArch(tt.arch, supported)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error: %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestPodCreationRequestToPodSpecArch(t *testing.T) {
+	config := DefaultKubernetesConfig()
+
+	pod := buildPodCreationRequest(t, &Runner{ID: "runner-1", Arch: "arm64"}, config).ToPodSpec()
+	if len(pod.Spec.NodeSelector) != 1 || pod.Spec.NodeSelector[corev1.LabelArchStable] != "arm64" {
+		t.Errorf("Expected a nodeSelector on %s=arm64, got %v", corev1.LabelArchStable, pod.Spec.NodeSelector)
+	}
+	if runner := PodToRunner(pod); runner.Arch != "arm64" {
+		t.Errorf("Expected the architecture read back from the pod, got '%s'", runner.Arch)
+	}
+	if clone := cloneRunnerRequest(pod, "copy", config); clone.Arch != "arm64" {
+		t.Errorf("Expected a clone on the same architecture, got '%s'", clone.Arch)
+	}
+
+	pod = buildPodCreationRequest(t, &Runner{ID: "runner-2"}, config).ToPodSpec()
+	if pod.Spec.NodeSelector != nil {
+		t.Errorf("Expected no nodeSelector for a runner without architecture, got %v", pod.Spec.NodeSelector)
+	}
+}
+
+func TestCreateRunnerArch(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.k8sClient.Config().SupportedArchs = []string{"amd64"}
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Arch: "arm64"}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for an unsupported architecture, got %v", err)
+	}
+
+	pinned, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Arch: "amd64"})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if pinned.Arch != "amd64" {
+		t.Errorf("Expected the runner pinned to amd64, got '%s'", pinned.Arch)
+	}
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{}); err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	runners, total, err := svc.ListRunners(ctx, &ListOptions{Arch: "amd64"})
+	if err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+	if total != 1 || runners[0].ID != pinned.ID {
+		t.Errorf("Expected only %s to be listed, got %d runners", pinned.ID, total)
+	}
+}
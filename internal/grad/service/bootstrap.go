@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// bootstrapOutputTailBytes is how much bootstrap output is kept in the status detail on failure
+const bootstrapOutputTailBytes = 2048
+
+// commandExecFunc executes a command in a runner, streaming output to the channels.
+// Implementations must close both channels before returning.
+type commandExecFunc func(ctx context.Context, runnerID, command string, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error)
+
+// bootstrapResult holds the outcome of a bootstrap command
+type bootstrapResult struct {
+	ExitCode   int32
+	Err        error
+	Output     []byte
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Succeeded reports whether the bootstrap command exited 0
+func (r *bootstrapResult) Succeeded() bool {
+	return r.Err == nil && r.ExitCode == 0
+}
+
+// runBootstrapCommand executes the bootstrap command and collects its combined output
+func runBootstrapCommand(ctx context.Context, exec commandExecFunc, runnerID, command string) *bootstrapResult {
+	stdoutCh := make(chan []byte, 100)
+	stderrCh := make(chan []byte, 100)
+
+	// Collect stdout and stderr in arrival order until both channels are closed
+	outputCh := make(chan []byte, 1)
+	go func() {
+		var output []byte
+		for stdoutCh != nil || stderrCh != nil {
+			select {
+			case data, ok := <-stdoutCh:
+				if !ok {
+					stdoutCh = nil
+					continue
+				}
+				output = append(output, data...)
+			case data, ok := <-stderrCh:
+				if !ok {
+					stderrCh = nil
+					continue
+				}
+				output = append(output, data...)
+			}
+		}
+		outputCh <- output
+	}()
+
+	result := &bootstrapResult{StartedAt: time.Now()}
+	status, err := exec(ctx, runnerID, command, stdoutCh, stderrCh)
+	result.ExitCode, result.Err = status.Code, err
+	if err == nil && status.Reason != ExitReasonExited {
+		result.Err = errors.New(status.String())
+	}
+	result.Output = <-outputCh
+	result.FinishedAt = time.Now()
+
+	return result
+}
+
+// bootstrapAnnotations returns the pod annotations recording a bootstrap result (pure function)
+func bootstrapAnnotations(result *bootstrapResult) map[string]string {
+	finishedAt := result.FinishedAt.UTC().Format(time.RFC3339)
+	if result.Succeeded() {
+		return map[string]string{
+			RunnerBootstrapStatusAnnotation:   BootstrapStatusSucceeded,
+			RunnerBootstrapFinishedAnnotation: finishedAt,
+			RunnerStatusDetailAnnotation:      "",
+		}
+	}
+
+	detail := fmt.Sprintf("bootstrap command failed with exit code %d", result.ExitCode)
+	if result.Err != nil {
+		detail = fmt.Sprintf("bootstrap command failed: %v", result.Err)
+	}
+	if tail := outputTail(result.Output, bootstrapOutputTailBytes); tail != "" {
+		detail += "\n" + tail
+	}
+
+	return map[string]string{
+		RunnerBootstrapStatusAnnotation:   BootstrapStatusFailed,
+		RunnerBootstrapFinishedAnnotation: finishedAt,
+		RunnerStatusDetailAnnotation:      detail,
+	}
+}
+
+// toExecutionRecord converts a bootstrap result into an execution history entry
+func (r *bootstrapResult) toExecutionRecord(command string) ExecutionRecord {
+	record := ExecutionRecord{
+		Command:    command,
+		ExitCode:   r.ExitCode,
+		Output:     string(r.Output),
+		Bootstrap:  true,
+		StartedAt:  r.StartedAt,
+		FinishedAt: r.FinishedAt,
+	}
+	if r.Err != nil {
+		record.Error = r.Err.Error()
+	}
+	return record
+}
+
+// outputTail returns at most maxBytes from the end of the output
+func outputTail(output []byte, maxBytes int) string {
+	if len(output) > maxBytes {
+		output = output[len(output)-maxBytes:]
+	}
+	return string(output)
+}
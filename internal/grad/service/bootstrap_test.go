@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// mockExec returns a commandExecFunc that emits the given output and exit code
+func mockExec(stdout, stderr string, exitCode int32, err error) commandExecFunc {
+	return func(ctx context.Context, runnerID, command string, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+		defer close(stdoutCh)
+		defer close(stderrCh)
+		if stdout != "" {
+			stdoutCh <- []byte(stdout)
+		}
+		if stderr != "" {
+			stderrCh <- []byte(stderr)
+		}
+		return ExitStatus{Code: exitCode, Reason: ExitReasonExited}, err
+	}
+}
+
+func TestRunBootstrapCommand(t *testing.T) {
+	tests := []struct {
+		name            string
+		exec            commandExecFunc
+		expectSucceeded bool
+		expectStatus    string
+		expectDetail    string
+	}{
+		{
+			name:            "Successful bootstrap",
+			exec:            mockExec("installed\n", "", 0, nil),
+			expectSucceeded: true,
+			expectStatus:    BootstrapStatusSucceeded,
+			expectDetail:    "",
+		},
+		{
+			name:            "Non-zero exit code",
+			exec:            mockExec("", "ERROR: no such file requirements.txt\n", 2, nil),
+			expectSucceeded: false,
+			expectStatus:    BootstrapStatusFailed,
+			expectDetail:    "ERROR: no such file requirements.txt",
+		},
+		{
+			name: "Timed out",
+			exec: func(ctx context.Context, runnerID, command string, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+				close(stdoutCh)
+				close(stderrCh)
+				return ExitStatus{Code: ExitCodeTimeout, Reason: ExitReasonTimeout}, nil
+			},
+			expectSucceeded: false,
+			expectStatus:    BootstrapStatusFailed,
+			expectDetail:    "bootstrap command failed: timed out",
+		},
+		{
+			name:            "Exec error",
+			exec:            mockExec("partial output\n", "", 1, errors.New("stream reset")),
+			expectSucceeded: false,
+			expectStatus:    BootstrapStatusFailed,
+			expectDetail:    "stream reset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runBootstrapCommand(context.Background(), tt.exec, "runner-1", "pip install -r requirements.txt")
+
+			if result.Succeeded() != tt.expectSucceeded {
+				t.Errorf("Expected succeeded=%v, got %v (exit=%d, err=%v)", tt.expectSucceeded, result.Succeeded(), result.ExitCode, result.Err)
+			}
+
+			annotations := bootstrapAnnotations(result)
+			if annotations[RunnerBootstrapStatusAnnotation] != tt.expectStatus {
+				t.Errorf("Expected bootstrap status '%s', got '%s'", tt.expectStatus, annotations[RunnerBootstrapStatusAnnotation])
+			}
+			if annotations[RunnerBootstrapFinishedAnnotation] != result.FinishedAt.UTC().Format(time.RFC3339) {
+				t.Errorf("Expected the finish time to be recorded, got '%s'", annotations[RunnerBootstrapFinishedAnnotation])
+			}
+
+			detail := annotations[RunnerStatusDetailAnnotation]
+			if tt.expectDetail == "" && detail != "" {
+				t.Errorf("Expected empty status detail, got '%s'", detail)
+			}
+			if !strings.Contains(detail, tt.expectDetail) {
+				t.Errorf("Expected status detail to contain '%s', got '%s'", tt.expectDetail, detail)
+			}
+		})
+	}
+}
+
+func TestRunBootstrapCommandCollectsOutput(t *testing.T) {
+	result := runBootstrapCommand(context.Background(), mockExec("out\n", "err\n", 0, nil), "runner-1", "true")
+
+	output := string(result.Output)
+	if !strings.Contains(output, "out\n") || !strings.Contains(output, "err\n") {
+		t.Errorf("Expected combined stdout and stderr, got '%s'", output)
+	}
+
+	record := result.toExecutionRecord("true")
+	if !record.Bootstrap {
+		t.Error("Expected execution record to be marked as bootstrap")
+	}
+	if record.Output != output {
+		t.Errorf("Expected record output '%s', got '%s'", output, record.Output)
+	}
+}
+
+func TestBootstrapAnnotationsTruncatesOutput(t *testing.T) {
+	output := strings.Repeat("a", bootstrapOutputTailBytes) + "TAIL"
+	result := &bootstrapResult{ExitCode: 1, Output: []byte(output)}
+
+	detail := bootstrapAnnotations(result)[RunnerStatusDetailAnnotation]
+	if !strings.HasSuffix(detail, "TAIL") {
+		t.Errorf("Expected detail to keep the end of the output")
+	}
+	if len(detail) > bootstrapOutputTailBytes+100 {
+		t.Errorf("Expected detail to be truncated, got %d bytes", len(detail))
+	}
+}
+
+func TestApplyBootstrapStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          RunnerStatus
+		bootstrapStatus string
+		expected        RunnerStatus
+	}{
+		{"No bootstrap", RunnerStatusRunning, "", RunnerStatusRunning},
+		{"Pending while creating", RunnerStatusCreating, BootstrapStatusPending, RunnerStatusCreating},
+		{"Pending once ready", RunnerStatusRunning, BootstrapStatusPending, RunnerStatusBootstrapping},
+		{"Running once ready", RunnerStatusRunning, BootstrapStatusRunning, RunnerStatusBootstrapping},
+		{"Succeeded", RunnerStatusRunning, BootstrapStatusSucceeded, RunnerStatusRunning},
+		{"Failed", RunnerStatusRunning, BootstrapStatusFailed, RunnerStatusError},
+		{"Pod failed", RunnerStatusError, BootstrapStatusSucceeded, RunnerStatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyBootstrapStatus(tt.status, tt.bootstrapStatus); got != tt.expected {
+				t.Errorf("Expected status %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPodToRunnerBootstrapStatus(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				RunnerIDAnnotation:               "runner-1",
+				RunnerBootstrapCommandAnnotation: "pip install -r requirements.txt",
+				RunnerBootstrapStatusAnnotation:  BootstrapStatusFailed,
+				RunnerStatusDetailAnnotation:     "bootstrap command failed with exit code 1",
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+
+	runner := PodToRunner(pod)
+	if runner.Status != RunnerStatusError {
+		t.Errorf("Expected status error, got %v", runner.Status)
+	}
+	if runner.StatusDetail != "bootstrap command failed with exit code 1" {
+		t.Errorf("Unexpected status detail '%s'", runner.StatusDetail)
+	}
+	if runner.BootstrapCommand != "pip install -r requirements.txt" {
+		t.Errorf("Unexpected bootstrap command '%s'", runner.BootstrapCommand)
+	}
+	if runner.ErrorReason != RunnerErrorBootstrapFailed || runner.ErrorMessage != "bootstrap command failed with exit code 1" {
+		t.Errorf("Expected a bootstrap_failed error, got %q: %q", runner.ErrorReason, runner.ErrorMessage)
+	}
+
+	pod.Annotations[RunnerBootstrapStatusAnnotation] = BootstrapStatusRunning
+	if runner := PodToRunner(pod); runner.Status != RunnerStatusBootstrapping || runner.ErrorReason != "" {
+		t.Errorf("Expected status bootstrapping without an error, got %v and %q", runner.Status, runner.ErrorReason)
+	}
+}
+
+func TestUpdateRunnerAnnotations(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "grad-runner-runner-1",
+			Namespace: "default",
+			Annotations: map[string]string{
+				RunnerIDAnnotation:              "runner-1",
+				RunnerBootstrapStatusAnnotation: BootstrapStatusPending,
+			},
+		},
+	}
+
+	k8sClient := &KubernetesClient{
+		clientset: fake.NewSimpleClientset(pod),
+		config:    DefaultKubernetesConfig(),
+	}
+
+	ctx := context.Background()
+	if err := k8sClient.UpdateRunnerAnnotations(ctx, "runner-1", map[string]string{
+		RunnerBootstrapStatusAnnotation: BootstrapStatusSucceeded,
+	}); err != nil {
+		t.Fatalf("UpdateRunnerAnnotations failed: %v", err)
+	}
+
+	updated, err := k8sClient.GetRunnerPod(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	if updated.Annotations[RunnerBootstrapStatusAnnotation] != BootstrapStatusSucceeded {
+		t.Errorf("Expected bootstrap status to be updated, got '%s'", updated.Annotations[RunnerBootstrapStatusAnnotation])
+	}
+	if updated.Annotations[RunnerIDAnnotation] != "runner-1" {
+		t.Error("Expected existing annotations to be preserved")
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// Version, Commit and BuildDate describe the grad build. They are set at build time, e.g.
+// -ldflags "-X github.com/strrl/gra/internal/grad/service.Version=v1.2.0"; see the Makefile.
+var (
+	Version   string
+	Commit    string
+	BuildDate string
+)
+
+// unknownBuildValue stands in for build details that were neither set nor embedded by Go
+const unknownBuildValue = "unknown"
+
+// BuildInfo describes the grad binary
+type BuildInfo struct {
+	Version   string
+	Commit    string
+	BuildDate string
+	GoVersion string
+}
+
+// ReadBuildInfo returns the build details set with ldflags, falling back to the module
+// version and VCS details Go embeds in the binary
+func ReadBuildInfo() BuildInfo {
+	info, _ := debug.ReadBuildInfo()
+	return buildInfoFrom(Version, Commit, BuildDate, info)
+}
+
+// buildInfoFrom fills in what version, commit and buildDate leave empty from info, which
+// may be nil (pure function)
+func buildInfoFrom(version, commit, buildDate string, info *debug.BuildInfo) BuildInfo {
+	build := BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if info != nil {
+		if info.GoVersion != "" {
+			build.GoVersion = info.GoVersion
+		}
+		if build.Version == "" && info.Main.Version != "(devel)" {
+			build.Version = info.Main.Version
+		}
+
+		settings := make(map[string]string)
+		for _, setting := range info.Settings {
+			settings[setting.Key] = setting.Value
+		}
+		if build.Commit == "" && settings["vcs.revision"] != "" {
+			build.Commit = settings["vcs.revision"]
+			if settings["vcs.modified"] == "true" {
+				build.Commit += "-dirty"
+			}
+		}
+		if build.BuildDate == "" {
+			build.BuildDate = settings["vcs.time"]
+		}
+	}
+
+	for _, value := range []*string{&build.Version, &build.Commit, &build.BuildDate} {
+		if *value == "" {
+			*value = unknownBuildValue
+		}
+	}
+	return build
+}
+
+// ToProto converts the build details to their protobuf form
+func (b BuildInfo) ToProto() *gradv1.BuildInfo {
+	return &gradv1.BuildInfo{
+		Version:   b.Version,
+		Commit:    b.Commit,
+		BuildDate: b.BuildDate,
+		GoVersion: b.GoVersion,
+	}
+}
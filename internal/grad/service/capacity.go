@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Capacity check modes
+const (
+	// CapacityCheckEnforce rejects runners that no node has room for
+	CapacityCheckEnforce = "enforce"
+	// CapacityCheckWarn creates them anyway, with a warning, for clusters whose autoscaler
+	// adds nodes on demand
+	CapacityCheckWarn = "warn"
+	// CapacityCheckOff skips the check
+	CapacityCheckOff = "off"
+
+	// DefaultCapacityCacheTTL is how long the free resources of the nodes are reused
+	DefaultCapacityCacheTTL = 30 * time.Second
+)
+
+// CapacityConfig controls checking that some node has room for a runner before creating it,
+// so a runner that could never be scheduled fails right away instead of at the provision timeout
+type CapacityConfig struct {
+	// Mode is CapacityCheckEnforce, CapacityCheckWarn or CapacityCheckOff
+	Mode string
+	// CacheTTL is how long the free resources of the nodes are reused; zero lists them every time
+	CacheTTL time.Duration
+}
+
+// DefaultCapacityConfig returns the default capacity check: enforced, with nodes listed at most
+// every DefaultCapacityCacheTTL
+func DefaultCapacityConfig() *CapacityConfig {
+	return &CapacityConfig{
+		Mode:     CapacityCheckEnforce,
+		CacheTTL: DefaultCapacityCacheTTL,
+	}
+}
+
+// validateCapacityCheckMode checks that mode is one of the capacity check modes (pure function)
+func validateCapacityCheckMode(mode string) error {
+	switch mode {
+	case CapacityCheckEnforce, CapacityCheckWarn, CapacityCheckOff:
+		return nil
+	}
+	return fmt.Errorf("must be %s, %s or %s", CapacityCheckEnforce, CapacityCheckWarn, CapacityCheckOff)
+}
+
+// nodeHeadroom is what a schedulable node has left for new pods: its allocatable resources minus
+// the requests of the pods bound to it
+type nodeHeadroom struct {
+	Name          string
+	Labels        map[string]string
+	CPUMillicores int64
+	MemoryBytes   int64
+}
+
+// ListNodes lists the nodes of the cluster
+func (k *KubernetesClient) ListNodes(ctx context.Context) (*corev1.NodeList, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	nodes, err := k.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	return nodes, nil
+}
+
+// ListActivePods lists the pods of all namespaces that haven't terminated, which are the ones
+// taking up node resources
+func (k *KubernetesClient) ListActivePods(ctx context.Context) (*corev1.PodList, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	pods, err := k.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=" + string(corev1.PodSucceeded) + ",status.phase!=" + string(corev1.PodFailed),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return pods, nil
+}
+
+// clusterHeadroom returns the headroom of every schedulable node (pure function)
+func clusterHeadroom(nodes []corev1.Node, pods []corev1.Pod) []nodeHeadroom {
+	type requests struct{ cpu, memory int64 }
+	requested := make(map[string]requests)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		cpu, memory := podRequests(pod)
+		total := requested[pod.Spec.NodeName]
+		requested[pod.Spec.NodeName] = requests{total.cpu + cpu, total.memory + memory}
+	}
+
+	headroom := make([]nodeHeadroom, 0, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Spec.Unschedulable {
+			continue
+		}
+		used := requested[node.Name]
+		headroom = append(headroom, nodeHeadroom{
+			Name:          node.Name,
+			Labels:        node.Labels,
+			CPUMillicores: node.Status.Allocatable.Cpu().MilliValue() - used.cpu,
+			MemoryBytes:   node.Status.Allocatable.Memory().Value() - used.memory,
+		})
+	}
+	return headroom
+}
+
+// podRequests returns the CPU and memory a pod requests: its containers together, or its largest
+// init container if that asks for more, as the scheduler counts them (pure function)
+func podRequests(pod *corev1.Pod) (cpuMillicores, memoryBytes int64) {
+	for _, container := range pod.Spec.Containers {
+		cpuMillicores += container.Resources.Requests.Cpu().MilliValue()
+		memoryBytes += container.Resources.Requests.Memory().Value()
+	}
+	for _, container := range pod.Spec.InitContainers {
+		cpuMillicores = max(cpuMillicores, container.Resources.Requests.Cpu().MilliValue())
+		memoryBytes = max(memoryBytes, container.Resources.Requests.Memory().Value())
+	}
+	return cpuMillicores, memoryBytes
+}
+
+// capacityShortfall explains why no node matching nodeSelector has room for a pod requesting
+// cpuMillicores and memoryBytes, or returns nil if one has. Without any nodes there is nothing
+// to go by, so that is not a shortfall. (pure function)
+func capacityShortfall(headroom []nodeHeadroom, nodeSelector map[string]string, cpuMillicores, memoryBytes int64) error {
+	if len(headroom) == 0 {
+		return nil
+	}
+
+	selector := labels.SelectorFromSet(nodeSelector)
+	var candidates int
+	var maxCPU, maxMemory int64
+	for _, node := range headroom {
+		if !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		if node.CPUMillicores >= cpuMillicores && node.MemoryBytes >= memoryBytes {
+			return nil
+		}
+		candidates++
+		maxCPU = max(maxCPU, node.CPUMillicores)
+		maxMemory = max(maxMemory, node.MemoryBytes)
+	}
+
+	need := fmt.Sprintf("%s CPU and %s memory",
+		resource.NewMilliQuantity(cpuMillicores, resource.DecimalSI), resource.NewQuantity(memoryBytes, resource.BinarySI))
+	if candidates == 0 {
+		return fmt.Errorf("no schedulable node matches %s for a runner needing %s", selector, need)
+	}
+	return fmt.Errorf("no node has room for a runner needing %s; the most free on any node is %s CPU and %s memory",
+		need, resource.NewMilliQuantity(max(maxCPU, 0), resource.DecimalSI), resource.NewQuantity(max(maxMemory, 0), resource.BinarySI))
+}
+
+// capacityCache holds the headroom of the nodes as last listed
+type capacityCache struct {
+	mu        sync.Mutex
+	fetchedAt time.Time
+	headroom  []nodeHeadroom
+}
+
+// clusterHeadroom returns the headroom of the nodes, listing them and their pods again once the
+// cached headroom is older than the configured TTL
+func (s *runnerService) clusterHeadroom(ctx context.Context, ttl time.Duration) ([]nodeHeadroom, error) {
+	s.capacity.mu.Lock()
+	defer s.capacity.mu.Unlock()
+
+	now := s.now()
+	if !s.capacity.fetchedAt.IsZero() && now.Sub(s.capacity.fetchedAt) < ttl {
+		return s.capacity.headroom, nil
+	}
+
+	nodes, err := s.k8sClient.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := s.k8sClient.ListActivePods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.capacity.headroom = clusterHeadroom(nodes.Items, pods.Items)
+	s.capacity.fetchedAt = now
+	return s.capacity.headroom, nil
+}
+
+// checkCapacity checks that some node matching nodeSelector has room for a runner with
+// workspace. It fails with ErrFailedPrecondition if none has, or only returns a warning in
+// CapacityCheckWarn mode. If the nodes can't be listed, e.g. without permission to, the runner
+// is created unchecked.
+func (s *runnerService) checkCapacity(ctx context.Context, nodeSelector map[string]string, workspace *WorkspaceConfig) ([]string, error) {
+	config := s.k8sClient.Config().capacity()
+	if config.Mode == CapacityCheckOff {
+		return nil, nil
+	}
+
+	headroom, err := s.clusterHeadroom(ctx, config.CacheTTL)
+	if err != nil {
+		slog.Warn("Skipping capacity check, failed to read node resources", "error", err)
+		return nil, nil
+	}
+
+	// Invalid resources fail building the pod, with an error saying so
+	cpu, memory, err := s.k8sClient.Config().runnerRequests(workspace)
+	if err != nil {
+		return nil, nil
+	}
+	shortfall := capacityShortfall(headroom, nodeSelector, cpu, memory)
+	if shortfall == nil {
+		return nil, nil
+	}
+	if config.Mode == CapacityCheckWarn {
+		return []string{shortfall.Error() + "; the runner stays pending until one has"}, nil
+	}
+	return nil, fmt.Errorf("%w: %w", ErrFailedPrecondition, shortfall)
+}
+
+// runnerRequests returns the CPU and memory a runner's pod requests: the runner container's,
+// plus the s3fs sidecar's if it mounts an S3 workspace
+func (c *KubernetesConfig) runnerRequests(workspace *WorkspaceConfig) (cpuMillicores, memoryBytes int64, err error) {
+	if c.DisabledFeatures.Disabled(FeatureS3Workspace) {
+		workspace = nil
+	}
+	req := &PodCreationRequest{Workspace: workspace}
+	if err := req.setResources(workspace, c); err != nil {
+		return 0, 0, err
+	}
+	cpuMillicores, memoryBytes = req.CPURequest.MilliValue(), req.MemoryRequest.Value()
+	if req.hasS3Workspace() {
+		cpuMillicores += req.S3FSCPURequest.MilliValue()
+		memoryBytes += req.S3FSMemoryRequest.Value()
+	}
+	return cpuMillicores, memoryBytes, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testNode returns a node of arch with cpu and memory allocatable
+func testNode(name, arch, cpu, memory string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelArchStable: arch}},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}},
+	}
+}
+
+// testBoundPod returns a running pod on node requesting cpu and memory
+func testBoundPod(name, node, cpu, memory string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system"},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestClusterHeadroom(t *testing.T) {
+	cordoned := testNode("node-c", "amd64", "8", "16Gi")
+	cordoned.Spec.Unschedulable = true
+	finished := testBoundPod("finished", "node-a", "2", "2Gi")
+	finished.Status.Phase = corev1.PodSucceeded
+
+	headroom := clusterHeadroom(
+		[]corev1.Node{*testNode("node-a", "amd64", "4", "8Gi"), *testNode("node-b", "arm64", "2", "4Gi"), *cordoned},
+		[]corev1.Pod{*testBoundPod("app", "node-a", "1500m", "1Gi"), *finished, *testBoundPod("pending", "", "4", "4Gi")},
+	)
+
+	if len(headroom) != 2 {
+		t.Fatalf("Expected the headroom of the 2 schedulable nodes, got %v", headroom)
+	}
+	if headroom[0].Name != "node-a" || headroom[0].CPUMillicores != 2500 || headroom[0].MemoryBytes != 7<<30 {
+		t.Errorf("Expected node-a to have 2500m CPU and 7Gi memory free, got %+v", headroom[0])
+	}
+	if headroom[1].Name != "node-b" || headroom[1].CPUMillicores != 2000 || headroom[1].MemoryBytes != 4<<30 {
+		t.Errorf("Expected node-b to have all of 2 CPU and 4Gi memory free, got %+v", headroom[1])
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	pod := testBoundPod("app", "node-a", "500m", "1Gi")
+	pod.Spec.Containers = append(pod.Spec.Containers, testBoundPod("sidecar", "node-a", "100m", "64Mi").Spec.Containers...)
+	pod.Spec.InitContainers = testBoundPod("init", "node-a", "1", "256Mi").Spec.Containers
+
+	cpu, memory := podRequests(pod)
+	if cpu != 1000 {
+		t.Errorf("Expected the larger init container CPU of 1000m, got %dm", cpu)
+	}
+	if memory != 1<<30+64<<20 {
+		t.Errorf("Expected the containers' memory together, got %d", memory)
+	}
+}
+
+func TestCapacityShortfall(t *testing.T) {
+	headroom := []nodeHeadroom{
+		{Name: "small", Labels: map[string]string{corev1.LabelArchStable: "amd64"}, CPUMillicores: 3000, MemoryBytes: 8 << 30},
+		{Name: "big-memory", Labels: map[string]string{corev1.LabelArchStable: "arm64"}, CPUMillicores: 1000, MemoryBytes: 32 << 30},
+	}
+	tests := []struct {
+		name         string
+		headroom     []nodeHeadroom
+		nodeSelector map[string]string
+		cpu          int64
+		memory       int64
+		expectedErr  string
+	}{
+		{"Fits", headroom, nil, 2000, 2 << 30, ""},
+		{"Fits on the selected node", headroom, archNodeSelector("arm64"), 1000, 16 << 30, ""},
+		{"Never fits", headroom, nil, 8000, 8 << 30, "the most free on any node is 3 CPU and 32Gi memory"},
+		{"Too little CPU on the selected node", headroom, archNodeSelector("arm64"), 2000, 2 << 30, "the most free on any node is 1 CPU and 32Gi memory"},
+		{"No selected node", headroom, archNodeSelector("s390x"), 2000, 2 << 30, "no schedulable node matches kubernetes.io/arch=s390x"},
+		{"No nodes", nil, nil, 8000, 8 << 30, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := capacityShortfall(tt.headroom, tt.nodeSelector, tt.cpu, tt.memory)
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Expected the runner to fit, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Expected an error containing '%s', got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCreateRunnerCapacity(t *testing.T) {
+	ctx := context.Background()
+	// The runner container requests exactly the 2 CPUs left on node-a; the s3fs sidecar's 50m don't fit
+	exactFit := []runtime.Object{testNode("node-a", "amd64", "4", "8Gi"), testBoundPod("app", "node-a", "2", "1Gi")}
+	workspace := &WorkspaceConfig{Bucket: "datasets"}
+	tests := []struct {
+		name        string
+		mode        string
+		objects     []runtime.Object
+		workspace   *WorkspaceConfig
+		expectedErr error
+		warned      bool
+	}{
+		{"Fits", CapacityCheckEnforce, []runtime.Object{testNode("node-a", "amd64", "4", "8Gi"), testBoundPod("app", "node-a", "1", "1Gi")}, nil, nil, false},
+		{"Never fits", CapacityCheckEnforce, []runtime.Object{testNode("node-a", "amd64", "4", "8Gi"), testBoundPod("app", "node-a", "3", "1Gi")}, nil, ErrFailedPrecondition, false},
+		{"Never fits, warning only", CapacityCheckWarn, []runtime.Object{testNode("node-a", "amd64", "1", "8Gi")}, nil, nil, true},
+		{"Never fits, check disabled", CapacityCheckOff, []runtime.Object{testNode("node-a", "amd64", "1", "8Gi")}, nil, nil, false},
+		{"Fits without workspace", CapacityCheckEnforce, exactFit, nil, nil, false},
+		{"Never fits with the s3fs sidecar", CapacityCheckEnforce, exactFit, workspace, ErrFailedPrecondition, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestRunnerService()
+			kubernetesClient(svc).clientset = fake.NewSimpleClientset(tt.objects...)
+			svc.k8sClient.Config().Capacity = &CapacityConfig{Mode: tt.mode, CacheTTL: time.Minute}
+
+			runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Workspace: tt.workspace})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), "the most free on any node is") {
+					t.Errorf("Expected the largest headroom in the error, got %v", err)
+				}
+				return
+			}
+			if warned := len(runner.Warnings) > 0; warned != tt.warned {
+				t.Errorf("Expected warnings: %v, got %v", tt.warned, runner.Warnings)
+			}
+		})
+	}
+}
+
+func TestClusterHeadroomCache(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	clientset := fake.NewSimpleClientset(testNode("node-a", "amd64", "4", "8Gi"))
+	kubernetesClient(svc).clientset = clientset
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	listNodes := func() int {
+		count := 0
+		for _, action := range clientset.Actions() {
+			if action.GetVerb() == "list" && action.GetResource().Resource == "nodes" {
+				count++
+			}
+		}
+		return count
+	}
+
+	for range 2 {
+		if _, err := svc.clusterHeadroom(ctx, time.Minute); err != nil {
+			t.Fatalf("clusterHeadroom failed: %v", err)
+		}
+	}
+	if got := listNodes(); got != 1 {
+		t.Errorf("Expected the nodes to be listed once within the TTL, got %d", got)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := svc.clusterHeadroom(ctx, time.Minute); err != nil {
+		t.Fatalf("clusterHeadroom failed: %v", err)
+	}
+	if got := listNodes(); got != 2 {
+		t.Errorf("Expected the nodes to be listed again after the TTL, got %d", got)
+	}
+}
@@ -2,43 +2,117 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
 	"time"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
+// errRunnerNotIdle means a runner hasn't been idle for the timeout of its group yet
+var errRunnerNotIdle = errors.New("runner has not reached its group's idle timeout")
+
 // CleanupService manages inactive runner cleanup
 type CleanupService struct {
 	runnerService   RunnerService
 	activityTracker *ActivityTracker
+	// events records why idle runners are deleted; nil records nothing
+	events RunnerEventRecorder
+
+	// timeoutsMu guards cleanupInterval, inactiveTimeout and groupTimeouts, which
+	// SetIdleTimeouts changes at runtime
+	timeoutsMu      sync.RWMutex
 	cleanupInterval time.Duration
 	inactiveTimeout time.Duration
-	stopCh          chan struct{}
+	// groupTimeouts replace inactiveTimeout for runners in matching groups
+	groupTimeouts GroupIdleTimeouts
+	// intervalChanged wakes Start to reset its ticker to a new cleanupInterval
+	intervalChanged chan struct{}
+
+	stopCh chan struct{}
+	// warnedReservations holds the reservation end each runner was last warned about
+	warnedReservations map[string]time.Time
 }
 
 // NewCleanupService creates a new cleanup service
-func NewCleanupService(runnerService RunnerService, activityTracker *ActivityTracker) *CleanupService {
+func NewCleanupService(runnerService RunnerService, activityTracker *ActivityTracker, events RunnerEventRecorder, durations *Durations, groupTimeouts GroupIdleTimeouts) *CleanupService {
 	return &CleanupService{
 		runnerService:   runnerService,
 		activityTracker: activityTracker,
-		cleanupInterval: 1 * time.Minute,  // Check every 1 minute
-		inactiveTimeout: 5 * time.Minute,  // Delete runners inactive for >5 minutes
+		events:          events,
+		cleanupInterval: durations.CleanupInterval,
+		inactiveTimeout: durations.IdleTimeout,
+		groupTimeouts:   groupTimeouts,
+		intervalChanged: make(chan struct{}, 1),
 		stopCh:          make(chan struct{}),
+
+		warnedReservations: make(map[string]time.Time),
 	}
 }
 
+// SetIdleTimeouts changes how often idle runners are looked for and how long they may be idle,
+// taking CleanupInterval and IdleTimeout from durations. The next cleanup is a full new
+// interval away if the interval changed.
+func (cs *CleanupService) SetIdleTimeouts(durations *Durations, groupTimeouts GroupIdleTimeouts) {
+	cs.timeoutsMu.Lock()
+	intervalChanged := cs.cleanupInterval != durations.CleanupInterval
+	cs.cleanupInterval = durations.CleanupInterval
+	cs.inactiveTimeout = durations.IdleTimeout
+	cs.groupTimeouts = groupTimeouts
+	cs.timeoutsMu.Unlock()
+
+	if intervalChanged {
+		select {
+		case cs.intervalChanged <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// timeouts returns the cleanup interval, the default idle timeout and the group idle timeouts
+func (cs *CleanupService) timeouts() (time.Duration, time.Duration, GroupIdleTimeouts) {
+	cs.timeoutsMu.RLock()
+	defer cs.timeoutsMu.RUnlock()
+	return cs.cleanupInterval, cs.inactiveTimeout, cs.groupTimeouts
+}
+
+// idleTimeout returns how long a runner of group may be idle before it is deleted
+func (cs *CleanupService) idleTimeout(group string) time.Duration {
+	_, inactiveTimeout, groupTimeouts := cs.timeouts()
+	if timeout, ok := groupTimeouts.For(group); ok {
+		return timeout
+	}
+	return inactiveTimeout
+}
+
+// shortestIdleTimeout returns the smallest idle timeout of any runner
+func (cs *CleanupService) shortestIdleTimeout() time.Duration {
+	_, shortest, groupTimeouts := cs.timeouts()
+	for _, timeout := range groupTimeouts {
+		shortest = min(shortest, timeout.Timeout)
+	}
+	return shortest
+}
+
 // Start begins the cleanup background task
 func (cs *CleanupService) Start(ctx context.Context) {
-	ticker := time.NewTicker(cs.cleanupInterval)
+	cleanupInterval, inactiveTimeout, groupTimeouts := cs.timeouts()
+	ticker := time.NewTicker(cleanupInterval)
 	defer ticker.Stop()
 
 	slog.Info("Starting cleanup service", 
-		"cleanup_interval", cs.cleanupInterval.String(), 
-		"inactive_timeout", cs.inactiveTimeout.String())
+		"cleanup_interval", cleanupInterval.String(), 
+		"inactive_timeout", inactiveTimeout.String(),
+		"group_idle_timeouts", groupTimeouts.String())
 
 	for {
 		select {
 		case <-ticker.C:
 			cs.cleanupInactiveRunners(ctx)
+		case <-cs.intervalChanged:
+			cleanupInterval, _, _ := cs.timeouts()
+			ticker.Reset(cleanupInterval)
 		case <-cs.stopCh:
 			slog.Info("Cleanup service stopped")
 			return
@@ -60,12 +134,13 @@ func (cs *CleanupService) cleanupInactiveRunners(ctx context.Context) {
 	allTracked := cs.activityTracker.GetAllTrackedRunners()
 	totalTrackedCount := len(allTracked)
 	
+	_, inactiveTimeout, _ := cs.timeouts()
 	slog.Info("Starting cleanup cycle", 
 		"total_tracked_runners", totalTrackedCount,
-		"inactive_timeout", cs.inactiveTimeout.String())
+		"inactive_timeout", inactiveTimeout.String())
 
-	// Get list of inactive runners
-	inactiveRunners := cs.activityTracker.GetInactiveRunners(cs.inactiveTimeout)
+	// Get list of inactive runners; those in groups with a longer timeout are skipped below
+	inactiveRunners := cs.activityTracker.GetInactiveRunners(cs.shortestIdleTimeout())
 	
 	if len(inactiveRunners) == 0 {
 		slog.Info("Cleanup cycle completed - no inactive runners found",
@@ -83,12 +158,19 @@ func (cs *CleanupService) cleanupInactiveRunners(ctx context.Context) {
 		successfulDeletes = 0
 		alreadyStopped    = 0
 		failedDeletes     = 0
+		notIdleYet        = 0
+		protected         = 0
 	)
 
 	// Delete each inactive runner
 	for _, runnerID := range inactiveRunners {
 		deleted, err := cs.deleteInactiveRunner(ctx, runnerID)
-		if err != nil {
+		if errors.Is(err, errRunnerNotIdle) {
+			notIdleYet++
+		} else if errors.Is(err, ErrRunnerProtected) {
+			// Still tracked, so the runner is cleaned up once it is unprotected
+			protected++
+		} else if err != nil {
 			failedDeletes++
 			slog.Error("Failed to delete inactive runner", 
 				"runner_id", runnerID, 
@@ -114,6 +196,8 @@ func (cs *CleanupService) cleanupInactiveRunners(ctx context.Context) {
 		"successful_deletes", successfulDeletes,
 		"already_stopped", alreadyStopped,
 		"failed_deletes", failedDeletes,
+		"not_idle_yet", notIdleYet,
+		"protected", protected,
 		"remaining_tracked_runners", remainingTracked)
 }
 
@@ -140,8 +224,10 @@ func (cs *CleanupService) deleteInactiveRunner(ctx context.Context, runnerID str
 		"status", runner.Status,
 		"created_at", runner.CreatedAt)
 
-	// Only delete running or creating runners - don't delete already stopped/error runners
-	if runner.Status == RunnerStatusStopped || runner.Status == RunnerStatusError {
+	// Only delete running or creating runners - don't delete already stopped/error runners.
+	// Auto-restart runners in error, e.g. spot runners whose node was reclaimed, come back, so
+	// they stay tracked and are cleaned up once idle like running ones.
+	if runner.Status == RunnerStatusStopped || (runner.Status == RunnerStatusError && !runner.AutoRestart) {
 		slog.Info("Skipping deletion of already stopped/error runner", 
 			"runner_id", runnerID, 
 			"status", runner.Status)
@@ -149,13 +235,33 @@ func (cs *CleanupService) deleteInactiveRunner(ctx context.Context, runnerID str
 		return false, nil
 	}
 
+	// Protected runners are never cleaned up, however long they are idle
+	if err := checkDeletable(runner, false); err != nil {
+		slog.Debug("Skipping deletion of protected runner", "runner_id", runnerID)
+		return false, err
+	}
+
+	// A reservation counts as activity until it ends. The runner may also be in a group with a
+	// longer timeout than the one it was found with.
+	cs.warnReservationEnding(ctx, runner)
+	idle := time.Since(idleSince(cs.activityTracker.GetLastActiveTime(runnerID), runner.ReservedUntil))
+	if timeout := cs.idleTimeout(runner.Group); idle <= timeout {
+		return false, errRunnerNotIdle
+	}
+	delete(cs.warnedReservations, runnerID)
+
+	if cs.events != nil {
+		cs.events.RecordRunnerEvent(ctx, runnerID, corev1.EventTypeNormal, EventReasonRunnerIdle,
+			"Deleting runner %s after %s without activity", runnerID, idle.Round(time.Second))
+	}
+
 	// Delete the runner
 	slog.Info("Deleting inactive runner", 
 		"runner_id", runnerID, 
 		"status", runner.Status,
 		"last_active", cs.activityTracker.GetLastActiveTime(runnerID))
 	
-	err = cs.runnerService.DeleteRunner(ctx, runnerID)
+	_, err = cs.runnerService.DeleteRunner(ctx, runnerID, false)
 	if err != nil {
 		slog.Error("Failed to delete runner", "runner_id", runnerID, "error", err)
 		return false, err
@@ -163,4 +269,20 @@ func (cs *CleanupService) deleteInactiveRunner(ctx context.Context, runnerID str
 
 	slog.Info("Successfully initiated deletion of inactive runner", "runner_id", runnerID)
 	return true, nil
-}
\ No newline at end of file
+}
+
+// warnReservationEnding records a warning event on an idle runner whose reservation ends within
+// ReservationWarningLead, once per reservation, since idle cleanup applies to it afterwards
+func (cs *CleanupService) warnReservationEnding(ctx context.Context, runner *Runner) {
+	if cs.events == nil || runner.ReservedUntil.IsZero() {
+		return
+	}
+	left := time.Until(runner.ReservedUntil)
+	if left <= 0 || left > ReservationWarningLead || cs.warnedReservations[runner.ID].Equal(runner.ReservedUntil) {
+		return
+	}
+	cs.warnedReservations[runner.ID] = runner.ReservedUntil
+	cs.events.RecordRunnerEvent(ctx, runner.ID, corev1.EventTypeWarning, EventReasonReservationEnding,
+		"Reservation of runner %s ends at %s; it is deleted after %s without activity from then",
+		runner.ID, runner.ReservedUntil.UTC().Format(time.RFC3339), cs.idleTimeout(runner.Group))
+}
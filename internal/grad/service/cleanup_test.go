@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 )
@@ -21,17 +22,50 @@ func newMockRunnerService() *mockRunnerService {
 	}
 }
 
+func (m *mockRunnerService) Start(ctx context.Context) {}
+
+func (m *mockRunnerService) Stop() {}
+
 func (m *mockRunnerService) CreateRunner(ctx context.Context, req *CreateRunnerRequest) (*Runner, error) {
 	return nil, nil // Not needed for cleanup tests
 }
 
-func (m *mockRunnerService) DeleteRunner(ctx context.Context, runnerID string) error {
+func (m *mockRunnerService) DeleteRunner(ctx context.Context, runnerID string, overrideProtection bool) (*RunnerCleanup, error) {
 	if m.shouldFailDelete {
-		return ErrKubernetesAPI
+		return nil, ErrKubernetesAPI
+	}
+	if runner, ok := m.runners[runnerID]; ok {
+		if err := checkDeletable(runner, overrideProtection); err != nil {
+			return nil, err
+		}
 	}
 	m.deletedRunners = append(m.deletedRunners, runnerID)
 	delete(m.runners, runnerID)
-	return nil
+	return &RunnerCleanup{}, nil
+}
+
+func (m *mockRunnerService) WaitForRunnerDeleted(ctx context.Context, runnerID string, timeout time.Duration) ([]RunnerResource, error) {
+	return nil, nil // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) GetRunnerUsage(ctx context.Context, runnerID string) (*RunnerUsage, error) {
+	return nil, nil // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) ListRunnerUsage(ctx context.Context) ([]*RunnerUsage, error) {
+	return nil, nil // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) UpdateRunner(ctx context.Context, runnerID string, req *UpdateRunnerRequest) (*Runner, error) {
+	return nil, nil // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) CloneRunner(ctx context.Context, sourceRunnerID, name string) (*Runner, error) {
+	return nil, nil // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) DeleteRunnerGroup(ctx context.Context, group string) (*RunnerGroupDeletion, error) {
+	return nil, nil // Not needed for cleanup tests
 }
 
 func (m *mockRunnerService) ListRunners(ctx context.Context, opts *ListOptions) ([]*Runner, int32, error) {
@@ -48,8 +82,32 @@ func (m *mockRunnerService) GetRunner(ctx context.Context, runnerID string) (*Ru
 	return nil, ErrRunnerNotFound
 }
 
-func (m *mockRunnerService) ExecuteCommandStream(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (int32, error) {
-	return 0, nil // Not needed for cleanup tests
+func (m *mockRunnerService) GetRunnerStats(ctx context.Context, opts *ListOptions) (*RunnerStats, error) {
+	return &RunnerStats{}, nil // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) GetRunnerDiagnostics(ctx context.Context, runnerID string, tailLines int64) (*RunnerDiagnostics, error) {
+	return nil, ErrRunnerNotFound // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) AddSSHKey(ctx context.Context, runnerID, publicKey string) (*SSHKey, bool, error) {
+	return nil, false, ErrRunnerNotFound // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) ListSSHKeys(ctx context.Context, runnerID string) ([]SSHKey, error) {
+	return nil, ErrRunnerNotFound // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) RemoveSSHKey(ctx context.Context, runnerID, fingerprint string) (bool, error) {
+	return false, ErrRunnerNotFound // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) SnapshotWorkspace(ctx context.Context, req *SnapshotRequest, progress func(SnapshotProgress)) (*SnapshotProgress, error) {
+	return nil, ErrRunnerNotFound // Not needed for cleanup tests
+}
+
+func (m *mockRunnerService) ExecuteCommandStream(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+	return ExitStatus{}, nil // Not needed for cleanup tests
 }
 
 func TestCleanupService(t *testing.T) {
@@ -57,7 +115,7 @@ func TestCleanupService(t *testing.T) {
 	tracker := NewActivityTracker()
 	
 	// Create cleanup service with short intervals for testing
-	cleanupService := NewCleanupService(mockService, tracker)
+	cleanupService := NewCleanupService(mockService, tracker, nil, DefaultDurations(), nil)
 	cleanupService.cleanupInterval = 100 * time.Millisecond
 	cleanupService.inactiveTimeout = 200 * time.Millisecond
 
@@ -109,7 +167,7 @@ func TestCleanupServiceErrorHandling(t *testing.T) {
 	mockService := newMockRunnerService()
 	tracker := NewActivityTracker()
 	
-	cleanupService := NewCleanupService(mockService, tracker)
+	cleanupService := NewCleanupService(mockService, tracker, nil, DefaultDurations(), nil)
 
 	// Test runner not found (should be handled gracefully)
 	tracker.lastActiveTimes["nonexistent-runner"] = time.Now().Add(-10 * time.Minute)
@@ -128,7 +186,7 @@ func TestCleanupServiceLifecycle(t *testing.T) {
 	mockService := newMockRunnerService()
 	tracker := NewActivityTracker()
 	
-	cleanupService := NewCleanupService(mockService, tracker)
+	cleanupService := NewCleanupService(mockService, tracker, nil, DefaultDurations(), nil)
 	cleanupService.cleanupInterval = 50 * time.Millisecond
 
 	// Start cleanup service
@@ -154,4 +212,177 @@ func TestCleanupServiceLifecycle(t *testing.T) {
 	case <-time.After(1 * time.Second):
 		t.Error("Cleanup service did not stop within timeout")
 	}
-}
\ No newline at end of file
+}
+func TestCleanupServiceGroupIdleTimeouts(t *testing.T) {
+	mockService := newMockRunnerService()
+	tracker := NewActivityTracker()
+
+	groupTimeouts := GroupIdleTimeouts{
+		{Pattern: "ci-*", Timeout: time.Minute},
+		{Pattern: "nightly", Timeout: time.Hour},
+	}
+	cleanupService := NewCleanupService(mockService, tracker, nil, DefaultDurations(), groupTimeouts)
+	cleanupService.inactiveTimeout = 10 * time.Minute
+
+	mockService.runners["runner-ci"] = &Runner{ID: "runner-ci", Status: RunnerStatusRunning, Group: "ci-1234"}
+	mockService.runners["runner-nightly"] = &Runner{ID: "runner-nightly", Status: RunnerStatusRunning, Group: "nightly"}
+	mockService.runners["runner-plain"] = &Runner{ID: "runner-plain", Status: RunnerStatusRunning}
+
+	idleSince := time.Now().Add(-5 * time.Minute)
+	for id := range mockService.runners {
+		tracker.lastActiveTimes[id] = idleSince
+	}
+
+	cleanupService.cleanupInactiveRunners(context.Background())
+
+	if len(mockService.deletedRunners) != 1 || mockService.deletedRunners[0] != "runner-ci" {
+		t.Errorf("Expected only runner-ci to be deleted, got %v", mockService.deletedRunners)
+	}
+	for _, id := range []string{"runner-nightly", "runner-plain"} {
+		if _, exists := tracker.lastActiveTimes[id]; !exists {
+			t.Errorf("Expected %s to stay tracked", id)
+		}
+	}
+}
+
+// recordingEvents implements RunnerEventRecorder, keeping "runner-id reason" of each event
+type recordingEvents struct {
+	events []string
+}
+
+func (r *recordingEvents) RecordRunnerEvent(ctx context.Context, runnerID, eventType, reason, messageFmt string, args ...interface{}) {
+	r.events = append(r.events, runnerID+" "+reason)
+}
+
+// withReason returns the IDs of the runners events with reason were recorded for
+func (r *recordingEvents) withReason(reason string) []string {
+	var runnerIDs []string
+	for _, event := range r.events {
+		if runnerID, ok := strings.CutSuffix(event, " "+reason); ok {
+			runnerIDs = append(runnerIDs, runnerID)
+		}
+	}
+	return runnerIDs
+}
+
+func TestCleanupServiceReservations(t *testing.T) {
+	mockService := newMockRunnerService()
+	tracker := NewActivityTracker()
+	events := &recordingEvents{}
+	cleanupService := NewCleanupService(mockService, tracker, events, DefaultDurations(), nil)
+	cleanupService.inactiveTimeout = 10 * time.Minute
+
+	now := time.Now()
+	mockService.runners["runner-unreserved"] = &Runner{ID: "runner-unreserved", Status: RunnerStatusRunning}
+	mockService.runners["runner-reserved"] = &Runner{ID: "runner-reserved", Status: RunnerStatusRunning, ReservedUntil: now.Add(2 * time.Hour)}
+	mockService.runners["runner-ending"] = &Runner{ID: "runner-ending", Status: RunnerStatusRunning, ReservedUntil: now.Add(5 * time.Minute)}
+	// Idle cleanup counts from the end of a past reservation
+	mockService.runners["runner-recently-ended"] = &Runner{ID: "runner-recently-ended", Status: RunnerStatusRunning, ReservedUntil: now.Add(-5 * time.Minute)}
+	mockService.runners["runner-long-ended"] = &Runner{ID: "runner-long-ended", Status: RunnerStatusRunning, ReservedUntil: now.Add(-time.Hour)}
+	for id := range mockService.runners {
+		tracker.lastActiveTimes[id] = now.Add(-3 * time.Hour)
+	}
+
+	cleanupService.cleanupInactiveRunners(context.Background())
+
+	deleted := strings.Join(mockService.deletedRunners, ",")
+	if deleted != "runner-long-ended,runner-unreserved" && deleted != "runner-unreserved,runner-long-ended" {
+		t.Errorf("Expected only the unreserved runner and the one whose reservation ended long ago to be deleted, got %v", mockService.deletedRunners)
+	}
+	if warned := events.withReason(EventReasonReservationEnding); len(warned) != 1 || warned[0] != "runner-ending" {
+		t.Errorf("Expected a warning for the reservation ending soon, got %q", warned)
+	}
+
+	// The warning is only given once per reservation
+	cleanupService.cleanupInactiveRunners(context.Background())
+	if warned := events.withReason(EventReasonReservationEnding); len(warned) != 1 {
+		t.Errorf("Expected no repeated warning, got %q", warned)
+	}
+	mockService.runners["runner-ending"].ReservedUntil = now.Add(10 * time.Minute)
+	cleanupService.cleanupInactiveRunners(context.Background())
+	if warned := events.withReason(EventReasonReservationEnding); len(warned) != 2 {
+		t.Errorf("Expected a warning for the extended reservation, got %q", warned)
+	}
+}
+
+func TestCleanupServiceSetIdleTimeouts(t *testing.T) {
+	mockService := newMockRunnerService()
+	mockService.runners["runner-1"] = &Runner{ID: "runner-1", Status: RunnerStatusRunning}
+	tracker := NewActivityTracker()
+	tracker.lastActiveTimes["runner-1"] = time.Now().Add(-10 * time.Minute)
+
+	// Nothing would be cleaned up for an hour at the interval the service starts with
+	durations := DefaultDurations()
+	durations.CleanupInterval = time.Hour
+	durations.IdleTimeout = time.Hour
+	cleanupService := NewCleanupService(mockService, tracker, nil, durations, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cleanupService.Start(ctx)
+
+	cleanupService.SetIdleTimeouts(&Durations{CleanupInterval: 10 * time.Millisecond, IdleTimeout: 5 * time.Minute}, GroupIdleTimeouts{{Pattern: "ci-*", Timeout: time.Minute}})
+	if timeout := cleanupService.idleTimeout("ci-1"); timeout != time.Minute {
+		t.Errorf("Expected the new group timeout, got %s", timeout)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(tracker.GetAllTrackedRunners()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the idle runner to be cleaned up at the new interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCleanupServiceSkipsProtectedRunners(t *testing.T) {
+	mockService := newMockRunnerService()
+	tracker := NewActivityTracker()
+	cleanupService := NewCleanupService(mockService, tracker, nil, DefaultDurations(), nil)
+	cleanupService.inactiveTimeout = time.Minute
+
+	mockService.runners["runner-1"] = &Runner{ID: "runner-1", Status: RunnerStatusRunning}
+	mockService.runners["runner-2"] = &Runner{ID: "runner-2", Status: RunnerStatusRunning, Protected: true}
+	for id := range mockService.runners {
+		tracker.lastActiveTimes[id] = time.Now().Add(-24 * time.Hour)
+	}
+
+	cleanupService.cleanupInactiveRunners(context.Background())
+
+	if len(mockService.deletedRunners) != 1 || mockService.deletedRunners[0] != "runner-1" {
+		t.Errorf("Expected only the unprotected runner to be deleted, got %v", mockService.deletedRunners)
+	}
+	// The protected runner stays tracked, so it is cleaned up once it is unprotected
+	if _, tracked := tracker.lastActiveTimes["runner-2"]; !tracked {
+		t.Fatal("Expected the protected runner to stay tracked")
+	}
+
+	mockService.runners["runner-2"].Protected = false
+	cleanupService.cleanupInactiveRunners(context.Background())
+	if len(mockService.deletedRunners) != 2 || mockService.deletedRunners[1] != "runner-2" {
+		t.Errorf("Expected the unprotected runner to be deleted, got %v", mockService.deletedRunners)
+	}
+}
+
+func TestCleanupServiceAutoRestartRunnersInError(t *testing.T) {
+	mockService := newMockRunnerService()
+	tracker := NewActivityTracker()
+	cleanupService := NewCleanupService(mockService, tracker, nil, DefaultDurations(), nil)
+	cleanupService.inactiveTimeout = time.Minute
+
+	// Only the spot runner whose node was reclaimed comes back, so only it is cleaned up
+	mockService.runners["runner-1"] = &Runner{ID: "runner-1", Status: RunnerStatusError}
+	mockService.runners["runner-2"] = &Runner{ID: "runner-2", Status: RunnerStatusError, Spot: true, AutoRestart: true, ErrorReason: RunnerErrorNodeShutdown}
+	for id := range mockService.runners {
+		tracker.lastActiveTimes[id] = time.Now().Add(-24 * time.Hour)
+	}
+
+	cleanupService.cleanupInactiveRunners(context.Background())
+
+	if len(mockService.deletedRunners) != 1 || mockService.deletedRunners[0] != "runner-2" {
+		t.Errorf("Expected only the idle auto-restart runner to be deleted, got %v", mockService.deletedRunners)
+	}
+	if _, tracked := tracker.lastActiveTimes["runner-1"]; tracked {
+		t.Error("Expected the runner left in error to be dropped from tracking")
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// cloneExcludedEnvNames are set on the runner container by the server rather than the user.
+// A clone gets them from the server configuration again instead of copying the source's values.
+var cloneExcludedEnvNames = map[string]bool{
+	"HTTP_PROXY":         true,
+	"HTTPS_PROXY":        true,
+	"http_proxy":         true,
+	"https_proxy":        true,
+	"NO_PROXY":           true,
+	"no_proxy":           true,
+	"REQUESTS_CA_BUNDLE": true,
+	"PIP_CERT":           true,
+}
+
+// checkCloneSource rejects runners that are failing or going away (pure function)
+func checkCloneSource(runner *Runner) error {
+	switch runner.Status {
+	case RunnerStatusError, RunnerStatusStopping, RunnerStatusStopped, RunnerStatusDeleteFailed, RunnerStatusRestarting:
+		return fmt.Errorf("%w: runner %s is %s and can't be cloned", ErrFailedPrecondition, runner.ID, runner.Status)
+	}
+	return nil
+}
+
+// cloneEnv returns the user-supplied env vars of a runner container, leaving out
+// per-instance and server-injected ones (pure function)
+func cloneEnv(env []corev1.EnvVar) map[string]string {
+	cloned := make(map[string]string, len(env))
+	for _, envVar := range env {
+		if IsReservedEnvName(envVar.Name) || cloneExcludedEnvNames[envVar.Name] {
+			continue
+		}
+		cloned[envVar.Name] = envVar.Value
+	}
+	return cloned
+}
+
+// cloneLabels returns the pod labels that grad doesn't set itself (pure function)
+func cloneLabels(labels map[string]string) map[string]string {
+	owned := runnerPodLabels("")
+	cloned := make(map[string]string)
+	for key, value := range labels {
+		if _, ok := owned[key]; !ok && key != RunnerGroupLabel && key != RunnerAutoRestartLabel {
+			cloned[key] = value
+		}
+	}
+	return cloned
+}
+
+// cloneWorkspace reads the S3 workspace back from the sidecar, nil if the pod has none (pure function)
+func cloneWorkspace(pod *corev1.Pod, config *KubernetesConfig) *WorkspaceConfig {
+	sidecar := findContainer(pod, S3FSContainerName)
+	if sidecar == nil {
+		return nil
+	}
+
+	workspace := &WorkspaceConfig{}
+	for _, envVar := range sidecar.Env {
+		switch envVar.Name {
+		case "S3_BUCKET":
+			workspace.Bucket = envVar.Value
+		case "S3_ENDPOINT":
+			workspace.Endpoint = envVar.Value
+		case "S3_PREFIX":
+			workspace.Prefix = envVar.Value
+		case "AWS_DEFAULT_REGION":
+			workspace.Region = envVar.Value
+		case "MOUNT_OPTIONS":
+			workspace.ReadOnly = envVar.Value == "ro"
+		}
+	}
+
+	// A per-runner override sets request and limit to the same value, so resources that
+	// differ from the server's are carried over as the override
+	var server PodCreationRequest
+	if err := server.setResources(nil, config); err != nil {
+		// Without the server's resources to compare against, the clone gets them instead
+		return workspace
+	}
+	workspace.S3FSCPU = sidecarOverride(sidecar.Resources, corev1.ResourceCPU, server.S3FSCPURequest, server.S3FSCPULimit)
+	workspace.S3FSMemory = sidecarOverride(sidecar.Resources, corev1.ResourceMemory, server.S3FSMemoryRequest, server.S3FSMemoryLimit)
+
+	return workspace
+}
+
+// sidecarOverride returns the sidecar's request for name if it isn't the server's, else "" (pure function)
+func sidecarOverride(resources corev1.ResourceRequirements, name corev1.ResourceName, request, limit resource.Quantity) string {
+	actual, ok := resources.Requests[name]
+	if !ok {
+		return ""
+	}
+	actualLimit := resources.Limits[name]
+	if actual.Cmp(request) == 0 && actualLimit.Cmp(limit) == 0 {
+		return ""
+	}
+	return actual.String()
+}
+
+// cloneRunnerRequest builds the request creating a copy of the runner in pod. Image and
+// resources aren't copied: every runner uses the server's image and preset. (pure function)
+func cloneRunnerRequest(pod *corev1.Pod, name string, config *KubernetesConfig) *CreateRunnerRequest {
+	req := &CreateRunnerRequest{
+		Name:             name,
+		Env:              map[string]string{},
+		Workspace:        cloneWorkspace(pod, config),
+		BootstrapCommand: pod.Annotations[RunnerBootstrapCommandAnnotation],
+		DefaultShell:     pod.Annotations[RunnerDefaultShellAnnotation],
+		DefaultWorkdir:   pod.Annotations[RunnerDefaultWorkdirAnnotation],
+		Description:      decodeAnnotationText(pod.Annotations[RunnerDescriptionAnnotation]),
+		Labels:           cloneLabels(pod.Labels),
+		Group:            pod.Labels[RunnerGroupLabel],
+		// The server's own entries are added again from the current config
+		HostAliases: fromPodHostAliases(pod.Spec.HostAliases, config.HostAliases),
+		AutoRestart: &[]bool{pod.Labels[RunnerAutoRestartLabel] == "true"}[0],
+		Arch:        podArch(pod),
+		Spot:        podSpot(pod),
+	}
+
+	runnerContainer := findContainer(pod, RunnerContainerName)
+	if runnerContainer != nil {
+		req.Env = cloneEnv(runnerContainer.Env)
+	}
+
+	// The source opted out of the server proxy if its container didn't get it
+	if config.Proxy != nil && config.Proxy.URL != "" {
+		req.DisableProxy = true
+		if runnerContainer != nil {
+			for _, envVar := range runnerContainer.Env {
+				if envVar.Name == "HTTPS_PROXY" {
+					req.DisableProxy = false
+				}
+			}
+		}
+	}
+
+	// Only a ServiceAccount other than the server's was picked by the request
+	defaultAccount, defaultAutomount := "", false
+	if config.ServiceAccount != nil {
+		defaultAccount, defaultAutomount = config.ServiceAccount.Name, config.ServiceAccount.AutomountToken
+	}
+	if account := pod.Spec.ServiceAccountName; account != "" && account != defaultAccount {
+		req.ServiceAccount = account
+	}
+	automount := pod.Spec.AutomountServiceAccountToken
+	if automount != nil && !*automount && (req.ServiceAccount != "" || defaultAutomount) {
+		req.DisableServiceAccountToken = true
+	}
+
+	// A class the request could pick is kept; others come from the server's default again
+	if class := pod.Spec.PriorityClassName; class != config.PriorityClassName && slices.Contains(config.Allowlists().PriorityClasses, class) {
+		req.PriorityClassName = class
+	}
+
+	return req
+}
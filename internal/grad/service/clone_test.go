@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCloneEnv(t *testing.T) {
+	env := []corev1.EnvVar{
+		{Name: "RUNNER_ID", Value: "runner-1"},
+		{Name: "RUNNER_NAME", Value: "analytics"},
+		{Name: "HTTP_PROXY", Value: "http://proxy:3128"},
+		{Name: "https_proxy", Value: "http://proxy:3128"},
+		{Name: "NO_PROXY", Value: "localhost"},
+		{Name: ExtraCADirEnv, Value: ExtraCAMountPath},
+		{Name: "REQUESTS_CA_BUNDLE", Value: systemCABundle},
+		{Name: "PIP_CERT", Value: systemCABundle},
+		{Name: "DATASET", Value: "s3://bucket/data"},
+		{Name: "AWS_ACCESS_KEY_ID", Value: "AKIA"},
+	}
+
+	expected := map[string]string{
+		"DATASET":           "s3://bucket/data",
+		"AWS_ACCESS_KEY_ID": "AKIA",
+	}
+	if got := cloneEnv(env); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestCloneRunnerRequest(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	config.Proxy = &ProxyConfig{URL: "http://proxy:3128", NoProxy: "localhost", ExtraCAConfigMap: "corp-ca"}
+	config.ServiceAccount = &ServiceAccountConfig{Name: "grad-runner", Allowed: []string{"trainer"}}
+	config.HostAliases = []HostAlias{{IP: "10.0.0.5", Hostnames: []string{"legacy"}}}
+	config.PriorityClassName = "interactive"
+	config.AllowedPriorityClasses = []string{"interactive", "gpu-training"}
+
+	tests := []struct {
+		name   string
+		source *Runner
+		expect *CreateRunnerRequest
+	}{
+		{
+			name:   "Defaults",
+			source: &Runner{ID: "runner-1", Name: "runner-1"},
+			expect: &CreateRunnerRequest{Name: "copy", Env: map[string]string{}, Labels: map[string]string{}, AutoRestart: &[]bool{false}[0]},
+		},
+		{
+			name:   "Default priority class",
+			source: &Runner{ID: "runner-1", Name: "runner-1", PriorityClassName: "interactive"},
+			expect: &CreateRunnerRequest{Name: "copy", Env: map[string]string{}, Labels: map[string]string{}, AutoRestart: &[]bool{false}[0]},
+		},
+		{
+			name:   "Auto-runner priority class",
+			source: &Runner{ID: "runner-1", Name: "runner-1", PriorityClassName: "batch"},
+			expect: &CreateRunnerRequest{Name: "copy", Env: map[string]string{}, Labels: map[string]string{}, AutoRestart: &[]bool{false}[0]},
+		},
+		{
+			name: "Everything set",
+			source: &Runner{
+				ID:   "runner-1",
+				Name: "analytics",
+				Env:  map[string]string{"DATASET": "sales", "AWS_ACCESS_KEY_ID": "AKIA"},
+				Workspace: &WorkspaceConfig{
+					Bucket:   "data",
+					Endpoint: "https://s3.example.com",
+					Prefix:   "team/",
+					Region:   "eu-west-1",
+					ReadOnly: true,
+					S3FSCPU:  "1",
+				},
+				BootstrapCommand:           "pip install -r requirements.txt",
+				DisableProxy:               true,
+				ServiceAccount:             "trainer",
+				DisableServiceAccountToken: true,
+				DefaultShell:               "zsh",
+				DefaultWorkdir:             "/workspace",
+				Description:                "Nightly import\nfor analytics",
+				Labels:                     map[string]string{RunnerOwnerLabel: "data-team"},
+				HostAliases:                []HostAlias{{IP: "10.0.0.6", Hostnames: []string{"db.corp.example"}}},
+				AutoRestart:                true,
+				PriorityClassName:          "gpu-training",
+			},
+			expect: &CreateRunnerRequest{
+				Name: "copy",
+				Env:  map[string]string{"DATASET": "sales", "AWS_ACCESS_KEY_ID": "AKIA"},
+				Workspace: &WorkspaceConfig{
+					Bucket:   "data",
+					Endpoint: "https://s3.example.com",
+					Prefix:   "team/",
+					Region:   "eu-west-1",
+					ReadOnly: true,
+					S3FSCPU:  "1",
+				},
+				BootstrapCommand:           "pip install -r requirements.txt",
+				DisableProxy:               true,
+				ServiceAccount:             "trainer",
+				DisableServiceAccountToken: true,
+				DefaultShell:               "zsh",
+				DefaultWorkdir:             "/workspace",
+				Description:                "Nightly import\nfor analytics",
+				Labels:                     map[string]string{RunnerOwnerLabel: "data-team"},
+				HostAliases:                []HostAlias{{IP: "10.0.0.6", Hostnames: []string{"db.corp.example"}}},
+				AutoRestart:                &[]bool{true}[0],
+				PriorityClassName:          "gpu-training",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := buildPodCreationRequest(t, tt.source, config).ToPodSpec()
+
+			got := cloneRunnerRequest(pod, "copy", config)
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("Expected %+v, got %+v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestCloneRunner(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	source, err := svc.CreateRunner(ctx, &CreateRunnerRequest{
+		Name:        "analytics",
+		Env:         map[string]string{"DATASET": "sales"},
+		Description: "with pandas installed",
+	})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	clone, err := svc.CloneRunner(ctx, source.ID, "analytics-2")
+	if err != nil {
+		t.Fatalf("CloneRunner failed: %v", err)
+	}
+	if clone.ID == source.ID || clone.Name != "analytics-2" {
+		t.Errorf("Expected a new runner named analytics-2, got %s named %s", clone.ID, clone.Name)
+	}
+	if !reflect.DeepEqual(clone.Env, source.Env) || clone.Description != source.Description {
+		t.Errorf("Expected env %v and description %q, got %v and %q", source.Env, source.Description, clone.Env, clone.Description)
+	}
+
+	setPodStatus(t, svc, clientset, source.ID, corev1.PodFailed, false)
+	if _, err := svc.CloneRunner(ctx, source.ID, ""); !errors.Is(err, ErrFailedPrecondition) {
+		t.Errorf("Expected ErrFailedPrecondition for a failed runner, got %v", err)
+	}
+	if _, err := svc.CloneRunner(ctx, "runner-missing", ""); !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("Expected ErrRunnerNotFound, got %v", err)
+	}
+}
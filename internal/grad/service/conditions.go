@@ -0,0 +1,253 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// RunnerConditionType names a provisioning step of a runner
+type RunnerConditionType string
+
+// Provisioning steps, in the order a runner goes through them
+const (
+	RunnerConditionPodCreated      RunnerConditionType = "PodCreated"
+	RunnerConditionPodScheduled    RunnerConditionType = "PodScheduled"
+	RunnerConditionImagePulled     RunnerConditionType = "ImagePulled"
+	RunnerConditionContainersReady RunnerConditionType = "ContainersReady"
+	// RunnerConditionWorkspaceMounted is only reported for runners with an S3 workspace
+	RunnerConditionWorkspaceMounted RunnerConditionType = "WorkspaceMounted"
+	// RunnerConditionBootstrapComplete is only reported for runners with a bootstrap command
+	RunnerConditionBootstrapComplete RunnerConditionType = "BootstrapComplete"
+)
+
+// ConditionStatus says whether a runner condition holds
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// RunnerCondition is the state of one provisioning step of a runner, like a pod condition
+type RunnerCondition struct {
+	Type   RunnerConditionType
+	Status ConditionStatus
+	// LastTransitionTime is when Status last changed, zero if that isn't known
+	LastTransitionTime time.Time
+	Reason             string
+	Message            string
+}
+
+// RunnerConditions derives the provisioning steps of the runner in pod from the pod's own
+// conditions and container statuses, and from the bootstrap state grad records on it
+// (pure function)
+func RunnerConditions(pod *corev1.Pod) []RunnerCondition {
+	conditions := []RunnerCondition{
+		{Type: RunnerConditionPodCreated, Status: ConditionTrue, LastTransitionTime: pod.CreationTimestamp.Time},
+		podCondition(pod, corev1.PodScheduled, RunnerConditionPodScheduled),
+		imagePulledCondition(pod),
+		// The pod is ready once every container is, the runner's as well as the sidecar's
+		podCondition(pod, corev1.PodReady, RunnerConditionContainersReady),
+	}
+	if findContainer(pod, S3FSContainerName) != nil {
+		conditions = append(conditions, workspaceMountedCondition(pod))
+	}
+	if pod.Annotations[RunnerBootstrapCommandAnnotation] != "" {
+		conditions = append(conditions, bootstrapCompleteCondition(pod))
+	}
+	return conditions
+}
+
+// findCondition returns the condition of the given type, or nil if there is none (pure function)
+func findCondition(conditions []RunnerCondition, conditionType RunnerConditionType) *RunnerCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// podCondition copies the pod condition podType as a runner condition, Unknown until the pod
+// reports it (pure function)
+func podCondition(pod *corev1.Pod, podType corev1.PodConditionType, conditionType RunnerConditionType) RunnerCondition {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == podType {
+			return RunnerCondition{
+				Type:               conditionType,
+				Status:             ConditionStatus(c.Status),
+				LastTransitionTime: c.LastTransitionTime.Time,
+				Reason:             c.Reason,
+				Message:            c.Message,
+			}
+		}
+	}
+	return RunnerCondition{Type: conditionType, Status: ConditionUnknown}
+}
+
+// imagePulledCondition says whether the images of all the pod's containers are on its node: a
+// container can't start before, and one waiting for an image says so (pure function)
+func imagePulledCondition(pod *corev1.Pod) RunnerCondition {
+	condition := RunnerCondition{Type: RunnerConditionImagePulled, Status: ConditionUnknown}
+	if pod.Spec.NodeName == "" {
+		return condition
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	if len(statuses) < len(pod.Spec.InitContainers)+len(pod.Spec.Containers) {
+		return condition
+	}
+
+	var pulledAt time.Time
+	for _, status := range statuses {
+		if waiting := status.State.Waiting; waiting != nil && status.ImageID == "" {
+			condition.Status = ConditionFalse
+			condition.Reason = waiting.Reason
+			condition.Message = containerMessage(status.Name, waiting.Reason, waiting.Message)
+			for _, reason := range imagePullWaitingReasons {
+				if waiting.Reason == reason {
+					return condition
+				}
+			}
+			continue
+		}
+		// The earliest start of a container whose image was pulled last is unknown, so the
+		// latest start stands in for when the last image arrived
+		if startedAt := containerStartedAt(status); startedAt.After(pulledAt) {
+			pulledAt = startedAt
+		}
+	}
+	if condition.Status == ConditionFalse {
+		return condition
+	}
+	condition.Status = ConditionTrue
+	condition.LastTransitionTime = pulledAt
+	return condition
+}
+
+// workspaceMountedCondition says whether the s3fs sidecar, which mounts the S3 workspace, is up
+// (pure function)
+func workspaceMountedCondition(pod *corev1.Pod) RunnerCondition {
+	condition := RunnerCondition{Type: RunnerConditionWorkspaceMounted, Status: ConditionUnknown}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != S3FSContainerName {
+			continue
+		}
+		switch {
+		case status.Ready:
+			condition.Status = ConditionTrue
+			condition.LastTransitionTime = containerStartedAt(status)
+		case status.State.Waiting != nil:
+			condition.Status = ConditionFalse
+			condition.Reason = status.State.Waiting.Reason
+			condition.Message = containerMessage(status.Name, status.State.Waiting.Reason, status.State.Waiting.Message)
+		case status.State.Terminated != nil:
+			condition.Status = ConditionFalse
+			condition.Reason = status.State.Terminated.Reason
+			condition.LastTransitionTime = status.State.Terminated.FinishedAt.Time
+			condition.Message = fmt.Sprintf("container %s exited with code %d", status.Name, status.State.Terminated.ExitCode)
+		default:
+			condition.Status = ConditionFalse
+			condition.Reason = "NotReady"
+		}
+	}
+	return condition
+}
+
+// bootstrapCompleteCondition says whether the bootstrap command succeeded, from the state grad
+// records in the pod's annotations (pure function)
+func bootstrapCompleteCondition(pod *corev1.Pod) RunnerCondition {
+	condition := RunnerCondition{Type: RunnerConditionBootstrapComplete, Status: ConditionFalse}
+	if finishedAt, err := time.Parse(time.RFC3339, pod.Annotations[RunnerBootstrapFinishedAnnotation]); err == nil {
+		condition.LastTransitionTime = finishedAt
+	}
+
+	switch pod.Annotations[RunnerBootstrapStatusAnnotation] {
+	case BootstrapStatusSucceeded:
+		condition.Status = ConditionTrue
+		condition.Reason = "Succeeded"
+	case BootstrapStatusFailed:
+		condition.Reason = "Failed"
+		condition.Message, _, _ = strings.Cut(pod.Annotations[RunnerStatusDetailAnnotation], "\n")
+	case BootstrapStatusRunning:
+		condition.Reason = "Running"
+	default:
+		condition.Reason = "Pending"
+		condition.Message = "waiting for the runner to become ready"
+	}
+	return condition
+}
+
+// containerStartedAt returns when a container last started, zero if it hasn't (pure function)
+func containerStartedAt(status corev1.ContainerStatus) time.Time {
+	switch {
+	case status.State.Running != nil:
+		return status.State.Running.StartedAt.Time
+	case status.State.Terminated != nil:
+		return status.State.Terminated.StartedAt.Time
+	default:
+		return time.Time{}
+	}
+}
+
+// runnerStatusFromConditions maps the pod's phase and the runner's conditions to a status. A
+// running pod only counts as running once ContainersReady holds. (pure function)
+func runnerStatusFromConditions(pod *corev1.Pod, conditions []RunnerCondition) RunnerStatus {
+	// A pod marked for deletion is being torn down regardless of its phase
+	if pod.DeletionTimestamp != nil {
+		return RunnerStatusStopping
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodPending:
+		return RunnerStatusCreating
+	case corev1.PodRunning:
+		if ready := findCondition(conditions, RunnerConditionContainersReady); ready != nil && ready.Status == ConditionTrue {
+			return RunnerStatusRunning
+		}
+		return RunnerStatusCreating
+	case corev1.PodSucceeded:
+		return RunnerStatusStopped
+	default:
+		return RunnerStatusError
+	}
+}
+
+// ToProto converts a domain RunnerCondition to proto
+func (c RunnerCondition) ToProto() *gradv1.RunnerCondition {
+	return &gradv1.RunnerCondition{
+		Type:               string(c.Type),
+		Status:             c.Status.ToProto(),
+		LastTransitionTime: unixSeconds(c.LastTransitionTime),
+		Reason:             c.Reason,
+		Message:            c.Message,
+	}
+}
+
+// ToProto converts a domain ConditionStatus to proto
+func (s ConditionStatus) ToProto() gradv1.ConditionStatus {
+	switch s {
+	case ConditionTrue:
+		return gradv1.ConditionStatus_CONDITION_STATUS_TRUE
+	case ConditionFalse:
+		return gradv1.ConditionStatus_CONDITION_STATUS_FALSE
+	case ConditionUnknown:
+		return gradv1.ConditionStatus_CONDITION_STATUS_UNKNOWN
+	default:
+		return gradv1.ConditionStatus_CONDITION_STATUS_UNSPECIFIED
+	}
+}
+
+// toProtoConditions converts domain runner conditions to proto
+func toProtoConditions(conditions []RunnerCondition) []*gradv1.RunnerCondition {
+	var protoConditions []*gradv1.RunnerCondition
+	for _, c := range conditions {
+		protoConditions = append(protoConditions, c.ToProto())
+	}
+	return protoConditions
+}
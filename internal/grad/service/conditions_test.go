@@ -0,0 +1,154 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// conditionPod returns a pod of a runner with an S3 workspace and a bootstrap command, created
+// at createdAt, with the given status
+func conditionPod(createdAt time.Time, status corev1.PodStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(createdAt),
+			Annotations: map[string]string{
+				RunnerBootstrapCommandAnnotation: "pip install -r requirements.txt",
+				RunnerBootstrapStatusAnnotation:  BootstrapStatusPending,
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: RunnerContainerName}, {Name: S3FSContainerName}},
+		},
+		Status: status,
+	}
+}
+
+// expectConditions checks the status and reason of each condition, in order
+func expectConditions(t *testing.T, conditions []RunnerCondition, expected []RunnerCondition) {
+	t.Helper()
+	if len(conditions) != len(expected) {
+		t.Fatalf("Expected %d conditions, got %+v", len(expected), conditions)
+	}
+	for i, e := range expected {
+		c := conditions[i]
+		if c.Type != e.Type || c.Status != e.Status || c.Reason != e.Reason {
+			t.Errorf("Condition %d: expected %s=%s (%s), got %s=%s (%s)", i, e.Type, e.Status, e.Reason, c.Type, c.Status, c.Reason)
+		}
+	}
+}
+
+func TestRunnerConditionsUnschedulable(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	pod := conditionPod(createdAt, corev1.PodStatus{
+		Phase: corev1.PodPending,
+		Conditions: []corev1.PodCondition{{
+			Type:               corev1.PodScheduled,
+			Status:             corev1.ConditionFalse,
+			Reason:             corev1.PodReasonUnschedulable,
+			Message:            "0/3 nodes are available: 3 Insufficient cpu.",
+			LastTransitionTime: metav1.NewTime(createdAt.Add(time.Second)),
+		}},
+	})
+
+	conditions := RunnerConditions(pod)
+	expectConditions(t, conditions, []RunnerCondition{
+		{Type: RunnerConditionPodCreated, Status: ConditionTrue},
+		{Type: RunnerConditionPodScheduled, Status: ConditionFalse, Reason: "Unschedulable"},
+		{Type: RunnerConditionImagePulled, Status: ConditionUnknown},
+		{Type: RunnerConditionContainersReady, Status: ConditionUnknown},
+		{Type: RunnerConditionWorkspaceMounted, Status: ConditionUnknown},
+		{Type: RunnerConditionBootstrapComplete, Status: ConditionFalse, Reason: "Pending"},
+	})
+	if !conditions[0].LastTransitionTime.Equal(createdAt) {
+		t.Errorf("Expected the pod to be created at %s, got %s", createdAt, conditions[0].LastTransitionTime)
+	}
+	if scheduled := conditions[1]; scheduled.Message != "0/3 nodes are available: 3 Insufficient cpu." || !scheduled.LastTransitionTime.Equal(createdAt.Add(time.Second)) {
+		t.Errorf("Expected the scheduler's message and time, got %+v", scheduled)
+	}
+	if status := MapPodStatusToRunnerStatus(pod); status != RunnerStatusCreating {
+		t.Errorf("Expected creating, got %s", status)
+	}
+}
+
+func TestRunnerConditionsPulling(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	waiting := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}
+	pod := conditionPod(createdAt, corev1.PodStatus{
+		Phase: corev1.PodPending,
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+			{Type: corev1.PodReady, Status: corev1.ConditionFalse, Reason: "ContainersNotReady"},
+		},
+		ContainerStatuses: []corev1.ContainerStatus{
+			{Name: RunnerContainerName, State: waiting},
+			{Name: S3FSContainerName, State: waiting},
+		},
+	})
+	pod.Spec.NodeName = "node-1"
+
+	expectConditions(t, RunnerConditions(pod), []RunnerCondition{
+		{Type: RunnerConditionPodCreated, Status: ConditionTrue},
+		{Type: RunnerConditionPodScheduled, Status: ConditionTrue},
+		{Type: RunnerConditionImagePulled, Status: ConditionFalse, Reason: "ContainerCreating"},
+		{Type: RunnerConditionContainersReady, Status: ConditionFalse, Reason: "ContainersNotReady"},
+		{Type: RunnerConditionWorkspaceMounted, Status: ConditionFalse, Reason: "ContainerCreating"},
+		{Type: RunnerConditionBootstrapComplete, Status: ConditionFalse, Reason: "Pending"},
+	})
+
+	// A pull error in one container wins over another still being created
+	pod.Status.ContainerStatuses[1].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}}
+	pulled := RunnerConditions(pod)[2]
+	if pulled.Status != ConditionFalse || pulled.Reason != "ImagePullBackOff" || pulled.Message != "container s3fs-sidecar: ImagePullBackOff: Back-off pulling image" {
+		t.Errorf("Expected the pull error, got %+v", pulled)
+	}
+}
+
+func TestRunnerConditionsReady(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	runnerStarted := createdAt.Add(20 * time.Second)
+	sidecarStarted := createdAt.Add(25 * time.Second)
+	bootstrapped := createdAt.Add(time.Minute)
+	pod := conditionPod(createdAt, corev1.PodStatus{
+		Phase: corev1.PodRunning,
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(sidecarStarted)},
+		},
+		ContainerStatuses: []corev1.ContainerStatus{
+			{Name: RunnerContainerName, Ready: true, ImageID: "sha256:1", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(runnerStarted)}}},
+			{Name: S3FSContainerName, Ready: true, ImageID: "sha256:2", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(sidecarStarted)}}},
+		},
+	})
+	pod.Spec.NodeName = "node-1"
+	pod.Annotations[RunnerBootstrapStatusAnnotation] = BootstrapStatusSucceeded
+	pod.Annotations[RunnerBootstrapFinishedAnnotation] = bootstrapped.Format(time.RFC3339)
+
+	conditions := RunnerConditions(pod)
+	expectConditions(t, conditions, []RunnerCondition{
+		{Type: RunnerConditionPodCreated, Status: ConditionTrue},
+		{Type: RunnerConditionPodScheduled, Status: ConditionTrue},
+		{Type: RunnerConditionImagePulled, Status: ConditionTrue},
+		{Type: RunnerConditionContainersReady, Status: ConditionTrue},
+		{Type: RunnerConditionWorkspaceMounted, Status: ConditionTrue},
+		{Type: RunnerConditionBootstrapComplete, Status: ConditionTrue, Reason: "Succeeded"},
+	})
+	for i, expected := range map[int]time.Time{2: sidecarStarted, 3: sidecarStarted, 4: sidecarStarted, 5: bootstrapped} {
+		if !conditions[i].LastTransitionTime.Equal(expected) {
+			t.Errorf("%s: expected transition at %s, got %s", conditions[i].Type, expected, conditions[i].LastTransitionTime)
+		}
+	}
+	if status := MapPodStatusToRunnerStatus(pod); status != RunnerStatusRunning {
+		t.Errorf("Expected running, got %s", status)
+	}
+
+	// Without a workspace or bootstrap command the steps that don't apply are left out
+	pod.Spec.Containers = pod.Spec.Containers[:1]
+	pod.Status.ContainerStatuses = pod.Status.ContainerStatuses[:1]
+	delete(pod.Annotations, RunnerBootstrapCommandAnnotation)
+	if conditions := RunnerConditions(pod); len(conditions) != 4 || findCondition(conditions, RunnerConditionWorkspaceMounted) != nil {
+		t.Errorf("Expected only the pod's steps, got %+v", conditions)
+	}
+}
@@ -1,46 +1,772 @@
 package service
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Config holds the configuration for the grad service
 type Config struct {
 	Kubernetes *KubernetesConfig
+	EnvLimits  *EnvLimits
+	// CommandLimits bound exec commands and bootstrap commands
+	CommandLimits *CommandLimits
+	RateLimits    *RateLimits
+	StreamLimits  *StreamLimits
+	Durations     *Durations
+	// GroupIdleTimeouts override Durations.IdleTimeout for runners in matching groups
+	GroupIdleTimeouts GroupIdleTimeouts
+	// AdminToken authenticates admin API calls; empty disables the gRPC AdminService
+	AdminToken string
+	// ImageCheckPullSecretFile is a docker config JSON, e.g. a mounted pull secret's
+	// .dockerconfigjson, authenticating the image check; empty checks anonymously
+	ImageCheckPullSecretFile string
+}
+
+// EnvLimits bounds the environment variables a client may pass to a runner
+type EnvLimits struct {
+	MaxValueBytes int
+	MaxTotalBytes int
+	MaxEntries    int
+}
+
+// CommandLimits bounds the commands a client may run: exec command lines, argv and bootstrap
+// commands. They can only be lowered from the defaults, which are what a runner's shell can
+// take as a single argument.
+type CommandLimits struct {
+	// MaxBytes is the longest command line, with argv quoted for the shell
+	MaxBytes int
+	// MaxArgs is the most words of argv
+	MaxArgs int
+}
+
+// RateLimits sets the token-bucket limits of the gRPC API. Mutating RPCs create or delete
+// pods; read RPCs only query them. A zero rate disables that limit.
+type RateLimits struct {
+	MutatingRate  float64
+	MutatingBurst int
+	ReadRate      float64
+	ReadBurst     int
+
+	// Identity* limit each authenticated client separately, on top of the global limits
+	IdentityMutatingRate  float64
+	IdentityMutatingBurst int
+	IdentityReadRate      float64
+	IdentityReadBurst     int
+}
+
+// Validate checks that every enabled limit allows at least one request
+func (l RateLimits) Validate() error {
+	for _, limit := range []struct {
+		name  string
+		rate  float64
+		burst int
+	}{
+		{"mutating", l.MutatingRate, l.MutatingBurst},
+		{"read", l.ReadRate, l.ReadBurst},
+		{"identity mutating", l.IdentityMutatingRate, l.IdentityMutatingBurst},
+		{"identity read", l.IdentityReadRate, l.IdentityReadBurst},
+	} {
+		if limit.rate < 0 {
+			return fmt.Errorf("%s rate must not be negative, got %v", limit.name, limit.rate)
+		}
+		if limit.rate > 0 && limit.burst < 1 {
+			return fmt.Errorf("%s burst must be at least 1, got %d", limit.name, limit.burst)
+		}
+	}
+	return nil
+}
+
+// StreamLimits bounds the gRPC streams grad keeps open. Every command output stream
+// buffers data in memory, so MaxExecStreams caps them across all clients.
+type StreamLimits struct {
+	// MaxConcurrentStreams is the HTTP/2 limit on streams per client connection
+	MaxConcurrentStreams int
+	// MaxExecStreams caps the command output streams open at once, 0 for no cap
+	MaxExecStreams int
+	// MaxExecOutputBytes caps the output sent for a single command, 0 for no cap. Requests
+	// may lower it but not raise it.
+	MaxExecOutputBytes int
+	// ExecResumeGrace is how long a resumable command keeps running after its stream breaks,
+	// waiting for the client to reattach; 0 cancels it right away
+	ExecResumeGrace time.Duration
+}
+
+// Durations holds the intervals and timeouts of grad's background work
+type Durations struct {
+	// CleanupInterval is how often idle runners are looked for
+	CleanupInterval time.Duration
+	// IdleTimeout is how long a runner may go without commands before it is deleted
+	IdleTimeout time.Duration
+	// ProvisionTimeout bounds how long a new runner may take to become ready
+	ProvisionTimeout time.Duration
+	// MonitorInterval is how often a new runner's pod is polled while waiting for readiness
+	MonitorInterval time.Duration
+	// BootstrapTimeout bounds how long a bootstrap command may run
+	BootstrapTimeout time.Duration
+	// ExecRunnerReadyTimeout bounds how long ExecuteCommand waits for a runner it created
+	ExecRunnerReadyTimeout time.Duration
+	// ShutdownGrace bounds how long grad waits for servers to stop on shutdown
+	ShutdownGrace time.Duration
+	// ExecOutputFlushInterval is how long command output may be held back to be sent with
+	// what follows it; zero sends every piece as it arrives
+	ExecOutputFlushInterval time.Duration
+	// DeleteWaitTimeout bounds how long DeleteRunner may wait for a runner to be gone
+	DeleteWaitTimeout time.Duration
+	// APITimeout bounds each call grad makes to the Kubernetes API
+	APITimeout time.Duration
+	// MaxReservation caps how far ahead a runner may be reserved against idle cleanup
+	MaxReservation time.Duration
+	// SSHSessionCheckInterval is how often running runners are checked for open SSH
+	// sessions; zero disables the checks
+	SSHSessionCheckInterval time.Duration
+}
+
+// DurationSetting describes how a duration is configured and the range it must fall in
+type DurationSetting struct {
+	Key         string
+	Description string
+	Target      *time.Duration
+	Min, Max    time.Duration
+}
+
+// durationSettings lists the environment variables setting each field of d
+func durationSettings(d *Durations) []DurationSetting {
+	return []DurationSetting{
+		{"CLEANUP_INTERVAL", "How often idle runners are looked for", &d.CleanupInterval, time.Second, 24 * time.Hour},
+		{"RUNNER_IDLE_TIMEOUT", "How long a runner may go without commands before it is deleted", &d.IdleTimeout, time.Minute, 30 * 24 * time.Hour},
+		{"RUNNER_PROVISION_TIMEOUT", "How long a new runner may take to become ready", &d.ProvisionTimeout, 10 * time.Second, 2 * time.Hour},
+		{"RUNNER_MONITOR_INTERVAL", "How often a new runner is polled while waiting for readiness", &d.MonitorInterval, 100 * time.Millisecond, time.Minute},
+		{"RUNNER_BOOTSTRAP_TIMEOUT", "How long a bootstrap command may run", &d.BootstrapTimeout, time.Minute, 24 * time.Hour},
+		{"EXEC_RUNNER_READY_TIMEOUT", "How long execute waits for a runner it created", &d.ExecRunnerReadyTimeout, 10 * time.Second, 2 * time.Hour},
+		{"SHUTDOWN_GRACE", "How long to wait for servers to stop on shutdown", &d.ShutdownGrace, 0, 5 * time.Minute},
+		{"EXEC_OUTPUT_FLUSH_INTERVAL", "How long command output may be held back to be sent in one message; partial lines such as prompts arrive within it", &d.ExecOutputFlushInterval, 0, time.Second},
+		{"RUNNER_DELETE_WAIT_TIMEOUT", "How long a runner deletion may wait for the runner's objects to be gone", &d.DeleteWaitTimeout, time.Second, 30 * time.Minute},
+		{"KUBERNETES_API_TIMEOUT", "How long a call to the Kubernetes API may take before it is abandoned", &d.APITimeout, time.Second, 5 * time.Minute},
+		{"RUNNER_MAX_RESERVATION", "How far ahead a runner may be reserved against idle cleanup", &d.MaxReservation, time.Minute, 30 * 24 * time.Hour},
+		{"SSH_SESSION_CHECK_INTERVAL", "How often running runners are checked for open SSH sessions, which count as activity; 0 disables the checks", &d.SSHSessionCheckInterval, 0, time.Hour},
+	}
+}
+
+// Settings lists the configurable durations with their keys and ranges, pointing into d
+func (d *Durations) Settings() []DurationSetting {
+	return durationSettings(d)
+}
+
+// ProxyConfig describes the outbound proxy and extra CA certificates injected into runner pods
+type ProxyConfig struct {
+	// URL is set as HTTP_PROXY and HTTPS_PROXY in the runner container
+	URL string
+	// NoProxy is set as NO_PROXY in the runner container
+	NoProxy string
+	// ExtraCAConfigMap names a ConfigMap of CA certificates mounted into the runner container
+	ExtraCAConfigMap string
+}
+
+// NamespaceConfig controls whether grad manages the runner namespace itself.
+// Many clusters forbid namespace creation, so everything here is off by default.
+type NamespaceConfig struct {
+	// Ensure creates the runner namespace if it doesn't exist
+	Ensure bool
+	// CleanupEmpty deletes a namespace grad created once its last runner is deleted
+	CleanupEmpty bool
+
+	// Hard limits of the ResourceQuota created with the namespace (optional)
+	QuotaCPU    string
+	QuotaMemory string
+	QuotaPods   string
+
+	// Container defaults of the LimitRange created with the namespace (optional)
+	LimitCPU    string
+	LimitMemory string
+}
+
+// NetworkPolicyConfig describes the NetworkPolicy applied to runner pods.
+// Selectors use the "key=value,key=value" label syntax.
+type NetworkPolicyConfig struct {
+	// Mode is NetworkPolicyModePerRunner, NetworkPolicyModeShared or empty to disable policies
+	Mode string
+	// SSHNamespaces and SSHPodSelector select the clients allowed to reach the SSH port
+	SSHNamespaces  []string
+	SSHPodSelector string
+	// GradPodSelector selects the grad pods, which are always allowed in
+	GradPodSelector string
+	// S3Endpoint is the default S3 endpoint runners may reach (AWS S3 over HTTPS if empty)
+	S3Endpoint string
+	// EgressCIDRs lists extra destinations runners may reach
+	EgressCIDRs []string
+}
+
+// StableDNSConfig gives runner pods DNS names that survive pod restarts
+type StableDNSConfig struct {
+	// Enabled maintains the headless RunnerServiceName Service and sets each pod's hostname and subdomain
+	Enabled bool
+	// ClusterDomain is the cluster's DNS domain (DefaultClusterDomain if empty)
+	ClusterDomain string
+}
+
+// ServiceAccountConfig controls the ServiceAccount runner pods run as
+type ServiceAccountConfig struct {
+	// Name is the ServiceAccount grad creates if missing and assigns to runners; empty uses the namespace default
+	Name string
+	// AutomountToken mounts the API token into runners that don't request their own ServiceAccount
+	AutomountToken bool
+	// Allowed lists the ServiceAccounts a request may pick for runners that need API access
+	Allowed []string
+}
+
+// SidecarConfig sets the resources of the s3fs sidecar. Values are Kubernetes quantities.
+type SidecarConfig struct {
+	CPURequest    string
+	MemoryRequest string
+	CPULimit      string
+	MemoryLimit   string
+
+	// MaxCPU and MaxMemory bound the per-runner overrides in WorkspaceConfig
+	MaxCPU    string
+	MaxMemory string
+}
+
+// RestartConfig controls recreating the pods of runners that failed, e.g. because their
+// node went away. Runners opt in with auto_restart; Default applies when they don't say.
+type RestartConfig struct {
+	Default bool
+	// MaxRestarts bounds how often one runner is recreated; 0 disables restarts
+	MaxRestarts int
+	// Backoff is the wait before the first restart; it doubles with each one after it,
+	// up to MaxRestartBackoff
+	Backoff time.Duration
+}
+
+// DefaultRestartConfig returns the default restart settings: opt-in, at most 3 restarts
+func DefaultRestartConfig() *RestartConfig {
+	return &RestartConfig{
+		MaxRestarts: 3,
+		Backoff:     10 * time.Second,
+	}
+}
+
+// DefaultSidecarConfig returns the default s3fs sidecar resources
+func DefaultSidecarConfig() *SidecarConfig {
+	return &SidecarConfig{
+		CPURequest:    "50m",
+		MemoryRequest: "64Mi",
+		CPULimit:      "100m",
+		MemoryLimit:   "128Mi",
+		MaxCPU:        "1",
+		MaxMemory:     "2Gi",
+	}
+}
+
+// DefaultDurations returns the default intervals and timeouts
+func DefaultDurations() *Durations {
+	return &Durations{
+		CleanupInterval:         time.Minute,
+		IdleTimeout:             5 * time.Minute,
+		ProvisionTimeout:        5 * time.Minute,
+		MonitorInterval:         2 * time.Second,
+		BootstrapTimeout:        30 * time.Minute,
+		ExecRunnerReadyTimeout:  2 * time.Minute,
+		ShutdownGrace:           10 * time.Second,
+		ExecOutputFlushInterval: 50 * time.Millisecond,
+		DeleteWaitTimeout:       2 * time.Minute,
+		APITimeout:              10 * time.Second,
+		MaxReservation:          24 * time.Hour,
+		SSHSessionCheckInterval: time.Minute,
+	}
+}
+
+// DefaultEnvLimits returns the default environment variable limits
+func DefaultEnvLimits() *EnvLimits {
+	return &EnvLimits{
+		MaxValueBytes: 32 * 1024,
+		MaxTotalBytes: 256 * 1024,
+		MaxEntries:    128,
+	}
+}
+
+// DefaultCommandLimits returns the default and highest command limits. 128 KiB is Linux's
+// MAX_ARG_STRLEN, beyond which sh -c can't be started with the command at all.
+func DefaultCommandLimits() *CommandLimits {
+	return &CommandLimits{
+		MaxBytes: 128 * 1024,
+		MaxArgs:  4096,
+	}
+}
+
+// DefaultRateLimits returns the default gRPC API rate limits
+func DefaultRateLimits() *RateLimits {
+	return &RateLimits{
+		MutatingRate:  5,
+		MutatingBurst: 10,
+		ReadRate:      50,
+		ReadBurst:     100,
+
+		IdentityMutatingBurst: 5,
+		IdentityReadBurst:     50,
+	}
+}
+
+// DefaultStreamLimits returns the default gRPC stream limits
+func DefaultStreamLimits() *StreamLimits {
+	return &StreamLimits{
+		MaxConcurrentStreams: 100,
+		MaxExecStreams:       256,
+		MaxExecOutputBytes:   1 << 30,
+		ExecResumeGrace:      30 * time.Second,
+	}
 }
 
 // LoadConfig loads configuration from environment variables and defaults
-func LoadConfig() *Config {
-	return &Config{
-		Kubernetes: loadKubernetesConfig(),
+func LoadConfig() (*Config, error) {
+	return LoadConfigFrom(os.Getenv)
+}
+
+// LoadConfigFrom loads configuration from lookup, which returns the value of an environment
+// variable or "" if unset. Every invalid value is reported in a single ConfigError.
+func LoadConfigFrom(lookup func(key string) string) (*Config, error) {
+	loader := &configLoader{lookup: lookup}
+	config := &Config{
+		Kubernetes:    loader.kubernetesConfig(),
+		EnvLimits:     loader.envLimits(),
+		CommandLimits: loader.commandLimits(),
+		RateLimits:    loader.rateLimits(),
+		StreamLimits:  loader.streamLimits(),
+		Durations:     loader.durations(),
+
+		GroupIdleTimeouts: loader.groupIdleTimeouts(),
+	}
+	loader.string("GRAD_ADMIN_TOKEN", &config.AdminToken)
+	loader.string("IMAGE_CHECK_PULL_SECRET_FILE", &config.ImageCheckPullSecretFile)
+	if err := config.RateLimits.Validate(); err != nil {
+		loader.problems = append(loader.problems, err.Error())
+	}
+	if err := loader.err(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ConfigError lists every invalid configuration value found while loading
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// configLoader reads typed values through lookup, collecting problems instead of stopping at the first
+type configLoader struct {
+	lookup   func(key string) string
+	problems []string
+}
+
+// invalid records a problem with the value of key
+func (l *configLoader) invalid(key, value, reason string) {
+	l.problems = append(l.problems, fmt.Sprintf("%s=%q: %s", key, value, reason))
+}
+
+// err returns a ConfigError if any value was invalid
+func (l *configLoader) err() error {
+	if len(l.problems) == 0 {
+		return nil
+	}
+	return &ConfigError{Problems: l.problems}
+}
+
+// string sets target to the value of key if set
+func (l *configLoader) string(key string, target *string) {
+	if value := l.lookup(key); value != "" {
+		*target = value
+	}
+}
+
+// int sets target to the value of key if set, which must be at least min
+func (l *configLoader) int(key string, target *int, min int) {
+	value := l.lookup(key)
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		l.invalid(key, value, "not an integer")
+		return
+	}
+	if n < min {
+		l.invalid(key, value, fmt.Sprintf("must be at least %d", min))
+		return
+	}
+	*target = n
+}
+
+// float sets target to the value of key if set, which must not be negative
+func (l *configLoader) float(key string, target *float64) {
+	value := l.lookup(key)
+	if value == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.invalid(key, value, "not a number")
+		return
+	}
+	if f < 0 {
+		l.invalid(key, value, "must not be negative")
+		return
+	}
+	*target = f
+}
+
+// bool sets target to the value of key if set
+func (l *configLoader) bool(key string, target *bool) {
+	value := l.lookup(key)
+	if value == "" {
+		return
 	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid(key, value, "not a boolean (use true or false)")
+		return
+	}
+	*target = b
+}
+
+// quantity sets target to the value of key if set, which must be a Kubernetes quantity
+func (l *configLoader) quantity(key string, target *string) {
+	value := l.lookup(key)
+	if value == "" {
+		return
+	}
+	if _, err := resource.ParseQuantity(value); err != nil {
+		l.invalid(key, value, "not a Kubernetes quantity (e.g. 500m, 256Mi)")
+		return
+	}
+	*target = value
 }
 
-// loadKubernetesConfig loads Kubernetes configuration from environment variables
-func loadKubernetesConfig() *KubernetesConfig {
+// positiveQuantity is quantity for container resources, which must also be above zero
+func (l *configLoader) positiveQuantity(key string, target *string) {
+	value := l.lookup(key)
+	if value == "" {
+		return
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		l.invalid(key, value, "not a Kubernetes quantity (e.g. 500m, 256Mi)")
+		return
+	}
+	if quantity.Sign() <= 0 {
+		l.invalid(key, value, "must be positive")
+		return
+	}
+	*target = value
+}
+
+// duration sets target to the value of key if set, which must be a Go duration within [min, max]
+func (l *configLoader) duration(key string, target *time.Duration, min, max time.Duration) {
+	value := l.lookup(key)
+	if value == "" {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		l.invalid(key, value, "not a duration (e.g. 30s, 5m, 1h30m)")
+		return
+	}
+	if d < min || d > max {
+		l.invalid(key, value, fmt.Sprintf("must be between %s and %s", min, max))
+		return
+	}
+	*target = d
+}
+
+// kubernetesConfig loads Kubernetes configuration
+func (l *configLoader) kubernetesConfig() *KubernetesConfig {
 	config := DefaultKubernetesConfig()
 
-	// Override with environment variables if provided
-	if namespace := os.Getenv("KUBERNETES_NAMESPACE"); namespace != "" {
-		config.Namespace = namespace
+	l.string("KUBERNETES_NAMESPACE", &config.Namespace)
+
+	// Override images if provided (handles skaffold dynamic tags)
+	l.string("RUNNER_IMAGE", &config.RunnerImage)
+	l.string("S3FS_IMAGE", &config.S3FSImage)
+
+	// Resources of every runner, parsed here so a typo fails startup rather than each create
+	l.positiveQuantity("RUNNER_DEFAULT_CPU", &config.DefaultCPU)
+	l.positiveQuantity("RUNNER_DEFAULT_MEMORY", &config.DefaultMemory)
+	l.positiveQuantity("RUNNER_DEFAULT_STORAGE", &config.DefaultStorage)
+
+	l.string("RUNNER_DEFAULT_SHELL", &config.DefaultShell)
+	l.string("RUNNER_DEFAULT_WORKDIR", &config.DefaultWorkdir)
+	if err := validateExecDefaults(config.DefaultShell, config.DefaultWorkdir); err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("RUNNER_DEFAULT_SHELL/RUNNER_DEFAULT_WORKDIR: %v", err))
+	}
+
+	sshPort := int(config.SSHPort)
+	l.int("SSH_PORT", &sshPort, 1)
+	if sshPort > 65535 {
+		l.invalid("SSH_PORT", strconv.Itoa(sshPort), "must be at most 65535")
+	} else {
+		config.SSHPort = int32(sshPort)
+	}
+
+	config.Namespaces = &NamespaceConfig{}
+	l.quantity("NAMESPACE_QUOTA_CPU", &config.Namespaces.QuotaCPU)
+	l.quantity("NAMESPACE_QUOTA_MEMORY", &config.Namespaces.QuotaMemory)
+	l.quantity("NAMESPACE_QUOTA_PODS", &config.Namespaces.QuotaPods)
+	l.quantity("NAMESPACE_LIMIT_CPU", &config.Namespaces.LimitCPU)
+	l.quantity("NAMESPACE_LIMIT_MEMORY", &config.Namespaces.LimitMemory)
+	l.bool("ENSURE_NAMESPACE", &config.Namespaces.Ensure)
+	l.bool("CLEANUP_EMPTY_NAMESPACE", &config.Namespaces.CleanupEmpty)
+
+	config.NetworkPolicy = &NetworkPolicyConfig{
+		Mode:            l.lookup("RUNNER_NETWORK_POLICY"),
+		SSHNamespaces:   splitList(l.lookup("RUNNER_NETWORK_POLICY_SSH_NAMESPACES")),
+		SSHPodSelector:  l.lookup("RUNNER_NETWORK_POLICY_SSH_POD_SELECTOR"),
+		GradPodSelector: DefaultGradPodSelector,
+		S3Endpoint:      l.lookup("RUNNER_NETWORK_POLICY_S3_ENDPOINT"),
+		EgressCIDRs:     splitList(l.lookup("RUNNER_NETWORK_POLICY_EGRESS_CIDRS")),
 	}
+	l.string("RUNNER_NETWORK_POLICY_GRAD_SELECTOR", &config.NetworkPolicy.GradPodSelector)
 
-	// Override runner image if provided (handles skaffold dynamic tags)
-	if runnerImage := os.Getenv("RUNNER_IMAGE"); runnerImage != "" {
-		config.RunnerImage = runnerImage
+	config.ServiceAccount = &ServiceAccountConfig{
+		Name:    l.lookup("RUNNER_SERVICE_ACCOUNT"),
+		Allowed: splitList(l.lookup("RUNNER_ALLOWED_SERVICE_ACCOUNTS")),
 	}
+	l.bool("RUNNER_AUTOMOUNT_SERVICE_ACCOUNT_TOKEN", &config.ServiceAccount.AutomountToken)
 
-	// Override S3FS sidecar image if provided (handles skaffold dynamic tags)
-	if s3fsImage := os.Getenv("S3FS_IMAGE"); s3fsImage != "" {
-		config.S3FSImage = s3fsImage
+	// Auto-created runners can get a lower, preemptible priority than interactive ones
+	l.string("RUNNER_PRIORITY_CLASS", &config.PriorityClassName)
+	l.string("RUNNER_AUTO_PRIORITY_CLASS", &config.AutoPriorityClassName)
+	config.AllowedPriorityClasses = splitList(l.lookup("RUNNER_ALLOWED_PRIORITY_CLASSES"))
+
+	// Mixed clusters may have nodes of only some of the architectures the runner image supports
+	if archs := splitList(l.lookup("RUNNER_SUPPORTED_ARCHS")); len(archs) > 0 {
+		config.SupportedArchs = archs
+	}
+
+	// Spot runners land on tainted spot/preemptible nodes; without either they can't be created
+	config.Spot = &SpotConfig{}
+	if value := l.lookup("RUNNER_SPOT_TOLERATIONS"); value != "" {
+		tolerations, err := ParseTolerations(value)
+		if err != nil {
+			l.invalid("RUNNER_SPOT_TOLERATIONS", value, err.Error())
+		}
+		config.Spot.Tolerations = tolerations
+	}
+	if value := l.lookup("RUNNER_SPOT_NODE_SELECTOR"); value != "" {
+		selector, err := ParseNodeSelector(value)
+		if err != nil {
+			l.invalid("RUNNER_SPOT_NODE_SELECTOR", value, err.Error())
+		}
+		config.Spot.NodeSelector = selector
 	}
 
-	if sshPortStr := os.Getenv("SSH_PORT"); sshPortStr != "" {
-		if port, err := strconv.ParseInt(sshPortStr, 10, 32); err == nil {
-			config.SSHPort = int32(port)
+	// Air-gapped clusters turn off the integrations that would reach outside them
+	if value := l.lookup("GRAD_DISABLE_FEATURES"); value != "" {
+		features, err := ParseDisabledFeatures(value)
+		if err != nil {
+			l.invalid("GRAD_DISABLE_FEATURES", value, err.Error())
 		}
+		config.DisabledFeatures = features
+	}
+
+	// Resources of the s3fs sidecar; large datasets may need more memory than the defaults
+	config.Sidecar = DefaultSidecarConfig()
+	l.positiveQuantity("S3FS_CPU_REQUEST", &config.Sidecar.CPURequest)
+	l.positiveQuantity("S3FS_MEMORY_REQUEST", &config.Sidecar.MemoryRequest)
+	l.positiveQuantity("S3FS_CPU_LIMIT", &config.Sidecar.CPULimit)
+	l.positiveQuantity("S3FS_MEMORY_LIMIT", &config.Sidecar.MemoryLimit)
+	l.quantity("S3FS_MAX_CPU", &config.Sidecar.MaxCPU)
+	l.quantity("S3FS_MAX_MEMORY", &config.Sidecar.MaxMemory)
+
+	// Proxy and CA trust settings for runners behind a corporate proxy
+	config.Proxy = &ProxyConfig{
+		URL:              l.lookup("RUNNER_PROXY_URL"),
+		NoProxy:          l.lookup("RUNNER_NO_PROXY"),
+		ExtraCAConfigMap: l.lookup("RUNNER_EXTRA_CA_CONFIGMAP"),
 	}
 
+	l.dns(config)
+
+	config.StableDNS = &StableDNSConfig{ClusterDomain: DefaultClusterDomain}
+	l.bool("RUNNER_HEADLESS_SERVICE", &config.StableDNS.Enabled)
+	l.string("CLUSTER_DOMAIN", &config.StableDNS.ClusterDomain)
+	if err := validateClusterDomain(config.StableDNS.ClusterDomain); err != nil {
+		l.invalid("CLUSTER_DOMAIN", config.StableDNS.ClusterDomain, err.Error())
+	}
+
+	// Recreating failed runner pods
+	config.Restart = DefaultRestartConfig()
+	l.bool("RUNNER_AUTO_RESTART", &config.Restart.Default)
+	l.int("RUNNER_MAX_RESTARTS", &config.Restart.MaxRestarts, 0)
+	l.duration("RUNNER_RESTART_BACKOFF", &config.Restart.Backoff, time.Second, MaxRestartBackoff)
+
+	// Clusters whose autoscaler adds nodes for pending pods may only want a warning
+	config.Capacity = DefaultCapacityConfig()
+	l.string("RUNNER_CAPACITY_CHECK", &config.Capacity.Mode)
+	if err := validateCapacityCheckMode(config.Capacity.Mode); err != nil {
+		l.invalid("RUNNER_CAPACITY_CHECK", config.Capacity.Mode, err.Error())
+	}
+	l.duration("RUNNER_CAPACITY_CACHE_TTL", &config.Capacity.CacheTTL, 0, 10*time.Minute)
+
 	return config
 }
+
+// dns loads the DNS policy, resolver settings and host aliases of runner pods
+func (l *configLoader) dns(config *KubernetesConfig) {
+	config.DNSConfig = &DNSConfig{
+		Nameservers: splitList(l.lookup("RUNNER_DNS_NAMESERVERS")),
+		Searches:    splitList(l.lookup("RUNNER_DNS_SEARCHES")),
+		Options:     splitList(l.lookup("RUNNER_DNS_OPTIONS")),
+	}
+	if err := validateDNSConfig(config.DNSConfig); err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("RUNNER_DNS_NAMESERVERS/RUNNER_DNS_SEARCHES/RUNNER_DNS_OPTIONS: %v", err))
+	}
+
+	l.string("RUNNER_DNS_POLICY", &config.DNSPolicy)
+	if err := validateDNSPolicy(config.DNSPolicy, config.DNSConfig); err != nil {
+		l.invalid("RUNNER_DNS_POLICY", config.DNSPolicy, err.Error())
+	}
+
+	// Host aliases come from the variable, a file in /etc/hosts format such as a mounted ConfigMap, or both
+	if value := l.lookup("RUNNER_HOST_ALIASES"); value != "" {
+		aliases, err := ParseHostAliases(value)
+		if err == nil {
+			err = validateHostAliases(aliases)
+		}
+		if err != nil {
+			l.invalid("RUNNER_HOST_ALIASES", value, err.Error())
+		} else {
+			config.HostAliases = append(config.HostAliases, aliases...)
+		}
+	}
+	if path := l.lookup("RUNNER_HOST_ALIASES_FILE"); path != "" {
+		aliases, err := readHostsFile(path)
+		if err == nil {
+			err = validateHostAliases(aliases)
+		}
+		if err != nil {
+			l.invalid("RUNNER_HOST_ALIASES_FILE", path, err.Error())
+		} else {
+			config.HostAliases = append(config.HostAliases, aliases...)
+		}
+	}
+}
+
+// readHostsFile reads host aliases from the /etc/hosts format file at path
+func readHostsFile(path string) ([]HostAlias, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseHostsFile(file)
+}
+
+// envLimits loads environment variable limits
+func (l *configLoader) envLimits() *EnvLimits {
+	limits := DefaultEnvLimits()
+	l.int("ENV_MAX_VALUE_BYTES", &limits.MaxValueBytes, 1)
+	l.int("ENV_MAX_TOTAL_BYTES", &limits.MaxTotalBytes, 1)
+	l.int("ENV_MAX_ENTRIES", &limits.MaxEntries, 1)
+	return limits
+}
+
+// commandLimits loads command limits, which may only be lowered
+func (l *configLoader) commandLimits() *CommandLimits {
+	limits, most := DefaultCommandLimits(), DefaultCommandLimits()
+	l.int("EXEC_MAX_COMMAND_BYTES", &limits.MaxBytes, 1)
+	l.int("EXEC_MAX_ARGS", &limits.MaxArgs, 1)
+	if limits.MaxBytes > most.MaxBytes {
+		l.invalid("EXEC_MAX_COMMAND_BYTES", fmt.Sprint(limits.MaxBytes), fmt.Sprintf("must be at most %d", most.MaxBytes))
+	}
+	if limits.MaxArgs > most.MaxArgs {
+		l.invalid("EXEC_MAX_ARGS", fmt.Sprint(limits.MaxArgs), fmt.Sprintf("must be at most %d", most.MaxArgs))
+	}
+	return limits
+}
+
+// rateLimits loads gRPC API rate limits
+func (l *configLoader) rateLimits() *RateLimits {
+	limits := DefaultRateLimits()
+	l.float("RATE_LIMIT_MUTATING_RPS", &limits.MutatingRate)
+	l.int("RATE_LIMIT_MUTATING_BURST", &limits.MutatingBurst, 1)
+	l.float("RATE_LIMIT_READ_RPS", &limits.ReadRate)
+	l.int("RATE_LIMIT_READ_BURST", &limits.ReadBurst, 1)
+	l.float("RATE_LIMIT_IDENTITY_MUTATING_RPS", &limits.IdentityMutatingRate)
+	l.int("RATE_LIMIT_IDENTITY_MUTATING_BURST", &limits.IdentityMutatingBurst, 1)
+	l.float("RATE_LIMIT_IDENTITY_READ_RPS", &limits.IdentityReadRate)
+	l.int("RATE_LIMIT_IDENTITY_READ_BURST", &limits.IdentityReadBurst, 1)
+	return limits
+}
+
+// streamLimits loads gRPC stream limits
+func (l *configLoader) streamLimits() *StreamLimits {
+	limits := DefaultStreamLimits()
+	l.int("GRPC_MAX_CONCURRENT_STREAMS", &limits.MaxConcurrentStreams, 1)
+	l.int("GRPC_MAX_EXEC_STREAMS", &limits.MaxExecStreams, 0)
+	l.int("GRPC_MAX_EXEC_OUTPUT_BYTES", &limits.MaxExecOutputBytes, 0)
+	l.duration("GRPC_EXEC_RESUME_GRACE", &limits.ExecResumeGrace, 0, time.Hour)
+	return limits
+}
+
+// durations loads the intervals and timeouts of background work
+func (l *configLoader) durations() *Durations {
+	durations := DefaultDurations()
+	for _, setting := range durationSettings(durations) {
+		l.duration(setting.Key, setting.Target, setting.Min, setting.Max)
+	}
+
+	// Polling slower than the timeout would never see the runner become ready
+	if durations.MonitorInterval >= durations.ProvisionTimeout {
+		l.problems = append(l.problems, fmt.Sprintf("RUNNER_MONITOR_INTERVAL (%s) must be shorter than RUNNER_PROVISION_TIMEOUT (%s)",
+			durations.MonitorInterval, durations.ProvisionTimeout))
+	}
+
+	return durations
+}
+
+// groupIdleTimeouts loads per-group idle timeouts, bounded like RUNNER_IDLE_TIMEOUT
+func (l *configLoader) groupIdleTimeouts() GroupIdleTimeouts {
+	const key = "RUNNER_GROUP_IDLE_TIMEOUTS"
+	value := l.lookup(key)
+	if value == "" {
+		return nil
+	}
+
+	timeouts, err := ParseGroupIdleTimeouts(value)
+	if err != nil {
+		l.invalid(key, value, err.Error())
+		return nil
+	}
+
+	for _, setting := range durationSettings(DefaultDurations()) {
+		if setting.Key != "RUNNER_IDLE_TIMEOUT" {
+			continue
+		}
+		for _, timeout := range timeouts {
+			if timeout.Timeout < setting.Min || timeout.Timeout > setting.Max {
+				l.invalid(key, value, fmt.Sprintf("timeout for %q must be between %s and %s", timeout.Pattern, setting.Min, setting.Max))
+				return nil
+			}
+		}
+	}
+	return timeouts
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
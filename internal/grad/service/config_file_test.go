@@ -0,0 +1,49 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	values, err := ParseConfigFile(strings.NewReader(`# Runner cleanup
+RUNNER_IDLE_TIMEOUT = 20m
+
+RUNNER_ALLOWED_PRIORITY_CLASSES=batch,interactive
+RUNNER_GROUP_IDLE_TIMEOUTS=ci-*=15m
+CLEANUP_INTERVAL=
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := map[string]string{
+		"RUNNER_IDLE_TIMEOUT":             "20m",
+		"RUNNER_ALLOWED_PRIORITY_CLASSES": "batch,interactive",
+		"RUNNER_GROUP_IDLE_TIMEOUTS":      "ci-*=15m",
+		"CLEANUP_INTERVAL":                "",
+	}
+	if len(values) != len(expected) {
+		t.Errorf("Expected %d values, got %v", len(expected), values)
+	}
+	for key, value := range expected {
+		if got, ok := values[key]; !ok || got != value {
+			t.Errorf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"RUNNER_IDLE_TIMEOUT=20m\nCLEANUP_INTERVAL\n", `line 2: expected KEY=VALUE, got "CLEANUP_INTERVAL"`},
+		{"=20m\n", `line 1: expected KEY=VALUE, got "=20m"`},
+		{"# idle\nRUNNER IDLE TIMEOUT=20m\n", `line 2: expected KEY=VALUE, got "RUNNER IDLE TIMEOUT=20m"`},
+	}
+	for _, tt := range tests {
+		if _, err := ParseConfigFile(strings.NewReader(tt.content)); err == nil || err.Error() != tt.expected {
+			t.Errorf("Expected %q, got %v", tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mapLookup returns a lookup function reading from env
+func mapLookup(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestLoadConfigFromDefaults(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(nil))
+	if err != nil {
+		t.Fatalf("Expected defaults to be valid, got %v", err)
+	}
+	if *config.Durations != *DefaultDurations() {
+		t.Errorf("Expected default durations, got %+v", *config.Durations)
+	}
+	if *config.RateLimits != *DefaultRateLimits() {
+		t.Errorf("Expected default rate limits, got %+v", *config.RateLimits)
+	}
+	if *config.StreamLimits != *DefaultStreamLimits() {
+		t.Errorf("Expected default stream limits, got %+v", *config.StreamLimits)
+	}
+}
+
+func TestLoadConfigFromDurations(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{
+		"CLEANUP_INTERVAL":           "30s",
+		"RUNNER_IDLE_TIMEOUT":        "1h30m",
+		"RUNNER_PROVISION_TIMEOUT":   "10m",
+		"RUNNER_MONITOR_INTERVAL":    "500ms",
+		"RUNNER_BOOTSTRAP_TIMEOUT":   "2h",
+		"EXEC_RUNNER_READY_TIMEOUT":  "3m",
+		"SHUTDOWN_GRACE":             "0s",
+		"EXEC_OUTPUT_FLUSH_INTERVAL": "0s",
+		"RUNNER_DELETE_WAIT_TIMEOUT": "10m",
+		"KUBERNETES_API_TIMEOUT":     "30s",
+		"RUNNER_MAX_RESERVATION":     "8h",
+		"SSH_SESSION_CHECK_INTERVAL": "0s",
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := Durations{
+		CleanupInterval:         30 * time.Second,
+		IdleTimeout:             90 * time.Minute,
+		ProvisionTimeout:        10 * time.Minute,
+		MonitorInterval:         500 * time.Millisecond,
+		BootstrapTimeout:        2 * time.Hour,
+		ExecRunnerReadyTimeout:  3 * time.Minute,
+		ShutdownGrace:           0,
+		ExecOutputFlushInterval: 0,
+		DeleteWaitTimeout:       10 * time.Minute,
+		APITimeout:              30 * time.Second,
+		MaxReservation:          8 * time.Hour,
+		SSHSessionCheckInterval: 0,
+	}
+	if *config.Durations != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *config.Durations)
+	}
+}
+
+func TestLoadConfigFromInvalidDuration(t *testing.T) {
+	tests := []struct {
+		name          string
+		key           string
+		value         string
+		expectMessage string
+	}{
+		{"Bare number", "RUNNER_IDLE_TIMEOUT", "300", "not a duration"},
+		{"Unknown unit", "CLEANUP_INTERVAL", "1 minute", "not a duration"},
+		{"Below minimum", "CLEANUP_INTERVAL", "10ms", "must be between 1s and 24h0m0s"},
+		{"Above maximum", "SHUTDOWN_GRACE", "1h", "must be between 0s and 5m0s"},
+		{"Negative", "RUNNER_PROVISION_TIMEOUT", "-5m", "must be between"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFrom(mapLookup(map[string]string{tt.key: tt.value}))
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("Expected a ConfigError, got %v", err)
+			}
+			if len(configErr.Problems) != 1 {
+				t.Fatalf("Expected 1 problem, got %v", configErr.Problems)
+			}
+			if problem := configErr.Problems[0]; !strings.HasPrefix(problem, tt.key) || !strings.Contains(problem, tt.expectMessage) {
+				t.Errorf("Expected problem for %s mentioning %q, got %q", tt.key, tt.expectMessage, problem)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromGroupIdleTimeouts(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{"RUNNER_GROUP_IDLE_TIMEOUTS": "ci-*=15m, nightly=2h"}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := config.GroupIdleTimeouts.String(); got != "ci-*=15m0s,nightly=2h0m0s" {
+		t.Errorf("Expected ci-*=15m0s,nightly=2h0m0s, got %q", got)
+	}
+
+	tests := []struct {
+		name          string
+		value         string
+		expectMessage string
+	}{
+		{"Missing duration", "ci-*", "is not pattern=duration"},
+		{"Bad pattern", "ci-[=15m", "invalid group pattern"},
+		{"Bad duration", "ci-*=soon", "invalid idle timeout"},
+		{"Below minimum", "ci-*=10s", "must be between 1m0s and"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFrom(mapLookup(map[string]string{"RUNNER_GROUP_IDLE_TIMEOUTS": tt.value}))
+			if err == nil || !strings.Contains(err.Error(), tt.expectMessage) {
+				t.Errorf("Expected an error mentioning %q, got %v", tt.expectMessage, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromMonitorIntervalBelowProvisionTimeout(t *testing.T) {
+	_, err := LoadConfigFrom(mapLookup(map[string]string{
+		"RUNNER_MONITOR_INTERVAL":  "30s",
+		"RUNNER_PROVISION_TIMEOUT": "20s",
+	}))
+	if err == nil || !strings.Contains(err.Error(), "must be shorter than RUNNER_PROVISION_TIMEOUT") {
+		t.Errorf("Expected the monitor interval to be checked against the provision timeout, got %v", err)
+	}
+}
+
+func TestLoadConfigFromAggregatesProblems(t *testing.T) {
+	_, err := LoadConfigFrom(mapLookup(map[string]string{
+		"RUNNER_IDLE_TIMEOUT":       "forever",
+		"SSH_PORT":                  "70000",
+		"ENV_MAX_ENTRIES":           "0",
+		"RATE_LIMIT_READ_RPS":       "fast",
+		"ENSURE_NAMESPACE":          "maybe",
+		"NAMESPACE_QUOTA_MEMORY":    "lots",
+		"EXEC_RUNNER_READY_TIMEOUT": "1s",
+	}))
+
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a ConfigError, got %v", err)
+	}
+	if len(configErr.Problems) != 7 {
+		t.Errorf("Expected 7 problems, got %d: %v", len(configErr.Problems), configErr.Problems)
+	}
+	for _, key := range []string{"RUNNER_IDLE_TIMEOUT", "SSH_PORT", "ENV_MAX_ENTRIES", "RATE_LIMIT_READ_RPS", "ENSURE_NAMESPACE", "NAMESPACE_QUOTA_MEMORY", "EXEC_RUNNER_READY_TIMEOUT"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected error to mention %s, got: %v", key, err)
+		}
+	}
+}
+
+func TestLoadConfigFromRateLimitValidation(t *testing.T) {
+	_, err := LoadConfigFrom(mapLookup(map[string]string{
+		"RATE_LIMIT_IDENTITY_READ_RPS":   "1",
+		"RATE_LIMIT_IDENTITY_READ_BURST": "0",
+	}))
+	if err == nil {
+		t.Fatal("Expected a zero burst to be rejected")
+	}
+}
+
+func TestLoadConfigFromCommandLimits(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{"EXEC_MAX_COMMAND_BYTES": "65536"}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if limits := config.CommandLimits; limits.MaxBytes != 65536 || limits.MaxArgs != DefaultCommandLimits().MaxArgs {
+		t.Errorf("Expected a lowered byte limit and the default word limit, got %+v", limits)
+	}
+
+	// Limits can't be raised past what a shell can take
+	_, err = LoadConfigFrom(mapLookup(map[string]string{"EXEC_MAX_COMMAND_BYTES": "262144", "EXEC_MAX_ARGS": "0"}))
+	if err == nil || !strings.Contains(err.Error(), "EXEC_MAX_COMMAND_BYTES=\"262144\": must be at most 131072") || !strings.Contains(err.Error(), "EXEC_MAX_ARGS") {
+		t.Errorf("Expected both limits to be rejected, got %v", err)
+	}
+}
+
+func TestLoadConfigFromExecDefaults(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{
+		"RUNNER_DEFAULT_SHELL":   "zsh",
+		"RUNNER_DEFAULT_WORKDIR": "/workspace",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if config.Kubernetes.DefaultShell != "zsh" || config.Kubernetes.DefaultWorkdir != "/workspace" {
+		t.Errorf("Expected zsh and /workspace, got %q and %q", config.Kubernetes.DefaultShell, config.Kubernetes.DefaultWorkdir)
+	}
+
+	_, err = LoadConfigFrom(mapLookup(map[string]string{"RUNNER_DEFAULT_WORKDIR": "workspace"}))
+	if err == nil || !strings.Contains(err.Error(), "RUNNER_DEFAULT_WORKDIR") {
+		t.Errorf("Expected a relative RUNNER_DEFAULT_WORKDIR to be rejected, got %v", err)
+	}
+}
+
+func TestLoadConfigFromSupportedArchs(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if !slices.Equal(config.Kubernetes.SupportedArchs, DefaultSupportedArchs) {
+		t.Errorf("Expected %v, got %v", DefaultSupportedArchs, config.Kubernetes.SupportedArchs)
+	}
+
+	config, err = LoadConfigFrom(mapLookup(map[string]string{"RUNNER_SUPPORTED_ARCHS": "arm64"}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if !slices.Equal(config.Kubernetes.SupportedArchs, []string{"arm64"}) {
+		t.Errorf("Expected only arm64, got %v", config.Kubernetes.SupportedArchs)
+	}
+}
+
+func TestLoadConfigFromSpot(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if config.Kubernetes.Spot.enabled() {
+		t.Errorf("Expected spot runners to be off by default, got %+v", config.Kubernetes.Spot)
+	}
+
+	config, err = LoadConfigFrom(mapLookup(map[string]string{
+		"RUNNER_SPOT_TOLERATIONS":   "spot=true:NoSchedule",
+		"RUNNER_SPOT_NODE_SELECTOR": "pool=spot",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if spot := config.Kubernetes.Spot; len(spot.Tolerations) != 1 || spot.Tolerations[0].Value != "true" || spot.NodeSelector["pool"] != "spot" {
+		t.Errorf("Expected the spot toleration and node label, got %+v", spot)
+	}
+
+	_, err = LoadConfigFrom(mapLookup(map[string]string{"RUNNER_SPOT_TOLERATIONS": "spot=true:Never"}))
+	if err == nil || !strings.Contains(err.Error(), "RUNNER_SPOT_TOLERATIONS") {
+		t.Errorf("Expected an invalid RUNNER_SPOT_TOLERATIONS, got %v", err)
+	}
+}
+
+func TestLoadConfigFromRestart(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{
+		"RUNNER_AUTO_RESTART":    "true",
+		"RUNNER_MAX_RESTARTS":    "5",
+		"RUNNER_RESTART_BACKOFF": "30s",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	expected := RestartConfig{Default: true, MaxRestarts: 5, Backoff: 30 * time.Second}
+	if *config.Kubernetes.Restart != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *config.Kubernetes.Restart)
+	}
+
+	_, err = LoadConfigFrom(mapLookup(map[string]string{"RUNNER_RESTART_BACKOFF": "1h"}))
+	if err == nil || !strings.Contains(err.Error(), "RUNNER_RESTART_BACKOFF") {
+		t.Errorf("Expected a backoff above %s to be rejected, got %v", MaxRestartBackoff, err)
+	}
+}
+
+func TestLoadConfigFromCapacity(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if *config.Kubernetes.Capacity != *DefaultCapacityConfig() {
+		t.Errorf("Expected the default capacity check, got %+v", *config.Kubernetes.Capacity)
+	}
+
+	config, err = LoadConfigFrom(mapLookup(map[string]string{
+		"RUNNER_CAPACITY_CHECK":     "warn",
+		"RUNNER_CAPACITY_CACHE_TTL": "0s",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	expected := CapacityConfig{Mode: CapacityCheckWarn}
+	if *config.Kubernetes.Capacity != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *config.Kubernetes.Capacity)
+	}
+
+	_, err = LoadConfigFrom(mapLookup(map[string]string{"RUNNER_CAPACITY_CHECK": "strict"}))
+	if err == nil || !strings.Contains(err.Error(), "RUNNER_CAPACITY_CHECK") {
+		t.Errorf("Expected an unknown mode to be rejected, got %v", err)
+	}
+}
+
+func TestLoadConfigFromStableDNS(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if config.Kubernetes.StableDNS.Enabled || config.Kubernetes.StableDNS.ClusterDomain != DefaultClusterDomain {
+		t.Errorf("Expected stable DNS off with domain %s, got %+v", DefaultClusterDomain, *config.Kubernetes.StableDNS)
+	}
+
+	config, err = LoadConfigFrom(mapLookup(map[string]string{
+		"RUNNER_HEADLESS_SERVICE": "true",
+		"CLUSTER_DOMAIN":          "corp.internal",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	expected := StableDNSConfig{Enabled: true, ClusterDomain: "corp.internal"}
+	if *config.Kubernetes.StableDNS != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *config.Kubernetes.StableDNS)
+	}
+
+	_, err = LoadConfigFrom(mapLookup(map[string]string{"CLUSTER_DOMAIN": "not_a_domain"}))
+	if err == nil || !strings.Contains(err.Error(), "CLUSTER_DOMAIN") {
+		t.Errorf("Expected an invalid CLUSTER_DOMAIN to be rejected, got %v", err)
+	}
+}
+
+func TestLoadConfigFromResourceQuantities(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{
+		"RUNNER_DEFAULT_CPU":    "4",
+		"RUNNER_DEFAULT_MEMORY": "8Gi",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if config.Kubernetes.DefaultCPU != "4" || config.Kubernetes.DefaultMemory != "8Gi" {
+		t.Errorf("Expected 4 and 8Gi, got %q and %q", config.Kubernetes.DefaultCPU, config.Kubernetes.DefaultMemory)
+	}
+
+	tests := []struct {
+		key           string
+		value         string
+		expectMessage string
+	}{
+		{"RUNNER_DEFAULT_MEMORY", "2Gib", "not a Kubernetes quantity"},
+		{"RUNNER_DEFAULT_CPU", "0", "must be positive"},
+		{"RUNNER_DEFAULT_STORAGE", "lots", "not a Kubernetes quantity"},
+		{"S3FS_MEMORY_LIMIT", "-1Gi", "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			_, err := LoadConfigFrom(mapLookup(map[string]string{tt.key: tt.value}))
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) || len(configErr.Problems) != 1 {
+				t.Fatalf("Expected a ConfigError with 1 problem, got %v", err)
+			}
+			if problem := configErr.Problems[0]; !strings.HasPrefix(problem, tt.key) || !strings.Contains(problem, tt.expectMessage) {
+				t.Errorf("Expected problem for %s mentioning %q, got %q", tt.key, tt.expectMessage, problem)
+			}
+		})
+	}
+}
+
+func TestDurationSettingsCoverDefaults(t *testing.T) {
+	for _, setting := range DefaultDurations().Settings() {
+		if *setting.Target < setting.Min || *setting.Target > setting.Max {
+			t.Errorf("Expected default %s of %s to be within [%s, %s]", setting.Key, *setting.Target, setting.Min, setting.Max)
+		}
+	}
+	if settings := DefaultDurations().Settings(); len(settings) != 12 {
+		t.Errorf("Expected every duration to have a setting, got %d", len(settings))
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Correlation variables set in the environment of every executed command, so that the
+// telemetry of user workloads can be joined with grad's
+const (
+	CorrelationEnvRunnerID = "GRAD_RUNNER_ID"
+	CorrelationEnvExecID   = "GRAD_EXEC_ID"
+	// CorrelationEnvTraceParent is the W3C trace context of grad's span for the request, only
+	// set when the request is traced
+	CorrelationEnvTraceParent = "TRACEPARENT"
+)
+
+// correlationEnv returns the correlation variables of the command of req, nil if the request
+// disables them. TRACEPARENT is left out if the server has tracing disabled.
+func correlationEnv(ctx context.Context, req *ExecuteCommandRequest, features DisabledFeatures) map[string]string {
+	if req.DisableCorrelationEnv {
+		return nil
+	}
+	env := map[string]string{CorrelationEnvRunnerID: req.RunnerID}
+	if req.ExecID != "" {
+		env[CorrelationEnvExecID] = req.ExecID
+	}
+	if features.Disabled(FeatureTracing) {
+		return env
+	}
+	if traceParent := traceParent(trace.SpanContextFromContext(ctx)); traceParent != "" {
+		env[CorrelationEnvTraceParent] = traceParent
+	}
+	return env
+}
+
+// traceParent formats a span context as a W3C traceparent, "" if it isn't valid (pure function)
+func traceParent(span trace.SpanContext) string {
+	if !span.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-%s", span.TraceID(), span.SpanID(), span.TraceFlags())
+}
+
+// withEnv returns argv run through env with the variables of env set in name order, or argv
+// itself without any (pure function)
+func withEnv(env map[string]string, argv []string) []string {
+	if len(env) == 0 {
+		return argv
+	}
+	wrapped := []string{"env"}
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		wrapped = append(wrapped, name+"="+env[name])
+	}
+	return append(wrapped, argv...)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCorrelationEnv(t *testing.T) {
+	req := &ExecuteCommandRequest{RunnerID: "runner-1", ExecID: "exec-1", Command: "python train.py"}
+
+	t.Run("Untraced", func(t *testing.T) {
+		got := withEnv(correlationEnv(context.Background(), req, nil), execArgv("bash", "", req))
+		expected := []string{"env", "GRAD_EXEC_ID=exec-1", "GRAD_RUNNER_ID=runner-1", "bash", "-c", "python train.py"}
+		if !slices.Equal(got, expected) {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+	})
+
+	t.Run("Traced", func(t *testing.T) {
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+		ctx := trace.ContextWithSpanContext(context.Background(), span)
+
+		env := correlationEnv(ctx, req, nil)
+		expected := "00-" + span.TraceID().String() + "-" + span.SpanID().String() + "-01"
+		if env[CorrelationEnvTraceParent] != expected {
+			t.Errorf("Expected traceparent %q of the server span, got %q", expected, env[CorrelationEnvTraceParent])
+		}
+		got := withEnv(env, []string{"nvidia-smi"})
+		if !slices.Equal(got, []string{"env", "GRAD_EXEC_ID=exec-1", "GRAD_RUNNER_ID=runner-1", "TRACEPARENT=" + expected, "nvidia-smi"}) {
+			t.Errorf("Expected the traceparent set in the environment, got %q", got)
+		}
+
+		// Servers with tracing disabled keep the other variables
+		env = correlationEnv(ctx, req, DisabledFeatures{FeatureTracing: true})
+		if _, ok := env[CorrelationEnvTraceParent]; ok || env[CorrelationEnvExecID] != "exec-1" {
+			t.Errorf("Expected the correlation variables without traceparent, got %v", env)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		disabled := *req
+		disabled.DisableCorrelationEnv = true
+		if env := correlationEnv(context.Background(), &disabled, nil); env != nil {
+			t.Errorf("Expected no variables, got %v", env)
+		}
+		if got := withEnv(nil, []string{"nvidia-smi"}); !slices.Equal(got, []string{"nvidia-smi"}) {
+			t.Errorf("Expected the command unchanged, got %q", got)
+		}
+	})
+}
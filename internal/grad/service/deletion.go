@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// WaitForRunnerDeleted waits until the pod of a deleted runner and the objects deleted with it
+// are gone, checking every monitor interval. It waits for at most timeout, or for the
+// configured RUNNER_DELETE_WAIT_TIMEOUT if timeout is zero or longer. Objects still present
+// when the wait times out are returned; that is not an error, the deletion goes on without it.
+func (s *runnerService) WaitForRunnerDeleted(ctx context.Context, runnerID string, timeout time.Duration) ([]RunnerResource, error) {
+	if timeout <= 0 || timeout > s.deleteWaitTimeout {
+		timeout = s.deleteWaitTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.monitorInterval)
+	defer ticker.Stop()
+
+	var remaining []RunnerResource
+	for {
+		current, err := s.k8sClient.RemainingRunnerResources(waitCtx, runnerID)
+		switch {
+		case err == nil:
+			remaining = current
+			if len(remaining) == 0 {
+				return nil, nil
+			}
+		case waitCtx.Err() == nil:
+			return nil, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+		}
+
+		select {
+		case <-waitCtx.Done():
+			// Only the caller going away is an error; running out of time reports what's left
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			slog.Info("Runner still being deleted after waiting",
+				"runner_id", runnerID,
+				"timeout", timeout.String(),
+				"remaining", len(remaining))
+			return remaining, nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// emulateSlowTermination makes deleting pods and claims on clientset only mark them for
+// deletion, the way a pod stays while its containers shut down and a claim stays while a pod
+// still uses it. Calling the returned function removes the objects marked so far.
+func emulateSlowTermination(clientset *fake.Clientset) (terminate func()) {
+	tracker := clientset.Tracker()
+
+	var mu sync.Mutex
+	type object struct {
+		resource        schema.GroupVersionResource
+		namespace, name string
+	}
+	var terminating []object
+
+	for _, resource := range []string{"pods", "persistentvolumeclaims"} {
+		gvr := corev1.SchemeGroupVersion.WithResource(resource)
+		clientset.PrependReactor("delete", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+			deleteAction := action.(k8stesting.DeleteAction)
+			obj, err := tracker.Get(gvr, deleteAction.GetNamespace(), deleteAction.GetName())
+			if err != nil {
+				return true, nil, err
+			}
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return true, nil, err
+			}
+			now := metav1.Now()
+			accessor.SetDeletionTimestamp(&now)
+
+			mu.Lock()
+			terminating = append(terminating, object{gvr, deleteAction.GetNamespace(), deleteAction.GetName()})
+			mu.Unlock()
+			return true, obj, tracker.Update(gvr, obj, deleteAction.GetNamespace())
+		})
+	}
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, obj := range terminating {
+			tracker.Delete(obj.resource, obj.namespace, obj.name)
+		}
+		terminating = nil
+	}
+}
+
+// deleteTestRunner creates a runner with a workspace claim on svc and deletes it
+func deleteTestRunner(t *testing.T, svc *runnerService, clientset *fake.Clientset) *Runner {
+	t.Helper()
+	ctx := context.Background()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	claim := &corev1.PersistentVolumeClaim{ObjectMeta: runnerObjectMeta(svc.k8sClient.Config().Namespace, "workspace", runner.ID)}
+	if err := clientset.Tracker().Add(claim); err != nil {
+		t.Fatalf("Failed to add claim: %v", err)
+	}
+
+	if _, err := svc.DeleteRunner(ctx, runner.ID, false); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+	return runner
+}
+
+func TestWaitForRunnerDeletedSlowTermination(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	terminate := emulateSlowTermination(clientset)
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner := deleteTestRunner(t, svc, clientset)
+
+	const terminationDelay = 50 * time.Millisecond
+	start := time.Now()
+	timer := time.AfterFunc(terminationDelay, terminate)
+	defer timer.Stop()
+
+	remaining, err := svc.WaitForRunnerDeleted(ctx, runner.ID, time.Second)
+	if err != nil {
+		t.Fatalf("WaitForRunnerDeleted failed: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("Expected nothing to remain, got %v", remaining)
+	}
+	if elapsed := time.Since(start); elapsed < terminationDelay {
+		t.Errorf("Expected to wait for the pod to terminate, returned after %s", elapsed)
+	}
+}
+
+func TestWaitForRunnerDeletedTimeout(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	emulateSlowTermination(clientset)
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner := deleteTestRunner(t, svc, clientset)
+
+	// The requested timeout is capped by the server's
+	svc.deleteWaitTimeout = 50 * time.Millisecond
+	start := time.Now()
+	remaining, err := svc.WaitForRunnerDeleted(ctx, runner.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("WaitForRunnerDeleted failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the wait to be capped at %s, took %s", svc.deleteWaitTimeout, elapsed)
+	}
+
+	expected := []string{"pod/" + kubernetesClient(svc).getPodName(runner.ID), "persistentvolumeclaim/workspace"}
+	if len(remaining) != len(expected) {
+		t.Fatalf("Expected %v to remain, got %v", expected, remaining)
+	}
+	for i, resource := range remaining {
+		if resource.String() != expected[i] {
+			t.Errorf("Expected %s to remain, got %s", expected[i], resource)
+		}
+	}
+}
+
+func TestWaitForRunnerDeletedCancelled(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	svc, clientset := newTestRunnerService()
+	emulateSlowTermination(clientset)
+	svc.Start(context.Background())
+	defer svc.Stop()
+
+	runner := deleteTestRunner(t, svc, clientset)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := svc.WaitForRunnerDeleted(ctx, runner.ID, time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the caller's deadline to be reported, got %v", err)
+	}
+}
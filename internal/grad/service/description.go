@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxDescriptionBytes bounds a runner description, which is stored in a pod annotation
+const MaxDescriptionBytes = 1024
+
+// Annotation values are kept on one line so they stay readable in kubectl output.
+// Backslashes are escaped first, so decoding can tell "\n" typed by a user from a newline.
+var (
+	annotationTextEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	annotationTextUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r", `\t`, "\t")
+)
+
+// encodeAnnotationText escapes free-form text for storing in an annotation (pure function)
+func encodeAnnotationText(text string) string {
+	return annotationTextEscaper.Replace(text)
+}
+
+// decodeAnnotationText reverses encodeAnnotationText (pure function)
+func decodeAnnotationText(value string) string {
+	return annotationTextUnescaper.Replace(value)
+}
+
+// validateDescription checks a runner description's size and encoding (pure function)
+func validateDescription(description string) error {
+	if len(description) > MaxDescriptionBytes {
+		return fmt.Errorf("%w: description is %d bytes, at most %d allowed", ErrInvalidRequest, len(description), MaxDescriptionBytes)
+	}
+	if !utf8.ValidString(description) {
+		return fmt.Errorf("%w: description must be valid UTF-8", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// runnerMatchesFilter reports whether the runner's ID, name or description contains filter,
+// ignoring case. An empty filter matches every runner (pure function)
+func runnerMatchesFilter(runner *Runner, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	filter = strings.ToLower(filter)
+	for _, field := range []string{runner.ID, runner.Name, runner.Description} {
+		if strings.Contains(strings.ToLower(field), filter) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/goleak"
+)
+
+func TestDescriptionAnnotationRoundTrip(t *testing.T) {
+	descriptions := []string{
+		"nightly ETL for the analytics team",
+		"línea 1\nzweite Zeile\n第三行 🚀",
+		"tabs\tand\r\nwindows line endings",
+		`literal \n and \\ stay as typed`,
+		"trailing backslash \\",
+	}
+
+	for _, description := range descriptions {
+		t.Run(description, func(t *testing.T) {
+			runner := &Runner{ID: "runner-1", Name: "runner-1", Description: description}
+			pod := buildPodCreationRequest(t, runner, DefaultKubernetesConfig()).ToPodSpec()
+
+			annotation := pod.Annotations[RunnerDescriptionAnnotation]
+			if strings.ContainsAny(annotation, "\n\r\t") {
+				t.Errorf("Expected annotation to be escaped to one line, got %q", annotation)
+			}
+			if got := PodToRunner(pod).Description; got != description {
+				t.Errorf("Expected description %q, got %q", description, got)
+			}
+		})
+	}
+}
+
+func TestValidateDescription(t *testing.T) {
+	if err := validateDescription(strings.Repeat("é", MaxDescriptionBytes/2)); err != nil {
+		t.Errorf("Expected %d bytes to be allowed, got %v", MaxDescriptionBytes, err)
+	}
+	if err := validateDescription(strings.Repeat("a", MaxDescriptionBytes+1)); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected an oversized description to be rejected, got %v", err)
+	}
+	if err := validateDescription("bad \xff byte"); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected invalid UTF-8 to be rejected, got %v", err)
+	}
+}
+
+func TestRunnerMatchesFilter(t *testing.T) {
+	runner := &Runner{ID: "runner-37", Name: "etl", Description: "Nightly import for Analytics"}
+
+	tests := []struct {
+		filter string
+		expect bool
+	}{
+		{"", true},
+		{"runner-37", true},
+		{"ETL", true},
+		{"analytics", true},
+		{"import for", true},
+		{"billing", false},
+	}
+
+	for _, tt := range tests {
+		if got := runnerMatchesFilter(runner, tt.filter); got != tt.expect {
+			t.Errorf("Expected filter %q to match=%v, got %v", tt.filter, tt.expect, got)
+		}
+	}
+}
+
+func TestUpdateRunnerDescription(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Description: "first"})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	other, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Description: "unrelated"})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	description := "second\nwith details"
+	updated, err := svc.UpdateRunner(ctx, runner.ID, &UpdateRunnerRequest{Description: &description})
+	if err != nil {
+		t.Fatalf("UpdateRunner failed: %v", err)
+	}
+	if updated.Description != description {
+		t.Errorf("Expected description %q, got %q", description, updated.Description)
+	}
+
+	runners, _, err := svc.ListRunners(ctx, &ListOptions{Filter: "DETAILS"})
+	if err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+	if len(runners) != 1 || runners[0].ID != runner.ID {
+		t.Errorf("Expected the filter to match only %s, got %v", runner.ID, runners)
+	}
+
+	// An empty request leaves the runner as it is
+	unchanged, err := svc.UpdateRunner(ctx, other.ID, &UpdateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("UpdateRunner failed: %v", err)
+	}
+	if unchanged.Description != "unrelated" {
+		t.Errorf("Expected description to be kept, got %q", unchanged.Description)
+	}
+
+	tooLong := strings.Repeat("x", MaxDescriptionBytes+1)
+	if _, err := svc.UpdateRunner(ctx, runner.ID, &UpdateRunnerRequest{Description: &tooLong}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest, got %v", err)
+	}
+	if _, err := svc.UpdateRunner(ctx, "runner-missing", &UpdateRunnerRequest{Description: &description}); !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("Expected ErrRunnerNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+const (
+	// DefaultDiagnosticsTailLines is how many log lines per container diagnostics include by default
+	DefaultDiagnosticsTailLines = 200
+	// MaxDiagnosticsTailLines caps the log lines per container a client may ask for
+	MaxDiagnosticsTailLines = 5000
+	// diagnosticsLogLimitBytes caps each container's log, keeping the response well below
+	// gRPC's default 4MB message limit
+	diagnosticsLogLimitBytes = 1 << 20
+)
+
+// RunnerEvent is a Kubernetes event about a runner's pod
+type RunnerEvent struct {
+	Type           string
+	Reason         string
+	Message        string
+	FieldPath      string
+	Count          int32
+	FirstTimestamp time.Time
+	LastTimestamp  time.Time
+}
+
+// ContainerLog is the tail of a container's log, or why it couldn't be read
+type ContainerLog struct {
+	Container string
+	Log       string
+	Error     string
+}
+
+// RunnerDiagnostics is what's needed to debug a runner
+type RunnerDiagnostics struct {
+	// PodJSON is the runner's pod as Kubernetes has it, without managed fields
+	PodJSON    []byte
+	Events     []RunnerEvent
+	Logs       []ContainerLog
+	Executions []ExecutionRecord
+}
+
+// diagnosticsTailLines returns the log lines per container to fetch for a request (pure function)
+func diagnosticsTailLines(requested int64) int64 {
+	if requested <= 0 {
+		return DefaultDiagnosticsTailLines
+	}
+	return min(requested, MaxDiagnosticsTailLines)
+}
+
+// GetRunnerDiagnostics collects a runner's pod, events, container logs and recent executions.
+// Logs and events are best effort: a container that can't be read has its error recorded in
+// its log, and missing events leave the list empty.
+func (s *runnerService) GetRunnerDiagnostics(ctx context.Context, runnerID string, tailLines int64) (*RunnerDiagnostics, error) {
+	pod, err := s.getRunnerPod(ctx, runnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	podJSON, err := marshalPod(pod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode runner pod: %w", err)
+	}
+
+	diagnostics := &RunnerDiagnostics{
+		PodJSON:    podJSON,
+		Executions: s.history.Get(runnerID),
+	}
+
+	events, err := s.k8sClient.RunnerEvents(ctx, runnerID)
+	if err != nil {
+		slog.Warn("Failed to list runner events", "runner_id", runnerID, "error", err)
+	}
+	diagnostics.Events = events
+
+	tailLines = diagnosticsTailLines(tailLines)
+	for _, container := range podContainerNames(pod) {
+		log := ContainerLog{Container: container}
+		text, err := s.k8sClient.ContainerLog(ctx, runnerID, container, tailLines)
+		if err != nil {
+			log.Error = err.Error()
+		}
+		log.Log = text
+		diagnostics.Logs = append(diagnostics.Logs, log)
+	}
+
+	return diagnostics, nil
+}
+
+// marshalPod encodes a pod the way kubectl get -o json shows it, without managed fields (pure function)
+func marshalPod(pod *corev1.Pod) ([]byte, error) {
+	pod = pod.DeepCopy()
+	pod.ManagedFields = nil
+	pod.APIVersion, pod.Kind = "v1", "Pod"
+	return json.MarshalIndent(pod, "", "  ")
+}
+
+// podContainerNames returns the names of a pod's init and regular containers, in spec order (pure function)
+func podContainerNames(pod *corev1.Pod) []string {
+	var names []string
+	for _, container := range pod.Spec.InitContainers {
+		names = append(names, container.Name)
+	}
+	for _, container := range pod.Spec.Containers {
+		names = append(names, container.Name)
+	}
+	return names
+}
+
+// RunnerEvents returns the events about a runner's pod, oldest first. Events outlive the pod,
+// so a recreated pod's events include those of the pods before it.
+func (k *KubernetesClient) RunnerEvents(ctx context.Context, runnerID string) ([]RunnerEvent, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	podName := k.getPodName(runnerID)
+	list, err := k.clientset.CoreV1().Events(k.config.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.name", podName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runner events: %w", err)
+	}
+
+	var events []RunnerEvent
+	for _, event := range list.Items {
+		// Not every API server, or fake, honors the field selector
+		if event.InvolvedObject.Name != podName {
+			continue
+		}
+		events = append(events, RunnerEvent{
+			Type:           event.Type,
+			Reason:         event.Reason,
+			Message:        event.Message,
+			FieldPath:      event.InvolvedObject.FieldPath,
+			Count:          event.Count,
+			FirstTimestamp: event.FirstTimestamp.Time,
+			LastTimestamp:  eventTime(&event),
+		})
+	}
+	slices.SortStableFunc(events, func(a, b RunnerEvent) int {
+		return cmp.Compare(a.LastTimestamp.UnixNano(), b.LastTimestamp.UnixNano())
+	})
+	return events, nil
+}
+
+// eventTime returns when an event last happened; events from newer components only set
+// eventTime (pure function)
+func eventTime(event *corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	return event.EventTime.Time
+}
+
+// ContainerLog returns the last tailLines lines of a runner container's log, up to 1MiB
+func (k *KubernetesClient) ContainerLog(ctx context.Context, runnerID, container string, tailLines int64) (string, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	limitBytes := int64(diagnosticsLogLimitBytes)
+	log, err := k.clientset.CoreV1().Pods(k.config.Namespace).GetLogs(k.getPodName(runnerID), &corev1.PodLogOptions{
+		Container:  container,
+		TailLines:  &tailLines,
+		LimitBytes: &limitBytes,
+	}).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s log: %w", container, err)
+	}
+	return string(log), nil
+}
+
+// ToProto converts domain RunnerDiagnostics to a proto GetRunnerDiagnosticsResponse
+func (d *RunnerDiagnostics) ToProto() *gradv1.GetRunnerDiagnosticsResponse {
+	resp := &gradv1.GetRunnerDiagnosticsResponse{PodJson: string(d.PodJSON)}
+	for _, event := range d.Events {
+		resp.Events = append(resp.Events, &gradv1.RunnerEvent{
+			Type:           event.Type,
+			Reason:         event.Reason,
+			Message:        event.Message,
+			FieldPath:      event.FieldPath,
+			Count:          event.Count,
+			FirstTimestamp: unixOrZero(event.FirstTimestamp),
+			LastTimestamp:  unixOrZero(event.LastTimestamp),
+		})
+	}
+	for _, log := range d.Logs {
+		resp.Logs = append(resp.Logs, &gradv1.ContainerLog{
+			Container: log.Container,
+			Log:       log.Log,
+			Error:     log.Error,
+		})
+	}
+	for _, record := range d.Executions {
+		resp.Executions = append(resp.Executions, record.ToProto())
+	}
+	return resp
+}
+
+// ToProto converts a domain ExecutionRecord to proto
+func (r ExecutionRecord) ToProto() *gradv1.ExecutionRecord {
+	return &gradv1.ExecutionRecord{
+		Command:    r.Command,
+		ExitCode:   r.ExitCode,
+		Error:      r.Error,
+		Output:     r.Output,
+		Bootstrap:  r.Bootstrap,
+		StartedAt:  unixOrZero(r.StartedAt),
+		FinishedAt: unixOrZero(r.FinishedAt),
+	}
+}
+
+// unixOrZero returns t as a Unix timestamp, or 0 if t is unset (pure function)
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
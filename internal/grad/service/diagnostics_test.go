@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podEvent builds an event about the named pod that last happened at lastSeen
+func podEvent(namespace, name, podName, reason string, lastSeen time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: podName, FieldPath: "spec.containers{runner}"},
+		Type:           corev1.EventTypeNormal,
+		Reason:         reason,
+		Count:          1,
+		FirstTimestamp: metav1.NewTime(lastSeen),
+		LastTimestamp:  metav1.NewTime(lastSeen),
+	}
+}
+
+func TestGetRunnerDiagnostics(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	defer svc.Stop()
+
+	namespace := svc.k8sClient.Config().Namespace
+	podName := kubernetesClient(svc).getPodName(runner.ID)
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for _, event := range []*corev1.Event{
+		podEvent(namespace, "started", podName, "Started", start.Add(2*time.Second)),
+		podEvent(namespace, "scheduled", podName, "Scheduled", start),
+		podEvent(namespace, "other", "grad-runner-other", "Killing", start.Add(time.Second)),
+	} {
+		if _, err := clientset.CoreV1().Events(namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create event: %v", err)
+		}
+	}
+	svc.history.Record(runner.ID, ExecutionRecord{Command: "make test", ExitCode: 2, StartedAt: start, FinishedAt: start.Add(time.Minute)})
+
+	diagnostics, err := svc.GetRunnerDiagnostics(ctx, runner.ID, 0)
+	if err != nil {
+		t.Fatalf("GetRunnerDiagnostics failed: %v", err)
+	}
+
+	// Only the runner's own events, oldest first
+	if len(diagnostics.Events) != 2 || diagnostics.Events[0].Reason != "Scheduled" || diagnostics.Events[1].Reason != "Started" {
+		t.Errorf("Expected Scheduled then Started, got %+v", diagnostics.Events)
+	}
+
+	var pod corev1.Pod
+	if err := json.Unmarshal(diagnostics.PodJSON, &pod); err != nil {
+		t.Fatalf("Expected the pod as JSON, got %v", err)
+	}
+	if pod.Kind != "Pod" || pod.Name != podName || len(pod.ManagedFields) != 0 {
+		t.Errorf("Expected pod %s without managed fields, got %s %s with %d", podName, pod.Kind, pod.Name, len(pod.ManagedFields))
+	}
+
+	// One log per container, from the fake clientset
+	if len(diagnostics.Logs) != len(podContainerNames(&pod)) || len(diagnostics.Logs) == 0 {
+		t.Fatalf("Expected a log per container, got %+v", diagnostics.Logs)
+	}
+	for _, log := range diagnostics.Logs {
+		if log.Log == "" || log.Error != "" {
+			t.Errorf("Expected the %s log, got %+v", log.Container, log)
+		}
+	}
+
+	if len(diagnostics.Executions) != 1 || diagnostics.Executions[0].Command != "make test" {
+		t.Errorf("Expected the recorded execution, got %+v", diagnostics.Executions)
+	}
+
+	proto := diagnostics.ToProto()
+	if len(proto.Events) != 2 || proto.Events[0].LastTimestamp != start.Unix() {
+		t.Errorf("Expected event timestamps in proto, got %v", proto.Events)
+	}
+	if len(proto.Executions) != 1 || proto.Executions[0].ExitCode != 2 || proto.Executions[0].FinishedAt != start.Add(time.Minute).Unix() {
+		t.Errorf("Expected the execution in proto, got %v", proto.Executions)
+	}
+
+	if _, err := svc.GetRunnerDiagnostics(ctx, "missing", 0); !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("Expected ErrRunnerNotFound, got %v", err)
+	}
+}
+
+func TestDiagnosticsTailLines(t *testing.T) {
+	tests := []struct {
+		requested int64
+		expected  int64
+	}{
+		{0, DefaultDiagnosticsTailLines},
+		{-1, DefaultDiagnosticsTailLines},
+		{50, 50},
+		{MaxDiagnosticsTailLines + 1, MaxDiagnosticsTailLines},
+	}
+	for _, tt := range tests {
+		if got := diagnosticsTailLines(tt.requested); got != tt.expected {
+			t.Errorf("Expected %d lines for %d, got %d", tt.expected, tt.requested, got)
+		}
+	}
+}
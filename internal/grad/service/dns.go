@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Limits enforced by the Kubernetes API server on a pod's dnsConfig
+const (
+	maxDNSNameservers = 3
+	maxDNSSearches    = 32
+)
+
+// MaxHostAliases bounds the /etc/hosts entries a create request may add
+const MaxHostAliases = 32
+
+// DNSConfig adds resolver settings to runner pods, merged with those of their DNS policy
+type DNSConfig struct {
+	Nameservers []string
+	Searches    []string
+	// Options are resolv.conf options such as "ndots:2" or "edns0"
+	Options []string
+}
+
+// HostAlias is an /etc/hosts entry of a runner pod
+type HostAlias struct {
+	IP        string
+	Hostnames []string
+}
+
+// validateDNSPolicy checks policy is one Kubernetes accepts for pods, or empty for the
+// cluster default. The None policy only works with nameservers of its own. (pure function)
+func validateDNSPolicy(policy string, config *DNSConfig) error {
+	switch corev1.DNSPolicy(policy) {
+	case "", corev1.DNSClusterFirst, corev1.DNSClusterFirstWithHostNet, corev1.DNSDefault:
+		return nil
+	case corev1.DNSNone:
+		if config == nil || len(config.Nameservers) == 0 {
+			return fmt.Errorf("policy None requires at least one nameserver")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown DNS policy %q (want ClusterFirst, ClusterFirstWithHostNet, Default or None)", policy)
+}
+
+// validateDNSConfig checks nameservers are IP addresses and searches are domain names (pure function)
+func validateDNSConfig(config *DNSConfig) error {
+	if len(config.Nameservers) > maxDNSNameservers {
+		return fmt.Errorf("at most %d nameservers allowed, got %d", maxDNSNameservers, len(config.Nameservers))
+	}
+	for _, nameserver := range config.Nameservers {
+		if _, err := netip.ParseAddr(nameserver); err != nil {
+			return fmt.Errorf("nameserver %q is not an IP address", nameserver)
+		}
+	}
+
+	if len(config.Searches) > maxDNSSearches {
+		return fmt.Errorf("at most %d search domains allowed, got %d", maxDNSSearches, len(config.Searches))
+	}
+	for _, search := range config.Searches {
+		if problems := validation.IsDNS1123Subdomain(strings.TrimSuffix(search, ".")); len(problems) > 0 {
+			return fmt.Errorf("search domain %q: %s", search, strings.Join(problems, "; "))
+		}
+	}
+
+	for _, option := range config.Options {
+		if name, _, _ := strings.Cut(option, ":"); name == "" {
+			return fmt.Errorf("DNS option %q has no name", option)
+		}
+	}
+	return nil
+}
+
+// validateHostAliases checks each entry has an IP address and at least one valid hostname (pure function)
+func validateHostAliases(aliases []HostAlias) error {
+	for _, alias := range aliases {
+		if _, err := netip.ParseAddr(alias.IP); err != nil {
+			return fmt.Errorf("host alias IP %q is not an IP address", alias.IP)
+		}
+		if len(alias.Hostnames) == 0 {
+			return fmt.Errorf("host alias %s has no hostnames", alias.IP)
+		}
+		for _, hostname := range alias.Hostnames {
+			if problems := validation.IsDNS1123Subdomain(hostname); len(problems) > 0 {
+				return fmt.Errorf("host alias hostname %q: %s", hostname, strings.Join(problems, "; "))
+			}
+		}
+	}
+	return nil
+}
+
+// validateRequestHostAliases checks the host aliases of a create request (pure function)
+func validateRequestHostAliases(aliases []HostAlias) error {
+	if len(aliases) > MaxHostAliases {
+		return fmt.Errorf("%w: at most %d host aliases allowed, got %d", ErrInvalidRequest, MaxHostAliases, len(aliases))
+	}
+	if err := validateHostAliases(aliases); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	return nil
+}
+
+// ParseHostAliases parses comma-separated IP=HOSTNAME[ HOSTNAME...] entries,
+// e.g. "10.0.0.5=legacy.corp.example legacy,10.0.0.6=db.corp.example"
+func ParseHostAliases(value string) ([]HostAlias, error) {
+	var aliases []HostAlias
+	for _, entry := range splitList(value) {
+		ip, hostnames, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not IP=HOSTNAME", entry)
+		}
+		aliases = append(aliases, HostAlias{IP: strings.TrimSpace(ip), Hostnames: strings.Fields(hostnames)})
+	}
+	return aliases, nil
+}
+
+// ParseHostsFile reads entries in /etc/hosts format: an IP followed by hostnames per line,
+// with # starting a comment
+func ParseHostsFile(r io.Reader) ([]HostAlias, error) {
+	var aliases []HostAlias
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		aliases = append(aliases, HostAlias{IP: fields[0], Hostnames: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// toPodDNSConfig converts config to its pod spec form, nil for no settings (pure function)
+func toPodDNSConfig(config *DNSConfig) *corev1.PodDNSConfig {
+	if config == nil || len(config.Nameservers)+len(config.Searches)+len(config.Options) == 0 {
+		return nil
+	}
+
+	podConfig := &corev1.PodDNSConfig{
+		Nameservers: config.Nameservers,
+		Searches:    config.Searches,
+	}
+	for _, option := range config.Options {
+		name, value, hasValue := strings.Cut(option, ":")
+		podOption := corev1.PodDNSConfigOption{Name: name}
+		if hasValue {
+			podOption.Value = &value
+		}
+		podConfig.Options = append(podConfig.Options, podOption)
+	}
+	return podConfig
+}
+
+// toPodHostAliases converts aliases to their pod spec form (pure function)
+func toPodHostAliases(aliases []HostAlias) []corev1.HostAlias {
+	if len(aliases) == 0 {
+		return nil
+	}
+	podAliases := make([]corev1.HostAlias, len(aliases))
+	for i, alias := range aliases {
+		podAliases[i] = corev1.HostAlias{IP: alias.IP, Hostnames: alias.Hostnames}
+	}
+	return podAliases
+}
+
+// fromPodHostAliases converts the host aliases of a pod spec, leaving out those in skip (pure function)
+func fromPodHostAliases(podAliases []corev1.HostAlias, skip []HostAlias) []HostAlias {
+	var aliases []HostAlias
+	for _, podAlias := range podAliases {
+		alias := HostAlias{IP: podAlias.IP, Hostnames: podAlias.Hostnames}
+		if slices.ContainsFunc(skip, func(s HostAlias) bool {
+			return s.IP == alias.IP && slices.Equal(s.Hostnames, alias.Hostnames)
+		}) {
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
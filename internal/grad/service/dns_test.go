@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodCreationRequestToPodSpecDNS(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	config.DNSPolicy = "None"
+	config.DNSConfig = &DNSConfig{
+		Nameservers: []string{"10.10.0.53"},
+		Searches:    []string{"corp.example"},
+		Options:     []string{"ndots:2", "edns0"},
+	}
+	config.HostAliases = []HostAlias{{IP: "10.0.0.5", Hostnames: []string{"legacy.corp.example", "legacy"}}}
+
+	runner := &Runner{
+		ID:          "runner-1",
+		Name:        "runner-1",
+		HostAliases: []HostAlias{{IP: "fd00::6", Hostnames: []string{"db.corp.example"}}},
+	}
+	pod := buildPodCreationRequest(t, runner, config).ToPodSpec()
+
+	if pod.Spec.DNSPolicy != corev1.DNSNone {
+		t.Errorf("Expected DNS policy None, got %q", pod.Spec.DNSPolicy)
+	}
+	ndots := "2"
+	expectDNS := &corev1.PodDNSConfig{
+		Nameservers: []string{"10.10.0.53"},
+		Searches:    []string{"corp.example"},
+		Options:     []corev1.PodDNSConfigOption{{Name: "ndots", Value: &ndots}, {Name: "edns0"}},
+	}
+	if !reflect.DeepEqual(pod.Spec.DNSConfig, expectDNS) {
+		t.Errorf("Expected DNS config %+v, got %+v", expectDNS, pod.Spec.DNSConfig)
+	}
+	expectAliases := []corev1.HostAlias{
+		{IP: "10.0.0.5", Hostnames: []string{"legacy.corp.example", "legacy"}},
+		{IP: "fd00::6", Hostnames: []string{"db.corp.example"}},
+	}
+	if !reflect.DeepEqual(pod.Spec.HostAliases, expectAliases) {
+		t.Errorf("Expected host aliases %+v, got %+v", expectAliases, pod.Spec.HostAliases)
+	}
+	if len(config.HostAliases) != 1 {
+		t.Errorf("Expected the server's host aliases to be left alone, got %+v", config.HostAliases)
+	}
+
+	// Only the request's entries are carried over by a clone
+	if got := fromPodHostAliases(pod.Spec.HostAliases, config.HostAliases); !reflect.DeepEqual(got, runner.HostAliases) {
+		t.Errorf("Expected %+v, got %+v", runner.HostAliases, got)
+	}
+
+	// Without DNS settings the pod uses the cluster defaults
+	pod = buildPodCreationRequest(t, &Runner{ID: "runner-2"}, DefaultKubernetesConfig()).ToPodSpec()
+	if pod.Spec.DNSPolicy != "" || pod.Spec.DNSConfig != nil || pod.Spec.HostAliases != nil {
+		t.Errorf("Expected no DNS settings, got %q, %+v, %+v", pod.Spec.DNSPolicy, pod.Spec.DNSConfig, pod.Spec.HostAliases)
+	}
+}
+
+func TestValidateRequestHostAliases(t *testing.T) {
+	tests := []struct {
+		name          string
+		aliases       []HostAlias
+		expectMessage string
+	}{
+		{"Valid", []HostAlias{{IP: "10.0.0.5", Hostnames: []string{"legacy"}}, {IP: "::1", Hostnames: []string{"local.test"}}}, ""},
+		{"Malformed IP", []HostAlias{{IP: "10.0.0.256", Hostnames: []string{"legacy"}}}, `"10.0.0.256" is not an IP address`},
+		{"Hostname as IP", []HostAlias{{IP: "legacy.corp", Hostnames: []string{"legacy"}}}, "is not an IP address"},
+		{"CIDR", []HostAlias{{IP: "10.0.0.0/8", Hostnames: []string{"legacy"}}}, "is not an IP address"},
+		{"No hostnames", []HostAlias{{IP: "10.0.0.5"}}, "has no hostnames"},
+		{"Invalid hostname", []HostAlias{{IP: "10.0.0.5", Hostnames: []string{"legacy_host"}}}, `"legacy_host"`},
+		{"Too many", make([]HostAlias, MaxHostAliases+1), "at most 32 host aliases"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRequestHostAliases(tt.aliases)
+			if tt.expectMessage == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), tt.expectMessage) {
+				t.Errorf("Expected ErrInvalidRequest mentioning %q, got %v", tt.expectMessage, err)
+			}
+		})
+	}
+}
+
+func TestParseHostAliases(t *testing.T) {
+	got, err := ParseHostAliases("10.0.0.5=legacy.corp.example legacy, 10.0.0.6=db.corp.example")
+	if err != nil {
+		t.Fatalf("ParseHostAliases failed: %v", err)
+	}
+	expected := []HostAlias{
+		{IP: "10.0.0.5", Hostnames: []string{"legacy.corp.example", "legacy"}},
+		{IP: "10.0.0.6", Hostnames: []string{"db.corp.example"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	if _, err := ParseHostAliases("10.0.0.5 legacy"); err == nil {
+		t.Error("Expected an error for an entry without =")
+	}
+}
+
+func TestLoadConfigFromDNS(t *testing.T) {
+	hostsFile := filepath.Join(t.TempDir(), "hosts")
+	hosts := "# legacy services\n10.0.0.7  ldap.corp.example ldap\n\n10.0.0.8 mail.corp.example # relay\n"
+	if err := os.WriteFile(hostsFile, []byte(hosts), 0644); err != nil {
+		t.Fatalf("Failed to write hosts file: %v", err)
+	}
+
+	config, err := LoadConfigFrom(mapLookup(map[string]string{
+		"RUNNER_DNS_POLICY":        "None",
+		"RUNNER_DNS_NAMESERVERS":   "10.10.0.53,10.10.0.54",
+		"RUNNER_DNS_SEARCHES":      "corp.example",
+		"RUNNER_DNS_OPTIONS":       "ndots:2",
+		"RUNNER_HOST_ALIASES":      "10.0.0.5=legacy",
+		"RUNNER_HOST_ALIASES_FILE": hostsFile,
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	k8s := config.Kubernetes
+	if k8s.DNSPolicy != "None" || len(k8s.DNSConfig.Nameservers) != 2 || k8s.DNSConfig.Options[0] != "ndots:2" {
+		t.Errorf("Expected policy None with 2 nameservers and ndots:2, got %q, %+v", k8s.DNSPolicy, k8s.DNSConfig)
+	}
+	expected := []HostAlias{
+		{IP: "10.0.0.5", Hostnames: []string{"legacy"}},
+		{IP: "10.0.0.7", Hostnames: []string{"ldap.corp.example", "ldap"}},
+		{IP: "10.0.0.8", Hostnames: []string{"mail.corp.example"}},
+	}
+	if !reflect.DeepEqual(k8s.HostAliases, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, k8s.HostAliases)
+	}
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expectMessage string
+	}{
+		{"Unknown policy", map[string]string{"RUNNER_DNS_POLICY": "Custom"}, "unknown DNS policy"},
+		{"None without nameservers", map[string]string{"RUNNER_DNS_POLICY": "None"}, "requires at least one nameserver"},
+		{"Nameserver not an IP", map[string]string{"RUNNER_DNS_NAMESERVERS": "dns.corp"}, "is not an IP address"},
+		{"Too many nameservers", map[string]string{"RUNNER_DNS_NAMESERVERS": "10.0.0.1,10.0.0.2,10.0.0.3,10.0.0.4"}, "at most 3 nameservers"},
+		{"Malformed alias IP", map[string]string{"RUNNER_HOST_ALIASES": "10.0.0=legacy"}, "RUNNER_HOST_ALIASES="},
+		{"Missing hosts file", map[string]string{"RUNNER_HOST_ALIASES_FILE": filepath.Join(t.TempDir(), "missing")}, "RUNNER_HOST_ALIASES_FILE="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFrom(mapLookup(tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.expectMessage) {
+				t.Errorf("Expected an error mentioning %q, got %v", tt.expectMessage, err)
+			}
+		})
+	}
+}
+
+func TestCreateRunnerHostAliases(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	aliases := []HostAlias{{IP: "10.0.0.5", Hostnames: []string{"legacy"}}}
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{HostAliases: aliases})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if !reflect.DeepEqual(runner.HostAliases, aliases) {
+		t.Errorf("Expected host aliases %+v, got %+v", aliases, runner.HostAliases)
+	}
+
+	_, err = svc.CreateRunner(ctx, &CreateRunnerRequest{HostAliases: []HostAlias{{IP: "300.0.0.1", Hostnames: []string{"legacy"}}}})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for a malformed IP, got %v", err)
+	}
+}
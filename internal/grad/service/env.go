@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// reservedEnvNames are set by grad on runner containers and cannot be overridden
+var reservedEnvNames = map[string]bool{
+	"RUNNER_ID":     true,
+	"RUNNER_NAME":   true,
+	"S3_BUCKET":     true,
+	"S3_ENDPOINT":   true,
+	"S3_PREFIX":     true,
+	"MOUNT_PATH":    true,
+	"MOUNT_OPTIONS": true,
+}
+
+// reservedEnvPrefixes are env name prefixes kept for grad's own use
+var reservedEnvPrefixes = []string{"GRAD_"}
+
+// IsReservedEnvName reports whether name is owned by grad
+func IsReservedEnvName(name string) bool {
+	if reservedEnvNames[name] {
+		return true
+	}
+	for _, prefix := range reservedEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateEnv checks environment variable names and sizes against the limits.
+// The returned error lists every problem and every offending key.
+func ValidateEnv(env map[string]string, limits *EnvLimits) error {
+	problems := envProblems(env, limits)
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// envProblems returns what's wrong with env, one entry per kind of problem (pure function)
+func envProblems(env map[string]string, limits *EnvLimits) []string {
+	if limits == nil {
+		limits = DefaultEnvLimits()
+	}
+
+	var problems []string
+	if len(env) > limits.MaxEntries {
+		problems = append(problems, fmt.Sprintf("too many env vars: %d (max %d)", len(env), limits.MaxEntries))
+	}
+
+	var invalidNames, reserved, oversized []string
+	total := 0
+	for key, value := range env {
+		if !IsValidEnvName(key) {
+			invalidNames = append(invalidNames, fmt.Sprintf("%q", key))
+		} else if IsReservedEnvName(key) {
+			reserved = append(reserved, key)
+		}
+		if len(value) > limits.MaxValueBytes {
+			oversized = append(oversized, key)
+		}
+		total += len(key) + len(value)
+	}
+
+	if len(invalidNames) > 0 {
+		sort.Strings(invalidNames)
+		problems = append(problems, fmt.Sprintf("invalid env var names (must match [A-Za-z_][A-Za-z0-9_]*): %s", strings.Join(invalidNames, ", ")))
+	}
+
+	if len(reserved) > 0 {
+		sort.Strings(reserved)
+		problems = append(problems, fmt.Sprintf("env vars are reserved by grad: %s", strings.Join(reserved, ", ")))
+	}
+
+	if len(oversized) > 0 {
+		sort.Strings(oversized)
+		problems = append(problems, fmt.Sprintf("env var values exceed %d bytes: %s", limits.MaxValueBytes, strings.Join(oversized, ", ")))
+	}
+
+	if total > limits.MaxTotalBytes {
+		problems = append(problems, fmt.Sprintf("env vars total %d bytes (max %d)", total, limits.MaxTotalBytes))
+	}
+
+	return problems
+}
+
+// IsValidEnvName reports whether name is a valid C identifier usable as an env var name
+func IsValidEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
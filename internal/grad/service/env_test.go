@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateEnv(t *testing.T) {
+	limits := &EnvLimits{
+		MaxValueBytes: 16,
+		MaxTotalBytes: 64,
+		MaxEntries:    4,
+	}
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		expectError bool
+		errorParts  []string
+	}{
+		{
+			name: "Valid env",
+			env:  map[string]string{"FOO": "bar", "_PRIVATE": "1", "path2": "x"},
+		},
+		{
+			name: "Empty env",
+			env:  nil,
+		},
+		{
+			name:        "Empty key",
+			env:         map[string]string{"": "value"},
+			expectError: true,
+			errorParts:  []string{`""`},
+		},
+		{
+			name:        "Key with equals sign",
+			env:         map[string]string{"FOO=BAR": "value"},
+			expectError: true,
+			errorParts:  []string{`"FOO=BAR"`},
+		},
+		{
+			name:        "Key with NUL byte",
+			env:         map[string]string{"FOO\x00": "value"},
+			expectError: true,
+			errorParts:  []string{`"FOO\x00"`},
+		},
+		{
+			name:        "Key starting with digit",
+			env:         map[string]string{"1FOO": "value", "BAD-NAME": "value"},
+			expectError: true,
+			errorParts:  []string{`"1FOO"`, `"BAD-NAME"`},
+		},
+		{
+			name:        "Reserved names",
+			env:         map[string]string{"RUNNER_ID": "foo", "MOUNT_PATH": "/tmp", "OK": "1"},
+			expectError: true,
+			errorParts:  []string{"reserved", "MOUNT_PATH, RUNNER_ID"},
+		},
+		{
+			name:        "Reserved prefix",
+			env:         map[string]string{"GRAD_INTERNAL": "1"},
+			expectError: true,
+			errorParts:  []string{"reserved", "GRAD_INTERNAL"},
+		},
+		{
+			name:        "Value too large",
+			env:         map[string]string{"BIG": strings.Repeat("x", 17), "OK": "small"},
+			expectError: true,
+			errorParts:  []string{"exceed 16 bytes", "BIG"},
+		},
+		{
+			name:        "Total too large",
+			env:         map[string]string{"A": strings.Repeat("x", 16), "B": strings.Repeat("x", 16), "C": strings.Repeat("x", 16), "D": strings.Repeat("x", 16)},
+			expectError: true,
+			errorParts:  []string{"total 68 bytes"},
+		},
+		{
+			name:        "Too many entries",
+			env:         map[string]string{"A": "1", "B": "2", "C": "3", "D": "4", "E": "5"},
+			expectError: true,
+			errorParts:  []string{"too many env vars: 5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEnv(tt.env, limits)
+			if tt.expectError && err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			for _, part := range tt.errorParts {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("Expected error to contain %s, got '%v'", part, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateEnvDefaultLimits(t *testing.T) {
+	env := map[string]string{"BIG": strings.Repeat("x", 32*1024+1)}
+	if err := ValidateEnv(env, nil); err == nil {
+		t.Error("Expected default limits to reject a value over 32KB")
+	}
+
+	env = make(map[string]string)
+	for i := 0; i < 129; i++ {
+		env[fmt.Sprintf("VAR_%d", i)] = "x"
+	}
+	if err := ValidateEnv(env, nil); err == nil {
+		t.Error("Expected default limits to reject more than 128 entries")
+	}
+}
+
+func TestIsReservedEnvName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"RUNNER_ID", true},
+		{"RUNNER_NAME", true},
+		{"S3_BUCKET", true},
+		{"MOUNT_OPTIONS", true},
+		{"GRAD_TOKEN", true},
+		{"AWS_ACCESS_KEY_ID", false},
+		{"RUNNER_IDX", false},
+		{"PATH_EXTRA", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsReservedEnvName(tt.name); got != tt.expected {
+				t.Errorf("Expected IsReservedEnvName(%s)=%v, got %v", tt.name, tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// EventComponent is the reporting component of the Events grad emits
+const EventComponent = "grad"
+
+// Reasons of the Events grad emits on runner pods
+const (
+	EventReasonRunnerCreated = "RunnerCreated"
+	EventReasonRunnerReady   = "RunnerReady"
+	// EventReasonRunnerIdle precedes the deletion of a runner by the idle cleanup
+	EventReasonRunnerIdle = "RunnerIdle"
+	// EventReasonRunnerDeleted means grad force-deleted the runner's pod, without a grace period
+	EventReasonRunnerDeleted = "RunnerDeleted"
+	// EventReasonExecFailed means a command couldn't run, timed out or was killed
+	EventReasonExecFailed = "ExecFailed"
+	// EventReasonReservationEnding warns that an idle runner's reservation ends soon
+	EventReasonReservationEnding = "ReservationEnding"
+)
+
+// EnableEvents makes grad emit Kubernetes Events on runner pods, so kubectl get events and
+// kubectl describe pod show why grad created or deleted them. It is called once at startup;
+// if grad may not create Events in the runner namespace, none are emitted.
+func (k *KubernetesClient) EnableEvents(ctx context.Context) bool {
+	if err := k.checkEventPermission(ctx); err != nil {
+		slog.Info("Can't create events, runner events are disabled", "namespace", k.config.Namespace, "error", err)
+		return false
+	}
+
+	k.broadcaster = record.NewBroadcaster()
+	k.broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k.clientset.CoreV1().Events("")})
+	k.recorder = k.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: EventComponent})
+	slog.Info("Runner events are enabled", "component", EventComponent)
+	return true
+}
+
+// StopEvents sends the events still queued and stops emitting new ones
+func (k *KubernetesClient) StopEvents() {
+	if k.broadcaster == nil {
+		return
+	}
+	k.broadcaster.Shutdown()
+	k.broadcaster = nil
+	k.recorder = nil
+}
+
+// checkEventPermission asks the API server whether grad may create Events in the runner namespace
+func (k *KubernetesClient) checkEventPermission(ctx context.Context) error {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: k.config.Namespace,
+				Verb:      "create",
+				Resource:  "events",
+			},
+		},
+	}
+
+	result, err := k.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create access review: %w", err)
+	}
+	if !result.Status.Allowed {
+		return fmt.Errorf("denied: no RBAC rule allows creating events")
+	}
+	return nil
+}
+
+// RecordPodEvent emits an Event on a runner pod, unless events are disabled
+func (k *KubernetesClient) RecordPodEvent(pod *corev1.Pod, eventType, reason, messageFmt string, args ...interface{}) {
+	if k.recorder == nil || pod == nil {
+		return
+	}
+	k.recorder.Eventf(pod, eventType, reason, messageFmt, args...)
+}
+
+// RecordRunnerEvent emits an Event on the pod of runnerID, unless events are disabled. Failing
+// to find the pod is only logged, since events are informational.
+func (k *KubernetesClient) RecordRunnerEvent(ctx context.Context, runnerID, eventType, reason, messageFmt string, args ...interface{}) {
+	if k.recorder == nil {
+		return
+	}
+	pod, err := k.GetRunnerPod(ctx, runnerID)
+	if err != nil {
+		slog.Warn("Failed to get runner pod for event", "runner_id", runnerID, "reason", reason, "error", err)
+		return
+	}
+	k.RecordPodEvent(pod, eventType, reason, messageFmt, args...)
+}
+
+// execFailureMessage describes a command that failed to run, timed out or was killed, or returns
+// "" for one that exited on its own or was cancelled by its client (pure function)
+func execFailureMessage(status ExitStatus, err error, timeout time.Duration) string {
+	switch {
+	case err != nil:
+		return fmt.Sprintf("Command failed to run: %v", err)
+	case status.Reason == ExitReasonTimeout:
+		return fmt.Sprintf("Command timed out after %s", timeout)
+	case status.Reason == ExitReasonSignaled && status.LikelyOOM:
+		return fmt.Sprintf("Command killed by %s, likely out of memory", status.Signal)
+	case status.Reason == ExitReasonSignaled:
+		return fmt.Sprintf("Command killed by %s", status.Signal)
+	default:
+		return ""
+	}
+}
+
+// RunnerEventRecorder emits Events on runner pods; it is implemented by KubernetesClient
+type RunnerEventRecorder interface {
+	RecordRunnerEvent(ctx context.Context, runnerID, eventType, reason, messageFmt string, args ...interface{})
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// recordEvents makes svc emit events to a fake recorder and returns it
+func recordEvents(svc *runnerService) *record.FakeRecorder {
+	recorder := record.NewFakeRecorder(20)
+	kubernetesClient(svc).recorder = recorder
+	return recorder
+}
+
+// recordedEvents returns the events recorded so far, as "type reason message"
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestCreateRunnerEvents(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	recorder := recordEvents(svc)
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	events := recordedEvents(recorder)
+	if len(events) != 1 || events[0] != "Normal RunnerCreated Created runner "+runner.ID {
+		t.Errorf("Expected a RunnerCreated event, got %q", events)
+	}
+
+	setPodStatus(t, svc, clientset, runner.ID, corev1.PodRunning, true)
+	waitForMonitors(t, svc)
+
+	events = recordedEvents(recorder)
+	if len(events) != 1 || !strings.HasPrefix(events[0], "Normal RunnerReady Runner is ready after ") {
+		t.Errorf("Expected a RunnerReady event, got %q", events)
+	}
+}
+
+func TestDeleteRunnerEvent(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	recorder := recordEvents(svc)
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+
+	if _, err := svc.DeleteRunner(ctx, "runner-1", false); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+
+	events := recordedEvents(recorder)
+	if len(events) != 1 || events[0] != "Normal RunnerDeleted Force-deleting runner runner-1 without a grace period" {
+		t.Errorf("Expected a RunnerDeleted event, got %q", events)
+	}
+}
+
+func TestIdleCleanupEvents(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	recorder := recordEvents(svc)
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+	svc.activityTracker.RestoreLastActiveTime("runner-1", time.Now().Add(-90*time.Minute))
+
+	cleanupService := NewCleanupService(svc, svc.activityTracker, kubernetesClient(svc), DefaultDurations(), nil)
+	cleanupService.cleanupInactiveRunners(ctx)
+
+	expected := []string{
+		"Normal RunnerIdle Deleting runner runner-1 after 1h30m0s without activity",
+		"Normal RunnerDeleted Force-deleting runner runner-1 without a grace period",
+	}
+	if events := recordedEvents(recorder); strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected events %q, got %q", expected, events)
+	}
+}
+
+func TestEventsDisabled(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	allowVerbs(clientset, "create pods")
+
+	if kubernetesClient(svc).EnableEvents(ctx) {
+		t.Fatal("Expected events to be disabled without permission to create them")
+	}
+
+	// Without a recorder, transitions must not fail
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+	kubernetesClient(svc).RecordRunnerEvent(ctx, "runner-1", corev1.EventTypeNormal, EventReasonRunnerIdle, "idle")
+	if _, err := svc.DeleteRunner(ctx, "runner-1", false); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+}
+
+func TestEnableEvents(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	svc, clientset := newTestRunnerService()
+	allowVerbs(clientset, "create events")
+
+	if !kubernetesClient(svc).EnableEvents(context.Background()) {
+		t.Fatal("Expected events to be enabled")
+	}
+	if kubernetesClient(svc).recorder == nil {
+		t.Fatal("Expected a recorder")
+	}
+
+	kubernetesClient(svc).StopEvents()
+	if kubernetesClient(svc).recorder != nil {
+		t.Error("Expected StopEvents to disable events")
+	}
+}
+
+func TestExecFailureMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   ExitStatus
+		err      error
+		expected string
+	}{
+		{"Exited", ExitStatus{Code: 1, Reason: ExitReasonExited}, nil, ""},
+		{"Cancelled", ExitStatus{Code: 130, Reason: ExitReasonCancelled}, nil, ""},
+		{"Timed out", ExitStatus{Code: 124, Reason: ExitReasonTimeout}, nil, "Command timed out after 30s"},
+		{"Killed", ExitStatus{Code: 137, Reason: ExitReasonSignaled, Signal: "SIGKILL"}, nil, "Command killed by SIGKILL"},
+		{"OOM", ExitStatus{Code: 137, Reason: ExitReasonSignaled, Signal: "SIGKILL", LikelyOOM: true}, nil, "Command killed by SIGKILL, likely out of memory"},
+		{"Failed to run", ExitStatus{}, errors.New("container not found"), "Command failed to run: container not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := execFailureMessage(tt.status, tt.err, 30*time.Second); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -3,78 +3,70 @@ package service
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 )
 
+// ephemeralDeleteTimeout bounds deleting an ephemeral runner once its command has finished
+const ephemeralDeleteTimeout = 30 * time.Second
+
 // executeService implements the ExecuteService interface
 type executeService struct {
 	runnerService RunnerService
+	// readyTimeout bounds how long to wait for a runner created for a command
+	readyTimeout time.Duration
+	// pollInterval is how often a created runner is checked while waiting for it
+	pollInterval time.Duration
+	// disabledFeatures are refused up front, whichever runner the command would run in
+	disabledFeatures DisabledFeatures
 }
 
-// NewExecuteService creates a new execute service
-func NewExecuteService(runnerService RunnerService) ExecuteService {
+// NewExecuteService creates a new execute service refusing requests that use disabledFeatures
+func NewExecuteService(runnerService RunnerService, durations *Durations, disabledFeatures DisabledFeatures) ExecuteService {
 	return &executeService{
-		runnerService: runnerService,
+		runnerService:    runnerService,
+		readyTimeout:     durations.ExecRunnerReadyTimeout,
+		pollInterval:     1 * time.Second,
+		disabledFeatures: disabledFeatures,
 	}
 }
 
-// ExecuteCommand executes a command, creating a runner if needed
-func (s *executeService) ExecuteCommand(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (int32, error) {
-	// First, try to find an available running runner
-	runners, _, err := s.runnerService.ListRunners(ctx, &ListOptions{
-		Status: RunnerStatusRunning,
-		Limit:  10,
-	})
-	if err != nil {
-		return 1, fmt.Errorf("failed to list runners: %w", err)
+// ExecuteCommand executes a command in the runner picked by the request: the given runner,
+// a fresh runner for ephemeral and keep-runner requests, or otherwise any running runner,
+// creating one if needed
+func (s *executeService) ExecuteCommand(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+	if err := validateExecuteMode(req); err != nil {
+		return ExitStatus{}, err
+	}
+	if err := checkWorkspace(req.Workspace, s.disabledFeatures); err != nil {
+		return ExitStatus{}, err
 	}
 
-	var runnerID string
-	if len(runners) > 0 {
-		// Use the first available running runner
-		runnerID = runners[0].ID
-	} else {
-		// No running runners available, create a new one
-		createReq := &CreateRunnerRequest{
-			Name: fmt.Sprintf("auto-runner-%d", time.Now().Unix()),
-			// Pass through workspace config if available
-			Workspace: req.Workspace,
-			// Pass through environment variables if available
-			Env: req.Env,
+	runnerID := req.RunnerID
+	switch {
+	case runnerID != "":
+		if err := s.checkRunnerRunning(ctx, runnerID); err != nil {
+			return ExitStatus{}, err
 		}
 
-		runner, err := s.runnerService.CreateRunner(ctx, createReq)
+	case req.Ephemeral || req.KeepRunner:
+		var err error
+		runnerID, err = s.createRunner(ctx, req)
 		if err != nil {
-			return 1, fmt.Errorf("failed to create runner: %w", err)
+			return ExitStatus{}, err
+		}
+		if req.Ephemeral {
+			defer s.deleteEphemeralRunner(ctx, runnerID)
+		}
+		if err := s.waitForRunner(ctx, runnerID); err != nil {
+			return ExitStatus{}, err
 		}
 
-		runnerID = runner.ID
-
-		// Wait for runner to be ready (with timeout)
-		waitCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
-		defer cancel()
-
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-
-		runnerReady := false
-		for !runnerReady {
-			select {
-			case <-waitCtx.Done():
-				return 1, fmt.Errorf("timeout waiting for runner to be ready")
-			case <-ticker.C:
-				runner, err := s.runnerService.GetRunner(ctx, runnerID)
-				if err != nil {
-					return 1, fmt.Errorf("failed to get runner status: %w", err)
-				}
-
-				if runner.Status == RunnerStatusRunning {
-					// Runner is ready, exit the wait loop
-					runnerReady = true
-				} else if runner.Status == RunnerStatusError || runner.Status == RunnerStatusStopped {
-					return 1, fmt.Errorf("runner failed to start: status=%s", runner.Status)
-				}
-			}
+	default:
+		var err error
+		runnerID, err = s.findOrCreateRunner(ctx, req)
+		if err != nil {
+			return ExitStatus{}, err
 		}
 	}
 
@@ -82,11 +74,134 @@ func (s *executeService) ExecuteCommand(ctx context.Context, req *ExecuteCommand
 	execReq := &ExecuteCommandRequest{
 		RunnerID:   runnerID,
 		Command:    req.Command,
+		Argv:       req.Argv,
 		Shell:      req.Shell,
 		Timeout:    req.Timeout,
 		WorkingDir: req.WorkingDir,
+		Started:    req.Started,
+
+		ExecID:                req.ExecID,
+		DisableCorrelationEnv: req.DisableCorrelationEnv,
 	}
 
 	// Execute the command in the runner
 	return s.runnerService.ExecuteCommandStream(ctx, execReq, stdoutCh, stderrCh)
 }
+
+// validateExecuteMode rejects requests asking for more than one way of picking a runner (pure function)
+func validateExecuteMode(req *ExecuteCommandRequest) error {
+	return executeModeViolations(req).Err()
+}
+
+// executeModeViolations lists the conflicting ways of picking a runner a request asks for (pure function)
+func executeModeViolations(req *ExecuteCommandRequest) Violations {
+	var v Violations
+	if req.Ephemeral && req.KeepRunner {
+		v.Add("keep_runner", "ephemeral and keep_runner are mutually exclusive")
+	}
+	if req.RunnerID != "" && (req.Ephemeral || req.KeepRunner) {
+		v.Add("runner_id", "runner_id can't be combined with ephemeral or keep_runner")
+	}
+	if req.RunnerID != "" && req.Arch != "" {
+		v.Add("arch", "arch can't be combined with runner_id")
+	}
+	return v
+}
+
+// checkRunnerRunning fails unless the runner exists and is running
+func (s *executeService) checkRunnerRunning(ctx context.Context, runnerID string) error {
+	runner, err := s.runnerService.GetRunner(ctx, runnerID)
+	if err != nil {
+		return err
+	}
+	if runner.Status != RunnerStatusRunning {
+		return fmt.Errorf("%w: runner %s is %s", ErrRunnerNotRunning, runnerID, runner.Status)
+	}
+	return nil
+}
+
+// findOrCreateRunner returns a running runner, creating one and waiting for it if there is none
+func (s *executeService) findOrCreateRunner(ctx context.Context, req *ExecuteCommandRequest) (string, error) {
+	runners, _, err := s.runnerService.ListRunners(ctx, &ListOptions{
+		Status: RunnerStatusRunning,
+		Limit:  10,
+		Arch:   req.Arch,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list runners: %w", err)
+	}
+
+	if len(runners) > 0 {
+		// Use the first available running runner
+		return runners[0].ID, nil
+	}
+
+	// No running runners available, create a new one
+	runnerID, err := s.createRunner(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if err := s.waitForRunner(ctx, runnerID); err != nil {
+		return "", err
+	}
+	return runnerID, nil
+}
+
+// createRunner creates a runner for the command and returns its ID without waiting for it
+func (s *executeService) createRunner(ctx context.Context, req *ExecuteCommandRequest) (string, error) {
+	createReq := &CreateRunnerRequest{
+		Name: fmt.Sprintf("auto-runner-%d", time.Now().Unix()),
+		// Pass through workspace config if available
+		Workspace: req.Workspace,
+		// Pass through environment variables if available
+		Env:         req.Env,
+		Arch:        req.Arch,
+		AutoCreated: true,
+	}
+
+	runner, err := s.runnerService.CreateRunner(ctx, createReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to create runner: %w", err)
+	}
+	return runner.ID, nil
+}
+
+// waitForRunner waits until a created runner is running, bounded by readyTimeout
+func (s *executeService) waitForRunner(ctx context.Context, runnerID string) error {
+	waitCtx, cancel := context.WithTimeout(ctx, s.readyTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("timeout waiting for runner to be ready")
+		case <-ticker.C:
+			runner, err := s.runnerService.GetRunner(ctx, runnerID)
+			if err != nil {
+				return fmt.Errorf("failed to get runner status: %w", err)
+			}
+
+			if runner.Status == RunnerStatusRunning {
+				return nil
+			} else if runner.Status == RunnerStatusError || runner.Status == RunnerStatusStopped {
+				return fmt.Errorf("runner failed to start: status=%s", runner.Status)
+			}
+		}
+	}
+}
+
+// deleteEphemeralRunner deletes a runner created for a single command. It runs on a context
+// detached from ctx, so the runner is removed even when the client cancelled the stream.
+func (s *executeService) deleteEphemeralRunner(ctx context.Context, runnerID string) {
+	deleteCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ephemeralDeleteTimeout)
+	defer cancel()
+
+	if _, err := s.runnerService.DeleteRunner(deleteCtx, runnerID, false); err != nil {
+		slog.Warn("Failed to delete ephemeral runner", "runner_id", runnerID, "error", err)
+		return
+	}
+	slog.Info("Deleted ephemeral runner", "runner_id", runnerID)
+}
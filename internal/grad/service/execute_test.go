@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// executeMockRunnerService records which runners commands ran in. Created runners start out running.
+type executeMockRunnerService struct {
+	*mockRunnerService
+	mu          sync.Mutex
+	created     int
+	autoCreated int
+	executed    []string
+	// execute runs in place of the command; nil exits with code 0
+	execute func(ctx context.Context) (ExitStatus, error)
+}
+
+func newExecuteMockRunnerService() *executeMockRunnerService {
+	return &executeMockRunnerService{mockRunnerService: newMockRunnerService()}
+}
+
+func (m *executeMockRunnerService) CreateRunner(ctx context.Context, req *CreateRunnerRequest) (*Runner, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.created++
+	if req.AutoCreated {
+		m.autoCreated++
+	}
+	runner := &Runner{ID: fmt.Sprintf("created-%d", m.created), Status: RunnerStatusRunning, Arch: req.Arch}
+	m.runners[runner.ID] = runner
+	return runner, nil
+}
+
+func (m *executeMockRunnerService) DeleteRunner(ctx context.Context, runnerID string, overrideProtection bool) (*RunnerCleanup, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mockRunnerService.DeleteRunner(ctx, runnerID, overrideProtection)
+}
+
+func (m *executeMockRunnerService) GetRunner(ctx context.Context, runnerID string) (*Runner, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mockRunnerService.GetRunner(ctx, runnerID)
+}
+
+func (m *executeMockRunnerService) ListRunners(ctx context.Context, opts *ListOptions) ([]*Runner, int32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var runners []*Runner
+	for _, runner := range m.runners {
+		if runner.Status == opts.Status && (opts.Arch == "" || runner.Arch == opts.Arch) {
+			runners = append(runners, runner)
+		}
+	}
+	return runners, int32(len(runners)), nil
+}
+
+func (m *executeMockRunnerService) ExecuteCommandStream(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+	m.mu.Lock()
+	m.executed = append(m.executed, req.RunnerID)
+	m.mu.Unlock()
+	if req.Started != nil {
+		req.Started(ExecStart{RunnerID: req.RunnerID})
+	}
+	if m.execute != nil {
+		return m.execute(ctx)
+	}
+	return ExitStatus{Reason: ExitReasonExited}, nil
+}
+
+func newTestExecuteService(runners RunnerService) *executeService {
+	svc := NewExecuteService(runners, DefaultDurations(), nil).(*executeService)
+	svc.pollInterval = time.Millisecond
+	return svc
+}
+
+func TestExecuteCommandTargetRunner(t *testing.T) {
+	runners := newExecuteMockRunnerService()
+	runners.runners["runner-1"] = &Runner{ID: "runner-1", Status: RunnerStatusRunning}
+	runners.runners["runner-2"] = &Runner{ID: "runner-2", Status: RunnerStatusRunning}
+	runners.runners["runner-3"] = &Runner{ID: "runner-3", Status: RunnerStatusCreating}
+	svc := newTestExecuteService(runners)
+	ctx := context.Background()
+
+	if _, err := svc.ExecuteCommand(ctx, &ExecuteCommandRequest{RunnerID: "runner-2", Command: "true"}, nil, nil); err != nil {
+		t.Fatalf("ExecuteCommand failed: %v", err)
+	}
+	if len(runners.executed) != 1 || runners.executed[0] != "runner-2" {
+		t.Errorf("Expected the command to run in runner-2, got %v", runners.executed)
+	}
+
+	_, err := svc.ExecuteCommand(ctx, &ExecuteCommandRequest{RunnerID: "missing", Command: "true"}, nil, nil)
+	if !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("Expected ErrRunnerNotFound, got %v", err)
+	}
+	_, err = svc.ExecuteCommand(ctx, &ExecuteCommandRequest{RunnerID: "runner-3", Command: "true"}, nil, nil)
+	if !errors.Is(err, ErrRunnerNotRunning) {
+		t.Errorf("Expected ErrRunnerNotRunning, got %v", err)
+	}
+	if runners.created != 0 || len(runners.executed) != 1 {
+		t.Errorf("Expected no runner to be created and nothing else run, got %d created, ran in %v", runners.created, runners.executed)
+	}
+}
+
+func TestExecuteCommandReportsStart(t *testing.T) {
+	runners := newExecuteMockRunnerService()
+	svc := newTestExecuteService(runners)
+
+	var started []ExecStart
+	req := &ExecuteCommandRequest{Command: "true", Started: func(start ExecStart) { started = append(started, start) }}
+	if _, err := svc.ExecuteCommand(context.Background(), req, nil, nil); err != nil {
+		t.Fatalf("ExecuteCommand failed: %v", err)
+	}
+	if len(started) != 1 || started[0].RunnerID != "created-1" {
+		t.Errorf("Expected the start to be reported once with the created runner, got %v", started)
+	}
+}
+
+func TestExecuteCommandEphemeral(t *testing.T) {
+	runners := newExecuteMockRunnerService()
+	runners.runners["runner-1"] = &Runner{ID: "runner-1", Status: RunnerStatusRunning}
+	runners.execute = func(ctx context.Context) (ExitStatus, error) {
+		return ExitStatus{Code: 3, Reason: ExitReasonExited}, nil
+	}
+	svc := newTestExecuteService(runners)
+
+	status, err := svc.ExecuteCommand(context.Background(), &ExecuteCommandRequest{Command: "exit 3", Ephemeral: true}, nil, nil)
+	if err != nil {
+		t.Fatalf("ExecuteCommand failed: %v", err)
+	}
+	if status.Code != 3 {
+		t.Errorf("Expected exit code 3, got %d", status.Code)
+	}
+	if len(runners.executed) != 1 || runners.executed[0] != "created-1" {
+		t.Errorf("Expected the command to run in a fresh runner, got %v", runners.executed)
+	}
+	if len(runners.deletedRunners) != 1 || runners.deletedRunners[0] != "created-1" {
+		t.Errorf("Expected the fresh runner to be deleted, got %v", runners.deletedRunners)
+	}
+}
+
+func TestExecuteCommandEphemeralDeletesOnCancel(t *testing.T) {
+	runners := newExecuteMockRunnerService()
+	ctx, cancel := context.WithCancel(context.Background())
+	runners.execute = func(execCtx context.Context) (ExitStatus, error) {
+		// The client hangs up while the command runs
+		cancel()
+		<-execCtx.Done()
+		return ExitStatus{}, execCtx.Err()
+	}
+	svc := newTestExecuteService(runners)
+
+	_, err := svc.ExecuteCommand(ctx, &ExecuteCommandRequest{Command: "sleep 60", Ephemeral: true}, nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(runners.deletedRunners) != 1 || runners.deletedRunners[0] != "created-1" {
+		t.Errorf("Expected the runner to be deleted after cancellation, got %v", runners.deletedRunners)
+	}
+}
+
+func TestExecuteCommandKeepRunner(t *testing.T) {
+	runners := newExecuteMockRunnerService()
+	runners.runners["runner-1"] = &Runner{ID: "runner-1", Status: RunnerStatusRunning}
+	svc := newTestExecuteService(runners)
+
+	if _, err := svc.ExecuteCommand(context.Background(), &ExecuteCommandRequest{Command: "true", KeepRunner: true}, nil, nil); err != nil {
+		t.Fatalf("ExecuteCommand failed: %v", err)
+	}
+	if len(runners.executed) != 1 || runners.executed[0] != "created-1" {
+		t.Errorf("Expected the command to run in a fresh runner, got %v", runners.executed)
+	}
+	if runners.autoCreated != 1 {
+		t.Errorf("Expected the runner to be created as an auto-runner, for its priority class")
+	}
+	if len(runners.deletedRunners) != 0 {
+		t.Errorf("Expected the runner to be kept, got deleted %v", runners.deletedRunners)
+	}
+}
+
+func TestExecuteCommandReusesRunningRunner(t *testing.T) {
+	runners := newExecuteMockRunnerService()
+	runners.runners["runner-1"] = &Runner{ID: "runner-1", Status: RunnerStatusRunning}
+	svc := newTestExecuteService(runners)
+
+	if _, err := svc.ExecuteCommand(context.Background(), &ExecuteCommandRequest{Command: "true"}, nil, nil); err != nil {
+		t.Fatalf("ExecuteCommand failed: %v", err)
+	}
+	if runners.created != 0 || len(runners.executed) != 1 || runners.executed[0] != "runner-1" {
+		t.Errorf("Expected runner-1 to be reused, got %d created, ran in %v", runners.created, runners.executed)
+	}
+}
+
+func TestExecuteCommandArch(t *testing.T) {
+	runners := newExecuteMockRunnerService()
+	runners.runners["runner-1"] = &Runner{ID: "runner-1", Status: RunnerStatusRunning, Arch: "amd64"}
+	svc := newTestExecuteService(runners)
+
+	// No arm64 runner is running, so one is created for the command and reused after it
+	for range 2 {
+		if _, err := svc.ExecuteCommand(context.Background(), &ExecuteCommandRequest{Command: "true", Arch: "arm64"}, nil, nil); err != nil {
+			t.Fatalf("ExecuteCommand failed: %v", err)
+		}
+	}
+	if runners.created != 1 || runners.runners["created-1"].Arch != "arm64" {
+		t.Fatalf("Expected one arm64 runner to be created, got %d", runners.created)
+	}
+	if len(runners.executed) != 2 || runners.executed[0] != "created-1" || runners.executed[1] != "created-1" {
+		t.Errorf("Expected both commands to run in created-1, got %v", runners.executed)
+	}
+}
+
+func TestValidateExecuteMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         *ExecuteCommandRequest
+		expectError bool
+	}{
+		{"Default", &ExecuteCommandRequest{}, false},
+		{"Runner", &ExecuteCommandRequest{RunnerID: "runner-1"}, false},
+		{"Ephemeral", &ExecuteCommandRequest{Ephemeral: true}, false},
+		{"Keep runner", &ExecuteCommandRequest{KeepRunner: true}, false},
+		{"Ephemeral and keep runner", &ExecuteCommandRequest{Ephemeral: true, KeepRunner: true}, true},
+		{"Runner and ephemeral", &ExecuteCommandRequest{RunnerID: "runner-1", Ephemeral: true}, true},
+		{"Runner and keep runner", &ExecuteCommandRequest{RunnerID: "runner-1", KeepRunner: true}, true},
+		{"Arch", &ExecuteCommandRequest{Arch: "arm64"}, false},
+		{"Runner and arch", &ExecuteCommandRequest{RunnerID: "runner-1", Arch: "arm64"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateExecuteMode(tt.req)
+			if tt.expectError != errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("Expected error=%v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// Exit codes used when a command didn't exit on its own, following shell conventions
+const (
+	// ExitCodeTimeout matches coreutils timeout(1)
+	ExitCodeTimeout int32 = 124
+	// ExitCodeCancelled matches a shell interrupted by SIGINT
+	ExitCodeCancelled int32 = 130
+	// ExitCodeOutputLimit matches a command whose reader went away (128+SIGPIPE)
+	ExitCodeOutputLimit int32 = 141
+	// exitCodeSignalBase is added to the signal number for commands killed by a signal
+	exitCodeSignalBase int32 = 128
+	// maxSignal bounds the exit codes read as 128+signal
+	maxSignal = 64
+)
+
+// signalNames maps common signal numbers to their names; the numbers are the same on all Linux architectures
+var signalNames = map[int]string{
+	1:  "SIGHUP",
+	2:  "SIGINT",
+	3:  "SIGQUIT",
+	4:  "SIGILL",
+	6:  "SIGABRT",
+	8:  "SIGFPE",
+	9:  "SIGKILL",
+	11: "SIGSEGV",
+	13: "SIGPIPE",
+	14: "SIGALRM",
+	15: "SIGTERM",
+}
+
+// signalName returns the name of a signal number, e.g. "SIGKILL" (pure function)
+func signalName(signal int) string {
+	if name, ok := signalNames[signal]; ok {
+		return name
+	}
+	return fmt.Sprintf("SIG%d", signal)
+}
+
+// signaledExitStatus builds the status of a command killed by a signal (pure function)
+func signaledExitStatus(signal int) ExitStatus {
+	return ExitStatus{
+		Code:   exitCodeSignalBase + int32(signal),
+		Reason: ExitReasonSignaled,
+		Signal: signalName(signal),
+	}
+}
+
+// exitStatusFromError classifies the error returned by running a command. Commands that ran
+// and exited, were killed, timed out or were cancelled get an ExitStatus; any other error
+// means the command couldn't be run and is returned unchanged.
+func exitStatusFromError(ctx context.Context, err error) (ExitStatus, error) {
+	if err == nil {
+		return ExitStatus{Code: 0, Reason: ExitReasonExited}, nil
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return ExitStatus{Code: ExitCodeTimeout, Reason: ExitReasonTimeout}, nil
+	case errors.Is(ctx.Err(), context.Canceled):
+		return ExitStatus{Code: ExitCodeCancelled, Reason: ExitReasonCancelled}, nil
+	}
+
+	var processErr *exec.ExitError
+	if errors.As(err, &processErr) {
+		if status, ok := processErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return signaledExitStatus(int(status.Signal())), nil
+		}
+		return ExitStatus{Code: int32(processErr.ExitCode()), Reason: ExitReasonExited}, nil
+	}
+
+	// The Kubernetes exec API reports the command's exit code as a CodeExitError. A command
+	// killed by a signal shows up as 128+signal, which is the only way to tell it apart.
+	var codeErr utilexec.ExitError
+	if errors.As(err, &codeErr) {
+		code := int32(codeErr.ExitStatus())
+		if code > exitCodeSignalBase && code <= exitCodeSignalBase+maxSignal {
+			return signaledExitStatus(int(code - exitCodeSignalBase)), nil
+		}
+		return ExitStatus{Code: code, Reason: ExitReasonExited}, nil
+	}
+
+	return ExitStatus{}, err
+}
+
+// oomKilledSince reports whether the runner container was OOM-killed after since (pure function)
+func oomKilledSince(pod *corev1.Pod, since time.Time) bool {
+	// Container timestamps only have second precision
+	since = since.Truncate(time.Second)
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != RunnerContainerName {
+			continue
+		}
+		for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+			if terminated != nil && terminated.Reason == "OOMKilled" && !terminated.FinishedAt.Time.Before(since) {
+				return true
+			}
+		}
+	}
+	return false
+}
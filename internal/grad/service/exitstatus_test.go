@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// runChild runs a shell command as a child process and returns its error
+func runChild(t *testing.T, script string, signal syscall.Signal) error {
+	t.Helper()
+
+	cmd := exec.Command("sh", "-c", script)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start child process: %v", err)
+	}
+	if signal != 0 {
+		if err := cmd.Process.Signal(signal); err != nil {
+			t.Fatalf("Failed to signal child process: %v", err)
+		}
+	}
+	return cmd.Wait()
+}
+
+func TestExitStatusFromError(t *testing.T) {
+	expired, cancelExpired := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancelExpired()
+	<-expired.Done()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		err          func(t *testing.T) error
+		expectStatus ExitStatus
+		expectError  bool
+	}{
+		{
+			name:         "Success",
+			err:          func(t *testing.T) error { return nil },
+			expectStatus: ExitStatus{Code: 0, Reason: ExitReasonExited},
+		},
+		{
+			name: "Kubernetes exec exit code",
+			err: func(t *testing.T) error {
+				return utilexec.CodeExitError{Err: errors.New("command terminated with exit code 3"), Code: 3}
+			},
+			expectStatus: ExitStatus{Code: 3, Reason: ExitReasonExited},
+		},
+		{
+			name:         "Wrapped Kubernetes exec exit code",
+			err:          func(t *testing.T) error { return fmt.Errorf("stream: %w", utilexec.CodeExitError{Code: 1}) },
+			expectStatus: ExitStatus{Code: 1, Reason: ExitReasonExited},
+		},
+		{
+			name:         "Kubernetes exec killed by SIGKILL",
+			err:          func(t *testing.T) error { return utilexec.CodeExitError{Code: 137} },
+			expectStatus: ExitStatus{Code: 137, Reason: ExitReasonSignaled, Signal: "SIGKILL"},
+		},
+		{
+			name:         "Kubernetes exec killed by SIGTERM",
+			err:          func(t *testing.T) error { return utilexec.CodeExitError{Code: 143} },
+			expectStatus: ExitStatus{Code: 143, Reason: ExitReasonSignaled, Signal: "SIGTERM"},
+		},
+		{
+			name:         "Kubernetes exec exit code above the signal range",
+			err:          func(t *testing.T) error { return utilexec.CodeExitError{Code: 200} },
+			expectStatus: ExitStatus{Code: 200, Reason: ExitReasonExited},
+		},
+		{
+			name:         "Child process exit code",
+			err:          func(t *testing.T) error { return runChild(t, "exit 7", 0) },
+			expectStatus: ExitStatus{Code: 7, Reason: ExitReasonExited},
+		},
+		{
+			name:         "Child process killed by SIGTERM",
+			err:          func(t *testing.T) error { return runChild(t, "sleep 10", syscall.SIGTERM) },
+			expectStatus: ExitStatus{Code: 143, Reason: ExitReasonSignaled, Signal: "SIGTERM"},
+		},
+		{
+			name:         "Child process killed by SIGKILL",
+			err:          func(t *testing.T) error { return runChild(t, "sleep 10", syscall.SIGKILL) },
+			expectStatus: ExitStatus{Code: 137, Reason: ExitReasonSignaled, Signal: "SIGKILL"},
+		},
+		{
+			name:         "Timeout",
+			ctx:          expired,
+			err:          func(t *testing.T) error { return context.DeadlineExceeded },
+			expectStatus: ExitStatus{Code: ExitCodeTimeout, Reason: ExitReasonTimeout},
+		},
+		{
+			name:         "Cancelled",
+			ctx:          cancelled,
+			err:          func(t *testing.T) error { return errors.New("stream closed") },
+			expectStatus: ExitStatus{Code: ExitCodeCancelled, Reason: ExitReasonCancelled},
+		},
+		{
+			name:        "Infrastructure failure",
+			err:         func(t *testing.T) error { return errors.New("error dialing backend: connection refused") },
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			status, err := exitStatusFromError(ctx, tt.err(t))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected infrastructure error to be returned, got status %+v", status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if status != tt.expectStatus {
+				t.Errorf("Expected %+v, got %+v", tt.expectStatus, status)
+			}
+		})
+	}
+}
+
+func TestExitStatusString(t *testing.T) {
+	tests := []struct {
+		status   ExitStatus
+		expected string
+	}{
+		{ExitStatus{Code: 2, Reason: ExitReasonExited}, "exited with code 2"},
+		{signaledExitStatus(9), "terminated by SIGKILL"},
+		{signaledExitStatus(40), "terminated by SIG40"},
+		{ExitStatus{Code: 137, Reason: ExitReasonSignaled, Signal: "SIGKILL", LikelyOOM: true}, "terminated by SIGKILL (likely OOM)"},
+		{ExitStatus{Code: ExitCodeTimeout, Reason: ExitReasonTimeout}, "timed out"},
+		{ExitStatus{Code: ExitCodeCancelled, Reason: ExitReasonCancelled}, "cancelled"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.String(); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestOOMKilledSince(t *testing.T) {
+	startedAt := time.Date(2025, 1, 1, 12, 0, 0, 500000000, time.UTC)
+	terminated := func(reason string, finishedAt time.Time) *corev1.ContainerStateTerminated {
+		return &corev1.ContainerStateTerminated{Reason: reason, ExitCode: 137, FinishedAt: metav1.NewTime(finishedAt)}
+	}
+
+	tests := []struct {
+		name     string
+		status   corev1.ContainerStatus
+		expected bool
+	}{
+		{
+			name:     "OOM-killed during the command",
+			status:   corev1.ContainerStatus{Name: "runner", LastTerminationState: corev1.ContainerState{Terminated: terminated("OOMKilled", startedAt.Add(5*time.Second))}},
+			expected: true,
+		},
+		{
+			name:     "OOM-killed in the same second",
+			status:   corev1.ContainerStatus{Name: "runner", State: corev1.ContainerState{Terminated: terminated("OOMKilled", startedAt.Truncate(time.Second))}},
+			expected: true,
+		},
+		{
+			name:   "OOM-killed before the command",
+			status: corev1.ContainerStatus{Name: "runner", LastTerminationState: corev1.ContainerState{Terminated: terminated("OOMKilled", startedAt.Add(-time.Hour))}},
+		},
+		{
+			name:   "Terminated for another reason",
+			status: corev1.ContainerStatus{Name: "runner", LastTerminationState: corev1.ContainerState{Terminated: terminated("Error", startedAt.Add(time.Second))}},
+		},
+		{
+			name:   "Sidecar OOM-killed",
+			status: corev1.ContainerStatus{Name: "s3fs-sidecar", LastTerminationState: corev1.ContainerState{Terminated: terminated("OOMKilled", startedAt.Add(time.Second))}},
+		},
+		{
+			name:   "Never terminated",
+			status: corev1.ContainerStatus{Name: "runner"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{tt.status}}}
+			if got := oomKilledSince(pod, startedAt); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
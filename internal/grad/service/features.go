@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Features that can be turned off with GRAD_DISABLE_FEATURES, e.g. on an air-gapped cluster
+// that can't reach S3 or a collector
+const (
+	// FeatureS3Workspace mounts S3 buckets into runners through the s3fs sidecar
+	FeatureS3Workspace = "s3-workspace"
+	// FeatureWebhooks sends notifications to outside endpoints. grad has no webhook
+	// integration yet, so disabling it only records that none may be added.
+	FeatureWebhooks = "webhooks"
+	// FeatureTracing propagates grad's trace context to commands as TRACEPARENT
+	FeatureTracing = "tracing"
+)
+
+// knownFeatures are the features GRAD_DISABLE_FEATURES accepts
+var knownFeatures = []string{FeatureS3Workspace, FeatureWebhooks, FeatureTracing}
+
+// DisabledFeatures is the set of features turned off on this server; nil disables none
+type DisabledFeatures map[string]bool
+
+// ParseDisabledFeatures parses a comma-separated list of feature names, e.g.
+// "s3-workspace,tracing" (pure function)
+func ParseDisabledFeatures(value string) (DisabledFeatures, error) {
+	var features DisabledFeatures
+	for _, name := range splitList(value) {
+		if !slices.Contains(knownFeatures, name) {
+			return nil, fmt.Errorf("unknown feature %q, must be one of %s", name, strings.Join(knownFeatures, ", "))
+		}
+		if features == nil {
+			features = make(DisabledFeatures)
+		}
+		features[name] = true
+	}
+	return features, nil
+}
+
+// Disabled reports whether feature is turned off
+func (f DisabledFeatures) Disabled(feature string) bool {
+	return f[feature]
+}
+
+// List returns the disabled features in name order, nil if none
+func (f DisabledFeatures) List() []string {
+	if len(f) == 0 {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(f))
+}
+
+func (f DisabledFeatures) String() string {
+	return strings.Join(f.List(), ",")
+}
+
+// checkWorkspace fails with ErrFailedPrecondition if a request asks for an S3 workspace but
+// the server has them disabled (pure function)
+func checkWorkspace(workspace *WorkspaceConfig, features DisabledFeatures) error {
+	if workspace != nil && workspace.Bucket != "" && features.Disabled(FeatureS3Workspace) {
+		return fmt.Errorf("%w: s3 workspaces are disabled on this server", ErrFailedPrecondition)
+	}
+	return nil
+}
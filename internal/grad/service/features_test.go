@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestParseDisabledFeatures(t *testing.T) {
+	features, err := ParseDisabledFeatures("tracing, s3-workspace,,webhooks")
+	if err != nil {
+		t.Fatalf("ParseDisabledFeatures failed: %v", err)
+	}
+	if expected := []string{"s3-workspace", "tracing", "webhooks"}; !slices.Equal(features.List(), expected) {
+		t.Errorf("Expected %v, got %v", expected, features.List())
+	}
+	if features.String() != "s3-workspace,tracing,webhooks" {
+		t.Errorf("Expected the features comma-separated, got %q", features.String())
+	}
+
+	if features, err := ParseDisabledFeatures(""); err != nil || features.List() != nil || features.Disabled(FeatureTracing) {
+		t.Errorf("Expected no disabled features, got %v and %v", features, err)
+	}
+
+	_, err = ParseDisabledFeatures("s3-workspace,s3")
+	if err == nil || !strings.Contains(err.Error(), `"s3"`) || !strings.Contains(err.Error(), "s3-workspace, webhooks, tracing") {
+		t.Errorf("Expected the unknown feature to be rejected with the known ones, got %v", err)
+	}
+}
+
+func TestLoadConfigFromDisabledFeatures(t *testing.T) {
+	config, err := LoadConfigFrom(mapLookup(map[string]string{"GRAD_DISABLE_FEATURES": "s3-workspace,tracing"}))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+	if features := config.Kubernetes.DisabledFeatures; !features.Disabled(FeatureS3Workspace) || !features.Disabled(FeatureTracing) || features.Disabled(FeatureWebhooks) {
+		t.Errorf("Expected s3-workspace and tracing disabled, got %v", features)
+	}
+
+	_, err = LoadConfigFrom(mapLookup(map[string]string{"GRAD_DISABLE_FEATURES": "telemetry"}))
+	if err == nil || !strings.Contains(err.Error(), "GRAD_DISABLE_FEATURES") {
+		t.Errorf("Expected an unknown feature to be rejected, got %v", err)
+	}
+}
+
+func TestPodCreationRequestS3WorkspaceDisabled(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	config.DisabledFeatures = DisabledFeatures{FeatureS3Workspace: true}
+
+	// A runner created before S3 workspaces were disabled comes back without the sidecar
+	req := buildPodCreationRequest(t, &Runner{ID: "runner-1", Workspace: &WorkspaceConfig{Bucket: "datasets"}}, config)
+	if req.Workspace != nil || req.S3FSImage != "" {
+		t.Errorf("Expected no workspace or s3fs image, got %+v and %q", req.Workspace, req.S3FSImage)
+	}
+	pod := req.ToPodSpec()
+	if findContainer(pod, S3FSContainerName) != nil || len(pod.Spec.Containers) != 1 || len(pod.Spec.Volumes) != 0 {
+		t.Errorf("Expected only the runner container without volumes, got %+v", pod.Spec)
+	}
+}
+
+func TestCreateRunnerS3WorkspaceDisabled(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	defer svc.Stop()
+	svc.k8sClient.Config().DisabledFeatures = DisabledFeatures{FeatureS3Workspace: true}
+
+	_, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Workspace: &WorkspaceConfig{Bucket: "datasets"}})
+	if !errors.Is(err, ErrFailedPrecondition) || !strings.Contains(err.Error(), "s3 workspaces are disabled on this server") {
+		t.Errorf("Expected ErrFailedPrecondition for a workspace, got %v", err)
+	}
+
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{}); err != nil {
+		t.Errorf("Expected a runner without workspace to be created, got %v", err)
+	}
+}
+
+func TestExecuteCommandS3WorkspaceDisabled(t *testing.T) {
+	runners := newExecuteMockRunnerService()
+	runners.runners["runner-1"] = &Runner{ID: "runner-1", Status: RunnerStatusRunning}
+	svc := NewExecuteService(runners, DefaultDurations(), DisabledFeatures{FeatureS3Workspace: true})
+
+	// Refused even when a running runner could take the command
+	req := &ExecuteCommandRequest{Command: "ls /workspace", Workspace: &WorkspaceConfig{Bucket: "datasets"}}
+	if _, err := svc.ExecuteCommand(context.Background(), req, nil, nil); !errors.Is(err, ErrFailedPrecondition) {
+		t.Errorf("Expected ErrFailedPrecondition for a workspace, got %v", err)
+	}
+	if len(runners.executed) != 0 {
+		t.Errorf("Expected no command to run, got %v", runners.executed)
+	}
+}
+
+func TestSnapshotWorkspaceS3WorkspaceDisabled(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+	svc.k8sClient.Config().DisabledFeatures = DisabledFeatures{FeatureS3Workspace: true}
+
+	var script string
+	_, err := svc.snapshotWorkspace(ctx, snapshotExec(&script, 0), &SnapshotRequest{RunnerID: "runner-1", Destination: "s3://archive/"}, nil)
+	if !errors.Is(err, ErrFailedPrecondition) || !strings.Contains(err.Error(), "s3 snapshots are disabled on this server") {
+		t.Errorf("Expected ErrFailedPrecondition, got %v", err)
+	}
+	if script != "" {
+		t.Errorf("Expected nothing to run in the runner, got:\n%s", script)
+	}
+}
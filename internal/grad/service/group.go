@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// RunnerGroupLabel is the pod label holding the runner's group
+const RunnerGroupLabel = RunnerAnnotationPrefix + "group"
+
+// groupDeleteConcurrency bounds how many runners of a group are deleted at once
+const groupDeleteConcurrency = 8
+
+// RunnerGroupDeletion is the outcome of deleting the runners of a group
+type RunnerGroupDeletion struct {
+	Deleted []string
+	Failed  []RunnerDeleteFailure
+}
+
+// RunnerDeleteFailure is a runner of a group that could not be deleted, or only partially
+type RunnerDeleteFailure struct {
+	RunnerID string
+	Err      error
+}
+
+// validateGroup checks that a group name can be stored as a label value (pure function)
+func validateGroup(group string) error {
+	if problems := validation.IsValidLabelValue(group); len(problems) > 0 {
+		return fmt.Errorf("%w: invalid group %q: %s", ErrInvalidRequest, group, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// DeleteRunnerGroup deletes every runner of group concurrently. Failures of single runners
+// are reported in the result rather than as an error, so the others are still deleted.
+// Protected runners fail with ErrRunnerProtected.
+func (s *runnerService) DeleteRunnerGroup(ctx context.Context, group string) (*RunnerGroupDeletion, error) {
+	if group == "" {
+		return nil, fmt.Errorf("%w: group is required", ErrInvalidRequest)
+	}
+
+	runners, _, err := s.ListRunners(ctx, &ListOptions{Group: group})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		slots  = make(chan struct{}, groupDeleteConcurrency)
+		result = &RunnerGroupDeletion{}
+	)
+	for _, runner := range runners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			_, err := s.DeleteRunner(ctx, runner.ID, false)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed = append(result.Failed, RunnerDeleteFailure{RunnerID: runner.ID, Err: err})
+			} else {
+				result.Deleted = append(result.Deleted, runner.ID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Strings(result.Deleted)
+	sort.Slice(result.Failed, func(i, j int) bool { return result.Failed[i].RunnerID < result.Failed[j].RunnerID })
+	return result, nil
+}
+
+// GroupIdleTimeout is the idle timeout of runners whose group matches Pattern, a
+// path.Match pattern such as "ci-*"
+type GroupIdleTimeout struct {
+	Pattern string
+	Timeout time.Duration
+}
+
+// GroupIdleTimeouts are idle timeouts overriding the default for some groups.
+// The first matching pattern applies.
+type GroupIdleTimeouts []GroupIdleTimeout
+
+// For returns the idle timeout of group, and false if no pattern matches it
+func (t GroupIdleTimeouts) For(group string) (time.Duration, bool) {
+	if group == "" {
+		return 0, false
+	}
+	for _, timeout := range t {
+		if matched, _ := path.Match(timeout.Pattern, group); matched {
+			return timeout.Timeout, true
+		}
+	}
+	return 0, false
+}
+
+// String formats the timeouts the way ParseGroupIdleTimeouts reads them
+func (t GroupIdleTimeouts) String() string {
+	pairs := make([]string, len(t))
+	for i, timeout := range t {
+		pairs[i] = timeout.Pattern + "=" + timeout.Timeout.String()
+	}
+	return strings.Join(pairs, ",")
+}
+
+// ParseGroupIdleTimeouts parses comma-separated pattern=duration pairs, e.g. "ci-*=15m,nightly=2h"
+func ParseGroupIdleTimeouts(value string) (GroupIdleTimeouts, error) {
+	var timeouts GroupIdleTimeouts
+	for _, pair := range splitList(value) {
+		pattern, duration, ok := strings.Cut(pair, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("%q is not pattern=duration", pair)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid group pattern %q: %v", pattern, err)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil {
+			return nil, fmt.Errorf("invalid idle timeout for %q: %v", pattern, err)
+		}
+		timeouts = append(timeouts, GroupIdleTimeout{Pattern: pattern, Timeout: timeout})
+	}
+	return timeouts, nil
+}
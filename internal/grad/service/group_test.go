@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// createGroupRunners creates count runners in group and returns their IDs, sorted
+func createGroupRunners(t *testing.T, svc *runnerService, group string, count int) []string {
+	t.Helper()
+
+	var ids []string
+	for i := 0; i < count; i++ {
+		runner, err := svc.CreateRunner(context.Background(), &CreateRunnerRequest{Group: group})
+		if err != nil {
+			t.Fatalf("CreateRunner failed: %v", err)
+		}
+		if runner.Group != group {
+			t.Errorf("Expected group %q, got %q", group, runner.Group)
+		}
+		ids = append(ids, runner.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestListRunnersByGroup(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	ci := createGroupRunners(t, svc, "ci-1234", 2)
+	createGroupRunners(t, svc, "ci-5678", 1)
+	createGroupRunners(t, svc, "", 1)
+
+	runners, total, err := svc.ListRunners(ctx, &ListOptions{Group: "ci-1234"})
+	if err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+	var ids []string
+	for _, runner := range runners {
+		ids = append(ids, runner.ID)
+	}
+	sort.Strings(ids)
+	if total != 2 || !reflect.DeepEqual(ids, ci) {
+		t.Errorf("Expected runners %v, got %v (total %d)", ci, ids, total)
+	}
+
+	if _, total, _ := svc.ListRunners(ctx, &ListOptions{}); total != 4 {
+		t.Errorf("Expected 4 runners without a group filter, got %d", total)
+	}
+}
+
+func TestCreateRunnerInvalidGroup(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	for _, group := range []string{"ci 1234", "-ci", "ci/1234"} {
+		if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Group: group}); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest for group %q, got %v", group, err)
+		}
+	}
+}
+
+func TestDeleteRunnerGroup(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	emulatePodFinalizers(clientset)
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	members := createGroupRunners(t, svc, "ci-1234", 3)
+	other := createGroupRunners(t, svc, "ci-5678", 1)
+
+	// One member has a workspace claim that can't be deleted
+	failing := members[1]
+	namespace := svc.k8sClient.Config().Namespace
+	claim := &corev1.PersistentVolumeClaim{ObjectMeta: runnerObjectMeta(namespace, "workspace", failing)}
+	if err := clientset.Tracker().Add(claim); err != nil {
+		t.Fatalf("Failed to add claim: %v", err)
+	}
+	clientset.PrependReactor("delete", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("admission webhook denied the request")
+	})
+
+	deletion, err := svc.DeleteRunnerGroup(ctx, "ci-1234")
+	if err != nil {
+		t.Fatalf("DeleteRunnerGroup failed: %v", err)
+	}
+
+	expectDeleted := []string{members[0], members[2]}
+	if !reflect.DeepEqual(deletion.Deleted, expectDeleted) {
+		t.Errorf("Expected %v to be deleted, got %v", expectDeleted, deletion.Deleted)
+	}
+	if len(deletion.Failed) != 1 || deletion.Failed[0].RunnerID != failing || !errors.Is(deletion.Failed[0].Err, ErrCleanupIncomplete) {
+		t.Fatalf("Expected only %s to fail with ErrCleanupIncomplete, got %+v", failing, deletion.Failed)
+	}
+
+	if runner, err := svc.GetRunner(ctx, failing); err != nil || runner.Status != RunnerStatusDeleteFailed {
+		t.Errorf("Expected %s to be kept as %s, got %v, %v", failing, RunnerStatusDeleteFailed, runner, err)
+	}
+	if _, err := svc.GetRunner(ctx, other[0]); err != nil {
+		t.Errorf("Expected runner of another group to be kept, got %v", err)
+	}
+
+	if _, err := svc.DeleteRunnerGroup(ctx, ""); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest without a group, got %v", err)
+	}
+	if deletion, err := svc.DeleteRunnerGroup(ctx, "empty"); err != nil || len(deletion.Deleted)+len(deletion.Failed) != 0 {
+		t.Errorf("Expected nothing to delete in an empty group, got %+v, %v", deletion, err)
+	}
+}
+
+func TestGroupIdleTimeoutsFor(t *testing.T) {
+	timeouts, err := ParseGroupIdleTimeouts("ci-*=15m,ci-long=2h,nightly=2h")
+	if err != nil {
+		t.Fatalf("ParseGroupIdleTimeouts failed: %v", err)
+	}
+
+	tests := []struct {
+		group         string
+		expectTimeout time.Duration
+		expectOK      bool
+	}{
+		{"ci-1234", 15 * time.Minute, true},
+		// The first match wins
+		{"ci-long", 15 * time.Minute, true},
+		{"nightly", 2 * time.Hour, true},
+		{"nightly-2", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		timeout, ok := timeouts.For(tt.group)
+		if timeout != tt.expectTimeout || ok != tt.expectOK {
+			t.Errorf("Expected %s, %v for group %q, got %s, %v", tt.expectTimeout, tt.expectOK, tt.group, timeout, ok)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultExecutionHistorySize is the number of executions kept per runner
+const DefaultExecutionHistorySize = 20
+
+// ExecutionRecord describes a single command execution in a runner
+type ExecutionRecord struct {
+	Command    string
+	ExitCode   int32
+	Error      string
+	Output     string
+	Bootstrap  bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// ExecutionHistory keeps the most recent executions per runner in memory
+type ExecutionHistory struct {
+	mu         sync.RWMutex
+	maxRecords int
+	records    map[string][]ExecutionRecord
+}
+
+// NewExecutionHistory creates a new execution history keeping maxRecords entries per runner
+func NewExecutionHistory(maxRecords int) *ExecutionHistory {
+	if maxRecords <= 0 {
+		maxRecords = DefaultExecutionHistorySize
+	}
+	return &ExecutionHistory{
+		maxRecords: maxRecords,
+		records:    make(map[string][]ExecutionRecord),
+	}
+}
+
+// Record appends an execution record for a runner, evicting the oldest entry when full
+func (h *ExecutionHistory) Record(runnerID string, record ExecutionRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := append(h.records[runnerID], record)
+	if len(records) > h.maxRecords {
+		records = records[len(records)-h.maxRecords:]
+	}
+	h.records[runnerID] = records
+}
+
+// Get returns the execution records for a runner, oldest first
+func (h *ExecutionHistory) Get(runnerID string) []ExecutionRecord {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	records := make([]ExecutionRecord, len(h.records[runnerID]))
+	copy(records, h.records[runnerID])
+	return records
+}
+
+// RemoveRunner drops all execution records for a runner
+func (h *ExecutionHistory) RemoveRunner(runnerID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.records, runnerID)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExecutionHistory(t *testing.T) {
+	history := NewExecutionHistory(3)
+
+	for i := 0; i < 5; i++ {
+		history.Record("runner-1", ExecutionRecord{Command: fmt.Sprintf("cmd-%d", i)})
+	}
+
+	records := history.Get("runner-1")
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records after eviction, got %d", len(records))
+	}
+
+	// Oldest records are evicted first
+	if records[0].Command != "cmd-2" || records[2].Command != "cmd-4" {
+		t.Errorf("Expected records cmd-2..cmd-4, got %v", records)
+	}
+
+	if len(history.Get("runner-2")) != 0 {
+		t.Error("Expected no records for unknown runner")
+	}
+
+	history.RemoveRunner("runner-1")
+	if len(history.Get("runner-1")) != 0 {
+		t.Error("Expected no records after removal")
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// imageDigestPattern matches a content digest such as sha256:<hex>
+var imageDigestPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+
+// imageDigest extracts the digest from a container status imageID. Runtimes report it as
+// "docker-pullable://repo@sha256:...", "repo@sha256:..." or a bare "sha256:...";
+// anything else yields "". (pure function)
+func imageDigest(imageID string) string {
+	if _, rest, ok := strings.Cut(imageID, "://"); ok {
+		imageID = rest
+	}
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		imageID = imageID[i+1:]
+	}
+	if !imageDigestPattern.MatchString(imageID) {
+		return ""
+	}
+	return imageID
+}
+
+// runnerImageDigest returns the digest the runner container's image resolved to, "" until
+// the kubelet reports it (pure function)
+func runnerImageDigest(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == RunnerContainerName {
+			return imageDigest(status.ImageID)
+		}
+	}
+	return ""
+}
+
+// MutableImageTagWarning explains why image may resolve to different code over time, or
+// returns "" if it is pinned (pure function)
+func MutableImageTagWarning(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+
+	// A colon after the last slash separates the tag; one before it belongs to a registry port
+	tag := ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		tag = image[i+1:]
+	}
+	switch tag {
+	case "":
+		return fmt.Sprintf("image %q has no tag and resolves to latest; runners may run different code, pin a tag or digest", image)
+	case "latest":
+		return fmt.Sprintf("image %q uses the latest tag; runners may run different code, pin a tag or digest", image)
+	}
+	return ""
+}
+
+// runnerMatchesImageDigest reports whether the runner's image digest starts with digest,
+// so short digests work. An empty digest matches every runner (pure function)
+func runnerMatchesImageDigest(runner *Runner, digest string) bool {
+	if digest == "" {
+		return true
+	}
+	return runner.ImageDigest != "" && strings.HasPrefix(runner.ImageDigest, digest)
+}
+
+// imageReference is an image reference split into its normalized repository, tag and digest
+type imageReference struct {
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseImageReference splits image into its parts, spelling out what Docker Hub references
+// leave implicit: "nginx" is "docker.io/library/nginx:latest" (pure function)
+func parseImageReference(image string) imageReference {
+	var ref imageReference
+	if name, digest, ok := strings.Cut(image, "@"); ok {
+		image, ref.digest = name, digest
+	}
+	// A colon after the last slash separates the tag; one before it belongs to a registry port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, ref.tag = image[:i], image[i+1:]
+	}
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = "latest"
+	}
+
+	registry, _, hasRegistry := strings.Cut(image, "/")
+	switch {
+	case !hasRegistry:
+		image = "docker.io/library/" + image
+	case !strings.ContainsAny(registry, ".:") && registry != "localhost":
+		image = "docker.io/" + image
+	}
+	ref.repository = image
+	return ref
+}
+
+// imageIsStale reports whether a runner created from image, which resolved to digest ("" if
+// not known yet), runs something other than the configured image. A configured digest is
+// compared with the runner's; a configured tag with the tag the runner was created from, so
+// a mutable tag that moved on isn't noticed. Unknown images are never stale. (pure function)
+func imageIsStale(image, digest, configured string) bool {
+	if image == "" || configured == "" {
+		return false
+	}
+	runner, current := parseImageReference(image), parseImageReference(configured)
+	if runner.repository != current.repository {
+		return true
+	}
+
+	if current.digest == "" {
+		return runner.tag != current.tag
+	}
+	if digest == "" {
+		digest = runner.digest
+	}
+	if digest == "" {
+		// Until the kubelet reports the digest, the same tag is the best guess
+		return runner.tag != current.tag
+	}
+	return digest != current.digest
+}
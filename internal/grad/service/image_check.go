@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// imageCheckTimeout bounds resolving the configured images
+const imageCheckTimeout = 30 * time.Second
+
+// ImageCheck resolves the configured runner images in their registries, so a typo in an
+// image shows up in readiness right away instead of as ImagePullBackOff on the first runner
+type ImageCheck struct {
+	registry *RegistryClient
+
+	mu sync.Mutex
+	// generation counts Start calls; only the result of the latest one is kept
+	generation int
+	problem    string
+}
+
+// NewImageCheck creates an image check resolving images with registry
+func NewImageCheck(registry *RegistryClient) *ImageCheck {
+	return &ImageCheck{registry: registry}
+}
+
+// Start resolves images in the background. Its result replaces that of earlier checks,
+// unless Start is called again before it finishes.
+func (c *ImageCheck) Start(images RunnerImages) {
+	c.mu.Lock()
+	c.generation++
+	generation := c.generation
+	c.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), imageCheckTimeout)
+		defer cancel()
+		problem := c.resolve(ctx, images)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if generation == c.generation {
+			c.problem = problem
+		}
+	}()
+}
+
+// Problem explains why a configured image can't be resolved, "" if every image was
+// resolved, the check hasn't finished yet or c is nil because the check is skipped
+func (c *ImageCheck) Problem() string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.problem
+}
+
+// resolve resolves each of images, logging the digest it found, and explains the ones that
+// can't be resolved; "" if all were. Empty images aren't needed and are skipped.
+func (c *ImageCheck) resolve(ctx context.Context, images RunnerImages) string {
+	var problems []string
+	for _, image := range []struct{ name, ref string }{
+		{"runner image", images.Runner},
+		{"s3fs image", images.S3FS},
+	} {
+		if image.ref == "" {
+			continue
+		}
+		digest, err := c.registry.Resolve(ctx, image.ref)
+		if err != nil {
+			slog.Warn("Failed to resolve image", "image", image.ref, "error", err)
+			problems = append(problems, fmt.Sprintf("%s %q can't be pulled: %v", image.name, image.ref, err))
+			continue
+		}
+		slog.Info("Resolved image", "image", image.ref, "digest", digest)
+	}
+	return strings.Join(problems, "; ")
+}
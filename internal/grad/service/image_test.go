@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	testDigestOld = "sha256:3f1c0a9e8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d"
+	testDigestNew = "sha256:9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b"
+)
+
+func TestImageDigest(t *testing.T) {
+	tests := []struct {
+		imageID string
+		expect  string
+	}{
+		{"docker-pullable://ghcr.io/strrl/grad-runner@" + testDigestOld, testDigestOld},
+		{"ghcr.io/strrl/grad-runner@" + testDigestOld, testDigestOld},
+		{"localhost:5000/grad-runner@" + testDigestOld, testDigestOld},
+		{testDigestOld, testDigestOld},
+		{"docker://" + testDigestOld, testDigestOld},
+		{"", ""},
+		{"ghcr.io/strrl/grad-runner:latest", ""},
+		{"sha256:not-hex", ""},
+	}
+
+	for _, tt := range tests {
+		if got := imageDigest(tt.imageID); got != tt.expect {
+			t.Errorf("Expected %q for %q, got %q", tt.expect, tt.imageID, got)
+		}
+	}
+}
+
+func TestPodToRunnerImage(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	config.RunnerImage = "ghcr.io/strrl/grad-runner:v1.2.0"
+	runner := &Runner{ID: "runner-1", Workspace: &WorkspaceConfig{Bucket: "data"}}
+	pod := buildPodCreationRequest(t, runner, config).ToPodSpec()
+
+	// Before the kubelet reports the containers there is no digest yet
+	got := PodToRunner(pod)
+	if got.Image != config.RunnerImage || got.ImageDigest != "" {
+		t.Errorf("Expected image %s without digest, got %q, %q", config.RunnerImage, got.Image, got.ImageDigest)
+	}
+
+	// The sidecar's digest must not be mistaken for the runner's
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: S3FSContainerName, Image: config.S3FSImage, ImageID: "ghcr.io/strrl/grad-runner-s3fs@" + testDigestNew},
+		{Name: RunnerContainerName, Image: config.RunnerImage, ImageID: "docker-pullable://ghcr.io/strrl/grad-runner@" + testDigestOld},
+	}
+	if got := PodToRunner(pod); got.ImageDigest != testDigestOld {
+		t.Errorf("Expected digest %s, got %q", testDigestOld, got.ImageDigest)
+	}
+	if got := PodToRunner(pod).ToProto(); got.Image != config.RunnerImage || got.ImageDigest != testDigestOld {
+		t.Errorf("Expected image and digest in the proto, got %q, %q", got.Image, got.ImageDigest)
+	}
+}
+
+func TestMutableImageTagWarning(t *testing.T) {
+	tests := []struct {
+		image  string
+		expect bool
+	}{
+		{"ghcr.io/strrl/grad-runner:latest", true},
+		{"ghcr.io/strrl/grad-runner", true},
+		{"localhost:5000/grad-runner", true},
+		{"localhost:5000/grad-runner:v1", false},
+		{"ghcr.io/strrl/grad-runner:v1.2.0", false},
+		{"ghcr.io/strrl/grad-runner:latest@" + testDigestOld, false},
+	}
+
+	for _, tt := range tests {
+		if got := MutableImageTagWarning(tt.image); (got != "") != tt.expect {
+			t.Errorf("Expected warning %v for %q, got %q", tt.expect, tt.image, got)
+		}
+	}
+}
+
+func TestListRunnersByImageDigest(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	digests := map[string]string{}
+	for _, digest := range []string{testDigestOld, testDigestNew, ""} {
+		runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+		if err != nil {
+			t.Fatalf("CreateRunner failed: %v", err)
+		}
+		digests[runner.ID] = digest
+		if digest == "" {
+			continue
+		}
+
+		pod, err := svc.k8sClient.GetRunnerPod(ctx, runner.ID)
+		if err != nil {
+			t.Fatalf("GetRunnerPod failed: %v", err)
+		}
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: RunnerContainerName, ImageID: "ghcr.io/strrl/grad-runner@" + digest}}
+		if _, err := clientset.CoreV1().Pods(pod.Namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("Failed to update pod: %v", err)
+		}
+	}
+
+	for _, filter := range []string{testDigestOld, testDigestOld[:12]} {
+		runners, total, err := svc.ListRunners(ctx, &ListOptions{ImageDigest: filter})
+		if err != nil {
+			t.Fatalf("ListRunners failed: %v", err)
+		}
+		if total != 1 || digests[runners[0].ID] != testDigestOld {
+			t.Errorf("Expected only the runner on %s for filter %q, got %d runners", testDigestOld, filter, total)
+		}
+	}
+}
+
+func TestImageIsStale(t *testing.T) {
+	const runnerImage = "ghcr.io/strrl/grad-runner"
+	tests := []struct {
+		name       string
+		image      string
+		digest     string
+		configured string
+		expect     bool
+	}{
+		{"same tag", runnerImage + ":v1.2.0", testDigestOld, runnerImage + ":v1.2.0", false},
+		{"other tag", runnerImage + ":v1.1.0", testDigestOld, runnerImage + ":v1.2.0", true},
+		{"other repository", "ghcr.io/example/runner:v1.2.0", "", runnerImage + ":v1.2.0", true},
+		{"implicit latest", runnerImage, "", runnerImage + ":latest", false},
+		{"implicit docker hub", "ubuntu:24.04", "", "docker.io/library/ubuntu:24.04", false},
+		{"registry port", "localhost:5000/runner:v1", "", "localhost:5000/runner:v2", true},
+		{"tag resolved to configured digest", runnerImage + ":v1.2.0", testDigestOld, runnerImage + "@" + testDigestOld, false},
+		{"tag resolved to other digest", runnerImage + ":v1.2.0", testDigestNew, runnerImage + "@" + testDigestOld, true},
+		{"digest not reported yet", runnerImage + ":v1.2.0", "", runnerImage + ":v1.2.0@" + testDigestOld, false},
+		{"pinned to configured digest", runnerImage + "@" + testDigestOld, "", runnerImage + "@" + testDigestOld, false},
+		{"pinned to other digest", runnerImage + "@" + testDigestNew, "", runnerImage + "@" + testDigestOld, true},
+		{"pinned, now a tag", runnerImage + "@" + testDigestOld, testDigestOld, runnerImage + ":v1.2.0", true},
+		{"unknown image", "", "", runnerImage + ":v1.2.0", false},
+	}
+
+	for _, tt := range tests {
+		if got := imageIsStale(tt.image, tt.digest, tt.configured); got != tt.expect {
+			t.Errorf("%s: expected stale %v for %q (%q) against %q, got %v", tt.name, tt.expect, tt.image, tt.digest, tt.configured, got)
+		}
+	}
+}
+
+func TestListRunnersStaleOnly(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.k8sClient.Config().RunnerImage = "ghcr.io/strrl/grad-runner:v1.1.0"
+
+	old, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if _, err := svc.k8sClient.Config().SetImages(RunnerImages{Runner: "ghcr.io/strrl/grad-runner:v1.2.0"}); err != nil {
+		t.Fatalf("SetImages failed: %v", err)
+	}
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{}); err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	defer svc.Stop()
+
+	runners, total, err := svc.ListRunners(ctx, &ListOptions{StaleOnly: true})
+	if err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+	if total != 1 || runners[0].ID != old.ID || !runners[0].StaleImage {
+		t.Errorf("Expected only %s to have a stale image, got %d runners", old.ID, total)
+	}
+	if got, err := svc.GetRunner(ctx, old.ID); err != nil || !got.ToProto().StaleImage {
+		t.Errorf("Expected GetRunner to report the stale image, got %v", err)
+	}
+
+	stale, err := svc.staleImageRunners(ctx)
+	if err != nil || len(stale) != 1 || stale[0] != old.ID {
+		t.Errorf("Expected the check to find %s, got %v, %v", old.ID, stale, err)
+	}
+}
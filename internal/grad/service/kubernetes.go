@@ -3,15 +3,24 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/tools/remotecommand"
 )
 
@@ -37,6 +46,42 @@ const (
 	RunnerNameAnnotation    = RunnerAnnotationPrefix + "runner-name"
 	RunnerStatusAnnotation  = RunnerAnnotationPrefix + "status"
 	RunnerCreatedAnnotation = RunnerAnnotationPrefix + "created-at"
+
+	// Bootstrap annotations track the readiness hook state machine
+	RunnerBootstrapCommandAnnotation = RunnerAnnotationPrefix + "bootstrap-command"
+	RunnerBootstrapStatusAnnotation  = RunnerAnnotationPrefix + "bootstrap-status"
+	RunnerStatusDetailAnnotation     = RunnerAnnotationPrefix + "status-detail"
+	// RunnerBootstrapFinishedAnnotation records when the bootstrap command finished, in RFC 3339
+	RunnerBootstrapFinishedAnnotation = RunnerAnnotationPrefix + "bootstrap-finished-at"
+
+	// RunnerProvisioningDurationAnnotation records how long the runner took to become ready
+	RunnerProvisioningDurationAnnotation = RunnerAnnotationPrefix + "provisioning-duration-ms"
+	// RunnerNetworkPolicyAnnotation names the NetworkPolicy attached to the runner
+	RunnerNetworkPolicyAnnotation = RunnerAnnotationPrefix + "network-policy"
+
+	// Defaults for commands that don't set their own shell or working directory
+	RunnerDefaultShellAnnotation   = RunnerAnnotationPrefix + "default-shell"
+	RunnerDefaultWorkdirAnnotation = RunnerAnnotationPrefix + "default-workdir"
+
+	// RunnerLastActiveAnnotation records when a command last ran in the runner, so the activity
+	// tracker can be restored after grad restarts
+	RunnerLastActiveAnnotation = RunnerAnnotationPrefix + "last-active"
+
+	// RunnerDescriptionAnnotation holds the runner's description, escaped to a single line
+	RunnerDescriptionAnnotation = RunnerAnnotationPrefix + "description"
+
+	// RunnerDeleteStatusAnnotation is set to DeleteStatusFailed on a runner pod whose other
+	// objects could not all be deleted; the pod is kept until a retried delete removes them
+	RunnerDeleteStatusAnnotation = RunnerAnnotationPrefix + "delete-status"
+	DeleteStatusFailed           = "failed"
+)
+
+// Bootstrap states stored in the bootstrap-status annotation
+const (
+	BootstrapStatusPending   = "pending"
+	BootstrapStatusRunning   = "running"
+	BootstrapStatusSucceeded = "succeeded"
+	BootstrapStatusFailed    = "failed"
 )
 
 // RunnerSpec holds resource specifications for a runner preset
@@ -96,19 +141,65 @@ func GetCurrentRunnerSpec() RunnerSpec {
 // GetEffectiveRunnerImage returns the runner image that will be used
 // Takes into account environment variable overrides for skaffold dynamic tags
 func GetEffectiveRunnerImage() string {
-	config := loadKubernetesConfig()
-	return config.RunnerImage
+	loader := &configLoader{lookup: os.Getenv}
+	return loader.kubernetesConfig().RunnerImage
 }
 
 // KubernetesConfig holds configuration for Kubernetes operations
 type KubernetesConfig struct {
 	Namespace      string
+	// RunnerImage and S3FSImage can be changed at runtime with SetImages; read them with Images
+	// once grad is serving
 	RunnerImage    string
 	S3FSImage      string
 	DefaultCPU     string
 	DefaultMemory  string
 	DefaultStorage string
 	SSHPort        int32
+	// DefaultShell and DefaultWorkdir apply to commands when neither they nor their runner set one
+	DefaultShell   string
+	DefaultWorkdir string
+	// Proxy configures outbound proxy and CA trust for runners (optional)
+	Proxy *ProxyConfig
+	// Namespaces configures creation and cleanup of the runner namespace (optional)
+	Namespaces *NamespaceConfig
+	// NetworkPolicy restricts runner pod traffic (optional)
+	NetworkPolicy *NetworkPolicyConfig
+	// ServiceAccount sets the identity of runner pods (optional)
+	ServiceAccount *ServiceAccountConfig
+	// PriorityClassName is the PriorityClass of runners users create and AutoPriorityClassName
+	// of those the ExecuteService creates for commands; empty uses the cluster default
+	PriorityClassName     string
+	AutoPriorityClassName string
+	// AllowedPriorityClasses lists the PriorityClasses a request may pick for its runner. It
+	// and ServiceAccount.Allowed can be changed at runtime with SetAllowlists; read them with
+	// Allowlists once grad is serving
+	AllowedPriorityClasses []string
+	// SupportedArchs lists the CPU architectures a request may pin its runner to
+	SupportedArchs []string
+	// Spot places spot runners; without tolerations or a nodeSelector they can't be created
+	Spot *SpotConfig
+	// Sidecar sets the s3fs sidecar resources (DefaultSidecarConfig if nil)
+	Sidecar *SidecarConfig
+	// DNSPolicy, DNSConfig and HostAliases set name resolution in runner pods (optional)
+	DNSPolicy   string
+	DNSConfig   *DNSConfig
+	HostAliases []HostAlias
+	// StableDNS gives runners DNS names through a headless Service (optional)
+	StableDNS *StableDNSConfig
+	// Restart controls recreating failed runner pods (DefaultRestartConfig if nil)
+	Restart *RestartConfig
+	// Capacity controls checking for node capacity before creating runners (DefaultCapacityConfig if nil)
+	Capacity *CapacityConfig
+	// DisabledFeatures turns off integrations that reach outside the cluster (optional)
+	DisabledFeatures DisabledFeatures
+
+	// imagesMu guards RunnerImage, S3FSImage, previousImages and imagesChanged
+	imagesMu       sync.RWMutex
+	previousImages *RunnerImages
+	imagesChanged  []func(RunnerImages)
+	// allowlistsMu guards AllowedPriorityClasses and ServiceAccount.Allowed
+	allowlistsMu sync.RWMutex
 }
 
 // DefaultKubernetesConfig returns default configuration with hardcoded "small" preset
@@ -124,14 +215,53 @@ func DefaultKubernetesConfig() *KubernetesConfig {
 		DefaultMemory:  RunnerSpecPreset.Small.Memory,
 		DefaultStorage: RunnerSpecPreset.Small.Storage,
 		SSHPort:        22,
+		DefaultShell:   DefaultExecShell,
+		Sidecar:        DefaultSidecarConfig(),
+		Restart:        DefaultRestartConfig(),
+		Capacity:       DefaultCapacityConfig(),
+		SupportedArchs: slices.Clone(DefaultSupportedArchs),
+	}
+}
+
+// sidecar returns the s3fs sidecar config, falling back to the defaults
+func (c *KubernetesConfig) sidecar() *SidecarConfig {
+	if c.Sidecar == nil {
+		return DefaultSidecarConfig()
+	}
+	return c.Sidecar
+}
+
+// restart returns the restart config, falling back to the defaults
+func (c *KubernetesConfig) restart() *RestartConfig {
+	if c.Restart == nil {
+		return DefaultRestartConfig()
+	}
+	return c.Restart
+}
+
+// capacity returns the capacity check config, falling back to the defaults
+func (c *KubernetesConfig) capacity() *CapacityConfig {
+	if c.Capacity == nil {
+		return DefaultCapacityConfig()
 	}
+	return c.Capacity
 }
 
 // KubernetesClient wraps the Kubernetes client with runner-specific operations
 type KubernetesClient struct {
-	clientset  *kubernetes.Clientset
+	clientset  kubernetes.Interface
 	restConfig *rest.Config
 	config     *KubernetesConfig
+	// metrics reads pod usage from metrics.k8s.io; nil unless DetectMetrics found the API
+	metrics dynamic.Interface
+	// recorder emits Events on runner pods; nil unless EnableEvents found grad may create them
+	recorder record.EventRecorder
+	// broadcaster sends the recorder's events to the API server until StopEvents
+	broadcaster record.EventBroadcaster
+	// apiTimeout bounds each call to the API server; zero leaves calls bounded only by their context
+	apiTimeout time.Duration
+	// annotations writes runner pod annotations, without a rate limit unless NewKubernetesClient set one
+	annotations annotationWriter
 }
 
 // NewKubernetesClient creates a new Kubernetes client for runner management
@@ -149,6 +279,12 @@ func NewKubernetesClient(config *KubernetesConfig) (*KubernetesClient, error) {
 		}
 	}
 
+	return NewKubernetesClientForConfig(kubeConfig, config)
+}
+
+// NewKubernetesClientForConfig creates a Kubernetes client for runner management talking to
+// the API server of kubeConfig, such as a test cluster
+func NewKubernetesClientForConfig(kubeConfig *rest.Config, config *KubernetesConfig) (*KubernetesClient, error) {
 	clientset, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create clientset: %w", err)
@@ -159,27 +295,69 @@ func NewKubernetesClient(config *KubernetesConfig) (*KubernetesClient, error) {
 	}
 
 	return &KubernetesClient{
-		clientset:  clientset,
-		restConfig: kubeConfig,
-		config:     config,
+		clientset:   clientset,
+		restConfig:  kubeConfig,
+		config:      config,
+		apiTimeout:  DefaultDurations().APITimeout,
+		annotations: annotationWriter{interval: annotationWriteInterval},
 	}, nil
 }
 
+// SetAPITimeout changes how long each call to the API server may take
+func (k *KubernetesClient) SetAPITimeout(timeout time.Duration) {
+	k.apiTimeout = timeout
+}
+
+// apiContext derives the context of one API call from ctx, so neither a cancelled request nor a
+// slow API server can keep the call running
+func (k *KubernetesClient) apiContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if k.apiTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, k.apiTimeout)
+}
+
 // CreateRunnerPod creates a new pod for a runner
 func (k *KubernetesClient) CreateRunnerPod(ctx context.Context, runner *Runner) error {
-	req := BuildPodCreationRequest(runner, k.config)
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	req, err := BuildPodCreationRequest(runner, k.config)
+	if err != nil {
+		return err
+	}
 	pod := req.ToPodSpec()
 
-	_, err := k.clientset.CoreV1().Pods(k.config.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	// Create the network policy first so the pod is never reachable without it
+	if err := k.createRunnerNetworkPolicy(ctx, runner); err != nil {
+		return err
+	}
+
+	// The pod's DNS name only resolves while the headless Service exists
+	if err := k.ensureRunnerService(ctx); err != nil {
+		slog.Warn("Failed to ensure headless runner service", "runner_id", runner.ID, "error", err)
+	}
+
+	created, err := k.clientset.CoreV1().Pods(k.config.Namespace).Create(ctx, pod, metav1.CreateOptions{})
 	if err != nil {
+		if policyErr := k.deleteRunnerNetworkPolicy(ctx, runner.ID); policyErr != nil {
+			slog.Warn("Failed to delete network policy of failed runner", "runner_id", runner.ID, "error", policyErr)
+		}
 		return fmt.Errorf("failed to create runner pod: %w", err)
 	}
 
+	if err := k.adoptRunnerNetworkPolicy(ctx, created); err != nil {
+		slog.Warn("Failed to set runner pod as network policy owner", "runner_id", runner.ID, "error", err)
+	}
+
 	return nil
 }
 
 // DeleteRunnerPod deletes a runner pod
 func (k *KubernetesClient) DeleteRunnerPod(ctx context.Context, runnerID string) error {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
 	req := BuildPodDeletionRequest(runnerID, k.config)
 
 	// Use grace period 0 and force to ensure immediate deletion
@@ -199,6 +377,9 @@ func (k *KubernetesClient) DeleteRunnerPod(ctx context.Context, runnerID string)
 
 // GetRunnerPod gets a specific runner pod by ID
 func (k *KubernetesClient) GetRunnerPod(ctx context.Context, runnerID string) (*corev1.Pod, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
 	podName := k.getPodName(runnerID)
 
 	pod, err := k.clientset.CoreV1().Pods(k.config.Namespace).Get(ctx, podName, metav1.GetOptions{})
@@ -209,8 +390,24 @@ func (k *KubernetesClient) GetRunnerPod(ctx context.Context, runnerID string) (*
 	return pod, nil
 }
 
+// GetConfigMap gets a ConfigMap from the runner namespace
+func (k *KubernetesClient) GetConfigMap(ctx context.Context, name string) (*corev1.ConfigMap, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	configMap, err := k.clientset.CoreV1().ConfigMaps(k.config.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configmap: %w", err)
+	}
+
+	return configMap, nil
+}
+
 // ListRunnerPods lists all runner pods using label selectors with optional status filtering
 func (k *KubernetesClient) ListRunnerPods(ctx context.Context) (*corev1.PodList, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
 	labelSelector := RunnerLabelSelector + "," + RunnerComponentLabel
 
 	listOptions := metav1.ListOptions{
@@ -235,12 +432,27 @@ func (k *KubernetesClient) getPodName(runnerID string) string {
 	return fmt.Sprintf("grad-runner-%s", runnerID)
 }
 
-// ExecuteCommandStream executes a command in a runner pod with streaming output
-func (k *KubernetesClient) ExecuteCommandStream(ctx context.Context, runnerID, command string, stdoutCh, stderrCh chan<- []byte) (int32, error) {
+// execOutput sets how ExecuteCommandStream sends command output, and what it gives as input
+type execOutput struct {
+	// flushInterval is how long output is held back at most (see channelWriter)
+	flushInterval time.Duration
+	// sent, if set, is called with the size of each chunk sent, by stream
+	sent func(stream string, n int)
+	// stdin, if set, is the command's stdin; it keeps secrets out of the command line
+	stdin io.Reader
+}
+
+// ExecuteCommandStream executes a command in a runner pod with streaming output.
+// An error means the command couldn't be run; its own failures are reported in the ExitStatus.
+func (k *KubernetesClient) ExecuteCommandStream(ctx context.Context, runnerID string, command []string, output execOutput, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
 	slog.Info("ExecuteCommandStream called",
 		"runnerID", runnerID,
 		"command", command)
 
+	// Close channels when done, including early failures, so consumers never block
+	defer close(stdoutCh)
+	defer close(stderrCh)
+
 	// Get pod name for the runner
 	podName := k.getPodName(runnerID)
 	
@@ -257,9 +469,9 @@ func (k *KubernetesClient) ExecuteCommandStream(ctx context.Context, runnerID, c
 
 	// Configure exec parameters
 	req.VersionedParams(&corev1.PodExecOptions{
-		Container: "runner", // Always execute in the main runner container
-		Command:   []string{"bash", "-c", command},
-		Stdin:     false,
+		Container: RunnerContainerName, // Always execute in the main runner container
+		Command:   command,
+		Stdin:     output.stdin != nil,
 		Stdout:    true,
 		Stderr:    true,
 		TTY:       false,
@@ -271,56 +483,63 @@ func (k *KubernetesClient) ExecuteCommandStream(ctx context.Context, runnerID, c
 	exec, err := remotecommand.NewSPDYExecutor(k.restConfig, "POST", req.URL())
 	if err != nil {
 		slog.Error("Failed to create executor", "error", err)
-		return 1, fmt.Errorf("failed to create executor: %w", err)
+		return ExitStatus{}, fmt.Errorf("failed to create executor: %w", err)
 	}
 
 	// Create custom streams that write to our channels
-	stdoutStream := &channelWriter{ch: stdoutCh, name: "stdout"}
-	stderrStream := &channelWriter{ch: stderrCh, name: "stderr"}
+	// Closed before the channels, sending what's still held back
+	stdoutStream := newChannelWriter(stdoutCh, "stdout", output.flushInterval)
+	stderrStream := newChannelWriter(stderrCh, "stderr", output.flushInterval)
+	stdoutStream.sent, stderrStream.sent = output.sent, output.sent
+	defer stdoutStream.Close()
+	defer stderrStream.Close()
 
 	slog.Info("Starting command execution in pod")
 
 	// Execute the command
+	startedAt := time.Now()
 	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  output.stdin,
 		Stdout: stdoutStream,
 		Stderr: stderrStream,
 	})
 
-	// Close channels when done
-	close(stdoutCh)
-	close(stderrCh)
-
+	status, err := exitStatusFromError(ctx, err)
 	if err != nil {
 		slog.Error("Command execution failed", "error", err)
-		// For now, return exit code 1 for any error
-		// TODO: Add proper exit code extraction when client-go API is clarified
-		return 1, fmt.Errorf("command execution failed: %w", err)
+		return ExitStatus{}, fmt.Errorf("command execution failed: %w", err)
 	}
 
-	slog.Info("Command completed successfully")
-	return 0, nil
-}
+	// The kernel OOM killer uses SIGKILL; check whether the container hit its memory limit
+	if status.Reason == ExitReasonSignaled && status.Signal == "SIGKILL" {
+		if pod, err := k.GetRunnerPod(ctx, runnerID); err == nil {
+			status.LikelyOOM = oomKilledSince(pod, startedAt)
+		}
+	}
 
-// channelWriter implements io.Writer and writes to a channel
-type channelWriter struct {
-	ch   chan<- []byte
-	name string
+	slog.Info("Command completed", "exit_code", status.Code, "reason", status.Reason)
+	return status, nil
 }
 
-func (cw *channelWriter) Write(p []byte) (n int, err error) {
-	if len(p) > 0 {
-		// Copy the data since it might be reused
-		dataCopy := make([]byte, len(p))
-		copy(dataCopy, p)
-		
-		select {
-		case cw.ch <- dataCopy:
-			slog.Debug("Sent data to channel", "stream", cw.name, "bytes", len(dataCopy))
-		default:
-			slog.Warn("Channel full, dropping data", "stream", cw.name, "bytes", len(dataCopy))
-		}
+// podRunnerStatus returns the status of the runner in pod (pure function)
+func podRunnerStatus(pod *corev1.Pod) RunnerStatus {
+	// Always derive status from actual pod state (pod phase and conditions)
+	// This ensures we get the real-time status rather than stale annotations
+	status := MapPodStatusToRunnerStatus(pod)
+
+	// A pod that timed out while being created stays in error even though it's still pending
+	status = applyErrorStatus(status, pod.Annotations[RunnerErrorReasonAnnotation])
+
+	// A pod kept around because its runner's objects couldn't be deleted waits for a retry
+	if pod.Annotations[RunnerDeleteStatusAnnotation] == DeleteStatusFailed {
+		return RunnerStatusDeleteFailed
 	}
-	return len(p), nil
+
+	// A failed pod of an auto-restart runner is recreated rather than left in error
+	status = applyRestartStatus(status, pod.Annotations[RunnerRestartStatusAnnotation])
+
+	// A ready pod is only usable once its bootstrap command has succeeded
+	return applyBootstrapStatus(status, pod.Annotations[RunnerBootstrapStatusAnnotation])
 }
 
 // PodToRunner converts a Kubernetes pod to a domain Runner object
@@ -330,9 +549,32 @@ func PodToRunner(pod *corev1.Pod) *Runner {
 		Name: pod.Annotations[RunnerNameAnnotation],
 	}
 
-	// Always derive status from actual pod state (pod phase and conditions)
-	// This ensures we get the real-time status rather than stale annotations
-	runner.Status = MapPodStatusToRunnerStatus(pod)
+	runner.Status = podRunnerStatus(pod)
+	runner.Conditions = RunnerConditions(pod)
+	runner.ErrorReason, runner.ErrorMessage = runnerError(pod, runner.Status)
+	runner.BootstrapCommand = pod.Annotations[RunnerBootstrapCommandAnnotation]
+	runner.StatusDetail = pod.Annotations[RunnerStatusDetailAnnotation]
+	runner.NetworkPolicy = pod.Annotations[RunnerNetworkPolicyAnnotation]
+	runner.DefaultShell = pod.Annotations[RunnerDefaultShellAnnotation]
+	runner.DefaultWorkdir = pod.Annotations[RunnerDefaultWorkdirAnnotation]
+	runner.Description = decodeAnnotationText(pod.Annotations[RunnerDescriptionAnnotation])
+	runner.ReservedUntil = decodeReservation(pod.Annotations[RunnerReservedUntilAnnotation])
+	runner.Protected = pod.Annotations[RunnerProtectedAnnotation] == "true"
+	runner.Group = pod.Labels[RunnerGroupLabel]
+	runner.HostAliases = fromPodHostAliases(pod.Spec.HostAliases, nil)
+	runner.AutoRestart = pod.Labels[RunnerAutoRestartLabel] == "true"
+	runner.RestartCount = podRestartCount(pod)
+	runner.SSHSessions, runner.LastSSHLogin = podSSHSessions(pod)
+	runner.PriorityClassName = pod.Spec.PriorityClassName
+	runner.Arch = podArch(pod)
+	runner.Spot = podSpot(pod)
+	runner.WorkspaceMode = podWorkspaceMode(pod)
+
+	if durationStr, ok := pod.Annotations[RunnerProvisioningDurationAnnotation]; ok {
+		if ms, err := strconv.ParseInt(durationStr, 10, 64); err == nil {
+			runner.ProvisioningDuration = time.Duration(ms) * time.Millisecond
+		}
+	}
 
 	// Parse timestamps
 	if createdStr, ok := pod.Annotations[RunnerCreatedAnnotation]; ok {
@@ -350,11 +592,15 @@ func PodToRunner(pod *corev1.Pod) *Runner {
 
 	// Get IP address
 	runner.IPAddress = pod.Status.PodIP
+	runner.SSH = podSSHDetails(pod)
+
+	// Extract resource requirements and env from the runner container; pods with an S3
+	// workspace also run the s3fs sidecar, so it's looked up by name
+	runner.Env = make(map[string]string)
+	runner.ImageDigest = runnerImageDigest(pod)
+	if runnerContainer := findContainer(pod, RunnerContainerName); runnerContainer != nil {
+		runner.Image = runnerContainer.Image
 
-	// Extract resource requirements from the runner container (second container)
-	// The pod has two containers: [0] s3fs-sidecar, [1] runner
-	if len(pod.Spec.Containers) > 1 {
-		runnerContainer := pod.Spec.Containers[1] // Get the runner container, not the s3fs sidecar
 		if requests := runnerContainer.Resources.Requests; requests != nil {
 			runner.Resources = &ResourceRequirements{}
 
@@ -368,12 +614,8 @@ func PodToRunner(pod *corev1.Pod) *Runner {
 				runner.Resources.StorageGB = int32(storage.Value() / (1024 * 1024 * 1024))
 			}
 		}
-	}
 
-	// Extract environment variables from the runner container (second container)
-	runner.Env = make(map[string]string)
-	if len(pod.Spec.Containers) > 1 {
-		for _, envVar := range pod.Spec.Containers[1].Env {
+		for _, envVar := range runnerContainer.Env {
 			// Skip internal runner environment variables
 			if envVar.Name != "RUNNER_ID" && envVar.Name != "RUNNER_NAME" {
 				runner.Env[envVar.Name] = envVar.Value
@@ -384,52 +626,66 @@ func PodToRunner(pod *corev1.Pod) *Runner {
 	return runner
 }
 
+// ImagePullState reports whether the runner container's image was already cached on the node.
+// It looks at the kubelet's Pulled events for the pod and returns ImagePullUnknown if there are none.
+func (k *KubernetesClient) ImagePullState(ctx context.Context, runnerID string) string {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
 
-// AddRunnerFinalizer adds the runner finalizer to a pod
-func (k *KubernetesClient) AddRunnerFinalizer(ctx context.Context, podName string) error {
-	pod, err := k.clientset.CoreV1().Pods(k.config.Namespace).Get(ctx, podName, metav1.GetOptions{})
+	podName := k.getPodName(runnerID)
+
+	events, err := k.clientset.CoreV1().Events(k.config.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": "Pod", "involvedObject.name": podName}.String(),
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get pod for finalizer: %w", err)
+		slog.Debug("Failed to list pod events", "runner_id", runnerID, "error", err)
+		return ImagePullUnknown
 	}
 
-	// Check if finalizer already exists
-	for _, finalizer := range pod.Finalizers {
-		if finalizer == RunnerFinalizer {
-			return nil // Already has finalizer
+	for _, event := range events.Items {
+		// Not every API server, or fake, honors the field selector
+		if event.InvolvedObject.Name != podName || event.Reason != "Pulled" {
+			continue
+		}
+		if !strings.Contains(event.InvolvedObject.FieldPath, "{runner}") {
+			continue
+		}
+		if strings.Contains(event.Message, "already present on machine") {
+			return ImagePullWarm
 		}
+		return ImagePullCold
 	}
 
-	// Add finalizer
-	pod.Finalizers = append(pod.Finalizers, RunnerFinalizer)
+	return ImagePullUnknown
+}
 
-	_, err = k.clientset.CoreV1().Pods(k.config.Namespace).Update(ctx, pod, metav1.UpdateOptions{})
+// UpdateRunnerAnnotations merges the given annotations into a runner pod, batched with other
+// writes to it through the annotation writer. Values over their size limit are truncated, or
+// rejected if they aren't free text.
+func (k *KubernetesClient) UpdateRunnerAnnotations(ctx context.Context, runnerID string, annotations map[string]string) error {
+	podName := k.getPodName(runnerID)
+	err := k.annotations.write(ctx, podName, annotations, func(ctx context.Context, annotations map[string]string) error {
+		return k.patchPodAnnotations(ctx, podName, annotations)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to add finalizer: %w", err)
+		return fmt.Errorf("failed to update annotations: %w", err)
 	}
-
 	return nil
 }
 
-// RemoveRunnerFinalizer removes the runner finalizer from a pod
-func (k *KubernetesClient) RemoveRunnerFinalizer(ctx context.Context, podName string) error {
-	pod, err := k.clientset.CoreV1().Pods(k.config.Namespace).Get(ctx, podName, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get pod for finalizer removal: %w", err)
-	}
-
-	// Remove finalizer
-	finalizers := make([]string, 0)
-	for _, finalizer := range pod.Finalizers {
-		if finalizer != RunnerFinalizer {
-			finalizers = append(finalizers, finalizer)
-		}
+// AddRunnerFinalizer adds the runner finalizer to a pod
+func (k *KubernetesClient) AddRunnerFinalizer(ctx context.Context, podName string) error {
+	if err := k.updateFinalizers(ctx, podName, withFinalizer); err != nil {
+		return fmt.Errorf("failed to add finalizer: %w", err)
 	}
-	pod.Finalizers = finalizers
+	return nil
+}
 
-	_, err = k.clientset.CoreV1().Pods(k.config.Namespace).Update(ctx, pod, metav1.UpdateOptions{})
-	if err != nil {
+// RemoveRunnerFinalizer removes the runner finalizer from a pod, and forgets its annotation writes
+func (k *KubernetesClient) RemoveRunnerFinalizer(ctx context.Context, podName string) error {
+	if err := k.updateFinalizers(ctx, podName, withoutFinalizer); err != nil {
 		return fmt.Errorf("failed to remove finalizer: %w", err)
 	}
-
+	k.annotations.forget(podName)
 	return nil
 }
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// newStalledRunnerService returns a runner service whose API server never answers, the way an
+// overloaded one doesn't; requests only end when the client gives up on them
+func newStalledRunnerService(t *testing.T, apiTimeout time.Duration) *runnerService {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create clientset: %v", err)
+	}
+	k8sClient := &KubernetesClient{clientset: clientset, config: DefaultKubernetesConfig()}
+	k8sClient.SetAPITimeout(apiTimeout)
+
+	return NewRunnerService(k8sClient, NewActivityTracker(), DefaultDurations(), nil).(*runnerService)
+}
+
+// runnerServiceCalls calls each RunnerService method that reaches the API server
+var runnerServiceCalls = []struct {
+	name string
+	call func(ctx context.Context, s *runnerService) error
+}{
+	{"CreateRunner", func(ctx context.Context, s *runnerService) error {
+		_, err := s.CreateRunner(ctx, &CreateRunnerRequest{})
+		return err
+	}},
+	{"GetRunner", func(ctx context.Context, s *runnerService) error {
+		_, err := s.GetRunner(ctx, "runner-1")
+		return err
+	}},
+	{"ListRunners", func(ctx context.Context, s *runnerService) error {
+		_, _, err := s.ListRunners(ctx, &ListOptions{})
+		return err
+	}},
+	{"UpdateRunner", func(ctx context.Context, s *runnerService) error {
+		description := "updated"
+		_, err := s.UpdateRunner(ctx, "runner-1", &UpdateRunnerRequest{Description: &description})
+		return err
+	}},
+	{"DeleteRunner", func(ctx context.Context, s *runnerService) error {
+		_, err := s.DeleteRunner(ctx, "runner-1", false)
+		return err
+	}},
+	{"CloneRunner", func(ctx context.Context, s *runnerService) error {
+		_, err := s.CloneRunner(ctx, "runner-1", "")
+		return err
+	}},
+	{"GetRunnerStats", func(ctx context.Context, s *runnerService) error {
+		_, err := s.GetRunnerStats(ctx, &ListOptions{})
+		return err
+	}},
+	{"GetRunnerUsage", func(ctx context.Context, s *runnerService) error {
+		_, err := s.GetRunnerUsage(ctx, "runner-1")
+		return err
+	}},
+}
+
+func TestRunnerServiceCancelledContext(t *testing.T) {
+	// Without an API timeout only the caller's context can end the calls
+	svc := newStalledRunnerService(t, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tt := range runnerServiceCalls {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := tt.call(ctx, svc)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Expected a prompt return, took %s", elapsed)
+			}
+			if !errors.Is(err, ErrKubernetesAPI) || !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected a cancelled Kubernetes API error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRunnerServiceAPITimeout(t *testing.T) {
+	const apiTimeout = 50 * time.Millisecond
+	svc := newStalledRunnerService(t, apiTimeout)
+
+	for _, tt := range runnerServiceCalls {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := tt.call(context.Background(), svc)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Expected the call to give up after %s, took %s", apiTimeout, elapsed)
+			}
+			if !errors.Is(err, ErrKubernetesAPI) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected a timed out Kubernetes API error, got %v", err)
+			}
+			// A runner that couldn't be looked up isn't reported as missing
+			if errors.Is(err, ErrRunnerNotFound) {
+				t.Errorf("Expected no ErrRunnerNotFound, got %v", err)
+			}
+		})
+	}
+}
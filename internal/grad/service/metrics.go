@@ -0,0 +1,22 @@
+package service
+
+// Image pull states used as the image label of the provisioning histogram
+const (
+	ImagePullWarm    = "warm"
+	ImagePullCold    = "cold"
+	ImagePullUnknown = "unknown"
+)
+
+// Results used as the result label of the restart counter
+const (
+	RestartResultRestarted = "restarted"
+	RestartResultFailed    = "failed"
+	RestartResultGaveUp    = "gave_up"
+)
+
+// Reasons used as the reason label of the provisioning failure counter
+const (
+	ProvisioningFailureCreate  = "create_failed"
+	ProvisioningFailureTimeout = "timeout"
+	ProvisioningFailurePod     = "pod_error"
+)
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NamespaceAutoCreatedLabel marks namespaces created by grad, the only ones it will delete
+	NamespaceAutoCreatedLabel = RunnerAnnotationPrefix + "auto-created"
+
+	runnerResourceQuotaName = "grad-runner-quota"
+	runnerLimitRangeName    = "grad-runner-limits"
+)
+
+// EnsureNamespace creates the namespace, with its default ResourceQuota and LimitRange,
+// if namespace creation is enabled and it doesn't exist yet. Existing namespaces are left untouched.
+func (k *KubernetesClient) EnsureNamespace(ctx context.Context, name string) error {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	config := k.config.Namespaces
+	if config == nil || !config.Ensure {
+		return nil
+	}
+
+	_, err := k.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get namespace: %w", err)
+	}
+
+	// Build the quota objects first so a bad config doesn't leave a half-set-up namespace
+	quota, err := config.resourceQuota(name)
+	if err != nil {
+		return err
+	}
+	limitRange, err := config.limitRange(name)
+	if err != nil {
+		return err
+	}
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "grad",
+				NamespaceAutoCreatedLabel:      "true",
+			},
+		},
+	}
+	if _, err := k.clientset.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{}); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to create namespace: %w", err)
+	}
+	slog.Info("Created runner namespace", "namespace", name)
+
+	if quota != nil {
+		if _, err := k.clientset.CoreV1().ResourceQuotas(name).Create(ctx, quota, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create resource quota: %w", err)
+		}
+	}
+	if limitRange != nil {
+		if _, err := k.clientset.CoreV1().LimitRanges(name).Create(ctx, limitRange, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create limit range: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CleanupNamespace deletes a namespace created by EnsureNamespace once no runners are left in it,
+// if cleanup is enabled
+func (k *KubernetesClient) CleanupNamespace(ctx context.Context, name string) error {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	config := k.config.Namespaces
+	if config == nil || !config.CleanupEmpty {
+		return nil
+	}
+
+	namespace, err := k.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get namespace: %w", err)
+	}
+	if namespace.Labels[NamespaceAutoCreatedLabel] != "true" {
+		return nil
+	}
+
+	pods, err := k.clientset.CoreV1().Pods(name).List(ctx, metav1.ListOptions{
+		LabelSelector: RunnerLabelSelector + "," + RunnerComponentLabel,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list runner pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		// Pods already being deleted don't keep the namespace alive
+		if pod.DeletionTimestamp == nil {
+			return nil
+		}
+	}
+
+	if err := k.clientset.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete namespace: %w", err)
+	}
+	slog.Info("Deleted empty runner namespace", "namespace", name)
+
+	return nil
+}
+
+// resourceQuota builds the ResourceQuota for a new namespace, or nil if no quota is configured
+func (c *NamespaceConfig) resourceQuota(namespace string) (*corev1.ResourceQuota, error) {
+	hard, err := parseResourceList(map[corev1.ResourceName]string{
+		corev1.ResourceRequestsCPU:    c.QuotaCPU,
+		corev1.ResourceRequestsMemory: c.QuotaMemory,
+		corev1.ResourcePods:           c.QuotaPods,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace quota: %w", err)
+	}
+	if len(hard) == 0 {
+		return nil, nil
+	}
+
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      runnerResourceQuotaName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "grad"},
+		},
+		Spec: corev1.ResourceQuotaSpec{Hard: hard},
+	}, nil
+}
+
+// limitRange builds the LimitRange for a new namespace, or nil if no defaults are configured
+func (c *NamespaceConfig) limitRange(namespace string) (*corev1.LimitRange, error) {
+	defaults, err := parseResourceList(map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    c.LimitCPU,
+		corev1.ResourceMemory: c.LimitMemory,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace limit range: %w", err)
+	}
+	if len(defaults) == 0 {
+		return nil, nil
+	}
+
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      runnerLimitRangeName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "grad"},
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type:           corev1.LimitTypeContainer,
+					Default:        defaults,
+					DefaultRequest: defaults.DeepCopy(),
+				},
+			},
+		},
+	}, nil
+}
+
+// parseResourceList parses the non-empty quantities of a resource map (pure function)
+func parseResourceList(values map[corev1.ResourceName]string) (corev1.ResourceList, error) {
+	list := corev1.ResourceList{}
+	for name, value := range values {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %w", name, value, err)
+		}
+		list[name] = quantity
+	}
+	return list, nil
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newNamespaceTestClient creates a Kubernetes client for the "runners" namespace with the given namespace config
+func newNamespaceTestClient(namespaces *NamespaceConfig, objects ...runtime.Object) (*KubernetesClient, *fake.Clientset) {
+	clientset := fake.NewSimpleClientset(objects...)
+	config := DefaultKubernetesConfig()
+	config.Namespace = "runners"
+	config.Namespaces = namespaces
+	return &KubernetesClient{clientset: clientset, config: config}, clientset
+}
+
+func TestEnsureNamespaceCreatesNamespace(t *testing.T) {
+	ctx := context.Background()
+	k8sClient, clientset := newNamespaceTestClient(&NamespaceConfig{
+		Ensure:      true,
+		QuotaCPU:    "20",
+		QuotaMemory: "40Gi",
+		QuotaPods:   "10",
+		LimitCPU:    "500m",
+		LimitMemory: "512Mi",
+	})
+
+	if err := k8sClient.EnsureNamespace(ctx, "runners"); err != nil {
+		t.Fatalf("EnsureNamespace failed: %v", err)
+	}
+
+	namespace, err := clientset.CoreV1().Namespaces().Get(ctx, "runners", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace to be created: %v", err)
+	}
+	if namespace.Labels["app.kubernetes.io/managed-by"] != "grad" {
+		t.Errorf("Expected managed-by label 'grad', got '%s'", namespace.Labels["app.kubernetes.io/managed-by"])
+	}
+	if namespace.Labels[NamespaceAutoCreatedLabel] != "true" {
+		t.Errorf("Expected auto-created label 'true', got '%s'", namespace.Labels[NamespaceAutoCreatedLabel])
+	}
+
+	quota, err := clientset.CoreV1().ResourceQuotas("runners").Get(ctx, runnerResourceQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected resource quota to be created: %v", err)
+	}
+	expectedHard := map[corev1.ResourceName]string{
+		corev1.ResourceRequestsCPU:    "20",
+		corev1.ResourceRequestsMemory: "40Gi",
+		corev1.ResourcePods:           "10",
+	}
+	for name, value := range expectedHard {
+		if got, ok := quota.Spec.Hard[name]; !ok || !got.Equal(resource.MustParse(value)) {
+			t.Errorf("Expected quota %s=%s, got %v", name, value, quota.Spec.Hard[name])
+		}
+	}
+
+	limitRange, err := clientset.CoreV1().LimitRanges("runners").Get(ctx, runnerLimitRangeName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected limit range to be created: %v", err)
+	}
+	if len(limitRange.Spec.Limits) != 1 {
+		t.Fatalf("Expected 1 limit range item, got %d", len(limitRange.Spec.Limits))
+	}
+	item := limitRange.Spec.Limits[0]
+	if item.Type != corev1.LimitTypeContainer {
+		t.Errorf("Expected container limit type, got %s", item.Type)
+	}
+	if cpu := item.Default[corev1.ResourceCPU]; !cpu.Equal(resource.MustParse("500m")) {
+		t.Errorf("Expected default CPU 500m, got %s", cpu.String())
+	}
+	if memory := item.DefaultRequest[corev1.ResourceMemory]; !memory.Equal(resource.MustParse("512Mi")) {
+		t.Errorf("Expected default memory request 512Mi, got %s", memory.String())
+	}
+
+	// A second call is a no-op
+	if err := k8sClient.EnsureNamespace(ctx, "runners"); err != nil {
+		t.Errorf("Expected EnsureNamespace to be idempotent, got %v", err)
+	}
+}
+
+func TestEnsureNamespaceWithoutQuota(t *testing.T) {
+	ctx := context.Background()
+	k8sClient, clientset := newNamespaceTestClient(&NamespaceConfig{Ensure: true})
+
+	if err := k8sClient.EnsureNamespace(ctx, "runners"); err != nil {
+		t.Fatalf("EnsureNamespace failed: %v", err)
+	}
+
+	quotas, _ := clientset.CoreV1().ResourceQuotas("runners").List(ctx, metav1.ListOptions{})
+	if len(quotas.Items) != 0 {
+		t.Errorf("Expected no resource quota, got %d", len(quotas.Items))
+	}
+	limitRanges, _ := clientset.CoreV1().LimitRanges("runners").List(ctx, metav1.ListOptions{})
+	if len(limitRanges.Items) != 0 {
+		t.Errorf("Expected no limit range, got %d", len(limitRanges.Items))
+	}
+}
+
+func TestEnsureNamespaceDisabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *NamespaceConfig
+	}{
+		{name: "No config", config: nil},
+		{name: "Ensure off", config: &NamespaceConfig{QuotaCPU: "10"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			k8sClient, clientset := newNamespaceTestClient(tt.config)
+
+			if err := k8sClient.EnsureNamespace(ctx, "runners"); err != nil {
+				t.Fatalf("EnsureNamespace failed: %v", err)
+			}
+			if len(clientset.Actions()) != 0 {
+				t.Errorf("Expected no API calls, got %d", len(clientset.Actions()))
+			}
+		})
+	}
+}
+
+func TestEnsureNamespaceLeavesExistingNamespace(t *testing.T) {
+	ctx := context.Background()
+	existing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "runners"}}
+	k8sClient, clientset := newNamespaceTestClient(&NamespaceConfig{Ensure: true, QuotaPods: "10"}, existing)
+
+	if err := k8sClient.EnsureNamespace(ctx, "runners"); err != nil {
+		t.Fatalf("EnsureNamespace failed: %v", err)
+	}
+
+	namespace, _ := clientset.CoreV1().Namespaces().Get(ctx, "runners", metav1.GetOptions{})
+	if _, ok := namespace.Labels[NamespaceAutoCreatedLabel]; ok {
+		t.Error("Expected existing namespace not to be labelled")
+	}
+	quotas, _ := clientset.CoreV1().ResourceQuotas("runners").List(ctx, metav1.ListOptions{})
+	if len(quotas.Items) != 0 {
+		t.Errorf("Expected no quota in existing namespace, got %d", len(quotas.Items))
+	}
+}
+
+func TestEnsureNamespaceInvalidQuota(t *testing.T) {
+	ctx := context.Background()
+	k8sClient, clientset := newNamespaceTestClient(&NamespaceConfig{Ensure: true, QuotaMemory: "lots"})
+
+	if err := k8sClient.EnsureNamespace(ctx, "runners"); err == nil {
+		t.Fatal("Expected error for invalid quota")
+	}
+
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "runners", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected namespace not to be created, got %v", err)
+	}
+}
+
+func TestCleanupNamespace(t *testing.T) {
+	autoCreated := func() *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   "runners",
+			Labels: map[string]string{NamespaceAutoCreatedLabel: "true"},
+		}}
+	}
+	runnerPod := func(terminating bool) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      "grad-runner-runner-1",
+			Namespace: "runners",
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "grad",
+				"app.kubernetes.io/component":  "runner",
+			},
+		}}
+		if terminating {
+			now := metav1.Now()
+			pod.DeletionTimestamp = &now
+		}
+		return pod
+	}
+
+	tests := []struct {
+		name          string
+		config        *NamespaceConfig
+		objects       []runtime.Object
+		expectDeleted bool
+	}{
+		{
+			name:          "Empty auto-created namespace",
+			config:        &NamespaceConfig{Ensure: true, CleanupEmpty: true},
+			objects:       []runtime.Object{autoCreated()},
+			expectDeleted: true,
+		},
+		{
+			name:          "Only terminating runners left",
+			config:        &NamespaceConfig{Ensure: true, CleanupEmpty: true},
+			objects:       []runtime.Object{autoCreated(), runnerPod(true)},
+			expectDeleted: true,
+		},
+		{
+			name:    "Runners remaining",
+			config:  &NamespaceConfig{Ensure: true, CleanupEmpty: true},
+			objects: []runtime.Object{autoCreated(), runnerPod(false)},
+		},
+		{
+			name:    "Namespace not created by grad",
+			config:  &NamespaceConfig{Ensure: true, CleanupEmpty: true},
+			objects: []runtime.Object{&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "runners"}}},
+		},
+		{
+			name:    "Cleanup disabled",
+			config:  &NamespaceConfig{Ensure: true},
+			objects: []runtime.Object{autoCreated()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			k8sClient, clientset := newNamespaceTestClient(tt.config, tt.objects...)
+
+			if err := k8sClient.CleanupNamespace(ctx, "runners"); err != nil {
+				t.Fatalf("CleanupNamespace failed: %v", err)
+			}
+
+			_, err := clientset.CoreV1().Namespaces().Get(ctx, "runners", metav1.GetOptions{})
+			if deleted := errors.IsNotFound(err); deleted != tt.expectDeleted {
+				t.Errorf("Expected namespace deleted=%v, got %v", tt.expectDeleted, deleted)
+			}
+		})
+	}
+}
+
+func TestDeleteLastRunnerCleansUpNamespace(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.k8sClient.Config().Namespace = "runners"
+	svc.k8sClient.Config().Namespaces = &NamespaceConfig{Ensure: true, CleanupEmpty: true}
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	first, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	second, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	if _, err := svc.DeleteRunner(ctx, first.ID, false); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "runners", metav1.GetOptions{}); err != nil {
+		t.Fatalf("Expected namespace to remain while a runner is left: %v", err)
+	}
+
+	if _, err := svc.DeleteRunner(ctx, second.ID, false); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "runners", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected namespace to be deleted with its last runner, got %v", err)
+	}
+
+	// The next runner recreates it
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{}); err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "runners", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected namespace to be recreated: %v", err)
+	}
+}
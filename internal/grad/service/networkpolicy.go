@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Network policy modes
+const (
+	// NetworkPolicyModePerRunner creates one policy per runner, deleted along with it
+	NetworkPolicyModePerRunner = "per-runner"
+	// NetworkPolicyModeShared maintains a single policy covering all runners
+	NetworkPolicyModeShared = "shared"
+
+	// DefaultGradPodSelector matches the grad pods deployed by the helm chart
+	DefaultGradPodSelector = "app.kubernetes.io/name=grad"
+
+	sharedNetworkPolicyName = "grad-runners"
+)
+
+// enabled reports whether runner pods get a NetworkPolicy
+func (c *NetworkPolicyConfig) enabled() bool {
+	return c != nil && c.Mode != ""
+}
+
+// policyName returns the name of the policy covering a runner, or "" if policies are disabled
+func (c *NetworkPolicyConfig) policyName(runnerID string) string {
+	switch {
+	case !c.enabled():
+		return ""
+	case c.Mode == NetworkPolicyModeShared:
+		return sharedNetworkPolicyName
+	default:
+		return fmt.Sprintf("grad-runner-%s", runnerID)
+	}
+}
+
+// BuildNetworkPolicy builds the policy for a single runner, or the shared policy in shared mode.
+// Ingress is limited to the SSH port from grad and the configured clients; egress to DNS,
+// the S3 endpoint and the configured CIDRs.
+func (c *NetworkPolicyConfig) BuildNetworkPolicy(namespace, runnerID string, sshPort int32, s3Endpoint string) (*networkingv1.NetworkPolicy, error) {
+	if c.Mode != NetworkPolicyModePerRunner && c.Mode != NetworkPolicyModeShared {
+		return nil, fmt.Errorf("unknown network policy mode %q", c.Mode)
+	}
+
+	policyLabels := map[string]string{"app.kubernetes.io/managed-by": "grad"}
+	podSelector := map[string]string{
+		"app.kubernetes.io/managed-by": "grad",
+		"app.kubernetes.io/component":  "runner",
+	}
+	if c.Mode == NetworkPolicyModePerRunner {
+		policyLabels["app.kubernetes.io/instance"] = runnerID
+		podSelector["app.kubernetes.io/instance"] = runnerID
+	}
+
+	ingressPeers, err := c.sshPeers()
+	if err != nil {
+		return nil, err
+	}
+
+	if s3Endpoint == "" {
+		s3Endpoint = c.S3Endpoint
+	}
+	s3Rule, err := s3EgressRule(s3Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{
+				policyPort(corev1.ProtocolUDP, 53),
+				policyPort(corev1.ProtocolTCP, 53),
+			},
+		},
+		s3Rule,
+	}
+	if len(c.EgressCIDRs) > 0 {
+		rule := networkingv1.NetworkPolicyEgressRule{}
+		for _, cidr := range c.EgressCIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return nil, fmt.Errorf("invalid egress CIDR %q: %w", cidr, err)
+			}
+			rule.To = append(rule.To, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+		}
+		egress = append(egress, rule)
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.policyName(runnerID),
+			Namespace: namespace,
+			Labels:    policyLabels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podSelector},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					Ports: []networkingv1.NetworkPolicyPort{policyPort(corev1.ProtocolTCP, sshPort)},
+					From:  ingressPeers,
+				},
+			},
+			Egress: egress,
+		},
+	}, nil
+}
+
+// sshPeers returns grad and the configured SSH clients as ingress peers
+func (c *NetworkPolicyConfig) sshPeers() ([]networkingv1.NetworkPolicyPeer, error) {
+	gradSelector, err := labels.ConvertSelectorToLabelsMap(c.GradPodSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grad pod selector %q: %w", c.GradPodSelector, err)
+	}
+
+	// grad may run in any namespace
+	peers := []networkingv1.NetworkPolicyPeer{
+		{
+			NamespaceSelector: &metav1.LabelSelector{},
+			PodSelector:       &metav1.LabelSelector{MatchLabels: gradSelector},
+		},
+	}
+
+	if len(c.SSHNamespaces) == 0 && c.SSHPodSelector == "" {
+		return peers, nil
+	}
+
+	client := networkingv1.NetworkPolicyPeer{}
+	if len(c.SSHNamespaces) > 0 {
+		client.NamespaceSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      corev1.LabelMetadataName,
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   c.SSHNamespaces,
+				},
+			},
+		}
+	}
+	if c.SSHPodSelector != "" {
+		clientSelector, err := labels.ConvertSelectorToLabelsMap(c.SSHPodSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH pod selector %q: %w", c.SSHPodSelector, err)
+		}
+		client.PodSelector = &metav1.LabelSelector{MatchLabels: clientSelector}
+	}
+
+	return append(peers, client), nil
+}
+
+// s3EgressRule allows traffic to the S3 endpoint's port. NetworkPolicy can only match IPs,
+// so the destination is restricted only when the endpoint host is an IP address.
+func s3EgressRule(endpoint string) (networkingv1.NetworkPolicyEgressRule, error) {
+	rule := networkingv1.NetworkPolicyEgressRule{}
+	if endpoint == "" {
+		rule.Ports = []networkingv1.NetworkPolicyPort{policyPort(corev1.ProtocolTCP, 443)}
+		return rule, nil
+	}
+
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return rule, fmt.Errorf("invalid S3 endpoint %q: %w", endpoint, err)
+	}
+
+	port := int32(443)
+	if u.Scheme == "http" {
+		port = 80
+	}
+	if u.Port() != "" {
+		p, err := strconv.ParseInt(u.Port(), 10, 32)
+		if err != nil {
+			return rule, fmt.Errorf("invalid S3 endpoint port %q: %w", u.Port(), err)
+		}
+		port = int32(p)
+	}
+	rule.Ports = []networkingv1.NetworkPolicyPort{policyPort(corev1.ProtocolTCP, port)}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		cidr := ip.String() + "/32"
+		if ip.To4() == nil {
+			cidr = ip.String() + "/128"
+		}
+		rule.To = []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}}
+	}
+
+	return rule, nil
+}
+
+// policyPort builds a NetworkPolicy port (pure function)
+func policyPort(protocol corev1.Protocol, port int32) networkingv1.NetworkPolicyPort {
+	portValue := intstr.FromInt32(port)
+	return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &portValue}
+}
+
+// SetupNetworkPolicy validates the network policy config and, in shared mode, creates or updates the shared policy
+func (k *KubernetesClient) SetupNetworkPolicy(ctx context.Context) error {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	config := k.config.NetworkPolicy
+	if !config.enabled() {
+		return nil
+	}
+
+	policy, err := config.BuildNetworkPolicy(k.config.Namespace, "validate", k.config.SSHPort, "")
+	if err != nil {
+		return err
+	}
+	if config.Mode != NetworkPolicyModeShared {
+		return nil
+	}
+
+	policies := k.clientset.NetworkingV1().NetworkPolicies(k.config.Namespace)
+	existing, err := policies.Get(ctx, policy.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := policies.Create(ctx, policy, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create network policy: %w", err)
+		}
+		slog.Info("Created shared runner network policy", "name", policy.Name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get network policy: %w", err)
+	}
+
+	existing.Labels = policy.Labels
+	existing.Spec = policy.Spec
+	if _, err := policies.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update network policy: %w", err)
+	}
+	return nil
+}
+
+// createRunnerNetworkPolicy creates the policy covering a new runner. In shared mode the
+// shared policy is recreated if missing, e.g. after its namespace was cleaned up.
+func (k *KubernetesClient) createRunnerNetworkPolicy(ctx context.Context, runner *Runner) error {
+	config := k.config.NetworkPolicy
+	if !config.enabled() {
+		return nil
+	}
+
+	// The shared policy can't follow per-runner workspaces
+	s3Endpoint := ""
+	if runner.Workspace != nil && config.Mode == NetworkPolicyModePerRunner {
+		s3Endpoint = runner.Workspace.Endpoint
+	}
+	policy, err := config.BuildNetworkPolicy(k.config.Namespace, runner.ID, k.config.SSHPort, s3Endpoint)
+	if err != nil {
+		return err
+	}
+
+	_, err = k.clientset.NetworkingV1().NetworkPolicies(k.config.Namespace).Create(ctx, policy, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create network policy: %w", err)
+	}
+	return nil
+}
+
+// adoptRunnerNetworkPolicy makes the runner pod own its policy, so Kubernetes also removes it
+// if the pod is deleted outside of grad
+func (k *KubernetesClient) adoptRunnerNetworkPolicy(ctx context.Context, pod *corev1.Pod) error {
+	config := k.config.NetworkPolicy
+	if !config.enabled() || config.Mode != NetworkPolicyModePerRunner {
+		return nil
+	}
+
+	policies := k.clientset.NetworkingV1().NetworkPolicies(k.config.Namespace)
+	policy, err := policies.Get(ctx, config.policyName(pod.Annotations[RunnerIDAnnotation]), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get network policy: %w", err)
+	}
+
+	policy.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Name:       pod.Name,
+			UID:        pod.UID,
+		},
+	}
+	if _, err := policies.Update(ctx, policy, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update network policy: %w", err)
+	}
+	return nil
+}
+
+// deleteRunnerNetworkPolicy deletes the per-runner policy; it is a no-op in other modes
+func (k *KubernetesClient) deleteRunnerNetworkPolicy(ctx context.Context, runnerID string) error {
+	config := k.config.NetworkPolicy
+	if !config.enabled() || config.Mode != NetworkPolicyModePerRunner {
+		return nil
+	}
+
+	err := k.clientset.NetworkingV1().NetworkPolicies(k.config.Namespace).Delete(ctx, config.policyName(runnerID), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete network policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// portsOf flattens NetworkPolicy ports into "PROTO/port" strings
+func portsOf(ports []networkingv1.NetworkPolicyPort) []string {
+	var result []string
+	for _, port := range ports {
+		result = append(result, string(*port.Protocol)+"/"+port.Port.String())
+	}
+	return result
+}
+
+func TestBuildNetworkPolicyPerRunner(t *testing.T) {
+	config := &NetworkPolicyConfig{
+		Mode:            NetworkPolicyModePerRunner,
+		SSHNamespaces:   []string{"dev", "ci"},
+		SSHPodSelector:  "app=workspace-sync",
+		GradPodSelector: DefaultGradPodSelector,
+		EgressCIDRs:     []string{"10.20.0.0/16"},
+	}
+
+	policy, err := config.BuildNetworkPolicy("runners", "runner-1", 2222, "http://10.0.0.5:9000")
+	if err != nil {
+		t.Fatalf("BuildNetworkPolicy failed: %v", err)
+	}
+
+	if policy.Name != "grad-runner-runner-1" || policy.Namespace != "runners" {
+		t.Errorf("Expected runners/grad-runner-runner-1, got %s/%s", policy.Namespace, policy.Name)
+	}
+	expectedSelector := map[string]string{
+		"app.kubernetes.io/managed-by": "grad",
+		"app.kubernetes.io/component":  "runner",
+		"app.kubernetes.io/instance":   "runner-1",
+	}
+	if !reflect.DeepEqual(policy.Spec.PodSelector.MatchLabels, expectedSelector) {
+		t.Errorf("Expected pod selector %v, got %v", expectedSelector, policy.Spec.PodSelector.MatchLabels)
+	}
+	expectedTypes := []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}
+	if !reflect.DeepEqual(policy.Spec.PolicyTypes, expectedTypes) {
+		t.Errorf("Expected policy types %v, got %v", expectedTypes, policy.Spec.PolicyTypes)
+	}
+
+	// Ingress: SSH only, from grad and the configured clients
+	if len(policy.Spec.Ingress) != 1 {
+		t.Fatalf("Expected 1 ingress rule, got %d", len(policy.Spec.Ingress))
+	}
+	ingress := policy.Spec.Ingress[0]
+	if ports := portsOf(ingress.Ports); !reflect.DeepEqual(ports, []string{"TCP/2222"}) {
+		t.Errorf("Expected ingress on TCP/2222, got %v", ports)
+	}
+	if len(ingress.From) != 2 {
+		t.Fatalf("Expected 2 ingress peers, got %d", len(ingress.From))
+	}
+	grad := ingress.From[0]
+	if grad.NamespaceSelector == nil || len(grad.NamespaceSelector.MatchLabels) != 0 {
+		t.Errorf("Expected grad peer to match all namespaces, got %v", grad.NamespaceSelector)
+	}
+	if grad.PodSelector.MatchLabels["app.kubernetes.io/name"] != "grad" {
+		t.Errorf("Expected grad pod selector, got %v", grad.PodSelector.MatchLabels)
+	}
+	clients := ingress.From[1]
+	expectedNamespaces := metav1.LabelSelectorRequirement{
+		Key:      "kubernetes.io/metadata.name",
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   []string{"dev", "ci"},
+	}
+	if clients.NamespaceSelector == nil || !reflect.DeepEqual(clients.NamespaceSelector.MatchExpressions, []metav1.LabelSelectorRequirement{expectedNamespaces}) {
+		t.Errorf("Expected client namespace selector %v, got %v", expectedNamespaces, clients.NamespaceSelector)
+	}
+	if clients.PodSelector == nil || clients.PodSelector.MatchLabels["app"] != "workspace-sync" {
+		t.Errorf("Expected client pod selector app=workspace-sync, got %v", clients.PodSelector)
+	}
+
+	// Egress: DNS, S3 and the allowlist
+	if len(policy.Spec.Egress) != 3 {
+		t.Fatalf("Expected 3 egress rules, got %d", len(policy.Spec.Egress))
+	}
+	dns := policy.Spec.Egress[0]
+	if ports := portsOf(dns.Ports); !reflect.DeepEqual(ports, []string{"UDP/53", "TCP/53"}) || len(dns.To) != 0 {
+		t.Errorf("Expected DNS rule on port 53 to anywhere, got ports %v to %v", ports, dns.To)
+	}
+	s3 := policy.Spec.Egress[1]
+	if ports := portsOf(s3.Ports); !reflect.DeepEqual(ports, []string{"TCP/9000"}) {
+		t.Errorf("Expected S3 rule on TCP/9000, got %v", ports)
+	}
+	if len(s3.To) != 1 || s3.To[0].IPBlock.CIDR != "10.0.0.5/32" {
+		t.Errorf("Expected S3 rule to 10.0.0.5/32, got %v", s3.To)
+	}
+	allow := policy.Spec.Egress[2]
+	if len(allow.To) != 1 || allow.To[0].IPBlock.CIDR != "10.20.0.0/16" || len(allow.Ports) != 0 {
+		t.Errorf("Expected allowlist rule to 10.20.0.0/16 on all ports, got %v", allow)
+	}
+}
+
+func TestBuildNetworkPolicyShared(t *testing.T) {
+	config := &NetworkPolicyConfig{
+		Mode:            NetworkPolicyModeShared,
+		GradPodSelector: DefaultGradPodSelector,
+		S3Endpoint:      "minio.storage.svc:9000",
+	}
+
+	policy, err := config.BuildNetworkPolicy("runners", "", 22, "")
+	if err != nil {
+		t.Fatalf("BuildNetworkPolicy failed: %v", err)
+	}
+
+	if policy.Name != sharedNetworkPolicyName {
+		t.Errorf("Expected shared policy name, got %s", policy.Name)
+	}
+	if _, ok := policy.Spec.PodSelector.MatchLabels["app.kubernetes.io/instance"]; ok {
+		t.Error("Expected shared policy to select all runners")
+	}
+	if len(policy.Spec.Ingress[0].From) != 1 {
+		t.Errorf("Expected only grad as ingress peer, got %d peers", len(policy.Spec.Ingress[0].From))
+	}
+
+	// A DNS name can't be matched by a policy, so only the port is restricted
+	s3 := policy.Spec.Egress[1]
+	if ports := portsOf(s3.Ports); !reflect.DeepEqual(ports, []string{"TCP/9000"}) || len(s3.To) != 0 {
+		t.Errorf("Expected S3 rule on TCP/9000 to anywhere, got ports %v to %v", ports, s3.To)
+	}
+	if len(policy.Spec.Egress) != 2 {
+		t.Errorf("Expected no allowlist rule, got %d egress rules", len(policy.Spec.Egress))
+	}
+}
+
+func TestS3EgressRule(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		port     string
+		cidr     string
+	}{
+		{endpoint: "", port: "TCP/443"},
+		{endpoint: "https://s3.amazonaws.com", port: "TCP/443"},
+		{endpoint: "http://minio", port: "TCP/80"},
+		{endpoint: "192.168.1.10:9000", port: "TCP/9000", cidr: "192.168.1.10/32"},
+		{endpoint: "http://[fd00::1]:9000", port: "TCP/9000", cidr: "fd00::1/128"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			rule, err := s3EgressRule(tt.endpoint)
+			if err != nil {
+				t.Fatalf("s3EgressRule failed: %v", err)
+			}
+			if ports := portsOf(rule.Ports); !reflect.DeepEqual(ports, []string{tt.port}) {
+				t.Errorf("Expected port %s, got %v", tt.port, ports)
+			}
+			if tt.cidr == "" && len(rule.To) != 0 {
+				t.Errorf("Expected no destination restriction, got %v", rule.To)
+			}
+			if tt.cidr != "" && (len(rule.To) != 1 || rule.To[0].IPBlock.CIDR != tt.cidr) {
+				t.Errorf("Expected destination %s, got %v", tt.cidr, rule.To)
+			}
+		})
+	}
+}
+
+func TestBuildNetworkPolicyInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *NetworkPolicyConfig
+	}{
+		{name: "Unknown mode", config: &NetworkPolicyConfig{Mode: "strict", GradPodSelector: DefaultGradPodSelector}},
+		{name: "Bad CIDR", config: &NetworkPolicyConfig{Mode: NetworkPolicyModeShared, GradPodSelector: DefaultGradPodSelector, EgressCIDRs: []string{"10.0.0.0"}}},
+		{name: "Bad selector", config: &NetworkPolicyConfig{Mode: NetworkPolicyModeShared, GradPodSelector: DefaultGradPodSelector, SSHPodSelector: "app"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.config.BuildNetworkPolicy("runners", "runner-1", 22, ""); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
+
+func TestSetupSharedNetworkPolicy(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	config := DefaultKubernetesConfig()
+	config.NetworkPolicy = &NetworkPolicyConfig{Mode: NetworkPolicyModeShared, GradPodSelector: DefaultGradPodSelector}
+	k8sClient := &KubernetesClient{clientset: clientset, config: config}
+
+	if err := k8sClient.SetupNetworkPolicy(ctx); err != nil {
+		t.Fatalf("SetupNetworkPolicy failed: %v", err)
+	}
+
+	// A changed config updates the existing policy
+	config.NetworkPolicy.EgressCIDRs = []string{"10.0.0.0/8"}
+	if err := k8sClient.SetupNetworkPolicy(ctx); err != nil {
+		t.Fatalf("SetupNetworkPolicy failed on update: %v", err)
+	}
+
+	policy, err := clientset.NetworkingV1().NetworkPolicies(config.Namespace).Get(ctx, sharedNetworkPolicyName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected shared policy to exist: %v", err)
+	}
+	if len(policy.Spec.Egress) != 3 {
+		t.Errorf("Expected updated policy with allowlist rule, got %d egress rules", len(policy.Spec.Egress))
+	}
+}
+
+func TestRunnerNetworkPolicyLifecycle(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	config := svc.k8sClient.Config()
+	config.NetworkPolicy = &NetworkPolicyConfig{Mode: NetworkPolicyModePerRunner, GradPodSelector: DefaultGradPodSelector}
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if runner.NetworkPolicy != "grad-runner-runner-1" {
+		t.Errorf("Expected runner to report policy 'grad-runner-runner-1', got '%s'", runner.NetworkPolicy)
+	}
+	if runner.ToProto().NetworkPolicy != runner.NetworkPolicy {
+		t.Errorf("Expected proto to carry the network policy, got '%s'", runner.ToProto().NetworkPolicy)
+	}
+
+	policies := clientset.NetworkingV1().NetworkPolicies(config.Namespace)
+	policy, err := policies.Get(ctx, "grad-runner-runner-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected runner policy to exist: %v", err)
+	}
+	if len(policy.OwnerReferences) != 1 || policy.OwnerReferences[0].Kind != "Pod" || policy.OwnerReferences[0].Name != "grad-runner-runner-1" {
+		t.Errorf("Expected policy to be owned by the runner pod, got %v", policy.OwnerReferences)
+	}
+
+	if _, err := svc.DeleteRunner(ctx, runner.ID, false); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+	if _, err := policies.Get(ctx, "grad-runner-runner-1", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected runner policy to be deleted with the pod, got %v", err)
+	}
+}
+
+func TestRunnerWithoutNetworkPolicy(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if runner.NetworkPolicy != "" {
+		t.Errorf("Expected no network policy, got '%s'", runner.NetworkPolicy)
+	}
+
+	policies, err := clientset.NetworkingV1().NetworkPolicies(corev1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list policies: %v", err)
+	}
+	if len(policies.Items) != 0 {
+		t.Errorf("Expected no policies, got %d", len(policies.Items))
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"cmp"
+	"slices"
+)
+
+// RunnerOrderKeys are the values ListOptions.OrderBy accepts
+var RunnerOrderKeys = []string{"id", "name", "age", "status", "cpu", "memory"}
+
+// compareRunners orders runners by the key of RunnerOrderKeys, then by ID; nil Resources count
+// as none requested. It returns nil for an unknown key. (pure function)
+func compareRunners(orderBy string) func(a, b *Runner) int {
+	var compare func(a, b *Runner) int
+	switch orderBy {
+	case "id":
+		compare = func(a, b *Runner) int { return 0 }
+	case "name":
+		compare = func(a, b *Runner) int { return cmp.Compare(a.Name, b.Name) }
+	case "age":
+		compare = func(a, b *Runner) int { return cmp.Compare(b.CreatedAt, a.CreatedAt) }
+	case "status":
+		compare = func(a, b *Runner) int { return cmp.Compare(a.Status.ToProto(), b.Status.ToProto()) }
+	case "cpu":
+		compare = func(a, b *Runner) int { return cmp.Compare(requested(b).CPUMillicores, requested(a).CPUMillicores) }
+	case "memory":
+		compare = func(a, b *Runner) int { return cmp.Compare(requested(b).MemoryMB, requested(a).MemoryMB) }
+	default:
+		return nil
+	}
+	return func(a, b *Runner) int {
+		return cmp.Or(compare(a, b), cmp.Compare(a.ID, b.ID))
+	}
+}
+
+// requested returns the resources of runner, zero if they aren't known (pure function)
+func requested(runner *Runner) ResourceRequirements {
+	if runner.Resources == nil {
+		return ResourceRequirements{}
+	}
+	return *runner.Resources
+}
+
+// sortRunners orders runners by orderBy, one of RunnerOrderKeys, reversed if reverse is set.
+// An empty or unknown orderBy leaves them as they are. (pure function)
+func sortRunners(runners []*Runner, orderBy string, reverse bool) {
+	compare := compareRunners(orderBy)
+	if compare == nil {
+		return
+	}
+	slices.SortStableFunc(runners, func(a, b *Runner) int {
+		if reverse {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortRunners(t *testing.T) {
+	newRunners := func() []*Runner {
+		return []*Runner{
+			{ID: "runner-3", Name: "web", Status: RunnerStatusError, CreatedAt: 300, Resources: &ResourceRequirements{CPUMillicores: 1000, MemoryMB: 4096}},
+			{ID: "runner-1", Name: "api", Status: RunnerStatusRunning, CreatedAt: 100, Resources: &ResourceRequirements{CPUMillicores: 4000, MemoryMB: 2048}},
+			{ID: "runner-2", Name: "api", Status: RunnerStatusCreating, CreatedAt: 200},
+		}
+	}
+
+	tests := []struct {
+		orderBy  string
+		reverse  bool
+		expected string
+	}{
+		{"id", false, "runner-1 runner-2 runner-3"},
+		{"name", false, "runner-1 runner-2 runner-3"},
+		{"age", false, "runner-3 runner-2 runner-1"},
+		{"status", false, "runner-2 runner-1 runner-3"},
+		{"cpu", false, "runner-1 runner-3 runner-2"},
+		{"memory", false, "runner-3 runner-1 runner-2"},
+		{"memory", true, "runner-2 runner-1 runner-3"},
+		{"", false, "runner-3 runner-1 runner-2"},
+	}
+	for _, tt := range tests {
+		runners := newRunners()
+		sortRunners(runners, tt.orderBy, tt.reverse)
+		var ids []string
+		for _, runner := range runners {
+			ids = append(ids, runner.ID)
+		}
+		if got := strings.Join(ids, " "); got != tt.expected {
+			t.Errorf("sortRunners(%q, reverse=%t) = %s, want %s", tt.orderBy, tt.reverse, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// maxOutputChunkBytes is how much output channelWriter holds back at most before sending it
+const maxOutputChunkBytes = 32 * 1024
+
+// channelWriter implements io.Writer and sends what is written to a channel. Writes within
+// flushInterval of the first unsent one are sent together, so bursts of tiny writes such as
+// progress bars take few channel slots, while output without a newline, such as a prompt,
+// still goes out within flushInterval. A zero flushInterval sends every write as it comes.
+type channelWriter struct {
+	ch            chan<- []byte
+	name          string
+	flushInterval time.Duration
+	// sent, if set, is called with the name and size of each chunk sent
+	sent func(name string, n int)
+
+	mu      sync.Mutex
+	pending []byte
+	timer   *time.Timer
+	closed  bool
+}
+
+// newChannelWriter returns a writer sending to ch; name identifies the stream in logs
+func newChannelWriter(ch chan<- []byte, name string, flushInterval time.Duration) *channelWriter {
+	return &channelWriter{ch: ch, name: name, flushInterval: flushInterval}
+}
+
+func (cw *channelWriter) Write(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	// Appending copies p, which the caller may reuse
+	cw.pending = append(cw.pending, p...)
+	if cw.flushInterval <= 0 || len(cw.pending) >= maxOutputChunkBytes {
+		cw.flushLocked()
+	} else if cw.timer == nil {
+		cw.timer = time.AfterFunc(cw.flushInterval, cw.Flush)
+	}
+	return len(p), nil
+}
+
+// Flush sends the output held back, if any
+func (cw *channelWriter) Flush() {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.flushLocked()
+}
+
+// Close sends the output held back. Nothing is sent afterwards, so ch may be closed.
+func (cw *channelWriter) Close() {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.flushLocked()
+	cw.closed = true
+}
+
+func (cw *channelWriter) flushLocked() {
+	if cw.timer != nil {
+		cw.timer.Stop()
+		cw.timer = nil
+	}
+	if len(cw.pending) == 0 || cw.closed {
+		return
+	}
+
+	data := cw.pending
+	cw.pending = nil
+	select {
+	case cw.ch <- data:
+		slog.Debug("Sent data to channel", "stream", cw.name, "bytes", len(data))
+		if cw.sent != nil {
+			cw.sent(cw.name, len(data))
+		}
+	default:
+		slog.Warn("Channel full, dropping data", "stream", cw.name, "bytes", len(data))
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// receive returns the next chunk sent to ch, failing if none arrives within timeout
+func receive(t *testing.T, ch <-chan []byte, timeout time.Duration) []byte {
+	t.Helper()
+	select {
+	case data := <-ch:
+		return data
+	case <-time.After(timeout):
+		t.Fatalf("Expected output within %s", timeout)
+		return nil
+	}
+}
+
+func TestChannelWriterFlushesPromptWithoutNewline(t *testing.T) {
+	ch := make(chan []byte, 10)
+	w := newChannelWriter(ch, "stdout", 50*time.Millisecond)
+	defer w.Close()
+
+	// The command is still running, waiting for an answer to its prompt
+	w.Write([]byte("Continue? "))
+	w.Write([]byte("[y/N]: "))
+	if data := receive(t, ch, time.Second); string(data) != "Continue? [y/N]: " {
+		t.Errorf("Expected the prompt in one chunk, got %q", data)
+	}
+}
+
+func TestChannelWriterCoalescesWrites(t *testing.T) {
+	ch := make(chan []byte, 10)
+	w := newChannelWriter(ch, "stdout", time.Hour)
+
+	buf := []byte("10%\r")
+	w.Write(buf)
+	// The caller may reuse its buffer once Write returns
+	copy(buf, "20%\r")
+	w.Write(buf)
+	if len(ch) != 0 {
+		t.Fatalf("Expected output to be held back within the flush interval, got %d chunks", len(ch))
+	}
+
+	w.Close()
+	if data := receive(t, ch, time.Second); string(data) != "10%\r20%\r" {
+		t.Errorf("Expected the writes in one chunk on close, got %q", data)
+	}
+
+	w.Write([]byte("late"))
+	w.Flush()
+	if len(ch) != 0 {
+		t.Errorf("Expected nothing to be sent after close, got %d chunks", len(ch))
+	}
+}
+
+func TestChannelWriterSendsLargeOutputImmediately(t *testing.T) {
+	ch := make(chan []byte, 10)
+	w := newChannelWriter(ch, "stdout", time.Hour)
+	defer w.Close()
+
+	data := bytes.Repeat([]byte("x"), maxOutputChunkBytes)
+	w.Write(data)
+	if got := receive(t, ch, time.Second); len(got) != maxOutputChunkBytes {
+		t.Errorf("Expected a full chunk of %d bytes, got %d", maxOutputChunkBytes, len(got))
+	}
+}
+
+func TestChannelWriterWithoutFlushInterval(t *testing.T) {
+	ch := make(chan []byte, 10)
+	w := newChannelWriter(ch, "stdout", 0)
+	defer w.Close()
+
+	w.Write([]byte("a"))
+	w.Write([]byte("b"))
+	if len(ch) != 2 {
+		t.Errorf("Expected every write to be sent as is, got %d chunks", len(ch))
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+)
+
+// PodManager is what the runner service needs from the cluster that runs the runners. It is
+// implemented by KubernetesClient; tests implement it without a cluster. Errors for objects
+// that don't exist must satisfy errors.IsNotFound of k8s.io/apimachinery.
+type PodManager interface {
+	// Config returns the configuration runners are created with
+	Config() *KubernetesConfig
+
+	// Runner pods
+	CreateRunnerPod(ctx context.Context, runner *Runner) error
+	GetRunnerPod(ctx context.Context, runnerID string) (*corev1.Pod, error)
+	ListRunnerPods(ctx context.Context) (*corev1.PodList, error)
+	ListAutoRestartPods(ctx context.Context) (*corev1.PodList, error)
+	ReplaceRunnerPod(ctx context.Context, failed, replacement *corev1.Pod) error
+	UpdateRunnerAnnotations(ctx context.Context, runnerID string, annotations map[string]string) error
+	DeleteRunnerPod(ctx context.Context, runnerID string) error
+	// RemoveRunnerFinalizer lets a deleted runner pod go once its other objects are deleted
+	RemoveRunnerFinalizer(ctx context.Context, podName string) error
+	DeleteRunnerResources(ctx context.Context, runnerID string) *RunnerCleanup
+	RemainingRunnerResources(ctx context.Context, runnerID string) ([]RunnerResource, error)
+
+	// Commands
+	ExecuteCommandStream(ctx context.Context, runnerID string, command []string, output execOutput, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error)
+
+	// Observing runners
+	ImagePullState(ctx context.Context, runnerID string) string
+	RunnerUsage(ctx context.Context, runnerID string) (*RunnerUsage, error)
+	ListRunnerUsage(ctx context.Context) ([]*RunnerUsage, error)
+	RunnerEvents(ctx context.Context, runnerID string) ([]RunnerEvent, error)
+	ContainerLog(ctx context.Context, runnerID, container string, tailLines int64) (string, error)
+	RecordPodEvent(pod *corev1.Pod, eventType, reason, messageFmt string, args ...interface{})
+
+	// The cluster around the runners
+	EnsureNamespace(ctx context.Context, name string) error
+	CleanupNamespace(ctx context.Context, name string) error
+	EnsureServiceAccount(ctx context.Context) error
+	GetServiceAccount(ctx context.Context, name string) (*corev1.ServiceAccount, error)
+	GetConfigMap(ctx context.Context, name string) (*corev1.ConfigMap, error)
+	GetPriorityClass(ctx context.Context, name string) (*schedulingv1.PriorityClass, error)
+	ListNodes(ctx context.Context) (*corev1.NodeList, error)
+	ListActivePods(ctx context.Context) (*corev1.PodList, error)
+}
+
+var _ PodManager = (*KubernetesClient)(nil)
+
+// Config returns the configuration runners are created with
+func (k *KubernetesClient) Config() *KubernetesConfig {
+	return k.config
+}
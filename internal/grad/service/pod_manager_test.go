@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// fakePodManager keeps runner pods in memory. Calls it doesn't implement panic through the
+// embedded nil PodManager, so a test notices when the service starts depending on more.
+type fakePodManager struct {
+	PodManager
+
+	config *KubernetesConfig
+
+	mu   sync.Mutex
+	pods map[string]*corev1.Pod
+	// getErr fails every GetRunnerPod, createErr and deleteErr the next CreateRunnerPod and DeleteRunnerPod
+	getErr    error
+	createErr error
+	deleteErr error
+	// finalizersRemoved holds the pods whose finalizer was removed
+	finalizersRemoved []string
+}
+
+func newFakePodManager() *fakePodManager {
+	return &fakePodManager{config: DefaultKubernetesConfig(), pods: make(map[string]*corev1.Pod)}
+}
+
+// newFakeRunnerService creates a runner service backed by pods with fast monitor polling
+func newFakeRunnerService(pods *fakePodManager) *runnerService {
+	svc := NewRunnerService(pods, NewActivityTracker(), DefaultDurations(), nil).(*runnerService)
+	svc.monitorInterval = 10 * time.Millisecond
+	return svc
+}
+
+func (f *fakePodManager) notFound(runnerID string) error {
+	return apierrors.NewNotFound(corev1.Resource("pods"), BuildPodDeletionRequest(runnerID, f.config).PodName)
+}
+
+// setPhase moves the pod of runnerID to phase, ready if it is running
+func (f *fakePodManager) setPhase(runnerID string, phase corev1.PodPhase) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pod := f.pods[runnerID]
+	pod.Status.Phase = phase
+	if phase == corev1.PodRunning {
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	}
+}
+
+func (f *fakePodManager) Config() *KubernetesConfig { return f.config }
+
+func (f *fakePodManager) EnsureNamespace(ctx context.Context, name string) error { return nil }
+
+func (f *fakePodManager) EnsureServiceAccount(ctx context.Context) error { return nil }
+
+func (f *fakePodManager) CleanupNamespace(ctx context.Context, name string) error { return nil }
+
+func (f *fakePodManager) CreateRunnerPod(ctx context.Context, runner *Runner) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.createErr; err != nil {
+		f.createErr = nil
+		return err
+	}
+	req, err := BuildPodCreationRequest(runner, f.config)
+	if err != nil {
+		return err
+	}
+	pod := req.ToPodSpec()
+	pod.Status.Phase = corev1.PodPending
+	f.pods[runner.ID] = pod
+	return nil
+}
+
+func (f *fakePodManager) GetRunnerPod(ctx context.Context, runnerID string) (*corev1.Pod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	pod, ok := f.pods[runnerID]
+	if !ok {
+		return nil, f.notFound(runnerID)
+	}
+	return pod.DeepCopy(), nil
+}
+
+func (f *fakePodManager) ListRunnerPods(ctx context.Context) (*corev1.PodList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := &corev1.PodList{}
+	for i := 1; len(list.Items) < len(f.pods); i++ {
+		if pod, ok := f.pods[fmt.Sprintf("runner-%d", i)]; ok {
+			list.Items = append(list.Items, *pod.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (f *fakePodManager) UpdateRunnerAnnotations(ctx context.Context, runnerID string, annotations map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pod, ok := f.pods[runnerID]
+	if !ok {
+		return f.notFound(runnerID)
+	}
+	maps.Copy(pod.Annotations, annotations)
+	return nil
+}
+
+func (f *fakePodManager) DeleteRunnerPod(ctx context.Context, runnerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.deleteErr; err != nil {
+		f.deleteErr = nil
+		return err
+	}
+	if _, ok := f.pods[runnerID]; !ok {
+		return f.notFound(runnerID)
+	}
+	delete(f.pods, runnerID)
+	return nil
+}
+
+func (f *fakePodManager) DeleteRunnerResources(ctx context.Context, runnerID string) *RunnerCleanup {
+	return &RunnerCleanup{}
+}
+
+func (f *fakePodManager) RemoveRunnerFinalizer(ctx context.Context, podName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.finalizersRemoved = append(f.finalizersRemoved, podName)
+	return nil
+}
+
+// ListNodes is forbidden, as it is to grad without a ClusterRole, which skips the capacity check
+func (f *fakePodManager) ListNodes(ctx context.Context) (*corev1.NodeList, error) {
+	return nil, apierrors.NewForbidden(corev1.Resource("nodes"), "", errors.New("cannot list nodes"))
+}
+
+func (f *fakePodManager) ImagePullState(ctx context.Context, runnerID string) string {
+	return ImagePullWarm
+}
+
+func (f *fakePodManager) RunnerUsage(ctx context.Context, runnerID string) (*RunnerUsage, error) {
+	return nil, errors.New("metrics unavailable")
+}
+
+func (f *fakePodManager) RecordPodEvent(pod *corev1.Pod, eventType, reason, messageFmt string, args ...interface{}) {
+}
+
+func TestCreateRunnerRollsBack(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	pods := newFakePodManager()
+	svc := newFakeRunnerService(pods)
+	defer svc.Stop()
+
+	// The pod is created but can't be read back, so its ID never reaches the caller
+	pods.getErr = errors.New("connection refused")
+	if _, err := svc.CreateRunner(context.Background(), &CreateRunnerRequest{}); !errors.Is(err, ErrKubernetesAPI) {
+		t.Fatalf("Expected ErrKubernetesAPI, got %v", err)
+	}
+	if len(pods.pods) != 0 {
+		t.Errorf("Expected the pod to be deleted, got %d pods", len(pods.pods))
+	}
+	if len(pods.finalizersRemoved) != 1 || pods.finalizersRemoved[0] != BuildPodDeletionRequest("runner-1", pods.config).PodName {
+		t.Errorf("Expected the finalizer of runner-1 to be removed, got %v", pods.finalizersRemoved)
+	}
+	if count := monitorCount(svc); count != 0 {
+		t.Errorf("Expected no monitor for the rolled back runner, got %d", count)
+	}
+
+	// Nothing to roll back when the pod isn't created
+	pods.getErr = nil
+	pods.createErr = errors.New("admission webhook denied the request")
+	if _, err := svc.CreateRunner(context.Background(), &CreateRunnerRequest{}); !errors.Is(err, ErrKubernetesAPI) {
+		t.Fatalf("Expected ErrKubernetesAPI, got %v", err)
+	}
+	if len(pods.finalizersRemoved) != 1 || monitorCount(svc) != 0 {
+		t.Errorf("Expected nothing to be rolled back, got finalizers %v and %d monitors", pods.finalizersRemoved, monitorCount(svc))
+	}
+}
+
+func TestDeleteRunnerNotFound(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	pods := newFakePodManager()
+	svc := newFakeRunnerService(pods)
+	defer svc.Stop()
+
+	if _, err := svc.DeleteRunner(ctx, "runner-1", false); !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("Expected ErrRunnerNotFound, got %v", err)
+	}
+
+	// A pod deleted by someone else in the meantime still gets its finalizer removed
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	pods.deleteErr = pods.notFound(runner.ID)
+	cleanup, err := svc.DeleteRunner(ctx, runner.ID, false)
+	if err != nil {
+		t.Fatalf("Expected the deletion to succeed, got %v", err)
+	}
+	if len(cleanup.Deleted) != 1 || len(pods.finalizersRemoved) != 1 {
+		t.Errorf("Expected the pod to be reported deleted, got %+v and finalizers %v", cleanup.Deleted, pods.finalizersRemoved)
+	}
+	if count := monitorCount(svc); count != 0 {
+		t.Errorf("Expected the monitor to be stopped, got %d", count)
+	}
+
+	// Any other failure is the API's
+	pods.deleteErr = errors.New("etcdserver: request timed out")
+	if _, err := svc.DeleteRunner(ctx, runner.ID, false); !errors.Is(err, ErrKubernetesAPI) {
+		t.Errorf("Expected ErrKubernetesAPI, got %v", err)
+	}
+}
+
+func TestMonitorTransitions(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	pods := newFakePodManager()
+	svc := newFakeRunnerService(pods)
+	defer svc.Stop()
+
+	pending, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	failed, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if pending.Status != RunnerStatusCreating {
+		t.Errorf("Expected a new runner to be creating, got %s", pending.Status)
+	}
+
+	// Still pending after a few polls
+	time.Sleep(5 * svc.monitorInterval)
+	if count := monitorCount(svc); count != 2 {
+		t.Fatalf("Expected both runners to be monitored, got %d", count)
+	}
+
+	pods.setPhase(pending.ID, corev1.PodRunning)
+	pods.setPhase(failed.ID, corev1.PodFailed)
+	waitForMonitors(t, svc)
+
+	running, err := svc.GetRunner(ctx, pending.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if running.Status != RunnerStatusRunning || running.ProvisioningDuration <= 0 {
+		t.Errorf("Expected a running runner with its provisioning duration, got %s after %v", running.Status, running.ProvisioningDuration)
+	}
+	errored, err := svc.GetRunner(ctx, failed.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if errored.Status != RunnerStatusError || errored.ProvisioningDuration != 0 {
+		t.Errorf("Expected a failed runner without a provisioning duration, got %s after %v", errored.Status, errored.ProvisioningDuration)
+	}
+}
+
+func TestListRunnersPagination(t *testing.T) {
+	ctx := context.Background()
+	pods := newFakePodManager()
+	svc := newFakeRunnerService(pods)
+	defer svc.Stop()
+
+	for i := 1; i <= 5; i++ {
+		if err := pods.CreateRunnerPod(ctx, &Runner{ID: fmt.Sprintf("runner-%d", i)}); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		offset, limit int32
+		expected      []string
+	}{
+		{0, 2, []string{"runner-1", "runner-2"}},
+		{2, 2, []string{"runner-3", "runner-4"}},
+		{4, 2, []string{"runner-5"}},
+		{5, 2, nil},
+		{1, 0, []string{"runner-2", "runner-3", "runner-4", "runner-5"}},
+	} {
+		runners, total, err := svc.ListRunners(ctx, &ListOptions{Offset: tc.offset, Limit: tc.limit})
+		if err != nil {
+			t.Fatalf("ListRunners failed: %v", err)
+		}
+		var ids []string
+		for _, runner := range runners {
+			ids = append(ids, runner.ID)
+		}
+		if total != 5 || fmt.Sprint(ids) != fmt.Sprint(tc.expected) {
+			t.Errorf("Offset %d, limit %d: expected %v of 5, got %v of %d", tc.offset, tc.limit, tc.expected, ids, total)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -9,6 +10,22 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// ExtraCAMountPath is where the extra CA ConfigMap is mounted in the runner container
+	ExtraCAMountPath = "/etc/grad/ca-certificates"
+	// ExtraCADirEnv points the runner entrypoint at the extra CA certificates
+	ExtraCADirEnv = "GRAD_EXTRA_CA_DIR"
+	// systemCABundle is the trust bundle rebuilt by the runner entrypoint to include the extra CAs
+	systemCABundle = "/etc/ssl/certs/ca-certificates.crt"
+
+	// RunnerContainerName is the main container of a runner pod, where commands run
+	RunnerContainerName = "runner"
+	// S3FSContainerName is the sidecar mounting the S3 workspace, present only when one is configured
+	S3FSContainerName = "s3fs-sidecar"
+	// datasetMountPath is where the S3 workspace is mounted
+	datasetMountPath = "/workspace/dataset"
+)
+
 // PodCreationRequest represents a request to create a pod
 type PodCreationRequest struct {
 	PodName       string
@@ -17,11 +34,60 @@ type PodCreationRequest struct {
 	RunnerName    string
 	Image         string
 	S3FSImage     string
-	CPURequest    string
-	MemoryRequest string
+	CPURequest    resource.Quantity
+	MemoryRequest resource.Quantity
 	SSHPort       int32
 	Env           map[string]string
 	Workspace     *WorkspaceConfig
+	// S3FS* set the sidecar's resources, used only when the pod has an S3 workspace
+	S3FSCPURequest    resource.Quantity
+	S3FSMemoryRequest resource.Quantity
+	S3FSCPULimit      resource.Quantity
+	S3FSMemoryLimit   resource.Quantity
+	// BootstrapCommand runs once after the pod becomes ready (optional)
+	BootstrapCommand string
+	// ProxyURL and NoProxy are exported to the runner container when set
+	ProxyURL string
+	NoProxy  string
+	// ExtraCAConfigMap is mounted at ExtraCAMountPath when set
+	ExtraCAConfigMap string
+	// NetworkPolicy names the policy covering the pod, recorded for display
+	NetworkPolicy string
+	// Hostname and Subdomain give the pod the stable name DNSName through the headless runner Service (optional)
+	Hostname  string
+	Subdomain string
+	DNSName   string
+	// DefaultShell and DefaultWorkdir are recorded for the exec path (optional)
+	DefaultShell   string
+	DefaultWorkdir string
+	// Description is the runner's free-form note (optional)
+	Description string
+	// ReservedUntil is stored in RunnerReservedUntilAnnotation unless zero
+	ReservedUntil time.Time
+	// Protected sets RunnerProtectedAnnotation
+	Protected bool
+	// Labels are added to the pod's own labels, which take precedence (optional)
+	Labels map[string]string
+	// Group is set as the RunnerGroupLabel label (optional)
+	Group string
+	// AutoRestart sets the RunnerAutoRestartLabel label
+	AutoRestart bool
+	// DNSPolicy and DNSConfig come from the server config; HostAliases also from the request
+	DNSPolicy   string
+	DNSConfig   *DNSConfig
+	HostAliases []HostAlias
+	// ServiceAccountName is empty for the namespace default ServiceAccount
+	ServiceAccountName           string
+	AutomountServiceAccountToken bool
+	// PriorityClassName is empty for the cluster's default priority
+	PriorityClassName string
+	// Arch pins the pod to nodes of that CPU architecture (optional)
+	Arch string
+	// Spot sets the RunnerSpotLabel label; SpotTolerations and SpotNodeSelector come from the
+	// server config and place the pod on spot nodes
+	Spot             bool
+	SpotTolerations  []corev1.Toleration
+	SpotNodeSelector map[string]string
 }
 
 // PodDeletionRequest represents a request to delete a pod
@@ -31,26 +97,88 @@ type PodDeletionRequest struct {
 	RunnerID  string
 }
 
-// BuildPodCreationRequest creates a pod creation request from a runner
-func BuildPodCreationRequest(runner *Runner, config *KubernetesConfig) *PodCreationRequest {
+// BuildPodCreationRequest creates a pod creation request from a runner. It fails with
+// ErrInvalidConfig or ErrInvalidRequest if a resource quantity of the config or of the runner's
+// workspace doesn't parse, which LoadConfig and request validation normally catch first.
+func BuildPodCreationRequest(runner *Runner, config *KubernetesConfig) (*PodCreationRequest, error) {
 	podName := fmt.Sprintf("grad-runner-%s", runner.ID)
+	images := config.Images()
+
+	req := &PodCreationRequest{
+		PodName:           podName,
+		Namespace:         config.Namespace,
+		RunnerID:          runner.ID,
+		RunnerName:        runner.Name,
+		Image:             images.Runner,
+		S3FSImage:         images.S3FS,
+		SSHPort:           config.SSHPort,
+		Env:               runner.Env,
+		Workspace:         runner.Workspace,
+		BootstrapCommand:  runner.BootstrapCommand,
+		DefaultShell:      runner.DefaultShell,
+		DefaultWorkdir:    runner.DefaultWorkdir,
+		Description:       runner.Description,
+		ReservedUntil:     runner.ReservedUntil,
+		Protected:         runner.Protected,
+		Labels:            runner.Labels,
+		Group:             runner.Group,
+		AutoRestart:       runner.AutoRestart,
+		DNSPolicy:         config.DNSPolicy,
+		DNSConfig:         config.DNSConfig,
+		HostAliases:       append(slices.Clone(config.HostAliases), runner.HostAliases...),
+		PriorityClassName: runner.PriorityClassName,
+		Arch:              runner.Arch,
+	}
+
+	// Air-gapped servers never add the s3fs sidecar, also not to runners recreated from before
+	// S3 workspaces were disabled
+	if config.DisabledFeatures.Disabled(FeatureS3Workspace) {
+		req.Workspace = nil
+		req.S3FSImage = ""
+	}
+
+	if err := req.setResources(req.Workspace, config); err != nil {
+		return nil, err
+	}
+
+	if config.NetworkPolicy != nil {
+		req.NetworkPolicy = config.NetworkPolicy.policyName(runner.ID)
+	}
+
+	if runner.Spot && config.Spot != nil {
+		req.Spot = true
+		req.SpotTolerations = config.Spot.Tolerations
+		req.SpotNodeSelector = config.Spot.NodeSelector
+	}
+
+	if config.StableDNS.enabled() {
+		req.Hostname = runner.ID
+		req.Subdomain = RunnerServiceName
+		req.DNSName = config.StableDNS.dnsName(config.Namespace, runner.ID)
+	}
 
-	// Use hardcoded "small" preset configuration: 2c2g40g
-	return &PodCreationRequest{
-		PodName:    podName,
-		Namespace:  config.Namespace,
-		RunnerID:   runner.ID,
-		RunnerName: runner.Name,
-		Image:      config.RunnerImage,
-		S3FSImage:  config.S3FSImage,
-		// Small preset: 2000m (2 cores)
-		CPURequest: config.DefaultCPU,
-		// Small preset: 2Gi
-		MemoryRequest: config.DefaultMemory,
-		SSHPort:       config.SSHPort,
-		Env:           runner.Env,
-		Workspace:     runner.Workspace,
+	// A runner asking for its own ServiceAccount needs its token; others follow the server default
+	if config.ServiceAccount != nil {
+		req.ServiceAccountName = config.ServiceAccount.Name
+		req.AutomountServiceAccountToken = config.ServiceAccount.AutomountToken
+	}
+	if runner.ServiceAccount != "" {
+		req.ServiceAccountName = runner.ServiceAccount
+		req.AutomountServiceAccountToken = true
+	}
+	if runner.DisableServiceAccountToken {
+		req.AutomountServiceAccountToken = false
+	}
+
+	if config.Proxy != nil {
+		if !runner.DisableProxy {
+			req.ProxyURL = config.Proxy.URL
+			req.NoProxy = config.Proxy.NoProxy
+		}
+		req.ExtraCAConfigMap = config.Proxy.ExtraCAConfigMap
 	}
+
+	return req, nil
 }
 
 // BuildPodDeletionRequest creates a pod deletion request from a runner ID
@@ -86,200 +214,447 @@ func (req *PodCreationRequest) ToPodSpec() *corev1.Pod {
 		})
 	}
 
-	// Build environment variables for S3FS sidecar
-	s3fsEnv := []corev1.EnvVar{
+	// Proxy and CA settings come after the user's variables, so a request can override them
+	mainEnv = append(mainEnv, req.proxyEnv()...)
+
+	// Grad-owned variables come first, so they win over user-supplied duplicates
+	mainEnv = dedupeEnv(mainEnv)
+
+	annotations := map[string]string{
+		"grad.io/runner-id":   req.RunnerID,
+		"grad.io/runner-name": req.RunnerName,
+		"grad.io/status":      "creating",
+		"grad.io/created-at":  time.Now().Format(time.RFC3339),
+	}
+
+	// Record the bootstrap command so the status monitor can pick it up
+	if req.BootstrapCommand != "" {
+		annotations[RunnerBootstrapCommandAnnotation] = req.BootstrapCommand
+		annotations[RunnerBootstrapStatusAnnotation] = BootstrapStatusPending
+	}
+
+	if req.NetworkPolicy != "" {
+		annotations[RunnerNetworkPolicyAnnotation] = req.NetworkPolicy
+	}
+	if req.DNSName != "" {
+		annotations[RunnerDNSNameAnnotation] = req.DNSName
+	}
+
+	if req.DefaultShell != "" {
+		annotations[RunnerDefaultShellAnnotation] = req.DefaultShell
+	}
+	if req.DefaultWorkdir != "" {
+		annotations[RunnerDefaultWorkdirAnnotation] = req.DefaultWorkdir
+	}
+	if req.Description != "" {
+		annotations[RunnerDescriptionAnnotation] = encodeAnnotationText(req.Description)
+	}
+	if !req.ReservedUntil.IsZero() {
+		annotations[RunnerReservedUntilAnnotation] = encodeReservation(req.ReservedUntil)
+	}
+	if req.Protected {
+		annotations[RunnerProtectedAnnotation] = encodeProtected(req.Protected)
+	}
+
+	var volumes []corev1.Volume
+	var mainMounts []corev1.VolumeMount
+
+	// Without an S3 workspace there's no sidecar, so no shared volume or privileges are needed
+	s3Workspace := req.hasS3Workspace()
+	if s3Workspace {
+		volumes = append(volumes, corev1.Volume{
+			Name: "workspace",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+		mainMounts = append(mainMounts, req.workspaceMount())
+	}
+
+	// Mount extra CA certificates for the runner entrypoint to add to the trust store
+	if req.ExtraCAConfigMap != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: "extra-ca",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: req.ExtraCAConfigMap},
+				},
+			},
+		})
+		mainMounts = append(mainMounts, corev1.VolumeMount{
+			Name:      "extra-ca",
+			MountPath: ExtraCAMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	containers := []corev1.Container{
 		{
-			Name:  "RUNNER_ID",
-			Value: req.RunnerID,
+			Name:  RunnerContainerName,
+			Image: req.Image,
+			Ports: []corev1.ContainerPort{
+				{
+					ContainerPort: req.SSHPort,
+					Name:          "ssh",
+					Protocol:      corev1.ProtocolTCP,
+				},
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    req.CPURequest,
+					corev1.ResourceMemory: req.MemoryRequest,
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    req.CPURequest,
+					corev1.ResourceMemory: req.MemoryRequest,
+				},
+			},
+			Env:          mainEnv,
+			VolumeMounts: mainMounts,
+			Command:      []string{"/usr/local/bin/entrypoint.sh"},
+			Args:         []string{"sleep", "infinity"},
 		},
-		{
-			Name:  "RUNNER_NAME",
-			Value: req.RunnerName,
+	}
+	if s3Workspace {
+		// Bidirectional mount propagation requires a privileged container
+		if !req.Workspace.ReadOnly {
+			containers[0].SecurityContext = &corev1.SecurityContext{
+				Privileged: &[]bool{true}[0],
+			}
+		}
+		// The sidecar goes first so the mount is set up before the runner starts
+		containers = append([]corev1.Container{req.s3fsContainer()}, containers...)
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        req.PodName,
+			Namespace:   req.Namespace,
+			Labels:      req.podLabels(),
+			Annotations: annotations,
+			Finalizers: []string{
+				"grad.io/runner-finalizer",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                 corev1.RestartPolicyAlways,
+			ServiceAccountName:            req.ServiceAccountName,
+			AutomountServiceAccountToken:  &[]bool{req.AutomountServiceAccountToken}[0],
+			ShareProcessNamespace:         &[]bool{s3Workspace}[0],
+			Volumes:                       volumes,
+			TerminationGracePeriodSeconds: &[]int64{3}[0],
+			Containers:                    containers,
+			DNSPolicy:                     corev1.DNSPolicy(req.DNSPolicy),
+			DNSConfig:                     toPodDNSConfig(req.DNSConfig),
+			HostAliases:                   toPodHostAliases(req.HostAliases),
+			Hostname:                      req.Hostname,
+			Subdomain:                     req.Subdomain,
+			PriorityClassName:             req.PriorityClassName,
+			NodeSelector:                  runnerNodeSelector(req.Arch, req.SpotNodeSelector),
+			Tolerations:                   slices.Clone(req.SpotTolerations),
 		},
 	}
+}
+
+// runnerPodLabels returns the labels grad puts on every pod of a runner (pure function)
+func runnerPodLabels(runnerID string) map[string]string {
+	return map[string]string{
+		"app":                          "grad-runner",
+		"app.kubernetes.io/managed-by": "grad",
+		"app.kubernetes.io/component":  "runner",
+		"app.kubernetes.io/name":       "grad-runner",
+		"app.kubernetes.io/instance":   runnerID,
+		"type":                         "runner",
+		"runner-id":                    runnerID,
+	}
+}
+
+// podLabels merges the request's extra labels with grad's own (pure function)
+func (req *PodCreationRequest) podLabels() map[string]string {
+	labels := make(map[string]string, len(req.Labels))
+	for key, value := range req.Labels {
+		labels[key] = value
+	}
+	for key, value := range runnerPodLabels(req.RunnerID) {
+		labels[key] = value
+	}
+	if req.Group != "" {
+		labels[RunnerGroupLabel] = req.Group
+	}
+	if req.AutoRestart {
+		labels[RunnerAutoRestartLabel] = "true"
+	}
+	if req.Spot {
+		labels[RunnerSpotLabel] = "true"
+	}
+	return labels
+}
+
+// hasS3Workspace reports whether the pod mounts an S3 workspace through the sidecar
+func (req *PodCreationRequest) hasS3Workspace() bool {
+	return req.Workspace != nil && req.Workspace.Bucket != ""
+}
+
+// workspaceMount mounts the volume the sidecar mounts the S3 workspace into in the runner
+// container. A read-only workspace is mounted read-only, so writes fail instead of landing in
+// the volume under the s3fs mount, and only receives the sidecar's mount, which doesn't need a
+// privileged runner container.
+func (req *PodCreationRequest) workspaceMount() corev1.VolumeMount {
+	mount := corev1.VolumeMount{
+		Name:             "workspace",
+		MountPath:        datasetMountPath,
+		MountPropagation: &[]corev1.MountPropagationMode{corev1.MountPropagationBidirectional}[0],
+	}
+	if req.Workspace.ReadOnly {
+		mount.ReadOnly = true
+		mount.MountPropagation = &[]corev1.MountPropagationMode{corev1.MountPropagationHostToContainer}[0]
+	}
+	return mount
+}
+
+// podWorkspaceMode reads how the S3 workspace is mounted into the runner container of pod,
+// empty if it has none (pure function)
+func podWorkspaceMode(pod *corev1.Pod) WorkspaceMode {
+	sidecar := findContainer(pod, S3FSContainerName)
+	runnerContainer := findContainer(pod, RunnerContainerName)
+	if sidecar == nil || runnerContainer == nil {
+		return ""
+	}
+	for _, mount := range runnerContainer.VolumeMounts {
+		if mount.MountPath == datasetMountPath && mount.ReadOnly {
+			return WorkspaceModeReadOnly
+		}
+	}
+	for _, env := range sidecar.Env {
+		if env.Name == "MOUNT_OPTIONS" && env.Value == "ro" {
+			return WorkspaceModeReadOnlyUnenforced
+		}
+	}
+	return WorkspaceModeReadWrite
+}
+
+// s3fsContainer builds the sidecar that mounts the S3 workspace into the shared volume
+func (req *PodCreationRequest) s3fsContainer() corev1.Container {
+	env := []corev1.EnvVar{
+		{Name: "RUNNER_ID", Value: req.RunnerID},
+		{Name: "RUNNER_NAME", Value: req.RunnerName},
+		{Name: "S3_BUCKET", Value: req.Workspace.Bucket},
+	}
+	if req.Workspace.Endpoint != "" {
+		env = append(env, corev1.EnvVar{Name: "S3_ENDPOINT", Value: req.Workspace.Endpoint})
+	}
+	if req.Workspace.Prefix != "" {
+		env = append(env, corev1.EnvVar{Name: "S3_PREFIX", Value: req.Workspace.Prefix})
+	}
+	if req.Workspace.Region != "" {
+		env = append(env, corev1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: req.Workspace.Region})
+	}
+	env = append(env, corev1.EnvVar{Name: "MOUNT_PATH", Value: datasetMountPath})
+	if req.Workspace.ReadOnly {
+		env = append(env, corev1.EnvVar{Name: "MOUNT_OPTIONS", Value: "ro"})
+	}
 
-	// Add AWS credentials from custom environment variables first
+	// AWS credentials come from the user's env, after grad-owned values
 	for key, value := range req.Env {
 		if key == "AWS_ACCESS_KEY_ID" || key == "AWS_SECRET_ACCESS_KEY" || key == "AWS_SESSION_TOKEN" {
-			s3fsEnv = append(s3fsEnv, corev1.EnvVar{
-				Name:  key,
-				Value: value,
-			})
+			env = append(env, corev1.EnvVar{Name: key, Value: value})
 		}
 	}
 
-	// Add workspace S3 configuration if present
-	if req.Workspace != nil && req.Workspace.Bucket != "" {
-		s3fsEnv = append(s3fsEnv, corev1.EnvVar{
-			Name:  "S3_BUCKET",
-			Value: req.Workspace.Bucket,
-		})
+	return corev1.Container{
+		Name:  S3FSContainerName,
+		Image: req.S3FSImage,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    req.S3FSCPURequest,
+				corev1.ResourceMemory: req.S3FSMemoryRequest,
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    req.S3FSCPULimit,
+				corev1.ResourceMemory: req.S3FSMemoryLimit,
+			},
+		},
+		Env: dedupeEnv(env),
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:             "workspace",
+				MountPath:        datasetMountPath,
+				MountPropagation: &[]corev1.MountPropagationMode{corev1.MountPropagationBidirectional}[0],
+			},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: &[]bool{true}[0],
+			Capabilities: &corev1.Capabilities{
+				Add: []corev1.Capability{"SYS_ADMIN"},
+			},
+		},
+	}
+}
 
-		if req.Workspace.Endpoint != "" {
-			s3fsEnv = append(s3fsEnv, corev1.EnvVar{
-				Name:  "S3_ENDPOINT",
-				Value: req.Workspace.Endpoint,
-			})
+// parsePodQuantity parses the Kubernetes quantity of a container resource, which must be
+// positive (pure function)
+func parsePodQuantity(name, value string) (resource.Quantity, error) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("invalid %s %q: not a Kubernetes quantity (e.g. 500m, 256Mi)", name, value)
+	}
+	if quantity.Sign() <= 0 {
+		return resource.Quantity{}, fmt.Errorf("%s must be positive, got %q", name, value)
+	}
+	return quantity, nil
+}
+
+// setResources parses the runner container's resources from config and the sidecar's from
+// config and the workspace's overrides. An override sets both request and limit, so the
+// sidecar can't be OOM-killed below it. Empty sidecar settings fall back to the defaults.
+func (req *PodCreationRequest) setResources(workspace *WorkspaceConfig, config *KubernetesConfig) error {
+	sidecar, defaults := config.sidecar(), DefaultSidecarConfig()
+	for _, setting := range []struct {
+		target          *resource.Quantity
+		name            string
+		value, fallback string
+	}{
+		{&req.CPURequest, "runner CPU", config.DefaultCPU, ""},
+		{&req.MemoryRequest, "runner memory", config.DefaultMemory, ""},
+		{&req.S3FSCPURequest, "s3fs CPU request", sidecar.CPURequest, defaults.CPURequest},
+		{&req.S3FSMemoryRequest, "s3fs memory request", sidecar.MemoryRequest, defaults.MemoryRequest},
+		{&req.S3FSCPULimit, "s3fs CPU limit", sidecar.CPULimit, defaults.CPULimit},
+		{&req.S3FSMemoryLimit, "s3fs memory limit", sidecar.MemoryLimit, defaults.MemoryLimit},
+	} {
+		value := setting.value
+		if value == "" {
+			value = setting.fallback
+		}
+		quantity, err := parsePodQuantity(setting.name, value)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
 		}
+		*setting.target = quantity
+	}
 
-		if req.Workspace.Prefix != "" {
-			s3fsEnv = append(s3fsEnv, corev1.EnvVar{
-				Name:  "S3_PREFIX",
-				Value: req.Workspace.Prefix,
-			})
+	if workspace == nil {
+		return nil
+	}
+	for _, override := range []struct {
+		request, limit *resource.Quantity
+		name, value    string
+	}{
+		{&req.S3FSCPURequest, &req.S3FSCPULimit, "s3fs_cpu", workspace.S3FSCPU},
+		{&req.S3FSMemoryRequest, &req.S3FSMemoryLimit, "s3fs_memory", workspace.S3FSMemory},
+	} {
+		if override.value == "" {
+			continue
+		}
+		quantity, err := parsePodQuantity(override.name, override.value)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
 		}
+		*override.request, *override.limit = quantity, quantity.DeepCopy()
+	}
+	return nil
+}
 
-		if req.Workspace.Region != "" {
-			s3fsEnv = append(s3fsEnv, corev1.EnvVar{
-				Name:  "AWS_DEFAULT_REGION",
-				Value: req.Workspace.Region,
-			})
+// validateSidecarResources checks a workspace's sidecar resource overrides parse and stay within the server max (pure function)
+func validateSidecarResources(workspace *WorkspaceConfig, config *SidecarConfig) error {
+	if workspace == nil {
+		return nil
+	}
+
+	for _, override := range []struct {
+		name, value, max string
+	}{
+		{"s3fs_cpu", workspace.S3FSCPU, config.MaxCPU},
+		{"s3fs_memory", workspace.S3FSMemory, config.MaxMemory},
+	} {
+		if override.value == "" {
+			continue
+		}
+		quantity, err := parsePodQuantity(override.name, override.value)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		if override.max == "" {
+			continue
+		}
+		if max, err := resource.ParseQuantity(override.max); err == nil && quantity.Cmp(max) > 0 {
+			return fmt.Errorf("%w: %s %q exceeds the server maximum %q", ErrInvalidRequest, override.name, override.value, override.max)
 		}
+	}
 
-		// Always use hardcoded mount path
-		s3fsEnv = append(s3fsEnv, corev1.EnvVar{
-			Name:  "MOUNT_PATH",
-			Value: "/workspace/dataset",
-		})
+	return nil
+}
 
-		// Set read-only flag
-		if req.Workspace.ReadOnly {
-			s3fsEnv = append(s3fsEnv, corev1.EnvVar{
-				Name:  "MOUNT_OPTIONS",
-				Value: "ro",
-			})
+// findContainer returns the named container of a pod, or nil (pure function)
+func findContainer(pod *corev1.Pod, name string) *corev1.Container {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i]
 		}
 	}
+	return nil
+}
 
-	// Always use hardcoded mount path
-	mountPath := "/workspace/dataset"
+// proxyEnv returns the proxy and CA trust env vars for the runner container (pure function)
+func (req *PodCreationRequest) proxyEnv() []corev1.EnvVar {
+	var env []corev1.EnvVar
 
-	// Create shared volume for workspace
-	workspaceVolume := corev1.Volume{
-		Name: "workspace",
-		VolumeSource: corev1.VolumeSource{
-			EmptyDir: &corev1.EmptyDirVolumeSource{},
-		},
+	// Tools disagree on upper or lower case, so set both
+	if req.ProxyURL != "" {
+		for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+			env = append(env, corev1.EnvVar{Name: name, Value: req.ProxyURL})
+		}
+	}
+	if req.NoProxy != "" {
+		for _, name := range []string{"NO_PROXY", "no_proxy"} {
+			env = append(env, corev1.EnvVar{Name: name, Value: req.NoProxy})
+		}
 	}
 
-	return &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      req.PodName,
-			Namespace: req.Namespace,
-			Labels: map[string]string{
-				"app":                          "grad-runner",
-				"app.kubernetes.io/managed-by": "grad",
-				"app.kubernetes.io/component":  "runner",
-				"app.kubernetes.io/name":       "grad-runner",
-				"app.kubernetes.io/instance":   req.RunnerID,
-				"type":                         "runner",
-				"runner-id":                    req.RunnerID,
-			},
-			Annotations: map[string]string{
-				"grad.io/runner-id":   req.RunnerID,
-				"grad.io/runner-name": req.RunnerName,
-				"grad.io/status":      "creating",
-				"grad.io/created-at":  time.Now().Format(time.RFC3339),
-			},
-			Finalizers: []string{
-				"grad.io/runner-finalizer",
-			},
-		},
-		Spec: corev1.PodSpec{
-			RestartPolicy:                  corev1.RestartPolicyAlways,
-			ShareProcessNamespace:          &[]bool{true}[0],
-			Volumes:                        []corev1.Volume{workspaceVolume},
-			TerminationGracePeriodSeconds:  &[]int64{3}[0],
-			// Regular containers - S3FS sidecar and main runner
-			Containers: []corev1.Container{
-				// S3FS sidecar container
-				{
-					Name:  "s3fs-sidecar",
-					Image: req.S3FSImage,
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse("50m"),
-							corev1.ResourceMemory: resource.MustParse("64Mi"),
-						},
-						Limits: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse("100m"),
-							corev1.ResourceMemory: resource.MustParse("128Mi"),
-						},
-					},
-					Env: s3fsEnv,
-					VolumeMounts: []corev1.VolumeMount{
-						{
-							Name:             "workspace",
-							MountPath:        mountPath,
-							MountPropagation: &[]corev1.MountPropagationMode{corev1.MountPropagationBidirectional}[0],
-						},
-					},
-					SecurityContext: &corev1.SecurityContext{
-						Privileged: &[]bool{true}[0],
-						Capabilities: &corev1.Capabilities{
-							Add: []corev1.Capability{"SYS_ADMIN"},
-						},
-					},
-				},
-				// Main runner container
-				{
-					Name:  "runner",
-					Image: req.Image,
-					Ports: []corev1.ContainerPort{
-						{
-							ContainerPort: req.SSHPort,
-							Name:          "ssh",
-							Protocol:      corev1.ProtocolTCP,
-						},
-					},
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse(req.CPURequest),
-							corev1.ResourceMemory: resource.MustParse(req.MemoryRequest),
-						},
-						Limits: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse(req.CPURequest),
-							corev1.ResourceMemory: resource.MustParse(req.MemoryRequest),
-						},
-					},
-					Env: mainEnv,
-					VolumeMounts: []corev1.VolumeMount{
-						{
-							Name:             "workspace",
-							MountPath:        mountPath,
-							MountPropagation: &[]corev1.MountPropagationMode{corev1.MountPropagationBidirectional}[0],
-						},
-					},
-					Command: []string{"/usr/local/bin/entrypoint.sh"},
-					Args:    []string{"sleep", "infinity"},
-					SecurityContext: &corev1.SecurityContext{
-						Privileged: &[]bool{true}[0],
-					},
-				},
-			},
-		},
+	// pip and requests ship their own CA bundle, so point them at the system one
+	if req.ExtraCAConfigMap != "" {
+		env = append(env,
+			corev1.EnvVar{Name: ExtraCADirEnv, Value: ExtraCAMountPath},
+			corev1.EnvVar{Name: "REQUESTS_CA_BUNDLE", Value: systemCABundle},
+			corev1.EnvVar{Name: "PIP_CERT", Value: systemCABundle},
+		)
 	}
+
+	return env
 }
 
-// MapPodStatusToRunnerStatus maps Kubernetes pod status to runner status (pure function)
-func MapPodStatusToRunnerStatus(pod *corev1.Pod) RunnerStatus {
-	switch pod.Status.Phase {
-	case corev1.PodPending:
-		return RunnerStatusCreating
-	case corev1.PodRunning:
-		// Check if all containers are ready
-		for _, condition := range pod.Status.Conditions {
-			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
-				return RunnerStatusRunning
-			}
+// dedupeEnv drops later env vars whose name already appeared (pure function)
+func dedupeEnv(env []corev1.EnvVar) []corev1.EnvVar {
+	seen := make(map[string]bool, len(env))
+	deduped := make([]corev1.EnvVar, 0, len(env))
+	for _, envVar := range env {
+		if seen[envVar.Name] {
+			continue
 		}
-		return RunnerStatusCreating
-	case corev1.PodSucceeded:
-		return RunnerStatusStopped
-	case corev1.PodFailed:
+		seen[envVar.Name] = true
+		deduped = append(deduped, envVar)
+	}
+	return deduped
+}
+
+// MapPodStatusToRunnerStatus maps Kubernetes pod status to runner status through the runner's
+// conditions, so the two always agree (pure function)
+func MapPodStatusToRunnerStatus(pod *corev1.Pod) RunnerStatus {
+	return runnerStatusFromConditions(pod, RunnerConditions(pod))
+}
+
+// applyBootstrapStatus holds a ready runner in bootstrapping until its bootstrap command succeeds (pure function)
+func applyBootstrapStatus(status RunnerStatus, bootstrapStatus string) RunnerStatus {
+	if bootstrapStatus == "" || status != RunnerStatusRunning {
+		return status
+	}
+
+	switch bootstrapStatus {
+	case BootstrapStatusSucceeded:
+		return RunnerStatusRunning
+	case BootstrapStatusFailed:
 		return RunnerStatusError
 	default:
-		return RunnerStatusError
+		return RunnerStatusBootstrapping
 	}
 }
 
@@ -1,13 +1,27 @@
 package service
 
 import (
+	"errors"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// buildPodCreationRequest is BuildPodCreationRequest failing the test on error
+func buildPodCreationRequest(t *testing.T, runner *Runner, config *KubernetesConfig) *PodCreationRequest {
+	t.Helper()
+	req, err := BuildPodCreationRequest(runner, config)
+	if err != nil {
+		t.Fatalf("BuildPodCreationRequest failed: %v", err)
+	}
+	return req
+}
+
 func TestBuildPodCreationRequest(t *testing.T) {
 	config := &KubernetesConfig{
 		Namespace:      "test-namespace",
@@ -32,7 +46,7 @@ func TestBuildPodCreationRequest(t *testing.T) {
 		},
 	}
 
-	req := BuildPodCreationRequest(runner, config)
+	req := buildPodCreationRequest(t, runner, config)
 
 	// Test basic fields
 	if req.PodName != "grad-runner-test-runner-123" {
@@ -52,12 +66,12 @@ func TestBuildPodCreationRequest(t *testing.T) {
 	}
 
 	// Test preset resource configuration (ignores runner.Resources)
-	if req.CPURequest != "2000m" {
-		t.Errorf("Expected CPU request '2000m' (preset), got '%s'", req.CPURequest)
+	if req.CPURequest.Cmp(resource.MustParse("2000m")) != 0 {
+		t.Errorf("Expected CPU request '2000m' (preset), got '%s'", req.CPURequest.String())
 	}
 
-	if req.MemoryRequest != "2Gi" {
-		t.Errorf("Expected memory request '2Gi' (preset), got '%s'", req.MemoryRequest)
+	if req.MemoryRequest.Cmp(resource.MustParse("2Gi")) != 0 {
+		t.Errorf("Expected memory request '2Gi' (preset), got '%s'", req.MemoryRequest.String())
 	}
 
 	// Test environment variables
@@ -88,15 +102,37 @@ func TestBuildPodCreationRequestWithDefaults(t *testing.T) {
 		Env:       map[string]string{},
 	}
 
-	req := BuildPodCreationRequest(runner, config)
+	req := buildPodCreationRequest(t, runner, config)
 
 	// Should use preset configuration regardless of runner.Resources
-	if req.CPURequest != "2000m" {
-		t.Errorf("Expected preset CPU request '2000m', got '%s'", req.CPURequest)
+	if req.CPURequest.Cmp(resource.MustParse("2000m")) != 0 {
+		t.Errorf("Expected preset CPU request '2000m', got '%s'", req.CPURequest.String())
 	}
 
-	if req.MemoryRequest != "2Gi" {
-		t.Errorf("Expected preset memory request '2Gi', got '%s'", req.MemoryRequest)
+	if req.MemoryRequest.Cmp(resource.MustParse("2Gi")) != 0 {
+		t.Errorf("Expected preset memory request '2Gi', got '%s'", req.MemoryRequest.String())
+	}
+}
+
+func TestBuildPodCreationRequestInvalidQuantity(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	config.DefaultCPU = "two cores"
+	if _, err := BuildPodCreationRequest(&Runner{ID: "runner-1"}, config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for an unparsable default CPU, got %v", err)
+	}
+
+	config = DefaultKubernetesConfig()
+	config.Sidecar = &SidecarConfig{MemoryLimit: "0"}
+	runner := &Runner{ID: "runner-1", Workspace: &WorkspaceConfig{Bucket: "data"}}
+	if _, err := BuildPodCreationRequest(runner, config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for a zero sidecar memory limit, got %v", err)
+	}
+
+	// A crafted request that bypassed validation
+	runner.Workspace.S3FSMemory = "2Gib"
+	_, err := BuildPodCreationRequest(runner, DefaultKubernetesConfig())
+	if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), "s3fs_memory") {
+		t.Errorf("Expected ErrInvalidRequest naming s3fs_memory, got %v", err)
 	}
 }
 
@@ -108,8 +144,8 @@ func TestPodCreationRequestToPodSpec(t *testing.T) {
 		RunnerName:    "test-runner",
 		Image:         "ghcr.io/strrl/grad-runner:latest",
 		S3FSImage:     "ghcr.io/strrl/grad-s3fs:latest",
-		CPURequest:    "500m",
-		MemoryRequest: "1Gi",
+		CPURequest:    resource.MustParse("500m"),
+		MemoryRequest: resource.MustParse("1Gi"),
 		SSHPort:       22,
 		Env: map[string]string{
 			"TEST": "value",
@@ -245,6 +281,118 @@ func TestPodCreationRequestToPodSpec(t *testing.T) {
 	}
 }
 
+func TestPodCreationRequestToPodSpecReadOnlyWorkspace(t *testing.T) {
+	req := &PodCreationRequest{
+		PodName:       "test-pod",
+		Namespace:     "test-ns",
+		RunnerID:      "runner-123",
+		Image:         "ghcr.io/strrl/grad-runner:latest",
+		S3FSImage:     "ghcr.io/strrl/grad-s3fs:latest",
+		CPURequest:    resource.MustParse("500m"),
+		MemoryRequest: resource.MustParse("1Gi"),
+		SSHPort:       22,
+		Workspace:     &WorkspaceConfig{Bucket: "datasets", ReadOnly: true},
+	}
+
+	pod := req.ToPodSpec()
+	sidecar, runnerContainer := findContainer(pod, S3FSContainerName), findContainer(pod, RunnerContainerName)
+
+	// The runner only receives the sidecar's mount and can't write underneath it
+	runnerMount := runnerContainer.VolumeMounts[0]
+	if !runnerMount.ReadOnly {
+		t.Error("Expected the runner container to mount the workspace read-only")
+	}
+	if runnerMount.MountPropagation == nil || *runnerMount.MountPropagation != corev1.MountPropagationHostToContainer {
+		t.Errorf("Expected HostToContainer propagation for the runner container, got %v", runnerMount.MountPropagation)
+	}
+	if sc := runnerContainer.SecurityContext; sc != nil && sc.Privileged != nil && *sc.Privileged {
+		t.Error("Expected the runner container not to be privileged with a read-only workspace")
+	}
+
+	// The sidecar still mounts s3fs, read-only, and propagates the mount
+	sidecarMount := sidecar.VolumeMounts[0]
+	if sidecarMount.ReadOnly || sidecarMount.MountPropagation == nil || *sidecarMount.MountPropagation != corev1.MountPropagationBidirectional {
+		t.Errorf("Expected a writable, bidirectional sidecar mount, got %+v", sidecarMount)
+	}
+	if sc := sidecar.SecurityContext; sc == nil || sc.Privileged == nil || !*sc.Privileged {
+		t.Error("Expected the sidecar to stay privileged")
+	}
+	if !slices.Contains(sidecar.Env, corev1.EnvVar{Name: "MOUNT_OPTIONS", Value: "ro"}) {
+		t.Errorf("Expected MOUNT_OPTIONS=ro for s3fs, got %v", sidecar.Env)
+	}
+	if mode := podWorkspaceMode(pod); mode != WorkspaceModeReadOnly {
+		t.Errorf("Expected a read-only workspace, got %q", mode)
+	}
+
+	// A pod created before the runner's mount was made read-only only has s3fs mount read-only
+	runnerContainer.VolumeMounts[0].ReadOnly = false
+	if mode := podWorkspaceMode(pod); mode != WorkspaceModeReadOnlyUnenforced {
+		t.Errorf("Expected an unenforced read-only workspace, got %q", mode)
+	}
+
+	req.Workspace.ReadOnly = false
+	pod = req.ToPodSpec()
+	if mode := podWorkspaceMode(pod); mode != WorkspaceModeReadWrite {
+		t.Errorf("Expected a read-write workspace, got %q", mode)
+	}
+	if sc := pod.Spec.Containers[1].SecurityContext; sc == nil || sc.Privileged == nil || !*sc.Privileged {
+		t.Error("Expected the runner container to be privileged for bidirectional propagation")
+	}
+	req.Workspace = nil
+	if mode := podWorkspaceMode(req.ToPodSpec()); mode != "" {
+		t.Errorf("Expected no workspace mode without a workspace, got %q", mode)
+	}
+}
+
+func TestPodCreationRequestToPodSpecWithoutWorkspace(t *testing.T) {
+	tests := []struct {
+		name      string
+		workspace *WorkspaceConfig
+	}{
+		{"No workspace", nil},
+		{"Workspace without bucket", &WorkspaceConfig{Region: "us-east-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &PodCreationRequest{
+				PodName:       "test-pod",
+				Namespace:     "test-ns",
+				RunnerID:      "runner-123",
+				RunnerName:    "test-runner",
+				Image:         "ghcr.io/strrl/grad-runner:latest",
+				S3FSImage:     "ghcr.io/strrl/grad-s3fs:latest",
+				CPURequest:    resource.MustParse("500m"),
+				MemoryRequest: resource.MustParse("512Mi"),
+				SSHPort:       22,
+				Workspace:     tt.workspace,
+			}
+
+			pod := req.ToPodSpec()
+
+			if len(pod.Spec.Containers) != 1 {
+				t.Fatalf("Expected only the runner container, got %d containers", len(pod.Spec.Containers))
+			}
+			runnerContainer := pod.Spec.Containers[0]
+			if runnerContainer.Name != "runner" {
+				t.Errorf("Expected container name 'runner', got '%s'", runnerContainer.Name)
+			}
+			if sc := runnerContainer.SecurityContext; sc != nil && sc.Privileged != nil && *sc.Privileged {
+				t.Error("Expected runner container not to be privileged without a workspace")
+			}
+			if len(runnerContainer.VolumeMounts) != 0 {
+				t.Errorf("Expected no volume mounts, got %d", len(runnerContainer.VolumeMounts))
+			}
+			if len(pod.Spec.Volumes) != 0 {
+				t.Errorf("Expected no volumes, got %d", len(pod.Spec.Volumes))
+			}
+			if share := pod.Spec.ShareProcessNamespace; share != nil && *share {
+				t.Error("Expected process namespace not to be shared without a sidecar")
+			}
+		})
+	}
+}
+
 func TestMapPodStatusToRunnerStatus(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -430,7 +578,10 @@ func TestRunnerImageEnvironmentOverride(t *testing.T) {
 	dynamicTag := "ghcr.io/strrl/grad-runner:v1.17.1-38-g1c6517887"
 	os.Setenv("RUNNER_IMAGE", dynamicTag)
 
-	config := LoadConfig()
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
 
 	if config.Kubernetes.RunnerImage != dynamicTag {
 		t.Errorf("Expected runner image to be overridden to '%s', got '%s'", dynamicTag, config.Kubernetes.RunnerImage)
@@ -438,9 +589,416 @@ func TestRunnerImageEnvironmentOverride(t *testing.T) {
 
 	// Test default behavior when env var is not set
 	os.Unsetenv("RUNNER_IMAGE")
-	config = LoadConfig()
+	config, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
 
 	if config.Kubernetes.RunnerImage != DefaultRunnerImage {
 		t.Errorf("Expected default runner image '%s', got '%s'", DefaultRunnerImage, config.Kubernetes.RunnerImage)
 	}
 }
+
+func TestPodCreationRequestToPodSpecDedupesEnv(t *testing.T) {
+	req := &PodCreationRequest{
+		PodName:       "test-pod",
+		Namespace:     "test-ns",
+		RunnerID:      "runner-123",
+		RunnerName:    "test-runner",
+		CPURequest:    resource.MustParse("500m"),
+		MemoryRequest: resource.MustParse("1Gi"),
+		SSHPort:       22,
+		Env: map[string]string{
+			"RUNNER_ID":  "foo",
+			"MOUNT_PATH": "/tmp",
+			"S3_BUCKET":  "other-bucket",
+			"TEST":       "value",
+		},
+		Workspace: &WorkspaceConfig{
+			Bucket: "test-bucket",
+		},
+	}
+
+	pod := req.ToPodSpec()
+
+	for _, container := range pod.Spec.Containers {
+		counts := make(map[string]int)
+		values := make(map[string]string)
+		for _, env := range container.Env {
+			counts[env.Name]++
+			values[env.Name] = env.Value
+		}
+
+		for name, count := range counts {
+			if count > 1 {
+				t.Errorf("Expected env %s once in container %s, got %d", name, container.Name, count)
+			}
+		}
+
+		if values["RUNNER_ID"] != "runner-123" {
+			t.Errorf("Expected grad-owned RUNNER_ID='runner-123' in container %s, got '%s'", container.Name, values["RUNNER_ID"])
+		}
+		if container.Name != "runner" {
+			if values["MOUNT_PATH"] != "/workspace/dataset" {
+				t.Errorf("Expected grad-owned MOUNT_PATH in container %s, got '%s'", container.Name, values["MOUNT_PATH"])
+			}
+			if values["S3_BUCKET"] != "test-bucket" {
+				t.Errorf("Expected grad-owned S3_BUCKET in container %s, got '%s'", container.Name, values["S3_BUCKET"])
+			}
+		}
+	}
+}
+
+func TestPodCreationRequestToPodSpecProxy(t *testing.T) {
+	tests := []struct {
+		name         string
+		proxy        *ProxyConfig
+		disableProxy bool
+		userEnv      map[string]string
+		expectEnv    map[string]string
+		absentEnv    []string
+		expectCA     bool
+	}{
+		{
+			name:      "No proxy config",
+			proxy:     nil,
+			absentEnv: []string{"HTTP_PROXY", "NO_PROXY", ExtraCADirEnv},
+		},
+		{
+			name:      "Empty proxy config",
+			proxy:     &ProxyConfig{},
+			absentEnv: []string{"HTTP_PROXY", "NO_PROXY", ExtraCADirEnv},
+		},
+		{
+			name:  "Proxy only",
+			proxy: &ProxyConfig{URL: "http://proxy:3128", NoProxy: "localhost,.svc"},
+			expectEnv: map[string]string{
+				"HTTP_PROXY":  "http://proxy:3128",
+				"HTTPS_PROXY": "http://proxy:3128",
+				"http_proxy":  "http://proxy:3128",
+				"https_proxy": "http://proxy:3128",
+				"NO_PROXY":    "localhost,.svc",
+				"no_proxy":    "localhost,.svc",
+			},
+			absentEnv: []string{ExtraCADirEnv, "PIP_CERT"},
+		},
+		{
+			name:  "CA only",
+			proxy: &ProxyConfig{ExtraCAConfigMap: "corp-ca"},
+			expectEnv: map[string]string{
+				ExtraCADirEnv:        ExtraCAMountPath,
+				"REQUESTS_CA_BUNDLE": "/etc/ssl/certs/ca-certificates.crt",
+				"PIP_CERT":           "/etc/ssl/certs/ca-certificates.crt",
+			},
+			absentEnv: []string{"HTTP_PROXY", "NO_PROXY"},
+			expectCA:  true,
+		},
+		{
+			name:  "Proxy and CA",
+			proxy: &ProxyConfig{URL: "http://proxy:3128", NoProxy: "localhost", ExtraCAConfigMap: "corp-ca"},
+			expectEnv: map[string]string{
+				"HTTPS_PROXY": "http://proxy:3128",
+				"NO_PROXY":    "localhost",
+				ExtraCADirEnv: ExtraCAMountPath,
+			},
+			expectCA: true,
+		},
+		{
+			name:         "Proxy disabled by request",
+			proxy:        &ProxyConfig{URL: "http://proxy:3128", NoProxy: "localhost"},
+			disableProxy: true,
+			absentEnv:    []string{"HTTP_PROXY", "https_proxy", "NO_PROXY", ExtraCADirEnv},
+		},
+		{
+			name:         "Proxy disabled by request keeps CA",
+			proxy:        &ProxyConfig{URL: "http://proxy:3128", ExtraCAConfigMap: "corp-ca"},
+			disableProxy: true,
+			expectEnv:    map[string]string{ExtraCADirEnv: ExtraCAMountPath},
+			absentEnv:    []string{"HTTP_PROXY"},
+			expectCA:     true,
+		},
+		{
+			name:      "User env overrides proxy",
+			proxy:     &ProxyConfig{URL: "http://proxy:3128"},
+			userEnv:   map[string]string{"HTTPS_PROXY": "http://other:8080"},
+			expectEnv: map[string]string{"HTTP_PROXY": "http://proxy:3128", "HTTPS_PROXY": "http://other:8080"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultKubernetesConfig()
+			config.Proxy = tt.proxy
+			runner := &Runner{
+				ID:           "runner-1",
+				Name:         "test-runner",
+				Env:          tt.userEnv,
+				DisableProxy: tt.disableProxy,
+			}
+
+			pod := buildPodCreationRequest(t, runner, config).ToPodSpec()
+
+			if sidecar := findContainer(pod, S3FSContainerName); sidecar != nil {
+				t.Errorf("Expected no s3fs sidecar without a workspace, got %s", sidecar.Image)
+			}
+			main := findContainer(pod, RunnerContainerName)
+			env := make(map[string]string)
+			counts := make(map[string]int)
+			for _, envVar := range main.Env {
+				env[envVar.Name] = envVar.Value
+				counts[envVar.Name]++
+			}
+
+			for name, count := range counts {
+				if count > 1 {
+					t.Errorf("Expected env %s once, got %d", name, count)
+				}
+			}
+			for name, value := range tt.expectEnv {
+				if env[name] != value {
+					t.Errorf("Expected %s='%s', got '%s'", name, value, env[name])
+				}
+			}
+			for _, name := range tt.absentEnv {
+				if _, ok := env[name]; ok {
+					t.Errorf("Expected %s to be unset, got '%s'", name, env[name])
+				}
+			}
+			var caVolume bool
+			for _, volume := range pod.Spec.Volumes {
+				if volume.ConfigMap != nil {
+					caVolume = true
+					if volume.ConfigMap.Name != "corp-ca" {
+						t.Errorf("Expected configmap volume 'corp-ca', got '%s'", volume.ConfigMap.Name)
+					}
+				}
+			}
+			var caMount bool
+			for _, mount := range main.VolumeMounts {
+				if mount.MountPath == ExtraCAMountPath {
+					caMount = true
+					if !mount.ReadOnly {
+						t.Error("Expected CA mount to be read-only")
+					}
+				}
+			}
+			if caVolume != tt.expectCA || caMount != tt.expectCA {
+				t.Errorf("Expected CA volume and mount %v, got volume=%v mount=%v", tt.expectCA, caVolume, caMount)
+			}
+		})
+	}
+}
+
+func TestPodCreationRequestToPodSpecServiceAccount(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          *ServiceAccountConfig
+		runnerSA        string
+		disableToken    bool
+		expectedSA      string
+		expectAutomount bool
+	}{
+		{
+			name:            "No config",
+			config:          nil,
+			expectedSA:      "",
+			expectAutomount: false,
+		},
+		{
+			name:            "Configured account without token",
+			config:          &ServiceAccountConfig{Name: "grad-runner"},
+			expectedSA:      "grad-runner",
+			expectAutomount: false,
+		},
+		{
+			name:            "Configured account with token",
+			config:          &ServiceAccountConfig{Name: "grad-runner", AutomountToken: true},
+			expectedSA:      "grad-runner",
+			expectAutomount: true,
+		},
+		{
+			name:            "Token disabled per runner",
+			config:          &ServiceAccountConfig{Name: "grad-runner", AutomountToken: true},
+			disableToken:    true,
+			expectedSA:      "grad-runner",
+			expectAutomount: false,
+		},
+		{
+			name:            "Requested account gets its token",
+			config:          &ServiceAccountConfig{Name: "grad-runner"},
+			runnerSA:        "ci-deployer",
+			expectedSA:      "ci-deployer",
+			expectAutomount: true,
+		},
+		{
+			name:            "Requested account with token disabled",
+			config:          &ServiceAccountConfig{Name: "grad-runner"},
+			runnerSA:        "ci-deployer",
+			disableToken:    true,
+			expectedSA:      "ci-deployer",
+			expectAutomount: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultKubernetesConfig()
+			config.ServiceAccount = tt.config
+			runner := &Runner{
+				ID:                         "runner-1",
+				Name:                       "test-runner",
+				ServiceAccount:             tt.runnerSA,
+				DisableServiceAccountToken: tt.disableToken,
+			}
+
+			pod := buildPodCreationRequest(t, runner, config).ToPodSpec()
+
+			if pod.Spec.ServiceAccountName != tt.expectedSA {
+				t.Errorf("Expected service account '%s', got '%s'", tt.expectedSA, pod.Spec.ServiceAccountName)
+			}
+			if pod.Spec.AutomountServiceAccountToken == nil {
+				t.Fatal("Expected automountServiceAccountToken to be set explicitly")
+			}
+			if *pod.Spec.AutomountServiceAccountToken != tt.expectAutomount {
+				t.Errorf("Expected automountServiceAccountToken=%v, got %v", tt.expectAutomount, *pod.Spec.AutomountServiceAccountToken)
+			}
+		})
+	}
+}
+
+func TestPodCreationRequestToPodSpecPriorityClass(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	config.PriorityClassName = "interactive"
+
+	pod := buildPodCreationRequest(t, &Runner{ID: "runner-1", PriorityClassName: "batch"}, config).ToPodSpec()
+	if pod.Spec.PriorityClassName != "batch" {
+		t.Errorf("Expected the runner's priority class 'batch', got '%s'", pod.Spec.PriorityClassName)
+	}
+	if runner := PodToRunner(pod); runner.PriorityClassName != "batch" {
+		t.Errorf("Expected the priority class read back from the pod, got '%s'", runner.PriorityClassName)
+	}
+
+	// The server default is resolved at create time, so a runner without one has none
+	pod = buildPodCreationRequest(t, &Runner{ID: "runner-2"}, config).ToPodSpec()
+	if pod.Spec.PriorityClassName != "" {
+		t.Errorf("Expected no priority class, got '%s'", pod.Spec.PriorityClassName)
+	}
+}
+
+func TestSidecarResources(t *testing.T) {
+	tests := []struct {
+		name          string
+		sidecar       *SidecarConfig
+		workspace     *WorkspaceConfig
+		expectRequest [2]string
+		expectLimit   [2]string
+	}{
+		{
+			name:          "Defaults",
+			workspace:     &WorkspaceConfig{Bucket: "data"},
+			expectRequest: [2]string{"50m", "64Mi"},
+			expectLimit:   [2]string{"100m", "128Mi"},
+		},
+		{
+			name:          "Config override",
+			sidecar:       &SidecarConfig{CPURequest: "100m", MemoryRequest: "256Mi", CPULimit: "500m", MemoryLimit: "1Gi"},
+			workspace:     &WorkspaceConfig{Bucket: "data"},
+			expectRequest: [2]string{"100m", "256Mi"},
+			expectLimit:   [2]string{"500m", "1Gi"},
+		},
+		{
+			name:          "Per-request memory override",
+			workspace:     &WorkspaceConfig{Bucket: "data", S3FSMemory: "512Mi"},
+			expectRequest: [2]string{"50m", "512Mi"},
+			expectLimit:   [2]string{"100m", "512Mi"},
+		},
+		{
+			name:          "Per-request overrides win over config",
+			sidecar:       &SidecarConfig{CPURequest: "100m", MemoryRequest: "256Mi", CPULimit: "500m", MemoryLimit: "1Gi"},
+			workspace:     &WorkspaceConfig{Bucket: "data", S3FSCPU: "250m", S3FSMemory: "768Mi"},
+			expectRequest: [2]string{"250m", "768Mi"},
+			expectLimit:   [2]string{"250m", "768Mi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultKubernetesConfig()
+			config.Sidecar = tt.sidecar
+			runner := &Runner{ID: "runner-1", Name: "test-runner", Workspace: tt.workspace}
+
+			pod := buildPodCreationRequest(t, runner, config).ToPodSpec()
+
+			sidecar := findContainer(pod, S3FSContainerName)
+			if sidecar == nil {
+				t.Fatal("Expected an s3fs sidecar")
+			}
+			requests, limits := sidecar.Resources.Requests, sidecar.Resources.Limits
+			if requests.Cpu().String() != tt.expectRequest[0] || requests.Memory().String() != tt.expectRequest[1] {
+				t.Errorf("Expected requests %v, got [%s %s]", tt.expectRequest, requests.Cpu(), requests.Memory())
+			}
+			if limits.Cpu().String() != tt.expectLimit[0] || limits.Memory().String() != tt.expectLimit[1] {
+				t.Errorf("Expected limits %v, got [%s %s]", tt.expectLimit, limits.Cpu(), limits.Memory())
+			}
+		})
+	}
+}
+
+func TestLoadConfigSidecarResources(t *testing.T) {
+	t.Setenv("S3FS_MEMORY_LIMIT", "1Gi")
+	t.Setenv("S3FS_MAX_MEMORY", "4Gi")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	sidecar := config.Kubernetes.Sidecar
+
+	if sidecar.MemoryLimit != "1Gi" {
+		t.Errorf("Expected memory limit '1Gi', got '%s'", sidecar.MemoryLimit)
+	}
+	if sidecar.MaxMemory != "4Gi" {
+		t.Errorf("Expected max memory '4Gi', got '%s'", sidecar.MaxMemory)
+	}
+	if sidecar.CPULimit != DefaultSidecarConfig().CPULimit {
+		t.Errorf("Expected CPU limit to keep the default, got '%s'", sidecar.CPULimit)
+	}
+
+	t.Setenv("S3FS_CPU_LIMIT", "lots")
+	_, err = LoadConfig()
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || !strings.Contains(err.Error(), "S3FS_CPU_LIMIT") {
+		t.Errorf("Expected a ConfigError naming S3FS_CPU_LIMIT, got %v", err)
+	}
+}
+
+func TestValidateSidecarResources(t *testing.T) {
+	config := &SidecarConfig{MaxCPU: "1", MaxMemory: "2Gi"}
+
+	tests := []struct {
+		name        string
+		workspace   *WorkspaceConfig
+		expectError bool
+	}{
+		{"No workspace", nil, false},
+		{"No overrides", &WorkspaceConfig{Bucket: "data"}, false},
+		{"Within max", &WorkspaceConfig{Bucket: "data", S3FSCPU: "500m", S3FSMemory: "2Gi"}, false},
+		{"Unparsable memory", &WorkspaceConfig{Bucket: "data", S3FSMemory: "lots"}, true},
+		{"Unparsable CPU", &WorkspaceConfig{Bucket: "data", S3FSCPU: "1 core"}, true},
+		{"Negative memory", &WorkspaceConfig{Bucket: "data", S3FSMemory: "-1Gi"}, true},
+		{"Memory above max", &WorkspaceConfig{Bucket: "data", S3FSMemory: "3Gi"}, true},
+		{"CPU above max", &WorkspaceConfig{Bucket: "data", S3FSCPU: "1500m"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSidecarResources(tt.workspace, config)
+			if tt.expectError && !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("Expected ErrInvalidRequest, got %v", err)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PreflightCheck is the outcome of one check run before grad starts serving
+type PreflightCheck struct {
+	Name string
+	// Err is nil if the check passed
+	Err error
+	// Warning notes a problem that doesn't fail the check
+	Warning string
+}
+
+// resourcePermission is an RBAC permission grad needs in the runner namespace
+type resourcePermission struct {
+	Verb        string
+	Group       string
+	Resource    string
+	Subresource string
+}
+
+// String names the permission as "verb resource[.group][/subresource]" (pure function)
+func (p resourcePermission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	if p.Subresource != "" {
+		resource += "/" + p.Subresource
+	}
+	return p.Verb + " " + resource
+}
+
+// runnerPodPermissions lists what grad does with runner pods: create them, watch them,
+// patch their annotations and finalizers, exec into them and delete them
+var runnerPodPermissions = []resourcePermission{
+	{Verb: "create", Resource: "pods"},
+	{Verb: "get", Resource: "pods"},
+	{Verb: "list", Resource: "pods"},
+	{Verb: "patch", Resource: "pods"},
+	{Verb: "delete", Resource: "pods"},
+	{Verb: "create", Resource: "pods", Subresource: "exec"},
+}
+
+// runnerPermissions returns the permissions on runner pods, then list and delete on every kind
+// in the runner resource manifest, which deleting a runner needs (pure function)
+func runnerPermissions() []resourcePermission {
+	permissions := slices.Clone(runnerPodPermissions)
+	for _, kind := range runnerResourceKinds {
+		for _, verb := range []string{"list", "delete"} {
+			permissions = append(permissions, resourcePermission{Verb: verb, Group: kind.group, Resource: kind.resource})
+		}
+	}
+	return permissions
+}
+
+// imageReferencePattern follows the distribution reference grammar:
+// [domain[:port]/]path[/path...][:tag][@digest]
+var imageReferencePattern = regexp.MustCompile(`^` +
+	`(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+	`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+	`(?::[\w][\w.-]{0,127})?` +
+	`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?` +
+	`$`)
+
+// Preflight checks that the configured images are valid references and that grad can reach
+// the Kubernetes API with the permissions it needs in the runner namespace
+func (k *KubernetesClient) Preflight(ctx context.Context) []PreflightCheck {
+	images := k.config.Images()
+	checks := []PreflightCheck{
+		{Name: "runner image reference", Err: validateImageReference(images.Runner), Warning: MutableImageTagWarning(images.Runner)},
+		{Name: "s3fs image reference", Err: validateImageReference(images.S3FS)},
+		{Name: "kubernetes API", Err: k.CheckAPIAccess(ctx)},
+	}
+
+	for _, permission := range runnerPermissions() {
+		checks = append(checks, PreflightCheck{
+			Name: fmt.Sprintf("rbac: %s in namespace %s", permission, k.config.Namespace),
+			Err:  k.checkPermission(ctx, permission),
+		})
+	}
+
+	return checks
+}
+
+// CheckAPIAccess verifies the Kubernetes API server is reachable with grad's credentials
+func (k *KubernetesClient) CheckAPIAccess(ctx context.Context) error {
+	// Discovery doesn't take a context, so check for cancellation up front
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := k.clientset.Discovery().ServerVersion(); err != nil {
+		return fmt.Errorf("failed to reach the Kubernetes API: %w", err)
+	}
+	return nil
+}
+
+// checkPermission asks the API server whether grad has permission in the runner namespace
+func (k *KubernetesClient) checkPermission(ctx context.Context, permission resourcePermission) error {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   k.config.Namespace,
+				Verb:        permission.Verb,
+				Group:       permission.Group,
+				Resource:    permission.Resource,
+				Subresource: permission.Subresource,
+			},
+		},
+	}
+
+	result, err := k.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create access review: %w", err)
+	}
+	if !result.Status.Allowed {
+		reason := result.Status.Reason
+		if reason == "" {
+			reason = "no RBAC rule allows it"
+		}
+		return fmt.Errorf("denied: %s", reason)
+	}
+	return nil
+}
+
+// validateImageReference checks that image is a well-formed container image reference (pure function)
+func validateImageReference(image string) error {
+	if image == "" {
+		return fmt.Errorf("image reference is empty")
+	}
+	name, _, _ := strings.Cut(image, "@")
+	if len(name) > 255 {
+		return fmt.Errorf("image reference %q is longer than 255 characters", image)
+	}
+	if !imageReferencePattern.MatchString(image) {
+		return fmt.Errorf("invalid image reference %q", image)
+	}
+	return nil
+}
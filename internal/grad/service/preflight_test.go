@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// allowVerbs makes access reviews on clientset succeed only for the given "verb resource" pairs
+func allowVerbs(clientset *fake.Clientset, allowed ...string) {
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		resource := attributes.Resource
+		if attributes.Group != "" {
+			resource += "." + attributes.Group
+		}
+		if attributes.Subresource != "" {
+			resource += "/" + attributes.Subresource
+		}
+		for _, permission := range allowed {
+			if permission == attributes.Verb+" "+resource {
+				review.Status.Allowed = true
+			}
+		}
+		return true, review, nil
+	})
+}
+
+// allowAll makes every access review Preflight runs succeed
+func allowAll(clientset *fake.Clientset) {
+	var allowed []string
+	for _, permission := range runnerPermissions() {
+		allowed = append(allowed, permission.String())
+	}
+	allowVerbs(clientset, allowed...)
+}
+
+func TestPreflightRBAC(t *testing.T) {
+	everything := []string{
+		"create pods", "get pods", "list pods", "patch pods", "delete pods", "create pods/exec",
+		"list secrets", "delete secrets",
+		"list persistentvolumeclaims", "delete persistentvolumeclaims",
+		"list networkpolicies.networking.k8s.io", "delete networkpolicies.networking.k8s.io",
+	}
+	cleanup := everything[6:]
+
+	tests := []struct {
+		name         string
+		allowed      []string
+		expectFailed []string
+	}{
+		{"All allowed", everything, nil},
+		{"Read only", []string{"get pods", "list pods"}, append([]string{"create pods", "patch pods", "delete pods", "create pods/exec"}, cleanup...)},
+		{"No exec", slices.Concat(everything[:5], cleanup), []string{"create pods/exec"}},
+		{"Pods only", everything[:6], cleanup},
+		{"No network policy list", slices.Concat(everything[:10], everything[11:]), []string{"list networkpolicies.networking.k8s.io"}},
+		{"Nothing allowed", nil, everything},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			allowVerbs(clientset, tt.allowed...)
+			config := DefaultKubernetesConfig()
+			config.Namespace = "runners"
+			k8sClient := &KubernetesClient{clientset: clientset, config: config}
+
+			var failed []string
+			for _, check := range k8sClient.Preflight(context.Background()) {
+				if check.Err == nil {
+					continue
+				}
+				if !strings.HasPrefix(check.Name, "rbac: ") {
+					t.Errorf("Expected only RBAC checks to fail, got %s: %v", check.Name, check.Err)
+					continue
+				}
+				if !strings.HasSuffix(check.Name, " in namespace runners") {
+					t.Errorf("Expected check to name the runner namespace, got %q", check.Name)
+				}
+				failed = append(failed, strings.TrimSuffix(strings.TrimPrefix(check.Name, "rbac: "), " in namespace runners"))
+			}
+
+			if strings.Join(failed, ",") != strings.Join(tt.expectFailed, ",") {
+				t.Errorf("Expected failed checks %v, got %v", tt.expectFailed, failed)
+			}
+		})
+	}
+}
+
+func TestPreflightInvalidImage(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	allowAll(clientset)
+	config := DefaultKubernetesConfig()
+	config.RunnerImage = "ghcr.io/strrl/Grad-Runner:latest"
+	k8sClient := &KubernetesClient{clientset: clientset, config: config}
+
+	for _, check := range k8sClient.Preflight(context.Background()) {
+		if (check.Err != nil) != (check.Name == "runner image reference") {
+			t.Errorf("Unexpected result for %s: %v", check.Name, check.Err)
+		}
+	}
+}
+
+func TestValidateImageReference(t *testing.T) {
+	tests := []struct {
+		image       string
+		expectError bool
+	}{
+		{DefaultRunnerImage, false},
+		{"ubuntu", false},
+		{"library/ubuntu:22.04", false},
+		{"localhost:5000/grad-runner", false},
+		{"ghcr.io/strrl/grad-runner:v1.17.1-38-g1c6517887", false},
+		{"registry.example.com/team/grad_runner@sha256:" + strings.Repeat("a", 64), false},
+		{"grad-runner:latest@sha256:" + strings.Repeat("0", 64), false},
+		{"", true},
+		{"Ubuntu", true},
+		{"ghcr.io/strrl/grad-runner:", true},
+		{"ghcr.io//grad-runner", true},
+		{"grad-runner:latest tag", true},
+		{"grad-runner@sha256:abc", true},
+		{"https://ghcr.io/strrl/grad-runner", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			err := validateImageReference(tt.image)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected %q to be rejected", tt.image)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected %q to be valid, got %v", tt.image, err)
+			}
+		})
+	}
+}
+
+func TestPreflightWarnsOnLatestImage(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	allowAll(clientset)
+	config := DefaultKubernetesConfig()
+	k8sClient := &KubernetesClient{clientset: clientset, config: config}
+
+	for _, check := range k8sClient.Preflight(context.Background()) {
+		if check.Err != nil {
+			t.Errorf("Expected %s to pass, got %v", check.Name, check.Err)
+		}
+		if (check.Warning != "") != (check.Name == "runner image reference") {
+			t.Errorf("Unexpected warning for %s: %q", check.Name, check.Warning)
+		}
+	}
+
+	config.RunnerImage = "ghcr.io/strrl/grad-runner:v1.2.0"
+	for _, check := range k8sClient.Preflight(context.Background()) {
+		if check.Warning != "" {
+			t.Errorf("Expected no warning for a pinned image, got %s: %q", check.Name, check.Warning)
+		}
+	}
+}
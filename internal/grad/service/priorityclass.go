@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetPriorityClass gets a PriorityClass, which is cluster-scoped
+func (k *KubernetesClient) GetPriorityClass(ctx context.Context, name string) (*schedulingv1.PriorityClass, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	priorityClass, err := k.clientset.SchedulingV1().PriorityClasses().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get priority class: %w", err)
+	}
+	return priorityClass, nil
+}
+
+// runnerPriorityClass returns the PriorityClass a runner created by req gets: the one the
+// request picked, which must be allowed, or else the server's default for user-created or
+// auto-created runners (pure function)
+func runnerPriorityClass(req *CreateRunnerRequest, config *KubernetesConfig) (string, error) {
+	if req.PriorityClassName != "" {
+		if !slices.Contains(config.Allowlists().PriorityClasses, req.PriorityClassName) {
+			return "", fmt.Errorf("%w: priority class %q is not allowed for runners", ErrInvalidRequest, req.PriorityClassName)
+		}
+		return req.PriorityClassName, nil
+	}
+	if req.AutoCreated {
+		return config.AutoPriorityClassName, nil
+	}
+	return config.PriorityClassName, nil
+}
+
+// checkPriorityClass returns the PriorityClass of a runner created by req, verifying that it
+// exists unless the request skips validation, since Kubernetes would reject the pod otherwise
+func (s *runnerService) checkPriorityClass(ctx context.Context, req *CreateRunnerRequest) (string, error) {
+	name, err := runnerPriorityClass(req, s.k8sClient.Config())
+	if err != nil || name == "" || req.SkipValidation {
+		return name, err
+	}
+
+	if _, err := s.k8sClient.GetPriorityClass(ctx, name); err != nil {
+		if errors.IsNotFound(err) {
+			return "", fmt.Errorf("%w: priority class %q not found", ErrFailedPrecondition, name)
+		}
+		return "", fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunnerPriorityClass(t *testing.T) {
+	config := &KubernetesConfig{
+		PriorityClassName:      "interactive",
+		AutoPriorityClassName:  "batch",
+		AllowedPriorityClasses: []string{"interactive", "batch"},
+	}
+	tests := []struct {
+		name        string
+		req         *CreateRunnerRequest
+		expected    string
+		expectedErr error
+	}{
+		{"User default", &CreateRunnerRequest{}, "interactive", nil},
+		{"Auto default", &CreateRunnerRequest{AutoCreated: true}, "batch", nil},
+		{"Allowed override", &CreateRunnerRequest{PriorityClassName: "batch"}, "batch", nil},
+		{"Override of an auto runner", &CreateRunnerRequest{AutoCreated: true, PriorityClassName: "interactive"}, "interactive", nil},
+		{"Override not allowed", &CreateRunnerRequest{PriorityClassName: "system-cluster-critical"}, "", ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runnerPriorityClass(tt.req, config)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected priority class '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+
+	if got, _ := runnerPriorityClass(&CreateRunnerRequest{AutoCreated: true}, DefaultKubernetesConfig()); got != "" {
+		t.Errorf("Expected no priority class without config, got '%s'", got)
+	}
+}
+
+func TestCreateRunnerPriorityClass(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.k8sClient.Config().PriorityClassName = "interactive"
+	svc.k8sClient.Config().AutoPriorityClassName = "batch"
+	svc.k8sClient.Config().AllowedPriorityClasses = []string{"interactive", "batch"}
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	// The cluster only knows the interactive class
+	_, err := clientset.SchedulingV1().PriorityClasses().Create(ctx, &schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "interactive"},
+		Value:      1000,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create priority class: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		req         *CreateRunnerRequest
+		expected    string
+		expectedErr error
+	}{
+		{"Existing default", &CreateRunnerRequest{}, "interactive", nil},
+		{"Missing auto default", &CreateRunnerRequest{AutoCreated: true}, "", ErrFailedPrecondition},
+		{"Missing override", &CreateRunnerRequest{PriorityClassName: "batch"}, "", ErrFailedPrecondition},
+		{"Missing override without validation", &CreateRunnerRequest{PriorityClassName: "batch", SkipValidation: true}, "batch", nil},
+		{"Override not allowed", &CreateRunnerRequest{PriorityClassName: "system-cluster-critical", SkipValidation: true}, "", ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := svc.CreateRunner(ctx, tt.req)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if runner.PriorityClassName != tt.expected {
+				t.Errorf("Expected runner priority class '%s', got '%s'", tt.expected, runner.PriorityClassName)
+			}
+			pod, err := svc.k8sClient.GetRunnerPod(ctx, runner.ID)
+			if err != nil {
+				t.Fatalf("GetRunnerPod failed: %v", err)
+			}
+			if pod.Spec.PriorityClassName != tt.expected {
+				t.Errorf("Expected pod priority class '%s', got '%s'", tt.expected, pod.Spec.PriorityClassName)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+import "fmt"
+
+// RunnerProtectedAnnotation is "true" on runners protected from deletion: DeleteRunner refuses
+// them unless told to override the protection, and the cleanup service skips them
+const RunnerProtectedAnnotation = RunnerAnnotationPrefix + "protected"
+
+// encodeProtected formats a runner's protection for RunnerProtectedAnnotation, "" for an
+// unprotected runner (pure function)
+func encodeProtected(protected bool) string {
+	if !protected {
+		return ""
+	}
+	return "true"
+}
+
+// checkDeletable returns ErrRunnerProtected for a protected runner unless overrideProtection
+// is set (pure function)
+func checkDeletable(runner *Runner, overrideProtection bool) error {
+	if runner.Protected && !overrideProtection {
+		return fmt.Errorf("%w: unprotect runner %s or override its protection to delete it", ErrRunnerProtected, runner.ID)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/goleak"
+)
+
+func TestDeleteProtectedRunner(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	emulatePodFinalizers(clientset)
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Group: "prod", Protected: true})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if !runner.Protected || !runner.ToProto().Protected {
+		t.Fatalf("Expected the runner to be protected, got %+v", runner)
+	}
+
+	_, err = svc.DeleteRunner(ctx, runner.ID, false)
+	if !errors.Is(err, ErrRunnerProtected) || !errors.Is(err, ErrFailedPrecondition) {
+		t.Fatalf("Expected ErrRunnerProtected, got %v", err)
+	}
+	if _, err := svc.GetRunner(ctx, runner.ID); err != nil {
+		t.Fatalf("Expected the protected runner to be kept, got %v", err)
+	}
+
+	// Group deletion can't override the protection, the runner is reported as failed
+	deletion, err := svc.DeleteRunnerGroup(ctx, "prod")
+	if err != nil {
+		t.Fatalf("DeleteRunnerGroup failed: %v", err)
+	}
+	if len(deletion.Failed) != 1 || !errors.Is(deletion.Failed[0].Err, ErrRunnerProtected) {
+		t.Errorf("Expected the protected runner to fail, got %+v", deletion)
+	}
+
+	unprotected := false
+	updated, err := svc.UpdateRunner(ctx, runner.ID, &UpdateRunnerRequest{Protected: &unprotected})
+	if err != nil {
+		t.Fatalf("UpdateRunner failed: %v", err)
+	}
+	if updated.Protected {
+		t.Errorf("Expected the protection to be removed")
+	}
+	protected := true
+	if updated, err = svc.UpdateRunner(ctx, runner.ID, &UpdateRunnerRequest{Protected: &protected}); err != nil || !updated.Protected {
+		t.Fatalf("Expected the runner to be protected again, got %+v, %v", updated, err)
+	}
+
+	if _, err := svc.DeleteRunner(ctx, runner.ID, true); err != nil {
+		t.Fatalf("Expected overriding the protection to delete the runner, got %v", err)
+	}
+	if _, err := svc.GetRunner(ctx, runner.ID); !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("Expected the runner to be gone, got %v", err)
+	}
+}
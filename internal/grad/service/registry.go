@@ -0,0 +1,256 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// registryTimeout bounds each request to a registry
+const registryTimeout = 10 * time.Second
+
+// manifestMediaTypes are the manifest formats accepted when resolving an image: single and
+// multi-arch images, in the OCI and the Docker format
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// registryCredential is a username and password for a registry from a docker config
+type registryCredential struct {
+	username string
+	password string
+}
+
+// RegistryClient resolves image references against their registries with the OCI
+// distribution API, anonymously or with the credentials of a pull secret
+type RegistryClient struct {
+	httpClient *http.Client
+	// credentials are keyed by registry host, with Docker Hub as docker.io
+	credentials map[string]registryCredential
+}
+
+// NewRegistryClient creates a registry client authenticating with the credentials in
+// dockerConfig, the JSON of a kubernetes.io/dockerconfigjson Secret; empty pulls anonymously
+func NewRegistryClient(dockerConfig []byte) (*RegistryClient, error) {
+	client := &RegistryClient{
+		httpClient:  &http.Client{Timeout: registryTimeout},
+		credentials: make(map[string]registryCredential),
+	}
+	if len(dockerConfig) == 0 {
+		return client, nil
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(dockerConfig, &config); err != nil {
+		return nil, fmt.Errorf("invalid docker config: %w", err)
+	}
+	for server, auth := range config.Auths {
+		credential := registryCredential{username: auth.Username, password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid docker config: auth of %s: %w", server, err)
+			}
+			username, password, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid docker config: auth of %s must be username:password", server)
+			}
+			credential = registryCredential{username: username, password: password}
+		}
+		client.credentials[registryHost(server)] = credential
+	}
+	return client, nil
+}
+
+// LoadRegistryClient creates a registry client with the docker config in path, e.g. the
+// .dockerconfigjson of a mounted pull secret; an empty path pulls anonymously
+func LoadRegistryClient(path string) (*RegistryClient, error) {
+	if path == "" {
+		return NewRegistryClient(nil)
+	}
+	dockerConfig, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pull secret: %w", err)
+	}
+	return NewRegistryClient(dockerConfig)
+}
+
+// registryHost normalizes a docker config server, which may be a URL such as
+// "https://index.docker.io/v1/", to the host images name (pure function)
+func registryHost(server string) string {
+	if _, rest, ok := strings.Cut(server, "://"); ok {
+		server = rest
+	}
+	host, _, _ := strings.Cut(server, "/")
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return host
+}
+
+// Resolve looks up the manifest of image in its registry and returns its digest. It fails
+// if the image doesn't exist or the registry refuses access to it.
+func (c *RegistryClient) Resolve(ctx context.Context, image string) (string, error) {
+	ref := parseImageReference(image)
+	host, repository, _ := strings.Cut(ref.repository, "/")
+	endpoint := host
+	if host == "docker.io" {
+		endpoint = "registry-1.docker.io"
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", endpoint, repository, cmp.Or(ref.digest, ref.tag))
+
+	resp, err := c.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := c.authorize(ctx, host, repository, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		if resp, err = c.headManifest(ctx, manifestURL, authorization); err != nil {
+			return "", err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		digest := cmp.Or(resp.Header.Get("Docker-Content-Digest"), ref.digest)
+		if digest == "" {
+			return "", fmt.Errorf("registry %s didn't return the digest of the manifest", host)
+		}
+		return digest, nil
+	case http.StatusNotFound:
+		return "", fmt.Errorf("manifest unknown: registry %s has no %s", host, cmp.Or(ref.digest, ref.tag))
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", fmt.Errorf("registry %s denied access (%s), check the pull secret", host, resp.Status)
+	default:
+		return "", fmt.Errorf("registry %s returned %s", host, resp.Status)
+	}
+}
+
+// headManifest requests the headers of a manifest, with authorization if not empty
+func (c *RegistryClient) headManifest(ctx context.Context, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the registry: %w", err)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// authorize answers the WWW-Authenticate challenge of a registry: with the credentials for
+// host for Basic auth, or with a pull token of repository for Bearer auth, which registries
+// such as Docker Hub also hand out anonymously for public images
+func (c *RegistryClient) authorize(ctx context.Context, host, repository, challenge string) (string, error) {
+	scheme, params := parseAuthChallenge(challenge)
+	credential, hasCredential := c.credentials[host]
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCredential {
+			return "", fmt.Errorf("registry %s requires credentials, configure a pull secret for it", host)
+		}
+		return "Basic " + basicAuth(credential), nil
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || realm.Host == "" {
+			return "", fmt.Errorf("registry %s sent an invalid token realm %q", host, params["realm"])
+		}
+		query := realm.Query()
+		if params["service"] != "" {
+			query.Set("service", params["service"])
+		}
+		query.Set("scope", cmp.Or(params["scope"], "repository:"+repository+":pull"))
+		realm.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCredential {
+			req.Header.Set("Authorization", "Basic "+basicAuth(credential))
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to get a pull token: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("registry %s refused a pull token (%s), check the pull secret", host, resp.Status)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+			return "", fmt.Errorf("invalid pull token response: %w", err)
+		}
+		if token.Token == "" && token.AccessToken == "" {
+			return "", errors.New("invalid pull token response: no token")
+		}
+		return "Bearer " + cmp.Or(token.Token, token.AccessToken), nil
+	default:
+		return "", fmt.Errorf("registry %s requires unsupported authentication %q", host, scheme)
+	}
+}
+
+// basicAuth encodes credential for a Basic Authorization header (pure function)
+func basicAuth(credential registryCredential) string {
+	return base64.StdEncoding.EncodeToString([]byte(credential.username + ":" + credential.password))
+}
+
+// parseAuthChallenge splits a WWW-Authenticate header such as
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"` into its scheme
+// and parameters. Quoted values may contain commas. (pure function)
+func parseAuthChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			params[key] = value
+		}
+	}
+	return scheme, params
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRegistry serves the manifests of team/runner:v1 and team/s3fs:v1, requiring a pull
+// token that it only hands out for the credentials grad:hunter2
+type testRegistry struct {
+	*httptest.Server
+	// host is how images name the registry, e.g. 127.0.0.1:41234
+	host string
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	t.Helper()
+	registry := &testRegistry{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "grad" || password != "hunter2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if scope := r.URL.Query().Get("scope"); !strings.HasPrefix(scope, "repository:team/") {
+			t.Errorf("Expected a repository scope, got %q", scope)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token": "pull-token"}`))
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+			t.Errorf("Expected a HEAD request accepting OCI indexes, got %s with Accept %q", r.Method, r.Header.Get("Accept"))
+		}
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+registry.URL+`/token",service="test-registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/team/runner/manifests/v1", "/v2/team/s3fs/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", "sha256:4bf92f3577b34da6a3ce929d0e0e4736")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	registry.Server = httptest.NewTLSServer(mux)
+	registry.host = strings.TrimPrefix(registry.URL, "https://")
+	t.Cleanup(registry.Close)
+	return registry
+}
+
+// client returns a client of the registry authenticating with dockerConfig
+func (r *testRegistry) client(t *testing.T, dockerConfig string) *RegistryClient {
+	t.Helper()
+	client, err := NewRegistryClient([]byte(dockerConfig))
+	if err != nil {
+		t.Fatalf("NewRegistryClient failed: %v", err)
+	}
+	client.httpClient = r.Client()
+	return client
+}
+
+// dockerConfig is the .dockerconfigjson of a pull secret for host with the credentials grad:hunter2
+func dockerConfig(host string) string {
+	return `{"auths": {"` + host + `": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("grad:hunter2")) + `"}}}`
+}
+
+func TestRegistryClientResolve(t *testing.T) {
+	registry := newTestRegistry(t)
+	client := registry.client(t, dockerConfig(registry.host))
+	ctx := context.Background()
+
+	digest, err := client.Resolve(ctx, registry.host+"/team/runner:v1")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if digest != "sha256:4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the registry's digest, got %q", digest)
+	}
+
+	_, err = client.Resolve(ctx, registry.host+"/team/runner:v1-typo")
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") || !strings.Contains(err.Error(), "v1-typo") {
+		t.Errorf("Expected a missing tag to fail as manifest unknown, got %v", err)
+	}
+
+	// Anonymous clients don't get a pull token
+	_, err = registry.client(t, "").Resolve(ctx, registry.host+"/team/runner:v1")
+	if err == nil || !strings.Contains(err.Error(), "refused a pull token") {
+		t.Errorf("Expected an anonymous client to be refused, got %v", err)
+	}
+}
+
+func TestNewRegistryClient(t *testing.T) {
+	client, err := NewRegistryClient([]byte(`{"auths": {
+		"https://index.docker.io/v1/": {"username": "grad", "password": "hunter2"},
+		"ghcr.io": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("bot:ghp_token")) + `"}
+	}}`))
+	if err != nil {
+		t.Fatalf("NewRegistryClient failed: %v", err)
+	}
+	if credential := client.credentials["docker.io"]; credential.username != "grad" || credential.password != "hunter2" {
+		t.Errorf("Expected the Docker Hub credentials under docker.io, got %+v", client.credentials)
+	}
+	if credential := client.credentials["ghcr.io"]; credential.username != "bot" || credential.password != "ghp_token" {
+		t.Errorf("Expected the decoded ghcr.io credentials, got %+v", credential)
+	}
+
+	for _, config := range []string{`{"auths": [`, `{"auths": {"ghcr.io": {"auth": "not base64!"}}}`} {
+		if _, err := NewRegistryClient([]byte(config)); err == nil {
+			t.Errorf("Expected %s to be rejected", config)
+		}
+	}
+}
+
+func TestParseAuthChallenge(t *testing.T) {
+	scheme, params := parseAuthChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull,push"`)
+	if scheme != "Bearer" {
+		t.Errorf("Expected Bearer, got %q", scheme)
+	}
+	expected := map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io", "scope": "repository:library/nginx:pull,push"}
+	for key, value := range expected {
+		if params[key] != value {
+			t.Errorf("Expected %s %q, got %q", key, value, params[key])
+		}
+	}
+
+	if scheme, params := parseAuthChallenge(`Basic realm=registry`); scheme != "Basic" || params["realm"] != "registry" {
+		t.Errorf("Expected an unquoted Basic realm, got %q and %v", scheme, params)
+	}
+}
+
+func TestImageCheck(t *testing.T) {
+	registry := newTestRegistry(t)
+	check := NewImageCheck(registry.client(t, dockerConfig(registry.host)))
+
+	images := RunnerImages{Runner: registry.host + "/team/runner:v1", S3FS: registry.host + "/team/s3fs:v1"}
+	if problem := check.resolve(context.Background(), images); problem != "" {
+		t.Errorf("Expected both images to resolve, got %q", problem)
+	}
+
+	// A typo in a rotated image degrades readiness
+	images.S3FS = registry.host + "/team/s3f:v1"
+	check.Start(images)
+	deadline := time.Now().Add(5 * time.Second)
+	for check.Problem() == "" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if problem := check.Problem(); !strings.HasPrefix(problem, `s3fs image "`+images.S3FS+`" can't be pulled: manifest unknown`) {
+		t.Errorf("Expected the s3fs image to be reported, got %q", problem)
+	}
+
+	var skipped *ImageCheck
+	if problem := skipped.Problem(); problem != "" {
+		t.Errorf("Expected a skipped check to report nothing, got %q", problem)
+	}
+}
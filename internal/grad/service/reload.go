@@ -0,0 +1,163 @@
+package service
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// dynamicConfigKeys are the settings a reload applies to a running grad. Every other setting
+// is read once at startup, e.g. the ports and namespace, and needs a restart to change.
+var dynamicConfigKeys = map[string]bool{
+	"CLEANUP_INTERVAL":           true,
+	"RUNNER_IDLE_TIMEOUT":        true,
+	"RUNNER_GROUP_IDLE_TIMEOUTS": true,
+
+	"RUNNER_ALLOWED_SERVICE_ACCOUNTS": true,
+	"RUNNER_ALLOWED_PRIORITY_CLASSES": true,
+
+	"ENV_MAX_VALUE_BYTES":    true,
+	"ENV_MAX_TOTAL_BYTES":    true,
+	"ENV_MAX_ENTRIES":        true,
+	"EXEC_MAX_COMMAND_BYTES": true,
+	"EXEC_MAX_ARGS":          true,
+
+	"RATE_LIMIT_MUTATING_RPS":            true,
+	"RATE_LIMIT_MUTATING_BURST":          true,
+	"RATE_LIMIT_READ_RPS":                true,
+	"RATE_LIMIT_READ_BURST":              true,
+	"RATE_LIMIT_IDENTITY_MUTATING_RPS":   true,
+	"RATE_LIMIT_IDENTITY_MUTATING_BURST": true,
+	"RATE_LIMIT_IDENTITY_READ_RPS":       true,
+	"RATE_LIMIT_IDENTITY_READ_BURST":     true,
+}
+
+// ConfigReload is the outcome of reloading the configuration, listing the settings that
+// changed by environment variable, sorted
+type ConfigReload struct {
+	// Applied are the changed settings now in effect
+	Applied []string
+	// Ignored are the changed settings that only take effect when grad restarts
+	Ignored []string
+}
+
+// Changed reports whether any applied setting starts with one of prefixes
+func (r *ConfigReload) Changed(prefixes ...string) bool {
+	return slices.ContainsFunc(r.Applied, func(key string) bool {
+		return slices.ContainsFunc(prefixes, func(prefix string) bool {
+			return strings.HasPrefix(key, prefix)
+		})
+	})
+}
+
+// ConfigReloader loads the configuration again on demand, e.g. on SIGHUP, and passes the
+// settings that changed to the function registered with OnReload. An invalid configuration
+// changes nothing.
+type ConfigReloader struct {
+	mu sync.Mutex
+	// load returns the lookup to load the configuration from, reading any file it comes from again
+	load  func() (func(key string) string, error)
+	apply func(config *Config, reload *ConfigReload)
+	// values holds the value of every key the configuration in effect was loaded from
+	values map[string]string
+}
+
+// NewConfigReloader loads the configuration from the lookup load returns and creates a
+// reloader loading it the same way again
+func NewConfigReloader(load func() (func(key string) string, error)) (*ConfigReloader, *Config, error) {
+	lookup, err := load()
+	if err != nil {
+		return nil, nil, err
+	}
+	config, values, err := loadConfigValues(lookup)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ConfigReloader{load: load, values: values}, config, nil
+}
+
+// OnReload sets the function applying the dynamic settings of a reloaded configuration. It
+// is only called when one of them changed, and never for two reloads at once.
+func (r *ConfigReloader) OnReload(apply func(config *Config, reload *ConfigReload)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apply = apply
+}
+
+// Reload loads and validates the configuration, applies the dynamic settings that changed and
+// warns about the others, which keep their value until grad restarts
+func (r *ConfigReloader) Reload() (*ConfigReload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lookup, err := r.load()
+	if err != nil {
+		slog.Error("Failed to reload configuration", "error", err)
+		return nil, err
+	}
+	config, values, err := loadConfigValues(lookup)
+	if err != nil {
+		slog.Error("Failed to reload configuration", "error", err)
+		return nil, err
+	}
+
+	reload := &ConfigReload{}
+	for _, key := range changedConfigKeys(r.values, values) {
+		if !dynamicConfigKeys[key] {
+			reload.Ignored = append(reload.Ignored, key)
+			// Keep comparing against the value in effect, so every reload warns again
+			if old, ok := r.values[key]; ok {
+				values[key] = old
+			} else {
+				delete(values, key)
+			}
+			continue
+		}
+		reload.Applied = append(reload.Applied, key)
+		slog.Info("Changed setting", "key", key, "value", values[key], "previous_value", r.values[key])
+	}
+
+	if len(reload.Applied) > 0 && r.apply != nil {
+		r.apply(config, reload)
+	}
+	r.values = values
+
+	// The values of ignored settings aren't logged, as they may be secrets such as GRAD_ADMIN_TOKEN
+	if len(reload.Ignored) > 0 {
+		slog.Warn("Changed settings need a restart to take effect", "keys", reload.Ignored)
+	}
+	slog.Info("Reloaded configuration", "applied", reload.Applied, "ignored", reload.Ignored)
+	return reload, nil
+}
+
+// loadConfigValues loads the configuration from lookup, returning the value of every key read
+func loadConfigValues(lookup func(key string) string) (*Config, map[string]string, error) {
+	values := make(map[string]string)
+	config, err := LoadConfigFrom(func(key string) string {
+		value := lookup(key)
+		values[key] = value
+		return value
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, values, nil
+}
+
+// changedConfigKeys returns the keys whose value differs between old and new, sorted (pure function)
+func changedConfigKeys(old, new map[string]string) []string {
+	var keys []string
+	for key, value := range new {
+		if old[key] != value {
+			keys = append(keys, key)
+		}
+	}
+	for key, value := range old {
+		if _, ok := new[key]; !ok && value != "" {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
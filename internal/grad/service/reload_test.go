@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// configSource is a configuration that can be changed between loads, like a config file
+type configSource struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// set changes the value of key for the next load
+func (s *configSource) set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// load returns a lookup of the current values
+func (s *configSource) load() (func(key string) string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := make(map[string]string, len(s.values))
+	for key, value := range s.values {
+		values[key] = value
+	}
+	return func(key string) string { return values[key] }, nil
+}
+
+func TestConfigReloader(t *testing.T) {
+	source := &configSource{values: map[string]string{"RUNNER_IDLE_TIMEOUT": "10m"}}
+	reloader, config, err := NewConfigReloader(source.load)
+	if err != nil {
+		t.Fatalf("NewConfigReloader failed: %v", err)
+	}
+	if config.Durations.IdleTimeout != 10*time.Minute {
+		t.Errorf("Expected the initial configuration, got idle timeout %s", config.Durations.IdleTimeout)
+	}
+
+	var applied []*Config
+	var reloads []*ConfigReload
+	reloader.OnReload(func(config *Config, reload *ConfigReload) {
+		applied = append(applied, config)
+		reloads = append(reloads, reload)
+	})
+
+	if reload, err := reloader.Reload(); err != nil || len(reload.Applied) != 0 || len(reload.Ignored) != 0 || len(applied) != 0 {
+		t.Fatalf("Expected nothing to change, got %+v, %v", reload, err)
+	}
+
+	source.set("RUNNER_IDLE_TIMEOUT", "20m")
+	source.set("RATE_LIMIT_READ_RPS", "20")
+	source.set("KUBERNETES_NAMESPACE", "runners")
+	reload, err := reloader.Reload()
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if !slices.Equal(reload.Applied, []string{"RATE_LIMIT_READ_RPS", "RUNNER_IDLE_TIMEOUT"}) || !slices.Equal(reload.Ignored, []string{"KUBERNETES_NAMESPACE"}) {
+		t.Errorf("Expected the rate limit and idle timeout to apply and the namespace to need a restart, got %+v", reload)
+	}
+	if len(applied) != 1 || applied[0].Durations.IdleTimeout != 20*time.Minute || applied[0].RateLimits.ReadRate != 20 {
+		t.Fatalf("Expected the new configuration to be applied once, got %d", len(applied))
+	}
+	if !reloads[0].Changed("RATE_LIMIT_") || reloads[0].Changed("ENV_MAX_") {
+		t.Errorf("Expected only the rate limits to have changed, got %+v", reloads[0])
+	}
+
+	// A setting that needs a restart is reported on every reload until grad restarts
+	reload, err = reloader.Reload()
+	if err != nil || len(reload.Applied) != 0 || !slices.Equal(reload.Ignored, []string{"KUBERNETES_NAMESPACE"}) || len(applied) != 1 {
+		t.Errorf("Expected only the namespace to be reported again, got %+v, %v", reload, err)
+	}
+
+	// An invalid configuration changes nothing
+	source.set("RUNNER_IDLE_TIMEOUT", "soon")
+	var configErr *ConfigError
+	if _, err := reloader.Reload(); !errors.As(err, &configErr) || len(applied) != 1 {
+		t.Errorf("Expected a ConfigError and nothing applied, got %v", err)
+	}
+	source.set("RUNNER_IDLE_TIMEOUT", "30m")
+	if reload, err := reloader.Reload(); err != nil || !slices.Equal(reload.Applied, []string{"RUNNER_IDLE_TIMEOUT"}) {
+		t.Errorf("Expected the fixed idle timeout to apply, got %+v, %v", reload, err)
+	}
+
+	// Unsetting a key changes it back to its default
+	source.set("RATE_LIMIT_READ_RPS", "")
+	if reload, err := reloader.Reload(); err != nil || !slices.Equal(reload.Applied, []string{"RATE_LIMIT_READ_RPS"}) || applied[len(applied)-1].RateLimits.ReadRate != DefaultRateLimits().ReadRate {
+		t.Errorf("Expected the default read rate to apply, got %+v, %v", reload, err)
+	}
+}
+
+func TestConfigReloadUnderConcurrentReaders(t *testing.T) {
+	source := &configSource{values: map[string]string{
+		"RUNNER_IDLE_TIMEOUT":             "1m",
+		"RUNNER_ALLOWED_PRIORITY_CLASSES": "batch",
+		"RUNNER_ALLOWED_SERVICE_ACCOUNTS": "batch",
+	}}
+	reloader, config, err := NewConfigReloader(source.load)
+	if err != nil {
+		t.Fatalf("NewConfigReloader failed: %v", err)
+	}
+	cleanup := NewCleanupService(newMockRunnerService(), NewActivityTracker(), nil, config.Durations, config.GroupIdleTimeouts)
+	reloader.OnReload(func(reloaded *Config, reload *ConfigReload) {
+		cleanup.SetIdleTimeouts(reloaded.Durations, reloaded.GroupIdleTimeouts)
+		config.Kubernetes.SetAllowlists(reloaded.Kubernetes.Allowlists())
+	})
+
+	const reloads = 100
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan string, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if timeout := cleanup.idleTimeout(""); timeout < time.Minute || timeout > reloads*time.Minute {
+					errs <- "idle timeout " + timeout.String()
+					return
+				}
+				// Each reload sets both allowlists to the same class, so a reader sees both from one reload
+				allowlists := config.Kubernetes.Allowlists()
+				if len(allowlists.PriorityClasses) != 1 || !slices.Equal(allowlists.PriorityClasses, allowlists.ServiceAccounts) {
+					errs <- fmt.Sprintf("allowlists %+v", allowlists)
+					return
+				}
+				_, _ = runnerPriorityClass(&CreateRunnerRequest{PriorityClassName: "batch"}, config.Kubernetes)
+			}
+		}()
+	}
+
+	for i := 1; i <= reloads; i++ {
+		class := []string{"batch", "interactive"}[i%2]
+		source.set("RUNNER_IDLE_TIMEOUT", (time.Duration(i) * time.Minute).String())
+		source.set("RUNNER_ALLOWED_PRIORITY_CLASSES", class)
+		source.set("RUNNER_ALLOWED_SERVICE_ACCOUNTS", class)
+		if _, err := reloader.Reload(); err != nil {
+			t.Fatalf("Reload %d failed: %v", i, err)
+		}
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("A reader saw an inconsistent configuration: %s", err)
+	}
+
+	if timeout := cleanup.idleTimeout(""); timeout != reloads*time.Minute {
+		t.Errorf("Expected the last idle timeout, got %s", timeout)
+	}
+	if class, err := runnerPriorityClass(&CreateRunnerRequest{PriorityClassName: "batch"}, config.Kubernetes); err != nil || class != "batch" {
+		t.Errorf("Expected the last allowlist to allow batch, got %q, %v", class, err)
+	}
+}
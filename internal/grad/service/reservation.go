@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// RunnerReservedUntilAnnotation holds the end of the runner's reservation in RFC 3339; the
+// cleanup service doesn't count the runner as idle before it
+const RunnerReservedUntilAnnotation = RunnerAnnotationPrefix + "reserved-until"
+
+// ReservationWarningLead is how long before a reservation ends the cleanup service warns that
+// the runner will be subject to idle cleanup again
+const ReservationWarningLead = 15 * time.Minute
+
+// validateReservation checks that a reservation ends after now and at most max after it; a
+// zero until is no reservation (pure function)
+func validateReservation(until, now time.Time, max time.Duration) error {
+	if until.IsZero() {
+		return nil
+	}
+	if !until.After(now) {
+		return fmt.Errorf("%w: reservation end %s has passed", ErrInvalidRequest, until.UTC().Format(time.RFC3339))
+	}
+	if until.Sub(now) > max {
+		return fmt.Errorf("%w: reservation may end at most %s from now, got %s", ErrInvalidRequest, max, until.Sub(now).Round(time.Second))
+	}
+	return nil
+}
+
+// encodeReservation formats the end of a reservation for RunnerReservedUntilAnnotation, ""
+// for none (pure function)
+func encodeReservation(until time.Time) string {
+	if until.IsZero() {
+		return ""
+	}
+	return until.UTC().Format(time.RFC3339)
+}
+
+// decodeReservation reverses encodeReservation, ignoring values it can't parse (pure function)
+func decodeReservation(value string) time.Time {
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return until
+}
+
+// unixTime converts a Unix timestamp from the API, where 0 means unset (pure function)
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// unixSeconds reverses unixTime (pure function)
+func unixSeconds(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// idleSince returns when a runner started counting as idle: its last activity, or the end of
+// its reservation if that is later (pure function)
+func idleSince(lastActive, reservedUntil time.Time) time.Time {
+	if reservedUntil.After(lastActive) {
+		return reservedUntil
+	}
+	return lastActive
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestValidateReservation(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		until         time.Time
+		expectMessage string
+	}{
+		{"None", time.Time{}, ""},
+		{"Within the maximum", now.Add(6 * time.Hour), ""},
+		{"At the maximum", now.Add(24 * time.Hour), ""},
+		{"Past", now.Add(-time.Minute), "reservation end 2026-10-16T11:59:00Z has passed"},
+		{"Now", now, "has passed"},
+		{"Beyond the maximum", now.Add(25 * time.Hour), "at most 24h0m0s from now, got 25h0m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateReservation(tt.until, now, 24*time.Hour)
+			if tt.expectMessage == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), tt.expectMessage) {
+				t.Errorf("Expected an invalid request containing %q, got %v", tt.expectMessage, err)
+			}
+		})
+	}
+}
+
+func TestIdleSince(t *testing.T) {
+	lastActive := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	if got := idleSince(lastActive, time.Time{}); !got.Equal(lastActive) {
+		t.Errorf("Expected the last activity without a reservation, got %v", got)
+	}
+	if got := idleSince(lastActive, lastActive.Add(-time.Hour)); !got.Equal(lastActive) {
+		t.Errorf("Expected the last activity after a reservation, got %v", got)
+	}
+	if reservedUntil := lastActive.Add(time.Hour); !idleSince(lastActive, reservedUntil).Equal(reservedUntil) {
+		t.Errorf("Expected the end of a later reservation")
+	}
+}
+
+func TestRunnerReservation(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	until := time.Now().Add(4 * time.Hour).Truncate(time.Second)
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{ReservedUntil: until})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if !runner.ReservedUntil.Equal(until) || runner.ToProto().ReservedUntil != until.Unix() {
+		t.Errorf("Expected the runner to be reserved until %v, got %v", until, runner.ReservedUntil)
+	}
+
+	extended := until.Add(2 * time.Hour)
+	updated, err := svc.UpdateRunner(ctx, runner.ID, &UpdateRunnerRequest{ReservedUntil: &extended})
+	if err != nil {
+		t.Fatalf("UpdateRunner failed: %v", err)
+	}
+	if !updated.ReservedUntil.Equal(extended) {
+		t.Errorf("Expected the reservation to be extended to %v, got %v", extended, updated.ReservedUntil)
+	}
+
+	tooLong := time.Now().Add(48 * time.Hour)
+	if _, err := svc.UpdateRunner(ctx, runner.ID, &UpdateRunnerRequest{ReservedUntil: &tooLong}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected a reservation beyond the maximum to be rejected, got %v", err)
+	}
+
+	var cleared time.Time
+	updated, err = svc.UpdateRunner(ctx, runner.ID, &UpdateRunnerRequest{ReservedUntil: &cleared})
+	if err != nil {
+		t.Fatalf("UpdateRunner failed: %v", err)
+	}
+	if !updated.ReservedUntil.IsZero() {
+		t.Errorf("Expected the reservation to be cleared, got %v", updated.ReservedUntil)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	_, err = svc.CreateRunner(ctx, &CreateRunnerRequest{ReservedUntil: past})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Violations[0].Field != "reserved_until" {
+		t.Errorf("Expected a reserved_until violation, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RunnerInstanceLabel carries the runner ID on the runner pod and on every object created for it
+const RunnerInstanceLabel = "app.kubernetes.io/instance"
+
+// RunnerResource identifies a Kubernetes object that belongs to a runner
+type RunnerResource struct {
+	Kind string
+	Name string
+}
+
+// String formats the resource the way kubectl does, e.g. networkpolicy/grad-runner-abc
+func (r RunnerResource) String() string {
+	if r.Name == "" {
+		return strings.ToLower(r.Kind)
+	}
+	return strings.ToLower(r.Kind) + "/" + r.Name
+}
+
+// RunnerResourceFailure is an object that could not be listed or deleted
+type RunnerResourceFailure struct {
+	Resource RunnerResource
+	Err      error
+}
+
+// RunnerCleanup reports what deleting a runner removed and what it left behind
+type RunnerCleanup struct {
+	Deleted []RunnerResource
+	Failed  []RunnerResourceFailure
+}
+
+// Summary describes the failed deletions in one line (pure function)
+func (c *RunnerCleanup) Summary() string {
+	failures := make([]string, 0, len(c.Failed))
+	for _, failure := range c.Failed {
+		failures = append(failures, fmt.Sprintf("%s: %v", failure.Resource, failure.Err))
+	}
+	return strings.Join(failures, "; ")
+}
+
+// runnerResourceKind lists and deletes the objects of one kind that carry a runner's labels.
+// group and resource name the kind for RBAC.
+type runnerResourceKind struct {
+	kind     string
+	group    string
+	resource string
+	list     func(ctx context.Context, k *KubernetesClient, selector string) ([]string, error)
+	delete   func(ctx context.Context, k *KubernetesClient, name string) error
+}
+
+// runnerResourceKinds is the manifest of object kinds grad may create per runner besides its
+// pod. Anything of these kinds labeled with the runner's instance is deleted with the runner.
+var runnerResourceKinds = []runnerResourceKind{
+	{
+		kind:     "Secret",
+		resource: "secrets",
+		list: func(ctx context.Context, k *KubernetesClient, selector string) ([]string, error) {
+			list, err := k.clientset.CoreV1().Secrets(k.config.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return nil, err
+			}
+			names := make([]string, 0, len(list.Items))
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+			return names, nil
+		},
+		delete: func(ctx context.Context, k *KubernetesClient, name string) error {
+			return k.clientset.CoreV1().Secrets(k.config.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	},
+	{
+		kind:     "PersistentVolumeClaim",
+		resource: "persistentvolumeclaims",
+		list: func(ctx context.Context, k *KubernetesClient, selector string) ([]string, error) {
+			list, err := k.clientset.CoreV1().PersistentVolumeClaims(k.config.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return nil, err
+			}
+			names := make([]string, 0, len(list.Items))
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+			return names, nil
+		},
+		delete: func(ctx context.Context, k *KubernetesClient, name string) error {
+			return k.clientset.CoreV1().PersistentVolumeClaims(k.config.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	},
+	{
+		kind:     "NetworkPolicy",
+		group:    "networking.k8s.io",
+		resource: "networkpolicies",
+		list: func(ctx context.Context, k *KubernetesClient, selector string) ([]string, error) {
+			list, err := k.clientset.NetworkingV1().NetworkPolicies(k.config.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return nil, err
+			}
+			names := make([]string, 0, len(list.Items))
+			for _, item := range list.Items {
+				names = append(names, item.Name)
+			}
+			return names, nil
+		},
+		delete: func(ctx context.Context, k *KubernetesClient, name string) error {
+			return k.clientset.NetworkingV1().NetworkPolicies(k.config.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	},
+}
+
+// runnerResourceSelector selects the objects grad created for runnerID (pure function)
+func runnerResourceSelector(runnerID string) string {
+	return RunnerLabelSelector + "," + RunnerInstanceLabel + "=" + runnerID
+}
+
+// DeleteRunnerResources deletes every object in the runner resource manifest that is labeled
+// with runnerID. It keeps going past failures and reports each one in the returned cleanup;
+// objects that are already gone count as deleted.
+func (k *KubernetesClient) DeleteRunnerResources(ctx context.Context, runnerID string) *RunnerCleanup {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	selector := runnerResourceSelector(runnerID)
+	cleanup := &RunnerCleanup{}
+
+	for _, kind := range runnerResourceKinds {
+		names, err := kind.list(ctx, k, selector)
+		if err != nil {
+			cleanup.Failed = append(cleanup.Failed, RunnerResourceFailure{
+				Resource: RunnerResource{Kind: kind.kind},
+				Err:      fmt.Errorf("failed to list: %w", err),
+			})
+			continue
+		}
+
+		for _, name := range names {
+			resource := RunnerResource{Kind: kind.kind, Name: name}
+			if err := kind.delete(ctx, k, name); err != nil && !errors.IsNotFound(err) {
+				cleanup.Failed = append(cleanup.Failed, RunnerResourceFailure{Resource: resource, Err: err})
+				continue
+			}
+			cleanup.Deleted = append(cleanup.Deleted, resource)
+		}
+	}
+
+	return cleanup
+}
+
+// RemainingRunnerResources lists the runner's pod, if it still exists, and every object in the
+// runner resource manifest still labeled with runnerID, including ones being deleted
+func (k *KubernetesClient) RemainingRunnerResources(ctx context.Context, runnerID string) ([]RunnerResource, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	var remaining []RunnerResource
+
+	pod, err := k.GetRunnerPod(ctx, runnerID)
+	switch {
+	case err == nil:
+		remaining = append(remaining, RunnerResource{Kind: "Pod", Name: pod.Name})
+	case !errors.IsNotFound(err):
+		return nil, err
+	}
+
+	selector := runnerResourceSelector(runnerID)
+	for _, kind := range runnerResourceKinds {
+		names, err := kind.list(ctx, k, selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s objects: %w", kind.kind, err)
+		}
+		for _, name := range names {
+			remaining = append(remaining, RunnerResource{Kind: kind.kind, Name: name})
+		}
+	}
+
+	return remaining, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// emulatePodFinalizers makes pod deletion on clientset honor finalizers the way the API server
+// does: a pod with finalizers is only marked for deletion, and goes away once they are removed
+func emulatePodFinalizers(clientset *fake.Clientset) {
+	pods := corev1.SchemeGroupVersion.WithResource("pods")
+	tracker := clientset.Tracker()
+
+	clientset.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleteAction := action.(k8stesting.DeleteAction)
+		obj, err := tracker.Get(pods, deleteAction.GetNamespace(), deleteAction.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		pod := obj.(*corev1.Pod)
+		if len(pod.Finalizers) == 0 {
+			return false, nil, nil
+		}
+		now := metav1.Now()
+		pod.DeletionTimestamp = &now
+		return true, pod, tracker.Update(pods, pod, pod.Namespace)
+	})
+
+	clientset.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		handled, obj, err := k8stesting.ObjectReaction(tracker)(action)
+		if err != nil {
+			return handled, obj, err
+		}
+		pod := obj.(*corev1.Pod)
+		if pod.DeletionTimestamp == nil || len(pod.Finalizers) > 0 {
+			return handled, obj, nil
+		}
+		return true, pod, tracker.Delete(pods, pod.Namespace, pod.Name)
+	})
+}
+
+// runnerObjectMeta labels an object as belonging to runnerID
+func runnerObjectMeta(namespace, name, runnerID string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels: map[string]string{
+			"app.kubernetes.io/managed-by": "grad",
+			RunnerInstanceLabel:            runnerID,
+		},
+	}
+}
+
+func TestDeleteRunnerGarbageCollectsResources(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	emulatePodFinalizers(clientset)
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	namespace := svc.k8sClient.Config().Namespace
+	objects := []runtime.Object{
+		&corev1.Secret{ObjectMeta: runnerObjectMeta(namespace, "credentials", runner.ID)},
+		&corev1.PersistentVolumeClaim{ObjectMeta: runnerObjectMeta(namespace, "workspace", runner.ID)},
+		&networkingv1.NetworkPolicy{ObjectMeta: runnerObjectMeta(namespace, "policy", runner.ID)},
+		&corev1.Secret{ObjectMeta: runnerObjectMeta(namespace, "other-credentials", "other-runner")},
+	}
+	for _, obj := range objects {
+		if err := clientset.Tracker().Add(obj); err != nil {
+			t.Fatalf("Failed to add object: %v", err)
+		}
+	}
+
+	// The workspace claim can't be deleted until the failure is cleared
+	failPVCDelete := true
+	clientset.PrependReactor("delete", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failPVCDelete {
+			return true, nil, errors.New("admission webhook denied the request")
+		}
+		return false, nil, nil
+	})
+
+	cleanup, err := svc.DeleteRunner(ctx, runner.ID, false)
+	if !errors.Is(err, ErrCleanupIncomplete) {
+		t.Fatalf("Expected ErrCleanupIncomplete, got %v", err)
+	}
+	if len(cleanup.Failed) != 1 || cleanup.Failed[0].Resource.String() != "persistentvolumeclaim/workspace" {
+		t.Errorf("Expected only the workspace claim to fail, got %v", cleanup.Failed)
+	}
+	if len(cleanup.Deleted) != 2 {
+		t.Errorf("Expected the secret and network policy to be deleted, got %v", cleanup.Deleted)
+	}
+
+	stored, err := svc.GetRunner(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("Expected runner to be kept for retry: %v", err)
+	}
+	if stored.Status != RunnerStatusDeleteFailed {
+		t.Errorf("Expected status %s, got %s", RunnerStatusDeleteFailed, stored.Status)
+	}
+	if _, err := clientset.CoreV1().Secrets(namespace).Get(ctx, "other-credentials", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected another runner's secret to be kept, got %v", err)
+	}
+
+	failPVCDelete = false
+	cleanup, err = svc.DeleteRunner(ctx, runner.ID, false)
+	if err != nil {
+		t.Fatalf("Expected retried DeleteRunner to succeed, got %v", err)
+	}
+	if len(cleanup.Deleted) != 2 || cleanup.Deleted[0].Kind != "Pod" || cleanup.Deleted[1].String() != "persistentvolumeclaim/workspace" {
+		t.Errorf("Expected the pod and workspace claim to be deleted, got %v", cleanup.Deleted)
+	}
+	if _, err := svc.k8sClient.GetRunnerPod(ctx, runner.ID); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected runner pod to be gone, got %v", err)
+	}
+}
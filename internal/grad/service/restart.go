@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Restart bookkeeping on runner pods
+const (
+	// RunnerAutoRestartLabel is "true" on the pods of runners that are recreated if they fail.
+	// It is a label rather than an annotation so the supervisor only lists those pods.
+	RunnerAutoRestartLabel = RunnerAnnotationPrefix + "auto-restart"
+	// RunnerRestartCountAnnotation counts how often the runner's pod was recreated
+	RunnerRestartCountAnnotation = RunnerAnnotationPrefix + "restart-count"
+	// RunnerRestartStatusAnnotation is RestartStatusRestarting from the pod failing until its
+	// replacement is ready, or RestartStatusGaveUp once the runner ran out of restarts
+	RunnerRestartStatusAnnotation = RunnerAnnotationPrefix + "restart-status"
+	// RunnerRestartAtAnnotation is when a failed pod is due to be recreated, in RFC 3339
+	RunnerRestartAtAnnotation = RunnerAnnotationPrefix + "restart-at"
+
+	RestartStatusRestarting = "restarting"
+	RestartStatusGaveUp     = "gave_up"
+)
+
+const (
+	// MaxRestartBackoff caps the wait before recreating a failed pod
+	MaxRestartBackoff = 10 * time.Minute
+	// podDeleteTimeout bounds how long a restart waits for the failed pod to go away
+	podDeleteTimeout = 2 * time.Minute
+	// podDeletePollInterval is how often a restart checks whether the failed pod is gone
+	podDeletePollInterval = time.Second
+)
+
+// restartBackoff returns the wait before a runner that was restarted restarts times is
+// recreated again: base, doubled for every earlier restart, up to MaxRestartBackoff (pure function)
+func restartBackoff(base time.Duration, restarts int32) time.Duration {
+	backoff := base
+	for i := int32(0); i < restarts && backoff < MaxRestartBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, MaxRestartBackoff)
+}
+
+// podRestartCount returns how often the runner's pod was recreated (pure function)
+func podRestartCount(pod *corev1.Pod) int32 {
+	count, err := strconv.ParseInt(pod.Annotations[RunnerRestartCountAnnotation], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(count)
+}
+
+// applyRestartStatus reports a runner as restarting while its failed pod waits to be
+// recreated and while the replacement starts (pure function)
+func applyRestartStatus(status RunnerStatus, restartStatus string) RunnerStatus {
+	if restartStatus != RestartStatusRestarting {
+		return status
+	}
+	if status == RunnerStatusError || status == RunnerStatusCreating {
+		return RunnerStatusRestarting
+	}
+	return status
+}
+
+// podFailureReason describes why a pod failed, e.g. "NodeLost" (pure function)
+func podFailureReason(pod *corev1.Pod) string {
+	switch {
+	case pod.Status.Reason != "":
+		return pod.Status.Reason
+	case pod.Status.Message != "":
+		return pod.Status.Message
+	default:
+		return "unknown reason"
+	}
+}
+
+// replacementPod returns a pod with the spec, labels and annotations of a failed one, to be
+// created once it is gone. The replacement may land on any node and bootstraps again. (pure function)
+func replacementPod(failed *corev1.Pod, restartCount int32) *corev1.Pod {
+	annotations := maps.Clone(failed.Annotations)
+	annotations[RunnerRestartCountAnnotation] = strconv.Itoa(int(restartCount))
+	annotations[RunnerRestartStatusAnnotation] = RestartStatusRestarting
+	delete(annotations, RunnerRestartAtAnnotation)
+	delete(annotations, RunnerStatusDetailAnnotation)
+	delete(annotations, RunnerErrorReasonAnnotation)
+	delete(annotations, RunnerErrorMessageAnnotation)
+	delete(annotations, RunnerSSHSessionsAnnotation)
+	delete(annotations, RunnerBootstrapFinishedAnnotation)
+	if annotations[RunnerBootstrapCommandAnnotation] != "" {
+		annotations[RunnerBootstrapStatusAnnotation] = BootstrapStatusPending
+	}
+
+	spec := failed.Spec.DeepCopy()
+	spec.NodeName = ""
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        failed.Name,
+			Namespace:   failed.Namespace,
+			Labels:      maps.Clone(failed.Labels),
+			Annotations: annotations,
+			Finalizers:  slices.Clone(failed.Finalizers),
+		},
+		Spec: *spec,
+	}
+}
+
+// ListAutoRestartPods lists the pods of runners that are recreated if they fail
+func (k *KubernetesClient) ListAutoRestartPods(ctx context.Context) (*corev1.PodList, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	labelSelector := RunnerLabelSelector + "," + RunnerComponentLabel + "," + RunnerAutoRestartLabel + "=true"
+
+	pods, err := k.clientset.CoreV1().Pods(k.config.Namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auto-restart runner pods: %w", err)
+	}
+	return pods, nil
+}
+
+// ReplaceRunnerPod deletes a failed runner pod and creates replacement under the same name.
+// The runner's other objects are orphaned instead of deleted along with the pod, and the
+// replacement takes over its NetworkPolicy.
+func (k *KubernetesClient) ReplaceRunnerPod(ctx context.Context, failed, replacement *corev1.Pod) error {
+	pods := k.clientset.CoreV1().Pods(k.config.Namespace)
+
+	gracePeriodSeconds := int64(0)
+	propagationPolicy := metav1.DeletePropagationOrphan
+	deleteCtx, cancelDelete := k.apiContext(ctx)
+	err := pods.Delete(deleteCtx, failed.Name, metav1.DeleteOptions{
+		GracePeriodSeconds: &gracePeriodSeconds,
+		PropagationPolicy:  &propagationPolicy,
+	})
+	cancelDelete()
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete failed pod: %w", err)
+	}
+	if err := k.RemoveRunnerFinalizer(ctx, failed.Name); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err := k.waitForPodDeleted(ctx, failed.Name); err != nil {
+		return err
+	}
+
+	createCtx, cancelCreate := k.apiContext(ctx)
+	created, err := pods.Create(createCtx, replacement, metav1.CreateOptions{})
+	cancelCreate()
+	if err != nil {
+		return fmt.Errorf("failed to create replacement pod: %w", err)
+	}
+
+	if err := k.adoptRunnerNetworkPolicy(ctx, created); err != nil {
+		slog.Warn("Failed to set replacement pod as network policy owner", "runner_id", created.Annotations[RunnerIDAnnotation], "error", err)
+	}
+	return nil
+}
+
+// waitForPodDeleted waits until the pod named podName no longer exists
+func (k *KubernetesClient) waitForPodDeleted(ctx context.Context, podName string) error {
+	ctx, cancel := context.WithTimeout(ctx, podDeleteTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(podDeletePollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := k.clientset.CoreV1().Pods(k.config.Namespace).Get(ctx, podName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed pod %s was not deleted: %w", podName, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// superviseRestarts looks for failed auto-restart runners every monitorInterval until ctx is done
+func (s *runnerService) superviseRestarts(ctx context.Context) {
+	ticker := time.NewTicker(s.monitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.restartFailedRunners(ctx)
+		}
+	}
+}
+
+// restartFailedRunners moves every failed auto-restart runner one step along: a newly failed
+// pod is scheduled for a restart after the backoff, a pod whose backoff is over is recreated,
+// and a runner out of restarts is left in error.
+func (s *runnerService) restartFailedRunners(ctx context.Context) {
+	pods, err := s.k8sClient.ListAutoRestartPods(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("Failed to list runners to restart", "error", err)
+		}
+		return
+	}
+
+	config := s.k8sClient.Config().restart()
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if MapPodStatusToRunnerStatus(pod) != RunnerStatusError || pod.Annotations[RunnerDeleteStatusAnnotation] == DeleteStatusFailed {
+			continue
+		}
+
+		restarts := podRestartCount(pod)
+		switch {
+		case pod.Annotations[RunnerRestartStatusAnnotation] == RestartStatusGaveUp:
+			continue
+		case restarts >= int32(config.MaxRestarts):
+			s.giveUpRestart(ctx, pod, config.MaxRestarts)
+		case pod.Annotations[RunnerRestartAtAnnotation] == "":
+			s.scheduleRestart(ctx, pod, restarts, config)
+		default:
+			restartAt, err := time.Parse(time.RFC3339, pod.Annotations[RunnerRestartAtAnnotation])
+			if err == nil && s.now().Before(restartAt) {
+				continue
+			}
+			s.restartRunner(ctx, pod, restarts)
+		}
+	}
+}
+
+// scheduleRestart marks a failed runner as restarting once its backoff is over
+func (s *runnerService) scheduleRestart(ctx context.Context, pod *corev1.Pod, restarts int32, config *RestartConfig) {
+	runnerID := pod.Annotations[RunnerIDAnnotation]
+	backoff := restartBackoff(config.Backoff, restarts)
+	// A pod whose node shut down, such as a reclaimed spot node, didn't fail by itself
+	if podNodeShutDown(pod) {
+		backoff = 0
+	}
+	reason := podFailureReason(pod)
+
+	slog.Warn("Runner pod failed, scheduling restart", "runner_id", runnerID, "reason", reason,
+		"restart", restarts+1, "max_restarts", config.MaxRestarts, "backoff", backoff.String())
+
+	if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, map[string]string{
+		RunnerRestartStatusAnnotation: RestartStatusRestarting,
+		RunnerRestartAtAnnotation:     s.now().Add(backoff).Format(time.RFC3339),
+		RunnerStatusDetailAnnotation:  fmt.Sprintf("pod failed (%s), restart %d of %d in %s", reason, restarts+1, config.MaxRestarts, backoff),
+	}); err != nil {
+		slog.Warn("Failed to schedule runner restart", "runner_id", runnerID, "error", err)
+	}
+}
+
+// giveUpRestart leaves a runner that ran out of restarts in error
+func (s *runnerService) giveUpRestart(ctx context.Context, pod *corev1.Pod, maxRestarts int) {
+	runnerID := pod.Annotations[RunnerIDAnnotation]
+	reason := podFailureReason(pod)
+
+	slog.Error("Runner pod failed and ran out of restarts", "runner_id", runnerID, "reason", reason, "max_restarts", maxRestarts)
+	s.metrics.RunnerRestartsTotal.WithLabelValues(RestartResultGaveUp).Inc()
+	errorReason, _ := runnerError(pod, RunnerStatusError)
+	s.metrics.RunnerErrorsTotal.WithLabelValues(string(errorReason)).Inc()
+
+	if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, map[string]string{
+		RunnerRestartStatusAnnotation: RestartStatusGaveUp,
+		RunnerStatusDetailAnnotation:  fmt.Sprintf("pod failed (%s), not restarted after %d restarts", reason, maxRestarts),
+	}); err != nil {
+		slog.Warn("Failed to mark runner as out of restarts", "runner_id", runnerID, "error", err)
+	}
+}
+
+// restartRunner recreates the pod of a failed runner and monitors the replacement like a new runner
+func (s *runnerService) restartRunner(ctx context.Context, pod *corev1.Pod, restarts int32) {
+	runnerID := pod.Annotations[RunnerIDAnnotation]
+	s.cancelMonitor(runnerID)
+
+	startedAt := s.now()
+	if err := s.k8sClient.ReplaceRunnerPod(ctx, pod, replacementPod(pod, restarts+1)); err != nil {
+		if ctx.Err() == nil {
+			slog.Error("Failed to restart runner", "runner_id", runnerID, "restart", restarts+1, "error", err)
+			s.metrics.RunnerRestartsTotal.WithLabelValues(RestartResultFailed).Inc()
+		}
+		return
+	}
+
+	slog.Info("Restarted runner", "runner_id", runnerID, "restart_count", restarts+1)
+	s.metrics.RunnerRestartsTotal.WithLabelValues(RestartResultRestarted).Inc()
+	s.startMonitor(runnerID, startedAt, pod.Annotations[RunnerBootstrapCommandAnnotation])
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	tests := []struct {
+		base     time.Duration
+		restarts int32
+		expected time.Duration
+	}{
+		{10 * time.Second, 0, 10 * time.Second},
+		{10 * time.Second, 1, 20 * time.Second},
+		{10 * time.Second, 3, 80 * time.Second},
+		{10 * time.Second, 10, MaxRestartBackoff},
+		{10 * time.Second, 1000, MaxRestartBackoff},
+		{MaxRestartBackoff, 1, MaxRestartBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := restartBackoff(tt.base, tt.restarts); got != tt.expected {
+			t.Errorf("Expected backoff %s for base %s after %d restarts, got %s", tt.expected, tt.base, tt.restarts, got)
+		}
+	}
+}
+
+func TestApplyRestartStatus(t *testing.T) {
+	tests := []struct {
+		status        RunnerStatus
+		restartStatus string
+		expected      RunnerStatus
+	}{
+		{RunnerStatusError, "", RunnerStatusError},
+		{RunnerStatusError, RestartStatusRestarting, RunnerStatusRestarting},
+		{RunnerStatusCreating, RestartStatusRestarting, RunnerStatusRestarting},
+		{RunnerStatusRunning, RestartStatusRestarting, RunnerStatusRunning},
+		{RunnerStatusStopping, RestartStatusRestarting, RunnerStatusStopping},
+		{RunnerStatusError, RestartStatusGaveUp, RunnerStatusError},
+	}
+
+	for _, tt := range tests {
+		if got := applyRestartStatus(tt.status, tt.restartStatus); got != tt.expected {
+			t.Errorf("Expected %s for %s with restart status %q, got %s", tt.expected, tt.status, tt.restartStatus, got)
+		}
+	}
+}
+
+func TestReplacementPod(t *testing.T) {
+	runner := &Runner{ID: "runner-1", Name: "runner-1", BootstrapCommand: "make setup", AutoRestart: true}
+	failed := buildPodCreationRequest(t, runner, DefaultKubernetesConfig()).ToPodSpec()
+	failed.UID = "uid-1"
+	failed.ResourceVersion = "42"
+	failed.Spec.NodeName = "node-1"
+	failed.Status.Phase = corev1.PodFailed
+	failed.Annotations[RunnerBootstrapStatusAnnotation] = BootstrapStatusSucceeded
+	failed.Annotations[RunnerRestartCountAnnotation] = "1"
+	failed.Annotations[RunnerRestartAtAnnotation] = time.Now().Format(time.RFC3339)
+	failed.Annotations[RunnerStatusDetailAnnotation] = "pod failed (NodeLost), restart 2 of 3 in 20s"
+
+	replacement := replacementPod(failed, 2)
+
+	if replacement.Name != failed.Name || replacement.UID != "" || replacement.ResourceVersion != "" {
+		t.Errorf("Expected a new pod named %s, got name %s, UID %q, resource version %q",
+			failed.Name, replacement.Name, replacement.UID, replacement.ResourceVersion)
+	}
+	if replacement.Spec.NodeName != "" {
+		t.Errorf("Expected the replacement not to be bound to a node, got %s", replacement.Spec.NodeName)
+	}
+	if replacement.Status.Phase != "" {
+		t.Errorf("Expected the replacement to have no status, got phase %s", replacement.Status.Phase)
+	}
+	if replacement.Labels[RunnerAutoRestartLabel] != "true" {
+		t.Errorf("Expected the replacement to keep the auto-restart label, got %v", replacement.Labels)
+	}
+	if len(replacement.Finalizers) != 1 || replacement.Finalizers[0] != RunnerFinalizer {
+		t.Errorf("Expected the runner finalizer, got %v", replacement.Finalizers)
+	}
+	if got := podRestartCount(replacement); got != 2 {
+		t.Errorf("Expected restart count 2, got %d", got)
+	}
+	if got := replacement.Annotations[RunnerBootstrapStatusAnnotation]; got != BootstrapStatusPending {
+		t.Errorf("Expected the bootstrap command to run again, got bootstrap status %q", got)
+	}
+	for _, key := range []string{RunnerRestartAtAnnotation, RunnerStatusDetailAnnotation} {
+		if value, ok := replacement.Annotations[key]; ok {
+			t.Errorf("Expected no %s annotation, got %q", key, value)
+		}
+	}
+
+	// The failed pod is left as it was
+	if failed.Spec.NodeName != "node-1" || failed.Annotations[RunnerRestartCountAnnotation] != "1" {
+		t.Errorf("Expected the failed pod to be unchanged, got node %s and restart count %s",
+			failed.Spec.NodeName, failed.Annotations[RunnerRestartCountAnnotation])
+	}
+}
+
+// failRunnerPod puts a runner's pod in the Failed phase on node-1, as a node failure would
+func failRunnerPod(t *testing.T, svc *runnerService, clientset *fake.Clientset, runnerID string) {
+	t.Helper()
+
+	ctx := context.Background()
+	pod, err := svc.k8sClient.GetRunnerPod(ctx, runnerID)
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	pod.Spec.NodeName = "node-1"
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.Reason = "NodeLost"
+	pod.Status.Conditions = nil
+	if _, err := clientset.CoreV1().Pods(pod.Namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update pod: %v", err)
+	}
+}
+
+func TestRestartFailedRunnersUpToMaxRestarts(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	emulatePodFinalizers(clientset)
+	svc.k8sClient.Config().Restart = &RestartConfig{MaxRestarts: 2, Backoff: time.Minute}
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{AutoRestart: &[]bool{true}[0]})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if !runner.AutoRestart {
+		t.Errorf("Expected the runner to auto-restart")
+	}
+	other, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	failRunnerPod(t, svc, clientset, other.ID)
+
+	for restart := int32(1); restart <= 2; restart++ {
+		failRunnerPod(t, svc, clientset, runner.ID)
+
+		svc.restartFailedRunners(ctx)
+		got, err := svc.GetRunner(ctx, runner.ID)
+		if err != nil {
+			t.Fatalf("GetRunner failed: %v", err)
+		}
+		if got.Status != RunnerStatusRestarting || got.RestartCount != restart-1 {
+			t.Fatalf("Expected restart %d to be scheduled, got status %s and restart count %d", restart, got.Status, got.RestartCount)
+		}
+
+		// Nothing happens until the backoff is over
+		svc.restartFailedRunners(ctx)
+		if pod, _ := svc.k8sClient.GetRunnerPod(ctx, runner.ID); pod.Status.Phase != corev1.PodFailed {
+			t.Fatalf("Expected the pod to be recreated only after the backoff, got phase %s", pod.Status.Phase)
+		}
+
+		now = now.Add(restartBackoff(time.Minute, restart-1))
+		svc.restartFailedRunners(ctx)
+		pod, err := svc.k8sClient.GetRunnerPod(ctx, runner.ID)
+		if err != nil {
+			t.Fatalf("GetRunnerPod failed: %v", err)
+		}
+		if pod.Status.Phase != corev1.PodPending || pod.Spec.NodeName != "" {
+			t.Fatalf("Expected a new pending pod, got phase %s on node %q", pod.Status.Phase, pod.Spec.NodeName)
+		}
+		got = PodToRunner(pod)
+		if got.Status != RunnerStatusRestarting || got.RestartCount != restart {
+			t.Errorf("Expected the new pod to be restarting with restart count %d, got status %s and restart count %d", restart, got.Status, got.RestartCount)
+		}
+
+		// The monitor ends the restart once the new pod is ready
+		setPodStatus(t, svc, clientset, runner.ID, corev1.PodRunning, true)
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if got, _ = svc.GetRunner(ctx, runner.ID); got.Status == RunnerStatusRunning {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got.Status != RunnerStatusRunning {
+			t.Fatalf("Expected the restarted runner to be running, got %s", got.Status)
+		}
+		waitForMonitors(t, svc)
+	}
+
+	// The third failure is one too many
+	failRunnerPod(t, svc, clientset, runner.ID)
+	svc.restartFailedRunners(ctx)
+	now = now.Add(time.Hour)
+	svc.restartFailedRunners(ctx)
+
+	got, err := svc.GetRunner(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if got.Status != RunnerStatusError || got.RestartCount != 2 {
+		t.Errorf("Expected the runner to stay in error after 2 restarts, got status %s and restart count %d", got.Status, got.RestartCount)
+	}
+	if !strings.Contains(got.StatusDetail, "NodeLost") || !strings.Contains(got.StatusDetail, "not restarted") {
+		t.Errorf("Expected the status detail to explain why the runner wasn't restarted, got %q", got.StatusDetail)
+	}
+	if got.ErrorReason != RunnerErrorPodFailed || !strings.Contains(got.ErrorMessage, "NodeLost") {
+		t.Errorf("Expected a pod_failed error mentioning NodeLost, got %q: %q", got.ErrorReason, got.ErrorMessage)
+	}
+	// The other runner failed while it was still being monitored; the restarted one once it ran out of restarts
+	if count := testutil.ToFloat64(svc.metrics.RunnerErrorsTotal.WithLabelValues(string(RunnerErrorPodFailed))); count != 2 {
+		t.Errorf("Expected 2 pod_failed runner errors, got %v", count)
+	}
+
+	if restarted := testutil.ToFloat64(svc.metrics.RunnerRestartsTotal.WithLabelValues(RestartResultRestarted)); restarted != 2 {
+		t.Errorf("Expected 2 restarts to be counted, got %v", restarted)
+	}
+	if gaveUp := testutil.ToFloat64(svc.metrics.RunnerRestartsTotal.WithLabelValues(RestartResultGaveUp)); gaveUp != 1 {
+		t.Errorf("Expected giving up to be counted once, got %v", gaveUp)
+	}
+
+	// Runners without auto-restart are left alone
+	got, err = svc.GetRunner(ctx, other.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if got.Status != RunnerStatusError || got.RestartCount != 0 {
+		t.Errorf("Expected the other runner to stay in error, got status %s and restart count %d", got.Status, got.RestartCount)
+	}
+}
+
+func TestCreateRunnerAutoRestartDefault(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.k8sClient.Config().Restart = &RestartConfig{Default: true, MaxRestarts: 3, Backoff: time.Second}
+	defer svc.Stop()
+
+	tests := []struct {
+		name        string
+		autoRestart *bool
+		expected    bool
+	}{
+		{"Server default", nil, true},
+		{"Opted out", &[]bool{false}[0], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{AutoRestart: tt.autoRestart})
+			if err != nil {
+				t.Fatalf("CreateRunner failed: %v", err)
+			}
+			if runner.AutoRestart != tt.expected {
+				t.Errorf("Expected auto-restart %v, got %v", tt.expected, runner.AutoRestart)
+			}
+		})
+	}
+}
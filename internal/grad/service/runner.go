@@ -3,31 +3,196 @@ package service
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/strrl/gra/internal/grad/metrics"
 )
 
+// runnerPreset is the name of the resource preset every runner currently uses
+const runnerPreset = "small"
+
 // runnerService implements the RunnerService interface using Kubernetes API
 type runnerService struct {
-	k8sClient       *KubernetesClient
+	k8sClient       PodManager
 	activityTracker *ActivityTracker
+	history         *ExecutionHistory
+	metrics         *metrics.Metrics
+
+	// ctx is the root context for background work; cancelling it stops all monitors
+	ctx              context.Context
+	cancel           context.CancelFunc
+	wg               sync.WaitGroup
+	monitorInterval  time.Duration
+	monitorTimeout   time.Duration
+	bootstrapTimeout time.Duration
+	// flushInterval bounds how long command output is held back before it is streamed
+	flushInterval time.Duration
+	// deleteWaitTimeout caps how long WaitForRunnerDeleted may wait
+	deleteWaitTimeout time.Duration
+	// maxReservation caps how far ahead a runner may be reserved
+	maxReservation time.Duration
+	// sshSessionInterval is how often runners are checked for SSH sessions, zero for never
+	sshSessionInterval time.Duration
+	sshSessionBackoff  *sshSessionBackoff
+	// now is the clock used to measure provisioning time
+	now func() time.Time
+	// startedAt is when the service was created; runners older than it may have lost their activity
+	startedAt time.Time
+
+	monitorsMu sync.Mutex
+	monitors   map[string]*runnerMonitor
+
+	// capacity caches the free resources of the nodes for the capacity check
+	capacity capacityCache
+}
+
+// runnerMonitor tracks the background monitor of a single runner
+type runnerMonitor struct {
+	cancel context.CancelFunc
 }
 
-// NewRunnerService creates a new runner service
-func NewRunnerService(k8sClient *KubernetesClient, activityTracker *ActivityTracker) RunnerService {
+// NewRunnerService creates a new runner service recording provisioning and restarts in m; a
+// nil m records into unregistered metrics
+func NewRunnerService(k8sClient PodManager, activityTracker *ActivityTracker, durations *Durations, m *metrics.Metrics) RunnerService {
+	if m == nil {
+		m = metrics.NewUnregistered()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &runnerService{
-		k8sClient:       k8sClient,
-		activityTracker: activityTracker,
+		k8sClient:          k8sClient,
+		activityTracker:    activityTracker,
+		history:            NewExecutionHistory(DefaultExecutionHistorySize),
+		metrics:            m,
+		ctx:                ctx,
+		cancel:             cancel,
+		monitorInterval:    durations.MonitorInterval,
+		monitorTimeout:     durations.ProvisionTimeout,
+		bootstrapTimeout:   durations.BootstrapTimeout,
+		flushInterval:      durations.ExecOutputFlushInterval,
+		deleteWaitTimeout:  durations.DeleteWaitTimeout,
+		maxReservation:     durations.MaxReservation,
+		sshSessionInterval: durations.SSHSessionCheckInterval,
+		sshSessionBackoff:  newSSHSessionBackoff(),
+		now:                time.Now,
+		startedAt:          time.Now(),
+		monitors:           make(map[string]*runnerMonitor),
 	}
 }
 
+// Start ties the service's background goroutines to ctx; they are stopped once it is cancelled
+func (s *runnerService) Start(ctx context.Context) {
+	slog.Info("Starting runner service",
+		"monitor_interval", s.monitorInterval.String(),
+		"monitor_timeout", s.monitorTimeout.String())
+
+	context.AfterFunc(ctx, s.cancel)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.adoptRunners(s.ctx)
+	}()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.superviseImages(s.ctx)
+	}()
+
+	if s.sshSessionInterval > 0 {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.superviseSSHSessions(s.ctx)
+		}()
+	}
+
+	// Without restarts allowed, failed auto-restart runners just stay in error
+	if restart := s.k8sClient.Config().restart(); restart.MaxRestarts > 0 {
+		slog.Info("Supervising auto-restart runners",
+			"default", restart.Default,
+			"max_restarts", restart.MaxRestarts,
+			"backoff", restart.Backoff.String())
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.superviseRestarts(s.ctx)
+		}()
+	}
+}
+
+// Stop cancels all background goroutines and waits for them to exit
+func (s *runnerService) Stop() {
+	s.monitorsMu.Lock()
+	s.cancel()
+	s.monitorsMu.Unlock()
+
+	s.wg.Wait()
+	slog.Info("Runner service stopped")
+}
+
 // CreateRunner creates a new runner instance
 func (s *runnerService) CreateRunner(ctx context.Context, req *CreateRunnerRequest) (*Runner, error) {
+	createdAt := s.now()
+
+	// The namespace may have been cleaned up along with its last runner
+	if err := s.k8sClient.EnsureNamespace(ctx, s.k8sClient.Config().Namespace); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+	}
+
+	if err := s.k8sClient.EnsureServiceAccount(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+	}
+
+	if err := s.checkExtraCAConfigMap(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkServiceAccount(ctx, req.ServiceAccount); err != nil {
+		return nil, err
+	}
+
+	priorityClass, err := s.checkPriorityClass(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSpot(req, s.k8sClient.Config()); err != nil {
+		return nil, err
+	}
+
+	if err := checkWorkspace(req.Workspace, s.k8sClient.Config().DisabledFeatures); err != nil {
+		return nil, err
+	}
+
+	violations := CreateRunnerViolations(req, s.k8sClient.Config().sidecar())
+	violations.Check("reserved_until", validateReservation(req.ReservedUntil, s.now(), s.maxReservation))
+	violations.Check("arch", validateArch(req.Arch, s.k8sClient.Config().SupportedArchs))
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	var spotNodeSelector map[string]string
+	if req.Spot {
+		spotNodeSelector = s.k8sClient.Config().Spot.NodeSelector
+	}
+	warnings, err := s.checkCapacity(ctx, runnerNodeSelector(req.Arch, spotNodeSelector), req.Workspace)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate simple runner ID by counting existing runners
 	runnerID, err := s.generateRunnerID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("%w: failed to generate runner ID: %v", ErrKubernetesAPI, err)
+		return nil, fmt.Errorf("%w: failed to generate runner ID: %w", ErrKubernetesAPI, err)
 	}
 
 	// Use provided name or generate one
@@ -43,6 +208,12 @@ func (s *runnerService) CreateRunner(ctx context.Context, req *CreateRunnerReque
 		StorageGB:     RunnerSpecPreset.Small.StorageGB,
 	}
 
+	// Spot nodes may be reclaimed at any time, so spot runners come back unless asked not to
+	autoRestart := s.k8sClient.Config().restart().Default || req.Spot
+	if req.AutoRestart != nil {
+		autoRestart = *req.AutoRestart
+	}
+
 	// Create runner object for pod creation
 	runner := &Runner{
 		ID:        runnerID,
@@ -56,50 +227,132 @@ func (s *runnerService) CreateRunner(ctx context.Context, req *CreateRunnerReque
 			Port:     22,
 			Username: "runner",
 		},
-		IPAddress: "127.0.0.1", // Will be updated with actual pod IP
-		Env:       req.Env,
-		Workspace: req.Workspace,
+		IPAddress:        "127.0.0.1", // Will be updated with actual pod IP
+		Env:              req.Env,
+		Workspace:        req.Workspace,
+		BootstrapCommand: req.BootstrapCommand,
+		DisableProxy:     req.DisableProxy,
+
+		ServiceAccount:             req.ServiceAccount,
+		DisableServiceAccountToken: req.DisableServiceAccountToken,
+		DefaultShell:               req.DefaultShell,
+		DefaultWorkdir:             req.DefaultWorkdir,
+		Description:                req.Description,
+		Labels:                     req.Labels,
+		Group:                      req.Group,
+		HostAliases:                req.HostAliases,
+		AutoRestart:                autoRestart,
+		ReservedUntil:              req.ReservedUntil,
+		PriorityClassName:          priorityClass,
+		Arch:                       req.Arch,
+		Protected:                  req.Protected,
+		Spot:                       req.Spot,
 	}
 
 	// Create Kubernetes pod with proper annotations and finalizers
 	if err := s.k8sClient.CreateRunnerPod(ctx, runner); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrKubernetesAPI, err)
+		s.metrics.RunnerProvisioningFailuresTotal.WithLabelValues(ProvisioningFailureCreate).Inc()
+		if isSpecError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
 	}
 
+	// Watch the pod in the background to measure provisioning time and run the bootstrap command
+	s.startMonitor(runnerID, createdAt, runner.BootstrapCommand)
+
 	// Get the created pod to return accurate information from Kubernetes
 	pod, err := s.k8sClient.GetRunnerPod(ctx, runnerID)
 	if err != nil {
-		return nil, fmt.Errorf("%w: failed to get created pod: %v", ErrKubernetesAPI, err)
+		// The caller never learns the runner's ID, so it mustn't be left running
+		s.metrics.RunnerProvisioningFailuresTotal.WithLabelValues(ProvisioningFailureCreate).Inc()
+		s.rollbackRunner(ctx, runnerID)
+		return nil, fmt.Errorf("%w: failed to get created pod: %w", ErrKubernetesAPI, err)
 	}
+	s.k8sClient.RecordPodEvent(pod, corev1.EventTypeNormal, EventReasonRunnerCreated, "Created runner %s", runnerID)
+
+	created := PodToRunner(pod)
+	created.Warnings = warnings
+	return created, nil
+}
+
+// rollbackRunner deletes a runner whose creation failed after its pod was created, along with
+// its other objects. Failures are only logged, as CreateRunner fails either way.
+func (s *runnerService) rollbackRunner(ctx context.Context, runnerID string) {
+	s.cancelMonitor(runnerID)
 
-	return PodToRunner(pod), nil
+	// The creation may have failed because the request ended, which mustn't stop the rollback
+	ctx = context.WithoutCancel(ctx)
+	if err := s.k8sClient.DeleteRunnerPod(ctx, runnerID); err != nil && !errors.IsNotFound(err) {
+		slog.Error("Failed to roll back runner", "runner_id", runnerID, "error", err)
+		return
+	}
+	if cleanup := s.k8sClient.DeleteRunnerResources(ctx, runnerID); len(cleanup.Failed) > 0 {
+		slog.Error("Failed to roll back runner", "runner_id", runnerID, "error", cleanup.Summary())
+		return
+	}
+	podName := BuildPodDeletionRequest(runnerID, s.k8sClient.Config()).PodName
+	if err := s.k8sClient.RemoveRunnerFinalizer(ctx, podName); err != nil && !errors.IsNotFound(err) {
+		slog.Error("Failed to roll back runner", "runner_id", runnerID, "error", err)
+		return
+	}
+	slog.Info("Rolled back runner", "runner_id", runnerID)
 }
 
-// DeleteRunner removes a runner instance with proper finalizer cleanup
-func (s *runnerService) DeleteRunner(ctx context.Context, runnerID string) error {
+// DeleteRunner removes a runner instance with proper finalizer cleanup. A protected runner
+// fails with ErrRunnerProtected unless overrideProtection is set.
+func (s *runnerService) DeleteRunner(ctx context.Context, runnerID string, overrideProtection bool) (*RunnerCleanup, error) {
 	// Check if runner pod exists
-	pod, err := s.k8sClient.GetRunnerPod(ctx, runnerID)
+	pod, err := s.getRunnerPod(ctx, runnerID)
 	if err != nil {
-		return ErrRunnerNotFound
+		return nil, err
 	}
-
-	// Remove finalizer to allow Kubernetes to delete the pod
-	if err := s.k8sClient.RemoveRunnerFinalizer(ctx, pod.Name); err != nil {
-		return fmt.Errorf("%w: failed to remove finalizer: %v", ErrKubernetesAPI, err)
+	if err := checkDeletable(PodToRunner(pod), overrideProtection); err != nil {
+		return nil, err
 	}
 
-	// Delete Kubernetes pod
+	// Stop monitoring before tearing down so the monitor can't overwrite the status
+	s.cancelMonitor(runnerID)
+
+	// Recorded first since the event can't be attached to the pod once it is gone
+	s.k8sClient.RecordPodEvent(pod, corev1.EventTypeNormal, EventReasonRunnerDeleted, "Force-deleting runner %s without a grace period", runnerID)
+
+	// Delete Kubernetes pod. Its finalizer keeps the object around until the runner's
+	// other objects are gone, so a failed cleanup leaves something to retry from.
 	if err := s.k8sClient.DeleteRunnerPod(ctx, runnerID); err != nil {
 		// If pod doesn't exist, that's fine (already deleted)
 		if !errors.IsNotFound(err) {
-			return fmt.Errorf("%w: %v", ErrKubernetesAPI, err)
+			return nil, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+		}
+	}
+
+	cleanup := s.k8sClient.DeleteRunnerResources(ctx, runnerID)
+	if len(cleanup.Failed) > 0 {
+		if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, map[string]string{
+			RunnerDeleteStatusAnnotation: DeleteStatusFailed,
+			RunnerStatusDetailAnnotation: cleanup.Summary(),
+		}); err != nil {
+			slog.Warn("Failed to mark runner as delete failed", "runner_id", runnerID, "error", err)
 		}
+		return cleanup, fmt.Errorf("%w: %s", ErrCleanupIncomplete, cleanup.Summary())
+	}
+
+	// Remove finalizer to allow Kubernetes to delete the pod
+	if err := s.k8sClient.RemoveRunnerFinalizer(ctx, pod.Name); err != nil && !errors.IsNotFound(err) {
+		return cleanup, fmt.Errorf("%w: failed to remove finalizer: %w", ErrKubernetesAPI, err)
 	}
+	cleanup.Deleted = append([]RunnerResource{{Kind: "Pod", Name: pod.Name}}, cleanup.Deleted...)
 
 	// Remove runner from activity tracking
 	s.activityTracker.RemoveRunner(runnerID)
+	s.history.RemoveRunner(runnerID)
 
-	return nil
+	// The runner is gone either way, so a failed namespace cleanup is only logged
+	if err := s.k8sClient.CleanupNamespace(ctx, pod.Namespace); err != nil {
+		slog.Warn("Failed to clean up runner namespace", "namespace", pod.Namespace, "error", err)
+	}
+
+	return cleanup, nil
 }
 
 // ListRunners returns all available runners by querying Kubernetes API
@@ -113,22 +366,49 @@ func (s *runnerService) ListRunners(ctx context.Context, opts *ListOptions) ([]*
 	// List runner pods from Kubernetes
 	podList, err := s.k8sClient.ListRunnerPods(ctx)
 	if err != nil {
-		return nil, 0, fmt.Errorf("%w: %v", ErrKubernetesAPI, err)
+		return nil, 0, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
 	}
 
 	// Convert pods to runners and filter by status
 	runners := make([]*Runner, 0, len(podList.Items))
 	for _, pod := range podList.Items {
 		runner := PodToRunner(&pod)
+		s.adoptRunner(&pod, runner)
+		s.markStaleImage(runner)
 
 		// Filter by status if specified
 		if status != RunnerStatusUnspecified && runner.Status != status {
 			continue
 		}
+		if opts != nil && !runnerMatchesFilter(runner, opts.Filter) {
+			continue
+		}
+		if opts != nil && opts.Group != "" && runner.Group != opts.Group {
+			continue
+		}
+		if opts != nil && !runnerMatchesImageDigest(runner, opts.ImageDigest) {
+			continue
+		}
+		if opts != nil && !labels.SelectorFromSet(opts.Labels).Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if opts != nil && opts.StaleOnly && !runner.StaleImage {
+			continue
+		}
+		if opts != nil && opts.Arch != "" && runner.Arch != opts.Arch {
+			continue
+		}
+		if opts != nil && opts.Name != "" && runner.Name != opts.Name {
+			continue
+		}
 
 		runners = append(runners, runner)
 	}
 
+	if opts != nil {
+		sortRunners(runners, opts.OrderBy, opts.Reverse)
+	}
+
 	// Apply pagination
 	total := int32(len(runners))
 	if opts != nil {
@@ -156,37 +436,388 @@ func (s *runnerService) ListRunners(ctx context.Context, opts *ListOptions) ([]*
 // GetRunner returns details about a specific runner by querying Kubernetes API
 func (s *runnerService) GetRunner(ctx context.Context, runnerID string) (*Runner, error) {
 	// Get runner pod from Kubernetes
-	pod, err := s.k8sClient.GetRunnerPod(ctx, runnerID)
+	pod, err := s.getRunnerPod(ctx, runnerID)
 	if err != nil {
-		return nil, ErrRunnerNotFound
+		return nil, err
+	}
+
+	runner := PodToRunner(pod)
+	s.adoptRunner(pod, runner)
+	s.markStaleImage(runner)
+	// Usage is best effort: it's missing without metrics-server and for brand new pods
+	if usage, err := s.k8sClient.RunnerUsage(ctx, runnerID); err == nil {
+		runner.Usage = usage
+	}
+	return runner, nil
+}
+
+// UpdateRunner changes the runner fields set in req and returns the updated runner
+func (s *runnerService) UpdateRunner(ctx context.Context, runnerID string, req *UpdateRunnerRequest) (*Runner, error) {
+	annotations := make(map[string]string)
+	if req.Description != nil {
+		if err := validateDescription(*req.Description); err != nil {
+			return nil, err
+		}
+		annotations[RunnerDescriptionAnnotation] = encodeAnnotationText(*req.Description)
+	}
+	if req.ReservedUntil != nil {
+		if err := validateReservation(*req.ReservedUntil, s.now(), s.maxReservation); err != nil {
+			return nil, err
+		}
+		annotations[RunnerReservedUntilAnnotation] = encodeReservation(*req.ReservedUntil)
+	}
+	if req.Protected != nil {
+		annotations[RunnerProtectedAnnotation] = encodeProtected(*req.Protected)
+	}
+
+	if _, err := s.getRunnerPod(ctx, runnerID); err != nil {
+		return nil, err
+	}
+
+	if len(annotations) > 0 {
+		if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, annotations); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+		}
 	}
 
-	return PodToRunner(pod), nil
+	return s.GetRunner(ctx, runnerID)
+}
+
+// CloneRunner creates a runner with the configuration of sourceRunnerID, named name
+func (s *runnerService) CloneRunner(ctx context.Context, sourceRunnerID, name string) (*Runner, error) {
+	pod, err := s.getRunnerPod(ctx, sourceRunnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCloneSource(PodToRunner(pod)); err != nil {
+		return nil, err
+	}
+
+	return s.CreateRunner(ctx, cloneRunnerRequest(pod, name, s.k8sClient.Config()))
 }
 
 // ExecuteCommandStream executes a command in a specific runner with streaming output
-func (s *runnerService) ExecuteCommandStream(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (int32, error) {
+func (s *runnerService) ExecuteCommandStream(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
 	// Check if runner exists and is running
-	pod, err := s.k8sClient.GetRunnerPod(ctx, req.RunnerID)
+	pod, err := s.getRunnerPod(ctx, req.RunnerID)
 	if err != nil {
-		return 1, ErrRunnerNotFound
+		return ExitStatus{}, err
 	}
 
 	runner := PodToRunner(pod)
 	if runner.Status != RunnerStatusRunning {
-		return 1, ErrRunnerNotRunning
+		return ExitStatus{}, ErrRunnerNotRunning
+	}
+
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.Timeout)*time.Second)
+		defer cancel()
 	}
 
 	// Record the last active time when command execution starts
-	s.activityTracker.UpdateLastActiveTime(req.RunnerID)
+	s.recordActivity(ctx, req.RunnerID)
+	defer s.activityTracker.BeginExec(req.RunnerID)()
 
 	// Execute command via Kubernetes client with streaming
-	exitCode, err := s.k8sClient.ExecuteCommandStream(ctx, req.RunnerID, req.Command, stdoutCh, stderrCh)
+	shell, workdir := resolveExecSettings(req, runner, s.k8sClient.Config())
+	env := correlationEnv(ctx, req, s.k8sClient.Config().DisabledFeatures)
+	if req.Started != nil {
+		req.Started(ExecStart{RunnerID: req.RunnerID, Shell: shell, WorkingDir: workdir, CorrelationEnv: env})
+	}
+	startedAt := time.Now()
+	exitStatus, err := s.k8sClient.ExecuteCommandStream(ctx, req.RunnerID, withEnv(env, execArgv(shell, workdir, req)), s.execOutput(req.RunnerID), stdoutCh, stderrCh)
+
+	record := ExecutionRecord{
+		Command:    req.CommandLine(),
+		ExitCode:   exitStatus.Code,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+	}
 	if err != nil {
-		return 1, fmt.Errorf("%w: %v", ErrCommandExecution, err)
+		record.Error = err.Error()
 	}
+	s.history.Record(req.RunnerID, record)
+
+	if message := execFailureMessage(exitStatus, err, time.Duration(req.Timeout)*time.Second); message != "" {
+		s.k8sClient.RecordPodEvent(pod, corev1.EventTypeWarning, EventReasonExecFailed, "%s", message)
+	}
+
+	if err != nil {
+		return ExitStatus{}, fmt.Errorf("%w: %v", ErrCommandExecution, err)
+	}
+
+	return exitStatus, nil
+}
+
+// execOutput sends the output of a runner's commands, counting it for the runner's usage
+func (s *runnerService) execOutput(runnerID string) execOutput {
+	return execOutput{
+		flushInterval: s.flushInterval,
+		sent: func(stream string, n int) {
+			s.activityTracker.AddOutputBytes(runnerID, stream, n)
+		},
+	}
+}
+
+// startMonitor launches the background monitor for a new runner
+func (s *runnerService) startMonitor(runnerID string, createdAt time.Time, bootstrapCommand string) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	monitor := &runnerMonitor{cancel: cancel}
+
+	s.monitorsMu.Lock()
+	if s.ctx.Err() != nil {
+		s.monitorsMu.Unlock()
+		cancel()
+		slog.Warn("Runner service is stopped, not monitoring runner", "runner_id", runnerID)
+		return
+	}
+	if existing, ok := s.monitors[runnerID]; ok {
+		existing.cancel()
+	}
+	s.monitors[runnerID] = monitor
+	s.wg.Add(1)
+	s.monitorsMu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		defer s.removeMonitor(runnerID, monitor)
+		s.monitorRunnerStatus(ctx, runnerID, createdAt, bootstrapCommand)
+	}()
+}
+
+// cancelMonitor stops the background monitor of a runner, if any
+func (s *runnerService) cancelMonitor(runnerID string) {
+	s.monitorsMu.Lock()
+	defer s.monitorsMu.Unlock()
+
+	if monitor, ok := s.monitors[runnerID]; ok {
+		monitor.cancel()
+		delete(s.monitors, runnerID)
+	}
+}
 
-	return exitCode, nil
+// removeMonitor releases a finished monitor unless it has already been replaced
+func (s *runnerService) removeMonitor(runnerID string, monitor *runnerMonitor) {
+	monitor.cancel()
+
+	s.monitorsMu.Lock()
+	defer s.monitorsMu.Unlock()
+
+	if s.monitors[runnerID] == monitor {
+		delete(s.monitors, runnerID)
+	}
+}
+
+// monitorRunnerStatus waits for a new runner's pod to become ready, records how long that took and
+// then runs its bootstrap command, if any.
+// It returns as soon as ctx is cancelled, i.e. when the runner is deleted or the service shuts down.
+func (s *runnerService) monitorRunnerStatus(ctx context.Context, runnerID string, createdAt time.Time, bootstrapCommand string) {
+	waitCtx, cancel := context.WithTimeout(ctx, s.monitorTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.monitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				slog.Info("Stopped monitoring runner", "runner_id", runnerID)
+				return
+			}
+			slog.Warn("Timed out waiting for runner to become ready", "runner_id", runnerID)
+			s.metrics.RunnerProvisioningFailuresTotal.WithLabelValues(ProvisioningFailureTimeout).Inc()
+			timeoutErr := fmt.Errorf("runner did not become ready within %s", s.monitorTimeout)
+			if bootstrapCommand != "" {
+				s.recordBootstrapResult(ctx, runnerID, &bootstrapResult{
+					ExitCode: 1,
+					Err:      timeoutErr,
+				})
+			}
+			s.recordTimeoutError(ctx, runnerID, timeoutErr.Error())
+			return
+		case <-ticker.C:
+			pod, err := s.k8sClient.GetRunnerPod(waitCtx, runnerID)
+			if err != nil {
+				if errors.IsNotFound(err) {
+					slog.Info("Runner disappeared before becoming ready", "runner_id", runnerID)
+					return
+				}
+				if waitCtx.Err() == nil {
+					slog.Warn("Failed to get runner pod while monitoring", "runner_id", runnerID, "error", err)
+				}
+				continue
+			}
+
+			switch MapPodStatusToRunnerStatus(pod) {
+			case RunnerStatusRunning:
+				s.recordProvisioned(ctx, runnerID, pod, createdAt)
+				if bootstrapCommand != "" {
+					s.bootstrapRunner(ctx, PodToRunner(pod), bootstrapCommand)
+				}
+				return
+			case RunnerStatusError:
+				reason, message := runnerError(pod, RunnerStatusError)
+				slog.Warn("Runner failed before becoming ready", "runner_id", runnerID, "reason", reason, "message", message)
+				s.metrics.RunnerProvisioningFailuresTotal.WithLabelValues(ProvisioningFailurePod).Inc()
+				s.metrics.RunnerErrorsTotal.WithLabelValues(string(reason)).Inc()
+				return
+			case RunnerStatusStopping, RunnerStatusStopped:
+				slog.Info("Runner stopped before becoming ready", "runner_id", runnerID)
+				return
+			}
+		}
+	}
+}
+
+// recordTimeoutError puts a runner that didn't become ready in time in error, blaming its image
+// if that can't be pulled
+func (s *runnerService) recordTimeoutError(ctx context.Context, runnerID, timeoutMessage string) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	pod, err := s.k8sClient.GetRunnerPod(ctx, runnerID)
+	if err != nil {
+		slog.Warn("Failed to get runner pod after timeout", "runner_id", runnerID, "error", err)
+		pod = nil
+	}
+	reason, message := timeoutError(pod, timeoutMessage)
+	s.metrics.RunnerErrorsTotal.WithLabelValues(string(reason)).Inc()
+
+	if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, errorAnnotations(reason, message)); err != nil {
+		slog.Error("Failed to record runner error", "runner_id", runnerID, "error", err)
+	}
+}
+
+// recordProvisioned observes the provisioning time of a runner that just became ready, stores it on the pod
+// and reports it in a RunnerReady event.
+// For the replacement pod of a restarted runner, it ends the restart instead of storing the time.
+func (s *runnerService) recordProvisioned(ctx context.Context, runnerID string, pod *corev1.Pod, createdAt time.Time) {
+	duration := s.now().Sub(createdAt)
+	if pod.Annotations[RunnerRestartStatusAnnotation] == RestartStatusRestarting {
+		slog.Info("Restarted runner is ready", "runner_id", runnerID, "restart_duration", duration.String())
+		s.k8sClient.RecordPodEvent(pod, corev1.EventTypeNormal, EventReasonRunnerReady, "Restarted runner is ready after %s", duration.Round(time.Second))
+		if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, map[string]string{
+			RunnerRestartStatusAnnotation: "",
+		}); err != nil {
+			slog.Warn("Failed to clear restart status", "runner_id", runnerID, "error", err)
+		}
+		return
+	}
+
+	imageState := s.k8sClient.ImagePullState(ctx, runnerID)
+	s.metrics.RunnerProvisioningDuration.WithLabelValues(runnerPreset, imageState).Observe(duration.Seconds())
+	slog.Info("Runner is ready", "runner_id", runnerID, "provisioning_duration", duration.String(), "image", imageState)
+	s.k8sClient.RecordPodEvent(pod, corev1.EventTypeNormal, EventReasonRunnerReady, "Runner is ready after %s", duration.Round(time.Second))
+
+	if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, map[string]string{
+		RunnerProvisioningDurationAnnotation: strconv.FormatInt(duration.Milliseconds(), 10),
+	}); err != nil {
+		slog.Warn("Failed to record provisioning duration", "runner_id", runnerID, "error", err)
+	}
+}
+
+// bootstrapRunner executes the bootstrap command through the normal exec path, with the runner's
+// default shell and working directory, and records the outcome
+func (s *runnerService) bootstrapRunner(ctx context.Context, runner *Runner, bootstrapCommand string) {
+	runnerID := runner.ID
+	execCtx, cancel := context.WithTimeout(ctx, s.bootstrapTimeout)
+	defer cancel()
+
+	if err := s.k8sClient.UpdateRunnerAnnotations(execCtx, runnerID, map[string]string{
+		RunnerBootstrapStatusAnnotation: BootstrapStatusRunning,
+	}); err != nil {
+		slog.Warn("Failed to mark runner as bootstrapping", "runner_id", runnerID, "error", err)
+	}
+
+	slog.Info("Running bootstrap command", "runner_id", runnerID, "command", bootstrapCommand)
+	shell, workdir := resolveExecSettings(&ExecuteCommandRequest{}, runner, s.k8sClient.Config())
+	exec := func(ctx context.Context, runnerID, command string, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+		return s.k8sClient.ExecuteCommandStream(ctx, runnerID, shellCommand(shell, workdir, command), s.execOutput(runnerID), stdoutCh, stderrCh)
+	}
+	result := runBootstrapCommand(execCtx, exec, runnerID, bootstrapCommand)
+	if !result.Succeeded() && ctx.Err() == nil {
+		s.metrics.RunnerErrorsTotal.WithLabelValues(string(RunnerErrorBootstrapFailed)).Inc()
+	}
+
+	s.recordBootstrapResult(ctx, runnerID, result)
+	if ctx.Err() == nil {
+		s.history.Record(runnerID, result.toExecutionRecord(bootstrapCommand))
+	}
+}
+
+// recordBootstrapResult stores the bootstrap outcome on the runner pod
+func (s *runnerService) recordBootstrapResult(ctx context.Context, runnerID string, result *bootstrapResult) {
+	// The runner is being deleted or the server is shutting down; don't overwrite its status
+	if ctx.Err() != nil {
+		slog.Info("Discarding bootstrap result for cancelled runner", "runner_id", runnerID)
+		return
+	}
+
+	if result.Succeeded() {
+		slog.Info("Bootstrap command succeeded", "runner_id", runnerID)
+	} else {
+		slog.Warn("Bootstrap command failed", "runner_id", runnerID, "exit_code", result.ExitCode, "error", result.Err)
+	}
+
+	if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, bootstrapAnnotations(result)); err != nil {
+		slog.Error("Failed to record bootstrap result", "runner_id", runnerID, "error", err)
+	}
+}
+
+// getRunnerPod returns the pod of runnerID, failing with ErrRunnerNotFound only if it doesn't exist
+func (s *runnerService) getRunnerPod(ctx context.Context, runnerID string) (*corev1.Pod, error) {
+	pod, err := s.k8sClient.GetRunnerPod(ctx, runnerID)
+	if errors.IsNotFound(err) {
+		return nil, ErrRunnerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+	}
+	return pod, nil
+}
+
+// checkExtraCAConfigMap fails fast if the configured extra CA ConfigMap is missing,
+// since the runner pod would otherwise be stuck in ContainerCreating
+func (s *runnerService) checkExtraCAConfigMap(ctx context.Context) error {
+	proxy := s.k8sClient.Config().Proxy
+	if proxy == nil || proxy.ExtraCAConfigMap == "" {
+		return nil
+	}
+
+	if _, err := s.k8sClient.GetConfigMap(ctx, proxy.ExtraCAConfigMap); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("%w: extra CA configmap %q not found in namespace %q",
+				ErrFailedPrecondition, proxy.ExtraCAConfigMap, s.k8sClient.Config().Namespace)
+		}
+		return fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+	}
+
+	return nil
+}
+
+// checkServiceAccount verifies that a ServiceAccount requested for a runner is allowed and exists
+func (s *runnerService) checkServiceAccount(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	if !slices.Contains(s.k8sClient.Config().Allowlists().ServiceAccounts, name) {
+		return fmt.Errorf("%w: service account %q is not allowed for runners", ErrInvalidRequest, name)
+	}
+
+	if _, err := s.k8sClient.GetServiceAccount(ctx, name); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("%w: service account %q not found in namespace %q",
+				ErrFailedPrecondition, name, s.k8sClient.Config().Namespace)
+		}
+		return fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+	}
+
+	return nil
 }
 
 // generateRunnerID generates a simple incrementing runner ID (runner-1, runner-2, etc.)
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// RunnerServiceName is the headless Service giving runner pods stable DNS names
+	RunnerServiceName = "grad-runners"
+
+	// DefaultClusterDomain is the DNS domain of most clusters
+	DefaultClusterDomain = "cluster.local"
+
+	// RunnerDNSNameAnnotation records the runner's fully qualified DNS name
+	RunnerDNSNameAnnotation = RunnerAnnotationPrefix + "dns-name"
+)
+
+// enabled reports whether runners get stable DNS names
+func (c *StableDNSConfig) enabled() bool {
+	return c != nil && c.Enabled
+}
+
+// dnsName returns the fully qualified name of a runner pod, or "" if stable DNS is disabled.
+// The pod's hostname is its runner ID, which is a valid DNS label.
+func (c *StableDNSConfig) dnsName(namespace, runnerID string) string {
+	if !c.enabled() {
+		return ""
+	}
+	domain := c.ClusterDomain
+	if domain == "" {
+		domain = DefaultClusterDomain
+	}
+	return fmt.Sprintf("%s.%s.%s.svc.%s", runnerID, RunnerServiceName, namespace, domain)
+}
+
+// validateClusterDomain checks that domain can end a DNS name (pure function)
+func validateClusterDomain(domain string) error {
+	if problems := validation.IsDNS1123Subdomain(strings.TrimSuffix(domain, ".")); len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// BuildRunnerService builds the headless Service selecting all runner pods. Publishing
+// not-ready addresses lets a runner resolve its own name while its bootstrap command runs.
+func BuildRunnerService(namespace string, sshPort int32) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RunnerServiceName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "grad"},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector: map[string]string{
+				"app.kubernetes.io/managed-by": "grad",
+				"app.kubernetes.io/component":  "runner",
+			},
+			PublishNotReadyAddresses: true,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "ssh",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       sshPort,
+					TargetPort: intstr.FromString("ssh"),
+				},
+			},
+		},
+	}
+}
+
+// SetupRunnerService creates or updates the headless runner Service when stable DNS is
+// enabled, and otherwise deletes one a previous grad left behind
+func (k *KubernetesClient) SetupRunnerService(ctx context.Context) error {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	services := k.clientset.CoreV1().Services(k.config.Namespace)
+	if !k.config.StableDNS.enabled() {
+		existing, err := services.Get(ctx, RunnerServiceName, metav1.GetOptions{})
+		// Without the services permission there is nothing grad could have created
+		if errors.IsNotFound(err) || errors.IsForbidden(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get runner service: %w", err)
+		}
+		if existing.Labels["app.kubernetes.io/managed-by"] != "grad" {
+			return nil
+		}
+		if err := services.Delete(ctx, RunnerServiceName, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete runner service: %w", err)
+		}
+		slog.Info("Deleted headless runner service", "name", RunnerServiceName)
+		return nil
+	}
+
+	service := BuildRunnerService(k.config.Namespace, k.config.SSHPort)
+	existing, err := services.Get(ctx, RunnerServiceName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := services.Create(ctx, service, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create runner service: %w", err)
+		}
+		slog.Info("Created headless runner service", "name", RunnerServiceName)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get runner service: %w", err)
+	}
+
+	// The cluster IP can't change, so only the rest of the spec is replaced
+	existing.Labels = service.Labels
+	existing.Spec.Selector = service.Spec.Selector
+	existing.Spec.Ports = service.Spec.Ports
+	existing.Spec.PublishNotReadyAddresses = service.Spec.PublishNotReadyAddresses
+	if _, err := services.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update runner service: %w", err)
+	}
+	return nil
+}
+
+// ensureRunnerService recreates the headless runner Service if missing, e.g. after its
+// namespace was cleaned up
+func (k *KubernetesClient) ensureRunnerService(ctx context.Context) error {
+	if !k.config.StableDNS.enabled() {
+		return nil
+	}
+
+	service := BuildRunnerService(k.config.Namespace, k.config.SSHPort)
+	_, err := k.clientset.CoreV1().Services(k.config.Namespace).Create(ctx, service, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create runner service: %w", err)
+	}
+	return nil
+}
+
+// podSSHDetails returns how to reach the runner pod over SSH: its stable DNS name if it has
+// one, else its IP. It returns nil until the pod has an IP, as the name doesn't resolve before.
+func podSSHDetails(pod *corev1.Pod) *SSHDetails {
+	if pod.Status.PodIP == "" {
+		return nil
+	}
+	host := pod.Annotations[RunnerDNSNameAnnotation]
+	if host == "" {
+		host = pod.Status.PodIP
+	}
+
+	port := int32(22)
+	if container := findContainer(pod, RunnerContainerName); container != nil {
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == "ssh" {
+				port = containerPort.ContainerPort
+			}
+		}
+	}
+	return &SSHDetails{Host: host, Port: port, Username: "runner"}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBuildRunnerService(t *testing.T) {
+	service := BuildRunnerService("runners", 2222)
+
+	if service.Name != RunnerServiceName || service.Namespace != "runners" {
+		t.Errorf("Expected runners/%s, got %s/%s", RunnerServiceName, service.Namespace, service.Name)
+	}
+	if service.Spec.ClusterIP != corev1.ClusterIPNone {
+		t.Errorf("Expected a headless service, got cluster IP %q", service.Spec.ClusterIP)
+	}
+	if service.Spec.Selector["app.kubernetes.io/component"] != "runner" || service.Spec.Selector["app.kubernetes.io/managed-by"] != "grad" {
+		t.Errorf("Expected the service to select runner pods, got %v", service.Spec.Selector)
+	}
+	if len(service.Spec.Ports) != 1 || service.Spec.Ports[0].Port != 2222 || service.Spec.Ports[0].TargetPort.String() != "ssh" {
+		t.Errorf("Expected port 2222 targeting ssh, got %+v", service.Spec.Ports)
+	}
+}
+
+func TestStableDNSName(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *StableDNSConfig
+		expected string
+	}{
+		{"nil config", nil, ""},
+		{"disabled", &StableDNSConfig{ClusterDomain: "cluster.local"}, ""},
+		{"enabled", &StableDNSConfig{Enabled: true, ClusterDomain: "corp.internal"}, "runner-42.grad-runners.runners.svc.corp.internal"},
+		{"default domain", &StableDNSConfig{Enabled: true}, "runner-42.grad-runners.runners.svc.cluster.local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.dnsName("runners", "runner-42"); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestToPodSpecStableDNS(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	config.Namespace = "runners"
+	config.StableDNS = &StableDNSConfig{Enabled: true, ClusterDomain: "cluster.local"}
+
+	req, err := BuildPodCreationRequest(&Runner{ID: "runner-42", Name: "dns"}, config)
+	if err != nil {
+		t.Fatalf("BuildPodCreationRequest failed: %v", err)
+	}
+	pod := req.ToPodSpec()
+
+	if pod.Spec.Hostname != "runner-42" || pod.Spec.Subdomain != RunnerServiceName {
+		t.Errorf("Expected hostname runner-42 and subdomain %s, got %q and %q", RunnerServiceName, pod.Spec.Hostname, pod.Spec.Subdomain)
+	}
+	if got := pod.Annotations[RunnerDNSNameAnnotation]; got != "runner-42.grad-runners.runners.svc.cluster.local" {
+		t.Errorf("Unexpected DNS name annotation %q", got)
+	}
+
+	// Without stable DNS the pod keeps its default hostname
+	config.StableDNS = nil
+	req, err = BuildPodCreationRequest(&Runner{ID: "runner-42", Name: "dns"}, config)
+	if err != nil {
+		t.Fatalf("BuildPodCreationRequest failed: %v", err)
+	}
+	pod = req.ToPodSpec()
+	if pod.Spec.Hostname != "" || pod.Spec.Subdomain != "" {
+		t.Errorf("Expected no hostname or subdomain, got %q and %q", pod.Spec.Hostname, pod.Spec.Subdomain)
+	}
+	if _, ok := pod.Annotations[RunnerDNSNameAnnotation]; ok {
+		t.Error("Expected no DNS name annotation")
+	}
+}
+
+func TestPodToRunnerSSHDetails(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	config.SSHPort = 2222
+	config.StableDNS = &StableDNSConfig{Enabled: true, ClusterDomain: "cluster.local"}
+
+	req, err := BuildPodCreationRequest(&Runner{ID: "runner-1", Name: "dns"}, config)
+	if err != nil {
+		t.Fatalf("BuildPodCreationRequest failed: %v", err)
+	}
+	pod := req.ToPodSpec()
+
+	// No address yet
+	if ssh := PodToRunner(pod).SSH; ssh != nil {
+		t.Errorf("Expected no SSH details before the pod has an IP, got %+v", ssh)
+	}
+
+	pod.Status.PodIP = "10.1.2.3"
+	ssh := PodToRunner(pod).SSH
+	if ssh == nil || ssh.Host != "runner-1.grad-runners.default.svc.cluster.local" || ssh.Port != 2222 || ssh.Username != "runner" {
+		t.Errorf("Expected the stable DNS name on port 2222, got %+v", ssh)
+	}
+
+	// Without stable DNS the pod IP is used
+	delete(pod.Annotations, RunnerDNSNameAnnotation)
+	ssh = PodToRunner(pod).SSH
+	if ssh == nil || ssh.Host != "10.1.2.3" {
+		t.Errorf("Expected the pod IP, got %+v", ssh)
+	}
+}
+
+func TestSetupRunnerService(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	config := DefaultKubernetesConfig()
+	config.StableDNS = &StableDNSConfig{Enabled: true}
+	k := &KubernetesClient{clientset: clientset, config: config}
+	ctx := context.Background()
+	services := clientset.CoreV1().Services(config.Namespace)
+
+	if err := k.SetupRunnerService(ctx); err != nil {
+		t.Fatalf("SetupRunnerService failed: %v", err)
+	}
+	service, err := services.Get(ctx, RunnerServiceName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the runner service to be created: %v", err)
+	}
+
+	// A changed SSH port is applied to the existing Service
+	config.SSHPort = 2222
+	if err := k.SetupRunnerService(ctx); err != nil {
+		t.Fatalf("SetupRunnerService failed on update: %v", err)
+	}
+	service, _ = services.Get(ctx, RunnerServiceName, metav1.GetOptions{})
+	if service.Spec.Ports[0].Port != 2222 {
+		t.Errorf("Expected port 2222 after update, got %d", service.Spec.Ports[0].Port)
+	}
+
+	// Disabling stable DNS removes the Service
+	config.StableDNS.Enabled = false
+	if err := k.SetupRunnerService(ctx); err != nil {
+		t.Fatalf("SetupRunnerService failed on cleanup: %v", err)
+	}
+	if _, err := services.Get(ctx, RunnerServiceName, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected the runner service to be deleted, got %v", err)
+	}
+
+	// A Service of the same name grad doesn't manage is left alone
+	foreign := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: RunnerServiceName, Namespace: config.Namespace}}
+	if _, err := services.Create(ctx, foreign, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	if err := k.SetupRunnerService(ctx); err != nil {
+		t.Fatalf("SetupRunnerService failed: %v", err)
+	}
+	if _, err := services.Get(ctx, RunnerServiceName, metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the unmanaged service to be kept, got %v", err)
+	}
+}
+
+func TestCreateRunnerPodRecreatesRunnerService(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.k8sClient.Config().StableDNS = &StableDNSConfig{Enabled: true}
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	if _, err := clientset.CoreV1().Services("default").Get(ctx, RunnerServiceName, metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the runner service to be created with the runner: %v", err)
+	}
+	pod, err := clientset.CoreV1().Pods("default").Get(ctx, "grad-runner-"+runner.ID, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get runner pod: %v", err)
+	}
+	if pod.Spec.Hostname != runner.ID || pod.Spec.Subdomain != RunnerServiceName {
+		t.Errorf("Expected hostname %s.%s, got %s.%s", runner.ID, RunnerServiceName, pod.Spec.Hostname, pod.Spec.Subdomain)
+	}
+}
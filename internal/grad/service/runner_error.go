@@ -0,0 +1,121 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Error bookkeeping for failures that can't be read from the pod itself
+const (
+	// RunnerErrorReasonAnnotation holds the RunnerErrorReason grad recorded when it put the runner in error
+	RunnerErrorReasonAnnotation = RunnerAnnotationPrefix + "error-reason"
+	// RunnerErrorMessageAnnotation explains the recorded error reason
+	RunnerErrorMessageAnnotation = RunnerAnnotationPrefix + "error-message"
+)
+
+// imagePullWaitingReasons are the container waiting reasons of an image that can't be pulled
+var imagePullWaitingReasons = []string{"ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull"}
+
+// applyErrorStatus reports a runner grad gave up on while it was still being created as
+// in error (pure function)
+func applyErrorStatus(status RunnerStatus, errorReason string) RunnerStatus {
+	if errorReason != "" && status == RunnerStatusCreating {
+		return RunnerStatusError
+	}
+	return status
+}
+
+// runnerError explains why a runner with the given status is in error: from the reason grad
+// recorded, a failed bootstrap command or the pod's own state. It returns "" for runners in
+// other states. (pure function)
+func runnerError(pod *corev1.Pod, status RunnerStatus) (RunnerErrorReason, string) {
+	if status != RunnerStatusError {
+		return "", ""
+	}
+
+	if reason := pod.Annotations[RunnerErrorReasonAnnotation]; reason != "" {
+		return RunnerErrorReason(reason), pod.Annotations[RunnerErrorMessageAnnotation]
+	}
+
+	if pod.Annotations[RunnerBootstrapStatusAnnotation] == BootstrapStatusFailed {
+		message, _, _ := strings.Cut(pod.Annotations[RunnerStatusDetailAnnotation], "\n")
+		return RunnerErrorBootstrapFailed, message
+	}
+
+	if reason, message := podContainerError(pod); reason != "" {
+		return reason, message
+	}
+	return RunnerErrorUnknown, fmt.Sprintf("pod phase %s", pod.Status.Phase)
+}
+
+// podContainerError classifies what went wrong with a pod's containers: an image that can't be
+// pulled, a container killed for running out of memory, a pod whose node shut down or a failed
+// pod. It returns "" if nothing did. (pure function)
+func podContainerError(pod *corev1.Pod) (RunnerErrorReason, string) {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+
+	for _, status := range statuses {
+		if waiting := status.State.Waiting; waiting != nil {
+			for _, reason := range imagePullWaitingReasons {
+				if waiting.Reason == reason {
+					return RunnerErrorImagePull, containerMessage(status.Name, waiting.Reason, waiting.Message)
+				}
+			}
+		}
+	}
+
+	for _, status := range statuses {
+		for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+			if terminated != nil && terminated.Reason == "OOMKilled" {
+				return RunnerErrorOOM, fmt.Sprintf("container %s was killed for exceeding its memory limit", status.Name)
+			}
+		}
+	}
+
+	if pod.Status.Phase != corev1.PodFailed {
+		return "", ""
+	}
+	if podNodeShutDown(pod) {
+		return RunnerErrorNodeShutdown, podFailureReason(pod) + ": " + cmp.Or(pod.Status.Message, "the node shut down")
+	}
+	message := podFailureReason(pod)
+	for _, status := range statuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			message += fmt.Sprintf("; container %s exited with code %d", status.Name, terminated.ExitCode)
+			if terminated.Reason != "" {
+				message += fmt.Sprintf(" (%s)", terminated.Reason)
+			}
+		}
+	}
+	return RunnerErrorPodFailed, message
+}
+
+// containerMessage describes the state of a container (pure function)
+func containerMessage(container, reason, message string) string {
+	if message == "" {
+		return fmt.Sprintf("container %s: %s", container, reason)
+	}
+	return fmt.Sprintf("container %s: %s: %s", container, reason, message)
+}
+
+// timeoutError explains why a runner didn't become ready in time: an image that can't be pulled,
+// else the timeout itself (pure function)
+func timeoutError(pod *corev1.Pod, timeoutMessage string) (RunnerErrorReason, string) {
+	if pod != nil {
+		if reason, message := podContainerError(pod); reason == RunnerErrorImagePull {
+			return reason, message
+		}
+	}
+	return RunnerErrorProvisionTimeout, timeoutMessage
+}
+
+// errorAnnotations returns the pod annotations recording why grad put a runner in error (pure function)
+func errorAnnotations(reason RunnerErrorReason, message string) map[string]string {
+	return map[string]string{
+		RunnerErrorReasonAnnotation:  string(reason),
+		RunnerErrorMessageAnnotation: message,
+	}
+}
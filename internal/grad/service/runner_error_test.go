@@ -0,0 +1,167 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+func TestApplyErrorStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      RunnerStatus
+		errorReason string
+		expected    RunnerStatus
+	}{
+		{"No error recorded", RunnerStatusCreating, "", RunnerStatusCreating},
+		{"Timed out while creating", RunnerStatusCreating, string(RunnerErrorProvisionTimeout), RunnerStatusError},
+		{"Became ready after the timeout", RunnerStatusRunning, string(RunnerErrorProvisionTimeout), RunnerStatusRunning},
+		{"Stopping", RunnerStatusStopping, string(RunnerErrorImagePull), RunnerStatusStopping},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyErrorStatus(tt.status, tt.errorReason); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunnerError(t *testing.T) {
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		status        RunnerStatus
+		expectReason  RunnerErrorReason
+		expectMessage string
+	}{
+		{
+			name:   "Not in error",
+			pod:    &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+			status: RunnerStatusRunning,
+		},
+		{
+			name: "Recorded timeout",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: errorAnnotations(
+				RunnerErrorProvisionTimeout, "runner did not become ready within 5m0s")}},
+			status:        RunnerStatusError,
+			expectReason:  RunnerErrorProvisionTimeout,
+			expectMessage: "runner did not become ready within 5m0s",
+		},
+		{
+			name: "Bootstrap failed",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				RunnerBootstrapStatusAnnotation: BootstrapStatusFailed,
+				RunnerStatusDetailAnnotation:    "bootstrap command failed with exit code 2\nE: Unable to locate package",
+			}}},
+			status:        RunnerStatusError,
+			expectReason:  RunnerErrorBootstrapFailed,
+			expectMessage: "bootstrap command failed with exit code 2",
+		},
+		{
+			name: "Image pull",
+			pod: &corev1.Pod{Status: corev1.PodStatus{
+				Phase: corev1.PodFailed,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  RunnerContainerName,
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}},
+				}},
+			}},
+			status:        RunnerStatusError,
+			expectReason:  RunnerErrorImagePull,
+			expectMessage: "container runner: ImagePullBackOff: Back-off pulling image",
+		},
+		{
+			name: "OOM",
+			pod: &corev1.Pod{Status: corev1.PodStatus{
+				Phase: corev1.PodFailed,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  RunnerContainerName,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+				}},
+			}},
+			status:        RunnerStatusError,
+			expectReason:  RunnerErrorOOM,
+			expectMessage: "container runner was killed for exceeding its memory limit",
+		},
+		{
+			name: "Pod failed",
+			pod: &corev1.Pod{Status: corev1.PodStatus{
+				Phase:  corev1.PodFailed,
+				Reason: "Evicted",
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  RunnerContainerName,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+				}},
+			}},
+			status:        RunnerStatusError,
+			expectReason:  RunnerErrorPodFailed,
+			expectMessage: "Evicted; container runner exited with code 1 (Error)",
+		},
+		{
+			name:          "Unknown",
+			pod:           &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodUnknown}},
+			status:        RunnerStatusError,
+			expectReason:  RunnerErrorUnknown,
+			expectMessage: "pod phase Unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, message := runnerError(tt.pod, tt.status)
+			if reason != tt.expectReason || message != tt.expectMessage {
+				t.Errorf("Expected %q: %q, got %q: %q", tt.expectReason, tt.expectMessage, reason, message)
+			}
+		})
+	}
+}
+
+func TestTimeoutError(t *testing.T) {
+	// A pod waiting for its image is blamed on the image
+	pod := &corev1.Pod{Status: corev1.PodStatus{
+		Phase: corev1.PodPending,
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  RunnerContainerName,
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull"}},
+		}},
+	}}
+	if reason, message := timeoutError(pod, "timed out"); reason != RunnerErrorImagePull || !strings.Contains(message, "ErrImagePull") {
+		t.Errorf("Expected an image_pull error, got %q: %q", reason, message)
+	}
+
+	// Anything else, including a pod that couldn't be read, is a timeout
+	for _, pod := range []*corev1.Pod{nil, {Status: corev1.PodStatus{Phase: corev1.PodPending}}} {
+		if reason, message := timeoutError(pod, "timed out"); reason != RunnerErrorProvisionTimeout || message != "timed out" {
+			t.Errorf("Expected a provision_timeout error, got %q: %q", reason, message)
+		}
+	}
+}
+
+func TestPodToRunnerErrorReason(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{RunnerIDAnnotation: "runner-1"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	if runner := PodToRunner(pod); runner.Status != RunnerStatusCreating || runner.ErrorReason != "" {
+		t.Errorf("Expected a creating runner without an error, got %s and %q", runner.Status, runner.ErrorReason)
+	}
+
+	for key, value := range errorAnnotations(RunnerErrorProvisionTimeout, "runner did not become ready within 5m0s") {
+		pod.Annotations[key] = value
+	}
+	runner := PodToRunner(pod)
+	if runner.Status != RunnerStatusError || runner.ErrorReason != RunnerErrorProvisionTimeout {
+		t.Errorf("Expected error with reason provision_timeout, got %s and %q", runner.Status, runner.ErrorReason)
+	}
+
+	proto := runner.ToProto()
+	if proto.ErrorReason != gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_PROVISION_TIMEOUT || proto.ErrorMessage != runner.ErrorMessage {
+		t.Errorf("Expected the proto to carry the error, got %v: %q", proto.ErrorReason, proto.ErrorMessage)
+	}
+}
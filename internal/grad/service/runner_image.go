@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// RunnerImages are the container images of new runner pods
+type RunnerImages struct {
+	Runner string
+	S3FS   string
+}
+
+// Images returns the images new runner pods use
+func (c *KubernetesConfig) Images() RunnerImages {
+	c.imagesMu.RLock()
+	defer c.imagesMu.RUnlock()
+	return RunnerImages{Runner: c.RunnerImage, S3FS: c.S3FSImage}
+}
+
+// SetImages changes the images of runners created from now on and returns the ones they
+// replace, which RevertImages restores. Empty fields keep their current image; runners that
+// already exist keep theirs.
+func (c *KubernetesConfig) SetImages(images RunnerImages) (RunnerImages, error) {
+	if images.Runner == "" && images.S3FS == "" {
+		return RunnerImages{}, fmt.Errorf("%w: a runner or s3fs image is required", ErrInvalidRequest)
+	}
+	for _, image := range []string{images.Runner, images.S3FS} {
+		if image == "" {
+			continue
+		}
+		if err := validateImageReference(image); err != nil {
+			return RunnerImages{}, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+	}
+
+	c.imagesMu.Lock()
+	previous := RunnerImages{Runner: c.RunnerImage, S3FS: c.S3FSImage}
+	if images.Runner != "" {
+		c.RunnerImage = images.Runner
+	}
+	if images.S3FS != "" {
+		c.S3FSImage = images.S3FS
+	}
+	c.previousImages = &previous
+	current, changed := RunnerImages{Runner: c.RunnerImage, S3FS: c.S3FSImage}, c.imagesChanged
+	c.imagesMu.Unlock()
+
+	logImageChange(previous, current)
+	for _, fn := range changed {
+		fn(current)
+	}
+	return previous, nil
+}
+
+// RevertImages restores the images in use before the last SetImages or RevertImages and
+// returns the ones they replace, so reverting twice undoes the revert
+func (c *KubernetesConfig) RevertImages() (RunnerImages, error) {
+	c.imagesMu.Lock()
+	if c.previousImages == nil {
+		c.imagesMu.Unlock()
+		return RunnerImages{}, fmt.Errorf("%w: the runner images were not changed since grad started", ErrFailedPrecondition)
+	}
+
+	previous := RunnerImages{Runner: c.RunnerImage, S3FS: c.S3FSImage}
+	c.RunnerImage = c.previousImages.Runner
+	c.S3FSImage = c.previousImages.S3FS
+	c.previousImages = &previous
+	current, changed := RunnerImages{Runner: c.RunnerImage, S3FS: c.S3FSImage}, c.imagesChanged
+	c.imagesMu.Unlock()
+
+	logImageChange(previous, current)
+	for _, fn := range changed {
+		fn(current)
+	}
+	return previous, nil
+}
+
+// OnImagesChange calls fn with the new images after every SetImages and RevertImages
+func (c *KubernetesConfig) OnImagesChange(fn func(RunnerImages)) {
+	c.imagesMu.Lock()
+	defer c.imagesMu.Unlock()
+	c.imagesChanged = append(c.imagesChanged, fn)
+}
+
+// logImageChange records a change of the runner images, warning if the new runner image isn't pinned
+func logImageChange(previous, current RunnerImages) {
+	slog.Info("Changed runner images",
+		"runner_image", current.Runner,
+		"s3fs_image", current.S3FS,
+		"previous_runner_image", previous.Runner,
+		"previous_s3fs_image", previous.S3FS,
+	)
+	if warning := MutableImageTagWarning(current.Runner); warning != "" {
+		slog.Warn("Runner image is not pinned", "warning", warning)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetImages(t *testing.T) {
+	config := DefaultKubernetesConfig()
+
+	previous, err := config.SetImages(RunnerImages{Runner: "ghcr.io/strrl/grad-runner:v2"})
+	if err != nil {
+		t.Fatalf("SetImages failed: %v", err)
+	}
+	if previous != (RunnerImages{Runner: DefaultRunnerImage, S3FS: DefaultS3FSImage}) {
+		t.Errorf("Expected the default images to be replaced, got %+v", previous)
+	}
+	expected := RunnerImages{Runner: "ghcr.io/strrl/grad-runner:v2", S3FS: DefaultS3FSImage}
+	if got := config.Images(); got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	// New runners get the new image
+	req := buildPodCreationRequest(t, &Runner{ID: "runner-1"}, config)
+	if req.Image != expected.Runner || req.S3FSImage != expected.S3FS {
+		t.Errorf("Expected the pod to use %+v, got image %s and s3fs image %s", expected, req.Image, req.S3FSImage)
+	}
+
+	// Reverting twice undoes the revert
+	if _, err := config.RevertImages(); err != nil {
+		t.Fatalf("RevertImages failed: %v", err)
+	}
+	if got := config.Images(); got.Runner != DefaultRunnerImage {
+		t.Errorf("Expected the revert to restore %s, got %s", DefaultRunnerImage, got.Runner)
+	}
+	if _, err := config.RevertImages(); err != nil {
+		t.Fatalf("RevertImages failed: %v", err)
+	}
+	if got := config.Images(); got != expected {
+		t.Errorf("Expected the second revert to restore %+v, got %+v", expected, got)
+	}
+}
+
+func TestSetImagesValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		images RunnerImages
+	}{
+		{"No image", RunnerImages{}},
+		{"Invalid runner image", RunnerImages{Runner: "ghcr.io/strrl/Grad-Runner:v2"}},
+		{"Invalid s3fs image", RunnerImages{Runner: "ghcr.io/strrl/grad-runner:v2", S3FS: "s3fs image"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultKubernetesConfig()
+			if _, err := config.SetImages(tt.images); !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("Expected ErrInvalidRequest, got %v", err)
+			}
+			if got := config.Images(); got.Runner != DefaultRunnerImage || got.S3FS != DefaultS3FSImage {
+				t.Errorf("Expected a rejected change to keep the images, got %+v", got)
+			}
+		})
+	}
+
+	if _, err := DefaultKubernetesConfig().RevertImages(); !errors.Is(err, ErrFailedPrecondition) {
+		t.Errorf("Expected ErrFailedPrecondition reverting an unchanged config, got %v", err)
+	}
+}
+
+func TestOnImagesChange(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	var changes []RunnerImages
+	config.OnImagesChange(func(images RunnerImages) { changes = append(changes, images) })
+
+	if _, err := config.SetImages(RunnerImages{S3FS: "ghcr.io/strrl/grad-runner-s3fs:v2"}); err != nil {
+		t.Fatalf("SetImages failed: %v", err)
+	}
+	if _, err := config.RevertImages(); err != nil {
+		t.Fatalf("RevertImages failed: %v", err)
+	}
+	if _, err := config.SetImages(RunnerImages{}); err == nil {
+		t.Fatalf("Expected SetImages without images to fail")
+	}
+
+	expected := []RunnerImages{
+		{Runner: DefaultRunnerImage, S3FS: "ghcr.io/strrl/grad-runner-s3fs:v2"},
+		{Runner: DefaultRunnerImage, S3FS: DefaultS3FSImage},
+	}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		t.Errorf("Expected the changes %+v, got %+v", expected, changes)
+	}
+}
+
+func TestImagesConcurrentRotation(t *testing.T) {
+	config := DefaultKubernetesConfig()
+	const rotations = 200
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				// Both images change together, so a pod never mixes two versions
+				req, err := BuildPodCreationRequest(&Runner{ID: "runner-1"}, config)
+				if err != nil {
+					t.Errorf("BuildPodCreationRequest failed: %v", err)
+					return
+				}
+				runnerTag := req.Image[strings.LastIndex(req.Image, ":"):]
+				s3fsTag := req.S3FSImage[strings.LastIndex(req.S3FSImage, ":"):]
+				if runnerTag != s3fsTag {
+					t.Errorf("Expected matching image tags, got %s and %s", req.Image, req.S3FSImage)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := range rotations {
+		_, err := config.SetImages(RunnerImages{
+			Runner: fmt.Sprintf("ghcr.io/strrl/grad-runner:v%d", i),
+			S3FS:   fmt.Sprintf("ghcr.io/strrl/grad-runner-s3fs:v%d", i),
+		})
+		if err != nil {
+			t.Fatalf("SetImages failed: %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if got := config.Images().Runner; got != fmt.Sprintf("ghcr.io/strrl/grad-runner:v%d", rotations-1) {
+		t.Errorf("Expected the last image to win, got %s", got)
+	}
+}
@@ -4,91 +4,254 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/strrl/gra/internal/grad/metrics"
+	"github.com/strrl/gra/internal/testing/e2e"
 )
 
-// TestRunnerServiceBasics tests basic functionality of the runner service
-// This is an integration test that requires a real Kubernetes cluster
-func TestRunnerServiceBasics(t *testing.T) {
-	// For testing, we'll use a mock that doesn't actually connect to Kubernetes
-	// In a real test environment, you'd use a fake Kubernetes client
+// newIntegrationRunnerService creates a started runner service managing runners in a namespace
+// of its own on cluster, and the registry its metrics are scraped from
+func newIntegrationRunnerService(t *testing.T, cluster *e2e.Cluster) (*runnerService, *prometheus.Registry) {
+	t.Helper()
+
+	config := DefaultKubernetesConfig()
+	config.Namespace = cluster.Namespace(t)
+	config.RunnerImage = cluster.RunnerImage
+	// The stub runner image is busybox, which has no bash
+	config.DefaultShell = "sh"
 
-	// Skip this test if we can't create a Kubernetes client
-	k8sClient, err := NewKubernetesClient(DefaultKubernetesConfig())
+	k8sClient, err := NewKubernetesClientForConfig(cluster.Config, config)
 	if err != nil {
-		t.Skipf("Skipping test - cannot create Kubernetes client: %v", err)
+		t.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
 
-	activityTracker := NewActivityTracker()
-	service := NewRunnerService(k8sClient, activityTracker)
-	ctx := context.Background()
-
-	// Test creating a runner
-	req := &CreateRunnerRequest{
-		Name: "test-runner",
-		Resources: &ResourceRequirements{
-			CPUMillicores: 500,
-			MemoryMB:      1024,
-			StorageGB:     5,
-		},
-		Env: map[string]string{
-			"TEST_ENV": "test_value",
-		},
-	}
-
-	// This will likely fail in testing because we don't have a real K8s cluster
-	// but we can verify the service layer structure is correct
-	runner, err := service.CreateRunner(ctx, req)
+	registry := prometheus.NewRegistry()
+	m, err := metrics.New(registry)
 	if err != nil {
-		// Expected in test environment without real K8s cluster
-		t.Logf("CreateRunner failed as expected in test environment: %v", err)
-		return
+		t.Fatalf("Failed to create metrics: %v", err)
 	}
 
-	// If we somehow succeed, verify the response
-	if runner.Name != "test-runner" {
-		t.Errorf("Expected runner name 'test-runner', got '%s'", runner.Name)
+	durations := DefaultDurations()
+	durations.MonitorInterval = 200 * time.Millisecond
+	durations.ProvisionTimeout = e2e.PodTimeout
+	svc := NewRunnerService(k8sClient, NewActivityTracker(), durations, m).(*runnerService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		svc.Stop()
+	})
+	return svc, registry
+}
+
+// waitForRunnerRunning brings a new runner to running: the kubelet does on a cluster that runs
+// pods, and MarkPodReady stands in for it on envtest
+func waitForRunnerRunning(t *testing.T, cluster *e2e.Cluster, svc *runnerService, runnerID string) {
+	t.Helper()
+
+	namespace, podName := svc.k8sClient.Config().Namespace, kubernetesClient(svc).getPodName(runnerID)
+	if !cluster.RunsPods {
+		e2e.MarkPodReady(t, cluster.Clientset, namespace, podName)
 	}
+	e2e.WaitForPodPhase(t, cluster.Clientset, namespace, podName, corev1.PodRunning)
+	e2e.Eventually(t, e2e.PodTimeout, "runner "+runnerID+" to be running", func(ctx context.Context) (bool, error) {
+		runner, err := svc.GetRunner(ctx, runnerID)
+		if err != nil {
+			return false, err
+		}
+		return runner.Status == RunnerStatusRunning, nil
+	})
+}
+
+// TestRunnerLifecycle takes a runner from creation through exec to deletion
+func TestRunnerLifecycle(t *testing.T) {
+	cluster := e2e.Start(t)
+	svc, registry := newIntegrationRunnerService(t, cluster)
+	ctx := context.Background()
 
-	// Note: Resources now use hardcoded "small" preset (2c2g40g), not request values
-	if runner.Resources.CPUMillicores != RunnerSpecPreset.Small.CPUMillicores {
-		t.Errorf("Expected CPU millicores %d (small preset), got %d", RunnerSpecPreset.Small.CPUMillicores, runner.Resources.CPUMillicores)
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Name: "e2e-runner"})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if runner.Name != "e2e-runner" || runner.Status != RunnerStatusCreating {
+		t.Errorf("Expected runner e2e-runner to be creating, got %s (%s)", runner.Name, runner.Status)
 	}
 
-	// Test getting the runner
-	retrieved, err := service.GetRunner(ctx, runner.ID)
+	namespace, podName := svc.k8sClient.Config().Namespace, kubernetesClient(svc).getPodName(runner.ID)
+	pod, err := cluster.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
-		t.Errorf("Failed to get runner: %v", err)
-		return
+		t.Fatalf("Failed to get runner pod: %v", err)
+	}
+	if !slices.Contains(pod.Finalizers, RunnerFinalizer) {
+		t.Errorf("Expected the runner pod to carry %s, got %v", RunnerFinalizer, pod.Finalizers)
+	}
+	if pod.Spec.Containers[0].Image != cluster.RunnerImage {
+		t.Errorf("Expected runner image '%s', got '%s'", cluster.RunnerImage, pod.Spec.Containers[0].Image)
 	}
 
-	if retrieved == nil {
-		t.Error("Retrieved runner is nil")
-		return
+	waitForRunnerRunning(t, cluster, svc, runner.ID)
+	e2e.Eventually(t, e2e.PodTimeout, "the provisioning duration to be recorded", func(ctx context.Context) (bool, error) {
+		return e2e.Scrape(t, registry).HistogramCount("runner_provisioning_duration_seconds", nil) == 1, nil
+	})
+
+	runners, total, err := svc.ListRunners(ctx, &ListOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
 	}
+	if total != 1 || runners[0].ID != runner.ID {
+		t.Errorf("Expected only %s to be listed, got %d runners", runner.ID, total)
+	}
+
+	t.Run("Exec", func(t *testing.T) {
+		cluster.RequirePods(t)
 
-	if retrieved.ID != runner.ID {
-		t.Errorf("Expected runner ID '%s', got '%s'", runner.ID, retrieved.ID)
+		stdout, exitStatus, err := execCollect(ctx, svc, &ExecuteCommandRequest{RunnerID: runner.ID, Command: "echo hello"})
+		if err != nil {
+			t.Fatalf("ExecuteCommandStream failed: %v", err)
+		}
+		if exitStatus.Code != 0 {
+			t.Errorf("Expected exit code 0, got %d", exitStatus.Code)
+		}
+		if got := strings.TrimSpace(stdout); got != "hello" {
+			t.Errorf("Expected 'hello', got '%s'", got)
+		}
+
+		_, exitStatus, err = execCollect(ctx, svc, &ExecuteCommandRequest{RunnerID: runner.ID, Command: "exit 3"})
+		if err != nil {
+			t.Fatalf("ExecuteCommandStream failed: %v", err)
+		}
+		if exitStatus.Code != 3 {
+			t.Errorf("Expected exit code 3, got %d", exitStatus.Code)
+		}
+	})
+
+	if _, err := svc.DeleteRunner(ctx, runner.ID, false); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+	e2e.WaitForPodGone(t, cluster.Clientset, namespace, podName)
+	if _, err := svc.GetRunner(ctx, runner.ID); !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("Expected ErrRunnerNotFound after deletion, got %v", err)
 	}
+}
+
+// TestCleanupReapsIdleRunner leaves a runner idle until the cleanup service deletes it
+func TestCleanupReapsIdleRunner(t *testing.T) {
+	cluster := e2e.Start(t)
+	svc, _ := newIntegrationRunnerService(t, cluster)
+	ctx := context.Background()
 
-	// Test listing runners
-	runners, total, err := service.ListRunners(ctx, &ListOptions{Limit: 10})
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
 	if err != nil {
-		t.Errorf("Failed to list runners: %v", err)
+		t.Fatalf("CreateRunner failed: %v", err)
 	}
+	waitForRunnerRunning(t, cluster, svc, runner.ID)
 
-	if total == 0 {
-		t.Error("Expected at least one runner in the list")
+	durations := DefaultDurations()
+	durations.CleanupInterval = 200 * time.Millisecond
+	durations.IdleTimeout = time.Second
+	cleanup := NewCleanupService(svc, svc.activityTracker, kubernetesClient(svc), durations, nil)
+	cleanupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cleanup.Start(cleanupCtx)
+	defer cleanup.Stop()
+
+	e2e.WaitForPodGone(t, cluster.Clientset, svc.k8sClient.Config().Namespace, kubernetesClient(svc).getPodName(runner.ID))
+	e2e.Eventually(t, 10*time.Second, "the reaped runner to no longer be tracked", func(ctx context.Context) (bool, error) {
+		return svc.activityTracker.GetLastActiveTime(runner.ID).IsZero(), nil
+	})
+}
+
+// TestReadOnlyWorkspaceRejectsWrites checks that a process in a runner with a read-only
+// workspace can't write to the volume under the s3fs mount. There's no S3 on the cluster, so
+// the stub image stands in for the sidecar and nothing is mounted over the volume.
+func TestReadOnlyWorkspaceRejectsWrites(t *testing.T) {
+	cluster := e2e.Start(t)
+	svc, _ := newIntegrationRunnerService(t, cluster)
+	ctx := context.Background()
+	if _, err := svc.k8sClient.Config().SetImages(RunnerImages{S3FS: cluster.RunnerImage}); err != nil {
+		t.Fatalf("SetImages failed: %v", err)
 	}
 
-	if len(runners) == 0 {
-		t.Error("Expected runners in the response")
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Workspace: &WorkspaceConfig{Bucket: "e2e-datasets", ReadOnly: true}})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
 	}
+	if runner.WorkspaceMode != WorkspaceModeReadOnly {
+		t.Errorf("Expected a read-only workspace, got %q", runner.WorkspaceMode)
+	}
+
+	cluster.RequirePods(t)
+	// The stand-in sidecar exits, so the pod runs but the runner never becomes ready
+	e2e.WaitForPodPhase(t, cluster.Clientset, svc.k8sClient.Config().Namespace, kubernetesClient(svc).getPodName(runner.ID), corev1.PodRunning)
+
+	stdoutCh := make(chan []byte, 16)
+	stderrCh := make(chan []byte, 16)
+	var stderr bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range stdoutCh {
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for chunk := range stderrCh {
+			stderr.Write(chunk)
+		}
+	}()
+	// The runner isn't ready, so the command goes to its container directly
+	command := []string{"sh", "-c", "echo lost > " + datasetMountPath + "/probe"}
+	exitStatus, err := svc.k8sClient.ExecuteCommandStream(ctx, runner.ID, command, execOutput{}, stdoutCh, stderrCh)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("ExecuteCommandStream failed: %v", err)
+	}
+	if exitStatus.Code == 0 {
+		t.Errorf("Expected writing to %s to fail, it succeeded", datasetMountPath)
+	}
+	if !strings.Contains(stderr.String(), "Read-only file system") {
+		t.Errorf("Expected a read-only file system error, got %q", stderr.String())
+	}
+}
+
+// execCollect runs a command in a runner and returns its stdout. The output channels are only
+// closed once the command was started, so they aren't waited on after an error.
+func execCollect(ctx context.Context, svc *runnerService, req *ExecuteCommandRequest) (string, ExitStatus, error) {
+	stdoutCh := make(chan []byte, 16)
+	stderrCh := make(chan []byte, 16)
+	var stdout bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for chunk := range stdoutCh {
+			stdout.Write(chunk)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range stderrCh {
+		}
+	}()
 
-	// Test deleting the runner
-	err = service.DeleteRunner(ctx, runner.ID)
+	exitStatus, err := svc.ExecuteCommandStream(ctx, req, stdoutCh, stderrCh)
 	if err != nil {
-		t.Errorf("Failed to delete runner: %v", err)
+		return "", exitStatus, err
 	}
-}
\ No newline at end of file
+	wg.Wait()
+	return stdout.String(), exitStatus, err
+}
@@ -1,9 +1,80 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
+// newTestRunnerService creates a runner service backed by a fake clientset with fast monitor polling
+func newTestRunnerService() (*runnerService, *fake.Clientset) {
+	clientset := fake.NewSimpleClientset()
+	k8sClient := &KubernetesClient{
+		clientset: clientset,
+		config:    DefaultKubernetesConfig(),
+	}
+
+	// New pods start out pending, as they would before being scheduled
+	clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		action.(k8stesting.CreateAction).GetObject().(*corev1.Pod).Status.Phase = corev1.PodPending
+		return false, nil, nil
+	})
+
+	svc := NewRunnerService(k8sClient, NewActivityTracker(), DefaultDurations(), nil).(*runnerService)
+	svc.monitorInterval = 10 * time.Millisecond
+	return svc, clientset
+}
+
+// kubernetesClient returns the KubernetesClient of a service created by newTestRunnerService
+func kubernetesClient(svc *runnerService) *KubernetesClient {
+	return svc.k8sClient.(*KubernetesClient)
+}
+
+// monitorCount returns the number of active runner monitors
+func monitorCount(svc *runnerService) int {
+	svc.monitorsMu.Lock()
+	defer svc.monitorsMu.Unlock()
+	return len(svc.monitors)
+}
+
+// bootstrapStatusUpdates counts pod patches that touched the bootstrap status annotation
+func bootstrapStatusUpdates(clientset *fake.Clientset) int {
+	count := 0
+	for _, action := range clientset.Actions() {
+		patch, ok := action.(k8stesting.PatchAction)
+		if !ok || patch.GetResource().Resource != "pods" {
+			continue
+		}
+		var body struct {
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal(patch.GetPatch(), &body); err != nil {
+			continue
+		}
+		status := body.Metadata.Annotations[RunnerBootstrapStatusAnnotation]
+		if status != "" && status != BootstrapStatusPending {
+			count++
+		}
+	}
+	return count
+}
+
 // TestDomainTypes tests the domain type conversions
 func TestDomainTypes(t *testing.T) {
 	// Test ResourceRequirements conversion
@@ -67,3 +138,630 @@ func TestDomainTypes(t *testing.T) {
 		t.Errorf("Expected IP address '192.168.1.1', got '%s'", runnerProto.IpAddress)
 	}
 }
+
+func TestDeleteRunnerStopsMonitor(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{BootstrapCommand: "make setup"})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	// Let the monitor poll the pending pod a few times
+	time.Sleep(50 * time.Millisecond)
+
+	if _, err := svc.DeleteRunner(ctx, runner.ID, false); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+
+	if remaining := monitorCount(svc); remaining != 0 {
+		t.Errorf("Expected no monitors after delete, got %d", remaining)
+	}
+
+	if updates := bootstrapStatusUpdates(clientset); updates != 0 {
+		t.Errorf("Expected monitor not to touch the bootstrap status, got %d updates", updates)
+	}
+}
+
+func TestMonitorStopsOnShutdown(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc, clientset := newTestRunnerService()
+	svc.Start(ctx)
+
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{BootstrapCommand: "make setup"}); err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	cancel()
+	svc.Stop()
+
+	if remaining := monitorCount(svc); remaining != 0 {
+		t.Errorf("Expected no monitors after stop, got %d", remaining)
+	}
+
+	if updates := bootstrapStatusUpdates(clientset); updates != 0 {
+		t.Errorf("Expected shutdown not to record a bootstrap result, got %d updates", updates)
+	}
+}
+
+func TestMonitorIgnoresTerminatingPod(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{BootstrapCommand: "make setup"})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	// Mark the pod as terminating and ready, as seen while Kubernetes tears it down
+	pod, err := svc.k8sClient.GetRunnerPod(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	now := metav1.Now()
+	pod.DeletionTimestamp = &now
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	if _, err := clientset.CoreV1().Pods(pod.Namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update pod: %v", err)
+	}
+
+	// The monitor exits on its own once it sees the pod is stopping
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && monitorCount(svc) != 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if status := PodToRunner(pod).Status; status != RunnerStatusStopping {
+		t.Errorf("Expected terminating pod to map to stopping, got %v", status)
+	}
+	if updates := bootstrapStatusUpdates(clientset); updates != 0 {
+		t.Errorf("Expected no bootstrap on a terminating pod, got %d updates", updates)
+	}
+}
+
+func TestCreateDeleteCyclesDoNotLeak(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+
+	for i := 0; i < 10; i++ {
+		runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{BootstrapCommand: "make setup"})
+		if err != nil {
+			t.Fatalf("CreateRunner failed: %v", err)
+		}
+		if _, err := svc.DeleteRunner(ctx, runner.ID, false); err != nil {
+			t.Fatalf("DeleteRunner failed: %v", err)
+		}
+	}
+
+	// Leave some runners behind for Stop to clean up
+	for i := 0; i < 3; i++ {
+		if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{BootstrapCommand: "make setup"}); err != nil {
+			t.Fatalf("CreateRunner failed: %v", err)
+		}
+	}
+
+	svc.Stop()
+
+	if remaining := monitorCount(svc); remaining != 0 {
+		t.Errorf("Expected no monitors after stop, got %d", remaining)
+	}
+}
+
+func TestCreateRunnerAfterStopDoesNotMonitor(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+	svc.Stop()
+
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{BootstrapCommand: "make setup"}); err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	if remaining := monitorCount(svc); remaining != 0 {
+		t.Errorf("Expected no monitor once stopped, got %d", remaining)
+	}
+}
+
+// fakeClock is a manually advanced clock for measuring provisioning time
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// setPodStatus updates the phase and readiness of a runner pod in the fake clientset
+func setPodStatus(t *testing.T, svc *runnerService, clientset *fake.Clientset, runnerID string, phase corev1.PodPhase, ready bool) {
+	t.Helper()
+
+	ctx := context.Background()
+	pod, err := svc.k8sClient.GetRunnerPod(ctx, runnerID)
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	pod.Status.Phase = phase
+	if ready {
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	}
+	if _, err := clientset.CoreV1().Pods(pod.Namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update pod: %v", err)
+	}
+}
+
+// waitForMonitors waits until all runner monitors have exited
+func waitForMonitors(t *testing.T, svc *runnerService) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && monitorCount(svc) != 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if remaining := monitorCount(svc); remaining != 0 {
+		t.Fatalf("Expected monitors to exit, %d still running", remaining)
+	}
+}
+
+// histogramSnapshot returns the sample count and sum of a provisioning histogram series of svc
+func histogramSnapshot(t *testing.T, svc *runnerService, image string) (uint64, float64) {
+	t.Helper()
+
+	var m dto.Metric
+	if err := svc.metrics.RunnerProvisioningDuration.WithLabelValues(runnerPreset, image).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Failed to read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+func TestProvisioningDurationRecorded(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	svc.now = clock.Now
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	// The kubelet reports the image as cached
+	_, err = clientset.CoreV1().Events(svc.k8sClient.Config().Namespace).Create(ctx, &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: "pulled"},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "Pod",
+			Name:      kubernetesClient(svc).getPodName(runner.ID),
+			FieldPath: "spec.containers{runner}",
+		},
+		Reason:  "Pulled",
+		Message: `Container image "ghcr.io/strrl/grad-runner:latest" already present on machine`,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create event: %v", err)
+	}
+
+	clock.Advance(42 * time.Second)
+	setPodStatus(t, svc, clientset, runner.ID, corev1.PodRunning, true)
+	waitForMonitors(t, svc)
+
+	count, sum := histogramSnapshot(t, svc, ImagePullWarm)
+	if count != 1 {
+		t.Errorf("Expected 1 observation, got %d", count)
+	}
+	if sum != 42 {
+		t.Errorf("Expected observed duration of 42s, got %vs", sum)
+	}
+
+	got, err := svc.GetRunner(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if got.ProvisioningDuration != 42*time.Second {
+		t.Errorf("Expected provisioning duration 42s, got %v", got.ProvisioningDuration)
+	}
+	if ms := got.ToProto().ProvisioningDurationMs; ms != 42000 {
+		t.Errorf("Expected proto provisioning duration 42000ms, got %d", ms)
+	}
+}
+
+func TestProvisioningFailureCounted(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	setPodStatus(t, svc, clientset, runner.ID, corev1.PodFailed, false)
+	waitForMonitors(t, svc)
+
+	if got := testutil.ToFloat64(svc.metrics.RunnerProvisioningFailuresTotal.WithLabelValues(ProvisioningFailurePod)); got != 1 {
+		t.Errorf("Expected 1 pod_error failure, got %v", got)
+	}
+	if got := testutil.ToFloat64(svc.metrics.RunnerErrorsTotal.WithLabelValues(string(RunnerErrorPodFailed))); got != 1 {
+		t.Errorf("Expected 1 pod_failed runner error, got %v", got)
+	}
+
+	got, err := svc.GetRunner(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if got.ErrorReason != RunnerErrorPodFailed {
+		t.Errorf("Expected error reason pod_failed, got %q", got.ErrorReason)
+	}
+}
+
+func TestProvisioningTimeoutCounted(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.monitorTimeout = 50 * time.Millisecond
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	waitForMonitors(t, svc)
+
+	if got := testutil.ToFloat64(svc.metrics.RunnerProvisioningFailuresTotal.WithLabelValues(ProvisioningFailureTimeout)); got != 1 {
+		t.Errorf("Expected 1 timeout failure, got %v", got)
+	}
+	if got := testutil.ToFloat64(svc.metrics.RunnerErrorsTotal.WithLabelValues(string(RunnerErrorProvisionTimeout))); got != 1 {
+		t.Errorf("Expected 1 provision_timeout runner error, got %v", got)
+	}
+
+	// The pod is still pending, but grad gave up on it
+	got, err := svc.GetRunner(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if got.Status != RunnerStatusError || got.ErrorReason != RunnerErrorProvisionTimeout {
+		t.Errorf("Expected error with reason provision_timeout, got %s and %q", got.Status, got.ErrorReason)
+	}
+	if !strings.Contains(got.ErrorMessage, "did not become ready") {
+		t.Errorf("Expected the error message to explain the timeout, got %q", got.ErrorMessage)
+	}
+
+	// Without a bootstrap command the timeout must not mark the runner as failed bootstrap
+	if updates := bootstrapStatusUpdates(clientset); updates != 0 {
+		t.Errorf("Expected no bootstrap status updates, got %d", updates)
+	}
+}
+
+func TestImagePullState(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    *corev1.Event
+		expected string
+	}{
+		{
+			name:     "No events",
+			expected: ImagePullUnknown,
+		},
+		{
+			name: "Cached image",
+			event: &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{Name: "grad-runner-runner-1", FieldPath: "spec.containers{runner}"},
+				Reason:         "Pulled",
+				Message:        `Container image "runner:latest" already present on machine`,
+			},
+			expected: ImagePullWarm,
+		},
+		{
+			name: "Pulled image",
+			event: &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{Name: "grad-runner-runner-1", FieldPath: "spec.containers{runner}"},
+				Reason:         "Pulled",
+				Message:        `Successfully pulled image "runner:latest" in 12.3s`,
+			},
+			expected: ImagePullCold,
+		},
+		{
+			name: "Sidecar pull is ignored",
+			event: &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{Name: "grad-runner-runner-1", FieldPath: "spec.containers{s3fs-sidecar}"},
+				Reason:         "Pulled",
+				Message:        `Successfully pulled image "s3fs:latest" in 1s`,
+			},
+			expected: ImagePullUnknown,
+		},
+		{
+			name: "Other pod is ignored",
+			event: &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{Name: "grad-runner-runner-2", FieldPath: "spec.containers{runner}"},
+				Reason:         "Pulled",
+				Message:        `Successfully pulled image "runner:latest" in 12.3s`,
+			},
+			expected: ImagePullUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			k8sClient := &KubernetesClient{clientset: clientset, config: DefaultKubernetesConfig()}
+
+			ctx := context.Background()
+			if tt.event != nil {
+				tt.event.Name = "event"
+				if _, err := clientset.CoreV1().Events(k8sClient.config.Namespace).Create(ctx, tt.event, metav1.CreateOptions{}); err != nil {
+					t.Fatalf("Failed to create event: %v", err)
+				}
+			}
+
+			if got := k8sClient.ImagePullState(ctx, "runner-1"); got != tt.expected {
+				t.Errorf("Expected image pull state %s, got %s", tt.expected, got)
+			}
+
+			// Only the runner pod's events are listed, not the namespace's
+			listed := 0
+			for _, action := range clientset.Actions() {
+				list, ok := action.(k8stesting.ListAction)
+				if !ok || list.GetResource().Resource != "events" {
+					continue
+				}
+				listed++
+				selector := list.GetListRestrictions().Fields.String()
+				if selector != "involvedObject.kind=Pod,involvedObject.name=grad-runner-runner-1" {
+					t.Errorf("Expected events listed by the runner pod, got field selector %q", selector)
+				}
+			}
+			if listed != 1 {
+				t.Errorf("Expected one events list, got %d", listed)
+			}
+		})
+	}
+}
+
+func TestCreateRunnerRequiresExtraCAConfigMap(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.k8sClient.Config().Proxy = &ProxyConfig{ExtraCAConfigMap: "corp-ca"}
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	_, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if !errors.Is(err, ErrFailedPrecondition) {
+		t.Fatalf("Expected ErrFailedPrecondition, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "corp-ca") {
+		t.Errorf("Expected error to name the configmap, got '%v'", err)
+	}
+
+	pods, err := clientset.CoreV1().Pods(svc.k8sClient.Config().Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list pods: %v", err)
+	}
+	if len(pods.Items) != 0 {
+		t.Errorf("Expected no pod to be created, got %d", len(pods.Items))
+	}
+
+	_, err = clientset.CoreV1().ConfigMaps(svc.k8sClient.Config().Namespace).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp-ca"},
+		Data:       map[string]string{"ca.crt": "-----BEGIN CERTIFICATE-----"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create configmap: %v", err)
+	}
+
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{}); err != nil {
+		t.Fatalf("Expected CreateRunner to succeed once the configmap exists, got %v", err)
+	}
+}
+
+func TestCreateRunnerDisableProxy(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.k8sClient.Config().Proxy = &ProxyConfig{URL: "http://proxy:3128"}
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	for _, disable := range []bool{false, true} {
+		runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{DisableProxy: disable})
+		if err != nil {
+			t.Fatalf("CreateRunner failed: %v", err)
+		}
+
+		pod, err := svc.k8sClient.GetRunnerPod(ctx, runner.ID)
+		if err != nil {
+			t.Fatalf("GetRunnerPod failed: %v", err)
+		}
+		hasProxy := false
+		for _, env := range findContainer(pod, RunnerContainerName).Env {
+			if env.Name == "HTTP_PROXY" {
+				hasProxy = true
+			}
+		}
+		if hasProxy == disable {
+			t.Errorf("Expected HTTP_PROXY set=%v with disable_proxy=%v", !disable, disable)
+		}
+	}
+}
+
+func TestCreateRunnerRejectsInvalidSidecarResources(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	_, err := svc.CreateRunner(ctx, &CreateRunnerRequest{
+		Workspace: &WorkspaceConfig{Bucket: "data", S3FSMemory: "plenty"},
+	})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("Expected ErrInvalidRequest, got %v", err)
+	}
+
+	pods, err := clientset.CoreV1().Pods(svc.k8sClient.Config().Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list pods: %v", err)
+	}
+	if len(pods.Items) != 0 {
+		t.Errorf("Expected no pod to be created, got %d", len(pods.Items))
+	}
+}
+
+func TestCreateRunnerInvalidConfigQuantity(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	// A config that skipped LoadConfig, which would have rejected the typo
+	svc.k8sClient.Config().DefaultMemory = "2Gib"
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("Expected CreateRunner to return an error, got a panic: %v", r)
+			}
+		}()
+		_, err = svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	}()
+	if !errors.Is(err, ErrInvalidConfig) || errors.Is(err, ErrKubernetesAPI) {
+		t.Fatalf("Expected ErrInvalidConfig, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "2Gib") {
+		t.Errorf("Expected the error to name the bad quantity, got %v", err)
+	}
+
+	pods, err := clientset.CoreV1().Pods(svc.k8sClient.Config().Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list pods: %v", err)
+	}
+	if len(pods.Items) != 0 {
+		t.Errorf("Expected no pod to be created, got %d", len(pods.Items))
+	}
+}
+
+func TestCreateRunnerExecDefaults(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{DefaultShell: "zsh", DefaultWorkdir: "/workspace"})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+
+	pod, err := svc.k8sClient.GetRunnerPod(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	stored := PodToRunner(pod)
+	if stored.DefaultShell != "zsh" || stored.DefaultWorkdir != "/workspace" {
+		t.Errorf("Expected defaults zsh and /workspace, got %q and %q", stored.DefaultShell, stored.DefaultWorkdir)
+	}
+
+	_, err = svc.CreateRunner(ctx, &CreateRunnerRequest{DefaultWorkdir: "workspace"})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for relative workdir, got %v", err)
+	}
+}
+
+func TestListRunnersByLabels(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	ml, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Labels: map[string]string{"team": "ml", "gpu": "a100"}})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	for _, labels := range []map[string]string{{"team": "infra"}, nil} {
+		if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Labels: labels}); err != nil {
+			t.Fatalf("CreateRunner failed: %v", err)
+		}
+	}
+
+	runners, total, err := svc.ListRunners(ctx, &ListOptions{Labels: map[string]string{"team": "ml"}})
+	if err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+	if total != 1 || runners[0].ID != ml.ID {
+		t.Errorf("Expected only runner %s, got %d runners", ml.ID, total)
+	}
+
+	if _, total, _ := svc.ListRunners(ctx, &ListOptions{Labels: map[string]string{"team": "ml", "gpu": "h100"}}); total != 0 {
+		t.Errorf("Expected every label to have to match, got %d runners", total)
+	}
+	if _, total, _ := svc.ListRunners(ctx, &ListOptions{}); total != 3 {
+		t.Errorf("Expected 3 runners without a label filter, got %d", total)
+	}
+}
+
+func TestListRunnersByName(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	for _, name := range []string{"train", "train", "train-2"} {
+		if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Name: name}); err != nil {
+			t.Fatalf("CreateRunner failed: %v", err)
+		}
+	}
+
+	// Unlike Filter, Name has to match exactly
+	runners, total, err := svc.ListRunners(ctx, &ListOptions{Name: "train"})
+	if err != nil {
+		t.Fatalf("ListRunners failed: %v", err)
+	}
+	if total != 2 || runners[0].Name != "train" || runners[1].Name != "train" {
+		t.Errorf("Expected the 2 runners named train, got %d runners", total)
+	}
+	if _, total, _ := svc.ListRunners(ctx, &ListOptions{Name: "Train"}); total != 0 {
+		t.Errorf("Expected names to be compared by case, got %d runners", total)
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultKubernetesVersionTTL is how long ServerInfo reuses the Kubernetes server version
+	DefaultKubernetesVersionTTL = 10 * time.Minute
+	// kubernetesVersionRetryInterval is how soon a failed version lookup is tried again
+	kubernetesVersionRetryInterval = 30 * time.Second
+	// kubernetesVersionTimeout bounds a version lookup, keeping health checks fast
+	kubernetesVersionTimeout = 2 * time.Second
+)
+
+// ServerInfo reports the grad build, its uptime and the version of the Kubernetes API it
+// talks to. The Kubernetes version is looked up on first use and cached for a TTL.
+type ServerInfo struct {
+	build        BuildInfo
+	startedAt    time.Time
+	fetchVersion func(ctx context.Context) (string, error)
+	ttl          time.Duration
+	// now is the clock used for uptime and the cache
+	now func() time.Time
+
+	mu        sync.Mutex
+	version   string
+	expiresAt time.Time
+}
+
+// ServerStatus is what ServerInfo reports at one point in time
+type ServerStatus struct {
+	BuildInfo
+	Uptime time.Duration
+	// KubernetesVersion is the API server's git version, e.g. "v1.30.2", or "" if unknown
+	KubernetesVersion string
+}
+
+// NewServerInfo returns a ServerInfo started now. fetchVersion looks up the Kubernetes
+// server version; it may be nil if there is none.
+func NewServerInfo(build BuildInfo, fetchVersion func(ctx context.Context) (string, error), ttl time.Duration) *ServerInfo {
+	return &ServerInfo{
+		build:        build,
+		startedAt:    time.Now(),
+		fetchVersion: fetchVersion,
+		ttl:          ttl,
+		now:          time.Now,
+	}
+}
+
+// Status returns the build, uptime and Kubernetes version, looking the version up if the
+// cached one expired
+func (i *ServerInfo) Status(ctx context.Context) ServerStatus {
+	return ServerStatus{
+		BuildInfo:         i.build,
+		Uptime:            i.now().Sub(i.startedAt),
+		KubernetesVersion: i.kubernetesVersion(ctx),
+	}
+}
+
+// kubernetesVersion returns the cached version, refreshing it once expired. A failed lookup
+// keeps the last known version and is retried sooner than the TTL.
+func (i *ServerInfo) kubernetesVersion(ctx context.Context) string {
+	if i.fetchVersion == nil {
+		return ""
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.now().Before(i.expiresAt) {
+		return i.version
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, kubernetesVersionTimeout)
+	defer cancel()
+	version, err := i.fetchVersion(ctx)
+	if err != nil {
+		slog.Warn("Failed to get Kubernetes server version", "error", err)
+		i.expiresAt = i.now().Add(kubernetesVersionRetryInterval)
+		return i.version
+	}
+
+	i.version = version
+	i.expiresAt = i.now().Add(i.ttl)
+	return i.version
+}
+
+// ServerVersion returns the git version of the Kubernetes API server, e.g. "v1.30.2"
+func (k *KubernetesClient) ServerVersion(ctx context.Context) (string, error) {
+	type result struct {
+		version string
+		err     error
+	}
+
+	// The discovery client takes no context, so stop waiting for it once ctx is done
+	resultCh := make(chan result, 1)
+	go func() {
+		info, err := k.clientset.Discovery().ServerVersion()
+		if err != nil {
+			resultCh <- result{err: err}
+			return
+		}
+		resultCh <- result{version: info.GitVersion}
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.version, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
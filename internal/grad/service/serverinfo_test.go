@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBuildInfoFrom(t *testing.T) {
+	embedded := &debug.BuildInfo{
+		GoVersion: "go1.24.5",
+		Main:      debug.Module{Path: "github.com/strrl/gra", Version: "v0.3.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "3f1c0a9e"},
+			{Key: "vcs.time", Value: "2026-10-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	build := buildInfoFrom("v1.2.0", "abc1234", "2026-10-02", embedded)
+	expected := BuildInfo{Version: "v1.2.0", Commit: "abc1234", BuildDate: "2026-10-02", GoVersion: "go1.24.5"}
+	if build != expected {
+		t.Errorf("Expected ldflags values to win, got %+v", build)
+	}
+
+	build = buildInfoFrom("", "", "", embedded)
+	expected = BuildInfo{Version: "v0.3.0", Commit: "3f1c0a9e-dirty", BuildDate: "2026-10-01T12:00:00Z", GoVersion: "go1.24.5"}
+	if build != expected {
+		t.Errorf("Expected the embedded build info, got %+v", build)
+	}
+
+	build = buildInfoFrom("", "", "", &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}})
+	if build.Version != "unknown" || build.Commit != "unknown" || build.BuildDate != "unknown" || build.GoVersion == "" {
+		t.Errorf("Expected unknown build details and the running Go version, got %+v", build)
+	}
+}
+
+func TestServerInfoCachesKubernetesVersion(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	fetches := 0
+	var fetchErr error
+	version := "v1.30.2"
+	fetch := func(ctx context.Context) (string, error) {
+		fetches++
+		if fetchErr != nil {
+			return "", fetchErr
+		}
+		return version, nil
+	}
+
+	info := NewServerInfo(BuildInfo{Version: "v1.2.0"}, fetch, 10*time.Minute)
+	info.startedAt = now
+	info.now = func() time.Time { return now }
+
+	if fetches != 0 {
+		t.Fatalf("Expected no lookup before the version is needed, got %d", fetches)
+	}
+
+	now = now.Add(90 * time.Second)
+	status := info.Status(ctx)
+	if status.KubernetesVersion != "v1.30.2" || status.Uptime != 90*time.Second || status.Version != "v1.2.0" {
+		t.Errorf("Expected v1.30.2 after 90s of uptime, got %+v", status)
+	}
+
+	version = "v1.31.0"
+	now = now.Add(5 * time.Minute)
+	if got := info.Status(ctx).KubernetesVersion; got != "v1.30.2" || fetches != 1 {
+		t.Errorf("Expected the cached version within the TTL, got %q after %d lookups", got, fetches)
+	}
+
+	// A failed lookup keeps the last version and is retried before the TTL is up again
+	fetchErr = errors.New("connection refused")
+	now = now.Add(10 * time.Minute)
+	if got := info.Status(ctx).KubernetesVersion; got != "v1.30.2" || fetches != 2 {
+		t.Errorf("Expected the last version after a failed lookup, got %q after %d lookups", got, fetches)
+	}
+
+	fetchErr = nil
+	now = now.Add(kubernetesVersionRetryInterval)
+	if got := info.Status(ctx).KubernetesVersion; got != "v1.31.0" || fetches != 3 {
+		t.Errorf("Expected the new version once retried, got %q after %d lookups", got, fetches)
+	}
+}
+
+func TestServerInfoWithoutKubernetes(t *testing.T) {
+	info := NewServerInfo(BuildInfo{}, nil, time.Minute)
+	if got := info.Status(context.Background()).KubernetesVersion; got != "" {
+		t.Errorf("Expected no Kubernetes version, got %q", got)
+	}
+}
+
+func TestKubernetesClientServerVersion(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.30.2"}
+	k8sClient := &KubernetesClient{clientset: clientset, config: DefaultKubernetesConfig()}
+
+	got, err := k8sClient.ServerVersion(context.Background())
+	if err != nil || got != "v1.30.2" {
+		t.Errorf("Expected v1.30.2, got %q (%v)", got, err)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnsureServiceAccount creates the configured runner ServiceAccount if it doesn't exist.
+// It has no RBAC bindings, so runners using it can't do anything with the Kubernetes API.
+func (k *KubernetesClient) EnsureServiceAccount(ctx context.Context) error {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	config := k.config.ServiceAccount
+	if config == nil || config.Name == "" {
+		return nil
+	}
+
+	_, err := k.clientset.CoreV1().ServiceAccounts(k.config.Namespace).Get(ctx, config.Name, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get service account: %w", err)
+	}
+
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.Name,
+			Namespace: k.config.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "grad"},
+		},
+		AutomountServiceAccountToken: &[]bool{config.AutomountToken}[0],
+	}
+	if _, err := k.clientset.CoreV1().ServiceAccounts(k.config.Namespace).Create(ctx, serviceAccount, metav1.CreateOptions{}); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to create service account: %w", err)
+	}
+	slog.Info("Created runner service account", "name", config.Name, "namespace", k.config.Namespace)
+
+	return nil
+}
+
+// GetServiceAccount gets a ServiceAccount from the runner namespace
+func (k *KubernetesClient) GetServiceAccount(ctx context.Context, name string) (*corev1.ServiceAccount, error) {
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	serviceAccount, err := k.clientset.CoreV1().ServiceAccounts(k.config.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account: %w", err)
+	}
+
+	return serviceAccount, nil
+}
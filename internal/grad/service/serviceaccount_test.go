@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnsureServiceAccount(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	config := DefaultKubernetesConfig()
+	config.ServiceAccount = &ServiceAccountConfig{Name: "grad-runner"}
+	k8sClient := &KubernetesClient{clientset: clientset, config: config}
+
+	if err := k8sClient.EnsureServiceAccount(ctx); err != nil {
+		t.Fatalf("EnsureServiceAccount failed: %v", err)
+	}
+
+	serviceAccount, err := clientset.CoreV1().ServiceAccounts(config.Namespace).Get(ctx, "grad-runner", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected service account to be created: %v", err)
+	}
+	if serviceAccount.AutomountServiceAccountToken == nil || *serviceAccount.AutomountServiceAccountToken {
+		t.Error("Expected token automount to be disabled on the service account")
+	}
+	if serviceAccount.Labels["app.kubernetes.io/managed-by"] != "grad" {
+		t.Errorf("Expected managed-by label 'grad', got '%s'", serviceAccount.Labels["app.kubernetes.io/managed-by"])
+	}
+
+	// Existing accounts are left alone
+	serviceAccount.Labels["team"] = "data"
+	if _, err := clientset.CoreV1().ServiceAccounts(config.Namespace).Update(ctx, serviceAccount, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update service account: %v", err)
+	}
+	if err := k8sClient.EnsureServiceAccount(ctx); err != nil {
+		t.Fatalf("EnsureServiceAccount failed on existing account: %v", err)
+	}
+	serviceAccount, _ = clientset.CoreV1().ServiceAccounts(config.Namespace).Get(ctx, "grad-runner", metav1.GetOptions{})
+	if serviceAccount.Labels["team"] != "data" {
+		t.Error("Expected existing service account to be left untouched")
+	}
+}
+
+func TestEnsureServiceAccountDisabled(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	config := DefaultKubernetesConfig()
+	config.ServiceAccount = &ServiceAccountConfig{}
+	k8sClient := &KubernetesClient{clientset: clientset, config: config}
+
+	if err := k8sClient.EnsureServiceAccount(ctx); err != nil {
+		t.Fatalf("EnsureServiceAccount failed: %v", err)
+	}
+	if len(clientset.Actions()) != 0 {
+		t.Errorf("Expected no API calls without a configured account, got %d", len(clientset.Actions()))
+	}
+}
+
+func TestCreateRunnerServiceAccount(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	namespace := svc.k8sClient.Config().Namespace
+	svc.k8sClient.Config().ServiceAccount = &ServiceAccountConfig{
+		Name:    "grad-runner",
+		Allowed: []string{"ci-deployer", "missing"},
+	}
+	svc.Start(ctx)
+	defer svc.Stop()
+
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{ServiceAccount: "default"}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for a service account not in the allowlist, got %v", err)
+	}
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{ServiceAccount: "missing"}); !errors.Is(err, ErrFailedPrecondition) {
+		t.Errorf("Expected ErrFailedPrecondition for a missing service account, got %v", err)
+	}
+
+	_, err := clientset.CoreV1().ServiceAccounts(namespace).Create(ctx, &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci-deployer"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create service account: %v", err)
+	}
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{ServiceAccount: "ci-deployer"})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	pod, err := svc.k8sClient.GetRunnerPod(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	if pod.Spec.ServiceAccountName != "ci-deployer" {
+		t.Errorf("Expected pod to run as 'ci-deployer', got '%s'", pod.Spec.ServiceAccountName)
+	}
+
+	// The default runner account is created on demand
+	if _, err := clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, "grad-runner", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected runner service account to be created: %v", err)
+	}
+}
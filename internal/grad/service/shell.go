@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultExecShell runs commands when neither the request, the runner nor the server config sets a shell
+const DefaultExecShell = "bash"
+
+// ExecShellNone as a request's shell runs its argv directly, without a shell
+const ExecShellNone = "none"
+
+// cdAndExec is the sh script changing into the directory $1 and then running the rest of its
+// arguments as a program
+const cdAndExec = `cd "$1" && shift && exec "$@"`
+
+// resolveExecSettings picks the shell and working directory for a command: the request's own
+// values win, then the runner's defaults, then the server's (pure function)
+func resolveExecSettings(req *ExecuteCommandRequest, runner *Runner, config *KubernetesConfig) (shell, workdir string) {
+	shell = firstNonEmpty(req.Shell, runner.DefaultShell, config.DefaultShell, DefaultExecShell)
+	workdir = firstNonEmpty(req.WorkingDir, runner.DefaultWorkdir, config.DefaultWorkdir)
+	return shell, workdir
+}
+
+// shellCommand returns the argv running command with shell in workdir (pure function).
+// Exec has no working directory option, so sh changes into it first and then execs the
+// shell, which works for interpreters like python that can't run cd themselves.
+func shellCommand(shell, workdir, command string) []string {
+	if workdir == "" {
+		return []string{shell, "-c", command}
+	}
+	return []string{"sh", "-c", cdAndExec, "sh", workdir, shell, "-c", command}
+}
+
+// execArgv returns the argv running the command of req with shell in workdir: its argv
+// directly for ExecShellNone, otherwise its command line through the shell (pure function)
+func execArgv(shell, workdir string, req *ExecuteCommandRequest) []string {
+	if len(req.Argv) == 0 || shell != ExecShellNone {
+		return shellCommand(shell, workdir, req.CommandLine())
+	}
+	if workdir == "" {
+		return slices.Clone(req.Argv)
+	}
+	return append([]string{"sh", "-c", cdAndExec, "sh", workdir}, req.Argv...)
+}
+
+// validateExecDefaults checks a runner's default shell and working directory (pure function)
+func validateExecDefaults(shell, workdir string) error {
+	if err := validateDefaultShell(shell); err != nil {
+		return err
+	}
+	return validateDefaultWorkdir(workdir)
+}
+
+// validateDefaultShell checks that a default shell is a bare program (pure function)
+func validateDefaultShell(shell string) error {
+	if shell == ExecShellNone {
+		return fmt.Errorf("%w: default shell can't be %q, which only runs argv", ErrInvalidRequest, ExecShellNone)
+	}
+	if strings.ContainsAny(shell, " \t\r\n") {
+		return fmt.Errorf("%w: default shell %q must be a program name or path without arguments", ErrInvalidRequest, shell)
+	}
+	return nil
+}
+
+// validateDefaultWorkdir checks that a default workdir, if set, is absolute (pure function)
+func validateDefaultWorkdir(workdir string) error {
+	if workdir != "" && !path.IsAbs(workdir) {
+		return fmt.Errorf("%w: default workdir %q must be an absolute path", ErrInvalidRequest, workdir)
+	}
+	return nil
+}
+
+// firstNonEmpty returns the first non-empty value, or "" if all are empty (pure function)
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
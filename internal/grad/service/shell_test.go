@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolveExecSettings(t *testing.T) {
+	config := &KubernetesConfig{DefaultShell: "sh", DefaultWorkdir: "/srv"}
+
+	tests := []struct {
+		name          string
+		req           *ExecuteCommandRequest
+		runner        *Runner
+		config        *KubernetesConfig
+		expectShell   string
+		expectWorkdir string
+	}{
+		{"Request wins", &ExecuteCommandRequest{Shell: "fish", WorkingDir: "/tmp"}, &Runner{DefaultShell: "zsh", DefaultWorkdir: "/workspace"}, config, "fish", "/tmp"},
+		{"Runner default", &ExecuteCommandRequest{}, &Runner{DefaultShell: "zsh", DefaultWorkdir: "/workspace"}, config, "zsh", "/workspace"},
+		{"Server default", &ExecuteCommandRequest{}, &Runner{}, config, "sh", "/srv"},
+		{"Fallback", &ExecuteCommandRequest{}, &Runner{}, &KubernetesConfig{}, DefaultExecShell, ""},
+		{"Mixed", &ExecuteCommandRequest{WorkingDir: "/tmp"}, &Runner{DefaultShell: "zsh"}, config, "zsh", "/tmp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shell, workdir := resolveExecSettings(tt.req, tt.runner, tt.config)
+			if shell != tt.expectShell {
+				t.Errorf("Expected shell %q, got %q", tt.expectShell, shell)
+			}
+			if workdir != tt.expectWorkdir {
+				t.Errorf("Expected workdir %q, got %q", tt.expectWorkdir, workdir)
+			}
+		})
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	got := strings.Join(shellCommand("bash", "", "echo hi"), "|")
+	if got != "bash|-c|echo hi" {
+		t.Errorf("Expected plain shell command, got %q", got)
+	}
+
+	argv := shellCommand("python3", "/work dir", "print(1)")
+	if argv[0] != "sh" {
+		t.Errorf("Expected sh to change directory, got %q", argv[0])
+	}
+	tail := strings.Join(argv[4:], "|")
+	if tail != "/work dir|python3|-c|print(1)" {
+		t.Errorf("Expected workdir and shell passed as arguments, got %q", tail)
+	}
+}
+
+func TestExecArgv(t *testing.T) {
+	argv := []string{"python", "-c", "print('a b'); import os; print(os.environ['HOME'])"}
+	tests := []struct {
+		name     string
+		shell    string
+		workdir  string
+		req      *ExecuteCommandRequest
+		expected []string
+	}{
+		{
+			"Command line", "bash", "",
+			&ExecuteCommandRequest{Command: "echo $HOME; ls *.go"},
+			[]string{"bash", "-c", "echo $HOME; ls *.go"},
+		},
+		{
+			"Argv quoted for the shell", "bash", "",
+			&ExecuteCommandRequest{Argv: argv},
+			[]string{"bash", "-c", `python -c 'print('\''a b'\''); import os; print(os.environ['\''HOME'\''])'`},
+		},
+		{
+			"Argv quoted in a workdir", "sh", "/work dir",
+			&ExecuteCommandRequest{Argv: []string{"echo", "$HOME", "*", "a;b"}},
+			[]string{"sh", "-c", cdAndExec, "sh", "/work dir", "sh", "-c", `echo '$HOME' '*' 'a;b'`},
+		},
+		{
+			"Argv without a shell", ExecShellNone, "",
+			&ExecuteCommandRequest{Argv: argv},
+			argv,
+		},
+		{
+			"Argv without a shell in a workdir", ExecShellNone, "/srv",
+			&ExecuteCommandRequest{Argv: argv},
+			append([]string{"sh", "-c", cdAndExec, "sh", "/srv"}, argv...),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := execArgv(tt.shell, tt.workdir, tt.req)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	if got := (&ExecuteCommandRequest{Command: "ls | wc -l"}).CommandLine(); got != "ls | wc -l" {
+		t.Errorf("Expected the command as it is, got %q", got)
+	}
+	if got := (&ExecuteCommandRequest{Argv: []string{"echo", "hello world"}}).CommandLine(); got != "echo 'hello world'" {
+		t.Errorf("Expected argv quoted, got %q", got)
+	}
+}
+
+func TestValidateExecDefaults(t *testing.T) {
+	tests := []struct {
+		shell       string
+		workdir     string
+		expectError bool
+	}{
+		{"", "", false},
+		{"zsh", "/workspace", false},
+		{"/usr/bin/fish", "", false},
+		{"bash -l", "", true},
+		{"zsh", "workspace", true},
+		{"zsh", "./workspace", true},
+		{ExecShellNone, "", true},
+	}
+
+	for _, tt := range tests {
+		err := validateExecDefaults(tt.shell, tt.workdir)
+		if tt.expectError && !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest for shell %q workdir %q, got %v", tt.shell, tt.workdir, err)
+		}
+		if !tt.expectError && err != nil {
+			t.Errorf("Expected shell %q workdir %q to be valid, got %v", tt.shell, tt.workdir, err)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/shellquote"
+)
+
+const (
+	// SnapshotUploader is the program in the runner image that archives the workspace and
+	// uploads it, reporting progress as JSON lines on stdout
+	SnapshotUploader = "grad-snapshot"
+
+	// snapshotDir is the directory a snapshot archives, without the S3 dataset mounted in it
+	snapshotDir = "/workspace"
+
+	// snapshotStderrTailBytes is how much of the uploader's stderr a failure reports
+	snapshotStderrTailBytes = 1024
+)
+
+// Errors the uploader reports, by the error field of its output
+const (
+	snapshotErrMissingCredentials = "missing_credentials"
+	snapshotErrDiskSpace          = "insufficient_disk_space"
+	snapshotErrUploaderMissing    = "uploader_missing"
+)
+
+// SnapshotPhase is the step a workspace snapshot is at
+type SnapshotPhase string
+
+const (
+	SnapshotPhaseArchiving SnapshotPhase = "archiving"
+	SnapshotPhaseUploading SnapshotPhase = "uploading"
+	SnapshotPhaseDone      SnapshotPhase = "done"
+)
+
+// S3Credentials are AWS credentials for S3
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// SnapshotRequest is the domain request to archive a runner's workspace to S3
+type SnapshotRequest struct {
+	RunnerID string
+	// Destination is an S3 URL; one ending in / gets a name for the archive appended
+	Destination string
+	// Endpoint and Region default to the runner's workspace settings
+	Endpoint string
+	Region   string
+	// Credentials default to the AWS credentials the runner has
+	Credentials *S3Credentials
+}
+
+// SnapshotProgress reports how far a snapshot got. Bucket, Key and SizeBytes are only set
+// once it is SnapshotPhaseDone.
+type SnapshotProgress struct {
+	Phase      SnapshotPhase
+	BytesDone  int64
+	BytesTotal int64
+	Bucket     string
+	Key        string
+	SizeBytes  int64
+}
+
+// snapshotTarget is what the uploader is asked to do
+type snapshotTarget struct {
+	Bucket      string
+	Key         string
+	Endpoint    string
+	Region      string
+	Credentials *S3Credentials
+}
+
+// snapshotLine is a line of the uploader's output: progress, the uploaded archive or an error
+type snapshotLine struct {
+	Phase   SnapshotPhase `json:"phase"`
+	Bytes   int64         `json:"bytes"`
+	Total   int64         `json:"total"`
+	Bucket  string        `json:"bucket"`
+	Key     string        `json:"key"`
+	Size    int64         `json:"size"`
+	Error   string        `json:"error"`
+	Message string        `json:"message"`
+}
+
+// snapshotExecFunc executes a command in a runner like commandExecFunc, giving it stdin
+type snapshotExecFunc func(ctx context.Context, runnerID, command string, stdin io.Reader, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error)
+
+// SnapshotWorkspace archives the workspace of a running runner and uploads it to S3 with the
+// runner image's uploader, calling progress as it goes. It returns the uploaded archive.
+func (s *runnerService) SnapshotWorkspace(ctx context.Context, req *SnapshotRequest, progress func(SnapshotProgress)) (*SnapshotProgress, error) {
+	return s.snapshotWorkspace(ctx, s.snapshotExec, req, progress)
+}
+
+// snapshotExec runs a script with sh in the runner container like sshKeyExec, with stdin
+func (s *runnerService) snapshotExec(ctx context.Context, runnerID, command string, stdin io.Reader, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+	return s.k8sClient.ExecuteCommandStream(ctx, runnerID, []string{"sh", "-c", command}, execOutput{flushInterval: s.flushInterval, stdin: stdin}, stdoutCh, stderrCh)
+}
+
+func (s *runnerService) snapshotWorkspace(ctx context.Context, exec snapshotExecFunc, req *SnapshotRequest, progress func(SnapshotProgress)) (*SnapshotProgress, error) {
+	if s.k8sClient.Config().DisabledFeatures.Disabled(FeatureS3Workspace) {
+		return nil, fmt.Errorf("%w: s3 snapshots are disabled on this server", ErrFailedPrecondition)
+	}
+	bucket, key, err := parseSnapshotDestination(req.Destination, req.RunnerID, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: destination: %w", ErrInvalidRequest, err)
+	}
+	stdin, err := snapshotStdin(req.Credentials)
+	if err != nil {
+		return nil, fmt.Errorf("%w: credentials: %w", ErrInvalidRequest, err)
+	}
+	pod, err := s.getRunningRunnerPod(ctx, req.RunnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	target := snapshotTarget{Bucket: bucket, Key: key, Credentials: req.Credentials}
+	target.Endpoint, target.Region = podWorkspaceS3(pod)
+	if req.Endpoint != "" {
+		target.Endpoint = req.Endpoint
+	}
+	if req.Region != "" {
+		target.Region = req.Region
+	}
+
+	stdoutCh := make(chan []byte, 100)
+	stderrCh := make(chan []byte, 100)
+	type execOutcome struct {
+		status ExitStatus
+		err    error
+	}
+	outcomeCh := make(chan execOutcome, 1)
+	go func() {
+		status, err := exec(ctx, req.RunnerID, snapshotScript(target), stdin, stdoutCh, stderrCh)
+		outcomeCh <- execOutcome{status, err}
+	}()
+
+	var result *SnapshotProgress
+	var failure error
+	var pending, stderr []byte
+	handleLine := func(data []byte) {
+		line, ok := parseSnapshotLine(data)
+		switch {
+		case !ok || failure != nil:
+		case line.Error != "":
+			failure = snapshotError(line.Error, line.Message)
+		case line.Phase == SnapshotPhaseDone:
+			result = &SnapshotProgress{Phase: SnapshotPhaseDone, BytesDone: line.Size, BytesTotal: line.Size, Bucket: line.Bucket, Key: line.Key, SizeBytes: line.Size}
+		case progress != nil:
+			progress(SnapshotProgress{Phase: line.Phase, BytesDone: line.Bytes, BytesTotal: line.Total})
+		}
+	}
+
+	// The exec closes both channels once the uploader is done
+	for stdoutCh != nil || stderrCh != nil {
+		select {
+		case data, ok := <-stdoutCh:
+			if !ok {
+				stdoutCh = nil
+				handleLine(pending)
+				continue
+			}
+			pending = append(pending, data...)
+			for {
+				i := bytes.IndexByte(pending, '\n')
+				if i < 0 {
+					break
+				}
+				handleLine(pending[:i])
+				pending = pending[i+1:]
+			}
+		case data, ok := <-stderrCh:
+			if !ok {
+				stderrCh = nil
+				continue
+			}
+			stderr = append(stderr, data...)
+		}
+	}
+
+	outcome := <-outcomeCh
+	switch {
+	case outcome.err != nil:
+		return nil, fmt.Errorf("%w: %w", ErrCommandExecution, outcome.err)
+	case failure != nil:
+		return nil, failure
+	case outcome.status.Reason != ExitReasonExited || outcome.status.Code != 0:
+		return nil, fmt.Errorf("%w: %s %s: %s", ErrCommandExecution, SnapshotUploader, outcome.status, strings.TrimSpace(outputTail(stderr, snapshotStderrTailBytes)))
+	case result == nil:
+		return nil, fmt.Errorf("%w: %s exited without reporting the upload", ErrCommandExecution, SnapshotUploader)
+	}
+	return result, nil
+}
+
+// parseSnapshotDestination splits an S3 URL into its bucket and key. A key that is empty or
+// ends in / is a prefix, to which RUNNER_ID-TIMESTAMP.tar.gz is appended. (pure function)
+func parseSnapshotDestination(destination, runnerID string, now time.Time) (bucket, key string, err error) {
+	if destination == "" {
+		return "", "", fmt.Errorf("is required")
+	}
+	u, err := url.Parse(destination)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("%q is not an S3 URL like s3://bucket/path", destination)
+	}
+	key = strings.TrimPrefix(u.Path, "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		key += fmt.Sprintf("%s-%s.tar.gz", runnerID, now.UTC().Format("20060102T150405Z"))
+	}
+	return u.Host, key, nil
+}
+
+// podWorkspaceS3 returns the endpoint and region of a runner's S3 workspace, empty if it has
+// none or they are the defaults (pure function)
+func podWorkspaceS3(pod *corev1.Pod) (endpoint, region string) {
+	sidecar := findContainer(pod, S3FSContainerName)
+	if sidecar == nil {
+		return "", ""
+	}
+	for _, env := range sidecar.Env {
+		switch env.Name {
+		case "S3_ENDPOINT":
+			endpoint = env.Value
+		case "AWS_DEFAULT_REGION":
+			region = env.Value
+		}
+	}
+	return endpoint, region
+}
+
+// snapshotScript returns the sh script running the uploader for target. Credentials given in
+// the request replace the runner's own, read from stdin (see snapshotStdin); otherwise the
+// uploader finds them as the AWS SDK does. (pure function)
+func snapshotScript(target snapshotTarget) string {
+	var script strings.Builder
+	if target.Credentials != nil {
+		fmt.Fprintf(&script, "IFS= read -r AWS_ACCESS_KEY_ID && IFS= read -r AWS_SECRET_ACCESS_KEY && IFS= read -r AWS_SESSION_TOKEN || { echo '{\"error\":\"%s\",\"message\":\"no credentials on stdin\"}'; exit 1; }\n",
+			snapshotErrMissingCredentials)
+		script.WriteString("export AWS_ACCESS_KEY_ID AWS_SECRET_ACCESS_KEY\n")
+		script.WriteString("if [ -n \"$AWS_SESSION_TOKEN\" ]; then export AWS_SESSION_TOKEN; else unset AWS_SESSION_TOKEN; fi\n")
+	}
+	fmt.Fprintf(&script, "command -v %s >/dev/null 2>&1 || { echo '{\"error\":\"%s\",\"message\":\"the runner image has no %s\"}'; exit 127; }\n",
+		SnapshotUploader, snapshotErrUploaderMissing, SnapshotUploader)
+
+	args := []string{"exec", SnapshotUploader,
+		"--dir", snapshotDir,
+		"--exclude", strings.TrimPrefix(datasetMountPath, snapshotDir+"/"),
+		"--bucket", shellquote.Quote(target.Bucket),
+		"--key", shellquote.Quote(target.Key),
+	}
+	if target.Endpoint != "" {
+		args = append(args, "--endpoint", shellquote.Quote(target.Endpoint))
+	}
+	if target.Region != "" {
+		args = append(args, "--region", shellquote.Quote(target.Region))
+	}
+	script.WriteString(strings.Join(args, " ") + "\n")
+	return script.String()
+}
+
+// snapshotStdin returns the stdin passing creds to snapshotScript, one per line, or nil
+// without them. Credentials never go in the command, which ends up in the exec URL, the API
+// server's audit log and the runner's /proc. (pure function)
+func snapshotStdin(creds *S3Credentials) (io.Reader, error) {
+	if creds == nil {
+		return nil, nil
+	}
+	values := []string{creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken}
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("must not contain line breaks")
+		}
+	}
+	return strings.NewReader(strings.Join(values, "\n") + "\n"), nil
+}
+
+// parseSnapshotLine parses a line of the uploader's output, reporting false for lines that
+// aren't its JSON, e.g. warnings of the tools it runs (pure function)
+func parseSnapshotLine(data []byte) (snapshotLine, bool) {
+	var line snapshotLine
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return line, false
+	}
+	if err := json.Unmarshal(data, &line); err != nil {
+		return line, false
+	}
+	return line, line.Error != "" || line.Phase != ""
+}
+
+// snapshotError converts an error the uploader reported to a domain error (pure function)
+func snapshotError(code, message string) error {
+	switch code {
+	case snapshotErrMissingCredentials:
+		return fmt.Errorf("%w: %s", ErrMissingCredentials, message)
+	case snapshotErrDiskSpace:
+		return fmt.Errorf("%w: %s", ErrInsufficientDiskSpace, message)
+	case snapshotErrUploaderMissing:
+		return fmt.Errorf("%w: %s", ErrFailedPrecondition, message)
+	default:
+		return fmt.Errorf("%w: %s: %s", ErrCommandExecution, code, message)
+	}
+}
+
+// ToProto converts snapshot progress to proto
+func (p SnapshotProgress) ToProto() *gradv1.SnapshotWorkspaceResponse {
+	return &gradv1.SnapshotWorkspaceResponse{
+		Phase:      p.Phase.ToProto(),
+		BytesDone:  p.BytesDone,
+		BytesTotal: p.BytesTotal,
+		Bucket:     p.Bucket,
+		Key:        p.Key,
+		SizeBytes:  p.SizeBytes,
+	}
+}
+
+// ToProto converts a snapshot phase to proto
+func (p SnapshotPhase) ToProto() gradv1.SnapshotPhase {
+	switch p {
+	case SnapshotPhaseArchiving:
+		return gradv1.SnapshotPhase_SNAPSHOT_PHASE_ARCHIVING
+	case SnapshotPhaseUploading:
+		return gradv1.SnapshotPhase_SNAPSHOT_PHASE_UPLOADING
+	case SnapshotPhaseDone:
+		return gradv1.SnapshotPhase_SNAPSHOT_PHASE_DONE
+	default:
+		return gradv1.SnapshotPhase_SNAPSHOT_PHASE_UNSPECIFIED
+	}
+}
+
+// FromProtoSnapshotRequest converts a proto snapshot request to the domain one
+func FromProtoSnapshotRequest(req *gradv1.SnapshotWorkspaceRequest) *SnapshotRequest {
+	domainReq := &SnapshotRequest{
+		RunnerID:    req.RunnerId,
+		Destination: req.Destination,
+		Endpoint:    req.Endpoint,
+		Region:      req.Region,
+	}
+	if creds := req.Credentials; creds != nil {
+		domainReq.Credentials = &S3Credentials{
+			AccessKeyID:     creds.AccessKeyId,
+			SecretAccessKey: creds.SecretAccessKey,
+			SessionToken:    creds.SessionToken,
+		}
+	}
+	return domainReq
+}
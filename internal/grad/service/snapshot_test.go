@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// snapshotExec returns a snapshotExecFunc that records the script it runs and writes output
+// in the given chunks to stdout, then exits with exitCode
+func snapshotExec(script *string, exitCode int32, chunks ...string) snapshotExecFunc {
+	return func(ctx context.Context, runnerID, command string, stdin io.Reader, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+		defer close(stdoutCh)
+		defer close(stderrCh)
+		*script = command
+		for _, chunk := range chunks {
+			stdoutCh <- []byte(chunk)
+		}
+		if exitCode != 0 {
+			stderrCh <- []byte("tar: /workspace/code: Permission denied\n")
+		}
+		return ExitStatus{Code: exitCode, Reason: ExitReasonExited}, nil
+	}
+}
+
+func TestParseSnapshotDestination(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		destination    string
+		expectedBucket string
+		expectedKey    string
+		expectErr      bool
+	}{
+		{"s3://archive/snapshots/run-42.tar.gz", "archive", "snapshots/run-42.tar.gz", false},
+		{"s3://archive/snapshots/", "archive", "snapshots/runner-1-20261017T093000Z.tar.gz", false},
+		{"s3://archive", "archive", "runner-1-20261017T093000Z.tar.gz", false},
+		{"", "", "", true},
+		{"https://archive.s3.amazonaws.com/run.tar.gz", "", "", true},
+		{"s3:///run.tar.gz", "", "", true},
+	}
+	for _, tt := range tests {
+		bucket, key, err := parseSnapshotDestination(tt.destination, "runner-1", now)
+		if tt.expectErr {
+			if err == nil {
+				t.Errorf("%q: expected an error, got bucket %q key %q", tt.destination, bucket, key)
+			}
+			continue
+		}
+		if err != nil || bucket != tt.expectedBucket || key != tt.expectedKey {
+			t.Errorf("%q: expected %s/%s, got %s/%s (%v)", tt.destination, tt.expectedBucket, tt.expectedKey, bucket, key, err)
+		}
+	}
+}
+
+func TestSnapshotScript(t *testing.T) {
+	script := snapshotScript(snapshotTarget{Bucket: "archive", Key: "it's/run.tar.gz", Region: "eu-west-1"})
+	for _, expected := range []string{
+		"command -v grad-snapshot",
+		"exec grad-snapshot --dir /workspace --exclude dataset --bucket archive --key 'it'\\''s/run.tar.gz' --region eu-west-1",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("Expected the script to contain %q, got:\n%s", expected, script)
+		}
+	}
+	if strings.Contains(script, "AWS_ACCESS_KEY_ID") || strings.Contains(script, "--endpoint") {
+		t.Errorf("Expected the runner's own credentials and the default endpoint, got:\n%s", script)
+	}
+
+	script = snapshotScript(snapshotTarget{Bucket: "archive", Key: "run.tar.gz", Endpoint: "http://minio:9000", Credentials: &S3Credentials{AccessKeyID: "AKIA", SecretAccessKey: "s3cr3t"}})
+	for _, expected := range []string{
+		"IFS= read -r AWS_ACCESS_KEY_ID && IFS= read -r AWS_SECRET_ACCESS_KEY && IFS= read -r AWS_SESSION_TOKEN",
+		"export AWS_ACCESS_KEY_ID AWS_SECRET_ACCESS_KEY",
+		"unset AWS_SESSION_TOKEN",
+		"--endpoint http://minio:9000",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("Expected the script to contain %q, got:\n%s", expected, script)
+		}
+	}
+	if strings.Contains(script, "AKIA") || strings.Contains(script, "s3cr3t") {
+		t.Errorf("Expected the credentials to stay out of the script, got:\n%s", script)
+	}
+}
+
+func TestSnapshotStdin(t *testing.T) {
+	stdin, err := snapshotStdin(nil)
+	if stdin != nil || err != nil {
+		t.Errorf("Expected no stdin without credentials, got %v, %v", stdin, err)
+	}
+
+	stdin, err = snapshotStdin(&S3Credentials{AccessKeyID: "AKIA", SecretAccessKey: "s3cr3t", SessionToken: "t0ken"})
+	if err != nil {
+		t.Fatalf("snapshotStdin failed: %v", err)
+	}
+	data, _ := io.ReadAll(stdin)
+	if string(data) != "AKIA\ns3cr3t\nt0ken\n" {
+		t.Errorf("Expected a line per credential, got %q", data)
+	}
+
+	if _, err := snapshotStdin(&S3Credentials{AccessKeyID: "AKIA", SecretAccessKey: "s3cr3t\nexport X=1"}); err == nil {
+		t.Error("Expected an error for a credential with a line break")
+	}
+}
+
+func TestParseSnapshotLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected snapshotLine
+		ok       bool
+	}{
+		{`{"phase":"archiving","bytes":1024,"total":4096}`, snapshotLine{Phase: SnapshotPhaseArchiving, Bytes: 1024, Total: 4096}, true},
+		{` {"phase":"done","bucket":"archive","key":"run.tar.gz","size":2048}` + "\r", snapshotLine{Phase: SnapshotPhaseDone, Bucket: "archive", Key: "run.tar.gz", Size: 2048}, true},
+		{`{"error":"missing_credentials","message":"no AWS credentials found"}`, snapshotLine{Error: "missing_credentials", Message: "no AWS credentials found"}, true},
+		{"tar: Removing leading `/' from member names", snapshotLine{}, false},
+		{`{"phase":`, snapshotLine{}, false},
+		{`{"unrelated":true}`, snapshotLine{}, false},
+	}
+	for _, tt := range tests {
+		line, ok := parseSnapshotLine([]byte(tt.line))
+		if ok != tt.ok || (ok && line != tt.expected) {
+			t.Errorf("%q: expected %+v (%v), got %+v (%v)", tt.line, tt.expected, tt.ok, line, ok)
+		}
+	}
+}
+
+func TestSnapshotWorkspace(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+	pod, err := svc.k8sClient.GetRunnerPod(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	pod.Spec.Containers = []corev1.Container{{Name: S3FSContainerName, Env: []corev1.EnvVar{
+		{Name: "S3_ENDPOINT", Value: "http://minio:9000"},
+		{Name: "AWS_DEFAULT_REGION", Value: "eu-west-1"},
+	}}}
+	if _, err := clientset.CoreV1().Pods(pod.Namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update pod: %v", err)
+	}
+
+	// Lines arrive split across chunks, mixed with output that isn't the uploader's
+	var script string
+	exec := snapshotExec(&script, 0,
+		`{"phase":"archiving","bytes":0,"total":4096}`+"\n"+`{"phase":"archi`,
+		`ving","bytes":4096,"total":4096}`+"\ntar: Removing leading `/'\n",
+		`{"phase":"uploading","bytes":2048,"total":2048}`+"\n",
+		`{"phase":"done","bucket":"archive","key":"snapshots/run.tar.gz","size":2048}`,
+	)
+	var progress []SnapshotProgress
+	result, err := svc.snapshotWorkspace(ctx, exec, &SnapshotRequest{RunnerID: "runner-1", Destination: "s3://archive/snapshots/run.tar.gz", Region: "us-east-2"}, func(p SnapshotProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatalf("snapshotWorkspace failed: %v", err)
+	}
+
+	if result.Bucket != "archive" || result.Key != "snapshots/run.tar.gz" || result.SizeBytes != 2048 || result.Phase != SnapshotPhaseDone {
+		t.Errorf("Expected the uploaded archive, got %+v", result)
+	}
+	expected := []SnapshotProgress{
+		{Phase: SnapshotPhaseArchiving, BytesDone: 0, BytesTotal: 4096},
+		{Phase: SnapshotPhaseArchiving, BytesDone: 4096, BytesTotal: 4096},
+		{Phase: SnapshotPhaseUploading, BytesDone: 2048, BytesTotal: 2048},
+	}
+	if len(progress) != len(expected) {
+		t.Fatalf("Expected %d progress reports, got %+v", len(expected), progress)
+	}
+	for i := range expected {
+		if progress[i] != expected[i] {
+			t.Errorf("Progress %d: expected %+v, got %+v", i, expected[i], progress[i])
+		}
+	}
+	if !strings.Contains(script, "--endpoint http://minio:9000") || !strings.Contains(script, "--region us-east-2") {
+		t.Errorf("Expected the workspace endpoint and the requested region, got:\n%s", script)
+	}
+}
+
+func TestSnapshotWorkspaceErrors(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+	addRunningRunner(t, svc, clientset, "runner-2", time.Now(), nil)
+	setPodStatus(t, svc, clientset, "runner-2", corev1.PodPending, false)
+
+	tests := []struct {
+		name        string
+		runnerID    string
+		destination string
+		exitCode    int32
+		output      string
+		expectedErr error
+	}{
+		{"Missing credentials", "runner-1", "s3://archive/", 3, `{"error":"missing_credentials","message":"no AWS credentials found"}` + "\n", ErrMissingCredentials},
+		{"Insufficient disk space", "runner-1", "s3://archive/", 4, `{"error":"insufficient_disk_space","message":"needs 12Gi in /tmp, 3Gi free"}` + "\n", ErrInsufficientDiskSpace},
+		{"Uploader missing", "runner-1", "s3://archive/", 127, `{"error":"uploader_missing","message":"the runner image has no grad-snapshot"}` + "\n", ErrFailedPrecondition},
+		{"Uploader failed", "runner-1", "s3://archive/", 1, `{"phase":"archiving","bytes":0,"total":4096}` + "\n", ErrCommandExecution},
+		{"No upload reported", "runner-1", "s3://archive/", 0, "", ErrCommandExecution},
+		{"Invalid destination", "runner-1", "/tmp/run.tar.gz", 0, "", ErrInvalidRequest},
+		{"Runner not running", "runner-2", "s3://archive/", 0, "", ErrRunnerNotRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var script string
+			_, err := svc.snapshotWorkspace(ctx, snapshotExec(&script, tt.exitCode, tt.output), &SnapshotRequest{RunnerID: tt.runnerID, Destination: tt.destination}, nil)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// RunnerSpotLabel is "true" on the pods of runners created to run on spot/preemptible nodes
+const RunnerSpotLabel = RunnerAnnotationPrefix + "spot"
+
+// nodeShutdownReasons are the pod status reasons the kubelet fails pods with when it
+// terminates them because their node is shutting down, as spot nodes do when reclaimed
+var nodeShutdownReasons = []string{"Terminated", "Shutdown", "NodeShutdown"}
+
+// SpotConfig places spot runners on the cluster's spot/preemptible nodes, which are usually
+// tainted so that only pods tolerating it land there
+type SpotConfig struct {
+	// Tolerations let spot runner pods onto the tainted nodes
+	Tolerations []corev1.Toleration
+	// NodeSelector keeps spot runner pods off other nodes
+	NodeSelector map[string]string
+}
+
+// enabled reports whether spot runners may be created
+func (c *SpotConfig) enabled() bool {
+	return c != nil && (len(c.Tolerations) > 0 || len(c.NodeSelector) > 0)
+}
+
+// ParseTolerations parses a comma-separated list of tolerations in the format of taints,
+// key[=value][:effect], e.g. "spot=true:NoSchedule". A toleration without value tolerates
+// any value of the key, and one without effect every effect. (pure function)
+func ParseTolerations(value string) ([]corev1.Toleration, error) {
+	var tolerations []corev1.Toleration
+	for _, item := range splitList(value) {
+		rest, effect, hasEffect := strings.Cut(item, ":")
+		key, taintValue, hasValue := strings.Cut(rest, "=")
+		if problems := validation.IsQualifiedName(key); len(problems) > 0 {
+			return nil, fmt.Errorf("invalid toleration %q: key: %s", item, strings.Join(problems, "; "))
+		}
+
+		toleration := corev1.Toleration{Key: key, Operator: corev1.TolerationOpExists}
+		if hasValue {
+			if problems := validation.IsValidLabelValue(taintValue); len(problems) > 0 {
+				return nil, fmt.Errorf("invalid toleration %q: value: %s", item, strings.Join(problems, "; "))
+			}
+			toleration.Operator = corev1.TolerationOpEqual
+			toleration.Value = taintValue
+		}
+		if hasEffect {
+			switch taintEffect := corev1.TaintEffect(effect); taintEffect {
+			case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+				toleration.Effect = taintEffect
+			default:
+				return nil, fmt.Errorf("invalid toleration %q: effect must be NoSchedule, PreferNoSchedule or NoExecute", item)
+			}
+		}
+		tolerations = append(tolerations, toleration)
+	}
+	return tolerations, nil
+}
+
+// ParseNodeSelector parses a comma-separated list of key=value node labels (pure function)
+func ParseNodeSelector(value string) (map[string]string, error) {
+	var selector map[string]string
+	for _, item := range splitList(value) {
+		key, labelValue, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid node label %q: must be key=value", item)
+		}
+		if problems := validation.IsQualifiedName(key); len(problems) > 0 {
+			return nil, fmt.Errorf("invalid node label %q: key: %s", item, strings.Join(problems, "; "))
+		}
+		if problems := validation.IsValidLabelValue(labelValue); len(problems) > 0 {
+			return nil, fmt.Errorf("invalid node label %q: value: %s", item, strings.Join(problems, "; "))
+		}
+		if selector == nil {
+			selector = make(map[string]string)
+		}
+		selector[key] = labelValue
+	}
+	return selector, nil
+}
+
+// checkSpot fails with ErrFailedPrecondition if req asks for a spot runner but the server
+// doesn't know how to place one (pure function)
+func checkSpot(req *CreateRunnerRequest, config *KubernetesConfig) error {
+	if req.Spot && !config.Spot.enabled() {
+		return fmt.Errorf("%w: spot runners aren't configured on this server (RUNNER_SPOT_TOLERATIONS or RUNNER_SPOT_NODE_SELECTOR)", ErrFailedPrecondition)
+	}
+	return nil
+}
+
+// runnerNodeSelector combines the nodeSelector of the spot nodes with the one pinning a
+// runner to arch, which wins; nil if there is neither (pure function)
+func runnerNodeSelector(arch string, spot map[string]string) map[string]string {
+	if len(spot) == 0 {
+		return archNodeSelector(arch)
+	}
+	selector := maps.Clone(spot)
+	maps.Copy(selector, archNodeSelector(arch))
+	return selector
+}
+
+// podSpot reports whether a runner pod was created to run on spot nodes (pure function)
+func podSpot(pod *corev1.Pod) bool {
+	return pod.Labels[RunnerSpotLabel] == "true"
+}
+
+// podNodeShutDown reports whether a pod failed because the kubelet terminated it when its
+// node shut down, e.g. because the spot node was reclaimed (pure function)
+func podNodeShutDown(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodFailed && slices.Contains(nodeShutdownReasons, pod.Status.Reason)
+}
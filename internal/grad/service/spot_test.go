@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// spotConfig returns a config placing spot runners on nodes tainted spot=true:NoSchedule and
+// labelled pool=spot
+func spotConfig() *KubernetesConfig {
+	config := DefaultKubernetesConfig()
+	config.Spot = &SpotConfig{
+		Tolerations:  []corev1.Toleration{{Key: "spot", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+		NodeSelector: map[string]string{"pool": "spot"},
+	}
+	return config
+}
+
+func TestParseTolerations(t *testing.T) {
+	tolerations, err := ParseTolerations("spot=true:NoSchedule, cloud.google.com/gke-spot, preemptible:NoExecute")
+	if err != nil {
+		t.Fatalf("ParseTolerations failed: %v", err)
+	}
+	expected := []corev1.Toleration{
+		{Key: "spot", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "cloud.google.com/gke-spot", Operator: corev1.TolerationOpExists},
+		{Key: "preemptible", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	}
+	if !reflect.DeepEqual(tolerations, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, tolerations)
+	}
+
+	for _, value := range []string{"spot=true:NoWhere", "=true", "spot=not valid"} {
+		if _, err := ParseTolerations(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestParseNodeSelector(t *testing.T) {
+	selector, err := ParseNodeSelector("pool=spot, kubernetes.azure.com/scalesetpriority=spot")
+	if err != nil {
+		t.Fatalf("ParseNodeSelector failed: %v", err)
+	}
+	if len(selector) != 2 || selector["pool"] != "spot" || selector["kubernetes.azure.com/scalesetpriority"] != "spot" {
+		t.Errorf("Expected both labels, got %v", selector)
+	}
+
+	for _, value := range []string{"pool", "=spot", "pool=not valid"} {
+		if _, err := ParseNodeSelector(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestPodCreationRequestToPodSpecSpot(t *testing.T) {
+	config := spotConfig()
+
+	pod := buildPodCreationRequest(t, &Runner{ID: "runner-1", Spot: true, Arch: "arm64"}, config).ToPodSpec()
+	if !reflect.DeepEqual(pod.Spec.Tolerations, config.Spot.Tolerations) {
+		t.Errorf("Expected the spot tolerations, got %+v", pod.Spec.Tolerations)
+	}
+	if expected := map[string]string{"pool": "spot", corev1.LabelArchStable: "arm64"}; !reflect.DeepEqual(pod.Spec.NodeSelector, expected) {
+		t.Errorf("Expected nodeSelector %v, got %v", expected, pod.Spec.NodeSelector)
+	}
+	if pod.Labels[RunnerSpotLabel] != "true" {
+		t.Errorf("Expected the %s label, got %v", RunnerSpotLabel, pod.Labels)
+	}
+	if runner := PodToRunner(pod); !runner.Spot || runner.Arch != "arm64" {
+		t.Errorf("Expected a spot arm64 runner read back from the pod, got spot %v and arch '%s'", runner.Spot, runner.Arch)
+	}
+	if clone := cloneRunnerRequest(pod, "copy", config); !clone.Spot {
+		t.Errorf("Expected a clone on spot nodes too")
+	}
+
+	pod = buildPodCreationRequest(t, &Runner{ID: "runner-2"}, config).ToPodSpec()
+	if pod.Spec.Tolerations != nil || pod.Spec.NodeSelector != nil || pod.Labels[RunnerSpotLabel] != "" {
+		t.Errorf("Expected a runner off spot nodes, got tolerations %+v, nodeSelector %v and labels %v", pod.Spec.Tolerations, pod.Spec.NodeSelector, pod.Labels)
+	}
+}
+
+func TestCreateRunnerSpot(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+	defer svc.Stop()
+
+	if _, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Spot: true}); !errors.Is(err, ErrFailedPrecondition) {
+		t.Errorf("Expected ErrFailedPrecondition without spot config, got %v", err)
+	}
+
+	svc.k8sClient.Config().Spot = spotConfig().Spot
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Spot: true})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if !runner.Spot || !runner.AutoRestart {
+		t.Errorf("Expected an auto-restart spot runner, got spot %v and auto-restart %v", runner.Spot, runner.AutoRestart)
+	}
+	if proto := runner.ToProto(); !proto.Spot {
+		t.Errorf("Expected the runner to be reported as spot")
+	}
+
+	optedOut, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Spot: true, AutoRestart: &[]bool{false}[0]})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if optedOut.AutoRestart {
+		t.Errorf("Expected a spot runner that opted out not to auto-restart")
+	}
+}
+
+// shutDownRunnerPod fails a runner's pod the way the kubelet does when its node shuts down
+func shutDownRunnerPod(pod *corev1.Pod) {
+	pod.Spec.NodeName = "spot-node-1"
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.Reason = "Terminated"
+	pod.Status.Message = "Pod was terminated in response to imminent node shutdown."
+	pod.Status.Conditions = nil
+}
+
+func TestPodToRunnerNodeShutdown(t *testing.T) {
+	pod := buildPodCreationRequest(t, &Runner{ID: "runner-1", Spot: true}, spotConfig()).ToPodSpec()
+	shutDownRunnerPod(pod)
+
+	runner := PodToRunner(pod)
+	if runner.Status != RunnerStatusError || runner.ErrorReason != RunnerErrorNodeShutdown {
+		t.Fatalf("Expected a node_shutdown error, got status %s and reason %q", runner.Status, runner.ErrorReason)
+	}
+	if runner.ErrorMessage != "Terminated: Pod was terminated in response to imminent node shutdown." {
+		t.Errorf("Expected the kubelet's message, got %q", runner.ErrorMessage)
+	}
+	if reason := runner.ToProto().ErrorReason; reason != gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_NODE_SHUTDOWN {
+		t.Errorf("Expected RUNNER_ERROR_REASON_NODE_SHUTDOWN, got %s", reason)
+	}
+
+	// Other failures stay pod failures
+	pod.Status.Reason = "Evicted"
+	if runner := PodToRunner(pod); runner.ErrorReason != RunnerErrorPodFailed {
+		t.Errorf("Expected an evicted pod to be a pod_failed error, got %q", runner.ErrorReason)
+	}
+}
+
+func TestRestartSpotRunnerAfterNodeShutdown(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	emulatePodFinalizers(clientset)
+	svc.k8sClient.Config().Spot = spotConfig().Spot
+	svc.k8sClient.Config().Restart = &RestartConfig{MaxRestarts: 3, Backoff: time.Minute}
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	defer svc.Stop()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{Spot: true})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	pod, err := svc.k8sClient.GetRunnerPod(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	shutDownRunnerPod(pod)
+	if _, err := clientset.CoreV1().Pods(pod.Namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update pod: %v", err)
+	}
+
+	// The node went away, not the runner, so it comes back without waiting out a backoff
+	svc.restartFailedRunners(ctx)
+	got, err := svc.GetRunner(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if got.Status != RunnerStatusRestarting || !strings.Contains(got.StatusDetail, "restart 1 of 3 in 0s") {
+		t.Fatalf("Expected an immediate restart to be scheduled, got status %s: %q", got.Status, got.StatusDetail)
+	}
+
+	svc.restartFailedRunners(ctx)
+	pod, err = svc.k8sClient.GetRunnerPod(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunnerPod failed: %v", err)
+	}
+	if pod.Status.Phase != corev1.PodPending || pod.Spec.NodeName != "" || podRestartCount(pod) != 1 {
+		t.Fatalf("Expected a new pending pod, got phase %s on node %q after %d restarts", pod.Status.Phase, pod.Spec.NodeName, podRestartCount(pod))
+	}
+	if !reflect.DeepEqual(pod.Spec.Tolerations, spotConfig().Spot.Tolerations) || !podSpot(pod) {
+		t.Errorf("Expected the new pod on spot nodes again, got tolerations %+v", pod.Spec.Tolerations)
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/shellquote"
+	"github.com/strrl/gra/pkg/sshkey"
+)
+
+const (
+	// RunnerSSHKeysAnnotation lists the keys added with AddSSHKey as JSON, so they can be
+	// listed without exec
+	RunnerSSHKeysAnnotation = RunnerAnnotationPrefix + "ssh-keys"
+
+	// sshKeyExecTimeout bounds the command editing a runner's authorized_keys
+	sshKeyExecTimeout = 30 * time.Second
+)
+
+// authorizedKeysFiles are the files the runner image's entrypoint installs the create-time
+// key in: the runner user's, and root's for sshfs workspace sync
+var authorizedKeysFiles = []string{"/home/runner/.ssh/authorized_keys", "/root/.ssh/authorized_keys"}
+
+// SSHKey is a public key added to a runner
+type SSHKey struct {
+	Fingerprint string `json:"fingerprint"`
+	// Key is the key as a line of authorized_keys
+	Key string `json:"key"`
+}
+
+// AddSSHKey parses publicKey and appends it to the runner's authorized_keys. Adding a key the
+// runner already has is a no-op that reports added as false; the key is installed again
+// anyway, in case the runner's container was restarted since.
+func (s *runnerService) AddSSHKey(ctx context.Context, runnerID, publicKey string) (*SSHKey, bool, error) {
+	return s.addSSHKey(ctx, s.sshKeyExec, runnerID, publicKey)
+}
+
+func (s *runnerService) addSSHKey(ctx context.Context, exec commandExecFunc, runnerID, publicKey string) (*SSHKey, bool, error) {
+	parsed, err := sshkey.Parse(publicKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	key := SSHKey{Fingerprint: parsed.Fingerprint(), Key: parsed.String()}
+
+	pod, err := s.getRunningRunnerPod(ctx, runnerID)
+	if err != nil {
+		return nil, false, err
+	}
+	keys, err := runnerSSHKeys(pod)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := runSSHKeyScript(ctx, exec, runnerID, addAuthorizedKeyScript(key.Key)); err != nil {
+		return nil, false, fmt.Errorf("failed to add SSH key: %w", err)
+	}
+
+	if findSSHKey(keys, key.Fingerprint) >= 0 {
+		return &key, false, nil
+	}
+	if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, sshKeysAnnotations(append(keys, key))); err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+	}
+	return &key, true, nil
+}
+
+// ListSSHKeys returns the keys added to a runner, oldest first
+func (s *runnerService) ListSSHKeys(ctx context.Context, runnerID string) ([]SSHKey, error) {
+	pod, err := s.getRunnerPod(ctx, runnerID)
+	if err != nil {
+		return nil, err
+	}
+	return runnerSSHKeys(pod)
+}
+
+// RemoveSSHKey removes the key with fingerprint from the runner's authorized_keys, reporting
+// false if the runner doesn't have it
+func (s *runnerService) RemoveSSHKey(ctx context.Context, runnerID, fingerprint string) (bool, error) {
+	return s.removeSSHKey(ctx, s.sshKeyExec, runnerID, fingerprint)
+}
+
+func (s *runnerService) removeSSHKey(ctx context.Context, exec commandExecFunc, runnerID, fingerprint string) (bool, error) {
+	pod, err := s.getRunningRunnerPod(ctx, runnerID)
+	if err != nil {
+		return false, err
+	}
+	keys, err := runnerSSHKeys(pod)
+	if err != nil {
+		return false, err
+	}
+
+	i := findSSHKey(keys, fingerprint)
+	if i < 0 {
+		return false, nil
+	}
+	if err := runSSHKeyScript(ctx, exec, runnerID, removeAuthorizedKeyScript(keys[i].Key)); err != nil {
+		return false, fmt.Errorf("failed to remove SSH key: %w", err)
+	}
+
+	keys = append(keys[:i], keys[i+1:]...)
+	if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, sshKeysAnnotations(keys)); err != nil {
+		return false, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+	}
+	return true, nil
+}
+
+// getRunningRunnerPod returns the pod of runnerID, failing unless the runner is running
+func (s *runnerService) getRunningRunnerPod(ctx context.Context, runnerID string) (*corev1.Pod, error) {
+	pod, err := s.getRunnerPod(ctx, runnerID)
+	if err != nil {
+		return nil, err
+	}
+	if PodToRunner(pod).Status != RunnerStatusRunning {
+		return nil, ErrRunnerNotRunning
+	}
+	return pod, nil
+}
+
+// sshKeyExec runs a script with sh in the runner container, ignoring its default shell and workdir
+func (s *runnerService) sshKeyExec(ctx context.Context, runnerID, command string, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+	return s.k8sClient.ExecuteCommandStream(ctx, runnerID, []string{"sh", "-c", command}, execOutput{flushInterval: s.flushInterval}, stdoutCh, stderrCh)
+}
+
+// runSSHKeyScript runs script in the runner, failing with its output unless it exits 0
+func runSSHKeyScript(ctx context.Context, exec commandExecFunc, runnerID, script string) error {
+	ctx, cancel := context.WithTimeout(ctx, sshKeyExecTimeout)
+	defer cancel()
+
+	result := runBootstrapCommand(ctx, exec, runnerID, script)
+	if result.Err != nil {
+		return fmt.Errorf("%w: %w", ErrCommandExecution, result.Err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("%w: exit code %d: %s", ErrCommandExecution, result.ExitCode, strings.TrimSpace(string(result.Output)))
+	}
+	return nil
+}
+
+// addAuthorizedKeyScript returns a script appending key to every authorized_keys that lacks
+// it, creating the files as sshd expects them (pure function)
+func addAuthorizedKeyScript(key string) string {
+	return fmt.Sprintf(`key=%s
+for file in %s; do
+  dir=$(dirname "$file")
+  [ -d "$(dirname "$dir")" ] || continue
+  mkdir -p "$dir" && chmod 700 "$dir" && touch "$file" && chmod 600 "$file" || exit 1
+  grep -qxF "$key" "$file" || printf '%%s\n' "$key" >> "$file" || exit 1
+done
+chown -R runner:runner /home/runner/.ssh 2>/dev/null || true
+`, shellquote.Quote(key), strings.Join(authorizedKeysFiles, " "))
+}
+
+// removeAuthorizedKeyScript returns a script deleting the lines equal to key from every
+// authorized_keys, keeping the files' owner and mode (pure function)
+func removeAuthorizedKeyScript(key string) string {
+	return fmt.Sprintf(`key=%s
+for file in %s; do
+  [ -f "$file" ] || continue
+  grep -vxF "$key" "$file" > "$file.grad"
+  cat "$file.grad" > "$file" && rm -f "$file.grad" || exit 1
+done
+`, shellquote.Quote(key), strings.Join(authorizedKeysFiles, " "))
+}
+
+// runnerSSHKeys returns the keys recorded on a runner's pod (pure function)
+func runnerSSHKeys(pod *corev1.Pod) ([]SSHKey, error) {
+	value := pod.Annotations[RunnerSSHKeysAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+	var keys []SSHKey
+	if err := json.Unmarshal([]byte(value), &keys); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", RunnerSSHKeysAnnotation, err)
+	}
+	return keys, nil
+}
+
+// sshKeysAnnotations returns the pod annotations recording keys (pure function)
+func sshKeysAnnotations(keys []SSHKey) map[string]string {
+	if len(keys) == 0 {
+		return map[string]string{RunnerSSHKeysAnnotation: ""}
+	}
+	data, _ := json.Marshal(keys)
+	return map[string]string{RunnerSSHKeysAnnotation: string(data)}
+}
+
+// findSSHKey returns the index of the key with fingerprint, or -1 (pure function)
+func findSSHKey(keys []SSHKey, fingerprint string) int {
+	for i, key := range keys {
+		if key.Fingerprint == fingerprint {
+			return i
+		}
+	}
+	return -1
+}
+
+// ToProto converts a domain SSHKey to proto, splitting the key into its type and comment
+func (k SSHKey) ToProto() *gradv1.SSHKey {
+	key := &gradv1.SSHKey{Fingerprint: k.Fingerprint}
+	if parsed, err := sshkey.Parse(k.Key); err == nil {
+		key.Type, key.Comment = parsed.Type, parsed.Comment
+	}
+	return key
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	aliceKey         = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAILF1Askp6eCkzRFCxdUO9LuL8BhleWitPN1tCPGbVYPM alice@laptop"
+	aliceFingerprint = "SHA256:xGNji13BKIUvz1NnNE7EssSe/UDcUNG0rDnTGZa4jxE"
+)
+
+// recordingExec returns a commandExecFunc that records the commands it runs and exits with exitCode
+func recordingExec(commands *[]string, exitCode int32) commandExecFunc {
+	return func(ctx context.Context, runnerID, command string, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+		defer close(stdoutCh)
+		defer close(stderrCh)
+		*commands = append(*commands, command)
+		if exitCode != 0 {
+			stderrCh <- []byte("chmod: Read-only file system\n")
+		}
+		return ExitStatus{Code: exitCode, Reason: ExitReasonExited}, nil
+	}
+}
+
+func TestAddSSHKey(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+
+	var commands []string
+	key, added, err := svc.addSSHKey(ctx, recordingExec(&commands, 0), "runner-1", "  "+aliceKey+"\n")
+	if err != nil {
+		t.Fatalf("addSSHKey failed: %v", err)
+	}
+	if !added || key.Fingerprint != aliceFingerprint || key.Key != aliceKey {
+		t.Errorf("Expected alice's key to be added, got %+v added=%v", key, added)
+	}
+	if len(commands) != 1 || !strings.Contains(commands[0], "key='"+aliceKey+"'") {
+		t.Errorf("Expected the key to be installed, got %q", commands)
+	}
+
+	// Adding it again installs it again but doesn't record it twice
+	key, added, err = svc.addSSHKey(ctx, recordingExec(&commands, 0), "runner-1", aliceKey)
+	if err != nil {
+		t.Fatalf("addSSHKey failed: %v", err)
+	}
+	if added || key.Fingerprint != aliceFingerprint {
+		t.Errorf("Expected the key to be reported as present, got %+v added=%v", key, added)
+	}
+	if len(commands) != 2 {
+		t.Errorf("Expected the key to be installed again, got %d commands", len(commands))
+	}
+
+	keys, err := svc.ListSSHKeys(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("ListSSHKeys failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Fingerprint != aliceFingerprint {
+		t.Errorf("Expected alice's key once, got %+v", keys)
+	}
+	if proto := keys[0].ToProto(); proto.Type != "ssh-ed25519" || proto.Comment != "alice@laptop" {
+		t.Errorf("Expected the type and comment in proto, got %v", proto)
+	}
+}
+
+func TestAddSSHKeyErrors(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+	addRunningRunner(t, svc, clientset, "runner-2", time.Now(), nil)
+	setPodStatus(t, svc, clientset, "runner-2", corev1.PodPending, false)
+
+	var commands []string
+	if _, _, err := svc.addSSHKey(ctx, recordingExec(&commands, 0), "runner-1", "ssh-dss AAAAB3NzaC1kc3M="); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for an unsupported key, got %v", err)
+	}
+	if _, _, err := svc.addSSHKey(ctx, recordingExec(&commands, 0), "missing", aliceKey); !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("Expected ErrRunnerNotFound, got %v", err)
+	}
+	if _, _, err := svc.addSSHKey(ctx, recordingExec(&commands, 0), "runner-2", aliceKey); !errors.Is(err, ErrRunnerNotRunning) {
+		t.Errorf("Expected ErrRunnerNotRunning, got %v", err)
+	}
+	if len(commands) != 0 {
+		t.Errorf("Expected nothing to run, got %q", commands)
+	}
+
+	_, _, err := svc.addSSHKey(ctx, recordingExec(&commands, 1), "runner-1", aliceKey)
+	if !errors.Is(err, ErrCommandExecution) || !strings.Contains(err.Error(), "Read-only file system") {
+		t.Errorf("Expected the script's failure with its output, got %v", err)
+	}
+	if keys, _ := svc.ListSSHKeys(ctx, "runner-1"); len(keys) != 0 {
+		t.Errorf("Expected a key that failed to install not to be recorded, got %+v", keys)
+	}
+}
+
+func TestRemoveSSHKey(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+
+	var commands []string
+	if _, _, err := svc.addSSHKey(ctx, recordingExec(&commands, 0), "runner-1", aliceKey); err != nil {
+		t.Fatalf("addSSHKey failed: %v", err)
+	}
+
+	removed, err := svc.removeSSHKey(ctx, recordingExec(&commands, 0), "runner-1", aliceFingerprint)
+	if err != nil || !removed {
+		t.Fatalf("Expected the key to be removed, got %v, %v", removed, err)
+	}
+	if len(commands) != 2 || !strings.Contains(commands[1], `grep -vxF "$key"`) {
+		t.Errorf("Expected the key to be deleted from authorized_keys, got %q", commands)
+	}
+	if keys, _ := svc.ListSSHKeys(ctx, "runner-1"); len(keys) != 0 {
+		t.Errorf("Expected no keys left, got %+v", keys)
+	}
+
+	removed, err = svc.removeSSHKey(ctx, recordingExec(&commands, 0), "runner-1", aliceFingerprint)
+	if err != nil || removed {
+		t.Errorf("Expected removing an unknown key to be a no-op, got %v, %v", removed, err)
+	}
+	if len(commands) != 2 {
+		t.Errorf("Expected nothing to run for an unknown key, got %q", commands)
+	}
+}
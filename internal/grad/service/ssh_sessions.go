@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RunnerSSHSessionsAnnotation is the number of SSH sessions open in the runner when grad
+	// last looked
+	RunnerSSHSessionsAnnotation = RunnerAnnotationPrefix + "ssh-sessions"
+
+	// RunnerLastSSHLoginAnnotation is when the latest SSH login grad saw happened, in RFC 3339
+	RunnerLastSSHLoginAnnotation = RunnerAnnotationPrefix + "last-ssh-login"
+
+	// sshSessionCommand lists the sessions logged in to the runner, one per line
+	sshSessionCommand = "who 2>/dev/null"
+
+	// sshSessionExecTimeout bounds one check of a runner's SSH sessions
+	sshSessionExecTimeout = 10 * time.Second
+
+	// sshSessionMaxBackoff caps how long a runner whose checks keep failing goes unchecked
+	sshSessionMaxBackoff = 30 * time.Minute
+)
+
+// sshSession is a login listed by who
+type sshSession struct {
+	User string
+	Line string
+	// LoginAt is zero if who printed it in a format other than GNU coreutils' default
+	LoginAt time.Time
+}
+
+// parseWho returns the sessions in the output of who. Login times are read in GNU coreutils'
+// default "USER LINE YYYY-MM-DD HH:MM [(HOST)]" format, in UTC as runner containers use it.
+// (pure function)
+func parseWho(output string) []sshSession {
+	var sessions []sshSession
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		session := sshSession{User: fields[0], Line: fields[1]}
+		if len(fields) >= 4 {
+			if loginAt, err := time.Parse("2006-01-02 15:04", fields[2]+" "+fields[3]); err == nil {
+				session.LoginAt = loginAt
+			}
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// podSSHSessions returns the SSH session count and last login recorded on a pod (pure function)
+func podSSHSessions(pod *corev1.Pod) (int32, time.Time) {
+	count, _ := strconv.ParseInt(pod.Annotations[RunnerSSHSessionsAnnotation], 10, 32)
+	lastLogin, _ := time.Parse(time.RFC3339, pod.Annotations[RunnerLastSSHLoginAnnotation])
+	return int32(count), lastLogin
+}
+
+// sshSessionAnnotations returns the annotations recording sessions found in the runner of pod
+// at now, only those whose values change. The last login moves to the latest login time of
+// the sessions, or to now if sessions were added whose login time is unknown. (pure function)
+func sshSessionAnnotations(pod *corev1.Pod, sessions []sshSession, now time.Time) map[string]string {
+	previousCount, lastLogin := podSSHSessions(pod)
+	count := int32(len(sessions))
+
+	latest := lastLogin
+	for _, session := range sessions {
+		loginAt := session.LoginAt
+		if loginAt.IsZero() && count > previousCount {
+			loginAt = now
+		}
+		if loginAt.After(latest) {
+			latest = loginAt
+		}
+	}
+
+	annotations := make(map[string]string)
+	if count != previousCount {
+		annotations[RunnerSSHSessionsAnnotation] = strconv.Itoa(int(count))
+	}
+	if latest.After(lastLogin) {
+		annotations[RunnerLastSSHLoginAnnotation] = latest.UTC().Format(time.RFC3339)
+	}
+	return annotations
+}
+
+// sshSessionBackoff spaces out the checks of runners whose SSH session checks failed, doubling
+// the wait after every failure in a row
+type sshSessionBackoff struct {
+	mu       sync.Mutex
+	failures map[string]int
+	retryAt  map[string]time.Time
+}
+
+func newSSHSessionBackoff() *sshSessionBackoff {
+	return &sshSessionBackoff{
+		failures: make(map[string]int),
+		retryAt:  make(map[string]time.Time),
+	}
+}
+
+// Ready reports whether a runner may be checked at now
+func (b *sshSessionBackoff) Ready(runnerID string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.retryAt[runnerID])
+}
+
+// Failed records a failed check of a runner at now, holding the next one back for twice
+// interval, then four times and so on up to sshSessionMaxBackoff. It returns the number of
+// failures in a row.
+func (b *sshSessionBackoff) Failed(runnerID string, now time.Time, interval time.Duration) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures[runnerID]++
+	failures := b.failures[runnerID]
+	wait := sshSessionMaxBackoff
+	if failures < 16 && interval<<failures < sshSessionMaxBackoff {
+		wait = interval << failures
+	}
+	b.retryAt[runnerID] = now.Add(wait)
+	return failures
+}
+
+// Succeeded clears the failures of a runner
+func (b *sshSessionBackoff) Succeeded(runnerID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, runnerID)
+	delete(b.retryAt, runnerID)
+}
+
+// Forget drops the failures of runners not in runnerIDs, which no longer exist
+func (b *sshSessionBackoff) Forget(runnerIDs map[string]bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for runnerID := range b.failures {
+		if !runnerIDs[runnerID] {
+			delete(b.failures, runnerID)
+			delete(b.retryAt, runnerID)
+		}
+	}
+}
+
+// superviseSSHSessions checks the SSH sessions of running runners every sshSessionInterval
+// until ctx is done
+func (s *runnerService) superviseSSHSessions(ctx context.Context) {
+	ticker := time.NewTicker(s.sshSessionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.collectSSHSessions(ctx, s.sshKeyExec)
+	}
+}
+
+// collectSSHSessions checks the SSH sessions of every running runner, except those running a
+// command, which are active anyway, and those backing off after failed checks
+func (s *runnerService) collectSSHSessions(ctx context.Context, exec commandExecFunc) {
+	pods, err := s.k8sClient.ListRunnerPods(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("Failed to list runners to check their SSH sessions", "error", err)
+		}
+		return
+	}
+
+	runnerIDs := make(map[string]bool, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		runnerID := pod.Annotations[RunnerIDAnnotation]
+		runnerIDs[runnerID] = true
+		if pod.DeletionTimestamp != nil || podRunnerStatus(pod) != RunnerStatusRunning {
+			continue
+		}
+		if s.activityTracker.ExecInFlight(runnerID) || !s.sshSessionBackoff.Ready(runnerID, s.now()) {
+			continue
+		}
+		s.checkSSHSessions(ctx, exec, pod)
+	}
+	s.sshSessionBackoff.Forget(runnerIDs)
+}
+
+// checkSSHSessions lists the sessions in the runner of pod and records them on the pod. Open
+// sessions count as activity, so interactive use keeps the runner from idle cleanup.
+func (s *runnerService) checkSSHSessions(ctx context.Context, exec commandExecFunc, pod *corev1.Pod) {
+	runnerID := pod.Annotations[RunnerIDAnnotation]
+	execCtx, cancel := context.WithTimeout(ctx, sshSessionExecTimeout)
+	defer cancel()
+
+	result := runBootstrapCommand(execCtx, exec, runnerID, sshSessionCommand)
+	if !result.Succeeded() {
+		if ctx.Err() != nil {
+			return
+		}
+		failures := s.sshSessionBackoff.Failed(runnerID, s.now(), s.sshSessionInterval)
+		level := slog.LevelDebug
+		if failures == 1 {
+			level = slog.LevelWarn
+		}
+		slog.Log(ctx, level, "Failed to check runner SSH sessions, backing off",
+			"runner_id", runnerID,
+			"failures", failures,
+			"exit_code", result.ExitCode,
+			"error", result.Err,
+			"output", strings.TrimSpace(string(result.Output)))
+		return
+	}
+	s.sshSessionBackoff.Succeeded(runnerID)
+
+	sessions := parseWho(string(result.Output))
+	if len(sessions) > 0 {
+		s.recordActivity(ctx, runnerID)
+	}
+	annotations := sshSessionAnnotations(pod, sessions, s.now())
+	if len(annotations) == 0 {
+		return
+	}
+	if err := s.k8sClient.UpdateRunnerAnnotations(ctx, runnerID, annotations); err != nil {
+		slog.Warn("Failed to record runner SSH sessions", "runner_id", runnerID, "error", err)
+	}
+}
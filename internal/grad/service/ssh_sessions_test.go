@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const whoOutput = `runner   pts/0        2026-10-17 09:12 (10.0.0.5)
+root     pts/1        2026-10-17 11:40 (10.0.0.7)
+`
+
+// cannedWho returns a commandExecFunc printing output, or failing with err, that records the
+// runners it runs in
+func cannedWho(runners *[]string, output string, err error) commandExecFunc {
+	return func(ctx context.Context, runnerID, command string, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error) {
+		defer close(stdoutCh)
+		defer close(stderrCh)
+		*runners = append(*runners, runnerID)
+		if err != nil {
+			return ExitStatus{}, err
+		}
+		stdoutCh <- []byte(output)
+		return ExitStatus{Code: 0, Reason: ExitReasonExited}, nil
+	}
+}
+
+func TestParseWho(t *testing.T) {
+	tests := []struct {
+		name          string
+		output        string
+		expectedCount int
+		expectedLogin time.Time
+	}{
+		{"Empty", "", 0, time.Time{}},
+		{"GNU format", whoOutput, 2, time.Date(2026, 10, 17, 11, 40, 0, 0, time.UTC)},
+		{"Without host", "runner   pts/0        2026-10-17 09:12\n", 1, time.Date(2026, 10, 17, 9, 12, 0, 0, time.UTC)},
+		{"Other time format", "runner   pts/0   Oct 17 09:12\n", 1, time.Time{}},
+		{"Blank lines", "\nrunner pts/0\n\n", 1, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := parseWho(tt.output)
+			if len(sessions) != tt.expectedCount {
+				t.Fatalf("Expected %d sessions, got %+v", tt.expectedCount, sessions)
+			}
+			var latest time.Time
+			for _, session := range sessions {
+				if session.LoginAt.After(latest) {
+					latest = session.LoginAt
+				}
+			}
+			if !latest.Equal(tt.expectedLogin) {
+				t.Errorf("Expected the latest login at %s, got %s", tt.expectedLogin, latest)
+			}
+		})
+	}
+}
+
+func TestCollectSSHSessions(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+	addRunningRunner(t, svc, clientset, "runner-2", time.Now(), nil)
+
+	// runner-2 runs a command, so it isn't checked
+	endExec := svc.activityTracker.BeginExec("runner-2")
+	var checked []string
+	svc.collectSSHSessions(ctx, cannedWho(&checked, whoOutput, nil))
+	if !slices.Equal(checked, []string{"runner-1"}) {
+		t.Errorf("Expected only runner-1 to be checked, got %v", checked)
+	}
+
+	runner, err := svc.GetRunner(ctx, "runner-1")
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if expected := time.Date(2026, 10, 17, 11, 40, 0, 0, time.UTC); runner.SSHSessions != 2 || !runner.LastSSHLogin.Equal(expected) {
+		t.Errorf("Expected 2 sessions, last login at %s, got %d at %s", expected, runner.SSHSessions, runner.LastSSHLogin)
+	}
+	if proto := runner.ToProto(); proto.SshSessions != 2 || proto.LastSshLogin != runner.LastSSHLogin.Unix() {
+		t.Errorf("Expected the sessions in proto, got %d at %d", proto.SshSessions, proto.LastSshLogin)
+	}
+	if svc.activityTracker.GetLastActiveTime("runner-1").IsZero() {
+		t.Error("Expected open sessions to count as activity")
+	}
+
+	// Once everyone logged out, the count drops and the last login stays
+	endExec()
+	checked = nil
+	svc.collectSSHSessions(ctx, cannedWho(&checked, "", nil))
+	if !slices.Equal(checked, []string{"runner-1", "runner-2"}) {
+		t.Errorf("Expected both runners to be checked, got %v", checked)
+	}
+	runner, _ = svc.GetRunner(ctx, "runner-1")
+	if runner.SSHSessions != 0 || runner.LastSSHLogin.IsZero() {
+		t.Errorf("Expected no sessions and the last login kept, got %d at %s", runner.SSHSessions, runner.LastSSHLogin)
+	}
+	if !svc.activityTracker.GetLastActiveTime("runner-2").IsZero() {
+		t.Error("Expected a runner without sessions not to become active")
+	}
+}
+
+func TestCollectSSHSessionsBacksOff(t *testing.T) {
+	ctx := context.Background()
+	svc, clientset := newTestRunnerService()
+	addRunningRunner(t, svc, clientset, "runner-1", time.Now(), nil)
+	svc.sshSessionInterval = time.Minute
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	var checked []string
+	failing := cannedWho(&checked, "", errors.New("container not found"))
+	svc.collectSSHSessions(ctx, failing)
+	if len(checked) != 1 {
+		t.Fatalf("Expected runner-1 to be checked, got %v", checked)
+	}
+
+	// The first failure holds the next check back for 2 intervals, the second for 4
+	for _, step := range []struct {
+		advance  time.Duration
+		expected int
+	}{
+		{time.Minute, 1},
+		{time.Minute, 2},
+		{3 * time.Minute, 2},
+		{time.Minute, 3},
+	} {
+		now = now.Add(step.advance)
+		svc.collectSSHSessions(ctx, failing)
+		if len(checked) != step.expected {
+			t.Fatalf("Expected %d checks at %s, got %d", step.expected, now.Format(time.TimeOnly), len(checked))
+		}
+	}
+
+	// A check that succeeds ends the backoff
+	now = now.Add(8 * time.Minute)
+	svc.collectSSHSessions(ctx, cannedWho(&checked, "", nil))
+	svc.collectSSHSessions(ctx, cannedWho(&checked, "", nil))
+	if len(checked) != 5 {
+		t.Errorf("Expected checks every interval again after a success, got %d", len(checked))
+	}
+}
+
+func TestSSHSessionAnnotations(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		sessions    []sshSession
+		expected    map[string]string
+	}{
+		{"Nothing changed", nil, nil, map[string]string{}},
+		{
+			"New session",
+			nil,
+			[]sshSession{{User: "runner", LoginAt: now.Add(-time.Minute)}},
+			map[string]string{RunnerSSHSessionsAnnotation: "1", RunnerLastSSHLoginAnnotation: now.Add(-time.Minute).Format(time.RFC3339)},
+		},
+		{
+			"Same session",
+			map[string]string{RunnerSSHSessionsAnnotation: "1", RunnerLastSSHLoginAnnotation: earlier},
+			[]sshSession{{User: "runner", LoginAt: now.Add(-time.Hour)}},
+			map[string]string{},
+		},
+		{
+			"Logged out",
+			map[string]string{RunnerSSHSessionsAnnotation: "1", RunnerLastSSHLoginAnnotation: earlier},
+			nil,
+			map[string]string{RunnerSSHSessionsAnnotation: "0"},
+		},
+		{
+			"New session without login time",
+			map[string]string{RunnerSSHSessionsAnnotation: "1", RunnerLastSSHLoginAnnotation: earlier},
+			[]sshSession{{User: "runner"}, {User: "root"}},
+			map[string]string{RunnerSSHSessionsAnnotation: "2", RunnerLastSSHLoginAnnotation: now.Format(time.RFC3339)},
+		},
+		{
+			"Same sessions without login time",
+			map[string]string{RunnerSSHSessionsAnnotation: "2", RunnerLastSSHLoginAnnotation: earlier},
+			[]sshSession{{User: "runner"}, {User: "root"}},
+			map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			got := sshSessionAnnotations(pod, tt.sessions, now)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for key, value := range tt.expected {
+				if got[key] != value {
+					t.Errorf("Expected %s=%q, got %q", key, value, got[key])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// staleImageCheckInterval is how often runners are checked for an image other than the one
+// new runners get
+const staleImageCheckInterval = 5 * time.Minute
+
+// markStaleImage sets runner.StaleImage if it runs another image than new runners get
+func (s *runnerService) markStaleImage(runner *Runner) {
+	runner.StaleImage = imageIsStale(runner.Image, runner.ImageDigest, s.k8sClient.Config().Images().Runner)
+}
+
+// superviseImages checks for runners with a stale image at startup, then every
+// staleImageCheckInterval until ctx is done, warning whenever their number changes
+func (s *runnerService) superviseImages(ctx context.Context) {
+	ticker := time.NewTicker(staleImageCheckInterval)
+	defer ticker.Stop()
+
+	previous := 0
+	for {
+		if stale, err := s.staleImageRunners(ctx); err != nil {
+			if ctx.Err() == nil {
+				slog.Warn("Failed to check runner images", "error", err)
+			}
+		} else {
+			s.metrics.StaleImageRunners.Set(float64(len(stale)))
+			if len(stale) != previous && len(stale) > 0 {
+				slog.Warn("Runners run a different image than new runners get; recreate them to pick it up",
+					"count", len(stale),
+					"runner_ids", stale,
+					"runner_image", s.k8sClient.Config().Images().Runner)
+			}
+			previous = len(stale)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// staleImageRunners returns the IDs of the runners, other than those being deleted, that run
+// another image than new runners get
+func (s *runnerService) staleImageRunners(ctx context.Context) ([]string, error) {
+	pods, err := s.k8sClient.ListRunnerPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		runner := PodToRunner(pod)
+		s.markStaleImage(runner)
+		if runner.StaleImage {
+			stale = append(stale, runner.ID)
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+const (
+	// RunnerOwnerLabel is the optional pod label runner stats are grouped by
+	RunnerOwnerLabel = RunnerAnnotationPrefix + "owner"
+	// RunnerPresetCustom counts runners whose resources match no preset
+	RunnerPresetCustom = "custom"
+)
+
+// RunnerStats counts runners grouped by status, preset and owner
+type RunnerStats struct {
+	Total    int32
+	ByStatus map[RunnerStatus]int32
+	ByPreset map[string]int32
+	// ByOwner is keyed by the RunnerOwnerLabel value, "" for runners without one
+	ByOwner map[string]int32
+}
+
+// GetRunnerStats counts the runners matching opts. Only the status filter applies; it
+// reads the pods once and never builds Runner objects.
+func (s *runnerService) GetRunnerStats(ctx context.Context, opts *ListOptions) (*RunnerStats, error) {
+	podList, err := s.k8sClient.ListRunnerPods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKubernetesAPI, err)
+	}
+
+	status := RunnerStatusUnspecified
+	if opts != nil {
+		status = opts.Status
+	}
+	return countRunnerPods(podList.Items, status), nil
+}
+
+// countRunnerPods groups pods with the given status, or all pods if it is unspecified (pure function)
+func countRunnerPods(pods []corev1.Pod, status RunnerStatus) *RunnerStats {
+	stats := &RunnerStats{
+		ByStatus: make(map[RunnerStatus]int32),
+		ByPreset: make(map[string]int32),
+		ByOwner:  make(map[string]int32),
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		podStatus := podRunnerStatus(pod)
+		if status != RunnerStatusUnspecified && podStatus != status {
+			continue
+		}
+
+		stats.Total++
+		stats.ByStatus[podStatus]++
+		stats.ByPreset[podPreset(pod)]++
+		stats.ByOwner[pod.Labels[RunnerOwnerLabel]]++
+	}
+
+	return stats
+}
+
+// podPreset names the preset matching the runner container's CPU and memory requests (pure function)
+func podPreset(pod *corev1.Pod) string {
+	container := findContainer(pod, RunnerContainerName)
+	if container == nil {
+		return RunnerPresetCustom
+	}
+	requests := container.Resources.Requests
+
+	for name, spec := range map[string]RunnerSpec{
+		"small":  RunnerSpecPreset.Small,
+		"medium": RunnerSpecPreset.Medium,
+		"large":  RunnerSpecPreset.Large,
+	} {
+		if requests.Cpu().MilliValue() == int64(spec.CPUMillicores) && requests.Memory().Value() == int64(spec.MemoryMB)*1024*1024 {
+			return name
+		}
+	}
+	return RunnerPresetCustom
+}
+
+// ToProto converts domain RunnerStats to a proto GetRunnerStatsResponse
+func (s *RunnerStats) ToProto() *gradv1.GetRunnerStatsResponse {
+	byStatus := make(map[string]int32, len(s.ByStatus))
+	for status, count := range s.ByStatus {
+		byStatus[string(status)] = count
+	}
+
+	return &gradv1.GetRunnerStatsResponse{
+		Total:    s.Total,
+		ByStatus: byStatus,
+		ByPreset: s.ByPreset,
+		ByOwner:  s.ByOwner,
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// seedRunnerPod adds a runner pod with the given phase, resource requests and owner label to clientset
+func seedRunnerPod(t *testing.T, clientset *fake.Clientset, id string, phase corev1.PodPhase, cpu, memory, owner string) {
+	t.Helper()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "grad-runner-" + id,
+			Namespace:   DefaultKubernetesConfig().Namespace,
+			Labels:      map[string]string{"app.kubernetes.io/managed-by": "grad", "app.kubernetes.io/component": "runner"},
+			Annotations: map[string]string{RunnerIDAnnotation: id},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: RunnerContainerName,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(cpu),
+						corev1.ResourceMemory: resource.MustParse(memory),
+					},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+	if phase == corev1.PodRunning {
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	}
+	if owner != "" {
+		pod.Labels[RunnerOwnerLabel] = owner
+	}
+	if _, err := clientset.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to seed pod: %v", err)
+	}
+}
+
+func TestGetRunnerStats(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	svc := NewRunnerService(&KubernetesClient{clientset: clientset, config: DefaultKubernetesConfig()}, NewActivityTracker(), DefaultDurations(), nil)
+
+	seedRunnerPod(t, clientset, "r1", corev1.PodRunning, "2000m", "2Gi", "alice")
+	seedRunnerPod(t, clientset, "r2", corev1.PodRunning, "2", "2Gi", "bob")
+	seedRunnerPod(t, clientset, "r3", corev1.PodPending, "2000m", "2Gi", "alice")
+	seedRunnerPod(t, clientset, "r4", corev1.PodFailed, "4000m", "4Gi", "")
+	seedRunnerPod(t, clientset, "r5", corev1.PodRunning, "1500m", "2Gi", "alice")
+
+	stats, err := svc.GetRunnerStats(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("GetRunnerStats failed: %v", err)
+	}
+
+	if stats.Total != 5 {
+		t.Errorf("Expected total 5, got %d", stats.Total)
+	}
+	assertCounts(t, "status", stats.ByStatus, map[RunnerStatus]int32{
+		RunnerStatusRunning:  3,
+		RunnerStatusCreating: 1,
+		RunnerStatusError:    1,
+	})
+	assertCounts(t, "preset", stats.ByPreset, map[string]int32{"small": 3, "medium": 1, RunnerPresetCustom: 1})
+	assertCounts(t, "owner", stats.ByOwner, map[string]int32{"alice": 3, "bob": 1, "": 1})
+
+	// The status filter of ListRunners applies to the counts too
+	stats, err = svc.GetRunnerStats(context.Background(), &ListOptions{Status: RunnerStatusRunning})
+	if err != nil {
+		t.Fatalf("GetRunnerStats failed: %v", err)
+	}
+	_, listed, _ := svc.ListRunners(context.Background(), &ListOptions{Status: RunnerStatusRunning})
+	if stats.Total != 3 || stats.Total != listed {
+		t.Errorf("Expected 3 running runners matching ListRunners' total %d, got %d", listed, stats.Total)
+	}
+	assertCounts(t, "owner", stats.ByOwner, map[string]int32{"alice": 2, "bob": 1})
+}
+
+// assertCounts checks that got has exactly the expected counts
+func assertCounts[K comparable](t *testing.T, group string, got, expected map[K]int32) {
+	t.Helper()
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected counts by %s %v, got %v", group, expected, got)
+	}
+}
+
+func TestRunnerStatsToProto(t *testing.T) {
+	stats := &RunnerStats{
+		Total:    2,
+		ByStatus: map[RunnerStatus]int32{RunnerStatusRunning: 1, RunnerStatusBootstrapping: 1},
+		ByPreset: map[string]int32{"small": 2},
+		ByOwner:  map[string]int32{"": 2},
+	}
+
+	proto := stats.ToProto()
+	if proto.Total != 2 || proto.ByStatus["running"] != 1 || proto.ByStatus["bootstrapping"] != 1 {
+		t.Errorf("Expected counts keyed by status name, got %v", proto.ByStatus)
+	}
+	if proto.ByPreset["small"] != 2 || proto.ByOwner[""] != 2 {
+		t.Errorf("Expected preset and owner counts to be copied, got %v and %v", proto.ByPreset, proto.ByOwner)
+	}
+}
@@ -3,26 +3,81 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/shellquote"
 )
 
 // Domain errors
 var (
-	ErrRunnerNotFound   = errors.New("runner not found")
-	ErrRunnerNotRunning = errors.New("runner is not running")
-	ErrInvalidRequest   = errors.New("invalid request")
-	ErrKubernetesAPI    = errors.New("kubernetes API error")
-	ErrCommandExecution = errors.New("command execution failed")
-	ErrResourceConflict = errors.New("resource conflict")
+	ErrRunnerNotFound     = errors.New("runner not found")
+	ErrRunnerNotRunning   = errors.New("runner is not running")
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrKubernetesAPI      = errors.New("kubernetes API error")
+	ErrCommandExecution   = errors.New("command execution failed")
+	ErrResourceConflict   = errors.New("resource conflict")
+	ErrFailedPrecondition = errors.New("failed precondition")
+	ErrCleanupIncomplete  = errors.New("runner cleanup incomplete")
+	ErrMetricsUnavailable = errors.New("resource metrics unavailable")
+	ErrInvalidConfig      = errors.New("invalid server configuration")
+	// ErrMissingCredentials and ErrInsufficientDiskSpace are why a workspace snapshot can fail
+	// before anything is uploaded
+	ErrMissingCredentials    = errors.New("no S3 credentials")
+	ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+	// ErrRunnerProtected is why a protected runner isn't deleted
+	ErrRunnerProtected = fmt.Errorf("%w: runner is protected from deletion", ErrFailedPrecondition)
 )
 
 // CreateRunnerRequest represents the domain request to create a runner
 type CreateRunnerRequest struct {
-	Name      string
-	Resources *ResourceRequirements
-	Env       map[string]string
-	Workspace *WorkspaceConfig
+	Name             string
+	Resources        *ResourceRequirements
+	Env              map[string]string
+	Workspace        *WorkspaceConfig
+	BootstrapCommand string
+	// DisableProxy skips the server-configured proxy env vars for this runner
+	DisableProxy bool
+	// ServiceAccount overrides the runner ServiceAccount; it must be in the server's allowlist
+	ServiceAccount string
+	// DisableServiceAccountToken keeps the API token out of the runner
+	DisableServiceAccountToken bool
+	// DefaultShell and DefaultWorkdir apply to the runner's commands that don't set their own
+	DefaultShell   string
+	DefaultWorkdir string
+	// Description is a free-form note of at most MaxDescriptionBytes
+	Description string
+	// Labels are extra pod labels; CloneRunner uses them to carry over the source's labels
+	Labels map[string]string
+	// Group is stored in the RunnerGroupLabel label (optional)
+	Group string
+	// HostAliases are added to the server-configured /etc/hosts entries (at most MaxHostAliases)
+	HostAliases []HostAlias
+	// AutoRestart recreates the runner's pod if it fails; nil uses the server's RestartConfig
+	AutoRestart *bool
+	// ReservedUntil keeps the runner from idle cleanup until then; zero for no reservation
+	ReservedUntil time.Time
+	// PriorityClassName overrides the runner's PriorityClass; it must be in the server's allowlist
+	PriorityClassName string
+	// AutoCreated marks runners the ExecuteService creates, which get the auto-runner PriorityClass
+	AutoCreated bool
+	// SkipValidation skips checking that the runner's PriorityClass exists
+	SkipValidation bool
+	// Arch pins the runner to nodes of a CPU architecture the server supports; empty for any
+	Arch string
+	// Protected keeps the runner from being deleted without overriding it, and from idle cleanup
+	Protected bool
+	// Spot places the runner on the server's spot nodes and defaults AutoRestart to true
+	Spot bool
+}
+
+// UpdateRunnerRequest holds the runner fields to change; nil fields are left as they are
+type UpdateRunnerRequest struct {
+	Description *string
+	// ReservedUntil replaces the end of the runner's reservation; a zero time clears it
+	ReservedUntil *time.Time
+	Protected     *bool
 }
 
 // WorkspaceConfig represents S3 workspace configuration
@@ -32,6 +87,9 @@ type WorkspaceConfig struct {
 	Prefix    string
 	Region    string
 	ReadOnly  bool
+	// S3FSCPU and S3FSMemory override the sidecar's resources, bounded by the server's SidecarConfig
+	S3FSCPU    string
+	S3FSMemory string
 }
 
 // ResourceRequirements represents resource allocation for a runner
@@ -43,16 +101,72 @@ type ResourceRequirements struct {
 
 // Runner represents a runner instance in the domain
 type Runner struct {
-	ID        string
-	Name      string
-	Status    RunnerStatus
-	Resources *ResourceRequirements
-	CreatedAt int64
-	UpdatedAt int64
-	SSH       *SSHDetails
-	IPAddress string
-	Env       map[string]string
-	Workspace *WorkspaceConfig
+	ID               string
+	Name             string
+	Status           RunnerStatus
+	StatusDetail     string
+	Resources        *ResourceRequirements
+	CreatedAt        int64
+	UpdatedAt        int64
+	SSH              *SSHDetails
+	IPAddress        string
+	Env              map[string]string
+	Workspace        *WorkspaceConfig
+	BootstrapCommand string
+	// ProvisioningDuration is how long the runner took to first become ready, zero until then
+	ProvisioningDuration time.Duration
+	// DisableProxy is only used when creating the runner pod
+	DisableProxy bool
+	// NetworkPolicy names the NetworkPolicy attached to the runner, empty if none
+	NetworkPolicy string
+	// ServiceAccount and DisableServiceAccountToken are only used when creating the runner pod
+	ServiceAccount             string
+	DisableServiceAccountToken bool
+	// DefaultShell and DefaultWorkdir are empty when the runner uses the server defaults
+	DefaultShell   string
+	DefaultWorkdir string
+	Description    string
+	// Labels are only used when creating the runner pod; grad's own labels take precedence
+	Labels map[string]string
+	Group  string
+	// HostAliases are the runner's /etc/hosts entries, from the server config and the request
+	HostAliases []HostAlias
+	// Image is the runner container's image reference; ImageDigest what it resolved to on the
+	// node, empty until the container has started
+	Image       string
+	ImageDigest string
+	// StaleImage is set by the runner service if Image isn't the one new runners get
+	StaleImage bool
+	// AutoRestart recreates the pod if it fails; RestartCount is how often that happened
+	AutoRestart  bool
+	RestartCount int32
+	// Usage is the live resource usage, only set by GetRunner when metrics-server reports it
+	Usage *RunnerUsage
+	// ReservedUntil is when the runner's reservation ends, zero if it has none
+	ReservedUntil time.Time
+	// ErrorReason and ErrorMessage explain why the runner is in error, empty in other states
+	ErrorReason  RunnerErrorReason
+	ErrorMessage string
+	// SSHSessions is how many SSH sessions were open when grad last looked; LastSSHLogin is
+	// the latest login it saw, zero if none
+	SSHSessions  int32
+	LastSSHLogin time.Time
+	// PriorityClassName is the PriorityClass of the runner's pod, empty if it has none
+	PriorityClassName string
+	// Arch is the CPU architecture the runner is pinned to, empty if it may run anywhere
+	Arch string
+	// Conditions are the runner's provisioning steps, derived from its pod
+	Conditions []RunnerCondition
+	// WorkspaceMode is how the S3 workspace is mounted into the runner container, derived from
+	// its pod; empty without a workspace
+	WorkspaceMode WorkspaceMode
+	// Protected runners are only deleted when the deletion overrides it, never by idle cleanup
+	Protected bool
+	// Spot runners run on the server's spot nodes, which may be reclaimed at any time
+	Spot bool
+	// Warnings are problems CreateRunner noticed but created the runner anyway, such as no node
+	// having room for it yet; they are only set on the runner CreateRunner returns
+	Warnings []string
 }
 
 // RunnerStatus represents the status of a runner
@@ -65,6 +179,36 @@ const (
 	RunnerStatusStopping    RunnerStatus = "stopping"
 	RunnerStatusStopped     RunnerStatus = "stopped"
 	RunnerStatusError       RunnerStatus = "error"
+	// RunnerStatusBootstrapping means the pod is ready but the bootstrap command is still running
+	RunnerStatusBootstrapping RunnerStatus = "bootstrapping"
+	// RunnerStatusDeleteFailed means some of the runner's objects could not be deleted; deleting it again retries
+	RunnerStatusDeleteFailed RunnerStatus = "delete_failed"
+	// RunnerStatusRestarting means the pod failed and is being recreated
+	RunnerStatusRestarting RunnerStatus = "restarting"
+)
+
+// RunnerErrorReason classifies why a runner entered the error state
+type RunnerErrorReason string
+
+const (
+	RunnerErrorPodFailed        RunnerErrorReason = "pod_failed"
+	RunnerErrorProvisionTimeout RunnerErrorReason = "provision_timeout"
+	RunnerErrorBootstrapFailed  RunnerErrorReason = "bootstrap_failed"
+	RunnerErrorImagePull        RunnerErrorReason = "image_pull"
+	RunnerErrorOOM              RunnerErrorReason = "oom"
+	RunnerErrorUnknown          RunnerErrorReason = "unknown"
+	RunnerErrorNodeShutdown     RunnerErrorReason = "node_shutdown"
+)
+
+// WorkspaceMode is how a runner's S3 workspace is mounted into the runner container
+type WorkspaceMode string
+
+const (
+	WorkspaceModeReadWrite WorkspaceMode = "read_write"
+	WorkspaceModeReadOnly  WorkspaceMode = "read_only"
+	// WorkspaceModeReadOnlyUnenforced is a read-only s3fs mount under a writable volume mount,
+	// as runners created before grad mounted read-only workspaces read-only have
+	WorkspaceModeReadOnlyUnenforced WorkspaceMode = "read_only_unenforced"
 )
 
 // SSHDetails contains SSH connection information
@@ -77,13 +221,84 @@ type SSHDetails struct {
 
 // ExecuteCommandRequest represents a command execution request
 type ExecuteCommandRequest struct {
-	RunnerID   string
-	Command    string
+	RunnerID string
+	Command  string
+	// Argv runs a program with arguments instead of Command, see CommandLine
+	Argv       []string
 	Shell      string
 	Timeout    int32
 	WorkingDir string
 	Workspace  *WorkspaceConfig
 	Env        map[string]string
+	// Ephemeral creates a runner just for this command and deletes it afterwards
+	Ephemeral bool
+	// KeepRunner creates a runner for this command and leaves it running
+	KeepRunner bool
+	// MaxOutputBytes caps the stdout and stderr sent for the command together, 0 for the
+	// server's limit
+	MaxOutputBytes int64
+	// TruncatePolicy decides what happens to the command once its output passes MaxOutputBytes
+	TruncatePolicy TruncatePolicy
+	// Arch picks the architecture of the runner the ExecuteService reuses or creates
+	Arch string
+	// ExecID identifies the command in its correlation env, set by the gRPC layer
+	ExecID string
+	// DisableCorrelationEnv keeps the correlation variables out of the command's environment
+	DisableCorrelationEnv bool
+	// Started, if set, is called once the command's runner, shell and working directory are
+	// resolved, before it runs
+	Started func(ExecStart)
+}
+
+// ExecStart is what a command resolved to right before running
+type ExecStart struct {
+	RunnerID   string
+	Shell      string
+	WorkingDir string
+	// CorrelationEnv are the correlation variables set in the command's environment
+	CorrelationEnv map[string]string
+}
+
+// CommandLine returns the command as a shell runs it: Command, or Argv quoted for a POSIX
+// shell. It is also how the command is shown in the runner's history.
+func (r *ExecuteCommandRequest) CommandLine() string {
+	if len(r.Argv) > 0 {
+		return shellquote.Join(r.Argv)
+	}
+	return r.Command
+}
+
+// TruncatePolicy decides what happens to a command whose output passes its limit
+type TruncatePolicy string
+
+const (
+	// TruncatePolicyKill terminates the command
+	TruncatePolicyKill TruncatePolicy = "kill"
+	// TruncatePolicyDiscard lets the command finish, dropping the output beyond the limit
+	TruncatePolicyDiscard TruncatePolicy = "discard"
+)
+
+// ExitReason describes how a command finished
+type ExitReason string
+
+const (
+	ExitReasonExited    ExitReason = "exited"
+	ExitReasonSignaled  ExitReason = "signaled"
+	ExitReasonTimeout   ExitReason = "timeout"
+	ExitReasonCancelled ExitReason = "cancelled"
+	// ExitReasonOutputLimit means grad terminated the command for passing its output limit
+	ExitReasonOutputLimit ExitReason = "output_limit"
+)
+
+// ExitStatus is the outcome of a command that ran in a runner. Failures to run the
+// command at all are returned as errors instead.
+type ExitStatus struct {
+	Code   int32
+	Reason ExitReason
+	// Signal is the name of the terminating signal, e.g. "SIGKILL", when Reason is ExitReasonSignaled
+	Signal string
+	// LikelyOOM is set when the runner container was OOM-killed while the command ran
+	LikelyOOM bool
 }
 
 
@@ -92,20 +307,54 @@ type ListOptions struct {
 	Status RunnerStatus
 	Limit  int32
 	Offset int32
+	// Filter keeps runners whose ID, name or description contains it, ignoring case
+	Filter string
+	// Group keeps only the runners of that group
+	Group string
+	// ImageDigest keeps runners whose image digest starts with it
+	ImageDigest string
+	// Labels keeps runners whose pod has all of these labels
+	Labels map[string]string
+	// OrderBy sorts runners before paging by one of RunnerOrderKeys, reversed with Reverse;
+	// empty keeps the order of the pod list
+	OrderBy string
+	Reverse bool
+	// StaleOnly keeps runners whose image isn't the one new runners get
+	StaleOnly bool
+	// Arch keeps runners pinned to that CPU architecture
+	Arch string
+	// Name keeps runners with exactly that name
+	Name string
 }
 
 // RunnerService defines the interface for runner management
 type RunnerService interface {
+	// Start ties background work to ctx; Stop cancels it and waits for it to finish
+	Start(ctx context.Context)
+	Stop()
+
 	CreateRunner(ctx context.Context, req *CreateRunnerRequest) (*Runner, error)
-	DeleteRunner(ctx context.Context, runnerID string) error
+	DeleteRunner(ctx context.Context, runnerID string, overrideProtection bool) (*RunnerCleanup, error)
+	WaitForRunnerDeleted(ctx context.Context, runnerID string, timeout time.Duration) ([]RunnerResource, error)
+	GetRunnerUsage(ctx context.Context, runnerID string) (*RunnerUsage, error)
+	ListRunnerUsage(ctx context.Context) ([]*RunnerUsage, error)
+	UpdateRunner(ctx context.Context, runnerID string, req *UpdateRunnerRequest) (*Runner, error)
+	CloneRunner(ctx context.Context, sourceRunnerID, name string) (*Runner, error)
+	DeleteRunnerGroup(ctx context.Context, group string) (*RunnerGroupDeletion, error)
 	ListRunners(ctx context.Context, opts *ListOptions) ([]*Runner, int32, error)
 	GetRunner(ctx context.Context, runnerID string) (*Runner, error)
-	ExecuteCommandStream(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (int32, error)
+	GetRunnerStats(ctx context.Context, opts *ListOptions) (*RunnerStats, error)
+	GetRunnerDiagnostics(ctx context.Context, runnerID string, tailLines int64) (*RunnerDiagnostics, error)
+	AddSSHKey(ctx context.Context, runnerID, publicKey string) (*SSHKey, bool, error)
+	ListSSHKeys(ctx context.Context, runnerID string) ([]SSHKey, error)
+	RemoveSSHKey(ctx context.Context, runnerID, fingerprint string) (bool, error)
+	SnapshotWorkspace(ctx context.Context, req *SnapshotRequest, progress func(SnapshotProgress)) (*SnapshotProgress, error)
+	ExecuteCommandStream(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error)
 }
 
 // ExecuteService defines the interface for command execution with automatic runner provisioning
 type ExecuteService interface {
-	ExecuteCommand(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (int32, error)
+	ExecuteCommand(ctx context.Context, req *ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (ExitStatus, error)
 }
 
 // Conversion functions between domain and proto types
@@ -113,18 +362,63 @@ type ExecuteService interface {
 // ToProtoRunner converts domain Runner to proto Runner
 func (r *Runner) ToProto() *gradv1.Runner {
 	return &gradv1.Runner{
-		Id:        r.ID,
-		Name:      r.Name,
-		Status:    r.Status.ToProto(),
-		Resources: r.Resources.ToProto(),
-		CreatedAt: r.CreatedAt,
-		UpdatedAt: r.UpdatedAt,
-		Ssh:       r.SSH.ToProto(),
-		IpAddress: r.IPAddress,
-		Env:       r.Env,
+		Id:               r.ID,
+		Name:             r.Name,
+		Status:           r.Status.ToProto(),
+		Resources:        r.Resources.ToProto(),
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+		Ssh:              r.SSH.ToProto(),
+		IpAddress:        r.IPAddress,
+		Env:              r.Env,
+		StatusDetail:     r.StatusDetail,
+		BootstrapCommand: r.BootstrapCommand,
+
+		ProvisioningDurationMs: r.ProvisioningDuration.Milliseconds(),
+		NetworkPolicy:          r.NetworkPolicy,
+		DefaultShell:           r.DefaultShell,
+		DefaultWorkdir:         r.DefaultWorkdir,
+		Description:            r.Description,
+		Group:                  r.Group,
+		HostAliases:            toProtoHostAliases(r.HostAliases),
+		Image:                  r.Image,
+		ImageDigest:            r.ImageDigest,
+		StaleImage:             r.StaleImage,
+		RestartCount:           r.RestartCount,
+		AutoRestart:            r.AutoRestart,
+		Usage:                  r.Usage.ToProto(),
+		ReservedUntil:          unixSeconds(r.ReservedUntil),
+		ErrorReason:            r.ErrorReason.ToProto(),
+		ErrorMessage:           r.ErrorMessage,
+		SshSessions:            r.SSHSessions,
+		LastSshLogin:           unixSeconds(r.LastSSHLogin),
+		PriorityClassName:      r.PriorityClassName,
+		Arch:                   r.Arch,
+		Conditions:             toProtoConditions(r.Conditions),
+		WorkspaceMode:          r.WorkspaceMode.ToProto(),
+		Protected:              r.Protected,
+		Spot:                   r.Spot,
 	}
 }
 
+// toProtoHostAliases converts domain host aliases to proto
+func toProtoHostAliases(aliases []HostAlias) []*gradv1.HostAlias {
+	var protoAliases []*gradv1.HostAlias
+	for _, alias := range aliases {
+		protoAliases = append(protoAliases, &gradv1.HostAlias{Ip: alias.IP, Hostnames: alias.Hostnames})
+	}
+	return protoAliases
+}
+
+// fromProtoHostAliases converts proto host aliases to domain
+func fromProtoHostAliases(protoAliases []*gradv1.HostAlias) []HostAlias {
+	var aliases []HostAlias
+	for _, alias := range protoAliases {
+		aliases = append(aliases, HostAlias{IP: alias.Ip, Hostnames: alias.Hostnames})
+	}
+	return aliases
+}
+
 // ToProto converts domain ResourceRequirements to proto ResourceRequirements
 func (rr *ResourceRequirements) ToProto() *gradv1.ResourceRequirements {
 	if rr == nil {
@@ -153,13 +447,43 @@ func (ssh *SSHDetails) ToProto() *gradv1.SSHDetails {
 // FromProtoCreateRunnerRequest converts proto request to domain request
 func FromProtoCreateRunnerRequest(req *gradv1.CreateRunnerRequest) *CreateRunnerRequest {
 	return &CreateRunnerRequest{
-		Name:      req.Name,
-		Resources: nil, // Resources are no longer in the request - will use preset
-		Env:       req.Env,
-		Workspace: FromProtoWorkspaceConfig(req.Workspace),
+		Name:             req.Name,
+		Resources:        nil, // Resources are no longer in the request - will use preset
+		Env:              req.Env,
+		Workspace:        FromProtoWorkspaceConfig(req.Workspace),
+		BootstrapCommand: req.BootstrapCommand,
+		DisableProxy:     req.DisableProxy,
+
+		ServiceAccount:             req.ServiceAccount,
+		DisableServiceAccountToken: req.DisableServiceAccountToken,
+		DefaultShell:               req.DefaultShell,
+		DefaultWorkdir:             req.DefaultWorkdir,
+		Description:                req.Description,
+		Group:                      req.Group,
+		HostAliases:                fromProtoHostAliases(req.HostAliases),
+		AutoRestart:                req.AutoRestart,
+		ReservedUntil:              unixTime(req.ReservedUntil),
+		PriorityClassName:          req.PriorityClassName,
+		SkipValidation:             req.SkipValidation,
+		Arch:                       req.Arch,
+		Protected:                  req.Protected,
+		Spot:                       req.Spot,
 	}
 }
 
+// FromProtoUpdateRunnerRequest converts proto request to domain request
+func FromProtoUpdateRunnerRequest(req *gradv1.UpdateRunnerRequest) *UpdateRunnerRequest {
+	update := &UpdateRunnerRequest{
+		Description: req.Description,
+		Protected:   req.Protected,
+	}
+	if req.ReservedUntil != nil {
+		reservedUntil := unixTime(*req.ReservedUntil)
+		update.ReservedUntil = &reservedUntil
+	}
+	return update
+}
+
 // FromProtoWorkspaceConfig converts proto WorkspaceConfig to domain
 func FromProtoWorkspaceConfig(wc *gradv1.WorkspaceConfig) *WorkspaceConfig {
 	if wc == nil {
@@ -171,6 +495,9 @@ func FromProtoWorkspaceConfig(wc *gradv1.WorkspaceConfig) *WorkspaceConfig {
 		Prefix:    wc.Prefix,
 		Region:    wc.Region,
 		ReadOnly:  wc.ReadOnly,
+
+		S3FSCPU:    wc.S3FsCpu,
+		S3FSMemory: wc.S3FsMemory,
 	}
 }
 
@@ -191,27 +518,41 @@ func FromProtoExecuteCommandRequest(req *gradv1.ExecuteCommandRequest) *ExecuteC
 	result := &ExecuteCommandRequest{
 		RunnerID:   req.RunnerId,
 		Command:    req.Command,
+		Argv:       req.Argv,
 		Shell:      req.Shell,
 		Timeout:    req.Timeout,
 		WorkingDir: req.WorkingDir,
 		Env:        req.Env,
+		Ephemeral:  req.Ephemeral,
+		KeepRunner: req.KeepRunner,
+
+		MaxOutputBytes: req.MaxOutputBytes,
+		TruncatePolicy: TruncatePolicyFromProto(req.TruncatePolicy),
+		Arch:           req.Arch,
+
+		DisableCorrelationEnv: req.DisableCorrelationEnv,
 	}
 	
 	// Convert workspace config if provided
-	if req.Workspace != nil {
-		result.Workspace = &WorkspaceConfig{
-			Bucket:   req.Workspace.Bucket,
-			Endpoint: req.Workspace.Endpoint,
-			Prefix:   req.Workspace.Prefix,
-			Region:   req.Workspace.Region,
-			ReadOnly: req.Workspace.ReadOnly,
-		}
-	}
+	result.Workspace = FromProtoWorkspaceConfig(req.Workspace)
 	
 	return result
 }
 
 
+// TruncatePolicyFromProto converts a proto TruncatePolicy, which defaults to TruncatePolicyKill.
+// Unknown values convert to "", which ExecuteCommandViolations rejects.
+func TruncatePolicyFromProto(policy gradv1.TruncatePolicy) TruncatePolicy {
+	switch policy {
+	case gradv1.TruncatePolicy_TRUNCATE_POLICY_UNSPECIFIED, gradv1.TruncatePolicy_TRUNCATE_POLICY_KILL:
+		return TruncatePolicyKill
+	case gradv1.TruncatePolicy_TRUNCATE_POLICY_DISCARD:
+		return TruncatePolicyDiscard
+	default:
+		return ""
+	}
+}
+
 // FromProtoListOptions converts proto list options to domain
 func FromProtoListOptions(status gradv1.RunnerStatus, limit, offset int32) *ListOptions {
 	return &ListOptions{
@@ -236,11 +577,101 @@ func (rs RunnerStatus) ToProto() gradv1.RunnerStatus {
 		return gradv1.RunnerStatus_RUNNER_STATUS_STOPPED
 	case RunnerStatusError:
 		return gradv1.RunnerStatus_RUNNER_STATUS_ERROR
+	case RunnerStatusBootstrapping:
+		return gradv1.RunnerStatus_RUNNER_STATUS_BOOTSTRAPPING
+	case RunnerStatusDeleteFailed:
+		return gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED
+	case RunnerStatusRestarting:
+		return gradv1.RunnerStatus_RUNNER_STATUS_RESTARTING
 	default:
 		return gradv1.RunnerStatus_RUNNER_STATUS_UNSPECIFIED
 	}
 }
 
+// ToProto converts domain RunnerErrorReason to proto RunnerErrorReason
+func (r RunnerErrorReason) ToProto() gradv1.RunnerErrorReason {
+	switch r {
+	case RunnerErrorPodFailed:
+		return gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_POD_FAILED
+	case RunnerErrorProvisionTimeout:
+		return gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_PROVISION_TIMEOUT
+	case RunnerErrorBootstrapFailed:
+		return gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_BOOTSTRAP_FAILED
+	case RunnerErrorImagePull:
+		return gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_IMAGE_PULL
+	case RunnerErrorOOM:
+		return gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_OOM
+	case RunnerErrorUnknown:
+		return gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_UNKNOWN
+	case RunnerErrorNodeShutdown:
+		return gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_NODE_SHUTDOWN
+	default:
+		return gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_UNSPECIFIED
+	}
+}
+
+// ToProto converts domain WorkspaceMode to proto WorkspaceMode
+func (m WorkspaceMode) ToProto() gradv1.WorkspaceMode {
+	switch m {
+	case WorkspaceModeReadWrite:
+		return gradv1.WorkspaceMode_WORKSPACE_MODE_READ_WRITE
+	case WorkspaceModeReadOnly:
+		return gradv1.WorkspaceMode_WORKSPACE_MODE_READ_ONLY
+	case WorkspaceModeReadOnlyUnenforced:
+		return gradv1.WorkspaceMode_WORKSPACE_MODE_READ_ONLY_UNENFORCED
+	default:
+		return gradv1.WorkspaceMode_WORKSPACE_MODE_UNSPECIFIED
+	}
+}
+
+// ToProto converts domain ExitReason to proto ExitReason
+func (er ExitReason) ToProto() gradv1.ExitReason {
+	switch er {
+	case ExitReasonExited:
+		return gradv1.ExitReason_EXIT_REASON_EXITED
+	case ExitReasonSignaled:
+		return gradv1.ExitReason_EXIT_REASON_SIGNALED
+	case ExitReasonTimeout:
+		return gradv1.ExitReason_EXIT_REASON_TIMEOUT
+	case ExitReasonCancelled:
+		return gradv1.ExitReason_EXIT_REASON_CANCELLED
+	case ExitReasonOutputLimit:
+		return gradv1.ExitReason_EXIT_REASON_OUTPUT_LIMIT
+	default:
+		return gradv1.ExitReason_EXIT_REASON_UNSPECIFIED
+	}
+}
+
+// String describes how the command finished, e.g. "terminated by SIGKILL"
+func (es ExitStatus) String() string {
+	switch es.Reason {
+	case ExitReasonSignaled:
+		if es.LikelyOOM {
+			return fmt.Sprintf("terminated by %s (likely OOM)", es.Signal)
+		}
+		return fmt.Sprintf("terminated by %s", es.Signal)
+	case ExitReasonTimeout:
+		return "timed out"
+	case ExitReasonCancelled:
+		return "cancelled"
+	case ExitReasonOutputLimit:
+		return "terminated for exceeding the output limit"
+	default:
+		return fmt.Sprintf("exited with code %d", es.Code)
+	}
+}
+
+// ToProto converts an ExitStatus to the final EXIT stream message
+func (es ExitStatus) ToProto() *gradv1.ExecuteCommandStreamResponse {
+	return &gradv1.ExecuteCommandStreamResponse{
+		Type:          gradv1.StreamType_STREAM_TYPE_EXIT,
+		ExitCode:      es.Code,
+		FailureReason: es.Reason.ToProto(),
+		Signal:        es.Signal,
+		LikelyOom:     es.LikelyOOM,
+	}
+}
+
 // RunnerStatusFromProto converts proto RunnerStatus to domain RunnerStatus
 func RunnerStatusFromProto(status gradv1.RunnerStatus) RunnerStatus {
 	switch status {
@@ -256,6 +687,12 @@ func RunnerStatusFromProto(status gradv1.RunnerStatus) RunnerStatus {
 		return RunnerStatusStopped
 	case gradv1.RunnerStatus_RUNNER_STATUS_ERROR:
 		return RunnerStatusError
+	case gradv1.RunnerStatus_RUNNER_STATUS_BOOTSTRAPPING:
+		return RunnerStatusBootstrapping
+	case gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED:
+		return RunnerStatusDeleteFailed
+	case gradv1.RunnerStatus_RUNNER_STATUS_RESTARTING:
+		return RunnerStatusRestarting
 	default:
 		return RunnerStatusUnspecified
 	}
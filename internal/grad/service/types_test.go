@@ -236,6 +236,24 @@ func TestFromProtoExecuteCommandRequestNoWorkspace(t *testing.T) {
 	}
 }
 
+func TestTruncatePolicyFromProto(t *testing.T) {
+	tests := []struct {
+		policy   gradv1.TruncatePolicy
+		expected TruncatePolicy
+	}{
+		{gradv1.TruncatePolicy_TRUNCATE_POLICY_UNSPECIFIED, TruncatePolicyKill},
+		{gradv1.TruncatePolicy_TRUNCATE_POLICY_KILL, TruncatePolicyKill},
+		{gradv1.TruncatePolicy_TRUNCATE_POLICY_DISCARD, TruncatePolicyDiscard},
+		{gradv1.TruncatePolicy(42), ""},
+	}
+
+	for _, tt := range tests {
+		if got := TruncatePolicyFromProto(tt.policy); got != tt.expected {
+			t.Errorf("Expected %s to convert to %q, got %q", tt.policy, tt.expected, got)
+		}
+	}
+}
+
 func TestFromProtoListOptions(t *testing.T) {
 	opts := FromProtoListOptions(gradv1.RunnerStatus_RUNNER_STATUS_RUNNING, 20, 10)
 
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// podMetricsResource is where metrics-server serves the live usage of pods
+var podMetricsResource = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+// metricsUnavailableHint is appended to ErrMetricsUnavailable for clients
+const metricsUnavailableHint = "the cluster doesn't serve the metrics.k8s.io API; install metrics-server and restart grad"
+
+// ContainerUsage is the live resource usage of one container
+type ContainerUsage struct {
+	Name          string
+	CPUMillicores int64
+	// MemoryBytes is the container's working set
+	MemoryBytes int64
+}
+
+// RunnerUsage is the resource usage of a runner as last sampled by metrics-server
+type RunnerUsage struct {
+	RunnerID   string
+	Timestamp  time.Time
+	Window     time.Duration
+	Containers []ContainerUsage
+	// Output is the command output streamed from the runner, counted by grad itself
+	Output OutputBytes
+}
+
+// CPUMillicores is the CPU usage of all containers together
+func (u *RunnerUsage) CPUMillicores() int64 {
+	var total int64
+	for _, container := range u.Containers {
+		total += container.CPUMillicores
+	}
+	return total
+}
+
+// MemoryBytes is the memory usage of all containers together
+func (u *RunnerUsage) MemoryBytes() int64 {
+	var total int64
+	for _, container := range u.Containers {
+		total += container.MemoryBytes
+	}
+	return total
+}
+
+// ToProto converts domain RunnerUsage to proto RunnerUsage
+func (u *RunnerUsage) ToProto() *gradv1.RunnerUsage {
+	if u == nil {
+		return nil
+	}
+	usage := &gradv1.RunnerUsage{
+		RunnerId:      u.RunnerID,
+		Timestamp:     u.Timestamp.Unix(),
+		WindowMs:      u.Window.Milliseconds(),
+		CpuMillicores: u.CPUMillicores(),
+		MemoryBytes:   u.MemoryBytes(),
+		StdoutBytes:   u.Output.Stdout,
+		StderrBytes:   u.Output.Stderr,
+	}
+	for _, container := range u.Containers {
+		usage.Containers = append(usage.Containers, &gradv1.ContainerUsage{
+			Name:          container.Name,
+			CpuMillicores: container.CPUMillicores,
+			MemoryBytes:   container.MemoryBytes,
+		})
+	}
+	return usage
+}
+
+// metricsAPIServed reports whether the API server serves pod metrics
+func metricsAPIServed(client discovery.DiscoveryInterface) (bool, error) {
+	resources, err := client.ServerResourcesForGroupVersion(podMetricsResource.GroupVersion().String())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == podMetricsResource.Resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DetectMetrics enables runner usage if the cluster serves the metrics.k8s.io API, usually
+// through metrics-server. It is called once at startup; without the API, usage lookups fail
+// with ErrMetricsUnavailable.
+func (k *KubernetesClient) DetectMetrics() bool {
+	served, err := metricsAPIServed(k.clientset.Discovery())
+	if err != nil {
+		slog.Warn("Failed to look for the metrics API, runner usage is disabled", "error", err)
+		return false
+	}
+	if !served {
+		slog.Info("Metrics API not found, runner usage is disabled", "group_version", podMetricsResource.GroupVersion().String())
+		return false
+	}
+
+	client, err := dynamic.NewForConfig(k.restConfig)
+	if err != nil {
+		slog.Warn("Failed to create metrics client, runner usage is disabled", "error", err)
+		return false
+	}
+	k.metrics = client
+	slog.Info("Metrics API found, runner usage is enabled")
+	return true
+}
+
+// RunnerUsage returns a runner's live usage. It fails with ErrFailedPrecondition if
+// metrics-server hasn't sampled the runner's pod yet, which takes up to a minute.
+func (k *KubernetesClient) RunnerUsage(ctx context.Context, runnerID string) (*RunnerUsage, error) {
+	if k.metrics == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMetricsUnavailable, metricsUnavailableHint)
+	}
+
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	obj, err := k.metrics.Resource(podMetricsResource).Namespace(k.config.Namespace).Get(ctx, k.getPodName(runnerID), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: no usage reported for runner %s yet, metrics-server samples new pods within a minute", ErrFailedPrecondition, runnerID)
+		}
+		return nil, fmt.Errorf("%w: failed to get pod metrics: %w", ErrKubernetesAPI, err)
+	}
+	return podMetricsToUsage(runnerID, obj)
+}
+
+// ListRunnerUsage returns the live usage of every runner metrics-server reports on,
+// highest CPU first
+func (k *KubernetesClient) ListRunnerUsage(ctx context.Context) ([]*RunnerUsage, error) {
+	if k.metrics == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMetricsUnavailable, metricsUnavailableHint)
+	}
+
+	ctx, cancel := k.apiContext(ctx)
+	defer cancel()
+
+	list, err := k.metrics.Resource(podMetricsResource).Namespace(k.config.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: RunnerLabelSelector + "," + RunnerComponentLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list pod metrics: %w", ErrKubernetesAPI, err)
+	}
+
+	usages := make([]*RunnerUsage, 0, len(list.Items))
+	for i := range list.Items {
+		item := &list.Items[i]
+		runnerID, ok := strings.CutPrefix(item.GetName(), k.getPodName(""))
+		if !ok {
+			continue
+		}
+		usage, err := podMetricsToUsage(runnerID, item)
+		if err != nil {
+			slog.Warn("Skipping unreadable pod metrics", "pod", item.GetName(), "error", err)
+			continue
+		}
+		usages = append(usages, usage)
+	}
+	sortRunnerUsage(usages)
+	return usages, nil
+}
+
+// sortRunnerUsage orders usages by CPU, then memory, highest first (pure function)
+func sortRunnerUsage(usages []*RunnerUsage) {
+	slices.SortStableFunc(usages, func(a, b *RunnerUsage) int {
+		return cmp.Or(
+			cmp.Compare(b.CPUMillicores(), a.CPUMillicores()),
+			cmp.Compare(b.MemoryBytes(), a.MemoryBytes()),
+			cmp.Compare(a.RunnerID, b.RunnerID),
+		)
+	})
+}
+
+// podMetricsToUsage reads a metrics.k8s.io PodMetrics object (pure function)
+func podMetricsToUsage(runnerID string, obj *unstructured.Unstructured) (*RunnerUsage, error) {
+	usage := &RunnerUsage{RunnerID: runnerID}
+
+	if timestamp, _, _ := unstructured.NestedString(obj.Object, "timestamp"); timestamp != "" {
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", timestamp, err)
+		}
+		usage.Timestamp = t
+	}
+	if window, _, _ := unstructured.NestedString(obj.Object, "window"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window %q: %w", window, err)
+		}
+		usage.Window = d
+	}
+
+	containers, _, err := unstructured.NestedSlice(obj.Object, "containers")
+	if err != nil {
+		return nil, fmt.Errorf("invalid containers: %w", err)
+	}
+	for _, c := range containers {
+		container, ok := c.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid container %v", c)
+		}
+		name, _, _ := unstructured.NestedString(container, "name")
+		cpu, err := parseUsageQuantity(container, "cpu")
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %w", name, err)
+		}
+		memory, err := parseUsageQuantity(container, "memory")
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %w", name, err)
+		}
+		usage.Containers = append(usage.Containers, ContainerUsage{
+			Name:          name,
+			CPUMillicores: cpu.MilliValue(),
+			MemoryBytes:   memory.Value(),
+		})
+	}
+
+	return usage, nil
+}
+
+// parseUsageQuantity reads the named quantity of a container's usage, zero if missing (pure function)
+func parseUsageQuantity(container map[string]any, name string) (resource.Quantity, error) {
+	value, _, _ := unstructured.NestedString(container, "usage", name)
+	if value == "" {
+		return resource.Quantity{}, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("invalid %s usage %q: %w", name, value, err)
+	}
+	return quantity, nil
+}
+
+// GetRunnerUsage returns a runner's live usage from metrics-server
+func (s *runnerService) GetRunnerUsage(ctx context.Context, runnerID string) (*RunnerUsage, error) {
+	if _, err := s.getRunnerPod(ctx, runnerID); err != nil {
+		return nil, err
+	}
+	usage, err := s.k8sClient.RunnerUsage(ctx, runnerID)
+	if err != nil {
+		return nil, err
+	}
+	usage.Output = s.activityTracker.GetOutputBytes(runnerID)
+	return usage, nil
+}
+
+// ListRunnerUsage returns the live usage of every runner metrics-server reports on
+func (s *runnerService) ListRunnerUsage(ctx context.Context) ([]*RunnerUsage, error) {
+	usages, err := s.k8sClient.ListRunnerUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, usage := range usages {
+		usage.Output = s.activityTracker.GetOutputBytes(usage.RunnerID)
+	}
+	return usages, nil
+}
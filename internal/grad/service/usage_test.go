@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// podMetrics builds the metrics.k8s.io object metrics-server serves for a runner's pod
+func podMetrics(namespace, runnerID string, containers ...map[string]any) *unstructured.Unstructured {
+	items := make([]any, len(containers))
+	for i, container := range containers {
+		items[i] = container
+	}
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"metadata": map[string]any{
+			"name":      "grad-runner-" + runnerID,
+			"namespace": namespace,
+		},
+		"timestamp":  "2026-10-16T12:00:00Z",
+		"window":     "10.5s",
+		"containers": items,
+	}}
+	obj.SetLabels(runnerPodLabels(runnerID))
+	return obj
+}
+
+// containerMetrics is the usage of one container in a PodMetrics object
+func containerMetrics(name, cpu, memory string) map[string]any {
+	return map[string]any{"name": name, "usage": map[string]any{"cpu": cpu, "memory": memory}}
+}
+
+// newTestMetricsClient returns a KubernetesClient serving objects from a fake metrics API
+func newTestMetricsClient(objects ...*unstructured.Unstructured) *KubernetesClient {
+	listKinds := map[schema.GroupVersionResource]string{podMetricsResource: "PodMetricsList"}
+	metrics := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	// The tracker would guess the resource from the kind, but PodMetrics are served as pods
+	for _, obj := range objects {
+		metrics.Tracker().Create(podMetricsResource, obj, obj.GetNamespace())
+	}
+	return &KubernetesClient{
+		clientset: fake.NewSimpleClientset(),
+		config:    DefaultKubernetesConfig(),
+		metrics:   metrics,
+	}
+}
+
+func TestRunnerUsage(t *testing.T) {
+	namespace := DefaultKubernetesConfig().Namespace
+	k := newTestMetricsClient(podMetrics(namespace, "runner-1",
+		containerMetrics("runner", "1500m", "512Mi"),
+		containerMetrics("s3fs", "2500000n", "64Mi"),
+	))
+
+	usage, err := k.RunnerUsage(context.Background(), "runner-1")
+	if err != nil {
+		t.Fatalf("RunnerUsage failed: %v", err)
+	}
+
+	if usage.RunnerID != "runner-1" {
+		t.Errorf("Expected runner-1, got %s", usage.RunnerID)
+	}
+	if !usage.Timestamp.Equal(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)) || usage.Window != 10500*time.Millisecond {
+		t.Errorf("Expected a 10.5s window sampled at 12:00, got %s at %s", usage.Window, usage.Timestamp)
+	}
+	if len(usage.Containers) != 2 {
+		t.Fatalf("Expected 2 containers, got %d", len(usage.Containers))
+	}
+	if c := usage.Containers[1]; c.Name != "s3fs" || c.CPUMillicores != 3 || c.MemoryBytes != 64<<20 {
+		t.Errorf("Expected s3fs at 3m and 64Mi, got %+v", c)
+	}
+	if usage.CPUMillicores() != 1503 || usage.MemoryBytes() != 576<<20 {
+		t.Errorf("Expected 1503m and 576Mi in total, got %dm and %d bytes", usage.CPUMillicores(), usage.MemoryBytes())
+	}
+
+	proto := usage.ToProto()
+	if proto.CpuMillicores != 1503 || proto.WindowMs != 10500 || len(proto.Containers) != 2 {
+		t.Errorf("Expected the totals, window and containers in proto, got %+v", proto)
+	}
+
+	// Pods metrics-server hasn't sampled yet
+	if _, err := k.RunnerUsage(context.Background(), "runner-2"); !errors.Is(err, ErrFailedPrecondition) {
+		t.Errorf("Expected ErrFailedPrecondition for a runner without metrics, got %v", err)
+	}
+}
+
+func TestListRunnerUsage(t *testing.T) {
+	namespace := DefaultKubernetesConfig().Namespace
+	other := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"metadata":   map[string]any{"name": "grad", "namespace": namespace},
+		"containers": []any{containerMetrics("grad", "4", "1Gi")},
+	}}
+	k := newTestMetricsClient(
+		podMetrics(namespace, "runner-1", containerMetrics("runner", "100m", "256Mi")),
+		podMetrics(namespace, "runner-2", containerMetrics("runner", "900m", "128Mi")),
+		podMetrics(namespace, "runner-3", containerMetrics("runner", "100m", "1Gi")),
+		other,
+	)
+
+	usages, err := k.ListRunnerUsage(context.Background())
+	if err != nil {
+		t.Fatalf("ListRunnerUsage failed: %v", err)
+	}
+
+	// Highest CPU first, then highest memory; pods that aren't runners are left out
+	expected := []string{"runner-2", "runner-3", "runner-1"}
+	if len(usages) != len(expected) {
+		t.Fatalf("Expected %d runners, got %d", len(expected), len(usages))
+	}
+	for i, usage := range usages {
+		if usage.RunnerID != expected[i] {
+			t.Errorf("Expected %s at position %d, got %s", expected[i], i, usage.RunnerID)
+		}
+	}
+}
+
+func TestRunnerUsageWithoutMetricsAPI(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	k := &KubernetesClient{clientset: clientset, config: DefaultKubernetesConfig()}
+
+	if k.DetectMetrics() {
+		t.Error("Expected no metrics API on a cluster without metrics-server")
+	}
+	if _, err := k.RunnerUsage(context.Background(), "runner-1"); !errors.Is(err, ErrMetricsUnavailable) {
+		t.Errorf("Expected ErrMetricsUnavailable, got %v", err)
+	}
+	if _, err := k.ListRunnerUsage(context.Background()); !errors.Is(err, ErrMetricsUnavailable) {
+		t.Errorf("Expected ErrMetricsUnavailable, got %v", err)
+	}
+
+	clientset.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "metrics.k8s.io/v1beta1",
+		APIResources: []metav1.APIResource{{Name: "nodes"}, {Name: "pods"}},
+	}}
+	if served, err := metricsAPIServed(clientset.Discovery()); err != nil || !served {
+		t.Errorf("Expected the metrics API to be found, got %t (%v)", served, err)
+	}
+}
+
+func TestGetRunnerIncludesUsage(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	defer svc.Stop()
+
+	// Without metrics-server the runner is returned without usage
+	got, err := svc.GetRunner(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if got.Usage != nil {
+		t.Errorf("Expected no usage without metrics-server, got %+v", got.Usage)
+	}
+	if _, err := svc.GetRunnerUsage(ctx, runner.ID); !errors.Is(err, ErrMetricsUnavailable) {
+		t.Errorf("Expected ErrMetricsUnavailable, got %v", err)
+	}
+
+	kubernetesClient(svc).metrics = newTestMetricsClient(podMetrics(svc.k8sClient.Config().Namespace, runner.ID, containerMetrics("runner", "250m", "1Gi"))).metrics
+	got, err = svc.GetRunner(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if got.Usage == nil || got.Usage.CPUMillicores() != 250 {
+		t.Errorf("Expected 250m CPU usage, got %+v", got.Usage)
+	}
+
+	if _, err := svc.GetRunnerUsage(ctx, "missing"); !errors.Is(err, ErrRunnerNotFound) {
+		t.Errorf("Expected ErrRunnerNotFound, got %v", err)
+	}
+}
+
+func TestRunnerUsageIncludesOutputBytes(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestRunnerService()
+
+	runner, err := svc.CreateRunner(ctx, &CreateRunnerRequest{})
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	defer svc.Stop()
+	kubernetesClient(svc).metrics = newTestMetricsClient(podMetrics(svc.k8sClient.Config().Namespace, runner.ID, containerMetrics("runner", "250m", "1Gi"))).metrics
+
+	// Send output the way ExecuteCommandStream does
+	output := svc.execOutput(runner.ID)
+	stdout := newChannelWriter(make(chan []byte, 10), "stdout", output.flushInterval)
+	stderr := newChannelWriter(make(chan []byte, 10), "stderr", output.flushInterval)
+	stdout.sent, stderr.sent = output.sent, output.sent
+	stdout.Write(make([]byte, 1000))
+	stdout.Write(make([]byte, 24))
+	stderr.Write([]byte("warning\n"))
+	stdout.Close()
+	stderr.Close()
+
+	// Output dropped because nobody reads it isn't counted
+	dropped := newChannelWriter(make(chan []byte), "stdout", 0)
+	dropped.sent = output.sent
+	dropped.Write([]byte("lost\n"))
+
+	expected := OutputBytes{Stdout: 1024, Stderr: 8}
+	usage, err := svc.GetRunnerUsage(ctx, runner.ID)
+	if err != nil {
+		t.Fatalf("GetRunnerUsage failed: %v", err)
+	}
+	if usage.Output != expected {
+		t.Errorf("Expected %+v, got %+v", expected, usage.Output)
+	}
+	if proto := usage.ToProto(); proto.StdoutBytes != 1024 || proto.StderrBytes != 8 {
+		t.Errorf("Expected 1024 and 8 bytes in proto, got %d and %d", proto.StdoutBytes, proto.StderrBytes)
+	}
+
+	usages, err := svc.ListRunnerUsage(ctx)
+	if err != nil {
+		t.Fatalf("ListRunnerUsage failed: %v", err)
+	}
+	if len(usages) != 1 || usages[0].Output != expected {
+		t.Errorf("Expected one runner with %+v, got %+v", expected, usages)
+	}
+
+	svc.activityTracker.RemoveRunner(runner.ID)
+	if got := svc.activityTracker.GetOutputBytes(runner.ID); got != (OutputBytes{}) {
+		t.Errorf("Expected the counts to go with the runner, got %+v", got)
+	}
+}
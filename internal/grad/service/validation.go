@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// maxRunnerNameLength is the longest runner name accepted
+const maxRunnerNameLength = 100
+
+// FieldViolation is a problem with one field of a request
+type FieldViolation struct {
+	// Field is the field's path in the proto request, e.g. workspace or labels
+	Field       string
+	Description string
+}
+
+// ValidationError reports every problem found with a request at once, so clients can fix
+// them in one go. It matches ErrInvalidRequest.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		problems[i] = violation.Field + ": " + violation.Description
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidRequest, strings.Join(problems, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidRequest
+}
+
+// Violations collects the problems found with a request
+type Violations []FieldViolation
+
+// Add records a problem with field
+func (v *Violations) Add(field, format string, args ...any) {
+	*v = append(*v, FieldViolation{Field: field, Description: fmt.Sprintf(format, args...)})
+}
+
+// Check records err, if any, as a problem with field
+func (v *Violations) Check(field string, err error) {
+	if err == nil {
+		return
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		*v = append(*v, validationErr.Violations...)
+		return
+	}
+	v.Add(field, "%s", strings.TrimPrefix(err.Error(), ErrInvalidRequest.Error()+": "))
+}
+
+// CheckEnv records each problem of env against the limits as a problem with field
+func (v *Violations) CheckEnv(field string, env map[string]string, limits *EnvLimits) {
+	for _, problem := range envProblems(env, limits) {
+		v.Add(field, "%s", problem)
+	}
+}
+
+// CheckCommand records a problem with field if command is longer than limits allow
+func (v *Violations) CheckCommand(field, command string, limits *CommandLimits) {
+	if limits == nil {
+		limits = DefaultCommandLimits()
+	}
+	if len(command) > limits.MaxBytes {
+		v.Add(field, "must be at most %d bytes, got %d; put large scripts in the workspace and run them from there", limits.MaxBytes, len(command))
+	}
+}
+
+// CheckExecCommand records problems with the command or argv of req if they are larger than
+// limits allow. Argv is measured as the command line it is quoted into.
+func (v *Violations) CheckExecCommand(req *ExecuteCommandRequest, limits *CommandLimits) {
+	if limits == nil {
+		limits = DefaultCommandLimits()
+	}
+	if len(req.Argv) == 0 {
+		v.CheckCommand("command", req.Command, limits)
+		return
+	}
+	if len(req.Argv) > limits.MaxArgs {
+		v.Add("argv", "must have at most %d words, got %d", limits.MaxArgs, len(req.Argv))
+	}
+	v.CheckCommand("argv", req.CommandLine(), limits)
+}
+
+// Err returns a ValidationError listing the problems, or nil if there are none
+func (v Violations) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v}
+}
+
+// CreateRunnerViolations checks the fields of a create request that don't need the cluster.
+// A nil sidecar config skips the server maximums of sidecar resources. (pure function)
+func CreateRunnerViolations(req *CreateRunnerRequest, sidecar *SidecarConfig) Violations {
+	var v Violations
+	if len(req.Name) > maxRunnerNameLength {
+		v.Add("name", "must be at most %d characters, got %d", maxRunnerNameLength, len(req.Name))
+	}
+	if sidecar == nil {
+		sidecar = &SidecarConfig{}
+	}
+	v.Check("workspace", validateSidecarResources(req.Workspace, sidecar))
+	v.Check("default_shell", validateDefaultShell(req.DefaultShell))
+	v.Check("default_workdir", validateDefaultWorkdir(req.DefaultWorkdir))
+	v.Check("description", validateDescription(req.Description))
+	v.Check("group", validateGroup(req.Group))
+	v.Check("host_aliases", validateRequestHostAliases(req.HostAliases))
+	return v
+}
+
+// ExecuteCommandViolations checks an exec request. RunnerService requests must also name
+// their runner, which ExecuteService requests may leave out. (pure function)
+func ExecuteCommandViolations(req *ExecuteCommandRequest, requireRunner bool) Violations {
+	var v Violations
+	if requireRunner && req.RunnerID == "" {
+		v.Add("runner_id", "is required")
+	}
+	switch {
+	case req.Command == "" && len(req.Argv) == 0:
+		v.Add("command", "is required unless argv is set")
+	case req.Command != "" && len(req.Argv) > 0:
+		v.Add("argv", "can't be combined with command")
+	case len(req.Argv) > 0 && req.Argv[0] == "":
+		v.Add("argv", "must start with the program to run")
+	}
+	if req.Shell == ExecShellNone && len(req.Argv) == 0 {
+		v.Add("shell", "%q needs argv, a command line needs a shell", ExecShellNone)
+	}
+	if req.Timeout < 0 {
+		v.Add("timeout", "must be non-negative, got %d", req.Timeout)
+	}
+	if strings.ContainsAny(req.Shell, " \t\r\n") {
+		v.Add("shell", "must be a program name or path without arguments, got %q", req.Shell)
+	}
+	if req.MaxOutputBytes < 0 {
+		v.Add("max_output_bytes", "must be non-negative, got %d", req.MaxOutputBytes)
+	}
+	if req.TruncatePolicy != TruncatePolicyKill && req.TruncatePolicy != TruncatePolicyDiscard {
+		v.Add("truncate_policy", "must be TRUNCATE_POLICY_KILL or TRUNCATE_POLICY_DISCARD")
+	}
+	v = append(v, executeModeViolations(req)...)
+	return v
+}
+
+// ListOptionsViolations checks the paging and filters of a list request (pure function)
+func ListOptionsViolations(opts *ListOptions) Violations {
+	var v Violations
+	if opts.Limit < 0 {
+		v.Add("limit", "must be non-negative, got %d", opts.Limit)
+	}
+	if opts.Offset < 0 {
+		v.Add("offset", "must be non-negative, got %d", opts.Offset)
+	}
+	v.Check("group", validateGroup(opts.Group))
+	if opts.OrderBy != "" && !slices.Contains(RunnerOrderKeys, opts.OrderBy) {
+		v.Add("order_by", "must be one of %s, got %q", strings.Join(RunnerOrderKeys, ", "), opts.OrderBy)
+	}
+	for key, value := range opts.Labels {
+		if problems := validation.IsQualifiedName(key); len(problems) > 0 {
+			v.Add("labels", "invalid key %q: %s", key, strings.Join(problems, "; "))
+		}
+		if problems := validation.IsValidLabelValue(value); len(problems) > 0 {
+			v.Add("labels", "invalid value %q of %s: %s", value, key, strings.Join(problems, "; "))
+		}
+	}
+	return v
+}
+
+// isSpecError reports whether err is a request or configuration problem found while
+// building a runner's pod, rather than a failure of the Kubernetes API (pure function)
+func isSpecError(err error) bool {
+	return errors.Is(err, ErrInvalidRequest) || errors.Is(err, ErrInvalidConfig)
+}
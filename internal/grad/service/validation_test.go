@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestViolations(t *testing.T) {
+	var v Violations
+	if err := v.Err(); err != nil {
+		t.Fatalf("Expected no error without violations, got %v", err)
+	}
+
+	v.Add("timeout", "must be non-negative, got %d", -1)
+	v.Check("group", validateGroup("not a group"))
+	v.Check("description", nil)
+	v.Check("runner_id", validateExecuteMode(&ExecuteCommandRequest{RunnerID: "runner-1", Ephemeral: true}))
+
+	err := v.Err()
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest, got %v", err)
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Violations) != 3 {
+		t.Fatalf("Expected 3 violations, got %v", err)
+	}
+	if got := validationErr.Violations[1]; got.Field != "group" || strings.HasPrefix(got.Description, ErrInvalidRequest.Error()) {
+		t.Errorf("Expected the group violation without the sentinel, got %+v", got)
+	}
+	if !strings.HasPrefix(err.Error(), "invalid request: timeout: must be non-negative, got -1; group: ") {
+		t.Errorf("Expected every violation in the message, got %q", err)
+	}
+}
+
+func TestCreateRunnerViolations(t *testing.T) {
+	req := &CreateRunnerRequest{
+		Name:           strings.Repeat("x", maxRunnerNameLength+1),
+		DefaultShell:   "bash -l",
+		DefaultWorkdir: "workspace",
+		Group:          "-bad-",
+		Workspace:      &WorkspaceConfig{S3FSCPU: "4"},
+	}
+	violations := CreateRunnerViolations(req, &SidecarConfig{MaxCPU: "2"})
+
+	var fields []string
+	for _, violation := range violations {
+		fields = append(fields, violation.Field)
+	}
+	if fmt.Sprint(fields) != "[name workspace default_shell default_workdir group]" {
+		t.Errorf("Expected a violation per field, got %v", violations)
+	}
+
+	if violations := CreateRunnerViolations(req, nil); len(violations) != 4 {
+		t.Errorf("Expected no server maximum without a sidecar config, got %v", violations)
+	}
+}
+
+func TestExecuteCommandArgvViolations(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *ExecuteCommandRequest
+		expected string
+	}{
+		{"Command", &ExecuteCommandRequest{Command: "ls -la"}, "[]"},
+		{"Argv", &ExecuteCommandRequest{Argv: []string{"ls", "-la"}}, "[]"},
+		{"Argv without a shell", &ExecuteCommandRequest{Argv: []string{"ls"}, Shell: ExecShellNone}, "[]"},
+		{"Neither", &ExecuteCommandRequest{}, "[command]"},
+		{"Both", &ExecuteCommandRequest{Command: "ls", Argv: []string{"ls"}}, "[argv]"},
+		{"Empty program", &ExecuteCommandRequest{Argv: []string{"", "-la"}}, "[argv]"},
+		{"Command without a shell", &ExecuteCommandRequest{Command: "ls", Shell: ExecShellNone}, "[shell]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.TruncatePolicy = TruncatePolicyKill
+			var fields []string
+			for _, violation := range ExecuteCommandViolations(tt.req, false) {
+				fields = append(fields, violation.Field)
+			}
+			if got := fmt.Sprint(fields); got != tt.expected {
+				t.Errorf("Expected violations of %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestListOptionsViolations(t *testing.T) {
+	violations := ListOptionsViolations(&ListOptions{Limit: -1, Labels: map[string]string{"team": "a b", "bad key!": "x"}})
+	if len(violations) != 3 {
+		t.Errorf("Expected limit and two label violations, got %v", violations)
+	}
+	if violations := ListOptionsViolations(&ListOptions{Group: "ci", Labels: map[string]string{"team": "infra"}, OrderBy: "age"}); len(violations) != 0 {
+		t.Errorf("Expected valid options to pass, got %v", violations)
+	}
+	if violations := ListOptionsViolations(&ListOptions{OrderBy: "size"}); len(violations) != 1 || violations[0].Field != "order_by" {
+		t.Errorf("Expected an order_by violation, got %v", violations)
+	}
+}
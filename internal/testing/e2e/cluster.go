@@ -0,0 +1,167 @@
+// Package e2e runs grad's integration tests against a real API server: a control plane it
+// starts from envtest's etcd and kube-apiserver binaries, or an existing kind cluster. Tests
+// behind the integration build tag call Start, which skips them unless GRAD_E2E_CLUSTER picks
+// one of the two.
+//
+// envtest has no scheduler or kubelet, so runner pods never run there. MarkPodReady stands in
+// for the kubelet so status transitions can still be exercised, and RequirePods skips the steps
+// that need a container, such as exec. On kind, runners use a stub image built by
+// `make test-e2e` from devenv/e2e/stub-runner.
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Environment variables configuring the cluster tests run against
+const (
+	// ClusterEnv picks the cluster: ClusterEnvtest or ClusterKind. Tests skip if it is unset.
+	ClusterEnv = "GRAD_E2E_CLUSTER"
+	// KubeContextEnv is the kubeconfig context of the kind cluster (DefaultKubeContext if unset)
+	KubeContextEnv = "GRAD_E2E_KUBE_CONTEXT"
+	// RunnerImageEnv overrides the runner image on kind (DefaultRunnerImage if unset)
+	RunnerImageEnv = "GRAD_E2E_RUNNER_IMAGE"
+	// AssetsEnv is the directory holding envtest's etcd and kube-apiserver binaries, as
+	// `setup-envtest use -p path` prints it
+	AssetsEnv = "KUBEBUILDER_ASSETS"
+)
+
+const (
+	// ClusterEnvtest starts a local etcd and kube-apiserver for the test binary
+	ClusterEnvtest = "envtest"
+	// ClusterKind uses an existing kind cluster from the kubeconfig
+	ClusterKind = "kind"
+
+	// DefaultKubeContext is the context `kind create cluster --name grad` adds
+	DefaultKubeContext = "kind-grad"
+	// DefaultRunnerImage is the stub runner image `make test-e2e` builds and loads into kind
+	DefaultRunnerImage = "grad-e2e-runner:e2e"
+	// PauseImage is the runner image on envtest, where it is never pulled
+	PauseImage = "registry.k8s.io/pause:3.10"
+)
+
+// Cluster is an API server tests run grad's service layer against
+type Cluster struct {
+	// Kind is ClusterEnvtest or ClusterKind
+	Kind      string
+	Config    *rest.Config
+	Clientset kubernetes.Interface
+	// RunnerImage is the image runner pods should use on this cluster
+	RunnerImage string
+	// RunsPods is false on envtest, which has no scheduler or kubelet
+	RunsPods bool
+}
+
+// Start returns the cluster picked by GRAD_E2E_CLUSTER, skipping the test if it is unset. An
+// envtest control plane is stopped when the test ends.
+func Start(t testing.TB) *Cluster {
+	t.Helper()
+
+	kind := os.Getenv(ClusterEnv)
+	var cluster *Cluster
+	switch kind {
+	case "":
+		t.Skipf("Skipping: set %s to %s or %s to run against a cluster", ClusterEnv, ClusterEnvtest, ClusterKind)
+	case ClusterEnvtest:
+		plane, err := startControlPlane(os.Getenv(AssetsEnv), t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to start envtest control plane: %v", err)
+		}
+		t.Cleanup(plane.Stop)
+		cluster = &Cluster{Kind: kind, Config: plane.Config(), RunnerImage: PauseImage}
+	case ClusterKind:
+		config, err := kindConfig(envOr(KubeContextEnv, DefaultKubeContext))
+		if err != nil {
+			t.Fatalf("Failed to load kind cluster config: %v", err)
+		}
+		cluster = &Cluster{Kind: kind, Config: config, RunnerImage: envOr(RunnerImageEnv, DefaultRunnerImage), RunsPods: true}
+	default:
+		t.Fatalf("Unknown %s %q, must be %s or %s", ClusterEnv, kind, ClusterEnvtest, ClusterKind)
+	}
+
+	clientset, err := kubernetes.NewForConfig(cluster.Config)
+	if err != nil {
+		t.Fatalf("Failed to create clientset: %v", err)
+	}
+	cluster.Clientset = clientset
+	return cluster
+}
+
+// Namespace creates a namespace of its own for the test and deletes it when the test ends
+func (c *Cluster) Namespace(t testing.TB) string {
+	t.Helper()
+
+	name := "grad-e2e-" + strings.ToLower(rand.String(6))
+	ctx := context.Background()
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if _, err := c.Clientset.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create namespace %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		c.releasePods(ctx, t, name)
+		if err := c.Clientset.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+			t.Logf("Failed to delete namespace %s: %v", name, err)
+		}
+	})
+	return name
+}
+
+// releasePods removes the finalizers of pods a failed test left behind, so its namespace can go
+func (c *Cluster) releasePods(ctx context.Context, t testing.TB, namespace string) {
+	pods, err := c.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Logf("Failed to list pods left in %s: %v", namespace, err)
+		return
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if len(pod.Finalizers) == 0 {
+			continue
+		}
+		pod.Finalizers = nil
+		if _, err := c.Clientset.CoreV1().Pods(namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+			t.Logf("Failed to remove the finalizers of pod %s: %v", pod.Name, err)
+		}
+	}
+}
+
+// RequirePods skips the rest of the test unless pods actually run on the cluster
+func (c *Cluster) RequirePods(t testing.TB) {
+	t.Helper()
+	if !c.RunsPods {
+		t.Skipf("Skipping: pods don't run on %s", c.Kind)
+	}
+}
+
+// kindConfig loads the client config of a context from the kubeconfig, honouring KUBECONFIG
+func kindConfig(context string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("context %s: %w", context, err)
+	}
+	return config, nil
+}
+
+// envOr returns the value of an environment variable, or fallback if it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
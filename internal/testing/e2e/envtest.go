@@ -0,0 +1,226 @@
+package e2e
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// controlPlaneStartTimeout bounds how long etcd and kube-apiserver may take to become ready
+const controlPlaneStartTimeout = time.Minute
+
+// controlPlane is an etcd and kube-apiserver started from envtest's binaries. It has no
+// scheduler, controller manager or kubelet: objects are stored and validated, nothing more.
+type controlPlane struct {
+	etcd      *process
+	apiServer *process
+	host      string
+	token     string
+}
+
+// startControlPlane starts etcd and kube-apiserver from the binaries in assets, keeping their
+// data, certificates and logs in dir, and waits until the API server is ready
+func startControlPlane(assets, dir string) (*controlPlane, error) {
+	if assets == "" {
+		return nil, fmt.Errorf("%s is not set, point it at envtest's binaries with `setup-envtest use -p path`", AssetsEnv)
+	}
+
+	ports, err := freePorts(3)
+	if err != nil {
+		return nil, err
+	}
+	etcdURL := "http://127.0.0.1:" + strconv.Itoa(ports[0])
+	peerURL := "http://127.0.0.1:" + strconv.Itoa(ports[1])
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	tokenFile := filepath.Join(dir, "tokens.csv")
+	if err := os.WriteFile(tokenFile, []byte(token+",grad-e2e,grad-e2e,system:masters\n"), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write token file: %w", err)
+	}
+	keyFile := filepath.Join(dir, "sa.key")
+	if err := writeServiceAccountKey(keyFile); err != nil {
+		return nil, err
+	}
+
+	plane := &controlPlane{
+		host:  "https://127.0.0.1:" + strconv.Itoa(ports[2]),
+		token: token,
+	}
+
+	plane.etcd, err = startProcess(filepath.Join(assets, "etcd"), filepath.Join(dir, "etcd.log"),
+		"--data-dir="+filepath.Join(dir, "etcd"),
+		"--listen-client-urls="+etcdURL,
+		"--advertise-client-urls="+etcdURL,
+		"--listen-peer-urls="+peerURL,
+		"--initial-advertise-peer-urls="+peerURL,
+		"--initial-cluster=default="+peerURL,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	plane.apiServer, err = startProcess(filepath.Join(assets, "kube-apiserver"), filepath.Join(dir, "kube-apiserver.log"),
+		"--etcd-servers="+etcdURL,
+		"--bind-address=127.0.0.1",
+		"--secure-port="+strconv.Itoa(ports[2]),
+		"--cert-dir="+filepath.Join(dir, "certs"),
+		"--token-auth-file="+tokenFile,
+		"--authorization-mode=RBAC",
+		"--service-account-issuer=https://kubernetes.default.svc",
+		"--service-account-key-file="+keyFile,
+		"--service-account-signing-key-file="+keyFile,
+		"--service-cluster-ip-range=10.0.0.0/24",
+		"--allow-privileged=true",
+		// Without a controller manager, namespaces get no default service account for it to check
+		"--disable-admission-plugins=ServiceAccount",
+	)
+	if err != nil {
+		plane.Stop()
+		return nil, err
+	}
+
+	if err := plane.waitReady(); err != nil {
+		plane.Stop()
+		return nil, fmt.Errorf("%w (logs in %s)", err, dir)
+	}
+	return plane, nil
+}
+
+// Config returns a client config authenticating as a cluster admin. The API server's
+// certificate is self-signed, so it isn't verified.
+func (p *controlPlane) Config() *rest.Config {
+	return &rest.Config{
+		Host:            p.host,
+		BearerToken:     p.token,
+		TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+		QPS:             100,
+		Burst:           200,
+	}
+}
+
+// Stop kills kube-apiserver and etcd and waits for them to exit
+func (p *controlPlane) Stop() {
+	for _, proc := range []*process{p.apiServer, p.etcd} {
+		if proc != nil {
+			proc.stop()
+		}
+	}
+}
+
+// waitReady polls the API server's /readyz until it answers ok
+func (p *controlPlane) waitReady() error {
+	clientset, err := kubernetes.NewForConfig(p.Config())
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), controlPlaneStartTimeout)
+	defer cancel()
+	var lastErr error
+	for {
+		_, lastErr = clientset.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+		if lastErr == nil {
+			return nil
+		}
+		select {
+		case <-p.apiServer.done:
+			return errors.New("kube-apiserver exited")
+		case <-ctx.Done():
+			return fmt.Errorf("kube-apiserver not ready after %s: %w", controlPlaneStartTimeout, lastErr)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// process is a started control plane binary; done is closed once it has exited
+type process struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+// stop kills the process and waits for it to exit
+func (p *process) stop() {
+	_ = p.cmd.Process.Kill()
+	<-p.done
+}
+
+// startProcess starts a binary with its output going to logFile
+func startProcess(binary, logFile string, args ...string) (*process, error) {
+	log, err := os.Create(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file: %w", err)
+	}
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	if err := cmd.Start(); err != nil {
+		log.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", filepath.Base(binary), err)
+	}
+	proc := &process{cmd: cmd, done: make(chan struct{})}
+	go func() {
+		defer close(proc.done)
+		_ = cmd.Wait()
+		log.Close()
+	}()
+	return proc, nil
+}
+
+// freePorts returns n distinct ports nothing listens on right now
+func freePorts(n int) ([]int, error) {
+	ports := make([]int, 0, n)
+	listeners := make([]net.Listener, 0, n)
+	defer func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}()
+	for range n {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find a free port: %w", err)
+		}
+		listeners = append(listeners, listener)
+		ports = append(ports, listener.Addr().(*net.TCPAddr).Port)
+	}
+	return ports, nil
+}
+
+// randomToken returns a bearer token for the admin user
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// writeServiceAccountKey writes a fresh RSA key kube-apiserver signs service account tokens with
+func writeServiceAccountKey(path string) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return fmt.Errorf("failed to generate service account key: %w", err)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		return fmt.Errorf("failed to write service account key: %w", err)
+	}
+	return nil
+}
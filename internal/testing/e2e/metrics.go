@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Metrics is a scrape of the registry grad's metrics were registered with, as Prometheus
+// would see it on /metrics
+type Metrics struct {
+	families map[string]*dto.MetricFamily
+}
+
+// Scrape gathers the current value of every metric in gatherer
+func Scrape(t testing.TB, gatherer prometheus.Gatherer) *Metrics {
+	t.Helper()
+
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	m := &Metrics{families: make(map[string]*dto.MetricFamily, len(families))}
+	for _, family := range families {
+		m.families[family.GetName()] = family
+	}
+	return m
+}
+
+// Counter returns the value of the counter name with labels, 0 if it wasn't incremented yet
+func (m *Metrics) Counter(name string, labels map[string]string) float64 {
+	if metric := m.find(name, labels); metric != nil {
+		return metric.GetCounter().GetValue()
+	}
+	return 0
+}
+
+// Gauge returns the value of the gauge name with labels, 0 if it wasn't set yet
+func (m *Metrics) Gauge(name string, labels map[string]string) float64 {
+	if metric := m.find(name, labels); metric != nil {
+		return metric.GetGauge().GetValue()
+	}
+	return 0
+}
+
+// HistogramCount returns how many samples the histogram name with labels observed. A nil
+// labels sums all its series.
+func (m *Metrics) HistogramCount(name string, labels map[string]string) uint64 {
+	family := m.families[name]
+	if family == nil {
+		return 0
+	}
+	var count uint64
+	for _, metric := range family.GetMetric() {
+		if labels == nil || hasLabels(metric, labels) {
+			count += metric.GetHistogram().GetSampleCount()
+		}
+	}
+	return count
+}
+
+// find returns the series of name with exactly labels, nil if there is none
+func (m *Metrics) find(name string, labels map[string]string) *dto.Metric {
+	family := m.families[name]
+	if family == nil {
+		return nil
+	}
+	for _, metric := range family.GetMetric() {
+		if len(metric.GetLabel()) == len(labels) && hasLabels(metric, labels) {
+			return metric
+		}
+	}
+	return nil
+}
+
+// hasLabels reports whether metric carries all of labels (pure function)
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	matched := 0
+	for _, pair := range metric.GetLabel() {
+		if value, ok := labels[pair.GetName()]; ok {
+			if value != pair.GetValue() {
+				return false
+			}
+			matched++
+		}
+	}
+	return matched == len(labels)
+}
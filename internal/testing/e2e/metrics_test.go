@@ -0,0 +1,41 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/strrl/gra/internal/grad/metrics"
+)
+
+func TestScrape(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m, err := metrics.New(registry)
+	if err != nil {
+		t.Fatalf("metrics.New failed: %v", err)
+	}
+	m.RunnerRestartsTotal.WithLabelValues("restarted").Add(2)
+	m.StaleImageRunners.Set(3)
+	m.RunnerProvisioningDuration.WithLabelValues("small", "cached").Observe(4)
+	m.RunnerProvisioningDuration.WithLabelValues("small", "pulled").Observe(40)
+
+	scraped := Scrape(t, registry)
+	if got := scraped.Counter("runner_restarts_total", map[string]string{"result": "restarted"}); got != 2 {
+		t.Errorf("Expected counter 2, got %v", got)
+	}
+	if got := scraped.Counter("runner_restarts_total", map[string]string{"result": "gave_up"}); got != 0 {
+		t.Errorf("Expected an unseen series to be 0, got %v", got)
+	}
+	if got := scraped.Gauge("runners_stale_image", nil); got != 3 {
+		t.Errorf("Expected gauge 3, got %v", got)
+	}
+	if got := scraped.HistogramCount("runner_provisioning_duration_seconds", map[string]string{"image": "cached"}); got != 1 {
+		t.Errorf("Expected 1 sample for cached images, got %d", got)
+	}
+	if got := scraped.HistogramCount("runner_provisioning_duration_seconds", nil); got != 2 {
+		t.Errorf("Expected 2 samples in total, got %d", got)
+	}
+	if got := scraped.HistogramCount("no_such_metric", nil); got != 0 {
+		t.Errorf("Expected 0 samples of a missing metric, got %d", got)
+	}
+}
@@ -0,0 +1,125 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Timeouts of the wait helpers
+const (
+	// PodTimeout bounds waiting on a pod, long enough for kind to start the stub runner image
+	PodTimeout = 2 * time.Minute
+	// PollInterval is how often the wait helpers check again
+	PollInterval = 250 * time.Millisecond
+)
+
+// Eventually polls condition until it returns true, failing the test with what if it doesn't
+// within timeout. An error from condition fails the test right away.
+func Eventually(t testing.TB, timeout time.Duration, what string, condition func(ctx context.Context) (bool, error)) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for {
+		done, err := condition(ctx)
+		if err != nil {
+			t.Fatalf("Failed waiting for %s: %v", what, err)
+		}
+		if done {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out after %s waiting for %s", timeout, what)
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForPodPhase waits until a pod reaches phase and returns it
+func WaitForPodPhase(t testing.TB, clientset kubernetes.Interface, namespace, name string, phase corev1.PodPhase) *corev1.Pod {
+	t.Helper()
+
+	var pod *corev1.Pod
+	Eventually(t, PodTimeout, fmt.Sprintf("pod %s to be %s", name, phase), func(ctx context.Context) (bool, error) {
+		var err error
+		pod, err = clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return pod.Status.Phase == phase, nil
+	})
+	return pod
+}
+
+// WaitForPodGone waits until a pod no longer exists, which for a runner pod means its
+// finalizer was removed
+func WaitForPodGone(t testing.TB, clientset kubernetes.Interface, namespace, name string) {
+	t.Helper()
+
+	Eventually(t, PodTimeout, fmt.Sprintf("pod %s to be gone", name), func(ctx context.Context) (bool, error) {
+		_, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// MarkPodReady does what the kubelet would once a pod's containers started: it sets the pod
+// running and ready. envtest has no kubelet, so this is how its pods get there.
+func MarkPodReady(t testing.TB, clientset kubernetes.Interface, namespace, name string) {
+	t.Helper()
+
+	ctx := context.Background()
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get pod %s: %v", name, err)
+	}
+	pod.Status = readyPodStatus(pod, metav1.Now())
+	if _, err := clientset.CoreV1().Pods(namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to mark pod %s ready: %v", name, err)
+	}
+}
+
+// readyPodStatus returns the status of pod once all its containers run and are ready
+// (pure function)
+func readyPodStatus(pod *corev1.Pod, now metav1.Time) corev1.PodStatus {
+	status := corev1.PodStatus{
+		Phase:     corev1.PodRunning,
+		PodIP:     "10.244.0.10",
+		PodIPs:    []corev1.PodIP{{IP: "10.244.0.10"}},
+		HostIP:    "172.18.0.2",
+		StartTime: &now,
+	}
+	for _, conditionType := range []corev1.PodConditionType{corev1.PodScheduled, corev1.PodInitialized, corev1.ContainersReady, corev1.PodReady} {
+		status.Conditions = append(status.Conditions, corev1.PodCondition{
+			Type:               conditionType,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: now,
+		})
+	}
+	for _, container := range pod.Spec.Containers {
+		status.ContainerStatuses = append(status.ContainerStatuses, corev1.ContainerStatus{
+			Name:    container.Name,
+			Image:   container.Image,
+			ImageID: container.Image,
+			Ready:   true,
+			Started: &[]bool{true}[0],
+			State:   corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: now}},
+		})
+	}
+	return status
+}
@@ -0,0 +1,43 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMarkPodReady(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "runner-1", Namespace: "e2e"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "runner", Image: "pause"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	})
+
+	MarkPodReady(t, clientset, "e2e", "runner-1")
+	pod := WaitForPodPhase(t, clientset, "e2e", "runner-1", corev1.PodRunning)
+
+	ready := false
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	if !ready {
+		t.Errorf("Expected the pod to be ready, got conditions %v", pod.Status.Conditions)
+	}
+	if pod.Status.PodIP == "" {
+		t.Error("Expected the pod to get an IP")
+	}
+	if len(pod.Status.ContainerStatuses) != 1 || !pod.Status.ContainerStatuses[0].Ready {
+		t.Errorf("Expected the runner container to be ready, got %v", pod.Status.ContainerStatuses)
+	}
+
+	if err := clientset.CoreV1().Pods("e2e").Delete(ctx, "runner-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete pod: %v", err)
+	}
+	WaitForPodGone(t, clientset, "e2e", "runner-1")
+}
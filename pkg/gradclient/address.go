@@ -0,0 +1,114 @@
+package gradclient
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPort is used for plaintext addresses without a port
+	DefaultPort = "9090"
+	// DefaultTLSPort is used for grpcs:// addresses without a port
+	DefaultTLSPort = "443"
+)
+
+// Target is a server address normalized for dialing
+type Target struct {
+	// Address is the gRPC dial target, e.g. "grad.internal:9090" or "unix:///run/grad.sock"
+	Address string
+	// TLS is set for grpcs:// addresses
+	TLS bool
+}
+
+// ParseServerAddress normalizes the address forms accepted by --server (pure function):
+//
+//	host, host:port          plaintext, default port 9090
+//	grpc://host[:port]       plaintext, default port 9090
+//	grpcs://host[:port]      TLS, default port 443
+//	dns:///host[:port]       passed to the gRPC DNS resolver, default port 9090
+//	unix:///path/to/sock     passed through unchanged
+func ParseServerAddress(raw string) (Target, error) {
+	address := strings.TrimSpace(raw)
+	if address == "" {
+		return Target{}, fmt.Errorf("server address is empty")
+	}
+
+	scheme, rest, hasScheme := strings.Cut(address, "://")
+	if !hasScheme {
+		// unix:path is the relative form of a unix socket target
+		if strings.HasPrefix(address, "unix:") {
+			return Target{Address: address}, nil
+		}
+		hostPort, err := withDefaultPort(address, DefaultPort)
+		if err != nil {
+			return Target{}, err
+		}
+		return Target{Address: hostPort}, nil
+	}
+
+	switch strings.ToLower(scheme) {
+	case "unix", "unix-abstract":
+		return Target{Address: address}, nil
+	case "dns":
+		// dns://[authority]/host[:port]; the authority names the DNS server, not grad
+		authority, endpoint, _ := strings.Cut(rest, "/")
+		hostPort, err := withDefaultPort(endpoint, DefaultPort)
+		if err != nil {
+			return Target{}, err
+		}
+		return Target{Address: "dns://" + authority + "/" + hostPort}, nil
+	case "grpc", "grpcs":
+		u, err := url.Parse(address)
+		if err != nil {
+			return Target{}, fmt.Errorf("invalid server address %q: %w", raw, err)
+		}
+		if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+			return Target{}, fmt.Errorf("invalid server address %q: only host and port are allowed", raw)
+		}
+		tls := u.Scheme == "grpcs"
+		defaultPort := DefaultPort
+		if tls {
+			defaultPort = DefaultTLSPort
+		}
+		hostPort, err := withDefaultPort(u.Host, defaultPort)
+		if err != nil {
+			return Target{}, err
+		}
+		return Target{Address: hostPort, TLS: tls}, nil
+	case "http", "https":
+		return Target{}, fmt.Errorf("unsupported scheme %q in server address %q: grad speaks gRPC, use grpc://host:port or grpcs://host for TLS", scheme, raw)
+	default:
+		return Target{}, fmt.Errorf("unsupported scheme %q in server address %q (supported: grpc, grpcs, dns, unix)", scheme, raw)
+	}
+}
+
+// withDefaultPort returns host:port, adding defaultPort when address has no port (pure function)
+func withDefaultPort(address, defaultPort string) (string, error) {
+	if address == "" {
+		return "", fmt.Errorf("server address has no host")
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		// No port; bare IPv6 addresses have colons but no brackets
+		host = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+		if strings.Contains(host, "]") || strings.Contains(host, "[") {
+			return "", fmt.Errorf("invalid server address %q", address)
+		}
+		if strings.Contains(host, ":") && net.ParseIP(host) == nil {
+			return "", fmt.Errorf("invalid server address %q", address)
+		}
+		port = defaultPort
+	}
+	if host == "" {
+		return "", fmt.Errorf("server address %q has no host", address)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid port %q in server address %q", port, address)
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
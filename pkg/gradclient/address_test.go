@@ -0,0 +1,92 @@
+package gradclient
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseServerAddress(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Target
+	}{
+		{"localhost", Target{Address: "localhost:9090"}},
+		{"grad.internal:443", Target{Address: "grad.internal:443"}},
+		{"  grad.internal:9091 ", Target{Address: "grad.internal:9091"}},
+		{"10.0.0.5", Target{Address: "10.0.0.5:9090"}},
+		{"::1", Target{Address: "[::1]:9090"}},
+		{"[::1]:9091", Target{Address: "[::1]:9091"}},
+		{"grpc://grad.internal", Target{Address: "grad.internal:9090"}},
+		{"grpc://grad.internal:8080/", Target{Address: "grad.internal:8080"}},
+		{"grpcs://grad.internal.example.com", Target{Address: "grad.internal.example.com:443", TLS: true}},
+		{"grpcs://grad.internal.example.com:8443", Target{Address: "grad.internal.example.com:8443", TLS: true}},
+		{"GRPCS://grad.internal", Target{Address: "grad.internal:443", TLS: true}},
+		{"grpcs://[::1]", Target{Address: "[::1]:443", TLS: true}},
+		{"dns:///grad.internal:9091", Target{Address: "dns:///grad.internal:9091"}},
+		{"dns:///grad.internal", Target{Address: "dns:///grad.internal:9090"}},
+		{"dns://8.8.8.8/grad.internal", Target{Address: "dns://8.8.8.8/grad.internal:9090"}},
+		{"unix:///run/grad.sock", Target{Address: "unix:///run/grad.sock"}},
+		{"unix:grad.sock", Target{Address: "unix:grad.sock"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseServerAddress(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseServerAddressRejected(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectMessage string
+	}{
+		{"", "empty"},
+		{"https://grad.internal.example.com", "grpcs://"},
+		{"http://grad.internal:9090", "grpc://"},
+		{"ftp://grad.internal", "unsupported scheme"},
+		{"grad.internal:http", "invalid port"},
+		{"grad.internal:70000", "invalid port"},
+		{"grad.internal:0", "invalid port"},
+		{"grpc://", "no host"},
+		{"grpc://:9090", "no host"},
+		{"grpcs://grad.internal/api", "only host and port"},
+		{"grpc://user@grad.internal", "only host and port"},
+		{"dns:///", "no host"},
+		{"grad:internal:9090", "invalid server address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseServerAddress(tt.input)
+			if err == nil {
+				t.Fatalf("Expected an error, got %+v", got)
+			}
+			if !strings.Contains(err.Error(), tt.expectMessage) {
+				t.Errorf("Expected error to mention %q, got: %v", tt.expectMessage, err)
+			}
+		})
+	}
+}
+
+func TestNewClientRejectsUnsupportedScheme(t *testing.T) {
+	_, err := NewClient(&Config{ServerAddress: "https://grad.internal.example.com"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Errorf("Expected an unsupported scheme error, got %v", err)
+	}
+}
+
+func TestTransportCredentials(t *testing.T) {
+	if protocol := transportCredentials(Target{TLS: true}).Info().SecurityProtocol; protocol != "tls" {
+		t.Errorf("Expected TLS credentials for grpcs targets, got %q", protocol)
+	}
+	if protocol := transportCredentials(Target{}).Info().SecurityProtocol; protocol != "insecure" {
+		t.Errorf("Expected insecure credentials for plaintext targets, got %q", protocol)
+	}
+}
@@ -0,0 +1,57 @@
+package gradclient
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc/metadata"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// AdminTokenEnv is the environment variable gractl reads the admin token from
+const AdminTokenEnv = "GRAD_ADMIN_TOKEN"
+
+// WithAdminToken attaches the admin token AdminService calls authenticate with to ctx
+func WithAdminToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// SetRunnerImage changes the images of new runners, or reverts the last change with
+// req.Revert, authenticating with the server's admin token
+func (c *Client) SetRunnerImage(ctx context.Context, adminToken string, req *gradv1.SetRunnerImageRequest) (*gradv1.SetRunnerImageResponse, error) {
+	return c.adminService.SetRunnerImage(WithAdminToken(ctx, adminToken), req)
+}
+
+// ReloadConfig has the server reload its configuration as on SIGHUP, authenticating with its
+// admin token
+func (c *Client) ReloadConfig(ctx context.Context, adminToken string) (*gradv1.ReloadConfigResponse, error) {
+	return c.adminService.ReloadConfig(WithAdminToken(ctx, adminToken), &gradv1.ReloadConfigRequest{})
+}
+
+// TailServerLogs passes grad's most recent log records of at least req.MinLevel to handle,
+// oldest first, then with req.Follow new ones as they are logged until ctx ends. Returning an
+// error from handle stops reading the stream.
+func (c *Client) TailServerLogs(ctx context.Context, adminToken string, req *gradv1.TailServerLogsRequest, handle func(*gradv1.ServerLogRecord) error) error {
+	stream, err := c.adminService.TailServerLogs(WithAdminToken(ctx, adminToken), req)
+	if err != nil {
+		return err
+	}
+	for {
+		record, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := handle(record); err != nil {
+			return err
+		}
+	}
+}
+
+// GetServerInfo returns the server's build, limits and their current usage
+func (c *Client) GetServerInfo(ctx context.Context) (*gradv1.GetServerInfoResponse, error) {
+	return c.runnerService.GetServerInfo(ctx, &gradv1.GetServerInfoRequest{})
+}
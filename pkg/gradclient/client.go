@@ -0,0 +1,201 @@
+// Package gradclient is a Go client for the grad API. It wraps the generated gRPC clients
+// with typed methods for creating runners, running commands in them and waiting for them to
+// become ready, and helpers for the credentials runners are created with. gractl is built on it.
+//
+//	c, err := gradclient.NewClient(&gradclient.Config{ServerAddress: "grpc://grad.internal"})
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//
+//	runner, err := c.CreateRunner(ctx, gradclient.WithName("build"))
+//	if err != nil {
+//		return err
+//	}
+//	runner, err = c.WaitForRunnerReady(ctx, runner.Id, 0)
+//	...
+//	exit, err := c.Exec(ctx, &gradv1.ExecuteCommandRequest{RunnerId: runner.Id, Command: "make"},
+//		gradclient.WriteOutput(os.Stdout, os.Stderr))
+package gradclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// Client wraps the gRPC client connection
+type Client struct {
+	conn           *grpc.ClientConn
+	runnerService  gradv1.RunnerServiceClient
+	executeService gradv1.ExecuteServiceClient
+	adminService   gradv1.AdminServiceClient
+	// resumeTimeout bounds reattaching to a resumable command; see DefaultResumeTimeout
+	resumeTimeout time.Duration
+}
+
+// DefaultDialTimeout bounds how long NewClient waits for the server to become reachable
+const DefaultDialTimeout = 5 * time.Second
+
+// Config holds client configuration
+type Config struct {
+	ServerAddress string
+	// AddressSource describes where ServerAddress came from (flag, config file, ...) for diagnostics
+	AddressSource string
+	Timeout       time.Duration
+	DialTimeout   time.Duration
+}
+
+// UnreachableError reports that the server could not be reached within the dial timeout
+type UnreachableError struct {
+	Address string
+	Source  string
+	State   connectivity.State
+	Timeout time.Duration
+}
+
+func (e *UnreachableError) Error() string {
+	source := e.Source
+	if source == "" {
+		source = "unknown source"
+	}
+
+	reason := fmt.Sprintf("connection state %s", e.State)
+	if e.State != connectivity.TransientFailure && e.State != connectivity.Shutdown {
+		reason = fmt.Sprintf("timed out after %s", e.Timeout)
+	}
+
+	return fmt.Sprintf("cannot reach grad server at %s (address from %s, %s)\n"+
+		"hint: check that grad is running and that --server or server.address in .gractl.toml is correct",
+		e.Address, source, reason)
+}
+
+// DefaultConfig returns default client configuration
+func DefaultConfig() *Config {
+	serverAddr := os.Getenv("GRAD_SERVER")
+	if serverAddr == "" {
+		serverAddr = "localhost:9090"
+	}
+
+	return &Config{
+		ServerAddress: serverAddr,
+		Timeout:       30 * time.Second,
+		DialTimeout:   DefaultDialTimeout,
+	}
+}
+
+// NewClient creates a new gRPC client
+func NewClient(cfg *Config) (*Client, error) {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+
+	target, err := ParseServerAddress(cfg.ServerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(target.Address,
+		grpc.WithTransportCredentials(transportCredentials(target)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection to server %s: %w", cfg.ServerAddress, err)
+	}
+
+	// grpc.NewClient is lazy; check connectivity up front so commands fail before doing any work
+	if err := waitForReady(conn, cfg); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return NewFromConn(conn), nil
+}
+
+// NewFromConn creates a client using an existing connection, e.g. one with custom
+// credentials or a test dialer. Close closes conn.
+func NewFromConn(conn *grpc.ClientConn) *Client {
+	return &Client{
+		conn:           conn,
+		runnerService:  gradv1.NewRunnerServiceClient(conn),
+		executeService: gradv1.NewExecuteServiceClient(conn),
+		adminService:   gradv1.NewAdminServiceClient(conn),
+		resumeTimeout:  DefaultResumeTimeout,
+	}
+}
+
+// NewFromServices creates a client calling the given service clients instead of a
+// connection, e.g. the in-memory fake of package gradtest. Close does nothing.
+func NewFromServices(runners gradv1.RunnerServiceClient, execute gradv1.ExecuteServiceClient, admin gradv1.AdminServiceClient) *Client {
+	return &Client{
+		runnerService:  runners,
+		executeService: execute,
+		adminService:   admin,
+		resumeTimeout:  DefaultResumeTimeout,
+	}
+}
+
+// transportCredentials returns TLS credentials with the system roots for grpcs:// targets, plaintext otherwise
+func transportCredentials(target Target) credentials.TransportCredentials {
+	if target.TLS {
+		return credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	return insecure.NewCredentials()
+}
+
+// waitForReady connects and waits until the connection is ready, failing fast on a transient failure
+func waitForReady(conn *grpc.ClientConn, cfg *Config) error {
+	timeout := cfg.DialTimeout
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			return &UnreachableError{Address: cfg.ServerAddress, Source: cfg.AddressSource, State: state, Timeout: timeout}
+		}
+
+		if !conn.WaitForStateChange(ctx, state) {
+			return &UnreachableError{Address: cfg.ServerAddress, Source: cfg.AddressSource, State: state, Timeout: timeout}
+		}
+	}
+}
+
+// Close closes the client connection
+func (c *Client) Close() error {
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// RunnerService returns the runner service client
+func (c *Client) RunnerService() gradv1.RunnerServiceClient {
+	return c.runnerService
+}
+
+// ExecuteService returns the execute service client
+func (c *Client) ExecuteService() gradv1.ExecuteServiceClient {
+	return c.executeService
+}
+
+// AdminService returns the admin service client
+func (c *Client) AdminService() gradv1.AdminServiceClient {
+	return c.adminService
+}
@@ -0,0 +1,103 @@
+package gradclient
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// closedPortAddress returns an address nothing is listening on
+func closedPortAddress(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+func TestNewClientClosedPort(t *testing.T) {
+	addr := closedPortAddress(t)
+
+	start := time.Now()
+	_, err := NewClient(&Config{
+		ServerAddress: addr,
+		AddressSource: "--server flag",
+		DialTimeout:   2 * time.Second,
+	})
+	if err == nil {
+		t.Fatal("Expected error connecting to a closed port")
+	}
+
+	var unreachable *UnreachableError
+	if !errors.As(err, &unreachable) {
+		t.Fatalf("Expected UnreachableError, got %T: %v", err, err)
+	}
+	if !strings.Contains(err.Error(), addr) || !strings.Contains(err.Error(), "--server flag") {
+		t.Errorf("Expected error to name the address and its source, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "hint:") {
+		t.Errorf("Expected error to include a hint, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected connection refused to fail fast, took %s", elapsed)
+	}
+}
+
+func TestNewClientNonGRPCServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer lis.Close()
+
+	// Accept TCP connections but never speak HTTP/2
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+			conn.Close()
+		}
+	}()
+
+	_, err = NewClient(&Config{
+		ServerAddress: lis.Addr().String(),
+		AddressSource: "config file .gractl.toml",
+		DialTimeout:   2 * time.Second,
+	})
+	if err == nil {
+		t.Fatal("Expected error connecting to a non-gRPC server")
+	}
+	if !strings.Contains(err.Error(), "config file .gractl.toml") {
+		t.Errorf("Expected error to name the address source, got: %v", err)
+	}
+}
+
+func TestNewClientReachableServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := grpc.NewServer()
+	go server.Serve(lis)
+	defer server.Stop()
+
+	c, err := NewClient(&Config{
+		ServerAddress: lis.Addr().String(),
+		DialTimeout:   2 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Expected to connect, got: %v", err)
+	}
+	c.Close()
+}
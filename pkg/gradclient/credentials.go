@@ -0,0 +1,74 @@
+package gradclient
+
+import (
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/sshkey"
+)
+
+// Environment variables runners read their credentials from
+const (
+	// PublicKeyEnv holds the SSH public key allowed to log in to the runner
+	PublicKeyEnv = "PUBLIC_KEY"
+
+	AccessKeyIDEnv     = "AWS_ACCESS_KEY_ID"
+	SecretAccessKeyEnv = "AWS_SECRET_ACCESS_KEY"
+	SessionTokenEnv    = "AWS_SESSION_TOKEN"
+)
+
+// S3Workspace is an S3 bucket mounted into runners at /workspace/dataset, together with
+// the credentials to mount it with
+type S3Workspace struct {
+	Bucket   string
+	Endpoint string
+	Prefix   string
+	Region   string
+	ReadOnly bool
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Proto returns the workspace config of a request, or nil without a bucket
+func (w S3Workspace) Proto() *gradv1.WorkspaceConfig {
+	if w.Bucket == "" {
+		return nil
+	}
+	return &gradv1.WorkspaceConfig{
+		Bucket:   w.Bucket,
+		Endpoint: w.Endpoint,
+		Prefix:   w.Prefix,
+		Region:   w.Region,
+		ReadOnly: w.ReadOnly,
+	}
+}
+
+// CredentialEnv returns the environment variables passing the workspace credentials and
+// sshPublicKey to a runner, leaving out the empty ones. The credentials are passed even
+// without a bucket, for commands that use S3 themselves. (pure function)
+func CredentialEnv(workspace S3Workspace, sshPublicKey string) map[string]string {
+	env := make(map[string]string)
+	for key, value := range map[string]string{
+		AccessKeyIDEnv:     workspace.AccessKeyID,
+		SecretAccessKeyEnv: workspace.SecretAccessKey,
+		SessionTokenEnv:    workspace.SessionToken,
+		PublicKeyEnv:       sshPublicKey,
+	} {
+		if value != "" {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// GetUserSSHPublicKey finds the user's SSH public key in ~/.ssh or, failing that, in the
+// running ssh-agent (see sshkey.DefaultFinder)
+// Returns the public key content or empty string if no key is found
+func GetUserSSHPublicKey() (string, error) {
+	key, _, err := sshkey.DefaultFinder().Find("")
+	if err != nil || key == nil {
+		// No SSH public key found - this is not an error, just return empty string
+		return "", nil
+	}
+	return key.String(), nil
+}
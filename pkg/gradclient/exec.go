@@ -0,0 +1,228 @@
+package gradclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/shellquote"
+)
+
+const (
+	// DefaultResumeTimeout is how long Exec and Execute keep trying to reattach to a resumable
+	// command after its stream breaks without receiving anything
+	DefaultResumeTimeout = time.Minute
+
+	// MaxCommandBytes is the longest command line grad accepts, with argv quoted for the shell;
+	// servers may be configured lower, as GetServerInfo reports. It applies to bootstrap
+	// commands too.
+	MaxCommandBytes = 128 * 1024
+	// MaxCommandArgs is the most words of argv grad accepts
+	MaxCommandArgs = 4096
+
+	// resumeRetryDelay is the wait before reattaching again after the server was unavailable,
+	// doubled up to maxResumeRetryDelay
+	resumeRetryDelay    = 250 * time.Millisecond
+	maxResumeRetryDelay = 5 * time.Second
+)
+
+// ResumeError reports that the stream of a resumable command broke and reattaching to it
+// failed. Only the output up to message Received was passed on; the command may have gone
+// on running, and may have finished, without the rest of it being seen.
+type ResumeError struct {
+	ExecID string
+	// Received is the sequence number of the last message received
+	Received int64
+	// StreamErr broke the stream
+	StreamErr error
+	// Err is why reattaching failed
+	Err error
+}
+
+func (e *ResumeError) Error() string {
+	return fmt.Sprintf("output stream broke (%s) and reattaching to execution %s failed: %v; "+
+		"the output is incomplete, everything after message %d was lost, and the command may still be running or have finished",
+		status.Convert(e.StreamErr).Message(), e.ExecID, e.Err, e.Received)
+}
+
+func (e *ResumeError) Unwrap() error {
+	return e.Err
+}
+
+// CheckCommandSize returns an error if command, or argv quoted into a command line, is larger
+// than grad accepts, so it can be rejected without a round trip
+func CheckCommandSize(command string, argv []string) error {
+	if len(argv) > MaxCommandArgs {
+		return fmt.Errorf("argv has %d words, more than the %d grad accepts", len(argv), MaxCommandArgs)
+	}
+	if len(argv) > 0 {
+		command = shellquote.Join(argv)
+	}
+	if len(command) > MaxCommandBytes {
+		return fmt.Errorf("command is %d bytes, more than the %d grad accepts", len(command), MaxCommandBytes)
+	}
+	return nil
+}
+
+// StreamHandler is called with every message of a command's output stream in order,
+// including the final EXIT message. Returning an error stops reading the stream.
+type StreamHandler func(resp *gradv1.ExecuteCommandStreamResponse) error
+
+// WriteOutput returns a handler writing stdout and stderr messages to stdout and stderr
+func WriteOutput(stdout, stderr io.Writer) StreamHandler {
+	return func(resp *gradv1.ExecuteCommandStreamResponse) error {
+		var err error
+		switch resp.Type {
+		case gradv1.StreamType_STREAM_TYPE_STDOUT:
+			_, err = stdout.Write(resp.Data)
+		case gradv1.StreamType_STREAM_TYPE_STDERR:
+			_, err = stderr.Write(resp.Data)
+		}
+		return err
+	}
+}
+
+// Exec runs a command in the runner req.RunnerId, which must be running, passing its output
+// to handle as it arrives. It returns the EXIT message with the command's exit code, or nil
+// if the stream ended without one. Cancelling ctx cancels the command.
+//
+// With req.Resumable set, a stream that breaks with UNAVAILABLE, e.g. while grad restarts, is
+// reattached with AttachExecution and continues after the last message received; handle sees
+// every message once. If reattaching fails Exec returns a *ResumeError.
+func (c *Client) Exec(ctx context.Context, req *gradv1.ExecuteCommandRequest, handle StreamHandler) (*gradv1.ExecuteCommandStreamResponse, error) {
+	stream, err := c.runnerService.ExecuteCommandStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Resumable {
+		return c.readResumable(ctx, stream, handle)
+	}
+	return readStream(stream, handle)
+}
+
+// Execute is Exec through the ExecuteService, which picks or creates the runner unless
+// req.RunnerId names one; see the Ephemeral and KeepRunner fields of req
+func (c *Client) Execute(ctx context.Context, req *gradv1.ExecuteCommandRequest, handle StreamHandler) (*gradv1.ExecuteCommandStreamResponse, error) {
+	stream, err := c.executeService.ExecuteCommand(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Resumable {
+		return c.readResumable(ctx, stream, handle)
+	}
+	return readStream(stream, handle)
+}
+
+// readStream passes every message of stream to handle and returns the EXIT message
+func readStream(stream grpc.ServerStreamingClient[gradv1.ExecuteCommandStreamResponse], handle StreamHandler) (*gradv1.ExecuteCommandStreamResponse, error) {
+	var exit *gradv1.ExecuteCommandStreamResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return exit, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := handle(resp); err != nil {
+			return nil, err
+		}
+		if resp.Type == gradv1.StreamType_STREAM_TYPE_EXIT {
+			exit = resp
+		}
+	}
+}
+
+// readResumable reads the stream of a command run with Resumable set like readStream. While
+// the stream breaks with UNAVAILABLE it reattaches to the command, retrying with backoff for
+// up to c.resumeTimeout after the last message received.
+func (c *Client) readResumable(ctx context.Context, stream grpc.ServerStreamingClient[gradv1.ExecuteCommandStreamResponse], handle StreamHandler) (*gradv1.ExecuteCommandStreamResponse, error) {
+	r := &resumableReader{handle: handle}
+	streamErr := r.read(stream)
+	if streamErr == nil {
+		return r.exit, nil
+	}
+	// Without an exec ID, e.g. from a server that can't resume commands, there is nothing to reattach to
+	if status.Code(streamErr) != codes.Unavailable || r.execID == "" {
+		return nil, streamErr
+	}
+
+	err := streamErr
+	delay := time.Duration(0)
+	deadline := time.Now().Add(c.resumeTimeout)
+	for status.Code(err) == codes.Unavailable && time.Now().Before(deadline) {
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+		}
+		delay = min(max(2*delay, resumeRetryDelay), maxResumeRetryDelay)
+
+		r.progressed = false
+		err = r.attach(ctx, c.runnerService)
+		if err == nil {
+			return r.exit, nil
+		}
+		if r.progressed {
+			delay = 0
+			deadline = time.Now().Add(c.resumeTimeout)
+		}
+	}
+	return nil, &ResumeError{ExecID: r.execID, Received: r.received, StreamErr: streamErr, Err: err}
+}
+
+// resumableReader reads the streams of a resumable command, passing each message to handle once
+type resumableReader struct {
+	handle   StreamHandler
+	execID   string
+	received int64
+	exit     *gradv1.ExecuteCommandStreamResponse
+	// progressed is set once a message is received
+	progressed bool
+}
+
+// attach reattaches to the command after the last message received and reads the stream
+func (r *resumableReader) attach(ctx context.Context, runners gradv1.RunnerServiceClient) error {
+	stream, err := runners.AttachExecution(ctx, &gradv1.AttachExecutionRequest{ExecId: r.execID, AfterSequence: r.received})
+	if err != nil {
+		return err
+	}
+	return r.read(stream)
+}
+
+// read passes the messages of stream not received before to handle, until it ends
+func (r *resumableReader) read(stream grpc.ServerStreamingClient[gradv1.ExecuteCommandStreamResponse]) error {
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if resp.Sequence != 0 && resp.Sequence <= r.received {
+			continue
+		}
+		r.received = resp.Sequence
+		r.progressed = true
+		switch resp.Type {
+		case gradv1.StreamType_STREAM_TYPE_STARTED:
+			r.execID = resp.ExecId
+		case gradv1.StreamType_STREAM_TYPE_EXIT:
+			r.exit = resp
+		}
+		if err := r.handle(resp); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package gradclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradtest"
+)
+
+// commandOutput is a stream of two stdout chunks, one stderr chunk and an exit code of 3
+var commandOutput = []*gradv1.ExecuteCommandStreamResponse{
+	{Type: gradv1.StreamType_STREAM_TYPE_STDOUT, Data: []byte("hello ")},
+	{Type: gradv1.StreamType_STREAM_TYPE_STDERR, Data: []byte("warning\n")},
+	{Type: gradv1.StreamType_STREAM_TYPE_STDOUT, Data: []byte("world\n")},
+	{Type: gradv1.StreamType_STREAM_TYPE_EXIT, ExitCode: 3},
+}
+
+func TestExec(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(c *Client, ctx context.Context, req *gradv1.ExecuteCommandRequest, handle StreamHandler) (*gradv1.ExecuteCommandStreamResponse, error)
+	}{
+		{"Exec", (*Client).Exec},
+		{"Execute", (*Client).Execute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeServer{output: commandOutput}
+			c := newTestClient(t, s)
+
+			var stdout, stderr bytes.Buffer
+			exit, err := tt.run(c, context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "echo hello world"}, WriteOutput(&stdout, &stderr))
+			if err != nil {
+				t.Fatalf("%s failed: %v", tt.name, err)
+			}
+
+			if exit == nil || exit.ExitCode != 3 {
+				t.Errorf("Expected exit code 3, got %v", exit)
+			}
+			if stdout.String() != "hello world\n" {
+				t.Errorf("Expected stdout %q, got %q", "hello world\n", stdout.String())
+			}
+			if stderr.String() != "warning\n" {
+				t.Errorf("Expected stderr %q, got %q", "warning\n", stderr.String())
+			}
+			if len(s.executed) != 1 || s.executed[0].Command != "echo hello world" {
+				t.Errorf("Expected the command to be sent once, got %v", s.executed)
+			}
+		})
+	}
+}
+
+func TestExecHandlerError(t *testing.T) {
+	c := newTestClient(t, &fakeServer{output: commandOutput})
+	stop := errors.New("stop")
+
+	var calls int
+	_, err := c.Exec(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1"}, func(resp *gradv1.ExecuteCommandStreamResponse) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("Expected the handler's error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected reading to stop after the first message, got %d calls", calls)
+	}
+}
+
+func TestExecWithoutExit(t *testing.T) {
+	c := newTestClient(t, &fakeServer{output: commandOutput[:1]})
+
+	exit, err := c.Exec(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1"}, WriteOutput(&bytes.Buffer{}, &bytes.Buffer{}))
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if exit != nil {
+		t.Errorf("Expected no exit message, got %v", exit)
+	}
+}
+
+// restartingFake returns a fake whose next command stream breaks with UNAVAILABLE after
+// "building", as when grad restarts, leaving "done" and exit code 0 to be reattached to
+func restartingFake() *gradtest.Fake {
+	fake := gradtest.NewFake()
+	fake.AddRunners(gradtest.NewRunner("runner-1"))
+	fake.QueueExec(gradtest.ExecScript{
+		Frames:  []*gradv1.ExecuteCommandStreamResponse{gradtest.StdoutFrame("building\n")},
+		Err:     status.Error(codes.Unavailable, "connection reset"),
+		Resumed: []*gradv1.ExecuteCommandStreamResponse{gradtest.StdoutFrame("done\n"), gradtest.ExitFrame(0)},
+	})
+	return fake
+}
+
+func TestExecResumesAfterRestart(t *testing.T) {
+	fake := restartingFake()
+	attach := gradv1.RunnerService_AttachExecution_FullMethodName
+	// grad is still starting up when the client first tries to reattach
+	fake.FailNext(attach, 1, status.Error(codes.Unavailable, "connection refused"))
+	c := NewFromServices(fake, fake, fake)
+
+	var stdout bytes.Buffer
+	exit, err := c.Exec(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "make", Resumable: true}, WriteOutput(&stdout, &bytes.Buffer{}))
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if exit == nil || exit.ExitCode != 0 || stdout.String() != "building\ndone\n" {
+		t.Errorf("Expected all the output once and exit code 0, got %q, %v", stdout.String(), exit)
+	}
+
+	requests := fake.Requests(attach)
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 attempts to reattach, got %d", len(requests))
+	}
+	if req := requests[1].(*gradv1.AttachExecutionRequest); req.ExecId != "exec-1" || req.AfterSequence != 2 {
+		t.Errorf("Expected to resume exec-1 after message 2, got %v", req)
+	}
+}
+
+func TestExecResumeFails(t *testing.T) {
+	fake := restartingFake()
+	c := NewFromServices(fake, fake, fake)
+
+	// grad restarts after the first line and no longer knows the command
+	var stdout bytes.Buffer
+	write := WriteOutput(&stdout, &bytes.Buffer{})
+	exit, err := c.Exec(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "make", Resumable: true}, func(resp *gradv1.ExecuteCommandStreamResponse) error {
+		if resp.Type == gradv1.StreamType_STREAM_TYPE_STDOUT {
+			fake.ForgetExecutions()
+		}
+		return write(resp)
+	})
+	var resumeErr *ResumeError
+	if !errors.As(err, &resumeErr) || status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected a ResumeError for NotFound, got %v", err)
+	}
+	if exit != nil || stdout.String() != "building\n" {
+		t.Errorf("Expected only the output before the break, got %q, %v", stdout.String(), exit)
+	}
+	if resumeErr.ExecID != "exec-1" || resumeErr.Received != 2 || !strings.Contains(err.Error(), "output is incomplete") {
+		t.Errorf("Expected the error to warn about the incomplete output of exec-1, got %v", err)
+	}
+}
+
+func TestExecResumeTimesOut(t *testing.T) {
+	fake := restartingFake()
+	fake.FailWith(gradv1.RunnerService_AttachExecution_FullMethodName, status.Error(codes.Unavailable, "connection refused"))
+	c := NewFromServices(fake, fake, fake)
+	c.resumeTimeout = 10 * time.Millisecond
+
+	_, err := c.Exec(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "make", Resumable: true}, WriteOutput(&bytes.Buffer{}, &bytes.Buffer{}))
+	var resumeErr *ResumeError
+	if !errors.As(err, &resumeErr) || status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected a ResumeError once grad stayed unavailable, got %v", err)
+	}
+}
+
+func TestExecWithoutResume(t *testing.T) {
+	fake := restartingFake()
+	c := NewFromServices(fake, fake, fake)
+
+	_, err := c.Execute(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "make"}, WriteOutput(&bytes.Buffer{}, &bytes.Buffer{}))
+	var resumeErr *ResumeError
+	if status.Code(err) != codes.Unavailable || errors.As(err, &resumeErr) {
+		t.Errorf("Expected the stream's error, got %v", err)
+	}
+	if got := len(fake.Requests(gradv1.RunnerService_AttachExecution_FullMethodName)); got != 0 {
+		t.Errorf("Expected no attempt to reattach, got %d", got)
+	}
+}
+
+func TestCheckCommandSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		argv      []string
+		expectErr bool
+	}{
+		{"Command at the limit", strings.Repeat("x", MaxCommandBytes), nil, false},
+		{"Command over the limit", strings.Repeat("x", MaxCommandBytes+1), nil, true},
+		// Quoting "it's" makes the command line longer than the words
+		{"Argv over the limit once quoted", "", []string{"echo", "it's" + strings.Repeat("x", MaxCommandBytes-9)}, true},
+		{"Too many words", "", make([]string, MaxCommandArgs+1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckCommandSize(tt.command, tt.argv); (err != nil) != tt.expectErr {
+				t.Errorf("Expected an error: %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package gradclient
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestNoCLIImports keeps the package usable from programs that aren't gractl
+func TestNoCLIImports(t *testing.T) {
+	forbidden := []string{
+		"github.com/spf13/cobra",
+		"github.com/spf13/viper",
+		"github.com/strrl/gra/cmd/",
+		"github.com/strrl/gra/internal/",
+	}
+
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatalf("Failed to read package directory: %v", err)
+	}
+
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, name, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+		for _, spec := range file.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			for _, prefix := range forbidden {
+				if strings.HasPrefix(path, prefix) {
+					t.Errorf("Expected %s not to import %s", name, path)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,462 @@
+package gradclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+const (
+	// DefaultPollInterval is how often WaitForRunnerReady checks the runner by default
+	DefaultPollInterval = 2 * time.Second
+	// listPageSize is how many runners ListAllRunners fetches per call
+	listPageSize = 100
+)
+
+// CreateOption sets a field of the request CreateRunner sends
+type CreateOption func(req *gradv1.CreateRunnerRequest)
+
+// WithName names the runner
+func WithName(name string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.Name = name }
+}
+
+// WithGroup puts the runner in a group, which can be listed and deleted as one
+func WithGroup(group string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.Group = group }
+}
+
+// WithDescription notes what the runner is for
+func WithDescription(description string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.Description = description }
+}
+
+// WithEnv adds environment variables, replacing ones set by earlier options
+func WithEnv(env map[string]string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) {
+		if req.Env == nil {
+			req.Env = make(map[string]string)
+		}
+		maps.Copy(req.Env, env)
+	}
+}
+
+// WithWorkspace mounts the workspace's bucket, if any, and passes its credentials
+func WithWorkspace(workspace S3Workspace) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) {
+		if config := workspace.Proto(); config != nil {
+			// Keep the sidecar resources set by WithSidecarResources
+			if req.Workspace != nil {
+				config.S3FsCpu, config.S3FsMemory = req.Workspace.S3FsCpu, req.Workspace.S3FsMemory
+			}
+			req.Workspace = config
+		}
+		WithEnv(CredentialEnv(workspace, ""))(req)
+	}
+}
+
+// WithSidecarResources sets the CPU and memory of the s3fs sidecar, e.g. "200m" and
+// "512Mi"; it needs a workspace, which WithWorkspace may set before or after
+func WithSidecarResources(cpu, memory string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) {
+		if req.Workspace == nil {
+			req.Workspace = &gradv1.WorkspaceConfig{}
+		}
+		req.Workspace.S3FsCpu, req.Workspace.S3FsMemory = cpu, memory
+	}
+}
+
+// WithSSHPublicKey allows logging in to the runner with key; an empty key is ignored
+func WithSSHPublicKey(key string) CreateOption {
+	return WithEnv(CredentialEnv(S3Workspace{}, key))
+}
+
+// WithBootstrapCommand runs command once before the runner becomes running
+func WithBootstrapCommand(command string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.BootstrapCommand = command }
+}
+
+// WithoutProxy leaves out the server's HTTP proxy settings
+func WithoutProxy() CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.DisableProxy = true }
+}
+
+// WithServiceAccount runs the runner as a Kubernetes ServiceAccount the server allows
+func WithServiceAccount(name string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.ServiceAccount = name }
+}
+
+// WithoutServiceAccountToken doesn't mount a ServiceAccount token into the runner
+func WithoutServiceAccountToken() CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.DisableServiceAccountToken = true }
+}
+
+// WithPriorityClass gives the runner's pod a Kubernetes PriorityClass the server allows
+func WithPriorityClass(name string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.PriorityClassName = name }
+}
+
+// WithoutValidation skips the server's check that the runner's PriorityClass exists
+func WithoutValidation() CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.SkipValidation = true }
+}
+
+// WithArch pins the runner to nodes of a CPU architecture the server supports, e.g. arm64
+func WithArch(arch string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.Arch = arch }
+}
+
+// WithDefaultShell sets the shell of commands that don't pick one
+func WithDefaultShell(shell string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.DefaultShell = shell }
+}
+
+// WithDefaultWorkdir sets the working directory of commands that don't pick one
+func WithDefaultWorkdir(workdir string) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.DefaultWorkdir = workdir }
+}
+
+// WithHostAliases adds /etc/hosts entries to the runner
+func WithHostAliases(aliases ...*gradv1.HostAlias) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.HostAliases = append(req.HostAliases, aliases...) }
+}
+
+// WithAutoRestart overrides the server default for recreating the runner's pod if it fails
+func WithAutoRestart(autoRestart bool) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.AutoRestart = &autoRestart }
+}
+
+// WithReservation keeps the runner from idle cleanup until until; a zero time reserves nothing
+func WithReservation(until time.Time) CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) {
+		if !until.IsZero() {
+			req.ReservedUntil = until.Unix()
+		}
+	}
+}
+
+// WithProtection protects the runner from deletion: DeleteRunner fails with FailedPrecondition
+// unless WithOverrideProtection is passed, and idle cleanup never deletes it
+func WithProtection() CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.Protected = true }
+}
+
+// WithSpot runs the runner on the server's spot/preemptible nodes. Unless WithAutoRestart
+// says otherwise, the runner is recreated on another node if its node is reclaimed.
+func WithSpot() CreateOption {
+	return func(req *gradv1.CreateRunnerRequest) { req.Spot = true }
+}
+
+// NewCreateRunnerRequest returns the request CreateRunner sends for opts (pure function)
+func NewCreateRunnerRequest(opts ...CreateOption) *gradv1.CreateRunnerRequest {
+	req := &gradv1.CreateRunnerRequest{}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req
+}
+
+// CreateRunner creates a runner. It returns once the runner exists, usually before it is
+// running; see WaitForRunnerReady.
+func (c *Client) CreateRunner(ctx context.Context, opts ...CreateOption) (*gradv1.Runner, error) {
+	runner, _, err := c.CreateRunnerWithWarnings(ctx, opts...)
+	return runner, err
+}
+
+// CreateRunnerWithWarnings creates a runner like CreateRunner, also returning the problems the
+// server noticed but created the runner anyway, such as no node having room for it yet
+func (c *Client) CreateRunnerWithWarnings(ctx context.Context, opts ...CreateOption) (*gradv1.Runner, []string, error) {
+	resp, err := c.runnerService.CreateRunner(ctx, NewCreateRunnerRequest(opts...))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Runner, resp.Warnings, nil
+}
+
+// GetRunner returns a runner by ID
+func (c *Client) GetRunner(ctx context.Context, runnerID string) (*gradv1.Runner, error) {
+	resp, err := c.runnerService.GetRunner(ctx, &gradv1.GetRunnerRequest{RunnerId: runnerID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Runner, nil
+}
+
+// ListRunners returns one page of the runners matching req and how many match in total
+func (c *Client) ListRunners(ctx context.Context, req *gradv1.ListRunnersRequest) ([]*gradv1.Runner, int32, error) {
+	resp, err := c.runnerService.ListRunners(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Runners, resp.Total, nil
+}
+
+// ListAllRunners pages through every runner matching req, ignoring its limit and offset
+func (c *Client) ListAllRunners(ctx context.Context, req *gradv1.ListRunnersRequest) ([]*gradv1.Runner, error) {
+	page := &gradv1.ListRunnersRequest{
+		Status:      req.Status,
+		Filter:      req.Filter,
+		Group:       req.Group,
+		ImageDigest: req.ImageDigest,
+		Labels:      req.Labels,
+		OrderBy:     req.OrderBy,
+		Reverse:     req.Reverse,
+		StaleOnly:   req.StaleOnly,
+		Arch:        req.Arch,
+		Limit:       listPageSize,
+	}
+
+	var runners []*gradv1.Runner
+	for {
+		page.Offset = int32(len(runners))
+		resp, err := c.runnerService.ListRunners(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		runners = append(runners, resp.Runners...)
+		if len(resp.Runners) == 0 || int32(len(runners)) >= resp.Total {
+			return runners, nil
+		}
+	}
+}
+
+// GetRunnerStats counts the runners with status, or all runners if it is unspecified
+func (c *Client) GetRunnerStats(ctx context.Context, status gradv1.RunnerStatus) (*gradv1.GetRunnerStatsResponse, error) {
+	return c.runnerService.GetRunnerStats(ctx, &gradv1.GetRunnerStatsRequest{Status: status})
+}
+
+// GetRunnerMetrics returns a runner's live CPU and memory usage. It fails with
+// codes.Unimplemented if the cluster has no metrics-server.
+func (c *Client) GetRunnerMetrics(ctx context.Context, runnerID string) (*gradv1.RunnerUsage, error) {
+	resp, err := c.runnerService.GetRunnerMetrics(ctx, &gradv1.GetRunnerMetricsRequest{RunnerId: runnerID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Usage, nil
+}
+
+// ListRunnerMetrics returns the live usage of every runner, highest CPU first
+func (c *Client) ListRunnerMetrics(ctx context.Context) ([]*gradv1.RunnerUsage, error) {
+	resp, err := c.runnerService.ListRunnerMetrics(ctx, &gradv1.ListRunnerMetricsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Usage, nil
+}
+
+// GetRunnerDiagnostics returns a runner's pod, events, recent executions and the last
+// tailLines lines of each container's log; zero lets the server pick. Env values and commands
+// are returned unredacted.
+func (c *Client) GetRunnerDiagnostics(ctx context.Context, runnerID string, tailLines int32) (*gradv1.GetRunnerDiagnosticsResponse, error) {
+	return c.runnerService.GetRunnerDiagnostics(ctx, &gradv1.GetRunnerDiagnosticsRequest{RunnerId: runnerID, TailLines: tailLines})
+}
+
+// AddSSHKey allows publicKey, in authorized_keys format, to log in to a running runner.
+// added is false if the runner already had the key.
+func (c *Client) AddSSHKey(ctx context.Context, runnerID, publicKey string) (*gradv1.SSHKey, bool, error) {
+	resp, err := c.runnerService.AddSSHKey(ctx, &gradv1.AddSSHKeyRequest{RunnerId: runnerID, PublicKey: publicKey})
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Key, resp.Added, nil
+}
+
+// ListSSHKeys returns the keys added to a runner with AddSSHKey
+func (c *Client) ListSSHKeys(ctx context.Context, runnerID string) ([]*gradv1.SSHKey, error) {
+	resp, err := c.runnerService.ListSSHKeys(ctx, &gradv1.ListSSHKeysRequest{RunnerId: runnerID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
+
+// RemoveSSHKey removes the key with fingerprint from a runner; removed is false if the runner
+// didn't have it
+func (c *Client) RemoveSSHKey(ctx context.Context, runnerID, fingerprint string) (bool, error) {
+	resp, err := c.runnerService.RemoveSSHKey(ctx, &gradv1.RemoveSSHKeyRequest{RunnerId: runnerID, Fingerprint: fingerprint})
+	if err != nil {
+		return false, err
+	}
+	return resp.Removed, nil
+}
+
+// SnapshotWorkspace archives a running runner's workspace and uploads it to S3, calling
+// progress, if not nil, as it goes. It returns the final response, which has the bucket, key and
+// size of the uploaded archive.
+func (c *Client) SnapshotWorkspace(ctx context.Context, req *gradv1.SnapshotWorkspaceRequest, progress func(*gradv1.SnapshotWorkspaceResponse)) (*gradv1.SnapshotWorkspaceResponse, error) {
+	stream, err := c.runnerService.SnapshotWorkspace(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil, fmt.Errorf("snapshot stream ended before the upload finished")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if resp.Phase == gradv1.SnapshotPhase_SNAPSHOT_PHASE_DONE {
+			return resp, nil
+		}
+		if progress != nil {
+			progress(resp)
+		}
+	}
+}
+
+// UpdateRunnerDescription changes the description of a runner; "" clears it
+func (c *Client) UpdateRunnerDescription(ctx context.Context, runnerID, description string) (*gradv1.Runner, error) {
+	resp, err := c.runnerService.UpdateRunner(ctx, &gradv1.UpdateRunnerRequest{RunnerId: runnerID, Description: &description})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Runner, nil
+}
+
+// ReserveRunner sets the end of a runner's reservation against idle cleanup; a zero until clears it
+func (c *Client) ReserveRunner(ctx context.Context, runnerID string, until time.Time) (*gradv1.Runner, error) {
+	var reservedUntil int64
+	if !until.IsZero() {
+		reservedUntil = until.Unix()
+	}
+	resp, err := c.runnerService.UpdateRunner(ctx, &gradv1.UpdateRunnerRequest{RunnerId: runnerID, ReservedUntil: &reservedUntil})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Runner, nil
+}
+
+// SetRunnerProtected protects a runner from deletion, or removes its protection
+func (c *Client) SetRunnerProtected(ctx context.Context, runnerID string, protected bool) (*gradv1.Runner, error) {
+	resp, err := c.runnerService.UpdateRunner(ctx, &gradv1.UpdateRunnerRequest{RunnerId: runnerID, Protected: &protected})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Runner, nil
+}
+
+// CloneRunner creates a runner configured like sourceRunnerID; an empty name lets the server pick one
+func (c *Client) CloneRunner(ctx context.Context, sourceRunnerID, name string) (*gradv1.Runner, error) {
+	resp, err := c.runnerService.CloneRunner(ctx, &gradv1.CloneRunnerRequest{SourceRunnerId: sourceRunnerID, Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Runner, nil
+}
+
+// DeleteOption sets a field of the request DeleteRunner sends
+type DeleteOption func(req *gradv1.DeleteRunnerRequest)
+
+// WithWait makes DeleteRunner return only once the runner's pod and the objects deleted with
+// it are gone, or after timeout, rounded up to seconds and capped by the server; zero waits as
+// long as the server allows. The response's completed field tells which happened.
+func WithWait(timeout time.Duration) DeleteOption {
+	return func(req *gradv1.DeleteRunnerRequest) {
+		req.Wait = true
+		req.WaitTimeoutSeconds = int32((timeout + time.Second - 1) / time.Second)
+	}
+}
+
+// WithOverrideProtection makes DeleteRunner delete the runner even if it is protected
+func WithOverrideProtection() DeleteOption {
+	return func(req *gradv1.DeleteRunnerRequest) { req.OverrideProtection = true }
+}
+
+// DeleteRunner deletes a runner. Objects that couldn't be deleted are listed in the
+// response's failed resources rather than returned as an error.
+func (c *Client) DeleteRunner(ctx context.Context, runnerID string, opts ...DeleteOption) (*gradv1.DeleteRunnerResponse, error) {
+	req := &gradv1.DeleteRunnerRequest{RunnerId: runnerID}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return c.runnerService.DeleteRunner(ctx, req)
+}
+
+// DeleteRunnerGroup deletes every runner of a group, listing the ones that failed in the response
+func (c *Client) DeleteRunnerGroup(ctx context.Context, group string) (*gradv1.DeleteRunnerGroupResponse, error) {
+	return c.runnerService.DeleteRunnerGroup(ctx, &gradv1.DeleteRunnerGroupRequest{Group: group})
+}
+
+// RunnerNotReadyError reports that a runner ended up in a state it won't become running from
+type RunnerNotReadyError struct {
+	Runner *gradv1.Runner
+}
+
+func (e *RunnerNotReadyError) Error() string {
+	if e.Runner.StatusDetail != "" {
+		return fmt.Sprintf("runner %s is %s: %s", e.Runner.Id, e.Runner.Status, e.Runner.StatusDetail)
+	}
+	return fmt.Sprintf("runner %s is %s", e.Runner.Id, e.Runner.Status)
+}
+
+// runnerReady reports whether a runner is running, or returns a RunnerNotReadyError if it
+// won't get there (pure function)
+func runnerReady(runner *gradv1.Runner) (bool, error) {
+	switch runner.Status {
+	case gradv1.RunnerStatus_RUNNER_STATUS_RUNNING:
+		return true, nil
+	case gradv1.RunnerStatus_RUNNER_STATUS_ERROR,
+		gradv1.RunnerStatus_RUNNER_STATUS_STOPPING,
+		gradv1.RunnerStatus_RUNNER_STATUS_STOPPED,
+		gradv1.RunnerStatus_RUNNER_STATUS_DELETE_FAILED:
+		return false, &RunnerNotReadyError{Runner: runner}
+	default:
+		// Creating, bootstrapping and restarting runners are on their way
+		return false, nil
+	}
+}
+
+// WaitForRunnerReady polls a runner every interval, DefaultPollInterval if zero, until it is
+// running, and returns it. It fails with a RunnerNotReadyError once the runner is in error or
+// being deleted, and with ctx's error once ctx is done.
+func (c *Client) WaitForRunnerReady(ctx context.Context, runnerID string, interval time.Duration) (*gradv1.Runner, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runner, err := c.GetRunner(ctx, runnerID)
+		if err != nil {
+			if ctxErr := contextError(ctx, err); ctxErr != nil {
+				return nil, fmt.Errorf("waiting for runner %s: %w", runnerID, ctxErr)
+			}
+			return nil, err
+		}
+		ready, err := runnerReady(runner)
+		if err != nil {
+			return runner, err
+		}
+		if ready {
+			return runner, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return runner, fmt.Errorf("runner %s is still %s: %w", runnerID, runner.Status, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// contextError returns ctx's error if err is a call failing because ctx is done. gRPC may
+// fail the call at the deadline a moment before ctx reports it.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if deadline, ok := ctx.Deadline(); ok && status.Code(err) == codes.DeadlineExceeded && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
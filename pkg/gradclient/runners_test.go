@@ -0,0 +1,263 @@
+package gradclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+func TestCreateRunnerOptions(t *testing.T) {
+	s := &fakeServer{}
+	c := newTestClient(t, s)
+
+	runner, err := c.CreateRunner(context.Background(),
+		WithName("dev"),
+		WithGroup("ci-1234"),
+		WithEnv(map[string]string{"FOO": "bar", AccessKeyIDEnv: "overridden"}),
+		// Sidecar resources set before the workspace are kept
+		WithSidecarResources("200m", "512Mi"),
+		WithWorkspace(S3Workspace{Bucket: "data", Prefix: "team/", AccessKeyID: "AKIA", SecretAccessKey: "secret"}),
+		WithSSHPublicKey("ssh-ed25519 AAAA user@host"),
+		WithAutoRestart(false),
+		WithReservation(time.Unix(1800000000, 0)),
+	)
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if runner.Id != "runner-1" || runner.Name != "dev" {
+		t.Errorf("Expected runner-1 named dev, got %s named %s", runner.Id, runner.Name)
+	}
+
+	if len(s.created) != 1 {
+		t.Fatalf("Expected 1 CreateRunner call, got %d", len(s.created))
+	}
+	req := s.created[0]
+	if req.Group != "ci-1234" {
+		t.Errorf("Expected group ci-1234, got %q", req.Group)
+	}
+	if req.AutoRestart == nil || *req.AutoRestart {
+		t.Errorf("Expected auto restart to be explicitly disabled, got %v", req.AutoRestart)
+	}
+	if req.ReservedUntil != 1800000000 {
+		t.Errorf("Expected a reservation until 1800000000, got %d", req.ReservedUntil)
+	}
+
+	expectedEnv := map[string]string{
+		"FOO":              "bar",
+		AccessKeyIDEnv:     "AKIA",
+		SecretAccessKeyEnv: "secret",
+		PublicKeyEnv:       "ssh-ed25519 AAAA user@host",
+	}
+	if len(req.Env) != len(expectedEnv) {
+		t.Errorf("Expected env %v, got %v", expectedEnv, req.Env)
+	}
+	for key, value := range expectedEnv {
+		if req.Env[key] != value {
+			t.Errorf("Expected %s=%q, got %q", key, value, req.Env[key])
+		}
+	}
+
+	workspace := req.Workspace
+	if workspace == nil {
+		t.Fatal("Expected a workspace")
+	}
+	if workspace.Bucket != "data" || workspace.Prefix != "team/" {
+		t.Errorf("Expected bucket data with prefix team/, got %s with prefix %s", workspace.Bucket, workspace.Prefix)
+	}
+	if workspace.S3FsCpu != "200m" || workspace.S3FsMemory != "512Mi" {
+		t.Errorf("Expected sidecar resources 200m/512Mi, got %s/%s", workspace.S3FsCpu, workspace.S3FsMemory)
+	}
+}
+
+func TestCreateRunnerWithWarnings(t *testing.T) {
+	s := &fakeServer{warnings: []string{"no node has room for a runner needing 2 CPU and 2Gi memory"}}
+	c := newTestClient(t, s)
+
+	runner, warnings, err := c.CreateRunnerWithWarnings(context.Background(), WithName("dev"))
+	if err != nil {
+		t.Fatalf("CreateRunnerWithWarnings failed: %v", err)
+	}
+	if runner.Id != "runner-1" {
+		t.Errorf("Expected runner-1, got %s", runner.Id)
+	}
+	if len(warnings) != 1 || warnings[0] != s.warnings[0] {
+		t.Errorf("Expected the server's warning, got %v", warnings)
+	}
+}
+
+func TestNewCreateRunnerRequestWithoutBucket(t *testing.T) {
+	req := NewCreateRunnerRequest(WithWorkspace(S3Workspace{AccessKeyID: "AKIA"}), WithSSHPublicKey(""))
+
+	if req.Workspace != nil {
+		t.Errorf("Expected no workspace without a bucket, got %v", req.Workspace)
+	}
+	if len(req.Env) != 1 || req.Env[AccessKeyIDEnv] != "AKIA" {
+		t.Errorf("Expected only the access key to be passed, got %v", req.Env)
+	}
+	if req.AutoRestart != nil {
+		t.Errorf("Expected the server default for auto restart, got %v", *req.AutoRestart)
+	}
+}
+
+func TestListAllRunners(t *testing.T) {
+	s := &fakeServer{}
+	for i := range 250 {
+		s.runners = append(s.runners, &gradv1.Runner{Id: fmt.Sprintf("runner-%d", i)})
+	}
+	c := newTestClient(t, s)
+
+	runners, err := c.ListAllRunners(context.Background(), &gradv1.ListRunnersRequest{Group: "ci-1234", Limit: 5, Offset: 7})
+	if err != nil {
+		t.Fatalf("ListAllRunners failed: %v", err)
+	}
+	if len(runners) != 250 {
+		t.Errorf("Expected 250 runners, got %d", len(runners))
+	}
+	if runners[0].Id != "runner-0" || runners[249].Id != "runner-249" {
+		t.Errorf("Expected runners in order, got %s..%s", runners[0].Id, runners[len(runners)-1].Id)
+	}
+
+	if len(s.listed) != 3 {
+		t.Fatalf("Expected 3 pages, got %d", len(s.listed))
+	}
+	for i, req := range s.listed {
+		if req.Group != "ci-1234" || req.Limit != listPageSize || req.Offset != int32(i*listPageSize) {
+			t.Errorf("Expected page %d of group ci-1234 at offset %d, got group %q limit %d offset %d", i, i*listPageSize, req.Group, req.Limit, req.Offset)
+		}
+	}
+}
+
+func TestDeleteRunnerWait(t *testing.T) {
+	s := &fakeServer{}
+	c := newTestClient(t, s)
+
+	if _, err := c.DeleteRunner(context.Background(), "runner-1"); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+	resp, err := c.DeleteRunner(context.Background(), "runner-2", WithWait(1500*time.Millisecond))
+	if err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+	if !resp.Completed {
+		t.Error("Expected the waited deletion to complete")
+	}
+
+	if len(s.deleted) != 2 {
+		t.Fatalf("Expected 2 DeleteRunner calls, got %d", len(s.deleted))
+	}
+	if s.deleted[0].Wait {
+		t.Error("Expected no wait without WithWait")
+	}
+	// Timeouts are rounded up to whole seconds
+	if !s.deleted[1].Wait || s.deleted[1].WaitTimeoutSeconds != 2 {
+		t.Errorf("Expected a wait of 2s, got wait %t for %ds", s.deleted[1].Wait, s.deleted[1].WaitTimeoutSeconds)
+	}
+}
+
+func TestRunnerMetrics(t *testing.T) {
+	usage := []*gradv1.RunnerUsage{{RunnerId: "runner-2", CpuMillicores: 900}, {RunnerId: "runner-1", CpuMillicores: 100}}
+	c := newTestClient(t, &fakeServer{usage: usage})
+
+	got, err := c.GetRunnerMetrics(context.Background(), "runner-1")
+	if err != nil {
+		t.Fatalf("GetRunnerMetrics failed: %v", err)
+	}
+	if got.CpuMillicores != 100 {
+		t.Errorf("Expected 100m, got %dm", got.CpuMillicores)
+	}
+
+	all, err := c.ListRunnerMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("ListRunnerMetrics failed: %v", err)
+	}
+	if len(all) != 2 || all[0].RunnerId != "runner-2" {
+		t.Errorf("Expected runner-2 first, got %v", all)
+	}
+
+	// Without metrics-server
+	c = newTestClient(t, &fakeServer{})
+	if _, err := c.ListRunnerMetrics(context.Background()); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented, got %v", err)
+	}
+}
+
+func TestWaitForRunnerReady(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []gradv1.RunnerStatus
+		timeout     time.Duration
+		expectReady bool
+		expectErr   error
+	}{
+		{
+			name: "Ready after bootstrapping",
+			statuses: []gradv1.RunnerStatus{
+				gradv1.RunnerStatus_RUNNER_STATUS_CREATING,
+				gradv1.RunnerStatus_RUNNER_STATUS_BOOTSTRAPPING,
+				gradv1.RunnerStatus_RUNNER_STATUS_RUNNING,
+			},
+			timeout:     time.Second,
+			expectReady: true,
+		},
+		{
+			name: "Failed",
+			statuses: []gradv1.RunnerStatus{
+				gradv1.RunnerStatus_RUNNER_STATUS_CREATING,
+				gradv1.RunnerStatus_RUNNER_STATUS_ERROR,
+			},
+			timeout:   time.Second,
+			expectErr: &RunnerNotReadyError{},
+		},
+		{
+			name:      "Timed out",
+			statuses:  []gradv1.RunnerStatus{gradv1.RunnerStatus_RUNNER_STATUS_CREATING},
+			timeout:   50 * time.Millisecond,
+			expectErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, &fakeServer{statuses: tt.statuses})
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			runner, err := c.WaitForRunnerReady(ctx, "runner-1", 10*time.Millisecond)
+			if tt.expectReady {
+				if err != nil {
+					t.Fatalf("WaitForRunnerReady failed: %v", err)
+				}
+				if runner.Status != gradv1.RunnerStatus_RUNNER_STATUS_RUNNING {
+					t.Errorf("Expected a running runner, got %s", runner.Status)
+				}
+				return
+			}
+
+			switch expected := tt.expectErr.(type) {
+			case *RunnerNotReadyError:
+				if !errors.As(err, &expected) {
+					t.Errorf("Expected RunnerNotReadyError, got %T: %v", err, err)
+				}
+			default:
+				if !errors.Is(err, expected) {
+					t.Errorf("Expected %v, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitForRunnerReadyNotFound(t *testing.T) {
+	c := newTestClient(t, &fakeServer{})
+
+	if _, err := c.WaitForRunnerReady(context.Background(), "missing", 10*time.Millisecond); err == nil {
+		t.Error("Expected an error waiting for a missing runner")
+	}
+}
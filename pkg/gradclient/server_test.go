@@ -0,0 +1,145 @@
+package gradclient
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// fakeServer is a RunnerService and ExecuteService recording requests and replaying
+// canned responses
+type fakeServer struct {
+	gradv1.UnimplementedRunnerServiceServer
+	gradv1.UnimplementedExecuteServiceServer
+
+	mu      sync.Mutex
+	created []*gradv1.CreateRunnerRequest
+	deleted []*gradv1.DeleteRunnerRequest
+	listed  []*gradv1.ListRunnersRequest
+	runners []*gradv1.Runner
+	// statuses are what GetRunner returns in turn, then the last one
+	statuses []gradv1.RunnerStatus
+	executed []*gradv1.ExecuteCommandRequest
+	output   []*gradv1.ExecuteCommandStreamResponse
+	// usage is what the metrics RPCs return; nil makes them Unimplemented
+	usage    []*gradv1.RunnerUsage
+	warnings []string
+}
+
+func (s *fakeServer) CreateRunner(ctx context.Context, req *gradv1.CreateRunnerRequest) (*gradv1.CreateRunnerResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.created = append(s.created, req)
+	return &gradv1.CreateRunnerResponse{Runner: &gradv1.Runner{Id: "runner-1", Name: req.Name}, Warnings: s.warnings}, nil
+}
+
+func (s *fakeServer) DeleteRunner(ctx context.Context, req *gradv1.DeleteRunnerRequest) (*gradv1.DeleteRunnerResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleted = append(s.deleted, req)
+	return &gradv1.DeleteRunnerResponse{Completed: req.Wait}, nil
+}
+
+func (s *fakeServer) GetRunner(ctx context.Context, req *gradv1.GetRunnerRequest) (*gradv1.GetRunnerResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.statuses) == 0 {
+		return nil, status.Errorf(codes.NotFound, "runner %s not found", req.RunnerId)
+	}
+	runnerStatus := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+	return &gradv1.GetRunnerResponse{Runner: &gradv1.Runner{Id: req.RunnerId, Status: runnerStatus}}, nil
+}
+
+func (s *fakeServer) ListRunners(ctx context.Context, req *gradv1.ListRunnersRequest) (*gradv1.ListRunnersResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listed = append(s.listed, req)
+
+	start := min(int(req.Offset), len(s.runners))
+	end := min(start+int(req.Limit), len(s.runners))
+	return &gradv1.ListRunnersResponse{Runners: s.runners[start:end], Total: int32(len(s.runners))}, nil
+}
+
+func (s *fakeServer) GetRunnerMetrics(ctx context.Context, req *gradv1.GetRunnerMetricsRequest) (*gradv1.GetRunnerMetricsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.usage == nil {
+		return nil, status.Error(codes.Unimplemented, "resource metrics unavailable")
+	}
+	for _, usage := range s.usage {
+		if usage.RunnerId == req.RunnerId {
+			return &gradv1.GetRunnerMetricsResponse{Usage: usage}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "runner %s not found", req.RunnerId)
+}
+
+func (s *fakeServer) ListRunnerMetrics(ctx context.Context, req *gradv1.ListRunnerMetricsRequest) (*gradv1.ListRunnerMetricsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.usage == nil {
+		return nil, status.Error(codes.Unimplemented, "resource metrics unavailable")
+	}
+	return &gradv1.ListRunnerMetricsResponse{Usage: s.usage}, nil
+}
+
+func (s *fakeServer) ExecuteCommandStream(req *gradv1.ExecuteCommandRequest, stream grpc.ServerStreamingServer[gradv1.ExecuteCommandStreamResponse]) error {
+	return s.replay(req, stream)
+}
+
+func (s *fakeServer) ExecuteCommand(req *gradv1.ExecuteCommandRequest, stream grpc.ServerStreamingServer[gradv1.ExecuteCommandStreamResponse]) error {
+	return s.replay(req, stream)
+}
+
+// replay records req and sends the canned output
+func (s *fakeServer) replay(req *gradv1.ExecuteCommandRequest, stream grpc.ServerStreamingServer[gradv1.ExecuteCommandStreamResponse]) error {
+	s.mu.Lock()
+	s.executed = append(s.executed, req)
+	output := s.output
+	s.mu.Unlock()
+
+	for _, resp := range output {
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newTestClient serves s over an in-memory connection and returns a client for it
+func newTestClient(t *testing.T, s *fakeServer) *Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	gradv1.RegisterRunnerServiceServer(server, s)
+	gradv1.RegisterExecuteServiceServer(server, s)
+	go server.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	c := NewFromConn(conn)
+	t.Cleanup(func() {
+		c.Close()
+		server.Stop()
+	})
+	return c
+}
@@ -0,0 +1,129 @@
+package gradtest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// SetRunnerImage changes the image of runners created afterwards. The admin token isn't
+// checked, and reverting clears the images rather than restoring earlier ones.
+func (f *Fake) SetRunnerImage(ctx context.Context, in *gradv1.SetRunnerImageRequest, opts ...grpc.CallOption) (*gradv1.SetRunnerImageResponse, error) {
+	if err := f.call(ctx, gradv1.AdminService_SetRunnerImage_FullMethodName, in); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &gradv1.SetRunnerImageResponse{PreviousImage: f.image, PreviousS3FsImage: f.s3fsImage}
+	if in.Revert {
+		f.image, f.s3fsImage = "", ""
+	}
+	if in.Image != "" {
+		f.image = in.Image
+	}
+	if in.S3FsImage != "" {
+		f.s3fsImage = in.S3FsImage
+	}
+	resp.Image, resp.S3FsImage = f.image, f.s3fsImage
+	return resp, nil
+}
+
+// ReloadConfig reports that no setting changed, as a fake has no configuration to reload. The
+// admin token isn't checked.
+func (f *Fake) ReloadConfig(ctx context.Context, in *gradv1.ReloadConfigRequest, opts ...grpc.CallOption) (*gradv1.ReloadConfigResponse, error) {
+	if err := f.call(ctx, gradv1.AdminService_ReloadConfig_FullMethodName, in); err != nil {
+		return nil, err
+	}
+	return &gradv1.ReloadConfigResponse{}, nil
+}
+
+// TailServerLogs streams the records set with SetServerLogs of at least in.MinLevel, then with
+// in.Follow waits for the context to end, as no new records are logged. The admin token isn't
+// checked.
+func (f *Fake) TailServerLogs(ctx context.Context, in *gradv1.TailServerLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[gradv1.ServerLogRecord], error) {
+	f.record(gradv1.AdminService_TailServerLogs_FullMethodName, in)
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	stream := &logStream{clientStream: clientStream{ctx}, fake: f, follow: in.Follow}
+	var minLevel slog.Level
+	if in.MinLevel == "" {
+		minLevel = slog.LevelDebug
+	} else if err := minLevel.UnmarshalText([]byte(in.MinLevel)); err != nil {
+		stream.err = status.Errorf(codes.InvalidArgument, "invalid min_level %q", in.MinLevel)
+		return stream, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, record := range f.serverLogs {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(record.Level)); err == nil && level < minLevel {
+			continue
+		}
+		stream.records = append(stream.records, record)
+	}
+	return stream, nil
+}
+
+// logStream is the client end of a TailServerLogs stream. Like execStream, latency and
+// injected errors surface from the first Recv.
+type logStream struct {
+	clientStream
+	fake    *Fake
+	records []*gradv1.ServerLogRecord
+	follow  bool
+	err     error
+	started bool
+}
+
+var _ grpc.ServerStreamingClient[gradv1.ServerLogRecord] = (*logStream)(nil)
+
+// Recv returns the next record, then io.EOF, or with follow the status of the context once it ends
+func (s *logStream) Recv() (*gradv1.ServerLogRecord, error) {
+	if !s.started {
+		s.started = true
+		if err := s.fake.inject(s.ctx, gradv1.AdminService_TailServerLogs_FullMethodName); err != nil && s.err == nil {
+			s.err = err
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if len(s.records) == 0 {
+		if !s.follow {
+			return nil, io.EOF
+		}
+		<-s.ctx.Done()
+		s.err = status.FromContextError(s.ctx.Err()).Err()
+		return nil, s.err
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.err = status.FromContextError(err).Err()
+		return nil, s.err
+	}
+	record := s.records[0]
+	s.records = s.records[1:]
+	return proto.Clone(record).(*gradv1.ServerLogRecord), nil
+}
+
+// RecvMsg receives the next record into m, which must be a *ServerLogRecord
+func (s *logStream) RecvMsg(m any) error {
+	record, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	proto.Reset(m.(proto.Message))
+	proto.Merge(m.(proto.Message), record)
+	return nil
+}
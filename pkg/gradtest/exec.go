@@ -0,0 +1,347 @@
+package gradtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/shellquote"
+)
+
+// MissingCommandExitCode is the exit code of commands the fake has no output for, as for a
+// command a shell can't find
+const MissingCommandExitCode = 127
+
+// defaultShell runs commands when neither the request nor the runner sets a shell, as in grad
+const defaultShell = "bash"
+
+// Output is the canned result of a command that runs to completion
+type Output struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int32
+}
+
+// Frames returns the messages grad streams for the output: stdout, stderr and the EXIT
+// message counting their bytes
+func (o Output) Frames() []*gradv1.ExecuteCommandStreamResponse {
+	var frames []*gradv1.ExecuteCommandStreamResponse
+	if o.Stdout != "" {
+		frames = append(frames, StdoutFrame(o.Stdout))
+	}
+	if o.Stderr != "" {
+		frames = append(frames, StderrFrame(o.Stderr))
+	}
+	exit := ExitFrame(o.ExitCode)
+	exit.StdoutBytes = int64(len(o.Stdout))
+	exit.StderrBytes = int64(len(o.Stderr))
+	return append(frames, exit)
+}
+
+// ExecScript is how one command stream goes: its frames are received in order after a STARTED
+// frame, then Err, or io.EOF if it is nil. Leaving out the EXIT frame scripts a stream that
+// breaks off, and a script with only Err one that fails before the command starts.
+type ExecScript struct {
+	Frames []*gradv1.ExecuteCommandStreamResponse
+	Err    error
+	// Resumed are the frames of a resumable command after Err broke its stream, received by
+	// reattaching with AttachExecution
+	Resumed []*gradv1.ExecuteCommandStreamResponse
+}
+
+// execution is a resumable command AttachExecution reattaches to: its numbered frames
+// starting with STARTED, and the error its stream ends with, nil for io.EOF
+type execution struct {
+	frames []*gradv1.ExecuteCommandStreamResponse
+	err    error
+}
+
+// StdoutFrame returns a message of stdout output
+func StdoutFrame(data string) *gradv1.ExecuteCommandStreamResponse {
+	return &gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_STDOUT, Data: []byte(data)}
+}
+
+// StderrFrame returns a message of stderr output
+func StderrFrame(data string) *gradv1.ExecuteCommandStreamResponse {
+	return &gradv1.ExecuteCommandStreamResponse{Type: gradv1.StreamType_STREAM_TYPE_STDERR, Data: []byte(data)}
+}
+
+// ExitFrame returns the EXIT message of a command that exited with code
+func ExitFrame(code int32) *gradv1.ExecuteCommandStreamResponse {
+	return &gradv1.ExecuteCommandStreamResponse{
+		Type:          gradv1.StreamType_STREAM_TYPE_EXIT,
+		ExitCode:      code,
+		FailureReason: gradv1.ExitReason_EXIT_REASON_EXITED,
+	}
+}
+
+// outputKey is what output set with SetOutput or SetRunnerOutput is for: a command line in one
+// runner, or in every runner if runnerID is empty
+type outputKey struct {
+	runnerID string
+	command  string
+}
+
+// SetOutput sets the output of command, matched exactly against the command line, in every
+// runner. An argv is matched as the command line grad runs, each word quoted for the shell.
+// Commands without output exit with MissingCommandExitCode.
+func (f *Fake) SetOutput(command string, output Output) {
+	f.SetRunnerOutput("", command, output)
+}
+
+// SetRunnerOutput sets the output of command in one runner, which wins over output set for
+// every runner with SetOutput
+func (f *Fake) SetRunnerOutput(runnerID, command string, output Output) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.outputs[outputKey{runnerID, command}] = output
+}
+
+// output returns the output of command in a runner, preferring output for that runner over
+// output for every runner; f.mu must be held
+func (f *Fake) output(runnerID, command string) Output {
+	if output, ok := f.outputs[outputKey{runnerID, command}]; ok {
+		return output
+	}
+	if output, ok := f.outputs[outputKey{"", command}]; ok {
+		return output
+	}
+	return Output{
+		Stderr:   fmt.Sprintf("no canned output for %q\n", command),
+		ExitCode: MissingCommandExitCode,
+	}
+}
+
+// QueueExec queues scripts for the next command streams, one per stream, ahead of the output
+// set with SetOutput. Requests grad would reject still fail without using a script.
+func (f *Fake) QueueExec(scripts ...ExecScript) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.execScripts = append(f.execScripts, scripts...)
+}
+
+// commandLine returns the command line of in, as grad runs it: its argv quoted for the shell,
+// if it has one (pure function)
+func commandLine(in *gradv1.ExecuteCommandRequest) string {
+	if len(in.Argv) > 0 {
+		return shellquote.Join(in.Argv)
+	}
+	return in.Command
+}
+
+// ExecuteCommandStream runs a command in the running runner in.RunnerId
+func (f *Fake) ExecuteCommandStream(ctx context.Context, in *gradv1.ExecuteCommandRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[gradv1.ExecuteCommandStreamResponse], error) {
+	method := gradv1.RunnerService_ExecuteCommandStream_FullMethodName
+	f.record(method, in)
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if in.RunnerId == "" {
+		return f.openStream(ctx, method, ExecScript{Err: errRunnerIDRequired}), nil
+	}
+	return f.openStream(ctx, method, f.execScript(in.RunnerId, in)), nil
+}
+
+// ExecuteCommand runs a command in in.RunnerId, or else in the first running runner, adding
+// one if there is none. Unlike grad, the fake keeps the runners it adds.
+func (f *Fake) ExecuteCommand(ctx context.Context, in *gradv1.ExecuteCommandRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[gradv1.ExecuteCommandStreamResponse], error) {
+	method := gradv1.ExecuteService_ExecuteCommand_FullMethodName
+	f.record(method, in)
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	runnerID := in.RunnerId
+	if runnerID == "" && commandLine(in) != "" {
+		for _, runner := range f.runners {
+			if runner.Status == gradv1.RunnerStatus_RUNNER_STATUS_RUNNING {
+				runnerID = runner.Id
+				break
+			}
+		}
+		if runnerID == "" {
+			runnerID = f.addRunner(&gradv1.CreateRunnerRequest{}).Id
+		}
+	}
+	return f.openStream(ctx, method, f.execScript(runnerID, in)), nil
+}
+
+// AttachExecution reattaches to a resumable command, streaming its frames after
+// in.AfterSequence. Like grad, the fake keeps every execution until ForgetExecutions.
+func (f *Fake) AttachExecution(ctx context.Context, in *gradv1.AttachExecutionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[gradv1.ExecuteCommandStreamResponse], error) {
+	method := gradv1.RunnerService_AttachExecution_FullMethodName
+	f.record(method, in)
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if in.ExecId == "" {
+		return f.openStream(ctx, method, ExecScript{Err: status.Error(codes.InvalidArgument, "exec_id is required")}), nil
+	}
+	exec, ok := f.executions[in.ExecId]
+	if !ok {
+		return f.openStream(ctx, method, ExecScript{Err: status.Errorf(codes.NotFound, "execution %s not found", in.ExecId)}), nil
+	}
+
+	var frames []*gradv1.ExecuteCommandStreamResponse
+	for _, frame := range exec.frames {
+		if frame.Sequence > in.AfterSequence {
+			frames = append(frames, frame)
+		}
+	}
+	return f.openStream(ctx, method, ExecScript{Frames: frames, Err: exec.err}), nil
+}
+
+// ForgetExecutions drops the resumable commands AttachExecution reattaches to, as a grad
+// restart does, so reattaching fails with NotFound
+func (f *Fake) ForgetExecutions() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.executions = make(map[string]*execution)
+}
+
+// execScript returns how a command stream in a runner goes: grad's error for a request it
+// would reject, else the next queued script, else the command's output. Like grad's, the
+// stream starts with STARTED unless the script only fails, and a resumable command is
+// registered for AttachExecution with its stream numbered. f.mu must be held.
+func (f *Fake) execScript(runnerID string, in *gradv1.ExecuteCommandRequest) ExecScript {
+	command := commandLine(in)
+	if command == "" {
+		return ExecScript{Err: status.Error(codes.InvalidArgument, "command is required")}
+	}
+
+	i, err := f.runningRunner(runnerID)
+	if err != nil {
+		return ExecScript{Err: err}
+	}
+	var script ExecScript
+	if len(f.execScripts) > 0 {
+		script = f.execScripts[0]
+		f.execScripts = f.execScripts[1:]
+	} else {
+		script = ExecScript{Frames: f.output(runnerID, command).Frames()}
+	}
+	if len(script.Frames) == 0 && len(script.Resumed) == 0 {
+		return script
+	}
+
+	f.execCount++
+	started := &gradv1.ExecuteCommandStreamResponse{
+		Type:       gradv1.StreamType_STREAM_TYPE_STARTED,
+		ExecId:     fmt.Sprintf("exec-%d", f.execCount),
+		RunnerId:   runnerID,
+		Shell:      firstNonEmpty(in.Shell, f.runners[i].DefaultShell, defaultShell),
+		WorkingDir: firstNonEmpty(in.WorkingDir, f.runners[i].DefaultWorkdir),
+	}
+	if !in.DisableCorrelationEnv {
+		started.CorrelationEnv = map[string]string{"GRAD_RUNNER_ID": runnerID, "GRAD_EXEC_ID": started.ExecId}
+	}
+	if !in.Resumable {
+		return ExecScript{Frames: append([]*gradv1.ExecuteCommandStreamResponse{started}, script.Frames...), Err: script.Err}
+	}
+	return f.startExecution(started, script)
+}
+
+// startExecution registers a resumable command going as script after its STARTED message and
+// returns the script of its first stream; f.mu must be held
+func (f *Fake) startExecution(started *gradv1.ExecuteCommandStreamResponse, script ExecScript) ExecScript {
+	exec := &execution{}
+	add := func(frame *gradv1.ExecuteCommandStreamResponse) {
+		frame = proto.Clone(frame).(*gradv1.ExecuteCommandStreamResponse)
+		frame.Sequence = int64(len(exec.frames) + 1)
+		exec.frames = append(exec.frames, frame)
+	}
+	add(started)
+	for _, frame := range script.Frames {
+		add(frame)
+	}
+	first := ExecScript{Frames: exec.frames, Err: script.Err}
+
+	for _, frame := range script.Resumed {
+		add(frame)
+	}
+	f.executions[started.ExecId] = exec
+	return first
+}
+
+// firstNonEmpty returns the first non-empty value, or "" if all are empty (pure function)
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// openStream returns a stream playing script
+func (f *Fake) openStream(ctx context.Context, method string, script ExecScript) *execStream {
+	return &execStream{clientStream: clientStream{ctx}, fake: f, method: method, frames: script.Frames, err: script.Err}
+}
+
+// execStream is the client end of a command stream. Like a gRPC stream, the call opening it
+// only fails if its context has ended: latency, injected errors and rejected requests all surface from the first
+// Recv, and once the stream has ended every Recv returns how it ended.
+type execStream struct {
+	clientStream
+	fake    *Fake
+	method  string
+	frames  []*gradv1.ExecuteCommandStreamResponse
+	err     error
+	started bool
+}
+
+var _ grpc.ServerStreamingClient[gradv1.ExecuteCommandStreamResponse] = (*execStream)(nil)
+
+// end makes the stream end with err after no more frames
+func (s *execStream) end(err error) (*gradv1.ExecuteCommandStreamResponse, error) {
+	s.frames, s.err = nil, err
+	return nil, err
+}
+
+// Recv returns the next frame, io.EOF once the frames are used up or the error the stream ends with
+func (s *execStream) Recv() (*gradv1.ExecuteCommandStreamResponse, error) {
+	if !s.started {
+		s.started = true
+		if err := s.fake.inject(s.ctx, s.method); err != nil {
+			return s.end(err)
+		}
+	}
+
+	if len(s.frames) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	if err := s.ctx.Err(); err != nil {
+		return s.end(status.FromContextError(err).Err())
+	}
+	frame := s.frames[0]
+	s.frames = s.frames[1:]
+	return proto.Clone(frame).(*gradv1.ExecuteCommandStreamResponse), nil
+}
+
+// RecvMsg receives the next frame into m, which must be an *ExecuteCommandStreamResponse
+func (s *execStream) RecvMsg(m any) error {
+	frame, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	proto.Reset(m.(proto.Message))
+	proto.Merge(m.(proto.Message), frame)
+	return nil
+}
@@ -0,0 +1,335 @@
+package gradtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	gradgrpc "github.com/strrl/gra/internal/grad/grpc"
+	"github.com/strrl/gra/internal/grad/service"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+// streamResult is what a client sees of a command stream: the error opening it, the frames
+// received, how the stream ended and what a Recv after the end returns
+type streamResult struct {
+	openErr  error
+	frames   []*gradv1.ExecuteCommandStreamResponse
+	end      error
+	endAgain error
+}
+
+// collect reads a command stream to its end
+func collect(ctx context.Context, client gradv1.RunnerServiceClient, req *gradv1.ExecuteCommandRequest) streamResult {
+	stream, err := client.ExecuteCommandStream(ctx, req)
+	if err != nil {
+		return streamResult{openErr: err}
+	}
+	var result streamResult
+	for {
+		frame, err := stream.Recv()
+		if err != nil {
+			result.end = err
+			_, result.endAgain = stream.Recv()
+			return result
+		}
+		result.frames = append(result.frames, frame)
+	}
+}
+
+// sameEnd reports whether two stream errors end a stream the same way: both io.EOF, or the
+// same gRPC code
+func sameEnd(a, b error) bool {
+	if a == nil || b == nil || a == io.EOF || b == io.EOF {
+		return a == b
+	}
+	return status.Code(a) == status.Code(b)
+}
+
+// echoRunnerService runs every command in runner-1 with bash, printing hello and exiting with
+// code 3, and reports the correlation variables grad sets
+type echoRunnerService struct {
+	service.RunnerService
+}
+
+func (echoRunnerService) ExecuteCommandStream(ctx context.Context, req *service.ExecuteCommandRequest, stdoutCh, stderrCh chan<- []byte) (service.ExitStatus, error) {
+	defer close(stdoutCh)
+	defer close(stderrCh)
+
+	if req.RunnerID != "runner-1" {
+		return service.ExitStatus{}, service.ErrRunnerNotFound
+	}
+	started := service.ExecStart{RunnerID: req.RunnerID, Shell: "bash"}
+	if !req.DisableCorrelationEnv {
+		started.CorrelationEnv = map[string]string{service.CorrelationEnvRunnerID: req.RunnerID, service.CorrelationEnvExecID: req.ExecID}
+	}
+	req.Started(started)
+	stdoutCh <- []byte("hello\n")
+	return service.ExitStatus{Code: 3, Reason: service.ExitReasonExited}, nil
+}
+
+// startServer serves grad's gRPC server over echoRunnerService and returns a client for it
+func startServer(t *testing.T) gradv1.RunnerServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	gradv1.RegisterRunnerServiceServer(srv, gradgrpc.NewServer(echoRunnerService{}, nil, nil, nil, nil, nil, nil))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return gradv1.NewRunnerServiceClient(conn)
+}
+
+func TestExecStreamMatchesServer(t *testing.T) {
+	server := startServer(t)
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"))
+	fake.SetOutput("echo hello", Output{Stdout: "hello\n", ExitCode: 3})
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  *gradv1.ExecuteCommandRequest
+	}{
+		{"Exits", context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "echo hello"}},
+		{"Missing runner", context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-2", Command: "echo hello"}},
+		{"Without correlation env", context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "echo hello", DisableCorrelationEnv: true}},
+		{"No runner", context.Background(), &gradv1.ExecuteCommandRequest{Command: "echo hello"}},
+		{"No command", context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1"}},
+		{"Cancelled", cancelled, &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "echo hello"}},
+		{"Resumable", context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "echo hello", Resumable: true}},
+		{"Resumable missing runner", context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-2", Command: "echo hello", Resumable: true}},
+		{"Resumable no command", context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Resumable: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := collect(tt.ctx, server, tt.req)
+			got := collect(tt.ctx, fake, tt.req)
+
+			if status.Code(got.openErr) != status.Code(want.openErr) {
+				t.Fatalf("Expected opening the stream to fail with %v, got %v", want.openErr, got.openErr)
+			}
+			if len(got.frames) != len(want.frames) {
+				t.Fatalf("Expected frames %v, got %v", want.frames, got.frames)
+			}
+			for i := range want.frames {
+				// Exec IDs are random in grad
+				if want.frames[i].ExecId != "" && got.frames[i].ExecId != "" {
+					want.frames[i].ExecId, got.frames[i].ExecId = "", ""
+					delete(want.frames[i].CorrelationEnv, service.CorrelationEnvExecID)
+					delete(got.frames[i].CorrelationEnv, service.CorrelationEnvExecID)
+				}
+				if !proto.Equal(got.frames[i], want.frames[i]) {
+					t.Errorf("Expected frame %d to be %v, got %v", i, want.frames[i], got.frames[i])
+				}
+			}
+			if !sameEnd(got.end, want.end) || !sameEnd(got.endAgain, want.endAgain) {
+				t.Errorf("Expected the stream to end with %v then %v, got %v then %v", want.end, want.endAgain, got.end, got.endAgain)
+			}
+		})
+	}
+}
+
+func TestExecStreamScripts(t *testing.T) {
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"))
+	unavailable := status.Error(codes.Unavailable, "connection reset")
+	fake.QueueExec(
+		ExecScript{Frames: []*gradv1.ExecuteCommandStreamResponse{StdoutFrame("partial\n")}, Err: unavailable},
+		ExecScript{Frames: []*gradv1.ExecuteCommandStreamResponse{StdoutFrame("done\n"), ExitFrame(0)}},
+	)
+	req := &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "make"}
+
+	// A script's error ends the stream after STARTED and its frames, and keeps ending it
+	result := collect(context.Background(), fake, req)
+	if len(result.frames) != 2 || result.end != unavailable || result.endAgain != unavailable {
+		t.Errorf("Expected STARTED and one frame then the error, got %v then %v", result.frames, result.end)
+	}
+	if started := result.frames[0]; started.Type != gradv1.StreamType_STREAM_TYPE_STARTED || started.RunnerId != "runner-1" || started.Shell != "bash" {
+		t.Errorf("Expected STARTED in runner-1 with bash first, got %v", started)
+	}
+
+	result = collect(context.Background(), fake, req)
+	if len(result.frames) != 3 || result.end != io.EOF {
+		t.Errorf("Expected the second script, got %v then %v", result.frames, result.end)
+	}
+
+	// Once the scripts are used up, commands without output exit like a missing command
+	result = collect(context.Background(), fake, req)
+	if len(result.frames) != 3 || result.frames[2].ExitCode != MissingCommandExitCode {
+		t.Errorf("Expected stderr and exit code %d, got %v", MissingCommandExitCode, result.frames)
+	}
+
+	if got := len(fake.Requests(gradv1.RunnerService_ExecuteCommandStream_FullMethodName)); got != 3 {
+		t.Errorf("Expected 3 recorded requests, got %d", got)
+	}
+}
+
+func TestExecStreamInjectedErrors(t *testing.T) {
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"))
+	fake.SetOutput("true", Output{})
+	method := gradv1.RunnerService_ExecuteCommandStream_FullMethodName
+	fake.FailNext(method, 1, status.Error(codes.ResourceExhausted, "too many streams"))
+	req := &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "true"}
+
+	// Like a server's, the error comes from Recv rather than from opening the stream
+	result := collect(context.Background(), fake, req)
+	if result.openErr != nil || status.Code(result.end) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted from Recv, got %v and %v", result.openErr, result.end)
+	}
+	if result = collect(context.Background(), fake, req); result.end != io.EOF {
+		t.Errorf("Expected the next stream to succeed, got %v", result.end)
+	}
+}
+
+func TestExecStreamCancelled(t *testing.T) {
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"))
+	fake.SetOutput("make", Output{Stdout: "building\n", Stderr: "warning\n"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := fake.ExecuteCommandStream(ctx, &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "make"})
+	if err != nil {
+		t.Fatalf("ExecuteCommandStream failed: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Expected the first frame, got %v", err)
+	}
+
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Errorf("Expected Canceled after cancelling, got %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Errorf("Expected the stream to stay cancelled, got %v", err)
+	}
+}
+
+func TestExecThroughClient(t *testing.T) {
+	fake := NewFake()
+	fake.SetOutput("go test ./...", Output{Stdout: "ok\n", ExitCode: 1})
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	// Execute adds a runner when there is none to run in
+	var stdout, stderr bytes.Buffer
+	exit, err := c.Execute(context.Background(), &gradv1.ExecuteCommandRequest{Command: "go test ./..."}, gradclient.WriteOutput(&stdout, &stderr))
+	if err != nil || exit.ExitCode != 1 || stdout.String() != "ok\n" {
+		t.Fatalf("Expected ok and exit code 1, got %q, %v, %v", stdout.String(), exit, err)
+	}
+	if runners := fake.Runners(); len(runners) != 1 || runners[0].Id != "runner-1" {
+		t.Errorf("Expected runner-1 to be added, got %v", runners)
+	}
+
+	fake.AddRunners(NewRunner("runner-2", WithStatus(gradv1.RunnerStatus_RUNNER_STATUS_CREATING)))
+	_, err = c.Exec(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-2", Command: "go test ./..."}, gradclient.WriteOutput(&stdout, &stderr))
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for a runner that isn't running, got %v", err)
+	}
+}
+
+func TestExecRunnerOutput(t *testing.T) {
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"), NewRunner("runner-2"))
+	fake.SetOutput("hostname", Output{Stdout: "any\n"})
+	fake.SetRunnerOutput("runner-2", "hostname", Output{Stdout: "build\n", ExitCode: 2})
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	tests := []struct {
+		name         string
+		runnerID     string
+		command      string
+		expectStdout string
+		expectStderr string
+		expectCode   int32
+	}{
+		{"Any runner", "runner-1", "hostname", "any\n", "", 0},
+		{"Runner specific output wins", "runner-2", "hostname", "build\n", "", 2},
+		{"No canned output", "runner-2", "uptime", "", "no canned output for \"uptime\"\n", MissingCommandExitCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exit, err := c.Exec(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: tt.runnerID, Command: tt.command}, gradclient.WriteOutput(&stdout, &stderr))
+			if err != nil {
+				t.Fatalf("Exec failed: %v", err)
+			}
+			if stdout.String() != tt.expectStdout || stderr.String() != tt.expectStderr || exit.ExitCode != tt.expectCode {
+				t.Errorf("Expected %q, %q and exit code %d, got %q, %q and %d", tt.expectStdout, tt.expectStderr, tt.expectCode, stdout.String(), stderr.String(), exit.ExitCode)
+			}
+		})
+	}
+}
+
+func TestExecStreamRecvMsg(t *testing.T) {
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"))
+	fake.SetOutput("true", Output{})
+
+	stream, _ := fake.ExecuteCommandStream(context.Background(), &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "true"})
+	var started, exit gradv1.ExecuteCommandStreamResponse
+	if err := stream.RecvMsg(&started); err != nil || started.Type != gradv1.StreamType_STREAM_TYPE_STARTED {
+		t.Errorf("Expected the STARTED frame, got %v, %v", &started, err)
+	}
+	if err := stream.RecvMsg(&exit); err != nil || exit.Type != gradv1.StreamType_STREAM_TYPE_EXIT {
+		t.Errorf("Expected the EXIT frame, got %v, %v", &exit, err)
+	}
+	if err := stream.RecvMsg(&exit); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestAttachExecution(t *testing.T) {
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"))
+	fake.SetOutput("make", Output{Stdout: "ok\n"})
+	method := gradv1.RunnerService_AttachExecution_FullMethodName
+
+	first := collect(context.Background(), fake, &gradv1.ExecuteCommandRequest{RunnerId: "runner-1", Command: "make", Resumable: true})
+	if len(first.frames) != 3 || first.frames[0].ExecId != "exec-1" || first.frames[2].Sequence != 3 {
+		t.Fatalf("Expected STARTED, stdout and EXIT numbered 1 to 3, got %v", first.frames)
+	}
+
+	stream, _ := fake.AttachExecution(context.Background(), &gradv1.AttachExecutionRequest{ExecId: "exec-1", AfterSequence: 1})
+	var frames []*gradv1.ExecuteCommandStreamResponse
+	for frame, err := stream.Recv(); err == nil; frame, err = stream.Recv() {
+		frames = append(frames, frame)
+	}
+	if len(frames) != 2 || !proto.Equal(frames[0], first.frames[1]) {
+		t.Errorf("Expected the frames after the first again, got %v", frames)
+	}
+
+	fake.ForgetExecutions()
+	stream, _ = fake.AttachExecution(context.Background(), &gradv1.AttachExecutionRequest{ExecId: "exec-1"})
+	if _, err := stream.Recv(); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound once forgotten, got %v", err)
+	}
+	if got := len(fake.Requests(method)); got != 2 {
+		t.Errorf("Expected 2 recorded requests, got %d", got)
+	}
+}
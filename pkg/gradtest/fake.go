@@ -0,0 +1,283 @@
+// Package gradtest provides an in-memory fake of the grad API for testing code built on
+// package gradclient, and builders for the runners it serves. Fake implements the generated
+// RunnerService, ExecuteService and AdminService clients, so a *gradclient.Client runs against
+// it without a server:
+//
+//	fake := gradtest.NewFake()
+//	fake.AddRunners(gradtest.NewRunner("runner-1"))
+//	fake.SetOutput("make test", gradtest.Output{Stdout: "ok\n"})
+//	c := gradclient.NewFromServices(fake, fake, fake)
+//
+// Calls behave like grad's: unknown runners are NotFound, commands only run in running runners,
+// and command output streams end in an EXIT message followed by io.EOF, with failures surfacing
+// from Recv. Each method can be scripted with latency and errors, keyed by the generated full
+// method name such as gradv1.RunnerService_GetRunner_FullMethodName.
+package gradtest
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// StatusDeleted stands for a runner that no longer exists in a status script
+const StatusDeleted gradv1.RunnerStatus = -1
+
+// errRunnerNotFound, errRunnerNotRunning and errRunnerProtected are the errors grad returns for them
+var (
+	errRunnerNotFound   = status.Error(codes.NotFound, "runner not found")
+	errRunnerNotRunning = status.Error(codes.FailedPrecondition, "runner is not running")
+	errRunnerProtected  = status.Error(codes.FailedPrecondition, "failed precondition: runner is protected from deletion")
+)
+
+// injectedError fails calls of a method, all of them if remaining is negative
+type injectedError struct {
+	err       error
+	remaining int
+}
+
+// Fake is an in-memory grad holding runners, canned command output and the requests it
+// received. It is safe for concurrent use; the zero value is not, use NewFake.
+type Fake struct {
+	mu          sync.Mutex
+	runners     []*gradv1.Runner
+	scripts     map[string][]gradv1.RunnerStatus
+	outputs     map[outputKey]Output
+	labels      map[string]map[string]string
+	execScripts []ExecScript
+	executions  map[string]*execution
+	execCount   int
+	usage       []*gradv1.RunnerUsage
+	diagnostics map[string]*gradv1.GetRunnerDiagnosticsResponse
+	sshKeys     map[string][]*gradv1.SSHKey
+	serverInfo  *gradv1.GetServerInfoResponse
+	serverLogs  []*gradv1.ServerLogRecord
+	image       string
+	s3fsImage   string
+	latency     map[string]time.Duration
+	errors      map[string]*injectedError
+	requests    map[string][]proto.Message
+	now         func() time.Time
+}
+
+var (
+	_ gradv1.RunnerServiceClient  = (*Fake)(nil)
+	_ gradv1.ExecuteServiceClient = (*Fake)(nil)
+	_ gradv1.AdminServiceClient   = (*Fake)(nil)
+)
+
+// NewFake returns a fake without runners
+func NewFake() *Fake {
+	return &Fake{
+		scripts:     make(map[string][]gradv1.RunnerStatus),
+		outputs:     make(map[outputKey]Output),
+		labels:      make(map[string]map[string]string),
+		executions:  make(map[string]*execution),
+		diagnostics: make(map[string]*gradv1.GetRunnerDiagnosticsResponse),
+		sshKeys:     make(map[string][]*gradv1.SSHKey),
+		serverInfo:  &gradv1.GetServerInfoResponse{},
+		latency:     make(map[string]time.Duration),
+		errors:      make(map[string]*injectedError),
+		requests:    make(map[string][]proto.Message),
+		now:         time.Now,
+	}
+}
+
+// AddRunners adds copies of runners, replacing runners with the same ID
+func (f *Fake) AddRunners(runners ...*gradv1.Runner) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, runner := range runners {
+		runner = proto.Clone(runner).(*gradv1.Runner)
+		if i := f.findRunner(runner.Id); i >= 0 {
+			f.runners[i] = runner
+			continue
+		}
+		f.runners = append(f.runners, runner)
+	}
+}
+
+// ScriptStatus makes every GetRunner of a runner move it to the next of statuses, staying at
+// the last one once they are used up. StatusDeleted removes the runner, and any other status
+// adds it back if it is missing.
+func (f *Fake) ScriptStatus(runnerID string, statuses ...gradv1.RunnerStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scripts[runnerID] = statuses
+}
+
+// SetLatency delays every call of method by d, or every call of any method without its own
+// latency if method is "". A call whose context ends first fails like a gRPC call does.
+func (f *Fake) SetLatency(method string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency[method] = d
+}
+
+// FailWith makes every call of method fail with err, until it is called again with a nil err.
+// Use status errors to fail the way a server does.
+func (f *Fake) FailWith(method string, err error) {
+	f.FailNext(method, -1, err)
+}
+
+// FailNext makes the next n calls of method fail with err, after which calls succeed again
+func (f *Fake) FailNext(method string, n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil || n == 0 {
+		delete(f.errors, method)
+		return
+	}
+	f.errors[method] = &injectedError{err: err, remaining: n}
+}
+
+// SetLabels sets the labels of a runner, which ListRunners filters by; nil clears them
+func (f *Fake) SetLabels(runnerID string, labels map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if labels == nil {
+		delete(f.labels, runnerID)
+		return
+	}
+	f.labels[runnerID] = maps.Clone(labels)
+}
+
+// SetUsage sets the usage the metrics RPCs return. Without any they fail with
+// codes.Unimplemented, like grad in a cluster without metrics-server.
+func (f *Fake) SetUsage(usage ...*gradv1.RunnerUsage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.usage = nil
+	for _, u := range usage {
+		f.usage = append(f.usage, proto.Clone(u).(*gradv1.RunnerUsage))
+	}
+}
+
+// SetDiagnostics sets what GetRunnerDiagnostics returns for a runner, empty by default
+func (f *Fake) SetDiagnostics(runnerID string, diagnostics *gradv1.GetRunnerDiagnosticsResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.diagnostics[runnerID] = proto.Clone(diagnostics).(*gradv1.GetRunnerDiagnosticsResponse)
+}
+
+// SetServerInfo sets what GetServerInfo returns, empty by default
+func (f *Fake) SetServerInfo(info *gradv1.GetServerInfoResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.serverInfo = proto.Clone(info).(*gradv1.GetServerInfoResponse)
+}
+
+// SetServerLogs sets the log records TailServerLogs streams, none by default
+func (f *Fake) SetServerLogs(records ...*gradv1.ServerLogRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.serverLogs = nil
+	for _, record := range records {
+		f.serverLogs = append(f.serverLogs, proto.Clone(record).(*gradv1.ServerLogRecord))
+	}
+}
+
+// SetClock replaces the clock creation and update times are taken from
+func (f *Fake) SetClock(now func() time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Requests returns the requests method received in order, including failed calls
+func (f *Fake) Requests(method string) []proto.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]proto.Message(nil), f.requests[method]...)
+}
+
+// Runners returns copies of the runners the fake holds, in the order they were added
+func (f *Fake) Runners() []*gradv1.Runner {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	runners := make([]*gradv1.Runner, 0, len(f.runners))
+	for _, runner := range f.runners {
+		runners = append(runners, proto.Clone(runner).(*gradv1.Runner))
+	}
+	return runners
+}
+
+// call records a request to method, waits out its latency and returns its injected error, if any
+func (f *Fake) call(ctx context.Context, method string, req proto.Message) error {
+	f.record(method, req)
+	return f.inject(ctx, method)
+}
+
+// record remembers a request to method for Requests
+func (f *Fake) record(method string, req proto.Message) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[method] = append(f.requests[method], proto.Clone(req))
+}
+
+// inject waits out the latency of method and returns its injected error, if any, or the
+// status of ctx if it ended
+func (f *Fake) inject(ctx context.Context, method string) error {
+	f.mu.Lock()
+	latency, ok := f.latency[method]
+	if !ok {
+		latency = f.latency[""]
+	}
+	var err error
+	if injected := f.errors[method]; injected != nil {
+		err = injected.err
+		if injected.remaining > 0 {
+			injected.remaining--
+			if injected.remaining == 0 {
+				delete(f.errors, method)
+			}
+		}
+	}
+	f.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return status.FromContextError(ctxErr).Err()
+	}
+	return err
+}
+
+// findRunner returns the index of a runner in f.runners, or -1; f.mu must be held
+func (f *Fake) findRunner(runnerID string) int {
+	for i, runner := range f.runners {
+		if runner.Id == runnerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// runningRunner returns the index of a running runner, or an error like grad's; f.mu must be held
+func (f *Fake) runningRunner(runnerID string) (int, error) {
+	i := f.findRunner(runnerID)
+	if i < 0 {
+		return -1, errRunnerNotFound
+	}
+	if f.runners[i].Status != gradv1.RunnerStatus_RUNNER_STATUS_RUNNING {
+		return -1, errRunnerNotRunning
+	}
+	return i, nil
+}
@@ -0,0 +1,337 @@
+package gradtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/gradclient"
+)
+
+func TestFakeRunnerLifecycle(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-3", WithName("existing"), WithGroup("ci")))
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	runner, err := c.CreateRunner(ctx, gradclient.WithName("build"), gradclient.WithGroup("ci"))
+	if err != nil {
+		t.Fatalf("CreateRunner failed: %v", err)
+	}
+	if runner.Id != "runner-4" || runner.Status != gradv1.RunnerStatus_RUNNER_STATUS_RUNNING {
+		t.Errorf("Expected running runner-4, got %s %s", runner.Id, runner.Status)
+	}
+
+	runners, total, err := c.ListRunners(ctx, &gradv1.ListRunnersRequest{Group: "ci", Limit: 1, Offset: 1})
+	if err != nil || total != 2 || len(runners) != 1 || runners[0].Id != "runner-4" {
+		t.Errorf("Expected the second of 2 runners in ci, got %v of %d, %v", runners, total, err)
+	}
+
+	runner, err = c.UpdateRunnerDescription(ctx, "runner-4", "nightly build")
+	if err != nil || runner.Description != "nightly build" {
+		t.Errorf("Expected the description to be updated, got %v, %v", runner, err)
+	}
+
+	deleted, err := c.DeleteRunnerGroup(ctx, "ci")
+	if err != nil || len(deleted.DeletedRunnerIds) != 2 {
+		t.Errorf("Expected both runners in ci to be deleted, got %v, %v", deleted, err)
+	}
+	if _, err := c.GetRunner(ctx, "runner-4"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound after deleting, got %v", err)
+	}
+}
+
+func TestFakeScriptStatus(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.ScriptStatus("runner-1",
+		gradv1.RunnerStatus_RUNNER_STATUS_CREATING,
+		gradv1.RunnerStatus_RUNNER_STATUS_RUNNING,
+		StatusDeleted,
+	)
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	for _, expected := range []gradv1.RunnerStatus{gradv1.RunnerStatus_RUNNER_STATUS_CREATING, gradv1.RunnerStatus_RUNNER_STATUS_RUNNING} {
+		runner, err := c.GetRunner(ctx, "runner-1")
+		if err != nil || runner.Status != expected {
+			t.Fatalf("Expected %s, got %v, %v", expected, runner, err)
+		}
+	}
+	// The last status sticks
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetRunner(ctx, "runner-1"); status.Code(err) != codes.NotFound {
+			t.Errorf("Expected NotFound once deleted, got %v", err)
+		}
+	}
+}
+
+func TestFakeFailNext(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"))
+	c := gradclient.NewFromServices(fake, fake, fake)
+	method := gradv1.RunnerService_GetRunner_FullMethodName
+
+	fake.FailNext(method, 2, status.Error(codes.Unavailable, "connection refused"))
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetRunner(ctx, "runner-1"); status.Code(err) != codes.Unavailable {
+			t.Errorf("Expected call %d to fail with Unavailable, got %v", i+1, err)
+		}
+	}
+	if _, err := c.GetRunner(ctx, "runner-1"); err != nil {
+		t.Errorf("Expected the third call to succeed, got %v", err)
+	}
+	// Other methods aren't affected
+	if _, _, err := c.ListRunners(ctx, &gradv1.ListRunnersRequest{}); err != nil {
+		t.Errorf("Expected ListRunners to succeed, got %v", err)
+	}
+
+	fake.FailWith(method, status.Error(codes.Internal, "boom"))
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetRunner(ctx, "runner-1"); status.Code(err) != codes.Internal {
+			t.Errorf("Expected every call to fail, got %v", err)
+		}
+	}
+	fake.FailWith(method, nil)
+	if _, err := c.GetRunner(ctx, "runner-1"); err != nil {
+		t.Errorf("Expected calls to succeed once cleared, got %v", err)
+	}
+
+	if got := len(fake.Requests(method)); got != 7 {
+		t.Errorf("Expected 7 recorded requests including failed ones, got %d", got)
+	}
+}
+
+func TestFakeLatency(t *testing.T) {
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"))
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	fake.SetLatency(gradv1.RunnerService_GetRunner_FullMethodName, 20*time.Millisecond)
+	start := time.Now()
+	if _, err := c.GetRunner(context.Background(), "runner-1"); err != nil {
+		t.Fatalf("GetRunner failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected the call to take at least 20ms, took %s", elapsed)
+	}
+
+	// A deadline passing first fails the call like gRPC does
+	fake.SetLatency("", time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, _, err := c.ListRunners(ctx, &gradv1.ListRunnersRequest{}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestFakeSSHKeys(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"), NewRunner("runner-2", WithError(gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_OOM, "killed")))
+	c := gradclient.NewFromServices(fake, fake, fake)
+	const publicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl alice@laptop"
+
+	key, added, err := c.AddSSHKey(ctx, "runner-1", publicKey)
+	if err != nil || !added || key.Comment != "alice@laptop" {
+		t.Fatalf("Expected the key to be added, got %v, %t, %v", key, added, err)
+	}
+	if _, added, _ := c.AddSSHKey(ctx, "runner-1", publicKey); added {
+		t.Error("Expected the same key not to be added twice")
+	}
+	if removed, err := c.RemoveSSHKey(ctx, "runner-1", key.Fingerprint); err != nil || !removed {
+		t.Errorf("Expected the key to be removed, got %t, %v", removed, err)
+	}
+	if _, _, err := c.AddSSHKey(ctx, "runner-2", publicKey); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for a runner in error, got %v", err)
+	}
+}
+
+func TestFakeProtectedRunners(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	runner, err := c.CreateRunner(ctx, gradclient.WithGroup("prod"), gradclient.WithProtection())
+	if err != nil || !runner.Protected {
+		t.Fatalf("Expected a protected runner, got %v, %v", runner, err)
+	}
+	if _, err := c.DeleteRunner(ctx, runner.Id); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition deleting a protected runner, got %v", err)
+	}
+	if deleted, err := c.DeleteRunnerGroup(ctx, "prod"); err != nil || len(deleted.DeletedRunnerIds) != 0 || len(deleted.Failed) != 1 {
+		t.Errorf("Expected the protected runner to fail in its group, got %v, %v", deleted, err)
+	}
+	if _, err := c.DeleteRunner(ctx, runner.Id, gradclient.WithOverrideProtection()); err != nil {
+		t.Errorf("Expected overriding the protection to delete the runner, got %v", err)
+	}
+
+	runner, _ = c.CreateRunner(ctx, gradclient.WithProtection())
+	if runner, err = c.SetRunnerProtected(ctx, runner.Id, false); err != nil || runner.Protected {
+		t.Fatalf("Expected the protection to be removed, got %v, %v", runner, err)
+	}
+	if _, err := c.DeleteRunner(ctx, runner.Id); err != nil {
+		t.Errorf("Expected the unprotected runner to be deleted, got %v", err)
+	}
+}
+
+func TestFakeMetricsUnavailable(t *testing.T) {
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"))
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	if _, err := c.GetRunnerMetrics(context.Background(), "runner-1"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented without usage, got %v", err)
+	}
+	fake.SetUsage(&gradv1.RunnerUsage{RunnerId: "runner-1", CpuMillicores: 250})
+	if usage, err := c.GetRunnerMetrics(context.Background(), "runner-1"); err != nil || usage.CpuMillicores != 250 {
+		t.Errorf("Expected 250m, got %v, %v", usage, err)
+	}
+}
+
+func TestFakeMetricsByCPU(t *testing.T) {
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"), NewRunner("runner-2"))
+	fake.SetUsage(
+		&gradv1.RunnerUsage{RunnerId: "runner-1", CpuMillicores: 100},
+		&gradv1.RunnerUsage{RunnerId: "runner-2", CpuMillicores: 900},
+	)
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	usages, err := c.ListRunnerMetrics(context.Background())
+	if err != nil || len(usages) != 2 || usages[0].RunnerId != "runner-2" {
+		t.Errorf("Expected runner-2 first as the busiest, got %v, %v", usages, err)
+	}
+}
+
+func TestFakeLabels(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"), NewRunner("runner-2"))
+	fake.SetLabels("runner-2", map[string]string{"team": "ml", "tier": "gpu"})
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected int
+	}{
+		{"One label", map[string]string{"team": "ml"}, 1},
+		{"Every label", map[string]string{"team": "ml", "tier": "gpu"}, 1},
+		{"Other value", map[string]string{"team": "data"}, 0},
+		{"No labels", nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runners, err := c.ListAllRunners(ctx, &gradv1.ListRunnersRequest{Labels: tt.labels})
+			if err != nil || len(runners) != tt.expected {
+				t.Errorf("Expected %d runners, got %v, %v", tt.expected, runners, err)
+			}
+		})
+	}
+
+	// A runner added again with the same ID doesn't get the labels of the deleted one
+	if _, err := c.DeleteRunner(ctx, "runner-2"); err != nil {
+		t.Fatalf("DeleteRunner failed: %v", err)
+	}
+	fake.AddRunners(NewRunner("runner-2"))
+	if runners, _ := c.ListAllRunners(ctx, &gradv1.ListRunnersRequest{Labels: map[string]string{"team": "ml"}}); len(runners) != 0 {
+		t.Errorf("Expected the labels to be deleted with the runner, got %v", runners)
+	}
+}
+
+func TestFakeReservationEnd(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	fake := NewFake()
+	fake.SetClock(func() time.Time { return now })
+	fake.AddRunners(NewRunner("runner-1"))
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	if _, err := c.ReserveRunner(ctx, "runner-1", now); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a reservation ending now, got %v", err)
+	}
+	runner, err := c.ReserveRunner(ctx, "runner-1", now.Add(time.Hour))
+	if err != nil || runner.ReservedUntil != now.Add(time.Hour).Unix() {
+		t.Errorf("Expected the reservation to be set, got %v, %v", runner, err)
+	}
+	if runner, err := c.ReserveRunner(ctx, "runner-1", time.Time{}); err != nil || runner.ReservedUntil != 0 {
+		t.Errorf("Expected the reservation to be cleared, got %v, %v", runner, err)
+	}
+}
+
+func TestFakeTailServerLogs(t *testing.T) {
+	fake := NewFake()
+	fake.SetServerLogs(
+		&gradv1.ServerLogRecord{Level: "INFO", Message: "Starting grad service"},
+		&gradv1.ServerLogRecord{Level: "WARN", Message: "Runner image is not pinned"},
+	)
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	var got []string
+	collect := func(record *gradv1.ServerLogRecord) error {
+		got = append(got, record.Message)
+		return nil
+	}
+	if err := c.TailServerLogs(context.Background(), "token", &gradv1.TailServerLogsRequest{MinLevel: "warn"}, collect); err != nil {
+		t.Fatalf("TailServerLogs failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != "Runner image is not pinned" {
+		t.Errorf("Expected only the warning, got %q", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	got = nil
+	err := c.TailServerLogs(ctx, "token", &gradv1.TailServerLogsRequest{Follow: true}, collect)
+	if status.Code(err) != codes.DeadlineExceeded || len(got) != 2 {
+		t.Errorf("Expected both records, then following until the deadline, got %q, %v", got, err)
+	}
+}
+
+func TestFakeSnapshotWorkspace(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.AddRunners(NewRunner("runner-1"), NewRunner("runner-2", WithError(gradv1.RunnerErrorReason_RUNNER_ERROR_REASON_OOM, "killed")))
+	fake.SetClock(func() time.Time { return time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC) })
+	c := gradclient.NewFromServices(fake, fake, fake)
+
+	var phases []gradv1.SnapshotPhase
+	done, err := c.SnapshotWorkspace(ctx, &gradv1.SnapshotWorkspaceRequest{RunnerId: "runner-1", Destination: "s3://archive/snapshots/"}, func(resp *gradv1.SnapshotWorkspaceResponse) {
+		phases = append(phases, resp.Phase)
+	})
+	if err != nil {
+		t.Fatalf("SnapshotWorkspace failed: %v", err)
+	}
+	if done.Bucket != "archive" || done.Key != "snapshots/runner-1-20261017T093000Z.tar.gz" || done.SizeBytes != SnapshotSize {
+		t.Errorf("Expected the archive to be named after the runner, got %v", done)
+	}
+	if len(phases) != 2 || phases[0] != gradv1.SnapshotPhase_SNAPSHOT_PHASE_ARCHIVING || phases[1] != gradv1.SnapshotPhase_SNAPSHOT_PHASE_UPLOADING {
+		t.Errorf("Expected archiving then uploading progress, got %v", phases)
+	}
+
+	tests := []struct {
+		runnerID    string
+		destination string
+		expected    codes.Code
+	}{
+		{"runner-1", "/tmp/run.tar.gz", codes.InvalidArgument},
+		{"runner-2", "s3://archive/", codes.FailedPrecondition},
+		{"runner-9", "s3://archive/", codes.NotFound},
+	}
+	for _, tt := range tests {
+		if _, err := c.SnapshotWorkspace(ctx, &gradv1.SnapshotWorkspaceRequest{RunnerId: tt.runnerID, Destination: tt.destination}, nil); status.Code(err) != tt.expected {
+			t.Errorf("Expected %s for %s to %s, got %v", tt.expected, tt.runnerID, tt.destination, err)
+		}
+	}
+
+	fake.FailNext(gradv1.RunnerService_SnapshotWorkspace_FullMethodName, 1, status.Error(codes.ResourceExhausted, "insufficient disk space"))
+	if _, err := c.SnapshotWorkspace(ctx, &gradv1.SnapshotWorkspaceRequest{RunnerId: "runner-1", Destination: "s3://archive/"}, nil); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected the injected error, got %v", err)
+	}
+}
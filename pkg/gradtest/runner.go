@@ -0,0 +1,100 @@
+package gradtest
+
+import (
+	"time"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// RunnerOption sets a field of a runner built with NewRunner
+type RunnerOption func(*gradv1.Runner)
+
+// NewRunner builds a running runner with ID id, changed by opts
+func NewRunner(id string, opts ...RunnerOption) *gradv1.Runner {
+	runner := &gradv1.Runner{Id: id, Status: gradv1.RunnerStatus_RUNNER_STATUS_RUNNING}
+	for _, opt := range opts {
+		opt(runner)
+	}
+	return runner
+}
+
+// WithName sets the runner's name
+func WithName(name string) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.Name = name
+	}
+}
+
+// WithStatus sets the runner's status
+func WithStatus(status gradv1.RunnerStatus) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.Status = status
+	}
+}
+
+// WithStatusDetail sets the detail explaining the runner's status
+func WithStatusDetail(detail string) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.StatusDetail = detail
+	}
+}
+
+// WithError puts the runner in error for reason
+func WithError(reason gradv1.RunnerErrorReason, message string) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.Status = gradv1.RunnerStatus_RUNNER_STATUS_ERROR
+		r.ErrorReason = reason
+		r.ErrorMessage = message
+	}
+}
+
+// WithGroup sets the runner's group
+func WithGroup(group string) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.Group = group
+	}
+}
+
+// WithDescription sets the runner's description
+func WithDescription(description string) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.Description = description
+	}
+}
+
+// WithEnv sets the runner's environment variables
+func WithEnv(env map[string]string) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.Env = env
+	}
+}
+
+// WithImage sets the runner's image and its digest
+func WithImage(image, digest string) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.Image = image
+		r.ImageDigest = digest
+	}
+}
+
+// WithSSH sets the address the runner is reached at over SSH
+func WithSSH(host string, port int32) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.Ssh = &gradv1.SSHDetails{Host: host, Port: port, Username: "runner"}
+	}
+}
+
+// WithCreatedAt sets when the runner was created and last updated
+func WithCreatedAt(t time.Time) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.CreatedAt = t.Unix()
+		r.UpdatedAt = t.Unix()
+	}
+}
+
+// WithReservedUntil reserves the runner against idle cleanup until t
+func WithReservedUntil(t time.Time) RunnerOption {
+	return func(r *gradv1.Runner) {
+		r.ReservedUntil = t.Unix()
+	}
+}
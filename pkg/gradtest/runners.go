@@ -0,0 +1,500 @@
+package gradtest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+	"github.com/strrl/gra/pkg/sshkey"
+)
+
+// defaultListLimit is the page size grad uses when a list request has no limit
+const defaultListLimit = 50
+
+// errRunnerIDRequired is grad's error for a request without a runner ID
+var errRunnerIDRequired = status.Error(codes.InvalidArgument, "runner_id is required")
+
+// nextRunnerID returns runner-N for the lowest N above every such ID; f.mu must be held
+func (f *Fake) nextRunnerID() string {
+	maxID := 0
+	for _, runner := range f.runners {
+		var n int
+		if _, err := fmt.Sscanf(runner.Id, "runner-%d", &n); err == nil && n > maxID {
+			maxID = n
+		}
+	}
+	return fmt.Sprintf("runner-%d", maxID+1)
+}
+
+// addRunner adds a running runner created from req and returns a copy of it; f.mu must be held
+func (f *Fake) addRunner(req *gradv1.CreateRunnerRequest) *gradv1.Runner {
+	now := f.now().Unix()
+	runner := &gradv1.Runner{
+		Id:                f.nextRunnerID(),
+		Name:              req.Name,
+		Status:            gradv1.RunnerStatus_RUNNER_STATUS_RUNNING,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Env:               req.Env,
+		BootstrapCommand:  req.BootstrapCommand,
+		DefaultShell:      req.DefaultShell,
+		DefaultWorkdir:    req.DefaultWorkdir,
+		Description:       req.Description,
+		Group:             req.Group,
+		HostAliases:       req.HostAliases,
+		AutoRestart:       req.GetAutoRestart(),
+		Image:             f.image,
+		ReservedUntil:     req.ReservedUntil,
+		PriorityClassName: req.PriorityClassName,
+		Arch:              req.Arch,
+		Protected:         req.Protected,
+		Spot:              req.Spot,
+	}
+	f.runners = append(f.runners, runner)
+	return proto.Clone(runner).(*gradv1.Runner)
+}
+
+// removeRunner removes the runner at index i and everything kept for it; f.mu must be held
+func (f *Fake) removeRunner(i int) {
+	runnerID := f.runners[i].Id
+	f.runners = append(f.runners[:i:i], f.runners[i+1:]...)
+	delete(f.sshKeys, runnerID)
+	delete(f.diagnostics, runnerID)
+	delete(f.labels, runnerID)
+}
+
+// advanceScript moves a runner to the next status of its script, if it has one; f.mu must be held
+func (f *Fake) advanceScript(runnerID string) {
+	script := f.scripts[runnerID]
+	if len(script) == 0 {
+		return
+	}
+	next := script[0]
+	if len(script) > 1 {
+		f.scripts[runnerID] = script[1:]
+	}
+
+	i := f.findRunner(runnerID)
+	switch {
+	case next == StatusDeleted && i >= 0:
+		f.removeRunner(i)
+	case next == StatusDeleted:
+	case i < 0:
+		f.runners = append(f.runners, NewRunner(runnerID, WithStatus(next)))
+	default:
+		f.runners[i].Status = next
+	}
+}
+
+// CreateRunner adds a runner that is running right away, with the next free ID runner-N
+func (f *Fake) CreateRunner(ctx context.Context, in *gradv1.CreateRunnerRequest, opts ...grpc.CallOption) (*gradv1.CreateRunnerResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_CreateRunner_FullMethodName, in); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &gradv1.CreateRunnerResponse{Runner: f.addRunner(in)}, nil
+}
+
+// DeleteRunner removes a runner unless it is protected and the protection isn't overridden. A
+// wait completes at once, since nothing is left terminating.
+func (f *Fake) DeleteRunner(ctx context.Context, in *gradv1.DeleteRunnerRequest, opts ...grpc.CallOption) (*gradv1.DeleteRunnerResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_DeleteRunner_FullMethodName, in); err != nil {
+		return nil, err
+	}
+	if in.RunnerId == "" {
+		return nil, errRunnerIDRequired
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.findRunner(in.RunnerId)
+	if i < 0 {
+		return nil, errRunnerNotFound
+	}
+	if f.runners[i].Protected && !in.OverrideProtection {
+		return nil, errRunnerProtected
+	}
+	f.removeRunner(i)
+	delete(f.scripts, in.RunnerId)
+
+	resp := &gradv1.DeleteRunnerResponse{
+		Message:          fmt.Sprintf("runner %s deletion initiated", in.RunnerId),
+		DeletedResources: []string{"pod/grad-runner-" + in.RunnerId},
+	}
+	if in.Wait {
+		resp.Completed = true
+		resp.Message = fmt.Sprintf("runner %s deleted", in.RunnerId)
+	}
+	return resp, nil
+}
+
+// matches reports whether a runner matches the filters of req, as grad applies them, with the
+// labels set with SetLabels; f.mu must be held
+func (f *Fake) matches(runner *gradv1.Runner, req *gradv1.ListRunnersRequest) bool {
+	if req.Status != gradv1.RunnerStatus_RUNNER_STATUS_UNSPECIFIED && runner.Status != req.Status {
+		return false
+	}
+	if req.Group != "" && runner.Group != req.Group {
+		return false
+	}
+	if req.ImageDigest != "" && !strings.HasPrefix(runner.ImageDigest, req.ImageDigest) {
+		return false
+	}
+	if req.Arch != "" && runner.Arch != req.Arch {
+		return false
+	}
+	if req.Name != "" && runner.Name != req.Name {
+		return false
+	}
+	for key, value := range req.Labels {
+		if f.labels[runner.Id][key] != value {
+			return false
+		}
+	}
+	if req.Filter == "" {
+		return true
+	}
+	filter := strings.ToLower(req.Filter)
+	for _, field := range []string{runner.Id, runner.Name, runner.Description} {
+		if strings.Contains(strings.ToLower(field), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListRunners returns one page of the runners matching in and how many match in total
+func (f *Fake) ListRunners(ctx context.Context, in *gradv1.ListRunnersRequest, opts ...grpc.CallOption) (*gradv1.ListRunnersResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_ListRunners_FullMethodName, in); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	runners := []*gradv1.Runner{}
+	for _, runner := range f.runners {
+		if f.matches(runner, in) {
+			runners = append(runners, proto.Clone(runner).(*gradv1.Runner))
+		}
+	}
+	total := int32(len(runners))
+
+	limit := in.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	start := min(max(in.Offset, 0), total)
+	return &gradv1.ListRunnersResponse{Runners: runners[start:min(start+limit, total)], Total: total}, nil
+}
+
+// GetRunner returns a runner by ID, first moving it along its status script
+func (f *Fake) GetRunner(ctx context.Context, in *gradv1.GetRunnerRequest, opts ...grpc.CallOption) (*gradv1.GetRunnerResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_GetRunner_FullMethodName, in); err != nil {
+		return nil, err
+	}
+	if in.RunnerId == "" {
+		return nil, errRunnerIDRequired
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.advanceScript(in.RunnerId)
+	i := f.findRunner(in.RunnerId)
+	if i < 0 {
+		return nil, errRunnerNotFound
+	}
+	return &gradv1.GetRunnerResponse{Runner: proto.Clone(f.runners[i]).(*gradv1.Runner)}, nil
+}
+
+// GetRunnerStats counts the runners with in.Status, or all runners if it is unspecified. Every
+// runner counts as the custom preset without an owner, which the fake doesn't model.
+func (f *Fake) GetRunnerStats(ctx context.Context, in *gradv1.GetRunnerStatsRequest, opts ...grpc.CallOption) (*gradv1.GetRunnerStatsResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_GetRunnerStats_FullMethodName, in); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := &gradv1.GetRunnerStatsResponse{
+		ByStatus: make(map[string]int32),
+		ByPreset: make(map[string]int32),
+		ByOwner:  make(map[string]int32),
+	}
+	for _, runner := range f.runners {
+		if in.Status != gradv1.RunnerStatus_RUNNER_STATUS_UNSPECIFIED && runner.Status != in.Status {
+			continue
+		}
+		stats.Total++
+		stats.ByStatus[strings.ToLower(strings.TrimPrefix(runner.Status.String(), "RUNNER_STATUS_"))]++
+		stats.ByPreset["custom"]++
+		stats.ByOwner[""]++
+	}
+	return stats, nil
+}
+
+// updateRunner applies update to a runner and returns a copy of the result
+func (f *Fake) updateRunner(runnerID string, update func(runner *gradv1.Runner)) (*gradv1.Runner, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := f.findRunner(runnerID)
+	if i < 0 {
+		return nil, errRunnerNotFound
+	}
+	update(f.runners[i])
+	f.runners[i].UpdatedAt = f.now().Unix()
+	return proto.Clone(f.runners[i]).(*gradv1.Runner), nil
+}
+
+// UpdateRunner changes the description, reservation and protection of a runner, where in sets
+// them. Like grad, a reservation must not have ended; unlike grad, the fake doesn't cap how far
+// ahead it may end.
+func (f *Fake) UpdateRunner(ctx context.Context, in *gradv1.UpdateRunnerRequest, opts ...grpc.CallOption) (*gradv1.UpdateRunnerResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_UpdateRunner_FullMethodName, in); err != nil {
+		return nil, err
+	}
+	if in.RunnerId == "" {
+		return nil, errRunnerIDRequired
+	}
+	if until := in.GetReservedUntil(); until != 0 {
+		f.mu.Lock()
+		now := f.now()
+		f.mu.Unlock()
+		if end := time.Unix(until, 0); !end.After(now) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: reservation end %s has passed", end.UTC().Format(time.RFC3339))
+		}
+	}
+
+	runner, err := f.updateRunner(in.RunnerId, func(runner *gradv1.Runner) {
+		if in.Description != nil {
+			runner.Description = in.GetDescription()
+		}
+		if in.ReservedUntil != nil {
+			runner.ReservedUntil = in.GetReservedUntil()
+		}
+		if in.Protected != nil {
+			runner.Protected = in.GetProtected()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &gradv1.UpdateRunnerResponse{Runner: runner}, nil
+}
+
+// CloneRunner adds a runner configured like in.SourceRunnerId
+func (f *Fake) CloneRunner(ctx context.Context, in *gradv1.CloneRunnerRequest, opts ...grpc.CallOption) (*gradv1.CloneRunnerResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_CloneRunner_FullMethodName, in); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.findRunner(in.SourceRunnerId)
+	if i < 0 {
+		return nil, errRunnerNotFound
+	}
+	source := f.runners[i]
+	return &gradv1.CloneRunnerResponse{Runner: f.addRunner(&gradv1.CreateRunnerRequest{
+		Name:             in.Name,
+		Env:              source.Env,
+		BootstrapCommand: source.BootstrapCommand,
+		DefaultShell:     source.DefaultShell,
+		DefaultWorkdir:   source.DefaultWorkdir,
+		Description:      source.Description,
+		Group:            source.Group,
+		HostAliases:      source.HostAliases,
+		AutoRestart:      proto.Bool(source.AutoRestart),
+	})}, nil
+}
+
+// GetServerInfo returns the server info set with SetServerInfo
+func (f *Fake) GetServerInfo(ctx context.Context, in *gradv1.GetServerInfoRequest, opts ...grpc.CallOption) (*gradv1.GetServerInfoResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_GetServerInfo_FullMethodName, in); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return proto.Clone(f.serverInfo).(*gradv1.GetServerInfoResponse), nil
+}
+
+// DeleteRunnerGroup removes every unprotected runner of a group, reporting the protected ones as failed
+func (f *Fake) DeleteRunnerGroup(ctx context.Context, in *gradv1.DeleteRunnerGroupRequest, opts ...grpc.CallOption) (*gradv1.DeleteRunnerGroupResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_DeleteRunnerGroup_FullMethodName, in); err != nil {
+		return nil, err
+	}
+	if in.Group == "" {
+		return nil, status.Error(codes.InvalidArgument, "group is required")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &gradv1.DeleteRunnerGroupResponse{}
+	for i := len(f.runners) - 1; i >= 0; i-- {
+		if f.runners[i].Group != in.Group {
+			continue
+		}
+		if f.runners[i].Protected {
+			resp.Failed = append([]*gradv1.RunnerDeleteFailure{{RunnerId: f.runners[i].Id, Error: errRunnerProtected.Error()}}, resp.Failed...)
+		} else {
+			resp.DeletedRunnerIds = append([]string{f.runners[i].Id}, resp.DeletedRunnerIds...)
+			f.removeRunner(i)
+		}
+	}
+	return resp, nil
+}
+
+// GetRunnerMetrics returns a runner's usage set with SetUsage
+func (f *Fake) GetRunnerMetrics(ctx context.Context, in *gradv1.GetRunnerMetricsRequest, opts ...grpc.CallOption) (*gradv1.GetRunnerMetricsResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_GetRunnerMetrics_FullMethodName, in); err != nil {
+		return nil, err
+	}
+	if in.RunnerId == "" {
+		return nil, errRunnerIDRequired
+	}
+
+	usages, err := f.listUsage()
+	if err != nil {
+		return nil, err
+	}
+	for _, usage := range usages {
+		if usage.RunnerId == in.RunnerId {
+			return &gradv1.GetRunnerMetricsResponse{Usage: usage}, nil
+		}
+	}
+	return nil, errRunnerNotFound
+}
+
+// ListRunnerMetrics returns the usage set with SetUsage of the runners the fake holds, highest
+// CPU first like grad
+func (f *Fake) ListRunnerMetrics(ctx context.Context, in *gradv1.ListRunnerMetricsRequest, opts ...grpc.CallOption) (*gradv1.ListRunnerMetricsResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_ListRunnerMetrics_FullMethodName, in); err != nil {
+		return nil, err
+	}
+
+	usages, err := f.listUsage()
+	if err != nil {
+		return nil, err
+	}
+	return &gradv1.ListRunnerMetricsResponse{Usage: usages}, nil
+}
+
+// listUsage returns copies of the usage of existing runners, highest CPU first, or
+// codes.Unimplemented without any
+func (f *Fake) listUsage() ([]*gradv1.RunnerUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.usage) == 0 {
+		return nil, status.Error(codes.Unimplemented, "resource metrics unavailable")
+	}
+	var usages []*gradv1.RunnerUsage
+	for _, usage := range f.usage {
+		if f.findRunner(usage.RunnerId) >= 0 {
+			usages = append(usages, proto.Clone(usage).(*gradv1.RunnerUsage))
+		}
+	}
+	slices.SortStableFunc(usages, func(a, b *gradv1.RunnerUsage) int {
+		return cmp.Compare(b.CpuMillicores, a.CpuMillicores)
+	})
+	return usages, nil
+}
+
+// GetRunnerDiagnostics returns the diagnostics set with SetDiagnostics, empty if there are none
+func (f *Fake) GetRunnerDiagnostics(ctx context.Context, in *gradv1.GetRunnerDiagnosticsRequest, opts ...grpc.CallOption) (*gradv1.GetRunnerDiagnosticsResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_GetRunnerDiagnostics_FullMethodName, in); err != nil {
+		return nil, err
+	}
+	if in.RunnerId == "" {
+		return nil, errRunnerIDRequired
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.findRunner(in.RunnerId) < 0 {
+		return nil, errRunnerNotFound
+	}
+	if diagnostics, ok := f.diagnostics[in.RunnerId]; ok {
+		return proto.Clone(diagnostics).(*gradv1.GetRunnerDiagnosticsResponse), nil
+	}
+	return &gradv1.GetRunnerDiagnosticsResponse{}, nil
+}
+
+// AddSSHKey remembers in.PublicKey for ListSSHKeys; Added is false if the runner already had it
+func (f *Fake) AddSSHKey(ctx context.Context, in *gradv1.AddSSHKeyRequest, opts ...grpc.CallOption) (*gradv1.AddSSHKeyResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_AddSSHKey_FullMethodName, in); err != nil {
+		return nil, err
+	}
+	key, err := sshkey.Parse(in.PublicKey)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.runningRunner(in.RunnerId); err != nil {
+		return nil, err
+	}
+
+	added := &gradv1.SSHKey{Fingerprint: key.Fingerprint(), Type: key.Type, Comment: key.Comment}
+	for _, existing := range f.sshKeys[in.RunnerId] {
+		if existing.Fingerprint == added.Fingerprint {
+			return &gradv1.AddSSHKeyResponse{Key: proto.Clone(existing).(*gradv1.SSHKey)}, nil
+		}
+	}
+	f.sshKeys[in.RunnerId] = append(f.sshKeys[in.RunnerId], added)
+	return &gradv1.AddSSHKeyResponse{Key: proto.Clone(added).(*gradv1.SSHKey), Added: true}, nil
+}
+
+// ListSSHKeys returns the keys added to a runner with AddSSHKey
+func (f *Fake) ListSSHKeys(ctx context.Context, in *gradv1.ListSSHKeysRequest, opts ...grpc.CallOption) (*gradv1.ListSSHKeysResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_ListSSHKeys_FullMethodName, in); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.runningRunner(in.RunnerId); err != nil {
+		return nil, err
+	}
+
+	resp := &gradv1.ListSSHKeysResponse{}
+	for _, key := range f.sshKeys[in.RunnerId] {
+		resp.Keys = append(resp.Keys, proto.Clone(key).(*gradv1.SSHKey))
+	}
+	return resp, nil
+}
+
+// RemoveSSHKey forgets the key with in.Fingerprint; Removed is false if the runner didn't have it
+func (f *Fake) RemoveSSHKey(ctx context.Context, in *gradv1.RemoveSSHKeyRequest, opts ...grpc.CallOption) (*gradv1.RemoveSSHKeyResponse, error) {
+	if err := f.call(ctx, gradv1.RunnerService_RemoveSSHKey_FullMethodName, in); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.runningRunner(in.RunnerId); err != nil {
+		return nil, err
+	}
+
+	keys := f.sshKeys[in.RunnerId]
+	for i, key := range keys {
+		if key.Fingerprint == in.Fingerprint {
+			f.sshKeys[in.RunnerId] = append(keys[:i:i], keys[i+1:]...)
+			return &gradv1.RemoveSSHKeyResponse{Removed: true}, nil
+		}
+	}
+	return &gradv1.RemoveSSHKeyResponse{}, nil
+}
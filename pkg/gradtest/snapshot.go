@@ -0,0 +1,105 @@
+package gradtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	gradv1 "github.com/strrl/gra/gen/grad/v1"
+)
+
+// SnapshotSize is the size of the workspace archives the fake uploads
+const SnapshotSize = 1 << 20
+
+// SnapshotWorkspace streams the progress of archiving and uploading a running runner's
+// workspace, then the uploaded archive. A destination ending in / gets a name for the archive
+// appended like grad does; nothing is uploaded.
+func (f *Fake) SnapshotWorkspace(ctx context.Context, in *gradv1.SnapshotWorkspaceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[gradv1.SnapshotWorkspaceResponse], error) {
+	f.record(gradv1.RunnerService_SnapshotWorkspace_FullMethodName, in)
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	stream := &snapshotStream{clientStream: clientStream{ctx}, fake: f}
+	u, err := url.Parse(in.Destination)
+	switch {
+	case in.RunnerId == "":
+		stream.err = status.Error(codes.InvalidArgument, "invalid request: runner_id is required")
+		return stream, nil
+	case err != nil || u.Scheme != "s3" || u.Host == "":
+		stream.err = status.Errorf(codes.InvalidArgument, "invalid request: destination: %q is not an S3 URL like s3://bucket/path", in.Destination)
+		return stream, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.runningRunner(in.RunnerId); err != nil {
+		stream.err = err
+		return stream, nil
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		key += fmt.Sprintf("%s-%s.tar.gz", in.RunnerId, f.now().UTC().Format("20060102T150405Z"))
+	}
+	stream.responses = []*gradv1.SnapshotWorkspaceResponse{
+		{Phase: gradv1.SnapshotPhase_SNAPSHOT_PHASE_ARCHIVING, BytesDone: SnapshotSize, BytesTotal: SnapshotSize},
+		{Phase: gradv1.SnapshotPhase_SNAPSHOT_PHASE_UPLOADING, BytesDone: SnapshotSize, BytesTotal: SnapshotSize},
+		{Phase: gradv1.SnapshotPhase_SNAPSHOT_PHASE_DONE, BytesDone: SnapshotSize, BytesTotal: SnapshotSize, Bucket: u.Host, Key: key, SizeBytes: SnapshotSize},
+	}
+	return stream, nil
+}
+
+// snapshotStream is the client end of a SnapshotWorkspace stream. Like execStream, latency and
+// injected errors surface from the first Recv.
+type snapshotStream struct {
+	clientStream
+	fake      *Fake
+	responses []*gradv1.SnapshotWorkspaceResponse
+	err       error
+	started   bool
+}
+
+var _ grpc.ServerStreamingClient[gradv1.SnapshotWorkspaceResponse] = (*snapshotStream)(nil)
+
+// Recv returns the next progress report, then io.EOF
+func (s *snapshotStream) Recv() (*gradv1.SnapshotWorkspaceResponse, error) {
+	if !s.started {
+		s.started = true
+		if err := s.fake.inject(s.ctx, gradv1.RunnerService_SnapshotWorkspace_FullMethodName); err != nil && s.err == nil {
+			s.err = err
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.err = status.FromContextError(err).Err()
+		return nil, s.err
+	}
+
+	if len(s.responses) == 0 {
+		return nil, io.EOF
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+// RecvMsg receives the next progress report into m, which must be a *SnapshotWorkspaceResponse
+func (s *snapshotStream) RecvMsg(m any) error {
+	resp, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	proto.Reset(m.(proto.Message))
+	proto.Merge(m.(proto.Message), resp)
+	return nil
+}
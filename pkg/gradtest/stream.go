@@ -0,0 +1,40 @@
+package gradtest
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// clientStream is the part of the client end of a server stream that doesn't depend on its
+// messages
+type clientStream struct {
+	ctx context.Context
+}
+
+// Header returns no metadata
+func (s *clientStream) Header() (metadata.MD, error) {
+	return metadata.MD{}, nil
+}
+
+// Trailer returns no metadata
+func (s *clientStream) Trailer() metadata.MD {
+	return metadata.MD{}
+}
+
+// CloseSend does nothing, as a server stream's request has been sent already
+func (s *clientStream) CloseSend() error {
+	return nil
+}
+
+// Context returns the context the stream was opened with
+func (s *clientStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg fails, as the client of a server stream doesn't send messages
+func (s *clientStream) SendMsg(m any) error {
+	return status.Error(codes.Internal, "SendMsg called on a server stream")
+}
@@ -0,0 +1,55 @@
+// Package shellquote quotes arguments for POSIX shells, so a command given as an argument
+// vector survives being run with `sh -c` unchanged: no word splitting, globbing, expansion or
+// command separators apply to it. gractl and grad share it to turn argv into a command line.
+package shellquote
+
+import "strings"
+
+// reservedWords are the words a shell treats as syntax in command position, rather than as
+// the name of a program: POSIX's, and bash's extensions
+var reservedWords = map[string]bool{
+	"!": true, "{": true, "}": true, "case": true, "do": true, "done": true, "elif": true,
+	"else": true, "esac": true, "fi": true, "for": true, "if": true, "in": true, "then": true,
+	"until": true, "while": true, "[[": true, "]]": true, "function": true, "select": true,
+	"time": true, "coproc": true,
+}
+
+// isSafe reports whether s can be passed to a shell as one word without quoting: it is
+// non-empty and made only of characters no shell treats specially
+func isSafe(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("@%+=:,./_-", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Quote returns s as one shell word, single-quoted unless it is safe as it is. Single quotes
+// in s are closed, escaped and reopened, since nothing else is special inside them.
+func Quote(s string) string {
+	if isSafe(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join returns a command line a POSIX shell runs as the program args[0] with the arguments
+// args[1:], each exactly as given. args[0] is quoted too if the shell would otherwise read it
+// as a reserved word or a variable assignment.
+func Join(args []string) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		words[i] = Quote(arg)
+	}
+	if len(args) > 0 && words[0] == args[0] && (reservedWords[args[0]] || strings.Contains(args[0], "=")) {
+		words[0] = "'" + args[0] + "'"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,131 @@
+package shellquote
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected string
+	}{
+		{"", "''"},
+		{"hello", "hello"},
+		{"/usr/bin/python3", "/usr/bin/python3"},
+		{"--flag=value", "--flag=value"},
+		{"user@host:path,a+b%", "user@host:path,a+b%"},
+		{"hello world", "'hello world'"},
+		{"print('a b')", `'print('\''a b'\'')'`},
+		{"'", `''\'''`},
+		{`"double"`, `'"double"'`},
+		{"$HOME", "'$HOME'"},
+		{"${HOME}", "'${HOME}'"},
+		{"`id`", "'`id`'"},
+		{"$(id)", "'$(id)'"},
+		{"a;b", "'a;b'"},
+		{"a && b", "'a && b'"},
+		{"a|b", "'a|b'"},
+		{"*.go", "'*.go'"},
+		{"file?.txt", "'file?.txt'"},
+		{"[abc]", "'[abc]'"},
+		{"~", "'~'"},
+		{"~/src", "'~/src'"},
+		{"a\\b", `'a\b'`},
+		{"line1\nline2", "'line1\nline2'"},
+		{"tab\there", "'tab\there'"},
+		{"#comment", "'#comment'"},
+		{"a>b", "'a>b'"},
+		{"!", "'!'"},
+		{"{a,b}", "'{a,b}'"},
+		{"héllo", "'héllo'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			if got := Quote(tt.arg); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"Empty", nil, ""},
+		{"Plain", []string{"echo", "hello", "world"}, "echo hello world"},
+		{"Quoted argument", []string{"python", "-c", "print('a b')"}, `python -c 'print('\''a b'\'')'`},
+		{"Semicolon", []string{"echo", "a;", "rm", "-rf", "x"}, "echo 'a;' rm -rf x"},
+		{"Empty argument", []string{"printf", "%s", ""}, "printf %s ''"},
+		{"Reserved word as program", []string{"if", "true"}, "'if' true"},
+		{"Reserved word as argument", []string{"echo", "if"}, "echo if"},
+		{"Assignment-like program", []string{"A=b", "c"}, "'A=b' c"},
+		{"Assignment-like argument", []string{"env", "A=b", "c"}, "env A=b c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Join(tt.args); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// helperEnv makes the test binary print its arguments as JSON instead of running tests
+const helperEnv = "SHELLQUOTE_PRINT_ARGS"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		json.NewEncoder(os.Stdout).Encode(os.Args[1:])
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// TestJoinRoundTrip runs joined command lines through real shells, checking the program gets
+// exactly the arguments it was given
+func TestJoinRoundTrip(t *testing.T) {
+	argLists := [][]string{
+		{"hello world"},
+		{"print('a b')", `"double"`, "'"},
+		{"$HOME", "${PATH}", "$0", "$@", "$?"},
+		{"`id`", "$(id)", "$((1+1))"},
+		{"a;b", "a && b", "a || b", "a|b", "a & b"},
+		{"*", "*.go", "?", "[a-z]*", "{a,b}", "~", "~/src"},
+		{"a\\b", "\\", "\\'", "line1\nline2", "\t", " "},
+		{"", "", "x"},
+		{"#not a comment", "a>b", "a<b", "2>&1", "!", "!!"},
+		{"héllo", "日本語", "\x01"},
+	}
+
+	for _, shell := range []string{"sh", "bash", "dash", "zsh"} {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		for i, args := range argLists {
+			t.Run(fmt.Sprintf("%s/%d", shell, i), func(t *testing.T) {
+				cmd := exec.Command(path, "-c", Join(append([]string{os.Args[0]}, args...)))
+				cmd.Env = append(os.Environ(), helperEnv+"=1")
+				out, err := cmd.Output()
+				if err != nil {
+					t.Fatalf("Running %s failed: %v", Join(args), err)
+				}
+				var got []string
+				if err := json.Unmarshal(out, &got); err != nil {
+					t.Fatalf("Expected the arguments as JSON, got %q", out)
+				}
+				if !slices.Equal(got, args) {
+					t.Errorf("Expected %q, got %q", args, got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package sshkey
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// DefaultKeyFiles are the public keys looked for in ~/.ssh, in order of preference
+var DefaultKeyFiles = []string{
+	"id_ed25519.pub",
+	"id_rsa.pub",
+	"id_ecdsa.pub",
+	"id_ed25519_sk.pub",
+	"id_ecdsa_sk.pub",
+}
+
+// AgentLister lists the keys held by an ssh-agent
+type AgentLister interface {
+	List() ([]*agent.Key, error)
+}
+
+// SocketAgent is an ssh-agent listening on a Unix socket, e.g. $SSH_AUTH_SOCK. Each List
+// connects anew; an empty path lists no keys.
+type SocketAgent string
+
+// List returns the keys of the agent
+func (a SocketAgent) List() ([]*agent.Key, error) {
+	if a == "" {
+		return nil, nil
+	}
+	conn, err := net.Dial("unix", string(a))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh-agent: %w", err)
+	}
+	defer conn.Close()
+	return agent.NewClient(conn).List()
+}
+
+// Finder looks for the user's SSH public key
+type Finder struct {
+	// Dir is searched for DefaultKeyFiles, normally ~/.ssh
+	Dir string
+	// Agent is asked for its keys when Dir has none; nil skips the agent
+	Agent AgentLister
+}
+
+// DefaultFinder looks in ~/.ssh, then in the ssh-agent at $SSH_AUTH_SOCK if there is one
+func DefaultFinder() *Finder {
+	finder := &Finder{Agent: SocketAgent(os.Getenv("SSH_AUTH_SOCK"))}
+	if home, err := os.UserHomeDir(); err == nil {
+		finder.Dir = filepath.Join(home, ".ssh")
+	}
+	return finder
+}
+
+// Find returns the key in path, or without a path the first valid key of DefaultKeyFiles in
+// Dir, then the first key of a supported type the agent holds, together with where it came
+// from. It returns a nil key if nothing was found; the error says why a key file asked for
+// by path or the agent couldn't be read.
+func (f *Finder) Find(path string) (*PublicKey, string, error) {
+	if path != "" {
+		key, err := ReadFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+		return key, path, nil
+	}
+
+	if f.Dir != "" {
+		for _, name := range DefaultKeyFiles {
+			keyPath := filepath.Join(f.Dir, name)
+			if key, err := ReadFile(keyPath); err == nil {
+				return key, keyPath, nil
+			}
+		}
+	}
+
+	if f.Agent != nil {
+		agentKeys, err := f.Agent.List()
+		if err != nil {
+			return nil, "", err
+		}
+		for _, agentKey := range agentKeys {
+			if key, err := fromAgent(agentKey); err == nil {
+				return key, "ssh-agent", nil
+			}
+		}
+	}
+
+	return nil, "", nil
+}
+
+// ReadFile reads a public key file, e.g. ~/.ssh/id_ed25519.pub. A leading ~/ stands for the
+// home directory.
+func ReadFile(path string) (*PublicKey, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH key file %s: %w", path, err)
+	}
+	key, err := Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// fromAgent converts a key held by an ssh-agent, rejecting unsupported types (pure function)
+func fromAgent(agentKey *agent.Key) (*PublicKey, error) {
+	return Parse(agentKey.Format + " " + base64.StdEncoding.EncodeToString(agentKey.Blob) + " " + agentKey.Comment)
+}
@@ -0,0 +1,90 @@
+// Package sshkey parses SSH public keys in authorized_keys format, computes their
+// fingerprints the way ssh-keygen -l shows them and finds the user's key. grad validates
+// the keys it installs in runners with it, and gractl finds the key to inject into runners.
+package sshkey
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// validPrefixes are the key types accepted, each followed by the space before the key data.
+// The sk- types are FIDO security keys, e.g. from ssh-keygen -t ed25519-sk.
+var validPrefixes = []string{
+	"ssh-rsa ",
+	"ssh-ed25519 ",
+	"ssh-ecdsa ",
+	"ecdsa-sha2-nistp256 ",
+	"ecdsa-sha2-nistp384 ",
+	"ecdsa-sha2-nistp521 ",
+	"sk-ssh-ed25519@openssh.com ",
+	"sk-ecdsa-sha2-nistp256@openssh.com ",
+}
+
+// ErrInvalidKey means a string isn't an SSH public key of a supported type
+var ErrInvalidKey = errors.New("not a valid SSH public key")
+
+// PublicKey is an SSH public key as a line of authorized_keys: type, data and comment
+type PublicKey struct {
+	Type string
+	// Data is the base64 encoded key
+	Data    string
+	Comment string
+}
+
+// Parse parses a single public key, e.g. the content of id_ed25519.pub. Surrounding
+// whitespace is ignored; options before the key type are not supported. (pure function)
+func Parse(key string) (*PublicKey, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidKey)
+	}
+	if strings.ContainsAny(key, "\r\n") {
+		return nil, fmt.Errorf("%w: more than one line", ErrInvalidKey)
+	}
+	if !hasValidPrefix(key) {
+		return nil, fmt.Errorf("%w: unsupported key type", ErrInvalidKey)
+	}
+
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("%w: missing key data", ErrInvalidKey)
+	}
+	if _, err := base64.StdEncoding.DecodeString(fields[1]); err != nil {
+		return nil, fmt.Errorf("%w: key data is not base64: %v", ErrInvalidKey, err)
+	}
+
+	return &PublicKey{
+		Type:    fields[0],
+		Data:    fields[1],
+		Comment: strings.Join(fields[2:], " "),
+	}, nil
+}
+
+// hasValidPrefix reports whether key starts with a supported key type (pure function)
+func hasValidPrefix(key string) bool {
+	for _, prefix := range validPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the key as a line of authorized_keys, without a newline
+func (k *PublicKey) String() string {
+	if k.Comment == "" {
+		return k.Type + " " + k.Data
+	}
+	return k.Type + " " + k.Data + " " + k.Comment
+}
+
+// Fingerprint returns the SHA256 fingerprint of the key, e.g. SHA256:xGNji13B...
+func (k *PublicKey) Fingerprint() string {
+	data, _ := base64.StdEncoding.DecodeString(k.Data)
+	sum := sha256.Sum256(data)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}